@@ -28,17 +28,23 @@ import (
 	"github.com/companyzero/bisonrelay/brclient/internal/sloglinesbuffer"
 	"github.com/companyzero/bisonrelay/brclient/internal/version"
 	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/autoshare"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/client/postfeeds"
+	"github.com/companyzero/bisonrelay/client/postimport"
 	"github.com/companyzero/bisonrelay/client/resources"
 	"github.com/companyzero/bisonrelay/client/resources/simplestore"
 	"github.com/companyzero/bisonrelay/client/rpcserver"
 	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/embeddeddcrlnd"
+	"github.com/companyzero/bisonrelay/internal/invitetransport"
 	"github.com/companyzero/bisonrelay/internal/mdembeds"
 	"github.com/companyzero/bisonrelay/internal/strescape"
 	"github.com/companyzero/bisonrelay/internal/tlsconn"
 	"github.com/companyzero/bisonrelay/rates"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/swaps"
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil/v4"
@@ -48,6 +54,7 @@ import (
 	"github.com/decred/dcrlnd/zpay32"
 	lpclient "github.com/decred/dcrlnlpd/client"
 	"github.com/decred/slog"
+	"github.com/gorilla/websocket"
 	"github.com/muesli/reflow/wordwrap"
 	"github.com/puzpuzpuz/xsync/v2"
 	"golang.org/x/exp/maps"
@@ -98,6 +105,7 @@ type appState struct {
 	log         slog.Logger
 	lndLogLines *sloglinesbuffer.Buffer
 	styles      atomic.Pointer[theme]
+	keymap      atomic.Pointer[keymap]
 	network     string
 	isRestore   bool
 	rpcServer   *rpcserver.Server
@@ -115,6 +123,13 @@ type appState struct {
 	cmdHistory     []string
 	cmdHistoryIdx  int
 
+	// vim is the state of the vim mode of the main window.
+	vim *vimState
+
+	// execCmdMtx serializes commands executed through the clientrpc
+	// interface.
+	execCmdMtx sync.Mutex
+
 	// diagMsgs are the messages shown as a response to generic msgs,
 	// displayed on window 0.
 	diagMsgsMtx sync.Mutex
@@ -194,15 +209,34 @@ type appState struct {
 	// window pinning on startup
 	winpin []string
 
+	// seedBackupPath is the seed backup file to restore the identity from
+	// when creating a new client. restoredBackup is the decoded backup,
+	// which is restored once the client connects to the server.
+	seedBackupPath string
+	restoreMtx     sync.Mutex
+	restoredBackup *client.IdentityBackup
+
+	// devicePairingPath is the device pairing file to create the client
+	// as a secondary device from. devicePairing is the decoded pairing,
+	// which is completed once the client connects to the server.
+	devicePairingPath string
+	devicePairing     *client.DevicePairing
+
 	// Collator for sorting strings for displaying.
 	collator *collate.Collator
 
 	mimeMap atomic.Pointer[map[string]string]
 
+	// themesDir is the dir of the theme files switchable with /theme.
+	themesDir string
+
 	// cmd to run when receiving messages. First element is bin, other are
 	// args.
 	bellCmd []string
 
+	// desktopNtfns shows the notifications of received msgs.
+	desktopNtfns *desktopNotifier
+
 	unwelcomeError atomic.Pointer[error]
 
 	inboundMsgsMtx  sync.Mutex
@@ -211,6 +245,7 @@ type appState struct {
 	logsMsgs        bool
 
 	inviteFundsAccount string
+	inviteTransports   map[string]client.InviteTransport
 
 	externalEditorForComments atomic.Bool
 
@@ -220,12 +255,81 @@ type appState struct {
 	ssPayType    simpleStorePayType
 	ssAcct       string
 	ssShipCharge float64
+
+	autoSharer *autoshare.Sharer
+	postFeeds  *postfeeds.Feeds
+
+	postImporter *postimport.Importer
+
+	autopilot  *embeddeddcrlnd.Autopilot
+	inbound    *embeddeddcrlnd.InboundManager
+	scbBackups *embeddeddcrlnd.SCBBackups
+	scbDir     string
+	swaps      *swaps.Manager
+
+	watchtowers   *embeddeddcrlnd.Watchtowers
+	startupTowers []string
+
+	// budgetConfirmChan is set while waiting for the user to confirm a
+	// payment that exceeds a payment budget.
+	budgetConfirmMtx  sync.Mutex
+	budgetConfirmChan chan bool
 }
 
 type appStateErr struct {
 	err error
 }
 
+// sendSCBToContact sends a static channel backup as a file to the contact
+// with the given nick.
+func (as *appState) sendSCBToContact(nick string, b *embeddeddcrlnd.SCBBackup) error {
+	ru, err := as.c.UserByNick(nick)
+	if err != nil {
+		return err
+	}
+
+	// The file is chunked into the shared files dir, so the temp file can
+	// be removed after it is sent.
+	dir, err := os.MkdirTemp("", "brscb")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, b.FileName())
+	if err := os.WriteFile(fname, b.Data, 0o600); err != nil {
+		return err
+	}
+	return as.c.SendFile(ru.ID(), fname)
+}
+
+// restoreSeedBackup restores the client state from the seed backup used to
+// restore the local identity.
+func (as *appState) restoreSeedBackup(backup *client.IdentityBackup) {
+	if err := as.c.RestoreSeedBackup(backup); err != nil {
+		as.diagMsg("Unable to restore seed backup: %v", err)
+		return
+	}
+	as.diagMsg("Restored seed backup from %s. Requested ratchet reset "+
+		"with %d contacts", backup.Created.Format(ISO8601DateTime),
+		len(backup.Contacts))
+}
+
+// needsSeedWords returns true if the local identity is created from a seed
+// encrypted file (either a seed backup or a device pairing).
+func (as *appState) needsSeedWords() bool {
+	return as.seedBackupPath != "" || as.devicePairingPath != ""
+}
+
+// completeDevicePairing completes the pairing of the client as a secondary
+// device.
+func (as *appState) completeDevicePairing(p *client.DevicePairing) {
+	if err := as.c.CompleteDevicePairing(p); err != nil {
+		as.diagMsg("Unable to complete device pairing: %v", err)
+		return
+	}
+	as.diagMsg("Paired as secondary device of device %s", p.PrimaryID)
+}
+
 func (as *appState) run() error {
 	as.wg.Add(1)
 	var err error
@@ -265,6 +369,10 @@ func (as *appState) run() error {
 
 	go func() {
 		for _, nick := range as.winpin {
+			// Archived chats are not opened on startup.
+			if id, err := as.chatTargetID(nick); err == nil && as.c.IsChatArchived(id) {
+				continue
+			}
 			as.openChatWindow(nick)
 		}
 	}()
@@ -325,6 +433,92 @@ func (as *appState) run() error {
 		}()
 	}
 
+	// Run the auto sharer of watched dirs if set.
+	if as.autoSharer != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.autoSharer.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.log.Errorf("Error running auto share: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Run the channel autopilot if set.
+	if as.autopilot != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.autopilot.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.diagMsg("Unable to run autopilot: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Add the configured watchtowers.
+	if len(as.startupTowers) > 0 {
+		as.wg.Add(1)
+		go func() {
+			for _, uri := range as.startupTowers {
+				err := as.watchtowers.AddTower(as.ctx, uri)
+				if err != nil {
+					as.diagMsg("Unable to add watchtower %s: %v", uri, err)
+				}
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Run the inbound liquidity manager if set.
+	if as.inbound != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.inbound.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.diagMsg("Unable to run inbound liquidity manager: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Run the swap manager if set.
+	if as.swaps != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.swaps.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.diagMsg("Unable to run swap manager: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Run the scheduled SCB backups if set.
+	if as.scbBackups != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.scbBackups.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.diagMsg("Unable to run SCB backups: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
+	// Run the post feeds if set.
+	if as.postFeeds != nil {
+		as.wg.Add(1)
+		go func() {
+			err := as.postFeeds.Run(as.ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				as.diagMsg("Unable to run post feeds: %v", err)
+			}
+			as.wg.Done()
+		}()
+	}
+
 	as.wg.Wait()
 	if as.cmdHistoryFile != nil {
 		as.cmdHistoryFile.Close()
@@ -538,23 +732,40 @@ loop:
 			fromUID := user.ID()
 
 			var beepNick, rawMsg string
+			var msgID, replyTo *zkidentity.ShortID
+			var mentioned bool
 			var cw *chatWindow
 			switch msg := inmsg.rm.(type) {
 			case rpc.RMPrivateMessage:
 				cw = as.findOrNewChatWindow(user.ID(), fromNick)
 				beepNick = fromNick
 				rawMsg = msg.Message
+				msgID, replyTo = msg.MsgID, msg.ReplyTo
 
 			case rpc.RMGroupMessage:
 				cw = as.findOrNewGCWindow(msg.ID)
 				beepNick = cw.alias
 				rawMsg = msg.Message
+				if msg.Channel != nil {
+					chName := msg.Channel.ShortLogID()
+					if gc, err := as.c.GetGC(msg.ID); err == nil {
+						for _, ch := range gc.Channels {
+							if ch.ID == *msg.Channel {
+								chName = ch.Name
+								break
+							}
+						}
+					}
+					rawMsg = fmt.Sprintf("[#%s] %s", chName, rawMsg)
+				}
+				msgID, replyTo = msg.MsgID, msg.ReplyTo
+				mentioned = slices.Contains(msg.Mentions, as.c.PublicID())
 			default:
 				panic("unimplemented")
 			}
 
 			msgContent := as.handleRcvdText(rawMsg, beepNick)
-			mentioned := hasMention(as.c.LocalNick(), msgContent)
+			mentioned = mentioned || hasMention(as.c.LocalNick(), msgContent)
 
 			// Only add the message if the ntfn was received after
 			// the cw was initialized (otherwise the message is
@@ -563,7 +774,12 @@ loop:
 			// Otherwise, rewind the index of unread msgs, because
 			// this is a history message that hasn't been read.
 			if !inmsg.recvts.Before(cw.initTime) || !as.logsMsgs {
-				cw.newRecvdMsg(fromNick, msgContent, &fromUID, ts)
+				cw.newRecvdMsg(fromNick, msgContent, &fromUID, msgID, replyTo, ts)
+				if cw.isGC {
+					as.desktopNtfns.notifyMsg(cw.gc, fromNick, cw.alias, msgContent, mentioned)
+				} else {
+					as.desktopNtfns.notifyMsg(fromUID, fromNick, "", msgContent, mentioned)
+				}
 			} else {
 				cw.Lock()
 				cw.unreadIdx -= 1
@@ -653,6 +869,55 @@ func (as *appState) errorLogMsg(msg string) {
 }
 
 // diagMsg adds a message to be displayed in the diagnostic window (i.e. win0).
+// confirmBudgetOverride asks the user to confirm a payment that exceeds a
+// payment budget. Only one confirmation is requested at a time.
+func (as *appState) confirmBudgetOverride(budgetErr client.PaymentBudgetExceededError) bool {
+	as.budgetConfirmMtx.Lock()
+	if as.budgetConfirmChan != nil {
+		as.budgetConfirmMtx.Unlock()
+		as.diagMsg("Denied payment while waiting for another "+
+			"confirmation: %v", budgetErr)
+		return false
+	}
+	c := make(chan bool, 1)
+	as.budgetConfirmChan = c
+	as.budgetConfirmMtx.Unlock()
+	defer func() {
+		as.budgetConfirmMtx.Lock()
+		as.budgetConfirmChan = nil
+		as.budgetConfirmMtx.Unlock()
+	}()
+
+	as.diagMsg("Confirmation needed: %v. Type /budget allow to make "+
+		"the payment or /budget deny to cancel it (within 1 minute)",
+		budgetErr)
+	select {
+	case res := <-c:
+		return res
+	case <-time.After(time.Minute):
+		as.diagMsg("Payment that exceeds budget was not confirmed")
+		return false
+	case <-as.ctx.Done():
+		return false
+	}
+}
+
+// replyBudgetOverride replies to a pending request to confirm a payment that
+// exceeds a payment budget.
+func (as *appState) replyBudgetOverride(allow bool) error {
+	as.budgetConfirmMtx.Lock()
+	defer as.budgetConfirmMtx.Unlock()
+	if as.budgetConfirmChan == nil {
+		return errors.New("no payment is waiting for confirmation")
+	}
+	select {
+	case as.budgetConfirmChan <- allow:
+	default:
+		return errors.New("payment was already confirmed or denied")
+	}
+	return nil
+}
+
 func (as *appState) diagMsg(format string, args ...interface{}) {
 	as.manyDiagMsgsCb(func(pf printf) { pf(format, args...) })
 }
@@ -1094,6 +1359,7 @@ func (as *appState) findOrNewGCWindow(gcID zkidentity.ShortID) *chatWindow {
 		cw.newInternalMsg("Unable to read history messages")
 	}
 	cw.initTime = initTime
+	as.loadDraft(cw)
 	for i, chatLog := range chatHistory {
 		var empty *zkidentity.ShortID
 		if i == 0 ||
@@ -1142,6 +1408,7 @@ func (as *appState) findOrNewChatWindow(id clientintf.UserID, alias string) *cha
 		cw.newInternalMsg("Unable to read history messages")
 	}
 	cw.initTime = initTime
+	as.loadDraft(cw)
 	for i, chatLog := range chatHistory {
 		if i == 0 ||
 			(i > 0 &&
@@ -1157,6 +1424,50 @@ func (as *appState) findOrNewChatWindow(id clientintf.UserID, alias string) *cha
 	return cw
 }
 
+// draftSaveDelay is how long to wait after the last change to the draft of a
+// chat window before persisting it.
+const draftSaveDelay = 2 * time.Second
+
+// loadDraft loads the persisted draft of the chat window.
+func (as *appState) loadDraft(cw *chatWindow) {
+	target, ok := cw.draftTarget()
+	if !ok {
+		return
+	}
+	draft, err := as.c.Draft(target)
+	if err != nil {
+		as.log.Warnf("Unable to load draft of %s: %v", cw.alias, err)
+		return
+	}
+	cw.Lock()
+	cw.draft = draft
+	cw.Unlock()
+}
+
+// updateDraft updates the draft of the chat window. The draft is persisted
+// after draftSaveDelay elapses without further changes.
+func (as *appState) updateDraft(cw *chatWindow, text string) {
+	target, ok := cw.draftTarget()
+	if !ok {
+		return
+	}
+	cw.Lock()
+	defer cw.Unlock()
+	if cw.draft == text {
+		return
+	}
+	cw.draft = text
+	if cw.draftTimer != nil {
+		cw.draftTimer.Stop()
+	}
+	cw.draftTimer = time.AfterFunc(draftSaveDelay, func() {
+		text := cw.getDraft()
+		if err := as.c.SetDraft(target, text); err != nil {
+			as.log.Warnf("Unable to save draft of %s: %v", cw.alias, err)
+		}
+	})
+}
+
 // findChatWindow finds the existing chat window for the given user. Returns nil
 // if the chat window is not setup.
 func (as *appState) findChatWindow(id clientintf.UserID) *chatWindow {
@@ -1174,6 +1485,17 @@ func (as *appState) findChatWindow(id clientintf.UserID) *chatWindow {
 
 // openChatWindow opens (or creates) the chat window of the specified nick
 // or textual id. This handles both PMs and GCs.
+// chatTargetID returns the ID of the user or GC with the given nick or name.
+func (as *appState) chatTargetID(name string) (zkidentity.ShortID, error) {
+	if ru, err := as.c.UserByNick(name); err == nil {
+		return ru.ID(), nil
+	}
+	if gcID, err := as.c.GCIDByName(name); err == nil {
+		return gcID, nil
+	}
+	return zkidentity.ShortID{}, fmt.Errorf("%q is not a user or GC", name)
+}
+
 func (as *appState) openChatWindow(nick string) error {
 	var cw *chatWindow
 	if ru, err := as.c.UserByNick(nick); err == nil {
@@ -1237,6 +1559,52 @@ func (as *appState) findOrNewPagesChatWindow(sessID clientintf.PagesSessionID) *
 	return cw
 }
 
+// markChatWindowRead marks the existing chat window for the given user or GC
+// as read up to and including the message with the given ID, or entirely if
+// msgID is nil. Windows with no more unread messages are removed from the list
+// of updated windows. This does not create a new window if one does not
+// exist.
+func (as *appState) markChatWindowRead(uid *clientintf.UserID, gcID *zkidentity.ShortID,
+	msgID *zkidentity.ShortID) error {
+
+	var cw *chatWindow
+	as.chatWindowsMtx.Lock()
+	for _, w := range as.chatWindows {
+		if w.isPage {
+			continue
+		}
+		if (gcID != nil && w.isGC && w.gc == *gcID) ||
+			(uid != nil && !w.isGC && w.uid == *uid) {
+			cw = w
+			break
+		}
+	}
+	as.chatWindowsMtx.Unlock()
+	if cw == nil {
+		return nil
+	}
+
+	if msgID == nil {
+		cw.markAllRead()
+	} else if !cw.markReadUpTo(*msgID) {
+		return fmt.Errorf("message %s not found in chat", msgID)
+	}
+
+	if cw.unreadCount() == 0 {
+		as.chatWindowsMtx.Lock()
+		for i, w := range as.chatWindows {
+			if w == cw {
+				delete(as.updatedCW, i)
+				break
+			}
+		}
+		as.chatWindowsMtx.Unlock()
+	}
+	as.footerInvalidate()
+	as.sendMsg(struct{}{}) // force update footer
+	return nil
+}
+
 // markWindowUpdated marks the window as updated.
 //
 // If mentioned is specified, the window is noted as updated with a local user
@@ -1272,6 +1640,33 @@ func (as *appState) repaintIfActive(cw *chatWindow) {
 	}
 }
 
+// listNtfnRules lists the notification method and rules.
+func (as *appState) listNtfnRules(out *cmdOutput) {
+	rules := as.desktopNtfns.convRules()
+	out.msgs(func(pf printf) {
+		pf("Notification method: %s", as.desktopNtfns.method())
+		pf("PMs: %s", as.desktopNtfns.globalRule(false))
+		pf("GCs: %s", as.desktopNtfns.globalRule(true))
+		if len(rules) == 0 {
+			return
+		}
+		pf("")
+		pf("Rules of users and GCs")
+		for _, id := range sortedNtfnRuleIDs(rules) {
+			name := id
+			var sid zkidentity.ShortID
+			if sid.FromString(id) == nil {
+				if nick, err := as.c.UserNick(sid); err == nil {
+					name = strescape.Nick(nick)
+				} else if alias, err := as.c.GetGCAlias(sid); err == nil {
+					name = strescape.Nick(alias)
+				}
+			}
+			pf("%s - %s", name, rules[id])
+		}
+	})
+}
+
 // handleRcvdText does some improvements to a raw received message (escapes,
 // handles mentions, etc).
 func (as *appState) handleRcvdText(s string, nick string) string {
@@ -1309,57 +1704,147 @@ func (as *appState) handleRcvdText(s string, nick string) string {
 
 // writeInvite writes a new invite to the given filename. This blocks until the
 // invite is written.
-func (as *appState) writeInvite(filename string, gcID zkidentity.ShortID, funds *rpc.InviteFunds) {
-	as.cwHelpMsg("Attempting to create and subscribe to new invite")
+func (as *appState) writeInvite(out *cmdOutput, filename string, gcID zkidentity.ShortID, funds *rpc.InviteFunds) {
+	out.msg("Attempting to create and subscribe to new invite")
 	w := new(bytes.Buffer)
 	pii, inviteKey, err := as.c.CreatePrepaidInvite(w, funds)
 	if err != nil {
-		as.cwHelpMsg("Unable to create invite: %v", err)
+		out.msg("Unable to create invite: %v", err)
 		return
 	}
 	err = os.WriteFile(filename, w.Bytes(), 0o600)
 	if err != nil {
-		as.cwHelpMsg("Unable to write invite file: %v", err)
+		out.msg("Unable to write invite file: %v", err)
 		return
 	}
 	if !gcID.IsEmpty() {
 		err = as.c.AddInviteOnKX(pii.InitialRendezvous, gcID)
 		if err != nil {
-			as.cwHelpMsg("Unable to add KX action: %v", err)
+			out.msg("Unable to add KX action: %v", err)
 			return
 		}
-		as.cwHelpMsg("Will invite to GC %s after KX", gcID)
+		out.msg("Will invite to GC %s after KX", gcID)
 	}
 
 	encodedKey, err := inviteKey.Encode()
 	if err != nil {
-		as.cwHelpMsg("Unable to encode invite key: %v", err)
+		out.msg("Unable to encode invite key: %v", err)
 		return
 	}
 
-	as.cwHelpMsgs(func(pf printf) {
+	out.msgs(func(pf printf) {
 		pf("Listening for invite request at RV %s", pii.InitialRendezvous)
 		pf("Send file %q to other client and type /add %s",
 			filename, filepath.Base(filename))
 		pf("Prepaid invite written to RV %s", inviteKey.RVPoint())
 		pf("Key for fetching invite: %s", as.styles.Load().nick.Render(encodedKey))
+		pf("Show the key as a QR code with /invite qr %s", encodedKey)
+
+	})
+}
+
+// writeInviteBatch creates a batch of prepaid invites and writes them to the
+// given dir. This blocks until the invites are written.
+func (as *appState) writeInviteBatch(out *cmdOutput, dir string, labels []string, budget dcrutil.Amount,
+	gcID *zkidentity.ShortID) {
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		out.msg("Unable to create invites dir: %v", err)
+		return
+	}
+
+	out.msg("Creating batch of %d prepaid invites", len(labels))
+	createFunds := func(amount dcrutil.Amount) (*rpc.InviteFunds, error) {
+		return as.lnPC.CreateInviteFunds(as.ctx, amount, as.inviteFundsAccount)
+	}
+	invites, batchErr := as.c.CreatePrepaidInviteBatch(labels, budget,
+		createFunds, gcID)
+
+	// Write the invites that were created, even if the batch failed.
+	var keys bytes.Buffer
+	for _, inv := range invites {
+		fname := filepath.Join(dir, inv.Label)
+		if err := os.WriteFile(fname, inv.InviteBytes, 0o600); err != nil {
+			out.msg("Unable to write invite file: %v", err)
+			return
+		}
+		fmt.Fprintf(&keys, "%s %s %s\n", inv.Label, inv.EncodedKey,
+			inv.FundAmount)
+	}
+	if len(invites) > 0 {
+		keysFname := filepath.Join(dir, "keys.txt")
+		if err := os.WriteFile(keysFname, keys.Bytes(), 0o600); err != nil {
+			out.msg("Unable to write invite keys file: %v", err)
+			return
+		}
+	}
 
+	out.msgs(func(pf printf) {
+		pf("")
+		if batchErr != nil {
+			pf("Unable to create all invites: %v", batchErr)
+		}
+		if len(invites) == 0 {
+			return
+		}
+		pf("Wrote %d prepaid invites to %s", len(invites), dir)
+		if budget > 0 {
+			pf("Each invite includes %s of funds", invites[0].FundAmount)
+		}
+		if gcID != nil {
+			pf("Invitees will be invited to GC %s after KX", gcID)
+		}
+		pf("Keys for fetching the invites are listed in %s",
+			filepath.Join(dir, "keys.txt"))
 	})
 }
 
 // pm sends the given pm message in the specified window. Blocks until the
 // messsage is sent to the server.
 func (as *appState) pm(cw *chatWindow, msg string) {
+	as.sendChatMsg(cw, msg, nil)
+}
+
+// replyToLastMsg sends msg as a reply to the last message received in the
+// given window. Blocks until the message is sent to the server.
+func (as *appState) replyToLastMsg(cw *chatWindow, msg string) error {
+	replyTo, ok := cw.lastRecvdMsgID()
+	if !ok {
+		return fmt.Errorf("no received message to reply to in this window")
+	}
+	go as.sendChatMsg(cw, msg, &replyTo)
+	return nil
+}
+
+// sendChatMsg sends the given message in the specified window, optionally as
+// a reply to a previous message. Blocks until the message is sent to the
+// server.
+func (as *appState) sendChatMsg(cw *chatWindow, msg string, replyTo *zkidentity.ShortID) {
 	m := cw.newUnsentPM(msg)
+	if replyTo != nil {
+		cw.Lock()
+		m.replyTo = replyTo
+		cw.Unlock()
+	}
 	as.repaintIfActive(cw)
 
 	var err error
+	var msgID zkidentity.ShortID
 	var progrChan chan client.SendProgress
-	if cw.isGC {
+	if replyTo != nil {
+		target := cw.uid
+		if cw.isGC {
+			target = cw.gc
+		}
+		msgID, err = as.c.ReplyToMessage(target, *replyTo, msg)
+	} else if cw.isGC {
 		progrChan = make(chan client.SendProgress)
-		err = as.c.GCMessage(cw.gc, msg, rpc.MessageModeNormal, progrChan)
+		msgID, err = as.c.GCMessageWithMsgID(cw.gc, msg, rpc.MessageModeNormal, progrChan)
 	} else {
-		err = as.c.PM(cw.uid, msg)
+		msgID, err = as.c.PMWithMsgID(cw.uid, msg)
+	}
+	if err == nil {
+		cw.setMsgID(m, msgID)
 	}
 	if err != nil {
 		if cw.isGC {
@@ -1388,51 +1873,100 @@ func (as *appState) pm(cw *chatWindow, msg string) {
 	}
 }
 
-// payTip sends a tip to the user of the given window. This blocks until the
-// tip has been paid.
-func (as *appState) payTip(cw *chatWindow, dcrAmount float64) {
-	const maxAttempts = 1
-	m := cw.newInternalMsg(fmt.Sprintf("Attempting to send %.8f DCR as tip", dcrAmount))
+// gcChannelMsg sends the given message to the channel of the GC of the
+// specified window. Blocks until the message is sent to the server.
+func (as *appState) gcChannelMsg(out *cmdOutput, cw *chatWindow, ch client.GCChannel, msg string) {
+	m := cw.newUnsentPM(fmt.Sprintf("[#%s] %s", ch.Name, msg))
 	as.repaintIfActive(cw)
-	err := as.c.TipUser(cw.uid, dcrAmount, maxAttempts)
+
+	progrChan := make(chan client.SendProgress)
+	msgID, err := as.c.GCChannelMessage(cw.gc, ch.ID, msg,
+		rpc.MessageModeNormal, progrChan)
 	if err != nil {
-		as.cwHelpMsg("Unable to tip user %q: %v",
-			cw.alias, err)
-	} else {
-		cw.setMsgSent(m)
-		as.repaintIfActive(cw)
+		out.msg("Unable to send message to channel #%s of GC %q: %v",
+			ch.Name, cw.alias, err)
+		return
+	}
+	cw.setMsgID(m, msgID)
+	for progr := range progrChan {
+		if progr.Err != nil {
+			out.diagMsg("Error while sending GC channel msg: %v", progr.Err)
+		}
+		if progr.Sent == progr.Total {
+			cw.setMsgSent(m)
+			as.sendMsg(repaintActiveChat{})
+			break
+		}
 	}
 }
 
-func (as *appState) payPayReq(cw *chatWindow, invoice string, payReq *zpay32.Invoice) {
-	if isPayReqExpired(payReq) {
-		return
+// editLastMsg edits the last message sent by the local client in the given
+// window.
+func (as *appState) editLastMsg(cw *chatWindow, msg string) error {
+	msgID, ok := cw.lastSentMsgID()
+	if !ok {
+		return fmt.Errorf("no sent message to edit in this window")
 	}
 
-	_, loaded := as.payReqStatuses.LoadOrStore(*payReq.PaymentHash, lnrpc.Payment_IN_FLIGHT)
-	if loaded {
-		// Already attempting to pay.
-		return
+	target := cw.uid
+	if cw.isGC {
+		target = cw.gc
+	}
+	if err := as.c.EditMessage(target, msgID, msg); err != nil {
+		return err
 	}
+	cw.editMsg(nil, msgID, msg)
+	as.repaintIfActive(cw)
+	return nil
+}
 
-	fees, err := as.lnPC.PayInvoice(as.ctx, invoice)
-	if err != nil {
-		as.diagMsg(as.styles.Load().err.Render(fmt.Sprintf("Unable to pay invoice: %v", err)))
-		as.payReqStatuses.Store(*payReq.PaymentHash, lnrpc.Payment_FAILED)
-		return
+// reactToLastMsg adds (or removes) a reaction to the last message received in
+// the given window.
+func (as *appState) reactToLastMsg(cw *chatWindow, reaction string, remove bool) error {
+	msgID, ok := cw.lastRecvdMsgID()
+	if !ok {
+		return fmt.Errorf("no received message to react to in this window")
 	}
 
-	as.payReqStatuses.Store(*payReq.PaymentHash, lnrpc.Payment_SUCCEEDED)
-	as.diagMsg(fmt.Sprintf("Paid %s invoice (%d milliatoms as fees)", payReqStrAmount(payReq), fees))
+	target := cw.uid
+	if cw.isGC {
+		target = cw.gc
+	}
+	if err := as.c.ReactToMessage(target, msgID, reaction, remove); err != nil {
+		return err
+	}
+	if mr, err := as.c.MessageReactions(msgID); err == nil {
+		cw.setMsgReactions(msgID, mr.Reactions)
+	}
+	as.repaintIfActive(cw)
+	return nil
 }
 
-// block blocks a user.
-func (as *appState) block(cw *chatWindow) {
-	m := cw.newInternalMsg("Blocked user")
+// checkPaymentsAllowed returns an error if the payments mode of the client
+// does not allow spending funds.
+func (as *appState) checkPaymentsAllowed() error {
+	if mode := as.c.PaymentsMode(); mode != client.PaymentsModeNormal {
+		return fmt.Errorf("%w (%s mode)", clientintf.ErrPaymentsDisabled, mode)
+	}
+	return nil
+}
+
+// payTip sends a tip to the user of the given window. This blocks until the
+// tip has been paid.
+func (as *appState) payTip(out *cmdOutput, cw *chatWindow, dcrAmount float64) {
+	const maxAttempts = 0 // Use the tip retry policy.
+	msg := fmt.Sprintf("Attempting to send %.8f DCR as tip", dcrAmount)
+	if est, err := as.c.EstimateTipFee(cw.uid, dcrAmount); err == nil {
+		msg += fmt.Sprintf(" (estimated routing fee %.8f DCR)",
+			float64(est.FeeMAtoms)/1e11)
+	} else if errors.Is(err, clientintf.ErrNoRouteWithinFeeLimit) {
+		msg += " (no route currently found within the fee limit)"
+	}
+	m := cw.newInternalMsg(msg)
 	as.repaintIfActive(cw)
-	err := as.c.Block(cw.uid)
+	err := as.c.TipUser(cw.uid, dcrAmount, maxAttempts)
 	if err != nil {
-		as.cwHelpMsg("Unable to block user %q: %v",
+		out.msg("Unable to tip user %q: %v",
 			cw.alias, err)
 	} else {
 		cw.setMsgSent(m)
@@ -1440,9 +1974,113 @@ func (as *appState) block(cw *chatWindow) {
 	}
 }
 
-// inviteToGC invites the given user to the GC in the specified window. Blocks
-// until the invite message is sent to the server.
-func (as *appState) inviteToGC(cw *chatWindow, nick string, uid clientintf.UserID) {
+// payTipUSD sends a tip denominated in USD to the user of the given window.
+// The amount is converted to DCR at the current exchange rate.
+func (as *appState) payTipUSD(out *cmdOutput, cw *chatWindow, usdAmount float64) {
+	const maxAttempts = 0 // Use the tip retry policy.
+	m := cw.newInternalMsg(fmt.Sprintf("Attempting to send $%.2f as tip", usdAmount))
+	as.repaintIfActive(cw)
+	amt, err := as.c.TipUserUSD(cw.uid, usdAmount, maxAttempts)
+	if err != nil {
+		out.msg("Unable to tip user %q: %v",
+			cw.alias, err)
+		return
+	}
+	cw.newInternalMsg(fmt.Sprintf("Converted $%.2f to %s", usdAmount, amt))
+	cw.setMsgSent(m)
+	as.repaintIfActive(cw)
+}
+
+// tipExternal pays a tip to a recipient outside of the contacts, through
+// LNURL-pay. This blocks until the tip has been paid.
+func (as *appState) tipExternal(out *cmdOutput, target string, amt dcrutil.Amount, comment string) {
+	if as.lnPC == nil {
+		out.msg("Unable to tip %s: LN payments are disabled",
+			strescape.Content(target))
+		return
+	}
+	if err := as.checkPaymentsAllowed(); err != nil {
+		out.msg("Unable to tip %s: %v", strescape.Content(target), err)
+		return
+	}
+
+	out.msg("Attempting to send %s as tip to %s", amt,
+		strescape.Content(target))
+	ctx, cancel := context.WithTimeout(as.ctx, 5*time.Minute)
+	defer cancel()
+	r, err := as.c.TipExternal(ctx, as.httpClient, target,
+		int64(amt)*1e3, comment)
+	if err != nil {
+		out.msg("Unable to tip %s: %v", strescape.Content(target), err)
+		return
+	}
+	out.msgs(func(pf printf) {
+		pf("Sent %s as tip to %s (fees %.8f DCR)", amt,
+			strescape.Content(target), float64(r.Fees)/1e11)
+		as.printExternalTipDetails(pf, &r)
+	})
+}
+
+// printExternalTipDetails prints the description and success action of an
+// external tip receipt.
+func (as *appState) printExternalTipDetails(pf printf, r *client.ExternalTipReceipt) {
+	if r.Description != "" {
+		pf("  Description: %s", strescape.Content(r.Description))
+	}
+	if r.Comment != "" {
+		pf("  Comment: %s", strescape.Content(r.Comment))
+	}
+	if r.SuccessMessage != "" {
+		pf("  Message from recipient: %s", strescape.Content(r.SuccessMessage))
+	}
+	if r.SuccessURL != "" {
+		pf("  URL from recipient: %s", strescape.Content(r.SuccessURL))
+	}
+}
+
+func (as *appState) payPayReq(cw *chatWindow, invoice string, payReq *zpay32.Invoice) {
+	if isPayReqExpired(payReq) {
+		return
+	}
+	if err := as.checkPaymentsAllowed(); err != nil {
+		as.diagMsg(as.styles.Load().err.Render(fmt.Sprintf("Unable to pay invoice: %v", err)))
+		return
+	}
+
+	_, loaded := as.payReqStatuses.LoadOrStore(*payReq.PaymentHash, lnrpc.Payment_IN_FLIGHT)
+	if loaded {
+		// Already attempting to pay.
+		return
+	}
+
+	fees, err := as.c.PayExternalInvoice(as.ctx, invoice)
+	if err != nil {
+		as.diagMsg(as.styles.Load().err.Render(fmt.Sprintf("Unable to pay invoice: %v", err)))
+		as.payReqStatuses.Store(*payReq.PaymentHash, lnrpc.Payment_FAILED)
+		return
+	}
+
+	as.payReqStatuses.Store(*payReq.PaymentHash, lnrpc.Payment_SUCCEEDED)
+	as.diagMsg(fmt.Sprintf("Paid %s invoice (%d milliatoms as fees)", payReqStrAmount(payReq), fees))
+}
+
+// block blocks a user.
+func (as *appState) block(out *cmdOutput, cw *chatWindow) {
+	m := cw.newInternalMsg("Blocked user")
+	as.repaintIfActive(cw)
+	err := as.c.Block(cw.uid)
+	if err != nil {
+		out.msg("Unable to block user %q: %v",
+			cw.alias, err)
+	} else {
+		cw.setMsgSent(m)
+		as.repaintIfActive(cw)
+	}
+}
+
+// inviteToGC invites the given user to the GC in the specified window. Blocks
+// until the invite message is sent to the server.
+func (as *appState) inviteToGC(cw *chatWindow, nick string, uid clientintf.UserID) {
 	m := cw.newInternalMsg(fmt.Sprintf("Invited user %q to gc %s", nick, cw.alias))
 	as.repaintIfActive(cw)
 	err := as.c.InviteToGroupChat(cw.gc, uid)
@@ -1456,7 +2094,7 @@ func (as *appState) inviteToGC(cw *chatWindow, nick string, uid clientintf.UserI
 
 // kickFromGC kicks the given user from the given GC. Only works if we're the
 // admin of the GC.
-func (as *appState) kickFromGC(gcWin *chatWindow, uid clientintf.UserID,
+func (as *appState) kickFromGC(out *cmdOutput, gcWin *chatWindow, uid clientintf.UserID,
 	userNick, reason string) {
 
 	gcName := gcWin.alias
@@ -1466,13 +2104,13 @@ func (as *appState) kickFromGC(gcWin *chatWindow, uid clientintf.UserID,
 			userNick, gcName))
 		as.repaintIfActive(gcWin)
 	} else {
-		as.cwHelpMsg("Unable to kick %s from gc %q: %v", userNick,
+		out.msg("Unable to kick %s from gc %q: %v", userNick,
 			gcName, err)
 	}
 }
 
 // partFromGC withdraws the local user from the GC.
-func (as *appState) partFromGC(gcWin *chatWindow, reason string) {
+func (as *appState) partFromGC(out *cmdOutput, gcWin *chatWindow, reason string) {
 	gcName := gcWin.alias
 	m := gcWin.newInternalMsg("Parting from GC...")
 	as.repaintIfActive(gcWin)
@@ -1481,19 +2119,19 @@ func (as *appState) partFromGC(gcWin *chatWindow, reason string) {
 		gcWin.setMsgSent(m)
 		as.repaintIfActive(gcWin)
 	} else {
-		as.cwHelpMsg("Unable to part from gc %q: %v", gcName, err)
+		out.msg("Unable to part from gc %q: %v", gcName, err)
 	}
 }
 
 // killGC dissolves the given GC.
-func (as *appState) killGC(gcWin *chatWindow, reason string) {
+func (as *appState) killGC(out *cmdOutput, gcWin *chatWindow, reason string) {
 	gcName := gcWin.alias
 	err := as.c.KillGroupChat(gcWin.gc, reason)
 	if err == nil {
 		gcWin.newInternalMsg("Killed GC")
 		as.repaintIfActive(gcWin)
 	} else {
-		as.cwHelpMsg("Unable to kill GC %q: %v", gcName, err)
+		out.msg("Unable to kill GC %q: %v", gcName, err)
 	}
 }
 
@@ -1514,7 +2152,7 @@ func (as *appState) listUserContent(cw *chatWindow, dir, filter string) {
 	as.repaintIfActive(cw)
 }
 
-func (as *appState) getUserContent(cw *chatWindow, filename string) {
+func (as *appState) getUserContent(out *cmdOutput, cw *chatWindow, filename string, preview bool) {
 	var rf clientdb.RemoteFile
 	var fid, emptyFID clientdb.FileID
 
@@ -1536,27 +2174,37 @@ func (as *appState) getUserContent(cw *chatWindow, filename string) {
 	}
 
 	if fid == emptyFID {
-		as.cwHelpMsg("Cannot find file ID for file %q. Try `/ft ls <user>` first.",
+		out.msg("Cannot find file ID for file %q. Try `/ft ls <user>` first.",
 			filename)
 		return
 	}
 
+	if preview {
+		err := as.c.GetUserContentPreview(cw.uid, fid)
+		if err != nil {
+			out.msg("Unable to fetch user content preview: %v", err)
+		}
+		out.msg(fmt.Sprintf("Starting to download preview of file %s", filename))
+		as.repaintIfActive(cw)
+		return
+	}
+
 	if rf.DiskPath != "" {
 		if _, err := os.Stat(rf.DiskPath); err == nil {
-			as.cwHelpMsg("File already downloaded in %q", rf.DiskPath)
+			out.msg("File already downloaded in %q", rf.DiskPath)
 			return
 		}
 	}
 
 	err := as.c.GetUserContent(cw.uid, fid)
 	if err != nil {
-		as.cwHelpMsg("Unable to fetch user content: %v", err)
+		out.msg("Unable to fetch user content: %v", err)
 	}
-	as.cwHelpMsg(fmt.Sprintf("Starting to download file %s", filename))
+	out.msg(fmt.Sprintf("Starting to download file %s", filename))
 	as.repaintIfActive(cw)
 }
 
-func (as *appState) subscribeToPosts(uid clientintf.UserID) error {
+func (as *appState) subscribeToPosts(out *cmdOutput, uid clientintf.UserID) error {
 	cw := as.findChatWindow(uid)
 	nick, err := as.c.UserNick(uid)
 	if err != nil {
@@ -1571,12 +2219,12 @@ func (as *appState) subscribeToPosts(uid clientintf.UserID) error {
 		cw.newHelpMsg(msg)
 		as.repaintIfActive(cw)
 	} else {
-		as.cwHelpMsg(msg)
+		out.msg(msg)
 	}
 	return nil
 }
 
-func (as *appState) unsubscribeToPosts(uid clientintf.UserID) error {
+func (as *appState) unsubscribeToPosts(out *cmdOutput, uid clientintf.UserID) error {
 	cw := as.findChatWindow(uid)
 	nick, err := as.c.UserNick(uid)
 	if err != nil {
@@ -1591,7 +2239,7 @@ func (as *appState) unsubscribeToPosts(uid clientintf.UserID) error {
 		cw.newHelpMsg(msg)
 		as.repaintIfActive(cw)
 	} else {
-		as.cwHelpMsg(msg)
+		out.msg(msg)
 	}
 	return nil
 }
@@ -1608,25 +2256,25 @@ func (as *appState) requestRatchetReset(cw *chatWindow) {
 	as.repaintIfActive(cw)
 }
 
-func (as *appState) resetAllOldRatchets(interval time.Duration) error {
+func (as *appState) resetAllOldRatchets(out *cmdOutput, interval time.Duration) error {
 	intervalStr := interval.String()
 	if interval > time.Hour*24*3 {
 		intervalStr = fmt.Sprintf("%d days",
 			interval/(time.Hour*24))
 	}
 	progrChan := make(chan clientintf.UserID)
-	go func() {
+	out.goAsync(func() {
 		for uid := range progrChan {
 			nick, _ := as.c.UserNick(uid)
-			as.diagMsg("Requested ratchet reset with %s (%s)",
+			out.diagMsg("Requested ratchet reset with %s (%s)",
 				strescape.Nick(nick), uid)
 		}
-	}()
+	})
 
-	as.cwHelpMsg("Starting to reset ratchets older than %s", intervalStr)
-	go func() {
+	out.msg("Starting to reset ratchets older than %s", intervalStr)
+	out.goAsync(func() {
 		res, err := as.c.ResetAllOldRatchets(interval, progrChan)
-		as.manyDiagMsgsCb(func(pf printf) {
+		out.diagMsgs(func(pf printf) {
 			if len(res) == 0 && err == nil {
 				pf("No old ratchets in need of starting reset")
 			} else if err != nil {
@@ -1635,7 +2283,7 @@ func (as *appState) resetAllOldRatchets(interval time.Duration) error {
 		})
 		time.Sleep(time.Second)
 		close(progrChan)
-	}()
+	})
 	return nil
 }
 
@@ -1763,7 +2411,7 @@ func (as *appState) openChannel(amount, key, server string) error {
 
 // closeChannel attempts to close the specified channel. This blocks until the
 // channel is closed, so must be called from a goroutine.
-func (as *appState) closeChannel(chanPoint *lnrpc.ChannelPoint, force bool) {
+func (as *appState) closeChannel(out *cmdOutput, chanPoint *lnrpc.ChannelPoint, force bool) {
 	req := &lnrpc.CloseChannelRequest{
 		ChannelPoint: chanPoint,
 		Force:        force,
@@ -1771,26 +2419,26 @@ func (as *appState) closeChannel(chanPoint *lnrpc.ChannelPoint, force bool) {
 
 	res, err := as.lnRPC.CloseChannel(as.ctx, req)
 	if err != nil {
-		as.cwHelpMsg("Unable to close channel: %v", err)
+		out.msg("Unable to close channel: %v", err)
 		return
 	}
 
 	for {
 		updt, err := res.Recv()
 		if err != nil {
-			as.diagMsg("Error reading channel close update: %v", err)
+			out.diagMsg("Error reading channel close update: %v", err)
 			return
 		}
 
 		if updt, ok := updt.Update.(*lnrpc.CloseStatusUpdate_ClosePending); ok {
 			ch, _ := chainhash.NewHash(updt.ClosePending.Txid)
-			as.diagMsg("Channel %s close pending on tx %s:%d",
+			out.diagMsg("Channel %s close pending on tx %s:%d",
 				chanPointToStr(chanPoint), ch,
 				updt.ClosePending.OutputIndex)
 		}
 		if updt, ok := updt.Update.(*lnrpc.CloseStatusUpdate_ChanClose); ok {
 			ch, _ := chainhash.NewHash(updt.ChanClose.ClosingTxid)
-			as.diagMsg("Channel %s closed on tx %s",
+			out.diagMsg("Channel %s closed on tx %s",
 				chanPointToStr(chanPoint), ch)
 			break
 		}
@@ -1881,7 +2529,7 @@ func (as *appState) requestRecv(amount, server, key string, caCert []byte) error
 	}
 }
 
-func (as *appState) createPost(post string, root string) {
+func (as *appState) createPost(post string, root string, tags []string) {
 	// Process local data.
 	post = resources.RemoveEndOfPostMarker(post)
 	if root == "" {
@@ -1893,7 +2541,7 @@ func (as *appState) createPost(post string, root string) {
 		return
 	}
 
-	summ, err := as.c.CreatePost(post, "")
+	summ, err := as.c.CreateTaggedPost(post, "", tags)
 	if err != nil {
 		as.cwHelpMsg("Unable to create post: %v", err)
 	} else {
@@ -1906,6 +2554,20 @@ func (as *appState) createPost(post string, root string) {
 	}
 }
 
+// postDraftPublished is called after attempting to publish a post draft.
+func (as *appState) postDraftPublished(summ clientdb.PostSummary, err error) {
+	if err != nil {
+		as.cwHelpMsg("Unable to publish post draft: %v", err)
+		return
+	}
+	as.cwHelpMsg("Published post %s", summ.ID)
+	as.postsMtx.Lock()
+	as.posts = append(as.posts, summ)
+	as.sortPosts()
+	as.postsMtx.Unlock()
+	as.sendMsg(summ)
+}
+
 func (as *appState) loadPosts() {
 	posts, err := as.c.ListPosts()
 	if err != nil {
@@ -2031,6 +2693,15 @@ func (as *appState) relayPostToAll(fromUID clientintf.UserID, pid clientintf.Pos
 	}
 }
 
+func (as *appState) quoteRelayPost(fromUID clientintf.UserID, pid clientintf.PostID,
+	comment string) {
+	as.diagMsg(fmt.Sprintf("Relaying post %s from %s with comment", pid, fromUID))
+	err := as.c.QuoteRelayPost(fromUID, pid, comment)
+	if err != nil {
+		as.diagMsg("Unable to relay post: %v", err)
+	}
+}
+
 func (as *appState) subscribeAndFetchPost(uid clientintf.UserID, pid clientintf.PostID) {
 	err := as.c.SubscribeToPostsAndFetch(uid, pid)
 	if err != nil {
@@ -2038,7 +2709,7 @@ func (as *appState) subscribeAndFetchPost(uid clientintf.UserID, pid clientintf.
 	}
 }
 
-func (as *appState) queryLNNodeInfo(nodePubKey string, amount uint64) error {
+func (as *appState) queryLNNodeInfo(out *cmdOutput, nodePubKey string, amount uint64) error {
 	if as.lnRPC == nil {
 		return fmt.Errorf("LN client not configured")
 	}
@@ -2049,14 +2720,18 @@ func (as *appState) queryLNNodeInfo(nodePubKey string, amount uint64) error {
 	}
 	nodeInfoRes, nodeInfoErr := as.lnRPC.GetNodeInfo(as.ctx, nodeInfoReq)
 
+	feeLimit := client.PaymentFeeLimit(amount * 1000)
+	if as.lnPC != nil {
+		feeLimit = as.lnPC.FeePolicy().FeeLimit(int64(amount) * 1000)
+	}
 	routeReq := &lnrpc.QueryRoutesRequest{
 		PubKey:   nodePubKey,
 		Amt:      int64(amount),
-		FeeLimit: client.PaymentFeeLimit(amount * 1000),
+		FeeLimit: feeLimit,
 	}
 	route, routeErr := as.lnRPC.QueryRoutes(as.ctx, routeReq)
 
-	as.cwHelpMsgs(func(pf printf) {
+	out.msgs(func(pf printf) {
 		pf("")
 		pf("LN Node Info: %s", nodePubKey)
 		if nodeInfoErr != nil {
@@ -2195,6 +2870,7 @@ func (as *appState) msgInActiveWindow(msg string) {
 
 	cw := as.chatWindows[as.activeCW]
 	as.chatWindowsMtx.Unlock()
+	as.updateDraft(cw, "")
 	go as.pm(cw, msg)
 }
 
@@ -2478,6 +3154,60 @@ func (as *appState) modifyGCAdmins(gcID zkidentity.ShortID, add, del clientintf.
 	return nil
 }
 
+// gcTargetUID returns the ID of the user identified by nick or by their full
+// hex-encoded ID. Full IDs do not need to belong to known users.
+func (as *appState) gcTargetUID(nick string) (clientintf.UserID, error) {
+	var uid clientintf.UserID
+	if len(nick) == 64 {
+		err := uid.FromString(nick)
+		return uid, err
+	}
+	return as.c.UIDByNick(nick)
+}
+
+func (as *appState) modifyGCModerators(gcID zkidentity.ShortID, add, del clientintf.UserID) error {
+	if add.IsEmpty() && del.IsEmpty() {
+		return fmt.Errorf("no modifications")
+	}
+
+	gc, err := as.c.GetGC(gcID)
+	if err != nil {
+		return err
+	}
+
+	newMods := gc.Moderators
+
+	if !add.IsEmpty() {
+		if slices.Contains(gc.Moderators, add) {
+			return fmt.Errorf("user %s already a moderator", add)
+		}
+		newMods = append(newMods, add)
+	}
+	if !del.IsEmpty() {
+		idx := slices.Index(newMods, del)
+		if idx == -1 {
+			return fmt.Errorf("user %s not a moderator", del)
+		}
+		newMods = slices.Delete(newMods, idx, idx+1)
+	}
+
+	cw := as.findOrNewGCWindow(gcID)
+	err = as.c.ModifyGCModerators(gcID, newMods, "")
+	if err != nil {
+		return err
+	}
+	if !add.IsEmpty() {
+		nick, _ := as.c.UserNick(add)
+		cw.newHelpMsg("Added %s as GC moderator", strescape.Nick(nick))
+	}
+	if !del.IsEmpty() {
+		nick, _ := as.c.UserNick(del)
+		cw.newHelpMsg("Removed %s as GC moderator", strescape.Nick(nick))
+	}
+	as.repaintIfActive(cw)
+	return nil
+}
+
 // handleCmd executes the given (already parsed) command line.
 func (as *appState) handleCmd(rawText string, args []string) {
 	if len(args) == 0 {
@@ -2492,6 +3222,20 @@ func (as *appState) handleCmd(rawText string, args []string) {
 	}
 	as.cmdHistoryIdx = len(as.cmdHistory)
 
+	err := as.runCmd(rawText, args, &cmdOutput{as: as})
+
+	// Save successful command in history file. Ignore errors here as
+	// there's nothing to do about it.
+	if storeCmd && err == nil && as.cmdHistoryFile != nil {
+		_, _ = as.cmdHistoryFile.Write([]byte(rawText))
+		_, _ = as.cmdHistoryFile.Write([]byte("\n"))
+		_ = as.cmdHistoryFile.Sync()
+	}
+}
+
+// runCmd runs the given (already parsed) command line, writing its output and
+// any errors to out.
+func (as *appState) runCmd(rawText string, args []string, out *cmdOutput) error {
 	styles := as.styles.Load()
 	renderErr := renderPF(styles.err)
 	render := renderPF(styles.noStyle)
@@ -2500,10 +3244,10 @@ func (as *appState) handleCmd(rawText string, args []string) {
 	if cmd == nil {
 		msg := renderErr("Command %q not found.", args[0]) +
 			render(" Type %s%s for help.", string(leader), helpCmd.cmd)
-		as.cwHelpMsgs(func(pf printf) {
+		out.msgs(func(pf printf) {
 			pf(msg)
 		})
-		return
+		return fmt.Errorf("command %q not found", args[0])
 	}
 
 	fullCmd := cmd.cmd
@@ -2513,14 +3257,21 @@ func (as *appState) handleCmd(rawText string, args []string) {
 	}
 
 	// Verify preconditions.
+	if cmd.spendsFunds {
+		if err := as.checkPaymentsAllowed(); err != nil {
+			out.msg(renderErr("%s%s: cannot issue this command: %v",
+				string(leader), fullCmd, err))
+			return fmt.Errorf("cannot issue command: %w", err)
+		}
+	}
 	if !cmd.usableOffline {
 		if as.currentConnState() != connStateOnline {
-			as.cwHelpMsg("%s%s: cannot issue this command while offline",
+			out.msg("%s%s: cannot issue this command while offline",
 				string(leader), fullCmd)
-			return
+			return fmt.Errorf("cannot issue command while offline")
 		}
 		if !as.canPayServerOps() {
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("%s%s: cannot issue this command without capacity to pay server",
 					string(leader), fullCmd)
 				pf("Use '/ln svrnode' to check route to server")
@@ -2528,7 +3279,7 @@ func (as *appState) handleCmd(rawText string, args []string) {
 				pf("Use '/ln openchannel' to open outbound LN channels")
 				pf("Use '/enablecanpay' to skip this test and attempt to send server payments anyway")
 			})
-			return
+			return fmt.Errorf("cannot issue command without capacity to pay server")
 
 		}
 	}
@@ -2537,38 +3288,31 @@ func (as *appState) handleCmd(rawText string, args []string) {
 	var err error
 	switch {
 	case cmd.rawHandler != nil:
-		err = cmd.rawHandler(rawText, args, as)
+		err = cmd.rawHandler(rawText, args, as, out)
 	case cmd.handler != nil:
-		err = cmd.handler(args, as)
+		err = cmd.handler(args, as, out)
 	default:
-		as.cwHelpMsg(renderErr("Command %q unimplemented", fullCmd))
-		return
+		out.msg(renderErr("Command %q unimplemented", fullCmd))
+		return fmt.Errorf("command %q unimplemented", fullCmd)
 	}
 
 	if errors.Is(err, usageError{}) {
-		as.cwHelpMsgs(func(pf printf) {
+		out.msgs(func(pf printf) {
 			pf("")
 			pf(renderErr("Incorrect usage of %q: %v", fullCmd, err))
 			pf("Usage: %s%s %s", string(leader), fullCmd, cmd.usage)
 			pf("Type %s%s %s for additional help", string(leader),
 				helpCmd.cmd, fullCmd)
 		})
-		return
+		return err
 	}
 	if err != nil {
 		as.log.Errorf("Error executing %q: %v", rawText, err)
-		as.cwHelpMsgs(func(pf printf) {
+		out.msgs(func(pf printf) {
 			pf(renderErr("Error executing %q: %v", rawText, err))
 		})
 	}
-
-	// Save successful command in history file. Ignore errors here as
-	// there's nothing to do about it.
-	if storeCmd && err == nil && as.cmdHistoryFile != nil {
-		_, _ = as.cmdHistoryFile.Write([]byte(rawText))
-		_, _ = as.cmdHistoryFile.Write([]byte("\n"))
-		_ = as.cmdHistoryFile.Sync()
-	}
+	return err
 }
 
 // newAppState initializes the main app state.
@@ -2620,6 +3364,20 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 			MacaroonPath: args.LNMacaroonPath,
 			Address:      args.LNRPCHost,
 			Log:          logBknd.logger("LNPY"),
+			FeePolicy:    args.FeePolicy,
+
+			MaxPaymentParts: args.MaxPaymentParts,
+			OnConnStateChange: func(connected bool) {
+				if as == nil {
+					return
+				}
+				if connected {
+					as.diagMsg("Reconnected to dcrlnd at %s", args.LNRPCHost)
+				} else {
+					as.diagMsg("Connection to dcrlnd at %s lost; "+
+						"reconnecting", args.LNRPCHost)
+				}
+			},
 		}
 		lnPC, err = client.NewDcrlndPaymentClient(ctx, pcCfg)
 		if err != nil {
@@ -2695,6 +3453,66 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		}()
 	}))
 
+	ntfns.Register(client.OnMsgEditedNtfn(func(user *client.RemoteUser,
+		edit rpc.RMEditMessage, ts time.Time) {
+
+		fromNick := strescape.Nick(user.Nick())
+		fromUID := user.ID()
+		var cw *chatWindow
+		if edit.GC != nil {
+			cw = as.findOrNewGCWindow(*edit.GC)
+		} else {
+			cw = as.findOrNewChatWindow(fromUID, fromNick)
+		}
+
+		msg := strescape.CannonicalizeNL(strescape.Content(edit.Message))
+		if !cw.editMsg(&fromUID, edit.MsgID, msg) {
+			// Message is not in the window anymore (for example,
+			// after a restart), so show the edit as a new msg.
+			cw.newHelpMsg("%s edited a previous message:", fromNick)
+			cw.newRecvdMsg(fromNick, msg, &fromUID, &edit.MsgID, nil, ts)
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnMsgReactionNtfn(func(user *client.RemoteUser,
+		reaction rpc.RMMessageReaction, reactions *clientdb.MessageReactions,
+		ts time.Time) {
+
+		var cw *chatWindow
+		if reaction.GC != nil {
+			cw = as.findOrNewGCWindow(*reaction.GC)
+		} else {
+			cw = as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
+		}
+
+		if !cw.setMsgReactions(reaction.MsgID, reactions.Reactions) && !reaction.Remove {
+			// Message is not in the window anymore.
+			cw.newHelpMsg("%s reacted to a previous message with %s",
+				strescape.Nick(user.Nick()),
+				strescape.Content(reaction.Reaction))
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnMsgDeliveryStatusNtfn(func(user *client.RemoteUser,
+		msgID zkidentity.ShortID, status client.MsgDeliveryStatus, err error) {
+
+		if status != client.MsgStatusDelivered {
+			return
+		}
+
+		as.chatWindowsMtx.Lock()
+		cws := as.chatWindows
+		as.chatWindowsMtx.Unlock()
+		for _, cw := range cws {
+			if cw.setMsgDelivered(msgID) {
+				as.repaintIfActive(cw)
+				return
+			}
+		}
+	}))
+
 	ntfns.Register(client.OnPostRcvdNtfn(func(user *client.RemoteUser,
 		summ clientdb.PostSummary, pm rpc.PostMetadata) {
 
@@ -2997,25 +3815,261 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		as.repaintIfActive(cw)
 	}))
 
+	ntfns.Register(client.OnGCModeratorsChangedNtfn(func(ru *client.RemoteUser, gc rpc.RMGroupList, added, removed []zkidentity.ShortID) {
+		srcNick := strescape.Nick(ru.Nick())
+
+		cw := as.findOrNewGCWindow(gc.ID)
+		cw.manyHelpMsgs(func(pf printf) {
+			myID := as.c.PublicID()
+			pf("List of GC moderators modified by %s", srcNick)
+			for _, uid := range added {
+				if uid == myID {
+					pf("Added local client as moderator")
+				} else {
+					nick, _ := as.c.UserNick(uid)
+					pf("Added %q (%s) as moderator", strescape.Nick(nick),
+						uid)
+				}
+			}
+			for _, uid := range removed {
+				if uid == myID {
+					pf("Removed local client as moderator")
+				} else {
+					nick, _ := as.c.UserNick(uid)
+					pf("Removed %q (%s) as moderator", strescape.Nick(nick),
+						uid)
+				}
+			}
+		})
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCSlowModeChangedNtfn(func(ru *client.RemoteUser, gc rpc.RMGroupList, interval time.Duration) {
+		srcNick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewGCWindow(gc.ID)
+		if interval == 0 {
+			cw.newHelpMsg("Slow mode disabled by %s", srcNick)
+		} else {
+			cw.newHelpMsg("Slow mode set to %s between messages by %s",
+				interval, srcNick)
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCUnkxdMentionNtfn(func(ru *client.RemoteUser, m rpc.RMGroupUnkxdMention, autoKX bool) {
+		cw := as.findOrNewGCWindow(m.ID)
+		cw.newHelpMsg("Admin %s reports you were mentioned by unKXd member %s: %s",
+			strescape.Nick(ru.Nick()), m.Sender, strescape.Content(m.Message))
+		if autoKX {
+			cw.newHelpMsg("Requesting KX with %s", m.Sender)
+		} else {
+			cw.newHelpMsg("Type /mi %s %s to request KX with them",
+				strescape.Nick(ru.Nick()), m.Sender)
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCHistoryReceivedNtfn(func(ru *client.RemoteUser, gcID zkidentity.ShortID, msgs []client.ChatExportMessage) {
+		cw := as.findOrNewGCWindow(gcID)
+		cw.manyHelpMsgs(func(pf printf) {
+			pf("Recent GC history shared by %s (%d messages):",
+				strescape.Nick(ru.Nick()), len(msgs))
+			for _, m := range msgs {
+				pf("%s <%s> %s", m.Timestamp.Format(ISO8601DateTime),
+					strescape.Nick(m.From), strescape.Content(m.Message))
+			}
+			pf("End of shared GC history")
+		})
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCMetadataUpdatedNtfn(func(ru *client.RemoteUser, gc rpc.RMGroupList, fields []client.GCMetadataField) {
+		srcNick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewGCWindow(gc.ID)
+		cw.manyHelpMsgs(func(pf printf) {
+			for _, field := range fields {
+				switch {
+				case field == client.GCMetadataTopic && gc.Topic == "":
+					pf("GC topic cleared by %s", srcNick)
+				case field == client.GCMetadataTopic:
+					pf("GC topic set to %q by %s",
+						strescape.Content(gc.Topic), srcNick)
+				default:
+					pf("GC %s updated by %s", field, srcNick)
+				}
+			}
+		})
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCChannelsChangedNtfn(func(ru *client.RemoteUser, gc rpc.RMGroupList, added, removed []client.GCChannel) {
+		srcNick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewGCWindow(gc.ID)
+		cw.manyHelpMsgs(func(pf printf) {
+			for _, ch := range added {
+				pf("Channel #%s added by %s",
+					strescape.Nick(ch.Name), srcNick)
+			}
+			for _, ch := range removed {
+				pf("Channel #%s removed by %s",
+					strescape.Nick(ch.Name), srcNick)
+			}
+		})
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnGCJoinRequestNtfn(func(ru *client.RemoteUser, gc rpc.RMGroupList, req client.GCJoinRequest) {
+		srcNick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewGCWindow(gc.ID)
+		cw.newHelpMsg("%s requested to join the GC. Type "+
+			"'/gc approvejoin %s %s' or '/gc denyjoin %s %s' to "+
+			"handle the request", srcNick, cw.alias, srcNick,
+			cw.alias, srcNick)
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnDeviceSyncedNtfn(func(dev client.PairedDevice, sync rpc.RMDeviceSync) {
+		as.diagMsg("Received sync from primary device %s: %d contacts, "+
+			"%d GCs, %d conversations", dev.ID, len(sync.Contacts),
+			len(sync.GCs), len(sync.History))
+	}))
+
+	ntfns.Register(client.OnDeviceSyncUpdateNtfn(func(dev client.PairedDevice, update rpc.RMDeviceSyncUpdate) {
+		switch {
+		case update.Contact != nil:
+			as.diagMsg("Primary device added contact %s",
+				strescape.Nick(update.Contact.Nick))
+			return
+		case update.GC != nil:
+			as.diagMsg("Primary device updated GC %s",
+				strescape.Nick(update.GC.Name))
+			return
+		case update.Convo == nil:
+			return
+		}
+
+		// Show messages synced from the other device in the open
+		// chat window of the conversation. Secondary devices do not
+		// have chat windows for the contacts of the primary, so show
+		// the messages as diagnostic messages.
+		var cw *chatWindow
+		if update.Convo.UID != nil {
+			cw = as.findChatWindow(*update.Convo.UID)
+		}
+		if cw == nil {
+			for _, m := range update.Convo.Messages {
+				as.diagMsg("Synced message from %s: %s",
+					strescape.Nick(m.From),
+					strescape.Content(m.Message))
+			}
+			return
+		}
+		for _, m := range update.Convo.Messages {
+			cw.newRecvdMsg(m.From, m.Message, nil, nil, nil,
+				time.Unix(m.Timestamp, 0))
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnDeviceHandoffNtfn(func(dev client.PairedDevice, handoff client.DeviceHandoff) {
+		as.diagMsg("Device %s handed off %d contacts and %d GCs. "+
+			"This is now the primary device", dev.ID,
+			len(handoff.Contacts), len(handoff.GCs))
+	}))
+
 	ntfns.Register(client.OnKXSearchCompleted(func(ru *client.RemoteUser) {
 		as.diagMsg("Completed KX search of %s", ru)
 		as.sendMsg(kxSearchCompleted{uid: ru.ID()})
 	}))
 
-	ntfns.Register(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+	ntfns.Register(client.OnTipAttemptDetailsNtfn(func(ru *client.RemoteUser, progress client.TipAttemptProgress) {
 		// Ignore non-final attempts (user can check logs).
-		if willRetry {
+		if progress.WillRetry {
 			return
 		}
 
-		amtStr := fmt.Sprintf("%.8f DCR", float64(amtMAtoms)/1e11)
+		amtStr := fmt.Sprintf("%.8f DCR", float64(progress.AmtMAtoms)/1e11)
 		nick := strescape.Nick(ru.Nick())
-		if completed {
-			as.diagMsg("Completed tip payment of %s to %s", amtStr, nick)
+		if progress.Completed {
+			as.diagMsg("Completed tip payment of %s to %s (fees: %.8f DCR)",
+				amtStr, nick, float64(progress.FeesMAtoms)/1e11)
+			return
+		}
+
+		var hint string
+		switch progress.FailureReason {
+		case client.TipFailureNoRoute:
+			hint = "No route found to the user's LN node. Check the " +
+				"channels of the local node with /ln"
+		case client.TipFailureInsufficientBalance:
+			hint = "Not enough outbound capacity. Open a new " +
+				"channel or send funds to an existing one"
+		case client.TipFailureInvoiceExpired:
+			hint = "The invoice expired before it could be paid. " +
+				"Send the tip again"
+		case client.TipFailureBudgetExceeded:
+			hint = "The tip exceeds a payment budget. Check " +
+				"the budgets with /budget"
+		}
+		as.diagMsg("Unable to complete tip payment of %s to %s "+
+			"after %d attempts: %v", amtStr, nick, progress.Attempt,
+			progress.Err)
+		if hint != "" {
+			as.diagMsg("%s", hint)
+		}
+	}))
+
+	// Only report the progress of split tips when a new part completes.
+	var tipPartsMtx sync.Mutex
+	type tipPartsKey struct {
+		uid clientintf.UserID
+		tag int32
+	}
+	tipParts := make(map[tipPartsKey]int)
+	ntfns.Register(client.OnTipPaymentProgressNtfn(func(ru *client.RemoteUser, tag int32, p clientintf.MultiPathProgress) {
+		key := tipPartsKey{uid: ru.ID(), tag: tag}
+		tipPartsMtx.Lock()
+		newPart := p.Parts > tipParts[key]
+		if p.PaidMAtoms >= p.AmountMAtoms {
+			delete(tipParts, key)
 		} else {
-			as.diagMsg("Unable to complete tip payment of %s to %s "+
-				"after %d attempts: %v",
-				amtStr, nick, attempt, attemptErr)
+			tipParts[key] = p.Parts
+		}
+		tipPartsMtx.Unlock()
+		if !newPart {
+			return
+		}
+
+		as.diagMsg("Tip payment to %s: paid %.8f of %.8f DCR in %d "+
+			"parts (%d in flight, %d failed)", strescape.Nick(ru.Nick()),
+			float64(p.PaidMAtoms)/1e11, float64(p.AmountMAtoms)/1e11,
+			p.Parts, p.InFlight, p.Failed)
+	}))
+
+	ntfns.Register(client.OnGCTipSplitProgressNtfn(func(split client.GCTipSplit) {
+		cw := as.findOrNewGCWindow(split.GC)
+		completed, failed, inProgress := split.Counts()
+		if split.Done() {
+			cw.newInternalMsg(fmt.Sprintf("Tip split of %s completed: "+
+				"%d tips of %s completed, %d failed", split.Total,
+				completed, split.Share, failed))
+		} else {
+			cw.newInternalMsg(fmt.Sprintf("Tip split of %s: %d "+
+				"completed, %d failed, %d in progress", split.Total,
+				completed, failed, inProgress))
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnRecurringTipFailedNtfn(func(rt client.RecurringTip, err error) {
+		nick, _ := as.c.UserNick(rt.UID)
+		if rt.Paused {
+			as.diagMsg("Recurring tip %s to %s paused after %d failures: %v",
+				rt.ID.ShortLogID(), strescape.Nick(nick), rt.Failures, err)
+		} else {
+			as.diagMsg("Unable to pay recurring tip %s to %s (retrying at %s): %v",
+				rt.ID.ShortLogID(), strescape.Nick(nick),
+				rt.NextPayment.Format(ISO8601DateTime), err)
 		}
 	}))
 
@@ -3052,6 +4106,22 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 				as.diagMsg("Days to Expire Data: %d", expDays)
 			}
 			as.diagMsg("Client ready!")
+
+			as.restoreMtx.Lock()
+			backup := as.restoredBackup
+			as.restoredBackup = nil
+			as.restoreMtx.Unlock()
+			if backup != nil {
+				go as.restoreSeedBackup(backup)
+			}
+
+			as.restoreMtx.Lock()
+			pairing := as.devicePairing
+			as.devicePairing = nil
+			as.restoreMtx.Unlock()
+			if pairing != nil {
+				go as.completeDevicePairing(pairing)
+			}
 		} else {
 			as.diagMsg("Connection to server closed")
 		}
@@ -3168,6 +4238,73 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		as.repaintIfActive(cw)
 	}))
 
+	ntfns.Register(client.OnOnchainTipUpdatedNtfn(func(user *client.RemoteUser, tip client.OnchainTip) {
+		cw := as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
+		amt := dcrutil.Amount(tip.Atoms)
+		var msg string
+		switch {
+		case tip.Error != "":
+			msg = fmt.Sprintf("On-chain tip of %s failed: %s", amt,
+				strescape.Content(tip.Error))
+		case tip.Outbound && tip.Confirmed != nil:
+			msg = fmt.Sprintf("On-chain tip of %s confirmed (tx %s)",
+				amt, tip.TxHash)
+		case tip.Outbound:
+			msg = fmt.Sprintf("Sent on-chain tip of %s to %s (tx %s)",
+				amt, strescape.Content(tip.Address), tip.TxHash)
+		default:
+			msg = fmt.Sprintf("Received on-chain tip of %s (tx %s)",
+				amt, tip.TxHash)
+		}
+		cw.newInternalMsg(msg)
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnEscrowUpdatedNtfn(func(user *client.RemoteUser, e client.Escrow) {
+		cw := as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
+		amt := dcrutil.Amount(e.MilliAtoms / 1000)
+		id := e.ID.ShortLogID()
+		var msg string
+		switch {
+		case e.Status == clientdb.EscrowStatusCanceled:
+			msg = fmt.Sprintf("Escrow %s of %s canceled: %s", id, amt,
+				strescape.Content(e.Error))
+		case e.Status == clientdb.EscrowStatusSettled:
+			msg = fmt.Sprintf("Escrow %s of %s settled", id, amt)
+		case e.Status == clientdb.EscrowStatusFulfilled && e.Buyer:
+			msg = fmt.Sprintf("Escrow %s of %s fulfilled by seller (%s). "+
+				"Use '/escrow release %s' to release the payment", id,
+				amt, strescape.Content(e.Note), id)
+		case e.Status == clientdb.EscrowStatusHeld && e.Buyer:
+			msg = fmt.Sprintf("Paying escrow %s of %s", id, amt)
+		case e.Status == clientdb.EscrowStatusHeld:
+			msg = fmt.Sprintf("Payment of escrow %s of %s is held. Use "+
+				"'/escrow fulfill %s' once the order is fulfilled", id,
+				amt, id)
+		case e.Status == clientdb.EscrowStatusInvoiced:
+			msg = fmt.Sprintf("Received escrow request %s of %s: %s", id,
+				amt, strescape.Content(e.Description))
+		default:
+			return
+		}
+		cw.newInternalMsg(msg)
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnPaymentReceiptNtfn(func(user *client.RemoteUser, pr client.PaymentReceipt) {
+		// Content payments are made per file chunk, so only show the
+		// receipts of tips in the chat window.
+		if pr.Receipt.Kind != rpc.PaymentReceiptKindTip {
+			return
+		}
+		cw := as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
+		cw.newInternalMsg(fmt.Sprintf("Received signed receipt for tip "+
+			"of %.8f DCR%s (payment hash %x)",
+			float64(pr.Receipt.MilliAtoms)/1e11,
+			fiatConversionStr(pr.Receipt.Fiat), pr.Receipt.PaymentHash))
+		as.repaintIfActive(cw)
+	}))
+
 	ntfns.Register(client.OnPostSubscriberUpdated(func(user *client.RemoteUser, subscribed bool) {
 		cw := as.findChatWindow(user.ID())
 		msg := fmt.Sprintf("%s subscribed to my posts", strescape.Nick(user.Nick()))
@@ -3187,6 +4324,14 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		cw := as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
 		cw.manyHelpMsgs(func(pf printf) {
 			pf("")
+			if len(postList.Pinned) > 0 {
+				pf("Pinned posts")
+				for _, post := range postList.Pinned {
+					pf("ID: %s", post.ID)
+					pf("Title: %s", strescape.Content(post.Title))
+					pf("")
+				}
+			}
 			pf("List of user posts (%d total)", len(postList.Posts))
 			for _, post := range postList.Posts {
 				pf("ID: %s", post.ID)
@@ -3235,7 +4380,22 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 				pf("Description: %q", meta.Description)
 				pf("Size       : %d", meta.Size)
 				pf("Cost       : %.8f DCR / %0.8f USD", dcrCost, usdCost)
+				switch {
+				case meta.Preview != nil && meta.Preview.FID != "":
+					pf("Preview    : file %s", meta.Preview.FID)
+				case meta.Preview != nil:
+					pf("Preview    : first %d bytes", meta.Preview.Size)
+				}
+				var tierStart uint64
+				for _, tier := range meta.Tiers {
+					pf("Tier       : %d-%d %.8f DCR %s", tierStart,
+						tier.End, float64(tier.Cost)/1e8, tier.Name)
+					tierStart = tier.End
+				}
 				pf("Hash       : %q", meta.Hash)
+				if meta.MerkleRoot != "" {
+					pf("Merkle root: %q", meta.MerkleRoot)
+				}
 				pf("Signature  : %q", meta.Signature)
 				pf("")
 			}
@@ -3243,6 +4403,31 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		as.repaintIfActive(cw)
 	}))
 
+	ntfns.Register(client.OnSharedFileAutoUnshared(func(sf clientdb.SharedFile, uid *clientintf.UserID, reason string) {
+		if uid == nil {
+			as.diagMsg("Unshared file %q globally: %s", sf.Filename, reason)
+			return
+		}
+		nick, _ := as.c.UserNick(*uid)
+		as.diagMsg("Unshared file %q with %s: %s", sf.Filename,
+			strescape.Nick(nick), reason)
+	}))
+
+	ntfns.Register(client.OnPostUnlocked(func(author *client.RemoteUser, unlock clientdb.PostUnlock) {
+		as.diagMsg("Unlocked post %s from %s", unlock.Post,
+			strescape.Nick(author.Nick()))
+		as.sendMsg(postUnlocked{pid: unlock.Post})
+	}))
+
+	ntfns.Register(client.OnScheduledPostPublished(func(draft clientdb.PostDraft, summ clientdb.PostSummary, err error) {
+		if err != nil {
+			as.diagMsg("Unable to publish scheduled post draft %s: %v",
+				draft.ID, err)
+			return
+		}
+		as.postDraftPublished(summ, nil)
+	}))
+
 	ntfns.Register(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
 		cw := as.findOrNewChatWindow(user.ID(), strescape.Nick(user.Nick()))
 		cw.newInternalMsg(fmt.Sprintf("Download completed: %s",
@@ -3303,6 +4488,64 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		})
 	}))
 
+	ntfns.Register(client.OnMsgContentFilteredNtfn(func(e client.MsgContentFilteredEvent) {
+		if e.Rule.Action != clientdb.ContentFilterActionFlag || e.PID != nil {
+			return
+		}
+		var cw *chatWindow
+		if e.GC != nil {
+			cw = as.findOrNewGCWindow(*e.GC)
+		} else if ru, err := as.c.UserByID(e.UID); err == nil {
+			cw = as.findOrNewChatWindow(ru.ID(), strescape.Nick(ru.Nick()))
+		}
+		if cw == nil {
+			return
+		}
+		cw.newHelpMsg("Received a message flagged by content filter rule %d", e.Rule.ID)
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnAutoReplySentNtfn(func(ru *client.RemoteUser, msg string) {
+		cw := as.findOrNewChatWindow(ru.ID(), strescape.Nick(ru.Nick()))
+		cw.newHelpMsg("Sent auto reply: %s", strescape.Content(msg))
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnIdentityMigrationReceivedNtfn(func(ru *client.RemoteUser, mig clientdb.IdentityMigration) {
+		nick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewChatWindow(ru.ID(), nick)
+		if mig.NewID == nil {
+			cw.newHelpMsg("%s revoked their identity (reason: %q). "+
+				"The user is now ignored", nick, mig.Reason)
+		} else if _, err := as.c.UserByID(*mig.NewID); err == nil {
+			cw.newHelpMsg("%s moved to identity %s (reason: %q), "+
+				"which is already a contact. Use '/identity "+
+				"applymigration %s' to apply the migration", nick,
+				mig.NewID, mig.Reason, ru.ID())
+		} else {
+			cw.newHelpMsg("%s moved to identity %s (reason: %q). "+
+				"Requesting KX with the new identity", nick, mig.NewID,
+				mig.Reason)
+		}
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnIdentityMigratedNtfn(func(oldID client.UserID, ru *client.RemoteUser) {
+		nick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewChatWindow(ru.ID(), nick)
+		cw.newHelpMsg("%s migrated from old identity %s", nick, oldID)
+		as.repaintIfActive(cw)
+	}))
+
+	ntfns.Register(client.OnVerifiedKeyChangedNtfn(func(ru *client.RemoteUser) {
+		nick := strescape.Nick(ru.Nick())
+		cw := as.findOrNewChatWindow(ru.ID(), nick)
+		cw.newHelpMsg("WARNING: the keys of verified user %s changed. "+
+			"Compare the safety number with /verify %s", nick, nick)
+		as.diagMsg("WARNING: the keys of verified user %s changed", nick)
+		as.repaintIfActive(cw)
+	}))
+
 	ntfns.Register(client.OnProfileUpdated(func(ru *client.RemoteUser,
 		ab *clientdb.AddressBookEntry, fields []client.ProfileUpdateField) {
 
@@ -3324,6 +4567,9 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		} else if updatedAvatar {
 			cw.newHelpMsg("User cleared its avatar")
 		}
+		if slices.Contains(fields, client.ProfileUpdateStatus) && ab.ID.Status != "" {
+			cw.newHelpMsg("Status: %s", strescape.Content(ab.ID.Status))
+		}
 		as.repaintIfActive(cw)
 	}))
 
@@ -3345,11 +4591,35 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		NoLoadChatHistory: args.NoLoadChatHistory,
 
 		SendReceiveReceipts: args.SendRecvReceipts,
+		LinkPreviews:        args.LinkPreviews,
+		LinkPreviewDialFunc: args.dialFunc,
+		PaymentWebhooks:     args.PaymentWebhooks,
+		WebhookDialFunc:     args.dialFunc,
 
 		AutoHandshakeInterval:         args.AutoHandshakeInterval,
+		AutoHandshakeTimeout:          args.AutoHandshakeTimeout,
 		AutoRemoveIdleUsersInterval:   args.AutoRemoveIdleUsersInterval,
 		AutoRemoveIdleUsersIgnoreList: args.AutoRemoveIdleUsersIgnore,
+		AutoUnsubIdleUsersRVsInterval: args.AutoUnsubIdleUsersRVs,
+		ContentIndexRefreshInterval:   args.ContentIndexRefresh,
+		BandwidthLimits:               args.BandwidthLimits,
+		PostMediaThumbnails:           args.PostMediaThumbnails,
+		PostMediaCost:                 args.PostMediaCost,
+		PostAutoUnlockMaxCost:         args.PostAutoUnlockMaxCost,
+		IgnorePostModeration:          args.IgnorePostModeration,
 		AutoSubscribeToPosts:          args.AutoSubPosts,
+		TipUserKeysendMaxMAtoms:       int64(args.KeysendMaxTipAmt) * 1e3,
+		TipUserOnchainMinMAtoms:       int64(args.OnchainTipMinAmt) * 1e3,
+		PaymentsMode:                  args.PaymentsMode,
+		TipFiatMaxSlippage:            args.TipFiatSlippage,
+
+		PaymentBudgetConfirmer: func(err client.PaymentBudgetExceededError) bool {
+			return as.confirmBudgetOverride(err)
+		},
+		DCRUSDRate: func() (float64, time.Time) {
+			dcrUSD, _ := as.rates.Get()
+			return dcrUSD, as.rates.LastUpdated()
+		},
 
 		CertConfirmer: func(ctx context.Context, cs *tls.ConnectionState,
 			svrID *zkidentity.PublicIdentity) error {
@@ -3375,7 +4645,35 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 			as.sendMsg(getClientID{})
 			select {
 			case reply := <-as.clientIDChan:
-				return zkidentity.New(reply.nick, reply.name)
+				if reply.seed == "" {
+					return zkidentity.New(reply.nick, reply.name)
+				}
+				if as.devicePairingPath != "" {
+					bundle, err := os.ReadFile(as.devicePairingPath)
+					if err != nil {
+						return nil, err
+					}
+					pairing, err := client.DecodeDevicePairing(reply.seed, bundle)
+					if err != nil {
+						return nil, err
+					}
+					as.restoreMtx.Lock()
+					as.devicePairing = pairing
+					as.restoreMtx.Unlock()
+					return pairing.LocalIDIniter()(ctx)
+				}
+				bundle, err := os.ReadFile(as.seedBackupPath)
+				if err != nil {
+					return nil, err
+				}
+				backup, err := client.DecodeSeedBackup(reply.seed, bundle)
+				if err != nil {
+					return nil, err
+				}
+				as.restoreMtx.Lock()
+				as.restoredBackup = backup
+				as.restoreMtx.Unlock()
+				return backup.LocalIDIniter()(ctx)
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			}
@@ -3582,6 +4880,19 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		}
 	}
 
+	desktopNtfns, err := newDesktopNotifier(desktopNotifierConfig{
+		method:      args.NtfnMethod,
+		pmRule:      args.NtfnPMRule,
+		gcRule:      args.NtfnGCRule,
+		showContent: args.NtfnShowContent,
+		stateFile:   filepath.Join(args.Root, "notifications.json"),
+		term:        os.Stdout,
+		log:         logBknd.logger("NTFN"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize client.
 	c, err := client.New(cfg)
 	if err != nil {
@@ -3634,6 +4945,53 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 				as.repaintIfActive(cw)
 				return nil
 			},
+			OnEditMessage: func(ctx context.Context, target zkidentity.ShortID, req *types.EditMessageRequest) error {
+				var cw *chatWindow
+				if req.Gc != "" {
+					cw = as.findOrNewGCWindow(target)
+				} else {
+					cw = as.findOrNewChatWindow(target, "")
+				}
+				cw.newInternalMsg("API: edited message: " + req.Msg)
+				as.repaintIfActive(cw)
+				return nil
+			},
+			OnReactToMessage: func(ctx context.Context, target zkidentity.ShortID, req *types.ReactToMessageRequest) error {
+				var cw *chatWindow
+				if req.Gc != "" {
+					cw = as.findOrNewGCWindow(target)
+				} else {
+					cw = as.findOrNewChatWindow(target, "")
+				}
+				action := "reacted"
+				if req.Remove {
+					action = "removed reaction"
+				}
+				cw.newInternalMsg(fmt.Sprintf("API: %s %s", action, req.Reaction))
+				as.repaintIfActive(cw)
+				return nil
+			},
+			OnMarkRead: func(ctx context.Context, rr *types.ReceivedReadReceipt) error {
+				var msgID *zkidentity.ShortID
+				if len(rr.MsgId) > 0 {
+					msgID = new(zkidentity.ShortID)
+					if err := msgID.FromBytes(rr.MsgId); err != nil {
+						return err
+					}
+				}
+				if len(rr.Gc) > 0 {
+					var gcID zkidentity.ShortID
+					if err := gcID.FromBytes(rr.Gc); err != nil {
+						return err
+					}
+					return as.markChatWindowRead(nil, &gcID, msgID)
+				}
+				var uid clientintf.UserID
+				if err := uid.FromBytes(rr.Uid); err != nil {
+					return err
+				}
+				return as.markChatWindowRead(&uid, nil, msgID)
+			},
 		}
 		err = rpcServer.InitChatService(chatRPCServerCfg)
 		if err != nil {
@@ -3691,6 +5049,16 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		if err != nil {
 			return nil, err
 		}
+
+		profileServerCfg := rpcserver.ProfileServerCfg{
+			Log:               logBknd.logger("RPCS"),
+			Client:            c,
+			RootReplayMsgLogs: filepath.Join(args.DBRoot, "replaymsglog"),
+		}
+		err = rpcServer.InitProfileService(profileServerCfg)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Bind the selected upstream resource provider.
@@ -3753,6 +5121,49 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		},
 	}
 
+	// Initialize the auto sharer of watched dirs.
+	var autoSharer *autoshare.Sharer
+	if len(args.AutoShareDirs) > 0 {
+		autoSharer, err = autoshare.New(autoshare.Config{
+			Client:      c,
+			Dirs:        args.AutoShareDirs,
+			DefaultCost: args.AutoShareDefaultCost,
+			CostPerMB:   args.AutoShareCostPerMB,
+			Exclude:     args.AutoShareExclude,
+			StateFile:   filepath.Join(args.Root, "autoshare.json"),
+			Log:         logBknd.logger("ASHR"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize auto share: %v", err)
+		}
+	}
+
+	// Initialize the post feeds.
+	var postFeeds *postfeeds.Feeds
+	if args.PostFeedsListen != "" || args.PostFeedsDir != "" {
+		postFeeds = postfeeds.New(postfeeds.Config{
+			Client:     c,
+			Listen:     args.PostFeedsListen,
+			OutDir:     args.PostFeedsDir,
+			MaxEntries: args.PostFeedsMaxEntries,
+			Log:        logBknd.logger("FEED"),
+		})
+	}
+
+	inviteTransports := map[string]client.InviteTransport{
+		"paste": &invitetransport.PasteTransport{
+			Endpoint: args.InvitePasteURL,
+			Client:   &httpClient,
+		},
+		"nostr": &invitetransport.NostrTransport{
+			Relays: args.InviteNostrRelays,
+			Dialer: &websocket.Dialer{
+				NetDialContext:   args.dialFunc,
+				HandshakeTimeout: 30 * time.Second,
+			},
+		},
+	}
+
 	r := rates.New(rates.Config{
 		HTTPClient: &httpClient,
 		Log:        logBknd.logger("RATE"),
@@ -3761,6 +5172,24 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 	})
 	go r.Run(ctx)
 
+	if rpcServer != nil {
+		adminServerCfg := rpcserver.AdminServerCfg{
+			Log:       logBknd.logger("RPCS"),
+			Client:    c,
+			PayClient: lnPC,
+			Rates:     r,
+		}
+		if args.RPCEnableExecCommands {
+			adminServerCfg.ExecCommand = func(ctx context.Context, cmd string) (string, error) {
+				return as.execRPCCommand(ctx, cmd)
+			}
+		}
+		err = rpcServer.InitAdminService(adminServerCfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	as = &appState{
 		ctx:         ctx,
@@ -3792,6 +5221,7 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		cmdHistoryFile: cmdHistoryFile,
 		cmdHistory:     cmdHistory,
 		cmdHistoryIdx:  len(cmdHistory),
+		vim:            newVimState(args.VimMode),
 
 		remoteFiles: make(map[clientintf.UserID]map[clientdb.FileID]clientdb.RemoteFile),
 		progressMsg: make(map[clientdb.FileID]*chatMsg),
@@ -3805,8 +5235,12 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		lnFundWalletChan:  make(chan msgLNFundWalletReply),
 
 		winpin:             args.WinPin,
+		seedBackupPath:     cleanAndExpandPath(args.RestoreSeedBackup),
+		devicePairingPath:  cleanAndExpandPath(args.PairDevice),
 		bellCmd:            bellCmd,
+		desktopNtfns:       desktopNtfns,
 		inviteFundsAccount: args.InviteFundsAccount,
+		inviteTransports:   inviteTransports,
 
 		collator: collate.New(language.Und),
 
@@ -3822,10 +5256,186 @@ func newAppState(sendMsg func(tea.Msg), lndLogLines *sloglinesbuffer.Buffer,
 		ssPayType:    args.SimpleStorePayType,
 		ssAcct:       args.SimpleStoreAccount,
 		ssShipCharge: args.SimpleStoreShipCharge,
+
+		autoSharer: autoSharer,
+		postFeeds:  postFeeds,
+	}
+	as.postImporter = postimport.New(postimport.Config{
+		Client:       c,
+		Interval:     args.PostImportInterval,
+		MaxItems:     args.PostImportMaxItems,
+		MaxMediaSize: args.PostImportMaxMediaSize,
+		FetchMedia:   args.PostImportFetchMedia,
+		HTTPClient:   &httpClient,
+		OnImported: func(item *postimport.Item, pid clientintf.PostID) {
+			as.cwHelpMsg("Imported post %q as %s",
+				strescape.Content(item.Title), pid)
+		},
+		Log: logBknd.logger("IMPT"),
+	})
+	if args.AutopilotEnable && lnRPC != nil {
+		candidates := args.AutopilotCandidates
+		if len(candidates) == 0 && args.Network == "mainnet" {
+			candidates = []string{hub0PubKey + "@" + hub0Server}
+		}
+		as.autopilot, err = embeddeddcrlnd.NewAutopilot(embeddeddcrlnd.AutopilotConfig{
+			LN:            lnRPC,
+			Candidates:    candidates,
+			MinOutbound:   args.AutopilotMinOutbound,
+			ChannelSize:   args.AutopilotChannelSize,
+			MaxChannels:   args.AutopilotMaxChannels,
+			WalletReserve: args.AutopilotWalletReserve,
+			MinUptime:     args.AutopilotMinUptime,
+			MinChannelAge: args.AutopilotMinChanAge,
+			FeeBudget:     args.AutopilotFeeBudget,
+			BudgetPeriod:  args.AutopilotBudgetPeriod,
+			Interval:      args.AutopilotInterval,
+			StateFile:     filepath.Join(args.Root, "autopilot.json"),
+			OnAction: func(a embeddeddcrlnd.AutopilotAction) {
+				verb := "closed"
+				if a.Open {
+					verb = "opened"
+				}
+				as.diagMsg("Autopilot %s channel %s with %s: %s",
+					verb, a.ChannelPoint, a.Peer, a.Reason)
+			},
+			Log: logBknd.logger("APLT"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize autopilot: %v", err)
+		}
+	}
+	if args.InboundEnable && lnRPC != nil {
+		server := args.InboundServer
+		var cert []byte
+		if args.InboundCertFile != "" {
+			cert, err = os.ReadFile(args.InboundCertFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read inbound LP cert: %v", err)
+			}
+		}
+		if server == "" && args.Network == "mainnet" {
+			server = lp0Server
+			cert = []byte(lp0Cert)
+		}
+		as.inbound, err = embeddeddcrlnd.NewInboundManager(embeddeddcrlnd.InboundConfig{
+			LN:           lnRPC,
+			Server:       server,
+			Key:          args.InboundKey,
+			Certificates: cert,
+			MinInbound:   args.InboundMinInbound,
+			ChannelSize:  args.InboundChannelSize,
+			FeeBudget:    args.InboundFeeBudget,
+			BudgetPeriod: args.InboundBudgetPeriod,
+			Interval:     args.InboundInterval,
+			StateFile:    filepath.Join(args.Root, "inbound.json"),
+			OnAction: func(a embeddeddcrlnd.InboundAction) {
+				as.diagMsg("Acquired inbound channel %s of %s for %s: %s",
+					a.ChannelPoint, a.Capacity, a.Fee, a.Reason)
+			},
+			OnError: func(err error) {
+				as.diagMsg("Unable to acquire inbound liquidity: %v", err)
+			},
+			Log: logBknd.logger("INBD"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize inbound liquidity "+
+				"manager: %v", err)
+		}
+	}
+	if args.SwapProvider != "" && lnRPC != nil {
+		swapsCfg := swaps.Config{
+			Provider: &swaps.HTTPProvider{
+				URL:        args.SwapProvider,
+				HTTPClient: &httpClient,
+			},
+			LN: lnRPC,
+			BTC: &swaps.EsploraChecker{
+				URL:        args.SwapEsplora,
+				HTTPClient: &httpClient,
+			},
+			BTCNet:     args.Network,
+			Rates:      r.Get,
+			MaxPremium: args.SwapMaxPremium,
+			MinConfs:   int32(args.SwapMinConfs),
+			StateFile:  filepath.Join(args.Root, "swaps.json"),
+			OnProgress: func(s swaps.Swap) {
+				if s.Err != "" {
+					as.diagMsg("Swap %s %s: %s (%s)", s.ID,
+						s.Direction, s.State, s.Err)
+					return
+				}
+				as.diagMsg("Swap %s %s: %s", s.ID, s.Direction, s.State)
+			},
+			Log: logBknd.logger("SWAP"),
+		}
+		if lnPC != nil {
+			swapsCfg.FeeLimit = func(amountMAtoms int64) *lnrpc.FeeLimit {
+				return lnPC.FeePolicy().FeeLimit(amountMAtoms)
+			}
+		}
+		as.swaps, err = swaps.NewManager(swapsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize swap manager: %v", err)
+		}
+	}
+	as.scbDir = args.SCBBackupDir
+	if args.SCBBackupEnable && lnRPC != nil {
+		dests := []embeddeddcrlnd.SCBDestination{
+			&embeddeddcrlnd.LocalSCBDestination{
+				Dir:  args.SCBBackupDir,
+				Keep: args.SCBBackupKeep,
+			},
+		}
+		var sftpArgs []string
+		if args.SCBBackupSFTPKey != "" {
+			sftpArgs = []string{"-i", args.SCBBackupSFTPKey}
+		}
+		for _, target := range args.SCBBackupSFTP {
+			dests = append(dests, &embeddeddcrlnd.SFTPSCBDestination{
+				Target: target,
+				Args:   sftpArgs,
+			})
+		}
+		for _, nick := range args.SCBBackupContacts {
+			nick := nick
+			dests = append(dests, &embeddeddcrlnd.FuncSCBDestination{
+				DestName: "contact:" + nick,
+				Store: func(_ context.Context, b *embeddeddcrlnd.SCBBackup) error {
+					return as.sendSCBToContact(nick, b)
+				},
+			})
+		}
+		as.scbBackups, err = embeddeddcrlnd.NewSCBBackups(embeddeddcrlnd.SCBBackupsConfig{
+			LN:            lnRPC,
+			Destinations:  dests,
+			Interval:      args.SCBBackupInterval,
+			SkipUnchanged: true,
+			StateFile:     filepath.Join(args.Root, "scbbackups.json"),
+			OnBackup: func(dest string, b *embeddeddcrlnd.SCBBackup, err error) {
+				if err != nil {
+					as.diagMsg("Unable to back up SCB to %s: %v", dest, err)
+				}
+			},
+			Log: logBknd.logger("SCBB"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize SCB backups: %v", err)
+		}
+	}
+	if lnPC != nil {
+		as.watchtowers = embeddeddcrlnd.NewWatchtowers(lnPC.LNWtClient())
+		// The watchtower client of external wallets is enabled in
+		// the config of the external dcrlnd node.
+		if args.WatchtowerClient || args.WalletType != "internal" {
+			as.startupTowers = args.Watchtowers
+		}
 	}
 	as.externalEditorForComments.Store(args.ExternalEditorForComments)
 	as.mimeMap.Store(&args.MimeMap)
 	as.styles.Store(theme)
+	as.themesDir = args.ThemesDir
+	as.keymap.Store(args.Keymap)
 
 	as.diagMsg("%s version %s", appName, version.String())
 
@@ -16,9 +16,24 @@ root = {{ .Root }}
 # 0=no compression, 9=best compression (slowest).
 # compresslevel = 4
 
-# Whether to send receive receipts for received posts and comments.
+# Whether to send receive receipts for received posts, comments and chat
+# messages.
 # sendrecvreceipts = 1
 
+# Whether to attach previews (title, description and image) of links in sent
+# messages. When enabled, linked pages are fetched (through the configured
+# proxy, if any) before sending the message, so that recipients see the preview
+# without making their own requests.
+# linkpreviews = 0
+
+# Out of band invite transports, used by /invite publish. Invites are encrypted
+# before being published and the decryption key is included only in the
+# generated URL. The paste endpoint receives the invite in a POST request and
+# must reply with the URL of the paste. Nostr relays are specified as a comma
+# delimited list of wss:// URLs.
+# invitepasteurl =
+# invitenostrrelays =
+
 # Proxy Configuration. Also needed for accessing the server as a TOR hidden
 # service.
 # proxyaddr =
@@ -61,6 +76,14 @@ root = {{ .Root }}
 # Set to zero to disable autohandshaking.
 # autohandshakeinterval = 21d
 
+# The interval to wait for a reply to an automatic handshake before the remote
+# client is considered idle and may be unsubscribed and removed from GCs. This
+# is also used to determine whether ratchets are probably broken.
+#
+# Set to zero to use the default (half of autohandshakeinterval when removing
+# idle users and 3 days when checking ratchets).
+# autohandshaketimeout = 0
+
 # The interval after which to automatically unsubscribe and remove idle users
 # from GCs. If no message has been received from a remote client after this
 # interval, the local client will automatically unsubscribe the remote user from
@@ -76,9 +99,50 @@ root = {{ .Root }}
 # ad716557157c1f191d8b5f8c6757ea41af49de27dc619fc87f337ca85be325ee - GC bot
 # autoremoveignorelist =
 
+# The interval after which to stop listening for messages from idle users. If
+# no message has been sent to or received from a remote client after this
+# interval, the local client unsubscribes from the server RV points where that
+# client would send messages, reducing server costs. The local client listens
+# again for messages once a message is sent to the remote client. Messages sent
+# by the remote client in the meantime are lost if they expire in the server
+# before that. Users in autoremoveignorelist are never unsubscribed.
+#
+# Set to zero to always listen for messages from all users.
+# autounsubidleusersrvsinterval = 0
+
+# The interval after which to automatically fetch again the list of files
+# shared by remote clients, to refresh the index used in /ft search.
+#
+# Set to zero to only update the index when listing files with /ft ls.
+# contentindexrefreshinterval = 0
+
+# Limits (in KB/s) of the bandwidth used for file transfers with all users
+# (bwupload, bwdownload) and with each user (bwpeerupload, bwpeerdownload).
+# Chat traffic is not limited. Set to zero for no limit. The limits may be
+# changed at runtime with /ft bandwidth.
+# bwupload = 0
+# bwdownload = 0
+# bwpeerupload = 0
+# bwpeerdownload = 0
+
 # Whether to automatically subscribe to posts of everyone you KX with.
 # autosubposts = 1
 
+# Whether to replace large images embedded in new posts with thumbnails. The
+# full (recompressed) images are shared as files that can be downloaded from
+# the post, for the given cost (in DCR).
+# postmediathumbnails = 0
+# postmediacost = 0
+
+# Max price (in DCR) of paywalled posts that are automatically unlocked (paid
+# for) when received from subscriptions. Set to 0 to only unlock posts
+# manually.
+# postautounlockmaxcost = 0
+
+# Whether to ignore the moderation actions (hiding and flagging comments) taken
+# by the authors of posts received from subscriptions.
+# ignorepostmoderation = 0
+
 # logging and debug
 [log]
 
@@ -105,12 +169,88 @@ savehistory = false
 # Valid attributes are: none, underline and bold
 # format is: attribute:foreground:background
 [theme]
-nickcolor = bold:na:na
-gcothercolor = bold:green:na
-pmothercolor = bold:cyan:na
+
+# Name of the theme. Either one of the built-in themes (dark, light and
+# high-contrast), the name of a theme file (without the .theme extension) in
+# the themes dir of the root dir or the path to a theme file. The theme may be
+# switched at runtime with '/theme'.
+#
+# Each line of a theme file defines the style of one element of the UI as
+# <name> = <attribute>:<foreground>:<background>. Attributes are a comma
+# separated list of bold, underline and reverse (or na). Colors are either
+# names (black, red, green, yellow, blue, magenta, cyan, white), 256-color
+# palette numbers, #rrggbb values or na. Elements not defined in a theme file
+# use the style of the dark theme. The elements are: header, footer,
+# footermention, edit, focused, blurred, cursor, help, cursormodehelp,
+# timestamp, timestamphelp, nick, nickme, nickgc, unreadpost, msg, unsent,
+# online, offline, checkingwallet, err, mention and embed.
+# name = dark
+
+# Colors of nicks that override the ones of the theme, in the same format as
+# the styles of theme files.
+# nickcolor = bold:na:na
+# gcothercolor = bold:green:na
+# pmothercolor = bold:cyan:na
+
 blinkcursor = true
 
 
+[notifications]
+
+# Method used to show notifications of received msgs. One of:
+#
+#   - none: do not show notifications
+#   - auto: notifications of the desktop if available, otherwise bell
+#   - notify-send: run notify-send (Linux)
+#   - dbus: call the notifications service of the session D-Bus through gdbus
+#     (Linux)
+#   - osascript: run osascript (macOS)
+#   - osc9: OSC 9 escape sequence (iTerm2, kitty, WezTerm, Windows Terminal)
+#   - osc777: OSC 777 escape sequence (urxvt, foot, VTE-based terminals)
+#   - bell: ring the terminal BEL
+#
+# The osc9 and osc777 methods also work through ssh. Inside tmux, the
+# allow-passthrough option of tmux must be enabled for them.
+# method = none
+
+# Which msgs generate notifications in PMs and GCs. One of all, mentions (msgs
+# that mention the local nick) or off. The rule of specific users and GCs may
+# be changed with '/notify set'.
+# pms = all
+# gcs = mentions
+
+# Whether to show the content of msgs in notifications. When false, only the
+# sender is shown.
+# showcontent = true
+
+
+[keys]
+
+# Key bindings, as <context>.<action> = <key>[,<key>...]. Use '/bind' to list
+# every action and its keys and to try out bindings before adding them here.
+#
+# Keys are single chars (case sensitive), space, enter, tab, shift+tab, esc,
+# backspace, delete, insert, up, down, left, right, home, end, pgup, pgdown,
+# f1-f20, ctrl+<letter> and ctrl with the arrows, home, end, pgup and pgdown.
+# Any of them may be prefixed with alt+. Use none to unbind an action.
+#
+# A key may only be bound to one action of each context and keys of global
+# actions may not be bound to any other action. The window switching keys,
+# for example, may be changed to not clash with terminal multiplexers:
+#
+# chat.nextwin = alt+right
+# chat.prevwin = alt+left
+# feed.nextwin = alt+right
+# feed.prevwin = alt+left
+# chat.closewin = alt+w
+# global.quit = ctrl+q
+
+# Enable vim-style modal editing in the main window. Esc switches the line
+# editor to the normal mode, where hjkl, / searches, registers and other vim
+# keys are available. See '/help vim' for the list of keys.
+# vimmode = false
+
+
 [payment]
 
 # Type of ln wallet to use. Either "internal" (for an embedded wallet),
@@ -174,6 +314,71 @@ minimumsendbalance = 0.01
 # users on-chain inside invites.
 # invitefundsaccount = non-default-account
 
+# Whether the internal LN wallet accepts spontaneous (keysend) payments. This
+# allows other users to send small tips without first requesting an invoice.
+# acceptkeysend = false
+
+# Max amount (in DCR) of tips that are first attempted as keysend payments to
+# users whose LN node is known (learned from previous invoices). If the keysend
+# payment fails, an invoice is requested as usual. Zero disables keysend tips.
+# keysendmaxtip = 0
+
+# Min amount (in DCR) of tips that are sent as on-chain transactions when all
+# attempts to pay them through LN fail. The address to pay is requested from
+# the tipped user. Zero disables the on-chain fallback.
+# onchaintipmin = 0
+
+# Max routing fees of payments, by payment size. This is a comma separated list
+# of tiers in the format <min amount>:<max fee>:<max fee percent>, with amounts
+# in DCR. The tier with the largest min amount that does not exceed a payment
+# applies to it. When both a max fee and a max fee percent are set, the lowest
+# limit applies. Payments smaller than every tier use the default limits.
+# Example: feepolicy = 0:0.0002:,0.01:0.001:1
+# feepolicy =
+
+# Max number of parts into which tips are split when they do not fit the
+# outbound capacity of any single channel. Each part is routed separately, so
+# large tips may be paid using several smaller channels. Values lower than 2
+# disable splitting tips.
+# maxpaymentparts = 16
+
+# Max change (in percent) of the DCR/USD exchange rate between starting a tip
+# denominated in USD (e.g. '/paytip bob 5usd') and paying its invoice. Tips
+# are not paid while the rate differs by more than this from the rate used to
+# convert them. Negative values disable the check.
+# tipfiatmaxslippage = 2
+
+# Restricts the payments made by the client. Valid values:
+#   - normal: no restrictions
+#   - receiveonly: payments to other users (tips, paid content, escrows,
+#     on-chain sends, channel opens and rebalances) are rejected, while
+#     payments are still received and server fees are still paid
+#   - disabled: every payment is rejected, including server fees. Only
+#     usable with servers that do not charge for messages
+# This is useful for auditing accounts and public kiosk deployments.
+# mode = normal
+
+# Whether the internal LN wallet backs up its channel states to watchtowers.
+# Towers watch the channels for breaches while the wallet is offline.
+# watchtowerclient = false
+
+# Comma separated list of watchtowers (pubkey@host:port) added to the LN wallet
+# on startup. Towers may also be managed with '/ln wtclient'. External wallets
+# must have the watchtower client enabled in the config of the dcrlnd node
+# (wtclient.active).
+# watchtowers =
+
+# Comma separated list of URLs notified of received tips, settled invoices
+# and content purchases. Events are sent as JSON-encoded POST requests
+# and failed deliveries are retried with an increasing delay. Requests carry
+# the Unix time of the attempt in the X-BR-Timestamp header. When webhooksecret
+# is set, the string <timestamp>.<body> is signed with HMAC-SHA256 using the
+# secret as key, with the hex-encoded signature sent in the X-BR-Signature
+# header as sha256=<signature>. Receivers should reject stale timestamps and
+# repeated X-BR-Delivery IDs.
+# webhooks =
+# webhooksecret =
+
 [clientrpc]
 # Enable the JSON-RPC clientrpc protocol on the comma-separated list of addresses.
 # jsonrpclisten = 127.0.0.1:7676
@@ -191,6 +396,12 @@ minimumsendbalance = 0.01
 # for generating the client CA, and cert files.
 # rpcissueclientcert = true
 
+# If set to true, allow clients connected over the clientrpc interface to
+# execute any of the commands available in the UI (e.g. "/gc kick") through
+# the AdminService.ExecCommand call. Only enable this if the clientrpc
+# interface is restricted to trusted clients.
+# enableexeccommands = false
+
 [resources]
 # Use an upstream processor for handling resource requests. Options:
 # "pages:<path>" offers static pages stored in the local <path>.
@@ -219,5 +430,174 @@ minimumsendbalance = 0.01
 # simplestoreshipcharge is a surcharge (in USD) added to simplestore orders to
 # cover shipping and handling.
 # shipcharge = 0.0
+
+[autoshare]
+# Directories whose files are automatically shared with everyone. Files added
+# to or changed in these directories are shared and files removed from them are
+# unshared. Files in subdirectories are not shared. Each entry may be followed
+# by the cost (in DCR) of the files shared from that directory, separated by a
+# comma.
+# dir = ~/shared/samples,0
+# dir = ~/shared/music
+
+# Cost (in DCR) of files shared from directories that do not specify a cost,
+# plus an additional cost (in DCR) per MB of the file.
+# defaultcost = 0
+# costpermb = 0
+
+# Comma delimited list of patterns of file names that are not shared.
+# exclude = .*,*~,*.tmp,*.part
+
+[postfeeds]
+# Render your posts (own.atom, own.rss) and all received posts (all.atom,
+# all.rss) as feeds that can be followed with standard feed readers.
+
+# Address of the HTTP server that serves the feeds (e.g. 127.0.0.1:8380). The
+# feeds are served without authentication, so this should be a loopback
+# address. If empty, the feeds are not served.
+# listen =
+
+# Directory where the feeds are written as files (refreshed every minute). If
+# empty, the feeds are not written.
+# dir =
+
+# Max number of posts in each feed.
+# maxentries = 50
+
+[postimport]
+# Options for importing posts from external blogs with the /post import
+# command.
+
+# Interval between imported posts, to avoid flooding subscribers with a burst
+# of posts.
+# interval = 10s
+
+# Max number of posts created in each import. Run the import again to import
+# the remaining posts. Zero means no limit.
+# maxitems = 0
+
+# Max size (in KB) of images embedded in imported posts. Larger images are
+# kept as links.
+# maxmediasize = 512
+
+# Whether to fetch and embed remote images referenced by imported posts. When
+# false, only images read from local markdown dirs are embedded.
+# fetchmedia = false
+
+[autopilot]
+# Options for the channel autopilot of the LN wallet. The autopilot opens
+# channels when the outbound capacity falls below a target and closes channels
+# with a low uptime, within a budget for on-chain fees.
+
+# Whether to run the autopilot. Cannot be enabled unless payment.mode is normal.
+# enable = false
+
+# Comma separated list of nodes (pubkey@host:port) to which channels may be
+# opened. The best connected nodes are preferred. On mainnet, defaults to the
+# Bison Relay hub.
+# candidates =
+
+# Outbound capacity (in DCR) below which a new channel is opened.
+# minoutbound = 0.5
+
+# Size (in DCR) of the opened channels.
+# channelsize = 1.0
+
+# Max number of channels (including pending ones).
+# maxchannels = 5
+
+# On-chain balance (in DCR) that is never committed to new channels.
+# walletreserve = 0.1
+
+# Channels older than minchannelage with a ratio of uptime to lifetime below
+# minuptime are closed. Zero disables closing channels.
+# minuptime = 0
+# minchannelage = 168h
+
+# Max on-chain fees (in DCR) spent to open and close channels in each budget
+# period.
+# feebudget = 0.001
+# budgetperiod = 24h
+
+# Interval between evaluations of the channels.
+# interval = 10m
+
+[inbound]
+# Options for the automatic acquisition of inbound liquidity. When the inbound
+# (receive) capacity of the LN wallet falls below a target, a new inbound
+# channel is requested from a liquidity provider, within a budget for the fees
+# paid to the provider.
+
+# Whether to request inbound channels automatically. Cannot be enabled unless
+# payment.mode is normal.
+# enable = false
+
+# URL and TLS certificate of the liquidity provider. On mainnet, defaults to
+# the Bison Relay liquidity provider. The key is an optional value sent to the
+# provider.
+# server =
+# certfile =
+# key =
+
+# Inbound capacity (in DCR) below which a new inbound channel is requested.
+# mininbound = 0.5
+
+# Size (in DCR) of the requested inbound channels.
+# channelsize = 1.0
+
+# Max fees (in DCR) paid to the liquidity provider in each budget period.
+# feebudget = 0.01
+# budgetperiod = 168h
+
+# Interval between checks of the inbound capacity.
+# interval = 10m
+
+[scbbackup]
+# Options for scheduled backups of the static channel backup (SCB) of the LN
+# wallet. The SCB is exported periodically and whenever the channels of the
+# wallet change, and is stored in the local dir and optionally uploaded to
+# remote destinations. A backup is only stored in a destination when the
+# channels changed since the last backup stored in it. It may be restored with '/ln scbbackup restore' to
+# recover the funds of the channels after losing the wallet data.
+
+# Whether to back up the SCB.
+# enable = false
+
+# Local dir where backups are stored and number of backups kept in it. Defaults
+# to the scbbackups dir in the root dir.
+# dir =
+# keep = 10
+
+# Interval between checks for changes in the channels.
+# interval = 24h
+
+# Comma separated list of remote dirs ([user@]host:[dir]) where backups are
+# uploaded with the sftp command. The sftpkey is the ssh key used to
+# authenticate to the remote hosts.
+# sftp =
+# sftpkey =
+
+# Comma separated list of contacts to which backups are sent as (end-to-end
+# encrypted) files.
+# contacts =
+
+[swap]
+# Options for atomic swaps between BTC and DCR, used to fund the LN wallet
+# with BTC and to cash out DCR from the LN wallet to BTC. Swaps are managed
+# with the '/swap' command.
+
+# URL of the swap provider. Swaps are disabled when empty.
+# provider =
+
+# URL of the Esplora API used to verify the BTC sent by the provider.
+# esplora = https://mempool.space/api
+
+# Max percentage by which the rate of a swap (including the fee of the
+# provider) may be worse than the market rate.
+# maxpremium = 5
+
+# Number of confirmations of the BTC sent by the provider before the swap is
+# completed.
+# minconfs = 2
 `
 )
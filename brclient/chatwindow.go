@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,8 +16,10 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/companyzero/bisonrelay/client"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/linkpreview"
 	"github.com/companyzero/bisonrelay/internal/mdembeds"
 	"github.com/companyzero/bisonrelay/internal/strescape"
 	"github.com/companyzero/bisonrelay/rpc"
@@ -413,16 +416,21 @@ func parseMsgIntoElements(msg string, mention string) []*chatMsgElLine {
 }
 
 type chatMsg struct {
-	ts       time.Time
-	sent     bool
-	msg      string
-	elements []*chatMsgElLine
-	mine     bool
-	internal bool
-	help     bool
-	from     string
-	fromUID  *clientintf.UserID
-	post     *rpc.PostMetadata
+	ts        time.Time
+	sent      bool
+	msg       string
+	elements  []*chatMsgElLine
+	mine      bool
+	internal  bool
+	help      bool
+	from      string
+	fromUID   *clientintf.UserID
+	post      *rpc.PostMetadata
+	msgID     *zkidentity.ShortID
+	edited    bool
+	reactions map[string][]clientintf.UserID
+	replyTo   *zkidentity.ShortID
+	delivered int
 }
 
 type chatWindow struct {
@@ -446,6 +454,32 @@ type chatWindow struct {
 	maxSelectable int
 
 	unreadIdx int
+
+	// draft is the unsent text typed in this window. draftTimer is used
+	// to delay persisting the draft until the user stops typing.
+	draft      string
+	draftTimer *time.Timer
+}
+
+// draftTarget returns the ID of the user or GC used to store the draft of the
+// window. Returns false for windows that do not have drafts.
+func (cw *chatWindow) draftTarget() (zkidentity.ShortID, bool) {
+	switch {
+	case cw.isPage:
+		return zkidentity.ShortID{}, false
+	case cw.isGC:
+		return cw.gc, true
+	default:
+		return cw.uid, true
+	}
+}
+
+// getDraft returns the unsent text of the window.
+func (cw *chatWindow) getDraft() string {
+	cw.Lock()
+	draft := cw.draft
+	cw.Unlock()
+	return draft
 }
 
 func (cw *chatWindow) empty() bool {
@@ -525,7 +559,8 @@ func (cw *chatWindow) newHelpMsg(f string, args ...interface{}) {
 	})
 }
 
-func (cw *chatWindow) newRecvdMsg(from, msg string, fromUID *zkidentity.ShortID, ts time.Time) *chatMsg {
+func (cw *chatWindow) newRecvdMsg(from, msg string, fromUID *zkidentity.ShortID,
+	msgID, replyTo *zkidentity.ShortID, ts time.Time) *chatMsg {
 
 	m := &chatMsg{
 		mine: false,
@@ -534,6 +569,8 @@ func (cw *chatWindow) newRecvdMsg(from, msg string, fromUID *zkidentity.ShortID,
 		ts:       ts,
 		from:     from,
 		fromUID:  fromUID,
+		msgID:    msgID,
+		replyTo:  replyTo,
 	}
 	cw.appendMsg(m)
 	return m
@@ -577,12 +614,140 @@ func (cw *chatWindow) setMsgSent(msg *chatMsg) {
 	cw.Unlock()
 }
 
+// setMsgID sets the ID of a message sent by the local client.
+func (cw *chatWindow) setMsgID(msg *chatMsg, msgID zkidentity.ShortID) {
+	cw.Lock()
+	msg.msgID = &msgID
+	cw.Unlock()
+}
+
+// lastSentMsgID returns the ID of the last message sent by the local client
+// in this window.
+func (cw *chatWindow) lastSentMsgID() (zkidentity.ShortID, bool) {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		if cw.msgs[i].mine && cw.msgs[i].msgID != nil {
+			return *cw.msgs[i].msgID, true
+		}
+	}
+	return zkidentity.ShortID{}, false
+}
+
+// editMsg replaces the contents of the message with the given ID, sent by the
+// specified user (or by the local client, if fromUID is nil). Returns true if
+// the message was found.
+func (cw *chatWindow) editMsg(fromUID *zkidentity.ShortID, msgID zkidentity.ShortID, msg string) bool {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if m.msgID == nil || *m.msgID != msgID {
+			continue
+		}
+		if fromUID == nil && !m.mine {
+			continue
+		}
+		if fromUID != nil && (m.fromUID == nil || *m.fromUID != *fromUID) {
+			continue
+		}
+		me := cw.me
+		if m.mine {
+			me = ""
+		}
+		m.elements = parseMsgIntoElements(msg, me)
+		m.edited = true
+		return true
+	}
+	return false
+}
+
+// lastRecvdMsgID returns the ID of the last message received from a remote
+// user in this window.
+func (cw *chatWindow) lastRecvdMsgID() (zkidentity.ShortID, bool) {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if !m.mine && !m.internal && !m.help && m.msgID != nil {
+			return *m.msgID, true
+		}
+	}
+	return zkidentity.ShortID{}, false
+}
+
+// replyTarget returns the nick of the sender of the message with the given
+// ID. Must be called with the window locked.
+func (cw *chatWindow) replyTarget(msgID zkidentity.ShortID) (string, bool) {
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if m.msgID == nil || *m.msgID != msgID {
+			continue
+		}
+		if m.mine {
+			return cw.me, true
+		}
+		return m.from, true
+	}
+	return "", false
+}
+
+// setMsgReactions updates the reactions of the message with the given ID.
+// Returns true if the message was found.
+func (cw *chatWindow) setMsgReactions(msgID zkidentity.ShortID, reactions map[string][]clientintf.UserID) bool {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if m.msgID == nil || *m.msgID != msgID {
+			continue
+		}
+		m.reactions = reactions
+		return true
+	}
+	return false
+}
+
+// setMsgDelivered marks that a remote user sent a receive receipt for the
+// message with the given ID. Returns true if the message was found.
+func (cw *chatWindow) setMsgDelivered(msgID zkidentity.ShortID) bool {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if !m.mine || m.msgID == nil || *m.msgID != msgID {
+			continue
+		}
+		m.delivered += 1
+		return true
+	}
+	return false
+}
+
 func (cw *chatWindow) markAllRead() {
 	cw.Lock()
 	cw.unreadIdx = len(cw.msgs)
 	cw.Unlock()
 }
 
+// markReadUpTo marks the messages of the window as read up to and including
+// the message with the given ID. Returns false if the message was not found.
+func (cw *chatWindow) markReadUpTo(msgID zkidentity.ShortID) bool {
+	cw.Lock()
+	defer cw.Unlock()
+	for i := len(cw.msgs) - 1; i >= 0; i-- {
+		m := cw.msgs[i]
+		if m.msgID == nil || *m.msgID != msgID {
+			continue
+		}
+		if i+1 > cw.unreadIdx {
+			cw.unreadIdx = i + 1
+		}
+		return true
+	}
+	return false
+}
+
 func (cw *chatWindow) unreadCount() int {
 	cw.Lock()
 	count := len(cw.msgs) - cw.unreadIdx
@@ -714,6 +879,21 @@ func (cw *chatWindow) renderMsgElements(winW int, as *appState, elements []*chat
 					s += "[Empty link and data]"
 				case args.Download.IsEmpty() && args.Typ == "":
 					s += "[Embedded untyped data]"
+				case args.Download.IsEmpty() && args.Typ == linkpreview.MimeType:
+					if p, err := linkpreview.Decode(args.Data); err != nil {
+						s += "[Invalid link preview]"
+					} else if p.Description != "" {
+						s += fmt.Sprintf("[Preview: %s - %s]",
+							strescape.Content(p.Title),
+							strescape.Content(p.Description))
+					} else {
+						s += fmt.Sprintf("[Preview: %s]",
+							strescape.Content(p.Title))
+					}
+				case args.Download.IsEmpty() && args.Typ == client.VoiceMsgMimeType:
+					dur := time.Duration(args.DurationMs) * time.Millisecond
+					s += fmt.Sprintf("[Voice message %d:%02d]",
+						int(dur.Minutes()), int(dur.Seconds())%60)
 				case args.Download.IsEmpty():
 					s += fmt.Sprintf("[Embedded data of type %q]", args.Typ)
 				default:
@@ -819,10 +999,39 @@ func (cw *chatWindow) renderMsg(winW int, styles *theme, b *strings.Builder, as
 		style = styles.unsent
 	}
 
+	if msg.mine && msg.delivered > 0 {
+		if cw.isGC {
+			prefix += styles.help.Render(fmt.Sprintf("(delivered %d) ", msg.delivered))
+		} else {
+			prefix += styles.help.Render("(delivered) ")
+		}
+	}
+	if msg.edited {
+		prefix += styles.help.Render("(edited) ")
+	}
+	if msg.replyTo != nil {
+		if nick, ok := cw.replyTarget(*msg.replyTo); ok {
+			prefix += styles.help.Render("(re: " + nick + ") ")
+		} else {
+			prefix += styles.help.Render("(reply) ")
+		}
+	}
+
 	b.WriteString(prefix)
 	offset := lipgloss.Width(prefix)
 
 	cw.renderMsgElements(winW, as, msg.elements, msg.fromUID, style, b, offset)
+
+	if len(msg.reactions) > 0 {
+		reactions := make([]string, 0, len(msg.reactions))
+		for r, uids := range msg.reactions {
+			reactions = append(reactions, fmt.Sprintf("%s %d", r, len(uids)))
+		}
+		sort.Strings(reactions)
+		b.WriteString(strings.Repeat(" ", offset))
+		b.WriteString(styles.help.Render("[" + strings.Join(reactions, "] [") + "]"))
+		b.WriteString("\n")
+	}
 }
 
 func (cw *chatWindow) renderPage(winW int, as *appState, b *strings.Builder) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -18,13 +19,21 @@ import (
 	"github.com/companyzero/bisonrelay/client"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/client/postsite"
+	"github.com/companyzero/bisonrelay/client/resources"
+	"github.com/companyzero/bisonrelay/embeddeddcrlnd"
+	"github.com/companyzero/bisonrelay/internal/invitetransport"
+	"github.com/companyzero/bisonrelay/internal/qrcode"
 	"github.com/companyzero/bisonrelay/internal/strescape"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/swaps"
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrlnd/lnrpc"
 	"github.com/decred/dcrlnd/lnrpc/walletrpc"
 	"github.com/decred/dcrlnd/lnwire"
 	"github.com/mitchellh/go-homedir"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
@@ -43,8 +52,12 @@ type tuicmd struct {
 	// operations.
 	usableOffline bool
 
-	handler    func(args []string, as *appState) error
-	rawHandler func(rawCmd string, args []string, as *appState) error
+	// spendsFunds tracks if the command makes payments to other users or
+	// otherwise spends funds from the wallet.
+	spendsFunds bool
+
+	handler    func(args []string, as *appState, out *cmdOutput) error
+	rawHandler func(rawCmd string, args []string, as *appState, out *cmdOutput) error
 	completer  func(prevArgs []string, arg string, as *appState) []string
 }
 
@@ -168,9 +181,31 @@ func gcCompleter(arg string, as *appState) []string {
 	return res
 }
 
+// gcChannelCompleter completes the names of the channels of the GC with the
+// given name.
+func gcChannelCompleter(gcName, arg string, as *appState) []string {
+	gcID, err := as.c.GCIDByName(gcName)
+	if err != nil {
+		return nil
+	}
+	channels, err := as.c.ListGCChannels(gcID)
+	if err != nil {
+		return nil
+	}
+	var res []string
+	arg = strings.TrimPrefix(arg, "#")
+	for _, ch := range channels {
+		if strings.HasPrefix(strings.ToLower(ch.Name), strings.ToLower(arg)) {
+			res = append(res, "#"+ch.Name)
+		}
+	}
+	as.collator.SortStrings(res)
+	return res
+}
+
 // subcmdNeededHandler is used on top-level commands that only work with a
 // subcommand.
-func subcmdNeededHandler(args []string, _ *appState) error {
+func subcmdNeededHandler(args []string, _ *appState, _ *cmdOutput) error {
 	if len(args) == 0 {
 		return usageError{msg: "subcommand not specified"}
 	}
@@ -179,7 +214,7 @@ func subcmdNeededHandler(args []string, _ *appState) error {
 
 // handleWithSubcmd returns the handler of the given list of subcommands with
 // the given name or panics.
-func handleWithSubcmd(subCmds []tuicmd, subCmdName string) func(args []string, as *appState) error {
+func handleWithSubcmd(subCmds []tuicmd, subCmdName string) func(args []string, as *appState, out *cmdOutput) error {
 	for _, sub := range subCmds {
 		if sub.cmd == subCmdName {
 			return sub.handler
@@ -193,9 +228,9 @@ var listCommands = []tuicmd{
 		cmd:           "exchangerate",
 		usableOffline: true,
 		descr:         "Display the current exchange rates",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			dcrPrice, btcPrice := as.rates.Get()
-			as.cwHelpMsg(fmt.Sprintf("DCR: %.2f\tBTC: %.2f\t (USD/coin)", dcrPrice, btcPrice))
+			out.msg(fmt.Sprintf("DCR: %.2f\tBTC: %.2f\t (USD/coin)", dcrPrice, btcPrice))
 			return nil
 		},
 	}, {
@@ -203,13 +238,13 @@ var listCommands = []tuicmd{
 		usableOffline: true,
 		aliases:       []string{"subs"},
 		descr:         "List people subscribed to the local client's posts",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			subs, err := as.c.ListPostSubscribers()
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				if len(subs) == 0 {
 					pf("No subscribers to our posts")
@@ -233,13 +268,13 @@ var listCommands = []tuicmd{
 		usableOffline: true,
 		aliases:       []string{"mysubs"},
 		descr:         "List remote users we are subscribed to",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			subs, err := as.c.ListPostSubscriptions()
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				if len(subs) == 0 {
 					pf("No post subscriptions")
@@ -262,13 +297,13 @@ var listCommands = []tuicmd{
 		cmd:           "kx",
 		usableOffline: true,
 		descr:         "List outstanding KX attempts",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			kxs, err := as.c.ListKXs()
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Active KX attempts")
 				for _, kx := range kxs {
@@ -299,13 +334,13 @@ var listCommands = []tuicmd{
 		cmd:           "kxsearches",
 		usableOffline: true,
 		descr:         "List IDs of clients we're searching for",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			ids, err := as.c.ListKXSearches()
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("KX Searches in progress")
 				for _, id := range ids {
@@ -319,7 +354,7 @@ var listCommands = []tuicmd{
 		usableOffline: true,
 		aliases:       []string{"mis"},
 		descr:         "List mediate id requests",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			mis, err := as.c.ListMediateIDs()
 			if err != nil {
 				return err
@@ -329,7 +364,7 @@ var listCommands = []tuicmd{
 				return mis[i].Date.Before(mis[j].Date)
 			})
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Active Mediate ID requests")
 				for _, mi := range mis {
@@ -347,12 +382,12 @@ var listCommands = []tuicmd{
 		cmd:           "sharedfiles",
 		usableOffline: true,
 		descr:         "List files locally shared in the ftp subsystem",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			files, err := as.c.ListLocalSharedFiles()
 			if err != nil {
 				return nil
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Shared files")
 				for _, f := range files {
@@ -368,6 +403,25 @@ var listCommands = []tuicmd{
 						nick, _ := as.c.UserNick(id)
 						pf("  %s - %q", id, nick)
 					}
+					for _, l := range f.Limits {
+						with := "global share"
+						if l.UID != nil {
+							nick, _ := as.c.UserNick(*l.UID)
+							with = fmt.Sprintf("share with %q", nick)
+						}
+						var parts []string
+						if !l.Limits.Expires.IsZero() {
+							parts = append(parts, fmt.Sprintf("expires %s",
+								l.Limits.Expires.Format(ISO8601DateTime)))
+						}
+						if l.Limits.MaxDownloads > 0 {
+							parts = append(parts, fmt.Sprintf("downloads %d/%d",
+								l.Downloads, l.Limits.MaxDownloads))
+						}
+						if len(parts) > 0 {
+							pf("Limits of %s: %s", with, strings.Join(parts, ", "))
+						}
+					}
 				}
 			})
 
@@ -377,12 +431,12 @@ var listCommands = []tuicmd{
 		cmd:           "downloads",
 		usableOffline: true,
 		descr:         "List in-progress downloads",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			fds, err := as.c.ListDownloads()
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Downloads")
 				for _, fd := range fds {
@@ -401,6 +455,15 @@ var listCommands = []tuicmd{
 					progress := float64(downChunks) / float64(totalChunks) * 100
 					pf("Filename: %q", fd.Metadata.Filename)
 					pf("Cost: %s", dcrutil.Amount(fd.Metadata.Cost))
+					for _, src := range fd.Sources {
+						srcNick, _ := as.c.UserNick(src.UID)
+						if srcNick == "" {
+							srcNick = src.UID.String()
+						}
+						pf("Additional source: %s (cost %s)",
+							strescape.Nick(srcNick),
+							dcrutil.Amount(src.Cost))
+					}
 					pf("Progress: %.2f (%d/%d)", progress,
 						downChunks, totalChunks)
 					pf("")
@@ -413,7 +476,7 @@ var listCommands = []tuicmd{
 		usableOffline: true,
 		usage:         "[<nick or user id>]",
 		descr:         "List payment stats globally or for a specific user",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) == 0 {
 				stats, err := as.c.ListPaymentStats()
 				if err != nil {
@@ -421,7 +484,7 @@ var listCommands = []tuicmd{
 				}
 
 				ids := client.SortedUserPayStatsIDs(stats)
-				as.cwHelpMsgs(func(pf printf) {
+				out.msgs(func(pf printf) {
 					pf("")
 					pf("Global Payment Statistics")
 					pf("        Sent           Recv (DCR)")
@@ -456,7 +519,7 @@ var listCommands = []tuicmd{
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Payment Stats for user %q (%s)", ru.Nick(), uid)
 				for _, s := range stats {
@@ -471,9 +534,9 @@ var listCommands = []tuicmd{
 		cmd:     "svrrates",
 		aliases: []string{"serverrates"},
 		descr:   "Show server fee rates",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			pushRate, subRate := as.serverPaymentRates()
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Server Fee Rates")
 				pf("Push Rate: %.8f DCR/kB", float64(pushRate)/1e8)
@@ -485,10 +548,10 @@ var listCommands = []tuicmd{
 		cmd:           "timestats",
 		usableOffline: true,
 		descr:         "Show timing stats for outbound messages",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if as.lnPC != nil {
 				stats := as.lnPC.PaymentTimingStats()
-				as.cwHelpMsgs(func(pf printf) {
+				out.msgs(func(pf printf) {
 					pf("")
 					pf("Payment Timing Stats:")
 					for _, v := range stats {
@@ -498,7 +561,7 @@ var listCommands = []tuicmd{
 			}
 
 			stats := as.c.RMQTimingStat()
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Outbound Message Timing Stats:")
 				for _, v := range stats {
@@ -512,12 +575,12 @@ var listCommands = []tuicmd{
 		cmd:           "userslastmsgtime",
 		descr:         "List the timestamp of the last message received for every user",
 		usableOffline: true,
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			users, err := as.c.ListUsersLastReceivedTime()
 			if err != nil {
 				return nil
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Last received message time from users (most recent first)")
 				for _, user := range users {
@@ -532,12 +595,12 @@ var listCommands = []tuicmd{
 		cmd:           "runningtips",
 		descr:         "List the currently running tip user attempts",
 		usableOffline: true,
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			attempts, err := as.c.ListRunningTipUserAttempts()
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				if len(attempts) == 0 {
 					pf("No running tip attempts")
 				}
@@ -562,7 +625,7 @@ var inviteCommands = []tuicmd{
 		completer: func(args []string, arg string, as *appState) []string {
 			return fileCompleter(arg)
 		},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "filename must be specified"}
 			}
@@ -587,7 +650,7 @@ var inviteCommands = []tuicmd{
 			}
 
 			if pii.Funds != nil && !ignoreFunds {
-				as.cwHelpMsgs(func(pf printf) {
+				out.msgs(func(pf printf) {
 					pf("")
 					pf("Invitation from peer includes funds")
 					pf("Nick: %q", pii.Public.Nick)
@@ -599,26 +662,26 @@ var inviteCommands = []tuicmd{
 				})
 				return nil
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Adding invitation to peer")
 				pf("Nick: %q", pii.Public.Nick)
 				pf("Name: %q", pii.Public.Name)
 				pf("ID: %s", pii.Public.Identity)
 			})
-			go func() {
+			out.goAsync(func() {
 				err := as.c.AcceptInvite(pii)
 				if err != nil {
-					as.cwHelpMsg("Unable to accept invite: %v", err)
+					out.msg("Unable to accept invite: %v", err)
 				}
-			}()
+			})
 			return nil
 		},
 	}, {
 		cmd:   "new",
 		usage: "<filename> [<gcname>]",
 		descr: "Create invitation file with optional GC to send OOB to another user",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "filename must be specified"}
 			}
@@ -640,7 +703,7 @@ var inviteCommands = []tuicmd{
 				}
 			}
 
-			go as.writeInvite(filename, gcID, nil)
+			go as.writeInvite(out, filename, gcID, nil)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -656,7 +719,7 @@ var inviteCommands = []tuicmd{
 		cmd:   "fetch",
 		usage: "<key> <filename>",
 		descr: "Fetches a prepaid invite from the server",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			// TODO: go online if needed and make usableOffline=true
 
 			if len(args) < 1 {
@@ -688,7 +751,7 @@ var inviteCommands = []tuicmd{
 				return err
 			}
 
-			as.diagMsg("Fetched invite stored in RV %s and saved "+
+			out.diagMsg("Fetched invite stored in RV %s and saved "+
 				"in file %s", key.RVPoint(), filename)
 			return nil
 		},
@@ -698,6 +761,67 @@ var inviteCommands = []tuicmd{
 			}
 			return nil
 		},
+	}, {
+		cmd:           "qr",
+		usableOffline: true,
+		usage:         "<key> [<png filename>]",
+		descr:         "Show the key of a prepaid invite as a QR code",
+		long: []string{
+			"Shows the QR code of the key of a prepaid invite, which may be scanned by mobile clients to fetch the invite. If a filename is specified, the QR code is also written as a PNG image to it.",
+			"The QR code is drawn for terminals with a dark background.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "key cannot be empty"}
+			}
+			key, err := clientintf.DecodePaidInviteKey(args[0])
+			if err != nil {
+				return err
+			}
+			encKey, err := key.EncodeQR()
+			if err != nil {
+				return err
+			}
+			code, err := qrcode.Encode(encKey, qrcode.LevelM)
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 1 {
+				filename, err := homedir.Expand(args[1])
+				if err != nil {
+					return err
+				}
+				f, err := os.Create(filename)
+				if err != nil {
+					return err
+				}
+				err = code.WritePNG(f, 8)
+				f.Close()
+				if err != nil {
+					return err
+				}
+			}
+
+			lines := strings.Split(strings.TrimSuffix(code.Terminal(2, false), "\n"), "\n")
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("QR code for prepaid invite key %s", encKey)
+				for _, l := range lines {
+					pf("%s", l)
+				}
+				if len(args) > 1 {
+					pf("QR code image written to %s", args[1])
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 1 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
 	}, {
 		cmd:   "funded",
 		usage: "<filename> <fund amount> [<gcname>]",
@@ -706,7 +830,7 @@ var inviteCommands = []tuicmd{
 			"The specified amount of DCR will be sent from the default wallet account to the configured invite funding account and the corresponding private key will be included in the created invitation.",
 			"The invite funding account may be specified in the config file and cannot be the default wallet account",
 		},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "filename must be specified"}
 			}
@@ -714,7 +838,7 @@ var inviteCommands = []tuicmd{
 				return usageError{msg: "amount must be specified"}
 			}
 			if as.inviteFundsAccount == "" || as.inviteFundsAccount == "default" {
-				as.manyDiagMsgsCb(func(pf printf) {
+				out.diagMsgs(func(pf printf) {
 					pf(as.styles.Load().err.Render("Cannot fund invite when funding account is set to the default wallet account"))
 					pf("Create a new account with '/ln newaccount <name>'")
 					pf("and set the 'invitefundsaccount = <name>' config option in brclient.conf")
@@ -747,14 +871,17 @@ var inviteCommands = []tuicmd{
 				}
 			}
 
+			if err := as.checkPaymentsAllowed(); err != nil {
+				return err
+			}
 			funds, err := as.lnPC.CreateInviteFunds(as.ctx, amount, as.inviteFundsAccount)
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsg("%s available for invitee after tx %s confirms",
+			out.msg("%s available for invitee after tx %s confirms",
 				amount, funds.Tx)
 
-			go as.writeInvite(filename, gcID, funds)
+			go as.writeInvite(out, filename, gcID, funds)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -766,11 +893,258 @@ var inviteCommands = []tuicmd{
 			}
 			return nil
 		},
+	}, {
+		cmd:   "batch",
+		usage: "<dir> <count> [<fund budget>] [<gcname>] [<label prefix>]",
+		descr: "Create a batch of prepaid invites",
+		long: []string{
+			"Creates <count> prepaid invites, writing each one to a file named after its label in <dir>. The labels and the keys to fetch the invites are also written to the keys.txt file in <dir>.",
+			"If a fund budget (in DCR) greater than zero is specified, it is split equally among the invites. The funds are sent from the default wallet account to the configured invite funding account.",
+			"If a GC is specified, each invitee is invited to it after KX.",
+			"Labels are <label prefix>-NNN. The default label prefix is 'invite'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "dir must be specified"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "count must be specified"}
+			}
+			dir, err := homedir.Expand(args[0])
+			if err != nil {
+				return err
+			}
+			count, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil || count == 0 {
+				return usageError{msg: "count must be a positive number"}
+			}
+
+			var budget dcrutil.Amount
+			if len(args) > 2 {
+				dcrAmount, err := strconv.ParseFloat(args[2], 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("budget not a valid DCR amount: %v", err)}
+				}
+				budget, err = dcrutil.NewAmount(dcrAmount)
+				if err != nil {
+					return err
+				}
+			}
+			if budget > 0 && (as.inviteFundsAccount == "" || as.inviteFundsAccount == "default") {
+				return fmt.Errorf("cannot fund invites when funding " +
+					"account is set to the default wallet account")
+			}
+
+			var gcID *zkidentity.ShortID
+			if len(args) > 3 && args[3] != "" {
+				id, err := as.c.GCIDByName(args[3])
+				if err != nil {
+					return err
+				}
+				gcID = &id
+			}
+
+			prefix := "invite"
+			if len(args) > 4 && args[4] != "" {
+				prefix = args[4]
+			}
+			labels := make([]string, count)
+			for i := range labels {
+				labels[i] = fmt.Sprintf("%s-%03d", prefix, i+1)
+			}
+
+			go as.writeInviteBatch(out, dir, labels, budget, gcID)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			if len(args) == 3 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "publish",
+		usage: "<paste|nostr> [<fund amount>] [<gcname>]",
+		descr: "Create an invitation and publish it out of band",
+		long: []string{
+			"Creates an invitation (optionally with funds) and publishes it using the paste endpoint or nostr relays specified in the config file. The invitation is encrypted before being published and the generated URL includes the decryption key, so it must only be sent to the invitee.",
+			"The invitee accepts the invitation with '/invite fetchurl <url> <filename>'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "transport must be specified"}
+			}
+			t, ok := as.inviteTransports[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown invite transport %q", args[0])
+			}
+
+			var amount dcrutil.Amount
+			if len(args) > 1 {
+				dcrAmount, err := strconv.ParseFloat(args[1], 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("amount not a valid DCR amount: %v", err)}
+				}
+				amount, err = dcrutil.NewAmount(dcrAmount)
+				if err != nil {
+					return err
+				}
+			}
+			if amount > 0 && (as.inviteFundsAccount == "" || as.inviteFundsAccount == "default") {
+				return fmt.Errorf("cannot fund invite when funding " +
+					"account is set to the default wallet account")
+			}
+
+			var gcID zkidentity.ShortID
+			if len(args) > 2 {
+				var err error
+				gcID, err = as.c.GCIDByName(args[2])
+				if err != nil {
+					return err
+				}
+			}
+
+			var funds *rpc.InviteFunds
+			if amount > 0 {
+				if err := as.checkPaymentsAllowed(); err != nil {
+					return err
+				}
+				var err error
+				funds, err = as.lnPC.CreateInviteFunds(as.ctx, amount, as.inviteFundsAccount)
+				if err != nil {
+					return err
+				}
+				out.msg("%s available for invitee after tx %s confirms",
+					amount, funds.Tx)
+			}
+
+			out.goAsync(func() {
+				ctx, cancel := context.WithTimeout(as.ctx, time.Minute)
+				defer cancel()
+				u, pii, err := as.c.PublishInvite(ctx, t, funds)
+				if err != nil {
+					out.msg("Unable to publish invite: %v", err)
+					return
+				}
+				if !gcID.IsEmpty() {
+					err = as.c.AddInviteOnKX(pii.InitialRendezvous, gcID)
+					if err != nil {
+						out.msg("Unable to add KX action: %v", err)
+						return
+					}
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					pf("Listening for invite request at RV %s", pii.InitialRendezvous)
+					pf("Send the following URL to the invitee:")
+					pf("%s", as.styles.Load().nick.Render(u))
+					pf("Generate an email link with /invite mailto <address> %s", u)
+					if !gcID.IsEmpty() {
+						pf("Will invite to GC %s after KX", gcID)
+					}
+				})
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return wordCompleter(maps.Keys(as.inviteTransports), arg)
+			case 2:
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "fetchurl",
+		usage: "<url> <filename> [ignorefunds]",
+		descr: "Fetch and accept an invitation published out of band",
+		long: []string{
+			"Fetches the invitation from the URL generated by '/invite publish', saving it in the given file.",
+			"If the invitation does not include funds (or ignorefunds is specified), it is accepted. Otherwise, the funds may be redeemed with '/invite redeem <filename>' before accepting it.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "url and filename must be specified"}
+			}
+			ignoreFunds := len(args) > 2 && args[2] == "ignorefunds"
+			filename, err := homedir.Expand(args[1])
+			if err != nil {
+				return err
+			}
+
+			transports := maps.Values(as.inviteTransports)
+
+			out.goAsync(func() {
+				ctx, cancel := context.WithTimeout(as.ctx, time.Minute)
+				defer cancel()
+				var b bytes.Buffer
+				pii, err := as.c.FetchInviteURL(ctx, transports, args[0], &b)
+				if err != nil {
+					out.msg("Unable to fetch invite: %v", err)
+					return
+				}
+				if err := os.WriteFile(filename, b.Bytes(), 0o600); err != nil {
+					out.msg("Unable to write invite file: %v", err)
+					return
+				}
+
+				if pii.Funds != nil && !ignoreFunds {
+					out.msgs(func(pf printf) {
+						pf("")
+						pf("Invitation from peer includes funds")
+						pf("Nick: %q", pii.Public.Nick)
+						pf("UTXO: %s:%d", pii.Funds.Tx, pii.Funds.Index)
+						pf("Type '/invite accept %s ignorefunds' to add the invite anyway",
+							args[1])
+						pf("or '/invite redeem %s' to redeem the funds on-chain",
+							args[1])
+					})
+					return
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					pf("Adding invitation to peer fetched from URL")
+					pf("Nick: %q", pii.Public.Nick)
+					pf("Name: %q", pii.Public.Name)
+					pf("ID: %s", pii.Public.Identity)
+				})
+				if err := as.c.AcceptInvite(pii); err != nil {
+					out.msg("Unable to accept invite: %v", err)
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 1 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "mailto",
+		usableOffline: true,
+		usage:         "<address> <url>",
+		descr:         "Generate an email link to send an invitation URL",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "address and url must be specified"}
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Open the following link to send the invite by email:")
+				pf("%s", invitetransport.MailtoURL(args[0], args[1]))
+			})
+			return nil
+		},
 	}, {
 		cmd:   "redeem",
 		usage: "<filename>",
 		descr: "Redeem funds included in an invitation",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "filename must be specified"}
 			}
@@ -793,7 +1167,7 @@ var inviteCommands = []tuicmd{
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Redeemed %s as invite funds in tx %s", total, tx)
 				pf("The invite can be accepted by issuing the command")
@@ -813,21 +1187,21 @@ var gcCommands = []tuicmd{
 		usableOffline: true,
 		usage:         "<gc name>",
 		descr:         "Create a new group chat named <gc name>",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
 			if _, err := as.c.NewGroupChat(args[0]); err != nil {
 				return err
 			}
-			as.cwHelpMsg("GC %q created", args[0])
+			out.msg("GC %q created", args[0])
 			return nil
 		},
 	}, {
 		cmd:   "invite",
 		usage: "<gc name> <nick>",
 		descr: "invite the user with the given nick to join the given gc",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{"gc name cannot be empty"}
 			}
@@ -867,7 +1241,7 @@ var gcCommands = []tuicmd{
 		aliases: []string{"m"},
 		usage:   "<gc name> <message>",
 		descr:   "send a message to the given GC",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{"gc name cannot be empty"}
 			}
@@ -899,13 +1273,23 @@ var gcCommands = []tuicmd{
 	},
 	{
 		cmd:   "join",
-		usage: "<gc name>",
+		usage: "<gc name or invite link>",
 		descr: "Join the given GC we were invited to",
-		handler: func(args []string, as *appState) error {
+		long:  []string{"Invite links (created with '/gc invitelink') may only be used when the local client is KX'd with the creator of the link."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{"invitation id cannot be empty"}
 			}
 
+			if link, err := client.ParseGCInviteLink(args[0]); err == nil {
+				if err := as.c.RedeemGCInviteLink(link); err != nil {
+					return err
+				}
+				out.diagMsg("Requested to join gc %q using invite link",
+					strescape.Nick(link.Name))
+				return nil
+			}
+
 			gcName := args[0]
 			as.gcInvitesMtx.Lock()
 			iid, ok := as.gcInvites[gcName]
@@ -930,15 +1314,15 @@ var gcCommands = []tuicmd{
 				}
 			}
 
-			go func() {
+			out.goAsync(func() {
 				err := as.c.AcceptGroupChatInvite(iid)
 				if err != nil {
-					as.diagMsg("Unable to join gc %q: %v",
+					out.diagMsg("Unable to join gc %q: %v",
 						gcName, err)
 				} else {
-					as.diagMsg("Accepting invitation to join gc %q", gcName)
+					out.diagMsg("Accepting invitation to join gc %q", gcName)
 				}
-			}()
+			})
 			return nil
 		},
 	}, {
@@ -947,7 +1331,7 @@ var gcCommands = []tuicmd{
 		usage:         "[<gc name>]",
 		aliases:       []string{"l"},
 		descr:         "List the GCs we're a member of or members of a GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) == 0 {
 				gcs, err := as.c.ListGCs()
 				if err != nil {
@@ -976,16 +1360,20 @@ var gcCommands = []tuicmd{
 					return as.collator.CompareString(ni, nj) < 0
 				})
 
-				as.cwHelpMsgs(func(pf printf) {
+				out.msgs(func(pf printf) {
 					pf("")
 					pf("List of GCs:")
 					for _, gc := range gcs {
 						gcAlias := gcNames[gc.ID]
-						pf("%*s - %s - %d members",
+						line := fmt.Sprintf("%*s - %s - %d members",
 							maxNameLen,
 							gcAlias,
 							gc.ID,
 							len(gc.Members))
+						if gc.Topic != "" {
+							line += " - " + strescape.Content(gc.Topic)
+						}
+						pf("%s", line)
 					}
 				})
 
@@ -1050,25 +1438,61 @@ var gcCommands = []tuicmd{
 
 			maxNickW = clamp(maxNickW, 5, as.winW-64-10)
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("GC %q - %s", gcName, gc.ID.String())
 				pf("Version: %d, Generation: %d, Timestamp: %s",
 					gc.Version, gc.Generation,
 					time.Unix(gc.Timestamp, 0).Format(ISO8601DateTime))
-				if gc.Members[0] == myID {
-					pf("Local client is owner of this GC")
-				} else if slices.Contains(gc.ExtraAdmins, myID) {
-					pf("Local client is admin of this GC")
+				if role := client.GCMemberRole(&gc, myID); role != client.GCRoleMember {
+					pf("Local client is %s of this GC", role)
+				}
+				if gc.Version >= 2 {
+					pf("Admin permissions: %s", gc.AdminPerms)
+					pf("Moderator permissions: %s", gc.ModeratorPerms)
+					if len(gc.Pinned) > 0 {
+						pf("Pinned messages: %d", len(gc.Pinned))
+					}
+					if len(gc.Banned) > 0 {
+						pf("Banned users: %d", len(gc.Banned))
+					}
+					if gc.Topic != "" {
+						pf("Topic: %s", strescape.Content(gc.Topic))
+					}
+					if len(gc.Avatar) > 0 {
+						pf("Avatar: %s (%d bytes)",
+							imageMimeType(gc.Avatar), len(gc.Avatar))
+					}
+					if gc.Description != "" {
+						pf("Description:")
+						for _, line := range strings.Split(gc.Description, "\n") {
+							pf("  %s", strescape.Content(line))
+						}
+					}
+					if gc.SlowModeInterval > 0 {
+						pf("Slow mode: %s between messages",
+							time.Duration(gc.SlowModeInterval)*time.Second)
+					}
+					if gc.MaxMembers > 0 {
+						pf("Max members: %d", gc.MaxMembers)
+					}
+					if gc.JoinApproval {
+						pf("Joins through invite links require approval")
+					}
+					if len(gc.Channels) > 0 {
+						names := make([]string, len(gc.Channels))
+						for i, ch := range gc.Channels {
+							names[i] = "#" + strescape.Nick(ch.Name)
+						}
+						pf("Channels: %s", strings.Join(names, " "))
+					}
 				}
 				pf("Members (%d + local client)", len(members))
 				firstUknown := true
 				for _, uid := range members {
 					var ignored string
-					if uid == gc.Members[0] {
-						ignored += " (owner)"
-					} else if slices.Contains(gc.ExtraAdmins, uid) {
-						ignored += " (admin)"
+					if role := client.GCMemberRole(&gc, uid); role != client.GCRoleMember {
+						ignored += fmt.Sprintf(" (%s)", role)
 					}
 					if gcbl.IsBlocked(uid) {
 						ignored += " (in GC blocklist)"
@@ -1106,7 +1530,7 @@ var gcCommands = []tuicmd{
 		cmd:   "kick",
 		usage: "<gc> <nick> [<reason>]",
 		descr: "Kick the given user from the specified GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
@@ -1143,7 +1567,7 @@ var gcCommands = []tuicmd{
 			}
 			gcWin := as.findOrNewGCWindow(gcID)
 
-			go as.kickFromGC(gcWin, uid, nick, reason)
+			go as.kickFromGC(out, gcWin, uid, nick, reason)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -1159,7 +1583,7 @@ var gcCommands = []tuicmd{
 		cmd:   "part",
 		usage: "<gc> [<reason>]",
 		descr: "Exit from the specified GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
@@ -1175,7 +1599,7 @@ var gcCommands = []tuicmd{
 				return err
 			}
 			gcWin := as.findOrNewGCWindow(gcID)
-			go as.partFromGC(gcWin, reason)
+			go as.partFromGC(out, gcWin, reason)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -1188,7 +1612,7 @@ var gcCommands = []tuicmd{
 		cmd:   "kill",
 		usage: "<gc> [<reason>]",
 		descr: "Dissolve the specified GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
@@ -1204,7 +1628,7 @@ var gcCommands = []tuicmd{
 				return err
 			}
 			gcWin := as.findOrNewGCWindow(gcID)
-			go as.killGC(gcWin, reason)
+			go as.killGC(out, gcWin, reason)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -1221,7 +1645,7 @@ var gcCommands = []tuicmd{
 		long: []string{
 			"This also stops sending messages to the specified user in this GC",
 		},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
@@ -1260,7 +1684,7 @@ var gcCommands = []tuicmd{
 		usableOffline: true,
 		usage:         "<gc> <user>",
 		descr:         "Un-ignore a user's messages in this specific GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "gc name cannot be empty"}
 			}
@@ -1299,7 +1723,7 @@ var gcCommands = []tuicmd{
 		usableOffline: true,
 		usage:         "<existing gc> <new alias>",
 		descr:         "Modify the local alias of a GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "existing gc name cannot be empty"}
 			}
@@ -1332,7 +1756,7 @@ var gcCommands = []tuicmd{
 		cmd:   "resendlist",
 		usage: "<gc> [<user>]",
 		descr: "Resends the GC definition to the specified user or all users",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "GC cannot be empty"}
 			}
@@ -1351,7 +1775,7 @@ var gcCommands = []tuicmd{
 				uid = &user
 			}
 
-			go func() {
+			out.goAsync(func() {
 				gcWin := as.findOrNewGCWindow(gcID)
 				var msg *chatMsg
 				if uid == nil {
@@ -1368,7 +1792,7 @@ var gcCommands = []tuicmd{
 					gcWin.setMsgSent(msg)
 				}
 				as.repaintIfActive(gcWin)
-			}()
+			})
 
 			return nil
 		},
@@ -1386,7 +1810,7 @@ var gcCommands = []tuicmd{
 		cmd:   "upgrade",
 		usage: "<gc>",
 		descr: "Upgrades the GC to the next available version",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "GC cannot be empty"}
 			}
@@ -1420,7 +1844,7 @@ var gcCommands = []tuicmd{
 		cmd:   "addadmin",
 		usage: "<gc> <new admin>",
 		descr: "Add a user as an admin of a GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "GC cannot be empty"}
 			}
@@ -1454,7 +1878,7 @@ var gcCommands = []tuicmd{
 		cmd:   "deladmin",
 		usage: "<gc> <existing admin>",
 		descr: "Removes a user as an admin of a GC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "GC cannot be empty"}
 			}
@@ -1485,43 +1909,66 @@ var gcCommands = []tuicmd{
 			return nil
 		},
 	}, {
-		cmd:   "modowner",
-		usage: "<gc> <new owner>",
-		descr: "Change the owner of the given GC",
-		handler: func(args []string, as *appState) error {
+		cmd:   "addmod",
+		usage: "<gc> <new moderator>",
+		descr: "Add a user as a moderator of a GC",
+		long: []string{"Moderators may perform the actions allowed by the GC's moderator permissions (see '/gc perms').",
+			"Only GCs with version 2 or higher support moderators."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "GC cannot be empty"}
 			}
 			if len(args) < 2 {
-				return usageError{msg: "New admin cannot be empty"}
+				return usageError{msg: "New moderator cannot be empty"}
 			}
 
 			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			gcAlias, err := as.c.GetGCAlias(gcID)
+
+			uid, err := as.c.UIDByNick(args[1])
 			if err != nil {
 				return err
 			}
 
-			ru, err := as.c.UserByNick(args[1])
+			return as.modifyGCModerators(gcID, uid, clientintf.UserID{})
+		},
+
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "delmod",
+		usage: "<gc> <existing moderator>",
+		descr: "Removes a user as a moderator of a GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "GC cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "Moderator cannot be empty"}
+			}
+
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
 
-			reason := "Changing owner"
-			err = as.c.ModifyGCOwner(gcID, ru.ID(), reason)
+			uid, err := as.c.UIDByNick(args[1])
 			if err != nil {
 				return err
 			}
 
-			cw := as.findOrNewGCWindow(gcID)
-			cw.newHelpMsg("Changed owner of GC %s to %s",
-				strescape.Nick(gcAlias), strescape.Nick(ru.Nick()))
-			as.repaintIfActive(cw)
-			return nil
+			return as.modifyGCModerators(gcID, clientintf.UserID{}, uid)
 		},
+
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
 				return gcCompleter(arg, as)
@@ -1531,774 +1978,3353 @@ var gcCommands = []tuicmd{
 			}
 			return nil
 		},
-	},
-}
-
-var ftCommands = []tuicmd{
-	{
-		cmd:           "share",
-		usableOffline: true,
-		usage:         "<filename> <cost> [<nick>]",
-		descr:         "Share the given file for distribution",
-		long: []string{
-			"Imports the passed file into the local FTP repository. The cost is specified in DCR.",
-			"If a nick or user ID is specified, the file is shared only to that user.",
-			"By default, the passed cost is *added* to the estimated upload cost. Use \"=<amount>\" to directly specify the full cost",
-		},
-		completer: func(args []string, arg string, as *appState) []string {
-			if len(args) == 0 {
-				return fileCompleter(arg)
-			}
-			if len(args) == 2 {
-				return nickCompleter(arg, as)
-			}
-			return nil
-		},
-		handler: func(args []string, as *appState) error {
+	}, {
+		cmd:   "perms",
+		usage: "<gc> <admin|moderator> <perms>",
+		descr: "Sets the permissions of the admins or moderators of a GC",
+		long: []string{"Permissions are a comma separated list of: invite, kick, pin, metadata. Use 'all' or 'none' to set all or no permissions.",
+			"Only the owner may change the permissions of admins."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "filename cannot be empty"}
+				return usageError{msg: "GC cannot be empty"}
 			}
 			if len(args) < 2 {
-				return usageError{msg: "cost cannot be empty"}
+				return usageError{msg: "Role cannot be empty"}
 			}
-			if len(args[1]) < 1 {
-				return usageError{msg: "cost cannot be the empty string"}
+			if len(args) < 3 {
+				return usageError{msg: "Permissions cannot be empty"}
 			}
 
-			filename, err := homedir.Expand(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			stat, err := os.Stat(filename)
-			if err != nil {
-				return err
+
+			var role client.GCRole
+			switch args[1] {
+			case "admin", "admins":
+				role = client.GCRoleAdmin
+			case "moderator", "moderators", "mod", "mods":
+				role = client.GCRoleModerator
+			default:
+				return usageError{msg: fmt.Sprintf("Unknown role %q", args[1])}
 			}
 
-			var dcrCost, dcrUploadCost float64
-			// Figure out upload cost.
-			feeRate, _ := as.serverPaymentRates()
-			size := stat.Size()
-			uploadCost, err := clientintf.EstimateUploadCost(size, feeRate)
+			perms, err := rpc.ParseGCPermission(args[2])
 			if err != nil {
 				return err
 			}
-			dcrUploadCost = float64(uploadCost) / 1e11
 
-			if args[1][0] == '=' {
-				// Exact cost specified.
-				dcrCost, err = strconv.ParseFloat(args[1][1:], 64)
-				if err != nil {
-					return err
-				}
-			} else {
-				// Upload cost + overcharge
-				dcrCost, err = strconv.ParseFloat(args[1], 64)
-				if err != nil {
-					return err
-				}
-				dcrCost += dcrUploadCost
+			if err := as.c.SetGCRolePerms(gcID, role, perms); err != nil {
+				return err
 			}
 
-			var uid *clientintf.UserID
-			with := ""
-			if len(args) > 2 {
-				id, err := as.c.UIDByNick(args[2])
-				if err != nil {
-					return err
-				}
-				uid = &id
-				with = fmt.Sprintf(" with %q", args[2])
-			}
-			atomCost := uint64(dcrCost * 1e8)
-			sf, _, err := as.c.ShareFile(filename, uid, atomCost, "")
-			as.cwHelpMsg("Shared file %q for %.8f DCR (est. cost %.8f DCR)%s. FID: %s",
-				sf.Filename, dcrCost, dcrUploadCost, with,
-				sf.FID)
-			return err
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Set %s permissions to %s", role, perms)
+			as.repaintIfActive(cw)
+			return nil
+		},
 
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			return nil
 		},
 	}, {
-		cmd:           "list",
-		usableOffline: true,
-		aliases:       []string{"ls", "l"},
-		usage:         "<nick> <*|shared|global> [<filename_regex>]",
-		descr:         "List files of a remote peer",
-		handler: func(args []string, as *appState) error {
+		cmd:   "pin",
+		usage: "<gc>",
+		descr: "Pins the last message received in the GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
-			}
-			if len(args) < 2 {
-				return usageError{msg: "dir cannot be empty"}
+				return usageError{msg: "GC cannot be empty"}
 			}
-
-			uid, err := as.c.UIDByNick(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
 
-			cw := as.findOrNewChatWindow(uid, args[0])
-			var filter string
-			if len(args) > 2 {
-				filter = args[2]
+			cw := as.findOrNewGCWindow(gcID)
+			msgID, ok := cw.lastRecvdMsgID()
+			if !ok {
+				return fmt.Errorf("no received message to pin in GC")
 			}
-			go as.listUserContent(cw, args[1], filter)
+			if err := as.c.PinGCMessage(gcID, msgID); err != nil {
+				return err
+			}
+			cw.newHelpMsg("Pinned message %s", msgID.ShortLogID())
+			as.repaintIfActive(cw)
 			return nil
 		},
+
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
 	}, {
-		cmd:   "get",
-		usage: "<nick> [<filename> | <FID>]",
-		descr: "Fetch the given file from the remote peer",
-		long: []string{
-			"The file can be referenced either as a filename (in which case the local client must have had a /ft ls issued first) or a full file ID.",
-			"If the file requires payment, the remote peer will send an invoice that will be automatically paid before actually receiving the file's contents",
-		},
-		handler: func(args []string, as *appState) error {
+		cmd:   "unpin",
+		usage: "<gc>",
+		descr: "Unpins the most recently pinned message of the GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+				return usageError{msg: "GC cannot be empty"}
 			}
-			if len(args) < 2 {
-				return usageError{msg: "filename cannot be empty"}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
 			}
-
-			uid, err := as.c.UIDByNick(args[0])
+			gc, err := as.c.GetGC(gcID)
 			if err != nil {
 				return err
 			}
+			if len(gc.Pinned) == 0 {
+				return fmt.Errorf("GC has no pinned messages")
+			}
 
-			cw := as.findOrNewChatWindow(uid, args[0])
-			go as.getUserContent(cw, args[1])
+			msgID := gc.Pinned[len(gc.Pinned)-1]
+			if err := as.c.UnpinGCMessage(gcID, msgID); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Unpinned message %s", msgID.ShortLogID())
+			as.repaintIfActive(cw)
 			return nil
 		},
+
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
 	}, {
-		cmd:           "estimatecost",
-		usableOffline: true,
-		aliases:       []string{"estcost"},
-		descr:         "Estimate cost of uploading the specified file",
-		usage:         "<filepath>",
-		completer: func(args []string, arg string, as *appState) []string {
-			return fileCompleter(arg)
-		},
-		handler: func(args []string, as *appState) error {
+		cmd:   "ban",
+		usage: "<gc> <nick or id> [<reason>]",
+		descr: "Ban the given user from the specified GC",
+		long: []string{"Banned users are kicked from the GC (if they are members) and cannot be invited back until they are unbanned.",
+			"Only GCs with version 2 or higher support bans."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "file path cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-
-			fpath := args[0]
-			stat, err := os.Stat(fpath)
+			if len(args) < 2 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			var reason string
+			if len(args) > 2 {
+				reason = strings.Join(args[2:], " ")
+			}
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-
-			feeRate, _ := as.serverPaymentRates()
-			size := stat.Size()
-			cost, err := clientintf.EstimateUploadCost(size, feeRate)
+			uid, err := as.gcTargetUID(args[1])
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsg("Cost to upload file (%d B): %s", size,
-				dcrutil.Amount(cost/1e3))
-
+			if err := as.c.BanFromGC(gcID, uid, reason); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Banned %s from GC", strescape.Nick(args[1]))
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
 			return nil
 		},
 	}, {
-		cmd:           "unshare",
-		usableOffline: true,
-		usage:         "<file> [<user>]",
-		descr:         "Unshare a file",
-		handler: func(args []string, as *appState) error {
+		cmd:   "unban",
+		usage: "<gc> <nick or id>",
+		descr: "Revoke the ban of the given user from the specified GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "file cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			var user *clientintf.UserID
-			if len(args) > 1 {
-				uid, err := as.c.UIDByNick(args[1])
-				if err != nil {
-					return err
-				}
-				user = &uid
+			if len(args) < 2 {
+				return usageError{msg: "nick cannot be empty"}
 			}
-
-			files, err := as.c.ListLocalSharedFiles()
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
-				return nil
-			}
-			var fid zkidentity.ShortID
-			if err := fid.FromString(args[0]); err != nil {
-				// Try to find the named file.
-				for _, f := range files {
-					if f.SF.Filename == args[0] {
-						fid = f.SF.FID
-						break
-					}
-				}
-				if fid.IsEmpty() {
-					return fmt.Errorf("could not find shared file %q",
-						args[0])
-				}
+				return err
 			}
-
-			err = as.c.UnshareFile(fid, user)
+			uid, err := as.gcTargetUID(args[1])
 			if err != nil {
 				return err
 			}
-
-			as.cwHelpMsg("Unshared file %s", fid)
+			if err := as.c.UnbanFromGC(gcID, uid); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Unbanned %s from GC", strescape.Nick(args[1]))
+			as.repaintIfActive(cw)
 			return nil
 		},
-	}, {
-		cmd:   "send",
-		usage: "<user> <filename>",
-		descr: "Send a file to user",
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
 			if len(args) == 1 {
-				return fileCompleter(arg)
+				return nickCompleter(arg, as)
 			}
 			return nil
 		},
-		handler: func(args []string, as *appState) error {
+	}, {
+		cmd:   "bans",
+		usage: "<gc>",
+		descr: "List the users banned from the specified GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
-			}
-			if len(args) < 2 {
-				return usageError{msg: "filename cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-
-			uid, err := as.c.UIDByNick(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			nick, err := as.c.UserNick(uid)
-			if err != nil {
-				nick = args[0]
-			}
-
-			filename := args[1]
-			err = as.c.SendFile(uid, filename)
+			banned, err := as.c.ListGCBans(gcID)
 			if err != nil {
 				return err
 			}
-
-			cw := as.findChatWindow(uid)
-			msg := fmt.Sprintf("Sending file %q to user %q",
-				filepath.Base(filename), strescape.Nick(nick))
-			if cw == nil {
-				as.cwHelpMsg(msg)
-			} else {
-				cw.newHelpMsg(msg)
+			out.msgs(func(pf printf) {
+				if len(banned) == 0 {
+					pf("No users banned from GC")
+					return
+				}
+				pf("Users banned from GC")
+				for _, uid := range banned {
+					nick, _ := as.c.UserNick(uid)
+					pf("%s - %s", uid, strescape.Nick(nick))
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
 			}
-
 			return nil
 		},
-	},
-}
-
-var postCommands = []tuicmd{
-	{
-		cmd:   "new",
-		usage: "[<filename>]",
-		descr: "Create a new post",
-		long:  []string{"If called without arguments, opens the create post window. Otherwise, it creates the post based on the contents of the file."},
-		handler: func(args []string, as *appState) error {
-			if len(args) > 0 {
-				fname, err := homedir.Expand(args[0])
-				if err != nil {
-					return err
+	}, {
+		cmd:   "modlog",
+		usage: "<gc>",
+		descr: "Show the local log of moderation actions taken in the GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			entries, err := as.c.GCModLog(gcID)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				if len(entries) == 0 {
+					pf("No moderation actions recorded for GC")
+					return
 				}
-
-				data, err := os.ReadFile(fname)
-				if err != nil {
-					return err
+				pf("GC moderation log")
+				for _, e := range entries {
+					actor, _ := as.c.UserNick(e.Actor)
+					if e.Actor == as.c.PublicID() {
+						actor = "local client"
+					}
+					line := fmt.Sprintf("%s %s %s",
+						e.Timestamp.Format(ISO8601DateTime),
+						strescape.Nick(actor), e.Action)
+					if e.Target != nil {
+						target, err := as.c.UserNick(*e.Target)
+						if err != nil {
+							target = e.Target.String()
+						}
+						line += " " + strescape.Nick(target)
+					}
+					if e.Details != "" {
+						line += " " + strescape.Content(e.Details)
+					}
+					if e.Reason != "" {
+						line += fmt.Sprintf(" (reason: %q)", e.Reason)
+					}
+					pf("%s", line)
 				}
-
-				go as.createPost(string(data), filepath.Dir(fname))
-				return nil
-			}
-			as.sendMsg(showNewPostWindow{})
+			})
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return fileCompleter(arg)
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
 	}, {
-		cmd:     "external",
-		aliases: []string{"ext", "newext"},
-		descr:   "Launch $EDITOR to edit a new post",
-		handler: func(args []string, as *appState) error {
-			go func() {
-				post, err := as.editExternalTextFile(baseExternalNewPostContent)
-				if err != nil {
-					as.cwHelpMsg("Unable to open external editor: %v", err)
-					return
-				}
-
-				as.createPost(post, "")
-			}()
-			return nil
-		},
-	}, {
-		cmd:     "subscribe",
-		aliases: []string{"sub"},
-		usage:   "<nick>",
-		descr:   "Subscribe to posts by the given nick",
-		handler: func(args []string, as *appState) error {
+		cmd:   "slowmode",
+		usage: "<gc> <interval>",
+		descr: "Set the min interval between messages of each GC member",
+		long: []string{"The interval is a duration (for example, 30s or 5m). An interval of 0 disables slow mode.",
+			"Members with a moderation role are not subject to slow mode. Admins drop messages from members that do not respect it."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			uid, err := as.c.UIDByNick(args[0])
+			if len(args) < 2 {
+				return usageError{msg: "interval cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			interval, err := time.ParseDuration(args[1])
 			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid interval: %v", err)}
+			}
+			if err := as.c.SetGCSlowMode(gcID, interval); err != nil {
 				return err
 			}
-			go as.subscribeToPosts(uid)
+			cw := as.findOrNewGCWindow(gcID)
+			if interval == 0 {
+				cw.newHelpMsg("Disabled slow mode")
+			} else {
+				cw.newHelpMsg("Set slow mode to %s between messages",
+					interval.Round(time.Second))
+			}
+			as.repaintIfActive(cw)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
 	}, {
-		cmd:     "unsubscribe",
-		aliases: []string{"unsub"},
-		usage:   "<nick>",
-		descr:   "Unsubscribe to posts by the given nick",
-		handler: func(args []string, as *appState) error {
+		cmd:   "topic",
+		usage: "<gc> [<topic>]",
+		descr: "Set the topic line of the GC",
+		long:  []string{"Without a topic, the current topic is cleared."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			uid, err := as.c.UIDByNick(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			go as.unsubscribeToPosts(uid)
+			topic := strings.Join(args[1:], " ")
+			err = as.c.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Topic: &topic})
+			if err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			if topic == "" {
+				cw.newHelpMsg("Cleared GC topic")
+			} else {
+				cw.newHelpMsg("Set GC topic to %q", topic)
+			}
+			as.repaintIfActive(cw)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
 	}, {
-		cmd:     "list",
-		aliases: []string{"ls"},
-		usage:   "<nick>",
-		descr:   "List the posts made by the specified user",
-		handler: func(args []string, as *appState) error {
+		cmd:   "description",
+		usage: "<gc> [<filename>]",
+		descr: "Set the description of the GC",
+		long: []string{"The description is read as markdown from the file.",
+			"Without a filename, the current description is cleared."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			uid, err := as.c.UIDByNick(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			cw := as.findOrNewChatWindow(uid, args[0])
-			go func() {
-				err := as.c.ListUserPosts(uid)
+			var descr string
+			if len(args) > 1 {
+				b, err := os.ReadFile(args[1])
 				if err != nil {
-					cw.newInternalMsg(fmt.Sprintf("Unable to list user posts: %v", err))
-					as.repaintIfActive(cw)
+					return err
 				}
-			}()
-			cw.newInternalMsg("Listing user posts")
+				descr = strings.TrimSpace(string(b))
+			}
+			err = as.c.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Description: &descr})
+			if err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			if descr == "" {
+				cw.newHelpMsg("Cleared GC description")
+			} else {
+				cw.newHelpMsg("Updated GC description")
+			}
 			as.repaintIfActive(cw)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
-			return nil
+			return fileCompleter(arg)
 		},
 	}, {
-		cmd:   "get",
-		usage: "<nick> <post id>",
-		descr: "Fetch post written by a remote user",
-		long:  []string{"The local client must already be a subscriber of the remote user's posts to be able to fetch an older post."},
-		handler: func(args []string, as *appState) error {
+		cmd:   "avatar",
+		usage: "<gc> [<filename>]",
+		descr: "Set the avatar of the GC",
+		long:  []string{"Without a filename, the current avatar is cleared."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
-			}
-			if len(args) < 2 {
-				return usageError{msg: "post id cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-
-			uid, err := as.c.UIDByNick(args[0])
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			var pid clientintf.PostID
-			if err := pid.FromString(args[1]); err != nil {
+			avatar := []byte{}
+			if len(args) > 1 {
+				avatar, err = os.ReadFile(args[1])
+				if err != nil {
+					return err
+				}
+				if !strings.HasPrefix(imageMimeType(avatar), "image/") {
+					return fmt.Errorf("unsupported file format")
+				}
+			}
+			err = as.c.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Avatar: avatar})
+			if err != nil {
 				return err
 			}
-
-			cw := as.findOrNewChatWindow(uid, args[0])
-			go as.getUserPost(cw, pid)
+			cw := as.findOrNewGCWindow(gcID)
+			if len(avatar) == 0 {
+				cw.newHelpMsg("Cleared GC avatar")
+			} else {
+				cw.newHelpMsg("Updated GC avatar")
+			}
+			as.repaintIfActive(cw)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return gcCompleter(arg, as)
 			}
-			return nil
+			return fileCompleter(arg)
 		},
 	}, {
-		cmd:   "relay",
-		usage: "<from user> <post id> <to user>",
-		descr: "Relay a post made by the from user to the to user",
-		handler: func(args []string, as *appState) error {
+		cmd:   "retention",
+		usage: "<gc> [<max days> [<max msgs>]]",
+		descr: "Show or set the local message retention policy of the GC",
+		long: []string{"Messages older than max days or beyond the last max msgs messages are pruned from the local logs of the GC.",
+			"A value of 0 disables pruning by that criteria. Setting both to 0 disables pruning of the GC.",
+			"If only the GC is specified, the current policy is shown."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "from user cannot be empty"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			if len(args) < 2 {
-				return usageError{msg: "post id cannot be empty"}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
 			}
-			if len(args) < 3 {
-				return usageError{msg: "to user cannot be empty"}
+			if len(args) == 1 {
+				policy, err := as.c.GetGCRetentionPolicy(gcID)
+				if err != nil {
+					return err
+				}
+				if policy.IsEmpty() {
+					out.msg("GC messages are not pruned")
+					return nil
+				}
+				out.msg("GC retention policy: max %d days, max %d messages",
+					policy.MaxAgeDays, policy.MaxMessages)
+				return nil
 			}
 
-			fromUID, err := as.c.UIDByNick(args[0])
+			var policy client.GCRetentionPolicy
+			maxDays, err := strconv.ParseUint(args[1], 10, 32)
 			if err != nil {
-				return fmt.Errorf("from user: %v", err)
+				return usageError{msg: fmt.Sprintf("invalid max days: %v", err)}
 			}
-			var pid clientintf.PostID
-			if err := pid.FromString(args[1]); err != nil {
-				return err
+			policy.MaxAgeDays = uint32(maxDays)
+			if len(args) > 2 {
+				maxMsgs, err := strconv.ParseUint(args[2], 10, 32)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid max msgs: %v", err)}
+				}
+				policy.MaxMessages = uint32(maxMsgs)
 			}
-			toUID, err := as.c.UIDByNick(args[2])
+			pruned, err := as.c.SetGCRetentionPolicy(gcID, policy)
 			if err != nil {
-				return fmt.Errorf("to user: %v", err)
+				return err
 			}
-
-			cw := as.findOrNewChatWindow(toUID, args[2])
-			go as.relayPost(fromUID, pid, cw)
+			out.msg("Set GC retention policy (pruned %d messages)", pruned)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
-			if len(args) == 0 || len(args) == 2 {
-				return nickCompleter(arg, as)
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
 			}
 			return nil
 		},
-	},
-}
-
-var lnCommands = []tuicmd{
-	{
-		cmd:           "info",
-		usableOffline: true,
-		descr:         "Show basic LN info",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+	}, {
+		cmd:   "mentionkx",
+		usage: "<gc> [on|off]",
+		descr: "Show or set whether mentions by unKXd members trigger a KX",
+		long: []string{"Messages from GC members that have not KX'd with the local client are not received. When enabled, GC admins that detect a mention of the local client by an unKXd member are asked to mediate a KX with that member, so that the conversation can continue in PM.",
+			"If only the GC is specified, the current setting is shown."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
 			}
-
-			info, err := as.lnRPC.GetInfo(as.ctx,
-				&lnrpc.GetInfoRequest{})
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
+			if len(args) == 1 {
+				enabled, err := as.c.GCMentionAutoKX(gcID)
+				if err != nil {
+					return err
+				}
+				out.msg("Auto KX on mentions by unKXd members: %v", enabled)
+				return nil
+			}
 
-			as.cwHelpMsgs(func(pf printf) {
-				pf("LN Info")
-				pf("Node ID: %s", info.IdentityPubkey)
-				pf("Version: %s", info.Version)
-				pf("Channels Active: %d, Inactive: %d, Pending: %d",
-					info.NumActiveChannels, info.NumInactiveChannels,
-					info.NumPendingChannels)
-				pf("Synced to chain: %v, to graph: %v",
-					info.SyncedToChain, info.SyncedToGraph)
-				pf("Block height: %d, hash %s", info.BlockHeight,
-					info.BlockHash)
-			})
+			var enabled bool
+			switch args[1] {
+			case "on":
+				enabled = true
+			case "off":
+			default:
+				return usageError{msg: fmt.Sprintf("unknown setting %q", args[1])}
+			}
+			if err := as.c.SetGCMentionAutoKX(gcID, enabled); err != nil {
+				return err
+			}
+			out.msg("Set auto KX on mentions by unKXd members to %v", enabled)
 			return nil
 		},
-	},
-	{
-		cmd:           "newaddress",
-		usableOffline: true,
-		usage:         "[<account>]",
-		descr:         "Create a new standard P2PKH address from the LN wallet",
-		handler: func(args []string, as *appState) error {
-			var account string
-			if len(args) > 0 {
-				account = args[0]
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return gcCompleter(arg, as)
+			case 1:
+				return wordCompleter([]string{"on", "off"}, arg)
 			}
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+			return nil
+		},
+	}, {
+		cmd:   "tips",
+		usage: "<gc> [on|off|<days>]",
+		descr: "Show the tipping leaderboard of the GC or enable it",
+		long: []string{"When the leaderboard is enabled, tips made to members of the GC from within the GC (for example, with /tip in the GC window or with tip splits) are announced to the other members, and tips announced by members that also enabled it are recorded.",
+			"Only tips announced to the local client are included. If <days> is specified, only tips made in the last <days> days are included."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			req := &lnrpc.NewAddressRequest{
-				Type:    lnrpc.AddressType_PUBKEY_HASH,
-				Account: account,
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
 			}
-			na, err := as.lnRPC.NewAddress(as.ctx, req)
+
+			var since time.Time
+			if len(args) > 1 {
+				switch args[1] {
+				case "on", "off":
+					enabled := args[1] == "on"
+					if err := as.c.SetGCTipLeaderboard(gcID, enabled); err != nil {
+						return err
+					}
+					out.msg("Set tipping leaderboard to %v", enabled)
+					return nil
+				}
+				days, err := strconv.ParseUint(args[1], 10, 32)
+				if err != nil || days == 0 {
+					return usageError{msg: fmt.Sprintf("invalid number of days %q", args[1])}
+				}
+				since = time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+			}
+
+			enabled, err := as.c.GCTipLeaderboardEnabled(gcID)
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsg(fmt.Sprintf("Address: %v", na.Address))
-			return nil
-		},
-	},
-	{
-		cmd:           "listpeers",
-		usableOffline: true,
-		aliases:       []string{"lspeers"},
-		descr:         "List peers the LN node is connected to",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
-			}
-			lpr, err := as.lnRPC.ListPeers(as.ctx,
-				&lnrpc.ListPeersRequest{})
+			entries, err := as.c.GCTipLeaderboard(gcID, since)
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsgs(func(pf printf) {
-				pf("Peers: %d", len(lpr.Peers))
-				for _, p := range lpr.Peers {
-					pf("- %s %s", p.PubKey, p.Address)
-					pf("  bytesSent:%v bytesRecv:%v", p.BytesSent, p.BytesRecv)
-					pf("  sent:%.8f recv:%.8f", dcrutil.Amount(p.AtomsSent).ToCoin(),
-						dcrutil.Amount(p.AtomsRecv).ToCoin())
-					pf("  inbound:%v pingTime:%v syncType:%v", p.Inbound,
-						time.Duration(p.PingTime), p.SyncType)
+			out.msgs(func(pf printf) {
+				if !enabled {
+					pf("Tipping leaderboard of GC is disabled " +
+						"(enable it with '/gc tips <gc> on')")
+				}
+				if len(entries) == 0 {
+					pf("No tips recorded for GC")
+					return
+				}
+				pf("GC tipping leaderboard")
+				for i, e := range entries {
+					nick, err := as.c.UserNick(e.UID)
+					if e.UID == as.c.PublicID() {
+						nick = as.c.LocalNick()
+					} else if err != nil {
+						nick = e.UID.String()
+					}
+					pf("%3d. %s sent %.8f DCR (%d tips), "+
+						"received %.8f DCR (%d tips)", i+1,
+						strescape.Nick(nick),
+						float64(e.SentMAtoms)/1e11, e.SentCount,
+						float64(e.ReceivedMAtoms)/1e11,
+						e.ReceivedCount)
 				}
 			})
 			return nil
 		},
-	},
-	{
-		cmd:           "connectpeer",
-		usableOffline: true,
-		usage:         "<pubkey@ip:port>",
-		descr:         "Connect to LN peer in the format pubkey@host",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return gcCompleter(arg, as)
+			case 1:
+				return wordCompleter([]string{"on", "off"}, arg)
 			}
+			return nil
+		},
+	}, {
+		cmd:   "history",
+		usage: "<gc> [<max msgs>|off]",
+		descr: "Show or set sharing of the GC history with new members",
+		long: []string{"When enabled, the last messages of the GC (up to the specified max) are shared with new members the local client is KX'd with, so that they can see the recent conversation. New members download the history as a free shared file.",
+			"If only the GC is specified, the current setting is shown."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "destination cannot be empty"}
-			}
-			s := strings.Split(args[0], "@")
-			if len(s) != 2 {
-				return usageError{msg: "destination must be in the form pubkey@host"}
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			cpr := lnrpc.ConnectPeerRequest{
-				Addr: &lnrpc.LightningAddress{
-					Pubkey: s[0],
-					Host:   s[1],
-				},
-				Perm: false,
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
 			}
-			as.cwHelpMsg("Attempting to connect to peer %v", args[0])
-			go func() {
-				_, err := as.lnRPC.ConnectPeer(as.ctx, &cpr)
+			if len(args) == 1 {
+				maxMsgs, err := as.c.GCHistorySharing(gcID)
 				if err != nil {
-					as.cwHelpMsg("Unable to connect to "+
-						"peer %v: %v", args[0], err)
+					return err
+				}
+				if maxMsgs == 0 {
+					out.msg("GC history sharing is disabled")
 				} else {
-					as.cwHelpMsg("Connected to peer %v", args[0])
+					out.msg("Sharing last %d messages with new members",
+						maxMsgs)
+				}
+				return nil
+			}
+
+			var maxMsgs int
+			if args[1] != "off" {
+				maxMsgs, err = strconv.Atoi(args[1])
+				if err != nil || maxMsgs <= 0 {
+					return usageError{msg: fmt.Sprintf("invalid max number of messages %q", args[1])}
 				}
-			}()
+			}
+			if err := as.c.SetGCHistorySharing(gcID, maxMsgs); err != nil {
+				return err
+			}
+			if maxMsgs == 0 {
+				out.msg("Disabled GC history sharing")
+			} else {
+				out.msg("Sharing last %d messages with new members",
+					maxMsgs)
+			}
 			return nil
 		},
-	},
-	{
-		cmd:           "disconnectpeer",
-		usableOffline: true,
-		descr:         "Disconnect from peer identified by pubkey",
-		usage:         "<pubkey>",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return gcCompleter(arg, as)
+			case 1:
+				return wordCompleter([]string{"off"}, arg)
 			}
-			if len(args) < 1 {
-				return usageError{msg: "pubkey cannot be empty"}
+			return nil
+		},
+	}, {
+		cmd:           "bridge",
+		usableOffline: true,
+		usage:         "<gc> <other gc>",
+		descr:         "Relay messages between two GCs",
+		long: []string{"Messages received from other members in the main channel of either GC are relayed to the other GC, prefixed with the nick of the sender and the name of the source GC.",
+			"Relayed messages are not relayed again by any bridge, so bridging overlapping GCs does not cause loops. Messages sent by the local client are not relayed.",
+			"Use /gc unbridge to stop relaying messages and /gc bridges to list the existing bridges."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "both GCs must be specified"}
 			}
-			dpr := lnrpc.DisconnectPeerRequest{
-				PubKey: args[0],
+			gcA, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
 			}
-			_, err := as.lnRPC.DisconnectPeer(as.ctx, &dpr)
+			gcB, err := as.c.GCIDByName(args[1])
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsg(fmt.Sprintf("Disconnected from peer %v", args[0]))
+			if err := as.c.BridgeGCs(gcA, gcB); err != nil {
+				return err
+			}
+			out.msg("Relaying messages between GCs %s and %s",
+				args[0], args[1])
 			return nil
 		},
-	},
-	{
-		cmd:           "restoremultiscb",
-		usableOffline: true,
-		descr:         "Restore from a multipacked SCB",
-		usage:         "<filename>",
 		completer: func(args []string, arg string, as *appState) []string {
-			return fileCompleter(arg)
-		},
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+			if len(args) < 2 {
+				return gcCompleter(arg, as)
 			}
-			if len(args) < 1 {
-				return usageError{msg: "filename cannot be empty"}
+			return nil
+		},
+	}, {
+		cmd:           "unbridge",
+		usableOffline: true,
+		usage:         "<gc> <other gc>",
+		descr:         "Stop relaying messages between two GCs",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "both GCs must be specified"}
 			}
-			packedMulti, err := os.ReadFile(args[0])
+			gcA, err := as.c.GCIDByName(args[0])
 			if err != nil {
-				return fmt.Errorf("unable to read multi packed "+
-					"backup: %v", err)
+				return err
 			}
-			_, err = as.lnRPC.RestoreChannelBackups(as.ctx,
-				&lnrpc.RestoreChanBackupRequest{
-					Backup: &lnrpc.RestoreChanBackupRequest_MultiChanBackup{
-						MultiChanBackup: packedMulti,
-					},
-				})
-
+			gcB, err := as.c.GCIDByName(args[1])
 			if err != nil {
 				return err
 			}
-			as.diagMsg("Applied SCB file successfully")
+			if err := as.c.UnbridgeGCs(gcA, gcB); err != nil {
+				return err
+			}
+			out.msg("Stopped relaying messages between GCs %s and %s",
+				args[0], args[1])
 			return nil
 		},
-	},
-	{
-		cmd:           "openchannel",
-		usableOffline: true,
-		aliases:       []string{"openchan", "opench"},
-		descr:         "Open a channel funded by the local node",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) < 2 {
+				return gcCompleter(arg, as)
 			}
-			as.sendMsg(msgLNOpenChannel{})
 			return nil
 		},
-	},
-	{
-		cmd:           "closechannel",
+	}, {
+		cmd:           "bridges",
 		usableOffline: true,
-		aliases:       []string{"closechan"},
-		usage:         "<channel-point> [\"force\"]",
-		descr:         "Close a local channel",
-		long:          []string{"If the remote counterparty is offline, the channel can be force-closed by specifying \"force\" as the second parameter."},
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "channel point cannot be empty"}
+		descr:         "List the GC bridges",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			bridges, err := as.c.ListGCBridges()
+			if err != nil {
+				return err
 			}
-			var force bool
-			if len(args) > 1 {
-				if args[1] != "force" {
-					return usageError{msg: "second argument must " +
-						"be 'force' or remain empty"}
+			gcName := func(gcID zkidentity.ShortID) string {
+				if alias, err := as.c.GetGCAlias(gcID); err == nil {
+					return alias
 				}
-				force = true
+				return gcID.String()
 			}
-
-			var chanPoint *lnrpc.ChannelPoint
-			if len(args) < 64 {
-				// Try to find by the prefix of the channel point.
-				chans, err := as.lnRPC.ListChannels(as.ctx,
-					&lnrpc.ListChannelsRequest{})
-				if err != nil {
-					return err
+			out.msgs(func(pf printf) {
+				pf("")
+				if len(bridges) == 0 {
+					pf("No GC bridges")
+					return
 				}
-				for _, c := range chans.Channels {
-					if strings.HasPrefix(c.ChannelPoint, args[0]) {
-						cp, err := strToChanPoint(c.ChannelPoint)
-						if err != nil {
-							return err
-						} else if chanPoint != nil {
-							return fmt.Errorf("channel prefix %q "+
-								"matches multiple channels", args[0])
-						}
-						chanPoint = cp
-					}
+				pf("GC bridges:")
+				for _, b := range bridges {
+					pf("  %s <-> %s (since %s)", gcName(b.GCs[0]),
+						gcName(b.GCs[1]),
+						b.Created.Format(ISO8601DateTime))
 				}
-				if chanPoint == nil {
-					return fmt.Errorf("channel with "+
-						"ChannelPoint prefix %s not found",
-						args[0])
+			})
+			return nil
+		},
+	}, {
+		cmd:   "channels",
+		usage: "<gc>",
+		descr: "List the channels of the GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			channels, err := as.c.ListGCChannels(gcID)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				if len(channels) == 0 {
+					pf("GC has no channels")
+					return
 				}
-			} else {
-				var err error
-				chanPoint, err = strToChanPoint(args[0])
-				if err != nil {
-					return err
+				pf("GC channels")
+				for _, ch := range channels {
+					pf("#%s (%s)", strescape.Nick(ch.Name), ch.ID)
 				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
 			}
-
-			as.cwHelpMsg("Requesting channel %s to be closed",
-				chanPointToStr(chanPoint))
-			go as.closeChannel(chanPoint, force)
 			return nil
 		},
-	},
-	{
-		cmd:           "fundwallet",
-		usableOffline: true,
-		descr:         "Fund wallet",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+	}, {
+		cmd:   "addchannel",
+		usage: "<gc> <name>",
+		descr: "Create a new channel in the GC",
+		long:  []string{"Channels share the membership of the GC. Messages sent to a channel are logged separately from the main GC log."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			as.sendMsg(msgLNFundWallet{})
+			if len(args) < 2 {
+				return usageError{msg: "channel name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			ch, err := as.c.CreateGCChannel(gcID, args[1])
+			if err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Created channel #%s", strescape.Nick(ch.Name))
+			as.repaintIfActive(cw)
 			return nil
 		},
-	},
-	{
-		cmd:           "requestrecv",
-		usableOffline: true,
-		aliases:       []string{"reqrecv"},
-		descr:         "Request receive capacity",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
 			}
-			as.sendMsg(msgLNRequestRecv{})
 			return nil
 		},
-	},
-	{
-		cmd:           "pendingchannels",
-		usableOffline: true,
-		aliases:       []string{"pendingchans"},
-		descr:         "List pending channels",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+	}, {
+		cmd:   "delchannel",
+		usage: "<gc> <channel>",
+		descr: "Remove a channel from the GC",
+		long:  []string{"Messages already logged in the channel are kept."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
 			}
-			chans, err := as.lnRPC.PendingChannels(as.ctx,
-				&lnrpc.PendingChannelsRequest{})
+			if len(args) < 2 {
+				return usageError{msg: "channel name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsgs(func(pf printf) {
-				pf("Pending Open LN Channels: %d", len(chans.PendingOpenChannels))
-				for _, c := range chans.PendingOpenChannels {
-					remoteNodePub := c.Channel.RemoteNodePub
-					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
-					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
-					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
-					channelPoint := c.Channel.ChannelPoint
-
-					height := c.ConfirmationHeight
+			ch, err := as.c.GCChannelByName(gcID, args[1])
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemoveGCChannel(gcID, ch.ID); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Removed channel #%s", strescape.Nick(ch.Name))
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return gcChannelCompleter(args[0], arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "chanmsg",
+		usage: "<gc> <channel> <message>",
+		descr: "Send a message to a channel of the GC",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "channel name cannot be empty"}
+			}
+			if len(args) < 3 {
+				return usageError{msg: "message cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			ch, err := as.c.GCChannelByName(gcID, args[1])
+			if err != nil {
+				return err
+			}
+
+			_, msg := popNArgs(rawCmd, 4) // cmd + subcmd + gcname + channel
+
+			cw := as.findOrNewGCWindow(gcID)
+			go as.gcChannelMsg(out, cw, ch, msg)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return gcChannelCompleter(args[0], arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "chanhistory",
+		usage: "<gc> <channel> [<count>]",
+		descr: "Show the last messages logged in a channel of the GC",
+		long:  []string{"If not specified, count defaults to 50."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "channel name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			ch, err := as.c.GCChannelByName(gcID, args[1])
+			if err != nil {
+				return err
+			}
+			count := 50
+			if len(args) > 2 {
+				count, err = strconv.Atoi(args[2])
+				if err != nil || count <= 0 {
+					return usageError{msg: fmt.Sprintf("invalid count %q", args[2])}
+				}
+			}
+			entries, err := as.c.ReadGCChannelHistory(gcID, ch.ID, count, 0)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				if len(entries) == 0 {
+					pf("No messages logged in channel #%s",
+						strescape.Nick(ch.Name))
+					return
+				}
+				pf("History of channel #%s", strescape.Nick(ch.Name))
+				for _, e := range entries {
+					ts := time.Unix(e.Timestamp, 0)
+					if e.Internal {
+						pf("%s * %s", ts.Format(ISO8601DateTime),
+							strescape.Content(e.Message))
+						continue
+					}
+					pf("%s <%s> %s", ts.Format(ISO8601DateTime),
+						strescape.Nick(e.From), strescape.Content(e.Message))
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return gcChannelCompleter(args[0], arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "joinpolicy",
+		usage: "<gc> <max members> [approval]",
+		descr: "Set the max number of members of the GC and whether joins must be approved",
+		long: []string{"A max members of 0 removes the limit on the number of members of the GC.",
+			"If 'approval' is specified, requests to join the GC through invite links are queued until approved with '/gc approvejoin' or denied with '/gc denyjoin'."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "max members cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			maxMembers, err := strconv.ParseUint(args[1], 10, 32)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid max members: %v", err)}
+			}
+			var approval bool
+			if len(args) > 2 {
+				if args[2] != "approval" {
+					return usageError{msg: fmt.Sprintf("invalid argument %q", args[2])}
+				}
+				approval = true
+			}
+			if err := as.c.SetGCJoinPolicy(gcID, uint32(maxMembers), approval); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Set GC join policy: max members %d, approval required: %v",
+				maxMembers, approval)
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "joinrequests",
+		usage: "[<gc>]",
+		descr: "List the pending requests to join GCs through invite links",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var gcID *zkidentity.ShortID
+			if len(args) > 0 {
+				id, err := as.c.GCIDByName(args[0])
+				if err != nil {
+					return err
+				}
+				gcID = &id
+			}
+			reqs, err := as.c.ListGCJoinRequests(gcID)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				if len(reqs) == 0 {
+					pf("No pending GC join requests")
+					return
+				}
+				pf("Pending GC join requests")
+				for _, req := range reqs {
+					gcName, _ := as.c.GetGCAlias(req.GC)
+					if gcName == "" {
+						gcName = req.GC.String()
+					}
+					nick, err := as.c.UserNick(req.UID)
+					if err != nil {
+						nick = req.UID.String()
+					}
+					pf("%s %s wants to join %s",
+						req.Received.Format(ISO8601DateTime),
+						strescape.Nick(nick), strescape.Nick(gcName))
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "approvejoin",
+		usage: "<gc> <nick>",
+		descr: "Approve a pending request to join the GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			uid, err := as.c.UIDByNick(args[1])
+			if err != nil {
+				return err
+			}
+			if err := as.c.ApproveGCJoinRequest(gcID, uid); err != nil {
+				return err
+			}
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Approved request from %s to join the GC",
+				strescape.Nick(args[1]))
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "denyjoin",
+		usage: "<gc> <nick>",
+		descr: "Deny a pending request to join the GC",
+		long:  []string{"The user is not notified that the request was denied."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			uid, err := as.c.UIDByNick(args[1])
+			if err != nil {
+				return err
+			}
+			if err := as.c.DenyGCJoinRequest(gcID, uid); err != nil {
+				return err
+			}
+			out.msg("Denied request from %s to join the GC",
+				strescape.Nick(args[1]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "invitelink",
+		usage: "<gc> [<expiry>] [<max uses>]",
+		descr: "Create a shareable invite link to the GC",
+		long: []string{"The expiry is a duration (for example, 24h). If not specified or 0, the link does not expire.",
+			"If max uses is not specified or 0, the link may be used any number of times.",
+			"The link may be used with '/gc join' by any user KX'd with the local client."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc name cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			var expiry time.Duration
+			if len(args) > 1 {
+				expiry, err = time.ParseDuration(args[1])
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid expiry: %v", err)}
+				}
+			}
+			var maxUses uint64
+			if len(args) > 2 {
+				maxUses, err = strconv.ParseUint(args[2], 10, 32)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid max uses: %v", err)}
+				}
+			}
+			link, err := as.c.CreateGCInviteLink(gcID, expiry, uint32(maxUses))
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("Created invite link %s", link.ID.ShortLogID())
+				pf("%s", link.String())
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "links",
+		usage: "[<gc>]",
+		descr: "List the GC invite links created by the local client",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var gcID *zkidentity.ShortID
+			if len(args) > 0 {
+				id, err := as.c.GCIDByName(args[0])
+				if err != nil {
+					return err
+				}
+				gcID = &id
+			}
+			links, err := as.c.ListGCInviteLinks(gcID)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			out.msgs(func(pf printf) {
+				if len(links) == 0 {
+					pf("No GC invite links")
+					return
+				}
+				pf("GC invite links")
+				for _, l := range links {
+					gcName, _ := as.c.GetGCAlias(l.GC)
+					status := "active"
+					if err := l.Usable(now); err != nil {
+						status = err.Error()
+					}
+					uses := fmt.Sprintf("%d", len(l.UsedBy))
+					if l.MaxUses > 0 {
+						uses += fmt.Sprintf("/%d", l.MaxUses)
+					}
+					expires := "never"
+					if !l.Expires.IsZero() {
+						expires = l.Expires.Format(ISO8601DateTime)
+					}
+					pf("%s %s - uses %s - expires %s - %s",
+						l.ID.ShortLogID(), strescape.Nick(gcName),
+						uses, expires, status)
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "revokelink",
+		usage: "<link id>",
+		descr: "Revoke a GC invite link",
+		long:  []string{"The link id may be the prefix shown in '/gc links'."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "link id cannot be empty"}
+			}
+			links, err := as.c.ListGCInviteLinks(nil)
+			if err != nil {
+				return err
+			}
+			var matches []zkidentity.ShortID
+			for _, l := range links {
+				if strings.HasPrefix(l.ID.String(), args[0]) {
+					matches = append(matches, l.ID)
+				}
+			}
+			switch len(matches) {
+			case 0:
+				return fmt.Errorf("no invite link with id %q", args[0])
+			case 1:
+			default:
+				return fmt.Errorf("ambiguous invite link id %q", args[0])
+			}
+			if err := as.c.RevokeGCInviteLink(matches[0]); err != nil {
+				return err
+			}
+			out.msg("Revoked invite link %s", matches[0].ShortLogID())
+			return nil
+		},
+	}, {
+		cmd:   "modowner",
+		usage: "<gc> <new owner>",
+		descr: "Change the owner of the given GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "GC cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "New admin cannot be empty"}
+			}
+
+			gcID, err := as.c.GCIDByName(args[0])
+			if err != nil {
+				return err
+			}
+			gcAlias, err := as.c.GetGCAlias(gcID)
+			if err != nil {
+				return err
+			}
+
+			ru, err := as.c.UserByNick(args[1])
+			if err != nil {
+				return err
+			}
+
+			reason := "Changing owner"
+			err = as.c.ModifyGCOwner(gcID, ru.ID(), reason)
+			if err != nil {
+				return err
+			}
+
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newHelpMsg("Changed owner of GC %s to %s",
+				strescape.Nick(gcAlias), strescape.Nick(ru.Nick()))
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	},
+}
+
+var ftCommands = []tuicmd{
+	{
+		cmd:           "share",
+		usableOffline: true,
+		usage:         "<filename> <cost> [<nick>]",
+		descr:         "Share the given file for distribution",
+		long: []string{
+			"Imports the passed file into the local FTP repository. The cost is specified in DCR.",
+			"If a nick or user ID is specified, the file is shared only to that user.",
+			"By default, the passed cost is *added* to the estimated upload cost. Use \"=<amount>\" to directly specify the full cost",
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			if len(args) == 2 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "cost cannot be empty"}
+			}
+			if len(args[1]) < 1 {
+				return usageError{msg: "cost cannot be the empty string"}
+			}
+
+			filename, err := homedir.Expand(args[0])
+			if err != nil {
+				return err
+			}
+			stat, err := os.Stat(filename)
+			if err != nil {
+				return err
+			}
+
+			var dcrCost, dcrUploadCost float64
+			// Figure out upload cost.
+			feeRate, _ := as.serverPaymentRates()
+			size := stat.Size()
+			uploadCost, err := clientintf.EstimateUploadCost(size, feeRate)
+			if err != nil {
+				return err
+			}
+			dcrUploadCost = float64(uploadCost) / 1e11
+
+			if args[1][0] == '=' {
+				// Exact cost specified.
+				dcrCost, err = strconv.ParseFloat(args[1][1:], 64)
+				if err != nil {
+					return err
+				}
+			} else {
+				// Upload cost + overcharge
+				dcrCost, err = strconv.ParseFloat(args[1], 64)
+				if err != nil {
+					return err
+				}
+				dcrCost += dcrUploadCost
+			}
+
+			var uid *clientintf.UserID
+			with := ""
+			if len(args) > 2 {
+				id, err := as.c.UIDByNick(args[2])
+				if err != nil {
+					return err
+				}
+				uid = &id
+				with = fmt.Sprintf(" with %q", args[2])
+			}
+			atomCost := uint64(dcrCost * 1e8)
+			sf, _, err := as.c.ShareFile(filename, uid, atomCost, "")
+			out.msg("Shared file %q for %.8f DCR (est. cost %.8f DCR)%s. FID: %s",
+				sf.Filename, dcrCost, dcrUploadCost, with,
+				sf.FID)
+			return err
+
+		},
+	}, {
+		cmd:           "list",
+		usableOffline: true,
+		aliases:       []string{"ls", "l"},
+		usage:         "<nick> <*|shared|global> [<filename_regex>]",
+		descr:         "List files of a remote peer",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "dir cannot be empty"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			cw := as.findOrNewChatWindow(uid, args[0])
+			var filter string
+			if len(args) > 2 {
+				filter = args[2]
+			}
+			go as.listUserContent(cw, args[1], filter)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "get",
+		usage: "<nick> [<filename> | <FID>]",
+		descr: "Fetch the given file from the remote peer",
+		long: []string{
+			"The file can be referenced either as a filename (in which case the local client must have had a /ft ls issued first) or a full file ID.",
+			"If the file requires payment, the remote peer will send an invoice that will be automatically paid before actually receiving the file's contents",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			cw := as.findOrNewChatWindow(uid, args[0])
+			go as.getUserContent(out, cw, args[1], false)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "search",
+		usableOffline: true,
+		usage:         "[<name>] [type=<ext>]... [minsize=<bytes>] [maxsize=<bytes>] [maxcost=<DCR>] [sharer=<nick>]...",
+		descr:         "Search the files shared by remote peers",
+		long: []string{
+			"Searches the index of files built from the file listings fetched from remote peers (with /ft ls or automatically, according to the contentindexrefreshinterval config).",
+			"The name is matched against the filename and description of files. Multiple types and sharers may be specified.",
+			"Files found may be fetched with /ft get.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var q client.ContentQuery
+			var names []string
+			for _, arg := range args {
+				k, v, ok := strings.Cut(arg, "=")
+				if !ok {
+					names = append(names, arg)
+					continue
+				}
+				var err error
+				switch k {
+				case "type":
+					q.Types = append(q.Types, v)
+				case "minsize":
+					q.MinSize, err = strconv.ParseUint(v, 10, 64)
+				case "maxsize":
+					q.MaxSize, err = strconv.ParseUint(v, 10, 64)
+				case "maxcost":
+					var dcrCost float64
+					dcrCost, err = strconv.ParseFloat(v, 64)
+					atoms := uint64(dcrCost * 1e8)
+					q.MaxCost = &atoms
+				case "sharer":
+					var uid clientintf.UserID
+					uid, err = as.c.UIDByNick(v)
+					q.Sharers = append(q.Sharers, uid)
+				default:
+					names = append(names, arg)
+				}
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid %s: %v", k, err)}
+				}
+			}
+			q.Name = strings.Join(names, " ")
+
+			files, err := as.c.SearchContent(&q)
+			if err != nil {
+				return err
+			}
+
+			// Store the files so they can be fetched by name.
+			as.contentMtx.Lock()
+			for _, rf := range files {
+				userFiles, ok := as.remoteFiles[rf.UID]
+				if !ok {
+					userFiles = make(map[clientdb.FileID]clientdb.RemoteFile)
+					as.remoteFiles[rf.UID] = userFiles
+				}
+				userFiles[rf.FID] = rf
+			}
+			as.contentMtx.Unlock()
+
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Found %d files", len(files))
+				for _, rf := range files {
+					nick, _ := as.c.UserNick(rf.UID)
+					pf("%s - %q (%d bytes, %.8f DCR) - %s",
+						strescape.Nick(nick), rf.Metadata.Filename,
+						rf.Metadata.Size, float64(rf.Metadata.Cost)/1e8,
+						rf.FID)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "refreshindex",
+		descr: "Fetch the list of files of all remote peers to refresh the search index",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			n, err := as.c.RefreshContentIndex(time.Now())
+			if err != nil {
+				return err
+			}
+			out.msg("Requested list of files of %d users", n)
+			return nil
+		},
+	}, {
+		cmd:   "getpreview",
+		usage: "<nick> [<filename> | <FID>]",
+		descr: "Fetch the free preview of the given file from the remote peer",
+		long: []string{
+			"The file can be referenced either as a filename (in which case the local client must have had a /ft ls issued first) or a full file ID.",
+			"If the preview is a part of the file, only the free chunks are downloaded, otherwise the separate preview file is downloaded.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			cw := as.findOrNewChatWindow(uid, args[0])
+			go as.getUserContent(out, cw, args[1], true)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "swarm",
+		usage: "<nick> <filename | FID> on|off",
+		descr: "Re-share the chunks of a download with its other downloaders",
+		long: []string{
+			"When enabled, the downloaded chunks of the file are offered to other downloaders of the file that also enabled re-sharing it, for the same cost as set by the publisher (nick) of the file.",
+			"The publisher relays the list of available chunks among the downloaders, so that they may download chunks from one another.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+			if len(args) < 3 || (args[2] != "on" && args[2] != "off") {
+				return usageError{msg: "specify either on or off"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			// The file is either an outstanding download or one
+			// listed with /ft ls.
+			var fid clientdb.FileID
+			if err := fid.FromString(args[1]); err != nil {
+				downloads, err := as.c.ListDownloads()
+				if err != nil {
+					return err
+				}
+				found := false
+				for _, fd := range downloads {
+					if fd.UID == uid && fd.Metadata != nil &&
+						fd.Metadata.Filename == args[1] {
+						fid = fd.FID
+						found = true
+						break
+					}
+				}
+				as.contentMtx.Lock()
+				for id, file := range as.remoteFiles[uid] {
+					if !found && file.Metadata.Filename == args[1] {
+						fid = id
+						found = true
+					}
+				}
+				as.contentMtx.Unlock()
+				if !found {
+					return fmt.Errorf("no download of file %q from %s",
+						args[1], args[0])
+				}
+			}
+
+			swarm := args[2] == "on"
+			if err := as.c.SetFileDownloadSwarm(uid, fid, swarm); err != nil {
+				return err
+			}
+			if swarm {
+				out.msg("Re-sharing chunks of file %s", args[1])
+			} else {
+				out.msg("Stopped re-sharing chunks of file %s", args[1])
+			}
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "estimatecost",
+		usableOffline: true,
+		aliases:       []string{"estcost"},
+		descr:         "Estimate cost of uploading the specified file",
+		usage:         "<filepath>",
+		completer: func(args []string, arg string, as *appState) []string {
+			return fileCompleter(arg)
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "file path cannot be empty"}
+			}
+
+			fpath := args[0]
+			stat, err := os.Stat(fpath)
+			if err != nil {
+				return err
+			}
+
+			feeRate, _ := as.serverPaymentRates()
+			size := stat.Size()
+			cost, err := clientintf.EstimateUploadCost(size, feeRate)
+			if err != nil {
+				return err
+			}
+			out.msg("Cost to upload file (%d B): %s", size,
+				dcrutil.Amount(cost/1e3))
+
+			return nil
+		},
+	}, {
+		cmd:           "unshare",
+		usableOffline: true,
+		usage:         "<file> [<user>]",
+		descr:         "Unshare a file",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "file cannot be empty"}
+			}
+			var user *clientintf.UserID
+			if len(args) > 1 {
+				uid, err := as.c.UIDByNick(args[1])
+				if err != nil {
+					return err
+				}
+				user = &uid
+			}
+
+			files, err := as.c.ListLocalSharedFiles()
+			if err != nil {
+				return nil
+			}
+			var fid zkidentity.ShortID
+			if err := fid.FromString(args[0]); err != nil {
+				// Try to find the named file.
+				for _, f := range files {
+					if f.SF.Filename == args[0] {
+						fid = f.SF.FID
+						break
+					}
+				}
+				if fid.IsEmpty() {
+					return fmt.Errorf("could not find shared file %q",
+						args[0])
+				}
+			}
+
+			err = as.c.UnshareFile(fid, user)
+			if err != nil {
+				return err
+			}
+
+			out.msg("Unshared file %s", fid)
+			return nil
+		},
+	}, {
+		cmd:           "pricing",
+		usableOffline: true,
+		usage:         "<file> <cost> [preview=<bytes>] [previewfile=<file>] [tier=<end>:<cost>[:<name>]...]",
+		descr:         "Set the preview and price tiers of a shared file",
+		long: []string{
+			"Sets the full cost (in DCR) of a shared file, along with an optional free preview and price tiers, which are advertised in the file listing.",
+			"The preview is either the first bytes of the file (preview=<bytes>) or a separate shared file (previewfile=<file>).",
+			"Each tier specifies the byte offset where it ends and the cost (in DCR) of its bytes. The last tier must end at the size of the file. When tiers are specified, the full cost of the file is the sum of their costs.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "file cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "cost cannot be empty"}
+			}
+
+			files, err := as.c.ListLocalSharedFiles()
+			if err != nil {
+				return err
+			}
+			findFID := func(s string) (clientdb.FileID, error) {
+				var fid clientdb.FileID
+				if err := fid.FromString(s); err == nil {
+					return fid, nil
+				}
+				for _, f := range files {
+					if f.SF.Filename == s {
+						return f.SF.FID, nil
+					}
+				}
+				return fid, fmt.Errorf("could not find shared file %q", s)
+			}
+
+			fid, err := findFID(args[0])
+			if err != nil {
+				return err
+			}
+			dcrCost, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid cost: %v", err)}
+			}
+
+			var preview *rpc.FilePreview
+			var tiers []rpc.FilePriceTier
+			for _, arg := range args[2:] {
+				k, v, _ := strings.Cut(arg, "=")
+				switch k {
+				case "preview":
+					size, err := strconv.ParseUint(v, 10, 64)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid preview size: %v", err)}
+					}
+					preview = &rpc.FilePreview{Size: size}
+				case "previewfile":
+					previewFID, err := findFID(v)
+					if err != nil {
+						return err
+					}
+					preview = &rpc.FilePreview{FID: previewFID.String()}
+				case "tier":
+					parts := strings.SplitN(v, ":", 3)
+					if len(parts) < 2 {
+						return usageError{msg: fmt.Sprintf("invalid tier %q", v)}
+					}
+					end, err := strconv.ParseUint(parts[0], 10, 64)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid tier end: %v", err)}
+					}
+					tierCost, err := strconv.ParseFloat(parts[1], 64)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid tier cost: %v", err)}
+					}
+					tier := rpc.FilePriceTier{End: end, Cost: uint64(tierCost * 1e8)}
+					if len(parts) > 2 {
+						tier.Name = parts[2]
+					}
+					tiers = append(tiers, tier)
+				default:
+					return usageError{msg: fmt.Sprintf("unknown pricing option %q", arg)}
+				}
+			}
+
+			err = as.c.SetSharedFilePricing(fid, uint64(dcrCost*1e8), preview, tiers)
+			if err != nil {
+				return err
+			}
+			out.msg("Updated pricing of file %s", fid)
+			return nil
+		},
+	}, {
+		cmd:           "limits",
+		usableOffline: true,
+		usage:         "<file> [<nick>] [expires=<duration>] [maxdownloads=<n>]",
+		descr:         "Set the expiration and download limit of a share",
+		long: []string{
+			"Sets limits on the share of a file with the given user (or on the global share if no user is specified), after which the file is automatically unshared.",
+			"The expiration is specified as a duration from now (e.g. \"72h\"). The download limit is the number of users that may complete a download of the file.",
+			"If no limits are specified, the limits of the share are removed.",
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 1 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "file cannot be empty"}
+			}
+
+			files, err := as.c.ListLocalSharedFiles()
+			if err != nil {
+				return err
+			}
+			var fid clientdb.FileID
+			if err := fid.FromString(args[0]); err != nil {
+				for _, f := range files {
+					if f.SF.Filename == args[0] {
+						fid = f.SF.FID
+						break
+					}
+				}
+				if fid.IsEmpty() {
+					return fmt.Errorf("could not find shared file %q",
+						args[0])
+				}
+			}
+
+			var uid *clientintf.UserID
+			var limits *client.ShareLimits
+			for _, arg := range args[1:] {
+				k, v, isOpt := strings.Cut(arg, "=")
+				if !isOpt && uid == nil && limits == nil {
+					id, err := as.c.UIDByNick(arg)
+					if err != nil {
+						return err
+					}
+					uid = &id
+					continue
+				}
+				if limits == nil {
+					limits = new(client.ShareLimits)
+				}
+				switch k {
+				case "expires":
+					d, err := time.ParseDuration(v)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid expiration: %v", err)}
+					}
+					limits.Expires = time.Now().Add(d)
+				case "maxdownloads":
+					n, err := strconv.Atoi(v)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid max downloads: %v", err)}
+					}
+					limits.MaxDownloads = n
+				default:
+					return usageError{msg: fmt.Sprintf("unknown limit %q", arg)}
+				}
+			}
+
+			if err := as.c.SetShareLimits(fid, uid, limits); err != nil {
+				return err
+			}
+			if limits == nil {
+				out.msg("Removed limits of share of file %s", fid)
+			} else {
+				out.msg("Updated limits of share of file %s", fid)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "bandwidth",
+		aliases:       []string{"bw"},
+		usableOffline: true,
+		usage:         "[upload=<KB/s>] [download=<KB/s>] [peerupload=<KB/s>] [peerdownload=<KB/s>]",
+		descr:         "Show or set the bandwidth limits of file transfers",
+		long: []string{
+			"Sets the limits of the bandwidth used for file transfers with all users (upload, download) and with each user (peerupload, peerdownload). Limits that are not specified are not changed. Chat traffic is not limited.",
+			"A limit of zero means no limit. Without arguments, shows the current limits.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			limits := as.c.BandwidthLimits()
+			for _, arg := range args {
+				k, v, _ := strings.Cut(arg, "=")
+				kbps, err := strconv.ParseUint(v, 10, 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid limit %q: %v", arg, err)}
+				}
+				bps := kbps * 1000
+				switch k {
+				case "upload":
+					limits.Upload = bps
+				case "download":
+					limits.Download = bps
+				case "peerupload":
+					limits.PeerUpload = bps
+				case "peerdownload":
+					limits.PeerDownload = bps
+				default:
+					return usageError{msg: fmt.Sprintf("unknown limit %q", arg)}
+				}
+			}
+			if len(args) > 0 {
+				as.c.SetBandwidthLimits(limits)
+			}
+
+			fmtLimit := func(bps uint64) string {
+				if bps == 0 {
+					return "unlimited"
+				}
+				return fmt.Sprintf("%d KB/s", bps/1000)
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("File transfer bandwidth limits")
+				pf("Upload        : %s", fmtLimit(limits.Upload))
+				pf("Download      : %s", fmtLimit(limits.Download))
+				pf("Peer upload   : %s", fmtLimit(limits.PeerUpload))
+				pf("Peer download : %s", fmtLimit(limits.PeerDownload))
+			})
+			return nil
+		},
+	}, {
+		cmd:           "autoshare",
+		usableOffline: true,
+		descr:         "Rescan the auto share dirs and list auto shared files",
+		long: []string{
+			"Shares new or changed files and unshares removed files of the dirs configured in the [autoshare] section of the config file, then lists the files currently shared from them.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.autoSharer == nil {
+				return fmt.Errorf("no auto share dirs configured")
+			}
+			if err := as.autoSharer.Scan(); err != nil {
+				return err
+			}
+			shared := as.autoSharer.Shared()
+			fnames := make([]string, 0, len(shared))
+			for fname := range shared {
+				fnames = append(fnames, fname)
+			}
+			sort.Strings(fnames)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Auto shared files")
+				for _, fname := range fnames {
+					pf("%s - %s", shared[fname], fname)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "send",
+		usage: "<user> <filename>",
+		descr: "Send a file to user",
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "user cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			nick, err := as.c.UserNick(uid)
+			if err != nil {
+				nick = args[0]
+			}
+
+			filename := args[1]
+			err = as.c.SendFile(uid, filename)
+			if err != nil {
+				return err
+			}
+
+			cw := as.findChatWindow(uid)
+			msg := fmt.Sprintf("Sending file %q to user %q",
+				filepath.Base(filename), strescape.Nick(nick))
+			if cw == nil {
+				out.msg(msg)
+			} else {
+				cw.newHelpMsg(msg)
+			}
+
+			return nil
+		},
+	},
+}
+
+var postCommands = []tuicmd{
+	{
+		cmd:   "new",
+		usage: "[<filename>]",
+		descr: "Create a new post",
+		long:  []string{"If called without arguments, opens the create post window. Otherwise, it creates the post based on the contents of the file."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) > 0 {
+				fname, err := homedir.Expand(args[0])
+				if err != nil {
+					return err
+				}
+
+				data, err := os.ReadFile(fname)
+				if err != nil {
+					return err
+				}
+
+				go as.createPost(string(data), filepath.Dir(fname), nil)
+				return nil
+			}
+			as.sendMsg(showNewPostWindow{})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:     "external",
+		aliases: []string{"ext", "newext"},
+		descr:   "Launch $EDITOR to edit a new post",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			out.goAsync(func() {
+				post, err := as.editExternalTextFile(baseExternalNewPostContent)
+				if err != nil {
+					out.msg("Unable to open external editor: %v", err)
+					return
+				}
+
+				as.createPost(post, "", nil)
+			})
+			return nil
+		},
+	}, {
+		cmd:   "tagged",
+		usage: "<tags> <filename>",
+		descr: "Create a new post with tags",
+		long: []string{
+			"Creates a post based on the contents of the file, tagged with the comma-separated list of tags (e.g. dev,golang).",
+			"Subscribers may use the tags to filter which of the posts they receive.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "tags cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+			tags := strings.Split(args[0], ",")
+			if _, err := rpc.FormatPostTags(tags); err != nil {
+				return usageError{msg: err.Error()}
+			}
+			fname, err := homedir.Expand(args[1])
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(fname)
+			if err != nil {
+				return err
+			}
+			go as.createPost(string(data), filepath.Dir(fname), tags)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 1 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "paywall",
+		usage: "<price> <summary filename> <body filename>",
+		descr: "Create a new post where only the summary is free",
+		long: []string{
+			"Creates a post with the contents of the summary file. The contents of the body file are only delivered to readers after they pay the price (in DCR) to unlock the post.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "price cannot be empty"}
+			}
+			if len(args) < 3 {
+				return usageError{msg: "summary and body filenames cannot be empty"}
+			}
+			dcrPrice, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid price: %v", err)}
+			}
+			price, err := dcrutil.NewAmount(dcrPrice)
+			if err != nil || price <= 0 {
+				return usageError{msg: "price must be a positive DCR amount"}
+			}
+			var contents [2]string
+			for i, arg := range args[1:3] {
+				fname, err := homedir.Expand(arg)
+				if err != nil {
+					return err
+				}
+				data, err := os.ReadFile(fname)
+				if err != nil {
+					return err
+				}
+				contents[i] = resources.RemoveEndOfPostMarker(string(data))
+				contents[i] = resources.ProcessEmbeds(contents[i], filepath.Dir(fname), as.log)
+			}
+			out.goAsync(func() {
+				summ, err := as.c.CreatePaywalledPost(contents[0],
+					contents[1], "", uint64(price), nil)
+				if err != nil {
+					out.msg("Unable to create post: %v", err)
+					return
+				}
+				out.msg("Created post %s (unlocked for %s)", summ.ID, price)
+				as.postsMtx.Lock()
+				as.posts = append(as.posts, summ)
+				as.sortPosts()
+				as.postsMtx.Unlock()
+				as.sendMsg(summ)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 1 || len(args) == 2 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "draft",
+		usage: "<filename> [<time>]",
+		descr: "Save a post draft, optionally scheduled to be published",
+		long: []string{
+			"Saves the contents of the file as a post draft. If a time is specified, the draft is automatically published at that time, even if the client is restarted in the meantime.",
+			"The time is either a duration from now (e.g. 2h30m) or a local date and time (e.g. \"2024-03-11 08:15\").",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+			fname, err := homedir.Expand(args[0])
+			if err != nil {
+				return err
+			}
+			var publishAt *time.Time
+			if len(args) > 1 {
+				t, err := parseScheduleTime(strings.Join(args[1:], " "), time.Now())
+				if err != nil {
+					return usageError{msg: err.Error()}
+				}
+				publishAt = &t
+			}
+			data, err := os.ReadFile(fname)
+			if err != nil {
+				return err
+			}
+			post := resources.RemoveEndOfPostMarker(string(data))
+			post = resources.ProcessEmbeds(post, filepath.Dir(fname), as.log)
+			draft, err := as.c.SavePostDraft(nil, post, "", publishAt)
+			if err != nil {
+				return err
+			}
+			if publishAt != nil {
+				out.msg("Saved post draft %s, to be published at %s",
+					draft.ID, publishAt.Format(ISO8601DateTime))
+			} else {
+				out.msg("Saved post draft %s", draft.ID)
+			}
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "drafts",
+		descr: "List the post drafts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			drafts, err := as.c.ListPostDrafts()
+			if err != nil {
+				return err
+			}
+			if len(drafts) == 0 {
+				out.msg("No post drafts")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Post drafts")
+				for _, draft := range drafts {
+					title := clientintf.PostTitle(&rpc.PostMetadata{
+						Attributes: map[string]string{
+							rpc.RMPMain: draft.Post,
+						},
+					})
+					schedule := "not scheduled"
+					if draft.PublishAt != nil {
+						schedule = "publish at " +
+							draft.PublishAt.Format(ISO8601DateTime)
+					}
+					pf("%s - %s - %s", draft.ID, schedule, title)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "schedule",
+		usage: "<draft id> <time|none>",
+		descr: "Schedule a post draft to be published",
+		long:  []string{"The time is either a duration from now (e.g. 2h30m) or a local date and time (e.g. \"2024-03-11 08:15\"). Use none to cancel the schedule, keeping the draft."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "draft id cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "time cannot be empty"}
+			}
+			var id zkidentity.ShortID
+			if err := id.FromString(args[0]); err != nil {
+				return err
+			}
+			var publishAt *time.Time
+			if args[1] != "none" {
+				t, err := parseScheduleTime(strings.Join(args[1:], " "), time.Now())
+				if err != nil {
+					return usageError{msg: err.Error()}
+				}
+				publishAt = &t
+			}
+			if err := as.c.SchedulePostDraft(id, publishAt); err != nil {
+				return err
+			}
+			if publishAt == nil {
+				out.msg("Canceled schedule of post draft %s", id)
+			} else {
+				out.msg("Post draft %s will be published at %s",
+					id, publishAt.Format(ISO8601DateTime))
+			}
+			return nil
+		},
+	}, {
+		cmd:   "publish",
+		usage: "<draft id>",
+		descr: "Publish a post draft now",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "draft id cannot be empty"}
+			}
+			var id zkidentity.ShortID
+			if err := id.FromString(args[0]); err != nil {
+				return err
+			}
+			out.goAsync(func() {
+				summ, err := as.c.PublishPostDraft(id)
+				as.postDraftPublished(summ, err)
+			})
+			return nil
+		},
+	}, {
+		cmd:     "rmdraft",
+		aliases: []string{"deletedraft"},
+		usage:   "<draft id>",
+		descr:   "Remove a post draft",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "draft id cannot be empty"}
+			}
+			var id zkidentity.ShortID
+			if err := id.FromString(args[0]); err != nil {
+				return err
+			}
+			if err := as.c.RemovePostDraft(id); err != nil {
+				return err
+			}
+			out.msg("Removed post draft %s", id)
+			return nil
+		},
+	}, {
+		cmd:     "subscribe",
+		aliases: []string{"sub"},
+		usage:   "<nick>",
+		descr:   "Subscribe to posts by the given nick",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			go as.subscribeToPosts(out, uid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:     "unsubscribe",
+		aliases: []string{"unsub"},
+		usage:   "<nick>",
+		descr:   "Unsubscribe to posts by the given nick",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			go as.unsubscribeToPosts(out, uid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "tagfilter",
+		usage: "<nick> [+<tag>|-<tag>|none ...]",
+		descr: "Show or set the filter of tags of posts from a user",
+		long: []string{
+			"New posts from the user (including copies relayed by others) that do not pass the filter are ignored, neither stored nor relayed.",
+			"Tags prefixed with + are included: when there are any, only posts with at least one of them are accepted. Tags prefixed with - are excluded: posts with any of them are ignored.",
+			"Specifying 'none' removes the filter. Without tags, the current filter is shown.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				filter, err := as.c.GetPostTagFilter(uid)
+				if err != nil {
+					return err
+				}
+				if filter.IsEmpty() {
+					out.msg("No post tag filter for %s", args[0])
+				} else {
+					out.msg("Post tag filter for %s: include %s, exclude %s",
+						args[0], strings.Join(filter.Include, ","),
+						strings.Join(filter.Exclude, ","))
+				}
+				return nil
+			}
+
+			var include, exclude []string
+			for _, arg := range args[1:] {
+				switch {
+				case arg == "none":
+				case strings.HasPrefix(arg, "+"):
+					include = append(include, arg[1:])
+				case strings.HasPrefix(arg, "-"):
+					exclude = append(exclude, arg[1:])
+				default:
+					return usageError{msg: fmt.Sprintf("tag %q must be "+
+						"prefixed with + or -", arg)}
+				}
+			}
+			if err := as.c.SetPostTagFilter(uid, include, exclude); err != nil {
+				return err
+			}
+			if len(include) == 0 && len(exclude) == 0 {
+				out.msg("Removed post tag filter for %s", args[0])
+			} else {
+				out.msg("Set post tag filter for %s", args[0])
+			}
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "tagfilters",
+		descr: "List the filters of tags of posts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			filters, err := as.c.ListPostTagFilters()
+			if err != nil {
+				return err
+			}
+			if len(filters) == 0 {
+				out.msg("No post tag filters")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Post tag filters")
+				for uid, filter := range filters {
+					nick, _ := as.c.UserNick(uid)
+					if nick == "" {
+						nick = uid.String()
+					}
+					pf("%s: include %s, exclude %s",
+						strescape.Nick(nick),
+						strings.Join(filter.Include, ","),
+						strings.Join(filter.Exclude, ","))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "stats",
+		usage: "[<post id>]",
+		descr: "Show the metrics of local posts",
+		long:  []string{"Without arguments, shows the metrics of all local posts that had any activity. Relays are only counted when the relayer sends the post to the local client."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var stats []client.PostStats
+			if len(args) > 0 {
+				var pid clientintf.PostID
+				if err := pid.FromString(args[0]); err != nil {
+					return err
+				}
+				s, err := as.c.GetPostStats(pid)
+				if err != nil {
+					return err
+				}
+				stats = []client.PostStats{s}
+			} else {
+				var err error
+				stats, err = as.c.ListPostStats()
+				if err != nil {
+					return err
+				}
+			}
+			if len(stats) == 0 {
+				out.msg("No post stats")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Post stats")
+				for _, s := range stats {
+					pf("%s: %d comments, %d hearts, %d reactions, "+
+						"%d relays, %d unlocks (%s)", s.Post,
+						s.Comments, s.Hearts, s.Reactions,
+						len(s.Relayers), len(s.Unlockers),
+						dcrutil.Amount(s.UnlockAtoms))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "churn",
+		usage: "[<days>]",
+		descr: "Show the changes to the subscribers of local posts",
+		long:  []string{"Shows how many users subscribed and unsubscribed to the local posts in the last number of days (default 30)."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			days := 30
+			if len(args) > 0 {
+				var err error
+				days, err = strconv.Atoi(args[0])
+				if err != nil || days <= 0 {
+					return usageError{msg: "days must be a positive number"}
+				}
+			}
+			since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+			churn, err := as.c.PostSubscriberChurn(since)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("Post subscribers in the last %d days: %d subscribed, "+
+					"%d unsubscribed, %d current subscribers", days,
+					churn.Subscribed, churn.Unsubscribed,
+					churn.Subscribers)
+				for _, e := range churn.Events {
+					nick, _ := as.c.UserNick(e.UID)
+					if nick == "" {
+						nick = e.UID.String()
+					}
+					action := "unsubscribed"
+					if e.Subscribed {
+						action = "subscribed"
+					}
+					pf("%s %s %s", e.Timestamp.Format(ISO8601DateTime),
+						strescape.Nick(nick), action)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "import",
+		usage: "<feed url | feed file | markdown dir>",
+		descr: "Import posts from an external blog",
+		long: []string{
+			"Creates posts from the items of an RSS or Atom feed, or from the markdown files of a dir (and its subdirs). Markdown files may have a front matter block with their title, date and tags.",
+			"Posts are created from oldest to newest, one every postimport.interval, and images are embedded in the posts (see the [postimport] config section). Items imported before are skipped, so running the import again only creates the new posts.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "source cannot be empty"}
+			}
+			src := args[0]
+			if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+				var err error
+				src, err = homedir.Expand(src)
+				if err != nil {
+					return err
+				}
+			}
+			out.msg("Importing posts from %s", src)
+			out.goAsync(func() {
+				res, err := as.postImporter.Import(as.ctx, src)
+				if err != nil {
+					out.msg("Unable to import posts from %s: %v", src, err)
+				}
+				out.msgs(func(pf printf) {
+					pf("Imported %d posts from %s (%d already imported)",
+						len(res.Imported), src, res.Skipped)
+					if res.Remaining > 0 {
+						pf("%d posts remaining to import", res.Remaining)
+					}
+				})
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "exportsite",
+		usableOffline: true,
+		usage:         "<dir> [<nick>] [comments] [paywalled]",
+		descr:         "Export posts as a static HTML site",
+		long: []string{
+			"Renders your posts (or the posts by the given user) as a static HTML site in the dir, suitable for hosting with any web server. Images embedded in the posts are written as separate files.",
+			"With 'comments', the comments of the posts are also exported (except those hidden by the post author). With 'paywalled', the unlocked body of paywalled posts is exported; otherwise only their free summary is exported.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "dir cannot be empty"}
+			}
+			dir, err := homedir.Expand(args[0])
+			if err != nil {
+				return err
+			}
+			cfg := postsite.Config{
+				Client: as.c,
+				Log:    as.log,
+			}
+			for _, arg := range args[1:] {
+				switch arg {
+				case "comments":
+					cfg.Comments = true
+				case "paywalled":
+					cfg.Paywalled = true
+				default:
+					if cfg.Author != nil {
+						return usageError{msg: fmt.Sprintf("unknown argument %q", arg)}
+					}
+					uid, err := as.c.UIDByNick(arg)
+					if err != nil {
+						return err
+					}
+					cfg.Author = &uid
+				}
+			}
+			res, err := postsite.Export(cfg, dir)
+			if err != nil {
+				return err
+			}
+			out.msg("Exported %d posts and %d media files to %s",
+				res.Posts, res.Media, dir)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "bookmark",
+		usableOffline: true,
+		usage:         "<nick> <post id> [<comment id>] [#<tag>...] [<note>]",
+		descr:         "Bookmark a post or comment",
+		long:          []string{"Bookmarks are local. Arguments starting with '#' are tags and the remaining arguments are the note of the bookmark. Bookmarking an already bookmarked post or comment replaces its tags and note."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			uid, pid, cid, rest, err := parsePostBookmarkArgs(as, args)
+			if err != nil {
+				return err
+			}
+			var tags, note []string
+			for _, arg := range rest {
+				if strings.HasPrefix(arg, "#") {
+					tags = append(tags, arg)
+				} else {
+					note = append(note, arg)
+				}
+			}
+			bm, err := as.c.BookmarkPost(uid, pid, cid, tags, strings.Join(note, " "))
+			if err != nil {
+				return err
+			}
+			out.msg("Bookmarked %s", postBookmarkDescr(&bm))
+			return nil
+		},
+	}, {
+		cmd:           "unbookmark",
+		usableOffline: true,
+		usage:         "<nick> <post id> [<comment id>]",
+		descr:         "Remove the bookmark of a post or comment",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			uid, pid, cid, _, err := parsePostBookmarkArgs(as, args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemovePostBookmark(uid, pid, cid); err != nil {
+				return err
+			}
+			out.msg("Removed bookmark")
+			return nil
+		},
+	}, {
+		cmd:           "bookmarks",
+		usableOffline: true,
+		usage:         "[#<tag>...] [since:<yyyy-mm-dd>] [<text>]",
+		descr:         "List the bookmarked posts and comments",
+		long:          []string{"Lists the bookmarks that have all the given tags and the text in the post title, comment, note or author nick."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var q client.PostBookmarkQuery
+			var text []string
+			for _, arg := range args {
+				switch {
+				case strings.HasPrefix(arg, "#"):
+					q.Tags = append(q.Tags, arg)
+				case strings.HasPrefix(arg, "since:"):
+					t, err := time.ParseInLocation(ISO8601Date, arg[6:], time.Local)
+					if err != nil {
+						return usageError{msg: fmt.Sprintf("invalid since date: %v", err)}
+					}
+					q.Since = t
+				default:
+					text = append(text, arg)
+				}
+			}
+			q.Text = strings.Join(text, " ")
+			bookmarks, err := as.c.QueryPostBookmarks(q)
+			if err != nil {
+				return err
+			}
+			if len(bookmarks) == 0 {
+				out.msg("No bookmarks found")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Bookmarks")
+				for i := range bookmarks {
+					bm := &bookmarks[i]
+					pf("%s %s", bm.Created.Format(ISO8601Date), postBookmarkDescr(bm))
+					if bm.Comment != nil {
+						pf("  post %s from %s, comment %s", bm.Post,
+							bm.PostFrom, bm.Comment)
+					} else {
+						pf("  post %s from %s", bm.Post, bm.PostFrom)
+					}
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:     "list",
+		aliases: []string{"ls"},
+		usage:   "<nick>",
+		descr:   "List the posts made by the specified user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			cw := as.findOrNewChatWindow(uid, args[0])
+			out.goAsync(func() {
+				err := as.c.ListUserPosts(uid)
+				if err != nil {
+					cw.newInternalMsg(fmt.Sprintf("Unable to list user posts: %v", err))
+					as.repaintIfActive(cw)
+				}
+			})
+			cw.newInternalMsg("Listing user posts")
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "get",
+		usage: "<nick> <post id>",
+		descr: "Fetch post written by a remote user",
+		long:  []string{"The local client must already be a subscriber of the remote user's posts to be able to fetch an older post."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "post id cannot be empty"}
+			}
+
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			var pid clientintf.PostID
+			if err := pid.FromString(args[1]); err != nil {
+				return err
+			}
+
+			cw := as.findOrNewChatWindow(uid, args[0])
+			go as.getUserPost(cw, pid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "unlock",
+		usage: "<nick> <post id>",
+		descr: "Pay to unlock the body of a paywalled post",
+		long:  []string{"The nick is the user the post was received from. The post author must be a known user, who is paid the price advertised in the post."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "post id cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			var pid clientintf.PostID
+			if err := pid.FromString(args[1]); err != nil {
+				return err
+			}
+			if err := as.c.UnlockPost(uid, pid); err != nil {
+				return err
+			}
+			out.msg("Unlocking post %s", pid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "pin",
+		usage: "<post id>",
+		descr: "Pin one of your posts",
+		long:  []string{fmt.Sprintf("New subscribers receive pinned posts before any other posts. Up to %d posts may be pinned.", client.MaxPinnedPosts)},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return pinPost(as, out, args, true)
+		},
+	}, {
+		cmd:   "unpin",
+		usage: "<post id>",
+		descr: "Unpin one of your posts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return pinPost(as, out, args, false)
+		},
+	}, {
+		cmd:   "pinned",
+		descr: "List your pinned posts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			posts, err := as.c.ListPinnedPosts()
+			if err != nil {
+				return err
+			}
+			if len(posts) == 0 {
+				out.msg("No pinned posts")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Pinned posts")
+				for _, post := range posts {
+					pf("%s %s", post.ID, strescape.Content(post.Title))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "moderate",
+		usage: "<post id> <comment id> <hide|unhide|flag|unflag>",
+		descr: "Moderate a comment on one of your posts",
+		long:  []string{"The moderation action is sent to the post's subscribers, who may choose to honor it (see the ignorepostmoderation config option)."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "post id cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "comment id cannot be empty"}
+			}
+			if len(args) < 3 {
+				return usageError{msg: "action cannot be empty"}
+			}
+			var pid clientintf.PostID
+			if err := pid.FromString(args[0]); err != nil {
+				return err
+			}
+			var cid clientintf.ID
+			if err := cid.FromString(args[1]); err != nil {
+				return err
+			}
+			if err := as.c.ModeratePostComment(pid, cid, args[2]); err != nil {
+				return err
+			}
+			out.msg("Moderated (%s) comment %s", args[2], cid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 2 {
+				return wordCompleter([]string{rpc.RMPSModerationHide,
+					rpc.RMPSModerationUnhide, rpc.RMPSModerationFlag,
+					rpc.RMPSModerationUnflag}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "mutecommenter",
+		usage: "<nick>",
+		descr: "Mute a user from commenting on your posts",
+		long:  []string{"Comments from muted users are neither stored nor sent to your subscribers."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.MutePostCommenter(uid, true); err != nil {
+				return err
+			}
+			out.msg("Muted %s from commenting on your posts",
+				strescape.Nick(args[0]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "unmutecommenter",
+		usage: "<nick>",
+		descr: "Unmute a user previously muted from commenting on your posts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.MutePostCommenter(uid, false); err != nil {
+				return err
+			}
+			out.msg("Unmuted %s from commenting on your posts",
+				strescape.Nick(args[0]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "mutedcommenters",
+		descr: "List the users muted from commenting on your posts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			muted, err := as.c.ListMutedPostCommenters()
+			if err != nil {
+				return err
+			}
+			if len(muted) == 0 {
+				out.msg("No muted commenters")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Muted commenters")
+				for uid, ts := range muted {
+					nick, _ := as.c.UserNick(uid)
+					if nick == "" {
+						nick = uid.String()
+					}
+					pf("%s (muted %s)", strescape.Nick(nick),
+						ts.Format(ISO8601DateTime))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "react",
+		usage: "<nick> <post id> <reaction>",
+		descr: "React to a post",
+		long:  []string{"The nick is the user the post was received from. Reactions are sent to the post author, who relays them to the post's subscribers."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return reactToPost(as, out, args, false)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "unreact",
+		usage: "<nick> <post id> <reaction>",
+		descr: "Remove a previously sent reaction from a post",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return reactToPost(as, out, args, true)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "relay",
+		usage: "<from user> <post id> <to user>",
+		descr: "Relay a post made by the from user to the to user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "from user cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "post id cannot be empty"}
+			}
+			if len(args) < 3 {
+				return usageError{msg: "to user cannot be empty"}
+			}
+
+			fromUID, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return fmt.Errorf("from user: %v", err)
+			}
+			var pid clientintf.PostID
+			if err := pid.FromString(args[1]); err != nil {
+				return err
+			}
+			toUID, err := as.c.UIDByNick(args[2])
+			if err != nil {
+				return fmt.Errorf("to user: %v", err)
+			}
+
+			cw := as.findOrNewChatWindow(toUID, args[2])
+			go as.relayPost(fromUID, pid, cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 || len(args) == 2 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "quote",
+		usage: "<from user> <post id> <comment>",
+		descr: "Relay a post made by the from user to all subscribers, with a comment",
+		long:  []string{"The comment is attributed to the local client and shown by subscribers alongside the relayed post."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "from user cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "post id cannot be empty"}
+			}
+			if len(args) < 3 {
+				return usageError{msg: "comment cannot be empty"}
+			}
+
+			fromUID, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return fmt.Errorf("from user: %v", err)
+			}
+			var pid clientintf.PostID
+			if err := pid.FromString(args[1]); err != nil {
+				return err
+			}
+			comment := strings.Join(args[2:], " ")
+			go as.quoteRelayPost(fromUID, pid, comment)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	},
+}
+
+var lnCommands = []tuicmd{
+	{
+		cmd:           "info",
+		usableOffline: true,
+		descr:         "Show basic LN info",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			info, err := as.lnRPC.GetInfo(as.ctx,
+				&lnrpc.GetInfoRequest{})
+			if err != nil {
+				return err
+			}
+
+			out.msgs(func(pf printf) {
+				pf("LN Info")
+				pf("Node ID: %s", info.IdentityPubkey)
+				pf("Version: %s", info.Version)
+				pf("Channels Active: %d, Inactive: %d, Pending: %d",
+					info.NumActiveChannels, info.NumInactiveChannels,
+					info.NumPendingChannels)
+				pf("Synced to chain: %v, to graph: %v",
+					info.SyncedToChain, info.SyncedToGraph)
+				pf("Block height: %d, hash %s", info.BlockHeight,
+					info.BlockHash)
+				if as.lnPC != nil {
+					pf("Connected: %v", as.lnPC.Connected())
+				}
+			})
+			return nil
+		},
+	},
+	{
+		cmd:           "newaddress",
+		usableOffline: true,
+		usage:         "[<account>]",
+		descr:         "Create a new standard P2PKH address from the LN wallet",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var account string
+			if len(args) > 0 {
+				account = args[0]
+			}
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			req := &lnrpc.NewAddressRequest{
+				Type:    lnrpc.AddressType_PUBKEY_HASH,
+				Account: account,
+			}
+			na, err := as.lnRPC.NewAddress(as.ctx, req)
+			if err != nil {
+				return err
+			}
+			out.msg(fmt.Sprintf("Address: %v", na.Address))
+			return nil
+		},
+	},
+	{
+		cmd:           "listpeers",
+		usableOffline: true,
+		aliases:       []string{"lspeers"},
+		descr:         "List peers the LN node is connected to",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			lpr, err := as.lnRPC.ListPeers(as.ctx,
+				&lnrpc.ListPeersRequest{})
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("Peers: %d", len(lpr.Peers))
+				for _, p := range lpr.Peers {
+					pf("- %s %s", p.PubKey, p.Address)
+					pf("  bytesSent:%v bytesRecv:%v", p.BytesSent, p.BytesRecv)
+					pf("  sent:%.8f recv:%.8f", dcrutil.Amount(p.AtomsSent).ToCoin(),
+						dcrutil.Amount(p.AtomsRecv).ToCoin())
+					pf("  inbound:%v pingTime:%v syncType:%v", p.Inbound,
+						time.Duration(p.PingTime), p.SyncType)
+				}
+			})
+			return nil
+		},
+	},
+	{
+		cmd:           "connectpeer",
+		usableOffline: true,
+		usage:         "<pubkey@ip:port>",
+		descr:         "Connect to LN peer in the format pubkey@host",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "destination cannot be empty"}
+			}
+			s := strings.Split(args[0], "@")
+			if len(s) != 2 {
+				return usageError{msg: "destination must be in the form pubkey@host"}
+			}
+			cpr := lnrpc.ConnectPeerRequest{
+				Addr: &lnrpc.LightningAddress{
+					Pubkey: s[0],
+					Host:   s[1],
+				},
+				Perm: false,
+			}
+			out.msg("Attempting to connect to peer %v", args[0])
+			out.goAsync(func() {
+				_, err := as.lnRPC.ConnectPeer(as.ctx, &cpr)
+				if err != nil {
+					out.msg("Unable to connect to "+
+						"peer %v: %v", args[0], err)
+				} else {
+					out.msg("Connected to peer %v", args[0])
+				}
+			})
+			return nil
+		},
+	},
+	{
+		cmd:           "disconnectpeer",
+		usableOffline: true,
+		descr:         "Disconnect from peer identified by pubkey",
+		usage:         "<pubkey>",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "pubkey cannot be empty"}
+			}
+			dpr := lnrpc.DisconnectPeerRequest{
+				PubKey: args[0],
+			}
+			_, err := as.lnRPC.DisconnectPeer(as.ctx, &dpr)
+			if err != nil {
+				return err
+			}
+			out.msg(fmt.Sprintf("Disconnected from peer %v", args[0]))
+			return nil
+		},
+	},
+	{
+		cmd:           "restoremultiscb",
+		usableOffline: true,
+		descr:         "Restore from a multipacked SCB",
+		usage:         "<filename or dir>",
+		long:          []string{"If a dir is specified, the most recent SCB backup file in it is restored."},
+		completer: func(args []string, arg string, as *appState) []string {
+			return fileCompleter(arg)
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "filename cannot be empty"}
+			}
+			fname, err := embeddeddcrlnd.RestoreSCBFile(as.ctx,
+				as.lnRPC, args[0])
+			if err != nil {
+				return err
+			}
+			out.diagMsg("Applied SCB file %s successfully", fname)
+			return nil
+		},
+	},
+	{
+		cmd:           "openchannel",
+		spendsFunds:   true,
+		usableOffline: true,
+		aliases:       []string{"openchan", "opench"},
+		descr:         "Open a channel funded by the local node",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			as.sendMsg(msgLNOpenChannel{})
+			return nil
+		},
+	},
+	{
+		cmd:           "closechannel",
+		usableOffline: true,
+		aliases:       []string{"closechan"},
+		usage:         "<channel-point> [\"force\"]",
+		descr:         "Close a local channel",
+		long:          []string{"If the remote counterparty is offline, the channel can be force-closed by specifying \"force\" as the second parameter."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "channel point cannot be empty"}
+			}
+			var force bool
+			if len(args) > 1 {
+				if args[1] != "force" {
+					return usageError{msg: "second argument must " +
+						"be 'force' or remain empty"}
+				}
+				force = true
+			}
+
+			var chanPoint *lnrpc.ChannelPoint
+			if len(args) < 64 {
+				// Try to find by the prefix of the channel point.
+				chans, err := as.lnRPC.ListChannels(as.ctx,
+					&lnrpc.ListChannelsRequest{})
+				if err != nil {
+					return err
+				}
+				for _, c := range chans.Channels {
+					if strings.HasPrefix(c.ChannelPoint, args[0]) {
+						cp, err := strToChanPoint(c.ChannelPoint)
+						if err != nil {
+							return err
+						} else if chanPoint != nil {
+							return fmt.Errorf("channel prefix %q "+
+								"matches multiple channels", args[0])
+						}
+						chanPoint = cp
+					}
+				}
+				if chanPoint == nil {
+					return fmt.Errorf("channel with "+
+						"ChannelPoint prefix %s not found",
+						args[0])
+				}
+			} else {
+				var err error
+				chanPoint, err = strToChanPoint(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			out.msg("Requesting channel %s to be closed",
+				chanPointToStr(chanPoint))
+			go as.closeChannel(out, chanPoint, force)
+			return nil
+		},
+	},
+	{
+		cmd:           "fundwallet",
+		usableOffline: true,
+		descr:         "Fund wallet",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			as.sendMsg(msgLNFundWallet{})
+			return nil
+		},
+	},
+	{
+		cmd:           "requestrecv",
+		spendsFunds:   true,
+		usableOffline: true,
+		aliases:       []string{"reqrecv"},
+		descr:         "Request receive capacity",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			as.sendMsg(msgLNRequestRecv{})
+			return nil
+		},
+	},
+	{
+		cmd:           "pendingchannels",
+		usableOffline: true,
+		aliases:       []string{"pendingchans"},
+		descr:         "List pending channels",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			chans, err := as.lnRPC.PendingChannels(as.ctx,
+				&lnrpc.PendingChannelsRequest{})
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("Pending Open LN Channels: %d", len(chans.PendingOpenChannels))
+				for _, c := range chans.PendingOpenChannels {
+					remoteNodePub := c.Channel.RemoteNodePub
+					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
+					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
+					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
+					channelPoint := c.Channel.ChannelPoint
+
+					height := c.ConfirmationHeight
 					commitFee := dcrutil.Amount(c.CommitFee).ToCoin()
 					commitSize := c.CommitSize
 					feePerKb := dcrutil.Amount(c.FeePerKb)
@@ -2309,616 +5335,5117 @@ var lnCommands = []tuicmd{
 						height, commitFee, commitSize, feePerKb.ToCoin())
 				}
 
-				pf("Pending Force-Closed Channels: %d", len(chans.PendingForceClosingChannels))
-				for _, c := range chans.PendingForceClosingChannels {
-					remoteNodePub := c.Channel.RemoteNodePub
-					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
-					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
-					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
-					channelPoint := c.Channel.ChannelPoint
+				pf("Pending Force-Closed Channels: %d", len(chans.PendingForceClosingChannels))
+				for _, c := range chans.PendingForceClosingChannels {
+					remoteNodePub := c.Channel.RemoteNodePub
+					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
+					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
+					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
+					channelPoint := c.Channel.ChannelPoint
+
+					closingTx := c.ClosingTxid
+					limboBal := dcrutil.Amount(c.LimboBalance).ToCoin()
+					matHeight := c.MaturityHeight
+					recoveredBal := dcrutil.Amount(c.RecoveredBalance).ToCoin()
+					nbHTLCs := len(c.PendingHtlcs)
+
+					pf("- %s", remoteNodePub)
+					pf("  %s cap:%.8f localBal:%.8f remoteBal:%.8f", channelPoint,
+						capacity, localBal, remoteBal)
+					pf("  limboBalance:%.8f  recoveredBalance:%.8f  htlcs:%d",
+						limboBal, recoveredBal, nbHTLCs)
+					pf("  closingTx:%s maturityHeight:%d", closingTx,
+						matHeight)
+					for i, htlc := range c.PendingHtlcs {
+						amount := dcrutil.Amount(htlc.Amount).ToCoin()
+						pf("    HTLC %d: amount:%.8f  incoming:%v  maturityHeight:%d", i, amount, htlc.Incoming, htlc.MaturityHeight)
+					}
+				}
+
+				pf("Waiting Close Confirmation: %d", len(chans.WaitingCloseChannels))
+				for _, c := range chans.WaitingCloseChannels {
+					remoteNodePub := c.Channel.RemoteNodePub
+					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
+					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
+					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
+					channelPoint := c.Channel.ChannelPoint
+					limbo := dcrutil.Amount(c.LimboBalance).ToCoin()
+
+					pf("- %s", remoteNodePub)
+					pf("  %s cap:%.8f localBal:%.8f remoteBal:%.8f limbo:%.8f",
+						channelPoint, capacity, localBal, remoteBal, limbo)
+				}
+
+			})
+
+			return nil
+		},
+	},
+	{
+		cmd:           "chbalance",
+		usableOffline: true,
+		aliases:       []string{"channelbalance", "chbal"},
+		descr:         "Show current channel balances",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			bal, err := as.lnRPC.ChannelBalance(as.ctx,
+				&lnrpc.ChannelBalanceRequest{})
+			if err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("Channel Balance: %.8f, Max Inbound: %.8f, "+
+				"Max Outbound: %.8f", dcrutil.Amount(bal.Balance).ToCoin(),
+				dcrutil.Amount(bal.MaxInboundAmount).ToCoin(),
+				dcrutil.Amount(bal.MaxOutboundAmount).ToCoin())
+			out.msg(msg)
+			return nil
+		},
+	},
+	{
+		cmd:           "wbalance",
+		usableOffline: true,
+		aliases:       []string{"walletbalance", "wbal"},
+		descr:         "Show current wallet balance",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			bal, err := as.lnRPC.WalletBalance(as.ctx,
+				&lnrpc.WalletBalanceRequest{})
+			if err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("Wallet Balance: %.8f, Confirmed: %.8f, "+
+				"Unconfirmed: %.8f", dcrutil.Amount(bal.TotalBalance).ToCoin(),
+				dcrutil.Amount(bal.ConfirmedBalance).ToCoin(),
+				dcrutil.Amount(bal.UnconfirmedBalance).ToCoin())
+			out.msg(msg)
+			return nil
+		},
+	},
+	{
+		cmd:           "channels",
+		usage:         "[<debug>]",
+		usableOffline: true,
+		descr:         "Show list of active channels",
+		long:          []string{"If 'debug' is specified, then additional info for the channels is presented"},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			debug := len(args) > 0 && args[0] == "debug"
+
+			chans, err := as.lnRPC.ListChannels(as.ctx,
+				&lnrpc.ListChannelsRequest{})
+			if err != nil {
+				return err
+			}
+
+			// Fetch list of aliases.
+			nodeAlias := make(map[string]string)
+			for _, c := range chans.Channels {
+				if _, ok := nodeAlias[c.RemotePubkey]; ok {
+					continue
+				}
+				nodeInfo, _ := as.lnRPC.GetNodeInfo(as.ctx, &lnrpc.NodeInfoRequest{
+					PubKey: c.RemotePubkey,
+				})
+				var alias string
+				if nodeInfo != nil {
+					alias = nodeInfo.Node.Alias
+					if len(alias) > 32 {
+						alias = alias[:32]
+					}
+				} else if len(c.RemotePubkey) > 32 {
+					alias = c.RemotePubkey[:32]
+				} else {
+					nodeAlias[c.RemotePubkey] = c.RemotePubkey
+				}
+				nodeAlias[c.RemotePubkey] = strescape.Nick(alias)
+			}
+
+			out.msgs(func(pf printf) {
+				pf("LN Channels: %d", len(chans.Channels))
+				if !debug {
+					pf("      chan       send                    recv node")
+				}
+				for _, c := range chans.Channels {
+					active := "✓"
+					if !c.Active {
+						active = "✗"
+					}
+					shortCP := c.ChannelPoint[:6]
+					local := fmt.Sprintf("%.8f", float64(c.LocalBalance)/1e8)
+					remote := fmt.Sprintf("%.8f", float64(c.RemoteBalance)/1e8)
+					balDisplay := channelBalanceDisplay(c.LocalBalance, c.RemoteBalance)
+					pf("  %s %s %s %s %s %s", active, shortCP, local, balDisplay, remote,
+						nodeAlias[c.RemotePubkey])
+
+					if debug {
+						sid := lnwire.NewShortChanIDFromInt(c.ChanId)
+						pf("  cp:%s sid:%s cap:%.8f",
+							c.ChannelPoint,
+							sid,
+							dcrutil.Amount(c.Capacity).ToCoin())
+						pf("  pub:%s   localBal:%.8f remoteBal:%.8f",
+							c.RemotePubkey,
+							dcrutil.Amount(c.LocalBalance).ToCoin(),
+							dcrutil.Amount(c.RemoteBalance).ToCoin())
+						pf("  unsettled:%.8f updts:%d htlcs:%d",
+							dcrutil.Amount(c.UnsettledBalance).ToCoin(),
+							c.NumUpdates,
+							len(c.PendingHtlcs))
+						pf("")
+					}
+				}
+			})
+			return nil
+		},
+	},
+	{
+		cmd:           "closedchannels",
+		aliases:       []string{"closedchans"},
+		usableOffline: true,
+		descr:         "Show list of closed channels",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			chans, err := as.lnRPC.ClosedChannels(as.ctx,
+				&lnrpc.ClosedChannelsRequest{})
+			if err != nil {
+				return err
+			}
+
+			out.msgs(func(pf printf) {
+				pf("Closed LN Channels: %d", len(chans.Channels))
+				for _, c := range chans.Channels {
+					sid := lnwire.NewShortChanIDFromInt(c.ChanId)
+					pf("  cp:%s sid:%s cap:%.8f typ: %s",
+						c.ChannelPoint,
+						sid,
+						dcrutil.Amount(c.Capacity).ToCoin(),
+						c.CloseType)
+					pf("    %s   settled:%.8f timelocked:%.8f",
+						c.RemotePubkey,
+						dcrutil.Amount(c.SettledBalance).ToCoin(),
+						dcrutil.Amount(c.TimeLockedBalance).ToCoin())
+					pf("    tx: %s   height: %d",
+						c.ClosingTxHash, c.CloseHeight)
+				}
+			})
+			return nil
+		},
+	},
+
+	{
+		cmd:           "feereport",
+		aliases:       []string{"fees"},
+		usableOffline: true,
+		descr:         "Show the routing fees of channels and the fees earned forwarding payments",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			report, err := as.lnRPC.FeeReport(as.ctx,
+				&lnrpc.FeeReportRequest{})
+			if err != nil {
+				return err
+			}
+
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Forwarding fees earned")
+				pf("Last day: %s, week: %s, month: %s",
+					dcrutil.Amount(report.DayFeeSum),
+					dcrutil.Amount(report.WeekFeeSum),
+					dcrutil.Amount(report.MonthFeeSum))
+				pf("Channel fees: %d", len(report.ChannelFees))
+				for _, c := range report.ChannelFees {
+					sid := lnwire.NewShortChanIDFromInt(c.ChanId)
+					pf("  cp:%s sid:%s base:%d matoms rate:%d ppm",
+						c.ChannelPoint, sid, c.BaseFeeMAtoms,
+						c.FeePerMil)
+				}
+			})
+			return nil
+		},
+	},
+
+	{
+		cmd:         "rebalance",
+		spendsFunds: true,
+		usage:       "<out channel> <in channel> <amount in DCR> [<max fee in DCR>]",
+		descr:       "Move funds between two channels through a circular payment",
+		long: []string{
+			"Pays an invoice generated by the local node through the outbound channel, routed back to the local node through the peer of the inbound channel. This increases the outbound capacity of the inbound channel by decreasing it in the outbound channel.",
+			"Channels are specified by their ChannelPoint (or a unique prefix of it, as shown in '/ln channels'). If the max fee is not specified, the limit of the payment fee policy is used.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 3 {
+				return usageError{msg: "channels and amount must be specified"}
+			}
+			amount, err := strconv.ParseFloat(args[2], 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid amount: %v", err)}
+			}
+			amt, err := dcrutil.NewAmount(amount)
+			if err != nil || amt <= 0 {
+				return usageError{msg: "amount must be a positive DCR amount"}
+			}
+			req := clientintf.RebalanceRequest{
+				OutChannel:   args[0],
+				InChannel:    args[1],
+				AmountMAtoms: int64(amt) * 1000,
+			}
+			if len(args) > 3 {
+				maxFee, err := strconv.ParseFloat(args[3], 64)
+				if err != nil || maxFee <= 0 {
+					return usageError{msg: "max fee must be a positive DCR amount"}
+				}
+				req.MaxFeeMAtoms = int64(maxFee * 1e11)
+			}
+
+			out.msg("Attempting to rebalance %s from channel %s to %s",
+				amt, args[0], args[1])
+			out.goAsync(func() {
+				res, err := as.lnPC.Rebalance(as.ctx, req)
+				if err != nil {
+					out.msg("Unable to rebalance channels: %v", err)
+					return
+				}
+				out.msg("Rebalanced %s from channel %s to %s "+
+					"(fee %.8f DCR, %d hops)",
+					dcrutil.Amount(res.AmountMAtoms/1000),
+					res.OutChanPoint, res.InChanPoint,
+					float64(res.FeeMAtoms)/1e11, res.Hops)
+			})
+			return nil
+		},
+	},
+
+	{
+		cmd:           "autopilot",
+		usableOffline: true,
+		usage:         "[run]",
+		descr:         "Show the status of the channel autopilot",
+		long: []string{
+			"The autopilot opens channels when the outbound capacity falls below the configured target and closes channels with low uptime. It is configured in the [autopilot] section of the config file.",
+			"Use '/ln autopilot run' to evaluate the channels immediately.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.autopilot == nil {
+				return fmt.Errorf("autopilot is not enabled")
+			}
+			if len(args) > 0 && args[0] == "run" {
+				out.goAsync(func() {
+					err := as.autopilot.RunOnce(as.ctx)
+					if err != nil {
+						out.msg("Autopilot run failed: %v", err)
+						return
+					}
+					out.msg("Autopilot run completed")
+				})
+				return nil
+			}
+
+			st := as.autopilot.Status()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Channel autopilot")
+				if st.LastRun.IsZero() {
+					pf("Last run: never")
+				} else {
+					pf("Last run: %s", st.LastRun.Format(ISO8601DateTime))
+				}
+				if st.LastErr != nil {
+					pf("Last error: %v", st.LastErr)
+				}
+				pf("Fees spent: %s of %s budget", st.FeesSpent, st.FeeBudget)
+				for _, a := range st.Actions {
+					verb := "Closed"
+					if a.Open {
+						verb = "Opened"
+					}
+					pf("%s %s channel %s with %s (%s, fee %s): %s",
+						a.Time.Format(ISO8601DateTime), verb,
+						a.ChannelPoint, a.Peer, a.Amount, a.Fee, a.Reason)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "wtclient",
+		usableOffline: true,
+		usage:         "[add <pubkey@host:port> | remove <pubkey> [host:port]]",
+		descr:         "Manage the watchtowers the wallet backs up channel states to",
+		long: []string{
+			"Watchtowers watch the channels of the wallet for breaches while it is offline. The watchtower client of the internal wallet is enabled with the 'watchtowerclient' option of the [payment] section of the config file. For external wallets, it is enabled with the 'wtclient.active' option of the dcrlnd node.",
+			"Without arguments, shows the breach-watch status and the list of towers. Removing a tower without specifying an address removes the tower entirely.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.watchtowers == nil {
+				return fmt.Errorf("LN wallet is disabled")
+			}
+			if len(args) > 0 {
+				switch args[0] {
+				case "add":
+					if len(args) < 2 {
+						return usageError{msg: "tower uri cannot be empty"}
+					}
+					if err := as.watchtowers.AddTower(as.ctx, args[1]); err != nil {
+						return err
+					}
+					out.msg("Added watchtower %s", args[1])
+					return nil
+				case "remove", "rm":
+					if len(args) < 2 {
+						return usageError{msg: "tower pubkey cannot be empty"}
+					}
+					var addr string
+					if len(args) > 2 {
+						addr = args[2]
+					}
+					if err := as.watchtowers.RemoveTower(as.ctx, args[1], addr); err != nil {
+						return err
+					}
+					out.msg("Removed watchtower %s", args[1])
+					return nil
+				default:
+					return usageError{msg: fmt.Sprintf("unknown subcommand %q", args[0])}
+				}
+			}
+
+			st, err := as.watchtowers.Status(as.ctx)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Watchtower client")
+				if st.Protected() {
+					pf("Status: protected")
+				} else {
+					pf("Status: not protected")
+				}
+				pf("Backups: %d (%d pending, %d failed)", st.NumBackups,
+					st.NumPendingBackups, st.NumFailedBackups)
+				pf("Sessions: %d acquired, %d exhausted",
+					st.NumSessionsAcquired, st.NumSessionsExhausted)
+				if len(st.Towers) == 0 {
+					pf("No watchtowers added")
+				}
+				for _, t := range st.Towers {
+					active := ""
+					if t.Active {
+						active = " (active)"
+					}
+					pf("%s%s", t.PubKey, active)
+					pf("  Addresses: %s", strings.Join(t.Addresses, ", "))
+					pf("  Sessions: %d", t.NumSessions)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "feepolicy",
+		usableOffline: true,
+		usage:         "[set <policy> | clear]",
+		descr:         "Show or change the max routing fees of payments",
+		long: []string{
+			"The policy is a comma separated list of tiers in the format <min amount>:<max fee>:<max fee percent>, with amounts in DCR. The tier with the largest min amount that does not exceed a payment applies to it. When both a max fee and a max fee percent are set, the lowest limit applies. An empty limit is not enforced.",
+			"Payments smaller than every tier use the default fee limits. Changes made with this command are not saved to the config file; use the 'feepolicy' option of the [payment] section for that.",
+			"Example: '/ln feepolicy set 0:0.0002:,0.01:0.001:1' limits fees to 0.0002 DCR for payments below 0.01 DCR, and to the lowest of 0.001 DCR and 1% for larger payments.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnPC == nil {
+				return fmt.Errorf("LN wallet is disabled")
+			}
+			if len(args) > 0 {
+				var policy client.PaymentFeePolicy
+				switch args[0] {
+				case "set":
+					if len(args) < 2 {
+						return usageError{msg: "policy cannot be empty"}
+					}
+					var err error
+					policy, err = client.ParsePaymentFeePolicy(strings.Join(args[1:], ","))
+					if err != nil {
+						return err
+					}
+				case "clear":
+				default:
+					return usageError{msg: fmt.Sprintf("unknown subcommand %q", args[0])}
+				}
+				if err := as.lnPC.SetFeePolicy(policy); err != nil {
+					return err
+				}
+			}
+
+			policy := as.lnPC.FeePolicy()
+			out.msgs(func(pf printf) {
+				pf("")
+				if len(policy) == 0 {
+					pf("Using default routing fee limits")
+					return
+				}
+				pf("Routing fee policy")
+				for _, t := range policy {
+					maxFee := dcrutil.Amount(t.MaxFeeMAtoms / 1000)
+					var limit string
+					switch {
+					case t.MaxFeeMAtoms > 0 && t.MaxFeePercent > 0:
+						limit = fmt.Sprintf("lowest of %s and %g%%",
+							maxFee, t.MaxFeePercent)
+					case t.MaxFeeMAtoms > 0:
+						limit = maxFee.String()
+					default:
+						limit = fmt.Sprintf("%g%%", t.MaxFeePercent)
+					}
+					pf("Payments from %s: max fee %s",
+						dcrutil.Amount(t.MinMAtoms/1000), limit)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "inbound",
+		usableOffline: true,
+		usage:         "[run]",
+		descr:         "Show the status of the automatic inbound liquidity acquisition",
+		long: []string{
+			"When the inbound capacity falls below the configured target, a new inbound channel is requested from the liquidity provider, within the configured fee budget. It is configured in the [inbound] section of the config file.",
+			"Use '/ln inbound run' to check the inbound capacity immediately.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.inbound == nil {
+				return fmt.Errorf("automatic inbound liquidity is not enabled")
+			}
+			if len(args) > 0 && args[0] == "run" {
+				out.goAsync(func() {
+					err := as.inbound.RunOnce(as.ctx)
+					if err != nil {
+						out.msg("Inbound liquidity check failed: %v", err)
+						return
+					}
+					out.msg("Inbound liquidity check completed")
+				})
+				return nil
+			}
+
+			st := as.inbound.Status()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Inbound liquidity")
+				if st.LastRun.IsZero() {
+					pf("Last check: never")
+				} else {
+					pf("Last check: %s", st.LastRun.Format(ISO8601DateTime))
+					pf("Inbound capacity: %s (target %s)", st.Inbound,
+						st.MinInbound)
+				}
+				if st.LastErr != nil {
+					pf("Last error: %v", st.LastErr)
+				}
+				pf("Fees spent: %s of %s budget", st.FeesSpent, st.FeeBudget)
+				for _, a := range st.Acquisitions {
+					pf("%s Acquired channel %s (%s, fee %s): %s",
+						a.Time.Format(ISO8601DateTime), a.ChannelPoint,
+						a.Capacity, a.Fee, a.Reason)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "scbbackup",
+		usableOffline: true,
+		usage:         "[run | verify <filename> | restore [filename or dir]]",
+		descr:         "Show the status of the scheduled SCB backups",
+		long: []string{
+			"The static channel backup (SCB) is exported periodically and whenever the channels change, and stored in the local dir and remote destinations. It is configured in the [scbbackup] section of the config file.",
+			"Use '/ln scbbackup run' to back up the SCB to all destinations immediately.",
+			"Use '/ln scbbackup verify' to check that a backup file can be decrypted by the wallet.",
+			"Use '/ln scbbackup restore' to restore the most recent backup in the local dir (or the specified file or dir). Restoring asks the peers of the channels to force-close them, so that the funds are recovered on-chain.",
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter([]string{"run", "verify", "restore"}, arg)
+			}
+			return fileCompleter(arg)
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) > 0 {
+				switch args[0] {
+				case "run":
+					if as.scbBackups == nil {
+						return fmt.Errorf("SCB backups are not enabled")
+					}
+					out.goAsync(func() {
+						err := as.scbBackups.RunOnce(as.ctx, true)
+						if err != nil {
+							out.msg("SCB backup failed: %v", err)
+							return
+						}
+						out.msg("SCB backup completed")
+					})
+					return nil
+
+				case "verify":
+					if len(args) < 2 {
+						return usageError{msg: "filename cannot be empty"}
+					}
+					data, err := os.ReadFile(args[1])
+					if err != nil {
+						return err
+					}
+					if err := embeddeddcrlnd.VerifySCB(as.ctx, as.lnRPC, data); err != nil {
+						return fmt.Errorf("invalid SCB file: %v", err)
+					}
+					out.msg("SCB file %s is valid", args[1])
+					return nil
+
+				case "restore":
+					path := as.scbDir
+					if len(args) > 1 {
+						path = args[1]
+					}
+					fname, err := embeddeddcrlnd.RestoreSCBFile(as.ctx,
+						as.lnRPC, path)
+					if err != nil {
+						return err
+					}
+					out.diagMsg("Applied SCB file %s successfully", fname)
+					return nil
+
+				default:
+					return usageError{msg: fmt.Sprintf("unknown subcommand %q", args[0])}
+				}
+			}
+
+			if as.scbBackups == nil {
+				return fmt.Errorf("SCB backups are not enabled")
+			}
+			st := as.scbBackups.Status()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("SCB backups (every %s and on channel changes)", st.Interval)
+				if st.LastRun.IsZero() {
+					pf("Last run: never")
+				} else {
+					pf("Last run: %s", st.LastRun.Format(ISO8601DateTime))
+				}
+				if st.LastErr != nil {
+					pf("Last error: %v", st.LastErr)
+				}
+				for _, d := range st.Destinations {
+					if d.LastBackup.IsZero() {
+						pf("%s: no backups", d.Name)
+					} else {
+						pf("%s: last backup %s (%d channels)", d.Name,
+							d.LastBackup.Format(ISO8601DateTime),
+							d.NumChannels)
+					}
+					if d.LastErr != "" {
+						pf("  Last error (%s): %s",
+							d.LastAttempt.Format(ISO8601DateTime),
+							d.LastErr)
+					}
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "svrnode",
+		usableOffline: true,
+		aliases:       []string{"servernode"},
+		descr:         "Show the server LN node info",
+		long:          []string{"This also queries the local node for the ability to make payments to the remote server"},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			svrNode := as.c.ServerLNNode()
+			if svrNode == "" {
+				return fmt.Errorf("client does not have ID of server node")
+			}
+
+			return as.queryLNNodeInfo(out, svrNode, 1)
+		},
+	}, {
+		cmd:           "invoice",
+		usableOffline: true,
+		usage:         "[amount in DCR] [memo]",
+		aliases:       []string{"addinvoice"},
+		descr:         "Create an LN invoice",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+
+			var amount float64
+			if len(args) > 0 {
+				var err error
+				amount, err = strconv.ParseFloat(args[0], 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("amount is not a number: %v", err)}
+				}
+			}
+			_, memo := popNArgs(rawCmd, 3)
+
+			inv := lnrpc.Invoice{
+				Memo:  memo,
+				Value: int64(amount * 1e8),
+			}
+			res, err := as.lnRPC.AddInvoice(as.ctx, &inv)
+			if err != nil {
+				return err
+			}
+
+			out.msg("Create invoice %x", res.RHash)
+
+			// This is needed because wordwrap.String() doesn't break
+			// words, only sentences.
+			payreq := res.PaymentRequest
+			var msg string
+			for len(payreq) > 0 {
+				if len(payreq) < as.winW {
+					msg += payreq
+					payreq = ""
+				} else {
+					msg += payreq[:as.winW-1]
+					payreq = payreq[as.winW-1:]
+					msg += "\n"
+				}
+			}
+			out.msg(msg)
+			return nil
+		},
+	}, {
+		cmd:           "decodeinvoice",
+		usableOffline: true,
+		usage:         "[invoice]",
+		aliases:       []string{"decinvoice", "decodeinv"},
+		descr:         "Decode an LN invoice",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "invoice cannot be empty"}
+			}
+			payreq := strings.TrimPrefix(args[0], "lnpay://")
+
+			req := &lnrpc.PayReqString{PayReq: payreq}
+			invoice, err := as.lnRPC.DecodePayReq(as.ctx, req)
+			if err != nil {
+				return err
+			}
+
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Decoded Invoice")
+				if invoice.NumMAtoms < 1000 {
+					pf("Amount: %d MAtoms", invoice.NumMAtoms)
+				} else {
+					pf("Amount: %s", dcrutil.Amount(invoice.NumAtoms))
+				}
+				pf("Destination: %s", invoice.Destination)
+				expiryTime := time.Unix(invoice.Timestamp, 0).Add(time.Duration(invoice.Expiry) * time.Second)
+				pf("Expiry: %s", expiryTime.Format(ISO8601DateTime))
+				pf("CLTV expiry: %d blocks", invoice.CltvExpiry)
+				pf("Description: %s", strescape.Content(invoice.Description))
+			})
+			return nil
+		},
+	}, {
+		cmd:           "payinvoice",
+		spendsFunds:   true,
+		usableOffline: true,
+		usage:         "[invoice]",
+		aliases:       []string{"sendpayment", "pay"},
+		descr:         "Pay an LN invoice",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "invoice cannot be empty"}
+			}
+
+			payreq := strings.TrimPrefix(args[0], "lnpay://")
+
+			out.msg("Attempting to pay invoice")
+			out.goAsync(func() {
+				pc, err := as.lnRPC.SendPayment(as.ctx)
+				if err != nil {
+					out.msg("PC: %v", err)
+					return
+				}
+
+				req := &lnrpc.SendRequest{
+					PaymentRequest: payreq,
+				}
+				err = pc.Send(req)
+				if err != nil {
+					out.msg("Unable to start payment: %v", err)
+				}
+				for res, err := pc.Recv(); ; {
+					if err != nil {
+						out.msg("PC receive error: %v", err)
+						return
+					}
+					if res.PaymentError != "" {
+						out.msg("Payment error: %s", res.PaymentError)
+						return
+					}
+					out.msg("Payment done!")
+					return
+				}
+
+			})
+
+			return nil
+		},
+	}, {
+		cmd:           "queryroute",
+		usableOffline: true,
+		usage:         "<dest node ID> [amount in atoms]",
+		descr:         "Query a route to a destination",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if as.lnRPC == nil {
+				return fmt.Errorf("LN client not configured")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "dest node ID cannot be empty"}
+			}
+			var amount uint64 = 1
+			if len(args) > 1 {
+				var err error
+				amount, err = strconv.ParseUint(args[1], 10, 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("amount is not a number: %v", err)}
+				}
+			}
+
+			return as.queryLNNodeInfo(out, args[0], amount)
+		},
+	}, {
+		cmd:           "sendonchain",
+		spendsFunds:   true,
+		usage:         "<DCR amount> <dest address> [<source account>]",
+		descr:         "Send funds from the on-chain wallet",
+		usableOffline: true,
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "amount cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "destination address cannot be empty"}
+			}
+			var account string
+			if len(args) > 2 {
+				account = args[2]
+			}
+			dcrAmount, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return fmt.Errorf("amount is not valid: %v", err)
+			}
+			if dcrAmount <= 0 {
+				return usageError{msg: "cannot send non-positive dcr amount"}
+			}
+			amount, err := dcrutil.NewAmount(dcrAmount)
+			if err != nil {
+				return err
+			}
+
+			addr := args[1]
+			out.msg("Sending %s DCR to %s", amount, addr)
+			out.goAsync(func() {
+				req := &lnrpc.SendCoinsRequest{
+					Addr:    addr,
+					Amount:  int64(amount),
+					Account: account,
+				}
+				res, err := as.lnRPC.SendCoins(as.ctx, req)
+				if err != nil {
+					out.msg("Usable to send coins on-chain: %v", err)
+					return
+				}
+				out.msg("Sent coins through tx %s", res)
+			})
+			return nil
+		},
+	}, {
+		cmd:           "debuglevel",
+		usage:         "<level>",
+		usableOffline: true,
+		descr:         "Change the debug level of the internal LN wallet",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "debug level cannot be empty"}
+			}
+			req := &lnrpc.DebugLevelRequest{
+				LevelSpec: args[0],
+			}
+			_, err := as.lnRPC.DebugLevel(as.ctx, req)
+			return err
+		},
+	}, {
+		cmd:           "accounts",
+		usableOffline: true,
+		descr:         "List wallet accounts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			res, err := as.lnWallet.ListAccounts(as.ctx, &walletrpc.ListAccountsRequest{})
+			if err != nil {
+				return err
+			}
+
+			bal, err := as.lnRPC.WalletBalance(as.ctx, &lnrpc.WalletBalanceRequest{})
+			if err != nil {
+				return err
+			}
+
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Wallet accounts (%d)", len(res.Accounts))
+				for _, acc := range res.Accounts {
+					acctBal := dcrutil.Amount(bal.AccountBalance[acc.Name].ConfirmedBalance + bal.AccountBalance[acc.Name].UnconfirmedBalance)
+					pf("%s - %s - keys: %d internal, %d external",
+						acc.Name, acctBal, acc.InternalKeyCount,
+						acc.ExternalKeyCount)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "newaccount",
+		usage:         "<name>",
+		usableOffline: true,
+		descr:         "Create a new wallet account",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "account name cannot be empty"}
+			}
+			name := strings.TrimSpace(args[0])
+			if len(name) == 0 {
+				return usageError{msg: "account name cannot be empty string"}
+			}
+
+			_, err := as.lnWallet.DeriveNextAccount(as.ctx, &walletrpc.DeriveNextAccountRequest{Name: name})
+			if err != nil {
+				return err
+			}
+			out.msg("Created account %s", name)
+			return nil
+		},
+	}, {
+		cmd:           "rescanwallet",
+		usage:         "[<start height>]",
+		usableOffline: true,
+		descr:         "Rescan for on-chain wallet transactions",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var beginHeight int32
+			if len(args) > 0 {
+				h, err := strconv.ParseInt(args[0], 10, 32)
+				if err != nil {
+					return err
+				}
+				beginHeight = int32(h)
+			}
+
+			out.goAsync(func() {
+				req := &walletrpc.RescanWalletRequest{BeginHeight: beginHeight}
+				s, err := as.lnWallet.RescanWallet(as.ctx, req)
+				if err != nil {
+					out.msg("Unable to rescan wallet: %v", err)
+					return
+				}
+
+				t := time.Now()
+				out.msg("Starting rescan at height %d", beginHeight)
+				var lastHeight int32
+				ntf, err := s.Recv()
+				for ; err == nil; ntf, err = s.Recv() {
+					if time.Since(t) > 5*time.Second {
+						out.msg("Rescanned up to block %d", ntf.ScannedThroughHeight)
+						t = time.Now()
+					}
+					lastHeight = ntf.ScannedThroughHeight
+				}
+				if err == nil || errors.Is(err, io.EOF) {
+					out.msg("Finished rescan at height %d", lastHeight)
+				} else {
+					out.msg("Error during rescan (last height %d): %v",
+						lastHeight, err)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "listtxs",
+		usableOffline: true,
+		aliases:       []string{"listtransactions"},
+		descr:         "List on-chain wallet transactions",
+		usage:         "[<start height>] [<end height>]",
+		long: []string{
+			"If start height is not specified, it defaults to -1. If end height is not specified, it defaults to 0.",
+			"This makes the listing include unconfirmed transactions and returns most recent transactions first.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var startHeight, endHeight int32 = 0, 0
+			if len(args) > 0 {
+				i, err := strconv.ParseInt(args[0], 10, 32)
+				if err != nil {
+					return fmt.Errorf("start height parsing error: %v", err)
+				}
+				startHeight = int32(i)
+			}
+			if len(args) > 1 {
+				i, err := strconv.ParseInt(args[1], 10, 32)
+				if err != nil {
+					return fmt.Errorf("end height parsing error: %v", err)
+				}
+				endHeight = int32(i)
+			}
+
+			// TODO: What if GetTransactions it too large?
+			out.msg("Fetching list of transactions...")
+			out.goAsync(func() {
+				req := &lnrpc.GetTransactionsRequest{StartHeight: startHeight, EndHeight: endHeight}
+				txs, err := as.lnRPC.GetTransactions(as.ctx, req)
+				if err != nil {
+					errMsg := fmt.Sprintf("Unable to list transactions: %v", err)
+					out.diagMsg(as.styles.Load().err.Render(errMsg))
+					return
+				}
+
+				out.msgs(func(pf printf) {
+					pf("")
+					pf("Wallet transactions")
+					pf("       Net Amount -  Height - Tx Hash")
+					for _, tx := range txs.Transactions {
+						value := dcrutil.Amount(tx.Amount)
+						pf("%13.8f DCR - %7d - %s",
+							value.ToCoin(), tx.BlockHeight, tx.TxHash)
+					}
+				})
+			})
+			return nil
+		},
+	},
+}
+
+var pagesCommands = []tuicmd{
+	{
+		cmd:   "view",
+		descr: "View user page",
+		usage: "<nick> [<path/to/page>]",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			nextSess, err := as.c.NewPagesSession()
+			if err != nil {
+				return err
+			}
+
+			pagePath := "index.md"
+			if len(args) > 1 {
+				pagePath = strings.TrimSpace(args[1])
+			}
+			return as.fetchPage(uid, pagePath, nextSess, 0, nil)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "local",
+		descr: "View local user page",
+		usage: "[<path/to/page>]",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			pagePath := "index.md"
+			if len(args) > 1 {
+				pagePath = strings.TrimSpace(args[1])
+			}
+
+			// Always use the same session ID for convenience when
+			// fetching a local page.
+			nextSess := clientintf.PagesSessionID(0)
+			return as.fetchPage(as.c.PublicID(), pagePath, nextSess, 0, nil)
+		},
+	},
+}
+
+var pendingRMCommands = []tuicmd{
+	{
+		cmd:           "list",
+		usableOffline: true,
+		aliases:       []string{"ls"},
+		usage:         "[user]",
+		descr:         "List outbound messages not yet acked by the server",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var uid *clientintf.UserID
+			if len(args) > 0 {
+				ru, err := as.c.UserByNick(args[0])
+				if err != nil {
+					return err
+				}
+				id := ru.ID()
+				uid = &id
+			}
+
+			rms, err := as.c.ListPendingRMs(uid)
+			if err != nil {
+				return err
+			}
+			if len(rms) == 0 {
+				out.msg("No pending outbound messages")
+				return nil
+			}
+
+			now := time.Now()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Pending outbound messages (%d total)", len(rms))
+				for _, rm := range rms {
+					nick, _ := as.c.UserNick(rm.UID)
+					status := "queued"
+					if rm.Encrypted {
+						status = "sending"
+					}
+					pf("%d - %s %s pri=%d size=%s age=%s %s",
+						rm.ID, strescape.Nick(nick), rm.Type,
+						rm.Priority, hbytes(int64(rm.Size)),
+						now.Sub(rm.QueuedAt).Truncate(time.Second),
+						status)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "cancel",
+		usage: "<user> <id>",
+		descr: "Cancel sending a pending outbound message",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "user and id must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			id, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid id: %v", err)}
+			}
+			if err := as.c.CancelPendingRM(ru.ID(), id); err != nil {
+				return err
+			}
+			out.msg("Canceled pending message %d to %s", id,
+				strescape.Nick(ru.Nick()))
+			return nil
+		},
+	}, {
+		cmd:   "pri",
+		usage: "<user> <id> <priority>",
+		descr: "Change the priority (0-4) of a pending outbound message",
+		long:  []string{"Messages with lower priority values are sent first."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 3 {
+				return usageError{msg: "user, id and priority must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			id, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid id: %v", err)}
+			}
+			pri, err := strconv.ParseUint(args[2], 10, 32)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid priority: %v", err)}
+			}
+			if err := as.c.ReprioritizePendingRM(ru.ID(), id, uint(pri)); err != nil {
+				return err
+			}
+			out.msg("Changed priority of pending message %d to %d",
+				id, pri)
+			return nil
+		},
+	},
+}
+
+// parseSearchArgs parses the arguments of a message search. Arguments in the
+// form "since:<date>" and "until:<date>" restrict the search to a date range.
+// pinPost pins or unpins a local post, given the arguments of the "/post pin"
+// and "/post unpin" commands.
+func pinPost(as *appState, out *cmdOutput, args []string, pin bool) error {
+	if len(args) < 1 {
+		return usageError{msg: "post id cannot be empty"}
+	}
+	var pid clientintf.PostID
+	if err := pid.FromString(args[0]); err != nil {
+		return err
+	}
+	if err := as.c.PinPost(pid, pin); err != nil {
+		return err
+	}
+	if pin {
+		out.msg("Pinned post %s", pid)
+	} else {
+		out.msg("Unpinned post %s", pid)
+	}
+	return nil
+}
+
+// reactToPost adds or removes a reaction on a post, given the arguments of the
+// "/post react" and "/post unreact" commands.
+func reactToPost(as *appState, out *cmdOutput, args []string, remove bool) error {
+	if len(args) < 1 {
+		return usageError{msg: "nick cannot be empty"}
+	}
+	if len(args) < 2 {
+		return usageError{msg: "post id cannot be empty"}
+	}
+	if len(args) < 3 {
+		return usageError{msg: "reaction cannot be empty"}
+	}
+	uid, err := as.c.UIDByNick(args[0])
+	if err != nil {
+		return err
+	}
+	var pid clientintf.PostID
+	if err := pid.FromString(args[1]); err != nil {
+		return err
+	}
+	reaction := strings.Join(args[2:], " ")
+	if err := as.c.ReactToPost(uid, pid, reaction, remove); err != nil {
+		return err
+	}
+	if remove {
+		out.msg("Removed reaction %s from post %s", reaction, pid)
+	} else {
+		out.msg("Reacted with %s to post %s", reaction, pid)
+	}
+	return nil
+}
+
+// parsePostBookmarkArgs parses the post and optional comment of the
+// arguments of the bookmark commands. Returns the remaining arguments.
+func parsePostBookmarkArgs(as *appState, args []string) (clientintf.UserID,
+	clientintf.PostID, *clientintf.ID, []string, error) {
+
+	var uid clientintf.UserID
+	var pid clientintf.PostID
+	if len(args) < 1 {
+		return uid, pid, nil, nil, usageError{msg: "nick cannot be empty"}
+	}
+	if len(args) < 2 {
+		return uid, pid, nil, nil, usageError{msg: "post id cannot be empty"}
+	}
+	uid, err := as.c.UIDByNick(args[0])
+	if err != nil {
+		return uid, pid, nil, nil, err
+	}
+	if err := pid.FromString(args[1]); err != nil {
+		return uid, pid, nil, nil, err
+	}
+	args = args[2:]
+	var cid clientintf.ID
+	if len(args) > 0 && cid.FromString(args[0]) == nil {
+		return uid, pid, &cid, args[1:], nil
+	}
+	return uid, pid, nil, args, nil
+}
+
+// postBookmarkDescr returns a one line description of a bookmark.
+func postBookmarkDescr(bm *client.PostBookmark) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q", strescape.Content(bm.Title))
+	if bm.Comment != nil {
+		fmt.Fprintf(&b, " comment by %s: %s", strescape.Nick(bm.AuthorNick),
+			strescape.Content(bm.Excerpt))
+	} else if bm.AuthorNick != "" {
+		fmt.Fprintf(&b, " by %s", strescape.Nick(bm.AuthorNick))
+	}
+	for _, tag := range bm.Tags {
+		fmt.Fprintf(&b, " #%s", tag)
+	}
+	if bm.Note != "" {
+		fmt.Fprintf(&b, " (%s)", strescape.Content(bm.Note))
+	}
+	return b.String()
+}
+
+func parseSearchArgs(args []string, filter *clientdb.MsgSearchFilter) (string, error) {
+	var terms []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "since:"):
+			t, err := time.ParseInLocation(ISO8601Date, arg[6:], time.Local)
+			if err != nil {
+				return "", usageError{msg: fmt.Sprintf("invalid since date: %v", err)}
+			}
+			filter.Since = t
+		case strings.HasPrefix(arg, "until:"):
+			t, err := time.ParseInLocation(ISO8601Date, arg[6:], time.Local)
+			if err != nil {
+				return "", usageError{msg: fmt.Sprintf("invalid until date: %v", err)}
+			}
+			filter.Until = t.AddDate(0, 0, 1).Add(-time.Second)
+		default:
+			terms = append(terms, arg)
+		}
+	}
+	if len(terms) == 0 {
+		return "", usageError{msg: "search terms cannot be empty"}
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// searchMsgs runs a message search and shows the results.
+func searchMsgs(as *appState, out *cmdOutput, query string, filter clientdb.MsgSearchFilter) error {
+	const maxResults = 50
+	filter.Limit = maxResults
+	results, err := as.c.SearchMessages(query, filter)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		out.msg("No messages found")
+		return nil
+	}
+
+	out.msgs(func(pf printf) {
+		pf("")
+		pf("Found %d messages (newest first)", len(results))
+		for _, r := range results {
+			var conv string
+			if r.GC != nil {
+				conv, _ = as.c.GetGCAlias(*r.GC)
+			} else if r.UID != nil {
+				conv, _ = as.c.UserNick(*r.UID)
+			}
+			pf("%s [%s] <%s> %s", r.Timestamp.Format(ISO8601DateTime),
+				strescape.Nick(conv), strescape.Nick(r.From),
+				strescape.Content(r.Message))
+		}
+	})
+	return nil
+}
+
+var searchCommands = []tuicmd{
+	{
+		cmd:           "all",
+		usableOffline: true,
+		usage:         "<terms...>",
+		descr:         "Search messages in all conversations",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var filter clientdb.MsgSearchFilter
+			query, err := parseSearchArgs(args, &filter)
+			if err != nil {
+				return err
+			}
+			return searchMsgs(as, out, query, filter)
+		},
+	}, {
+		cmd:           "in",
+		usableOffline: true,
+		usage:         "<nick or gc> <terms...>",
+		descr:         "Search messages exchanged with a user or in a GC",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "user or gc and search terms must be specified"}
+			}
+			var filter clientdb.MsgSearchFilter
+			if ru, err := as.c.UserByNick(args[0]); err == nil {
+				uid := ru.ID()
+				filter.UID = &uid
+			} else if gcID, err := as.c.GCIDByName(args[0]); err == nil {
+				filter.GC = &gcID
+			} else {
+				return fmt.Errorf("%q is not a user or GC", args[0])
+			}
+			query, err := parseSearchArgs(args[1:], &filter)
+			if err != nil {
+				return err
+			}
+			return searchMsgs(as, out, query, filter)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "reindex",
+		usableOffline: true,
+		descr:         "Rebuild the search index from the message logs",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			out.goAsync(func() {
+				count, err := as.c.RebuildMsgSearchIndex()
+				if err != nil {
+					out.msg("Unable to rebuild search index: %v", err)
+					return
+				}
+				out.msg("Indexed %d messages", count)
+			})
+			return nil
+		},
+	},
+}
+
+var contactCommands = []tuicmd{
+	{
+		cmd:           "show",
+		usableOffline: true,
+		usage:         "<nick>",
+		descr:         "Show the notes, tags and fields of a user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			meta, err := as.c.ContactMetadata(ru.ID())
+			if err != nil {
+				return err
+			}
+			keys := maps.Keys(meta.Fields)
+			sort.Strings(keys)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Contact info for %s", strescape.Nick(ru.Nick()))
+				pf("Tags: %s", strings.Join(meta.Tags, ", "))
+				for _, k := range keys {
+					pf("%s: %s", k, meta.Fields[k])
+				}
+				if meta.Notes != "" {
+					pf("Notes:")
+					pf("%s", meta.Notes)
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "notes",
+		usableOffline: true,
+		usage:         "<nick> [notes]",
+		descr:         "Set the private notes about a user (empty to clear)",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			notes := strings.Join(args[1:], " ")
+			if err := as.c.SetContactNotes(ru.ID(), notes); err != nil {
+				return err
+			}
+			out.msg("Updated notes about %s", strescape.Nick(ru.Nick()))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "tag",
+		usableOffline: true,
+		usage:         "<nick> <tags...>",
+		descr:         "Add tags to a user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and tags must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.AddContactTags(ru.ID(), args[1:]...); err != nil {
+				return err
+			}
+			out.msg("Tagged %s", strescape.Nick(ru.Nick()))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return contactTagCompleter(arg, as)
+		},
+	}, {
+		cmd:           "untag",
+		usableOffline: true,
+		usage:         "<nick> <tags...>",
+		descr:         "Remove tags from a user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and tags must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemoveContactTags(ru.ID(), args[1:]...); err != nil {
+				return err
+			}
+			out.msg("Untagged %s", strescape.Nick(ru.Nick()))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return contactTagCompleter(arg, as)
+		},
+	}, {
+		cmd:           "field",
+		usableOffline: true,
+		usage:         "<nick> <key> [value]",
+		descr:         "Set a custom field of a user (empty value to remove)",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and key must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			value := strings.Join(args[2:], " ")
+			if err := as.c.SetContactField(ru.ID(), args[1], value); err != nil {
+				return err
+			}
+			out.msg("Updated field %q of %s", args[1], strescape.Nick(ru.Nick()))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "tagged",
+		usableOffline: true,
+		usage:         "<tag>",
+		descr:         "List users with a tag",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "tag cannot be empty"}
+			}
+			uids, err := as.c.ContactsByTag(args[0])
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Users tagged %q", args[0])
+				for _, uid := range uids {
+					nick, _ := as.c.UserNick(uid)
+					pf("%s %s", uid, strescape.Nick(nick))
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactTagCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "tags",
+		usableOffline: true,
+		descr:         "List all tags in use",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			tags, err := as.c.ListContactTags()
+			if err != nil {
+				return err
+			}
+			keys := maps.Keys(tags)
+			sort.Strings(keys)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Contact tags")
+				for _, tag := range keys {
+					pf("%s (%d users)", tag, tags[tag])
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "export",
+		usableOffline: true,
+		usage:         "<file> <passphrase>",
+		descr:         "Export the address book to an encrypted file",
+		long: []string{"The export includes the identity, nick, verification state and tags of every contact, encrypted with a key derived from the passphrase.",
+			"The file may be imported in another client with /contact import."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "file and passphrase cannot be empty"}
+			}
+			destPath := cleanAndExpandPath(args[0])
+			bundle, err := as.c.ExportAddressBook(args[1])
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(destPath, bundle, 0o600); err != nil {
+				return err
+			}
+			out.msg("Exported address book to %s", destPath)
+			return nil
+		},
+	}, {
+		cmd:           "import",
+		usableOffline: true,
+		usage:         "<file> <passphrase> [keeplocal|preferimported]",
+		descr:         "Import and merge an exported address book",
+		long: []string{"Tags and verifications of contacts that already exist in the local client are merged. Verifications are only imported when they refer to the current keys of the contact.",
+			"With keeplocal (the default), the local nicks of existing contacts are kept. With preferimported, they are replaced by the imported nicks.",
+			"Imported contacts that do not exist in the local client are listed, as a KX is needed to add them."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "file and passphrase cannot be empty"}
+			}
+			strategy := client.ABMergeKeepLocal
+			if len(args) > 2 {
+				strategy = client.AddressBookMergeStrategy(args[2])
+				if !strategy.IsValid() {
+					return usageError{msg: fmt.Sprintf("unknown merge strategy %q", args[2])}
+				}
+			}
+			bundle, err := os.ReadFile(cleanAndExpandPath(args[0]))
+			if err != nil {
+				return err
+			}
+			export, err := client.DecodeAddressBookExport(args[1], bundle)
+			if err != nil {
+				return err
+			}
+			res, err := as.c.ImportAddressBook(export, strategy)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Imported address book exported at %s",
+					export.Created.Format(ISO8601DateTime))
+				pf("Merged: %d, unchanged: %d", len(res.Merged),
+					len(res.Unchanged))
+				for _, c := range res.Conflicts {
+					pf("Conflict with %s: %s", strescape.Nick(c.Nick),
+						c.Reason)
+				}
+				if len(res.Unknown) > 0 {
+					pf("Contacts that need a KX:")
+				}
+				for _, c := range res.Unknown {
+					pf("%s %s", c.ID.Identity,
+						strescape.Nick(c.NickAlias))
+				}
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 2 {
+				return wordCompleter([]string{"keeplocal", "preferimported"}, arg)
+			}
+			return nil
+		},
+	},
+}
+
+// contactTagCompleter completes with the list of existing contact tags.
+func contactTagCompleter(arg string, as *appState) []string {
+	tags, err := as.c.ListContactTags()
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for tag := range tags {
+		if strings.HasPrefix(tag, arg) {
+			res = append(res, tag)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// broadcastListCompleter completes the names of broadcast lists.
+func broadcastListCompleter(arg string, as *appState) []string {
+	lists, err := as.c.ListBroadcastLists()
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, bl := range lists {
+		if strings.HasPrefix(bl.Name, arg) {
+			res = append(res, bl.Name)
+		}
+	}
+	return res
+}
+
+// broadcastMembers resolves the given nicks into user IDs.
+func broadcastMembers(as *appState, nicks []string) ([]clientintf.UserID, error) {
+	uids := make([]clientintf.UserID, 0, len(nicks))
+	for _, nick := range nicks {
+		ru, err := as.c.UserByNick(nick)
+		if err != nil {
+			return nil, err
+		}
+		uids = append(uids, ru.ID())
+	}
+	return uids, nil
+}
+
+var broadcastCommands = []tuicmd{
+	{
+		cmd:           "list",
+		usableOffline: true,
+		descr:         "List the broadcast lists",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			lists, err := as.c.ListBroadcastLists()
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Broadcast lists")
+				for _, bl := range lists {
+					nicks := make([]string, len(bl.Members))
+					for i, uid := range bl.Members {
+						nick, _ := as.c.UserNick(uid)
+						nicks[i] = strescape.Nick(nick)
+					}
+					pf("%s: %s", bl.Name, strings.Join(nicks, ", "))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "new",
+		usableOffline: true,
+		usage:         "<name> [nicks...]",
+		descr:         "Create (or replace) a broadcast list",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "name cannot be empty"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.StoreBroadcastList(args[0], uids); err != nil {
+				return err
+			}
+			out.msg("Created broadcast list %q with %d members",
+				args[0], len(uids))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) > 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "add",
+		usableOffline: true,
+		usage:         "<name> <nicks...>",
+		descr:         "Add users to a broadcast list",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and nicks must be specified"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.AddToBroadcastList(args[0], uids...); err != nil {
+				return err
+			}
+			out.msg("Added %d users to broadcast list %q",
+				len(uids), args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return broadcastListCompleter(arg, as)
+			}
+			return nickCompleter(arg, as)
+		},
+	}, {
+		cmd:           "del",
+		usableOffline: true,
+		usage:         "<name> <nicks...>",
+		descr:         "Remove users from a broadcast list",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and nicks must be specified"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemoveFromBroadcastList(args[0], uids...); err != nil {
+				return err
+			}
+			out.msg("Removed %d users from broadcast list %q",
+				len(uids), args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return broadcastListCompleter(arg, as)
+			}
+			return nickCompleter(arg, as)
+		},
+	}, {
+		cmd:           "rm",
+		usableOffline: true,
+		usage:         "<name>",
+		descr:         "Remove a broadcast list",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "name cannot be empty"}
+			}
+			if err := as.c.RemoveBroadcastList(args[0]); err != nil {
+				return err
+			}
+			out.msg("Removed broadcast list %q", args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return broadcastListCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "send",
+		usage: "<name> <msg>",
+		descr: "Send a message as a PM to every member of a broadcast list",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and msg must be specified"}
+			}
+			msg := strings.Join(args[1:], " ")
+			out.goAsync(func() {
+				bs, err := as.c.SendBroadcast(args[0], msg)
+				if err != nil {
+					out.msg("Unable to send broadcast: %v", err)
+					return
+				}
+				out.msgs(func(pf printf) {
+					pf("Sent broadcast to %d members of list %q",
+						len(bs.MsgIDs)-len(bs.Errors), bs.List)
+					for uid, err := range bs.Errors {
+						nick, _ := as.c.UserNick(uid)
+						pf("Failed to send to %s: %v",
+							strescape.Nick(nick), err)
+					}
+				})
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return broadcastListCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "status",
+		usableOffline: true,
+		descr:         "Show the delivery status of broadcasts sent in this session",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			broadcasts := as.c.ListBroadcasts()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Broadcasts sent in this session")
+				for _, bs := range broadcasts {
+					counts := bs.Counts()
+					pf("%s %q (%d members): %d queued, %d sent, "+
+						"%d acked, %d delivered, %d failed",
+						bs.Timestamp.Format(ISO8601DateTime),
+						bs.List, len(bs.MsgIDs),
+						counts[client.MsgStatusQueued]+counts[client.MsgStatusPaid],
+						counts[client.MsgStatusSent],
+						counts[client.MsgStatusAcked],
+						counts[client.MsgStatusDelivered],
+						counts[client.MsgStatusFailed])
+				}
+			})
+			return nil
+		},
+	},
+}
+
+func contactGroupCompleter(arg string, as *appState) []string {
+	groups, err := as.c.ListContactGroups()
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, cg := range groups {
+		if strings.HasPrefix(cg.Name, arg) {
+			res = append(res, cg.Name)
+		}
+	}
+	return res
+}
+
+// printContactGroupResult prints the result of a batch operation on a contact
+// group.
+func printContactGroupResult(as *appState, out *cmdOutput, action string, res *client.ContactGroupResult) {
+	out.msgs(func(pf printf) {
+		pf("%s %d members of group %q", action, len(res.Succeeded), res.Group)
+		if len(res.Skipped) > 0 {
+			nicks := make([]string, len(res.Skipped))
+			for i, uid := range res.Skipped {
+				nick, _ := as.c.UserNick(uid)
+				nicks[i] = strescape.Nick(nick)
+			}
+			pf("Skipped: %s", strings.Join(nicks, ", "))
+		}
+		for uid, err := range res.Errors {
+			nick, _ := as.c.UserNick(uid)
+			pf("Failed for %s: %v", strescape.Nick(nick), err)
+		}
+	})
+}
+
+var contactGroupCommands = []tuicmd{
+	{
+		cmd:           "list",
+		usableOffline: true,
+		descr:         "List the contact groups",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			groups, err := as.c.ListContactGroups()
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Contact groups")
+				for _, cg := range groups {
+					nicks := make([]string, len(cg.Members))
+					for i, uid := range cg.Members {
+						nick, _ := as.c.UserNick(uid)
+						nicks[i] = strescape.Nick(nick)
+					}
+					pf("%s: %s", cg.Name, strings.Join(nicks, ", "))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "new",
+		usableOffline: true,
+		usage:         "<name> [nicks...]",
+		descr:         "Create (or replace) a contact group",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "name cannot be empty"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.StoreContactGroup(args[0], uids); err != nil {
+				return err
+			}
+			out.msg("Created contact group %q with %d members",
+				args[0], len(uids))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) > 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "add",
+		usableOffline: true,
+		usage:         "<name> <nicks...>",
+		descr:         "Add users to a contact group, creating it if needed",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and nicks must be specified"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.AddToContactGroup(args[0], uids...); err != nil {
+				return err
+			}
+			out.msg("Added %d users to contact group %q",
+				len(uids), args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nickCompleter(arg, as)
+		},
+	}, {
+		cmd:           "del",
+		usableOffline: true,
+		usage:         "<name> <nicks...>",
+		descr:         "Remove users from a contact group",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and nicks must be specified"}
+			}
+			uids, err := broadcastMembers(as, args[1:])
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemoveFromContactGroup(args[0], uids...); err != nil {
+				return err
+			}
+			out.msg("Removed %d users from contact group %q",
+				len(uids), args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nickCompleter(arg, as)
+		},
+	}, {
+		cmd:           "rm",
+		usableOffline: true,
+		usage:         "<name>",
+		descr:         "Remove a contact group",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "name cannot be empty"}
+			}
+			if err := as.c.RemoveContactGroup(args[0]); err != nil {
+				return err
+			}
+			out.msg("Removed contact group %q", args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "msg",
+		usage: "<name> <msg>",
+		descr: "Send a message as a PM to every member of a contact group",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and msg must be specified"}
+			}
+			msg := strings.Join(args[1:], " ")
+			out.goAsync(func() {
+				res, err := as.c.PMContactGroup(args[0], msg)
+				if err != nil {
+					out.msg("Unable to message contact group: %v", err)
+					return
+				}
+				printContactGroupResult(as, out, "Sent message to", &res)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:         "tip",
+		spendsFunds: true,
+		usage:       "<name> <dcr amount>",
+		descr:       "Send a tip with the given dcr amount to every member of a contact group",
+		long: []string{
+			"Each member receives a tip of the given amount. Tips are sent via LN, so members only receive the tip if they are also online and connected to LN.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and amount must be specified"}
+			}
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return err
+			}
+			out.goAsync(func() {
+				const maxAttempts = 1
+				res, err := as.c.TipContactGroup(args[0], dcrAmount, maxAttempts)
+				if err != nil {
+					out.msg("Unable to tip contact group: %v", err)
+					return
+				}
+				printContactGroupResult(as, out, "Started tipping", &res)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "invite",
+		usage: "<name> <gc>",
+		descr: "Invite every member of a contact group to a GC",
+		long: []string{
+			"Members that are already part of the GC are skipped.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and gc must be specified"}
+			}
+			gcID, err := as.c.GCIDByName(args[1])
+			if err != nil {
+				return err
+			}
+			res, err := as.c.InviteContactGroupToGC(args[0], gcID)
+			if err != nil {
+				return err
+			}
+			printContactGroupResult(as, out, "Invited", &res)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			if len(args) == 1 {
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "filter",
+		usableOffline: true,
+		usage:         "<name> [gc=<gc> | noGC] [noPost] [noPC] [noPM] [embed=<type>] [action=<hide|mute|flag>] [--] [regexp]",
+		descr:         "Add a content filter rule for every member of a contact group",
+		long: []string{
+			"Adds one content filter rule for each member of the contact group. The options are the same as the ones of the '/filter addrule' command, except that the user of each rule is set to the respective member.",
+			"",
+			"Members added to the group afterwards are not affected by the rules. Rules may be listed and removed with the '/filter' commands.",
+		},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "name and filter must be specified"}
+			}
+			cf, err := parseContentFilterArgs(as, rawCmd, 3, args[1:]) // cmd+subcmd+name
+			if err != nil {
+				return err
+			}
+			res, err := as.c.ApplyContactGroupFilter(args[0], cf)
+			if err != nil {
+				return err
+			}
+			printContactGroupResult(as, out, "Added filter rule for", &res)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return contactGroupCompleter(arg, as)
+			}
+			return nil
+		},
+	},
+}
+
+// deviceSyncTarget returns the ID of the contact or GC of the primary device
+// with the given nick or name, as received in the last device sync.
+func deviceSyncTarget(as *appState, name string) (uid, gc *zkidentity.ShortID, err error) {
+	ds, err := as.c.LastDeviceSync()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range ds.Sync.Contacts {
+		if strings.EqualFold(ds.Sync.Contacts[i].Nick, name) {
+			return &ds.Sync.Contacts[i].ID, nil, nil
+		}
+	}
+	for i := range ds.Sync.GCs {
+		if strings.EqualFold(ds.Sync.GCs[i].Name, name) {
+			return nil, &ds.Sync.GCs[i].ID, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%q is not a contact or GC of the primary device", name)
+}
+
+// deviceSyncCompleter completes the nicks of contacts and names of GCs of the
+// primary device.
+func deviceSyncCompleter(arg string, as *appState) []string {
+	ds, err := as.c.LastDeviceSync()
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, c := range ds.Sync.Contacts {
+		if strings.HasPrefix(c.Nick, arg) {
+			res = append(res, c.Nick)
+		}
+	}
+	for _, gc := range ds.Sync.GCs {
+		if strings.HasPrefix(gc.Name, arg) {
+			res = append(res, gc.Name)
+		}
+	}
+	return res
+}
+
+// pairedDeviceCompleter completes the IDs of the paired devices.
+func pairedDeviceCompleter(arg string, as *appState) []string {
+	devices, err := as.c.ListPairedDevices()
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for _, dev := range devices {
+		id := dev.ID.String()
+		if strings.HasPrefix(id, arg) {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+var deviceCommands = []tuicmd{
+	{
+		cmd:           "list",
+		usableOffline: true,
+		descr:         "List the paired devices",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			devices, err := as.c.ListPairedDevices()
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				if len(devices) == 0 {
+					pf("No paired devices")
+					return
+				}
+				pf("")
+				pf("Paired devices")
+				for _, dev := range devices {
+					lastSync := "never"
+					if !dev.LastSync.IsZero() {
+						lastSync = dev.LastSync.Format(ISO8601DateTime)
+					}
+					pf("%s (%s) - paired: %s, last sync: %s", dev.ID,
+						dev.Role, dev.Paired.Format(ISO8601DateTime),
+						lastSync)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "pair",
+		usage: "<pairing-file>",
+		descr: "Create the pairing for a new secondary device",
+		long: []string{"The pairing includes the local identity, contacts, GCs and recent history, encrypted with a new seed. The seed words are displayed once and are required to use the pairing, so they must be copied to the new device along with the pairing file.",
+			"To pair, start brclient on the new device on an empty root dir with the -pairdevice flag pointing to the pairing file and type the seed words when asked. Both devices run the same identity, but only the primary device exchanges messages with the contacts. The secondary device receives the messages of the primary device and sends messages through it (see '/device pm' and '/device gcm').",
+			"Use '/device handoff' to transfer the ratchets to the secondary device, making it the primary device."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "pairing file cannot be empty"}
+			}
+			destPath := cleanAndExpandPath(args[0])
+
+			words, bundle, err := as.c.CreateDevicePairing()
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(destPath, bundle, 0o600); err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Wrote device pairing to %s", destPath)
+				pf("Seed words (write them down, they are not stored):")
+				pf("%s", words)
+			})
+			return nil
+		},
+	}, {
+		cmd:           "unpair",
+		usableOffline: true,
+		usage:         "<device-id>",
+		descr:         "Remove the pairing with another device",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "device id cannot be empty"}
+			}
+			var id zkidentity.ShortID
+			if err := id.FromString(args[0]); err != nil {
+				return err
+			}
+			if err := as.c.UnpairDevice(id); err != nil {
+				return err
+			}
+			out.msg("Unpaired device %s", id)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return pairedDeviceCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "sync",
+		usage: "[<device-id>]",
+		descr: "Synchronize the state of paired devices",
+		long:  []string{"On a primary device, sends the local state to the specified secondary device (or to all secondary devices). On a secondary device, requests the state of the primary device."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			devices, err := as.c.ListPairedDevices()
+			if err != nil {
+				return err
+			}
+			if len(devices) == 0 {
+				return fmt.Errorf("no paired devices")
+			}
+			if devices[0].Role == client.DeviceRolePrimary {
+				if err := as.c.RequestDeviceSync(); err != nil {
+					return err
+				}
+				out.msg("Requested sync from primary device")
+				return nil
+			}
+
+			if len(args) > 0 {
+				var id zkidentity.ShortID
+				if err := id.FromString(args[0]); err != nil {
+					return err
+				}
+				if err := as.c.SyncDevice(id); err != nil {
+					return err
+				}
+				out.msg("Synced device %s", id)
+				return nil
+			}
+			for _, dev := range devices {
+				if err := as.c.SyncDevice(dev.ID); err != nil {
+					return err
+				}
+			}
+			out.msg("Synced %d devices", len(devices))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return pairedDeviceCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "pm",
+		usage: "<nick> <message>",
+		descr: "Send a PM through the primary device",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and message must be specified"}
+			}
+			uid, _, err := deviceSyncTarget(as, args[0])
+			if err != nil {
+				return err
+			}
+			if uid == nil {
+				return fmt.Errorf("%q is not a contact of the primary device", args[0])
+			}
+			msg := strings.Join(args[1:], " ")
+			if err := as.c.DevicePM(*uid, msg); err != nil {
+				return err
+			}
+			out.msg("Sent PM to %s through the primary device",
+				strescape.Nick(args[0]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return deviceSyncCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "gcm",
+		usage: "<gc> <message>",
+		descr: "Send a GC message through the primary device",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "gc and message must be specified"}
+			}
+			_, gcID, err := deviceSyncTarget(as, args[0])
+			if err != nil {
+				return err
+			}
+			if gcID == nil {
+				return fmt.Errorf("%q is not a GC of the primary device", args[0])
+			}
+			msg := strings.Join(args[1:], " ")
+			if err := as.c.DeviceGCMessage(*gcID, msg); err != nil {
+				return err
+			}
+			out.msg("Sent message to GC %s through the primary device",
+				strescape.Nick(args[0]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return deviceSyncCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "handoff",
+		descr: "Transfer the ratchets to the secondary device",
+		long: []string{"The ratchets with every contact, the GCs and their aliases are sent to the paired secondary device, which becomes the primary device. The local client becomes its secondary device.",
+			"This waits until all outbound messages are sent. Only possible when there is exactly one secondary device."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			out.goAsync(func() {
+				if err := as.c.HandoffDevice(as.ctx); err != nil {
+					out.msg("Unable to hand off to device: %v", err)
+					return
+				}
+				out.msg("Handed off to device. This is now a " +
+					"secondary device")
+			})
+			return nil
+		},
+	}, {
+		cmd:           "history",
+		usableOffline: true,
+		usage:         "<nick or gc>",
+		descr:         "Show the recent history of a conversation received from the primary device",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick or gc cannot be empty"}
+			}
+			ds, err := as.c.LastDeviceSync()
+			if err != nil {
+				return err
+			}
+			var convo *rpc.DeviceSyncConvo
+			for i, h := range ds.Sync.History {
+				var name string
+				if h.UID != nil {
+					for _, c := range ds.Sync.Contacts {
+						if c.ID == *h.UID {
+							name = c.Nick
+						}
+					}
+				} else if h.GC != nil {
+					for _, gc := range ds.Sync.GCs {
+						if gc.ID == *h.GC {
+							name = gc.Name
+						}
+					}
+				}
+				if strings.EqualFold(name, args[0]) {
+					convo = &ds.Sync.History[i]
+					break
+				}
+			}
+			if convo == nil {
+				return fmt.Errorf("no synced history for %q", args[0])
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("History of %s synced at %s", strescape.Nick(args[0]),
+					ds.Received.Format(ISO8601DateTime))
+				for _, m := range convo.Messages {
+					pf("%s <%s> %s",
+						time.Unix(m.Timestamp, 0).Format(ISO8601DateTime),
+						strescape.Nick(m.From), strescape.Content(m.Message))
+				}
+			})
+			return nil
+		},
+	},
+}
+
+var autoReplyCommands = []tuicmd{
+	{
+		cmd:           "status",
+		usableOffline: true,
+		descr:         "Show the auto reply config",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			cfg, err := as.c.AutoReplyConfig()
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Auto reply enabled: %v", cfg.Enabled)
+				if cfg.Message != "" {
+					pf("Message: %s", cfg.Message)
+					pf("Interval: %s", cfg.Interval)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "on",
+		usableOffline: true,
+		usage:         "[interval] <message>",
+		descr:         "Enable auto replies",
+		long: []string{
+			"The message is sent as a reply to the first PM received from each user within the interval. If the first argument is a duration (for example, '12h'), it is used as the interval. The default interval is 24h.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var interval time.Duration
+			if len(args) > 1 {
+				if d, err := time.ParseDuration(args[0]); err == nil {
+					interval = d
+					args = args[1:]
+				}
+			}
+			if len(args) < 1 {
+				return usageError{msg: "message cannot be empty"}
+			}
+			err := as.c.SetAutoReply(clientdb.AutoReplyConfig{
+				Enabled:  true,
+				Message:  strings.Join(args, " "),
+				Interval: interval,
+			})
+			if err != nil {
+				return err
+			}
+			out.msg("Auto reply enabled")
+			return nil
+		},
+	}, {
+		cmd:           "off",
+		usableOffline: true,
+		descr:         "Disable auto replies",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			cfg, err := as.c.AutoReplyConfig()
+			if err != nil {
+				return err
+			}
+			cfg.Enabled = false
+			if err := as.c.SetAutoReply(cfg); err != nil {
+				return err
+			}
+			out.msg("Auto reply disabled")
+			return nil
+		},
+	},
+}
+
+var identityCommands = []tuicmd{
+	{
+		cmd:   "migrate",
+		usage: "<new identity nick> [reason]",
+		descr: "Announce to all contacts the move to a new identity",
+		long: []string{
+			"The new identity must first be KX'd with the local client (for example, by creating an invite on the client of the new identity and accepting it on this client) and must allow the migration by running '/identity allowmigration <old identity nick>' on its client.",
+			"",
+			"The migration is sent to the new identity to be signed, then announced to all contacts. Contacts verify the announcement and KX with the new identity through the local client, which must stay online until they do so.",
+			"",
+			"After completing the KX with the new identity, contacts give it the nick of the old identity and ignore the old identity. Contacts that were already KX'd with the new identity must confirm the migration with '/identity applymigration'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick of new identity cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			reason := strings.Join(args[1:], " ")
+			if err := as.c.MigrateIdentity(uid, reason); err != nil {
+				return err
+			}
+			out.msg("Requested identity %s to sign the migration, "+
+				"which will then be announced to all contacts", uid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "allowmigration",
+		usage: "<old identity nick>",
+		descr: "Allow an old identity to migrate to the local identity",
+		long: []string{
+			"Allows the old identity to run '/identity migrate' with the local identity as the new identity during the next 24 hours. The local client signs the migration, proving to contacts of the old identity that the local identity agreed to it.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick of old identity cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.AllowIdentityMigration(uid); err != nil {
+				return err
+			}
+			out.msg("Allowed identity %s to migrate to the local identity",
+				uid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "applymigration",
+		usage: "<old identity nick or id>",
+		descr: "Apply an identity migration to an existing contact",
+		long: []string{
+			"Migrations to identities that are already contacts are not applied automatically, as they merge two existing contacts. This applies the migration announced by the old identity: the new identity takes over its nick and the old identity is ignored.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "old identity cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.ApplyIdentityMigration(uid); err != nil {
+				return err
+			}
+			out.msg("Applied identity migration of %s", uid)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "revoke",
+		usage: "<reason>",
+		descr: "Announce to all contacts that the local identity is revoked",
+		long: []string{
+			"Contacts ignore the local identity after receiving the announcement. This should be used when the identity is compromised and cannot be undone.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "reason cannot be empty"}
+			}
+			if err := as.c.RevokeIdentity(strings.Join(args, " ")); err != nil {
+				return err
+			}
+			out.msg("Announced revocation of the local identity to all contacts")
+			return nil
+		},
+	}, {
+		cmd:           "migrations",
+		usableOffline: true,
+		descr:         "List identity migrations announced by contacts",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			migs, err := as.c.ListIdentityMigrations()
+			if err != nil {
+				return err
+			}
+			isContact := func(uid client.UserID) bool {
+				_, err := as.c.UserByID(uid)
+				return err == nil
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				if len(migs) == 0 {
+					pf("No identity migrations")
+					return
+				}
+				for _, mig := range migs {
+					switch {
+					case mig.NewID == nil:
+						pf("%s %s revoked (reason: %q)",
+							mig.Received.Format(ISO8601DateTime),
+							mig.OldID, mig.Reason)
+					case mig.Applied != nil:
+						pf("%s %s moved to %s (reason: %q)",
+							mig.Received.Format(ISO8601DateTime),
+							mig.OldID, mig.NewID, mig.Reason)
+					case isContact(*mig.NewID):
+						pf("%s %s moving to %s (pending confirmation) (reason: %q)",
+							mig.Received.Format(ISO8601DateTime),
+							mig.OldID, mig.NewID, mig.Reason)
+					default:
+						pf("%s %s moving to %s (pending KX) (reason: %q)",
+							mig.Received.Format(ISO8601DateTime),
+							mig.OldID, mig.NewID, mig.Reason)
+					}
+				}
+			})
+			return nil
+		},
+	},
+}
+
+// autoKXEvents are the names of the events configurable in the auto KX
+// policy.
+var autoKXEvents = []string{"suggestions", "mediateid", "mediatedkx"}
+
+// autoKXActions are the names of the actions of the auto KX policy.
+var autoKXActions = []string{"default", "prompt", "accept", "reject"}
+
+// wordCompleter completes with the words that have arg as prefix.
+func wordCompleter(words []string, arg string) []string {
+	var res []string
+	for _, w := range words {
+		if strings.HasPrefix(w, arg) {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// setAutoKXAction sets the action for the named event in the policy.
+func setAutoKXAction(p *clientdb.AutoKXContactPolicy, event, action string) error {
+	a := clientdb.AutoKXAction(action)
+	if action == "default" {
+		a = clientdb.AutoKXActionDefault
+	}
+	if !a.IsValid() {
+		return usageError{msg: fmt.Sprintf("unknown action %q", action)}
+	}
+	switch event {
+	case "suggestions":
+		p.Suggestions = a
+	case "mediateid":
+		p.MediateID = a
+	case "mediatedkx":
+		p.MediatedKX = a
+	default:
+		return usageError{msg: fmt.Sprintf("unknown event %q", event)}
+	}
+	return nil
+}
+
+// autoKXActionStr returns the description of an auto KX action.
+func autoKXActionStr(a, def clientdb.AutoKXAction) string {
+	if a == clientdb.AutoKXActionDefault {
+		return string(def) + " (default)"
+	}
+	return string(a)
+}
+
+var autoKXCommands = []tuicmd{
+	{
+		cmd:           "status",
+		usableOffline: true,
+		descr:         "Show the auto KX policy",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			policy, err := as.c.AutoKXPolicy()
+			if err != nil {
+				return err
+			}
+			def := policy.Default
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("KX suggestions: %s", autoKXActionStr(def.Suggestions, clientdb.AutoKXActionPrompt))
+				pf("Requests to mediate KX: %s", autoKXActionStr(def.MediateID, clientdb.AutoKXActionAccept))
+				pf("Mediated KX requests: %s", autoKXActionStr(def.MediatedKX, clientdb.AutoKXActionAccept))
+				pf("KX with unknown GC members: %v", !policy.DisableGCRequests)
+				pf("KX with GC members via admins: %v", policy.RequestViaGCAdmins)
+				if policy.MaxRequestsPerHour > 0 {
+					pf("Max KX requests per hour: %d", policy.MaxRequestsPerHour)
+				} else {
+					pf("Max KX requests per hour: unlimited")
+				}
+				if len(policy.Contacts) == 0 {
+					return
+				}
+				pf("Contact overrides:")
+				for suid, cp := range policy.Contacts {
+					var uid clientintf.UserID
+					nick := suid
+					if uid.FromString(suid) == nil {
+						if ru, err := as.c.UserByID(uid); err == nil {
+							nick = ru.Nick()
+						}
+					}
+					pf("  %s: suggestions=%s mediateid=%s mediatedkx=%s",
+						strescape.Nick(nick),
+						autoKXActionStr(cp.Suggestions, "global"),
+						autoKXActionStr(cp.MediateID, "global"),
+						autoKXActionStr(cp.MediatedKX, "global"))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "set",
+		usableOffline: true,
+		usage:         "<suggestions|mediateid|mediatedkx> <default|prompt|accept|reject>",
+		descr:         "Set the default action for an auto KX event",
+		long: []string{
+			"'suggestions' are suggestions from contacts to KX with another user. Accepting them automatically requests the contact to mediate the KX.",
+			"'mediateid' are requests from contacts for the local client to mediate a KX with another contact.",
+			"'mediatedkx' are requests from contacts (acting as mediators) for the local client to KX with another user.",
+			"",
+			"The 'prompt' action is only valid for suggestions.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "event and action must be specified"}
+			}
+			policy, err := as.c.AutoKXPolicy()
+			if err != nil {
+				return err
+			}
+			if err := setAutoKXAction(&policy.Default, args[0], args[1]); err != nil {
+				return err
+			}
+			if err := as.c.SetAutoKXPolicy(policy); err != nil {
+				return err
+			}
+			out.msg("Set default auto KX action for %s to %s",
+				args[0], args[1])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return wordCompleter(autoKXEvents, arg)
+			case 1:
+				return wordCompleter(autoKXActions, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "contact",
+		usableOffline: true,
+		usage:         "<nick> <suggestions|mediateid|mediatedkx> <default|prompt|accept|reject>",
+		descr:         "Override the auto KX action for a contact",
+		long: []string{
+			"Setting the action to 'default' removes the override for the event, so that the default action is used for the contact.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 3 {
+				return usageError{msg: "nick, event and action must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			policy, err := as.c.AutoKXPolicy()
+			if err != nil {
+				return err
+			}
+			cp := policy.Contacts[ru.ID().String()]
+			if err := setAutoKXAction(&cp, args[1], args[2]); err != nil {
+				return err
+			}
+			if err := as.c.SetContactAutoKXPolicy(ru.ID(), cp); err != nil {
+				return err
+			}
+			out.msg("Set auto KX action for %s of %s to %s",
+				args[1], strescape.Nick(ru.Nick()), args[2])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return nickCompleter(arg, as)
+			case 1:
+				return wordCompleter(autoKXEvents, arg)
+			case 2:
+				return wordCompleter(autoKXActions, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "gc",
+		usableOffline: true,
+		usage:         "<off|owner|admins>",
+		descr:         "Set how KX is requested with unknown GC members",
+		long: []string{
+			"'off' disables automatically requesting KX with unknown GC members. 'owner' requests the GC owner to mediate the KX (the default). 'admins' requests the GC owner or any of the GC admins with which the local client has KX'd to mediate the KX.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "mode cannot be empty"}
+			}
+			policy, err := as.c.AutoKXPolicy()
+			if err != nil {
+				return err
+			}
+			switch args[0] {
+			case "off":
+				policy.DisableGCRequests = true
+				policy.RequestViaGCAdmins = false
+			case "owner":
+				policy.DisableGCRequests = false
+				policy.RequestViaGCAdmins = false
+			case "admins":
+				policy.DisableGCRequests = false
+				policy.RequestViaGCAdmins = true
+			default:
+				return usageError{msg: fmt.Sprintf("unknown mode %q", args[0])}
+			}
+			if err := as.c.SetAutoKXPolicy(policy); err != nil {
+				return err
+			}
+			out.msg("Set auto KX with GC members to %s", args[0])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter([]string{"off", "owner", "admins"}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "ratelimit",
+		usableOffline: true,
+		usage:         "<max requests per hour>",
+		descr:         "Set the max number of automatic KX requests per hour",
+		long: []string{
+			"Setting the limit to 0 removes the limit.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "limit cannot be empty"}
+			}
+			limit, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid limit: %v", err)}
+			}
+			policy, err := as.c.AutoKXPolicy()
+			if err != nil {
+				return err
+			}
+			policy.MaxRequestsPerHour = uint32(limit)
+			if err := as.c.SetAutoKXPolicy(policy); err != nil {
+				return err
+			}
+			out.msg("Set max automatic KX requests per hour to %d",
+				limit)
+			return nil
+		},
+	},
+}
+
+// setRatchetResetSetting sets the named setting in the policy. When
+// allowDefault is true, the 'default' value removes the setting.
+func setRatchetResetSetting(p *clientdb.RatchetResetContactPolicy, setting, value string, allowDefault bool) error {
+	var v *uint32
+	switch value {
+	case "default":
+		if !allowDefault {
+			return usageError{msg: "'default' is only valid for contact overrides"}
+		}
+	case "off":
+		v = new(uint32)
+	default:
+		u, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return usageError{msg: fmt.Sprintf("invalid value: %v", err)}
+		}
+		v = new(uint32)
+		*v = uint32(u)
+	}
+	switch setting {
+	case "unreplieddays":
+		p.UnrepliedDays = v
+	case "faileddecrypts":
+		p.FailedDecrypts = v
+	default:
+		return usageError{msg: fmt.Sprintf("unknown setting %q", setting)}
+	}
+	return nil
+}
+
+// ratchetResetSettingStr returns the description of a ratchet reset setting.
+func ratchetResetSettingStr(v *uint32, def string) string {
+	switch {
+	case v == nil:
+		return def
+	case *v == 0:
+		return "off"
+	default:
+		return strconv.FormatUint(uint64(*v), 10)
+	}
+}
+
+var ratchetResetSettings = []string{"unreplieddays", "faileddecrypts"}
+
+var autoResetCommands = []tuicmd{
+	{
+		cmd:           "status",
+		usableOffline: true,
+		descr:         "Show the automatic ratchet reset policy",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			policy, err := as.c.RatchetResetPolicy()
+			if err != nil {
+				return err
+			}
+			def := policy.Default
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Reset after days without reply: %s",
+					ratchetResetSettingStr(def.UnrepliedDays, "off"))
+				pf("Reset after failed decrypts: %s",
+					ratchetResetSettingStr(def.FailedDecrypts, "off"))
+				if len(policy.Contacts) == 0 {
+					return
+				}
+				pf("Contact overrides:")
+				for suid, cp := range policy.Contacts {
+					var uid clientintf.UserID
+					nick := suid
+					if uid.FromString(suid) == nil {
+						if ru, err := as.c.UserByID(uid); err == nil {
+							nick = ru.Nick()
+						}
+					}
+					pf("  %s: unreplieddays=%s faileddecrypts=%s",
+						strescape.Nick(nick),
+						ratchetResetSettingStr(cp.UnrepliedDays, "default"),
+						ratchetResetSettingStr(cp.FailedDecrypts, "default"))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "set",
+		usableOffline: true,
+		usage:         "<unreplieddays|faileddecrypts> <value|off>",
+		descr:         "Set when ratchet resets are automatically requested",
+		long: []string{
+			"'unreplieddays' is the number of days after which a reset is requested with contacts to which messages were sent but from which no message was received. This is checked after connecting to the server.",
+			"'faileddecrypts' is the number of consecutive messages from a contact that fail to be decrypted after which a reset is requested.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "setting and value must be specified"}
+			}
+			policy, err := as.c.RatchetResetPolicy()
+			if err != nil {
+				return err
+			}
+			err = setRatchetResetSetting(&policy.Default, args[0], args[1], false)
+			if err != nil {
+				return err
+			}
+			if err := as.c.SetRatchetResetPolicy(policy); err != nil {
+				return err
+			}
+			out.msg("Set default automatic ratchet reset %s to %s",
+				args[0], args[1])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return wordCompleter(ratchetResetSettings, arg)
+			case 1:
+				return wordCompleter([]string{"off"}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "contact",
+		usableOffline: true,
+		usage:         "<nick> <unreplieddays|faileddecrypts> <value|off|default>",
+		descr:         "Override when ratchet resets are automatically requested with a contact",
+		long: []string{
+			"Setting the value to 'default' removes the override for the setting, so that the default value is used for the contact.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 3 {
+				return usageError{msg: "nick, setting and value must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			policy, err := as.c.RatchetResetPolicy()
+			if err != nil {
+				return err
+			}
+			cp := policy.Contacts[ru.ID().String()]
+			if err := setRatchetResetSetting(&cp, args[1], args[2], true); err != nil {
+				return err
+			}
+			if err := as.c.SetContactRatchetResetPolicy(ru.ID(), cp); err != nil {
+				return err
+			}
+			out.msg("Set automatic ratchet reset %s of %s to %s",
+				args[1], strescape.Nick(ru.Nick()), args[2])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return nickCompleter(arg, as)
+			case 1:
+				return wordCompleter(ratchetResetSettings, arg)
+			case 2:
+				return wordCompleter([]string{"off", "default"}, arg)
+			}
+			return nil
+		},
+	},
+}
+
+// setTipRetrySetting sets the named setting in the policy. When allowDefault
+// is true, the 'default' value removes the setting.
+func setTipRetrySetting(p *clientdb.TipRetryContactPolicy, setting, value string, allowDefault bool) error {
+	if value == "default" {
+		if !allowDefault {
+			return usageError{msg: "'default' is only valid for contact overrides"}
+		}
+		switch setting {
+		case "maxattempts":
+			p.MaxAttempts = nil
+		case "rerequestdelay":
+			p.ReRequestInvoiceDelay = nil
+		case "payretryfactor":
+			p.PayRetryDelayFactor = nil
+		case "maxpayretrydelay":
+			p.MaxPayRetryDelay = nil
+		case "maxlifetime":
+			p.MaxLifetime = nil
+		default:
+			return usageError{msg: fmt.Sprintf("unknown setting %q", setting)}
+		}
+		return nil
+	}
+
+	if setting == "maxattempts" {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return usageError{msg: fmt.Sprintf("invalid value: %v", err)}
+		}
+		p.MaxAttempts = new(int32)
+		*p.MaxAttempts = int32(v)
+		return nil
+	}
+
+	var d time.Duration
+	if value != "off" || setting != "maxpayretrydelay" {
+		var err error
+		d, err = time.ParseDuration(value)
+		if err != nil {
+			return usageError{msg: fmt.Sprintf("invalid duration: %v", err)}
+		}
+	}
+	switch setting {
+	case "rerequestdelay":
+		p.ReRequestInvoiceDelay = &d
+	case "payretryfactor":
+		p.PayRetryDelayFactor = &d
+	case "maxpayretrydelay":
+		p.MaxPayRetryDelay = &d
+	case "maxlifetime":
+		p.MaxLifetime = &d
+	default:
+		return usageError{msg: fmt.Sprintf("unknown setting %q", setting)}
+	}
+	return nil
+}
+
+// tipRetrySettingStr returns the description of the settings of a tip retry
+// policy. Unset settings are described as def.
+func tipRetrySettingStr(p clientdb.TipRetryContactPolicy, def string) string {
+	durStr := func(d *time.Duration) string {
+		if d == nil {
+			return def
+		}
+		return d.String()
+	}
+	maxAttempts := def
+	if p.MaxAttempts != nil {
+		maxAttempts = strconv.FormatInt(int64(*p.MaxAttempts), 10)
+	}
+	maxPayRetryDelay := durStr(p.MaxPayRetryDelay)
+	if p.MaxPayRetryDelay != nil && *p.MaxPayRetryDelay == 0 {
+		maxPayRetryDelay = "off"
+	}
+	return fmt.Sprintf("maxattempts=%s rerequestdelay=%s payretryfactor=%s "+
+		"maxpayretrydelay=%s maxlifetime=%s", maxAttempts,
+		durStr(p.ReRequestInvoiceDelay), durStr(p.PayRetryDelayFactor),
+		maxPayRetryDelay, durStr(p.MaxLifetime))
+}
+
+var tipRetrySettings = []string{"maxattempts", "rerequestdelay",
+	"payretryfactor", "maxpayretrydelay", "maxlifetime"}
+
+// parseSwapAmount parses the DCR amount of a swap.
+func parseSwapAmount(arg string) (dcrutil.Amount, error) {
+	dcrAmount, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, usageError{msg: fmt.Sprintf("invalid amount: %v", err)}
+	}
+	amount, err := dcrutil.NewAmount(dcrAmount)
+	if err != nil || amount <= 0 {
+		return 0, usageError{msg: "amount must be a positive DCR amount"}
+	}
+	return amount, nil
+}
+
+// printSwap prints the details of a swap.
+func printSwap(pf printf, s *swaps.Swap) {
+	pf("Swap %s (%s): %s", s.ID, s.Direction, s.State)
+	pf("  Created: %s, expires: %s", s.Created.Format(ISO8601DateTime),
+		s.Expires.Format(ISO8601DateTime))
+	pf("  DCR: %s (fee %s), BTC: %s", s.DCRAmount, s.Fee,
+		swaps.FormatBTC(s.BTCAmount))
+	if s.Direction == swaps.DirectionIn {
+		pf("  Deposit address: %s", s.BTCAddress)
+	} else {
+		pf("  BTC address: %s", s.BTCAddress)
+	}
+	if s.BTCTxID != "" {
+		pf("  BTC tx: %s", s.BTCTxID)
+	}
+	if s.Err != "" {
+		pf("  Error: %s", s.Err)
+	}
+}
+
+var statsCommands = []tuicmd{
+	{
+		cmd:           "payments",
+		aliases:       []string{"pay"},
+		usableOffline: true,
+		usage:         "[<days>] [<window in days>]",
+		descr:         "Show a report of payments made and received",
+		long: []string{
+			"Shows the totals of tips, content payments, other payments (such as the fees for sending messages) and routing fees made and received in the last number of days (default 30), along with the totals per user.",
+			"If a window is specified, the totals are also shown for each window of that number of days (for example, 1 for daily totals).",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			days, windowDays := 30, 0
+			var err error
+			if len(args) > 0 {
+				days, err = strconv.Atoi(args[0])
+				if err != nil || days <= 0 {
+					return usageError{msg: "days must be a positive number"}
+				}
+			}
+			if len(args) > 1 {
+				windowDays, err = strconv.Atoi(args[1])
+				if err != nil || windowDays <= 0 {
+					return usageError{msg: "window must be a positive number of days"}
+				}
+			}
+
+			const day = 24 * time.Hour
+			end := time.Now()
+			start := end.Add(-time.Duration(days) * day)
+			window := time.Duration(windowDays) * day
+			report, err := as.c.PaymentReport(start, end, window)
+			if err != nil {
+				return err
+			}
+
+			dcr := func(matoms int64) float64 { return float64(matoms) / 1e11 }
+			out.msgs(func(pf printf) {
+				t := &report.Totals
+				pf("")
+				pf("Payments since %s", start.Format(ISO8601DateTime))
+				pf("            Sent           Recv (DCR)")
+				pf("Tips    %12.8f   %12.8f", dcr(t.Tips.Sent), dcr(t.Tips.Received))
+				pf("Content %12.8f   %12.8f", dcr(t.Content.Sent), dcr(t.Content.Received))
+				pf("Other   %12.8f   %12.8f", dcr(t.Other.Sent), dcr(t.Other.Received))
+				pf("Fees    %12.8f", dcr(t.PayFees))
+				pf("Totals  %12.8f   %12.8f", dcr(t.TotalSent()), dcr(t.TotalReceived()))
+
+				if len(report.Windows) > 0 {
+					pf("")
+					pf("Windows of %d days", windowDays)
+					pf("                   Sent           Recv (DCR)")
+					for _, w := range report.Windows {
+						pf("%s %12.8f   %12.8f",
+							w.Start.Format(ISO8601Date),
+							dcr(w.TotalSent()),
+							dcr(w.TotalReceived()))
+					}
+				}
+
+				if len(report.Users) > 0 {
+					pf("")
+					pf("Per user")
+					pf("        Sent           Recv (DCR)")
+					for _, u := range report.Users {
+						nick, _ := as.c.UserNick(u.UID)
+						nick = ltjustify(strescape.Nick(nick), 12)
+						pf("%12.8f   %12.8f - %s %s",
+							dcr(u.TotalSent()), dcr(u.TotalReceived()),
+							nick, as.styles.Load().help.Render(u.UID.String()))
+					}
+				}
+			})
+			return nil
+		},
+	},
+}
+
+var swapCommands = []tuicmd{
+	{
+		cmd:           "quote",
+		usableOffline: true,
+		usage:         "<in | out> <amount in DCR>",
+		descr:         "Fetch a quote for a swap",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.swaps == nil {
+				return fmt.Errorf("swaps are not enabled")
+			}
+			if len(args) < 2 {
+				return usageError{msg: "direction and amount must be specified"}
+			}
+			dir := swaps.Direction(args[0])
+			if dir != swaps.DirectionIn && dir != swaps.DirectionOut {
+				return usageError{msg: "direction must be 'in' or 'out'"}
+			}
+			amount, err := parseSwapAmount(args[1])
+			if err != nil {
+				return err
+			}
+			out.goAsync(func() {
+				q, err := as.swaps.Quote(as.ctx, dir, amount)
+				if err != nil {
+					out.msg("Unable to fetch swap quote: %v", err)
+					return
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					if dir == swaps.DirectionIn {
+						pf("Send %s to receive %s", swaps.FormatBTC(q.BTCAmount),
+							dcrutil.Amount(q.DCRAmount))
+					} else {
+						pf("Pay %s to receive %s", dcrutil.Amount(q.DCRAmount),
+							swaps.FormatBTC(q.BTCAmount))
+					}
+					pf("Provider rate: %.8f BTC/DCR, fee: %s", q.Rate,
+						dcrutil.Amount(q.Fee))
+					if q.MarketRate > 0 {
+						pf("Market rate: %.8f BTC/DCR, premium: %.2f%%",
+							q.MarketRate, q.Premium*100)
+					} else {
+						pf("Market rate: unknown")
+					}
+					pf("Quote expires: %s", q.Expires.Format(ISO8601DateTime))
+				})
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter([]string{"in", "out"}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "in",
+		usableOffline: true,
+		usage:         "<amount in DCR>",
+		descr:         "Fund the LN wallet with BTC",
+		long: []string{
+			"Creates a swap that receives the amount of DCR in the LN wallet in exchange for BTC sent to a deposit address. The provider is only able to claim the BTC after paying the DCR.",
+			"The deposit address is verified to be the contract of the swap. Deposits of swaps that do not complete are not refunded automatically: the refund key, contract script and lock time of the swap (shown with '/swap show <id>') allow refunding the deposit with external BTC tools after the lock time.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.swaps == nil {
+				return fmt.Errorf("swaps are not enabled")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "amount must be specified"}
+			}
+			amount, err := parseSwapAmount(args[0])
+			if err != nil {
+				return err
+			}
+			out.goAsync(func() {
+				s, err := as.swaps.SwapIn(as.ctx, amount)
+				if err != nil {
+					out.msg("Unable to create swap: %v", err)
+					return
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					printSwap(pf, s)
+					pf("Send exactly %s to %s before %s",
+						swaps.FormatBTC(s.BTCAmount), s.BTCAddress,
+						s.Expires.Format(ISO8601DateTime))
+				})
+			})
+			return nil
+		},
+	}, {
+		cmd:           "out",
+		spendsFunds:   true,
+		usableOffline: true,
+		usage:         "<amount in DCR> <BTC address>",
+		descr:         "Cash out DCR from the LN wallet to BTC",
+		long: []string{
+			"Creates a swap that pays the amount of DCR from the LN wallet in exchange for BTC sent to the address. The payment is only completed after the BTC is confirmed, otherwise it is canceled when the swap expires.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.swaps == nil {
+				return fmt.Errorf("swaps are not enabled")
+			}
+			if len(args) < 2 {
+				return usageError{msg: "amount and BTC address must be specified"}
+			}
+			amount, err := parseSwapAmount(args[0])
+			if err != nil {
+				return err
+			}
+			out.goAsync(func() {
+				s, err := as.swaps.SwapOut(as.ctx, amount, args[1])
+				if err != nil {
+					out.msg("Unable to create swap: %v", err)
+					return
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					printSwap(pf, s)
+				})
+			})
+			return nil
+		},
+	}, {
+		cmd:           "list",
+		usableOffline: true,
+		descr:         "List swaps",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.swaps == nil {
+				return fmt.Errorf("swaps are not enabled")
+			}
+			list := as.swaps.Swaps()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Swaps: %d", len(list))
+				for _, s := range list {
+					pf("%s %s %s (%s) %s %s",
+						s.Created.Format(ISO8601DateTime), s.ID,
+						s.Direction, s.DCRAmount,
+						swaps.FormatBTC(s.BTCAmount), s.State)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "show",
+		usableOffline: true,
+		usage:         "<id>",
+		descr:         "Show the details of a swap",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.swaps == nil {
+				return fmt.Errorf("swaps are not enabled")
+			}
+			if len(args) < 1 {
+				return usageError{msg: "swap id must be specified"}
+			}
+			s, err := as.swaps.Swap(args[0])
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				printSwap(pf, &s)
+				pf("  Invoice: %s", s.Invoice)
+				if s.RefundKey != "" {
+					pf("  Refund key: %s", s.RefundKey)
+					pf("  Contract script: %s", s.ContractScript)
+					pf("  Lock time: %d", s.LockTime)
+				}
+			})
+			return nil
+		},
+	},
+}
+
+var tipRetryCommands = []tuicmd{
+	{
+		cmd:           "status",
+		usableOffline: true,
+		descr:         "Show the tip retry policy",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			policy := as.c.TipRetryPolicy()
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Default: %s", tipRetrySettingStr(policy.Default, "builtin"))
+				if len(policy.Contacts) == 0 {
+					return
+				}
+				pf("Contact overrides:")
+				for suid, cp := range policy.Contacts {
+					var uid clientintf.UserID
+					nick := suid
+					if uid.FromString(suid) == nil {
+						if ru, err := as.c.UserByID(uid); err == nil {
+							nick = ru.Nick()
+						}
+					}
+					pf("  %s: %s", strescape.Nick(nick),
+						tipRetrySettingStr(cp, "default"))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "set",
+		usableOffline: true,
+		usage:         "<setting> <value>",
+		descr:         "Set how tips are attempted and retried",
+		long: []string{
+			"'maxattempts' is the max number of invoices requested for a tip.",
+			"'rerequestdelay' is how long to wait before requesting a new invoice after an attempt fails.",
+			"'payretryfactor' is the factor of the exponential delay between retries of payments that fail with retriable errors (e.g. no route).",
+			"'maxpayretrydelay' is the max delay between retries of payments (or 'off' to not limit it).",
+			"'maxlifetime' is how long after a tip is started that it expires.",
+			"Durations are specified as, for example, '30s', '10m' or '24h'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "setting and value must be specified"}
+			}
+			policy := as.c.TipRetryPolicy()
+			err := setTipRetrySetting(&policy.Default, args[0], args[1], false)
+			if err != nil {
+				return err
+			}
+			if err := as.c.SetTipRetryPolicy(policy); err != nil {
+				return err
+			}
+			out.msg("Set default tip retry %s to %s", args[0], args[1])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter(tipRetrySettings, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "contact",
+		usableOffline: true,
+		usage:         "<nick> <setting> <value|default>",
+		descr:         "Override how tips to a contact are attempted and retried",
+		long: []string{
+			"Settings are the same as in '/tipretry set'. Setting the value to 'default' removes the override for the setting, so that the default value is used for the contact.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 3 {
+				return usageError{msg: "nick, setting and value must be specified"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+			policy := as.c.TipRetryPolicy()
+			cp := policy.Contacts[ru.ID().String()]
+			if err := setTipRetrySetting(&cp, args[1], args[2], true); err != nil {
+				return err
+			}
+			if err := as.c.SetContactTipRetryPolicy(ru.ID(), cp); err != nil {
+				return err
+			}
+			out.msg("Set tip retry %s of %s to %s", args[1],
+				strescape.Nick(ru.Nick()), args[2])
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return nickCompleter(arg, as)
+			case 1:
+				return wordCompleter(tipRetrySettings, arg)
+			case 2:
+				return wordCompleter([]string{"default"}, arg)
+			}
+			return nil
+		},
+	},
+}
+
+// contentFilterActionStr returns the action of the content filter with the
+// given id.
+func contentFilterActionStr(as *appState, id uint64, hidden bool) string {
+	if hidden {
+		return clientdb.ContentFilterActionHide.String()
+	}
+	for _, cf := range as.c.ListContentFilters() {
+		if cf.ID == id {
+			return cf.Action.String()
+		}
+	}
+	return "unknown"
+}
+
+// parseContentFilterArgs parses the options of a content filter rule (as
+// documented in the '/filter addrule' command) from args. nargs is the number
+// of leading words of rawCmd that precede args.
+func parseContentFilterArgs(as *appState, rawCmd string, nargs int, args []string) (clientdb.ContentFilter, error) {
+	var cf clientdb.ContentFilter
+loopArgs:
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "user="):
+			nick := arg[5:]
+			uid, err := as.c.UIDByNick(nick)
+			if err != nil {
+				return cf, err
+			}
+			cf.UID = &uid
+			nargs += 1
+
+		case strings.HasPrefix(arg, "gc="):
+			name := arg[3:]
+			gc, err := as.c.GCIDByName(name)
+			if err != nil {
+				return cf, err
+			}
+			cf.GC = &gc
+			nargs += 1
+
+		case strings.EqualFold(arg, "noPost"):
+			cf.SkipPosts = true
+			nargs += 1
+
+		case strings.EqualFold(arg, "noPC"):
+			cf.SkipPostComments = true
+			nargs += 1
+
+		case strings.EqualFold(arg, "noGC"):
+			cf.SkipGCMs = true
+			nargs += 1
+
+		case strings.EqualFold(arg, "noPM"):
+			cf.SkipPMs = true
+			nargs += 1
+
+		case strings.HasPrefix(arg, "embed="):
+			cf.EmbedType = arg[6:]
+			nargs += 1
+
+		case strings.HasPrefix(arg, "action="):
+			cf.Action = clientdb.ContentFilterAction(arg[7:])
+			if cf.Action == "hide" {
+				cf.Action = clientdb.ContentFilterActionHide
+			}
+			nargs += 1
+
+		case arg == "--":
+			nargs += 1
+			break loopArgs
+
+		default:
+			break loopArgs
+		}
+	}
+
+	_, cf.Regexp = popNArgs(rawCmd, nargs)
+	if cf.Regexp == "" && cf.EmbedType == "" {
+		return cf, usageError{msg: "regexp cannot be empty"}
+	}
+	return cf, nil
+}
+
+// parseRecurringTipID parses the id of a recurring tip.
+func parseRecurringTipID(args []string) (zkidentity.ShortID, error) {
+	var id zkidentity.ShortID
+	if len(args) < 1 {
+		return id, usageError{msg: "recurring tip id cannot be empty"}
+	}
+	err := id.FromString(args[0])
+	return id, err
+}
+
+var onchainTipCommands = []tuicmd{
+	{
+		cmd:         "send",
+		spendsFunds: true,
+		usage:       "<nick> <dcr amount>",
+		descr:       "Send a tip to a user as an on-chain transaction",
+		long: []string{
+			"An address is requested from the user, so the transaction is only sent after they reply. Both users are notified once the transaction is confirmed.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and amount must be specified"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid amount: %v", err)}
+			}
+			out.goAsync(func() {
+				tip, err := as.c.SendOnchainTip(uid, dcrAmount)
+				if err != nil {
+					out.msg("Unable to send on-chain tip: %v", err)
+					return
+				}
+				cw := as.findOrNewChatWindow(uid, args[0])
+				cw.newInternalMsg(fmt.Sprintf("Requested address to send "+
+					"on-chain tip of %s", dcrutil.Amount(tip.Atoms)))
+				as.repaintIfActive(cw)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		descr:         "List the on-chain tips sent and received",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			tips, err := as.c.ListOnchainTips()
+			if err != nil {
+				return err
+			}
+			if len(tips) == 0 {
+				out.msg("No on-chain tips")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("On-chain tips")
+				for _, tip := range tips {
+					nick, _ := as.c.UserNick(tip.UID)
+					dir := "from"
+					if tip.Outbound {
+						dir = "to"
+					}
+					var state string
+					switch {
+					case tip.Error != "":
+						state = "failed: " + strescape.Content(tip.Error)
+					case tip.Confirmed != nil:
+						state = "confirmed at " + tip.Confirmed.Format(ISO8601DateTime)
+					case tip.TxHash != "":
+						state = "waiting confirmation"
+					default:
+						state = "waiting address"
+					}
+					pf("%s %s %s %s: %s",
+						tip.Created.Format(ISO8601DateTime),
+						dcrutil.Amount(tip.Atoms), dir,
+						strescape.Nick(nick), state)
+					if tip.TxHash != "" {
+						pf("  Tx: %s", tip.TxHash)
+					}
+				}
+			})
+			return nil
+		},
+	},
+}
+
+// findEscrow returns the escrow with the given ID or unique ID prefix.
+func findEscrow(as *appState, prefix string) (client.Escrow, error) {
+	escrows, err := as.c.ListEscrows()
+	if err != nil {
+		return client.Escrow{}, err
+	}
+	var res []client.Escrow
+	for _, e := range escrows {
+		if strings.HasPrefix(e.ID.String(), prefix) {
+			res = append(res, e)
+		}
+	}
+	switch len(res) {
+	case 0:
+		return client.Escrow{}, fmt.Errorf("escrow %q not found", prefix)
+	case 1:
+		return res[0], nil
+	default:
+		return client.Escrow{}, fmt.Errorf("escrow prefix %q is ambiguous", prefix)
+	}
+}
+
+var escrowCommands = []tuicmd{
+	{
+		cmd:         "request",
+		spendsFunds: true,
+		usage:       "<nick> <dcr amount> [description]",
+		descr:       "Request an escrowed payment to the user",
+		long: []string{
+			"The user generates a hold invoice that is paid once received. The payment is held by the node of the user until it is released with '/escrow release' or until the user cancels it or lets it expire.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and amount must be specified"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return usageError{msg: fmt.Sprintf("invalid amount: %v", err)}
+			}
+			descr := strings.Join(args[2:], " ")
+			out.goAsync(func() {
+				e, err := as.c.RequestEscrow(uid, dcrAmount, descr)
+				if err != nil {
+					out.msg("Unable to request escrow: %v", err)
+					return
+				}
+				cw := as.findOrNewChatWindow(uid, args[0])
+				cw.newInternalMsg(fmt.Sprintf("Requested escrow %s of %s",
+					e.ID.ShortLogID(), dcrutil.Amount(e.MilliAtoms/1000)))
+				as.repaintIfActive(cw)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		descr:         "List the escrows paid and received",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			escrows, err := as.c.ListEscrows()
+			if err != nil {
+				return err
+			}
+			if len(escrows) == 0 {
+				out.msg("No escrows")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Escrows")
+				for _, e := range escrows {
+					nick, _ := as.c.UserNick(e.UID)
+					dir := "from"
+					if e.Buyer {
+						dir = "to"
+					}
+					pf("%s %s %s %s %s: %s", e.ID.ShortLogID(),
+						e.Created.Format(ISO8601DateTime),
+						dcrutil.Amount(e.MilliAtoms/1000), dir,
+						strescape.Nick(nick), e.Status)
+					if e.Description != "" {
+						pf("  Description: %s", strescape.Content(e.Description))
+					}
+					if e.Error != "" {
+						pf("  Error: %s", strescape.Content(e.Error))
+					}
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:   "fulfill",
+		usage: "<id> [note]",
+		descr: "Notify the buyer that the order paid by the escrow was fulfilled",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "escrow id must be specified"}
+			}
+			e, err := findEscrow(as, args[0])
+			if err != nil {
+				return err
+			}
+			note := strings.Join(args[1:], " ")
+			if err := as.c.FulfillEscrow(e.UID, e.ID, note); err != nil {
+				return err
+			}
+			out.msg("Fulfilled escrow %s", e.ID.ShortLogID())
+			return nil
+		},
+	}, {
+		cmd:   "release",
+		usage: "<id>",
+		descr: "Release the held payment of an escrow to the seller",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "escrow id must be specified"}
+			}
+			e, err := findEscrow(as, args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.ReleaseEscrow(e.UID, e.ID); err != nil {
+				return err
+			}
+			out.msg("Released escrow %s", e.ID.ShortLogID())
+			return nil
+		},
+	}, {
+		cmd:   "cancel",
+		usage: "<id> [reason]",
+		descr: "Cancel an escrow",
+		long: []string{
+			"Sellers may cancel an escrow at any time before it is settled, which returns any held payment to the buyer. Buyers may only cancel an escrow before paying it.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "escrow id must be specified"}
+			}
+			e, err := findEscrow(as, args[0])
+			if err != nil {
+				return err
+			}
+			reason := strings.Join(args[1:], " ")
+			if err := as.c.CancelEscrow(e.UID, e.ID, reason); err != nil {
+				return err
+			}
+			out.msg("Canceled escrow %s", e.ID.ShortLogID())
+			return nil
+		},
+	},
+}
+
+var recurringTipCommands = []tuicmd{
+	{
+		cmd:         "add",
+		spendsFunds: true,
+		usage:       "<nick> <amount> <weekly|monthly>",
+		descr:       "Add a tip periodically paid to a user",
+		long: []string{
+			"The amount is in DCR, unless prefixed with '$' or suffixed with 'usd' (e.g. '$5' or '5usd'), in which case it is converted to DCR at the exchange rate of the time of each payment.",
+			"The first payment is made immediately.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 3 {
+				return usageError{msg: "nick, amount and period must be specified"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			dcrAmount, usdAmount, err := parseTipAmount(args[1])
+			if err != nil {
+				return usageError{msg: err.Error()}
+			}
+			period := clientdb.RecurringTipPeriod(args[2])
+			rt, err := as.c.AddRecurringTip(uid, dcrAmount, usdAmount, period)
+			if err != nil {
+				return err
+			}
+			out.msg("Added %s recurring tip %s to %s", rt.Period,
+				rt.ID, strescape.Nick(args[0]))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return nickCompleter(arg, as)
+			case 2:
+				var res []string
+				for _, p := range []clientdb.RecurringTipPeriod{
+					clientdb.RecurringTipWeekly,
+					clientdb.RecurringTipMonthly,
+				} {
+					if strings.HasPrefix(string(p), arg) {
+						res = append(res, string(p))
+					}
+				}
+				return res
+			}
+			return nil
+		},
+	}, {
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		descr:         "List the recurring tips",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			tips, err := as.c.ListRecurringTips()
+			if err != nil {
+				return err
+			}
+			if len(tips) == 0 {
+				out.msg("No recurring tips")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Recurring tips")
+				for _, rt := range tips {
+					nick, _ := as.c.UserNick(rt.UID)
+					amount := fmt.Sprintf("%.8f DCR", rt.DCRAmount)
+					if rt.USDAmount > 0 {
+						amount = fmt.Sprintf("$%.2f", rt.USDAmount)
+					}
+					state := "next at " + rt.NextPayment.Format(ISO8601DateTime)
+					if rt.Paused {
+						state = "paused"
+					}
+					pf("%s - %s %s to %s - %s - %d payments (%s)",
+						rt.ID, rt.Period, amount, strescape.Nick(nick),
+						state, rt.Payments, dcrutil.Amount(rt.PaidAtoms))
+					if rt.LastError != "" {
+						pf("  Last error: %s", rt.LastError)
+					}
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "pause",
+		usableOffline: true,
+		usage:         "<id>",
+		descr:         "Pause a recurring tip",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			id, err := parseRecurringTipID(args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.PauseRecurringTip(id, true); err != nil {
+				return err
+			}
+			out.msg("Paused recurring tip %s", id)
+			return nil
+		},
+	}, {
+		cmd:           "resume",
+		usableOffline: true,
+		usage:         "<id>",
+		descr:         "Resume a paused recurring tip",
+		long:          []string{"Payments missed while the tip was paused are not made."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			id, err := parseRecurringTipID(args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.PauseRecurringTip(id, false); err != nil {
+				return err
+			}
+			out.msg("Resumed recurring tip %s", id)
+			return nil
+		},
+	}, {
+		cmd:           "cancel",
+		aliases:       []string{"rm"},
+		usableOffline: true,
+		usage:         "<id>",
+		descr:         "Cancel a recurring tip",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			id, err := parseRecurringTipID(args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.CancelRecurringTip(id); err != nil {
+				return err
+			}
+			out.msg("Canceled recurring tip %s", id)
+			return nil
+		},
+	},
+}
+
+// findInvoice returns the invoice that starts with the given prefix.
+func findInvoice(as *appState, args []string) (client.Invoice, error) {
+	if len(args) < 1 {
+		return client.Invoice{}, usageError{msg: "invoice cannot be empty"}
+	}
+	invoices, err := as.c.ListInvoices(false)
+	if err != nil {
+		return client.Invoice{}, err
+	}
+	var res *client.Invoice
+	for i := range invoices {
+		if !strings.HasPrefix(invoices[i].Invoice, args[0]) {
+			continue
+		}
+		if res != nil && res.Invoice != invoices[i].Invoice {
+			return client.Invoice{}, fmt.Errorf("prefix %q matches "+
+				"multiple invoices", args[0])
+		}
+		res = &invoices[i]
+	}
+	if res == nil {
+		return client.Invoice{}, fmt.Errorf("invoice %q not found", args[0])
+	}
+	return *res, nil
+}
 
-					closingTx := c.ClosingTxid
-					limboBal := dcrutil.Amount(c.LimboBalance).ToCoin()
-					matHeight := c.MaturityHeight
-					recoveredBal := dcrutil.Amount(c.RecoveredBalance).ToCoin()
-					nbHTLCs := len(c.PendingHtlcs)
+// printInvoice prints the details of an invoice.
+func printInvoice(pf printf, as *appState, inv *client.Invoice) {
+	nick, _ := as.c.UserNick(inv.UID)
+	dir := "from"
+	if inv.Direction == client.InvoiceReceived {
+		dir = "to"
+	}
+	pf("%s %.8f DCR %s %s - %s - %s", inv.Created.Format(ISO8601DateTime),
+		float64(inv.MilliAtoms)/1e11, dir, strescape.Nick(nick),
+		inv.Status, inv.Invoice)
+	if inv.Label != "" || inv.Memo != "" {
+		pf("  Label: %s  Memo: %s", inv.Label, inv.Memo)
+	}
+}
 
-					pf("- %s", remoteNodePub)
-					pf("  %s cap:%.8f localBal:%.8f remoteBal:%.8f", channelPoint,
-						capacity, localBal, remoteBal)
-					pf("  limboBalance:%.8f  recoveredBalance:%.8f  htlcs:%d",
-						limboBal, recoveredBal, nbHTLCs)
-					pf("  closingTx:%s maturityHeight:%d", closingTx,
-						matHeight)
-					for i, htlc := range c.PendingHtlcs {
-						amount := dcrutil.Amount(htlc.Amount).ToCoin()
-						pf("    HTLC %d: amount:%.8f  incoming:%v  maturityHeight:%d", i, amount, htlc.Incoming, htlc.MaturityHeight)
+var invoicesCommands = []tuicmd{
+	{
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		usage:         "[open]",
+		descr:         "List the invoices of tips",
+		long:          []string{"Lists the invoices generated to receive tips from (from) and received to send tips to (to) users. Specify 'open' to list only the invoices that may still be paid."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			onlyOpen := len(args) > 0 && args[0] == "open"
+			invoices, err := as.c.ListInvoices(onlyOpen)
+			if err != nil {
+				return err
+			}
+			if len(invoices) == 0 {
+				out.msg("No invoices")
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Invoices")
+				for i := range invoices {
+					printInvoice(pf, as, &invoices[i])
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "status",
+		usableOffline: true,
+		usage:         "<invoice prefix>",
+		descr:         "Show the settlement status of an invoice",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			inv, err := findInvoice(as, args)
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				printInvoice(pf, as, &inv)
+			})
+			return nil
+		},
+	}, {
+		cmd:   "cancel",
+		usage: "<invoice prefix>",
+		descr: "Cancel an open invoice generated to receive a tip",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			inv, err := findInvoice(as, args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.CancelInvoice(inv.Invoice); err != nil {
+				return err
+			}
+			out.msg("Canceled invoice %s", inv.Invoice)
+			return nil
+		},
+	}, {
+		cmd:           "label",
+		usableOffline: true,
+		usage:         "<invoice prefix> [label] [memo...]",
+		descr:         "Set the local label and memo of an invoice",
+		long:          []string{"Specify only the invoice to remove its label and memo."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			inv, err := findInvoice(as, args)
+			if err != nil {
+				return err
+			}
+			var label, memo string
+			if len(args) > 1 {
+				label = args[1]
+			}
+			if len(args) > 2 {
+				memo = strings.Join(args[2:], " ")
+			}
+			if err := as.c.SetInvoiceLabel(inv.Invoice, label, memo); err != nil {
+				return err
+			}
+			if label == "" && memo == "" {
+				out.msg("Removed label of invoice %s", inv.Invoice)
+			} else {
+				out.msg("Labeled invoice %s", inv.Invoice)
+			}
+			return nil
+		},
+	},
+}
+
+// parseBudgetTarget parses the scope and target of a payment budget. It
+// returns the remaining args.
+func parseBudgetTarget(as *appState, args []string) (client.PaymentBudget, []string, error) {
+	var b client.PaymentBudget
+	if len(args) < 1 {
+		return b, nil, usageError{msg: "scope cannot be empty"}
+	}
+	b.Scope = clientdb.PaymentBudgetScope(args[0])
+	args = args[1:]
+	var err error
+	switch b.Scope {
+	case clientdb.PaymentBudgetGlobal:
+		return b, args, nil
+	case clientdb.PaymentBudgetUser:
+		if len(args) < 1 {
+			return b, nil, usageError{msg: "nick cannot be empty"}
+		}
+		b.ID, err = as.c.UIDByNick(args[0])
+	case clientdb.PaymentBudgetGC:
+		if len(args) < 1 {
+			return b, nil, usageError{msg: "gc cannot be empty"}
+		}
+		b.ID, err = as.c.GCIDByName(args[0])
+	default:
+		return b, nil, usageError{msg: fmt.Sprintf("unknown scope %q", args[0])}
+	}
+	return b, args[1:], err
+}
+
+// budgetTargetName returns the name of the target of a payment budget.
+func budgetTargetName(as *appState, b *client.PaymentBudget) string {
+	switch b.Scope {
+	case clientdb.PaymentBudgetUser:
+		nick, _ := as.c.UserNick(b.ID)
+		return "user " + strescape.Nick(nick)
+	case clientdb.PaymentBudgetGC:
+		alias, _ := as.c.GetGCAlias(b.ID)
+		return "gc " + alias
+	default:
+		return "global"
+	}
+}
+
+var budgetCommands = []tuicmd{
+	{
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		descr:         "List the payment budgets",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			budgets, err := as.c.PaymentBudgets()
+			if err != nil {
+				return err
+			}
+			if len(budgets) == 0 {
+				out.msg("No payment budgets")
+				return nil
+			}
+			limit := func(atoms int64) string {
+				if atoms == 0 {
+					return "no limit"
+				}
+				return dcrutil.Amount(atoms).String()
+			}
+			out.msgs(func(pf printf) {
+				pf("Payment budgets")
+				for _, st := range budgets {
+					pf("%s - daily %s of %s - monthly %s of %s",
+						budgetTargetName(as, &st.Budget),
+						st.DailySpent, limit(st.Budget.DailyAtoms),
+						st.MonthlySpent, limit(st.Budget.MonthlyAtoms))
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:           "set",
+		usableOffline: true,
+		usage:         "<global | user <nick> | gc <gc>> <daily dcr> [monthly dcr]",
+		descr:         "Set a payment budget",
+		long: []string{
+			"Limits the amount spent in tips and content payments over the last 24 hours (daily) and 30 days (monthly). A limit of 0 means no limit for the respective period.",
+			"Payments that exceed a budget must be confirmed with '/budget allow'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			b, args, err := parseBudgetTarget(as, args)
+			if err != nil {
+				return err
+			}
+			if len(args) < 1 {
+				return usageError{msg: "daily limit cannot be empty"}
+			}
+			limits := make([]int64, 2)
+			for i := 0; i < len(args) && i < 2; i++ {
+				dcr, err := strconv.ParseFloat(args[i], 64)
+				if err != nil {
+					return usageError{msg: fmt.Sprintf("invalid limit: %v", err)}
+				}
+				amt, err := dcrutil.NewAmount(dcr)
+				if err != nil {
+					return err
+				}
+				limits[i] = int64(amt)
+			}
+			b.DailyAtoms, b.MonthlyAtoms = limits[0], limits[1]
+			if err := as.c.SetPaymentBudget(b); err != nil {
+				return err
+			}
+			out.msg("Set payment budget for %s", budgetTargetName(as, &b))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch {
+			case len(args) == 0:
+				var res []string
+				for _, s := range []string{"global", "user", "gc"} {
+					if strings.HasPrefix(s, arg) {
+						res = append(res, s)
 					}
 				}
+				return res
+			case len(args) == 1 && args[0] == "user":
+				return nickCompleter(arg, as)
+			case len(args) == 1 && args[0] == "gc":
+				return gcCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "rm",
+		usableOffline: true,
+		usage:         "<global | user <nick> | gc <gc>>",
+		descr:         "Remove a payment budget",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			b, _, err := parseBudgetTarget(as, args)
+			if err != nil {
+				return err
+			}
+			if err := as.c.RemovePaymentBudget(b.Scope, b.ID); err != nil {
+				return err
+			}
+			out.msg("Removed payment budget for %s", budgetTargetName(as, &b))
+			return nil
+		},
+	}, {
+		cmd:   "allow",
+		descr: "Allow the payment waiting for confirmation to exceed a budget",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return as.replyBudgetOverride(true)
+		},
+	}, {
+		cmd:   "deny",
+		descr: "Deny the payment waiting for confirmation to exceed a budget",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return as.replyBudgetOverride(false)
+		},
+	},
+}
 
-				pf("Waiting Close Confirmation: %d", len(chans.WaitingCloseChannels))
-				for _, c := range chans.WaitingCloseChannels {
-					remoteNodePub := c.Channel.RemoteNodePub
-					capacity := dcrutil.Amount(c.Channel.Capacity).ToCoin()
-					localBal := dcrutil.Amount(c.Channel.LocalBalance).ToCoin()
-					remoteBal := dcrutil.Amount(c.Channel.RemoteBalance).ToCoin()
-					channelPoint := c.Channel.ChannelPoint
-					limbo := dcrutil.Amount(c.LimboBalance).ToCoin()
+var filterCommands = []tuicmd{
+	{
+		cmd:           "list",
+		usableOffline: true,
+		aliases:       []string{"ls"},
+		descr:         "Lists content-based filters",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			filters := as.c.ListContentFilters()
+			if len(filters) == 0 {
+				out.msg("Client has no content-based filters")
+				return nil
+			}
+
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Content filters (%d total)", len(filters))
+				for _, cf := range filters {
+					var s string
+					if !cf.SkipPMs {
+						s += "PMs, "
+					}
+					if !cf.SkipGCMs {
+						s += "GCMs"
+						if cf.GC != nil && !cf.GC.IsEmpty() {
+							gc, _ := as.c.GetGCAlias(*cf.GC)
+							s += fmt.Sprintf(" gc=%s", strescape.Nick(gc))
+						}
+						s += ", "
+
+					}
+					if !cf.SkipPosts {
+						s += "posts, "
+					}
+					if !cf.SkipPostComments {
+						s += "post comments, "
+					}
+					if cf.UID != nil {
+						nick, _ := as.c.UserNick(*cf.UID)
+						s += fmt.Sprintf("user=%s, ", strescape.Nick(nick))
+					}
 
-					pf("- %s", remoteNodePub)
-					pf("  %s cap:%.8f localBal:%.8f remoteBal:%.8f limbo:%.8f",
-						channelPoint, capacity, localBal, remoteBal, limbo)
+					if cf.EmbedType != "" {
+						s += fmt.Sprintf("embed=%s, ", cf.EmbedType)
+					}
+					s += fmt.Sprintf("action=%s, ", cf.Action)
+					s += fmt.Sprintf("regexp=\"%s\"", cf.Regexp)
+					pf("%08d - %s", cf.ID, s)
 				}
-
 			})
 
 			return nil
 		},
-	},
-	{
-		cmd:           "chbalance",
+	}, {
+		cmd:           "add",
 		usableOffline: true,
-		aliases:       []string{"channelbalance", "chbal"},
-		descr:         "Show current channel balances",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		descr:         "Add a simple, case-insensitive content filter",
+		usage:         "[filter]",
+		long: []string{
+			"Adding a filter through this command filters content from ",
+			"all users on all contexts. Use the 'addrule' command for ",
+			"specifying more complex rules.",
+		},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			_, expr := popNArgs(rawCmd, 2) // cmd+subcmd
+			if len(expr) == 0 {
+				return usageError{"filter expression cannot be empty"}
 			}
 
-			bal, err := as.lnRPC.ChannelBalance(as.ctx,
-				&lnrpc.ChannelBalanceRequest{})
+			// Add the case-insensitive flag.
+			expr = "(?i)" + regexp.QuoteMeta(expr)
+
+			cf := &clientdb.ContentFilter{Regexp: expr}
+			err := as.c.StoreContentFilter(cf)
 			if err != nil {
 				return err
 			}
-
-			msg := fmt.Sprintf("Channel Balance: %.8f, Max Inbound: %.8f, "+
-				"Max Outbound: %.8f", dcrutil.Amount(bal.Balance).ToCoin(),
-				dcrutil.Amount(bal.MaxInboundAmount).ToCoin(),
-				dcrutil.Amount(bal.MaxOutboundAmount).ToCoin())
-			as.cwHelpMsg(msg)
+			out.msg("Added content filter rule %d", cf.ID)
 			return nil
 		},
-	},
-	{
-		cmd:           "wbalance",
+	}, {
+		cmd:           "addrule",
 		usableOffline: true,
-		aliases:       []string{"walletbalance", "wbal"},
-		descr:         "Show current wallet balance",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		descr:         "Add a content-based filter",
+		usage:         "[user=<user>] [gc=<gc> | noGC] [noPost] [noPC] [noPM] [embed=<type>] [action=<hide|mute|flag>] [--] [regexp]",
+		long: []string{
+			"Content-based filters drop received messages before they are ",
+			"presented to the user.",
+			"",
+			"If an incoming message matches any of the existing filters, ",
+			"the message is dropped.",
+			"",
+			"By default, filters apply to all messages, received from any ",
+			"user and on all contexts (PMs, GCMs, posts and post comments). ",
+			"Some attributes allow refining when the filter applies: ",
+			"",
+			"  - user=<user>: only test filter if the message is from a specific user",
+			"  - gc=<gc>: only test filter if GCM is in a specific GC",
+			"  - noGC: do not apply filter for messages in GCs",
+			"  - noPost: do not apply filter for post conent",
+			"  - noPC: do not apply filter for post comments",
+			"  - noPM: do not apply filter for PMs",
+			"  - embed=<type>: only apply filter if the message has an embed with this mime type prefix (e.g. 'image/')",
+			"  - action=<action>: what to do with matching messages: 'hide' (the default) drops them, 'mute' logs PMs and GCMs without notifying and 'flag' shows them with a warning",
+			"",
+			"Everything after the last valid option or after a literal '--'",
+			"is considered part of the regexp.",
+			"",
+			"The regexp follows Go's regexp package rules. As a reminder, ",
+			"it is case-sensitive by default, unless it is started with ",
+			"the '(?i)' flag.",
+			"",
+			"Examples of filters:",
+			"",
+			"- Filter all posts and post comments from user foo that contain ",
+			"the string 'barbaz':",
+			"",
+			"    /filter addrule user=foo nogc nopm -- barbaz",
+			"",
+			"- Filter all messages in GC 'testgc' that contain the string 'barbaz':",
+			"",
+			"    /filter addrule gc=testgc nopm nopost nopc -- barbaz",
+			"",
+			"- Filter any message that begins with the case insensitive string 'barbaz':",
+			"",
+			"    /filter addrule (?i)^barbaz",
+			"",
+			"Use the /filter test* commands to test if the setup filters work as ",
+			"needed.",
+		},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "filter cannot be empty"}
 			}
 
-			bal, err := as.lnRPC.WalletBalance(as.ctx,
-				&lnrpc.WalletBalanceRequest{})
+			cf, err := parseContentFilterArgs(as, rawCmd, 2, args) // cmd+subcmd
 			if err != nil {
 				return err
 			}
 
-			msg := fmt.Sprintf("Wallet Balance: %.8f, Confirmed: %.8f, "+
-				"Unconfirmed: %.8f", dcrutil.Amount(bal.TotalBalance).ToCoin(),
-				dcrutil.Amount(bal.ConfirmedBalance).ToCoin(),
-				dcrutil.Amount(bal.UnconfirmedBalance).ToCoin())
-			as.cwHelpMsg(msg)
+			err = as.c.StoreContentFilter(&cf)
+			if err != nil {
+				return err
+			}
+
+			out.msg("Added content filter rule %d", cf.ID)
 			return nil
 		},
-	},
-	{
-		cmd:           "channels",
-		usage:         "[<debug>]",
-		usableOffline: true,
-		descr:         "Show list of active channels",
-		long:          []string{"If 'debug' is specified, then additional info for the channels is presented"},
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+	}, {
+		cmd:     "del",
+		aliases: []string{"delete", "remove", "rem"},
+		descr:   "Remove a filter rule",
+		usage:   "[id]",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "rule number cannot be empty"}
 			}
-			debug := len(args) > 0 && args[0] == "debug"
 
-			chans, err := as.lnRPC.ListChannels(as.ctx,
-				&lnrpc.ListChannelsRequest{})
+			id, err := strconv.ParseUint(args[0], 10, 64)
 			if err != nil {
 				return err
 			}
 
-			// Fetch list of aliases.
-			nodeAlias := make(map[string]string)
-			for _, c := range chans.Channels {
-				if _, ok := nodeAlias[c.RemotePubkey]; ok {
-					continue
-				}
-				nodeInfo, _ := as.lnRPC.GetNodeInfo(as.ctx, &lnrpc.NodeInfoRequest{
-					PubKey: c.RemotePubkey,
-				})
-				var alias string
-				if nodeInfo != nil {
-					alias = nodeInfo.Node.Alias
-					if len(alias) > 32 {
-						alias = alias[:32]
-					}
-				} else if len(c.RemotePubkey) > 32 {
-					alias = c.RemotePubkey[:32]
-				} else {
-					nodeAlias[c.RemotePubkey] = c.RemotePubkey
-				}
-				nodeAlias[c.RemotePubkey] = strescape.Nick(alias)
+			if err := as.c.RemoveContentFilter(id); err != nil {
+				return fmt.Errorf("unable to remove rule %d: %v", id, err)
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
-				pf("LN Channels: %d", len(chans.Channels))
-				if !debug {
-					pf("      chan       send                    recv node")
-				}
-				for _, c := range chans.Channels {
-					active := "✓"
-					if !c.Active {
-						active = "✗"
-					}
-					shortCP := c.ChannelPoint[:6]
-					local := fmt.Sprintf("%.8f", float64(c.LocalBalance)/1e8)
-					remote := fmt.Sprintf("%.8f", float64(c.RemoteBalance)/1e8)
-					balDisplay := channelBalanceDisplay(c.LocalBalance, c.RemoteBalance)
-					pf("  %s %s %s %s %s %s", active, shortCP, local, balDisplay, remote,
-						nodeAlias[c.RemotePubkey])
-
-					if debug {
-						sid := lnwire.NewShortChanIDFromInt(c.ChanId)
-						pf("  cp:%s sid:%s cap:%.8f",
-							c.ChannelPoint,
-							sid,
-							dcrutil.Amount(c.Capacity).ToCoin())
-						pf("  pub:%s   localBal:%.8f remoteBal:%.8f",
-							c.RemotePubkey,
-							dcrutil.Amount(c.LocalBalance).ToCoin(),
-							dcrutil.Amount(c.RemoteBalance).ToCoin())
-						pf("  unsettled:%.8f updts:%d htlcs:%d",
-							dcrutil.Amount(c.UnsettledBalance).ToCoin(),
-							c.NumUpdates,
-							len(c.PendingHtlcs))
-						pf("")
-					}
-				}
-			})
+			out.msg("Removed content filter rule %d", id)
 			return nil
 		},
-	},
-	{
-		cmd:           "closedchannels",
-		aliases:       []string{"closedchans"},
+	}, {
+		cmd:           "testpm",
 		usableOffline: true,
-		descr:         "Show list of closed channels",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		descr:         "Test if a PM would be filtered",
+		usage:         "<user> <pm>",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "user cannot be empty"}
 			}
 
-			chans, err := as.lnRPC.ClosedChannels(as.ctx,
-				&lnrpc.ClosedChannelsRequest{})
+			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
-				pf("Closed LN Channels: %d", len(chans.Channels))
-				for _, c := range chans.Channels {
-					sid := lnwire.NewShortChanIDFromInt(c.ChanId)
-					pf("  cp:%s sid:%s cap:%.8f typ: %s",
-						c.ChannelPoint,
-						sid,
-						dcrutil.Amount(c.Capacity).ToCoin(),
-						c.CloseType)
-					pf("    %s   settled:%.8f timelocked:%.8f",
-						c.RemotePubkey,
-						dcrutil.Amount(c.SettledBalance).ToCoin(),
-						dcrutil.Amount(c.TimeLockedBalance).ToCoin())
-					pf("    tx: %s   height: %d",
-						c.ClosingTxHash, c.CloseHeight)
+			_, pm := popNArgs(rawCmd, 3) // cmd+subcmd+user
+			filter, id := as.c.FilterPM(uid, pm)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Test message: %q", pm)
+				if id == 0 {
+					pf("Message would NOT be filtered")
+				} else {
+					pf("Message would be filtered by rule %d (%s)", id,
+						contentFilterActionStr(as, id, filter))
 				}
 			})
 			return nil
 		},
-	},
-
-	{
-		cmd:           "svrnode",
-		usableOffline: true,
-		aliases:       []string{"servernode"},
-		descr:         "Show the server LN node info",
-		long:          []string{"This also queries the local node for the ability to make payments to the remote server"},
-		handler: func(args []string, as *appState) error {
-			svrNode := as.c.ServerLNNode()
-			if svrNode == "" {
-				return fmt.Errorf("client does not have ID of server node")
-			}
-
-			return as.queryLNNodeInfo(svrNode, 1)
-		},
 	}, {
-		cmd:           "invoice",
+		cmd:           "testgcm",
 		usableOffline: true,
-		usage:         "[amount in DCR] [memo]",
-		aliases:       []string{"addinvoice"},
-		descr:         "Create an LN invoice",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		descr:         "Test if a GC message would be filtered",
+		usage:         "<user> <gc> <pm>",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "user cannot be empty"}
 			}
-
-			var amount float64
-			if len(args) > 0 {
-				var err error
-				amount, err = strconv.ParseFloat(args[0], 64)
-				if err != nil {
-					return usageError{msg: fmt.Sprintf("amount is not a number: %v", err)}
-				}
+			if len(args) < 2 {
+				return usageError{msg: "gc cannot be empty"}
 			}
-			_, memo := popNArgs(rawCmd, 3)
 
-			inv := lnrpc.Invoice{
-				Memo:  memo,
-				Value: int64(amount * 1e8),
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
 			}
-			res, err := as.lnRPC.AddInvoice(as.ctx, &inv)
+			gc, err := as.c.GCIDByName(args[1])
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsg("Create invoice %x", res.RHash)
-
-			// This is needed because wordwrap.String() doesn't break
-			// words, only sentences.
-			payreq := res.PaymentRequest
-			var msg string
-			for len(payreq) > 0 {
-				if len(payreq) < as.winW {
-					msg += payreq
-					payreq = ""
+			_, gcm := popNArgs(rawCmd, 4) // cmd+subcmd+user+gc
+			filter, id := as.c.FilterGCM(uid, gc, gcm)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Test message: %q", gcm)
+				if id == 0 {
+					pf("Message would NOT be filtered")
 				} else {
-					msg += payreq[:as.winW-1]
-					payreq = payreq[as.winW-1:]
-					msg += "\n"
+					pf("Message would be filtered by rule %d (%s)", id,
+						contentFilterActionStr(as, id, filter))
 				}
-			}
-			as.cwHelpMsg(msg)
+			})
 			return nil
 		},
 	}, {
-		cmd:           "decodeinvoice",
+		cmd:           "testpost",
 		usableOffline: true,
-		usage:         "[invoice]",
-		aliases:       []string{"decinvoice", "decodeinv"},
-		descr:         "Decode an LN invoice",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
-			}
+		descr:         "Test if a post would be filtered",
+		usage:         "<user> <post>",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "invoice cannot be empty"}
+				return usageError{msg: "user cannot be empty"}
 			}
-			payreq := strings.TrimPrefix(args[0], "lnpay://")
 
-			req := &lnrpc.PayReqString{PayReq: payreq}
-			invoice, err := as.lnRPC.DecodePayReq(as.ctx, req)
+			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			_, post := popNArgs(rawCmd, 3) // cmd+subcmd+user
+			filter, id := as.c.FilterPost(uid, clientintf.PostID{}, post)
+			out.msgs(func(pf printf) {
 				pf("")
-				pf("Decoded Invoice")
-				if invoice.NumMAtoms < 1000 {
-					pf("Amount: %d MAtoms", invoice.NumMAtoms)
+				pf("Test post: %q", post)
+				if id == 0 {
+					pf("Post would NOT be filtered")
 				} else {
-					pf("Amount: %s", dcrutil.Amount(invoice.NumAtoms))
+					pf("Post would be filtered by rule %d (%s)", id,
+						contentFilterActionStr(as, id, filter))
 				}
-				pf("Destination: %s", invoice.Destination)
-				expiryTime := time.Unix(invoice.Timestamp, 0).Add(time.Duration(invoice.Expiry) * time.Second)
-				pf("Expiry: %s", expiryTime.Format(ISO8601DateTime))
-				pf("CLTV expiry: %d blocks", invoice.CltvExpiry)
-				pf("Description: %s", strescape.Content(invoice.Description))
 			})
 			return nil
 		},
 	}, {
-		cmd:           "payinvoice",
+		cmd:           "testpostcomment",
 		usableOffline: true,
-		usage:         "[invoice]",
-		aliases:       []string{"sendpayment", "pay"},
-		descr:         "Pay an LN invoice",
-		handler: func(args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
-			}
+		descr:         "Test if a post comment would be filtered",
+		usage:         "<user> <post>",
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "invoice cannot be empty"}
+				return usageError{msg: "user cannot be empty"}
 			}
 
-			payreq := strings.TrimPrefix(args[0], "lnpay://")
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
 
-			as.cwHelpMsg("Attempting to pay invoice")
-			go func() {
-				pc, err := as.lnRPC.SendPayment(as.ctx)
-				if err != nil {
-					as.cwHelpMsg("PC: %v", err)
-					return
+			_, comment := popNArgs(rawCmd, 3) // cmd+subcmd+user
+			postFrom, pid := clientintf.UserID{0: 0x01}, clientintf.UserID{0: 0x02}
+			filter, id := as.c.FilterPostComment(uid, postFrom, pid, comment)
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Test comment: %q", comment)
+				if id == 0 {
+					pf("Comment would NOT be filtered")
+				} else {
+					pf("Comment would be filtered by rule %d (%s)", id,
+						contentFilterActionStr(as, id, filter))
 				}
+			})
+			return nil
+		},
+	},
+}
 
-				req := &lnrpc.SendRequest{
-					PaymentRequest: payreq,
-				}
-				err = pc.Send(req)
-				if err != nil {
-					as.cwHelpMsg("Unable to start payment: %v", err)
-				}
-				for res, err := pc.Recv(); ; {
-					if err != nil {
-						as.cwHelpMsg("PC receive error: %v", err)
-						return
-					}
-					if res.PaymentError != "" {
-						as.cwHelpMsg("Payment error: %s", res.PaymentError)
-						return
-					}
-					as.cwHelpMsg("Payment done!")
-					return
-				}
+var myAvatarCmds = []tuicmd{
+	{
+		cmd:   "set",
+		descr: "Set the user avatar",
+		usage: "<filename>",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return fmt.Errorf("filename cannot be empty")
+			}
+
+			avatar, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
 
-			}()
+			if !strings.HasPrefix(imageMimeType(avatar), "image/") {
+				return fmt.Errorf("unsupported file format")
+			}
+
+			err = as.c.UpdateLocalAvatar(avatar)
+			if err != nil {
+				return err
+			}
 
+			out.diagMsg("Avatar updated!")
 			return nil
 		},
+		completer: func(args []string, arg string, as *appState) []string {
+			return fileCompleter(arg)
+		},
 	}, {
-		cmd:           "queryroute",
+		cmd:   "clear",
+		descr: "Removes the user avatar",
+		long:  []string{"An update is sent to remote users to clear the avatar."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			err := as.c.UpdateLocalAvatar(nil)
+			if err != nil {
+				return err
+			}
+			out.diagMsg("Avatar updated")
+			return nil
+		},
+	}, {
+		cmd:           "view",
+		descr:         "View the user avatar",
 		usableOffline: true,
-		usage:         "<dest node ID> [amount in atoms]",
-		descr:         "Query a route to a destination",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			if as.lnRPC == nil {
-				return fmt.Errorf("LN client not configured")
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			pubid := as.c.Public()
+			if len(pubid.Avatar) == 0 {
+				return fmt.Errorf("user does not have avatar")
 			}
-			if len(args) < 1 {
-				return usageError{msg: "dest node ID cannot be empty"}
+			cmd, err := as.viewRaw(pubid.Avatar)
+			if err != nil {
+				return err
 			}
-			var amount uint64 = 1
-			if len(args) > 1 {
-				var err error
-				amount, err = strconv.ParseUint(args[1], 10, 64)
-				if err != nil {
-					return usageError{msg: fmt.Sprintf("amount is not a number: %v", err)}
+			as.sendMsg(msgRunCmd(cmd))
+			return nil
+		},
+	},
+}
+
+var notifyCommands = []tuicmd{
+	{
+		cmd:           "list",
+		aliases:       []string{"ls"},
+		usableOffline: true,
+		descr:         "List the notification rules",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.listNtfnRules(out)
+			return nil
+		},
+	}, {
+		cmd:           "set",
+		usableOffline: true,
+		usage:         "<nick or gc> <all|mentions|off|default>",
+		descr:         "Set which msgs of a user or GC generate notifications",
+		long: []string{
+			"The 'default' rule removes the rule of the user or GC, which then uses the global rule for PMs or GCs set in the config file.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick or gc and rule must be specified"}
+			}
+			id, err := as.chatTargetID(args[0])
+			if err != nil {
+				return err
+			}
+			rule := ntfnRuleDefault
+			if args[1] != string(ntfnRuleDefault) {
+				if rule, err = parseNtfnRule(args[1]); err != nil {
+					return usageError{msg: err.Error()}
 				}
 			}
-
-			return as.queryLNNodeInfo(args[0], amount)
+			if err := as.desktopNtfns.setRule(id, rule); err != nil {
+				return err
+			}
+			out.msg("Set notification rule of %s to %s",
+				strescape.Nick(args[0]), rule)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
+			case 1:
+				return wordCompleter([]string{string(ntfnRuleAll),
+					string(ntfnRuleMentions), string(ntfnRuleOff),
+					string(ntfnRuleDefault)}, arg)
+			}
+			return nil
 		},
 	}, {
-		cmd:           "sendonchain",
-		usage:         "<DCR amount> <dest address> [<source account>]",
-		descr:         "Send funds from the on-chain wallet",
+		cmd:           "test",
+		usableOffline: true,
+		descr:         "Show a test notification",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if as.desktopNtfns.method() == ntfnMethodNone {
+				return fmt.Errorf("notifications are disabled (set the " +
+					"notifications.method config)")
+			}
+			as.desktopNtfns.notify(appName, "Test notification")
+			out.msg("Sent test notification with method %s",
+				as.desktopNtfns.method())
+			return nil
+		},
+	},
+}
+
+var commands = []tuicmd{
+	{
+		cmd:           "backup",
 		usableOffline: true,
-		handler: func(args []string, as *appState) error {
+		descr:         "Create a backup of brclient",
+		usage:         "<backup-directory>",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "amount cannot be empty"}
+				return usageError{msg: "backup directory cannot be empty"}
 			}
-			if len(args) < 2 {
-				return usageError{msg: "destination address cannot be empty"}
+			destPath := cleanAndExpandPath(args[0])
+
+			backupFile, err := as.c.Backup(as.ctx, as.rootDir, destPath)
+			if err != nil {
+				return err
 			}
-			var account string
-			if len(args) > 2 {
-				account = args[2]
+			as.log.Infof("Successfully backed up to %v", backupFile)
+			return nil
+		},
+	}, {
+		cmd:           "seedbackup",
+		usableOffline: true,
+		descr:         "Create a seed-encrypted backup of the local identity",
+		usage:         "<backup-file>",
+		long: []string{"The backup includes the local identity, contacts and GCs, encrypted with a new seed. The seed words are displayed once and are required to restore the backup, so they must be written down.",
+			"To restore, start brclient on an empty root dir with the -restoreseedbackup flag pointing to the backup file and type the seed words when asked.",
+			"After restoring, a ratchet reset is requested with every contact."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "backup file cannot be empty"}
 			}
-			dcrAmount, err := strconv.ParseFloat(args[0], 64)
+			destPath := cleanAndExpandPath(args[0])
+
+			words, bundle, err := as.c.CreateSeedBackup()
 			if err != nil {
-				return fmt.Errorf("amount is not valid: %v", err)
+				return err
 			}
-			if dcrAmount <= 0 {
-				return usageError{msg: "cannot send non-positive dcr amount"}
+			if err := os.WriteFile(destPath, bundle, 0o600); err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Wrote seed backup to %s", destPath)
+				pf("Seed words (write them down, they are not stored):")
+				pf("%s", words)
+			})
+			return nil
+		},
+	}, {
+		cmd:           "online",
+		usableOffline: true,
+		descr:         "Attempt to connect and remain connected to the server",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.c.GoOnline()
+			return nil
+		},
+	}, {
+		cmd:           "offline",
+		usableOffline: true,
+		descr:         "Disconnect from server and remain offline",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.c.RemainOffline()
+			return nil
+		},
+	}, {
+		cmd:           "enablecanpay",
+		usableOffline: true,
+		descr:         "Force-enable the 'can pay server' flag",
+		long: []string{"This allows bypassing the internal test that checks whether the client can reach the server LN node before sending commands.",
+			"This is sometimes needed when routing estimation in the underlying LN node is wrong.",
+			"Once force-enabled, this remains enabled until the app is closed."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.canPayServerMtx.Lock()
+			as.canPayServer = true
+			as.canPayServerTestTime = time.Now().Add(time.Hour * 24 * 365)
+			as.canPayServerMtx.Unlock()
+			out.msg("Force-enabled 'can pay server' flag")
+			return nil
+		},
+	}, {
+		cmd:           "debuglevel",
+		usableOffline: true,
+		aliases:       []string{"dlvl"},
+		usage:         "<level | subsys=level>",
+		descr:         "Set the log level of the application subsystems",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "level cannot be empty"}
 			}
-			amount, err := dcrutil.NewAmount(dcrAmount)
+			err := as.logBknd.setLogLevel(args[0])
 			if err != nil {
 				return err
 			}
-
-			addr := args[1]
-			as.cwHelpMsg("Sending %s DCR to %s", amount, addr)
-			go func() {
-				req := &lnrpc.SendCoinsRequest{
-					Addr:    addr,
-					Amount:  int64(amount),
-					Account: account,
-				}
-				res, err := as.lnRPC.SendCoins(as.ctx, req)
-				if err != nil {
-					as.cwHelpMsg("Usable to send coins on-chain: %v", err)
-					return
-				}
-				as.cwHelpMsg("Sent coins through tx %s", res)
-			}()
+			as.log.Infof("Modified log level to: %q", args[0])
 			return nil
 		},
 	}, {
-		cmd:           "debuglevel",
-		usage:         "<level>",
+		cmd:           "ignore",
 		usableOffline: true,
-		descr:         "Change the debug level of the internal LN wallet",
-		handler: func(args []string, as *appState) error {
+		usage:         "<nick>",
+		descr:         "Ignore user messages",
+		long:          []string{"This maintains internal communication with the user but avoids surfacing private and group messages from them."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "debug level cannot be empty"}
+				return usageError{msg: "user cannot be empty"}
 			}
-			req := &lnrpc.DebugLevelRequest{
-				LevelSpec: args[0],
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
 			}
-			_, err := as.lnRPC.DebugLevel(as.ctx, req)
-			return err
+			return as.c.Ignore(uid, true)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
 		},
 	}, {
-		cmd:           "accounts",
+		cmd:           "unignore",
 		usableOffline: true,
-		descr:         "List wallet accounts",
-		handler: func(args []string, as *appState) error {
-			res, err := as.lnWallet.ListAccounts(as.ctx, &walletrpc.ListAccountsRequest{})
+		usage:         "<nick>",
+		descr:         "Unignore user",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "user cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
+			return as.c.Ignore(uid, false)
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "block",
+		usage: "<nick>",
+		descr: "Remove and block further contact with the user",
+		long: []string{
+			"The remote user is asked not to send any more messages to the local client.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
 
-			bal, err := as.lnRPC.WalletBalance(as.ctx, &lnrpc.WalletBalanceRequest{})
+			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
-
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Wallet accounts (%d)", len(res.Accounts))
-				for _, acc := range res.Accounts {
-					acctBal := dcrutil.Amount(bal.AccountBalance[acc.Name].ConfirmedBalance + bal.AccountBalance[acc.Name].UnconfirmedBalance)
-					pf("%s - %s - keys: %d internal, %d external",
-						acc.Name, acctBal, acc.InternalKeyCount,
-						acc.ExternalKeyCount)
-				}
-			})
+			cw := as.findOrNewChatWindow(uid, args[0])
+			go as.block(out, cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
 			return nil
 		},
 	}, {
-		cmd:           "newaccount",
-		usage:         "<name>",
+		cmd:     "list",
+		aliases: []string{"l", "ls"},
+		usage:   "[subcmd]",
+		descr:   "List various things",
+		sub:     listCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(listCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "query",
 		usableOffline: true,
-		descr:         "Create a new wallet account",
-		handler: func(args []string, as *appState) error {
+		aliases:       []string{"q"},
+		usage:         "<nick>",
+		descr:         "Open a chat window with the provided nick",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "account name cannot be empty"}
+				return usageError{msg: "nick cannot be empty"}
 			}
-			name := strings.TrimSpace(args[0])
-			if len(name) == 0 {
-				return usageError{msg: "account name cannot be empty string"}
+			// Explicitly opening an archived chat unarchives it.
+			if id, err := as.chatTargetID(args[0]); err == nil && as.c.IsChatArchived(id) {
+				if err := as.c.UnarchiveChat(id); err != nil {
+					return err
+				}
 			}
-
-			_, err := as.lnWallet.DeriveNextAccount(as.ctx, &walletrpc.DeriveNextAccountRequest{Name: name})
-			if err != nil {
-				return err
+			return as.openChatWindow(args[0])
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return addressbookCompleter(arg, as)
 			}
-			as.cwHelpMsg("Created account %s", name)
 			return nil
 		},
 	}, {
-		cmd:           "rescanwallet",
-		usage:         "[<start height>]",
+		cmd:           "addressbook",
+		usage:         "[<user>] [viewavatar]",
 		usableOffline: true,
-		descr:         "Rescan for on-chain wallet transactions",
-		handler: func(args []string, as *appState) error {
-			var beginHeight int32
+		aliases:       []string{"ab"},
+		long: []string{
+			"Without arguments, shows the entire addressbook (list of known users).",
+			"",
+			"If passing a nick on the first argument, it shows detailed addressbook information for that user.",
+			"",
+			"If the user has an avatar, passing 'viewavatar' will open the external viewer for that avatar image type.",
+		},
+		descr: "Show the address book of known remote users (or a user profile)",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var viewAvatar bool
+			if len(args) > 1 {
+				switch {
+				case args[1] == "viewavatar":
+					viewAvatar = true
+				default:
+					return fmt.Errorf("unknown argument %q", args[1])
+				}
+			}
+
 			if len(args) > 0 {
-				h, err := strconv.ParseInt(args[0], 10, 32)
+				ru, err := as.c.UserByNick(args[0])
 				if err != nil {
 					return err
 				}
-				beginHeight = int32(h)
-			}
 
-			go func() {
-				req := &walletrpc.RescanWalletRequest{BeginHeight: beginHeight}
-				s, err := as.lnWallet.RescanWallet(as.ctx, req)
+				ab, err := as.c.AddressBookEntry(ru.ID())
 				if err != nil {
-					as.cwHelpMsg("Unable to rescan wallet: %v", err)
-					return
+					return err
 				}
 
-				t := time.Now()
-				as.cwHelpMsg("Starting rescan at height %d", beginHeight)
-				var lastHeight int32
-				ntf, err := s.Recv()
-				for ; err == nil; ntf, err = s.Recv() {
-					if time.Since(t) > 5*time.Second {
-						as.cwHelpMsg("Rescanned up to block %d", ntf.ScannedThroughHeight)
-						t = time.Now()
+				if viewAvatar {
+					if len(ab.ID.Avatar) == 0 {
+						return fmt.Errorf("user does not have an avatar")
 					}
-					lastHeight = ntf.ScannedThroughHeight
-				}
-				if err == nil || errors.Is(err, io.EOF) {
-					as.cwHelpMsg("Finished rescan at height %d", lastHeight)
-				} else {
-					as.cwHelpMsg("Error during rescan (last height %d): %v",
-						lastHeight, err)
+					cmd, err := as.viewRaw(ab.ID.Avatar)
+					if err != nil {
+						return err
+					}
+					as.sendMsg(msgRunCmd(cmd))
+					return nil
 				}
-			}()
-			return nil
-		},
-	}, {
-		cmd:           "listtxs",
-		usableOffline: true,
-		aliases:       []string{"listtransactions"},
-		descr:         "List on-chain wallet transactions",
-		usage:         "[<start height>] [<end height>]",
-		long: []string{
-			"If start height is not specified, it defaults to -1. If end height is not specified, it defaults to 0.",
-			"This makes the listing include unconfirmed transactions and returns most recent transactions first.",
-		},
-		handler: func(args []string, as *appState) error {
-			var startHeight, endHeight int32 = 0, 0
-			if len(args) > 0 {
-				i, err := strconv.ParseInt(args[0], 10, 32)
-				if err != nil {
-					return fmt.Errorf("start height parsing error: %v", err)
+
+				out.msgs(func(pf printf) {
+					r := ru.RatchetDebugInfo()
+					pf("")
+					pf("Info for user %s", strescape.Nick(ru.Nick()))
+					pf("              UID: %s", ru.ID())
+					if ru.Nick() != ab.ID.Nick {
+						pf("    Original Nick: %s", strescape.Nick(ab.ID.Nick))
+					}
+					pf("             Name: %s", strescape.Content(ab.ID.Name))
+					if ab.ID.Status != "" {
+						pf("           Status: %s", strescape.Content(ab.ID.Status))
+					}
+					pf("          Ignored: %v", ru.IsIgnored())
+					pf("    First Created: %s", ab.FirstCreated.Format(ISO8601DateTimeMs))
+					pf("Handshake Attempt: %s", ab.LastHandshakeAttempt.Format(ISO8601DateTimeMs))
+					pf("Last Encrypt Time: %s", r.LastEncTime.Format(ISO8601DateTimeMs))
+					pf("Last Decrypt Time: %s", r.LastDecTime.Format(ISO8601DateTimeMs))
+					pf("          Send RV: %s (%s...)",
+						r.SendRVPlain, r.SendRV.ShortLogID())
+					pf("          Recv RV: %s (%s...)",
+						r.RecvRVPlain, r.RecvRV.ShortLogID())
+					pf("         Drain RV: %s (%s...)",
+						r.DrainRVPlain, r.DrainRV.ShortLogID())
+					pf("      My Reset RV: %s", r.MyResetRV)
+					pf("   Their Reset RV: %s", r.TheirResetRV)
+					pf("       Saved Keys: %d", r.NbSavedKeys)
+					pf("     Will Ratchet: %v", r.WillRatchet)
+					if len(ab.ID.Avatar) > 0 {
+						pf("View user's avatar with the following command:")
+						pf("  /ab %s viewavatar", args[0])
+					}
+				})
+				return nil
+			}
+
+			ab := as.c.AddressBook()
+			var maxNickLen int
+			for i := range ab {
+				ab[i].NickAlias = strescape.Nick(ab[i].Nick())
+				l := lipgloss.Width(ab[i].ID.Nick)
+				if l > maxNickLen {
+					maxNickLen = l
 				}
-				startHeight = int32(i)
 			}
-			if len(args) > 1 {
-				i, err := strconv.ParseInt(args[1], 10, 32)
-				if err != nil {
-					return fmt.Errorf("end height parsing error: %v", err)
+			maxNickLen = clamp(maxNickLen, 5, as.winW-64-10)
+			sort.Slice(ab, func(i, j int) bool {
+				ni := ab[i].NickAlias
+				nj := ab[j].NickAlias
+				return as.collator.CompareString(ni, nj) < 0
+			})
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Address Book")
+				for _, entry := range ab {
+					ignored := ""
+					if entry.Ignored {
+						ignored = " (ignored)"
+					}
+					pf("%*s - %s%s", maxNickLen, entry.NickAlias,
+						entry.ID, ignored)
 				}
-				endHeight = int32(i)
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
 			}
-
-			// TODO: What if GetTransactions it too large?
-			as.cwHelpMsg("Fetching list of transactions...")
-			go func() {
-				req := &lnrpc.GetTransactionsRequest{StartHeight: startHeight, EndHeight: endHeight}
-				txs, err := as.lnRPC.GetTransactions(as.ctx, req)
-				if err != nil {
-					errMsg := fmt.Sprintf("Unable to list transactions: %v", err)
-					as.diagMsg(as.styles.Load().err.Render(errMsg))
-					return
+			if len(args) == 1 {
+				if strings.HasPrefix("viewavatar", arg) {
+					return []string{"viewavatar"}
 				}
-
-				as.cwHelpMsgs(func(pf printf) {
-					pf("")
-					pf("Wallet transactions")
-					pf("       Net Amount -  Height - Tx Hash")
-					for _, tx := range txs.Transactions {
-						value := dcrutil.Amount(tx.Amount)
-						pf("%13.8f DCR - %7d - %s",
-							value.ToCoin(), tx.BlockHeight, tx.TxHash)
-					}
-				})
-			}()
+			}
 			return nil
 		},
-	},
-}
-
-var pagesCommands = []tuicmd{
-	{
-		cmd:   "view",
-		descr: "View user page",
-		usage: "<nick> [<path/to/page>]",
-		handler: func(args []string, as *appState) error {
+	}, {
+		cmd:     "msg",
+		usage:   "<nick or id> <message>",
+		aliases: []string{"m"},
+		descr:   "Send a message to a known user",
+		long:    []string{"Whenever two users with the same nick exist in the DB, disambiguate by using a prefix of the ID."},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+				return usageError{msg: "Nick or ID cannot be empty"}
 			}
-			uid, err := as.c.UIDByNick(args[0])
-			if err != nil {
-				return err
+			if len(args) < 2 {
+				return usageError{msg: "Message cannot be empty"}
 			}
 
-			nextSess, err := as.c.NewPagesSession()
+			ru, err := as.c.UserByNick(args[0])
 			if err != nil {
 				return err
 			}
 
-			pagePath := "index.md"
-			if len(args) > 1 {
-				pagePath = strings.TrimSpace(args[1])
-			}
-			return as.fetchPage(uid, pagePath, nextSess, 0, nil)
+			_, msg := popNArgs(rawCmd, 2) // cmd + nick
+			cw := as.findOrNewChatWindow(ru.ID(), ru.Nick())
+			go as.pm(cw, msg)
+			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
@@ -2927,500 +10454,396 @@ var pagesCommands = []tuicmd{
 			return nil
 		},
 	}, {
-		cmd:   "local",
-		descr: "View local user page",
-		usage: "[<path/to/page>]",
-		handler: func(args []string, as *appState) error {
-			pagePath := "index.md"
-			if len(args) > 1 {
-				pagePath = strings.TrimSpace(args[1])
+		cmd:   "edit",
+		usage: "<new message>",
+		descr: "Edit the last message sent in the current window",
+		long:  []string{"The edit is sent to the remote user or to all members of the GC."},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "Message cannot be empty"}
 			}
 
-			// Always use the same session ID for convenience when
-			// fetching a local page.
-			nextSess := clientintf.PagesSessionID(0)
-			return as.fetchPage(as.c.PublicID(), pagePath, nextSess, 0, nil)
-		},
-	},
-}
-
-var filterCommands = []tuicmd{
-	{
-		cmd:           "list",
-		usableOffline: true,
-		aliases:       []string{"ls"},
-		descr:         "Lists content-based filters",
-		handler: func(args []string, as *appState) error {
-			filters := as.c.ListContentFilters()
-			if len(filters) == 0 {
-				as.cwHelpMsg("Client has no content-based filters")
-				return nil
+			cw := as.activeChatWindow()
+			if cw == nil {
+				return fmt.Errorf("current window is not a chat window")
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Content filters (%d total)", len(filters))
-				for _, cf := range filters {
-					var s string
-					if !cf.SkipPMs {
-						s += "PMs, "
-					}
-					if !cf.SkipGCMs {
-						s += "GCMs"
-						if cf.GC != nil && !cf.GC.IsEmpty() {
-							gc, _ := as.c.GetGCAlias(*cf.GC)
-							s += fmt.Sprintf(" gc=%s", strescape.Nick(gc))
-						}
-						s += ", "
-
-					}
-					if !cf.SkipPosts {
-						s += "posts, "
-					}
-					if !cf.SkipPostComments {
-						s += "post comments, "
-					}
-					if cf.UID != nil {
-						nick, _ := as.c.UserNick(*cf.UID)
-						s += fmt.Sprintf("user=%s, ", strescape.Nick(nick))
-					}
+			_, msg := popNArgs(rawCmd, 1) // cmd
+			return as.editLastMsg(cw, msg)
+		},
+	}, {
+		cmd:   "reply",
+		usage: "<message>",
+		descr: "Reply to the last message received in the current window",
+		long:  []string{"The reply is tracked in the thread of the original message."},
+		rawHandler: func(rawCmd string, args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "Message cannot be empty"}
+			}
 
-					s += fmt.Sprintf("regexp=\"%s\"", cf.Regexp)
-					pf("%08d - %s", cf.ID, s)
-				}
-			})
+			cw := as.activeChatWindow()
+			if cw == nil {
+				return fmt.Errorf("current window is not a chat window")
+			}
 
-			return nil
+			_, msg := popNArgs(rawCmd, 1) // cmd
+			return as.replyToLastMsg(cw, msg)
 		},
 	}, {
-		cmd:           "add",
-		usableOffline: true,
-		descr:         "Add a simple, case-insensitive content filter",
-		usage:         "[filter]",
-		long: []string{
-			"Adding a filter through this command filters content from ",
-			"all users on all contexts. Use the 'addrule' command for ",
-			"specifying more complex rules.",
-		},
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			_, expr := popNArgs(rawCmd, 2) // cmd+subcmd
-			if len(expr) == 0 {
-				return usageError{"filter expression cannot be empty"}
+		cmd:   "react",
+		usage: "<reaction>",
+		descr: "React to the last message received in the current window",
+		long: []string{"The reaction is usually an emoji (for example, 👍) and is sent to the remote user or to all members of the GC.",
+			"Use '/unreact <reaction>' to remove a previously sent reaction."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "Reaction cannot be empty"}
 			}
 
-			// Add the case-insensitive flag.
-			expr = "(?i)" + regexp.QuoteMeta(expr)
+			cw := as.activeChatWindow()
+			if cw == nil {
+				return fmt.Errorf("current window is not a chat window")
+			}
+			return as.reactToLastMsg(cw, args[0], false)
+		},
+	}, {
+		cmd:   "unreact",
+		usage: "<reaction>",
+		descr: "Remove a reaction to the last message received in the current window",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "Reaction cannot be empty"}
+			}
 
-			cf := &clientdb.ContentFilter{Regexp: expr}
-			err := as.c.StoreContentFilter(cf)
-			if err != nil {
-				return err
+			cw := as.activeChatWindow()
+			if cw == nil {
+				return fmt.Errorf("current window is not a chat window")
 			}
-			as.cwHelpMsg("Added content filter rule %d", cf.ID)
-			return nil
+			return as.reactToLastMsg(cw, args[0], true)
 		},
 	}, {
-		cmd:           "addrule",
+		cmd:           "winclose",
 		usableOffline: true,
-		descr:         "Add a content-based filter",
-		usage:         "[user=<user>] [gc=<gc> | noGC] [noPost] [noPC] [noPM] [--] [regexp]",
-		long: []string{
-			"Content-based filters drop received messages before they are ",
-			"presented to the user.",
-			"",
-			"If an incoming message matches any of the existing filters, ",
-			"the message is dropped.",
-			"",
-			"By default, filters apply to all messages, received from any ",
-			"user and on all contexts (PMs, GCMs, posts and post comments). ",
-			"Some attributes allow refining when the filter applies: ",
-			"",
-			"  - user=<user>: only test filter if the message is from a specific user",
-			"  - gc=<gc>: only test filter if GCM is in a specific GC",
-			"  - noGC: do not apply filter for messages in GCs",
-			"  - noPost: do not apply filter for post conent",
-			"  - noPC: do not apply filter for post comments",
-			"  - noPM: do not apply filter for PMs",
-			"",
-			"Everything after the last valid option or after a literal '--'",
-			"is considered part of the regexp.",
-			"",
-			"The regexp follows Go's regexp package rules. As a reminder, ",
-			"it is case-sensitive by default, unless it is started with ",
-			"the '(?i)' flag.",
-			"",
-			"Examples of filters:",
-			"",
-			"- Filter all posts and post comments from user foo that contain ",
-			"the string 'barbaz':",
-			"",
-			"    /filter addrule user=foo nogc nopm -- barbaz",
-			"",
-			"- Filter all messages in GC 'testgc' that contain the string 'barbaz':",
-			"",
-			"    /filter addrule gc=testgc nopm nopost nopc -- barbaz",
-			"",
-			"- Filter any message that begins with the case insensitive string 'barbaz':",
-			"",
-			"    /filter addrule (?i)^barbaz",
-			"",
-			"Use the /filter test* commands to test if the setup filters work as ",
-			"needed.",
+		aliases:       []string{"wc"},
+		descr:         "Close the current window",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			return as.closeActiveWindow()
 		},
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
+	}, {
+		cmd:           "win",
+		usableOffline: true,
+		usage:         "<number | 'log' | 'console' | 'feed'>",
+		aliases:       []string{"w"},
+		descr:         "Change the current window",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "filter cannot be empty"}
+				return usageError{"specify a window number"}
 			}
+			if args[0] == "log" {
+				as.changeActiveWindow(activeCWLog)
+			} else if args[0] == "lndlog" {
+				as.changeActiveWindow(activeCWLndLog)
+			} else if args[0] == "0" || args[0] == "console" {
+				as.changeActiveWindow(activeCWDiag)
+			} else if args[0] == "feed" {
+				as.changeActiveWindow(activeCWFeed)
+			} else {
+				win, err := strconv.ParseInt(args[0], 10, 32)
+				if err != nil {
+					return err
+				}
 
-			var cf clientdb.ContentFilter
-			nargs := 2 // cmd+subcmd
-		loopArgs:
-			for _, arg := range args {
-				switch {
-				case strings.HasPrefix(arg, "user="):
-					nick := arg[5:]
-					uid, err := as.c.UIDByNick(nick)
-					if err != nil {
-						return err
-					}
-					cf.UID = &uid
-					nargs += 1
-
-				case strings.HasPrefix(arg, "gc="):
-					name := arg[3:]
-					gc, err := as.c.GCIDByName(name)
-					if err != nil {
-						return err
-					}
-					cf.GC = &gc
-					nargs += 1
-
-				case strings.EqualFold(arg, "noPost"):
-					cf.SkipPosts = true
-					nargs += 1
-
-				case strings.EqualFold(arg, "noPC"):
-					cf.SkipPostComments = true
-					nargs += 1
-
-				case strings.EqualFold(arg, "noGC"):
-					cf.SkipGCMs = true
-					nargs += 1
-
-				case strings.EqualFold(arg, "noPM"):
-					cf.SkipPMs = true
-					nargs += 1
-
-				case arg == "--":
-					nargs += 1
-					break loopArgs
-
-				default:
-					break loopArgs
+				// Chat windows are 0-based internally, but
+				// 1-based to preserve legacy UX.
+				win -= 1
+				as.changeActiveWindow(int(win))
+			}
+			return nil
+		},
+	}, {
+		cmd:           "winlist",
+		aliases:       []string{"wls"},
+		usableOffline: true,
+		descr:         "List currently opened windows",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.chatWindowsMtx.Lock()
+			windows := as.chatWindows[:]
+			as.chatWindowsMtx.Unlock()
+			out.msgs(func(pf printf) {
+				pf("Current windows")
+				for i, cw := range windows {
+					isGC := ""
+					if cw.isGC {
+						isGC = " (GC)"
+					}
+					pf("%2d - %s%s", i+1, cw.alias, isGC)
 				}
-			}
-
-			_, cf.Regexp = popNArgs(rawCmd, nargs)
-			if cf.Regexp == "" {
-				return usageError{msg: "regexp cannot be empty"}
-			}
-
-			err := as.c.StoreContentFilter(&cf)
-			if err != nil {
-				return err
-			}
-
-			as.cwHelpMsg("Added content filter rule %d", cf.ID)
+			})
 			return nil
 		},
 	}, {
-		cmd:     "del",
-		aliases: []string{"delete", "remove", "rem"},
-		descr:   "Remove a filter rule",
-		usage:   "[id]",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "rule number cannot be empty"}
-			}
-
-			id, err := strconv.ParseUint(args[0], 10, 64)
-			if err != nil {
-				return err
-			}
-
-			if err := as.c.RemoveContentFilter(id); err != nil {
-				return fmt.Errorf("unable to remove rule %d: %v", id, err)
+		cmd:   "gc",
+		usage: "[sub]",
+		descr: "Group chat commands",
+		sub:   gcCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(gcCommands, arg, false)
 			}
-
-			as.cwHelpMsg("Removed content filter rule %d", id)
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "testpm",
-		usableOffline: true,
-		descr:         "Test if a PM would be filtered",
-		usage:         "<user> <pm>",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
-			}
-
-			uid, err := as.c.UIDByNick(args[0])
-			if err != nil {
-				return err
+		cmd:   "invite",
+		usage: "[sub]",
+		descr: "invite commands",
+		sub:   inviteCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(inviteCommands, arg, false)
 			}
-
-			_, pm := popNArgs(rawCmd, 3) // cmd+subcmd+user
-			filter, id := as.c.FilterPM(uid, pm)
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Test message: %q", pm)
-				if !filter {
-					pf("Message would NOT be filtered")
-				} else {
-					pf("Message would be filtered by rule %d", id)
-				}
-			})
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "testgcm",
-		usableOffline: true,
-		descr:         "Test if a GC message would be filtered",
-		usage:         "<user> <gc> <pm>",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
+		cmd:         "paytip",
+		spendsFunds: true,
+		usage:       "<nick or id> <amount>",
+		descr:       "Send a tip with the given amount to the user",
+		long: []string{
+			"The amount is in DCR, unless prefixed with '$' or suffixed with 'usd' (e.g. '$5' or '5usd'), in which case it is converted to DCR at the current exchange rate. The conversion is recorded in the receipt of the tip and the tip is not paid if the rate changes by more than the 'tipfiatmaxslippage' option of the [payment] section of the config file before the invoice is paid.",
+			"Note: the tip is sent via LN, so the other peer only receives the tip if it is also online an connected to LN.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
+				return usageError{msg: "destination nick cannot be empty"}
 			}
 			if len(args) < 2 {
-				return usageError{msg: "gc cannot be empty"}
+				return usageError{msg: "amount cannot be empty"}
 			}
 
 			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
-			gc, err := as.c.GCIDByName(args[1])
+			cw := as.findOrNewChatWindow(uid, args[0])
+			dcrAmount, usdAmount, err := parseTipAmount(args[1])
 			if err != nil {
-				return err
+				return usageError{msg: err.Error()}
 			}
 
-			_, gcm := popNArgs(rawCmd, 4) // cmd+subcmd+user+gc
-			filter, id := as.c.FilterGCM(uid, gc, gcm)
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Test message: %q", gcm)
-				if !filter {
-					pf("Message would NOT be filtered")
-				} else {
-					pf("Message would be filtered by rule %d", id)
-				}
-			})
+			if usdAmount > 0 {
+				go as.payTipUSD(out, cw, usdAmount)
+				return nil
+			}
+			go as.payTip(out, cw, dcrAmount)
 			return nil
 		},
-	}, {
-		cmd:           "testpost",
-		usableOffline: true,
-		descr:         "Test if a post would be filtered",
-		usage:         "<user> <post>",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
-			}
-
-			uid, err := as.c.UIDByNick(args[0])
-			if err != nil {
-				return err
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
 			}
-
-			_, post := popNArgs(rawCmd, 3) // cmd+subcmd+user
-			filter, id := as.c.FilterPost(uid, clientintf.PostID{}, post)
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Test post: %q", post)
-				if !filter {
-					pf("Post would NOT be filtered")
-				} else {
-					pf("Post would be filtered by rule %d", id)
-				}
-			})
 			return nil
 		},
 	}, {
-		cmd:           "testpostcomment",
-		usableOffline: true,
-		descr:         "Test if a post comment would be filtered",
-		usage:         "<user> <post>",
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
+		cmd:   "tipfee",
+		usage: "<nick or id> <dcr amount>",
+		descr: "Estimate the routing fee to tip the user with the given dcr amount",
+		long: []string{
+			"The estimate is made against the LN node of the user, which is only known after they have sent an invoice to the local client. The max fee is defined by the fee policy (see '/ln feepolicy').",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
+				return usageError{msg: "destination nick cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "amount cannot be empty"}
 			}
-
 			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
-
-			_, comment := popNArgs(rawCmd, 3) // cmd+subcmd+user
-			postFrom, pid := clientintf.UserID{0: 0x01}, clientintf.UserID{0: 0x02}
-			filter, id := as.c.FilterPostComment(uid, postFrom, pid, comment)
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Test comment: %q", comment)
-				if !filter {
-					pf("Comment would NOT be filtered")
-				} else {
-					pf("Comment would be filtered by rule %d", id)
-				}
-			})
-			return nil
-		},
-	},
-}
-
-var myAvatarCmds = []tuicmd{
-	{
-		cmd:   "set",
-		descr: "Set the user avatar",
-		usage: "<filename>",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return fmt.Errorf("filename cannot be empty")
-			}
-
-			avatar, err := os.ReadFile(args[0])
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
 			if err != nil {
 				return err
 			}
-
-			if !strings.HasPrefix(imageMimeType(avatar), "image/") {
-				return fmt.Errorf("unsupported file format")
-			}
-
-			err = as.c.UpdateLocalAvatar(avatar)
+			est, err := as.c.EstimateTipFee(uid, dcrAmount)
 			if err != nil {
 				return err
 			}
-
-			as.diagMsg("Avatar updated!")
+			maxFee := "unlimited"
+			if est.MaxFeeMAtoms >= 0 {
+				maxFee = fmt.Sprintf("%.8f DCR", float64(est.MaxFeeMAtoms)/1e11)
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Tip fee estimate for %.8f DCR to %s", dcrAmount,
+					strescape.Nick(args[0]))
+				pf("Estimated routing fee: %.8f DCR (%d hops)",
+					float64(est.FeeMAtoms)/1e11, est.Hops)
+				pf("Max routing fee: %s", maxFee)
+			})
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
-			return fileCompleter(arg)
-		},
-	}, {
-		cmd:   "clear",
-		descr: "Removes the user avatar",
-		long:  []string{"An update is sent to remote users to clear the avatar."},
-		handler: func(args []string, as *appState) error {
-			err := as.c.UpdateLocalAvatar(nil)
-			if err != nil {
-				return err
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
 			}
-			as.diagMsg("Avatar updated")
 			return nil
 		},
 	}, {
-		cmd:           "view",
-		descr:         "View the user avatar",
-		usableOffline: true,
-		handler: func(args []string, as *appState) error {
-			pubid := as.c.Public()
-			if len(pubid.Avatar) == 0 {
-				return fmt.Errorf("user does not have avatar")
+		cmd:         "tipgc",
+		spendsFunds: true,
+		usage:       "<gc> <dcr amount> [nick...]",
+		descr:       "Split a tip of the given dcr amount across members of a GC",
+		long: []string{
+			"The amount is split in equal parts, with an individual tip sent to each member. If no members are specified, the amount is split across all members of the GC with which the local client has KX'd.",
+			"Progress of the tips is shown in the GC window and a summary message is sent to the GC once all tips complete or fail.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "gc cannot be empty"}
 			}
-			cmd, err := as.viewRaw(pubid.Avatar)
+			if len(args) < 2 {
+				return usageError{msg: "amount cannot be empty"}
+			}
+			gcID, err := as.c.GCIDByName(args[0])
 			if err != nil {
 				return err
 			}
-			as.sendMsg(msgRunCmd(cmd))
-			return nil
-		},
-	},
-}
-
-var commands = []tuicmd{
-	{
-		cmd:           "backup",
-		usableOffline: true,
-		descr:         "Create a backup of brclient",
-		usage:         "<backup-directory>",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "backup directory cannot be empty"}
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				return err
+			}
+			var members []clientintf.UserID
+			for _, nick := range args[2:] {
+				uid, err := as.c.UIDByNick(nick)
+				if err != nil {
+					return err
+				}
+				members = append(members, uid)
 			}
-			destPath := cleanAndExpandPath(args[0])
 
-			backupFile, err := as.c.Backup(as.ctx, as.rootDir, destPath)
+			const maxAttempts = 1
+			const announce = true
+			split, err := as.c.TipGCMembers(gcID, dcrAmount, members,
+				maxAttempts, announce)
 			if err != nil {
 				return err
 			}
-			as.log.Infof("Successfully backed up to %v", backupFile)
+			cw := as.findOrNewGCWindow(gcID)
+			cw.newInternalMsg(fmt.Sprintf("Started tip split of %s: %s "+
+				"to each of %d members", split.Total, split.Share,
+				len(split.Members)))
+			as.repaintIfActive(cw)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return gcCompleter(arg, as)
+			}
+			if len(args) > 1 {
+				return nickCompleter(arg, as)
+			}
 			return nil
 		},
 	}, {
-		cmd:           "online",
+		cmd:           "budget",
 		usableOffline: true,
-		descr:         "Attempt to connect and remain connected to the server",
-		handler: func(args []string, as *appState) error {
-			as.c.GoOnline()
+		usage:         "[sub]",
+		descr:         "Manage the budgets of tips and content payments",
+		sub:           budgetCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(budgetCommands, arg, false)
+			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "offline",
+		cmd:           "invoices",
 		usableOffline: true,
-		descr:         "Disconnect from server and remain offline",
-		handler: func(args []string, as *appState) error {
-			as.c.RemainOffline()
+		usage:         "[sub]",
+		descr:         "Manage the invoices of tips",
+		sub:           invoicesCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(invoicesCommands, arg, false)
+			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "enablecanpay",
-		usableOffline: true,
-		descr:         "Force-enable the 'can pay server' flag",
-		long: []string{"This allows bypassing the internal test that checks whether the client can reach the server LN node before sending commands.",
-			"This is sometimes needed when routing estimation in the underlying LN node is wrong.",
-			"Once force-enabled, this remains enabled until the app is closed."},
-		handler: func(args []string, as *appState) error {
-			as.canPayServerMtx.Lock()
-			as.canPayServer = true
-			as.canPayServerTestTime = time.Now().Add(time.Hour * 24 * 365)
-			as.canPayServerMtx.Unlock()
-			as.cwHelpMsg("Force-enabled 'can pay server' flag")
+		cmd:   "onchaintip",
+		usage: "[sub]",
+		descr: "Send tips to users as on-chain transactions",
+		long: []string{
+			"Tips that fail to be paid through LN are also sent on-chain when they are at least the amount set in the 'onchaintipmin' option of the [payment] section of the config file.",
+		},
+		sub: onchainTipCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(onchainTipCommands, arg, false)
+			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "debuglevel",
-		usableOffline: true,
-		aliases:       []string{"dlvl"},
-		usage:         "<level | subsys=level>",
-		descr:         "Set the log level of the application subsystems",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "level cannot be empty"}
-			}
-			err := as.logBknd.setLogLevel(args[0])
-			if err != nil {
-				return err
+		cmd:   "escrow",
+		usage: "[sub]",
+		descr: "Manage payments held until released by the buyer",
+		sub:   escrowCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(escrowCommands, arg, false)
 			}
-			as.log.Infof("Modified log level to: %q", args[0])
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "ignore",
+		cmd:           "receipts",
 		usableOffline: true,
-		usage:         "<nick>",
-		descr:         "Ignore user messages",
-		long:          []string{"This maintains internal communication with the user but avoids surfacing private and group messages from them."},
-		handler: func(args []string, as *appState) error {
+		usage:         "<nick or id>",
+		descr:         "List the signed receipts of payments exchanged with the user",
+		long: []string{
+			"Receipts are signed by the payer after a tip or content payment completes and are stored by both parties. The signature of received receipts is verified before they are stored.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
+				return usageError{msg: "nick cannot be empty"}
 			}
 			uid, err := as.c.UIDByNick(args[0])
 			if err != nil {
 				return err
 			}
-			return as.c.Ignore(uid, true)
+			receipts, err := as.c.ListPaymentReceipts(uid)
+			if err != nil {
+				return err
+			}
+			nick, _ := as.c.UserNick(uid)
+			if len(receipts) == 0 {
+				out.msg("No payment receipts exchanged with %s",
+					strescape.Nick(nick))
+				return nil
+			}
+			out.msgs(func(pf printf) {
+				pf("Payment receipts exchanged with %s", strescape.Nick(nick))
+				for _, pr := range receipts {
+					dir := "from"
+					if pr.Outbound {
+						dir = "to"
+					}
+					ts := time.Unix(pr.Receipt.Timestamp, 0)
+					pf("%s %s %.8f DCR%s %s %s",
+						ts.Format(ISO8601DateTime),
+						strescape.Content(pr.Receipt.Kind),
+						float64(pr.Receipt.MilliAtoms)/1e11,
+						fiatConversionStr(pr.Receipt.Fiat), dir,
+						strescape.Nick(nick))
+					pf("  Payment hash: %x", pr.Receipt.PaymentHash)
+				}
+			})
+			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
@@ -3429,415 +10852,543 @@ var commands = []tuicmd{
 			return nil
 		},
 	}, {
-		cmd:           "unignore",
+		cmd:           "recurringtip",
 		usableOffline: true,
-		usage:         "<nick>",
-		descr:         "Unignore user",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "user cannot be empty"}
-			}
-			uid, err := as.c.UIDByNick(args[0])
-			if err != nil {
-				return err
-			}
-			return as.c.Ignore(uid, false)
-		},
+		usage:         "[sub]",
+		descr:         "Manage tips periodically paid to users",
+		sub:           recurringTipCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return cmdCompleter(recurringTipCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:   "block",
-		usage: "<nick>",
-		descr: "Remove and block further contact with the user",
+		cmd:   "tipexternal",
+		usage: "<lightning address or lnurl> <dcr amount> [comment] | receipts",
+		descr: "Send a tip to a lightning address or LNURL-pay link",
 		long: []string{
-			"The remote user is asked not to send any more messages to the local client.",
+			"Tips a recipient outside of the Bison Relay contacts through LNURL-pay. The invoice returned by the service of the recipient is checked against the requested amount and the metadata of the pay request before being paid.",
+			"Use '/tipexternal receipts' to list the receipts of the external tips sent.",
 		},
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) > 0 && args[0] == "receipts" {
+				receipts, err := as.c.ListExternalTips()
+				if err != nil {
+					return err
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					pf("External tips")
+					for i := range receipts {
+						r := &receipts[i]
+						pf("%s %.8f DCR to %s (fees %.8f DCR) hash %x",
+							r.Timestamp.Format(ISO8601DateTime),
+							float64(r.MAtoms)/1e11,
+							strescape.Content(r.Target),
+							float64(r.Fees)/1e11, r.PaymentHash)
+						as.printExternalTipDetails(pf, r)
+					}
+					if len(receipts) == 0 {
+						pf("No external tips sent")
+					}
+				})
+				return nil
 			}
 
-			uid, err := as.c.UIDByNick(args[0])
+			if len(args) < 1 {
+				return usageError{msg: "destination cannot be empty"}
+			}
+			if len(args) < 2 {
+				return usageError{msg: "amount cannot be empty"}
+			}
+			dcrAmount, err := strconv.ParseFloat(args[1], 64)
 			if err != nil {
 				return err
 			}
-			cw := as.findOrNewChatWindow(uid, args[0])
-			go as.block(cw)
+			amt, err := dcrutil.NewAmount(dcrAmount)
+			if err != nil {
+				return err
+			}
+			if amt <= 0 {
+				return fmt.Errorf("amount must be positive")
+			}
+			comment := strings.Join(args[2:], " ")
+			go as.tipExternal(out, args[0], amt, comment)
 			return nil
 		},
+	}, {
+		cmd:   "ft",
+		usage: "[sub]",
+		descr: "File Transfer commands",
+		sub:   ftCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return cmdCompleter(ftCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:     "list",
-		aliases: []string{"l", "ls"},
-		usage:   "[subcmd]",
-		descr:   "List various things",
-		sub:     listCommands,
+		cmd:   "post",
+		usage: "[sub]",
+		descr: "post related commands",
+		sub:   postCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(listCommands, arg, false)
+				return cmdCompleter(postCommands, arg, false)
+			}
+			return nil
+		},
+		handler: handleWithSubcmd(postCommands, "new"),
+	}, {
+		cmd:           "ln",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "LN related commands",
+		sub:           lnCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(lnCommands, arg, false)
 			}
 			return nil
 		},
 		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "query",
+		cmd:           "filters",
 		usableOffline: true,
-		aliases:       []string{"q"},
-		usage:         "<nick>",
-		descr:         "Open a chat window with the provided nick",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "nick cannot be empty"}
+		usage:         "[sub]",
+		descr:         "Content filter commands",
+		sub:           filterCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(filterCommands, arg, false)
 			}
-			return as.openChatWindow(args[0])
+			return nil
 		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "pendingrms",
+		usableOffline: true,
+		aliases:       []string{"prm"},
+		usage:         "[sub]",
+		descr:         "Manage outbound messages not yet sent to the server",
+		sub:           pendingRMCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return addressbookCompleter(arg, as)
+				return cmdCompleter(pendingRMCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "addressbook",
-		usage:         "[<user>] [viewavatar]",
+		cmd:           "search",
 		usableOffline: true,
-		aliases:       []string{"ab"},
+		usage:         "[sub]",
+		descr:         "Search the message history",
 		long: []string{
-			"Without arguments, shows the entire addressbook (list of known users).",
+			"Search terms are case insensitive and all of them must be present in a message for it to be found. The text of embedded content (such as file names and embedded text files) is also searched.",
 			"",
-			"If passing a nick on the first argument, it shows detailed addressbook information for that user.",
+			"Terms in the form 'since:YYYY-MM-DD' and 'until:YYYY-MM-DD' restrict the search to a date range.",
 			"",
-			"If the user has an avatar, passing 'viewavatar' will open the external viewer for that avatar image type.",
-		},
-		descr: "Show the address book of known remote users (or a user profile)",
-		handler: func(args []string, as *appState) error {
-			var viewAvatar bool
-			if len(args) > 1 {
-				switch {
-				case args[1] == "viewavatar":
-					viewAvatar = true
-				default:
-					return fmt.Errorf("unknown argument %q", args[1])
-				}
-			}
-
-			if len(args) > 0 {
-				ru, err := as.c.UserByNick(args[0])
-				if err != nil {
-					return err
-				}
-
-				ab, err := as.c.AddressBookEntry(ru.ID())
-				if err != nil {
-					return err
-				}
-
-				if viewAvatar {
-					if len(ab.ID.Avatar) == 0 {
-						return fmt.Errorf("user does not have an avatar")
-					}
-					cmd, err := as.viewRaw(ab.ID.Avatar)
-					if err != nil {
-						return err
-					}
-					as.sendMsg(msgRunCmd(cmd))
-					return nil
-				}
-
-				as.cwHelpMsgs(func(pf printf) {
-					r := ru.RatchetDebugInfo()
-					pf("")
-					pf("Info for user %s", strescape.Nick(ru.Nick()))
-					pf("              UID: %s", ru.ID())
-					if ru.Nick() != ab.ID.Nick {
-						pf("    Original Nick: %s", strescape.Nick(ab.ID.Nick))
-					}
-					pf("             Name: %s", strescape.Content(ab.ID.Name))
-					pf("          Ignored: %v", ru.IsIgnored())
-					pf("    First Created: %s", ab.FirstCreated.Format(ISO8601DateTimeMs))
-					pf("Handshake Attempt: %s", ab.LastHandshakeAttempt.Format(ISO8601DateTimeMs))
-					pf("Last Encrypt Time: %s", r.LastEncTime.Format(ISO8601DateTimeMs))
-					pf("Last Decrypt Time: %s", r.LastDecTime.Format(ISO8601DateTimeMs))
-					pf("          Send RV: %s (%s...)",
-						r.SendRVPlain, r.SendRV.ShortLogID())
-					pf("          Recv RV: %s (%s...)",
-						r.RecvRVPlain, r.RecvRV.ShortLogID())
-					pf("         Drain RV: %s (%s...)",
-						r.DrainRVPlain, r.DrainRV.ShortLogID())
-					pf("      My Reset RV: %s", r.MyResetRV)
-					pf("   Their Reset RV: %s", r.TheirResetRV)
-					pf("       Saved Keys: %d", r.NbSavedKeys)
-					pf("     Will Ratchet: %v", r.WillRatchet)
-					if len(ab.ID.Avatar) > 0 {
-						pf("View user's avatar with the following command:")
-						pf("  /ab %s viewavatar", args[0])
-					}
-				})
-				return nil
-			}
-
-			ab := as.c.AddressBook()
-			var maxNickLen int
-			for i := range ab {
-				ab[i].NickAlias = strescape.Nick(ab[i].Nick())
-				l := lipgloss.Width(ab[i].ID.Nick)
-				if l > maxNickLen {
-					maxNickLen = l
-				}
-			}
-			maxNickLen = clamp(maxNickLen, 5, as.winW-64-10)
-			sort.Slice(ab, func(i, j int) bool {
-				ni := ab[i].NickAlias
-				nj := ab[j].NickAlias
-				return as.collator.CompareString(ni, nj) < 0
-			})
-			as.cwHelpMsgs(func(pf printf) {
-				pf("")
-				pf("Address Book")
-				for _, entry := range ab {
-					ignored := ""
-					if entry.Ignored {
-						ignored = " (ignored)"
-					}
-					pf("%*s - %s%s", maxNickLen, entry.NickAlias,
-						entry.ID, ignored)
-				}
-			})
-			return nil
+			"Only messages logged while the message logs are enabled are searchable. Use the 'reindex' subcommand to index messages logged before the search index existed.",
 		},
+		sub: searchCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
-			}
-			if len(args) == 1 {
-				if strings.HasPrefix("viewavatar", arg) {
-					return []string{"viewavatar"}
-				}
+				return cmdCompleter(searchCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:     "msg",
-		usage:   "<nick or id> <message>",
-		aliases: []string{"m"},
-		descr:   "Send a message to a known user",
-		long:    []string{"Whenever two users with the same nick exist in the DB, disambiguate by using a prefix of the ID."},
-		rawHandler: func(rawCmd string, args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{msg: "Nick or ID cannot be empty"}
-			}
-			if len(args) < 2 {
-				return usageError{msg: "Message cannot be empty"}
-			}
-
-			ru, err := as.c.UserByNick(args[0])
-			if err != nil {
-				return err
+		cmd:           "autoreply",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage automatic replies to PMs (away mode)",
+		sub:           autoReplyCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(autoReplyCommands, arg, false)
 			}
-
-			_, msg := popNArgs(rawCmd, 2) // cmd + nick
-			cw := as.findOrNewChatWindow(ru.ID(), ru.Nick())
-			go as.pm(cw, msg)
 			return nil
 		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "contact",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage private notes, tags and fields of users",
+		long: []string{
+			"Notes, tags and custom fields are stored only in the local client and are never sent to remote users.",
+		},
+		sub: contactCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return nickCompleter(arg, as)
+				return cmdCompleter(contactCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "winclose",
+		cmd:           "device",
 		usableOffline: true,
-		aliases:       []string{"wc"},
-		descr:         "Close the current window",
-		handler: func(args []string, as *appState) error {
-			return as.closeActiveWindow()
+		usage:         "[sub]",
+		descr:         "Manage paired devices",
+		long: []string{
+			"Paired devices allow running clients of the same identity on multiple devices at the same time. The primary device exchanges messages with the contacts, sends its state to its paired secondary devices and relays their messages.",
 		},
-	}, {
-		cmd:           "win",
-		usableOffline: true,
-		usage:         "<number | 'log' | 'console' | 'feed'>",
-		aliases:       []string{"w"},
-		descr:         "Change the current window",
-		handler: func(args []string, as *appState) error {
-			if len(args) < 1 {
-				return usageError{"specify a window number"}
-			}
-			if args[0] == "log" {
-				as.changeActiveWindow(activeCWLog)
-			} else if args[0] == "lndlog" {
-				as.changeActiveWindow(activeCWLndLog)
-			} else if args[0] == "0" || args[0] == "console" {
-				as.changeActiveWindow(activeCWDiag)
-			} else if args[0] == "feed" {
-				as.changeActiveWindow(activeCWFeed)
-			} else {
-				win, err := strconv.ParseInt(args[0], 10, 32)
-				if err != nil {
-					return err
-				}
-
-				// Chat windows are 0-based internally, but
-				// 1-based to preserve legacy UX.
-				win -= 1
-				as.changeActiveWindow(int(win))
+		sub: deviceCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(deviceCommands, arg, false)
 			}
 			return nil
 		},
+		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "winlist",
-		aliases:       []string{"wls"},
+		cmd:           "broadcast",
 		usableOffline: true,
-		descr:         "List currently opened windows",
-		handler: func(args []string, as *appState) error {
-			as.chatWindowsMtx.Lock()
-			windows := as.chatWindows[:]
-			as.chatWindowsMtx.Unlock()
-			as.cwHelpMsgs(func(pf printf) {
-				pf("Current windows")
-				for i, cw := range windows {
-					isGC := ""
-					if cw.isGC {
-						isGC = " (GC)"
-					}
-					pf("%2d - %s%s", i+1, cw.alias, isGC)
-				}
-			})
-			return nil
+		usage:         "[sub]",
+		descr:         "Manage broadcast lists and send messages to them",
+		long: []string{
+			"Broadcast lists are locally defined lists of users. Messages sent to a broadcast list are sent as individual PMs to each member, without creating a GC. Members do not know who else received the message.",
 		},
-	}, {
-		cmd:   "gc",
-		usage: "[sub]",
-		descr: "Group chat commands",
-		sub:   gcCommands,
+		sub: broadcastCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(gcCommands, arg, false)
+				return cmdCompleter(broadcastCommands, arg, false)
 			}
 			return nil
 		},
 		handler: subcmdNeededHandler,
 	}, {
-		cmd:   "invite",
-		usage: "[sub]",
-		descr: "invite commands",
-		sub:   inviteCommands,
+		cmd:           "group",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage contact groups and perform batch operations on them",
+		long: []string{
+			"Contact groups are locally defined, named groups of users. Batch operations (sending messages, tipping, inviting to GCs and adding content filter rules) performed on a group apply to each of its members individually.",
+		},
+		sub: contactGroupCommands,
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(inviteCommands, arg, false)
+				return cmdCompleter(contactGroupCommands, arg, false)
 			}
 			return nil
 		},
 		handler: subcmdNeededHandler,
 	}, {
-		cmd:   "paytip",
-		usage: "<nick or id> <dcr amount>",
-		descr: "Send a tip with the given dcr amount to the user",
+		cmd:           "export",
+		usableOffline: true,
+		usage:         "<nick or gc> [json|html] [filename]",
+		descr:         "Export the history of a conversation",
 		long: []string{
-			"Note: the tip is sent via LN, so the other peer only receives the tip if it is also online an connected to LN.",
+			"Exports the logged messages (including embeds) of the PM conversation with a user or of a GC. PM exports also include the tips exchanged with the user.",
+			"",
+			"The format is either 'json' (the default) or 'html' (a self-contained HTML page). If the filename is not specified, the export is saved in the 'exports' dir of the client's root dir.",
 		},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
-				return usageError{msg: "destination nick cannot be empty"}
+				return usageError{msg: "nick or gc cannot be empty"}
 			}
-			if len(args) < 2 {
-				return usageError{msg: "amount cannot be empty"}
+			target, err := as.chatTargetID(args[0])
+			if err != nil {
+				return err
 			}
 
-			uid, err := as.c.UIDByNick(args[0])
+			format := client.ChatExportJSON
+			if len(args) > 1 {
+				format = client.ChatExportFormat(args[1])
+			}
+			if format != client.ChatExportJSON && format != client.ChatExportHTML {
+				return usageError{msg: fmt.Sprintf("unknown format %q", format)}
+			}
+
+			var fname string
+			if len(args) > 2 {
+				fname = args[2]
+			} else {
+				fname = filepath.Join(as.rootDir, "exports",
+					fmt.Sprintf("%s-%s.%s",
+						strescape.PathElement(args[0]),
+						time.Now().Format("20060102-150405"),
+						format))
+			}
+
+			export, err := as.c.ExportChat(target)
 			if err != nil {
 				return err
 			}
-			cw := as.findOrNewChatWindow(uid, args[0])
-			dcrAmount, err := strconv.ParseFloat(args[1], 64)
+			if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 			if err != nil {
 				return err
 			}
-
-			go as.payTip(cw, dcrAmount)
+			if err := export.Write(f, format); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			out.msg("Exported %d messages to %s", len(export.Messages), fname)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
-			if len(args) == 0 {
-				return nickCompleter(arg, as)
+			switch len(args) {
+			case 0:
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
+			case 1:
+				return []string{"json", "html"}
 			}
 			return nil
 		},
 	}, {
-		cmd:   "ft",
-		usage: "[sub]",
-		descr: "File Transfer commands",
-		sub:   ftCommands,
+		cmd:           "archive",
+		usableOffline: true,
+		usage:         "[nick or gc]",
+		descr:         "Archive a conversation and close its window",
+		long: []string{
+			"Archives the PM conversation with a user or a GC. If no nick or gc is specified, the conversation of the current window is archived.",
+			"",
+			"Archived conversations are not opened on startup. They are automatically unarchived when a new message is received or when explicitly opened with /query.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var target zkidentity.ShortID
+			var name string
+			cw := as.activeChatWindow()
+			if len(args) > 0 {
+				var err error
+				if target, err = as.chatTargetID(args[0]); err != nil {
+					return err
+				}
+				name = args[0]
+			} else if cw == nil || cw.isPage {
+				return usageError{msg: "current window is not a chat window"}
+			} else if cw.isGC {
+				target, name = cw.gc, cw.alias
+			} else {
+				target, name = cw.uid, cw.alias
+			}
+
+			if err := as.c.ArchiveChat(target); err != nil {
+				return err
+			}
+			if cw != nil && !cw.isPage && ((cw.isGC && cw.gc == target) ||
+				(!cw.isGC && cw.uid == target)) {
+				if err := as.closeActiveWindow(); err != nil {
+					return err
+				}
+			}
+			out.msg("Archived conversation %s", name)
+			return nil
+		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(ftCommands, arg, false)
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
 			}
 			return nil
 		},
-		handler: subcmdNeededHandler,
 	}, {
-		cmd:   "post",
-		usage: "[sub]",
-		descr: "post related commands",
-		sub:   postCommands,
+		cmd:           "unarchive",
+		usableOffline: true,
+		usage:         "<nick or gc>",
+		descr:         "Unarchive a conversation",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick or gc cannot be empty"}
+			}
+			target, err := as.chatTargetID(args[0])
+			if err != nil {
+				return err
+			}
+			if err := as.c.UnarchiveChat(target); err != nil {
+				return err
+			}
+			out.msg("Unarchived conversation %s", args[0])
+			return nil
+		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(postCommands, arg, false)
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
 			}
 			return nil
 		},
-		handler: handleWithSubcmd(postCommands, "new"),
 	}, {
-		cmd:           "ln",
+		cmd:           "archived",
 		usableOffline: true,
-		usage:         "[sub]",
-		descr:         "LN related commands",
-		sub:           lnCommands,
+		descr:         "List archived conversations",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			archived, err := as.c.ListArchivedChats()
+			if err != nil {
+				return err
+			}
+			if len(archived) == 0 {
+				out.msg("No archived conversations")
+				return nil
+			}
+			ids := maps.Keys(archived)
+			sort.Slice(ids, func(i, j int) bool {
+				return archived[ids[i]].After(archived[ids[j]])
+			})
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Archived conversations")
+				for _, id := range ids {
+					name := id.String()
+					if ru, err := as.c.UserByID(id); err == nil {
+						name = ru.Nick()
+					} else if alias, err := as.c.GetGCAlias(id); err == nil {
+						name = "gc " + alias
+					}
+					pf("%s  %s", archived[id].Format(ISO8601DateTime), name)
+				}
+			})
+			return nil
+		},
+	}, {
+		cmd:     "rreset",
+		aliases: []string{"rr", "ratchetreset"},
+		usage:   "<nick>",
+		descr:   "Request a ratchet reset with the given nick",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			uid, err := as.c.UIDByNick(args[0])
+			if err != nil {
+				return err
+			}
+			cw := as.findOrNewChatWindow(uid, args[0])
+			go as.requestRatchetReset(cw)
+			return nil
+		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(lnCommands, arg, false)
+				return nickCompleter(arg, as)
 			}
 			return nil
 		},
-		handler: subcmdNeededHandler,
 	}, {
-		cmd:           "filters",
+		cmd:   "rresetold",
+		usage: "[age]",
+		descr: "Request a ratchet reset with contacts with no messages since [age] ago",
+		long: []string{"Request a ratchet reset with every remote user:",
+			"1. From which no message has been received since [age] ago.",
+			"2. No other KX reset procedure has been done since [age] ago.",
+			"This command is useful to restablish comms after the local client has been offline for a long time (greater than the time the data lives on the server), on which case it's likely that many ratchets have been broken",
+			"If [age] is not specified, it defaults to the ExpiryDays setting of the server.",
+			"[age] may be specified either in days (without any suffix) or as a Go time.Duration string (with a time suffix)"},
+
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			var interval time.Duration
+			if len(args) > 0 {
+				var err error
+				interval, err = time.ParseDuration(args[0])
+				if err != nil {
+					d, err := strconv.ParseInt(args[0], 10, 64)
+					if err != nil {
+						return fmt.Errorf("arg %q is not a valid age",
+							args[0])
+					}
+					interval = time.Duration(d) * 24 * time.Hour
+				}
+			} else {
+				as.connectedMtx.Lock()
+				expiry := as.expirationDays
+				as.connectedMtx.Unlock()
+				interval = time.Duration(expiry) * 24 * time.Hour
+			}
+
+			return as.resetAllOldRatchets(out, interval)
+		},
+	}, {
+		cmd:           "verify",
 		usableOffline: true,
-		usage:         "[sub]",
-		descr:         "Content filter commands",
-		sub:           filterCommands,
+		usage:         "<nick> [safety number]",
+		descr:         "Show or verify the safety number of a user",
+		long: []string{
+			"The safety number is derived from the keys of both the local client and the remote user, so both compute the same number. Compare it with the user in person or through a trusted channel to detect MITM attacks on the KX (for example, through a compromised invite).",
+			"",
+			"Without a safety number, shows the safety number and verification status of the user. With a safety number, compares it to the one computed locally and marks the user as verified if they match.",
+			"",
+			"If the keys of a verified user change, a warning is shown and the user must be verified again.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 1 {
+				return usageError{msg: "nick cannot be empty"}
+			}
+			ru, err := as.c.UserByNick(args[0])
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 1 {
+				ok, err := as.c.VerifyContact(ru.ID(), strings.Join(args[1:], " "))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					out.msg("Safety number does not match the one "+
+						"computed for %s", strescape.Nick(ru.Nick()))
+					return nil
+				}
+				out.msg("Marked %s as verified", strescape.Nick(ru.Nick()))
+				return nil
+			}
+
+			sn, err := as.c.SafetyNumber(ru.ID())
+			if err != nil {
+				return err
+			}
+			cv, err := as.c.ContactVerification(ru.ID())
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				pf("Safety number with %s:", strescape.Nick(ru.Nick()))
+				pf("  %s", sn.Digits)
+				pf("  %s", strings.Join(sn.Emojis, " "))
+				switch {
+				case cv.IsVerified():
+					pf("Verified at %s", cv.VerifiedAt.Format(ISO8601DateTime))
+				case cv != nil && cv.KeyChangedAt != nil:
+					pf("WARNING: keys changed at %s after the user was verified",
+						cv.KeyChangedAt.Format(ISO8601DateTime))
+				default:
+					pf("Not verified")
+				}
+			})
+			return nil
+		},
 		completer: func(args []string, arg string, as *appState) []string {
 			if len(args) == 0 {
-				return cmdCompleter(filterCommands, arg, false)
+				return nickCompleter(arg, as)
 			}
 			return nil
 		},
-		handler: subcmdNeededHandler,
 	}, {
-		cmd:     "rreset",
-		aliases: []string{"rr", "ratchetreset"},
-		usage:   "<nick>",
-		descr:   "Request a ratchet reset with the given nick",
-		handler: func(args []string, as *appState) error {
+		cmd:           "unverify",
+		usableOffline: true,
+		usage:         "<nick>",
+		descr:         "Mark a user as not verified",
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "nick cannot be empty"}
 			}
-			uid, err := as.c.UIDByNick(args[0])
+			ru, err := as.c.UserByNick(args[0])
 			if err != nil {
 				return err
 			}
-			cw := as.findOrNewChatWindow(uid, args[0])
-			go as.requestRatchetReset(cw)
+			if err := as.c.MarkContactVerified(ru.ID(), false); err != nil {
+				return err
+			}
+			out.msg("Marked %s as not verified", strescape.Nick(ru.Nick()))
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -3847,44 +11398,80 @@ var commands = []tuicmd{
 			return nil
 		},
 	}, {
-		cmd:   "rresetold",
-		usage: "[age]",
-		descr: "Request a ratchet reset with contacts with no messages since [age] ago",
-		long: []string{"Request a ratchet reset with every remote user:",
-			"1. From which no message has been received since [age] ago.",
-			"2. No other KX reset procedure has been done since [age] ago.",
-			"This command is useful to restablish comms after the local client has been offline for a long time (greater than the time the data lives on the server), on which case it's likely that many ratchets have been broken",
-			"If [age] is not specified, it defaults to the ExpiryDays setting of the server.",
-			"[age] may be specified either in days (without any suffix) or as a Go time.Duration string (with a time suffix)"},
-
-		handler: func(args []string, as *appState) error {
-			var interval time.Duration
+		cmd:           "rhealth",
+		aliases:       []string{"ratchethealth"},
+		usableOffline: true,
+		usage:         "[nick]",
+		descr:         "Show diagnostics about the ratchets with contacts",
+		long: []string{"Without a nick, lists contacts whose ratchets are probably broken. With a nick, shows the full diagnostics of the ratchet with that user.",
+			"Use /rreset to reset a ratchet that is probably broken."},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) > 0 {
-				var err error
-				interval, err = time.ParseDuration(args[0])
+				uid, err := as.c.UIDByNick(args[0])
 				if err != nil {
-					d, err := strconv.ParseInt(args[0], 10, 64)
-					if err != nil {
-						return fmt.Errorf("arg %q is not a valid age",
-							args[0])
-					}
-					interval = time.Duration(d) * 24 * time.Hour
+					return err
 				}
-			} else {
-				as.connectedMtx.Lock()
-				expiry := as.expirationDays
-				as.connectedMtx.Unlock()
-				interval = time.Duration(expiry) * 24 * time.Hour
+				h, err := as.c.RatchetHealth(uid)
+				if err != nil {
+					return err
+				}
+				out.msgs(func(pf printf) {
+					pf("")
+					pf("Ratchet health for user %s", strescape.Nick(h.Nick))
+					pf("      Unacked RMs: %d", h.UnackedRMs)
+					pf("Last Encrypt Time: %s", h.LastEncTime.Format(ISO8601DateTime))
+					pf("Last Decrypt Time: %s", h.LastDecTime.Format(ISO8601DateTime))
+					pf("Handshake Attempt: %s", h.LastHandshakeAttempt.Format(ISO8601DateTime))
+					pf("         Draining: %v", h.Draining)
+					pf("       Saved Keys: %d", h.NbSavedKeys)
+					pf("   Keys Remaining: %d", h.KeysRemaining)
+					pf("  Failed Decrypts: %d", h.FailedDecrypts)
+					pf(" RVs Unsubscribed: %v", h.RVsUnsubscribed)
+					pf("Reset In Progress: %v", h.ResetInProgress)
+					if h.ProbablyBroken {
+						pf("  Probably Broken: %s", h.BrokenReason)
+						pf("Reset the ratchet with /rreset %s", args[0])
+					}
+				})
+				return nil
 			}
 
-			return as.resetAllOldRatchets(interval)
+			reports, err := as.c.ListRatchetHealth()
+			if err != nil {
+				return err
+			}
+			out.msgs(func(pf printf) {
+				pf("")
+				var nbBroken int
+				for _, h := range reports {
+					if !h.ProbablyBroken {
+						continue
+					}
+					nbBroken += 1
+					pf("%s (%s): %s", strescape.Nick(h.Nick), h.UID,
+						h.BrokenReason)
+				}
+				if nbBroken == 0 {
+					pf("No probably broken ratchets among %d contacts",
+						len(reports))
+					return
+				}
+				pf("Reset ratchets with /rreset <nick>")
+			})
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return nickCompleter(arg, as)
+			}
+			return nil
 		},
 	}, {
 		cmd:     "mediateid",
 		aliases: []string{"mi"},
 		usage:   "<nick> <identity>",
 		descr:   "Request nick to mediate an invite for the provided identity",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "nick cannot be empty"}
 			}
@@ -3907,7 +11494,7 @@ var commands = []tuicmd{
 		cmd:   "suggestkx",
 		usage: "<invitee> <target>",
 		descr: "Suggest 'invitee' to KX with 'target'",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "invitee cannot be empty"}
 			}
@@ -3923,16 +11510,16 @@ var commands = []tuicmd{
 				return err
 			}
 
-			go func() {
+			out.goAsync(func() {
 				err := as.c.SuggestKX(invitee, target)
 				if err != nil {
-					as.diagMsg("Unable to suggest %s KX with %s: %v",
+					out.diagMsg("Unable to suggest %s KX with %s: %v",
 						args[0], args[1], err)
 				} else {
-					as.diagMsg("Suggested %s KX with %s",
+					out.diagMsg("Suggested %s KX with %s",
 						args[0], args[1])
 				}
-			}()
+			})
 
 			return nil
 		},
@@ -3942,12 +11529,102 @@ var commands = []tuicmd{
 			}
 			return nil
 		},
+	}, {
+		cmd:           "identity",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Migrate to a new identity or revoke the local identity",
+		sub:           identityCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(identityCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "autokx",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage the policy for automatic transitive KX",
+		long: []string{
+			"The auto KX policy determines how suggestions to KX, requests to mediate KX and mediated KX requests from contacts are handled, and whether KX is automatically requested with unknown GC members.",
+		},
+		sub: autoKXCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(autoKXCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "autoreset",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage the policy for automatic ratchet resets",
+		long: []string{
+			"The automatic ratchet reset policy determines when a ratchet reset is requested with contacts without having to use /rreset. The policy may be overridden for individual contacts.",
+		},
+		sub: autoResetCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(autoResetCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "stats",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Show statistics and reports",
+		sub:           statsCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(statsCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "swap",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Swap between BTC and DCR in the LN wallet",
+		long: []string{
+			"Atomic swaps with the provider configured in the [swap] section of the config file allow funding the LN wallet with BTC and cashing out DCR to BTC. Quotes are compared to the market rate and rejected if the premium is too high.",
+		},
+		sub: swapCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(swapCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
+	}, {
+		cmd:           "tipretry",
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage the policy for attempting and retrying tips",
+		long: []string{
+			"The tip retry policy determines how many attempts are made to tip users and the delays between them. The policy may be overridden for individual contacts. Settings that are not specified in the policy use the built-in defaults.",
+		},
+		sub: tipRetryCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(tipRetryCommands, arg, false)
+			}
+			return nil
+		},
+		handler: subcmdNeededHandler,
 	}, {
 		cmd:     "treset",
 		aliases: []string{"tr", "transreset"},
 		usage:   "<mediator> <target>",
 		descr:   "Request 'mediator' to mediate a ratchet reset with 'target'",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "mediator cannot be empty"}
 			}
@@ -3977,7 +11654,7 @@ var commands = []tuicmd{
 		cmd:           "reload",
 		usableOffline: true,
 		descr:         "Reload the configuration",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			cfg, err := loadConfig()
 			if err != nil {
 				return err
@@ -3989,8 +11666,10 @@ var commands = []tuicmd{
 			as.externalEditorForComments.Store(cfg.ExternalEditorForComments)
 			as.mimeMap.Store(&cfg.MimeMap)
 			as.styles.Store(theme)
+			as.keymap.Store(cfg.Keymap)
+			as.vim.setEnabled(cfg.VimMode)
 
-			as.cwHelpMsg("reloaded configuration")
+			out.msg("reloaded configuration")
 			return nil
 		},
 	}, {
@@ -3998,7 +11677,7 @@ var commands = []tuicmd{
 		usableOffline: true,
 		descr:         "Modify the local nick of a user",
 		usage:         "<old nick> <new nick>",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "old nick cannot be empty"}
 			}
@@ -4017,7 +11696,7 @@ var commands = []tuicmd{
 				return err
 			}
 
-			as.cwHelpMsg("Renamed user %s to %q", uid, newNick)
+			out.msg("Renamed user %s to %q", uid, newNick)
 			return nil
 		},
 		completer: func(args []string, arg string, as *appState) []string {
@@ -4031,7 +11710,7 @@ var commands = []tuicmd{
 		usableOffline: true,
 		descr:         "Clear payment statistics",
 		usage:         "<user | *>",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "nick cannot be empty"}
 			}
@@ -4049,21 +11728,21 @@ var commands = []tuicmd{
 			if err != nil {
 				return err
 			}
-			as.cwHelpMsg("Cleared payment stats%s", forUser)
+			out.msg("Cleared payment stats%s", forUser)
 			return nil
 		},
 	}, {
 		cmd:           "info",
 		usableOffline: true,
 		descr:         "Show basic app version and information",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			var info *lnrpc.GetInfoResponse
 			if as.lnRPC != nil {
 				info, _ = as.lnRPC.GetInfo(as.ctx,
 					&lnrpc.GetInfoRequest{})
 			}
 
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("")
 				pf("Client Information")
 				pf("%s version %s", appName, version.String())
@@ -4091,7 +11770,7 @@ var commands = []tuicmd{
 		descr:         "Skip wallet check after connected to server",
 		long: []string{"After connecting to the server, multiple checks are done to ensure the local client can make LN payments.",
 			"Use this command to make the next time these checks are performed be skipped and allow the client to be used with the server regardless."},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			go as.skipNextWalletCheck()
 			return nil
 		},
@@ -4112,7 +11791,7 @@ var commands = []tuicmd{
 		usableOffline: true,
 		descr:         "Test a color spec",
 		usage:         "<attr>:<fg>:<bg>",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "color <attr>:<fg>:<bg> must be specified"}
 			}
@@ -4120,7 +11799,187 @@ var commands = []tuicmd{
 			if err != nil {
 				return err
 			}
-			as.diagMsg(style.Render("On sangen hauskaa, että polkupyörä on maanteiden jokapäiväinen ilmiö."))
+			out.diagMsg(style.Render("On sangen hauskaa, että polkupyörä on maanteiden jokapäiväinen ilmiö."))
+			return nil
+		},
+	}, {
+		cmd:           "theme",
+		usableOffline: true,
+		descr:         "Show or switch the theme",
+		usage:         "[<name or path to theme file>]",
+		long: []string{
+			"Without arguments, shows the current theme and lists the available ones: the built-in themes and the theme files in the themes dir of the root dir. The theme set in the config file is used again after a restart or '/reload'.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) == 0 {
+				out.msgs(func(pf printf) {
+					pf("Current theme: %s", as.styles.Load().name)
+					pf("Available themes: %s", strings.Join(listThemes(as.themesDir), ", "))
+				})
+				return nil
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			theme, err := newThemeNamed(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			as.styles.Store(theme)
+			out.msg("Switched to theme %s", theme.name)
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter(listThemes(as.themesDir), arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "bind",
+		usableOffline: true,
+		usage:         "[<context> | <action> [<key>[,<key>...] | default | none]]",
+		descr:         "List or change the key bindings",
+		long: []string{
+			"Without arguments, lists every action and its keys. With a context (global, chat, feed, post or newpost), lists the actions of the context. With an action and a comma separated list of keys, binds the action to the keys. 'default' restores the default keys of the action and 'none' unbinds it.",
+			"A key may only be bound to one action of each context and the keys of global actions may not be bound to any other action.",
+			"Bindings changed with '/bind' are used until the client is restarted or the config is reloaded. Add them to the [keys] section of the config file to keep them.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			km := as.keymap.Load()
+			listContext := func(pf printf, ctx keyContext) {
+				for _, defn := range keyActionDefns {
+					if defn.action.context() == ctx {
+						pf("  %s: %s - %s", defn.action,
+							km.help(defn.action), defn.descr)
+					}
+				}
+			}
+
+			if len(args) == 0 {
+				out.msgs(func(pf printf) {
+					pf("Key bindings")
+					for _, ctx := range keyContexts {
+						listContext(pf, ctx)
+					}
+				})
+				return nil
+			}
+
+			action := keyAction(args[0])
+			if len(args) == 1 {
+				if slices.Contains(keyContexts, keyContext(args[0])) {
+					out.msgs(func(pf printf) {
+						pf("Key bindings of %s", args[0])
+						listContext(pf, keyContext(args[0]))
+					})
+					return nil
+				}
+				if findKeyActionDefn(action) == nil {
+					return fmt.Errorf("unknown context or action %q", args[0])
+				}
+				out.msg("%s: %s", action, km.help(action))
+				return nil
+			}
+
+			var keys []string
+			if keysArg := strings.Join(args[1:], " "); keysArg != "default" {
+				var err error
+				keys, err = parseKeys(keysArg)
+				if err != nil {
+					return usageError{msg: err.Error()}
+				}
+			}
+			newKm, err := km.rebind(action, keys)
+			if err != nil {
+				return err
+			}
+			as.keymap.Store(newKm)
+			out.msg("Bound %s to %s", action, newKm.help(action))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			switch len(args) {
+			case 0:
+				words := make([]string, 0, len(keyContexts)+len(keyActionDefns))
+				for _, ctx := range keyContexts {
+					words = append(words, string(ctx))
+				}
+				for _, defn := range keyActionDefns {
+					words = append(words, string(defn.action))
+				}
+				return wordCompleter(words, arg)
+			case 1:
+				return wordCompleter([]string{"default", "none"}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "vim",
+		usableOffline: true,
+		usage:         "[on | off | registers]",
+		descr:         "Enable, disable or show the state of the vim mode",
+		long: []string{
+			"The vim mode adds a normal mode to the line editor of the main window. Esc switches from the insert mode to the normal mode and i, a, I, A, o or O switch back to the insert mode.",
+			"In the normal mode, h, l, w, b, 0 and $ move the cursor, x, X, D, C, dd and cc delete text, yy yanks the line and p and P put text. \"<register> before a cmd selects the register (a-z, A-Z to append or + for the clipboard). j, k, ctrl+e and ctrl+y scroll the window by lines, ctrl+d, ctrl+u, ctrl+f and ctrl+b by pages and gg and G go to the top and bottom. / and ? search forward and backward, n and N repeat the search. gt and gT switch to the next and previous window, <n>gt to window <n>. : starts typing a command.",
+			"Set 'vimmode = true' in the [keys] section of the config file to enable the vim mode on startup.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) == 0 {
+				if as.vim.isEnabled() {
+					out.msg("Vim mode is on")
+				} else {
+					out.msg("Vim mode is off")
+				}
+				return nil
+			}
+
+			switch args[0] {
+			case "on":
+				as.vim.setEnabled(true)
+				out.msg("Vim mode is on")
+			case "off":
+				as.vim.setEnabled(false)
+				out.msg("Vim mode is off")
+			case "registers", "reg":
+				names, regs := as.vim.listRegisters()
+				out.msgs(func(pf printf) {
+					pf("Registers")
+					for i, name := range names {
+						pf("  \"%c %q", name, regs[i].text)
+					}
+				})
+			default:
+				return usageError{msg: fmt.Sprintf("invalid argument %q", args[0])}
+			}
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return wordCompleter([]string{"on", "off", "registers"}, arg)
+			}
+			return nil
+		},
+	}, {
+		cmd:           "notify",
+		aliases:       []string{"notifications"},
+		usableOffline: true,
+		usage:         "[sub]",
+		descr:         "Manage the notifications of received msgs",
+		long: []string{
+			"Without a subcommand, lists the notification rules. The method used to show notifications and the global rules for PMs and GCs are set in the [notifications] section of the config file.",
+		},
+		sub: notifyCommands,
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return cmdCompleter(notifyCommands, arg, false)
+			}
+			return nil
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			as.listNtfnRules(out)
 			return nil
 		},
 	}, {
@@ -4135,7 +11994,7 @@ var commands = []tuicmd{
 			}
 			return nil
 		},
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{"user cannot be empty"}
 			}
@@ -4150,7 +12009,7 @@ var commands = []tuicmd{
 				return err
 			}
 
-			as.cwHelpMsg("Starting 3-way handshake with %s",
+			out.msg("Starting 3-way handshake with %s",
 				strescape.Nick(ru.Nick()))
 			return nil
 		},
@@ -4160,7 +12019,7 @@ var commands = []tuicmd{
 		usableOffline: true,
 		usage:         "<USD/DCR> <USD/BTC>",
 		descr:         "Manually set the exchange rate of USD/DCR and USD/BTC",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			if len(args) < 1 {
 				return usageError{msg: "USD/DCR rate cannot be empty"}
 			}
@@ -4175,7 +12034,7 @@ var commands = []tuicmd{
 			if err != nil {
 				return fmt.Errorf("invalid USD/BTC rate: %v", err)
 			}
-			as.cwHelpMsg("Setting manual exchange rate: DCR:%0.2f BTC:%0.2f",
+			out.msg("Setting manual exchange rate: DCR:%0.2f BTC:%0.2f",
 				dcrPrice, btcPrice)
 			as.rates.Set(dcrPrice, btcPrice)
 			return nil
@@ -4192,11 +12051,69 @@ var commands = []tuicmd{
 			return nil
 		},
 		handler: subcmdNeededHandler,
+	}, {
+		cmd:   "mystatus",
+		usage: "[status]",
+		descr: "Set the local client's status line",
+		long: []string{
+			"The status line is a short text (for example, 'away until Monday') sent to all remote users along with profile updates. Without arguments, the current status is cleared.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			status := strings.Join(args, " ")
+			err := as.c.UpdateLocalProfile(client.LocalProfileUpdate{Status: &status})
+			if err != nil {
+				return err
+			}
+			if status == "" {
+				out.msg("Status cleared")
+			} else {
+				out.msg("Status updated to %q", status)
+			}
+			return nil
+		},
+	}, {
+		cmd:   "voice",
+		usage: "<nick or gc> <file.ogg>",
+		descr: "Send a voice message",
+		long: []string{
+			"The file must contain Opus-encoded audio in an Ogg container (for example, as recorded by 'opusenc' or 'ffmpeg -c:a libopus'). Voice messages are limited in size and duration.",
+		},
+		handler: func(args []string, as *appState, out *cmdOutput) error {
+			if len(args) < 2 {
+				return usageError{msg: "nick and filename cannot be empty"}
+			}
+			target, err := as.chatTargetID(args[0])
+			if err != nil {
+				return err
+			}
+			filename, err := homedir.Expand(args[1])
+			if err != nil {
+				return err
+			}
+			audio, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			if _, err := as.c.SendVoiceMsg(target, audio, 0); err != nil {
+				return err
+			}
+			out.msg("Sent voice message from %s", filepath.Base(filename))
+			return nil
+		},
+		completer: func(args []string, arg string, as *appState) []string {
+			if len(args) == 0 {
+				return append(nickCompleter(arg, as), gcCompleter(arg, as)...)
+			}
+			if len(args) == 1 {
+				return fileCompleter(arg)
+			}
+			return nil
+		},
 	}, {
 		cmd:           "quit",
 		usableOffline: true,
 		descr:         "Quit the app",
-		handler: func(args []string, as *appState) error {
+		handler: func(args []string, as *appState, out *cmdOutput) error {
 			as.sendMsg(requestShutdown{})
 			return nil
 		},
@@ -4278,9 +12195,9 @@ var helpCmd = tuicmd{
 	usableOffline: true,
 	usage:         "<command> <subcommand>",
 	descr:         "Get command line help for the given command/subcommand",
-	handler: func(args []string, as *appState) error {
+	handler: func(args []string, as *appState, out *cmdOutput) error {
 		if len(args) == 0 {
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("Type %shelp [cmd] for help. Available commands:",
 					string(leader))
 				pf("")
@@ -4299,7 +12216,7 @@ var helpCmd = tuicmd{
 		}
 
 		if len(args) > 1 && subCmd == nil {
-			as.cwHelpMsgs(func(pf printf) {
+			out.msgs(func(pf printf) {
 				pf("Subcommand %q not found for command %q", args[1],
 					args[0])
 				pf("Type %shelp %s for the list of accepted subcommands",
@@ -4313,7 +12230,7 @@ var helpCmd = tuicmd{
 			cmd = subCmd
 		}
 
-		as.cwHelpMsgs(func(pf printf) {
+		out.msgs(func(pf printf) {
 			pf("")
 			pf("Help for command %q", fullCmd)
 			if len(cmd.aliases) > 0 {
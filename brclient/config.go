@@ -10,14 +10,18 @@ import (
 	"net"
 	"net/http"
 	_ "net/http/pprof"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/companyzero/bisonrelay/brclient/internal/version"
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/autoshare"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/go-socks/socks"
@@ -38,6 +42,10 @@ var (
 		"86abd31f2141b274196d481edd061a00ab7a56b61a31656775c8a590d612b966", // Oprah
 		"ad716557157c1f191d8b5f8c6757ea41af49de27dc619fc87f337ca85be325ee", // GC bot
 	}, ",")
+
+	// defaultAutoShareExclude is the list of patterns of files that are not
+	// automatically shared: hidden, backup and partially downloaded files.
+	defaultAutoShareExclude = ".*,*~,*.tmp,*.part"
 )
 
 var (
@@ -95,23 +103,47 @@ type config struct {
 	WalletType        string
 	CompressLevel     int
 	CmdHistoryPath    string
+	Theme             string
+	ThemesDir         string
 	NickColor         string
 	GCOtherColor      string
 	PMOtherColor      string
 	BlinkCursor       bool
 	BellCmd           string
+	NtfnMethod        ntfnMethod
+	NtfnPMRule        ntfnRule
+	NtfnGCRule        ntfnRule
+	NtfnShowContent   bool
+	Keymap            *keymap
+	VimMode           bool
 	Network           string
 	CPUProfile        string
 	CPUProfileHz      int
 	MemProfile        string
+	RestoreSeedBackup string
+	PairDevice        string
 	LogPings          bool
 	NoLoadChatHistory bool
 	SendRecvReceipts  bool
 	AutoSubPosts      bool
+	LinkPreviews      bool
+
+	PostMediaThumbnails bool
+	PostMediaCost       uint64
+
+	PostAutoUnlockMaxCost uint64
+	IgnorePostModeration  bool
+
+	InvitePasteURL    string
+	InviteNostrRelays []string
 
 	AutoHandshakeInterval       time.Duration
+	AutoHandshakeTimeout        time.Duration
 	AutoRemoveIdleUsersInterval time.Duration
 	AutoRemoveIdleUsersIgnore   []string
+	AutoUnsubIdleUsersRVs       time.Duration
+	ContentIndexRefresh         time.Duration
+	BandwidthLimits             client.BandwidthLimits
 
 	SyncFreeList bool
 
@@ -124,6 +156,19 @@ type config struct {
 	MinRecvBal   dcrutil.Amount
 	MinSendBal   dcrutil.Amount
 
+	AcceptKeysend    bool
+	KeysendMaxTipAmt dcrutil.Amount
+	OnchainTipMinAmt dcrutil.Amount
+	FeePolicy        client.PaymentFeePolicy
+	MaxPaymentParts  uint32
+	TipFiatSlippage  float64
+	PaymentsMode     client.PaymentsMode
+
+	WatchtowerClient bool
+	Watchtowers      []string
+
+	PaymentWebhooks []client.PaymentWebhook
+
 	WinPin             []string
 	MimeMap            map[string]string
 	InviteFundsAccount string
@@ -134,6 +179,8 @@ type config struct {
 	RPCClientCAPath    string
 	RPCIssueClientCert bool
 
+	RPCEnableExecCommands bool
+
 	ExternalEditorForComments bool
 
 	ResourcesUpstream     string
@@ -141,6 +188,55 @@ type config struct {
 	SimpleStoreAccount    string
 	SimpleStoreShipCharge float64
 
+	AutoShareDirs        []autoshare.Dir
+	AutoShareDefaultCost uint64
+	AutoShareCostPerMB   uint64
+	AutoShareExclude     []string
+
+	PostFeedsListen     string
+	PostFeedsDir        string
+	PostFeedsMaxEntries int
+
+	PostImportInterval     time.Duration
+	PostImportMaxItems     int
+	PostImportMaxMediaSize int
+	PostImportFetchMedia   bool
+
+	AutopilotEnable        bool
+	AutopilotCandidates    []string
+	AutopilotMinOutbound   dcrutil.Amount
+	AutopilotChannelSize   dcrutil.Amount
+	AutopilotMaxChannels   int
+	AutopilotWalletReserve dcrutil.Amount
+	AutopilotMinUptime     float64
+	AutopilotMinChanAge    time.Duration
+	AutopilotFeeBudget     dcrutil.Amount
+	AutopilotBudgetPeriod  time.Duration
+	AutopilotInterval      time.Duration
+
+	InboundEnable       bool
+	InboundServer       string
+	InboundCertFile     string
+	InboundKey          string
+	InboundMinInbound   dcrutil.Amount
+	InboundChannelSize  dcrutil.Amount
+	InboundFeeBudget    dcrutil.Amount
+	InboundBudgetPeriod time.Duration
+	InboundInterval     time.Duration
+
+	SCBBackupEnable   bool
+	SCBBackupDir      string
+	SCBBackupKeep     int
+	SCBBackupInterval time.Duration
+	SCBBackupSFTP     []string
+	SCBBackupSFTPKey  string
+	SCBBackupContacts []string
+
+	SwapProvider   string
+	SwapEsplora    string
+	SwapMaxPremium float64
+	SwapMinConfs   int
+
 	dialFunc func(context.Context, string, string) (net.Conn, error)
 }
 
@@ -231,12 +327,18 @@ func loadConfig() (*config, error) {
 	flagCPUProfile := fs.String("cpuprofile", "", "filename to dump CPU profiling")
 	flagCPUProfileHz := fs.Int("cpuprofilehz", 0, "Frequency to sample cpu profiling")
 	flagMemProfile := fs.String("memprofile", "", "filename to dump mem profiling")
+	flagRestoreSeedBackup := fs.String("restoreseedbackup", "", "Seed backup file to restore the identity from when creating a new client")
+	flagPairDevice := fs.String("pairdevice", "", "Device pairing file to create a new client as a secondary device of an existing client")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil, errCmdDone
 		}
 		return nil, err
 	}
+	if *flagRestoreSeedBackup != "" && *flagPairDevice != "" {
+		return nil, errors.New("cannot specify both -restoreseedbackup " +
+			"and -pairdevice")
+	}
 
 	if *flagProfile != "" {
 		go http.ListenAndServe(*flagProfile, nil)
@@ -271,6 +373,9 @@ func loadConfig() (*config, error) {
 	flagRootDir := fs.String("root", defaultAppDir, "Root of all app data")
 	flagWinPin := fs.String("winpin", "", "Comma delimited list of DM and GC windows to launch on start")
 	flagSendRecvReceipts := fs.Bool("sendrecvreceipts", true, "Send receive receipts")
+	flagLinkPreviews := fs.Bool("linkpreviews", false, "Attach previews of links to sent messages")
+	flagInvitePasteURL := fs.String("invitepasteurl", "", "Paste endpoint to publish invites")
+	flagInviteNostrRelays := fs.String("invitenostrrelays", "", "Comma delimited list of nostr relays to publish invites")
 	flagCompressLevel := fs.Int("compresslevel", defaultCompressLevel, "Compression level")
 	flagProxyAddr := fs.String("proxyaddr", "", "")
 	flagProxyUser := fs.String("proxyuser", "", "")
@@ -287,9 +392,20 @@ func loadConfig() (*config, error) {
 	flagNoLoadChatHistory := fs.Bool("noloadchathistory", false, "Whether to read chat logs to build chat history")
 
 	flagAutoHandshake := fs.String("autohandshakeinterval", "21d", "")
+	flagAutoHandshakeTimeout := fs.String("autohandshaketimeout", "0", "")
 	flagAutoRemove := fs.String("autoremoveidleusersinterval", "60d", "")
+	flagAutoUnsubIdleRVs := fs.String("autounsubidleusersrvsinterval", "0", "")
+	flagContentIndexRefresh := fs.String("contentindexrefreshinterval", "0", "")
+	flagBWUpload := fs.Uint64("bwupload", 0, "")
+	flagBWDownload := fs.Uint64("bwdownload", 0, "")
+	flagBWPeerUpload := fs.Uint64("bwpeerupload", 0, "")
+	flagBWPeerDownload := fs.Uint64("bwpeerdownload", 0, "")
 	flagAutoRemoveIgnoreList := fs.String("autoremoveignorelist", defaultAutoRemoveIgnoreList, "")
 	flagAutoSubPosts := fs.Bool("autosubposts", true, "")
+	flagPostMediaThumbnails := fs.Bool("postmediathumbnails", false, "")
+	flagPostMediaCost := fs.Float64("postmediacost", 0, "")
+	flagPostAutoUnlockMaxCost := fs.Float64("postautounlockmaxcost", 0, "")
+	flagIgnorePostModeration := fs.Bool("ignorepostmoderation", false, "")
 
 	// log
 	flagMsgRoot := fs.String("log.msglog", defaultMsgRoot, "Root for message log files")
@@ -300,11 +416,25 @@ func loadConfig() (*config, error) {
 	flagLogPings := fs.Bool("log.pings", false, "Whether to log pings")
 
 	// theme
-	flagNickColor := fs.String("theme.nickcolor", "bold:white:na", "color of the nick")
-	flagGCOtherColor := fs.String("theme.gcothercolor", "bold:green:na", "color of other nicks in gc")
-	flagPMOtherColor := fs.String("theme.pmothercolor", "bold:cyan:na", "color of other nicks in pms")
+	flagTheme := fs.String("theme.name", defaultThemeName, "Name of the theme or path to a theme file")
+	flagNickColor := fs.String("theme.nickcolor", "", "color of the nick")
+	flagGCOtherColor := fs.String("theme.gcothercolor", "", "color of other nicks in gc")
+	flagPMOtherColor := fs.String("theme.pmothercolor", "", "color of other nicks in pms")
 	flagBlinkCursor := fs.Bool("theme.blinkcursor", true, "Blink cursor")
 
+	// notifications
+	flagNtfnMethod := fs.String("notifications.method", "none", "Method used to show notifications of received msgs")
+	flagNtfnPMs := fs.String("notifications.pms", "all", "Which PMs generate notifications (all, mentions or off)")
+	flagNtfnGCs := fs.String("notifications.gcs", "mentions", "Which GC msgs generate notifications (all, mentions or off)")
+	flagNtfnShowContent := fs.Bool("notifications.showcontent", true, "Whether to show the content of msgs in notifications")
+
+	// keys
+	flagKeys := make(map[keyAction]*string, len(keyActionDefns))
+	for _, defn := range keyActionDefns {
+		flagKeys[defn.action] = fs.String("keys."+string(defn.action), "", defn.descr)
+	}
+	flagVimMode := fs.Bool("keys.vimmode", false, "Enable vim-style modal editing in the main window")
+
 	// payment
 	flagWalletType := fs.String("payment.wallettype", defaultWalletType, "Wallet type to use")
 	flagNetwork := fs.String("payment.network", "mainnet", "Network to connect")
@@ -318,6 +448,17 @@ func loadConfig() (*config, error) {
 	flagMinSendBal := fs.Float64("payment.minimumsendbalance", 0.01, "Minimum send balance before warn")
 	flagLNRPCListen := fs.String("payment.lnrpclisten", "", "list of addrs for the embedded ln to listen on")
 	flagInviteFundsAccount := fs.String("payment.invitefundsaccount", "", "")
+	flagAcceptKeysend := fs.Bool("payment.acceptkeysend", false, "Accept keysend payments in the internal wallet")
+	flagKeysendMaxTip := fs.Float64("payment.keysendmaxtip", 0, "Max tip amount to attempt as a keysend payment")
+	flagOnchainTipMin := fs.Float64("payment.onchaintipmin", 0, "Min amount of failed tips to send on-chain")
+	flagFeePolicy := fs.String("payment.feepolicy", "", "Max routing fees of payments, by payment size")
+	flagTipFiatSlippage := fs.Float64("payment.tipfiatmaxslippage", client.DefaultTipFiatMaxSlippage, "Max change (in percent) of the exchange rate before paying fiat-denominated tips")
+	flagMaxPaymentParts := fs.Uint("payment.maxpaymentparts", client.DefaultMaxPaymentParts, "Max number of parts into which large tips are split")
+	flagPaymentsMode := fs.String("payment.mode", "normal", "Restrict payments made by the client (normal, receiveonly or disabled)")
+	flagWatchtowerClient := fs.Bool("payment.watchtowerclient", false, "Back up channel states of the internal wallet to watchtowers")
+	flagWatchtowers := fs.String("payment.watchtowers", "", "Comma separated list of watchtowers (pubkey@host:port) to add on startup")
+	flagWebhooks := fs.String("payment.webhooks", "", "Comma separated list of URLs notified of received payments")
+	flagWebhookSecret := fs.String("payment.webhooksecret", "", "Secret used to sign the requests to payment webhooks")
 
 	// clientrpc
 	flagJSONRPCListen := fs.String("clientrpc.jsonrpclisten", "", "Comma delimited list of JSON-RPC server binding addresses")
@@ -325,6 +466,7 @@ func loadConfig() (*config, error) {
 	flagRPCKeyPath := fs.String("clientrpc.rpckeypath", defaultRPCKeyPath, "")
 	flagRPCClientCAPath := fs.String("clientrpc.rpcclientcapath", defaultRPCClientCA, "")
 	flagRPCIssueClientCert := fs.Bool("clientrpc.rpcissueclientcert", true, "")
+	flagRPCEnableExecCommands := fs.Bool("clientrpc.enableexeccommands", false, "")
 
 	// resources
 	flagResourcesUpstream := fs.String("resources.upstream", "", "Upstream processor of resource requests")
@@ -334,6 +476,63 @@ func loadConfig() (*config, error) {
 	flagSimpleStoreAccount := fs.String("simplestore.account", "", "Account to use for on-chain adresses")
 	flagSimpleStoreShipCharge := fs.Float64("simplestore.shipcharge", 0, "How much to charge for s&h")
 
+	// autoshare
+	var autoShareDirs cfgStringArray
+	fs.Var(&autoShareDirs, "autoshare.dir", "List of dirs to automatically share")
+	flagAutoShareDefaultCost := fs.Float64("autoshare.defaultcost", 0, "Default cost of auto shared files")
+	flagAutoShareCostPerMB := fs.Float64("autoshare.costpermb", 0, "Additional cost per MB of auto shared files")
+	flagAutoShareExclude := fs.String("autoshare.exclude", defaultAutoShareExclude, "Comma delimited list of patterns of files to not share")
+
+	// postfeeds
+	flagPostFeedsListen := fs.String("postfeeds.listen", "", "Address to serve post feeds")
+	flagPostFeedsDir := fs.String("postfeeds.dir", "", "Dir to write post feeds")
+	flagPostFeedsMaxEntries := fs.Int("postfeeds.maxentries", 50, "Max number of posts in each feed")
+
+	// postimport
+	flagPostImportInterval := fs.String("postimport.interval", "10s", "Interval between imported posts")
+	flagPostImportMaxItems := fs.Int("postimport.maxitems", 0, "Max number of posts created in each import")
+	flagPostImportMaxMediaSize := fs.Int("postimport.maxmediasize", 512, "Max size (in KB) of images embedded in imported posts")
+	flagPostImportFetchMedia := fs.Bool("postimport.fetchmedia", false, "Fetch and embed remote images of imported posts")
+
+	// autopilot
+	flagAutopilotEnable := fs.Bool("autopilot.enable", false, "Enable the channel autopilot of the LN wallet")
+	flagAutopilotCandidates := fs.String("autopilot.candidates", "", "Comma separated list of nodes (pubkey@host:port) to open channels to")
+	flagAutopilotMinOutbound := fs.Float64("autopilot.minoutbound", 0.5, "Outbound capacity (in DCR) below which channels are opened")
+	flagAutopilotChannelSize := fs.Float64("autopilot.channelsize", 1.0, "Size (in DCR) of opened channels")
+	flagAutopilotMaxChannels := fs.Int("autopilot.maxchannels", 5, "Max number of channels")
+	flagAutopilotWalletReserve := fs.Float64("autopilot.walletreserve", 0.1, "On-chain balance (in DCR) not committed to channels")
+	flagAutopilotMinUptime := fs.Float64("autopilot.minuptime", 0, "Min uptime ratio of channels before they are closed")
+	flagAutopilotMinChanAge := fs.String("autopilot.minchannelage", "168h", "Min age of channels before they are closed due to low uptime")
+	flagAutopilotFeeBudget := fs.Float64("autopilot.feebudget", 0.001, "Max on-chain fees (in DCR) spent per budget period")
+	flagAutopilotBudgetPeriod := fs.String("autopilot.budgetperiod", "24h", "Period of the fee budget")
+	flagAutopilotInterval := fs.String("autopilot.interval", "10m", "Interval between evaluations of the channels")
+
+	// inbound
+	flagInboundEnable := fs.Bool("inbound.enable", false, "Enable automatic acquisition of inbound liquidity")
+	flagInboundServer := fs.String("inbound.server", "", "URL of the liquidity provider")
+	flagInboundCertFile := fs.String("inbound.certfile", "", "Path to the TLS certificate of the liquidity provider")
+	flagInboundKey := fs.String("inbound.key", "", "Key sent to the liquidity provider")
+	flagInboundMinInbound := fs.Float64("inbound.mininbound", 0.5, "Inbound capacity (in DCR) below which inbound channels are requested")
+	flagInboundChannelSize := fs.Float64("inbound.channelsize", 1.0, "Size (in DCR) of requested inbound channels")
+	flagInboundFeeBudget := fs.Float64("inbound.feebudget", 0.01, "Max fees (in DCR) paid to the liquidity provider per budget period")
+	flagInboundBudgetPeriod := fs.String("inbound.budgetperiod", "168h", "Period of the fee budget")
+	flagInboundInterval := fs.String("inbound.interval", "10m", "Interval between checks of the inbound capacity")
+
+	// scbbackup
+	flagSCBBackupEnable := fs.Bool("scbbackup.enable", false, "Enable scheduled backups of the static channel backup (SCB) file")
+	flagSCBBackupDir := fs.String("scbbackup.dir", "", "Local dir where SCB backups are stored")
+	flagSCBBackupKeep := fs.Int("scbbackup.keep", 10, "Number of SCB backups kept in the local dir")
+	flagSCBBackupInterval := fs.String("scbbackup.interval", "24h", "Interval between checks for changes in the channels to back up")
+	flagSCBBackupSFTP := fs.String("scbbackup.sftp", "", "Comma separated list of remote dirs ([user@]host:[dir]) where SCB backups are uploaded with sftp")
+	flagSCBBackupSFTPKey := fs.String("scbbackup.sftpkey", "", "Path to the ssh key used by sftp")
+	flagSCBBackupContacts := fs.String("scbbackup.contacts", "", "Comma separated list of contacts to which SCB backups are sent")
+
+	// swap
+	flagSwapProvider := fs.String("swap.provider", "", "URL of the DCR/BTC atomic swap provider")
+	flagSwapEsplora := fs.String("swap.esplora", "https://mempool.space/api", "URL of the Esplora API used to verify BTC transactions")
+	flagSwapMaxPremium := fs.Float64("swap.maxpremium", 5, "Max percentage by which the rate of a swap may be worse than the market rate")
+	flagSwapMinConfs := fs.Int("swap.minconfs", 2, "Number of confirmations of BTC received in swaps")
+
 	// Load config from file.
 	parser := flagfile.Parser{
 		ParseSections: true,
@@ -353,10 +552,22 @@ func loadConfig() (*config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid value for flag 'autohandshakeinterval': %v", err)
 	}
+	autoHandshakeTimeout, err := strduration.ParseDuration(*flagAutoHandshakeTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'autohandshaketimeout': %v", err)
+	}
 	autoRemoveInterval, err := strduration.ParseDuration(*flagAutoRemove)
 	if err != nil {
 		return nil, fmt.Errorf("invalid value for flag 'autoremoveidleusersinterval': %v", err)
 	}
+	autoUnsubIdleRVs, err := strduration.ParseDuration(*flagAutoUnsubIdleRVs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'autounsubidleusersrvsinterval': %v", err)
+	}
+	contentIndexRefresh, err := strduration.ParseDuration(*flagContentIndexRefresh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'contentindexrefreshinterval': %v", err)
+	}
 
 	// Clean paths.
 	*flagRootDir = expandPath(homeDir, *flagRootDir)
@@ -413,6 +624,87 @@ func loadConfig() (*config, error) {
 	if err != nil || minSendBal < 0 {
 		return nil, fmt.Errorf("invalid minimum send balance")
 	}
+	keysendMaxTip, err := dcrutil.NewAmount(*flagKeysendMaxTip)
+	if err != nil || keysendMaxTip < 0 {
+		return nil, fmt.Errorf("invalid keysend max tip amount")
+	}
+	onchainTipMin, err := dcrutil.NewAmount(*flagOnchainTipMin)
+	if err != nil || onchainTipMin < 0 {
+		return nil, fmt.Errorf("invalid on-chain tip min amount")
+	}
+	feePolicy, err := client.ParsePaymentFeePolicy(*flagFeePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fee policy: %v", err)
+	}
+	paymentsMode, err := client.ParsePaymentsMode(*flagPaymentsMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'payment.mode': %v", err)
+	}
+	if paymentsMode != client.PaymentsModeNormal {
+		// The autopilot and the inbound liquidity manager open
+		// channels and pay fees without going through the client.
+		if *flagAutopilotEnable {
+			return nil, fmt.Errorf("flag 'autopilot.enable' cannot be "+
+				"set when 'payment.mode' is %s", paymentsMode)
+		}
+		if *flagInboundEnable {
+			return nil, fmt.Errorf("flag 'inbound.enable' cannot be "+
+				"set when 'payment.mode' is %s", paymentsMode)
+		}
+	}
+	ntfnMethod, err := parseNtfnMethod(*flagNtfnMethod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'notifications.method': %v", err)
+	}
+	ntfnPMRule, err := parseNtfnRule(*flagNtfnPMs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'notifications.pms': %v", err)
+	}
+	ntfnGCRule, err := parseNtfnRule(*flagNtfnGCs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'notifications.gcs': %v", err)
+	}
+	keyBindings := make(map[keyAction][]string)
+	for action, flagKey := range flagKeys {
+		if *flagKey == "" {
+			continue
+		}
+		keys, err := parseKeys(*flagKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for flag 'keys.%s': %v", action, err)
+		}
+		keyBindings[action] = keys
+	}
+	km, err := newKeymap(keyBindings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key bindings: %v", err)
+	}
+	var watchtowers []string
+	for _, tower := range strings.Split(*flagWatchtowers, ",") {
+		if tower = strings.TrimSpace(tower); tower != "" {
+			watchtowers = append(watchtowers, tower)
+		}
+	}
+	var paymentWebhooks []client.PaymentWebhook
+	for _, u := range strings.Split(*flagWebhooks, ",") {
+		if u = strings.TrimSpace(u); u == "" {
+			continue
+		}
+		if _, err := url.ParseRequestURI(u); err != nil {
+			return nil, fmt.Errorf("invalid payment webhook %q: %v", u, err)
+		}
+		paymentWebhooks = append(paymentWebhooks, client.PaymentWebhook{
+			URL:    u,
+			Secret: *flagWebhookSecret,
+		})
+	}
+	var inviteNostrRelays []string
+	for _, relay := range strings.Split(*flagInviteNostrRelays, ",") {
+		if relay = strings.TrimSpace(relay); relay != "" {
+			inviteNostrRelays = append(inviteNostrRelays, relay)
+		}
+	}
+
 	var winpin []string
 	if *flagWinPin != "" {
 		winpin = strings.Split(*flagWinPin, ",")
@@ -450,6 +742,158 @@ func loadConfig() (*config, error) {
 		}
 	}
 
+	var autoShareCfgDirs []autoshare.Dir
+	for _, v := range autoShareDirs {
+		var dir autoshare.Dir
+		path, costStr, hasCost := strings.Cut(v, ",")
+		dir.Path = expandPath(homeDir, strings.TrimSpace(path))
+		if hasCost {
+			cost, err := strconv.ParseFloat(strings.TrimSpace(costStr), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cost in autoshare dir %q: %v", v, err)
+			}
+			atoms, err := dcrutil.NewAmount(cost)
+			if err != nil || atoms < 0 {
+				return nil, fmt.Errorf("invalid cost in autoshare dir %q", v)
+			}
+			dir.Cost = new(uint64)
+			*dir.Cost = uint64(atoms)
+		}
+		autoShareCfgDirs = append(autoShareCfgDirs, dir)
+	}
+	autoShareDefaultCost, err := dcrutil.NewAmount(*flagAutoShareDefaultCost)
+	if err != nil || autoShareDefaultCost < 0 {
+		return nil, fmt.Errorf("invalid autoshare default cost")
+	}
+	autoShareCostPerMB, err := dcrutil.NewAmount(*flagAutoShareCostPerMB)
+	if err != nil || autoShareCostPerMB < 0 {
+		return nil, fmt.Errorf("invalid autoshare cost per MB")
+	}
+	var autoShareExclude []string
+	for _, pattern := range strings.Split(*flagAutoShareExclude, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			autoShareExclude = append(autoShareExclude, pattern)
+		}
+	}
+
+	postMediaCost, err := dcrutil.NewAmount(*flagPostMediaCost)
+	if err != nil || postMediaCost < 0 {
+		return nil, fmt.Errorf("invalid post media cost")
+	}
+	postAutoUnlockMaxCost, err := dcrutil.NewAmount(*flagPostAutoUnlockMaxCost)
+	if err != nil || postAutoUnlockMaxCost < 0 {
+		return nil, fmt.Errorf("invalid max cost to automatically unlock posts")
+	}
+
+	var postFeedsDir string
+	if *flagPostFeedsDir != "" {
+		postFeedsDir = cleanAndExpandPath(*flagPostFeedsDir)
+	}
+
+	postImportInterval, err := strduration.ParseDuration(*flagPostImportInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'postimport.interval': %v", err)
+	}
+
+	var autopilotCandidates []string
+	for _, cand := range strings.Split(*flagAutopilotCandidates, ",") {
+		if cand = strings.TrimSpace(cand); cand != "" {
+			autopilotCandidates = append(autopilotCandidates, cand)
+		}
+	}
+	parseAutopilotAmount := func(flag string, v float64) (dcrutil.Amount, error) {
+		amt, err := dcrutil.NewAmount(v)
+		if err != nil || amt < 0 {
+			return 0, fmt.Errorf("invalid value for flag 'autopilot.%s'", flag)
+		}
+		return amt, nil
+	}
+	autopilotMinOutbound, err := parseAutopilotAmount("minoutbound", *flagAutopilotMinOutbound)
+	if err != nil {
+		return nil, err
+	}
+	autopilotChannelSize, err := parseAutopilotAmount("channelsize", *flagAutopilotChannelSize)
+	if err != nil {
+		return nil, err
+	}
+	autopilotWalletReserve, err := parseAutopilotAmount("walletreserve", *flagAutopilotWalletReserve)
+	if err != nil {
+		return nil, err
+	}
+	autopilotFeeBudget, err := parseAutopilotAmount("feebudget", *flagAutopilotFeeBudget)
+	if err != nil {
+		return nil, err
+	}
+	autopilotMinChanAge, err := strduration.ParseDuration(*flagAutopilotMinChanAge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'autopilot.minchannelage': %v", err)
+	}
+	autopilotBudgetPeriod, err := strduration.ParseDuration(*flagAutopilotBudgetPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'autopilot.budgetperiod': %v", err)
+	}
+	autopilotInterval, err := strduration.ParseDuration(*flagAutopilotInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'autopilot.interval': %v", err)
+	}
+
+	parseInboundAmount := func(flag string, v float64) (dcrutil.Amount, error) {
+		amt, err := dcrutil.NewAmount(v)
+		if err != nil || amt < 0 {
+			return 0, fmt.Errorf("invalid value for flag 'inbound.%s'", flag)
+		}
+		return amt, nil
+	}
+	inboundMinInbound, err := parseInboundAmount("mininbound", *flagInboundMinInbound)
+	if err != nil {
+		return nil, err
+	}
+	inboundChannelSize, err := parseInboundAmount("channelsize", *flagInboundChannelSize)
+	if err != nil {
+		return nil, err
+	}
+	inboundFeeBudget, err := parseInboundAmount("feebudget", *flagInboundFeeBudget)
+	if err != nil {
+		return nil, err
+	}
+	inboundBudgetPeriod, err := strduration.ParseDuration(*flagInboundBudgetPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'inbound.budgetperiod': %v", err)
+	}
+	inboundInterval, err := strduration.ParseDuration(*flagInboundInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'inbound.interval': %v", err)
+	}
+
+	scbBackupInterval, err := strduration.ParseDuration(*flagSCBBackupInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for flag 'scbbackup.interval': %v", err)
+	}
+	var scbBackupSFTP []string
+	for _, target := range strings.Split(*flagSCBBackupSFTP, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			scbBackupSFTP = append(scbBackupSFTP, target)
+		}
+	}
+	var scbBackupContacts []string
+	for _, nick := range strings.Split(*flagSCBBackupContacts, ",") {
+		if nick = strings.TrimSpace(nick); nick != "" {
+			scbBackupContacts = append(scbBackupContacts, nick)
+		}
+	}
+	scbBackupDir := filepath.Join(*flagRootDir, "scbbackups")
+	if *flagSCBBackupDir != "" {
+		scbBackupDir = cleanAndExpandPath(*flagSCBBackupDir)
+	}
+
+	// Bandwidth limits are specified in KB/s.
+	bwLimits := client.BandwidthLimits{
+		Upload:       *flagBWUpload * 1000,
+		Download:     *flagBWDownload * 1000,
+		PeerUpload:   *flagBWPeerUpload * 1000,
+		PeerDownload: *flagBWPeerDownload * 1000,
+	}
+
 	ssPayType := simpleStorePayType(*flagSimpleStorePayType)
 	if !ssPayType.isValid() {
 		return nil, fmt.Errorf("invalid simple store payment type %q",
@@ -493,17 +937,30 @@ func loadConfig() (*config, error) {
 		DebugLevel:         *flagDebugLevel,
 		CompressLevel:      *flagCompressLevel,
 		CmdHistoryPath:     cmdHistoryPath,
+		Theme:              *flagTheme,
+		ThemesDir:          filepath.Join(*flagRootDir, "themes"),
 		NickColor:          *flagNickColor,
 		GCOtherColor:       *flagGCOtherColor,
 		PMOtherColor:       *flagPMOtherColor,
 		BlinkCursor:        *flagBlinkCursor,
 		BellCmd:            strings.TrimSpace(*flagBellCmd),
+		NtfnMethod:         ntfnMethod,
+		NtfnPMRule:         ntfnPMRule,
+		NtfnGCRule:         ntfnGCRule,
+		NtfnShowContent:    *flagNtfnShowContent,
+		Keymap:             km,
+		VimMode:            *flagVimMode,
 		Network:            *flagNetwork,
 		CPUProfile:         *flagCPUProfile,
 		CPUProfileHz:       *flagCPUProfileHz,
 		MemProfile:         *flagMemProfile,
+		RestoreSeedBackup:  *flagRestoreSeedBackup,
+		PairDevice:         *flagPairDevice,
 		LogPings:           *flagLogPings,
 		SendRecvReceipts:   *flagSendRecvReceipts,
+		LinkPreviews:       *flagLinkPreviews,
+		InvitePasteURL:     strings.TrimSpace(*flagInvitePasteURL),
+		InviteNostrRelays:  inviteNostrRelays,
 		NoLoadChatHistory:  *flagNoLoadChatHistory,
 		ProxyAddr:          *flagProxyAddr,
 		ProxyUser:          *flagProxyUser,
@@ -512,6 +969,16 @@ func loadConfig() (*config, error) {
 		MinWalletBal:       minWalletBal,
 		MinRecvBal:         minRecvBal,
 		MinSendBal:         minSendBal,
+		AcceptKeysend:      *flagAcceptKeysend,
+		KeysendMaxTipAmt:   keysendMaxTip,
+		OnchainTipMinAmt:   onchainTipMin,
+		FeePolicy:          feePolicy,
+		MaxPaymentParts:    uint32(*flagMaxPaymentParts),
+		TipFiatSlippage:    *flagTipFiatSlippage,
+		PaymentsMode:       paymentsMode,
+		WatchtowerClient:   *flagWatchtowerClient,
+		Watchtowers:        watchtowers,
+		PaymentWebhooks:    paymentWebhooks,
 		WinPin:             winpin,
 		MimeMap:            mimeMap,
 		JSONRPCListen:      jrpcListen,
@@ -523,9 +990,19 @@ func loadConfig() (*config, error) {
 		ResourcesUpstream:  *flagResourcesUpstream,
 
 		AutoHandshakeInterval:       autoHandshakeInterval,
+		AutoHandshakeTimeout:        autoHandshakeTimeout,
 		AutoRemoveIdleUsersInterval: autoRemoveInterval,
 		AutoRemoveIdleUsersIgnore:   autoRemoveIgnoreList,
+		AutoUnsubIdleUsersRVs:       autoUnsubIdleRVs,
+		ContentIndexRefresh:         contentIndexRefresh,
+		BandwidthLimits:             bwLimits,
 		AutoSubPosts:                *flagAutoSubPosts,
+		PostMediaThumbnails:         *flagPostMediaThumbnails,
+		PostMediaCost:               uint64(postMediaCost),
+		PostAutoUnlockMaxCost:       uint64(postAutoUnlockMaxCost),
+		IgnorePostModeration:        *flagIgnorePostModeration,
+
+		RPCEnableExecCommands: *flagRPCEnableExecCommands,
 
 		SyncFreeList:              *flagSyncFreeList,
 		ExternalEditorForComments: *flagExternalEditorForComments,
@@ -534,6 +1011,55 @@ func loadConfig() (*config, error) {
 		SimpleStoreAccount:    *flagSimpleStoreAccount,
 		SimpleStoreShipCharge: *flagSimpleStoreShipCharge,
 
+		AutoShareDirs:        autoShareCfgDirs,
+		AutoShareDefaultCost: uint64(autoShareDefaultCost),
+		AutoShareCostPerMB:   uint64(autoShareCostPerMB),
+		AutoShareExclude:     autoShareExclude,
+
+		PostFeedsListen:     strings.TrimSpace(*flagPostFeedsListen),
+		PostFeedsDir:        postFeedsDir,
+		PostFeedsMaxEntries: *flagPostFeedsMaxEntries,
+
+		PostImportInterval:     postImportInterval,
+		PostImportMaxItems:     *flagPostImportMaxItems,
+		PostImportMaxMediaSize: *flagPostImportMaxMediaSize * 1024,
+		PostImportFetchMedia:   *flagPostImportFetchMedia,
+
+		AutopilotEnable:        *flagAutopilotEnable,
+		AutopilotCandidates:    autopilotCandidates,
+		AutopilotMinOutbound:   autopilotMinOutbound,
+		AutopilotChannelSize:   autopilotChannelSize,
+		AutopilotMaxChannels:   *flagAutopilotMaxChannels,
+		AutopilotWalletReserve: autopilotWalletReserve,
+		AutopilotMinUptime:     *flagAutopilotMinUptime,
+		AutopilotMinChanAge:    autopilotMinChanAge,
+		AutopilotFeeBudget:     autopilotFeeBudget,
+		AutopilotBudgetPeriod:  autopilotBudgetPeriod,
+		AutopilotInterval:      autopilotInterval,
+
+		InboundEnable:       *flagInboundEnable,
+		InboundServer:       *flagInboundServer,
+		InboundCertFile:     cleanAndExpandPath(*flagInboundCertFile),
+		InboundKey:          *flagInboundKey,
+		InboundMinInbound:   inboundMinInbound,
+		InboundChannelSize:  inboundChannelSize,
+		InboundFeeBudget:    inboundFeeBudget,
+		InboundBudgetPeriod: inboundBudgetPeriod,
+		InboundInterval:     inboundInterval,
+
+		SCBBackupEnable:   *flagSCBBackupEnable,
+		SCBBackupDir:      scbBackupDir,
+		SCBBackupKeep:     *flagSCBBackupKeep,
+		SCBBackupInterval: scbBackupInterval,
+		SCBBackupSFTP:     scbBackupSFTP,
+		SCBBackupSFTPKey:  cleanAndExpandPath(*flagSCBBackupSFTPKey),
+		SCBBackupContacts: scbBackupContacts,
+
+		SwapProvider:   *flagSwapProvider,
+		SwapEsplora:    *flagSwapEsplora,
+		SwapMaxPremium: *flagSwapMaxPremium / 100,
+		SwapMinConfs:   *flagSwapMinConfs,
+
 		dialFunc: dialFunc,
 	}, nil
 }
@@ -171,8 +171,10 @@ func (ew *embedWidget) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return ew, cmd
 
 		case tea.KeyMsg:
+			km := ew.as.keymap.Load()
 			switch {
-			case msg.Type == tea.KeyF2 || msg.Type == tea.KeyEsc:
+			case msg.Type == tea.KeyEsc, km.is(msg, keyChatEmbed),
+				km.is(msg, keyNewPostEmbed):
 				// Simulate canceling the form.
 				return ew, emitMsg(msgCancelForm{})
 			}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ansiEscapeRe matches ANSI escape sequences (such as those generated by
+// lipgloss styles).
+var ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+
+// cmdOutputBuffer captures the textual output of commands executed through the
+// clientrpc interface.
+type cmdOutputBuffer struct {
+	mtx sync.Mutex
+	b   strings.Builder
+}
+
+func (buf *cmdOutputBuffer) printf(format string, args ...interface{}) {
+	s := ansiEscapeRe.ReplaceAllString(fmt.Sprintf(format, args...), "")
+	buf.mtx.Lock()
+	buf.b.WriteString(s)
+	buf.b.WriteString("\n")
+	buf.mtx.Unlock()
+}
+
+func (buf *cmdOutputBuffer) String() string {
+	buf.mtx.Lock()
+	defer buf.mtx.Unlock()
+	return buf.b.String()
+}
+
+// cmdOutput is the output sink of a single command invocation. Commands
+// write their output through it instead of directly to the windows, so that
+// the output of commands executed through the clientrpc interface is returned
+// to the caller.
+type cmdOutput struct {
+	as *appState
+
+	// buf captures the output. It is only set for commands executed
+	// through the clientrpc interface.
+	buf *cmdOutputBuffer
+
+	// wg tracks the goroutines started by the command.
+	wg sync.WaitGroup
+}
+
+// capture returns f modified to also write to the capture buffer.
+func (out *cmdOutput) capture(f func(pf printf)) func(pf printf) {
+	if out.buf == nil {
+		return f
+	}
+	return func(pf printf) {
+		f(func(format string, args ...interface{}) {
+			pf(format, args...)
+			out.buf.printf(format, args...)
+		})
+	}
+}
+
+// msgs prints help messages in the currently active window.
+func (out *cmdOutput) msgs(f func(pf printf)) {
+	out.as.cwHelpMsgs(out.capture(f))
+}
+
+// msg prints a help message in the currently active window.
+func (out *cmdOutput) msg(format string, args ...interface{}) {
+	out.msgs(func(pf printf) { pf(format, args...) })
+}
+
+// diagMsgs prints messages in the diagnostic window.
+func (out *cmdOutput) diagMsgs(f func(pf printf)) {
+	out.as.manyDiagMsgsCb(out.capture(f))
+}
+
+// diagMsg prints a message in the diagnostic window.
+func (out *cmdOutput) diagMsg(format string, args ...interface{}) {
+	out.diagMsgs(func(pf printf) { pf(format, args...) })
+}
+
+// goAsync runs f in a goroutine that is part of the command invocation, so
+// that its output is included in the output of commands executed through the
+// clientrpc interface.
+func (out *cmdOutput) goAsync(f func()) {
+	out.wg.Add(1)
+	go func() {
+		defer out.wg.Done()
+		f()
+	}()
+}
+
+// wait waits until the goroutines started by the command finish or the
+// context is done.
+func (out *cmdOutput) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		out.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// execRPCCommand executes a command received through the clientrpc interface
+// and returns its output. The output includes the one written by goroutines
+// started by the command, so this only returns after they finish (or ctx is
+// done).
+//
+// Only one command is executed at a time.
+func (as *appState) execRPCCommand(ctx context.Context, rawCmd string) (string, error) {
+	rawCmd = strings.TrimSpace(rawCmd)
+	if len(rawCmd) > 0 && rawCmd[0] != leader {
+		rawCmd = string(leader) + rawCmd
+	}
+	args := parseCommandLine(rawCmd)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+
+	as.execCmdMtx.Lock()
+	defer as.execCmdMtx.Unlock()
+
+	as.diagMsg("API: %s", rawCmd)
+	out := &cmdOutput{as: as, buf: new(cmdOutputBuffer)}
+	err := as.runCmd(rawCmd, args, out)
+	if waitErr := out.wait(ctx); waitErr != nil && err == nil {
+		err = fmt.Errorf("command did not finish: %w", waitErr)
+	}
+	return out.buf.String(), err
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/strescape"
 	"github.com/companyzero/bisonrelay/rpc"
 )
 
@@ -44,6 +45,9 @@ func (fw *feedWindow) renderPostSumm(post clientdb.PostSummary,
 	} else {
 		title = strings.TrimSpace(title)
 	}
+	if post.Pinned {
+		title = "[pinned] " + title
+	}
 
 	// Determine what to show for "author".
 	author, relayedBy := fw.as.postAuthorRelayer(post)
@@ -75,7 +79,14 @@ func (fw *feedWindow) renderPostSumm(post clientdb.PostSummary,
 	b.WriteString("\n")
 
 	// If this was relayed by someone else, add a "relayed by" line.
-	if relayedBy != "" {
+	if relayedBy != "" && post.RelayComment != "" {
+		comment := strescape.Content(strings.TrimSpace(post.RelayComment))
+		if i := strings.IndexByte(comment, '\n'); i > -1 {
+			comment = comment[:i] + "…"
+		}
+		b.WriteString(st.help.Render(pf("    Relayed by %s: %s", relayedBy, comment)))
+		b.WriteString("\n")
+	} else if relayedBy != "" {
 		b.WriteString(st.help.Render(pf("    Relayed by %s", relayedBy)))
 		b.WriteString("\n")
 	}
@@ -146,37 +157,38 @@ func (fw feedWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		fw.renderPosts()
 
 	case tea.KeyMsg:
+		km := fw.as.keymap.Load()
 		switch {
-		case msg.Type == tea.KeyEsc:
+		case km.is(msg, keyFeedBack):
 			// Return to main window
 			fw.as.markWindowSeen(activeCWFeed)
 			fw.as.changeActiveWindowToPrevActive()
 			return newMainWindowState(fw.as)
 
-		case msg.Type == tea.KeyUp, msg.String() == "k":
+		case km.is(msg, keyFeedUp):
 			if fw.idx > 0 {
 				fw.idx -= 1
 				fw.renderPosts()
 			}
 
-		case msg.Type == tea.KeyDown, msg.String() == "j":
+		case km.is(msg, keyFeedDown):
 			if fw.idx < len(fw.posts)-1 {
 				fw.idx += 1
 				fw.renderPosts()
 			}
 
-		case msg.Type == tea.KeyEnter && fw.idx < len(fw.posts):
+		case km.is(msg, keyFeedOpen) && fw.idx < len(fw.posts):
 			summ := fw.posts[fw.idx]
 			fw.as.activatePost(&summ)
 			return newPostWin(fw.as, fw.idx, fw.viewport.YOffset)
 
-		case msg.Type == tea.KeyCtrlPgUp:
+		case km.is(msg, keyFeedNextWin):
 			// Switch to the window after the feed and go back to
 			// main window.
 			fw.as.changeActiveWindowNext()
 			return newMainWindowState(fw.as)
 
-		case msg.Type == tea.KeyCtrlPgDown:
+		case km.is(msg, keyFeedPrevWin):
 			fw.as.changeActiveWindowPrev()
 			return newMainWindowState(fw.as)
 		}
@@ -196,7 +208,9 @@ func (fw feedWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (fw feedWindow) headerView(styles *theme) string {
-	msg := " Posts Feed - Press ESC to return - ctrl+pgup/pgdown to change windows"
+	km := fw.as.keymap.Load()
+	msg := fmt.Sprintf(" Posts Feed - Press %s to return - %s/%s to change windows",
+		km.help(keyFeedBack), km.help(keyFeedNextWin), km.help(keyFeedPrevWin))
 	headerMsg := styles.header.Render(msg)
 	spaces := styles.header.Render(strings.Repeat(" ",
 		max(0, fw.as.winW-lipgloss.Width(headerMsg))))
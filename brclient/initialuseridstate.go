@@ -58,11 +58,15 @@ func (ws initialUIDState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Did the user press enter while the submit button was focused?
 			// If so, exit.
 			if s == "enter" && ws.focusIndex == len(ws.inputs) {
+				reply := getClientIDReply{
+					nick: ws.inputs[0].Value(),
+					name: ws.inputs[0].Value(),
+				}
+				if ws.as.needsSeedWords() {
+					reply = getClientIDReply{seed: ws.inputs[0].Value()}
+				}
 				go func() {
-					ws.as.clientIDChan <- getClientIDReply{
-						nick: ws.inputs[0].Value(),
-						name: ws.inputs[0].Value(),
-					}
+					ws.as.clientIDChan <- reply
 				}()
 				return initStepState{
 					as: ws.as,
@@ -139,7 +143,13 @@ func (ws initialUIDState) View() string {
 	var b strings.Builder
 	b.WriteString(ws.headerView(styles))
 	b.WriteString("\n\n")
-	b.WriteString("Type the information needed about the local user.\n\n")
+	if ws.as.devicePairingPath != "" {
+		b.WriteString("Type the seed words of the device pairing.\n\n")
+	} else if ws.as.seedBackupPath != "" {
+		b.WriteString("Type the seed words of the backup to restore.\n\n")
+	} else {
+		b.WriteString("Type the information needed about the local user.\n\n")
+	}
 
 	for i := range ws.inputs {
 		b.WriteString(ws.inputs[i].View())
@@ -177,6 +187,10 @@ func newInitialUIDState(as *appState) (initialUIDState, tea.Cmd) {
 		switch i {
 		case 0:
 			t.Placeholder = "Nickname"
+			if as.needsSeedWords() {
+				t.Placeholder = "Seed words"
+				t.CharLimit = 1024
+			}
 			t.Focus()
 			t.PromptStyle = styles.focused
 			t.TextStyle = styles.focused
@@ -40,7 +40,7 @@ func (ins initStepState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
-	if err := isQuitMsg(msg); err != nil {
+	if err := isQuitMsg(ins.as.keymap.Load(), msg); err != nil {
 		return ins, tea.Quit
 	}
 
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyContext is a part of the UI where a set of key bindings is active.
+type keyContext string
+
+const (
+	// keyCtxGlobal bindings are active in every window, so their keys
+	// may not be bound to actions of any other context.
+	keyCtxGlobal  keyContext = "global"
+	keyCtxChat    keyContext = "chat"
+	keyCtxFeed    keyContext = "feed"
+	keyCtxPost    keyContext = "post"
+	keyCtxNewPost keyContext = "newpost"
+)
+
+var keyContexts = []keyContext{keyCtxGlobal, keyCtxChat, keyCtxFeed,
+	keyCtxPost, keyCtxNewPost}
+
+// keyAction is an action triggered by a key binding. Actions are named
+// <context>.<action>.
+type keyAction string
+
+const (
+	keyQuit  keyAction = "global.quit"
+	keyCrash keyAction = "global.crash"
+
+	keyChatSend       keyAction = "chat.send"
+	keyChatNewLine    keyAction = "chat.newline"
+	keyChatCloseWin   keyAction = "chat.closewin"
+	keyChatWinNumber  keyAction = "chat.winnumber"
+	keyChatNextWin    keyAction = "chat.nextwin"
+	keyChatPrevWin    keyAction = "chat.prevwin"
+	keyChatPageUp     keyAction = "chat.pageup"
+	keyChatPageDown   keyAction = "chat.pagedown"
+	keyChatLineUp     keyAction = "chat.lineup"
+	keyChatLineDown   keyAction = "chat.linedown"
+	keyChatHistPrev   keyAction = "chat.histprev"
+	keyChatHistNext   keyAction = "chat.histnext"
+	keyChatComplete   keyAction = "chat.complete"
+	keyChatSelectPrev keyAction = "chat.selectprev"
+	keyChatEmbed      keyAction = "chat.embed"
+	keyChatView       keyAction = "chat.view"
+	keyChatDownload   keyAction = "chat.download"
+
+	keyFeedBack    keyAction = "feed.back"
+	keyFeedUp      keyAction = "feed.up"
+	keyFeedDown    keyAction = "feed.down"
+	keyFeedOpen    keyAction = "feed.open"
+	keyFeedNextWin keyAction = "feed.nextwin"
+	keyFeedPrevWin keyAction = "feed.prevwin"
+
+	keyPostBack      keyAction = "post.back"
+	keyPostUp        keyAction = "post.up"
+	keyPostDown      keyAction = "post.down"
+	keyPostPageUp    keyAction = "post.pageup"
+	keyPostPageDown  keyAction = "post.pagedown"
+	keyPostLineUp    keyAction = "post.lineup"
+	keyPostLineDown  keyAction = "post.linedown"
+	keyPostPrevEmbed keyAction = "post.prevembed"
+	keyPostNextEmbed keyAction = "post.nextembed"
+	keyPostDownload  keyAction = "post.download"
+	keyPostView      keyAction = "post.view"
+	keyPostComment   keyAction = "post.comment"
+	keyPostReply     keyAction = "post.reply"
+	keyPostNewLine   keyAction = "post.newline"
+	keyPostSubmit    keyAction = "post.submit"
+	keyPostInvite    keyAction = "post.invite"
+	keyPostKXSearch  keyAction = "post.kxsearch"
+	keyPostFetch     keyAction = "post.fetch"
+	keyPostRelayAll  keyAction = "post.relayall"
+	keyPostRelayUser keyAction = "post.relayuser"
+	keyPostReceipts  keyAction = "post.receipts"
+
+	keyNewPostEmbed  keyAction = "newpost.embed"
+	keyNewPostCancel keyAction = "newpost.cancel"
+	keyNewPostSubmit keyAction = "newpost.submit"
+	keyNewPostFocus  keyAction = "newpost.focus"
+)
+
+// keyActionDefn is the definition of a key action.
+type keyActionDefn struct {
+	action keyAction
+	keys   []string
+	descr  string
+}
+
+// keyActionDefns are the definitions of every action, with their default
+// keys.
+var keyActionDefns = []keyActionDefn{
+	{keyQuit, []string{"ctrl+q"}, "Quit"},
+	{keyCrash, []string{`ctrl+\`}, "Quit with a stack trace of every goroutine"},
+
+	{keyChatSend, []string{"enter"}, "Send the msg or run the command"},
+	{keyChatNewLine, []string{"alt+enter"}, "Add a new line to the msg"},
+	{keyChatCloseWin, []string{"ctrl+w"}, "Close the window"},
+	{keyChatWinNumber, []string{"esc"}, "Switch to the window with the number typed next"},
+	{keyChatNextWin, []string{"ctrl+pgup"}, "Switch to the next window"},
+	{keyChatPrevWin, []string{"ctrl+pgdown"}, "Switch to the previous window"},
+	{keyChatPageUp, []string{"pgup"}, "Scroll up one page"},
+	{keyChatPageDown, []string{"pgdown"}, "Scroll down one page"},
+	{keyChatLineUp, []string{"alt+pgup"}, "Scroll up one line"},
+	{keyChatLineDown, []string{"alt+pgdown"}, "Scroll down one line"},
+	{keyChatHistPrev, []string{"up"}, "Previous line of the msg or previous command"},
+	{keyChatHistNext, []string{"down"}, "Next line of the msg or next command"},
+	{keyChatComplete, []string{"tab"}, "Complete the command or select the next element of the page"},
+	{keyChatSelectPrev, []string{"shift+tab"}, "Select the previous element of the page"},
+	{keyChatEmbed, []string{"f2"}, "Embed or link a file in the msg"},
+	{keyChatView, []string{"ctrl+v"}, "View the selected embed, link, form or invoice"},
+	{keyChatDownload, []string{"ctrl+d"}, "Download the selected embed"},
+
+	{keyFeedBack, []string{"esc"}, "Return to the main window"},
+	{keyFeedUp, []string{"up", "k"}, "Select the previous post"},
+	{keyFeedDown, []string{"down", "j"}, "Select the next post"},
+	{keyFeedOpen, []string{"enter"}, "Open the selected post"},
+	{keyFeedNextWin, []string{"ctrl+pgup"}, "Switch to the next window"},
+	{keyFeedPrevWin, []string{"ctrl+pgdown"}, "Switch to the previous window"},
+
+	{keyPostBack, []string{"esc"}, "Cancel the comment or return to the feed"},
+	{keyPostUp, []string{"up"}, "Scroll up or select the previous comment"},
+	{keyPostDown, []string{"down"}, "Scroll down or select the next comment"},
+	{keyPostPageUp, []string{"pgup"}, "Scroll up one page"},
+	{keyPostPageDown, []string{"pgdown"}, "Scroll down one page"},
+	{keyPostLineUp, []string{"alt+pgup"}, "Scroll up one line"},
+	{keyPostLineDown, []string{"alt+pgdown"}, "Scroll down one line"},
+	{keyPostPrevEmbed, []string{"left"}, "Select the previous embed"},
+	{keyPostNextEmbed, []string{"right"}, "Select the next embed"},
+	{keyPostDownload, []string{"ctrl+d"}, "Download the selected embed"},
+	{keyPostView, []string{"ctrl+v"}, "View the selected embed"},
+	{keyPostComment, []string{"c"}, "Comment on the post"},
+	{keyPostReply, []string{"r"}, "Reply to the selected comment"},
+	{keyPostNewLine, []string{"alt+enter"}, "Add a new line to the comment"},
+	{keyPostSubmit, []string{"enter"}, "Submit the comment or relay"},
+	{keyPostInvite, []string{"I"}, "Request an invite to the author"},
+	{keyPostKXSearch, []string{"S"}, "Search for the author"},
+	{keyPostFetch, []string{"G"}, "Subscribe to the author and fetch the post"},
+	{keyPostRelayAll, []string{"R"}, "Relay the post to subscribers"},
+	{keyPostRelayUser, []string{"U"}, "Relay the post to a user"},
+	{keyPostReceipts, []string{"f4"}, "Show the receive receipts"},
+
+	{keyNewPostEmbed, []string{"f2"}, "Embed or link a file in the post"},
+	{keyNewPostCancel, []string{"esc"}, "Cancel the post"},
+	{keyNewPostSubmit, []string{"enter"}, "Create the post (when the button is focused)"},
+	{keyNewPostFocus, []string{"tab"}, "Switch between the post and the button"},
+}
+
+// findKeyActionDefn returns the definition of the action or nil if it does
+// not exist.
+func findKeyActionDefn(action keyAction) *keyActionDefn {
+	for i := range keyActionDefns {
+		if keyActionDefns[i].action == action {
+			return &keyActionDefns[i]
+		}
+	}
+	return nil
+}
+
+// context returns the context where the action is active.
+func (a keyAction) context() keyContext {
+	ctx, _, _ := strings.Cut(string(a), ".")
+	return keyContext(ctx)
+}
+
+// validKeyNames are the names of keys (besides single chars) that may be
+// bound, optionally prefixed with alt+.
+var validKeyNames = func() map[string]bool {
+	names := []string{"enter", "tab", "esc", "backspace", "delete", "insert",
+		"up", "down", "left", "right", "home", "end", "pgup", "pgdown",
+		"shift+tab", "ctrl+@", `ctrl+\`, "ctrl+]", "ctrl+^", "ctrl+_",
+		"ctrl+pgup", "ctrl+pgdown"}
+	for _, k := range []string{"up", "down", "left", "right", "home", "end"} {
+		names = append(names, "ctrl+"+k, "shift+"+k, "ctrl+shift+"+k)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		// ctrl+i and ctrl+m are received as tab and enter.
+		if c != 'i' && c != 'm' {
+			names = append(names, "ctrl+"+string(c))
+		}
+	}
+	for i := 1; i <= 20; i++ {
+		names = append(names, fmt.Sprintf("f%d", i))
+	}
+	res := make(map[string]bool, len(names))
+	for _, n := range names {
+		res[n] = true
+	}
+	return res
+}()
+
+// normalizeKey returns the normalized name of a key as written in the config
+// file or in '/bind', which matches the name of the key in tea.KeyMsg.
+func normalizeKey(s string) (string, error) {
+	key := strings.TrimPrefix(s, "alt+")
+	alt := key != s
+	switch {
+	case key == "space":
+		key = " "
+	case utf8.RuneCountInString(key) == 1:
+		// Single char.
+	case validKeyNames[strings.ToLower(key)]:
+		key = strings.ToLower(key)
+	default:
+		return "", fmt.Errorf("unknown key %q", s)
+	}
+	if alt {
+		key = "alt+" + key
+	}
+	return key, nil
+}
+
+// parseKeys parses a comma separated list of keys. "none" is an empty list.
+func parseKeys(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "none" {
+		return []string{}, nil
+	}
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		key, err := normalizeKey(strings.TrimSpace(k))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keyName returns the name of the key of the msg.
+func keyName(msg tea.KeyMsg) string {
+	s := msg.String()
+	if s == "alt+\r" {
+		// Alt+enter on new bubbletea versions.
+		return "alt+enter"
+	}
+	return s
+}
+
+// keymap binds keys to actions. A keymap is immutable after being created.
+type keymap struct {
+	bindings map[keyAction][]string
+	actions  map[keyContext]map[string]keyAction
+}
+
+// newKeymap creates a keymap with the default keys of every action, except
+// the ones in bindings. It returns an error if a key is bound to more than
+// one action of the same context (or to a global action and an action of
+// another context).
+func newKeymap(bindings map[keyAction][]string) (*keymap, error) {
+	km := &keymap{
+		bindings: make(map[keyAction][]string, len(keyActionDefns)),
+		actions:  make(map[keyContext]map[string]keyAction, len(keyContexts)),
+	}
+	for action := range bindings {
+		if findKeyActionDefn(action) == nil {
+			return nil, fmt.Errorf("unknown key action %q", action)
+		}
+	}
+	for _, ctx := range keyContexts {
+		km.actions[ctx] = make(map[string]keyAction)
+	}
+
+	for _, defn := range keyActionDefns {
+		keys, ok := bindings[defn.action]
+		if !ok {
+			keys = defn.keys
+		}
+		km.bindings[defn.action] = keys
+
+		ctx := defn.action.context()
+		for _, key := range keys {
+			if other, ok := km.conflict(ctx, key); ok {
+				return nil, fmt.Errorf("key %q of %s is already bound "+
+					"to %s", key, defn.action, other)
+			}
+			km.actions[ctx][key] = defn.action
+		}
+	}
+	return km, nil
+}
+
+// defaultKeymap returns the keymap with the default keys of every action.
+func defaultKeymap() *keymap {
+	km, err := newKeymap(nil)
+	if err != nil {
+		panic(fmt.Sprintf("conflict in default keymap: %v", err))
+	}
+	return km
+}
+
+// conflict returns the action already bound to the key that conflicts with
+// binding the key to an action of the given context.
+func (km *keymap) conflict(ctx keyContext, key string) (keyAction, bool) {
+	if other, ok := km.actions[keyCtxGlobal][key]; ok {
+		return other, true
+	}
+	if ctx != keyCtxGlobal {
+		other, ok := km.actions[ctx][key]
+		return other, ok
+	}
+	for _, actions := range km.actions {
+		if other, ok := actions[key]; ok {
+			return other, true
+		}
+	}
+	return "", false
+}
+
+// rebind returns a copy of the keymap with the action bound to the given
+// keys. A nil list of keys restores the default keys of the action.
+func (km *keymap) rebind(action keyAction, keys []string) (*keymap, error) {
+	defn := findKeyActionDefn(action)
+	if defn == nil {
+		return nil, fmt.Errorf("unknown key action %q", action)
+	}
+	if keys == nil {
+		keys = defn.keys
+	}
+	bindings := make(map[keyAction][]string, len(km.bindings))
+	for a, k := range km.bindings {
+		bindings[a] = k
+	}
+	bindings[action] = keys
+	return newKeymap(bindings)
+}
+
+// keys returns the keys bound to the action.
+func (km *keymap) keys(action keyAction) []string {
+	return km.bindings[action]
+}
+
+// help returns the description of the keys bound to the action, to be used
+// in help msgs.
+func (km *keymap) help(action keyAction) string {
+	keys := km.bindings[action]
+	if len(keys) == 0 {
+		return "(unbound)"
+	}
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		if k == " " {
+			k = "space"
+		}
+		res[i] = k
+	}
+	return strings.Join(res, "/")
+}
+
+// is returns true if the key of the msg is bound to the action.
+func (km *keymap) is(msg tea.KeyMsg, action keyAction) bool {
+	return km.actions[action.context()][keyName(msg)] == action
+}
+
+// isMsg is like is, but for any msg.
+func (km *keymap) isMsg(msg tea.Msg, action keyAction) bool {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	return ok && km.is(keyMsg, action)
+}
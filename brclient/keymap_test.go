@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestParseKeys tests parsing lists of keys.
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{s: "ctrl+q", want: []string{"ctrl+q"}},
+		{s: "Ctrl+PgUp, alt+right", want: []string{"ctrl+pgup", "alt+right"}},
+		{s: "k,K,space", want: []string{"k", "K", " "}},
+		{s: "alt+enter,F2", want: []string{"alt+enter", "f2"}},
+		{s: "none", want: []string{}},
+		{s: "ctrl+i", wantErr: true},
+		{s: "hyper+x", wantErr: true},
+		{s: "a,", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := parseKeys(tc.s)
+		if tc.wantErr {
+			assert.NonNilErr(t, err)
+			continue
+		}
+		assert.NilErr(t, err)
+		assert.DeepEqual(t, got, tc.want)
+	}
+}
+
+// TestKeymapConflicts tests that keys bound to more than one action of a
+// context are rejected.
+func TestKeymapConflicts(t *testing.T) {
+	km := defaultKeymap()
+
+	// Same key in different contexts.
+	_, err := km.rebind(keyFeedBack, []string{"ctrl+w"})
+	assert.NilErr(t, err)
+
+	// Same key in the same context.
+	_, err = km.rebind(keyChatNextWin, []string{"ctrl+w"})
+	assert.NonNilErr(t, err)
+
+	// Keys of global actions conflict with every context.
+	_, err = km.rebind(keyPostComment, []string{"ctrl+q"})
+	assert.NonNilErr(t, err)
+	_, err = km.rebind(keyQuit, []string{"c"})
+	assert.NonNilErr(t, err)
+
+	// Unbinding an action releases its keys.
+	km, err = km.rebind(keyChatCloseWin, []string{})
+	assert.NilErr(t, err)
+	km, err = km.rebind(keyChatNextWin, []string{"ctrl+w"})
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, km.help(keyChatCloseWin), "(unbound)")
+
+	// Restoring the default keys conflicts with the new binding.
+	_, err = km.rebind(keyChatCloseWin, nil)
+	assert.NonNilErr(t, err)
+
+	_, err = newKeymap(map[keyAction][]string{"chat.foo": {"x"}})
+	assert.NonNilErr(t, err)
+}
+
+// TestKeymapIs tests matching key msgs to actions.
+func TestKeymapIs(t *testing.T) {
+	km, err := newKeymap(map[keyAction][]string{
+		keyChatNextWin: {"alt+right", "ctrl+n"},
+	})
+	assert.NilErr(t, err)
+
+	altRight := tea.KeyMsg{Type: tea.KeyRight, Alt: true}
+	ctrlN := tea.KeyMsg{Type: tea.KeyCtrlN}
+	ctrlPgUp := tea.KeyMsg{Type: tea.KeyCtrlPgUp}
+	altEnter := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'\r'}, Alt: true}
+
+	assert.DeepEqual(t, km.is(altRight, keyChatNextWin), true)
+	assert.DeepEqual(t, km.is(ctrlN, keyChatNextWin), true)
+	assert.DeepEqual(t, km.is(ctrlPgUp, keyChatNextWin), false)
+	assert.DeepEqual(t, km.is(ctrlPgUp, keyFeedNextWin), true)
+	assert.DeepEqual(t, km.is(altEnter, keyChatNewLine), true)
+	assert.DeepEqual(t, km.help(keyChatNextWin), "alt+right/ctrl+n")
+}
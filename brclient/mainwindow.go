@@ -56,10 +56,16 @@ func (mws *mainWindowState) updateHeader(styles *theme) {
 	}
 
 	var helpStr string
+	km := mws.as.keymap.Load()
 	if mws.isPage {
-		helpStr = " - tab/shift+tab to navigate form, enter to select, ctrl+pgup/pgdown to change windows, ctrl+w to close"
+		helpStr = fmt.Sprintf(" - %s/%s to navigate form, %s to select, "+
+			"%s/%s to change windows, %s to close",
+			km.help(keyChatComplete), km.help(keyChatSelectPrev),
+			km.help(keyChatSend), km.help(keyChatNextWin),
+			km.help(keyChatPrevWin), km.help(keyChatCloseWin))
 	} else {
-		helpStr = " - F2 to embed, ctrl+up/down to select, ctrl+v to view"
+		helpStr = fmt.Sprintf(" - %s to embed, %s to view",
+			km.help(keyChatEmbed), km.help(keyChatView))
 	}
 	helpMsg := styles.header.Render(helpStr)
 	qlenMsg := styles.header.Render(fmt.Sprintf("Q %d ", mws.as.rmqLen()))
@@ -157,6 +163,20 @@ func (mws *mainWindowState) onTextInputAction() {
 	mws.recalcViewportSize()
 }
 
+// textAreaChanged stores the working cmd and draft after the text of the line
+// editor changed.
+func (mws *mainWindowState) textAreaChanged() {
+	newVal := mws.textArea.Value()
+	mws.recalcViewportSize()
+	mws.as.workingCmd = newVal
+	mws.as.cmdHistoryIdx = len(mws.as.cmdHistory)
+	mws.updateDraft(newVal)
+
+	// Reset completion.
+	mws.completeOpts = nil
+	mws.completeIdx = 0
+}
+
 func (mws *mainWindowState) updateCompletion() {
 	// Advance to next completion option.
 	if len(mws.completeOpts) != 0 {
@@ -232,29 +252,29 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		// mws.debug = fmt.Sprintf("%q %v", msg.String(), msg.Type)
+		if handled, cmd := mws.handleVimKey(msg); handled {
+			cmds = appendCmd(cmds, cmd)
+			break
+		}
+
 		cw := mws.as.activeChatWindow()
+		km := mws.as.keymap.Load()
 
 		switch {
-		case msg.Type == tea.KeyCtrlW:
+		case km.is(msg, keyChatCloseWin):
 			mws.as.closeActiveWindow()
 
-		case msg.Type == tea.KeyEsc:
+		case km.is(msg, keyChatWinNumber):
 			mws.escStr = ""
 			mws.escMode = !mws.escMode
 
-		case msg.String() == "alt+\r":
-			// Alt+enter on new bubbletea version.
-			msg.Type = tea.KeyEnter
-			fallthrough
-
-		case mws.isChat && (msg.Type == tea.KeyEnter && msg.Alt):
-			// Alt+Enter: Add a new line to multiline edit.
-			msg.Alt = false
-			mws.textArea, cmd = mws.textArea.Update(msg)
+		case mws.isChat && km.is(msg, keyChatNewLine):
+			// Add a new line to multiline edit.
+			mws.textArea, cmd = mws.textArea.Update(tea.KeyMsg{Type: tea.KeyEnter})
 			cmds = appendCmd(cmds, cmd)
 			mws.recalcViewportSize()
 
-		case msg.Type == tea.KeyEnter:
+		case km.is(msg, keyChatSend):
 			if mws.isPage {
 				if cw.selEl != nil && cw.selEl.embed != nil {
 					// View selected embed.
@@ -295,20 +315,26 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Execute command
 			mws.onTextInputAction()
 
-		case msg.Type == tea.KeyPgUp, msg.Type == tea.KeyPgDown:
-			// Rewrite when alt is pressed to scroll a single line.
-			if msg.Type == tea.KeyPgUp && msg.Alt {
-				msg.Type = tea.KeyUp
-				msg.Alt = false
-			} else if msg.Type == tea.KeyPgDown && msg.Alt {
-				msg.Type = tea.KeyDown
-				msg.Alt = false
+		case km.is(msg, keyChatPageUp), km.is(msg, keyChatPageDown),
+			km.is(msg, keyChatLineUp), km.is(msg, keyChatLineDown):
+
+			// Rewrite as the keys of the viewport.
+			var scrollMsg tea.KeyMsg
+			switch {
+			case km.is(msg, keyChatPageUp):
+				scrollMsg.Type = tea.KeyPgUp
+			case km.is(msg, keyChatPageDown):
+				scrollMsg.Type = tea.KeyPgDown
+			case km.is(msg, keyChatLineUp):
+				scrollMsg.Type = tea.KeyUp
+			default:
+				scrollMsg.Type = tea.KeyDown
 			}
 
 			wasAtBottom := mws.viewport.AtBottom()
 
 			// send to viewport
-			mws.viewport, cmd = mws.viewport.Update(msg)
+			mws.viewport, cmd = mws.viewport.Update(scrollMsg)
 			cmds = appendCmd(cmds, cmd)
 
 			if !wasAtBottom && mws.viewport.AtBottom() {
@@ -319,7 +345,7 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-		case msg.Type == tea.KeyTab:
+		case km.is(msg, keyChatComplete):
 			if mws.isPage {
 				if cw.changeSelected(1) {
 					mws.updateViewportContent()
@@ -331,7 +357,7 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			mws.updateCompletion()
 
-		case msg.Type == tea.KeyShiftTab:
+		case km.is(msg, keyChatSelectPrev):
 			if mws.isPage {
 				if cw.changeSelected(-1) {
 					mws.updateViewportContent()
@@ -341,13 +367,22 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-		case msg.Type == tea.KeyCtrlPgUp:
+		case km.is(msg, keyChatNextWin):
 			mws.as.changeActiveWindowNext()
 
-		case msg.Type == tea.KeyCtrlPgDown:
+		case km.is(msg, keyChatPrevWin):
 			mws.as.changeActiveWindowPrev()
 
-		case msg.Type == tea.KeyUp, msg.Type == tea.KeyDown:
+		case km.is(msg, keyChatHistPrev), km.is(msg, keyChatHistNext):
+			up := km.is(msg, keyChatHistPrev)
+			down := !up
+
+			// Rewrite as the keys of the viewport and text area.
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+			if up {
+				msg.Type = tea.KeyUp
+			}
+
 			if mws.isPage {
 				// send to viewport
 				mws.viewport, cmd = mws.viewport.Update(msg)
@@ -358,8 +393,6 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			up := msg.Type == tea.KeyUp
-			down := !up
 			afterHistory := mws.as.cmdHistoryIdx >= len(mws.as.cmdHistory)
 			atWorkingCmd := afterHistory && mws.textArea.Value() == mws.as.workingCmd
 			atLastHistory := mws.as.cmdHistoryIdx == len(mws.as.cmdHistory)-1
@@ -442,10 +475,10 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return msgProcessEsc{}
 			}
 
-		case msg.Type == tea.KeyF2:
+		case km.is(msg, keyChatEmbed):
 			cmds = mws.ew.activate()
 
-		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.embed != nil && msg.Type == tea.KeyCtrlV:
+		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.embed != nil && km.is(msg, keyChatView):
 			// View selected embed.
 			embedded := *cw.selEl.embed
 			cmd, err := mws.as.viewEmbed(embedded)
@@ -455,7 +488,7 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cw.newHelpMsg("Unable to view embed: %v", err)
 			mws.updateViewportContent()
 
-		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.link != nil && msg.Type == tea.KeyCtrlV:
+		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.link != nil && km.is(msg, keyChatView):
 			// Navigate to other page.
 			uid := cw.page.UID
 			err := mws.as.fetchPage(uid, *cw.selEl.link,
@@ -464,7 +497,7 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				mws.as.diagMsg("Unable to fetch page: %v", err)
 			}
 
-		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.formField != nil && cw.selEl.formField.typ == "submit" && cw.selEl.form != nil && msg.Type == tea.KeyCtrlV:
+		case !mws.isPage && cw != nil && cw.selEl != nil && cw.selEl.formField != nil && cw.selEl.formField.typ == "submit" && cw.selEl.form != nil && km.is(msg, keyChatView):
 			// Submit form.
 			uid := cw.page.UID
 			action := cw.selEl.form.action()
@@ -480,11 +513,13 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				mws.as.diagMsg("Unable to fetch page: %v", err)
 			}
 
-		case cw != nil && cw.selEl != nil && cw.selEl.url != nil && cw.selEl.payReq != nil && msg.Type == tea.KeyCtrlV:
-			// Pay invoice.
-			mws.as.payPayReq(cw, *cw.selEl.url, cw.selEl.payReq)
+		case cw != nil && cw.selEl != nil && cw.selEl.url != nil && cw.selEl.payReq != nil && km.is(msg, keyChatView):
+			// Pay invoice. This may block waiting for a confirmation
+			// to exceed a payment budget, so it is done outside the
+			// UI loop.
+			go mws.as.payPayReq(cw, *cw.selEl.url, cw.selEl.payReq)
 
-		case msg.Type == tea.KeyCtrlD:
+		case km.is(msg, keyChatDownload):
 			if cw != nil && cw.selEl != nil && cw.selEl.embed != nil {
 				embedded := *cw.selEl.embed
 				if embedded.Uid != nil {
@@ -519,13 +554,7 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Store working cmd if the text input changed in
 			// response to this msg.
 			if prevVal != newVal {
-				mws.recalcViewportSize()
-				mws.as.workingCmd = newVal
-				mws.as.cmdHistoryIdx = len(mws.as.cmdHistory)
-
-				// Reset completion.
-				mws.completeOpts = nil
-				mws.completeIdx = 0
+				mws.textAreaChanged()
 			}
 		}
 
@@ -543,6 +572,12 @@ func (mws mainWindowState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = appendCmd(cmds, markAllRead(cw))
 			}
 
+			// Restore the draft of the new window, unless a command
+			// is being typed.
+			if !strings.HasPrefix(mws.textArea.Value(), "/") {
+				mws.restoreDraft(cw)
+			}
+
 			mws.updateViewportContent()
 			mws.resetFormInput()
 		}
@@ -645,6 +680,8 @@ func (mws mainWindowState) footerView(styles *theme) string {
 		esc = mws.debug
 	} else if mws.escMode {
 		esc = "ESC"
+	} else if vimStatus := mws.as.vim.statusLine(); vimStatus != "" && mws.isChat {
+		esc += styles.footer.Render(vimStatus + " ")
 	}
 
 	return mws.as.footerView(styles, esc)
@@ -679,6 +716,28 @@ func (mws mainWindowState) View() string {
 	)
 }
 
+// updateDraft updates the draft of the active chat window with the text
+// being typed. Commands are not stored as drafts.
+func (mws *mainWindowState) updateDraft(text string) {
+	cw := mws.as.activeChatWindow()
+	if cw == nil || strings.HasPrefix(text, "/") {
+		return
+	}
+	mws.as.updateDraft(cw, text)
+}
+
+// restoreDraft replaces the text being typed with the draft of the chat
+// window.
+func (mws *mainWindowState) restoreDraft(cw *chatWindow) {
+	draft := cw.getDraft()
+	if draft == mws.textArea.Value() {
+		return
+	}
+	mws.textArea.SetValue(draft)
+	mws.as.workingCmd = draft
+	mws.recalcViewportSize()
+}
+
 func newMainWindowState(as *appState) (mainWindowState, tea.Cmd) {
 	mws := mainWindowState{
 		as:           as,
@@ -695,6 +754,9 @@ func newMainWindowState(as *appState) (mainWindowState, tea.Cmd) {
 	mws.textArea.CharLimit = 1024 * 1024
 	mws.textArea.SetValue(as.workingCmd)
 	mws.textArea.Focus()
+	if cw := as.activeChatWindow(); cw != nil && as.workingCmd == "" {
+		mws.restoreDraft(cw)
+	}
 
 	mws.formInput.Focus()
 
@@ -95,6 +95,10 @@ type getClientID struct{}
 type getClientIDReply struct {
 	nick string
 	name string
+
+	// seed is filled instead of nick and name when restoring the
+	// identity from a seed backup.
+	seed string
 }
 
 // repaintActiveChat is sent to the main window whenever the active chat window
@@ -122,6 +126,9 @@ type feedUpdated struct {
 // sentPostComment is sent when a new local comment to a post is sent.
 type sentPostComment struct{}
 
+// postUnlocked is sent when the paywalled body of a post is received.
+type postUnlocked struct{ pid clientintf.PostID }
+
 // kxCompleted is sent when a KX process has completed with a remote peer.
 type kxCompleted struct{ uid clientintf.UserID }
 
@@ -198,11 +205,10 @@ type msgExternalCommentResult struct {
 
 // isQuitMsg returns true if the app should quit as a response to the given
 // msg. It returns an error with the reason for quitting.
-func isQuitMsg(msg tea.Msg) error {
+func isQuitMsg(km *keymap, msg tea.Msg) error {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		k := msg.String()
-		if k == "ctrl+q" {
+		if km.is(msg, keyQuit) {
 			return errQuitRequested
 		}
 	case requestShutdown:
@@ -215,10 +221,10 @@ func isQuitMsg(msg tea.Msg) error {
 
 // isCrashMsg returns true if the app should quit with a full goroutine stack
 // trace as a response to que given msg.
-func isCrashMsg(msg tea.Msg) bool {
+func isCrashMsg(km *keymap, msg tea.Msg) bool {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+\\" {
+		if km.is(msg, keyCrash) {
 			return true
 		}
 	case crashApp:
@@ -59,7 +59,7 @@ func (pw *newPostWindow) createPost(post string) {
 		return args.String()
 
 	})
-	go pw.as.createPost(fullPost, "")
+	go pw.as.createPost(fullPost, "", nil)
 }
 
 func (pw newPostWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -97,15 +97,16 @@ func (pw newPostWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handlers when the main post typing form is active.
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		km := pw.as.keymap.Load()
 		switch {
-		case msg.Type == tea.KeyF2:
+		case km.is(msg, keyNewPostEmbed):
 			cmds = pw.ew.activate()
 
-		case msg.Type == tea.KeyEsc:
+		case km.is(msg, keyNewPostCancel):
 			// Cancel post.
 			return newMainWindowState(pw.as)
 
-		case pw.focusIdx == 1 && msg.Type == tea.KeyEnter:
+		case pw.focusIdx == 1 && km.is(msg, keyNewPostSubmit):
 			post := pw.textArea.Value()
 			if post != "" {
 				go pw.createPost(post)
@@ -113,7 +114,7 @@ func (pw newPostWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return newMainWindowState(pw.as)
 
-		case msg.Type == tea.KeyTab:
+		case km.is(msg, keyNewPostFocus):
 			pw.focusIdx = (pw.focusIdx + 1) % 2
 			if pw.focusIdx == 0 {
 				pw.textArea.Focus()
@@ -143,7 +144,8 @@ func (pw newPostWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (pw *newPostWindow) headerView(styles *theme) string {
-	msg := " Create Post - F2 to Embed/Link File"
+	msg := fmt.Sprintf(" Create Post - %s to Embed/Link File",
+		pw.as.keymap.Load().help(keyNewPostEmbed))
 	headerMsg := styles.header.Render(msg)
 	spaces := styles.header.Render(strings.Repeat(" ",
 		max(0, pw.as.winW-lipgloss.Width(headerMsg))))
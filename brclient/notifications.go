@@ -0,0 +1,352 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/companyzero/bisonrelay/internal/jsonfile"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+)
+
+// Desktop notifications:
+//
+// Received PMs and GC messages may generate a notification, shown either by
+// the desktop (through notify-send, the D-Bus notifications service or
+// osascript) or by the terminal (through OSC escape sequences or the BEL).
+// Whether a message generates a notification is decided by the rule of its
+// conversation: the rule set for the specific user or GC with '/notify set'
+// or, when there is none, the global rule for PMs or GCs.
+
+// ntfnMethod is the method used to show notifications.
+type ntfnMethod string
+
+const (
+	ntfnMethodNone       ntfnMethod = "none"
+	ntfnMethodAuto       ntfnMethod = "auto"
+	ntfnMethodNotifySend ntfnMethod = "notify-send"
+	ntfnMethodDBus       ntfnMethod = "dbus"
+	ntfnMethodOsascript  ntfnMethod = "osascript"
+	ntfnMethodOSC9       ntfnMethod = "osc9"
+	ntfnMethodOSC777     ntfnMethod = "osc777"
+	ntfnMethodBell       ntfnMethod = "bell"
+)
+
+var ntfnMethods = []ntfnMethod{ntfnMethodNone, ntfnMethodAuto,
+	ntfnMethodNotifySend, ntfnMethodDBus, ntfnMethodOsascript,
+	ntfnMethodOSC9, ntfnMethodOSC777, ntfnMethodBell}
+
+func parseNtfnMethod(s string) (ntfnMethod, error) {
+	for _, m := range ntfnMethods {
+		if string(m) == s {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown notification method %q", s)
+}
+
+// resolveNtfnMethod returns the method used when the configured method is
+// auto: the native notifications of the desktop when they are available or
+// the terminal BEL otherwise.
+func resolveNtfnMethod(m ntfnMethod) ntfnMethod {
+	if m != ntfnMethodAuto {
+		return m
+	}
+
+	hasCmd := func(cmd string) bool {
+		_, err := exec.LookPath(cmd)
+		return err == nil
+	}
+	isSSH := os.Getenv("SSH_CONNECTION") != ""
+	hasDisplay := os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	switch {
+	case runtime.GOOS == "darwin" && !isSSH && hasCmd("osascript"):
+		return ntfnMethodOsascript
+	case hasDisplay && hasCmd("notify-send"):
+		return ntfnMethodNotifySend
+	case hasDisplay && hasCmd("gdbus"):
+		return ntfnMethodDBus
+	default:
+		return ntfnMethodBell
+	}
+}
+
+// ntfnRule is the rule that decides which msgs of a conversation generate
+// notifications.
+type ntfnRule string
+
+const (
+	// ntfnRuleDefault is only used in '/notify set', to remove the rule
+	// of a conversation.
+	ntfnRuleDefault  ntfnRule = "default"
+	ntfnRuleAll      ntfnRule = "all"
+	ntfnRuleMentions ntfnRule = "mentions"
+	ntfnRuleOff      ntfnRule = "off"
+)
+
+func parseNtfnRule(s string) (ntfnRule, error) {
+	switch r := ntfnRule(s); r {
+	case ntfnRuleAll, ntfnRuleMentions, ntfnRuleOff:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown notification rule %q", s)
+	}
+}
+
+// matches returns true if a msg matches the rule.
+func (r ntfnRule) matches(mentioned bool) bool {
+	return r == ntfnRuleAll || (r == ntfnRuleMentions && mentioned)
+}
+
+const (
+	// ntfnConvInterval is the min interval between notifications of the
+	// same conversation, so that bursts of msgs (for example, the ones
+	// received after being offline) do not flood the desktop.
+	ntfnConvInterval = 10 * time.Second
+
+	// ntfnMaxBodyLen is the max number of chars of a msg shown in a
+	// notification.
+	ntfnMaxBodyLen = 200
+)
+
+type desktopNotifierConfig struct {
+	method      ntfnMethod
+	pmRule      ntfnRule
+	gcRule      ntfnRule
+	showContent bool
+
+	// stateFile is the file where the rules of conversations are stored.
+	stateFile string
+
+	// term is where the escape sequences of the terminal methods are
+	// written.
+	term io.Writer
+	log  slog.Logger
+}
+
+// desktopNotifier shows notifications of received msgs.
+type desktopNotifier struct {
+	cfg    desktopNotifierConfig
+	inTmux bool
+
+	mtx      sync.Mutex
+	rules    map[string]ntfnRule // Rules of conversations by user or GC ID.
+	lastNtfn map[zkidentity.ShortID]time.Time
+}
+
+func newDesktopNotifier(cfg desktopNotifierConfig) (*desktopNotifier, error) {
+	cfg.method = resolveNtfnMethod(cfg.method)
+	dn := &desktopNotifier{
+		cfg:      cfg,
+		inTmux:   os.Getenv("TMUX") != "",
+		rules:    make(map[string]ntfnRule),
+		lastNtfn: make(map[zkidentity.ShortID]time.Time),
+	}
+	if cfg.stateFile != "" {
+		err := jsonfile.Read(cfg.stateFile, &dn.rules)
+		if err != nil && !errors.Is(err, jsonfile.ErrNotFound) {
+			return nil, fmt.Errorf("unable to read notification rules: %v", err)
+		}
+	}
+	return dn, nil
+}
+
+// method returns the method used to show notifications.
+func (dn *desktopNotifier) method() ntfnMethod {
+	return dn.cfg.method
+}
+
+// globalRule returns the rule of conversations without a specific rule.
+func (dn *desktopNotifier) globalRule(isGC bool) ntfnRule {
+	if isGC {
+		return dn.cfg.gcRule
+	}
+	return dn.cfg.pmRule
+}
+
+// rule returns the rule of the conversation with the given user or GC and
+// whether it is specific to the conversation.
+func (dn *desktopNotifier) rule(id zkidentity.ShortID, isGC bool) (ntfnRule, bool) {
+	dn.mtx.Lock()
+	r, ok := dn.rules[id.String()]
+	dn.mtx.Unlock()
+	if ok {
+		return r, true
+	}
+	return dn.globalRule(isGC), false
+}
+
+// convRules returns the rules specific to conversations.
+func (dn *desktopNotifier) convRules() map[string]ntfnRule {
+	dn.mtx.Lock()
+	res := make(map[string]ntfnRule, len(dn.rules))
+	for id, r := range dn.rules {
+		res[id] = r
+	}
+	dn.mtx.Unlock()
+	return res
+}
+
+// setRule sets the rule of the conversation with the given user or GC.
+// ntfnRuleDefault removes the rule of the conversation.
+func (dn *desktopNotifier) setRule(id zkidentity.ShortID, r ntfnRule) error {
+	dn.mtx.Lock()
+	defer dn.mtx.Unlock()
+	if r == ntfnRuleDefault {
+		delete(dn.rules, id.String())
+	} else {
+		dn.rules[id.String()] = r
+	}
+	if dn.cfg.stateFile == "" {
+		return nil
+	}
+	return jsonfile.Write(dn.cfg.stateFile, dn.rules, dn.cfg.log)
+}
+
+// shouldNotify returns true if a msg received in the conversation with the
+// given user or GC generates a notification. This also tracks the time of
+// the notification, to limit the rate of notifications of the conversation.
+func (dn *desktopNotifier) shouldNotify(id zkidentity.ShortID, isGC, mentioned bool, now time.Time) bool {
+	if dn.cfg.method == ntfnMethodNone {
+		return false
+	}
+	if r, _ := dn.rule(id, isGC); !r.matches(mentioned) {
+		return false
+	}
+
+	dn.mtx.Lock()
+	defer dn.mtx.Unlock()
+	if now.Sub(dn.lastNtfn[id]) < ntfnConvInterval {
+		return false
+	}
+	dn.lastNtfn[id] = now
+	return true
+}
+
+// notifyMsg shows the notification of a msg received in a conversation, if
+// the rules allow it. gcAlias is empty for PMs.
+func (dn *desktopNotifier) notifyMsg(id zkidentity.ShortID, fromNick, gcAlias, msg string, mentioned bool) {
+	if !dn.shouldNotify(id, gcAlias != "", mentioned, time.Now()) {
+		return
+	}
+
+	title := fromNick
+	switch {
+	case gcAlias != "" && mentioned:
+		title = fmt.Sprintf("%s mentioned you in %s", fromNick, gcAlias)
+	case gcAlias != "":
+		title = fmt.Sprintf("%s in %s", fromNick, gcAlias)
+	}
+	body := "New message"
+	if dn.cfg.showContent {
+		body = msg
+	}
+	dn.notify(title, body)
+}
+
+// notify shows a notification with the given title and body.
+func (dn *desktopNotifier) notify(title, body string) {
+	title = sanitizeNtfnText(title, ntfnMaxBodyLen)
+	body = sanitizeNtfnText(body, ntfnMaxBodyLen)
+
+	var cmd []string
+	switch dn.cfg.method {
+	case ntfnMethodNone:
+		return
+
+	case ntfnMethodNotifySend:
+		cmd = []string{"notify-send", "-a", appName, "--", title, body}
+
+	case ntfnMethodDBus:
+		cmd = []string{"gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			quoteNtfnString(appName), "0", `""`, quoteNtfnString(title),
+			quoteNtfnString(body), "[]", "{}", "-1"}
+
+	case ntfnMethodOsascript:
+		script := fmt.Sprintf("display notification %s with title %s",
+			quoteNtfnString(body), quoteNtfnString(title))
+		cmd = []string{"osascript", "-e", script}
+
+	default:
+		seq := termNtfnSeq(dn.cfg.method, title, body, dn.inTmux)
+		if _, err := io.WriteString(dn.cfg.term, seq); err != nil {
+			dn.cfg.log.Warnf("Unable to write notification to terminal: %v", err)
+		}
+		return
+	}
+
+	go func() {
+		out, err := exec.Command(cmd[0], cmd[1:]...).CombinedOutput()
+		if err != nil {
+			dn.cfg.log.Warnf("Unable to show notification with %s: %v (%s)",
+				cmd[0], err, strings.TrimSpace(string(out)))
+		}
+	}()
+}
+
+// termNtfnSeq returns the escape sequence that makes the terminal show a
+// notification with the given method. Inside tmux, the sequence is wrapped
+// to be passed through to the outer terminal (which requires the
+// allow-passthrough option of tmux).
+func termNtfnSeq(method ntfnMethod, title, body string, inTmux bool) string {
+	var seq string
+	switch method {
+	case ntfnMethodOSC9:
+		seq = "\x1b]9;" + title + ": " + body + "\a"
+	case ntfnMethodOSC777:
+		title = strings.ReplaceAll(title, ";", ",")
+		seq = "\x1b]777;notify;" + title + ";" + body + "\a"
+	default:
+		return "\a"
+	}
+	if inTmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// sanitizeNtfnText replaces the control chars of the text with spaces and
+// truncates it to maxLen chars.
+func sanitizeNtfnText(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > maxLen {
+		s = string(r[:maxLen-3]) + "..."
+	}
+	return s
+}
+
+// quoteNtfnString quotes the string as a GVariant (used by gdbus) or
+// AppleScript string literal.
+func quoteNtfnString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// sortedNtfnRuleIDs returns the IDs of the rules, sorted.
+func sortedNtfnRuleIDs(rules map[string]ntfnRule) []string {
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
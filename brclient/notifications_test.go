@@ -0,0 +1,88 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+)
+
+// TestNtfnRules tests the rules that decide which msgs generate
+// notifications.
+func TestNtfnRules(t *testing.T) {
+	cfg := desktopNotifierConfig{
+		method:    ntfnMethodBell,
+		pmRule:    ntfnRuleAll,
+		gcRule:    ntfnRuleMentions,
+		stateFile: filepath.Join(t.TempDir(), "notifications.json"),
+		log:       slog.Disabled,
+	}
+	dn, err := newDesktopNotifier(cfg)
+	assert.NilErr(t, err)
+
+	user, gc := zkidentity.ShortID{1}, zkidentity.ShortID{2}
+	now := time.Now()
+	next := func() time.Time {
+		now = now.Add(ntfnConvInterval)
+		return now
+	}
+
+	// Global rules.
+	assert.DeepEqual(t, dn.shouldNotify(user, false, false, next()), true)
+	assert.DeepEqual(t, dn.shouldNotify(gc, true, false, next()), false)
+	assert.DeepEqual(t, dn.shouldNotify(gc, true, true, next()), true)
+
+	// Notifications of a conversation are rate limited.
+	assert.DeepEqual(t, dn.shouldNotify(gc, true, true, now), false)
+
+	// Rules of conversations override the global rules and are
+	// persisted.
+	assert.NilErr(t, dn.setRule(user, ntfnRuleMentions))
+	assert.NilErr(t, dn.setRule(gc, ntfnRuleOff))
+	dn, err = newDesktopNotifier(cfg)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, dn.shouldNotify(user, false, false, next()), false)
+	assert.DeepEqual(t, dn.shouldNotify(user, false, true, next()), true)
+	assert.DeepEqual(t, dn.shouldNotify(gc, true, true, next()), false)
+
+	// Removing the rule of a conversation uses the global rule again.
+	assert.NilErr(t, dn.setRule(gc, ntfnRuleDefault))
+	assert.DeepEqual(t, dn.shouldNotify(gc, true, true, next()), true)
+
+	// No notifications are shown with the none method.
+	cfg.method = ntfnMethodNone
+	dn, err = newDesktopNotifier(cfg)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, dn.shouldNotify(user, false, true, next()), false)
+}
+
+// TestTermNtfnSeq tests the escape sequences of the terminal notification
+// methods.
+func TestTermNtfnSeq(t *testing.T) {
+	tests := []struct {
+		method ntfnMethod
+		inTmux bool
+		want   string
+	}{
+		{method: ntfnMethodBell, want: "\a"},
+		{method: ntfnMethodOSC9, want: "\x1b]9;a;b: c\a"},
+		{method: ntfnMethodOSC777, want: "\x1b]777;notify;a,b;c\a"},
+		{method: ntfnMethodOSC9, inTmux: true,
+			want: "\x1bPtmux;\x1b\x1b]9;a;b: c\a\x1b\\"},
+	}
+
+	for _, tc := range tests {
+		got := termNtfnSeq(tc.method, "a;b", "c", tc.inTmux)
+		assert.DeepEqual(t, got, tc.want)
+	}
+}
+
+// TestSanitizeNtfnText tests sanitizing the text of notifications.
+func TestSanitizeNtfnText(t *testing.T) {
+	assert.DeepEqual(t, sanitizeNtfnText("a\nb\x1b]9;c\a", 100), "a b ]9;c")
+	assert.DeepEqual(t, sanitizeNtfnText("abcdefghij", 8), "abcde...")
+	assert.DeepEqual(t, quoteNtfnString(`say "hi" \o/`), `"say \"hi\" \\o/"`)
+}
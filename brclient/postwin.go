@@ -33,9 +33,7 @@ type comment struct {
 	fromUID   *client.UserID
 	id        clientintf.ID
 	parent    *clientintf.ID
-	children  []*comment
 	depth     int
-	idx       int
 	timestamp int64
 }
 
@@ -48,11 +46,16 @@ type postWindow struct {
 	comments    []*comment
 	myComments  []string
 	hearts      int
+	reactions   []client.PostReaction
 	summ        clientdb.PostSummary
 	author      string
 	relayedBy   string
 	knowsAuthor bool
 
+	// unlockedBody is the body of the post, if it is paywalled and was
+	// unlocked.
+	unlockedBody string
+
 	feedActiveIdx   int
 	feedYOffsetHint int
 
@@ -78,16 +81,10 @@ type postWindow struct {
 	textArea *textAreaModel
 }
 
+// processStatus converts the status update of a comment into a comment.
 func (pw *postWindow) processStatus(status rpc.PostMetadataStatus) *comment {
 	var uid clientintf.UserID
 	var cmt *comment
-	if v, ok := status.Attributes[rpc.RMPSHeart]; ok && v != "" {
-		if v == rpc.RMPSHeartYes {
-			pw.hearts += 1
-		} else {
-			pw.hearts -= 1
-		}
-	}
 	if v, ok := status.Attributes[rpc.RMPSComment]; ok {
 		var fromUID *clientintf.UserID
 		var from string
@@ -154,41 +151,35 @@ func (pw *postWindow) updatePost() {
 
 	pw.author, pw.relayedBy = pw.as.postAuthorRelayer(pw.summ)
 
+	pw.unlockedBody = ""
+	if client.PostPaywall(&pw.post) != nil {
+		unlock, err := pw.as.c.GetPostUnlock(pw.summ.ID)
+		if err == nil && unlock.Completed != nil {
+			pw.unlockedBody = unlock.Body
+		}
+	}
+
 	_, err := pw.as.c.UserByID(pw.summ.AuthorID)
 	pw.knowsAuthor = err == nil
 
 	_, err = pw.as.c.GetKXSearch(pw.summ.AuthorID)
 	pw.kxSearchingAuthor = err == nil
 
-	var roots []*comment
-	cmap := make(map[clientintf.ID]*comment)
-	for i, status := range status {
-		cmt := pw.processStatus(status)
-		if cmt == nil {
-			continue
-		}
-		cmt.idx = i
-
-		// Create tree of comments.
-		cmap[cmt.id] = cmt
-		if cmt.parent == nil {
-			roots = append(roots, cmt)
-			continue
-		}
-
-		pc := cmap[*cmt.parent]
-		if pc == nil {
-			// Comment without knowing parent
-			roots = append(roots, cmt)
-			continue
+	for _, status := range status {
+		if v, ok := status.Attributes[rpc.RMPSHeart]; ok && v != "" {
+			if v == rpc.RMPSHeartYes {
+				pw.hearts += 1
+			} else {
+				pw.hearts -= 1
+			}
 		}
-
-		pc.children = append(pc.children, cmt)
-		cmt.depth = pc.depth + 1
 	}
 
-	// Flatten tree into ordered list of comments.
-	stack := make([]*comment, 0, len(roots))
+	pw.reactions = client.AggregatePostReactions(status)
+
+	// Flatten tree of comments into ordered list of comments.
+	roots := client.BuildPostCommentTree(status)
+	stack := make([]*client.PostComment, 0, len(roots))
 	for i := len(roots) - 1; i >= 0; i-- {
 		stack = append(stack, roots[i])
 	}
@@ -196,9 +187,21 @@ func (pw *postWindow) updatePost() {
 		l := len(stack)
 		el := stack[l-1]
 		stack = stack[:l-1]
-		pw.comments = append(pw.comments, el)
-		for i := len(el.children) - 1; i >= 0; i-- {
-			stack = append(stack, el.children[i])
+		cmt := pw.processStatus(el.Status)
+		cmt.depth = el.Depth
+		switch {
+		case el.Hidden && pw.summ.From == pw.as.c.PublicID():
+			// Own post: keep showing the hidden comment.
+			cmt.comment = "[hidden] " + cmt.comment
+		case el.Hidden:
+			cmt.comment = "(hidden by the post author)"
+		}
+		if el.Flagged {
+			cmt.comment = "[flagged by the post author] " + cmt.comment
+		}
+		pw.comments = append(pw.comments, cmt)
+		for i := len(el.Children) - 1; i >= 0; i-- {
+			stack = append(stack, el.Children[i])
 		}
 	}
 }
@@ -327,6 +330,26 @@ func (pw *postWindow) renderPost() {
 		write(styles.help.Render(pf("Relayed by %s", pw.relayedBy)))
 		write("\n")
 	}
+	if comment := strings.TrimSpace(attr[rpc.RMPRelayComment]); pw.relayedBy != "" && comment != "" {
+		write(styles.nick.Render(pw.relayedBy))
+		write(styles.help.Render(" commented: "))
+		write(strescape.Content(comment))
+		write("\n")
+	}
+
+	if tags := rpc.ParsePostTags(attr[rpc.RMPTags]); len(tags) > 0 {
+		write(styles.help.Render(pf("Tags: #%s", strings.Join(tags, " #"))))
+		write("\n")
+	}
+
+	if len(pw.reactions) > 0 {
+		reactions := make([]string, len(pw.reactions))
+		for i, r := range pw.reactions {
+			reactions[i] = pf("%s %d", strescape.Content(r.Reaction), len(r.Users))
+		}
+		write(styles.help.Render(pf("Reactions: %s", strings.Join(reactions, "  "))))
+		write("\n")
+	}
 
 	write(styles.help.Render("Received "))
 	write(styles.timestampHelp.Render(date))
@@ -337,6 +360,12 @@ func (pw *postWindow) renderPost() {
 	if content == "" {
 		content = " (empty content) "
 	}
+	if link := client.PostPaywall(&pw.post); link != nil && pw.unlockedBody != "" {
+		content += "\n\n" + strings.TrimSpace(pw.unlockedBody)
+	} else if link != nil {
+		content += pf("\n\nUnlock the full post for %s with /post unlock",
+			dcrutil.Amount(link.Cost))
+	}
 	content = strescape.Content(content)
 
 	// Replace embedded data tags.
@@ -450,7 +479,9 @@ func (pw *postWindow) renderPost() {
 		write(fmt.Sprintf("  /post get %s %s\n", nick, pw.summ.ID))
 
 	} else {
-		write(styles.help.Render("═════ Comments ══════════ (R)eply, (C)omment, (S+I) Req. Invite, F4 Recv Receipts "))
+		km := pw.as.keymap.Load()
+		write(styles.help.Render(fmt.Sprintf("═════ Comments ══════════ (%s) Reply, (%s) Comment, (%s) Req. Invite, (%s) Recv Receipts ",
+			km.help(keyPostReply), km.help(keyPostComment), km.help(keyPostInvite), km.help(keyPostReceipts))))
 		write(styles.help.Render(strings.Repeat("═", pw.as.winW-15)))
 		write("\n\n")
 		pw.startCommentsLine = lineCount
@@ -727,17 +758,30 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		pw.renderPost()
 
 	case tea.KeyMsg:
+		km := pw.as.keymap.Load()
+
+		// Rewrite the up and down keys as the keys of the viewport.
+		// While typing, keys are sent unchanged to the text area.
+		var upDown bool
+		typing := (pw.commenting || pw.relaying) && !pw.confirmingComment
+		switch {
+		case !typing && km.is(msg, keyPostUp):
+			msg, upDown = tea.KeyMsg{Type: tea.KeyUp}, true
+		case !typing && km.is(msg, keyPostDown):
+			msg, upDown = tea.KeyMsg{Type: tea.KeyDown}, true
+		}
+
 		switch {
-		case pw.confirmingComment && (msg.Type == tea.KeyUp || msg.Type == tea.KeyDown):
+		case pw.confirmingComment && upDown:
 			pw.viewport, cmd = pw.viewport.Update(msg)
 			cmds = appendCmd(cmds, cmd)
 
-		case pw.confirmingComment && msg.Type == tea.KeyEsc:
+		case pw.confirmingComment && km.is(msg, keyPostBack):
 			pw.confirmingComment = false
 			pw.renderPost()
 			pw.recalcViewportSize()
 
-		case pw.confirmingComment && msg.Type == tea.KeyEnter:
+		case pw.confirmingComment && km.is(msg, keyPostSubmit):
 			var parent *clientintf.ID
 			if pw.replying && pw.selComment < len(pw.comments) {
 				selComment := pw.comments[pw.selComment]
@@ -755,21 +799,21 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case pw.confirmingComment:
 			// Ignore all other msgs when confirming comment.
 
-		case pw.showingRR && msg.Type == tea.KeyF4,
-			pw.showingRR && msg.Type == tea.KeyEsc:
+		case pw.showingRR && km.is(msg, keyPostReceipts),
+			pw.showingRR && km.is(msg, keyPostBack):
 			pw.showingRR = false
 			pw.recalcViewportSize()
 			pw.renderPost()
 			return pw, cmd
 
-		case pw.showingRR && (msg.Type == tea.KeyUp || msg.Type == tea.KeyDown):
+		case pw.showingRR && upDown:
 			pw.viewport, cmd = pw.viewport.Update(msg)
 			cmds = appendCmd(cmds, cmd)
 
 		case pw.showingRR:
 			// Ignore all other msgs when showing receive receipts.
 
-		case msg.Type == tea.KeyEsc:
+		case km.is(msg, keyPostBack):
 			pw.cmdErr = ""
 			if pw.commenting {
 				pw.commenting = false
@@ -787,11 +831,7 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					pw.feedYOffsetHint)
 			}
 
-		case (pw.commenting || pw.relaying) && (msg.Type == tea.KeyUp || msg.Type == tea.KeyDown):
-			pw.textArea, cmd = pw.textArea.Update(msg)
-			cmds = appendCmd(cmds, cmd)
-
-		case msg.Type == tea.KeyUp, msg.Type == tea.KeyDown:
+		case upDown:
 			// If switching a comment, then select the next/previous
 			// comment and scroll to make it visible (instead of
 			// scrolling line by line).
@@ -812,33 +852,39 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			pw.viewport, cmd = pw.viewport.Update(msg)
 			return pw, cmd
 
-		case msg.Type == tea.KeyPgUp, msg.Type == tea.KeyPgDown:
-			// Rewrite when alt is pressed to scroll a single line.
-			if msg.Type == tea.KeyPgUp && msg.Alt {
-				msg.Type = tea.KeyUp
-				msg.Alt = false
-			} else if msg.Type == tea.KeyPgDown && msg.Alt {
-				msg.Type = tea.KeyDown
-				msg.Alt = false
+		case km.is(msg, keyPostPageUp), km.is(msg, keyPostPageDown),
+			km.is(msg, keyPostLineUp), km.is(msg, keyPostLineDown):
+
+			// Rewrite as the keys of the viewport.
+			var scrollMsg tea.KeyMsg
+			switch {
+			case km.is(msg, keyPostPageUp):
+				scrollMsg.Type = tea.KeyPgUp
+			case km.is(msg, keyPostPageDown):
+				scrollMsg.Type = tea.KeyPgDown
+			case km.is(msg, keyPostLineUp):
+				scrollMsg.Type = tea.KeyUp
+			default:
+				scrollMsg.Type = tea.KeyDown
 			}
 
 			// send to viewport
-			pw.viewport, cmd = pw.viewport.Update(msg)
+			pw.viewport, cmd = pw.viewport.Update(scrollMsg)
 
 			pw.selectVisibleComment()
 
 			return pw, cmd
 
-		case !pw.commenting && !pw.relaying && (msg.Type == tea.KeyLeft || msg.Type == tea.KeyRight):
+		case !pw.commenting && !pw.relaying && (km.is(msg, keyPostPrevEmbed) || km.is(msg, keyPostNextEmbed)):
 			embedCount := len(pw.embeds)
-			if msg.Type == tea.KeyLeft {
+			if km.is(msg, keyPostPrevEmbed) {
 				pw.selEmbed = clamp(pw.selEmbed-1, 0, embedCount)
 			} else {
 				pw.selEmbed = clamp(pw.selEmbed+1, 0, embedCount)
 			}
 			pw.renderPost()
 
-		case msg.Type == tea.KeyCtrlD:
+		case km.is(msg, keyPostDownload):
 			if len(pw.embeds) > 0 && len(pw.embeds) > pw.selEmbed {
 				embedded := pw.embeds[pw.selEmbed]
 				uid, err := pw.as.c.UIDByNick(pw.author)
@@ -854,7 +900,7 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-		case msg.Type == tea.KeyCtrlV:
+		case km.is(msg, keyPostView):
 			if len(pw.embeds) > 0 && len(pw.embeds) > pw.selEmbed {
 				embedded := pw.embeds[pw.selEmbed]
 				cmd, err := pw.as.viewEmbed(embedded)
@@ -865,19 +911,13 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return pw, cmd
 			}
 
-		case msg.String() == "alt+\r" && pw.commenting:
-			// Alt+enter on new bubbletea version.
-			msg.Type = tea.KeyEnter
-			fallthrough
-
-		case msg.Alt && msg.Type == tea.KeyEnter && pw.commenting:
+		case km.is(msg, keyPostNewLine) && pw.commenting:
 			// Add a new line to comment.
-			msg.Alt = false
-			pw.textArea, cmd = pw.textArea.Update(msg)
+			pw.textArea, cmd = pw.textArea.Update(tea.KeyMsg{Type: tea.KeyEnter})
 			cmds = appendCmd(cmds, cmd)
 			pw.recalcViewportSize()
 
-		case msg.Type == tea.KeyEnter && pw.commenting:
+		case km.is(msg, keyPostSubmit) && pw.commenting:
 			pw.confirmingComment = true
 			var b strings.Builder
 			b.WriteString("Really send the following comment\n\n")
@@ -887,11 +927,12 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			b.WriteString("\n")
 			b.WriteString(strings.Repeat("-", pw.as.winW))
 			b.WriteString("\n\n")
-			b.WriteString("Press <ENTER> to submit, <ESC> to cancel\n")
+			fmt.Fprintf(&b, "Press <%s> to submit, <%s> to cancel\n",
+				km.help(keyPostSubmit), km.help(keyPostBack))
 			pw.viewport.SetContent(b.String())
 			pw.recalcViewportSize()
 
-		case msg.Type == tea.KeyEnter && pw.relaying:
+		case km.is(msg, keyPostSubmit) && pw.relaying:
 			pw.relaying = false
 			text := pw.textArea.Value()
 
@@ -912,8 +953,8 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return pw, cmd
 
-		case msg.String() == "c", msg.String() == "r":
-			replying := msg.String() == "r"
+		case km.is(msg, keyPostComment), km.is(msg, keyPostReply):
+			replying := km.is(msg, keyPostReply)
 			if pw.as.externalEditorForComments.Load() {
 				var parent *zkidentity.ShortID
 				if replying && pw.selComment < len(pw.comments) {
@@ -945,17 +986,17 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return pw, cmd
 			}
 
-		case msg.String() == "I":
+		case km.is(msg, keyPostInvite):
 			pw.debug = ""
 			pw.requestTransInvite()
 			return pw, cmd
 
-		case msg.String() == "S":
+		case km.is(msg, keyPostKXSearch):
 			pw.debug = ""
 			pw.kxSearchAuthor()
 			return pw, cmd
 
-		case msg.String() == "G":
+		case km.is(msg, keyPostFetch):
 			pw.debug = fmt.Sprintf("XXX %v %v", pw.knowsAuthor, pw.postRequested)
 			if pw.knowsAuthor {
 				go pw.as.subscribeAndFetchPost(pw.summ.AuthorID, pw.summ.ID)
@@ -964,13 +1005,13 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return pw, cmd
 
-		case msg.String() == "R":
+		case km.is(msg, keyPostRelayAll):
 			pw.debug = ""
 			go pw.as.relayPostToAll(pw.summ.From, pw.summ.ID)
 			pw.debug = "Relaying post to subscribers"
 			return pw, cmd
 
-		case msg.String() == "U":
+		case km.is(msg, keyPostRelayUser):
 			pw.debug = ""
 			pw.textArea.SetValue("")
 			pw.cmdErr = ""
@@ -979,7 +1020,7 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmd = pw.textArea.Focus()
 			return pw, cmd
 
-		case msg.Type == tea.KeyF4:
+		case km.is(msg, keyPostReceipts):
 			pw.showingRR = true
 			pw.renderReceiveReceipts()
 			return pw, cmd
@@ -1001,6 +1042,12 @@ func (pw postWindow) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			pw.viewport.GotoBottom()
 		}
 
+	case postUnlocked:
+		if msg.pid == pw.summ.ID {
+			pw.updatePost()
+			pw.renderPost()
+		}
+
 	case sentPostComment:
 		pw.as.postsMtx.Lock()
 		pw.myComments = pw.as.myComments
@@ -1081,8 +1128,11 @@ done:
 }
 
 func (pw postWindow) headerView(styles *theme) string {
-	msg := " Post - ESC to return, " +
-		"(S+R) Relay Post, (S+S) KX Search Author, (S+U) Relay to User, (Ctrl+D) Download, (Ctrl+V) View File"
+	km := pw.as.keymap.Load()
+	msg := fmt.Sprintf(" Post - %s to return, "+
+		"(%s) Relay Post, (%s) KX Search Author, (%s) Relay to User, (%s) Download, (%s) View File",
+		km.help(keyPostBack), km.help(keyPostRelayAll), km.help(keyPostKXSearch),
+		km.help(keyPostRelayUser), km.help(keyPostDownload), km.help(keyPostView))
 	headerMsg := styles.header.Render(msg)
 	spaces := styles.header.Render(strings.Repeat(" ",
 		max(0, pw.as.winW-lipgloss.Width(headerMsg))))
@@ -40,6 +40,7 @@ type setupWizardScreen struct {
 	err        error
 	crashStack []byte
 	styles     *theme
+	keymap     *keymap
 
 	selNetwork      *selection.Model[string]
 	selWalletType   *selection.Model[string]
@@ -233,13 +234,13 @@ func (sws *setupWizardScreen) generateConfig() error {
 }
 
 func (sws setupWizardScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if isCrashMsg(msg) {
+	if isCrashMsg(sws.keymap, msg) {
 		sws.crashStack = allStack()
 		sws.err = fmt.Errorf("crashing app")
 		sws.connCancel()
 		return sws, tea.Quit
 	}
-	if err := isQuitMsg(msg); err != nil {
+	if err := isQuitMsg(sws.keymap, msg); err != nil {
 		sws.connCancel()
 		return sws, tea.Quit
 	}
@@ -633,6 +634,7 @@ func newSetupWizardScreen(cfgFilePath string) setupWizardScreen {
 		cfgFilePath: cfgFilePath,
 		stage:       swsStageNetwork,
 		styles:      theme,
+		keymap:      defaultKeymap(),
 
 		selNetwork:      selection.NewModel(selNetwork),
 		selWalletType:   selection.NewModel(selWalletType),
@@ -47,8 +47,9 @@ func (ss shutdownState) View() string {
 }
 
 func maybeShutdown(as *appState, msg tea.Msg) (tea.Model, tea.Cmd) {
-	crash := isCrashMsg(msg)
-	if err := isQuitMsg(msg); err != nil || crash {
+	km := as.keymap.Load()
+	crash := isCrashMsg(km, msg)
+	if err := isQuitMsg(km, msg); err != nil || crash {
 		if crash {
 			as.storeCrash()
 		}
@@ -1,7 +1,14 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -33,6 +40,8 @@ type theme struct {
 	mention        lipgloss.Style
 	embed          lipgloss.Style
 
+	// name is the name of the theme.
+	name  string
 	blink bool
 }
 
@@ -42,6 +51,13 @@ func textToColor(in string) (lipgloss.Color, error) {
 		return lipgloss.Color(in), nil
 	}
 
+	if n, err := strconv.Atoi(in); err == nil {
+		if n < 0 || n > 255 {
+			return c, fmt.Errorf("invalid color: %v", in)
+		}
+		return lipgloss.Color(in), nil
+	}
+
 	switch strings.ToLower(in) {
 	case "na":
 	case "black":
@@ -96,109 +112,278 @@ func colorDefnToLGStyle(color string) (lipgloss.Style, error) {
 		// return style, err
 		return style, fmt.Errorf("invalid foreground color: %v", err)
 	}
-	style = style.Foreground(fg)
+	if fg != "" {
+		style = style.Foreground(fg)
+	}
 
 	bg, err := textToColor(s[2])
 	if err != nil {
 		return style, fmt.Errorf("invalid background color: %v", err)
 	}
-	style = style.Background(bg)
+	if bg != "" {
+		style = style.Background(bg)
+	}
 
 	return style, nil
 }
 
-func newTheme(args *config) (*theme, error) {
-	var nickMe, nick, nickGC lipgloss.Style
-	var blink bool
-	var err error
+// themeStyles returns the styles of the theme that may be set in theme
+// files, keyed by name.
+func (t *theme) themeStyles() map[string]*lipgloss.Style {
+	return map[string]*lipgloss.Style{
+		"header":         &t.header,
+		"footer":         &t.footer,
+		"footermention":  &t.footerMention,
+		"edit":           &t.edit,
+		"focused":        &t.focused,
+		"blurred":        &t.blurred,
+		"cursor":         &t.cursor,
+		"help":           &t.help,
+		"cursormodehelp": &t.cursorModeHelp,
+		"timestamp":      &t.timestamp,
+		"timestamphelp":  &t.timestampHelp,
+		"nick":           &t.nick,
+		"nickme":         &t.nickMe,
+		"nickgc":         &t.nickGC,
+		"unreadpost":     &t.unreadPost,
+		"msg":            &t.msg,
+		"unsent":         &t.unsent,
+		"online":         &t.online,
+		"offline":        &t.offline,
+		"checkingwallet": &t.checkingWallet,
+		"err":            &t.err,
+		"mention":        &t.mention,
+		"embed":          &t.embed,
+	}
+}
 
-	if args != nil {
-		nickMe, err = colorDefnToLGStyle(args.NickColor)
-		if err != nil {
-			return nil, err
+// defaultThemeName is the name of the theme used when none is configured.
+const defaultThemeName = "dark"
+
+// themeFileExt is the extension of the theme files in the themes dir.
+const themeFileExt = ".theme"
+
+// builtinThemes are the themes that do not need a theme file. Styles that are
+// not defined in a theme use the style of the default theme.
+var builtinThemes = map[string]map[string]string{
+	"dark": {
+		"header":         "na:#FAFAFA:#000044",
+		"footer":         "na:#FAFAFA:#000044",
+		"footermention":  "bold:5:#000044",
+		"edit":           "na:#aaaaaa:#000000",
+		"focused":        "na:205:na",
+		"blurred":        "na:240:na",
+		"cursor":         "na:205:na",
+		"help":           "na:240:na",
+		"cursormodehelp": "na:244:na",
+		"timestamp":      "na:#a1ba22:na",
+		"timestamphelp":  "na:#6b6b6b:na",
+		"nick":           "bold:cyan:na",
+		"nickme":         "bold:white:na",
+		"nickgc":         "bold:green:na",
+		"unreadpost":     "na:cyan:na",
+		"msg":            "na:na:na",
+		"unsent":         "na:240:na",
+		"online":         "na:154:na",
+		"offline":        "na:160:na",
+		"checkingwallet": "na:214:na",
+		"err":            "bold:160:na",
+		"mention":        "bold:magenta:na",
+		"embed":          "na:27:na",
+	},
+	"light": {
+		"header":         "na:#000000:#d0d0e8",
+		"footer":         "na:#000000:#d0d0e8",
+		"footermention":  "bold:#8700af:#d0d0e8",
+		"edit":           "na:#1c1c1c:#eeeeee",
+		"focused":        "na:#af005f:na",
+		"blurred":        "na:#808080:na",
+		"cursor":         "na:#af005f:na",
+		"help":           "na:#626262:na",
+		"cursormodehelp": "na:#585858:na",
+		"timestamp":      "na:#5f8700:na",
+		"timestamphelp":  "na:#8a8a8a:na",
+		"nick":           "bold:#005f87:na",
+		"nickme":         "bold:#1c1c1c:na",
+		"nickgc":         "bold:#005f00:na",
+		"unreadpost":     "na:#005f87:na",
+		"msg":            "na:na:na",
+		"unsent":         "na:#9e9e9e:na",
+		"online":         "na:#008700:na",
+		"offline":        "na:#af0000:na",
+		"checkingwallet": "na:#af5f00:na",
+		"err":            "bold:#af0000:na",
+		"mention":        "bold:#8700af:na",
+		"embed":          "na:#0000af:na",
+	},
+	"high-contrast": {
+		"header":         "bold:black:white",
+		"footer":         "bold:black:white",
+		"footermention":  "bold,reverse:magenta:na",
+		"edit":           "na:white:black",
+		"focused":        "bold,underline:yellow:na",
+		"blurred":        "na:white:na",
+		"cursor":         "reverse:yellow:na",
+		"help":           "na:white:na",
+		"cursormodehelp": "bold:white:na",
+		"timestamp":      "na:yellow:na",
+		"timestamphelp":  "na:white:na",
+		"nick":           "bold:cyan:na",
+		"nickme":         "bold,underline:white:na",
+		"nickgc":         "bold:green:na",
+		"unreadpost":     "bold:cyan:na",
+		"msg":            "na:white:na",
+		"unsent":         "underline:white:na",
+		"online":         "bold:green:na",
+		"offline":        "bold:red:na",
+		"checkingwallet": "bold:yellow:na",
+		"err":            "bold,reverse:red:na",
+		"mention":        "bold,reverse:magenta:na",
+		"embed":          "bold,underline:blue:na",
+	},
+}
+
+// parseThemeFile parses a theme file. Each line of a theme file defines the
+// style of one element of the UI as "<name> = <attribute>:<fg>:<bg>". Empty
+// lines and lines starting with '#' or ';' are ignored.
+func parseThemeFile(r io.Reader) (map[string]string, error) {
+	known := (&theme{}).themeStyles()
+	defs := make(map[string]string)
+	scan := bufio.NewScanner(r)
+	for i := 1; scan.Scan(); i++ {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
 		}
-		nick, err = colorDefnToLGStyle(args.PMOtherColor)
-		if err != nil {
-			return nil, err
+		name, defn, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected <name> = <style>", i)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("line %d: unknown style %q", i, name)
+		}
+		defs[name] = strings.TrimSpace(defn)
+	}
+	return defs, scan.Err()
+}
+
+// loadThemeDefns returns the style definitions of the named theme. The name is
+// either the name of a built-in theme, the name of a theme file (without the
+// extension) in themesDir or the path to a theme file.
+func loadThemeDefns(name, themesDir string) (map[string]string, error) {
+	if defs, ok := builtinThemes[name]; ok {
+		return defs, nil
+	}
+
+	fname := name
+	if themesDir != "" && !strings.ContainsRune(name, filepath.Separator) {
+		fname = filepath.Join(themesDir, name+themeFileExt)
+	}
+	f, err := os.Open(fname)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("theme %q not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defs, err := parseThemeFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme file %s: %v", fname, err)
+	}
+	return defs, nil
+}
+
+// listThemes returns the names of the built-in themes and of the theme files
+// in themesDir.
+func listThemes(themesDir string) []string {
+	var names []string
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	entries, _ := os.ReadDir(themesDir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != themeFileExt {
+			continue
+		}
+		name = strings.TrimSuffix(name, themeFileExt)
+		if _, ok := builtinThemes[name]; !ok {
+			names = append(names, name)
 		}
-		nickGC, err = colorDefnToLGStyle(args.GCOtherColor)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildTheme creates a theme with the given style definitions. Styles that are
+// not defined use the style of the default theme.
+func buildTheme(name string, defs map[string]string) (*theme, error) {
+	t := &theme{name: name, noStyle: lipgloss.NewStyle(), blink: true}
+	for key, style := range t.themeStyles() {
+		defn, ok := defs[key]
+		if !ok {
+			defn = builtinThemes[defaultThemeName][key]
+		}
+		var err error
+		*style, err = colorDefnToLGStyle(defn)
 		if err != nil {
+			return nil, fmt.Errorf("invalid style %q of theme %q: %v",
+				key, name, err)
+		}
+	}
+	return t, nil
+}
+
+// newThemeNamed creates the named theme (see loadThemeDefns), applying the
+// style overrides of the config.
+func newThemeNamed(args *config, name string) (*theme, error) {
+	themesDir := ""
+	if args != nil {
+		themesDir = args.ThemesDir
+	}
+	defs, err := loadThemeDefns(name, themesDir)
+	if err != nil {
+		return nil, err
+	}
+	t, err := buildTheme(name, defs)
+	if err != nil {
+		return nil, err
+	}
+	if args == nil {
+		return t, nil
+	}
+
+	// Nick colors may be overridden in the config file.
+	overrides := []struct {
+		defn  string
+		style *lipgloss.Style
+	}{
+		{args.NickColor, &t.nickMe},
+		{args.PMOtherColor, &t.nick},
+		{args.GCOtherColor, &t.nickGC},
+	}
+	for _, o := range overrides {
+		if o.defn == "" {
+			continue
+		}
+		if *o.style, err = colorDefnToLGStyle(o.defn); err != nil {
 			return nil, err
 		}
-		blink = args.BlinkCursor
-	} else {
-		nickMe = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("7"))
-		nick = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("6"))
-		nickGC = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("2"))
-		blink = true
-	}
-
-	return &theme{
-		// Theme
-		header: lipgloss.NewStyle().
-			Bold(false).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#000044")),
-
-		footer: lipgloss.NewStyle().
-			Bold(false).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#000044")),
-		footerMention: lipgloss.NewStyle().
-			Background(lipgloss.Color("#000044")).
-			Foreground(lipgloss.Color("5")).Bold(true),
-
-		edit: lipgloss.NewStyle().
-			Bold(false).
-			Foreground(lipgloss.Color("#aaaaaa")).
-			Background(lipgloss.Color("#000000")),
-
-		timestamp: lipgloss.NewStyle().
-			Bold(false).
-			Foreground(lipgloss.Color("#a1ba22")),
-		timestampHelp: lipgloss.NewStyle().
-			Bold(false).
-			Foreground(lipgloss.Color("#6b6b6b")),
-		unreadPost: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("6")),
-
-		/*
-			nick: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#22a1ba")),
-			nickMe: lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#22ba3b")),
-		*/
-		nick:   nick,
-		nickMe: nickMe,
-		nickGC: nickGC,
-		mention: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("5")).Bold(true),
-
-		msg:    lipgloss.NewStyle(),
-		unsent: lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
-
-		online:         lipgloss.NewStyle().Foreground(lipgloss.Color("154")),
-		offline:        lipgloss.NewStyle().Foreground(lipgloss.Color("160")),
-		checkingWallet: lipgloss.NewStyle().Foreground(lipgloss.Color("214")),
-
-		err: lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Bold(true),
-
-		focused:        lipgloss.NewStyle().Foreground(lipgloss.Color("205")),
-		blurred:        lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
-		cursor:         lipgloss.NewStyle().Foreground(lipgloss.Color("205")),
-		noStyle:        lipgloss.NewStyle(),
-		help:           lipgloss.NewStyle().Foreground(lipgloss.Color("240")),
-		cursorModeHelp: lipgloss.NewStyle().Foreground(lipgloss.Color("244")),
-		embed:          lipgloss.NewStyle().Foreground(lipgloss.Color("27")),
-
-		blink: blink,
-	}, nil
+	}
+	t.blink = args.BlinkCursor
+	return t, nil
+}
+
+// newTheme creates the theme configured in the config. The default theme is
+// used when the config is nil.
+func newTheme(args *config) (*theme, error) {
+	name := defaultThemeName
+	if args != nil && args.Theme != "" {
+		name = args.Theme
+	}
+	return newThemeNamed(args, name)
 }
 
 // renderPF captures `style` and returns a new printf-like function that uses
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/companyzero/bisonrelay/internal/assert"
@@ -11,3 +12,43 @@ func TestNilConfigTheme(t *testing.T) {
 	_, err := newTheme(nil)
 	assert.NilErr(t, err)
 }
+
+// TestBuiltinThemes tests that the built-in themes define every style with
+// valid definitions.
+func TestBuiltinThemes(t *testing.T) {
+	known := (&theme{}).themeStyles()
+	for name, defs := range builtinThemes {
+		for key := range known {
+			if _, ok := defs[key]; !ok {
+				t.Fatalf("theme %s does not define style %s", name, key)
+			}
+		}
+		_, err := buildTheme(name, defs)
+		assert.NilErr(t, err)
+	}
+}
+
+// TestParseThemeFile tests parsing theme files.
+func TestParseThemeFile(t *testing.T) {
+	defs, err := parseThemeFile(strings.NewReader(`
+# Comment
+; Another comment
+Header = bold:black:white
+nick=na:#005f87:na
+`))
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, defs, map[string]string{
+		"header": "bold:black:white",
+		"nick":   "na:#005f87:na",
+	})
+	_, err = buildTheme("test", defs)
+	assert.NilErr(t, err)
+
+	_, err = parseThemeFile(strings.NewReader("unknown = na:na:na"))
+	assert.NonNilErr(t, err)
+	_, err = parseThemeFile(strings.NewReader("header"))
+	assert.NonNilErr(t, err)
+
+	_, err = buildTheme("test", map[string]string{"header": "na:256:na"})
+	assert.NonNilErr(t, err)
+}
@@ -50,6 +50,9 @@ func (ulns unlockLNScreen) Init() tea.Cmd {
 			TorAddr:      ulns.cfg.ProxyAddr,
 			TorIsolation: ulns.cfg.TorIsolation,
 			SyncFreeList: ulns.cfg.SyncFreeList,
+
+			AcceptKeysend:    ulns.cfg.AcceptKeysend,
+			WatchtowerClient: ulns.cfg.WatchtowerClient,
 		}
 
 		cmd := func() tea.Msg {
@@ -74,13 +77,13 @@ func (ulns *unlockLNScreen) runNotifier() {
 }
 
 func (ulns unlockLNScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if isCrashMsg(msg) {
+	if isCrashMsg(ulns.cfg.Keymap, msg) {
 		ulns.crashStack = allStack()
 		ulns.err = fmt.Errorf("crashing app")
 		ulns.connCancel()
 		return ulns, tea.Quit
 	}
-	if err := isQuitMsg(msg); err != nil {
+	if err := isQuitMsg(ulns.cfg.Keymap, msg); err != nil {
 		ulns.err = fmt.Errorf("user canceled unlocking")
 		ulns.connCancel()
 		return ulns, tea.Quit
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/maphash"
+	"math"
 	"net"
 	"runtime"
 	"strconv"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/companyzero/bisonrelay/internal/strescape"
+	"github.com/companyzero/bisonrelay/rpc"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/dcrlnd/lnrpc"
@@ -385,3 +388,52 @@ func payReqStrAmount(payReq *zpay32.Invoice) string {
 
 	return dcrutil.Amount(*payReq.MilliAt / 1000).String()
 }
+
+// parseScheduleTime parses the time to schedule an action. The time may be
+// either a duration relative to now (e.g. "2h30m") or a local date and time in
+// one of the ISO 8601 formats.
+func parseScheduleTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	for _, layout := range []string{ISO8601DateTime, "2006-01-02 15:04", ISO8601Date} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: must be a duration "+
+		"or a date in the format %q", s, ISO8601DateTime)
+}
+
+// parseTipAmount parses the amount of a tip. Amounts are in DCR, unless
+// prefixed with '$' or suffixed with "usd" (e.g. "$5" or "5usd"), in which
+// case they are in USD. Only one of the returned amounts is set.
+func parseTipAmount(s string) (dcrAmount, usdAmount float64, err error) {
+	lower := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(lower, "$"):
+		usdAmount, err = strconv.ParseFloat(lower[1:], 64)
+	case strings.HasSuffix(lower, "usd"):
+		usdAmount, err = strconv.ParseFloat(lower[:len(lower)-3], 64)
+	default:
+		dcrAmount, err = strconv.ParseFloat(strings.TrimSuffix(lower, "dcr"), 64)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if dcrAmount < 0 || usdAmount < 0 || math.IsNaN(dcrAmount+usdAmount) {
+		return 0, 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return dcrAmount, usdAmount, nil
+}
+
+// fiatConversionStr returns a description of the conversion of a
+// fiat-denominated payment, to be appended to its DCR amount.
+func fiatConversionStr(fiat *rpc.RMFiatConversion) string {
+	if fiat == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%.2f %s at %.4f %s/DCR)",
+		fiat.Amount, strescape.Content(fiat.Currency), fiat.Rate,
+		strescape.Content(fiat.Currency))
+}
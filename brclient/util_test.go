@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 )
 
 func TestStringsCommonPrefix(t *testing.T) {
@@ -42,3 +43,79 @@ func TestStringsCommonPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestParseScheduleTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{{
+		s:    "2h30m",
+		want: now.Add(150 * time.Minute),
+	}, {
+		s:    "2024-03-11 08:15:30",
+		want: time.Date(2024, 3, 11, 8, 15, 30, 0, time.UTC),
+	}, {
+		s:    "2024-03-11 08:15",
+		want: time.Date(2024, 3, 11, 8, 15, 0, 0, time.UTC),
+	}, {
+		s:    "2024-03-12",
+		want: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC),
+	}, {
+		s:       "tomorrow",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		got, err := parseScheduleTime(tc.s, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error", tc.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.s, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("%q: unexpected time: got %s, want %s", tc.s,
+				got, tc.want)
+		}
+	}
+}
+
+func TestParseTipAmount(t *testing.T) {
+	tests := []struct {
+		s       string
+		dcr     float64
+		usd     float64
+		wantErr bool
+	}{
+		{s: "0.5", dcr: 0.5},
+		{s: "0.5dcr", dcr: 0.5},
+		{s: "$5", usd: 5},
+		{s: "5usd", usd: 5},
+		{s: "2.5USD", usd: 2.5},
+		{s: "usd", wantErr: true},
+		{s: "-1", wantErr: true},
+		{s: "five", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		dcr, usd, err := parseTipAmount(tc.s)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error", tc.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.s, err)
+		}
+		if dcr != tc.dcr || usd != tc.usd {
+			t.Fatalf("%q: unexpected amounts: got %f DCR %f USD, "+
+				"want %f DCR %f USD", tc.s, dcr, usd, tc.dcr, tc.usd)
+		}
+	}
+}
@@ -0,0 +1,583 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/exp/slices"
+)
+
+// vimMode is the editing mode of the main window when the vim mode is
+// enabled.
+type vimMode int
+
+const (
+	vimModeInsert vimMode = iota
+	vimModeNormal
+	vimModeSearch
+)
+
+const (
+	// vimUnnamedReg is the register used when no register is selected.
+	// Every yank and delete also stores the text in it.
+	vimUnnamedReg = '"'
+
+	// vimClipboardReg is the register backed by the system clipboard.
+	vimClipboardReg = '+'
+)
+
+// vimRegister is the content of a register.
+type vimRegister struct {
+	text string
+
+	// linewise is true when the text was yanked as whole lines, in which
+	// case it is put in new lines.
+	linewise bool
+}
+
+// isValidVimReg returns true if r is the name of a register.
+func isValidVimReg(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+		r == vimUnnamedReg || r == vimClipboardReg
+}
+
+// vimCmd is a cmd typed in the normal mode.
+type vimCmd struct {
+	count int  // 0 when no count was typed.
+	reg   rune // 0 when no register was selected.
+	cmd   string
+}
+
+// vimCmds are the cmds of the normal mode. vimCmdPrefixes are the first keys
+// of multi key cmds.
+var (
+	vimCmds = map[string]bool{
+		"h": true, "l": true, " ": true, "0": true, "^": true, "$": true,
+		"w": true, "e": true, "b": true,
+		"i": true, "a": true, "I": true, "A": true, "o": true, "O": true,
+		"x": true, "X": true, "D": true, "C": true, "S": true, "Y": true,
+		"p": true, "P": true,
+		"j": true, "k": true, "G": true,
+		"/": true, "?": true, "n": true, "N": true, ":": true,
+		"dd": true, "cc": true, "yy": true,
+		"gg": true, "gt": true, "gT": true,
+	}
+	vimCmdPrefixes = map[string]bool{"d": true, "c": true, "y": true, "g": true}
+)
+
+// parseVimCmd parses the keys typed in the normal mode. It returns false if
+// more keys are needed to complete the cmd.
+func parseVimCmd(keys string) (vimCmd, bool, error) {
+	var c vimCmd
+	rest := []rune(keys)
+
+	// Register selection.
+	if len(rest) > 0 && rest[0] == '"' {
+		if len(rest) < 2 {
+			return c, false, nil
+		}
+		if !isValidVimReg(rest[1]) {
+			return c, false, fmt.Errorf("invalid register %q", rest[1])
+		}
+		c.reg = rest[1]
+		rest = rest[2:]
+	}
+
+	// Count. A leading 0 is the cmd to go to the start of the line.
+	var i int
+	for i < len(rest) && unicode.IsDigit(rest[i]) && (i > 0 || rest[i] != '0') {
+		i++
+	}
+	if i > 0 {
+		var err error
+		c.count, err = strconv.Atoi(string(rest[:i]))
+		if err != nil {
+			return c, false, fmt.Errorf("invalid count: %v", err)
+		}
+		rest = rest[i:]
+	}
+
+	c.cmd = string(rest)
+	switch {
+	case c.cmd == "" || vimCmdPrefixes[c.cmd]:
+		return c, false, nil
+	case vimCmds[c.cmd]:
+		return c, true, nil
+	default:
+		return c, false, fmt.Errorf("unknown cmd %q", keys)
+	}
+}
+
+// vimSearchLines returns the index of the next line (starting after from and
+// wrapping around) that contains pattern, or -1 if no line matches. The
+// search is case insensitive unless the pattern has upper case chars. Style
+// escape sequences are ignored.
+func vimSearchLines(lines []string, pattern string, from int, backward bool) int {
+	if pattern == "" || len(lines) == 0 {
+		return -1
+	}
+	ignoreCase := strings.ToLower(pattern) == pattern
+	if ignoreCase {
+		pattern = strings.ToLower(pattern)
+	}
+
+	step := 1
+	if backward {
+		step = -1
+	}
+	n := len(lines)
+	for i := 1; i <= n; i++ {
+		idx := ((from+i*step)%n + n) % n
+		line := ansiEscapeRe.ReplaceAllString(lines[idx], "")
+		if ignoreCase {
+			line = strings.ToLower(line)
+		}
+		if strings.Contains(line, pattern) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// vimState is the state of the vim mode of the main window. It is kept in the
+// appState so that the mode and the registers are preserved when switching
+// to other windows.
+type vimState struct {
+	// The following fields are only accessed from the UI goroutine.
+	mode           vimMode
+	keys           string // Keys of the normal mode cmd being typed.
+	search         string // Search pattern being typed.
+	searchBackward bool
+	lastSearch     string
+	lastBackward   bool
+	status         string
+
+	mtx       sync.Mutex
+	enabled   bool
+	registers map[rune]vimRegister
+}
+
+func newVimState(enabled bool) *vimState {
+	return &vimState{
+		enabled:   enabled,
+		registers: make(map[rune]vimRegister),
+	}
+}
+
+func (vs *vimState) isEnabled() bool {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	return vs.enabled
+}
+
+// setEnabled enables or disables the vim mode. The editing starts in the
+// insert mode.
+func (vs *vimState) setEnabled(enabled bool) {
+	vs.mtx.Lock()
+	vs.enabled = enabled
+	vs.mtx.Unlock()
+	vs.mode = vimModeInsert
+	vs.keys, vs.search, vs.status = "", "", ""
+}
+
+// yank stores the text in the register. Upper case registers append to the
+// corresponding lower case register.
+func (vs *vimState) yank(reg rune, r vimRegister) error {
+	if reg == vimClipboardReg {
+		if err := clipboard.WriteAll(r.text); err != nil {
+			return err
+		}
+	}
+
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	if unicode.IsUpper(reg) {
+		reg = unicode.ToLower(reg)
+		if old, ok := vs.registers[reg]; ok {
+			if old.linewise || r.linewise {
+				old.text += "\n"
+			}
+			r.text = old.text + r.text
+			r.linewise = old.linewise || r.linewise
+		}
+	}
+	if reg != 0 && reg != vimClipboardReg {
+		vs.registers[reg] = r
+	}
+	vs.registers[vimUnnamedReg] = r
+	return nil
+}
+
+// register returns the content of the register.
+func (vs *vimState) register(reg rune) (vimRegister, error) {
+	if reg == vimClipboardReg {
+		text, err := clipboard.ReadAll()
+		return vimRegister{text: text}, err
+	}
+	if reg == 0 {
+		reg = vimUnnamedReg
+	}
+
+	vs.mtx.Lock()
+	r, ok := vs.registers[unicode.ToLower(reg)]
+	vs.mtx.Unlock()
+	if !ok {
+		return r, fmt.Errorf("register %c is empty", reg)
+	}
+	return r, nil
+}
+
+// listRegisters returns the names and contents of the non empty registers,
+// sorted by name.
+func (vs *vimState) listRegisters() ([]rune, []vimRegister) {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	names := make([]rune, 0, len(vs.registers))
+	for name := range vs.registers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	regs := make([]vimRegister, len(names))
+	for i, name := range names {
+		regs[i] = vs.registers[name]
+	}
+	return names, regs
+}
+
+// statusLine returns the text displayed in the footer of the main window.
+func (vs *vimState) statusLine() string {
+	if !vs.isEnabled() {
+		return ""
+	}
+	switch {
+	case vs.mode == vimModeSearch && vs.searchBackward:
+		return "?" + vs.search
+	case vs.mode == vimModeSearch:
+		return "/" + vs.search
+	case vs.status != "":
+		return vs.status
+	case vs.mode == vimModeNormal:
+		return "-- NORMAL -- " + vs.keys
+	default:
+		return "-- INSERT --"
+	}
+}
+
+// handleVimKey handles a key msg when the vim mode is enabled. It returns
+// false if the key should be processed as usual.
+func (mws *mainWindowState) handleVimKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	vs := mws.as.vim
+	if !mws.isChat || !vs.isEnabled() {
+		return false, nil
+	}
+
+	switch vs.mode {
+	case vimModeSearch:
+		mws.handleVimSearchKey(msg)
+		return true, nil
+
+	case vimModeNormal:
+		return mws.handleVimNormalKey(msg)
+
+	default:
+		if keyName(msg) != "esc" {
+			return false, nil
+		}
+		vs.mode = vimModeNormal
+		vs.status = ""
+		_, _, col := mws.vimTextLine()
+		mws.vimTextKey(tea.KeyLeft, false, min(1, col))
+		return true, nil
+	}
+}
+
+func (mws *mainWindowState) handleVimSearchKey(msg tea.KeyMsg) {
+	vs := mws.as.vim
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		vs.search += string(msg.Runes)
+
+	case tea.KeyBackspace:
+		if vs.search == "" {
+			vs.mode = vimModeNormal
+			break
+		}
+		search := []rune(vs.search)
+		vs.search = string(search[:len(search)-1])
+
+	case tea.KeyEnter:
+		vs.mode = vimModeNormal
+		if vs.search != "" {
+			vs.lastSearch = vs.search
+			vs.lastBackward = vs.searchBackward
+		}
+		mws.vimSearch(vs.lastSearch, vs.lastBackward)
+
+	case tea.KeyEsc:
+		vs.mode = vimModeNormal
+	}
+}
+
+func (mws *mainWindowState) handleVimNormalKey(msg tea.KeyMsg) (bool, tea.Cmd) {
+	vs := mws.as.vim
+	key := keyName(msg)
+	vs.status = ""
+
+	switch key {
+	case "esc":
+		vs.keys = ""
+		return true, nil
+
+	case "ctrl+e", "ctrl+y", "ctrl+d", "ctrl+u", "ctrl+f", "ctrl+b":
+		// Keys bound to actions of the chat windows take precedence
+		// over the scrolling keys.
+		if _, bound := mws.as.keymap.Load().conflict(keyCtxChat, key); bound {
+			return false, nil
+		}
+		vs.keys = ""
+		return true, mws.vimScroll(func(vp *viewport.Model) {
+			switch key {
+			case "ctrl+e":
+				vp.LineDown(1)
+			case "ctrl+y":
+				vp.LineUp(1)
+			case "ctrl+d":
+				vp.HalfViewDown()
+			case "ctrl+u":
+				vp.HalfViewUp()
+			case "ctrl+f":
+				vp.ViewDown()
+			case "ctrl+b":
+				vp.ViewUp()
+			}
+		})
+	}
+
+	if (msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace) || msg.Alt || len(msg.Runes) > 1 {
+		// Other keys (enter, arrows, etc) and pasted text work as in
+		// the insert mode.
+		vs.keys = ""
+		return false, nil
+	}
+
+	vs.keys += string(msg.Runes)
+	c, complete, err := parseVimCmd(vs.keys)
+	if err != nil {
+		vs.keys = ""
+		vs.status = err.Error()
+		return true, nil
+	}
+	if !complete {
+		return true, nil
+	}
+	vs.keys = ""
+
+	prevVal := mws.textArea.Value()
+	cmd, err := mws.execVimCmd(c)
+	if err != nil {
+		vs.status = err.Error()
+	}
+	if mws.textArea.Value() != prevVal {
+		mws.textAreaChanged()
+	}
+	return true, cmd
+}
+
+// vimTextKey sends the key to the text area n times.
+func (mws *mainWindowState) vimTextKey(typ tea.KeyType, alt bool, n int) {
+	for i := 0; i < n; i++ {
+		mws.textArea.Model, _ = mws.textArea.Model.Update(tea.KeyMsg{Type: typ, Alt: alt})
+	}
+}
+
+// vimTextLine returns the lines of the text area, the index of the line of the
+// cursor and the column of the cursor in the line.
+func (mws *mainWindowState) vimTextLine() ([]string, int, int) {
+	lines := strings.Split(mws.textArea.Value(), "\n")
+	row := mws.textArea.Line()
+	info := mws.textArea.LineInfo()
+	return lines, row, info.StartColumn + info.ColumnOffset
+}
+
+// vimScroll scrolls the viewport, marking the msgs of the active window as
+// read when reaching the bottom.
+func (mws *mainWindowState) vimScroll(scroll func(vp *viewport.Model)) tea.Cmd {
+	wasAtBottom := mws.viewport.AtBottom()
+	scroll(&mws.viewport)
+	if !wasAtBottom && mws.viewport.AtBottom() {
+		if cw := mws.as.activeChatWindow(); cw != nil {
+			cmd := markAllRead(cw)
+			mws.updateViewportContent()
+			return cmd
+		}
+	}
+	return nil
+}
+
+// vimSearch scrolls the viewport to the next line that has the pattern.
+func (mws *mainWindowState) vimSearch(pattern string, backward bool) {
+	vs := mws.as.vim
+	if pattern == "" {
+		vs.status = "No previous search pattern"
+		return
+	}
+
+	lines := strings.Split(mws.as.activeWindowMsgs(), "\n")
+	idx := vimSearchLines(lines, pattern, mws.viewport.YOffset, backward)
+	if idx < 0 {
+		vs.status = fmt.Sprintf("Pattern not found: %s", pattern)
+		return
+	}
+	mws.vimScroll(func(vp *viewport.Model) { vp.SetYOffset(idx) })
+	vs.status = fmt.Sprintf("%s (line %d of %d)", pattern, idx+1, len(lines))
+}
+
+// execVimCmd executes a cmd of the normal mode.
+func (mws *mainWindowState) execVimCmd(c vimCmd) (tea.Cmd, error) {
+	vs := mws.as.vim
+	n := c.count
+	if n == 0 {
+		n = 1
+	}
+	lines, row, col := mws.vimTextLine()
+	line := []rune(lines[row])
+
+	switch c.cmd {
+	case "h":
+		mws.vimTextKey(tea.KeyLeft, false, min(n, col))
+	case "l", " ":
+		mws.vimTextKey(tea.KeyRight, false, min(n, len(line)-col))
+	case "0", "^":
+		mws.vimTextKey(tea.KeyHome, false, 1)
+	case "$":
+		mws.vimTextKey(tea.KeyEnd, false, 1)
+	case "w", "e":
+		mws.vimTextKey(tea.KeyRight, true, n)
+	case "b":
+		mws.vimTextKey(tea.KeyLeft, true, n)
+
+	case "i":
+		vs.mode = vimModeInsert
+	case "a":
+		mws.vimTextKey(tea.KeyRight, false, min(1, len(line)-col))
+		vs.mode = vimModeInsert
+	case "I":
+		mws.vimTextKey(tea.KeyHome, false, 1)
+		vs.mode = vimModeInsert
+	case "A":
+		mws.vimTextKey(tea.KeyEnd, false, 1)
+		vs.mode = vimModeInsert
+	case "o":
+		mws.vimTextKey(tea.KeyEnd, false, 1)
+		mws.textArea.InsertString("\n")
+		vs.mode = vimModeInsert
+	case "O":
+		mws.vimTextKey(tea.KeyHome, false, 1)
+		mws.textArea.InsertString("\n")
+		mws.vimTextKey(tea.KeyUp, false, 1)
+		vs.mode = vimModeInsert
+
+	case "x":
+		n = min(n, len(line)-col)
+		if n == 0 {
+			break
+		}
+		mws.vimTextKey(tea.KeyDelete, false, n)
+		return nil, vs.yank(c.reg, vimRegister{text: string(line[col : col+n])})
+	case "X":
+		n = min(n, col)
+		if n == 0 {
+			break
+		}
+		mws.vimTextKey(tea.KeyBackspace, false, n)
+		return nil, vs.yank(c.reg, vimRegister{text: string(line[col-n : col])})
+	case "D", "C":
+		if c.cmd == "C" {
+			vs.mode = vimModeInsert
+		}
+		if col >= len(line) {
+			break
+		}
+		mws.vimTextKey(tea.KeyCtrlK, false, 1)
+		return nil, vs.yank(c.reg, vimRegister{text: string(line[col:])})
+	case "cc", "S":
+		mws.vimTextKey(tea.KeyHome, false, 1)
+		if len(line) > 0 {
+			mws.vimTextKey(tea.KeyCtrlK, false, 1)
+		}
+		vs.mode = vimModeInsert
+		return nil, vs.yank(c.reg, vimRegister{text: string(line), linewise: true})
+	case "dd":
+		end := min(row+n, len(lines))
+		yanked := strings.Join(lines[row:end], "\n")
+		mws.textArea.SetValue(strings.Join(append(lines[:row:row], lines[end:]...), "\n"))
+		return nil, vs.yank(c.reg, vimRegister{text: yanked, linewise: true})
+	case "yy", "Y":
+		end := min(row+n, len(lines))
+		yanked := strings.Join(lines[row:end], "\n")
+		return nil, vs.yank(c.reg, vimRegister{text: yanked, linewise: true})
+	case "p", "P":
+		r, err := vs.register(c.reg)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.Repeat(r.text, n)
+		switch {
+		case r.linewise && c.cmd == "p":
+			mws.vimTextKey(tea.KeyEnd, false, 1)
+			text = strings.Repeat("\n"+r.text, n)
+		case r.linewise:
+			mws.vimTextKey(tea.KeyHome, false, 1)
+			text = strings.Repeat(r.text+"\n", n)
+		case c.cmd == "p":
+			mws.vimTextKey(tea.KeyRight, false, min(1, len(line)-col))
+		}
+		mws.textArea.InsertString(text)
+
+	case "j":
+		return mws.vimScroll(func(vp *viewport.Model) { vp.LineDown(n) }), nil
+	case "k":
+		return mws.vimScroll(func(vp *viewport.Model) { vp.LineUp(n) }), nil
+	case "gg":
+		return mws.vimScroll(func(vp *viewport.Model) { vp.GotoTop() }), nil
+	case "G":
+		return mws.vimScroll(func(vp *viewport.Model) { vp.GotoBottom() }), nil
+
+	case "/", "?":
+		vs.mode = vimModeSearch
+		vs.search = ""
+		vs.searchBackward = c.cmd == "?"
+	case "n":
+		mws.vimSearch(vs.lastSearch, vs.lastBackward)
+	case "N":
+		mws.vimSearch(vs.lastSearch, !vs.lastBackward)
+	case ":":
+		if mws.textArea.Value() != "" {
+			return nil, errors.New("input line is not empty")
+		}
+		mws.textArea.InsertString("/")
+		vs.mode = vimModeInsert
+
+	case "gt":
+		if c.count > 0 {
+			// Windows are 1-based here, as in the window
+			// number keys.
+			mws.as.changeActiveWindow(c.count - 1)
+			break
+		}
+		mws.as.changeActiveWindowNext()
+	case "gT":
+		for i := 0; i < n; i++ {
+			mws.as.changeActiveWindowPrev()
+		}
+	}
+
+	return nil, nil
+}
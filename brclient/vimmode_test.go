@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestParseVimCmd tests parsing the keys typed in the normal mode.
+func TestParseVimCmd(t *testing.T) {
+	tests := []struct {
+		keys     string
+		want     vimCmd
+		complete bool
+		wantErr  bool
+	}{
+		{keys: "j", want: vimCmd{cmd: "j"}, complete: true},
+		{keys: "12k", want: vimCmd{count: 12, cmd: "k"}, complete: true},
+		{keys: "0", want: vimCmd{cmd: "0"}, complete: true},
+		{keys: "10x", want: vimCmd{count: 10, cmd: "x"}, complete: true},
+		{keys: "d", want: vimCmd{cmd: "d"}},
+		{keys: "3", want: vimCmd{count: 3}},
+		{keys: "\"", want: vimCmd{}},
+		{keys: "\"a2yy", want: vimCmd{reg: 'a', count: 2, cmd: "yy"}, complete: true},
+		{keys: "\"+p", want: vimCmd{reg: '+', cmd: "p"}, complete: true},
+		{keys: "2gt", want: vimCmd{count: 2, cmd: "gt"}, complete: true},
+		{keys: "\"!", wantErr: true},
+		{keys: "dx", wantErr: true},
+		{keys: "z", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, complete, err := parseVimCmd(tc.keys)
+		if tc.wantErr {
+			assert.NonNilErr(t, err)
+			continue
+		}
+		assert.NilErr(t, err)
+		assert.DeepEqual(t, complete, tc.complete)
+		assert.DeepEqual(t, got, tc.want)
+	}
+}
+
+// TestVimSearchLines tests searching the lines of a window.
+func TestVimSearchLines(t *testing.T) {
+	lines := []string{
+		"first line",
+		"\x1b[1mHello\x1b[0m world",
+		"other",
+		"hello again",
+	}
+
+	assert.DeepEqual(t, vimSearchLines(lines, "hello", 0, false), 1)
+	assert.DeepEqual(t, vimSearchLines(lines, "hello", 1, false), 3)
+	assert.DeepEqual(t, vimSearchLines(lines, "hello", 3, false), 1)
+	assert.DeepEqual(t, vimSearchLines(lines, "Hello", 1, false), 1)
+	assert.DeepEqual(t, vimSearchLines(lines, "hello", 0, true), 3)
+	assert.DeepEqual(t, vimSearchLines(lines, "hello", 3, true), 1)
+	assert.DeepEqual(t, vimSearchLines(lines, "1m", 0, false), -1)
+	assert.DeepEqual(t, vimSearchLines(lines, "", 0, false), -1)
+}
+
+// TestVimRegisters tests yanking text into registers.
+func TestVimRegisters(t *testing.T) {
+	vs := newVimState(true)
+
+	_, err := vs.register('a')
+	assert.NonNilErr(t, err)
+
+	assert.NilErr(t, vs.yank(0, vimRegister{text: "foo"}))
+	assert.NilErr(t, vs.yank('a', vimRegister{text: "bar"}))
+	assert.NilErr(t, vs.yank('A', vimRegister{text: "baz"}))
+	assert.NilErr(t, vs.yank('b', vimRegister{text: "line", linewise: true}))
+	assert.NilErr(t, vs.yank('B', vimRegister{text: "next", linewise: true}))
+
+	r, err := vs.register('a')
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, r, vimRegister{text: "barbaz"})
+	r, err = vs.register('B')
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, r, vimRegister{text: "line\nnext", linewise: true})
+
+	// The unnamed register has the last yanked text.
+	r, err = vs.register(0)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, r, vimRegister{text: "line\nnext", linewise: true})
+
+	names, _ := vs.listRegisters()
+	assert.DeepEqual(t, names, []rune{'"', 'a', 'b'})
+}
@@ -124,6 +124,8 @@ func handleInitClient(handle uint32, args initClient) error {
 			MacaroonPath: args.LNMacaroonPath,
 			Address:      args.LNRPCHost,
 			Log:          logBknd.logger("LNPY"),
+
+			MaxPaymentParts: client.DefaultMaxPaymentParts,
 		}
 		lnpc, err = client.NewDcrlndPaymentClient(context.Background(), pcCfg)
 		if err != nil {
@@ -322,19 +324,26 @@ func handleInitClient(handle uint32, args initClient) error {
 		cctx.expirationDays = uint64(policy.ExpirationDays)
 	}))
 
-	ntfns.Register(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+	ntfns.Register(client.OnTipAttemptDetailsNtfn(func(ru *client.RemoteUser, progress client.TipAttemptProgress) {
 		var errMsg string
-		if attemptErr != nil {
-			errMsg = attemptErr.Error()
+		if progress.Err != nil {
+			errMsg = progress.Err.Error()
 		}
 		ntfn := &types.TipProgressEvent{
-			Uid:          ru.ID().Bytes(),
-			Nick:         ru.Nick(),
-			AmountMatoms: amtMAtoms,
-			Completed:    completed,
-			Attempt:      int32(attempt),
-			AttemptErr:   errMsg,
-			WillRetry:    willRetry,
+			Uid:              ru.ID().Bytes(),
+			Nick:             ru.Nick(),
+			AmountMatoms:     progress.AmtMAtoms,
+			Completed:        progress.Completed,
+			Attempt:          int32(progress.Attempt),
+			AttemptErr:       errMsg,
+			WillRetry:        progress.WillRetry,
+			MaxAttempts:      int32(progress.MaxAttempts),
+			RemainingRetries: int32(progress.RemainingRetries),
+			PaymentRetries:   int32(progress.PaymentRetries),
+			FailureReason:    string(progress.FailureReason),
+			Destination:      progress.Destination,
+			FeesMatoms:       progress.FeesMAtoms,
+			Keysend:          progress.Keysend,
 		}
 		notify(NTTipUserProgress, ntfn, nil)
 	}))
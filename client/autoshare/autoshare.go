@@ -0,0 +1,274 @@
+// Package autoshare implements a subsystem that watches directories and
+// automatically shares the files that appear in them.
+package autoshare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/jsonfile"
+	"github.com/decred/slog"
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounceInterval is the interval to wait after a filesystem event before
+// rescanning the directories, so that files that are still being written are
+// not shared multiple times.
+const debounceInterval = 2 * time.Second
+
+// Dir is a directory watched for files to share.
+type Dir struct {
+	// Path is the path to the directory. Only regular files directly
+	// inside the directory are shared (subdirectories are ignored).
+	Path string
+
+	// Cost is the cost (in atoms) of files shared from this directory. If
+	// nil, the default pricing rule of the config is used.
+	Cost *uint64
+}
+
+// Config holds the configuration for the auto share subsystem.
+type Config struct {
+	// Client is the client used to share files.
+	Client *client.Client
+
+	// Dirs are the directories to watch.
+	Dirs []Dir
+
+	// DefaultCost is the cost (in atoms) of files shared from directories
+	// that do not specify a cost.
+	DefaultCost uint64
+
+	// CostPerMB is an additional cost (in atoms) per MB of files shared
+	// from directories that do not specify a cost.
+	CostPerMB uint64
+
+	// Exclude is a list of glob patterns (as defined in filepath.Match)
+	// matched against the base name of files. Files that match any of the
+	// patterns are not shared.
+	Exclude []string
+
+	// StateFile is the file where the list of automatically shared files
+	// is stored, so that changed and removed files may be unshared after
+	// a restart.
+	StateFile string
+
+	Log slog.Logger
+}
+
+// sharedFile tracks a file that was automatically shared.
+type sharedFile struct {
+	FID     clientdb.FileID `json:"fid"`
+	Size    int64           `json:"size"`
+	ModTime time.Time       `json:"mod_time"`
+	Cost    uint64          `json:"cost"`
+}
+
+// Sharer automatically shares the files of a list of directories.
+type Sharer struct {
+	cfg Config
+	log slog.Logger
+
+	mtx    sync.Mutex
+	shared map[string]sharedFile // Key is the file path
+}
+
+// New creates a new auto sharer.
+func New(cfg Config) (*Sharer, error) {
+	for _, pattern := range cfg.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclusion pattern %q: %v",
+				pattern, err)
+		}
+	}
+
+	log := slog.Disabled
+	if cfg.Log != nil {
+		log = cfg.Log
+	}
+
+	s := &Sharer{
+		cfg:    cfg,
+		log:    log,
+		shared: make(map[string]sharedFile),
+	}
+	if cfg.StateFile != "" {
+		err := jsonfile.Read(cfg.StateFile, &s.shared)
+		if err != nil && !errors.Is(err, jsonfile.ErrNotFound) {
+			return nil, fmt.Errorf("unable to read auto share state: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// isExcluded returns true if the file should not be shared.
+func (s *Sharer) isExcluded(fname string) bool {
+	base := filepath.Base(fname)
+	for _, pattern := range s.cfg.Exclude {
+		if match, _ := filepath.Match(pattern, base); match {
+			return true
+		}
+	}
+	return false
+}
+
+// fileCost returns the cost of sharing a file of the given size from the
+// given dir.
+func (s *Sharer) fileCost(dir *Dir, size int64) uint64 {
+	if dir.Cost != nil {
+		return *dir.Cost
+	}
+	return s.cfg.DefaultCost + s.cfg.CostPerMB*uint64(size)/1e6
+}
+
+// Shared returns the paths of the files currently automatically shared.
+func (s *Sharer) Shared() map[string]clientdb.FileID {
+	s.mtx.Lock()
+	res := make(map[string]clientdb.FileID, len(s.shared))
+	for fname, sf := range s.shared {
+		res[fname] = sf.FID
+	}
+	s.mtx.Unlock()
+	return res
+}
+
+// Scan shares all new or changed files of the watched directories and
+// unshares files that were removed from them.
+func (s *Sharer) Scan() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	seen := make(map[string]struct{}, len(s.shared))
+	for i := range s.cfg.Dirs {
+		dir := &s.cfg.Dirs[i]
+		entries, err := os.ReadDir(dir.Path)
+		if err != nil {
+			s.log.Warnf("Unable to read dir %s: %v", dir.Path, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			fname := filepath.Join(dir.Path, entry.Name())
+			if !entry.Type().IsRegular() || s.isExcluded(fname) {
+				continue
+			}
+			fi, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			seen[fname] = struct{}{}
+
+			cost := s.fileCost(dir, fi.Size())
+			old, ok := s.shared[fname]
+			if ok && old.Size == fi.Size() && old.ModTime.Equal(fi.ModTime()) &&
+				old.Cost == cost {
+				// Already shared.
+				continue
+			}
+
+			if ok {
+				// The file (or its cost) changed. Stop sharing
+				// the previous version, otherwise the new one
+				// cannot be shared with the same name.
+				err := s.cfg.Client.UnshareFile(old.FID, nil)
+				if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+					s.log.Errorf("Unable to unshare previous "+
+						"version of file %s: %v", fname, err)
+					continue
+				}
+				delete(s.shared, fname)
+			}
+
+			sf, _, err := s.cfg.Client.ShareFile(fname, nil, cost, "")
+			if err != nil {
+				s.log.Errorf("Unable to share file %s: %v", fname, err)
+				continue
+			}
+			s.shared[fname] = sharedFile{
+				FID:     sf.FID,
+				Size:    fi.Size(),
+				ModTime: fi.ModTime(),
+				Cost:    cost,
+			}
+			s.log.Infof("Shared file %s (%s) with cost %d atoms", fname,
+				sf.FID, cost)
+		}
+	}
+
+	// Unshare files that were removed (or are no longer in a watched dir).
+	for fname, sf := range s.shared {
+		if _, ok := seen[fname]; ok {
+			continue
+		}
+		err := s.cfg.Client.UnshareFile(sf.FID, nil)
+		if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+			s.log.Errorf("Unable to unshare file %s: %v", fname, err)
+			continue
+		}
+		delete(s.shared, fname)
+		s.log.Infof("Unshared removed file %s (%s)", fname, sf.FID)
+	}
+
+	if s.cfg.StateFile == "" {
+		return nil
+	}
+	return jsonfile.Write(s.cfg.StateFile, s.shared, s.log)
+}
+
+// Run scans the watched directories and then watches them for changes until
+// the passed context is canceled.
+func (s *Sharer) Run(ctx context.Context) error {
+	if err := s.Scan(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to start filesystem watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range s.cfg.Dirs {
+		if err := watcher.Add(dir.Path); err != nil {
+			s.log.Warnf("Unable to watch dir %s: %v", dir.Path, err)
+		}
+	}
+
+	// chanScan is used to debounce file events so that we only rescan
+	// once when multiple events happen in sequence.
+	var chanScan <-chan time.Time
+
+	s.log.Debugf("Watching %d dirs for files to share", len(s.cfg.Dirs))
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-chanScan:
+			chanScan = nil
+			if err := s.Scan(); err != nil {
+				s.log.Errorf("Unable to scan dirs: %v", err)
+			}
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return errors.New("watcher events closed")
+			}
+			s.log.Tracef("Watcher event: %s", event)
+			chanScan = time.After(debounceInterval)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return errors.New("watcher errors closed")
+			}
+			s.log.Debugf("Watcher error: %v", err)
+		}
+	}
+}
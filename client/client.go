@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"errors"
 	"os"
@@ -102,6 +103,10 @@ type Config struct {
 	// download with the user.
 	FileDownloadConfirmer func(user *RemoteUser, fm rpc.FileMetadata) bool
 
+	// BandwidthLimits are the initial limits of the bandwidth used for
+	// content (file transfer) traffic.
+	BandwidthLimits BandwidthLimits
+
 	// TransitiveEvent is called whenever a request is made by source for
 	// the local client to forward a message to dst.
 	TransitiveEvent func(src, dst UserID, event TransitiveEvent)
@@ -131,6 +136,46 @@ type Config struct {
 	// If unspecified, a default value of 12 seconds (1/5 minute) is used.
 	TipUserPayRetryDelayFactor time.Duration
 
+	// TipUserMaxAttempts is the max number of attempts at requesting an
+	// invoice made for tips that do not specify one.
+	//
+	// If unspecified, a default value of 1 is used.
+	TipUserMaxAttempts int32
+
+	// TipUserMaxPayRetryDelay is the max delay between retries of a
+	// payment when the payment error indicates a retry may be possible.
+	// If zero, the delay is not limited.
+	TipUserMaxPayRetryDelay time.Duration
+
+	// TipUserKeysendMaxMAtoms is the max amount of tips that are first
+	// attempted as keysend payments to the LN node of the remote user
+	// (when known), before falling back to requesting an invoice. If
+	// zero, keysend is not used for tips.
+	TipUserKeysendMaxMAtoms int64
+
+	// TipUserOnchainMinMAtoms is the min amount of tips that fall back to
+	// an on-chain payment when all attempts to pay them through LN fail.
+	// If zero, tips are not sent on-chain automatically.
+	TipUserOnchainMinMAtoms int64
+
+	// OnchainTipCheckInterval is the interval between checks for the
+	// confirmation of the transactions of on-chain tips.
+	//
+	// If unspecified, a default value of 1 minute is used.
+	OnchainTipCheckInterval time.Duration
+
+	// EscrowMaxLifetime is how long escrowed payments may be held before
+	// they are canceled if not released by the buyer.
+	//
+	// If unspecified, a default value of 72 hours is used.
+	EscrowMaxLifetime time.Duration
+
+	// EscrowCheckInterval is the interval between checks of the state of
+	// the hold invoices of pending escrows.
+	//
+	// If unspecified, a default value of 1 minute is used.
+	EscrowCheckInterval time.Duration
+
 	// GCMQMaxLifetime is how long to wait for a message from an user,
 	// after which the GCMQ considers no other messages from this user
 	// will be received.
@@ -183,12 +228,41 @@ type Config struct {
 	// automatically unsubscribed from posts.
 	AutoRemoveIdleUsersInterval time.Duration
 
+	// AutoHandshakeTimeout is the interval to wait for a reply to an
+	// automatic handshake attempt before an idle user is removed from GCs
+	// and posts and before the ratchet with the user is considered
+	// probably broken.
+	//
+	// If unspecified, half of AutoHandshakeInterval is used for removing
+	// idle users and 3 days is used for the ratchet health check.
+	AutoHandshakeTimeout time.Duration
+
+	// AutoUnsubIdleUsersRVsInterval is the interval after which the client
+	// stops listening for messages (i.e. unsubscribes from the receive RV
+	// points) of idle users: users from which no message has been received
+	// and to which no message has been sent during the interval. The
+	// client listens for messages from the user again once a message is
+	// sent to them. Messages sent by the user while not listening are only
+	// received if the client listens again before the server expires them.
+	//
+	// Users in AutoRemoveIdleUsersIgnoreList are never unsubscribed. If
+	// unspecified, the client never stops listening for messages.
+	AutoUnsubIdleUsersRVsInterval time.Duration
+
 	// AutoRemoveIdleUsersIgnoreList is a list of users that should NOT be
 	// forcibly unsubscribed even if they are idle. The values may be an
 	// user's nick or the prefix of the string representatation of its nick
 	// (i.e. anything acceptable as returned by UserByNick()).
 	AutoRemoveIdleUsersIgnoreList []string
 
+	// ContentIndexRefreshInterval is the interval after which the listing
+	// of content shared by remote users is automatically fetched again to
+	// refresh the content index used in SearchContent.
+	//
+	// If unspecified, the content index is only updated when listing
+	// content manually.
+	ContentIndexRefreshInterval time.Duration
+
 	// SendReceiveReceipts flags whether to send receive receipts for all
 	// domains.
 	SendReceiveReceipts bool
@@ -196,6 +270,84 @@ type Config struct {
 	// AutoSubscribeToPosts flags whether to automatically subscribe to
 	// posts when kx'ing for the first time with an user.
 	AutoSubscribeToPosts bool
+
+	// LinkPreviews flags whether to generate previews for links in
+	// outgoing PMs and GC messages. When enabled, the metadata of linked
+	// pages is fetched before sending the message and attached to it, so
+	// that recipients do not need to make their own requests.
+	LinkPreviews bool
+
+	// LinkPreviewDialFunc is used to connect to the linked servers when
+	// generating link previews. This should be set to the configured
+	// proxy, if one is used. If nil, a direct connection is made.
+	LinkPreviewDialFunc clientintf.DialFunc
+
+	// LinkPreviewTimeout is how long to wait for the preview of a link
+	// to be fetched.
+	//
+	// If unspecified, a default value of 15 seconds is used.
+	LinkPreviewTimeout time.Duration
+
+	// PostMediaThumbnails flags whether to process images embedded in
+	// new posts. When enabled, images larger than PostMediaMaxInlineSize
+	// are replaced by a thumbnail, while a recompressed version of the
+	// full image is shared as a file that can be downloaded from the post.
+	PostMediaThumbnails bool
+
+	// PostMediaMaxInlineSize is the max size of images that are sent
+	// inline (without a thumbnail) in posts.
+	//
+	// If unspecified, a default value of 32KiB is used.
+	PostMediaMaxInlineSize int
+
+	// PostMediaThumbnailDim is the max width and height of thumbnails of
+	// images embedded in posts.
+	//
+	// If unspecified, a default value of 256 pixels is used.
+	PostMediaThumbnailDim int
+
+	// PostMediaCost is the cost (in atoms) to download the full
+	// version of images embedded in posts.
+	PostMediaCost uint64
+
+	// PostAutoUnlockMaxCost is the max price (in atoms) of paywalled posts
+	// that are automatically unlocked when received from subscriptions.
+	// Zero disables automatically unlocking posts.
+	PostAutoUnlockMaxCost uint64
+
+	// IgnorePostModeration ignores the moderation actions (hiding and
+	// flagging comments) taken by the authors of posts received from
+	// subscriptions.
+	IgnorePostModeration bool
+
+	// DCRUSDRate returns the current DCR/USD exchange rate and the time it
+	// was last updated. It is used to convert USD-denominated tips and
+	// recurring tips. If nil, USD-denominated tips fail to be paid.
+	DCRUSDRate func() (float64, time.Time)
+
+	// TipFiatMaxSlippage is the max change (in percent) of the exchange
+	// rate between starting a fiat-denominated tip and paying its invoice.
+	// Payments are not made while the rate differs by more than this from
+	// the rate used to convert the tip. Negative values disable the check.
+	TipFiatMaxSlippage float64
+
+	// PaymentBudgetConfirmer is called when an outbound payment would
+	// exceed a payment budget. The payment is made only if this returns
+	// true. If nil, payments that exceed a budget fail.
+	PaymentBudgetConfirmer func(err PaymentBudgetExceededError) bool
+
+	// PaymentsMode restricts the payments made by the client. Payments
+	// that are not allowed fail with clientintf.ErrPaymentsDisabled.
+	PaymentsMode PaymentsMode
+
+	// PaymentWebhooks are notified of the payments received by the client
+	// (see PaymentEvent).
+	PaymentWebhooks []PaymentWebhook
+
+	// WebhookDialFunc is used to connect to the payment webhooks. This
+	// should be set to the configured proxy, if one is used. If nil, a
+	// direct connection is made.
+	WebhookDialFunc clientintf.DialFunc
 }
 
 // logger creates a logger for the given subsystem in the configured backend.
@@ -222,6 +374,23 @@ func (cfg *Config) setDefaults() {
 	if cfg.TipUserPayRetryDelayFactor == 0 {
 		cfg.TipUserPayRetryDelayFactor = time.Minute / 5
 	}
+	if cfg.TipUserMaxAttempts == 0 {
+		cfg.TipUserMaxAttempts = 1
+	}
+	if cfg.TipFiatMaxSlippage == 0 {
+		cfg.TipFiatMaxSlippage = DefaultTipFiatMaxSlippage
+	}
+
+	if cfg.OnchainTipCheckInterval == 0 {
+		cfg.OnchainTipCheckInterval = time.Minute
+	}
+
+	if cfg.EscrowMaxLifetime == 0 {
+		cfg.EscrowMaxLifetime = time.Hour * 72
+	}
+	if cfg.EscrowCheckInterval == 0 {
+		cfg.EscrowCheckInterval = time.Minute
+	}
 
 	if cfg.RecentMediateIDThreshold == 0 {
 		cfg.RecentMediateIDThreshold = time.Hour * 24 * 7
@@ -235,6 +404,17 @@ func (cfg *Config) setDefaults() {
 		cfg.MaxAutoKXMediateIDRequests = 3
 	}
 
+	if cfg.LinkPreviewTimeout == 0 {
+		cfg.LinkPreviewTimeout = time.Second * 15
+	}
+
+	if cfg.PostMediaMaxInlineSize == 0 {
+		cfg.PostMediaMaxInlineSize = 32 * 1024
+	}
+	if cfg.PostMediaThumbnailDim == 0 {
+		cfg.PostMediaThumbnailDim = 256
+	}
+
 	// These following GCMQ times were obtained by profiling a client
 	// connected over tor to the server and may need tweaking from time to
 	// time.
@@ -254,7 +434,6 @@ func (cfg *Config) setDefaults() {
 type localIdentity struct {
 	id         zkidentity.ShortID
 	nick       string
-	name       string
 	privKey    zkidentity.FixedSizeSntrupPrivateKey
 	pubKey     zkidentity.FixedSizeSntrupPublicKey
 	privSigKey zkidentity.FixedSizeEd25519PrivateKey
@@ -281,7 +460,6 @@ func (li *localIdentity) verifyMessage(msg []byte, sig *zkidentity.FixedSizeSign
 // public returns a public identity instance with the fixed data filled.
 func (li *localIdentity) public() zkidentity.PublicIdentity {
 	return zkidentity.PublicIdentity{
-		Name:      li.name,
 		Nick:      li.nick,
 		SigKey:    li.pubSigKey,
 		Key:       li.pubKey,
@@ -299,14 +477,16 @@ func localIdentityFromFull(id *zkidentity.FullIdentity) localIdentity {
 		pubSigKey:  id.Public.SigKey,
 		id:         id.Public.Identity,
 		nick:       id.Public.Nick,
-		name:       id.Public.Name,
 		digest:     id.Public.Digest,
 		signature:  id.Public.Signature,
 	}
 }
 
 type localProfile struct {
-	avatar []byte
+	name        string
+	avatar      []byte
+	description string
+	status      string
 }
 
 // Client is the main state manager for a CR client connection. It attempts to
@@ -364,6 +544,21 @@ type Client struct {
 	unkxdWarningsMtx sync.Mutex
 	unkxdWarnings    map[clientintf.UserID]time.Time
 
+	// pendingGCHistories tracks GC histories announced before the first
+	// GC list was received.
+	pendingGCHistoriesMtx sync.Mutex
+	pendingGCHistories    map[pendingGCHistoryKey]pendingGCHistory
+
+	// allowedIDMigrations tracks the old identities allowed to migrate to
+	// the local identity, and until when they are allowed to do so.
+	allowedIDMigrationsMtx sync.Mutex
+	allowedIDMigrations    map[clientintf.UserID]time.Time
+
+	// autoKXRequests tracks the time of the automatic KX requests made in
+	// the last hour, to enforce the rate limit of the auto KX policy.
+	autoKXRequestsMtx sync.Mutex
+	autoKXRequests    []time.Time
+
 	// onboardRunning tracks whether there's a running onboard instance.
 	onboardMtx        sync.Mutex
 	onboardRunning    bool
@@ -373,11 +568,63 @@ type Client struct {
 	listRunningTipAttemptsChan chan chan []RunningTipUserAttempt
 	tipAttemptsRunning         chan struct{}
 
+	// tipRetryPolicy is the stored tip retry policy.
+	tipRetryPolicyMtx sync.Mutex
+	tipRetryPolicy    *clientdb.TipRetryPolicy
+
 	// filters are used to filter content so it is not presented
 	// to the user.
 	filtersMtx     sync.Mutex
 	filters        []clientdb.ContentFilter
 	filtersRegexps map[uint64]*regexp.Regexp
+
+	// broadcasts tracks the delivery status of the broadcasts sent since
+	// the client started. broadcastMsgs maps the ID of each PM sent in a
+	// broadcast to the ID of the broadcast.
+	broadcastsMtx sync.Mutex
+	broadcasts    map[zkidentity.ShortID]*BroadcastStatus
+	broadcastMsgs map[zkidentity.ShortID]zkidentity.ShortID
+
+	// gcSlowModeLast tracks the time of the last message sent by each
+	// member of GCs in slow mode.
+	gcSlowModeMtx  sync.Mutex
+	gcSlowModeLast map[gcSlowModeKey]time.Time
+
+	// bw throttles the content traffic.
+	bw *bandwidthThrottler
+
+	// postDraftsMtx serializes changes to post drafts. postDraftsChan is
+	// signalled when the schedule of post drafts changes.
+	postDraftsMtx  sync.Mutex
+	postDraftsChan chan struct{}
+
+	// recurringTipsMtx serializes changes to recurring tips.
+	// recurringTipsChan is signalled when the recurring tips change.
+	recurringTipsMtx  sync.Mutex
+	recurringTipsChan chan struct{}
+
+	// webhooksChan is signalled when new webhook deliveries are pending.
+	webhooksChan chan struct{}
+
+	// genTipInvoicesMtx protects genTipInvoicesCancel, which holds the
+	// funcs that stop tracking the open invoices generated for tips.
+	genTipInvoicesMtx    sync.Mutex
+	genTipInvoicesCancel map[string]context.CancelFunc
+
+	// paymentBudgetMtx serializes checking payment budgets and reserving
+	// spends.
+	paymentBudgetMtx sync.Mutex
+
+	// onchainTipsMtx serializes changes to on-chain tips.
+	onchainTipsMtx sync.Mutex
+
+	// escrowsMtx serializes changes to escrows.
+	escrowsMtx sync.Mutex
+
+	// deviceSendMtx and deviceRecvMtx serialize sending and receiving
+	// messages through the links with the paired devices.
+	deviceSendMtx sync.Mutex
+	deviceRecvMtx sync.Mutex
 }
 
 // New creates a new CR client with the given config.
@@ -420,8 +667,18 @@ func New(cfg Config) (*Client, error) {
 		})
 	}
 
+	// Server operations are paid through a client that fails every
+	// payment when payments are disabled.
+	var ckPC clientintf.PaymentClient = cfg.PayClient
+	if cfg.PaymentsMode == PaymentsModeDisabled {
+		ckPC = noSpendPaymentClient{
+			PaymentClient: cfg.PayClient,
+			log:           cfg.logger("CONN"),
+		}
+	}
+
 	ckCfg := lowlevel.ConnKeeperCfg{
-		PC:                      cfg.PayClient,
+		PC:                      ckPC,
 		Dialer:                  cfg.Dialer,
 		CertConf:                certConfirmer,
 		ReconnectDelay:          cfg.ReconnectDelay,
@@ -461,11 +718,25 @@ func New(cfg Config) (*Client, error) {
 		gcWarnedVersions: &singlesetmap.Map[zkidentity.ShortID]{},
 		unkxdWarnings:    make(map[clientintf.UserID]time.Time),
 
+		pendingGCHistories:  make(map[pendingGCHistoryKey]pendingGCHistory),
+		allowedIDMigrations: make(map[clientintf.UserID]time.Time),
+
+		broadcasts:     make(map[zkidentity.ShortID]*BroadcastStatus),
+		broadcastMsgs:  make(map[zkidentity.ShortID]zkidentity.ShortID),
+		gcSlowModeLast: make(map[gcSlowModeKey]time.Time),
+		bw:             newBandwidthThrottler(cfg.BandwidthLimits),
+
+		genTipInvoicesCancel: make(map[string]context.CancelFunc),
+
 		onboardCancelChan: make(chan struct{}, 1),
+		postDraftsChan:    make(chan struct{}, 1),
+		recurringTipsChan: make(chan struct{}, 1),
+		webhooksChan:      make(chan struct{}, 1),
 
 		tipAttemptsChan:            make(chan *clientdb.TipUserAttempt),
 		listRunningTipAttemptsChan: make(chan chan []RunningTipUserAttempt),
 		tipAttemptsRunning:         make(chan struct{}),
+		tipRetryPolicy:             new(clientdb.TipRetryPolicy),
 	}
 
 	kxl := newKXList(q, rmgr, &c.localID, c.Public, cfg.DB, ctx)
@@ -519,7 +790,10 @@ func (c *Client) loadLocalID(ctx context.Context) error {
 	}
 
 	c.localID = localIdentityFromFull(id)
+	c.profile.name = id.Public.Name
 	c.profile.avatar = id.Public.Avatar
+	c.profile.description = id.Public.Description
+	c.profile.status = id.Public.Status
 	zeroSlice(id.PrivateSigKey[:])
 	zeroSlice(id.PrivateKey[:])
 
@@ -671,6 +945,9 @@ func (c *Client) loadInitialDBData(ctx context.Context) error {
 	if err := c.loadContentFilters(ctx); err != nil {
 		return err
 	}
+	if err := c.loadTipRetryPolicy(ctx); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -696,17 +973,45 @@ func (c *Client) loadAddressBook(ctx context.Context) error {
 
 	c.log.Debugf("Loaded %d entries from the address book", len(ab))
 
+	// Users that are already idle are loaded without listening for their
+	// messages.
+	var idleLimitDate time.Time
+	if c.cfg.AutoUnsubIdleUsersRVsInterval > 0 {
+		idleLimitDate = time.Now().Add(-c.cfg.AutoUnsubIdleUsersRVsInterval)
+	}
+
+	var nbIdle int
 	for _, entry := range ab {
+		var idle bool
+		if !idleLimitDate.IsZero() {
+			id := entry.AddressBook.ID
+			nick := id.Nick
+			if entry.AddressBook.NickAlias != "" {
+				nick = entry.AddressBook.NickAlias
+			}
+			lastEncTime, lastDecTime := entry.Ratchet.LastEncDecTimes()
+			idle = !c.inIdleUsersIgnoreList(id.Identity, nick) &&
+				isIdleForRVs(lastEncTime, lastDecTime,
+					entry.AddressBook.FirstCreated, idleLimitDate)
+			if idle {
+				nbIdle += 1
+			}
+		}
+
 		_, _, err := c.initRemoteUser(entry.AddressBook.ID, entry.Ratchet, false,
 			clientdb.RawRVID{}, entry.AddressBook.MyResetRV,
 			entry.AddressBook.TheirResetRV, entry.AddressBook.Ignored,
-			entry.AddressBook.NickAlias)
+			entry.AddressBook.NickAlias, idle)
 		if err != nil {
 			c.log.Errorf("Unable to init remote user %s: %v",
 				entry.AddressBook.ID.Identity, err)
 		}
 	}
 
+	if nbIdle > 0 {
+		c.log.Infof("Not listening for messages from %d idle users", nbIdle)
+	}
+
 	return nil
 }
 
@@ -750,7 +1055,10 @@ func (c *Client) Public() zkidentity.PublicIdentity {
 
 	// Variable fields.
 	c.profileMtx.Lock()
+	public.Name = c.profile.name
 	public.Avatar = c.profile.avatar
+	public.Description = c.profile.description
+	public.Status = c.profile.status
 	c.profileMtx.Unlock()
 
 	return public
@@ -847,6 +1155,16 @@ func (c *Client) AddressBookEntry(uid UserID) (*clientdb.AddressBookEntry, error
 	return c.getAddressBookEntry(uid)
 }
 
+// UserAvatar returns the avatar of the given remote user, as last received by
+// the local client. Returns nil if the user has not set an avatar.
+func (c *Client) UserAvatar(uid UserID) ([]byte, error) {
+	ab, err := c.getAddressBookEntry(uid)
+	if err != nil {
+		return nil, err
+	}
+	return ab.ID.Avatar, nil
+}
+
 func (c *Client) UserExists(id UserID) bool {
 	var res bool
 	err := c.dbView(func(tx clientdb.ReadTx) error {
@@ -900,19 +1218,61 @@ func (c *Client) NicksWithPrefix(prefix string) []string {
 // PM sends a private message to the given user, identified by its public id.
 // The user must have been already KX'd with for this to work.
 func (c *Client) PM(uid UserID, msg string) error {
+	_, err := c.PMWithMsgID(uid, msg)
+	return err
+}
+
+// PMWithMsgID sends a private message to the given user and returns the ID
+// chosen for the message, which may be used to edit it later.
+func (c *Client) PMWithMsgID(uid UserID, msg string) (zkidentity.ShortID, error) {
+	return c.pm(uid, msg, nil)
+}
+
+// pm sends a private message to the given user, optionally as a reply to a
+// previous message.
+func (c *Client) pm(uid UserID, msg string, replyTo *zkidentity.ShortID) (zkidentity.ShortID, error) {
+	var msgID zkidentity.ShortID
+	if _, err := rand.Read(msgID[:]); err != nil {
+		return msgID, err
+	}
+	return msgID, c.pmWithID(uid, msgID, msg, replyTo)
+}
+
+// pmWithID sends a private message with the given ID to the user.
+func (c *Client) pmWithID(uid UserID, msgID zkidentity.ShortID, msg string,
+	replyTo *zkidentity.ShortID) error {
+
 	ru, err := c.rul.byID(uid)
 	if err != nil {
 		return err
 	}
+	msg = c.addLinkPreviews(msg)
 
 	myNick := c.LocalNick()
+	now := time.Now()
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
-		return c.db.LogPM(tx, uid, false, myNick, msg, time.Now())
+		if replyTo != nil {
+			reply := clientdb.ThreadMessage{
+				MsgID:     msgID,
+				ReplyTo:   *replyTo,
+				From:      c.PublicID(),
+				Message:   msg,
+				Timestamp: now,
+			}
+			if _, err := c.db.StoreThreadReply(tx, nil, reply); err != nil {
+				return err
+			}
+		}
+		return c.db.LogPM(tx, uid, false, myNick, msg, now)
 	})
 	if err != nil {
 		return err
 	}
-	return ru.sendPM(msg)
+	if err := ru.sendPM(msg, &msgID, replyTo); err != nil {
+		return err
+	}
+	c.syncDevicesPM(uid, myNick, msg, now)
+	return nil
 }
 
 // Handshake starts a 3-way handshake with the specified user. When the local
@@ -1039,6 +1399,15 @@ func (c *Client) maybeResetAllKXAfterConn(expDays int) {
 			c.log.Errorf("Unable to handshake idle users: %v", err)
 		}
 
+		// Stop listening for messages from idle users.
+		c.unsubIdleUsersRVs()
+
+		// Reset ratchets with users that have not replied, according
+		// to the ratchet reset policy.
+		if err := c.autoResetUnrepliedRatchets(); err != nil {
+			c.log.Errorf("Unable to reset unreplied ratchets: %v", err)
+		}
+
 		// Remove idle clients users from GCs and posts.
 		if err := c.unsubIdleUsers(0, 0); err != nil {
 			c.log.Errorf("Unable to unsubscribe idle users: %v", err)
@@ -1135,6 +1504,9 @@ func (c *Client) Run(ctx context.Context) error {
 	}
 
 	c.log.Infof("Starting client %s", c.localID.id)
+	if c.cfg.PaymentsMode != PaymentsModeNormal {
+		c.log.Warnf("Payments are restricted (%s mode)", c.cfg.PaymentsMode)
+	}
 
 	// From now on, all initialization data has been loaded. Init
 	// subsystems.
@@ -1335,5 +1707,37 @@ func (c *Client) Run(ctx context.Context) error {
 	// Restart tracking tip receiving.
 	g.Go(func() error { return c.restartTrackGeneratedTipInvoices(gctx) })
 
+	// Restart tracking on-chain tips.
+	g.Go(func() error { return c.restartOnchainTips(gctx) })
+	g.Go(func() error { return c.runEscrows(gctx) })
+
+	// Prune GC messages according to their retention policies.
+	g.Go(func() error { return c.runGCRetention(gctx) })
+
+	// Unshare files whose shares expired.
+	g.Go(func() error { return c.runShareExpiry(gctx) })
+
+	// Publish scheduled posts.
+	g.Go(func() error { return c.runScheduledPosts(gctx) })
+
+	// Pay the recurring tips.
+	g.Go(func() error { return c.runRecurringTips(gctx) })
+
+	// Deliver payment events to webhooks.
+	g.Go(func() error { return c.runWebhooks(gctx) })
+
+	// Listen for messages from the paired devices.
+	g.Go(func() error { return c.listenDeviceLinks(gctx) })
+
+	// Refresh the content index.
+	if c.cfg.ContentIndexRefreshInterval > 0 {
+		g.Go(func() error {
+			if err := waitAfterFirstConn(5 * time.Second); err != nil {
+				return err
+			}
+			return c.runContentIndexRefresh(gctx)
+		})
+	}
+
 	return g.Wait()
 }
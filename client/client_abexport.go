@@ -0,0 +1,312 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/sw"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/crypto/argon2"
+)
+
+// Address book export flow:
+//
+// ExportAddressBook serializes the contacts of the local client (identity,
+// nick, verification state and tags) and encrypts them with a key derived
+// from a passphrase. The resulting bundle may be imported in a different
+// client (for example, in a new machine or in a different account) with
+// ImportAddressBook, which merges the exported data into the existing
+// contacts.
+//
+// Exported contacts cannot be added to the address book of the importing
+// client without a KX, as there are no ratchets to communicate with them.
+// Those contacts are returned in the import result, so that the user may
+// KX with them.
+
+const (
+	// abExportVersion is the current version of the address book export
+	// bundle.
+	abExportVersion = 1
+
+	// abExportSaltSize is the size of the random salt used to derive the
+	// encryption key from the passphrase.
+	abExportSaltSize = 16
+
+	// abExportMinPassLen is the min length of the export passphrase.
+	abExportMinPassLen = 8
+)
+
+// AddressBookExportContact is a contact stored in an address book export.
+type AddressBookExportContact struct {
+	ID           zkidentity.PublicIdentity     `json:"id"`
+	NickAlias    string                        `json:"nick_alias"`
+	Verification *clientdb.ContactVerification `json:"verification,omitempty"`
+	Tags         []string                      `json:"tags,omitempty"`
+}
+
+// AddressBookExport is the decrypted contents of an address book export.
+type AddressBookExport struct {
+	Version  int                        `json:"version"`
+	Created  time.Time                  `json:"created"`
+	Exporter UserID                     `json:"exporter"`
+	Contacts []AddressBookExportContact `json:"contacts"`
+}
+
+// AddressBookMergeStrategy is the strategy used to resolve conflicts between
+// the imported data and the data of existing contacts.
+type AddressBookMergeStrategy string
+
+const (
+	// ABMergeKeepLocal keeps the local data of existing contacts, only
+	// adding missing data (tags and verification) from the import.
+	ABMergeKeepLocal AddressBookMergeStrategy = "keeplocal"
+
+	// ABMergePreferImported replaces the local nick of existing contacts
+	// with the imported one, in addition to adding missing data.
+	ABMergePreferImported AddressBookMergeStrategy = "preferimported"
+)
+
+// IsValid returns true if this is a known merge strategy.
+func (s AddressBookMergeStrategy) IsValid() bool {
+	return s == ABMergeKeepLocal || s == ABMergePreferImported
+}
+
+// AddressBookImportConflict is an imported contact that could not be fully
+// merged into the existing contact.
+type AddressBookImportConflict struct {
+	UID    UserID
+	Nick   string
+	Reason string
+}
+
+// AddressBookImportResult is the result of importing an address book.
+type AddressBookImportResult struct {
+	// Merged are the existing contacts that were modified by the import.
+	Merged []UserID
+
+	// Unchanged are the existing contacts that were not modified.
+	Unchanged []UserID
+
+	// Conflicts are the existing contacts with data that could not be
+	// merged.
+	Conflicts []AddressBookImportConflict
+
+	// Unknown are the imported contacts that are not in the local address
+	// book. A KX is needed to add them.
+	Unknown []AddressBookExportContact
+}
+
+// abExportKey derives the encryption key of an address book export from the
+// passphrase and salt.
+func abExportKey(passphrase string, salt []byte) *[32]byte {
+	var key [32]byte
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32))
+	return &key
+}
+
+// ExportAddressBook exports the contacts of the local client, encrypted with
+// a key derived from the given passphrase.
+func (c *Client) ExportAddressBook(passphrase string) ([]byte, error) {
+	if len(passphrase) < abExportMinPassLen {
+		return nil, fmt.Errorf("passphrase must have at least %d "+
+			"characters", abExportMinPassLen)
+	}
+
+	export := AddressBookExport{
+		Version:  abExportVersion,
+		Created:  time.Now(),
+		Exporter: c.PublicID(),
+		Contacts: []AddressBookExportContact{},
+	}
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		for _, uid := range c.rul.userList() {
+			ab, err := c.db.GetAddressBookEntry(tx, uid)
+			if err != nil {
+				return err
+			}
+			contact := AddressBookExportContact{
+				ID:        *ab.ID,
+				NickAlias: ab.NickAlias,
+			}
+			cv, err := c.db.GetContactVerification(tx, uid)
+			if err == nil {
+				contact.Verification = cv
+			} else if !errors.Is(err, clientdb.ErrNotFound) {
+				return err
+			}
+			meta, err := c.db.GetContactMetadata(tx, uid)
+			if err != nil {
+				return err
+			}
+			contact.Tags = meta.Tags
+			export.Contacts = append(export.Contacts, contact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, abExportSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	box, err := sw.Seal(data, abExportKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Infof("Exported address book with %d contacts", len(export.Contacts))
+	return append(salt, box...), nil
+}
+
+// DecodeAddressBookExport decrypts the address book export bundle using the
+// passphrase specified when exporting it.
+func DecodeAddressBookExport(passphrase string, bundle []byte) (*AddressBookExport, error) {
+	if len(bundle) < abExportSaltSize+sw.MinPackedEncryptedSize {
+		return nil, errors.New("address book export is too short")
+	}
+	salt, box := bundle[:abExportSaltSize], bundle[abExportSaltSize:]
+	data, ok := sw.Open(box, abExportKey(passphrase, salt))
+	if !ok {
+		return nil, errors.New("unable to decrypt address book export " +
+			"(wrong passphrase?)")
+	}
+
+	export := new(AddressBookExport)
+	if err := json.Unmarshal(data, export); err != nil {
+		return nil, fmt.Errorf("unable to decode address book export: %v", err)
+	}
+	if export.Version != abExportVersion {
+		return nil, fmt.Errorf("unsupported address book export version %d",
+			export.Version)
+	}
+	return export, nil
+}
+
+// mergeImportedContact merges the data of an imported contact into the
+// existing contact. Returns whether the contact was modified.
+func (c *Client) mergeImportedContact(ru *RemoteUser, contact *AddressBookExportContact,
+	strategy AddressBookMergeStrategy) (bool, error) {
+
+	uid := ru.ID()
+	ab, err := c.getAddressBookEntry(uid)
+	if err != nil {
+		return false, err
+	}
+	keysHash := contactKeysHash(ab.ID)
+	if contactKeysHash(&contact.ID) != keysHash {
+		return false, errors.New("imported keys differ from the keys " +
+			"of the local contact")
+	}
+
+	var modified bool
+	if strategy == ABMergePreferImported && contact.NickAlias != "" &&
+		contact.NickAlias != ru.Nick() {
+
+		if err := c.RenameUser(uid, contact.NickAlias); err != nil {
+			return false, err
+		}
+		modified = true
+	}
+
+	var newTags []string
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		meta, err := c.db.GetContactMetadata(tx, uid)
+		if err != nil {
+			return err
+		}
+		for _, tag := range contact.Tags {
+			tag = normalizeContactTag(tag)
+			if tag != "" && !meta.HasTag(tag) {
+				newTags = append(newTags, tag)
+			}
+		}
+
+		// Only verifications of the current keys of the contact are
+		// imported.
+		cv := contact.Verification
+		if !cv.IsVerified() || cv.KeysHash != keysHash {
+			return nil
+		}
+		localCV, err := c.db.GetContactVerification(tx, uid)
+		if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+			return err
+		}
+		if localCV.IsVerified() {
+			return nil
+		}
+		modified = true
+		return c.db.StoreContactVerification(tx, uid, &clientdb.ContactVerification{
+			VerifiedAt: cv.VerifiedAt,
+			KeysHash:   cv.KeysHash,
+		})
+	})
+	if err != nil {
+		return modified, err
+	}
+	if len(newTags) > 0 {
+		if err := c.AddContactTags(uid, newTags...); err != nil {
+			return modified, err
+		}
+		modified = true
+	}
+	return modified, nil
+}
+
+// ImportAddressBook merges the contacts of a decoded address book export into
+// the local address book, resolving conflicts with existing contacts using
+// the given strategy.
+//
+// Tags of existing contacts are always merged. Verifications are only
+// imported when they refer to the current keys of the contact.
+func (c *Client) ImportAddressBook(export *AddressBookExport,
+	strategy AddressBookMergeStrategy) (*AddressBookImportResult, error) {
+
+	if !strategy.IsValid() {
+		return nil, fmt.Errorf("unknown merge strategy %q", strategy)
+	}
+
+	res := new(AddressBookImportResult)
+	me := c.PublicID()
+	for i := range export.Contacts {
+		contact := &export.Contacts[i]
+		uid := contact.ID.Identity
+		if uid == me {
+			continue
+		}
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			res.Unknown = append(res.Unknown, *contact)
+			continue
+		}
+
+		modified, err := c.mergeImportedContact(ru, contact, strategy)
+		switch {
+		case err != nil:
+			res.Conflicts = append(res.Conflicts, AddressBookImportConflict{
+				UID:    uid,
+				Nick:   ru.Nick(),
+				Reason: err.Error(),
+			})
+		case modified:
+			res.Merged = append(res.Merged, uid)
+		default:
+			res.Unchanged = append(res.Unchanged, uid)
+		}
+	}
+
+	c.log.Infof("Imported address book: %d merged, %d unchanged, %d "+
+		"conflicts, %d unknown", len(res.Merged), len(res.Unchanged),
+		len(res.Conflicts), len(res.Unknown))
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// ArchiveChat archives the PM conversation with a user or a GC. target is
+// either the ID of the user or of the GC. Archived chats are automatically
+// unarchived when a new message is received on them.
+func (c *Client) ArchiveChat(target zkidentity.ShortID) error {
+	if _, err := c.resolveChatTarget(target); err != nil {
+		return err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.ArchiveChat(tx, target)
+	})
+	if err == nil {
+		c.log.Infof("Archived chat %s", target)
+	}
+	return err
+}
+
+// UnarchiveChat removes the archived status of a chat.
+func (c *Client) UnarchiveChat(target zkidentity.ShortID) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, err := c.db.UnarchiveChat(tx, target)
+		return err
+	})
+}
+
+// IsChatArchived returns true if the given chat is archived.
+func (c *Client) IsChatArchived(target zkidentity.ShortID) bool {
+	var archived bool
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		archived, err = c.db.IsChatArchived(tx, target)
+		return err
+	})
+	if err != nil {
+		c.log.Warnf("Unable to check if chat %s is archived: %v", target, err)
+	}
+	return archived
+}
+
+// ListArchivedChats returns the IDs of the archived chats (users or GCs),
+// along with the time they were archived.
+func (c *Client) ListArchivedChats() (map[zkidentity.ShortID]time.Time, error) {
+	var res map[zkidentity.ShortID]time.Time
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListArchivedChats(tx)
+		return err
+	})
+	return res, err
+}
+
+// unarchiveOnNewMsg unarchives the given chat (if it was archived) because a
+// new message was received on it.
+func (c *Client) unarchiveOnNewMsg(id zkidentity.ShortID) {
+	var unarchived bool
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		unarchived, err = c.db.UnarchiveChat(tx, id)
+		return err
+	})
+	if err != nil {
+		c.log.Warnf("Unable to unarchive chat %s: %v", id, err)
+		return
+	}
+	if unarchived {
+		c.log.Infof("Unarchived chat %s due to new message", id)
+		c.ntfns.notifyChatUnarchived(id)
+	}
+}
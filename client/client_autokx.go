@@ -72,6 +72,9 @@ func (c *Client) RequestMediateIdentity(mediator, target UserID) error {
 // maybeRequestMediateID checks if there are outstanding KX or transitive KX
 // attempts for the given target, and if there aren't, starts one using the
 // specified mediator.
+//
+// Returns errAutoKXRateLimited if the rate limit of the auto KX policy has
+// been reached.
 func (c *Client) maybeRequestMediateID(mediator, target UserID) error {
 
 	// Fast check if user exists.
@@ -92,6 +95,7 @@ func (c *Client) maybeRequestMediateID(mediator, target UserID) error {
 	}
 
 	// User does not exist. Check for outstanding KX/MI requests.
+	policy := c.autoKXPolicy()
 	errIgnore := errors.New("ignore")
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		kxs, err := c.db.HasKXWithUser(tx, target)
@@ -109,6 +113,9 @@ func (c *Client) maybeRequestMediateID(mediator, target UserID) error {
 		if hasRecent {
 			return errIgnore
 		}
+		if !c.allowAutoKXRequest(policy.MaxRequestsPerHour) {
+			return errAutoKXRateLimited
+		}
 
 		// Store total nb of MI requests.
 		unkx, err := c.db.ReadUnxkdUserInfo(tx, target)
@@ -145,6 +152,13 @@ func (c *Client) handleMediateID(ru *RemoteUser, mi rpc.RMMediateIdentity) error
 		c.cfg.TransitiveEvent(ru.ID(), mi.Identity, TEMediateID)
 	}
 
+	policy := c.autoKXPolicy().ForContact(ru.ID())
+	if policy.MediateID == clientdb.AutoKXActionReject {
+		ru.log.Infof("Rejecting request to mediate id to %s due to "+
+			"auto KX policy", zkidentity.ShortID(mi.Identity))
+		return nil
+	}
+
 	target, err := c.rul.byID(mi.Identity)
 	if err != nil {
 		ru.log.Warnf("Asked to mediate id to unknown user %s",
@@ -170,6 +184,13 @@ func (c *Client) handleRMInvite(ru *RemoteUser, iv rpc.RMInvite) error {
 		c.cfg.TransitiveEvent(ru.ID(), iv.Invitee.Identity, TERequestInvite)
 	}
 
+	policy := c.autoKXPolicy().ForContact(ru.ID())
+	if policy.MediatedKX == clientdb.AutoKXActionReject {
+		ru.log.Infof("Rejecting mediated KX with %s due to auto KX "+
+			"policy", iv.Invitee.Identity)
+		return nil
+	}
+
 	// Generate an invite.
 	mediatorID := ru.ID()
 	pii, err := c.kxl.createInvite(nil, &iv.Invitee, &mediatorID, false, nil)
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// errAutoKXRateLimited is returned when an automatic KX request is not made
+// due to the rate limit of the auto KX policy.
+var errAutoKXRateLimited = errors.New("auto KX request rate limit reached")
+
+// AutoKXPolicy returns the policy used to automatically handle transitive KX
+// events.
+func (c *Client) AutoKXPolicy() (*clientdb.AutoKXPolicy, error) {
+	var policy *clientdb.AutoKXPolicy
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		policy, err = c.db.GetAutoKXPolicy(tx)
+		return err
+	})
+	return policy, err
+}
+
+// SetAutoKXPolicy sets the policy used to automatically handle transitive KX
+// events.
+func (c *Client) SetAutoKXPolicy(policy *clientdb.AutoKXPolicy) error {
+	if err := policy.Default.Validate(); err != nil {
+		return err
+	}
+	for uid, cp := range policy.Contacts {
+		if err := cp.Validate(); err != nil {
+			return fmt.Errorf("policy for contact %s: %v", uid, err)
+		}
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.UpdateAutoKXPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Updated auto KX policy (%d contact overrides)",
+		len(policy.Contacts))
+	return nil
+}
+
+// SetContactAutoKXPolicy overrides the default auto KX policy for the given
+// contact. Actions that are not specified in the override use the action of
+// the default policy. Setting an empty override removes it.
+func (c *Client) SetContactAutoKXPolicy(uid UserID, cp clientdb.AutoKXContactPolicy) error {
+	if err := cp.Validate(); err != nil {
+		return err
+	}
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		policy, err := c.db.GetAutoKXPolicy(tx)
+		if err != nil {
+			return err
+		}
+		if cp == (clientdb.AutoKXContactPolicy{}) {
+			delete(policy.Contacts, uid.String())
+		} else {
+			if policy.Contacts == nil {
+				policy.Contacts = make(map[string]clientdb.AutoKXContactPolicy)
+			}
+			policy.Contacts[uid.String()] = cp
+		}
+		return c.db.UpdateAutoKXPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	ru.log.Infof("Updated auto KX policy override")
+	return nil
+}
+
+// autoKXPolicy returns the current auto KX policy. Errors loading the policy
+// are logged and an empty (default) policy is returned.
+func (c *Client) autoKXPolicy() *clientdb.AutoKXPolicy {
+	policy, err := c.AutoKXPolicy()
+	if err != nil {
+		c.log.Warnf("Unable to load auto KX policy: %v", err)
+		return new(clientdb.AutoKXPolicy)
+	}
+	return policy
+}
+
+// allowAutoKXRequest returns true if a new automatic KX request can be made
+// under the given hourly limit. If it can, the request is accounted for.
+func (c *Client) allowAutoKXRequest(maxPerHour uint32) bool {
+	if maxPerHour == 0 {
+		return true
+	}
+
+	c.autoKXRequestsMtx.Lock()
+	defer c.autoKXRequestsMtx.Unlock()
+
+	// Drop requests older than one hour.
+	now := time.Now()
+	var i int
+	for i < len(c.autoKXRequests) && now.Sub(c.autoKXRequests[i]) >= time.Hour {
+		i++
+	}
+	c.autoKXRequests = c.autoKXRequests[i:]
+
+	if len(c.autoKXRequests) >= int(maxPerHour) {
+		return false
+	}
+	c.autoKXRequests = append(c.autoKXRequests, now)
+	return true
+}
+
+// gcAutoKXMediator returns the GC member that should be asked to mediate a KX
+// with an unknown member of the GC, according to the policy. Returns nil if
+// there is no such member.
+func (c *Client) gcAutoKXMediator(policy *clientdb.AutoKXPolicy, gcl *rpc.RMGroupList) *clientintf.UserID {
+	if policy.DisableGCRequests || len(gcl.Members) == 0 {
+		return nil
+	}
+
+	localID := c.PublicID()
+	gcOwner := gcl.Members[0]
+	if !policy.RequestViaGCAdmins {
+		if gcOwner == localID {
+			return nil
+		}
+		return &gcOwner
+	}
+
+	// Use the first admin with which the local client has a session.
+	admins := append([]clientintf.UserID{gcOwner}, gcl.ExtraAdmins...)
+	for i := range admins {
+		if admins[i] == localID {
+			continue
+		}
+		if _, err := c.rul.byID(admins[i]); err == nil {
+			return &admins[i]
+		}
+	}
+	return nil
+}
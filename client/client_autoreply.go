@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// DefaultAutoReplyInterval is the default minimum interval between automatic
+// replies sent to the same user.
+const DefaultAutoReplyInterval = 24 * time.Hour
+
+// AutoReplyConfig returns the current config for automatic replies.
+func (c *Client) AutoReplyConfig() (clientdb.AutoReplyConfig, error) {
+	var cfg clientdb.AutoReplyConfig
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		cfg, err = c.db.GetAutoReplyConfig(tx)
+		return err
+	})
+	return cfg, err
+}
+
+// SetAutoReply sets the config for automatic replies. When enabled, the first
+// PM received from each user within the configured interval is automatically
+// replied to with the configured message. If the interval is zero,
+// DefaultAutoReplyInterval is used.
+func (c *Client) SetAutoReply(cfg clientdb.AutoReplyConfig) error {
+	if cfg.Enabled && cfg.Message == "" {
+		return fmt.Errorf("auto reply message cannot be empty")
+	}
+	if cfg.Interval < 0 {
+		return fmt.Errorf("auto reply interval cannot be negative")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultAutoReplyInterval
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.UpdateAutoReplyConfig(tx, cfg)
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.Enabled {
+		c.log.Infof("Enabled auto replies (interval %s)", cfg.Interval)
+	} else {
+		c.log.Infof("Disabled auto replies")
+	}
+	return nil
+}
+
+// maybeAutoReply sends the automatic reply to a user that sent a PM, if
+// automatic replies are enabled and the user was not sent one recently.
+func (c *Client) maybeAutoReply(ru *RemoteUser) {
+	var msg string
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		msg, err = c.db.ShouldAutoReply(tx, ru.ID(), time.Now())
+		return err
+	})
+	if err != nil {
+		ru.log.Warnf("Unable to check auto reply: %v", err)
+		return
+	}
+	if msg == "" {
+		return
+	}
+
+	ru.log.Debugf("Sending auto reply")
+	if err := c.PM(ru.ID(), msg); err != nil {
+		ru.log.Warnf("Unable to send auto reply: %v", err)
+		return
+	}
+	c.ntfns.notifyAutoReplySent(ru, msg)
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// BandwidthLimits are the limits (in bytes per second) of the bandwidth used
+// for content (file transfer) traffic. Chat and other messages are not
+// limited. A zero limit means no limit.
+type BandwidthLimits struct {
+	// Upload and Download are the limits across all remote users.
+	Upload   uint64
+	Download uint64
+
+	// PeerUpload and PeerDownload are the limits for each remote user.
+	PeerUpload   uint64
+	PeerDownload uint64
+}
+
+// newBandwidthLimiter returns a limiter for the given number of bytes per
+// second.
+func newBandwidthLimiter(bps uint64) *rate.Limiter {
+	if bps == 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(bps), int(bps))
+}
+
+// setBandwidthLimit sets the limiter to allow the given number of bytes per
+// second, with bursts of up to one second of traffic.
+func setBandwidthLimit(l *rate.Limiter, bps uint64) {
+	if bps == 0 {
+		l.SetLimit(rate.Inf)
+		return
+	}
+	l.SetLimit(rate.Limit(bps))
+	l.SetBurst(int(bps))
+}
+
+// waitBandwidth waits until the limiter allows n bytes to be transferred.
+func waitBandwidth(ctx context.Context, l *rate.Limiter, n int) error {
+	for n > 0 {
+		// Transfers larger than the burst of the limiter are waited
+		// for in parts.
+		wn := n
+		if l.Limit() != rate.Inf && wn > l.Burst() {
+			wn = l.Burst()
+		}
+		if err := l.WaitN(ctx, wn); err != nil {
+			return err
+		}
+		n -= wn
+	}
+	return nil
+}
+
+// bandwidthThrottler throttles the content traffic to and from remote users.
+type bandwidthThrottler struct {
+	mtx      sync.Mutex
+	limits   BandwidthLimits
+	upload   *rate.Limiter
+	download *rate.Limiter
+	peerUp   map[UserID]*rate.Limiter
+	peerDown map[UserID]*rate.Limiter
+}
+
+func newBandwidthThrottler(limits BandwidthLimits) *bandwidthThrottler {
+	return &bandwidthThrottler{
+		limits:   limits,
+		upload:   newBandwidthLimiter(limits.Upload),
+		download: newBandwidthLimiter(limits.Download),
+		peerUp:   make(map[UserID]*rate.Limiter),
+		peerDown: make(map[UserID]*rate.Limiter),
+	}
+}
+
+// setLimits updates the limits of the throttler, including the limiters of
+// each remote user.
+func (bt *bandwidthThrottler) setLimits(limits BandwidthLimits) {
+	bt.mtx.Lock()
+	bt.limits = limits
+	setBandwidthLimit(bt.upload, limits.Upload)
+	setBandwidthLimit(bt.download, limits.Download)
+	for _, l := range bt.peerUp {
+		setBandwidthLimit(l, limits.PeerUpload)
+	}
+	for _, l := range bt.peerDown {
+		setBandwidthLimit(l, limits.PeerDownload)
+	}
+	bt.mtx.Unlock()
+}
+
+func (bt *bandwidthThrottler) getLimits() BandwidthLimits {
+	bt.mtx.Lock()
+	limits := bt.limits
+	bt.mtx.Unlock()
+	return limits
+}
+
+// wait waits until n bytes may be uploaded to (or downloaded from) the given
+// remote user.
+func (bt *bandwidthThrottler) wait(ctx context.Context, uid UserID, n int, upload bool) error {
+	bt.mtx.Lock()
+	global, peers, peerLimit := bt.download, bt.peerDown, bt.limits.PeerDownload
+	if upload {
+		global, peers, peerLimit = bt.upload, bt.peerUp, bt.limits.PeerUpload
+	}
+	peer := peers[uid]
+	if peer == nil {
+		peer = newBandwidthLimiter(peerLimit)
+		peers[uid] = peer
+	}
+	bt.mtx.Unlock()
+
+	if err := waitBandwidth(ctx, peer, n); err != nil {
+		return err
+	}
+	return waitBandwidth(ctx, global, n)
+}
+
+// SetBandwidthLimits sets the limits of the bandwidth used for content
+// traffic. The new limits apply to the next transferred chunks of files.
+func (c *Client) SetBandwidthLimits(limits BandwidthLimits) {
+	c.bw.setLimits(limits)
+	c.log.Infof("Set content bandwidth limits to upload %d B/s, download "+
+		"%d B/s, per user upload %d B/s, per user download %d B/s",
+		limits.Upload, limits.Download, limits.PeerUpload,
+		limits.PeerDownload)
+}
+
+// BandwidthLimits returns the current limits of the bandwidth used for content
+// traffic.
+func (c *Client) BandwidthLimits() BandwidthLimits {
+	return c.bw.getLimits()
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestBandwidthThrottler tests that the throttler enforces the global and per
+// user bandwidth limits.
+func TestBandwidthThrottler(t *testing.T) {
+	ctx := context.Background()
+	var alice, bob UserID
+	alice[0], bob[0] = 1, 2
+
+	measure := func(f func()) time.Duration {
+		start := time.Now()
+		f()
+		return time.Since(start)
+	}
+
+	// Without limits, transfers are not throttled.
+	bt := newBandwidthThrottler(BandwidthLimits{})
+	d := measure(func() {
+		for i := 0; i < 100; i++ {
+			assert.NilErr(t, bt.wait(ctx, alice, 1<<20, true))
+		}
+	})
+	if d > 100*time.Millisecond {
+		t.Fatalf("unlimited transfers were throttled for %s", d)
+	}
+
+	// With a per user limit, uploads to one user are throttled but
+	// uploads to other users and downloads are not. The first second of
+	// traffic is allowed as a burst.
+	bt.setLimits(BandwidthLimits{PeerUpload: 1000})
+	d = measure(func() {
+		assert.NilErr(t, bt.wait(ctx, alice, 1500, true))
+	})
+	if d < 400*time.Millisecond {
+		t.Fatalf("upload to user not throttled (took %s)", d)
+	}
+	d = measure(func() {
+		assert.NilErr(t, bt.wait(ctx, bob, 1000, true))
+		assert.NilErr(t, bt.wait(ctx, alice, 1000, false))
+	})
+	if d > 100*time.Millisecond {
+		t.Fatalf("unrelated transfers were throttled for %s", d)
+	}
+
+	// The global limit applies across users.
+	bt.setLimits(BandwidthLimits{Download: 1000})
+	d = measure(func() {
+		assert.NilErr(t, bt.wait(ctx, alice, 1000, false))
+		assert.NilErr(t, bt.wait(ctx, bob, 500, false))
+	})
+	if d < 400*time.Millisecond {
+		t.Fatalf("downloads not throttled (took %s)", d)
+	}
+	assert.DeepEqual(t, bt.getLimits(), BandwidthLimits{Download: 1000})
+
+	// Waiting is canceled with the context.
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := bt.wait(ctx, alice, 5000, false)
+	assert.NonNilErr(t, err)
+}
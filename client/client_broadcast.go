@@ -0,0 +1,289 @@
+package client
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// BroadcastStatus is the aggregated delivery status of a broadcast.
+type BroadcastStatus struct {
+	// ID is the ID of the broadcast.
+	ID zkidentity.ShortID
+
+	// List is the name of the broadcast list the message was sent to.
+	List string
+
+	// Timestamp is when the broadcast was sent.
+	Timestamp time.Time
+
+	// MsgIDs are the IDs of the PMs sent to each member of the list.
+	MsgIDs map[UserID]zkidentity.ShortID
+
+	// Statuses are the delivery status of the PM sent to each member of
+	// the list.
+	Statuses map[UserID]MsgDeliveryStatus
+
+	// Errors are the errors that prevented the PM from being sent to
+	// members of the list.
+	Errors map[UserID]error
+}
+
+// Counts returns the number of members in each delivery status.
+func (bs *BroadcastStatus) Counts() map[MsgDeliveryStatus]int {
+	res := make(map[MsgDeliveryStatus]int)
+	for _, status := range bs.Statuses {
+		res[status] += 1
+	}
+	return res
+}
+
+// done returns true if the PMs to all members are either delivered or failed.
+func (bs *BroadcastStatus) done() bool {
+	for _, status := range bs.Statuses {
+		if status != MsgStatusDelivered && status != MsgStatusFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// copy returns a deep copy of the status.
+func (bs *BroadcastStatus) copy() BroadcastStatus {
+	res := *bs
+	res.MsgIDs = make(map[UserID]zkidentity.ShortID, len(bs.MsgIDs))
+	for uid, id := range bs.MsgIDs {
+		res.MsgIDs[uid] = id
+	}
+	res.Statuses = make(map[UserID]MsgDeliveryStatus, len(bs.Statuses))
+	for uid, status := range bs.Statuses {
+		res.Statuses[uid] = status
+	}
+	res.Errors = make(map[UserID]error, len(bs.Errors))
+	for uid, err := range bs.Errors {
+		res.Errors[uid] = err
+	}
+	return res
+}
+
+// StoreBroadcastList creates or replaces the broadcast list with the given name
+// and members. Broadcast lists are only stored locally: members are not
+// notified of being part of a list.
+func (c *Client) StoreBroadcastList(name string, members []UserID) error {
+	if name == "" {
+		return errors.New("broadcast list name cannot be empty")
+	}
+
+	// Ensure members are known and unique.
+	seen := make(map[UserID]bool, len(members))
+	uniq := make([]UserID, 0, len(members))
+	for _, uid := range members {
+		if seen[uid] {
+			continue
+		}
+		if _, err := c.rul.byID(uid); err != nil {
+			return err
+		}
+		seen[uid] = true
+		uniq = append(uniq, uid)
+	}
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreBroadcastList(tx, clientdb.BroadcastList{
+			Name:    name,
+			Members: uniq,
+		})
+	})
+}
+
+// AddToBroadcastList adds users to an existing broadcast list.
+func (c *Client) AddToBroadcastList(name string, uids ...UserID) error {
+	bl, err := c.GetBroadcastList(name)
+	if err != nil {
+		return err
+	}
+	return c.StoreBroadcastList(name, append(bl.Members, uids...))
+}
+
+// RemoveFromBroadcastList removes users from an existing broadcast list.
+func (c *Client) RemoveFromBroadcastList(name string, uids ...UserID) error {
+	bl, err := c.GetBroadcastList(name)
+	if err != nil {
+		return err
+	}
+	members := make([]UserID, 0, len(bl.Members))
+	for _, member := range bl.Members {
+		var remove bool
+		for _, uid := range uids {
+			remove = remove || uid == member
+		}
+		if !remove {
+			members = append(members, member)
+		}
+	}
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		bl.Members = members
+		return c.db.StoreBroadcastList(tx, bl)
+	})
+}
+
+// RemoveBroadcastList removes a broadcast list.
+func (c *Client) RemoveBroadcastList(name string) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemoveBroadcastList(tx, name)
+	})
+}
+
+// GetBroadcastList returns the broadcast list with the given name.
+func (c *Client) GetBroadcastList(name string) (clientdb.BroadcastList, error) {
+	var bl clientdb.BroadcastList
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		bl, err = c.db.GetBroadcastList(tx, name)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		err = fmt.Errorf("broadcast list %q does not exist", name)
+	}
+	return bl, err
+}
+
+// ListBroadcastLists returns the existing broadcast lists.
+func (c *Client) ListBroadcastLists() ([]clientdb.BroadcastList, error) {
+	var res []clientdb.BroadcastList
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListBroadcastLists(tx)
+		return err
+	})
+	return res, err
+}
+
+// SendBroadcast sends the message as an individual PM to each member of the
+// broadcast list. Unlike GC messages, members do not know who else received
+// the message.
+//
+// Failure to send the PM to some of the members does not cause an error: the
+// returned status tracks the individual errors. The delivery status of the
+// broadcast is updated as the PMs are sent and acknowledged and may be fetched
+// with BroadcastStatus or tracked with the OnBroadcastStatusNtfn notification.
+func (c *Client) SendBroadcast(name, msg string) (BroadcastStatus, error) {
+	bl, err := c.GetBroadcastList(name)
+	if err != nil {
+		return BroadcastStatus{}, err
+	}
+	if len(bl.Members) == 0 {
+		return BroadcastStatus{}, fmt.Errorf("broadcast list %q is empty", name)
+	}
+
+	bs := &BroadcastStatus{
+		List:      name,
+		Timestamp: time.Now(),
+		MsgIDs:    make(map[UserID]zkidentity.ShortID, len(bl.Members)),
+		Statuses:  make(map[UserID]MsgDeliveryStatus, len(bl.Members)),
+		Errors:    make(map[UserID]error),
+	}
+	if _, err := rand.Read(bs.ID[:]); err != nil {
+		return BroadcastStatus{}, err
+	}
+
+	// Track the IDs of all msgs before sending them, so that no status
+	// updates are missed.
+	c.broadcastsMtx.Lock()
+	for _, uid := range bl.Members {
+		var msgID zkidentity.ShortID
+		if _, err := rand.Read(msgID[:]); err != nil {
+			c.broadcastsMtx.Unlock()
+			return BroadcastStatus{}, err
+		}
+		bs.MsgIDs[uid] = msgID
+		bs.Statuses[uid] = MsgStatusQueued
+		c.broadcastMsgs[msgID] = bs.ID
+	}
+	c.broadcasts[bs.ID] = bs
+	c.broadcastsMtx.Unlock()
+
+	c.log.Infof("Sending broadcast %s to %d members of list %q", bs.ID,
+		len(bl.Members), name)
+	for _, uid := range bl.Members {
+		err := c.pmWithID(uid, bs.MsgIDs[uid], msg, nil)
+		if err == nil {
+			continue
+		}
+		c.log.Warnf("Unable to send broadcast %s to %s: %v", bs.ID, uid, err)
+		c.broadcastsMtx.Lock()
+		bs.Errors[uid] = err
+		c.broadcastsMtx.Unlock()
+		c.updateBroadcastStatus(uid, bs.MsgIDs[uid], MsgStatusFailed)
+	}
+
+	c.broadcastsMtx.Lock()
+	res := bs.copy()
+	c.broadcastsMtx.Unlock()
+	return res, nil
+}
+
+// BroadcastStatus returns the delivery status of a broadcast sent since the
+// client started.
+func (c *Client) BroadcastStatus(id zkidentity.ShortID) (BroadcastStatus, error) {
+	c.broadcastsMtx.Lock()
+	defer c.broadcastsMtx.Unlock()
+	bs, ok := c.broadcasts[id]
+	if !ok {
+		return BroadcastStatus{}, fmt.Errorf("broadcast %s not found", id)
+	}
+	return bs.copy(), nil
+}
+
+// ListBroadcasts returns the status of the broadcasts sent since the client
+// started, from oldest to newest.
+func (c *Client) ListBroadcasts() []BroadcastStatus {
+	c.broadcastsMtx.Lock()
+	res := make([]BroadcastStatus, 0, len(c.broadcasts))
+	for _, bs := range c.broadcasts {
+		res = append(res, bs.copy())
+	}
+	c.broadcastsMtx.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Timestamp.Before(res[j].Timestamp)
+	})
+	return res
+}
+
+// updateBroadcastStatus updates the status of a PM if it was sent as part of a
+// broadcast.
+func (c *Client) updateBroadcastStatus(uid UserID, msgID zkidentity.ShortID,
+	status MsgDeliveryStatus) {
+
+	c.broadcastsMtx.Lock()
+	id, ok := c.broadcastMsgs[msgID]
+	if !ok {
+		c.broadcastsMtx.Unlock()
+		return
+	}
+	bs := c.broadcasts[id]
+	old, ok := bs.Statuses[uid]
+	if !ok || status <= old {
+		// Ignore out of order updates.
+		c.broadcastsMtx.Unlock()
+		return
+	}
+	bs.Statuses[uid] = status
+	if status == MsgStatusDelivered || status == MsgStatusFailed {
+		// No more updates are expected for this msg.
+		delete(c.broadcastMsgs, msgID)
+	}
+	res := bs.copy()
+	done := bs.done()
+	c.broadcastsMtx.Unlock()
+
+	if done {
+		c.log.Infof("Broadcast %s to list %q completed", id, bs.List)
+	}
+	c.ntfns.notifyBroadcastStatus(res)
+}
@@ -0,0 +1,276 @@
+package client
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+const (
+	// paymentBudgetDay and paymentBudgetMonth are the (rolling) periods
+	// of the daily and monthly payment budgets.
+	paymentBudgetDay   = 24 * time.Hour
+	paymentBudgetMonth = 30 * 24 * time.Hour
+)
+
+// PaymentBudget limits the amount spent in outbound payments.
+type PaymentBudget = clientdb.PaymentBudget
+
+// PaymentBudgetStatus is a payment budget and the amounts spent within its
+// daily and monthly periods.
+type PaymentBudgetStatus struct {
+	Budget       PaymentBudget
+	DailySpent   dcrutil.Amount
+	MonthlySpent dcrutil.Amount
+}
+
+// SetPaymentBudget creates or replaces a payment budget. The daily and
+// monthly limits are applied over the last 24 hours and 30 days respectively.
+func (c *Client) SetPaymentBudget(budget PaymentBudget) error {
+	switch budget.Scope {
+	case clientdb.PaymentBudgetGlobal:
+		budget.ID = zkidentity.ShortID{}
+	case clientdb.PaymentBudgetUser:
+		if _, err := c.rul.byID(budget.ID); err != nil {
+			return err
+		}
+	case clientdb.PaymentBudgetGC:
+		if _, err := c.GetGC(budget.ID); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown payment budget scope %q", budget.Scope)
+	}
+	if budget.DailyAtoms < 0 || budget.MonthlyAtoms < 0 {
+		return errors.New("payment budget limits cannot be negative")
+	}
+	if budget.DailyAtoms == 0 && budget.MonthlyAtoms == 0 {
+		return errors.New("payment budget must have a daily or monthly limit")
+	}
+
+	c.paymentBudgetMtx.Lock()
+	defer c.paymentBudgetMtx.Unlock()
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePaymentBudget(tx, budget)
+	})
+}
+
+// RemovePaymentBudget removes the payment budget with the given scope and id.
+func (c *Client) RemovePaymentBudget(scope clientdb.PaymentBudgetScope, id zkidentity.ShortID) error {
+	c.paymentBudgetMtx.Lock()
+	defer c.paymentBudgetMtx.Unlock()
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemovePaymentBudget(tx, scope, id)
+	})
+}
+
+// spendsFor returns the amount spent in the spends that fall in the scope of
+// the budget and that were made after the given time.
+func spendsFor(budget *PaymentBudget, spends []clientdb.PaymentSpend, since time.Time) dcrutil.Amount {
+	var total int64
+	for _, s := range spends {
+		if !s.Time.After(since) {
+			continue
+		}
+		switch {
+		case budget.Scope == clientdb.PaymentBudgetGlobal:
+		case budget.Scope == clientdb.PaymentBudgetUser && s.UID == budget.ID:
+		case budget.Scope == clientdb.PaymentBudgetGC && s.GC != nil && *s.GC == budget.ID:
+		default:
+			continue
+		}
+		total += s.MilliAtoms
+	}
+	return dcrutil.Amount(total / 1000)
+}
+
+// PaymentBudgets returns the payment budgets and the amounts spent within
+// them.
+func (c *Client) PaymentBudgets() ([]PaymentBudgetStatus, error) {
+	var budgets []PaymentBudget
+	var spends []clientdb.PaymentSpend
+	now := time.Now()
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		budgets, err = c.db.ListPaymentBudgets(tx)
+		if err != nil {
+			return err
+		}
+		spends, err = c.db.ListPaymentSpends(tx, now.Add(-paymentBudgetMonth))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PaymentBudgetStatus, len(budgets))
+	for i := range budgets {
+		res[i] = PaymentBudgetStatus{
+			Budget:       budgets[i],
+			DailySpent:   spendsFor(&budgets[i], spends, now.Add(-paymentBudgetDay)),
+			MonthlySpent: spendsFor(&budgets[i], spends, now.Add(-paymentBudgetMonth)),
+		}
+	}
+	return res, nil
+}
+
+// checkPaymentBudgets returns an error if spending the given amount would
+// exceed any of the budgets that apply to the payment.
+func checkPaymentBudgets(budgets []PaymentBudget, spends []clientdb.PaymentSpend,
+	spend *clientdb.PaymentSpend) error {
+
+	amount := dcrutil.Amount(spend.MilliAtoms / 1000)
+	for i := range budgets {
+		b := &budgets[i]
+		switch {
+		case b.Scope == clientdb.PaymentBudgetGlobal:
+		case b.Scope == clientdb.PaymentBudgetUser && b.ID == spend.UID:
+		case b.Scope == clientdb.PaymentBudgetGC && spend.GC != nil && *spend.GC == b.ID:
+		default:
+			continue
+		}
+
+		limits := []struct {
+			period string
+			limit  int64
+			window time.Duration
+		}{
+			{"daily", b.DailyAtoms, paymentBudgetDay},
+			{"monthly", b.MonthlyAtoms, paymentBudgetMonth},
+		}
+		for _, l := range limits {
+			if l.limit <= 0 {
+				continue
+			}
+			spent := spendsFor(b, spends, spend.Time.Add(-l.window))
+			if spent+amount > dcrutil.Amount(l.limit) {
+				return PaymentBudgetExceededError{
+					Budget: *b,
+					Period: l.period,
+					Limit:  dcrutil.Amount(l.limit),
+					Spent:  spent,
+					Amount: amount,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tryReservePaymentSpend records the spend if it does not exceed the payment
+// budgets (or if override is true).
+func (c *Client) tryReservePaymentSpend(spend *clientdb.PaymentSpend, override bool) error {
+	c.paymentBudgetMtx.Lock()
+	defer c.paymentBudgetMtx.Unlock()
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if !override {
+			budgets, err := c.db.ListPaymentBudgets(tx)
+			if err != nil {
+				return err
+			}
+			since := spend.Time.Add(-paymentBudgetMonth)
+			spends, err := c.db.ListPaymentSpends(tx, since)
+			if err != nil {
+				return err
+			}
+			if err := checkPaymentBudgets(budgets, spends, spend); err != nil {
+				return err
+			}
+		}
+		return c.db.RecordPaymentSpend(tx, *spend)
+	})
+}
+
+// confirmPaymentBudgetOverride asks for confirmation to make a payment that
+// exceeds a payment budget.
+func (c *Client) confirmPaymentBudgetOverride(err error) bool {
+	var budgetErr PaymentBudgetExceededError
+	if !errors.As(err, &budgetErr) || c.cfg.PaymentBudgetConfirmer == nil {
+		return false
+	}
+	if !c.cfg.PaymentBudgetConfirmer(budgetErr) {
+		return false
+	}
+	c.log.Infof("Confirmed payment that exceeds budget: %v", budgetErr)
+	return true
+}
+
+// checkPaymentBudget checks whether a payment of the given amount to the user
+// exceeds any of the payment budgets. If it does and the override is
+// confirmed, this returns true. Otherwise, it returns the budget error.
+func (c *Client) checkPaymentBudget(uid UserID, gc *zkidentity.ShortID, mAtoms int64) (bool, error) {
+	spend := clientdb.PaymentSpend{
+		Time:       time.Now(),
+		UID:        uid,
+		GC:         gc,
+		MilliAtoms: mAtoms,
+	}
+	var budgets []PaymentBudget
+	var spends []clientdb.PaymentSpend
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		budgets, err = c.db.ListPaymentBudgets(tx)
+		if err != nil {
+			return err
+		}
+		spends, err = c.db.ListPaymentSpends(tx, spend.Time.Add(-paymentBudgetMonth))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	err = checkPaymentBudgets(budgets, spends, &spend)
+	if err == nil {
+		return false, nil
+	}
+	if c.confirmPaymentBudgetOverride(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// reservePaymentSpend reserves the amount of an outbound payment in the
+// payment budgets. It must be called before making any payment. If the
+// payment exceeds a budget, it is reserved only if override is true or if
+// the override is confirmed. Payments are never reserved when the payments
+// mode of the client disallows them.
+//
+// The spend must be released with releasePaymentSpend if the payment fails.
+func (c *Client) reservePaymentSpend(uid UserID, gc *zkidentity.ShortID, mAtoms int64,
+	override bool) (zkidentity.ShortID, error) {
+
+	if err := c.checkPaymentsAllowed("payment to user"); err != nil {
+		return zkidentity.ShortID{}, err
+	}
+
+	spend := clientdb.PaymentSpend{
+		Time:       time.Now(),
+		UID:        uid,
+		GC:         gc,
+		MilliAtoms: mAtoms,
+	}
+	if _, err := rand.Read(spend.ID[:]); err != nil {
+		return spend.ID, err
+	}
+	err := c.tryReservePaymentSpend(&spend, override)
+	if err != nil && !override && c.confirmPaymentBudgetOverride(err) {
+		err = c.tryReservePaymentSpend(&spend, true)
+	}
+	return spend.ID, err
+}
+
+// releasePaymentSpend releases a spend reserved for a payment that failed.
+func (c *Client) releasePaymentSpend(id zkidentity.ShortID) {
+	c.paymentBudgetMtx.Lock()
+	defer c.paymentBudgetMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemovePaymentSpend(tx, id)
+	})
+	if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+		c.log.Errorf("Unable to release payment spend %s: %v", id, err)
+	}
+}
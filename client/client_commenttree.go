@@ -0,0 +1,237 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// MaxPostCommentDepth is the maximum depth of replies to comments on posts.
+// Top-level comments have depth zero. Replies to comments at the maximum depth
+// are rejected by the post author.
+const MaxPostCommentDepth = 16
+
+// PostComment is a comment on a post, along with its replies.
+type PostComment struct {
+	// ID is the ID of the status update of the comment.
+	ID clientintf.ID
+
+	// Status is the status update of the comment.
+	Status rpc.PostMetadataStatus
+
+	// Parent is the ID of the comment this comment replies to. It is nil
+	// for top-level comments.
+	Parent *clientintf.ID
+
+	// Depth is the depth of the comment in the tree of comments.
+	Depth int
+
+	// Children are the replies to the comment, in the order they were
+	// received.
+	Children []*PostComment
+
+	// Hidden and Flagged are set when the post author hid or flagged the
+	// comment.
+	Hidden  bool
+	Flagged bool
+}
+
+// postCommentModeration is the moderation state of a comment.
+type postCommentModeration struct {
+	hidden  bool
+	flagged bool
+}
+
+// postCommentsModeration returns the moderation state of the comments of a
+// post, given its status updates. Later moderation actions override earlier
+// ones.
+func postCommentsModeration(statuses []rpc.PostMetadataStatus) map[clientintf.ID]postCommentModeration {
+	res := make(map[clientintf.ID]postCommentModeration)
+	for _, status := range statuses {
+		action := status.Attributes[rpc.RMPSModeration]
+		if action == "" {
+			continue
+		}
+		var target clientintf.ID
+		if err := target.FromString(status.Attributes[rpc.RMPSModerationTarget]); err != nil {
+			continue
+		}
+		mod := res[target]
+		switch action {
+		case rpc.RMPSModerationHide:
+			mod.hidden = true
+		case rpc.RMPSModerationUnhide:
+			mod.hidden = false
+		case rpc.RMPSModerationFlag:
+			mod.flagged = true
+		case rpc.RMPSModerationUnflag:
+			mod.flagged = false
+		}
+		res[target] = mod
+	}
+	return res
+}
+
+// BuildPostCommentTree assembles the comments in the given status updates of a
+// post into a tree, returning the top-level comments. Replies may be received
+// before the comment they reply to, and are attached to it regardless of the
+// order of the status updates. Comments whose parent is unknown are treated as
+// top-level comments. Comments deeper than MaxPostCommentDepth are attached to
+// their closest ancestor that allows replies. Comments are marked according to
+// the moderation actions of the post author.
+func BuildPostCommentTree(statuses []rpc.PostMetadataStatus) []*PostComment {
+	var order []*PostComment
+	comments := make(map[clientintf.ID]*PostComment)
+	moderation := postCommentsModeration(statuses)
+	for _, status := range statuses {
+		if _, ok := status.Attributes[rpc.RMPSComment]; !ok {
+			continue
+		}
+
+		cmt := &PostComment{ID: status.Hash(), Status: status}
+		if _, ok := comments[cmt.ID]; ok {
+			continue
+		}
+		cmt.Hidden = moderation[cmt.ID].hidden
+		cmt.Flagged = moderation[cmt.ID].flagged
+		if s, ok := status.Attributes[rpc.RMPParent]; ok {
+			var id clientintf.ID
+			if err := id.FromString(s); err == nil {
+				cmt.Parent = &id
+			}
+		}
+		comments[cmt.ID] = cmt
+		order = append(order, cmt)
+	}
+
+	// Determine the depth of each comment and the comment it is attached
+	// to, resolving the parents first. Comments that are (indirectly)
+	// their own parents are treated as top-level comments.
+	const (
+		unresolved = iota
+		resolving
+		resolved
+	)
+	state := make(map[clientintf.ID]int, len(order))
+	attachedTo := make(map[clientintf.ID]*PostComment, len(order))
+	var resolve func(cmt *PostComment)
+	resolve = func(cmt *PostComment) {
+		if state[cmt.ID] != unresolved {
+			return
+		}
+		state[cmt.ID] = resolving
+		var parent *PostComment
+		if cmt.Parent != nil {
+			parent = comments[*cmt.Parent]
+		}
+		if parent != nil {
+			resolve(parent)
+			if state[parent.ID] != resolved {
+				parent = nil
+			}
+		}
+		for parent != nil && parent.Depth >= MaxPostCommentDepth {
+			parent = attachedTo[parent.ID]
+		}
+		if parent != nil {
+			cmt.Depth = parent.Depth + 1
+			attachedTo[cmt.ID] = parent
+		}
+		state[cmt.ID] = resolved
+	}
+
+	var roots []*PostComment
+	for _, cmt := range order {
+		resolve(cmt)
+	}
+	for _, cmt := range order {
+		if parent := attachedTo[cmt.ID]; parent != nil {
+			parent.Children = append(parent.Children, cmt)
+		} else {
+			roots = append(roots, cmt)
+		}
+	}
+	return roots
+}
+
+// findPostComment returns the comment with the given id in the tree.
+func findPostComment(comments []*PostComment, id clientintf.ID) *PostComment {
+	stack := append([]*PostComment(nil), comments...)
+	for len(stack) > 0 {
+		cmt := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cmt.ID == id {
+			return cmt
+		}
+		stack = append(stack, cmt.Children...)
+	}
+	return nil
+}
+
+// PrunePostComment removes the replies to the comment that are deeper than
+// maxDepth levels below it.
+func PrunePostComment(cmt *PostComment, maxDepth int) {
+	if maxDepth <= 0 {
+		cmt.Children = nil
+		return
+	}
+	for _, child := range cmt.Children {
+		PrunePostComment(child, maxDepth-1)
+	}
+}
+
+// PostCommentTree returns the tree of comments of the given post.
+func (c *Client) PostCommentTree(from UserID, pid clientintf.PostID) ([]*PostComment, error) {
+	statuses, err := c.ListPostStatusUpdates(from, pid)
+	if err != nil {
+		return nil, err
+	}
+	return BuildPostCommentTree(statuses), nil
+}
+
+// PostCommentSubtree returns the given comment of a post along with its
+// replies, up to maxDepth levels below it. If maxDepth is negative, all
+// replies are returned.
+func (c *Client) PostCommentSubtree(from UserID, pid clientintf.PostID,
+	commentID clientintf.ID, maxDepth int) (*PostComment, error) {
+
+	roots, err := c.PostCommentTree(from, pid)
+	if err != nil {
+		return nil, err
+	}
+	cmt := findPostComment(roots, commentID)
+	if cmt == nil {
+		return nil, fmt.Errorf("comment %s: %w", commentID, clientdb.ErrNotFound)
+	}
+	if maxDepth >= 0 {
+		PrunePostComment(cmt, maxDepth)
+	}
+	return cmt, nil
+}
+
+// verifyCommentParent verifies that the comment in the status update does not
+// reply to a known comment of the post that does not allow replies. Replies to
+// unknown comments are accepted, because status updates may be received out of
+// order (for example, when relayed by the post author). They are shown as
+// top-level comments until the comment they reply to is received.
+func verifyCommentParent(statuses []rpc.PostMetadataStatus, pms *rpc.PostMetadataStatus) error {
+	s, ok := pms.Attributes[rpc.RMPParent]
+	if !ok {
+		return nil
+	}
+	var parentID clientintf.ID
+	if err := parentID.FromString(s); err != nil {
+		return fmt.Errorf("invalid parent comment id: %v", err)
+	}
+	parent := findPostComment(BuildPostCommentTree(statuses), parentID)
+	if parent == nil {
+		return nil
+	}
+	if parent.Depth >= MaxPostCommentDepth {
+		return fmt.Errorf("parent comment %s is at the max depth (%d) of "+
+			"replies", parentID, MaxPostCommentDepth)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestBuildPostCommentTree tests building the tree of comments of a post.
+func TestBuildPostCommentTree(t *testing.T) {
+	var statuses []rpc.PostMetadataStatus
+	addComment := func(comment string, parent *clientintf.ID) clientintf.ID {
+		pms := rpc.PostMetadataStatus{
+			Version:    rpc.PostMetadataVersion,
+			Attributes: map[string]string{rpc.RMPSComment: comment},
+		}
+		if parent != nil {
+			pms.Attributes[rpc.RMPParent] = parent.String()
+		}
+		statuses = append(statuses, pms)
+		return pms.Hash()
+	}
+	addHeart := func() {
+		statuses = append(statuses, rpc.PostMetadataStatus{
+			Version:    rpc.PostMetadataVersion,
+			Attributes: map[string]string{rpc.RMPSHeart: rpc.RMPSHeartYes},
+		})
+	}
+
+	// Two top-level comments, a reply to the first one and a reply to the
+	// reply. Hearts are not comments.
+	c1 := addComment("c1", nil)
+	addHeart()
+	c2 := addComment("c2", nil)
+	c11 := addComment("c11", &c1)
+	c111 := addComment("c111", &c11)
+	unknown := clientintf.ID{0: 0xff}
+	orphan := addComment("orphan", &unknown)
+
+	roots := BuildPostCommentTree(statuses)
+	if len(roots) != 3 {
+		t.Fatalf("unexpected nb of roots: got %d, want 3", len(roots))
+	}
+	if roots[0].ID != c1 || roots[1].ID != c2 || roots[2].ID != orphan {
+		t.Fatalf("unexpected roots")
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].ID != c11 {
+		t.Fatalf("unexpected children of c1")
+	}
+	cmt := findPostComment(roots, c111)
+	if cmt == nil || cmt.Depth != 2 || *cmt.Parent != c11 {
+		t.Fatalf("unexpected c111: %v", cmt)
+	}
+	if roots[2].Depth != 0 {
+		t.Fatalf("unexpected depth of orphan: %d", roots[2].Depth)
+	}
+
+	// Replies beyond the max depth are attached to the closest ancestor
+	// that allows replies.
+	parent := c111
+	for i := 3; i <= MaxPostCommentDepth; i++ {
+		parent = addComment("deep", &parent)
+	}
+	deepest := findPostComment(BuildPostCommentTree(statuses), parent)
+	if deepest == nil || deepest.Depth != MaxPostCommentDepth {
+		t.Fatalf("unexpected deepest comment: %v", deepest)
+	}
+	tooDeep := addComment("too deep", &parent)
+	cmt = findPostComment(BuildPostCommentTree(statuses), tooDeep)
+	if cmt == nil || cmt.Depth != MaxPostCommentDepth {
+		t.Fatalf("unexpected too deep comment: %v", cmt)
+	}
+
+	// Verify the parents of new comments.
+	reply := func(parent *clientintf.ID) *rpc.PostMetadataStatus {
+		pms := &rpc.PostMetadataStatus{Attributes: map[string]string{
+			rpc.RMPSComment: "reply",
+		}}
+		if parent != nil {
+			pms.Attributes[rpc.RMPParent] = parent.String()
+		}
+		return pms
+	}
+	if err := verifyCommentParent(statuses, reply(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := verifyCommentParent(statuses, reply(&c11)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := verifyCommentParent(statuses, reply(&unknown)); err != nil {
+		t.Fatalf("unexpected error replying to unknown comment: %v", err)
+	}
+	if err := verifyCommentParent(statuses, reply(&parent)); err == nil {
+		t.Fatalf("unexpected success replying to comment at max depth")
+	}
+
+	// Prune the replies of the first comment.
+	roots = BuildPostCommentTree(statuses)
+	PrunePostComment(roots[0], 1)
+	if len(roots[0].Children) != 1 || len(roots[0].Children[0].Children) != 0 {
+		t.Fatalf("unexpected pruned comment")
+	}
+}
+
+// TestBuildPostCommentTreeOutOfOrder tests that replies received before the
+// comment they reply to are attached to it.
+func TestBuildPostCommentTreeOutOfOrder(t *testing.T) {
+	newComment := func(comment string, parent *clientintf.ID) rpc.PostMetadataStatus {
+		pms := rpc.PostMetadataStatus{
+			Version:    rpc.PostMetadataVersion,
+			Attributes: map[string]string{rpc.RMPSComment: comment},
+		}
+		if parent != nil {
+			pms.Attributes[rpc.RMPParent] = parent.String()
+		}
+		return pms
+	}
+
+	c1 := newComment("c1", nil)
+	c1ID := clientintf.ID(c1.Hash())
+	c11 := newComment("c11", &c1ID)
+	c11ID := clientintf.ID(c11.Hash())
+	c111 := newComment("c111", &c11ID)
+	c12 := newComment("c12", &c1ID)
+
+	// Before c1 is received, its replies are top-level comments.
+	statuses := []rpc.PostMetadataStatus{c111, c11}
+	roots := BuildPostCommentTree(statuses)
+	if len(roots) != 1 || roots[0].ID != c11ID || len(roots[0].Children) != 1 {
+		t.Fatalf("unexpected roots before parent was received")
+	}
+
+	statuses = append(statuses, c12, c1)
+	roots = BuildPostCommentTree(statuses)
+	if len(roots) != 1 || roots[0].ID != c1ID {
+		t.Fatalf("unexpected roots")
+	}
+	children := roots[0].Children
+	if len(children) != 2 || children[0].ID != c11ID || children[1].ID != c12.Hash() {
+		t.Fatalf("unexpected children of c1")
+	}
+	cmt := findPostComment(roots, c111.Hash())
+	if cmt == nil || cmt.Depth != 2 || *cmt.Parent != c11ID {
+		t.Fatalf("unexpected c111: %v", cmt)
+	}
+}
+
+// TestBuildPostCommentTreeModeration tests that comments are marked according
+// to the moderation actions of the post author.
+func TestBuildPostCommentTreeModeration(t *testing.T) {
+	var statuses []rpc.PostMetadataStatus
+	addComment := func(comment string) clientintf.ID {
+		pms := rpc.PostMetadataStatus{
+			Version:    rpc.PostMetadataVersion,
+			Attributes: map[string]string{rpc.RMPSComment: comment},
+		}
+		statuses = append(statuses, pms)
+		return pms.Hash()
+	}
+	moderate := func(target clientintf.ID, action string) {
+		statuses = append(statuses, rpc.PostMetadataStatus{
+			Version: rpc.PostMetadataVersion,
+			Attributes: map[string]string{
+				rpc.RMPSModeration:       action,
+				rpc.RMPSModerationTarget: target.String(),
+			},
+		})
+	}
+
+	c1 := addComment("c1")
+	c2 := addComment("c2")
+	moderate(c1, rpc.RMPSModerationHide)
+	moderate(c2, rpc.RMPSModerationFlag)
+	moderate(c2, rpc.RMPSModerationHide)
+	moderate(c2, rpc.RMPSModerationUnhide)
+
+	roots := BuildPostCommentTree(statuses)
+	if len(roots) != 2 {
+		t.Fatalf("unexpected nb of roots: got %d, want 2", len(roots))
+	}
+	if !roots[0].Hidden || roots[0].Flagged {
+		t.Fatalf("unexpected moderation of c1: %v", roots[0])
+	}
+	if roots[1].Hidden || !roots[1].Flagged {
+		t.Fatalf("unexpected moderation of c2: %v", roots[1])
+	}
+}
@@ -0,0 +1,213 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// ContactGroupResult is the result of a batch operation performed on the
+// members of a contact group.
+type ContactGroupResult struct {
+	// Group is the name of the contact group.
+	Group string
+
+	// Succeeded are the members for which the operation succeeded.
+	Succeeded []UserID
+
+	// Skipped are the members for which the operation was not needed (for
+	// example, members already in a GC when inviting the group to it).
+	Skipped []UserID
+
+	// Errors are the errors that prevented the operation from being
+	// performed on members of the group.
+	Errors map[UserID]error
+}
+
+// StoreContactGroup creates or replaces the contact group with the given name
+// and members. Contact groups are only stored locally: members are not
+// notified of being part of a group.
+func (c *Client) StoreContactGroup(name string, members []UserID) error {
+	if name == "" {
+		return errors.New("contact group name cannot be empty")
+	}
+
+	// Ensure members are known and unique.
+	seen := make(map[UserID]bool, len(members))
+	uniq := make([]UserID, 0, len(members))
+	for _, uid := range members {
+		if seen[uid] {
+			continue
+		}
+		if _, err := c.rul.byID(uid); err != nil {
+			return err
+		}
+		seen[uid] = true
+		uniq = append(uniq, uid)
+	}
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreContactGroup(tx, clientdb.ContactGroup{
+			Name:    name,
+			Members: uniq,
+		})
+	})
+}
+
+// AddToContactGroup adds users to a contact group, creating the group if it
+// does not exist yet.
+func (c *Client) AddToContactGroup(name string, uids ...UserID) error {
+	var members []UserID
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		cg, err := c.db.GetContactGroup(tx, name)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		members = cg.Members
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return c.StoreContactGroup(name, append(members, uids...))
+}
+
+// RemoveFromContactGroup removes users from an existing contact group.
+func (c *Client) RemoveFromContactGroup(name string, uids ...UserID) error {
+	cg, err := c.GetContactGroup(name)
+	if err != nil {
+		return err
+	}
+	cg.Members = slices.DeleteFunc(cg.Members, func(member UserID) bool {
+		return slices.Contains(uids, member)
+	})
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreContactGroup(tx, cg)
+	})
+}
+
+// RemoveContactGroup removes a contact group. The members of the group are
+// not affected.
+func (c *Client) RemoveContactGroup(name string) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemoveContactGroup(tx, name)
+	})
+}
+
+// GetContactGroup returns the contact group with the given name.
+func (c *Client) GetContactGroup(name string) (clientdb.ContactGroup, error) {
+	var cg clientdb.ContactGroup
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		cg, err = c.db.GetContactGroup(tx, name)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		err = fmt.Errorf("contact group %q does not exist", name)
+	}
+	return cg, err
+}
+
+// ListContactGroups returns the existing contact groups.
+func (c *Client) ListContactGroups() ([]clientdb.ContactGroup, error) {
+	var res []clientdb.ContactGroup
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListContactGroups(tx)
+		return err
+	})
+	return res, err
+}
+
+// contactGroupBatch performs the given operation on each member of the contact
+// group. The operation returns true if it was skipped for the member.
+//
+// Failure to perform the operation on some of the members does not cause an
+// error: the returned result tracks the individual errors.
+func (c *Client) contactGroupBatch(name, opName string,
+	op func(uid UserID) (bool, error)) (ContactGroupResult, error) {
+
+	cg, err := c.GetContactGroup(name)
+	if err != nil {
+		return ContactGroupResult{}, err
+	}
+	if len(cg.Members) == 0 {
+		return ContactGroupResult{}, fmt.Errorf("contact group %q is empty", name)
+	}
+
+	res := ContactGroupResult{
+		Group:  name,
+		Errors: make(map[UserID]error),
+	}
+	for _, uid := range cg.Members {
+		skipped, err := op(uid)
+		switch {
+		case err != nil:
+			c.log.Warnf("Unable to %s %s (contact group %q): %v",
+				opName, uid, name, err)
+			res.Errors[uid] = err
+		case skipped:
+			res.Skipped = append(res.Skipped, uid)
+		default:
+			res.Succeeded = append(res.Succeeded, uid)
+		}
+	}
+	c.log.Infof("Performed %s on contact group %q: %d succeeded, %d skipped, "+
+		"%d failed", opName, name, len(res.Succeeded), len(res.Skipped),
+		len(res.Errors))
+	return res, nil
+}
+
+// PMContactGroup sends the message as an individual PM to each member of the
+// contact group.
+func (c *Client) PMContactGroup(name, msg string) (ContactGroupResult, error) {
+	if msg == "" {
+		return ContactGroupResult{}, errors.New("message cannot be empty")
+	}
+	return c.contactGroupBatch(name, "send PM to", func(uid UserID) (bool, error) {
+		return false, c.PM(uid, msg)
+	})
+}
+
+// TipContactGroup starts an attempt to tip each member of the contact group
+// the given amount of dcr. See TipUser for details on the tipping process.
+func (c *Client) TipContactGroup(name string, dcrAmount float64,
+	maxAttempts int32) (ContactGroupResult, error) {
+
+	if dcrAmount <= 0 {
+		return ContactGroupResult{}, fmt.Errorf("cannot pay users %f <= 0", dcrAmount)
+	}
+	return c.contactGroupBatch(name, "tip", func(uid UserID) (bool, error) {
+		return false, c.TipUser(uid, dcrAmount, maxAttempts)
+	})
+}
+
+// InviteContactGroupToGC invites each member of the contact group to the given
+// GC. Members that are already part of the GC are skipped.
+func (c *Client) InviteContactGroupToGC(name string, gcID zkidentity.ShortID) (ContactGroupResult, error) {
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return ContactGroupResult{}, err
+	}
+	return c.contactGroupBatch(name, "invite to GC", func(uid UserID) (bool, error) {
+		if slices.Contains(gc.Members, uid) {
+			return true, nil
+		}
+		return false, c.InviteToGroupChat(gcID, uid)
+	})
+}
+
+// ApplyContactGroupFilter stores a copy of the content filter for each member
+// of the contact group. The UID of each copy is set to the respective member
+// and the ID of the passed filter is ignored.
+func (c *Client) ApplyContactGroupFilter(name string, cf clientdb.ContentFilter) (ContactGroupResult, error) {
+	return c.contactGroupBatch(name, "apply filter to", func(uid UserID) (bool, error) {
+		memberCF := cf
+		memberCF.ID = 0
+		memberCF.UID = &uid
+		return false, c.StoreContentFilter(&memberCF)
+	})
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"golang.org/x/exp/slices"
+)
+
+// normalizeContactTag returns the normalized version of a contact tag.
+func normalizeContactTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// modifyContactMetadata loads the private metadata of a user, calls f to
+// modify it and saves it back.
+func (c *Client) modifyContactMetadata(uid UserID, f func(meta *clientdb.ContactMetadata) error) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		meta, err := c.db.GetContactMetadata(tx, uid)
+		if err != nil {
+			return err
+		}
+		if err := f(meta); err != nil {
+			return err
+		}
+		meta.LastUpdated = time.Now()
+		return c.db.UpdateContactMetadata(tx, uid, meta)
+	})
+}
+
+// ContactMetadata returns the private metadata (notes, tags and custom fields)
+// the local client keeps about the given user.
+func (c *Client) ContactMetadata(uid UserID) (*clientdb.ContactMetadata, error) {
+	var meta *clientdb.ContactMetadata
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		meta, err = c.db.GetContactMetadata(tx, uid)
+		return err
+	})
+	return meta, err
+}
+
+// SetContactNotes replaces the private notes about the given user.
+func (c *Client) SetContactNotes(uid UserID, notes string) error {
+	return c.modifyContactMetadata(uid, func(meta *clientdb.ContactMetadata) error {
+		meta.Notes = notes
+		return nil
+	})
+}
+
+// AddContactTags adds the given tags to the user. Tags are case insensitive.
+func (c *Client) AddContactTags(uid UserID, tags ...string) error {
+	return c.modifyContactMetadata(uid, func(meta *clientdb.ContactMetadata) error {
+		for _, tag := range tags {
+			tag = normalizeContactTag(tag)
+			if tag == "" {
+				return fmt.Errorf("tag cannot be empty")
+			}
+			if strings.ContainsAny(tag, " \t\n") {
+				return fmt.Errorf("tag %q cannot contain spaces", tag)
+			}
+			if !meta.HasTag(tag) {
+				meta.Tags = append(meta.Tags, tag)
+				sort.Strings(meta.Tags)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveContactTags removes the given tags from the user.
+func (c *Client) RemoveContactTags(uid UserID, tags ...string) error {
+	return c.modifyContactMetadata(uid, func(meta *clientdb.ContactMetadata) error {
+		for _, tag := range tags {
+			tag = normalizeContactTag(tag)
+			if i := slices.Index(meta.Tags, tag); i > -1 {
+				meta.Tags = slices.Delete(meta.Tags, i, i+1)
+			}
+		}
+		return nil
+	})
+}
+
+// SetContactField sets a custom field of the private metadata about the given
+// user. An empty value removes the field.
+func (c *Client) SetContactField(uid UserID, key, value string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("field key cannot be empty")
+	}
+	return c.modifyContactMetadata(uid, func(meta *clientdb.ContactMetadata) error {
+		if value == "" {
+			delete(meta.Fields, key)
+			return nil
+		}
+		if meta.Fields == nil {
+			meta.Fields = make(map[string]string)
+		}
+		meta.Fields[key] = value
+		return nil
+	})
+}
+
+// ContactsByTag returns the list of users that have the given tag.
+func (c *Client) ContactsByTag(tag string) ([]UserID, error) {
+	tag = normalizeContactTag(tag)
+	var res []UserID
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		all, err := c.db.ListContactsMetadata(tx)
+		if err != nil {
+			return err
+		}
+		for uid, meta := range all {
+			if meta.HasTag(tag) {
+				res = append(res, uid)
+			}
+		}
+		return nil
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].String() < res[j].String() })
+	return res, err
+}
+
+// ListContactTags returns all tags in use, along with the number of users that
+// have each tag.
+func (c *Client) ListContactTags() (map[string]int, error) {
+	res := make(map[string]int)
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		all, err := c.db.ListContactsMetadata(tx)
+		if err != nil {
+			return err
+		}
+		for _, meta := range all {
+			for _, tag := range meta.Tags {
+				res[tag] += 1
+			}
+		}
+		return nil
+	})
+	return res, err
+}
@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
 	"regexp"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/rpc"
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/slog"
 )
 
@@ -60,6 +63,15 @@ import (
 //   handleFTGetChunkReply()
 //
 
+// chunkReRequestInterval is the interval after which a file chunk that was
+// requested (or paid for) but not received is requested again.
+const chunkReRequestInterval = 24 * time.Hour
+
+// maxSourceCostPremium is the maximum premium (in percent) over the cost of the
+// cheapest source of a file download, that other sources may charge and still
+// be used to download chunks of the file.
+const maxSourceCostPremium = 10
+
 // ShareFile shares the given filename with the given user (or to all users if
 // none is specified).
 //
@@ -109,6 +121,40 @@ func (c *Client) UnshareFile(fid clientdb.FileID, uid *UserID) error {
 	})
 }
 
+// SetSharedFilePricing sets the pricing of a locally shared file. The preview
+// (if specified) defines either a number of initial bytes of the file that may
+// be downloaded for free or the id of a separate shared file that serves as
+// preview. When tiers are specified, the cost of the file is the sum of the
+// cost of the tiers, otherwise it is the passed cost (in atoms).
+func (c *Client) SetSharedFilePricing(fid clientdb.FileID, cost uint64,
+	preview *rpc.FilePreview, tiers []rpc.FilePriceTier) error {
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if preview != nil && preview.FID != "" {
+			var previewFID clientdb.FileID
+			if err := previewFID.FromString(preview.FID); err != nil {
+				return fmt.Errorf("invalid preview file id: %v", err)
+			}
+			if previewFID == fid {
+				return fmt.Errorf("file cannot be its own preview")
+			}
+			files, err := c.db.ListAllSharedFiles(tx)
+			if err != nil {
+				return err
+			}
+			found := false
+			for _, f := range files {
+				found = found || f.SF.FID == previewFID
+			}
+			if !found {
+				return fmt.Errorf("preview file %s is not shared",
+					previewFID)
+			}
+		}
+		return c.db.UpdateSharedFilePricing(tx, fid, cost, preview, tiers)
+	})
+}
+
 // ListLocalSharedFiles lists all locally shared files.
 func (c *Client) ListLocalSharedFiles() ([]clientdb.SharedFileAndShares, error) {
 	var files []clientdb.SharedFileAndShares
@@ -144,10 +190,12 @@ func (c *Client) ListUserContent(uid UserID, dirs []string, filter string) error
 		}
 	}
 
-	// Send the request.
+	// Send the request. The tag tracks the listed dirs, so that the
+	// content index is updated when the reply is received.
 	return ru.sendRM(rpc.RMFTList{
 		Directories: dirs,
 		Filter:      filter,
+		Tag:         ftListDirsTag(dirs),
 	}, "ftlist")
 }
 
@@ -226,8 +274,13 @@ func (c *Client) handleFTListReply(ru *RemoteUser, ftrp rpc.RMFTListReply) error
 
 	files := append(ftrp.Global, ftrp.Shared...)
 	var res []clientdb.RemoteFile
-	err := c.dbView(func(tx clientdb.ReadTx) error {
-		var err error
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		err := c.db.UpdateUserContentIndex(tx, ru.ID(),
+			ftListTagDirs(ftrp.Tag), ftrp.Global, ftrp.Shared)
+		if err != nil {
+			ru.log.Warnf("Unable to update content index: %v", err)
+		}
+
 		res, err = c.db.HasDownloadedFiles(tx, ru.Nick(), ru.ID(), files)
 		return err
 	})
@@ -243,34 +296,50 @@ func (c *Client) handleFTListReply(ru *RemoteUser, ftrp rpc.RMFTListReply) error
 // GetUserContent starts the process to fetch the given file from the remote
 // user.
 func (c *Client) GetUserContent(uid UserID, fid clientdb.FileID) error {
+	return c.getUserContent(uid, fid, false)
+}
+
+// GetUserContentPreview starts the process to fetch the free preview of the
+// given file from the remote user. If the preview is a separate file, then
+// that file is downloaded instead.
+func (c *Client) GetUserContentPreview(uid UserID, fid clientdb.FileID) error {
+	return c.getUserContent(uid, fid, true)
+}
+
+func (c *Client) getUserContent(uid UserID, fid clientdb.FileID, previewOnly bool) error {
 	ru, err := c.rul.byID(uid)
 	if err != nil {
 		return err
 	}
 
-	ru.log.Infof("Starting download of file %s", fid)
-
-	// Send request for file metadata.
-	rmftg := rpc.RMFTGet{
-		FileID: fid.String(),
-	}
-	payEvent := fmt.Sprintf("ftget.%s", fid.ShortLogID())
-	err = ru.sendRM(rmftg, payEvent)
-	if err != nil {
-		return err
+	if previewOnly {
+		ru.log.Infof("Starting download of preview of file %s", fid)
+	} else {
+		ru.log.Infof("Starting download of file %s", fid)
 	}
 
-	// Store that we want to download this file.
+	// Store that we want to download this file. This is done before
+	// sending the request, so that the reply is not handled with the state
+	// of a previous download of the file.
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
-		_, err = c.db.StartFileDownload(tx, uid, fid, false)
+		if previewOnly {
+			_, err = c.db.StartFilePreviewDownload(tx, uid, fid)
+		} else {
+			_, err = c.db.StartFileDownload(tx, uid, fid, false)
+		}
 		return err
 	})
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// Send request for file metadata.
+	rmftg := rpc.RMFTGet{
+		FileID: fid.String(),
+	}
+	payEvent := fmt.Sprintf("ftget.%s", fid.ShortLogID())
+	return ru.sendRM(rmftg, payEvent)
 }
 
 // handleFTGet handles starting the download process for a file.
@@ -334,27 +403,50 @@ func (c *Client) requestFileChunk(ru *RemoteUser, fid clientdb.FileID, chunkIdx
 			chunkIdx, fm.Filename)
 	}
 
+	// Wait until the chunk may be downloaded within the bandwidth limits.
+	chunkSize := int(fm.Manifest[chunkIdx].Size)
+	if err := c.bw.wait(c.ctx, ru.ID(), chunkSize, false); err != nil {
+		return err
+	}
+
 	rm := rpc.RMFTGetChunk{
 		FileID: fid.String(),
 		Index:  chunkIdx,
 		Hash:   chunkHash,
 	}
-	payEvent := fmt.Sprintf("ftgetchunk.%s.%d", fid.ShortLogID(), rm.Index)
-	if err := ru.sendRM(rm, payEvent); err != nil {
-		return err
-	}
 
+	// Mark the chunk as requested from this user before sending the
+	// request, so that the reply is accepted even if it is received
+	// quickly.
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		fd, err := c.db.ReadFileDownload(tx, ru.ID(), fid)
 		if err != nil {
 			return err
 		}
-		return c.db.ReplaceFileDownloadChunkState(tx, &fd, chunkIdx,
-			clientdb.ChunkStateRequestedChunk)
+		return c.db.MarkFileDownloadChunkRequested(tx, &fd, chunkIdx,
+			ru.ID())
 	})
 	if err != nil {
 		return err
 	}
+
+	payEvent := fmt.Sprintf("ftgetchunk.%s.%d", fid.ShortLogID(), rm.Index)
+	if err := ru.sendRM(rm, payEvent); err != nil {
+		// Clear the chunk state so that it is requested again.
+		dbErr := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			fd, err := c.db.ReadFileDownload(tx, ru.ID(), fid)
+			if err != nil {
+				return err
+			}
+			return c.db.ReplaceFileDownloadChunkState(tx, &fd,
+				chunkIdx, "")
+		})
+		if dbErr != nil {
+			ru.log.Errorf("Unable to clear state of chunk %d: %v",
+				chunkIdx, dbErr)
+		}
+		return err
+	}
 	return nil
 }
 
@@ -385,7 +477,14 @@ func (c *Client) payFileChunkInvoice(ru *RemoteUser, fid clientdb.FileID,
 	}
 
 	// Attempt to pay invoice.
-	fees, invErr := c.pc.PayInvoice(c.ctx, invoice)
+	var fees int64
+	spendID, invErr := c.reservePaymentSpend(ru.ID(), nil, matoms, false)
+	if invErr == nil {
+		fees, invErr = c.pc.PayInvoice(c.ctx, invoice)
+		if invErr != nil {
+			c.releasePaymentSpend(spendID)
+		}
+	}
 	if invErr == nil {
 		ru.log.Debugf("Paid for chunk %d of file download %s", chunkIdx, fid)
 	}
@@ -420,6 +519,10 @@ func (c *Client) payFileChunkInvoice(ru *RemoteUser, fid clientdb.FileID,
 	if dbErr != nil {
 		ru.log.Errorf("Unable to update invoice for file get in DB: %v", dbErr)
 	}
+	if invErr == nil && dbErr == nil {
+		c.sendInvoicePaymentReceipt(ru, rpc.PaymentReceiptKindContent,
+			matoms, invoice, nil, nil)
+	}
 
 	// Decide which error to return.
 	err = invErr
@@ -429,18 +532,121 @@ func (c *Client) payFileChunkInvoice(ru *RemoteUser, fid clientdb.FileID,
 	return err
 }
 
+// resumeFileChunkPayment checks the outcome of a payment for a chunk that was
+// interrupted (for example, by a client restart). If the payment completed,
+// the chunk is marked as paid and the remote user is expected to send it.
+// Otherwise, the chunk is requested again.
+func (c *Client) resumeFileChunkPayment(ru *RemoteUser, fid clientdb.FileID,
+	chunkIdx int, invoice string) error {
+
+	fees, payErr := c.pc.IsPaymentCompleted(c.ctx, invoice)
+	if errors.Is(payErr, context.Canceled) {
+		return clientintf.ErrSubsysExiting
+	}
+
+	var fm rpc.FileMetadata
+	var chunkMAtoms int64
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		fd, err := c.db.ReadFileDownload(tx, ru.ID(), fid)
+		if err != nil {
+			return err
+		}
+		fm = *fd.Metadata
+		chunkMAtoms = int64(fd.ChunkMAtoms(chunkIdx, ru.ID()))
+
+		if cs := fd.GetChunkState(chunkIdx); cs != clientdb.ChunkStatePayingInvoice {
+			return fmt.Errorf("invalid chunkstate when resuming "+
+				"payment: %q", cs)
+		}
+
+		if payErr != nil {
+			// Payment failed. Clear the invoice to request a
+			// new one.
+			invoices := map[int]string{chunkIdx: ""}
+			return c.db.ReplaceFileDownloadInvoices(tx, &fd, invoices)
+		}
+
+		// Register the payment event for statistics purposes.
+		payEvent := fmt.Sprintf("ftpaychunk.%s.%d", fid.ShortLogID(), chunkIdx)
+		amount := -chunkMAtoms
+		fees := -fees
+		if err := c.db.RecordUserPayEvent(tx, ru.ID(), payEvent, amount, fees); err != nil {
+			return err
+		}
+
+		return c.db.ReplaceFileDownloadChunkState(tx, &fd, chunkIdx,
+			clientdb.ChunkStatePaid)
+	})
+	if err != nil {
+		return err
+	}
+
+	if payErr == nil {
+		ru.log.Infof("Interrupted payment for chunk %d of file %s "+
+			"completed", chunkIdx, fid)
+		c.sendInvoicePaymentReceipt(ru, rpc.PaymentReceiptKindContent,
+			chunkMAtoms, invoice, nil, nil)
+		return nil
+	}
+
+	ru.log.Infof("Interrupted payment for chunk %d of file %s failed "+
+		"(%v). Requesting chunk again", chunkIdx, fid, payErr)
+	return c.requestFileChunk(ru, fid, chunkIdx, fm)
+}
+
+// fileDownloadSources returns the remote users from which chunks of the given
+// download may be fetched. Sources that charge more than maxSourceCostPremium
+// over the cheapest source are not used.
+func (c *Client) fileDownloadSources(fd *clientdb.FileDownload) []*RemoteUser {
+	uids := make([]UserID, 0, len(fd.Sources)+1)
+	uids = append(uids, fd.UID)
+	for _, src := range fd.Sources {
+		uids = append(uids, src.UID)
+	}
+
+	rus := make([]*RemoteUser, 0, len(uids))
+	var minCost uint64 = math.MaxUint64
+	for _, uid := range uids {
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			// Removed user.
+			continue
+		}
+		rus = append(rus, ru)
+		if cost := fd.SourceCost(uid); cost < minCost {
+			minCost = cost
+		}
+	}
+
+	maxCost := minCost + minCost*maxSourceCostPremium/100
+	res := make([]*RemoteUser, 0, len(rus))
+	for _, ru := range rus {
+		if fd.SourceCost(ru.ID()) <= maxCost {
+			res = append(res, ru)
+		}
+	}
+	return res
+}
+
 // downloadChunks is the main workhorse for chunked file download. It is called
 // both for initial download and for restarting old downloads (on client
 // startup).
 //
 // It determines the state of each chunk of the given download and takes
-// actions as appropriate.
+// actions as appropriate. When the file is shared by multiple sources, the
+// missing chunks are split among them, so that they are downloaded in
+// parallel.
 func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error {
 	if fd.Metadata == nil {
 		// Shouldn't happen, but avoid panic.
 		return fmt.Errorf("unable to start download with nil metadata")
 	}
 
+	sources := c.fileDownloadSources(&fd)
+	if len(sources) == 0 {
+		return fmt.Errorf("no sources to download file %s from", fd.FID)
+	}
+
 	var missing []int
 	err := c.dbView(func(tx clientdb.ReadTx) error {
 		missing = c.db.MissingFileDownloadChunks(tx, &fd)
@@ -450,8 +656,9 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 		return err
 	}
 
-	c.log.Infof("Starting to downloading %d missing chunks of file %q (%s)",
-		len(missing), fd.Metadata.Filename, fd.FID)
+	c.log.Infof("Starting to downloading %d missing chunks of file %q (%s) "+
+		"from %d sources", len(missing), fd.Metadata.Filename, fd.FID,
+		len(sources))
 
 	for _, chunkIdx := range missing {
 		chunkIdx := chunkIdx
@@ -459,8 +666,10 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 		// Track which action to take, depending on the current state
 		// of the chunk.
 		var actionToTake string
+		var actionRU *RemoteUser
 		const actRequest = "request invoice"
 		const actSendPayment = "send payment"
+		const actCheckPayment = "check payment"
 
 		// Helper func to log errors in goroutines.
 		logErr := func(err error, msg string) {
@@ -490,14 +699,46 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 					chunkIdx, len(fd.Metadata.Manifest))
 			}
 
+			// Chunks are split among the sources that have them.
+			// Find out the source the chunk should be downloaded
+			// from and the one it was requested from (if any).
+			chunkSources := make([]*RemoteUser, 0, len(sources))
+			for _, src := range sources {
+				if fd.SourceHasChunk(src.ID(), chunkIdx) {
+					chunkSources = append(chunkSources, src)
+				}
+			}
+			if len(chunkSources) == 0 {
+				chunkSources = sources
+			}
+			target := chunkSources[chunkIdx%len(chunkSources)]
+			actionRU = target
+			if srcUID := fd.ChunkSource(chunkIdx); srcUID != target.ID() {
+				// Ignore removed source users.
+				_, isSource := fd.SourceFID(srcUID)
+				if srcRU, err := c.rul.byID(srcUID); err == nil && isSource {
+					actionRU = srcRU
+				}
+			}
+			switchSource := actionRU != target
+
 			var payMAtoms int64
 			chunkState := fd.ChunkStates[chunkIdx]
-			switch chunkState {
-			case "":
-				// Safe to request again.
+			switch {
+			case chunkState == "":
+				// Safe to request again, from the target source.
+				actionToTake = actRequest
+				actionRU = target
+
+			case switchSource && (chunkState == clientdb.ChunkStateRequestedChunk ||
+				chunkState == clientdb.ChunkStateHasInvoice):
+				// Nothing was paid to the source the chunk was
+				// requested from, so request it from the target
+				// source instead.
 				actionToTake = actRequest
+				actionRU = target
 
-			case clientdb.ChunkStateRequestedChunk:
+			case chunkState == clientdb.ChunkStateRequestedChunk:
 				// Request again if it's been at least one day
 				// since we last requested (to avoid sending
 				// multiple redundant requests).
@@ -505,11 +746,11 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 				if fd.ChunkUpdatedTime != nil {
 					chunkUpdtTime = fd.ChunkUpdatedTime[chunkIdx]
 				}
-				if chunkUpdtTime.Before(time.Now().Add(-time.Hour * 24)) {
+				if chunkUpdtTime.Before(time.Now().Add(-chunkReRequestInterval)) {
 					actionToTake = actRequest
 				}
 
-			case clientdb.ChunkStateHasInvoice:
+			case chunkState == clientdb.ChunkStateHasInvoice:
 				// Have invoice, but haven't tried paying. See
 				// if it's still valid to attempt payment.
 				invoice := fd.GetChunkInvoice(chunkIdx)
@@ -525,38 +766,48 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 					payMAtoms = decoded.MAtoms
 				}
 
-			case clientdb.ChunkStatePayingInvoice:
-				// Already attempting to pay this invoice. Do
-				// nothing. Ordinarily, we would't expect to
-				// reach this state here, so log a warning for now.
-				//
-				// TODO: could've gotten to this state and
-				// crashed, so we need to actually check in the
-				// payment client if the payment is in flight,
-				// succeeded or failed.
-				ru.log.Warnf("Chunk %d of file %s has in-flight payment",
-					chunkIdx, fd.FID)
-
-			case clientdb.ChunkStatePaid:
+			case chunkState == clientdb.ChunkStatePayingInvoice:
+				// An attempt to pay the invoice was started,
+				// but the client was interrupted before its
+				// result was recorded. Check in the payment
+				// client whether the payment completed.
+				actionToTake = actCheckPayment
+
+			case chunkState == clientdb.ChunkStatePaid:
 				// Paid for chunk, but haven't received it yet.
-				// Wait for the remote client to send it.
-				//
-				// TODO: deal with unresponsive remotes.
-				// Re-request it?  Alert user? Ban remote?
-				ru.log.Warnf("Chunk %d of file %s was paid for "+
-					"but hasn't been received yet",
-					chunkIdx, fd.FID)
-
-			case clientdb.ChunkStateDownloaded:
+				// Request it again if it's been long enough,
+				// in which case the remote client sends it
+				// without requiring a new payment.
+				var chunkUpdtTime time.Time
+				if fd.ChunkUpdatedTime != nil {
+					chunkUpdtTime = fd.ChunkUpdatedTime[chunkIdx]
+				}
+				if chunkUpdtTime.Before(time.Now().Add(-chunkReRequestInterval)) {
+					actionToTake = actRequest
+				} else {
+					ru.log.Warnf("Chunk %d of file %s was paid for "+
+						"but hasn't been received yet",
+						chunkIdx, fd.FID)
+				}
+
+			case chunkState == clientdb.ChunkStateDownloaded:
 				// Already downloaded chunk, nothing to do.
 			}
 
-			// Actually take an action on this chunk.
+			// Actually take an action on this chunk. Messages
+			// refer to the file id as shared by the source.
+			fid, ok := fd.SourceFID(actionRU.ID())
+			if !ok {
+				// Shouldn't happen, but avoid requesting the
+				// wrong file.
+				return fmt.Errorf("user %s is not a source of "+
+					"download %s", actionRU.ID(), fd.FID)
+			}
 			switch actionToTake {
 			case actRequest:
 				// Re-request it.
 				go func() {
-					err := c.requestFileChunk(ru, fd.FID, chunkIdx, *fd.Metadata)
+					err := c.requestFileChunk(actionRU, fid, chunkIdx, *fd.Metadata)
 					logErr(err, "Unable to request file chunk: %v")
 				}()
 
@@ -564,10 +815,19 @@ func (c *Client) downloadChunks(ru *RemoteUser, fd clientdb.FileDownload) error
 				// Attempt payment.
 				go func() {
 					invoice := fd.GetChunkInvoice(chunkIdx)
-					err := c.payFileChunkInvoice(ru, fd.FID,
+					err := c.payFileChunkInvoice(actionRU, fid,
 						chunkIdx, invoice, payMAtoms)
 					logErr(err, "unable to pay for chunk: %v")
 				}()
+
+			case actCheckPayment:
+				// Check outcome of interrupted payment.
+				go func() {
+					invoice := fd.GetChunkInvoice(chunkIdx)
+					err := c.resumeFileChunkPayment(actionRU, fid,
+						chunkIdx, invoice)
+					logErr(err, "unable to resume chunk payment: %v")
+				}()
 			}
 
 			return nil
@@ -590,6 +850,13 @@ func (c *Client) handleFTGetReply(ru *RemoteUser, gr rpc.RMFTGetReply) error {
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
 		fd, err = c.db.ReadFileDownload(tx, ru.ID(), fid)
+		if errors.Is(err, clientdb.ErrNotFound) && gr.Metadata.Version >= 2 {
+			// The download may have been requested with the id
+			// calculated by clients that only support version 1
+			// metadata.
+			fid = gr.Metadata.LegacyMetadataHash()
+			fd, err = c.db.ReadFileDownload(tx, ru.ID(), fid)
+		}
 		if err != nil {
 			return err
 		}
@@ -607,9 +874,31 @@ func (c *Client) handleFTGetReply(ru *RemoteUser, gr rpc.RMFTGetReply) error {
 			fid)
 	}
 
+	// Previews are free, so they do not need confirmation and are not
+	// merged with other downloads.
+	if fd.PreviewOnly {
+		return c.startPreviewDownload(ru, fd)
+	}
+
+	// Downloads of the body of paywalled posts were explicitly requested,
+	// so they are not confirmed, as long as they do not cost more than
+	// the price advertised in the post.
+	unlock, err := c.findPostUnlock(ru.ID(), fid)
+	if err != nil {
+		return err
+	}
+	if unlock != nil && gr.Metadata.Cost > unlock.MaxCost {
+		ru.log.Warnf("Canceling download of body of post %s: cost %s "+
+			"is higher than advertised price %s", unlock.Post,
+			dcrutil.Amount(gr.Metadata.Cost), dcrutil.Amount(unlock.MaxCost))
+		return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			return c.db.CancelFileDownload(tx, fid)
+		})
+	}
+
 	// Ask user for confirmation before downloading file (specially
 	// due to cost).
-	if c.cfg.FileDownloadConfirmer != nil {
+	if c.cfg.FileDownloadConfirmer != nil && unlock == nil {
 		if !c.cfg.FileDownloadConfirmer(ru, gr.Metadata) {
 			// Canceled. Remove download.
 			ru.log.Infof("User canceled download of file %s", fid)
@@ -619,6 +908,56 @@ func (c *Client) handleFTGetReply(ru *RemoteUser, gr rpc.RMFTGetReply) error {
 		}
 	}
 
+	// If the same file is already being downloaded from another user, use
+	// this user as an additional source for that download.
+	var mergedFD *clientdb.FileDownload
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		other, err := c.db.FindFileDownloadByHash(tx, gr.Metadata.Hash, fid)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = c.db.AddFileDownloadSource(tx, &other, ru.ID(), gr.Metadata)
+		if err != nil {
+			ru.log.Warnf("Unable to use user as source of download "+
+				"%s: %v", other.FID, err)
+			return nil
+		}
+		mergedFD = &other
+		return c.db.CancelFileDownload(tx, fid)
+	})
+	if err != nil {
+		return err
+	}
+	if mergedFD != nil {
+		pru, err := c.rul.byID(mergedFD.UID)
+		if err != nil {
+			return err
+		}
+		ru.log.Infof("Downloading file %s as an additional source of "+
+			"download %s from %s", fid, mergedFD.FID, pru)
+		go func() {
+			err := c.downloadChunks(pru, *mergedFD)
+			if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+				pru.log.Errorf("Unable to download file chunk: %v", err)
+			}
+		}()
+		return nil
+	}
+
+	// Reload the download, given sources may have been added to it while
+	// waiting for confirmation.
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		fd, err = c.db.ReadFileDownload(tx, ru.ID(), fid)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
 	// Fetched metadata for the given file. Request chunks.
 	go func() {
 		err := c.downloadChunks(ru, fd)
@@ -629,6 +968,43 @@ func (c *Client) handleFTGetReply(ru *RemoteUser, gr rpc.RMFTGetReply) error {
 	return nil
 }
 
+// startPreviewDownload starts downloading the preview of the file of the given
+// preview-only download.
+func (c *Client) startPreviewDownload(ru *RemoteUser, fd clientdb.FileDownload) error {
+	preview := fd.Metadata.Preview
+	var previewFID clientdb.FileID
+	switch {
+	case preview == nil || (preview.FID == "" && len(clientintf.FilePreviewChunks(fd.Metadata)) == 0):
+		ru.log.Infof("File %s does not have a preview", fd.FID)
+		return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			return c.db.CancelFileDownload(tx, fd.FID)
+		})
+
+	case preview.FID != "":
+		// The preview is a separate file. Download it instead.
+		if err := previewFID.FromString(preview.FID); err != nil {
+			return fmt.Errorf("invalid preview file id: %v", err)
+		}
+		err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			return c.db.CancelFileDownload(tx, fd.FID)
+		})
+		if err != nil {
+			return err
+		}
+		ru.log.Infof("Downloading file %s as preview of file %s",
+			previewFID, fd.FID)
+		return c.GetUserContent(ru.ID(), previewFID)
+	}
+
+	go func() {
+		err := c.downloadChunks(ru, fd)
+		if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+			ru.log.Errorf("Unable to download file preview chunk: %v", err)
+		}
+	}()
+	return nil
+}
+
 // sendFileChunk sends the chunk data to the remote user. This is the last
 // step of a chunk upload process, where the actual data is sent to the remote
 // user.
@@ -645,6 +1021,10 @@ func (c *Client) sendFileChunk(ru *RemoteUser, sf clientdb.SharedFile,
 		return err
 	}
 
+	if err := c.bw.wait(c.ctx, ru.ID(), len(data), true); err != nil {
+		return err
+	}
+
 	rm := rpc.RMFTGetChunkReply{
 		FileID: sf.FID.String(),
 		Index:  chunkIdx,
@@ -660,9 +1040,20 @@ func (c *Client) sendFileChunk(ru *RemoteUser, sf clientdb.SharedFile,
 	ru.log.Debugf("Sent chunk %d of file %s to remote user", chunkIdx, sf.FID)
 
 	// Sent successfully (to server)! Mark chunk as sent.
-	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		return c.db.MarkChunkUploadSent(tx, ru.ID(), sf.FID, cid, chunkIdx)
 	})
+	if err != nil {
+		return err
+	}
+
+	// Track downloads of the file, for shares with download limits.
+	if err := c.maybeRecordShareDownload(ru, sf); err != nil {
+		return err
+	}
+
+	// Track sales of paywalled posts.
+	return c.maybeRecordPostUnlockSale(ru, sf)
 }
 
 // ftPaymentForChunkCompleted is called as a callback when the payment for the
@@ -686,6 +1077,20 @@ func (c *Client) ftPaymentForChunkCompleted(ru *RemoteUser, sf clientdb.SharedFi
 
 	ru.log.Debugf("Marked chunk %d of file %s paid by remote user",
 		chunkIdx, sf.FID)
+	ev := PaymentEvent{
+		Type:       PaymentEventContentPurchased,
+		MilliAtoms: receivedMAtoms,
+		Invoice:    invoice,
+		Content: &PaymentEventContent{
+			FileID:     sf.FID.String(),
+			Filename:   sf.Filename,
+			ChunkIndex: chunkIdx,
+		},
+	}
+	if decoded, err := c.pc.DecodeInvoice(c.ctx, invoice); err == nil {
+		ev.PaymentHash = hex.EncodeToString(decoded.ID)
+	}
+	c.firePaymentEvent(ru, ev)
 
 	// Attempt to send chunk to remote user.
 	return c.sendFileChunk(ru, sf, chunkIdx, cid, 0)
@@ -784,6 +1189,15 @@ func (c *Client) handleFTGetChunk(ru *RemoteUser, gc rpc.RMFTGetChunk) error {
 			return fmt.Errorf("data does not hash to specified chunk index")
 		}
 
+		// Only chunks already downloaded may be re-shared with swarm
+		// members.
+		if f.Swarm {
+			if _, err := c.db.GetSharedFileChunkData(tx, &f, chunkIdx); err != nil {
+				return fmt.Errorf("chunk %d of re-shared file %s "+
+					"not available: %w", chunkIdx, fid, err)
+			}
+		}
+
 		// Generate invoice for the given amount.
 		amountMAtoms := clientintf.FileChunkMAtoms(chunkIdx, &md)
 		if amountMAtoms < 1000 {
@@ -791,8 +1205,17 @@ func (c *Client) handleFTGetChunk(ru *RemoteUser, gc rpc.RMFTGetChunk) error {
 			return nil
 		}
 
-		// See if there's an existing, unexpired, unpaid invoice.
+		// See if the chunk was already paid for and sent. In that case,
+		// the remote user is resuming an interrupted download, so send
+		// the chunk again without requiring a new payment.
 		cup, err := c.db.GetFileChunkUpload(tx, ru.ID(), fid, cid)
+		if err == nil && cup.State == clientdb.ChunkStateUploaded {
+			ru.log.Debugf("Resending previously paid chunk %d of file %s",
+				chunkIdx, fid)
+			return nil
+		}
+
+		// See if there's an existing, unexpired, unpaid invoice.
 		if err == nil && len(cup.Invoices) > 0 {
 			oldInv := cup.Invoices[len(cup.Invoices)-1]
 			err := c.pc.IsInvoicePaid(c.ctx, int64(amountMAtoms), inv)
@@ -818,8 +1241,15 @@ func (c *Client) handleFTGetChunk(ru *RemoteUser, gc rpc.RMFTGetChunk) error {
 	}
 
 	if inv == "" {
-		// No need to pay an invoice. Send chunk directly.
-		return c.sendFileChunk(ru, f, chunkIdx, cid, gc.Tag)
+		// No need to pay an invoice. Send chunk directly. This is done
+		// in a goroutine because sending may be throttled.
+		go func() {
+			err := c.sendFileChunk(ru, f, chunkIdx, cid, gc.Tag)
+			if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+				ru.log.Errorf("Unable to send file chunk: %v", err)
+			}
+		}()
+		return nil
 	}
 
 	// Invoice needed. Send reply to pay for chunk.
@@ -865,11 +1295,16 @@ func (c *Client) handleFTPayForChunk(ru *RemoteUser, pfc rpc.RMFTPayForChunk) er
 			return fmt.Errorf("already paid for chunk %d", chunkIdx)
 		}
 
+		if fd.ChunkSource(chunkIdx) != ru.ID() {
+			return fmt.Errorf("chunk %d was requested from another "+
+				"source", chunkIdx)
+		}
+
 		// TODO: check whether the invoice has a payment attempt in
 		// flight or is already expired.
 
 		// Double check amount to pay for chunk.
-		wantMAtoms := clientintf.FileChunkMAtoms(chunkIdx, fd.Metadata)
+		wantMAtoms := fd.ChunkMAtoms(chunkIdx, ru.ID())
 		if uint64(inv.MAtoms) > wantMAtoms {
 			return fmt.Errorf("unexpected value of invoice (got %d, want %d)",
 				inv.MAtoms, wantMAtoms)
@@ -897,10 +1332,12 @@ func (c *Client) handleFTGetChunkReply(ru *RemoteUser, gcr rpc.RMFTGetChunkReply
 		return err
 	}
 
-	// Save the chunk.
+	// Save the chunk. The download is tracked under the user from which
+	// it was started, even if this chunk was sent by an additional source.
 	var fd clientdb.FileDownload
 	var completedFname string
 	var nbMissingChunks int
+	dlRU := ru
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
 		fd, err = c.db.ReadFileDownload(tx, ru.ID(), fid)
@@ -908,7 +1345,13 @@ func (c *Client) handleFTGetChunkReply(ru *RemoteUser, gcr rpc.RMFTGetChunkReply
 			return err
 		}
 
-		completedFname, err = c.db.SaveFileDownloadChunk(tx, ru.Nick(), &fd, gcr.Index, gcr.Chunk)
+		if fd.UID != ru.ID() {
+			if dlRU, err = c.rul.byID(fd.UID); err != nil {
+				return err
+			}
+		}
+
+		completedFname, err = c.db.SaveFileDownloadChunk(tx, dlRU.Nick(), &fd, gcr.Index, gcr.Chunk)
 		nbMissingChunks = len(c.db.MissingFileDownloadChunks(tx, &fd))
 		return err
 	})
@@ -920,12 +1363,19 @@ func (c *Client) handleFTGetChunkReply(ru *RemoteUser, gcr rpc.RMFTGetChunkReply
 
 	if completedFname != "" {
 		baseName := filepath.Base(completedFname)
-		ru.log.Infof("Completed file download %q (%s, saved as %q",
+		dlRU.log.Infof("Completed file download %q (%s, saved as %q",
 			fd.Metadata.Filename, fd.FID, baseName)
-		c.ntfns.notifyFileDownloadCompleted(ru, *fd.Metadata, completedFname)
+		c.ntfns.notifyFileDownloadCompleted(dlRU, *fd.Metadata, completedFname)
+		if err := c.maybeHandleGCHistoryDownload(dlRU, fd.FID, completedFname); err != nil {
+			dlRU.log.Warnf("Unable to handle GC history download: %v", err)
+		}
+		if err := c.maybeHandlePostUnlockDownload(dlRU, fd.FID, completedFname); err != nil {
+			dlRU.log.Warnf("Unable to handle post unlock download: %v", err)
+		}
 	} else {
-		c.ntfns.notifyFileDownloadProgress(ru, *fd.Metadata, nbMissingChunks)
+		c.ntfns.notifyFileDownloadProgress(dlRU, *fd.Metadata, nbMissingChunks)
 	}
+	c.maybeAnnounceSwarmChunks(dlRU, &fd, nbMissingChunks)
 	return err
 }
 
@@ -962,7 +1412,9 @@ func (c *Client) SendFile(uid UserID, filepath string) error {
 		return err
 	}
 
-	// Send the chunks.
+	// Send the chunks. They are sent with the legacy file id, which is the
+	// one calculated by receivers that only support version 1 metadata.
+	legacyFID := clientdb.FileID(fm.LegacyMetadataHash())
 	for i := range fm.Manifest {
 		var chunk []byte
 		err := c.dbView(func(tx clientdb.ReadTx) error {
@@ -975,7 +1427,7 @@ func (c *Client) SendFile(uid UserID, filepath string) error {
 		}
 
 		rmSFC := rpc.RMFTGetChunkReply{
-			FileID: sf.FID.String(),
+			FileID: legacyFID.String(),
 			Index:  i,
 			Chunk:  chunk,
 		}
@@ -990,7 +1442,9 @@ func (c *Client) SendFile(uid UserID, filepath string) error {
 }
 
 func (c *Client) handleFTSendFile(ru *RemoteUser, sf rpc.RMFTSendFile) error {
-	var fid clientdb.FileID = sf.Metadata.MetadataHash()
+	// Senders send the chunks with the legacy file id, for compatibility
+	// with clients that only support version 1 metadata.
+	var fid clientdb.FileID = sf.Metadata.LegacyMetadataHash()
 
 	// Store that we'll receive this file.
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
@@ -1034,6 +1488,27 @@ func (c *Client) restartDownloads(ctx context.Context) error {
 			continue
 		}
 
+		if fd.Metadata == nil {
+			// The metadata was never received. Request it again,
+			// unless the remote user is the one sending the file.
+			if fd.IsSentFile {
+				continue
+			}
+			ru.log.Infof("Requesting metadata of file %s again", fd.FID)
+			rmftg := rpc.RMFTGet{
+				FileID: fd.FID.String(),
+			}
+			payEvent := fmt.Sprintf("ftget.%s", fd.FID.ShortLogID())
+			go func() {
+				err := ru.sendRM(rmftg, payEvent)
+				if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+					ru.log.Errorf("Unable to request metadata of "+
+						"file %s: %v", fd.FID, err)
+				}
+			}()
+			continue
+		}
+
 		// Start to re-process the download.
 		go func() {
 			err := c.downloadChunks(ru, fd)
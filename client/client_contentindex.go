@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// contentIndexCheckInterval is the interval between checks for content
+// indexes that need to be refreshed.
+const contentIndexCheckInterval = 10 * time.Minute
+
+// Tags of RMFTList requests, used to know which dirs were listed when the
+// reply is received.
+const (
+	ftListTagGlobal uint32 = 1 << 0
+	ftListTagShared uint32 = 1 << 1
+)
+
+// ContentQuery is a query for files in the content index.
+type ContentQuery = clientdb.ContentQuery
+
+// ftListDirsTag returns the tag to send in a listing request of the dirs.
+func ftListDirsTag(dirs []string) uint32 {
+	var tag uint32
+	for _, dir := range dirs {
+		switch dir {
+		case rpc.RMFTDGlobal:
+			tag |= ftListTagGlobal
+		case rpc.RMFTDShared:
+			tag |= ftListTagShared
+		}
+	}
+	return tag
+}
+
+// ftListTagDirs returns the dirs that were listed in a request with the tag.
+func ftListTagDirs(tag uint32) []string {
+	var dirs []string
+	if tag&ftListTagGlobal != 0 {
+		dirs = append(dirs, rpc.RMFTDGlobal)
+	}
+	if tag&ftListTagShared != 0 {
+		dirs = append(dirs, rpc.RMFTDShared)
+	}
+	return dirs
+}
+
+// SearchContent searches the index of content shared by remote users. The
+// index is built from the file listings fetched from the users (either
+// manually with ListUserContent or automatically, when the content index
+// refresh interval is configured).
+func (c *Client) SearchContent(q *ContentQuery) ([]clientdb.RemoteFile, error) {
+	var res []clientdb.RemoteFile
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.SearchContentIndex(tx, q)
+		return err
+	})
+	return res, err
+}
+
+// UserContentIndex returns the indexed content shared by the given user.
+func (c *Client) UserContentIndex(uid UserID) (*clientdb.UserContentIndex, error) {
+	var res *clientdb.UserContentIndex
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.GetUserContentIndex(tx, uid)
+		return err
+	})
+	return res, err
+}
+
+// RefreshContentIndex requests the listing of content of all users whose
+// index was last updated before the given time. Returns the number of users
+// that were asked for their listing.
+func (c *Client) RefreshContentIndex(olderThan time.Time) (int, error) {
+	var indexes map[UserID]*clientdb.UserContentIndex
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		indexes, err = c.db.ListContentIndexes(tx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	dirs := []string{rpc.RMFTDGlobal, rpc.RMFTDShared}
+	var count int
+	for _, uid := range c.rul.userList() {
+		if idx := indexes[uid]; idx != nil && !idx.LastUpdated().Before(olderThan) {
+			continue
+		}
+		if err := c.ListUserContent(uid, dirs, ""); err != nil {
+			c.log.Warnf("Unable to request content listing of user %s: %v",
+				uid, err)
+			continue
+		}
+		count += 1
+	}
+	if count > 0 {
+		c.log.Debugf("Requested content listing of %d users to refresh "+
+			"the content index", count)
+	}
+	return count, nil
+}
+
+// runContentIndexRefresh periodically refreshes the content index according
+// to the configured refresh interval.
+func (c *Client) runContentIndexRefresh(ctx context.Context) error {
+	interval := c.cfg.ContentIndexRefreshInterval
+	checkInterval := contentIndexCheckInterval
+	if interval < checkInterval {
+		checkInterval = interval
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		_, err := c.RefreshContentIndex(time.Now().Add(-interval))
+		if err != nil {
+			c.log.Errorf("Unable to refresh content index: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,1072 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"decred.org/dcrwallet/v3/walletseed"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/client/internal/lowlevel"
+	"github.com/companyzero/bisonrelay/ratchet"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/sw"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// Devices flow (where Alice runs a primary client and a secondary client on a
+// different device, both with the same identity):
+//
+// Remote users keep a single ratchet with each identity, so only one of the
+// devices (the primary) holds the ratchets with the contacts of the user. On
+// the primary, CreateDevicePairing returns a list of seed words and a bundle
+// with the identity of the user, the initial keys of the device link and the
+// current state of the primary (contacts, GCs and recent history), encrypted
+// with a key derived from the seed. The secondary decodes the bundle with
+// DecodeDevicePairing, is started with the same identity (through
+// Config.LocalIDIniter) and calls CompleteDevicePairing.
+//
+// The devices then communicate through the device link. Each direction of the
+// link is a hash chain: the RV and encryption key of each message are derived
+// from the current key of the chain, which is advanced after every message.
+// Messages in the link are signed by the identity of the user, so they can only
+// be created by one of its devices.
+//
+// The primary sends an RMDeviceSyncUpdate to its secondary devices for every
+// new contact and GC and for every PM and GC message sent or received, and a
+// full RMDeviceSync when requested by a secondary. The secondary sends
+// messages to contacts and GCs by relaying them through the primary
+// (RMDeviceRelay), so both devices may be used at the same time.
+//
+// HandoffDevice moves the ratchets with every contact (along with the GCs)
+// from the primary to its secondary device through an RMDeviceHandoff, after
+// which the roles of the devices are swapped.
+
+const (
+	// deviceSyncHistoryMsgs is the max number of recent messages of each
+	// conversation sent during a device sync.
+	deviceSyncHistoryMsgs = 50
+
+	// deviceSyncMaxHistorySize is the max total size of the messages
+	// sent during a device sync.
+	deviceSyncMaxHistorySize = 256 * 1024
+
+	// devicePairingVersion is the current version of the device pairing
+	// bundle.
+	devicePairingVersion = 1
+
+	// devicePairingSeedSize is the size of the random seed used to encrypt
+	// device pairing bundles.
+	devicePairingSeedSize = 32
+
+	// The following are the domain separation strings used when deriving
+	// keys for device pairing and the device link.
+	devicePairingKeyDomain = "bisonrelay-device-pairing-v1"
+	deviceLinkRVDomain     = "bisonrelay-device-link-rv-v1"
+	deviceLinkKeyDomain    = "bisonrelay-device-link-key-v1"
+	deviceLinkChainDomain  = "bisonrelay-device-link-chain-v1"
+)
+
+// DeviceRole is the role of a paired device.
+type DeviceRole = clientdb.DeviceRole
+
+const (
+	DeviceRolePrimary   = clientdb.DeviceRolePrimary
+	DeviceRoleSecondary = clientdb.DeviceRoleSecondary
+)
+
+// PairedDevice is another client of the local user paired with the local
+// client.
+type PairedDevice = clientdb.PairedDevice
+
+// DeviceSync is the last state received from the primary device.
+type DeviceSync = clientdb.DeviceSync
+
+// DevicePairing is the decrypted contents of a device pairing bundle.
+type DevicePairing struct {
+	Version  int                     `json:"version"`
+	Created  time.Time               `json:"created"`
+	Identity zkidentity.FullIdentity `json:"identity"`
+
+	// PrimaryID is the ID of the primary device.
+	PrimaryID zkidentity.ShortID `json:"primary_id"`
+
+	// SendChain and RecvChain are the initial keys of the device link,
+	// from the point of view of the new device.
+	SendChain zkidentity.FixedSizeDigest `json:"send_chain"`
+	RecvChain zkidentity.FixedSizeDigest `json:"recv_chain"`
+
+	// Sync is the state of the primary device when the pairing was
+	// created.
+	Sync rpc.RMDeviceSync `json:"sync"`
+}
+
+// LocalIDIniter returns a function that may be used as the LocalIDIniter of
+// the config of the client being paired.
+func (p *DevicePairing) LocalIDIniter() func(context.Context) (*zkidentity.FullIdentity, error) {
+	return func(context.Context) (*zkidentity.FullIdentity, error) {
+		id := new(zkidentity.FullIdentity)
+		*id = p.Identity
+		return id, nil
+	}
+}
+
+// DeviceHandoff lists the contacts and GCs received in a handoff from the
+// paired primary device.
+type DeviceHandoff struct {
+	Contacts []UserID
+	GCs      []zkidentity.ShortID
+}
+
+// deviceLinkRV returns the RV of the next message of the device link chain.
+func deviceLinkRV(chain *zkidentity.FixedSizeDigest) lowlevel.RVID {
+	return lowlevel.RVID(*domainHash(deviceLinkRVDomain, chain[:]))
+}
+
+// deviceLinkKey returns the encryption key of the next message of the device
+// link chain.
+func deviceLinkKey(chain *zkidentity.FixedSizeDigest) *[32]byte {
+	return domainHash(deviceLinkKeyDomain, chain[:])
+}
+
+// deviceLinkNext returns the key of the device link chain after the next
+// message.
+func deviceLinkNext(chain *zkidentity.FixedSizeDigest) zkidentity.FixedSizeDigest {
+	return zkidentity.FixedSizeDigest(*domainHash(deviceLinkChainDomain, chain[:]))
+}
+
+// pairedDevice returns the paired device with the given ID.
+func (c *Client) pairedDevice(id zkidentity.ShortID) (PairedDevice, error) {
+	var dev PairedDevice
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		dev, err = c.db.GetPairedDevice(tx, id)
+		return err
+	})
+	return dev, err
+}
+
+// primaryDevice returns the paired primary device.
+func (c *Client) primaryDevice() (PairedDevice, error) {
+	devices, err := c.ListPairedDevices()
+	if err != nil {
+		return PairedDevice{}, err
+	}
+	for _, dev := range devices {
+		if dev.Role == DeviceRolePrimary {
+			return dev, nil
+		}
+	}
+	return PairedDevice{}, errors.New("not paired with a primary device")
+}
+
+// CreateDevicePairing pairs a new secondary device with the local client. It
+// returns a new random seed encoded as a list of words and a bundle, encrypted
+// with the seed, that contains the local identity and the state needed to
+// start the new device.
+//
+// The bundle contains the private keys of the local identity, so the words
+// MUST be transferred to the new device through a trusted channel.
+func (c *Client) CreateDevicePairing() (string, []byte, error) {
+	if _, err := c.primaryDevice(); err == nil {
+		return "", nil, errors.New("secondary devices cannot pair " +
+			"new devices")
+	}
+
+	sync, err := c.deviceSync()
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := DevicePairing{
+		Version: devicePairingVersion,
+		Created: time.Now(),
+		Sync:    sync,
+	}
+	var devID zkidentity.ShortID
+	for _, b := range [][]byte{p.PrimaryID[:], devID[:], p.SendChain[:], p.RecvChain[:]} {
+		if _, err := rand.Read(b); err != nil {
+			return "", nil, err
+		}
+	}
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		id, err := c.db.LocalID(tx)
+		if err != nil {
+			return err
+		}
+		p.Identity = *id
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := json.Marshal(p)
+	zeroSlice(p.Identity.PrivateKey[:])
+	zeroSlice(p.Identity.PrivateSigKey[:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	seed, err := walletseed.GenerateRandomSeed(devicePairingSeedSize)
+	if err != nil {
+		return "", nil, err
+	}
+	bundle, err := sw.Seal(data, domainHash(devicePairingKeyDomain, seed))
+	zeroSlice(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The chains of the local client are the reverse of the ones of the
+	// new device.
+	dev := PairedDevice{
+		ID:        devID,
+		Role:      DeviceRoleSecondary,
+		Paired:    p.Created,
+		LastSync:  p.Created,
+		SendChain: p.RecvChain,
+		RecvChain: p.SendChain,
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePairedDevice(tx, dev)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	go func() {
+		if err := c.subDeviceLink(dev); err != nil {
+			c.log.Warnf("Unable to listen to device %s: %v", dev.ID, err)
+		}
+	}()
+
+	c.log.Infof("Created pairing for device %s with %d contacts and %d GCs",
+		devID, len(sync.Contacts), len(sync.GCs))
+	return walletseed.EncodeMnemonic(seed), bundle, nil
+}
+
+// DecodeDevicePairing decrypts the device pairing bundle using the seed words
+// returned by CreateDevicePairing.
+func DecodeDevicePairing(words string, bundle []byte) (*DevicePairing, error) {
+	seed, err := walletseed.DecodeUserInput(words)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed: %v", err)
+	}
+	if len(bundle) < sw.MinPackedEncryptedSize {
+		return nil, errors.New("device pairing bundle is too short")
+	}
+	data, ok := sw.Open(bundle, domainHash(devicePairingKeyDomain, seed))
+	if !ok {
+		return nil, errors.New("unable to decrypt device pairing bundle " +
+			"(wrong seed?)")
+	}
+
+	p := new(DevicePairing)
+	err = json.Unmarshal(data, p)
+	zeroSlice(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode device pairing: %v", err)
+	}
+	if p.Version != devicePairingVersion {
+		return nil, fmt.Errorf("unsupported device pairing version %d",
+			p.Version)
+	}
+	return p, nil
+}
+
+// CompleteDevicePairing pairs the local client as a secondary device of the
+// primary device that created the decoded pairing. The client must be running
+// with the identity stored in the pairing and must not have any contacts.
+func (c *Client) CompleteDevicePairing(p *DevicePairing) error {
+	<-c.abLoaded
+
+	if p.Identity.Public.Identity != c.PublicID() {
+		return fmt.Errorf("device pairing is for identity %s, not the "+
+			"local identity", p.Identity.Public.Identity)
+	}
+	if len(c.rul.userList()) > 0 {
+		return errors.New("cannot pair as secondary device a client " +
+			"that has contacts")
+	}
+
+	now := time.Now()
+	dev := PairedDevice{
+		ID:        p.PrimaryID,
+		Role:      DeviceRolePrimary,
+		Paired:    now,
+		LastSync:  now,
+		SendChain: p.SendChain,
+		RecvChain: p.RecvChain,
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		devices, err := c.db.ListPairedDevices(tx)
+		if err != nil {
+			return err
+		}
+		if len(devices) > 0 {
+			return errors.New("client is already paired with " +
+				"other devices")
+		}
+		if err := c.db.StorePairedDevice(tx, dev); err != nil {
+			return err
+		}
+		return c.db.StoreDeviceSync(tx, DeviceSync{
+			From:     dev.ID,
+			Received: now,
+			Sync:     p.Sync,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.subDeviceLink(dev); err != nil {
+		return err
+	}
+
+	c.log.Infof("Paired as secondary of device %s with %d contacts and "+
+		"%d GCs", dev.ID, len(p.Sync.Contacts), len(p.Sync.GCs))
+	c.ntfns.notifyDeviceSynced(dev, p.Sync)
+	return nil
+}
+
+// UnpairDevice removes the pairing with the device. The device is not
+// notified.
+func (c *Client) UnpairDevice(id zkidentity.ShortID) error {
+	c.deviceRecvMtx.Lock()
+	defer c.deviceRecvMtx.Unlock()
+
+	dev, err := c.pairedDevice(id)
+	if err != nil {
+		return err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.DelPairedDevice(tx, id)
+	})
+	if err != nil {
+		return err
+	}
+	return c.rmgr.Unsub(deviceLinkRV(&dev.RecvChain))
+}
+
+// ListPairedDevices lists the devices paired with the local client.
+func (c *Client) ListPairedDevices() ([]PairedDevice, error) {
+	var res []PairedDevice
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListPairedDevices(tx)
+		return err
+	})
+	return res, err
+}
+
+// LastDeviceSync returns the last state received from the primary device.
+func (c *Client) LastDeviceSync() (DeviceSync, error) {
+	var res DeviceSync
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ReadDeviceSync(tx)
+		return err
+	})
+	return res, err
+}
+
+// listenDeviceLinks listens for messages from all paired devices.
+func (c *Client) listenDeviceLinks(ctx context.Context) error {
+	<-c.abLoaded
+
+	devices, err := c.ListPairedDevices()
+	if err != nil {
+		return err
+	}
+	for _, dev := range devices {
+		if err := c.subDeviceLink(dev); err != nil {
+			if errors.Is(err, clientintf.ErrSubsysExiting) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+	if len(devices) > 0 {
+		c.log.Debugf("Listening to %d paired devices", len(devices))
+	}
+	return nil
+}
+
+// subDeviceLink subscribes to the RV of the next message from the device. It
+// is not an error if the RV is already subscribed, which may happen when
+// listenDeviceLinks races with a pairing being completed.
+func (c *Client) subDeviceLink(dev PairedDevice) error {
+	devID := dev.ID
+	handler := func(blob lowlevel.RVBlob) error {
+		// Called as a goroutine to immediately ack the received
+		// message.
+		go func() {
+			err := c.handleDeviceLinkBlob(devID, blob)
+			if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+				c.log.Errorf("Unable to handle message from "+
+					"device %s: %v", devID, err)
+			}
+		}()
+		return nil
+	}
+	err := c.rmgr.Sub(deviceLinkRV(&dev.RecvChain), handler, nil)
+	if errors.Is(err, lowlevel.ErrRVAlreadySubscribed{}) {
+		err = nil
+	}
+	return err
+}
+
+// sendToDevice sends the message to the device through the device link.
+func (c *Client) sendToDevice(devID zkidentity.ShortID, rm interface{}) error {
+	c.deviceSendMtx.Lock()
+	defer c.deviceSendMtx.Unlock()
+
+	dev, err := c.pairedDevice(devID)
+	if err != nil {
+		return err
+	}
+	msg, err := rpc.ComposeCompressedRM(c.localID.signMessage, rm, c.cfg.CompressLevel)
+	if err != nil {
+		return err
+	}
+	encrypted, err := sw.Seal(msg, deviceLinkKey(&dev.SendChain))
+	if err != nil {
+		return err
+	}
+	err = c.q.SendRM(rawRM{rv: deviceLinkRV(&dev.SendChain), msg: encrypted})
+	if err != nil {
+		return err
+	}
+
+	// The chain is only advanced after the message is sent, so that a
+	// failed attempt does not create a gap in the link.
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		dev, err := c.db.GetPairedDevice(tx, devID)
+		if err != nil {
+			return err
+		}
+		dev.SendChain = deviceLinkNext(&dev.SendChain)
+		dev.SendCount += 1
+		return c.db.StorePairedDevice(tx, dev)
+	})
+}
+
+// handleDeviceLinkBlob handles a message received from the device. Messages
+// of a device are handled one at a time, in the order they were sent.
+func (c *Client) handleDeviceLinkBlob(devID zkidentity.ShortID, blob lowlevel.RVBlob) error {
+	c.deviceRecvMtx.Lock()
+	defer c.deviceRecvMtx.Unlock()
+
+	dev, err := c.pairedDevice(devID)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		c.log.Debugf("Ignoring message from unpaired device %s", devID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The handler may be called multiple times for the same RV, so ignore
+	// messages that were already handled.
+	if deviceLinkRV(&dev.RecvChain) != blob.ID {
+		c.log.Debugf("Ignoring already handled message from device %s "+
+			"at RV %s", devID, blob.ID)
+		return nil
+	}
+	msg, ok := sw.Open(blob.Decoded, deviceLinkKey(&dev.RecvChain))
+	if !ok {
+		return fmt.Errorf("unable to decrypt message at RV %s", blob.ID)
+	}
+
+	// Advance the chain before handling the message, so that it is not
+	// handled again and the next message may be received.
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		dev, err = c.db.GetPairedDevice(tx, devID)
+		if err != nil {
+			return err
+		}
+		dev.RecvChain = deviceLinkNext(&dev.RecvChain)
+		dev.RecvCount += 1
+		return c.db.StorePairedDevice(tx, dev)
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.rmgr.Unsub(blob.ID); err != nil {
+		c.log.Warnf("Unable to unsubscribe from device link RV: %v", err)
+	}
+	if err := c.subDeviceLink(dev); err != nil {
+		return err
+	}
+
+	_, rm, err := rpc.DecomposeRM(c.localID.verifyMessage, msg, uint(c.q.MaxMsgSize()))
+	if err != nil {
+		return fmt.Errorf("unable to decode message: %v", err)
+	}
+
+	// Messages from a primary device may only be received by a secondary
+	// and vice versa.
+	fromPrimary := dev.Role == DeviceRolePrimary
+	switch rm := rm.(type) {
+	case rpc.RMDeviceSyncRequest:
+		if !fromPrimary {
+			return c.SyncDevice(devID)
+		}
+	case rpc.RMDeviceRelay:
+		if !fromPrimary {
+			return c.handleDeviceRelay(dev, rm)
+		}
+	case rpc.RMDeviceSync:
+		if fromPrimary {
+			return c.handleDeviceSync(dev, rm)
+		}
+	case rpc.RMDeviceSyncUpdate:
+		if fromPrimary {
+			return c.handleDeviceSyncUpdate(dev, rm)
+		}
+	case rpc.RMDeviceHandoff:
+		if fromPrimary {
+			return c.handleDeviceHandoff(dev, rm)
+		}
+	default:
+		return fmt.Errorf("unsupported message %T", rm)
+	}
+	return fmt.Errorf("unexpected message %T from %s device", rm, dev.Role)
+}
+
+// deviceSyncHistory returns the recent history of the conversation to be sent
+// in a device sync.
+func (c *Client) deviceSyncHistory(uid UserID, gcName string) []rpc.DeviceSyncMsg {
+	entries, _, err := c.ReadHistoryMessages(uid, gcName, deviceSyncHistoryMsgs, 0)
+	if err != nil {
+		c.log.Debugf("Unable to read history of %s for device sync: %v",
+			uid, err)
+		return nil
+	}
+	res := make([]rpc.DeviceSyncMsg, 0, len(entries))
+	for _, e := range entries {
+		if e.Internal {
+			continue
+		}
+		res = append(res, rpc.DeviceSyncMsg{
+			From:      e.From,
+			Message:   e.Message,
+			Timestamp: e.Timestamp,
+		})
+	}
+	return res
+}
+
+// deviceSync returns the current state of the local client, to be sent to
+// secondary devices.
+func (c *Client) deviceSync() (rpc.RMDeviceSync, error) {
+	sync := rpc.RMDeviceSync{Timestamp: time.Now().Unix()}
+	var historySize int
+	addHistory := func(convo rpc.DeviceSyncConvo) {
+		for _, m := range convo.Messages {
+			historySize += len(m.Message)
+		}
+		if len(convo.Messages) > 0 && historySize <= deviceSyncMaxHistorySize {
+			sync.History = append(sync.History, convo)
+		}
+	}
+
+	for _, uid := range c.rul.userList() {
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			continue
+		}
+		sync.Contacts = append(sync.Contacts, rpc.DeviceSyncContact{
+			ID:   uid,
+			Nick: ru.Nick(),
+		})
+		uid := uid
+		addHistory(rpc.DeviceSyncConvo{
+			UID:      &uid,
+			Messages: c.deviceSyncHistory(uid, ""),
+		})
+	}
+
+	gcs, err := c.ListGCs()
+	if err != nil {
+		return sync, err
+	}
+	for _, gc := range gcs {
+		gcName, err := c.gcLogName(gc.ID)
+		if err != nil {
+			gcName = gc.Name
+		}
+		sync.GCs = append(sync.GCs, rpc.DeviceSyncGC{ID: gc.ID, Name: gcName})
+		gcID := gc.ID
+		addHistory(rpc.DeviceSyncConvo{
+			GC:       &gcID,
+			Messages: c.deviceSyncHistory(gcID, gcName),
+		})
+	}
+	return sync, nil
+}
+
+// SyncDevice sends the state of the local client to the paired secondary
+// device.
+func (c *Client) SyncDevice(id zkidentity.ShortID) error {
+	dev, err := c.pairedDevice(id)
+	if err != nil {
+		return err
+	}
+	if dev.Role != DeviceRoleSecondary {
+		return fmt.Errorf("device %s is not a secondary device", id)
+	}
+
+	sync, err := c.deviceSync()
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Sending device sync to %s with %d contacts, %d GCs and "+
+		"%d conversations", id, len(sync.Contacts), len(sync.GCs),
+		len(sync.History))
+	if err := c.sendToDevice(id, sync); err != nil {
+		return err
+	}
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		dev, err := c.db.GetPairedDevice(tx, id)
+		if err != nil {
+			return err
+		}
+		dev.LastSync = time.Now()
+		return c.db.StorePairedDevice(tx, dev)
+	})
+}
+
+// RequestDeviceSync requests the paired primary device to send its state to
+// the local client.
+func (c *Client) RequestDeviceSync() error {
+	dev, err := c.primaryDevice()
+	if err != nil {
+		return err
+	}
+	return c.sendToDevice(dev.ID, rpc.RMDeviceSyncRequest{})
+}
+
+// handleDeviceSync handles the state sent by the primary device.
+func (c *Client) handleDeviceSync(dev PairedDevice, sync rpc.RMDeviceSync) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		dev, err = c.db.GetPairedDevice(tx, dev.ID)
+		if err != nil {
+			return err
+		}
+		dev.LastSync = time.Now()
+		if err := c.db.StorePairedDevice(tx, dev); err != nil {
+			return err
+		}
+		return c.db.StoreDeviceSync(tx, DeviceSync{
+			From:     dev.ID,
+			Received: dev.LastSync,
+			Sync:     sync,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.Infof("Received device sync from %s with %d contacts, %d GCs "+
+		"and %d conversations", dev.ID, len(sync.Contacts),
+		len(sync.GCs), len(sync.History))
+	c.ntfns.notifyDeviceSynced(dev, sync)
+	return nil
+}
+
+// sendDeviceSyncUpdate sends the update to the paired secondary devices.
+func (c *Client) sendDeviceSyncUpdate(update rpc.RMDeviceSyncUpdate) {
+	devices, err := c.ListPairedDevices()
+	if err != nil {
+		c.log.Warnf("Unable to list paired devices: %v", err)
+		return
+	}
+	var dests []zkidentity.ShortID
+	for _, dev := range devices {
+		if dev.Role == DeviceRoleSecondary {
+			dests = append(dests, dev.ID)
+		}
+	}
+	if len(dests) == 0 {
+		return
+	}
+
+	go func() {
+		for _, id := range dests {
+			if err := c.sendToDevice(id, update); err != nil {
+				c.log.Warnf("Unable to send device sync update "+
+					"to %s: %v", id, err)
+			}
+		}
+	}()
+}
+
+// syncDevicesPM sends a PM sent to or received from the user to the paired
+// secondary devices.
+func (c *Client) syncDevicesPM(uid UserID, from, msg string, ts time.Time) {
+	c.sendDeviceSyncUpdate(rpc.RMDeviceSyncUpdate{
+		Convo: &rpc.DeviceSyncConvo{
+			UID: &uid,
+			Messages: []rpc.DeviceSyncMsg{{
+				From:      from,
+				Message:   msg,
+				Timestamp: ts.Unix(),
+			}},
+		},
+	})
+}
+
+// syncDevicesGCM sends a message sent or received in the GC to the paired
+// secondary devices.
+func (c *Client) syncDevicesGCM(gcID zkidentity.ShortID, from, msg string, ts time.Time) {
+	c.sendDeviceSyncUpdate(rpc.RMDeviceSyncUpdate{
+		Convo: &rpc.DeviceSyncConvo{
+			GC: &gcID,
+			Messages: []rpc.DeviceSyncMsg{{
+				From:      from,
+				Message:   msg,
+				Timestamp: ts.Unix(),
+			}},
+		},
+	})
+}
+
+// syncDevicesContact sends a new contact to the paired secondary devices.
+func (c *Client) syncDevicesContact(ru *RemoteUser) {
+	c.sendDeviceSyncUpdate(rpc.RMDeviceSyncUpdate{
+		Contact: &rpc.DeviceSyncContact{ID: ru.ID(), Nick: ru.Nick()},
+	})
+}
+
+// syncDevicesGC sends a new GC to the paired secondary devices.
+func (c *Client) syncDevicesGC(gcID zkidentity.ShortID) {
+	gcName, err := c.gcLogName(gcID)
+	if err != nil {
+		c.log.Warnf("Unable to load GC %s to sync devices: %v", gcID, err)
+		return
+	}
+	c.sendDeviceSyncUpdate(rpc.RMDeviceSyncUpdate{
+		GC: &rpc.DeviceSyncGC{ID: gcID, Name: gcName},
+	})
+}
+
+// mergeDeviceSyncUpdate merges the update received from the primary device
+// into its last synced state.
+func mergeDeviceSyncUpdate(sync *rpc.RMDeviceSync, update rpc.RMDeviceSyncUpdate) {
+	if contact := update.Contact; contact != nil {
+		i := slices.IndexFunc(sync.Contacts, func(c rpc.DeviceSyncContact) bool {
+			return c.ID == contact.ID
+		})
+		if i < 0 {
+			sync.Contacts = append(sync.Contacts, *contact)
+		}
+	}
+	if gc := update.GC; gc != nil {
+		i := slices.IndexFunc(sync.GCs, func(g rpc.DeviceSyncGC) bool {
+			return g.ID == gc.ID
+		})
+		if i < 0 {
+			sync.GCs = append(sync.GCs, *gc)
+		}
+	}
+
+	convo := update.Convo
+	if convo == nil || len(convo.Messages) == 0 || (convo.UID == nil) == (convo.GC == nil) {
+		return
+	}
+	i := slices.IndexFunc(sync.History, func(h rpc.DeviceSyncConvo) bool {
+		if convo.UID != nil {
+			return h.UID != nil && *h.UID == *convo.UID
+		}
+		return h.GC != nil && *h.GC == *convo.GC
+	})
+	if i < 0 {
+		sync.History = append(sync.History, rpc.DeviceSyncConvo{
+			UID: convo.UID,
+			GC:  convo.GC,
+		})
+		i = len(sync.History) - 1
+	}
+	h := &sync.History[i]
+	h.Messages = append(h.Messages, convo.Messages...)
+	if len(h.Messages) > deviceSyncHistoryMsgs {
+		h.Messages = h.Messages[len(h.Messages)-deviceSyncHistoryMsgs:]
+	}
+}
+
+// handleDeviceSyncUpdate handles an update of the state of the primary
+// device.
+func (c *Client) handleDeviceSyncUpdate(dev PairedDevice, update rpc.RMDeviceSyncUpdate) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		ds, err := c.db.ReadDeviceSync(tx)
+		if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+			return err
+		}
+		ds.From = dev.ID
+		ds.Received = time.Now()
+		mergeDeviceSyncUpdate(&ds.Sync, update)
+		return c.db.StoreDeviceSync(tx, ds)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.Debugf("Applied device sync update from %s", dev.ID)
+	c.ntfns.notifyDeviceSyncUpdate(dev, update)
+	return nil
+}
+
+// DevicePM sends a PM to the user through the paired primary device.
+func (c *Client) DevicePM(uid UserID, msg string) error {
+	dev, err := c.primaryDevice()
+	if err != nil {
+		return err
+	}
+	return c.sendToDevice(dev.ID, rpc.RMDeviceRelay{UID: &uid, Message: msg})
+}
+
+// DeviceGCMessage sends a message to the GC through the paired primary
+// device.
+func (c *Client) DeviceGCMessage(gcID zkidentity.ShortID, msg string) error {
+	dev, err := c.primaryDevice()
+	if err != nil {
+		return err
+	}
+	return c.sendToDevice(dev.ID, rpc.RMDeviceRelay{GC: &gcID, Message: msg})
+}
+
+// handleDeviceRelay sends a message on behalf of a secondary device. The
+// message is synced back to the secondary devices as any other message sent
+// by the local client.
+func (c *Client) handleDeviceRelay(dev PairedDevice, relay rpc.RMDeviceRelay) error {
+	switch {
+	case relay.UID != nil && relay.GC == nil:
+		c.log.Debugf("Relaying PM to %s from device %s", relay.UID, dev.ID)
+		return c.PM(*relay.UID, relay.Message)
+	case relay.GC != nil && relay.UID == nil:
+		c.log.Debugf("Relaying GC message to %s from device %s",
+			relay.GC, dev.ID)
+		return c.GCMessage(*relay.GC, relay.Message, rpc.MessageModeNormal, nil)
+	default:
+		return errors.New("relayed message must have either an UID or a GC")
+	}
+}
+
+// HandoffDevice makes the paired secondary device the primary device, by
+// sending it the ratchets with every contact and the GCs of the local client.
+// The local client must be paired with a single secondary device. After the
+// handoff, the local client becomes a secondary device of the other device.
+//
+// Messages are no longer sent or received from contacts while the handoff is
+// in progress.
+func (c *Client) HandoffDevice(ctx context.Context) error {
+	<-c.abLoaded
+
+	devices, err := c.ListPairedDevices()
+	if err != nil {
+		return err
+	}
+	if len(devices) != 1 || devices[0].Role != DeviceRoleSecondary {
+		return errors.New("handoff requires being paired with a " +
+			"single secondary device")
+	}
+	dev := devices[0]
+
+	// Stop sending messages to contacts and wait until all queued
+	// messages are sent, so that the ratchets are not used after being
+	// handed off.
+	var rus []*RemoteUser
+	for _, uid := range c.rul.userList() {
+		if ru, err := c.rul.byID(uid); err == nil {
+			rus = append(rus, ru)
+			c.rul.del(ru)
+		}
+	}
+	for {
+		queued, sending := c.q.Len()
+		if queued == 0 && sending == 0 {
+			break
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			for _, ru := range rus {
+				c.rul.add(ru, c.LocalNick())
+			}
+			return ctx.Err()
+		}
+	}
+	for _, ru := range rus {
+		ru.stop()
+	}
+
+	var ab []clientdb.AddressBookAndRatchet
+	var handoff rpc.RMDeviceHandoff
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		ab, err = c.db.LoadAddressBook(tx, &c.localID.privKey)
+		if err != nil {
+			return err
+		}
+		if handoff.GCs, err = c.db.ListGCs(tx); err != nil {
+			return err
+		}
+		handoff.GCAliases, err = c.db.GetGCAliases(tx)
+		return err
+	})
+	if err == nil {
+		for _, entry := range ab {
+			handoff.Contacts = append(handoff.Contacts, rpc.DeviceHandoffContact{
+				ID:           *entry.AddressBook.ID,
+				NickAlias:    entry.AddressBook.NickAlias,
+				MyResetRV:    entry.AddressBook.MyResetRV,
+				TheirResetRV: entry.AddressBook.TheirResetRV,
+				Ignored:      entry.AddressBook.Ignored,
+				FirstCreated: entry.AddressBook.FirstCreated.Unix(),
+				Ratchet:      *entry.Ratchet.DiskState(31 * 24 * time.Hour),
+			})
+		}
+		c.log.Infof("Handing off %d contacts and %d GCs to device %s",
+			len(handoff.Contacts), len(handoff.GCs), dev.ID)
+		err = c.sendToDevice(dev.ID, handoff)
+	}
+	if err != nil {
+		// Handoff failed. Resume using the ratchets locally.
+		for _, entry := range ab {
+			_, _, err := c.initRemoteUser(entry.AddressBook.ID,
+				entry.Ratchet, false, clientdb.RawRVID{},
+				entry.AddressBook.MyResetRV,
+				entry.AddressBook.TheirResetRV,
+				entry.AddressBook.Ignored,
+				entry.AddressBook.NickAlias, false)
+			if err != nil {
+				c.log.Errorf("Unable to restart remote user %s: %v",
+					entry.AddressBook.ID.Identity, err)
+			}
+		}
+		return fmt.Errorf("unable to hand off to device %s: %w", dev.ID, err)
+	}
+
+	// The other device now owns the ratchets, so remove them from the
+	// local client.
+	for _, entry := range ab {
+		c.kxl.unlistenReset(entry.AddressBook.MyResetRV)
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		for _, entry := range ab {
+			err := c.db.RemoveUser(tx, entry.AddressBook.ID.Identity, false)
+			if err != nil {
+				return err
+			}
+		}
+		dev, err := c.db.GetPairedDevice(tx, dev.ID)
+		if err != nil {
+			return err
+		}
+		dev.Role = DeviceRolePrimary
+		return c.db.StorePairedDevice(tx, dev)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Request the state of the new primary device, to be kept in sync as
+	// a secondary device.
+	if err := c.RequestDeviceSync(); err != nil {
+		c.log.Warnf("Unable to request device sync after handoff: %v", err)
+	}
+	return nil
+}
+
+// handleDeviceHandoff takes over the contacts and GCs of the primary device,
+// making the local client the primary device.
+func (c *Client) handleDeviceHandoff(dev PairedDevice, handoff rpc.RMDeviceHandoff) error {
+	var res DeviceHandoff
+	for _, hc := range handoff.Contacts {
+		hc := hc
+		r := ratchet.New(rand.Reader)
+		if err := r.Unmarshal(&hc.Ratchet); err != nil {
+			c.log.Errorf("Unable to decode handed off ratchet of %s: %v",
+				hc.ID.Identity, err)
+			continue
+		}
+		r.MyPrivateKey = &c.localID.privKey
+		r.TheirPublicKey = &hc.ID.Key
+
+		entry := &clientdb.AddressBookEntry{
+			ID:           &hc.ID,
+			MyResetRV:    hc.MyResetRV,
+			TheirResetRV: hc.TheirResetRV,
+			Ignored:      hc.Ignored,
+			FirstCreated: time.Unix(hc.FirstCreated, 0),
+			NickAlias:    hc.NickAlias,
+		}
+		err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			return c.db.UpdateAddressBookEntry(tx, entry)
+		})
+		if err != nil {
+			return err
+		}
+		_, _, err = c.initRemoteUser(&hc.ID, r, false, clientdb.RawRVID{},
+			hc.MyResetRV, hc.TheirResetRV, hc.Ignored, hc.NickAlias, false)
+		if err != nil {
+			return err
+		}
+		res.Contacts = append(res.Contacts, hc.ID.Identity)
+	}
+
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		for _, gc := range handoff.GCs {
+			if err := c.db.SaveGC(tx, gc); err != nil {
+				return err
+			}
+			res.GCs = append(res.GCs, gc.ID)
+		}
+
+		aliases, err := c.db.GetGCAliases(tx)
+		if err != nil {
+			return err
+		}
+		hasAlias := make(map[zkidentity.ShortID]bool, len(aliases))
+		for _, gcID := range aliases {
+			hasAlias[gcID] = true
+		}
+		for name, gcID := range handoff.GCAliases {
+			if _, ok := aliases[name]; ok || hasAlias[gcID] {
+				continue
+			}
+			hasAlias[gcID] = true
+			aliases, err = c.db.SetGCAlias(tx, gcID, name)
+			if err != nil {
+				return err
+			}
+		}
+		c.setGCAlias(aliases)
+
+		dev, err = c.db.GetPairedDevice(tx, dev.ID)
+		if err != nil {
+			return err
+		}
+		dev.Role = DeviceRoleSecondary
+		return c.db.StorePairedDevice(tx, dev)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.Infof("Received handoff of %d contacts and %d GCs from device %s. "+
+		"Local client is now the primary device", len(res.Contacts),
+		len(res.GCs), dev.ID)
+	c.ntfns.notifyDeviceHandoff(dev, res)
+	return nil
+}
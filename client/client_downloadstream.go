@@ -0,0 +1,260 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// defaultDownloadStreamStallTimeout is the default interval without receiving
+// new chunks after which a download stream is considered stalled.
+const defaultDownloadStreamStallTimeout = time.Minute
+
+// ErrDownloadStalled is returned by DownloadStream.Read when no new chunks of
+// the file were received during the stall timeout. Read may be called again to
+// keep waiting for the download.
+var ErrDownloadStalled = errors.New("download stalled")
+
+// DownloadStreamConfig is the configuration of a download stream.
+type DownloadStreamConfig struct {
+	// Readahead is the number of chunks (including the one being read)
+	// that must have been downloaded before data of a chunk is returned,
+	// so that readers consuming data at a constant rate (e.g. media
+	// players) do not stutter. If zero, data is returned as soon as each
+	// chunk is received.
+	Readahead int
+
+	// StallTimeout is the interval without receiving chunks after which
+	// Read returns ErrDownloadStalled. If zero, a default of one minute is
+	// used.
+	StallTimeout time.Duration
+}
+
+// DownloadStream yields the bytes of a file download in order, as its chunks
+// are received, so that the file may be consumed before the download
+// completes.
+type DownloadStream struct {
+	c   *Client
+	uid UserID
+	fid clientdb.FileID
+	cfg DownloadStreamConfig
+	reg NotificationRegistration
+
+	// progress is signalled when chunks of the file are received.
+	progress chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+
+	mtx      sync.Mutex
+	md       *rpc.FileMetadata
+	chunkIdx int    // Index of the next chunk to read
+	buf      []byte // Unread data of the last read chunk
+	pos      int64  // Position of the next byte to return
+	skip     int64  // Bytes to skip from the next chunk
+}
+
+// StreamFileDownload returns a stream that yields the bytes of the given
+// download as its chunks are received. The download must have been started
+// (with GetUserContent) from the given user. The stream must be closed after
+// use.
+func (c *Client) StreamFileDownload(uid UserID, fid clientdb.FileID,
+	cfg DownloadStreamConfig) (*DownloadStream, error) {
+
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		_, err := c.db.ReadFileDownload(tx, uid, fid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.StallTimeout <= 0 {
+		cfg.StallTimeout = defaultDownloadStreamStallTimeout
+	}
+	s := &DownloadStream{
+		c:        c,
+		uid:      uid,
+		fid:      fid,
+		cfg:      cfg,
+		progress: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	signal := func(fm rpc.FileMetadata) {
+		if fm.MetadataHash() != fid {
+			return
+		}
+		select {
+		case s.progress <- struct{}{}:
+		default:
+		}
+	}
+	regProgress := c.ntfns.Register(OnFileDownloadProgress(func(_ *RemoteUser, fm rpc.FileMetadata, _ int) {
+		signal(fm)
+	}))
+	regCompleted := c.ntfns.Register(OnFileDownloadCompleted(func(_ *RemoteUser, fm rpc.FileMetadata, _ string) {
+		signal(fm)
+	}))
+	s.reg = NotificationRegistration{unreg: func() bool {
+		return regProgress.Unregister() && regCompleted.Unregister()
+	}}
+	return s, nil
+}
+
+// nextChunk returns the data of the next chunk of the file, if it (and the
+// readahead chunks after it) have already been received.
+func (s *DownloadStream) nextChunk() ([]byte, error) {
+	var data []byte
+	err := s.c.dbView(func(tx clientdb.ReadTx) error {
+		fd, err := s.c.db.ReadFileDownload(tx, s.uid, s.fid)
+		if err != nil {
+			return err
+		}
+		if fd.Metadata == nil {
+			// Metadata not received yet.
+			return nil
+		}
+		s.md = fd.Metadata
+		if s.chunkIdx >= len(fd.Metadata.Manifest) {
+			return io.EOF
+		}
+
+		// Ensure the readahead chunks were received.
+		end := s.chunkIdx + s.cfg.Readahead
+		if end > len(fd.Metadata.Manifest) {
+			end = len(fd.Metadata.Manifest)
+		}
+		if fd.CompletedName == "" {
+			for _, idx := range s.c.db.MissingFileDownloadChunks(tx, &fd) {
+				if idx >= s.chunkIdx && idx < end {
+					return nil
+				}
+			}
+		}
+
+		data, err = s.c.db.ReadFileDownloadChunk(tx, &fd, s.chunkIdx)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	return data, err
+}
+
+// Read is part of the io.Reader interface. It blocks until the data at the
+// current position is received. If no chunks are received during the stall
+// timeout, it returns ErrDownloadStalled.
+func (s *DownloadStream) Read(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	select {
+	case <-s.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	stallTimer := time.NewTimer(s.cfg.StallTimeout)
+	defer stallTimer.Stop()
+	for len(s.buf) == 0 {
+		data, err := s.nextChunk()
+		if err != nil {
+			return 0, err
+		}
+		if data != nil {
+			// Skip data before the position (after a Seek).
+			if s.skip < int64(len(data)) {
+				s.buf = data[s.skip:]
+			}
+			s.skip = 0
+			s.chunkIdx += 1
+			continue
+		}
+
+		// Wait until more chunks are received.
+		select {
+		case <-s.progress:
+			if !stallTimer.Stop() {
+				<-stallTimer.C
+			}
+			stallTimer.Reset(s.cfg.StallTimeout)
+		case <-stallTimer.C:
+			return 0, ErrDownloadStalled
+		case <-s.done:
+			return 0, io.ErrClosedPipe
+		}
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	s.pos += int64(n)
+	return n, nil
+}
+
+// Seek is part of the io.Seeker interface. Seeking requires the file metadata
+// to have been received.
+func (s *DownloadStream) Seek(offset int64, whence int) (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.md == nil {
+		err := s.c.dbView(func(tx clientdb.ReadTx) error {
+			fd, err := s.c.db.ReadFileDownload(tx, s.uid, s.fid)
+			s.md = fd.Metadata
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		if s.md == nil {
+			return 0, fmt.Errorf("metadata of file %s not received yet", s.fid)
+		}
+	}
+
+	pos := offset
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		pos += s.pos
+	case io.SeekEnd:
+		pos += int64(s.md.Size)
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("negative position")
+	}
+
+	// Find the chunk of the new position. Its data before the position is
+	// skipped on the next Read.
+	var chunkStart int64
+	chunkIdx := 0
+	for ; chunkIdx < len(s.md.Manifest); chunkIdx++ {
+		size := int64(s.md.Manifest[chunkIdx].Size)
+		if pos < chunkStart+size {
+			break
+		}
+		chunkStart += size
+	}
+	s.chunkIdx = chunkIdx
+	s.buf = nil
+	s.skip = pos - chunkStart
+	s.pos = pos
+	return pos, nil
+}
+
+// Close is part of the io.Closer interface. Any blocked Read calls return
+// after the stream is closed.
+func (s *DownloadStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.reg.Unregister()
+		close(s.done)
+	})
+	return nil
+}
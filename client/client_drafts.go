@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// MaxDraftSize is the max size of the text of a draft.
+const MaxDraftSize = 1024 * 1024
+
+// Draft returns the unsent draft of the PM conversation with a user or of a
+// GC. target is either the ID of the user or of the GC. Returns an empty
+// string if there is no draft.
+func (c *Client) Draft(target zkidentity.ShortID) (string, error) {
+	var draft clientdb.Draft
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		draft, err = c.db.GetDraft(tx, target)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return "", nil
+	}
+	return draft.Text, err
+}
+
+// SetDraft stores the unsent draft of the PM conversation with a user or of a
+// GC, so that it may be restored after the client restarts. An empty text
+// removes the draft.
+func (c *Client) SetDraft(target zkidentity.ShortID, text string) error {
+	if len(text) > MaxDraftSize {
+		return fmt.Errorf("draft size %d > max size %d", len(text),
+			MaxDraftSize)
+	}
+	if text != "" {
+		if _, err := c.resolveChatTarget(target); err != nil {
+			return err
+		}
+	}
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreDraft(tx, target, text)
+	})
+}
+
+// ListDrafts returns the unsent drafts of all chats, keyed by the ID of the
+// user or GC.
+func (c *Client) ListDrafts() (map[zkidentity.ShortID]clientdb.Draft, error) {
+	var res map[zkidentity.ShortID]clientdb.Draft
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListDrafts(tx)
+		return err
+	})
+	return res, err
+}
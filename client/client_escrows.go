@@ -0,0 +1,713 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// Escrow is a payment to or from a user that is held by the node of the seller
+// until the buyer releases it or the seller cancels it.
+type Escrow = clientdb.Escrow
+
+// holdInvoicePC returns the payment client as a hold invoice payment client.
+func (c *Client) holdInvoicePC() (clientintf.HoldInvoicePaymentClient, error) {
+	hpc, ok := c.pc.(clientintf.HoldInvoicePaymentClient)
+	if !ok {
+		return nil, fmt.Errorf("payment client does not support hold invoices")
+	}
+	return hpc, nil
+}
+
+// getEscrow returns the escrow exchanged with the user.
+func (c *Client) getEscrow(uid UserID, id zkidentity.ShortID) (Escrow, error) {
+	var e Escrow
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		e, err = c.db.GetEscrow(tx, uid, id)
+		return err
+	})
+	return e, err
+}
+
+// saveEscrow stores the updated escrow and notifies the UI.
+func (c *Client) saveEscrow(ru *RemoteUser, e *Escrow) error {
+	e.Updated = time.Now()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreEscrow(tx, *e)
+	})
+	if err != nil {
+		return err
+	}
+	c.ntfns.notifyEscrowUpdated(ru, *e)
+	return nil
+}
+
+// cancelEscrow records the escrow as canceled and optionally notifies the
+// remote user. The hold invoice of the seller must be canceled by the caller.
+func (c *Client) cancelEscrow(ru *RemoteUser, e *Escrow, reason string,
+	notifyRemote bool) error {
+
+	e.Status = clientdb.EscrowStatusCanceled
+	e.Error = reason
+	if err := c.saveEscrow(ru, e); err != nil {
+		return err
+	}
+	ru.log.Infof("Canceled escrow %s of %s: %s", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000), reason)
+	if !notifyRemote {
+		return nil
+	}
+	rm := rpc.RMEscrowCancel{ID: e.ID, Reason: reason}
+	return c.sendWithSendQ("escrowcancel", rm, ru.ID())
+}
+
+// RequestEscrow requests the user to generate a hold invoice to receive an
+// escrowed payment of the given amount from the local client. The invoice is
+// paid once received, but the payment is held by the node of the user until
+// it is released with ReleaseEscrow or until the user cancels it. Progress is
+// reported through OnEscrowUpdatedNtfn.
+//
+// Escrows that exceed a payment budget fail, unless the override is confirmed
+// (see Config.PaymentBudgetConfirmer).
+func (c *Client) RequestEscrow(uid UserID, dcrAmount float64, descr string) (Escrow, error) {
+	if err := c.checkPaymentsAllowed("escrow"); err != nil {
+		return Escrow{}, err
+	}
+	amt, err := dcrutil.NewAmount(dcrAmount)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if amt <= 0 {
+		return Escrow{}, fmt.Errorf("cannot pay user %s <= 0", amt)
+	}
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return Escrow{}, err
+	}
+	mAtoms := int64(amt) * 1000
+	budgetOverride, err := c.checkPaymentBudget(uid, nil, mAtoms)
+	if err != nil {
+		return Escrow{}, err
+	}
+
+	// The preimage is only known by the local client until the payment
+	// is released.
+	var preimage [32]byte
+	if _, err := rand.Read(preimage[:]); err != nil {
+		return Escrow{}, err
+	}
+	hash := sha256.Sum256(preimage[:])
+
+	now := time.Now()
+	e := Escrow{
+		ID:             clientintf.RandomID(),
+		UID:            uid,
+		Buyer:          true,
+		MilliAtoms:     mAtoms,
+		Description:    descr,
+		PaymentHash:    hash[:],
+		Preimage:       preimage[:],
+		Status:         clientdb.EscrowStatusRequested,
+		Created:        now,
+		Updated:        now,
+		Expires:        now.Add(c.cfg.EscrowMaxLifetime),
+		BudgetOverride: budgetOverride,
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreEscrow(tx, e)
+	})
+	if err != nil {
+		return Escrow{}, err
+	}
+
+	rm := rpc.RMEscrowRequest{
+		ID:          e.ID,
+		PaymentHash: e.PaymentHash,
+		MilliAtoms:  mAtoms,
+		Description: descr,
+	}
+	if err := c.sendWithSendQ("escrowrequest", rm, uid); err != nil {
+		return Escrow{}, err
+	}
+	ru.log.Infof("Requested escrow %s of %s", e.ID, amt)
+	return e, nil
+}
+
+// ListEscrows lists the escrows where the local client is the buyer or the
+// seller.
+func (c *Client) ListEscrows() ([]Escrow, error) {
+	var res []Escrow
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListEscrows(tx)
+		return err
+	})
+	return res, err
+}
+
+// handleEscrowRequest handles a request from a remote user to generate a hold
+// invoice for an escrowed payment to the local client.
+func (c *Client) handleEscrowRequest(ru *RemoteUser, req rpc.RMEscrowRequest) error {
+	replyWithErr := func(err error) error {
+		errStr := err.Error()
+		reply := rpc.RMEscrowInvoice{ID: req.ID, Error: &errStr}
+		return c.sendWithSendQ("escrowinvoice", reply, ru.ID())
+	}
+
+	hpc, err := c.holdInvoicePC()
+	if err != nil {
+		return replyWithErr(err)
+	}
+	if req.MilliAtoms <= 0 {
+		return replyWithErr(fmt.Errorf("invalid escrow amount %d", req.MilliAtoms))
+	}
+	if len(req.PaymentHash) != 32 {
+		return replyWithErr(fmt.Errorf("invalid escrow payment hash len %d",
+			len(req.PaymentHash)))
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	// Reply with the same invoice when the request is repeated.
+	e, err := c.getEscrow(ru.ID(), req.ID)
+	switch {
+	case err == nil && e.Buyer:
+		return fmt.Errorf("escrow %s is not an inbound escrow", req.ID)
+	case err == nil && e.Final():
+		return replyWithErr(fmt.Errorf("escrow was already %s", e.Status))
+	case err == nil:
+	case errors.Is(err, clientdb.ErrNotFound):
+		lifetime := c.cfg.EscrowMaxLifetime
+		invoice, err := hpc.AddHoldInvoice(c.ctx, req.PaymentHash,
+			req.MilliAtoms, req.Description, lifetime)
+		if err != nil {
+			ru.log.Warnf("Unable to generate hold invoice for escrow: %v", err)
+			return replyWithErr(errors.New("unable to generate invoice"))
+		}
+		now := time.Now()
+		e = Escrow{
+			ID:          req.ID,
+			UID:         ru.ID(),
+			MilliAtoms:  req.MilliAtoms,
+			Description: req.Description,
+			PaymentHash: req.PaymentHash,
+			Invoice:     invoice,
+			Status:      clientdb.EscrowStatusInvoiced,
+			Created:     now,
+			Expires:     now.Add(lifetime),
+		}
+		if err := c.saveEscrow(ru, &e); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	ru.log.Infof("Generated hold invoice for escrow %s of %s", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000))
+	reply := rpc.RMEscrowInvoice{ID: e.ID, Invoice: e.Invoice}
+	return c.sendWithSendQ("escrowinvoice", reply, ru.ID())
+}
+
+// handleEscrowInvoice handles the hold invoice sent by a remote user to pay an
+// escrow requested by the local client.
+func (c *Client) handleEscrowInvoice(ru *RemoteUser, inv rpc.RMEscrowInvoice) error {
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(ru.ID(), inv.ID)
+	if err != nil {
+		return err
+	}
+	if !e.Buyer {
+		return fmt.Errorf("escrow %s is not an outbound escrow", e.ID)
+	}
+	if e.Status != clientdb.EscrowStatusRequested {
+		ru.log.Warnf("Ignoring invoice for escrow %s with status %s",
+			e.ID, e.Status)
+		return nil
+	}
+	if inv.Error != nil {
+		reason := fmt.Sprintf("remote user replied with error: %s", *inv.Error)
+		return c.cancelEscrow(ru, &e, reason, false)
+	}
+
+	decoded, err := c.pc.DecodeInvoice(c.ctx, inv.Invoice)
+	switch {
+	case err != nil:
+		return c.cancelEscrow(ru, &e, fmt.Sprintf("unable to decode "+
+			"invoice: %v", err), true)
+	case !bytes.Equal(decoded.ID, e.PaymentHash):
+		return c.cancelEscrow(ru, &e, "invoice has wrong payment hash", true)
+	case decoded.MAtoms != e.MilliAtoms:
+		return c.cancelEscrow(ru, &e, fmt.Sprintf("invoice amount %d "+
+			"is not the escrow amount %d", decoded.MAtoms,
+			e.MilliAtoms), true)
+	case decoded.IsExpired(0):
+		return c.cancelEscrow(ru, &e, "invoice is expired", true)
+	}
+
+	spendID, err := c.reservePaymentSpend(ru.ID(), nil, e.MilliAtoms,
+		e.BudgetOverride)
+	if err != nil {
+		return c.cancelEscrow(ru, &e, err.Error(), true)
+	}
+
+	e.Invoice = inv.Invoice
+	e.Status = clientdb.EscrowStatusHeld
+	e.SpendID = &spendID
+	if err := c.saveEscrow(ru, &e); err != nil {
+		c.releasePaymentSpend(spendID)
+		return err
+	}
+
+	ru.log.Infof("Paying hold invoice of escrow %s of %s", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000))
+	go func() {
+		fees, err := c.pc.PayInvoice(c.ctx, e.Invoice)
+		c.handleEscrowPaymentResult(ru, e.ID, fees, err)
+	}()
+	return nil
+}
+
+// trackEscrowPayment waits for the outcome of the payment of an outbound
+// escrow that was started before the client restarted.
+func (c *Client) trackEscrowPayment(ctx context.Context, ru *RemoteUser, e Escrow) {
+	fees, err := c.pc.IsPaymentCompleted(ctx, e.Invoice)
+	c.handleEscrowPaymentResult(ru, e.ID, fees, err)
+}
+
+// handleEscrowPaymentResult records the outcome of the payment of an outbound
+// escrow. The payment only completes once the seller settles or cancels it.
+func (c *Client) handleEscrowPaymentResult(ru *RemoteUser, id zkidentity.ShortID,
+	fees int64, payErr error) {
+
+	if errors.Is(payErr, context.Canceled) {
+		// Payment will be tracked again on restart.
+		return
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(ru.ID(), id)
+	if err != nil {
+		ru.log.Errorf("Unable to load escrow %s: %v", id, err)
+		return
+	}
+
+	if payErr != nil {
+		if e.SpendID != nil {
+			c.releasePaymentSpend(*e.SpendID)
+		}
+		if e.Final() {
+			return
+		}
+		reason := fmt.Sprintf("payment failed: %v", payErr)
+		if err := c.cancelEscrow(ru, &e, reason, false); err != nil {
+			ru.log.Errorf("Unable to store escrow cancellation: %v", err)
+		}
+		return
+	}
+
+	// The payment was settled, so the escrow is settled even if it was
+	// canceled locally.
+	e.Status = clientdb.EscrowStatusSettled
+	e.Error = ""
+	e.Updated = time.Now()
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		// Amount is negative because we're making a payment.
+		err := c.db.RecordUserPayEvent(tx, ru.ID(), "payescrow",
+			-e.MilliAtoms, -fees)
+		if err != nil {
+			return err
+		}
+		return c.db.StoreEscrow(tx, e)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to store settled escrow: %v", err)
+		return
+	}
+	ru.log.Infof("Escrow %s of %s settled", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000))
+	c.ntfns.notifyEscrowUpdated(ru, e)
+}
+
+// refreshSellerEscrow updates the status of an inbound escrow based on the
+// state of its hold invoice.
+func (c *Client) refreshSellerEscrow(hpc clientintf.HoldInvoicePaymentClient,
+	ru *RemoteUser, e *Escrow) error {
+
+	state, err := hpc.HoldInvoiceState(c.ctx, e.PaymentHash)
+	if err != nil {
+		return err
+	}
+	switch {
+	case state == clientintf.HoldInvoiceAccepted && e.Status == clientdb.EscrowStatusInvoiced:
+		e.Status = clientdb.EscrowStatusHeld
+		ru.log.Infof("Payment of escrow %s of %s is held", e.ID,
+			dcrutil.Amount(e.MilliAtoms/1000))
+		return c.saveEscrow(ru, e)
+	case state == clientintf.HoldInvoiceCanceled:
+		return c.cancelEscrow(ru, e, "invoice canceled", false)
+	}
+	return nil
+}
+
+// FulfillEscrow notifies the buyer of an escrow paid to the local client that
+// the order it pays for was fulfilled, so that they may release the payment.
+func (c *Client) FulfillEscrow(uid UserID, id zkidentity.ShortID, note string) error {
+	hpc, err := c.holdInvoicePC()
+	if err != nil {
+		return err
+	}
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(uid, id)
+	if err != nil {
+		return err
+	}
+	if e.Buyer {
+		return fmt.Errorf("escrow %s is not an inbound escrow", id)
+	}
+	if e.Status == clientdb.EscrowStatusInvoiced {
+		if err := c.refreshSellerEscrow(hpc, ru, &e); err != nil {
+			return err
+		}
+	}
+	if e.Status != clientdb.EscrowStatusHeld {
+		return fmt.Errorf("cannot fulfill escrow with status %s", e.Status)
+	}
+
+	e.Status = clientdb.EscrowStatusFulfilled
+	e.Note = note
+	if err := c.saveEscrow(ru, &e); err != nil {
+		return err
+	}
+	rm := rpc.RMEscrowFulfilled{ID: id, Note: note}
+	return c.sendWithSendQ("escrowfulfilled", rm, uid)
+}
+
+// handleEscrowFulfilled handles the notification from the seller of an escrow
+// that the order was fulfilled.
+func (c *Client) handleEscrowFulfilled(ru *RemoteUser, fulfilled rpc.RMEscrowFulfilled) error {
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(ru.ID(), fulfilled.ID)
+	if err != nil {
+		return err
+	}
+	if !e.Buyer {
+		return fmt.Errorf("escrow %s is not an outbound escrow", e.ID)
+	}
+	if e.Status != clientdb.EscrowStatusHeld {
+		ru.log.Warnf("Ignoring fulfillment of escrow %s with status %s",
+			e.ID, e.Status)
+		return nil
+	}
+
+	e.Status = clientdb.EscrowStatusFulfilled
+	e.Note = fulfilled.Note
+	ru.log.Infof("Escrow %s of %s fulfilled by seller", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000))
+	return c.saveEscrow(ru, &e)
+}
+
+// ReleaseEscrow releases the held payment of an escrow paid by the local
+// client, by sending the preimage of the payment to the seller. The escrow is
+// settled once the seller settles the payment.
+func (c *Client) ReleaseEscrow(uid UserID, id zkidentity.ShortID) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(uid, id)
+	if err != nil {
+		return err
+	}
+	if !e.Buyer {
+		return fmt.Errorf("escrow %s is not an outbound escrow", id)
+	}
+	if e.Status != clientdb.EscrowStatusHeld && e.Status != clientdb.EscrowStatusFulfilled {
+		return fmt.Errorf("cannot release escrow with status %s", e.Status)
+	}
+
+	now := time.Now()
+	e.Released = &now
+	if err := c.saveEscrow(ru, &e); err != nil {
+		return err
+	}
+	ru.log.Infof("Releasing escrow %s of %s", e.ID,
+		dcrutil.Amount(e.MilliAtoms/1000))
+	rm := rpc.RMEscrowRelease{ID: id, Preimage: e.Preimage}
+	return c.sendWithSendQ("escrowrelease", rm, uid)
+}
+
+// handleEscrowRelease handles the release of an escrow paid to the local
+// client by settling its hold invoice.
+func (c *Client) handleEscrowRelease(ru *RemoteUser, release rpc.RMEscrowRelease) error {
+	hpc, err := c.holdInvoicePC()
+	if err != nil {
+		return err
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(ru.ID(), release.ID)
+	if err != nil {
+		return err
+	}
+	if e.Buyer {
+		return fmt.Errorf("escrow %s is not an inbound escrow", e.ID)
+	}
+	if e.Status == clientdb.EscrowStatusSettled {
+		ru.log.Debugf("Ignoring duplicated release of escrow %s", e.ID)
+		return nil
+	}
+	hash := sha256.Sum256(release.Preimage)
+	if !bytes.Equal(hash[:], e.PaymentHash) {
+		return fmt.Errorf("released preimage does not match payment "+
+			"hash of escrow %s", e.ID)
+	}
+	if e.Status == clientdb.EscrowStatusInvoiced {
+		if err := c.refreshSellerEscrow(hpc, ru, &e); err != nil {
+			return err
+		}
+	}
+	if e.Status != clientdb.EscrowStatusHeld && e.Status != clientdb.EscrowStatusFulfilled {
+		return fmt.Errorf("cannot settle escrow %s with status %s", e.ID,
+			e.Status)
+	}
+
+	if err := hpc.SettleHoldInvoice(c.ctx, release.Preimage); err != nil {
+		return fmt.Errorf("unable to settle hold invoice of escrow %s: %v",
+			e.ID, err)
+	}
+
+	now := time.Now()
+	e.Preimage = release.Preimage
+	e.Released = &now
+	e.Status = clientdb.EscrowStatusSettled
+	e.Updated = now
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		err := c.db.RecordUserPayEvent(tx, ru.ID(), "escrow", e.MilliAtoms, 0)
+		if err != nil {
+			return err
+		}
+		return c.db.StoreEscrow(tx, e)
+	})
+	if err != nil {
+		return err
+	}
+	ru.log.Infof("Settled escrow %s of %s", e.ID, dcrutil.Amount(e.MilliAtoms/1000))
+	c.ntfns.notifyEscrowUpdated(ru, e)
+	c.firePaymentEvent(ru, PaymentEvent{
+		Type:        PaymentEventInvoiceSettled,
+		MilliAtoms:  e.MilliAtoms,
+		PaymentHash: hex.EncodeToString(e.PaymentHash),
+		Invoice:     e.Invoice,
+		Escrow: &PaymentEventEscrow{
+			ID:          e.ID.String(),
+			Description: e.Description,
+		},
+	})
+	return nil
+}
+
+// CancelEscrow cancels an escrow. The seller may cancel an escrow at any time
+// before it is settled, which returns any held payment to the buyer. The buyer
+// may only cancel an escrow before paying it.
+func (c *Client) CancelEscrow(uid UserID, id zkidentity.ShortID, reason string) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(uid, id)
+	if err != nil {
+		return err
+	}
+	if e.Final() {
+		return fmt.Errorf("escrow %s was already %s", id, e.Status)
+	}
+	if e.Buyer && e.Status != clientdb.EscrowStatusRequested {
+		return fmt.Errorf("paid escrows can only be canceled by the seller")
+	}
+	if !e.Buyer {
+		hpc, err := c.holdInvoicePC()
+		if err != nil {
+			return err
+		}
+		if err := hpc.CancelHoldInvoice(c.ctx, e.PaymentHash); err != nil {
+			return fmt.Errorf("unable to cancel hold invoice: %v", err)
+		}
+	}
+	if reason == "" {
+		reason = "canceled by user"
+	}
+	return c.cancelEscrow(ru, &e, reason, true)
+}
+
+// handleEscrowCancel handles the cancellation of an escrow by the remote user.
+func (c *Client) handleEscrowCancel(ru *RemoteUser, cancel rpc.RMEscrowCancel) error {
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	e, err := c.getEscrow(ru.ID(), cancel.ID)
+	if err != nil {
+		return err
+	}
+	if e.Final() {
+		ru.log.Debugf("Ignoring cancellation of escrow %s with status %s",
+			e.ID, e.Status)
+		return nil
+	}
+	reason := fmt.Sprintf("canceled by remote user: %s", cancel.Reason)
+	if e.Buyer {
+		// Any held payment is returned once the seller cancels the hold
+		// invoice.
+		return c.cancelEscrow(ru, &e, reason, false)
+	}
+
+	// The buyer may only cancel escrows that were not paid yet.
+	hpc, err := c.holdInvoicePC()
+	if err != nil {
+		return err
+	}
+	if err := c.refreshSellerEscrow(hpc, ru, &e); err != nil {
+		return err
+	}
+	if e.Status != clientdb.EscrowStatusInvoiced {
+		return fmt.Errorf("buyer attempted to cancel escrow %s with "+
+			"status %s", e.ID, e.Status)
+	}
+	if err := hpc.CancelHoldInvoice(c.ctx, e.PaymentHash); err != nil {
+		return fmt.Errorf("unable to cancel hold invoice: %v", err)
+	}
+	return c.cancelEscrow(ru, &e, reason, false)
+}
+
+// checkEscrows refreshes the status of the pending escrows and cancels the
+// expired ones.
+func (c *Client) checkEscrows() {
+	escrows, err := c.ListEscrows()
+	if err != nil {
+		c.log.Errorf("Unable to list escrows: %v", err)
+		return
+	}
+	hpc, _ := c.holdInvoicePC()
+
+	c.escrowsMtx.Lock()
+	defer c.escrowsMtx.Unlock()
+
+	now := time.Now()
+	for i := range escrows {
+		e := &escrows[i]
+		if e.Final() {
+			continue
+		}
+		ru, err := c.rul.byID(e.UID)
+		if err != nil {
+			continue
+		}
+
+		if e.Buyer {
+			// Paid escrows are completed by the outcome of the
+			// payment.
+			if e.Status != clientdb.EscrowStatusRequested || now.Before(e.Expires) {
+				continue
+			}
+			err := c.cancelEscrow(ru, e, "expired before receiving invoice", true)
+			if err != nil {
+				ru.log.Errorf("Unable to cancel escrow %s: %v", e.ID, err)
+			}
+			continue
+		}
+
+		if hpc == nil {
+			continue
+		}
+		if err := c.refreshSellerEscrow(hpc, ru, e); err != nil {
+			ru.log.Debugf("Unable to refresh escrow %s: %v", e.ID, err)
+		}
+		if e.Final() || now.Before(e.Expires) {
+			continue
+		}
+
+		// Cancel before the HTLCs of a held payment expire.
+		if err := hpc.CancelHoldInvoice(c.ctx, e.PaymentHash); err != nil {
+			ru.log.Errorf("Unable to cancel hold invoice of expired "+
+				"escrow %s: %v", e.ID, err)
+			continue
+		}
+		if err := c.cancelEscrow(ru, e, "escrow expired", true); err != nil {
+			ru.log.Errorf("Unable to cancel escrow %s: %v", e.ID, err)
+		}
+	}
+}
+
+// runEscrows restarts tracking the payments of outbound escrows and
+// periodically checks the pending escrows.
+func (c *Client) runEscrows(ctx context.Context) error {
+	select {
+	case <-c.abLoaded:
+	case <-ctx.Done():
+		return nil
+	}
+
+	escrows, err := c.ListEscrows()
+	if err != nil {
+		return err
+	}
+	for _, e := range escrows {
+		if !e.Buyer || (e.Status != clientdb.EscrowStatusHeld &&
+			e.Status != clientdb.EscrowStatusFulfilled) {
+			continue
+		}
+		ru, err := c.rul.byID(e.UID)
+		if err != nil {
+			c.log.Warnf("Unable to restart escrow %s: %v", e.ID, err)
+			continue
+		}
+		go c.trackEscrowPayment(ctx, ru, e)
+	}
+
+	ticker := time.NewTicker(c.cfg.EscrowCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.checkEscrows()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// ChatExportFormat is the format of an exported conversation.
+type ChatExportFormat string
+
+const (
+	// ChatExportJSON exports conversations as JSON.
+	ChatExportJSON ChatExportFormat = "json"
+
+	// ChatExportHTML exports conversations as a self-contained HTML file.
+	ChatExportHTML ChatExportFormat = "html"
+)
+
+// chatExportVersion is the version of the export format.
+const chatExportVersion = 1
+
+// ChatExportEmbed is an embed sent in an exported message.
+type ChatExportEmbed struct {
+	Type     string              `json:"type,omitempty"`
+	Alt      string              `json:"alt,omitempty"`
+	Data     []byte              `json:"data,omitempty"`
+	Download *zkidentity.ShortID `json:"download,omitempty"`
+	Filename string              `json:"filename,omitempty"`
+	Size     uint64              `json:"size,omitempty"`
+	Cost     uint64              `json:"cost,omitempty"`
+}
+
+// ChatExportMessage is a message in an exported conversation. Embeds in the
+// message text are replaced by "[embed N]" markers, where N is the index of
+// the embed in the Embeds list.
+type ChatExportMessage struct {
+	Timestamp time.Time         `json:"timestamp"`
+	From      string            `json:"from"`
+	Message   string            `json:"message"`
+	Embeds    []ChatExportEmbed `json:"embeds,omitempty"`
+}
+
+// ChatExportTip is a tip sent to or received from the remote user of an
+// exported PM conversation.
+type ChatExportTip struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Sent       bool      `json:"sent"`
+	MilliAtoms int64     `json:"milli_atoms"`
+	FeesMAtoms int64     `json:"fees_milli_atoms,omitempty"`
+}
+
+// ChatExport is an exported PM or GC conversation.
+type ChatExport struct {
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exported_at"`
+	LocalID    UserID              `json:"local_id"`
+	LocalNick  string              `json:"local_nick"`
+	UID        *UserID             `json:"uid,omitempty"`
+	Nick       string              `json:"nick,omitempty"`
+	GC         *zkidentity.ShortID `json:"gc,omitempty"`
+	GCName     string              `json:"gc_name,omitempty"`
+	Messages   []ChatExportMessage `json:"messages"`
+	Tips       []ChatExportTip     `json:"tips,omitempty"`
+}
+
+// Title returns a title for the exported conversation.
+func (ce *ChatExport) Title() string {
+	if ce.GC != nil {
+		return fmt.Sprintf("GC %s", ce.GCName)
+	}
+	return fmt.Sprintf("Conversation with %s", ce.Nick)
+}
+
+// WriteJSON writes the export as JSON to w.
+func (ce *ChatExport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ce)
+}
+
+// WriteHTML writes the export as a self-contained HTML page to w. Embedded
+// images are included inline.
+func (ce *ChatExport) WriteHTML(w io.Writer) error {
+	return chatExportTmpl.Execute(w, ce)
+}
+
+// Write writes the export in the given format to w.
+func (ce *ChatExport) Write(w io.Writer, format ChatExportFormat) error {
+	switch format {
+	case ChatExportJSON:
+		return ce.WriteJSON(w)
+	case ChatExportHTML:
+		return ce.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// parseExportMessage splits the embeds out of a logged message.
+func parseExportMessage(msg string) (string, []ChatExportEmbed) {
+	var embeds []ChatExportEmbed
+	text := mdembeds.ReplaceEmbeds(msg, func(args mdembeds.EmbeddedArgs) string {
+		embed := ChatExportEmbed{
+			Type:     args.Typ,
+			Alt:      args.Alt,
+			Data:     args.Data,
+			Filename: args.Filename,
+			Size:     args.Size,
+			Cost:     args.Cost,
+		}
+		if !args.Download.IsEmpty() {
+			download := args.Download
+			embed.Download = &download
+		}
+		embeds = append(embeds, embed)
+		return fmt.Sprintf("[embed %d]", len(embeds)-1)
+	})
+	return text, embeds
+}
+
+// ExportChat exports the logged history of the PM conversation with a user
+// or of a GC. target is either the ID of the user or of the GC. For PMs, the
+// tips sent to and received from the user are also exported.
+func (c *Client) ExportChat(target zkidentity.ShortID) (*ChatExport, error) {
+	ce := &ChatExport{
+		Version:    chatExportVersion,
+		ExportedAt: time.Now(),
+		LocalID:    c.PublicID(),
+		LocalNick:  c.LocalNick(),
+		Messages:   []ChatExportMessage{},
+	}
+
+	var gcName string
+	ru, err := c.rul.byID(target)
+	if err != nil {
+		gcName, err = c.GetGCAlias(target)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil, fmt.Errorf("%s is not a user or GC", target)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ce.GC = &target
+		ce.GCName = gcName
+	} else {
+		ce.UID = &target
+		ce.Nick = ru.Nick()
+	}
+
+	var entries []clientdb.PMLogEntry
+	var payEvents []clientdb.PayStatEvent
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		if ce.GC != nil {
+			entries, err = c.db.ReadLogGCMsg(tx, gcName, target, math.MaxInt32, 0)
+			return err
+		}
+		entries, err = c.db.ReadLogPM(tx, target, math.MaxInt32, 0)
+		if err != nil {
+			return err
+		}
+		payEvents, err = c.db.ListUserPayEvents(tx, target)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		text, embeds := parseExportMessage(e.Message)
+		ce.Messages = append(ce.Messages, ChatExportMessage{
+			Timestamp: time.Unix(e.Timestamp, 0),
+			From:      e.From,
+			Message:   text,
+			Embeds:    embeds,
+		})
+	}
+
+	for _, pe := range payEvents {
+		if pe.Event != "tip" && pe.Event != "paytip" {
+			continue
+		}
+		tip := ChatExportTip{
+			Timestamp:  time.Unix(pe.Timestamp, 0),
+			Sent:       pe.Amount < 0,
+			MilliAtoms: pe.Amount,
+			FeesMAtoms: -pe.PayFee,
+		}
+		if tip.Sent {
+			tip.MilliAtoms = -tip.MilliAtoms
+		}
+		ce.Tips = append(ce.Tips, tip)
+	}
+
+	return ce, nil
+}
+
+var chatExportTmpl = template.Must(template.New("export").Funcs(template.FuncMap{
+	"ts": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+	"dataURI": func(typ string, data []byte) template.URL {
+		return template.URL("data:" + typ + ";base64," +
+			base64.StdEncoding.EncodeToString(data))
+	},
+	"isImage": func(typ string) bool {
+		return strings.HasPrefix(typ, "image/")
+	},
+	"isText": func(typ string) bool {
+		return strings.HasPrefix(typ, "text/")
+	},
+	"str": func(b []byte) string {
+		return string(b)
+	},
+	"dcr": func(matoms int64) string {
+		return fmt.Sprintf("%.8f DCR", float64(matoms)/1e11)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.msg { margin: 0.5em 0; }
+.ts { color: #888; font-family: monospace; }
+.from { font-weight: bold; }
+.text { white-space: pre-wrap; }
+.embed { margin: 0.5em 0 0.5em 2em; }
+.embed img { max-width: 480px; }
+table { border-collapse: collapse; }
+td, th { padding: 0.2em 1em; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>Exported by {{ .LocalNick }} ({{ .LocalID }}) at {{ ts .ExportedAt }}.</p>
+{{- if .UID }}
+<p>Remote user ID: {{ .UID }}</p>
+{{- end }}
+{{- if .GC }}
+<p>GC ID: {{ .GC }}</p>
+{{- end }}
+<h2>Messages</h2>
+{{- range .Messages }}
+<div class="msg">
+<span class="ts">{{ ts .Timestamp }}</span>
+<span class="from">&lt;{{ .From }}&gt;</span>
+<span class="text">{{ .Message }}</span>
+{{- range $i, $e := .Embeds }}
+<div class="embed">[embed {{ $i }}]
+{{- if and $e.Data (isImage $e.Type) }}
+<br><img src="{{ dataURI $e.Type $e.Data }}" alt="{{ $e.Alt }}">
+{{- else if and $e.Data (isText $e.Type) }}
+<pre>{{ str $e.Data }}</pre>
+{{- else if $e.Data }}
+<a download="{{ $e.Filename }}" href="{{ dataURI $e.Type $e.Data }}">{{ if $e.Filename }}{{ $e.Filename }}{{ else }}{{ $e.Type }} file{{ end }}</a>
+{{- else if $e.Download }}
+Shared file {{ $e.Filename }} ({{ $e.Size }} bytes, id {{ $e.Download }})
+{{- end }}
+{{- if $e.Alt }} <i>{{ $e.Alt }}</i>{{ end }}
+</div>
+{{- end }}
+</div>
+{{- end }}
+{{- if .Tips }}
+<h2>Tips</h2>
+<table>
+<tr><th>Date</th><th>Direction</th><th>Amount</th><th>Fees</th></tr>
+{{- range .Tips }}
+<tr><td>{{ ts .Timestamp }}</td><td>{{ if .Sent }}Sent{{ else }}Received{{ end }}</td><td>{{ dcr .MilliAtoms }}</td><td>{{ dcr .FeesMAtoms }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/lnurl"
+)
+
+// ExternalTipReceipt is the receipt of a tip paid to a recipient outside of
+// the contact graph.
+type ExternalTipReceipt = clientdb.ExternalTipReceipt
+
+// TipExternal pays a tip to a recipient outside of the contacts through
+// LNURL-pay and stores its receipt. The amount of the tip is reserved in the
+// global payment budget before the payment is attempted, and released if the
+// payment fails. Failing to store the receipt of a completed payment is only
+// logged.
+func (c *Client) TipExternal(ctx context.Context, hc *http.Client, target string,
+	mAtoms int64, comment string) (ExternalTipReceipt, error) {
+
+	spendID, err := c.reservePaymentSpend(UserID{}, nil, mAtoms, false)
+	if err != nil {
+		return ExternalTipReceipt{}, err
+	}
+	p, err := lnurl.Pay(ctx, hc, c.pc, target, mAtoms, comment)
+	if err != nil {
+		c.releasePaymentSpend(spendID)
+		return ExternalTipReceipt{}, err
+	}
+	r, err := c.recordExternalTip(p)
+	if err != nil {
+		c.log.Errorf("Unable to store receipt of external tip to %s: %v",
+			target, err)
+	}
+	return r, nil
+}
+
+// PayExternalInvoice pays an LN invoice that is not associated with any
+// contact (for example, an invoice pasted in a chat). The amount of the
+// invoice is reserved in the global payment budget before the payment is
+// attempted, and released if the payment fails. Returns the fees paid.
+func (c *Client) PayExternalInvoice(ctx context.Context, invoice string) (int64, error) {
+	inv, err := c.pc.DecodeInvoice(ctx, invoice)
+	if err != nil {
+		return 0, err
+	}
+	spendID, err := c.reservePaymentSpend(UserID{}, nil, inv.MAtoms, false)
+	if err != nil {
+		return 0, err
+	}
+	fees, err := c.pc.PayInvoice(ctx, invoice)
+	if err != nil {
+		c.releasePaymentSpend(spendID)
+		return 0, err
+	}
+	return fees, nil
+}
+
+// recordExternalTip stores the receipt of a completed LNURL payment.
+func (c *Client) recordExternalTip(p *lnurl.Payment) (ExternalTipReceipt, error) {
+	r := ExternalTipReceipt{
+		Target:      p.Target,
+		Description: p.Description,
+		MAtoms:      p.MAtoms,
+		Fees:        p.Fees,
+		Invoice:     p.Invoice,
+		PaymentHash: p.PaymentHash,
+		Comment:     p.Comment,
+		Timestamp:   time.Now(),
+	}
+	if sa := p.SuccessAction; sa != nil {
+		switch sa.Tag {
+		case "message":
+			r.SuccessMessage = sa.Message
+		case "url":
+			r.SuccessMessage = sa.Description
+			r.SuccessURL = sa.URL
+		case "aes":
+			r.SuccessMessage = sa.Description
+		}
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreExternalTipReceipt(tx, r)
+	})
+	return r, err
+}
+
+// ListExternalTips returns the receipts of external tips, from newest to
+// oldest.
+func (c *Client) ListExternalTips() ([]ExternalTipReceipt, error) {
+	var receipts []ExternalTipReceipt
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		receipts, err = c.db.ListExternalTipReceipts(tx)
+		return err
+	})
+	for i, j := 0, len(receipts)-1; i < j; i, j = i+1, j-1 {
+		receipts[i], receipts[j] = receipts[j], receipts[i]
+	}
+	return receipts, err
+}
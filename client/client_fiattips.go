@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+const (
+	// DefaultTipFiatMaxSlippage is the default max change (in percent) of
+	// the exchange rate between starting a fiat-denominated tip and paying
+	// it.
+	DefaultTipFiatMaxSlippage = 2.0
+
+	// fiatTipMaxRateAge is the max age of the exchange rate used to
+	// convert fiat-denominated tips.
+	fiatTipMaxRateAge = time.Hour
+)
+
+// dcrUSDRate returns the current DCR/USD exchange rate, as long as it was
+// updated within maxAge.
+func (c *Client) dcrUSDRate(maxAge time.Duration) (float64, time.Time, error) {
+	if c.cfg.DCRUSDRate == nil {
+		return 0, time.Time{}, errors.New("exchange rate is not available")
+	}
+	rate, updated := c.cfg.DCRUSDRate()
+	if rate <= 0 {
+		return 0, time.Time{}, errors.New("exchange rate is not available")
+	}
+	if time.Since(updated) > maxAge {
+		return 0, time.Time{}, fmt.Errorf("exchange rate is stale (last "+
+			"updated %s)", updated.Format(time.RFC3339))
+	}
+	return rate, updated, nil
+}
+
+// convertUSD converts the USD amount to DCR at the given exchange rate.
+func convertUSD(usdAmount, rate float64, updated time.Time) (dcrutil.Amount, *rpc.RMFiatConversion, error) {
+	amt, err := dcrutil.NewAmount(usdAmount / rate)
+	if err != nil {
+		return 0, nil, err
+	}
+	if amt <= 0 {
+		return 0, nil, fmt.Errorf("USD amount %.2f is too small", usdAmount)
+	}
+	fiat := &rpc.RMFiatConversion{
+		Currency:  "USD",
+		Amount:    usdAmount,
+		Rate:      rate,
+		Timestamp: updated.Unix(),
+	}
+	return amt, fiat, nil
+}
+
+// TipUserUSD starts an attempt to tip the user an amount denominated in USD.
+// The amount is converted to DCR at the current exchange rate, which is
+// recorded in the receipt of the tip. The invoice of the tip is only paid
+// while the exchange rate remains within Config.TipFiatMaxSlippage of the
+// rate used in the conversion.
+//
+// Returns the converted DCR amount.
+func (c *Client) TipUserUSD(uid UserID, usdAmount float64, maxAttempts int32) (dcrutil.Amount, error) {
+	if usdAmount <= 0 {
+		return 0, fmt.Errorf("cannot pay user %f USD <= 0", usdAmount)
+	}
+	rate, updated, err := c.dcrUSDRate(fiatTipMaxRateAge)
+	if err != nil {
+		return 0, err
+	}
+	amt, fiat, err := convertUSD(usdAmount, rate, updated)
+	if err != nil {
+		return 0, err
+	}
+	c.log.Debugf("Converted tip of %.2f USD to %s at rate %.4f", usdAmount,
+		amt, rate)
+	return amt, c.tipUser(uid, amt.ToCoin(), maxAttempts, nil, fiat)
+}
+
+// rateSlippage returns the change (in percent) from the original to the
+// current exchange rate.
+func rateSlippage(original, current float64) float64 {
+	return math.Abs(current-original) / original * 100
+}
+
+// checkFiatSlippage returns an error if the current exchange rate differs
+// from the rate used to convert a fiat-denominated payment by more than the
+// configured max slippage.
+func (c *Client) checkFiatSlippage(fiat *rpc.RMFiatConversion) error {
+	if fiat == nil || c.cfg.TipFiatMaxSlippage < 0 {
+		return nil
+	}
+	if fiat.Currency != "USD" || fiat.Rate <= 0 {
+		return fmt.Errorf("unsupported fiat conversion %s at rate %f",
+			fiat.Currency, fiat.Rate)
+	}
+	rate, _, err := c.dcrUSDRate(fiatTipMaxRateAge)
+	if err != nil {
+		return err
+	}
+	slippage := rateSlippage(fiat.Rate, rate)
+	if slippage > c.cfg.TipFiatMaxSlippage {
+		return fmt.Errorf("DCR/USD rate changed %.2f%% (from %.4f to %.4f) "+
+			"since the tip was started, above the max slippage of %.2f%%",
+			slippage, fiat.Rate, rate, c.cfg.TipFiatMaxSlippage)
+	}
+	return nil
+}
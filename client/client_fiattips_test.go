@@ -0,0 +1,49 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// TestConvertUSD tests converting USD-denominated tips to DCR.
+func TestConvertUSD(t *testing.T) {
+	updated := time.Unix(1700000000, 0)
+	amt, fiat, err := convertUSD(5, 20, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dcrutil.Amount(25000000); amt != want {
+		t.Fatalf("unexpected amount: got %s, want %s", amt, want)
+	}
+	if fiat.Currency != "USD" || fiat.Amount != 5 || fiat.Rate != 20 ||
+		fiat.Timestamp != updated.Unix() {
+		t.Fatalf("unexpected conversion: %+v", fiat)
+	}
+
+	// Amounts that convert to less than one atom are rejected.
+	if _, _, err := convertUSD(1e-9, 20, updated); err == nil {
+		t.Fatal("expected error converting too small amount")
+	}
+}
+
+// TestRateSlippage tests calculating the change of exchange rates.
+func TestRateSlippage(t *testing.T) {
+	tests := []struct {
+		original, current float64
+		want              float64
+	}{
+		{original: 20, current: 20, want: 0},
+		{original: 20, current: 21, want: 5},
+		{original: 20, current: 19, want: 5},
+		{original: 20, current: 10, want: 50},
+	}
+	for _, tc := range tests {
+		got := rateSlippage(tc.original, tc.current)
+		if got != tc.want {
+			t.Fatalf("%f -> %f: unexpected slippage: got %f, want %f",
+				tc.original, tc.current, got, tc.want)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"golang.org/x/exp/slices"
 )
@@ -44,6 +46,12 @@ func (c *Client) StoreContentFilter(cf *clientdb.ContentFilter) error {
 	if _, err := regexp.Compile(cf.Regexp); err != nil {
 		return fmt.Errorf("invalid content filter regexp: %v", err)
 	}
+	if !cf.Action.IsValid() {
+		return fmt.Errorf("invalid content filter action %q", cf.Action)
+	}
+	if cf.Regexp == "" && cf.EmbedType == "" {
+		return fmt.Errorf("content filter needs either a regexp or an embed type")
+	}
 
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		return c.db.StoreContentFilter(tx, cf)
@@ -123,13 +131,22 @@ func (c *Client) ListContentFilters() []clientdb.ContentFilter {
 	return res
 }
 
-// shouldFilter determines if any of the content filtering rules applies to
-// the data. It returns the id of the rule that filters the data.
-func (c *Client) shouldFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
-	pid *clientintf.PostID, postFrom *clientintf.UserID, data string) (bool, uint64) {
+// msgHasEmbedType returns true if the message has an embed with a mime type
+// that starts with typ.
+func msgHasEmbedType(msg, typ string) bool {
+	for _, idx := range mdembeds.FindAllStringIndex(msg) {
+		args := mdembeds.ParseEmbedArgs(msg[idx[0]:idx[1]])
+		if strings.HasPrefix(args.Typ, typ) {
+			return true
+		}
+	}
+	return false
+}
 
-	var filter bool
-	var id uint64
+// matchContentFilter returns the first content filter rule that applies to the
+// data or nil if no rule applies.
+func (c *Client) matchContentFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
+	pid *clientintf.PostID, postFrom *clientintf.UserID, data string) *clientdb.ContentFilter {
 
 	isGCM := gcid != nil
 	isPostComment := postFrom != nil
@@ -137,6 +154,7 @@ func (c *Client) shouldFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
 	isPM := !isPost && !isPostComment && !isGCM
 
 	c.filtersMtx.Lock()
+	defer c.filtersMtx.Unlock()
 	for _, cf := range c.filters {
 		// Determine if this cf applies to this message.
 		if isPM && cf.SkipPMs {
@@ -157,6 +175,9 @@ func (c *Client) shouldFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
 		if cf.GC != nil && gcid != nil && !cf.GC.ConstantTimeEq(gcid) {
 			continue
 		}
+		if cf.EmbedType != "" && !msgHasEmbedType(data, cf.EmbedType) {
+			continue
+		}
 
 		// This cf does in fact apply to this message. Check the regexp.
 		re, ok := c.filtersRegexps[cf.ID]
@@ -182,33 +203,54 @@ func (c *Client) shouldFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
 			continue
 		}
 
-		// Should filter!
-		c.log.Tracef("Filtering msg from %s due to rule %d", uid, cf.ID)
-		filter = true
-		id = cf.ID
-
-		// Only create the notification object if there are handlers
-		// for the event registered, to avoid unnecessary work.
-		if c.ntfns.AnyRegistered(OnMsgContentFilteredNtfn(nil)) {
-			event := MsgContentFilteredEvent{
-				UID:           uid,
-				GC:            gcid,
-				PID:           pid,
-				PostFrom:      postFrom,
-				IsPostComment: isPostComment,
-				Msg:           data,
-				Rule:          cf,
-			}
-			c.ntfns.notifyMsgContentFiltered(event)
+		cf := cf
+		return &cf
+	}
+	return nil
+}
+
+// shouldFilter determines if any of the content filtering rules applies to
+// the data. It returns true if the data should be hidden from the user and the
+// id of the rule that applies to the data (which may be non-zero even when the
+// data should not be hidden, for rules that mute or flag the data).
+func (c *Client) shouldFilter(uid clientintf.UserID, gcid *zkidentity.ShortID,
+	pid *clientintf.PostID, postFrom *clientintf.UserID, data string) (bool, uint64) {
+
+	cf := c.matchContentFilter(uid, gcid, pid, postFrom, data)
+	if cf == nil {
+		return false, 0
+	}
+
+	c.log.Tracef("Filtering msg from %s due to rule %d (action %s)", uid,
+		cf.ID, cf.Action)
+
+	// Only create the notification object if there are handlers
+	// for the event registered, to avoid unnecessary work.
+	if c.ntfns.AnyRegistered(OnMsgContentFilteredNtfn(nil)) {
+		event := MsgContentFilteredEvent{
+			UID:           uid,
+			GC:            gcid,
+			PID:           pid,
+			PostFrom:      postFrom,
+			IsPostComment: postFrom != nil,
+			Msg:           data,
+			Rule:          *cf,
 		}
-		break
+		c.ntfns.notifyMsgContentFiltered(event)
 	}
-	c.filtersMtx.Unlock()
 
-	return filter, id
+	return cf.Action == clientdb.ContentFilterActionHide, cf.ID
+}
+
+// isMsgMuted returns true if the given PM or GC message matches a content
+// filter with the mute action.
+func (c *Client) isMsgMuted(uid UserID, gcid *zkidentity.ShortID, msg string) bool {
+	cf := c.matchContentFilter(uid, gcid, nil, nil, msg)
+	return cf != nil && cf.Action == clientdb.ContentFilterActionMute
 }
 
-// FilterPM returns true if the pm sent by the specified user should be filtered.
+// FilterPM returns true if the pm sent by the specified user should be filtered
+// (hidden).
 func (c *Client) FilterPM(uid UserID, msg string) (bool, uint64) {
 	return c.shouldFilter(uid, nil, nil, nil, msg)
 }
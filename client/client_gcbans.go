@@ -0,0 +1,280 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// GCModLogEntry is an entry of the local audit log of moderation actions taken
+// in a GC.
+type GCModLogEntry = clientdb.GCModLogEntry
+
+// The following are the actions recorded in the GC moderation log.
+const (
+	GCModActionKick        = "kick"
+	GCModActionBan         = "ban"
+	GCModActionUnban       = "unban"
+	GCModActionAddAdmin    = "addadmin"
+	GCModActionDelAdmin    = "deladmin"
+	GCModActionAddMod      = "addmod"
+	GCModActionDelMod      = "delmod"
+	GCModActionChangeOwner = "changeowner"
+	GCModActionSetPerms    = "setperms"
+	GCModActionPin         = "pin"
+	GCModActionUnpin       = "unpin"
+	GCModActionRename      = "rename"
+	GCModActionUpgrade     = "upgrade"
+	GCModActionSlowMode    = "slowmode"
+	GCModActionMetadata    = "metadata"
+	GCModActionAddChannel  = "addchannel"
+	GCModActionDelChannel  = "delchannel"
+	GCModActionJoinPolicy  = "joinpolicy"
+)
+
+// gcModLogEntries returns the moderation log entries that correspond to the
+// changes made by actor from oldGC to newGC.
+func gcModLogEntries(actor clientintf.UserID, oldGC, newGC *rpc.RMGroupList,
+	reason string) []GCModLogEntry {
+
+	var res []GCModLogEntry
+	now := time.Now()
+	add := func(action string, target *zkidentity.ShortID, details string) {
+		var t *zkidentity.ShortID
+		if target != nil {
+			tt := *target
+			t = &tt
+		}
+		res = append(res, GCModLogEntry{
+			Timestamp: now,
+			Actor:     actor,
+			Action:    action,
+			Target:    t,
+			Details:   details,
+			Reason:    reason,
+		})
+	}
+	stillMember := func(uid zkidentity.ShortID) bool {
+		return slices.Contains(newGC.Members, uid)
+	}
+
+	if oldGC.Version != newGC.Version {
+		add(GCModActionUpgrade, nil, fmt.Sprintf("version %d", newGC.Version))
+	}
+	if len(oldGC.Members) > 0 && len(newGC.Members) > 0 && oldGC.Members[0] != newGC.Members[0] {
+		add(GCModActionChangeOwner, &newGC.Members[0], "")
+	}
+
+	bans := sliceDiff(oldGC.Banned, newGC.Banned)
+	for i := range bans.added {
+		add(GCModActionBan, &bans.added[i], "")
+	}
+	for i := range bans.removed {
+		add(GCModActionUnban, &bans.removed[i], "")
+	}
+	members := sliceDiff(oldGC.Members, newGC.Members)
+	for i := range members.removed {
+		uid := members.removed[i]
+		if uid == actor || slices.Contains(newGC.Banned, uid) {
+			continue
+		}
+		add(GCModActionKick, &uid, "")
+	}
+
+	admins := sliceDiff(oldGC.ExtraAdmins, newGC.ExtraAdmins)
+	for i := range admins.added {
+		add(GCModActionAddAdmin, &admins.added[i], "")
+	}
+	for i := range admins.removed {
+		if stillMember(admins.removed[i]) {
+			add(GCModActionDelAdmin, &admins.removed[i], "")
+		}
+	}
+	mods := sliceDiff(oldGC.Moderators, newGC.Moderators)
+	for i := range mods.added {
+		add(GCModActionAddMod, &mods.added[i], "")
+	}
+	for i := range mods.removed {
+		if stillMember(mods.removed[i]) {
+			add(GCModActionDelMod, &mods.removed[i], "")
+		}
+	}
+
+	// Upgrades set the default permissions, so only record explicit
+	// changes.
+	if oldGC.Version == newGC.Version {
+		if oldGC.AdminPerms != newGC.AdminPerms {
+			add(GCModActionSetPerms, nil, fmt.Sprintf("%s: %s",
+				GCRoleAdmin, newGC.AdminPerms))
+		}
+		if oldGC.ModeratorPerms != newGC.ModeratorPerms {
+			add(GCModActionSetPerms, nil, fmt.Sprintf("%s: %s",
+				GCRoleModerator, newGC.ModeratorPerms))
+		}
+	}
+
+	pins := sliceDiff(oldGC.Pinned, newGC.Pinned)
+	for _, id := range pins.added {
+		add(GCModActionPin, nil, id.String())
+	}
+	for _, id := range pins.removed {
+		add(GCModActionUnpin, nil, id.String())
+	}
+	if oldGC.Name != newGC.Name {
+		add(GCModActionRename, nil, newGC.Name)
+	}
+	if oldGC.SlowModeInterval != newGC.SlowModeInterval {
+		interval := time.Duration(newGC.SlowModeInterval) * time.Second
+		add(GCModActionSlowMode, nil, interval.String())
+	}
+	for _, field := range gcMetadataChanges(oldGC, newGC) {
+		add(GCModActionMetadata, nil, string(field))
+	}
+	addedChannels, removedChannels := gcChannelChanges(oldGC, newGC)
+	for _, ch := range addedChannels {
+		add(GCModActionAddChannel, nil, ch.Name)
+	}
+	for _, ch := range removedChannels {
+		add(GCModActionDelChannel, nil, ch.Name)
+	}
+	if oldGC.MaxMembers != newGC.MaxMembers || oldGC.JoinApproval != newGC.JoinApproval {
+		add(GCModActionJoinPolicy, nil, fmt.Sprintf("max members %d, approval %v",
+			newGC.MaxMembers, newGC.JoinApproval))
+	}
+
+	return res
+}
+
+// logGCModActions records the moderation actions made by actor when changing
+// the GC from oldGC to newGC in the GC moderation log.
+func (c *Client) logGCModActions(actor clientintf.UserID, oldGC, newGC *rpc.RMGroupList,
+	reason string) {
+
+	entries := gcModLogEntries(actor, oldGC, newGC, reason)
+	if len(entries) == 0 {
+		return
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.AppendGCModLog(tx, newGC.ID, entries...)
+	})
+	if err != nil {
+		c.log.Errorf("Unable to record moderation actions of GC %s: %v",
+			newGC.ID, err)
+	}
+}
+
+// GCModLog returns the local audit log of moderation actions taken in the GC.
+func (c *Client) GCModLog(gcID zkidentity.ShortID) ([]GCModLogEntry, error) {
+	var res []GCModLogEntry
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListGCModLog(tx, gcID)
+		return err
+	})
+	return res, err
+}
+
+// ListGCBans returns the list of users banned from the GC.
+func (c *Client) ListGCBans(gcID zkidentity.ShortID) ([]clientintf.UserID, error) {
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return nil, err
+	}
+	return gc.Banned, nil
+}
+
+// BanFromGC bans the user from the GC. If the user is a member of the GC, they
+// are kicked from it. Banned users cannot be invited back to the GC until they
+// are unbanned. The local client must have the kick permission in the GC.
+func (c *Client) BanFromGC(gcID zkidentity.ShortID, uid UserID, reason string) error {
+	var wasMember bool
+	localID := c.PublicID()
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot ban users from GC with version < %d",
+				gcRolesVersion)
+		}
+		if uid == localID {
+			return fmt.Errorf("cannot ban local client from GC")
+		}
+		if slices.Contains(gc.Banned, uid) {
+			return fmt.Errorf("user %s is already banned from GC", uid)
+		}
+		localRole, uidRole := GCMemberRole(gc, localID), GCMemberRole(gc, uid)
+		if uidRole >= localRole {
+			return fmt.Errorf("local user with role %s cannot ban "+
+				"user with role %s from GC", localRole, uidRole)
+		}
+
+		if idx := slices.Index(gc.Members, uid); idx > -1 {
+			wasMember = true
+			gc.Members = slices.Delete(gc.Members, idx, idx+1)
+		}
+		if idx := slices.Index(gc.ExtraAdmins, uid); idx > -1 {
+			gc.ExtraAdmins = slices.Delete(gc.ExtraAdmins, idx, idx+1)
+		}
+		if idx := slices.Index(gc.Moderators, uid); idx > -1 {
+			gc.Moderators = slices.Delete(gc.Moderators, idx, idx+1)
+		}
+		gc.Banned = append(gc.Banned, uid)
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(localID, &oldGC, &newGC, reason)
+
+	us := uid.String()
+	if ru, err := c.rul.byID(uid); err == nil {
+		us = ru.String()
+	}
+	c.log.Infof("Banning %s from GC %q", us, gcID.String())
+
+	if !wasMember {
+		return c.sendToGCMembers(gcID, newGC.Members, "ban", newGC, nil)
+	}
+
+	// Notify user was kicked and send the kick event to the list of old
+	// members (which includes the banned user).
+	c.ntfns.notifyGCUserParted(gcID, uid, reason, true)
+	rmgk := rpc.RMGroupKick{
+		Member:       uid,
+		Reason:       reason,
+		Parted:       false,
+		NewGroupList: newGC,
+	}
+	return c.sendToGCMembers(gcID, oldGC.Members, "ban", rmgk, nil)
+}
+
+// UnbanFromGC removes the user from the list of users banned from the GC. The
+// local client must have the kick permission in the GC.
+func (c *Client) UnbanFromGC(gcID zkidentity.ShortID, uid UserID) error {
+	cb := func(gc *rpc.RMGroupList) error {
+		idx := slices.Index(gc.Banned, uid)
+		if idx < 0 {
+			return fmt.Errorf("user %s is not banned from GC", uid)
+		}
+		gc.Banned = slices.Delete(gc.Banned, idx, idx+1)
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Unbanned %s from GC %q", uid, gcID.String())
+	return c.sendToGCMembers(gcID, newGC.Members, "unban", newGC, nil)
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GC bridges:
+//
+// A client that is a member of two GCs may bridge them. Messages received
+// from other members in the main channel of either GC are relayed by the
+// bridging client to the other GC, prefixed with the nick of the original
+// sender and the name of the source GC. Relayed messages are flagged as
+// bridged (RMGroupMessage.Bridged) and bridged messages are never relayed,
+// so multiple bridges between the same (or overlapping) GCs do not cause
+// loops.
+
+// gcBridgePrefix returns the attribution prefix for a message relayed from
+// the given GC.
+func gcBridgePrefix(nick, gcName string) string {
+	return fmt.Sprintf("[%s@%s] ", nick, gcName)
+}
+
+// BridgeGCs starts bridging messages between the two GCs. The local client
+// must be a member of both GCs.
+func (c *Client) BridgeGCs(gcA, gcB zkidentity.ShortID) error {
+	if gcA == gcB {
+		return errors.New("cannot bridge a GC to itself")
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if _, err := c.db.GetGC(tx, gcA); err != nil {
+			return fmt.Errorf("GC %s: %w", gcA, err)
+		}
+		if _, err := c.db.GetGC(tx, gcB); err != nil {
+			return fmt.Errorf("GC %s: %w", gcB, err)
+		}
+		return c.db.AddGCBridge(tx, gcA, gcB)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Bridging messages between GCs %s and %s", gcA, gcB)
+	return nil
+}
+
+// UnbridgeGCs stops bridging messages between the two GCs.
+func (c *Client) UnbridgeGCs(gcA, gcB zkidentity.ShortID) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemoveGCBridge(tx, gcA, gcB)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Stopped bridging messages between GCs %s and %s", gcA, gcB)
+	return nil
+}
+
+// ListGCBridges lists the GC bridges.
+func (c *Client) ListGCBridges() ([]clientdb.GCBridge, error) {
+	var bridges []clientdb.GCBridge
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		bridges, err = c.db.ListGCBridges(tx)
+		return err
+	})
+	return bridges, err
+}
+
+// relayBridgedGCM relays a message received in a GC to the GCs bridged to it.
+func (c *Client) relayBridgedGCM(ru *RemoteUser, gc *rpc.RMGroupList, gcm rpc.RMGroupMessage) {
+	if gcm.Bridged || gcm.Channel != nil {
+		return
+	}
+	bridges, err := c.ListGCBridges()
+	if err != nil {
+		c.log.Errorf("Unable to list GC bridges: %v", err)
+		return
+	}
+
+	var gcName string
+	for i := range bridges {
+		target, ok := bridges[i].Other(gc.ID)
+		if !ok {
+			continue
+		}
+		if gcName == "" {
+			gcName, err = c.GetGCAlias(gc.ID)
+			if err != nil {
+				gcName = gc.Name
+			}
+		}
+
+		msg := gcBridgePrefix(ru.Nick(), gcName) + gcm.Message
+		go func() {
+			_, err := c.gcMessage(target, nil, msg, gcm.Mode, nil, true, nil)
+			if err != nil {
+				c.log.Warnf("Unable to relay message from GC %s to "+
+					"bridged GC %s: %v", gc.ID, target, err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// GCChannel is a named channel inside a GC.
+type GCChannel = rpc.GCChannel
+
+// gcChannelByID returns the channel of the GC with the given ID or nil if it
+// does not exist.
+func gcChannelByID(gc *rpc.RMGroupList, id zkidentity.ShortID) *GCChannel {
+	for i := range gc.Channels {
+		if gc.Channels[i].ID == id {
+			return &gc.Channels[i]
+		}
+	}
+	return nil
+}
+
+// checkGCChannels checks whether the channels of the GC are valid.
+func checkGCChannels(gc *rpc.RMGroupList) error {
+	if len(gc.Channels) > rpc.MaxGCChannels {
+		return fmt.Errorf("GC has %d channels > max channels %d",
+			len(gc.Channels), rpc.MaxGCChannels)
+	}
+	names := make(map[string]struct{}, len(gc.Channels))
+	ids := make(map[zkidentity.ShortID]struct{}, len(gc.Channels))
+	for _, ch := range gc.Channels {
+		if ch.Name == "" {
+			return fmt.Errorf("GC channel %s has an empty name", ch.ID)
+		}
+		if len(ch.Name) > rpc.MaxGCChannelNameSize {
+			return fmt.Errorf("GC channel name size %d > max name size %d",
+				len(ch.Name), rpc.MaxGCChannelNameSize)
+		}
+		name := strings.ToLower(ch.Name)
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("duplicate GC channel name %q", ch.Name)
+		}
+		if _, ok := ids[ch.ID]; ok {
+			return fmt.Errorf("duplicate GC channel ID %s", ch.ID)
+		}
+		names[name] = struct{}{}
+		ids[ch.ID] = struct{}{}
+	}
+	return nil
+}
+
+// gcChannelChanges returns the channels added to and removed from oldGC to
+// newGC. Renamed channels are returned as both removed and added.
+func gcChannelChanges(oldGC, newGC *rpc.RMGroupList) (added, removed []GCChannel) {
+	for _, ch := range newGC.Channels {
+		if !slices.Contains(oldGC.Channels, ch) {
+			added = append(added, ch)
+		}
+	}
+	for _, ch := range oldGC.Channels {
+		if !slices.Contains(newGC.Channels, ch) {
+			removed = append(removed, ch)
+		}
+	}
+	return added, removed
+}
+
+// ListGCChannels lists the channels of the GC.
+func (c *Client) ListGCChannels(gcID zkidentity.ShortID) ([]GCChannel, error) {
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return nil, err
+	}
+	return gc.Channels, nil
+}
+
+// GCChannelByName returns the channel of the GC with the given name. The name
+// is matched case-insensitively and may be prefixed with a '#'.
+func (c *Client) GCChannelByName(gcID zkidentity.ShortID, name string) (GCChannel, error) {
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return GCChannel{}, err
+	}
+	name = strings.TrimPrefix(name, "#")
+	for _, ch := range gc.Channels {
+		if strings.EqualFold(ch.Name, name) {
+			return ch, nil
+		}
+	}
+	return GCChannel{}, fmt.Errorf("channel %q not found in GC %s", name, gcID)
+}
+
+// CreateGCChannel creates a new channel in the GC. The local client must have
+// permission to change the metadata of the GC.
+func (c *Client) CreateGCChannel(gcID zkidentity.ShortID, name string) (GCChannel, error) {
+	ch := GCChannel{Name: strings.TrimPrefix(strings.TrimSpace(name), "#")}
+	if _, err := rand.Read(ch.ID[:]); err != nil {
+		return ch, err
+	}
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot create channels in GC with version < %d",
+				gcRolesVersion)
+		}
+		gc.Channels = append(gc.Channels, ch)
+		if err := checkGCChannels(gc); err != nil {
+			return err
+		}
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return ch, err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Created channel %q (%s) in GC %q", ch.Name, ch.ID, gcID.String())
+	return ch, c.sendToGCMembers(gcID, newGC.Members, "addChannel", newGC, nil)
+}
+
+// RemoveGCChannel removes the channel from the GC. Messages already logged in
+// the channel are kept. The local client must have permission to change the
+// metadata of the GC.
+func (c *Client) RemoveGCChannel(gcID, channelID zkidentity.ShortID) error {
+	cb := func(gc *rpc.RMGroupList) error {
+		idx := slices.IndexFunc(gc.Channels, func(ch GCChannel) bool {
+			return ch.ID == channelID
+		})
+		if idx < 0 {
+			return fmt.Errorf("channel %s not found in GC", channelID)
+		}
+		gc.Channels = slices.Delete(gc.Channels, idx, idx+1)
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Removed channel %s from GC %q", channelID, gcID.String())
+	return c.sendToGCMembers(gcID, newGC.Members, "delChannel", newGC, nil)
+}
+
+// GCChannelMessage sends a message to the channel of the GC. Returns the ID
+// of the message.
+func (c *Client) GCChannelMessage(gcID, channelID zkidentity.ShortID, msg string,
+	mode rpc.MessageMode, progressChan chan SendProgress) (zkidentity.ShortID, error) {
+
+	return c.gcMessage(gcID, &channelID, msg, mode, nil, false, progressChan)
+}
+
+// ReadGCChannelHistory returns the logged messages of the channel of the GC.
+// The page and pageNum arguments work as in ReadHistoryMessages.
+func (c *Client) ReadGCChannelHistory(gcID, channelID zkidentity.ShortID, page, pageNum int) ([]ChatHistoryEntry, error) {
+	gcName, err := c.gcLogName(gcID)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []ChatHistoryEntry
+	myNick := c.LocalNick()
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		messages, err := c.db.ReadLogGCChannelMsg(tx, gcName, gcID,
+			channelID, page, pageNum)
+		if err != nil {
+			return err
+		}
+		res = make([]ChatHistoryEntry, 0, len(messages))
+		for _, entry := range messages {
+			if entry.From != myNick {
+				uid, err := c.UIDByNick(entry.From)
+				if err == nil {
+					filter, _ := c.shouldFilter(uid, &gcID, nil, nil, entry.Message)
+					if filter {
+						continue
+					}
+				}
+			}
+			res = append(res, ChatHistoryEntry{
+				Message:   entry.Message,
+				From:      entry.From,
+				Internal:  entry.Internal,
+				Timestamp: entry.Timestamp,
+			})
+		}
+		return nil
+	})
+	return res, err
+}
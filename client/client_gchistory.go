@@ -0,0 +1,357 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// GC history sharing:
+//
+// Members that join a GC do not receive the messages sent before they joined.
+// GC members (usually admins) may opt-in to share a bounded backlog of recent
+// GC messages with new members. When a member that has opted-in sees a new
+// member added to the GC and is KX'd with them (which is always the case for
+// the admin that accepted the join), it exports the last messages of the GC
+// as a JSON ChatExport, shares the export as a free file with the new member
+// and sends an RMGroupHistory announcing it.
+//
+// The new member downloads the file through the usual content download
+// process. Only the first history announced for a GC is downloaded, so that
+// new members are not flooded when multiple members share the history.
+
+// maxGCHistoryShareMessages is the max number of messages that may be shared
+// with (and accepted by) new GC members.
+const maxGCHistoryShareMessages = 1000
+
+// maxPendingGCHistories is the max number of GC histories announced before the
+// GC list that are kept until the GC list is received.
+const maxPendingGCHistories = 32
+
+// pendingGCHistory is a GC history announced by a member before the first GC
+// list was received. Histories and GC lists may be sent by different members
+// (or concurrently by the same admin), so they may be received in any order.
+type pendingGCHistory struct {
+	ru       *RemoteUser
+	gh       rpc.RMGroupHistory
+	received time.Time
+}
+
+// pendingGCHistoryKey is the key of pending GC histories. Histories are kept
+// per sender, because whether the sender is a GC member is only known after
+// the GC list is received.
+type pendingGCHistoryKey struct {
+	gc  zkidentity.ShortID
+	uid UserID
+}
+
+// SetGCHistorySharing sets the max number of recent messages of the GC that
+// are shared with new members. A zero maxMsgs disables sharing the history.
+func (c *Client) SetGCHistorySharing(gcID zkidentity.ShortID, maxMsgs int) error {
+	if maxMsgs < 0 || maxMsgs > maxGCHistoryShareMessages {
+		return fmt.Errorf("max number of messages must be between 0 "+
+			"and %d", maxGCHistoryShareMessages)
+	}
+	if _, err := c.GetGC(gcID); err != nil {
+		return err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetGCHistoryShare(tx, gcID, maxMsgs)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Set history sharing of GC %s to %d messages", gcID, maxMsgs)
+	return nil
+}
+
+// GCHistorySharing returns the max number of recent messages of the GC that
+// are shared with new members. Returns zero if sharing is disabled.
+func (c *Client) GCHistorySharing(gcID zkidentity.ShortID) (int, error) {
+	var maxMsgs int
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		maxMsgs, err = c.db.GetGCHistoryShare(tx, gcID)
+		return err
+	})
+	return maxMsgs, err
+}
+
+// exportGCHistory exports the last maxMsgs non-internal messages of the GC.
+func (c *Client) exportGCHistory(gcID zkidentity.ShortID, maxMsgs int) (*ChatExport, error) {
+	gcName, err := c.GetGCAlias(gcID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []clientdb.PMLogEntry
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		entries, err = c.db.ReadLogGCMsg(tx, gcName, gcID, math.MaxInt32, 0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ce := &ChatExport{
+		Version:    chatExportVersion,
+		ExportedAt: time.Now(),
+		LocalID:    c.PublicID(),
+		LocalNick:  c.LocalNick(),
+		GC:         &gcID,
+		GCName:     gcName,
+		Messages:   []ChatExportMessage{},
+	}
+	for i := len(entries) - 1; i >= 0 && len(ce.Messages) < maxMsgs; i-- {
+		e := entries[i]
+		if e.Internal {
+			continue
+		}
+		text, embeds := parseExportMessage(e.Message)
+		ce.Messages = append(ce.Messages, ChatExportMessage{
+			Timestamp: time.Unix(e.Timestamp, 0),
+			From:      e.From,
+			Message:   text,
+			Embeds:    embeds,
+		})
+	}
+	slices.Reverse(ce.Messages)
+	return ce, nil
+}
+
+// shareGCHistory shares the recent history of the GC with the given new
+// member.
+func (c *Client) shareGCHistory(ru *RemoteUser, gcID zkidentity.ShortID, maxMsgs int) error {
+	ce, err := c.exportGCHistory(gcID, maxMsgs)
+	if err != nil {
+		return err
+	}
+	if len(ce.Messages) == 0 {
+		return nil
+	}
+
+	// The file contents are copied when sharing, so the export is written
+	// to a temp file with a unique name that is removed afterwards.
+	f, err := os.CreateTemp("", fmt.Sprintf("gchistory-%s-*.json",
+		gcID.ShortLogID()))
+	if err != nil {
+		return err
+	}
+	fname := f.Name()
+	defer os.Remove(fname)
+	err = json.NewEncoder(f).Encode(ce)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+
+	descr := fmt.Sprintf("History of GC %s", ce.GCName)
+	uid := ru.ID()
+	sf, _, err := c.ShareFile(fname, &uid, 0, descr)
+	if err != nil {
+		return err
+	}
+
+	rm := rpc.RMGroupHistory{
+		ID:     gcID,
+		FileID: sf.FID.String(),
+		Count:  len(ce.Messages),
+	}
+	ru.log.Infof("Sharing %d messages of history of GC %s", rm.Count, gcID)
+	payEvent := fmt.Sprintf("gc.%s.history", gcID.ShortLogID())
+	return ru.sendRMPriority(rm, payEvent, priorityGC)
+}
+
+// maybeShareGCHistory shares the recent history of the GC with the new members
+// the local client is KX'd with, if sharing the history of the GC is enabled.
+func (c *Client) maybeShareGCHistory(gcID zkidentity.ShortID, uids []UserID) {
+	maxMsgs, err := c.GCHistorySharing(gcID)
+	if err != nil {
+		c.log.Warnf("Unable to read history sharing config of GC %s: %v",
+			gcID, err)
+		return
+	}
+	if maxMsgs == 0 {
+		return
+	}
+
+	me := c.PublicID()
+	for _, uid := range uids {
+		if uid == me {
+			continue
+		}
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			continue
+		}
+		go func() {
+			err := c.shareGCHistory(ru, gcID, maxMsgs)
+			if err != nil {
+				ru.log.Errorf("Unable to share history of GC %s: %v",
+					gcID, err)
+			}
+		}()
+	}
+}
+
+// handleGCHistory handles the announcement of a GC history shared by a GC
+// member.
+func (c *Client) handleGCHistory(ru *RemoteUser, gh rpc.RMGroupHistory) error {
+	gc, err := c.GetGC(gh.ID)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		// The GC list may not have been received yet. Keep the
+		// history until it is.
+		key := pendingGCHistoryKey{gc: gh.ID, uid: ru.ID()}
+		c.pendingGCHistoriesMtx.Lock()
+		_, exists := c.pendingGCHistories[key]
+		full := len(c.pendingGCHistories) >= maxPendingGCHistories
+		if !exists && !full {
+			c.pendingGCHistories[key] = pendingGCHistory{
+				ru:       ru,
+				gh:       gh,
+				received: time.Now(),
+			}
+		}
+		c.pendingGCHistoriesMtx.Unlock()
+		if exists || full {
+			return err
+		}
+		ru.log.Debugf("Keeping history of GC %s until the GC list is "+
+			"received", gh.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(gc.Members, ru.ID()) {
+		return fmt.Errorf("received history of GC %s from non-member",
+			gh.ID)
+	}
+	if gh.Count <= 0 || gh.Count > maxGCHistoryShareMessages {
+		return fmt.Errorf("received history of GC %s with invalid "+
+			"number of messages %d", gh.ID, gh.Count)
+	}
+	var fid clientdb.FileID
+	if err := fid.FromString(gh.FileID); err != nil {
+		return err
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, err := c.db.GetGCHistoryDownload(tx, gh.ID)
+		if err == nil {
+			return errGCHistoryReceived
+		}
+		if !errors.Is(err, clientdb.ErrNotFound) {
+			return err
+		}
+		dl := clientdb.GCHistoryDownload{
+			GC:       gh.ID,
+			From:     ru.ID(),
+			FID:      fid,
+			Count:    gh.Count,
+			Received: time.Now(),
+		}
+		return c.db.StoreGCHistoryDownload(tx, dl)
+	})
+	if errors.Is(err, errGCHistoryReceived) {
+		ru.log.Debugf("Ignoring history of GC %s already received", gh.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Downloading %d messages of history of GC %s", gh.Count,
+		gh.ID)
+	return c.GetUserContent(ru.ID(), fid)
+}
+
+// handlePendingGCHistory handles the histories of the GC that were announced
+// before its first GC list was received, in the order they were received.
+// Histories announced by users that are not GC members are dropped.
+func (c *Client) handlePendingGCHistory(gcID zkidentity.ShortID) {
+	var pending []pendingGCHistory
+	c.pendingGCHistoriesMtx.Lock()
+	for key, ph := range c.pendingGCHistories {
+		if key.gc == gcID {
+			pending = append(pending, ph)
+			delete(c.pendingGCHistories, key)
+		}
+	}
+	c.pendingGCHistoriesMtx.Unlock()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].received.Before(pending[j].received)
+	})
+	for _, ph := range pending {
+		if err := c.handleGCHistory(ph.ru, ph.gh); err != nil {
+			ph.ru.log.Warnf("Unable to handle history of GC %s: %v",
+				gcID, err)
+		}
+	}
+}
+
+// errGCHistoryReceived is returned when the history of a GC was already
+// received.
+var errGCHistoryReceived = errors.New("GC history already received")
+
+// maybeHandleGCHistoryDownload is called when a file download completes. If
+// the file is a GC history, it is decoded and the history is notified.
+func (c *Client) maybeHandleGCHistoryDownload(ru *RemoteUser, fid clientdb.FileID,
+	diskPath string) error {
+
+	var dl clientdb.GCHistoryDownload
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		dl, err = c.db.FindGCHistoryDownload(tx, ru.ID(), fid)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(diskPath)
+	if err != nil {
+		return err
+	}
+	var ce ChatExport
+	err = json.NewDecoder(f).Decode(&ce)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("unable to decode history of GC %s: %v", dl.GC, err)
+	}
+	if ce.GC == nil || *ce.GC != dl.GC {
+		return fmt.Errorf("downloaded history is not from GC %s", dl.GC)
+	}
+	if len(ce.Messages) > maxGCHistoryShareMessages {
+		ce.Messages = ce.Messages[len(ce.Messages)-maxGCHistoryShareMessages:]
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		now := time.Now()
+		dl.Completed = &now
+		return c.db.StoreGCHistoryDownload(tx, dl)
+	})
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Received %d messages of history of GC %s",
+		len(ce.Messages), dl.GC)
+	c.ntfns.notifyGCHistoryReceived(ru, dl.GC, ce.Messages)
+	return nil
+}
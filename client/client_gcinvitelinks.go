@@ -0,0 +1,388 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// gcInviteLinkPrefix is the prefix of encoded GC invite links.
+const gcInviteLinkPrefix = "brgcinvite:"
+
+// gcInviteLinkMaxKXInvites is the max number of KX invites embedded in a GC
+// invite link.
+const gcInviteLinkMaxKXInvites = 4
+
+// GCInviteLinkRecord is the local record of a GC invite link created by the
+// local client.
+type GCInviteLinkRecord = clientdb.GCInviteLink
+
+// GCInviteLinkKXRVs are the rendezvous points of a KX invite embedded in a GC
+// invite link.
+type GCInviteLinkKXRVs struct {
+	Initial zkidentity.ShortID `json:"initial"`
+	Reset   zkidentity.ShortID `json:"reset"`
+}
+
+// GCInviteLink is a shareable invite to a GC. Any user may use it to join the
+// GC, until the link expires, is revoked or reaches its max number of uses.
+//
+// Users that are not KX'd with the issuer first KX with them using one of the
+// embedded KX invites. Each of those invites may only be used once, so only
+// a limited number of such users may redeem the link.
+type GCInviteLink struct {
+	ID      zkidentity.ShortID `json:"id"`
+	Issuer  clientintf.UserID  `json:"issuer"`
+	GC      zkidentity.ShortID `json:"gc"`
+	Name    string             `json:"name"`
+	Version uint8              `json:"version"`
+	Expires int64              `json:"expires,omitempty"`
+
+	// Public and KXInvites are used to KX with the issuer.
+	Public    *zkidentity.PublicIdentity `json:"public,omitempty"`
+	KXInvites []GCInviteLinkKXRVs        `json:"kx_invites,omitempty"`
+}
+
+// String returns the encoded link, which may be shared publicly.
+func (l GCInviteLink) String() string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		// Should not happen.
+		panic(err)
+	}
+	return gcInviteLinkPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseGCInviteLink decodes an encoded GC invite link.
+func ParseGCInviteLink(s string) (GCInviteLink, error) {
+	var l GCInviteLink
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, gcInviteLinkPrefix) {
+		return l, errors.New("not a GC invite link")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s[len(gcInviteLinkPrefix):])
+	if err != nil {
+		return l, fmt.Errorf("invalid GC invite link encoding: %v", err)
+	}
+	if err := json.Unmarshal(b, &l); err != nil {
+		return l, fmt.Errorf("invalid GC invite link: %v", err)
+	}
+	if l.ID.IsEmpty() || l.GC.IsEmpty() || l.Issuer.IsEmpty() {
+		return l, errors.New("GC invite link is missing required fields")
+	}
+	return l, nil
+}
+
+// CreateGCInviteLink creates a new invite link to the GC. If expiry is non
+// zero, the link expires after that duration. If maxUses is non zero, at most
+// that number of users may join using the link. The local client must have
+// permission to invite users to the GC.
+//
+// The link embeds KX invites, so that users that are not KX'd with the local
+// client may also redeem it.
+func (c *Client) CreateGCInviteLink(gcID zkidentity.ShortID, expiry time.Duration,
+	maxUses uint32) (GCInviteLink, error) {
+
+	var link GCInviteLink
+	var linkID zkidentity.ShortID
+	if _, err := rand.Read(linkID[:]); err != nil {
+		return link, err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		gc, err := c.db.GetGC(tx, gcID)
+		if err != nil {
+			return err
+		}
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermInvite); err != nil {
+			return fmt.Errorf("not permitted to create invite link: %v", err)
+		}
+
+		rec := clientdb.GCInviteLink{
+			ID:      linkID,
+			GC:      gcID,
+			Created: time.Now(),
+			MaxUses: maxUses,
+		}
+		if expiry > 0 {
+			rec.Expires = rec.Created.Add(expiry)
+		}
+		if err := c.db.StoreGCInviteLink(tx, rec); err != nil {
+			return err
+		}
+
+		link = GCInviteLink{
+			ID:      rec.ID,
+			Issuer:  c.PublicID(),
+			GC:      gcID,
+			Name:    gc.Name,
+			Version: gc.Version,
+		}
+		if !rec.Expires.IsZero() {
+			link.Expires = rec.Expires.Unix()
+		}
+		return nil
+	})
+	if err != nil {
+		return link, err
+	}
+
+	nbInvites := gcInviteLinkMaxKXInvites
+	if maxUses > 0 && maxUses < uint32(nbInvites) {
+		nbInvites = int(maxUses)
+	}
+	for i := 0; i < nbInvites; i++ {
+		pii, err := c.kxl.createInvite(nil, nil, nil, false, nil)
+		if err != nil {
+			return link, fmt.Errorf("unable to create kx invite: %v", err)
+		}
+		if link.Public == nil {
+			// The avatar is not needed for KX and would bloat
+			// the link.
+			public := pii.Public
+			public.Avatar = nil
+			link.Public = &public
+		}
+		link.KXInvites = append(link.KXInvites, GCInviteLinkKXRVs{
+			Initial: pii.InitialRendezvous,
+			Reset:   pii.ResetRendezvous,
+		})
+	}
+
+	c.log.Infof("Created invite link %s to GC %s", link.ID, gcID)
+	return link, nil
+}
+
+// RevokeGCInviteLink revokes the invite link with the given ID, so that it
+// can no longer be used to join the GC.
+func (c *Client) RevokeGCInviteLink(linkID zkidentity.ShortID) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		link, err := c.db.GetGCInviteLink(tx, linkID)
+		if err != nil {
+			return err
+		}
+		if link.Revoked {
+			return fmt.Errorf("invite link already revoked")
+		}
+		link.Revoked = true
+		return c.db.StoreGCInviteLink(tx, link)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Revoked GC invite link %s", linkID)
+	return nil
+}
+
+// ListGCInviteLinks lists the invite links created by the local client. If gcID
+// is specified, only links to that GC are returned.
+func (c *Client) ListGCInviteLinks(gcID *zkidentity.ShortID) ([]GCInviteLinkRecord, error) {
+	var res []GCInviteLinkRecord
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListGCInviteLinks(tx, gcID)
+		return err
+	})
+	return res, err
+}
+
+// RedeemGCInviteLink requests to join the GC of the given invite link. If the
+// local client is not KX'd with the issuer of the link, it first KXs with them
+// using one of the KX invites embedded in the link, and the request is sent
+// once the KX completes. The GC is joined once the issuer accepts the request
+// and sends the updated GC list.
+func (c *Client) RedeemGCInviteLink(link GCInviteLink) error {
+	if link.Issuer == c.PublicID() {
+		return fmt.Errorf("cannot redeem own invite link")
+	}
+	if link.Expires > 0 && time.Now().Unix() > link.Expires {
+		return fmt.Errorf("invite link expired")
+	}
+	if link.Version < minSupportedGCVersion || link.Version > maxSupportedGCVersion {
+		return fmt.Errorf("invite link to GC with unsupported version %d",
+			link.Version)
+	}
+
+	ru, err := c.rul.byID(link.Issuer)
+	if err != nil && !errors.Is(err, userNotFoundError{}) {
+		return err
+	}
+	var kxInvite *rpc.OOBPublicIdentityInvite
+	if ru == nil {
+		kxInvite, err = link.kxInvite()
+		if err != nil {
+			return err
+		}
+	}
+
+	name := strings.TrimSpace(link.Name)
+	if name == "" {
+		name = link.GC.ShortLogID()
+	}
+	invite := rpc.RMGroupInvite{
+		ID:      link.GC,
+		Name:    name,
+		Version: link.Version,
+		Expires: link.Expires,
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, err := c.db.GetGC(tx, link.GC)
+		if !errors.Is(err, clientdb.ErrNotFound) {
+			if err == nil {
+				err = fmt.Errorf("already a member of gc %s", link.GC)
+			}
+			return err
+		}
+
+		// Track the request as an accepted invite, so that the GC
+		// list sent by the issuer is accepted.
+		iid, err := c.db.AddGCInvite(tx, link.Issuer, invite)
+		if err != nil {
+			return err
+		}
+		if err := c.db.MarkGCInviteAccepted(tx, iid); err != nil {
+			return err
+		}
+		if kxInvite == nil {
+			return nil
+		}
+
+		// Send the join request once KX'd with the issuer.
+		action := clientdb.PostKXAction{
+			Type:      clientdb.PKXActionRedeemGCLink,
+			DateAdded: time.Now(),
+			Data:      link.String(),
+		}
+		return c.db.AddUniquePostKXAction(tx, link.Issuer, action)
+	})
+	if err != nil {
+		return err
+	}
+
+	if kxInvite != nil {
+		c.log.Infof("Starting KX with %s to redeem invite link to gc %q (%s)",
+			link.Issuer, name, link.GC)
+		return c.kxl.acceptInvite(*kxInvite, false, false)
+	}
+	return c.sendGCJoinLink(ru, link)
+}
+
+// kxInvite returns one of the KX invites embedded in the link, chosen at
+// random.
+func (l *GCInviteLink) kxInvite() (*rpc.OOBPublicIdentityInvite, error) {
+	if l.Public == nil || len(l.KXInvites) == 0 {
+		return nil, fmt.Errorf("not KX'd with the issuer of the invite " +
+			"link and the link has no KX invites")
+	}
+	if l.Public.Identity != l.Issuer {
+		return nil, fmt.Errorf("invite link public identity is not " +
+			"the issuer's")
+	}
+	if !l.Public.Verify() || !l.Public.VerifyIdentity() {
+		return nil, fmt.Errorf("invite link has an invalid public identity")
+	}
+
+	var b [1]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	rvs := l.KXInvites[int(b[0])%len(l.KXInvites)]
+	return &rpc.OOBPublicIdentityInvite{
+		Public:            *l.Public,
+		InitialRendezvous: rvs.Initial,
+		ResetRendezvous:   rvs.Reset,
+	}, nil
+}
+
+// sendGCJoinLink sends the request to join the GC of the invite link to its
+// issuer.
+func (c *Client) sendGCJoinLink(ru *RemoteUser, link GCInviteLink) error {
+	name := strings.TrimSpace(link.Name)
+	if name == "" {
+		name = link.GC.ShortLogID()
+	}
+	linkID := link.ID
+	join := rpc.RMGroupJoin{
+		ID:     link.GC,
+		LinkID: &linkID,
+	}
+	c.log.Infof("Redeeming invite link to gc %q (%s) from %s", name, link.GC, ru)
+	payEvent := fmt.Sprintf("gc.%s.redeemlink", link.GC.ShortLogID())
+	return ru.sendRM(join, payEvent)
+}
+
+// handleGCJoinLink handles a request from a remote user to join a GC using an
+// invite link created by the local client.
+func (c *Client) handleGCJoinLink(ru *RemoteUser, join rpc.RMGroupJoin) error {
+	var gc rpc.RMGroupList
+	var needsApproval bool
+	uid := ru.ID()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		link, err := c.db.GetGCInviteLink(tx, *join.LinkID)
+		if err != nil {
+			return err
+		}
+		if link.GC != join.ID {
+			return fmt.Errorf("invite link %s is not for gc %s",
+				link.ID, join.ID)
+		}
+		if err := link.Usable(time.Now()); err != nil {
+			return err
+		}
+
+		gc, err = c.db.GetGC(tx, join.ID)
+		if err != nil {
+			return err
+		}
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermInvite); err != nil {
+			return fmt.Errorf("local user does not have permission "+
+				"to add gc member: %v", err)
+		}
+
+		if gc.JoinApproval {
+			// Only check whether the user could be added now. The
+			// request is queued for approval.
+			needsApproval = true
+			if slices.Contains(gc.Banned, uid) {
+				return fmt.Errorf("user %s banned from gc %q "+
+					"attempted to join", uid, gc.ID.String())
+			}
+			if slices.Contains(gc.Members, uid) {
+				return fmt.Errorf("user %s already part of gc %q",
+					uid, gc.ID.String())
+			}
+			return checkGCCapacity(&gc, 1)
+		}
+
+		return c.addGCMemberFromLink(tx, &gc, uid, link.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to accept join request from invite "+
+			"link: %v", err)
+	}
+
+	if needsApproval {
+		return c.queueGCJoinRequest(ru, gc, *join.LinkID)
+	}
+
+	c.log.Infof("User %s joined gc %s (%q) using invite link %s", ru,
+		gc.ID, gc.Name, join.LinkID)
+
+	err = c.sendToGCMembers(gc.ID, gc.Members, "sendlist", gc, nil)
+	if err != nil {
+		return err
+	}
+
+	c.ntfns.notifyGCInviteAccepted(ru, gc)
+	c.maybeShareGCHistory(gc.ID, []UserID{ru.ID()})
+	return nil
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// maxGCJoinRequests is the max number of pending join requests kept for each
+// GC. Requests received after this limit is reached are rejected.
+const maxGCJoinRequests = 100
+
+// GCJoinRequest is a pending request to join a GC through an invite link
+// created by the local client.
+type GCJoinRequest = clientdb.GCJoinRequest
+
+// checkGCCapacity returns an error if adding the given number of members to
+// the GC would make it exceed its max number of members.
+func checkGCCapacity(gc *rpc.RMGroupList, adding int) error {
+	if gc.MaxMembers == 0 {
+		return nil
+	}
+	if len(gc.Members)+adding > int(gc.MaxMembers) {
+		return GCFullError{MaxMembers: gc.MaxMembers}
+	}
+	return nil
+}
+
+// addGCMemberFromLink adds the user as a member of the GC, after they used the
+// specified invite link to join it. The updated GC is saved in the db.
+func (c *Client) addGCMemberFromLink(tx clientdb.ReadWriteTx, gc *rpc.RMGroupList,
+	uid UserID, linkID zkidentity.ShortID) error {
+
+	if slices.Contains(gc.Banned, uid) {
+		return fmt.Errorf("user %s banned from gc %q attempted "+
+			"to join", uid, gc.ID.String())
+	}
+	if slices.Contains(gc.Members, uid) {
+		return fmt.Errorf("user %s already part of gc %q",
+			uid, gc.ID.String())
+	}
+	if err := checkGCCapacity(gc, 1); err != nil {
+		return err
+	}
+
+	gc.Members = append(gc.Members, uid)
+	gc.Generation += 1
+	gc.Timestamp = time.Now().Unix()
+	if err := c.db.SaveGC(tx, *gc); err != nil {
+		return err
+	}
+
+	link, err := c.db.GetGCInviteLink(tx, linkID)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	link.UsedBy = append(link.UsedBy, uid)
+	return c.db.StoreGCInviteLink(tx, link)
+}
+
+// SetGCJoinPolicy sets the max number of members of the GC and whether
+// requests to join the GC through invite links must be approved. A
+// maxMembers of zero removes the limit on the number of members. The local
+// client must have permission to invite users to the GC.
+func (c *Client) SetGCJoinPolicy(gcID zkidentity.ShortID, maxMembers uint32, requireApproval bool) error {
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot set join policy of GC with version < %d",
+				gcRolesVersion)
+		}
+		if maxMembers > 0 && int(maxMembers) < len(gc.Members) {
+			return fmt.Errorf("GC already has %d members > max members %d",
+				len(gc.Members), maxMembers)
+		}
+		if gc.MaxMembers == maxMembers && gc.JoinApproval == requireApproval {
+			return fmt.Errorf("GC join policy is unchanged")
+		}
+		gc.MaxMembers = maxMembers
+		gc.JoinApproval = requireApproval
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Set join policy of GC %q to max members %d, approval %v",
+		gcID.String(), maxMembers, requireApproval)
+	return c.sendToGCMembers(gcID, newGC.Members, "setJoinPolicy", newGC, nil)
+}
+
+// queueGCJoinRequest queues a request from the remote user to join the GC
+// through an invite link, to be approved or denied by the local client.
+func (c *Client) queueGCJoinRequest(ru *RemoteUser, gc rpc.RMGroupList, linkID zkidentity.ShortID) error {
+	req := GCJoinRequest{
+		GC:       gc.ID,
+		UID:      ru.ID(),
+		LinkID:   linkID,
+		Received: time.Now(),
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		reqs, err := c.db.ListGCJoinRequests(tx, &gc.ID)
+		if err != nil {
+			return err
+		}
+		if len(reqs) >= maxGCJoinRequests {
+			return fmt.Errorf("too many pending join requests")
+		}
+		return c.db.StoreGCJoinRequest(tx, req)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to queue join request: %v", err)
+	}
+
+	c.log.Infof("Queued request from %s to join gc %s (%q) using invite "+
+		"link %s", ru, gc.ID, gc.Name, linkID)
+	c.ntfns.notifyGCJoinRequest(ru, gc, req)
+	return nil
+}
+
+// ListGCJoinRequests lists the pending requests to join GCs through invite
+// links created by the local client. If gcID is specified, only requests to
+// join that GC are returned.
+func (c *Client) ListGCJoinRequests(gcID *zkidentity.ShortID) ([]GCJoinRequest, error) {
+	var res []GCJoinRequest
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListGCJoinRequests(tx, gcID)
+		return err
+	})
+	return res, err
+}
+
+// ApproveGCJoinRequest approves the pending request from the user to join the
+// GC. The user is added as a member and the updated GC list is sent to all
+// members.
+func (c *Client) ApproveGCJoinRequest(gcID zkidentity.ShortID, uid UserID) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+
+	var gc rpc.RMGroupList
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		req, err := c.db.GetGCJoinRequest(tx, gcID, uid)
+		if err != nil {
+			return err
+		}
+		gc, err = c.db.GetGC(tx, gcID)
+		if err != nil {
+			return err
+		}
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermInvite); err != nil {
+			return fmt.Errorf("local user does not have permission "+
+				"to add gc member: %v", err)
+		}
+		if err := c.addGCMemberFromLink(tx, &gc, uid, req.LinkID); err != nil {
+			return err
+		}
+		return c.db.DelGCJoinRequest(tx, gcID, uid)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.Infof("Approved request from %s to join gc %s (%q)", ru, gc.ID,
+		gc.Name)
+
+	err = c.sendToGCMembers(gc.ID, gc.Members, "sendlist", gc, nil)
+	if err != nil {
+		return err
+	}
+
+	c.ntfns.notifyGCInviteAccepted(ru, gc)
+	c.maybeShareGCHistory(gc.ID, []UserID{ru.ID()})
+	return nil
+}
+
+// DenyGCJoinRequest denies the pending request from the user to join the GC.
+// The user is not notified of the denial.
+func (c *Client) DenyGCJoinRequest(gcID zkidentity.ShortID, uid UserID) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.DelGCJoinRequest(tx, gcID, uid)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Denied request from %s to join gc %s", uid, gcID)
+	return nil
+}
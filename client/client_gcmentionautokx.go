@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// Mentions by unKXd GC members:
+//
+// Members that have not KX'd with each other cannot exchange GC messages, so
+// a member mentioned by an unKXd member never receives the message. GC admins
+// receive the message and detect the mention: the sender fills the list of
+// mentioned members (RMGroupMessage.Mentions) only with members it knows, so
+// members that are mentioned by nick (from the admin's point of view) but
+// missing from that list are probably unKXd with the sender.
+//
+// The admin sends an RMGroupUnkxdMention to those members. If the member
+// enabled auto KX on mentions for the GC, it asks the admin to mediate a KX
+// with the sender, so that the conversation can continue in PM.
+
+// SetGCMentionAutoKX sets whether mentions by unKXd members of the GC trigger
+// an automatic transitive KX with them (mediated by the GC admin that
+// reported the mention).
+func (c *Client) SetGCMentionAutoKX(gcID zkidentity.ShortID, enabled bool) error {
+	if _, err := c.GetGC(gcID); err != nil {
+		return err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetGCMentionAutoKX(tx, gcID, enabled)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Set auto KX on mentions of GC %s to %v", gcID, enabled)
+	return nil
+}
+
+// GCMentionAutoKX returns whether mentions by unKXd members of the GC trigger
+// an automatic transitive KX with them.
+func (c *Client) GCMentionAutoKX(gcID zkidentity.ShortID) (bool, error) {
+	var enabled bool
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		enabled, err = c.db.GetGCMentionAutoKX(tx, gcID)
+		return err
+	})
+	return enabled, err
+}
+
+// notifyUnkxdMentions is called by GC admins when a GC message is received
+// from a member. It alerts the members mentioned in the message that are
+// likely not KX'd with the sender.
+func (c *Client) notifyUnkxdMentions(ru *RemoteUser, gc *rpc.RMGroupList, gcm rpc.RMGroupMessage) {
+	nicks := parseMentions(gcm.Message)
+	if len(nicks) == 0 {
+		return
+	}
+
+	me := c.PublicID()
+	for _, uid := range gc.Members {
+		if uid == me || uid == ru.ID() || slices.Contains(gcm.Mentions, uid) {
+			continue
+		}
+		target, err := c.rul.byID(uid)
+		if err != nil {
+			continue
+		}
+		nick := target.Nick()
+		if slices.IndexFunc(nicks, func(s string) bool { return strings.EqualFold(s, nick) }) < 0 {
+			continue
+		}
+
+		target.log.Debugf("Alerting about mention in GC %s by possibly "+
+			"unKXd member %s", gc.ID, ru.ID())
+		rm := rpc.RMGroupUnkxdMention{
+			ID:      gc.ID,
+			Sender:  ru.ID(),
+			Message: gcm.Message,
+		}
+		payEvent := fmt.Sprintf("gc.%s.unkxdmention", gc.ID.ShortLogID())
+		if err := target.sendRMPriority(rm, payEvent, priorityGC); err != nil {
+			target.log.Warnf("Unable to send unkxd mention alert: %v", err)
+		}
+	}
+}
+
+// handleGCUnkxdMention handles an alert from a GC admin that the local client
+// was mentioned by an unKXd member of the GC.
+func (c *Client) handleGCUnkxdMention(ru *RemoteUser, m rpc.RMGroupUnkxdMention) error {
+	gc, err := c.GetGC(m.ID)
+	if err != nil {
+		return err
+	}
+	if GCMemberRole(&gc, ru.ID()) < GCRoleAdmin {
+		return fmt.Errorf("received unkxd mention alert in GC %s from "+
+			"non-admin", m.ID)
+	}
+	if !slices.Contains(gc.Members, m.Sender) {
+		return fmt.Errorf("received unkxd mention alert in GC %s from "+
+			"non-member %s", m.ID, m.Sender)
+	}
+	if _, err := c.rul.byID(m.Sender); err == nil {
+		// Already KX'd with sender, so the message was received
+		// directly.
+		return nil
+	}
+
+	enabled, err := c.GCMentionAutoKX(m.ID)
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Mentioned in GC %s by unKXd member %s (auto KX: %v)",
+		m.ID, m.Sender, enabled)
+	c.ntfns.notifyGCUnkxdMention(ru, m, enabled)
+	if !enabled {
+		return nil
+	}
+
+	err = c.maybeRequestMediateID(ru.ID(), m.Sender)
+	if err != nil {
+		ru.log.Warnf("Unable to request KX with %s after mention in GC "+
+			"%s: %v", m.Sender, m.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCMetadataField tracks GC metadata fields which may be updated.
+type GCMetadataField string
+
+const (
+	// GCMetadataTopic is the metadata field that corresponds to the topic
+	// line of the GC.
+	GCMetadataTopic GCMetadataField = "topic"
+
+	// GCMetadataDescription is the metadata field that corresponds to the
+	// markdown description of the GC.
+	GCMetadataDescription GCMetadataField = "description"
+
+	// GCMetadataAvatar is the metadata field that corresponds to the
+	// avatar of the GC.
+	GCMetadataAvatar GCMetadataField = "avatar"
+)
+
+// GCMetadataUpdate lists the GC metadata fields to update.
+type GCMetadataUpdate struct {
+	// Topic is the new topic. If nil, the topic is not updated. If set to
+	// an empty string, the topic is cleared.
+	Topic *string
+
+	// Description is the new description. If nil, the description is not
+	// updated. If set to an empty string, the description is cleared.
+	Description *string
+
+	// Avatar is the new avatar. If nil, the avatar is not updated. If set
+	// to an empty slice, the avatar is cleared.
+	Avatar []byte
+}
+
+// checkGCMetadata checks whether the metadata of the GC is within the allowed
+// limits.
+func checkGCMetadata(gc *rpc.RMGroupList) error {
+	if len(gc.Topic) > rpc.MaxGCTopicSize {
+		return fmt.Errorf("GC topic size %d > max topic size %d",
+			len(gc.Topic), rpc.MaxGCTopicSize)
+	}
+	if len(gc.Description) > rpc.MaxGCDescriptionSize {
+		return fmt.Errorf("GC description size %d > max description size %d",
+			len(gc.Description), rpc.MaxGCDescriptionSize)
+	}
+	if len(gc.Avatar) > rpc.MaxGCAvatarSize {
+		return fmt.Errorf("GC avatar byte size %d > max avatar size %d",
+			len(gc.Avatar), rpc.MaxGCAvatarSize)
+	}
+	return checkGCChannels(gc)
+}
+
+// gcMetadataChanges returns the metadata fields that changed from oldGC to
+// newGC.
+func gcMetadataChanges(oldGC, newGC *rpc.RMGroupList) []GCMetadataField {
+	var fields []GCMetadataField
+	if oldGC.Topic != newGC.Topic {
+		fields = append(fields, GCMetadataTopic)
+	}
+	if oldGC.Description != newGC.Description {
+		fields = append(fields, GCMetadataDescription)
+	}
+	if !bytes.Equal(oldGC.Avatar, newGC.Avatar) {
+		fields = append(fields, GCMetadataAvatar)
+	}
+	return fields
+}
+
+// UpdateGCMetadata changes the specified metadata fields of the GC and sends
+// the updated GC list to its members. The local client must have permission
+// to change the metadata of the GC.
+func (c *Client) UpdateGCMetadata(gcID zkidentity.ShortID, update GCMetadataUpdate) error {
+	if update.Topic == nil && update.Description == nil && update.Avatar == nil {
+		return fmt.Errorf("no GC metadata fields to update")
+	}
+
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot update metadata of GC with version < %d",
+				gcRolesVersion)
+		}
+		old := *gc
+		if update.Topic != nil {
+			gc.Topic = *update.Topic
+		}
+		if update.Description != nil {
+			gc.Description = *update.Description
+		}
+		if len(update.Avatar) > 0 {
+			gc.Avatar = update.Avatar
+		} else if update.Avatar != nil {
+			gc.Avatar = nil
+		}
+		if len(gcMetadataChanges(&old, gc)) == 0 {
+			return fmt.Errorf("GC metadata is unchanged")
+		}
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	fields := gcMetadataChanges(&oldGC, &newGC)
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Updated metadata %v of GC %q", fields, gcID.String())
+	return c.sendToGCMembers(gcID, newGC.Members, "setMetadata", newGC, nil)
+}
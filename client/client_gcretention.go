@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// gcRetentionInterval is the interval between automatic pruning of GC messages
+// according to their retention policies.
+const gcRetentionInterval = time.Hour
+
+// GCRetentionPolicy is the local policy for how many messages of a GC are kept
+// in the client db.
+type GCRetentionPolicy = clientdb.GCRetentionPolicy
+
+// gcLogName returns the name used for the message log of the GC.
+func (c *Client) gcLogName(gcID zkidentity.ShortID) (string, error) {
+	if alias, err := c.GetGCAlias(gcID); err == nil {
+		return alias, nil
+	}
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return "", err
+	}
+	return gc.Name, nil
+}
+
+// pruneGCMsgs prunes the messages of the GC according to the policy.
+func (c *Client) pruneGCMsgs(gcID zkidentity.ShortID, policy GCRetentionPolicy) (int, error) {
+	gcName, err := c.gcLogName(gcID)
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		pruned, err = c.db.PruneGCMsgs(tx, gcName, gcID, policy, time.Now())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pruned > 0 {
+		c.log.Debugf("Pruned %d messages from GC %s", pruned, gcID)
+	}
+	return pruned, nil
+}
+
+// SetGCRetentionPolicy sets the local retention policy of the GC and prunes
+// any messages that are not retained by the new policy. An empty policy
+// disables pruning of the GC messages. Returns the number of pruned messages.
+func (c *Client) SetGCRetentionPolicy(gcID zkidentity.ShortID, policy GCRetentionPolicy) (int, error) {
+	if _, err := c.GetGC(gcID); err != nil {
+		return 0, err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetGCRetentionPolicy(tx, gcID, policy)
+	})
+	if err != nil {
+		return 0, err
+	}
+	c.log.Infof("Set retention policy of GC %s to %d days and %d messages",
+		gcID, policy.MaxAgeDays, policy.MaxMessages)
+	return c.pruneGCMsgs(gcID, policy)
+}
+
+// GetGCRetentionPolicy returns the local retention policy of the GC. If the
+// GC does not have a retention policy, an empty policy is returned.
+func (c *Client) GetGCRetentionPolicy(gcID zkidentity.ShortID) (GCRetentionPolicy, error) {
+	var policy GCRetentionPolicy
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		policy, err = c.db.GetGCRetentionPolicy(tx, gcID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			err = nil
+		}
+		return err
+	})
+	return policy, err
+}
+
+// pruneAllGCMsgs prunes the messages of all GCs that have a retention policy.
+func (c *Client) pruneAllGCMsgs() error {
+	var policies map[zkidentity.ShortID]GCRetentionPolicy
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		policies, err = c.db.ListGCRetentionPolicies(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for gcID, policy := range policies {
+		_, err := c.pruneGCMsgs(gcID, policy)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			c.log.Warnf("Unable to prune messages of GC %s: %v",
+				gcID, err)
+		}
+	}
+	return nil
+}
+
+// runGCRetention periodically prunes the messages of GCs according to their
+// retention policies.
+func (c *Client) runGCRetention(ctx context.Context) error {
+	ticker := time.NewTicker(gcRetentionInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.pruneAllGCMsgs(); err != nil {
+			c.log.Errorf("Unable to prune GC messages: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
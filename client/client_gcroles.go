@@ -0,0 +1,335 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// gcRolesVersion is the first GC version that supports moderation
+	// roles and per-role permissions.
+	gcRolesVersion = 2
+
+	// defaultGCModeratorPerms are the permissions of moderators when a GC
+	// is created with or upgraded to a version that supports roles.
+	defaultGCModeratorPerms = rpc.GCPermKick | rpc.GCPermPin
+
+	// maxGCPinnedMsgs is the max number of pinned messages in a GC.
+	maxGCPinnedMsgs = 10
+)
+
+// GCRole is the moderation role of a member in a GC.
+type GCRole int
+
+const (
+	// GCRoleMember is the role of regular GC members.
+	GCRoleMember GCRole = iota
+
+	// GCRoleModerator is the role of GC moderators. Moderators may only
+	// perform the actions allowed by the GC's moderator permissions.
+	GCRoleModerator
+
+	// GCRoleAdmin is the role of GC admins. Admins may only perform the
+	// actions allowed by the GC's admin permissions, but may also change
+	// the list and permissions of moderators.
+	GCRoleAdmin
+
+	// GCRoleOwner is the role of the GC owner (Members[0]). The owner has
+	// all permissions.
+	GCRoleOwner
+)
+
+func (r GCRole) String() string {
+	switch r {
+	case GCRoleMember:
+		return "member"
+	case GCRoleModerator:
+		return "moderator"
+	case GCRoleAdmin:
+		return "admin"
+	case GCRoleOwner:
+		return "owner"
+	default:
+		return "unknown"
+	}
+}
+
+// GCMemberRole returns the role of the user in the GC.
+func GCMemberRole(gc *rpc.RMGroupList, uid clientintf.UserID) GCRole {
+	switch {
+	case len(gc.Members) > 0 && gc.Members[0] == uid:
+		return GCRoleOwner
+	case gc.Version >= 1 && slices.Contains(gc.ExtraAdmins, uid):
+		return GCRoleAdmin
+	case gc.Version >= gcRolesVersion && slices.Contains(gc.Moderators, uid):
+		return GCRoleModerator
+	default:
+		return GCRoleMember
+	}
+}
+
+// GCRolePerms returns the permissions of the given role in the GC. GCs with a
+// version that does not support roles give all permissions to the owner and
+// admins.
+func GCRolePerms(gc *rpc.RMGroupList, role GCRole) rpc.GCPermission {
+	switch {
+	case role == GCRoleOwner:
+		return rpc.GCPermAll
+	case gc.Version < gcRolesVersion && role == GCRoleAdmin:
+		return rpc.GCPermAll
+	case gc.Version < gcRolesVersion:
+		return 0
+	case role == GCRoleAdmin:
+		return gc.AdminPerms
+	case role == GCRoleModerator:
+		return gc.ModeratorPerms
+	default:
+		return 0
+	}
+}
+
+// uidHasGCPermission returns an error if the user does not have the given
+// permission in the GC.
+func (c *Client) uidHasGCPermission(gc *rpc.RMGroupList, uid clientintf.UserID,
+	perm rpc.GCPermission) error {
+
+	if gc.Version < gcRolesVersion {
+		return c.uidHasGCPerm(*gc, uid)
+	}
+
+	role := GCMemberRole(gc, uid)
+	if !GCRolePerms(gc, role).Has(perm) {
+		return fmt.Errorf("user %s with role %s does not have %s "+
+			"permission in GC %s", uid, role, perm, gc.ID)
+	}
+	return nil
+}
+
+// checkGCUpdatePerms returns an error if the user does not have the
+// permissions needed to change the GC from oldGC to newGC.
+func (c *Client) checkGCUpdatePerms(oldGC, newGC *rpc.RMGroupList, updaterID clientintf.UserID) error {
+	if oldGC.Version < gcRolesVersion {
+		return c.uidHasGCPerm(*oldGC, updaterID)
+	}
+
+	memberChanges := sliceDiff(oldGC.Members, newGC.Members)
+	if len(memberChanges.added) > 0 {
+		if err := checkGCCapacity(newGC, 0); err != nil {
+			return err
+		}
+	}
+
+	role := GCMemberRole(oldGC, updaterID)
+	perms := GCRolePerms(oldGC, role)
+	switch role {
+	case GCRoleOwner:
+		return nil
+	case GCRoleMember:
+		return fmt.Errorf("user %s is not a moderator of GC %s",
+			updaterID, oldGC.ID)
+	}
+
+	// Changes to the GC version, admins and their permissions may only be
+	// done by the owner.
+	if oldGC.Version != newGC.Version ||
+		!slices.Equal(oldGC.ExtraAdmins, newGC.ExtraAdmins) ||
+		oldGC.AdminPerms != newGC.AdminPerms {
+		return fmt.Errorf("only the owner of GC %s may change its "+
+			"version or admins", oldGC.ID)
+	}
+
+	// Changes to the moderators may only be done by admins.
+	if role < GCRoleAdmin && (!slices.Equal(oldGC.Moderators, newGC.Moderators) ||
+		oldGC.ModeratorPerms != newGC.ModeratorPerms) {
+		return fmt.Errorf("only admins of GC %s may change its "+
+			"moderators", oldGC.ID)
+	}
+
+	if len(memberChanges.added) > 0 && !perms.Has(rpc.GCPermInvite) {
+		return fmt.Errorf("user %s does not have permission to add "+
+			"members to GC %s", updaterID, oldGC.ID)
+	}
+	joinPolicyChanged := oldGC.MaxMembers != newGC.MaxMembers ||
+		oldGC.JoinApproval != newGC.JoinApproval
+	if joinPolicyChanged && !perms.Has(rpc.GCPermInvite) {
+		return fmt.Errorf("user %s does not have permission to change "+
+			"the join policy of GC %s", updaterID, oldGC.ID)
+	}
+	if len(memberChanges.removed) > 0 && !perms.Has(rpc.GCPermKick) {
+		return fmt.Errorf("user %s does not have permission to remove "+
+			"members from GC %s", updaterID, oldGC.ID)
+	}
+	for _, uid := range memberChanges.removed {
+		if GCMemberRole(oldGC, uid) >= role {
+			return fmt.Errorf("user %s may not remove %s with "+
+				"role %s from GC %s", updaterID, uid,
+				GCMemberRole(oldGC, uid), oldGC.ID)
+		}
+	}
+
+	if !slices.Equal(oldGC.Banned, newGC.Banned) {
+		if !perms.Has(rpc.GCPermKick) {
+			return fmt.Errorf("user %s does not have permission to "+
+				"ban users from GC %s", updaterID, oldGC.ID)
+		}
+		for _, uid := range sliceDiff(oldGC.Banned, newGC.Banned).added {
+			if GCMemberRole(oldGC, uid) >= role {
+				return fmt.Errorf("user %s may not ban %s with "+
+					"role %s from GC %s", updaterID, uid,
+					GCMemberRole(oldGC, uid), oldGC.ID)
+			}
+		}
+	}
+
+	if !slices.Equal(oldGC.Pinned, newGC.Pinned) && !perms.Has(rpc.GCPermPin) {
+		return fmt.Errorf("user %s does not have permission to pin "+
+			"messages in GC %s", updaterID, oldGC.ID)
+	}
+	metadataChanged := oldGC.Name != newGC.Name ||
+		oldGC.SlowModeInterval != newGC.SlowModeInterval ||
+		len(gcMetadataChanges(oldGC, newGC)) > 0 ||
+		!slices.Equal(oldGC.Channels, newGC.Channels)
+	if metadataChanged && !perms.Has(rpc.GCPermMetadata) {
+		return fmt.Errorf("user %s does not have permission to change "+
+			"the metadata of GC %s", updaterID, oldGC.ID)
+	}
+
+	return nil
+}
+
+// ModifyGCModerators modifies the moderators of the GC. The local client must
+// be the owner or an admin of the GC.
+func (c *Client) ModifyGCModerators(gcid zkidentity.ShortID, moderators []zkidentity.ShortID, reason string) error {
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot modify moderators of GC with version < %d",
+				gcRolesVersion)
+		}
+		for _, uid := range moderators {
+			if !slices.Contains(gc.Members, uid) {
+				return fmt.Errorf("user %s is not a member of the GC", uid)
+			}
+			if role := GCMemberRole(gc, uid); role > GCRoleModerator {
+				return fmt.Errorf("user %s is already the GC %s", uid, role)
+			}
+		}
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		gc.Moderators = moderators
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, reason)
+
+	c.log.Infof("Changed list of GC moderators for GC %s to %v",
+		gcid, moderators)
+
+	rm := rpc.RMGroupUpdateAdmins{
+		Reason:       reason,
+		NewGroupList: newGC,
+	}
+	return c.sendToGCMembers(gcid, newGC.Members, "modifyModerators", rm, nil)
+}
+
+// SetGCRolePerms sets the permissions of the admin or moderator role of the
+// GC. Only the owner may change the permissions of admins, while admins may
+// change the permissions of moderators.
+func (c *Client) SetGCRolePerms(gcid zkidentity.ShortID, role GCRole, perms rpc.GCPermission) error {
+	if perms & ^rpc.GCPermAll != 0 {
+		return fmt.Errorf("unknown GC permissions %d", perms)
+	}
+
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot modify permissions of GC with version < %d",
+				gcRolesVersion)
+		}
+		switch role {
+		case GCRoleAdmin:
+			gc.AdminPerms = perms
+		case GCRoleModerator:
+			gc.ModeratorPerms = perms
+		default:
+			return fmt.Errorf("cannot modify permissions of role %s", role)
+		}
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Changed permissions of role %s in GC %s to %s", role,
+		gcid, perms)
+
+	rm := rpc.RMGroupUpdateAdmins{
+		NewGroupList: newGC,
+	}
+	return c.sendToGCMembers(gcid, newGC.Members, "modifyRolePerms", rm, nil)
+}
+
+// setGCMsgPinned pins or unpins a message of the GC.
+func (c *Client) setGCMsgPinned(gcid, msgID zkidentity.ShortID, pin bool) error {
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot pin messages in GC with version < %d",
+				gcRolesVersion)
+		}
+		idx := slices.Index(gc.Pinned, msgID)
+		switch {
+		case pin && idx > -1:
+			return fmt.Errorf("message %s is already pinned", msgID)
+		case pin && len(gc.Pinned) >= maxGCPinnedMsgs:
+			return fmt.Errorf("GC already has the max number of "+
+				"pinned messages (%d)", maxGCPinnedMsgs)
+		case pin:
+			gc.Pinned = append(gc.Pinned, msgID)
+		case idx < 0:
+			return fmt.Errorf("message %s is not pinned", msgID)
+		default:
+			gc.Pinned = slices.Delete(gc.Pinned, idx, idx+1)
+		}
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	payType := "pinMsg"
+	if !pin {
+		payType = "unpinMsg"
+	}
+	c.log.Infof("Changed pinned status of msg %s in GC %s to %v", msgID,
+		gcid, pin)
+	return c.sendToGCMembers(gcid, newGC.Members, payType, newGC, nil)
+}
+
+// PinGCMessage pins a message of the GC. The local client must have the pin
+// permission in the GC.
+func (c *Client) PinGCMessage(gcid, msgID zkidentity.ShortID) error {
+	return c.setGCMsgPinned(gcid, msgID, true)
+}
+
+// UnpinGCMessage unpins a message of the GC. The local client must have the
+// pin permission in the GC.
+func (c *Client) UnpinGCMessage(gcid, msgID zkidentity.ShortID) error {
+	return c.setGCMsgPinned(gcid, msgID, false)
+}
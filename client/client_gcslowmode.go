@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// gcSlowModeTolerance is the tolerance used when checking whether messages
+// received from remote members respect the slow mode interval of a GC. This
+// accounts for differences between the time messages are sent by the remote
+// client and the time they are received by the server.
+const gcSlowModeTolerance = 2 * time.Second
+
+// gcSlowModeKey is the key used to track the last message sent by a member of
+// a GC in slow mode.
+type gcSlowModeKey struct {
+	gc  zkidentity.ShortID
+	uid clientintf.UserID
+}
+
+// gcSlowModeInterval returns the min interval between messages sent by the
+// user in the GC. Returns zero if the user is not subject to slow mode.
+func gcSlowModeInterval(gc *rpc.RMGroupList, uid clientintf.UserID) time.Duration {
+	if gc.Version < gcRolesVersion || gc.SlowModeInterval == 0 {
+		return 0
+	}
+	if GCMemberRole(gc, uid) != GCRoleMember {
+		return 0
+	}
+	return time.Duration(gc.SlowModeInterval) * time.Second
+}
+
+// checkGCSlowMode checks whether a message sent by the user at the given time
+// respects the slow mode interval of the GC (minus the tolerance). If it does,
+// the time is recorded as the time of the last message sent by the user.
+// Otherwise, a GCSlowModeError is returned.
+func (c *Client) checkGCSlowMode(gc *rpc.RMGroupList, uid clientintf.UserID,
+	ts time.Time, tolerance time.Duration) error {
+
+	interval := gcSlowModeInterval(gc, uid)
+	if interval == 0 {
+		return nil
+	}
+
+	key := gcSlowModeKey{gc: gc.ID, uid: uid}
+	c.gcSlowModeMtx.Lock()
+	defer c.gcSlowModeMtx.Unlock()
+	if last, ok := c.gcSlowModeLast[key]; ok {
+		elapsed := ts.Sub(last)
+		if elapsed < interval-tolerance {
+			return GCSlowModeError{Wait: interval - elapsed}
+		}
+	}
+	c.gcSlowModeLast[key] = ts
+	return nil
+}
+
+// SetGCSlowMode sets the min interval between messages sent by members of the
+// GC that do not have a moderation role. An interval of zero disables slow
+// mode. The local client must have permission to change the metadata of the
+// GC.
+func (c *Client) SetGCSlowMode(gcID zkidentity.ShortID, interval time.Duration) error {
+	if interval < 0 {
+		return fmt.Errorf("slow mode interval cannot be negative")
+	}
+	secs := uint32(interval.Round(time.Second) / time.Second)
+	cb := func(gc *rpc.RMGroupList) error {
+		if gc.Version < gcRolesVersion {
+			return fmt.Errorf("cannot set slow mode of GC with version < %d",
+				gcRolesVersion)
+		}
+		if gc.SlowModeInterval == secs {
+			return fmt.Errorf("slow mode interval already set to %s",
+				interval.Round(time.Second))
+		}
+		gc.SlowModeInterval = secs
+		gc.Timestamp = time.Now().Unix()
+		gc.Generation += 1
+		return nil
+	}
+
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcID, cb)
+	if err != nil {
+		return err
+	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
+
+	c.log.Infof("Set slow mode interval of GC %q to %ds", gcID.String(), secs)
+	return c.sendToGCMembers(gcID, newGC.Members, "setSlowMode", newGC, nil)
+}
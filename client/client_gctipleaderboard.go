@@ -0,0 +1,146 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// GC tipping leaderboards:
+//
+// Members that enable the tipping leaderboard of a GC announce the tips they
+// make to other members from within the context of the GC (for example, tips
+// sent from the GC window or tip splits) by sending the signed receipt of the
+// tip, with the GC and payee filled, to the payee and to every other member
+// they are KX'd with. Members that also enabled the leaderboard verify the
+// receipt and record the tip. The leaderboard only includes tips that were
+// announced to the local client.
+
+// GCTipLeaderboardEntry is the total of the tips sent and received by a GC
+// member.
+type GCTipLeaderboardEntry = clientdb.GCTipLeaderboardEntry
+
+// SetGCTipLeaderboard sets whether the tipping leaderboard of the GC is
+// enabled. When enabled, tips made to other members within the context of the
+// GC are announced to the GC and tips announced by other members are
+// recorded.
+func (c *Client) SetGCTipLeaderboard(gcID zkidentity.ShortID, enabled bool) error {
+	if _, err := c.GetGC(gcID); err != nil {
+		return err
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetGCTipLeaderboard(tx, gcID, enabled)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Set tipping leaderboard of GC %s to %v", gcID, enabled)
+	return nil
+}
+
+// GCTipLeaderboardEnabled returns whether the tipping leaderboard of the GC is
+// enabled.
+func (c *Client) GCTipLeaderboardEnabled(gcID zkidentity.ShortID) (bool, error) {
+	var enabled bool
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		enabled, err = c.db.GetGCTipLeaderboard(tx, gcID)
+		return err
+	})
+	return enabled, err
+}
+
+// GCTipLeaderboard returns the totals of the tips sent and received by the
+// members of the GC since the specified time, sorted by amount sent.
+func (c *Client) GCTipLeaderboard(gcID zkidentity.ShortID, since time.Time) ([]GCTipLeaderboardEntry, error) {
+	var res []GCTipLeaderboardEntry
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.GCTipLeaderboard(tx, gcID, since)
+		return err
+	})
+	return res, err
+}
+
+// gcTipAnnounceTargets returns the members of the GC (other than the payee)
+// to which a tip made to the payee within the context of the GC should be
+// announced. Returns false if the tip should not be announced.
+func (c *Client) gcTipAnnounceTargets(gcID zkidentity.ShortID, payee UserID) ([]UserID, bool) {
+	enabled, err := c.GCTipLeaderboardEnabled(gcID)
+	if err != nil {
+		c.log.Warnf("Unable to load tipping leaderboard setting of GC %s: %v",
+			gcID, err)
+		return nil, false
+	}
+	if !enabled {
+		return nil, false
+	}
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		c.log.Warnf("Unable to load GC %s to announce tip: %v", gcID, err)
+		return nil, false
+	}
+	if !slices.Contains(gc.Members, payee) {
+		return nil, false
+	}
+
+	myID := c.PublicID()
+	var targets []UserID
+	for _, uid := range gc.Members {
+		if uid == myID || uid == payee {
+			continue
+		}
+		if _, err := c.rul.byID(uid); err != nil {
+			continue
+		}
+		targets = append(targets, uid)
+	}
+	return targets, true
+}
+
+// recordGCTip records the tip of the receipt in the leaderboard of its GC, if
+// the leaderboard is enabled and both payer and payee are members of the GC.
+func (c *Client) recordGCTip(payer, payee UserID, rm rpc.RMPaymentReceipt) error {
+	if rm.GC == nil || rm.Kind != rpc.PaymentReceiptKindTip {
+		return nil
+	}
+	gcID := *rm.GC
+	var stored bool
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		enabled, err := c.db.GetGCTipLeaderboard(tx, gcID)
+		if err != nil || !enabled {
+			return err
+		}
+		gc, err := c.db.GetGC(tx, gcID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(gc.Members, payer) || !slices.Contains(gc.Members, payee) {
+			return nil
+		}
+		tip := clientdb.GCTip{
+			Payer:       payer,
+			Payee:       payee,
+			MilliAtoms:  rm.MilliAtoms,
+			PaymentHash: rm.PaymentHash,
+			Timestamp:   time.Unix(rm.Timestamp, 0),
+		}
+		stored, err = c.db.StoreGCTip(tx, gcID, tip)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if stored {
+		c.log.Debugf("Recorded tip of %.8f DCR from %s to %s in GC %s",
+			float64(rm.MilliAtoms)/1e11, payer, payee, gcID)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+)
+
+// TestGCTipLeaderboard tests aggregating the tips made within a GC in its
+// tipping leaderboard.
+func TestGCTipLeaderboard(t *testing.T) {
+	db := testDB(t, nil, slog.Disabled)
+	runTestDB(t, db)
+
+	gcID := zkidentity.ShortID{0: 0xff}
+	alice, bob, carol := zkidentity.ShortID{0: 1}, zkidentity.ShortID{0: 2}, zkidentity.ShortID{0: 3}
+	now := time.Now()
+	tips := []clientdb.GCTip{
+		{Payer: alice, Payee: bob, MilliAtoms: 1000, PaymentHash: []byte{1}, Timestamp: now},
+		{Payer: alice, Payee: carol, MilliAtoms: 3000, PaymentHash: []byte{2}, Timestamp: now},
+		{Payer: bob, Payee: carol, MilliAtoms: 5000, PaymentHash: []byte{3}, Timestamp: now},
+		{Payer: carol, Payee: alice, MilliAtoms: 7000, PaymentHash: []byte{4}, Timestamp: now.Add(-48 * time.Hour)},
+
+		// Duplicate payment hash is ignored.
+		{Payer: alice, Payee: bob, MilliAtoms: 1000, PaymentHash: []byte{1}, Timestamp: now},
+	}
+	stored := make([]bool, len(tips))
+	err := db.Update(context.Background(), func(tx clientdb.ReadWriteTx) error {
+		for i, tip := range tips {
+			var err error
+			stored[i], err = db.StoreGCTip(tx, gcID, tip)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tips {
+		if wantStored := i < 4; stored[i] != wantStored {
+			t.Fatalf("tip %d: unexpected stored: got %v, want %v", i,
+				stored[i], wantStored)
+		}
+	}
+
+	leaderboard := func(since time.Time) []clientdb.GCTipLeaderboardEntry {
+		t.Helper()
+		var res []clientdb.GCTipLeaderboardEntry
+		err := db.View(context.Background(), func(tx clientdb.ReadTx) error {
+			var err error
+			res, err = db.GCTipLeaderboard(tx, gcID, since)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	got := leaderboard(time.Time{})
+	want := []clientdb.GCTipLeaderboardEntry{
+		{UID: carol, SentMAtoms: 7000, SentCount: 1, ReceivedMAtoms: 8000, ReceivedCount: 2},
+		{UID: bob, SentMAtoms: 5000, SentCount: 1, ReceivedMAtoms: 1000, ReceivedCount: 1},
+		{UID: alice, SentMAtoms: 4000, SentCount: 2, ReceivedMAtoms: 7000, ReceivedCount: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected nb of entries: got %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Old tips are not included in recent leaderboards.
+	got = leaderboard(now.Add(-24 * time.Hour))
+	want = []clientdb.GCTipLeaderboardEntry{
+		{UID: bob, SentMAtoms: 5000, SentCount: 1, ReceivedMAtoms: 1000, ReceivedCount: 1},
+		{UID: alice, SentMAtoms: 4000, SentCount: 2},
+		{UID: carol, ReceivedMAtoms: 8000, ReceivedCount: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected nb of entries: got %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
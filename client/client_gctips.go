@@ -0,0 +1,257 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"golang.org/x/exp/slices"
+)
+
+// GCTipSplitMember is the progress of the tip to a member of a GC tip split.
+type GCTipSplitMember struct {
+	UID       UserID
+	Nick      string
+	Completed bool
+	Err       error
+}
+
+// done returns true if the tip to the member completed or failed.
+func (m *GCTipSplitMember) done() bool {
+	return m.Completed || m.Err != nil
+}
+
+// GCTipSplit is an amount split across members of a GC, with an individual
+// LN tip to each member.
+type GCTipSplit struct {
+	GC      zkidentity.ShortID
+	Started time.Time
+
+	// Total is the amount split across the members and Share is the
+	// amount tipped to each member. Any remainder of the division is not
+	// tipped.
+	Total dcrutil.Amount
+	Share dcrutil.Amount
+
+	Members []GCTipSplitMember
+}
+
+// Counts returns the number of members for which the tip completed, failed
+// and is still in progress.
+func (s GCTipSplit) Counts() (completed, failed, inProgress int) {
+	for i := range s.Members {
+		switch {
+		case s.Members[i].Completed:
+			completed++
+		case s.Members[i].Err != nil:
+			failed++
+		default:
+			inProgress++
+		}
+	}
+	return
+}
+
+// Done returns true if the tips to all members completed or failed.
+func (s GCTipSplit) Done() bool {
+	_, _, inProgress := s.Counts()
+	return inProgress == 0
+}
+
+// summary returns the summary message sent to the GC once the split is done.
+func (s GCTipSplit) summary() string {
+	var nicks []string
+	for _, m := range s.Members {
+		if m.Completed {
+			nicks = append(nicks, m.Nick)
+		}
+	}
+	if len(nicks) == 0 {
+		return fmt.Sprintf("Tip of %s could not be sent to any of the %d "+
+			"selected members", s.Total, len(s.Members))
+	}
+	return fmt.Sprintf("Tipped %s to each of %d members (%s total): %s",
+		s.Share, len(nicks), s.Share*dcrutil.Amount(len(nicks)),
+		strings.Join(nicks, ", "))
+}
+
+// gcTipSplitTracker tracks the progress of a GC tip split.
+type gcTipSplitTracker struct {
+	mtx   sync.Mutex
+	split GCTipSplit
+	reg   NotificationRegistration
+}
+
+// update updates the progress of the tip to the given member. It returns a
+// copy of the split and whether the member was updated.
+func (t *gcTipSplitTracker) update(uid UserID, completed bool, err error) (GCTipSplit, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for i := range t.split.Members {
+		m := &t.split.Members[i]
+		if m.UID != uid || m.done() {
+			continue
+		}
+		m.Completed = completed
+		m.Err = err
+		split := t.split
+		split.Members = slices.Clone(t.split.Members)
+		return split, true
+	}
+	return GCTipSplit{}, false
+}
+
+// finishGCTipSplit is called once a GC tip split is done.
+func (c *Client) finishGCTipSplit(t *gcTipSplitTracker, split GCTipSplit, announce bool) {
+	t.mtx.Lock()
+	t.reg.Unregister()
+	t.mtx.Unlock()
+	completed, failed, _ := split.Counts()
+	c.log.Infof("Completed tip split in GC %s: %d tips completed, %d failed",
+		split.GC, completed, failed)
+	if !announce {
+		return
+	}
+	err := c.GCMessage(split.GC, split.summary(), rpc.MessageModeNormal, nil)
+	if err != nil {
+		c.log.Errorf("Unable to send tip split summary to GC %s: %v",
+			split.GC, err)
+	}
+}
+
+// GCTipSplitMembers returns the members of the GC across which a tip would be
+// split by TipGCMembers. If members is empty, this returns all members of the
+// GC with which the local client has KX'd. Otherwise, the passed members are
+// validated to be members of the GC.
+func (c *Client) GCTipSplitMembers(gcID zkidentity.ShortID, members []UserID) ([]UserID, error) {
+	gc, err := c.GetGC(gcID)
+	if err != nil {
+		return nil, err
+	}
+
+	myID := c.PublicID()
+	if len(members) == 0 {
+		for _, uid := range gc.Members {
+			if uid == myID {
+				continue
+			}
+			if _, err := c.rul.byID(uid); err != nil {
+				continue
+			}
+			members = append(members, uid)
+		}
+	} else {
+		for i, uid := range members {
+			if slices.Contains(members[:i], uid) {
+				return nil, fmt.Errorf("duplicate member %s", uid)
+			}
+			if uid == myID {
+				return nil, errors.New("cannot tip the local client")
+			}
+			if !slices.Contains(gc.Members, uid) {
+				return nil, fmt.Errorf("user %s is not a member "+
+					"of GC %s", uid, gcID)
+			}
+		}
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("GC %s does not have members to tip", gcID)
+	}
+	return members, nil
+}
+
+// TipGCMembers splits the given amount of dcr across members of the GC,
+// starting an individual tip attempt to each member (see TipUser). If members
+// is empty, the amount is split across all members of the GC with which the
+// local client has KX'd.
+//
+// Progress of the split is reported through OnGCTipSplitProgressNtfn. If
+// announce is true, a summary message is sent to the GC once the tips to all
+// members complete or fail.
+func (c *Client) TipGCMembers(gcID zkidentity.ShortID, dcrAmount float64,
+	members []UserID, maxAttempts int32, announce bool) (GCTipSplit, error) {
+
+	if err := c.checkPaymentsAllowed("tip"); err != nil {
+		return GCTipSplit{}, err
+	}
+	if dcrAmount <= 0 {
+		return GCTipSplit{}, fmt.Errorf("cannot pay users %f <= 0", dcrAmount)
+	}
+	total, err := dcrutil.NewAmount(dcrAmount)
+	if err != nil {
+		return GCTipSplit{}, err
+	}
+	members, err = c.GCTipSplitMembers(gcID, members)
+	if err != nil {
+		return GCTipSplit{}, err
+	}
+
+	share := total / dcrutil.Amount(len(members))
+	if share <= 0 {
+		return GCTipSplit{}, fmt.Errorf("amount %s is too small to split "+
+			"across %d members", total, len(members))
+	}
+
+	t := &gcTipSplitTracker{
+		split: GCTipSplit{
+			GC:      gcID,
+			Started: time.Now(),
+			Total:   total,
+			Share:   share,
+			Members: make([]GCTipSplitMember, len(members)),
+		},
+	}
+	for i, uid := range members {
+		t.split.Members[i].UID = uid
+		t.split.Members[i].Nick, _ = c.UserNick(uid)
+	}
+
+	res := t.split
+	res.Members = slices.Clone(t.split.Members)
+
+	// Track the progress of the individual tips. This is registered before
+	// starting the tips because keysend tips complete synchronously.
+	shareMAtoms := int64(share) * 1e3
+	onProgress := func(uid UserID, completed bool, err error) {
+		split, ok := t.update(uid, completed, err)
+		if !ok {
+			return
+		}
+		c.ntfns.notifyGCTipSplitProgress(split)
+		if split.Done() {
+			go c.finishGCTipSplit(t, split, announce)
+		}
+	}
+	t.mtx.Lock()
+	t.reg = c.ntfns.Register(OnTipAttemptProgressNtfn(func(ru *RemoteUser,
+		amtMAtoms int64, completed bool, _ int, attemptErr error, willRetry bool) {
+
+		if amtMAtoms != shareMAtoms {
+			return
+		}
+		if !completed && (attemptErr == nil || willRetry) {
+			return
+		}
+		onProgress(ru.ID(), completed, attemptErr)
+	}))
+	t.mtx.Unlock()
+
+	c.log.Infof("Splitting tip of %s across %d members of GC %s (%s each)",
+		total, len(members), gcID, share)
+	go func() {
+		for _, uid := range members {
+			err := c.tipUser(uid, share.ToCoin(), maxAttempts, &gcID, nil)
+			if err != nil {
+				c.log.Warnf("Unable to tip %s in GC %s split: %v",
+					uid, gcID, err)
+				onProgress(uid, false, err)
+			}
+		}
+	}()
+	return res, nil
+}
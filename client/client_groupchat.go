@@ -21,7 +21,7 @@ const (
 	// {min,max}SupportedGCVersion tracks the mininum and maximum versions
 	// the client code handles for GCs.
 	minSupportedGCVersion = 0
-	maxSupportedGCVersion = 1
+	maxSupportedGCVersion = 2
 
 	// newGCVersion is the version of newly created GCs.
 	newGCVersion = 1
@@ -171,6 +171,10 @@ func (c *Client) NewGroupChatVersion(name string, version uint8) (zkidentity.Sho
 				c.PublicID(),
 			},
 		}
+		if version >= gcRolesVersion {
+			gc.AdminPerms = rpc.GCPermAll
+			gc.ModeratorPerms = defaultGCModeratorPerms
+		}
 		if err = c.db.SaveGC(tx, gc); err != nil {
 			return fmt.Errorf("can't save gc %q (%s): %v", name, id.String(), err)
 		}
@@ -183,7 +187,11 @@ func (c *Client) NewGroupChatVersion(name string, version uint8) (zkidentity.Sho
 
 		return nil
 	})
-	return id, err
+	if err != nil {
+		return id, err
+	}
+	c.syncDevicesGC(id)
+	return id, nil
 }
 
 // NewGroupChat creates a group chat with the local client as admin.
@@ -203,7 +211,7 @@ func (c *Client) uidHasGCPerm(gc rpc.RMGroupList, uid clientintf.UserID) error {
 		return fmt.Errorf("user %s not version 0 GC admin", uid)
 	}
 
-	if gc.Version == 1 {
+	if gc.Version == 1 || gc.Version == 2 {
 		if len(gc.Members) > 0 && gc.Members[0].ConstantTimeEq(&uid) {
 			// Update from admin. Accept.
 			return nil
@@ -214,7 +222,7 @@ func (c *Client) uidHasGCPerm(gc rpc.RMGroupList, uid clientintf.UserID) error {
 			return nil
 		}
 
-		return fmt.Errorf("user %s not version 1 GC admin", uid)
+		return fmt.Errorf("user %s not version %d GC admin", uid, gc.Version)
 	}
 
 	return fmt.Errorf("unsupported GC version %d", gc.Version)
@@ -240,10 +248,17 @@ func (c *Client) InviteToGroupChat(gcID zkidentity.ShortID, user UserID) error {
 			return err
 		}
 
-		if err := c.uidHasGCPerm(gc, c.PublicID()); err != nil {
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermInvite); err != nil {
 			return fmt.Errorf("not permitted to send send invite: %v", err)
 		}
 
+		if slices.Contains(gc.Banned, user) {
+			return fmt.Errorf("user %s is banned from the GC", user)
+		}
+		if err := checkGCCapacity(&gc, 1); err != nil {
+			return err
+		}
+
 		invite.Name = gc.Name
 		invite.Version = gc.Version
 
@@ -482,8 +497,9 @@ func (c *Client) sendToGCMembers(gcID zkidentity.ShortID,
 	// warn the UI about it.
 	//
 	// First: go through the DB to see if they are being KX'd with.
+	policy := c.autoKXPolicy()
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
-		var localID, gcOwner clientintf.UserID
+		var gcMediator *clientintf.UserID
 		var gotGCInfo bool
 		for i := range missingKX {
 			target := missingKX[i].uid
@@ -547,16 +563,13 @@ func (c *Client) sendToGCMembers(gcID zkidentity.ShortID,
 				if err != nil {
 					return err
 				}
-				localID = c.PublicID()
-				gcOwner = gcl.Members[0]
+				gcMediator = c.gcAutoKXMediator(policy, &gcl)
 				gotGCInfo = true
 			}
 
-			// Determine if we can ask the GC's owner for a mediate
-			// ID request.
-			if gcOwner != localID {
-				missingKX[i].medID = &gcOwner
-			}
+			// Determine if we can ask the GC's owner (or one of
+			// its admins) for a mediate ID request.
+			missingKX[i].medID = gcMediator
 		}
 		return nil
 	})
@@ -650,9 +663,25 @@ func (c *Client) maybeUpdateGCFunc(ru *RemoteUser, gcid zkidentity.ShortID, f fu
 		newGC = oldGC
 		newGC.Members = slices.Clone(oldGC.Members)
 		newGC.ExtraAdmins = slices.Clone(oldGC.ExtraAdmins)
+		newGC.Moderators = slices.Clone(oldGC.Moderators)
+		newGC.Pinned = slices.Clone(oldGC.Pinned)
+		newGC.Banned = slices.Clone(oldGC.Banned)
+		newGC.Avatar = slices.Clone(oldGC.Avatar)
+		newGC.Channels = slices.Clone(oldGC.Channels)
 		if err := f(&newGC); err != nil {
 			return err
 		}
+		if err := checkGCMetadata(&newGC); err != nil {
+			return err
+		}
+
+		// Ensure no banned user is a member.
+		for _, uid := range newGC.Banned {
+			if slices.Contains(newGC.Members, uid) {
+				return fmt.Errorf("user %s banned from GC %s "+
+					"cannot be a member", uid, gcid)
+			}
+		}
 
 		// Ensure no backtrack on generation.
 		if newGC.Generation < oldGC.Generation {
@@ -683,7 +712,7 @@ func (c *Client) maybeUpdateGCFunc(ru *RemoteUser, gcid zkidentity.ShortID, f fu
 		// permission.
 		checkVersionWarning = ru != nil
 
-		if err := c.uidHasGCPerm(oldGC, updaterID); err != nil {
+		if err := c.checkGCUpdatePerms(&oldGC, &newGC, updaterID); err != nil {
 			return err
 		}
 
@@ -756,6 +785,10 @@ func (c *Client) maybeUpdateGC(ru *RemoteUser, newGC rpc.RMGroupList) (oldGC rpc
 // handleGCJoin handles a msg when a remote user is asking to join a GC we
 // administer (that is, responding to an invite previously sent by us).
 func (c *Client) handleGCJoin(ru *RemoteUser, invite rpc.RMGroupJoin) error {
+	if invite.LinkID != nil {
+		return c.handleGCJoinLink(ru, invite)
+	}
+
 	var gc rpc.RMGroupList
 	updated := false
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
@@ -778,7 +811,7 @@ func (c *Client) handleGCJoin(ru *RemoteUser, invite rpc.RMGroupJoin) error {
 		if err != nil {
 			return err
 		}
-		if err := c.uidHasGCPerm(gc, c.PublicID()); err != nil {
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermInvite); err != nil {
 			return fmt.Errorf("local user does not have permission "+
 				"to add gc member: %v", err)
 		}
@@ -788,6 +821,12 @@ func (c *Client) handleGCJoin(ru *RemoteUser, invite rpc.RMGroupJoin) error {
 			return err
 		}
 
+		// Ensure user was not banned after being invited.
+		if slices.Contains(gc.Banned, uid) {
+			return fmt.Errorf("user %s banned from gc %q attempted "+
+				"to join", uid, gc.ID.String())
+		}
+
 		// Ensure user is not on gc yet.
 		if slices.Contains(gc.Members, uid) {
 			return fmt.Errorf("user %s already part of gc %q",
@@ -795,6 +834,10 @@ func (c *Client) handleGCJoin(ru *RemoteUser, invite rpc.RMGroupJoin) error {
 		}
 
 		if invite.Error == "" {
+			if err := checkGCCapacity(&gc, 1); err != nil {
+				return err
+			}
+
 			// Add the new member, increment generation, save the
 			// new gc group.
 			gc.Members = append(gc.Members, uid)
@@ -825,12 +868,16 @@ func (c *Client) handleGCJoin(ru *RemoteUser, invite rpc.RMGroupJoin) error {
 	}
 
 	c.ntfns.notifyGCInviteAccepted(ru, gc)
+	c.maybeShareGCHistory(gc.ID, []UserID{ru.ID()})
 	return nil
 }
 
 // notifyUpdatedGC determines what changed between two GC definitions and
-// notifies the user about it.
-func (c *Client) notifyUpdatedGC(ru *RemoteUser, oldGC, newGC rpc.RMGroupList) {
+// notifies the user about it. Changes are also recorded in the GC moderation
+// log.
+func (c *Client) notifyUpdatedGC(ru *RemoteUser, oldGC, newGC rpc.RMGroupList, reason string) {
+	c.logGCModActions(ru.ID(), &oldGC, &newGC, reason)
+
 	if oldGC.Version != newGC.Version {
 		c.ntfns.notifyOnGCUpgraded(newGC, oldGC.Version)
 	}
@@ -838,6 +885,7 @@ func (c *Client) notifyUpdatedGC(ru *RemoteUser, oldGC, newGC rpc.RMGroupList) {
 	memberChanges := sliceDiff(oldGC.Members, newGC.Members)
 	if len(memberChanges.added) > 0 {
 		c.ntfns.notifyOnAddedGCMembers(newGC, memberChanges.added)
+		c.maybeShareGCHistory(newGC.ID, memberChanges.added)
 	}
 	if len(memberChanges.removed) > 0 {
 		c.ntfns.notifyOnRemovedGCMembers(newGC, memberChanges.removed)
@@ -854,6 +902,25 @@ func (c *Client) notifyUpdatedGC(ru *RemoteUser, oldGC, newGC rpc.RMGroupList) {
 	if len(adminChanges.removed) > 0 || len(adminChanges.added) > 0 {
 		c.ntfns.notifyGCAdminsChanged(ru, newGC, adminChanges.added, adminChanges.removed)
 	}
+
+	modChanges := sliceDiff(oldGC.Moderators, newGC.Moderators)
+	if len(modChanges.removed) > 0 || len(modChanges.added) > 0 {
+		c.ntfns.notifyGCModeratorsChanged(ru, newGC, modChanges.added, modChanges.removed)
+	}
+
+	if oldGC.SlowModeInterval != newGC.SlowModeInterval {
+		interval := time.Duration(newGC.SlowModeInterval) * time.Second
+		c.ntfns.notifyGCSlowModeChanged(ru, newGC, interval)
+	}
+
+	if fields := gcMetadataChanges(&oldGC, &newGC); len(fields) > 0 {
+		c.ntfns.notifyGCMetadataUpdated(ru, newGC, fields)
+	}
+
+	addedChannels, removedChannels := gcChannelChanges(&oldGC, &newGC)
+	if len(addedChannels) > 0 || len(removedChannels) > 0 {
+		c.ntfns.notifyGCChannelsChanged(ru, newGC, addedChannels, removedChannels)
+	}
 }
 
 // saveJoinedGC is called when the local client receives the first RMGroupList
@@ -885,7 +952,10 @@ func (c *Client) saveJoinedGC(ru *RemoteUser, gl rpc.RMGroupList) (string, error
 
 		// Ensure we received this from someone that can add
 		// members.
-		if err := c.uidHasGCPerm(gl, ru.ID()); err != nil {
+		if err := c.uidHasGCPermission(&gl, ru.ID(), rpc.GCPermInvite); err != nil {
+			return err
+		}
+		if err := checkGCMetadata(&gl); err != nil {
 			return err
 		}
 
@@ -941,7 +1011,7 @@ func (c *Client) handleGCList(ru *RemoteUser, gl rpc.RMGroupList) error {
 
 		gcName, _ = c.GetGCAlias(gl.ID)
 		c.log.Infof("Received updated GC list %s (%q) from %s", gl.ID, gcName, ru)
-		c.notifyUpdatedGC(ru, oldGC, gl)
+		c.notifyUpdatedGC(ru, oldGC, gl, "")
 		return nil
 	}
 
@@ -952,9 +1022,16 @@ func (c *Client) handleGCList(ru *RemoteUser, gl rpc.RMGroupList) error {
 	}
 	c.log.Infof("Received first GC list of %s (%q) from %s", gl.ID, gcName, ru)
 	c.ntfns.notifyOnJoinedGC(gl)
+	c.handlePendingGCHistory(gl.ID)
+	c.syncDevicesGC(gl.ID)
 
 	// Start kx with unknown members. They are relying on us performing
 	// transitive KX via an admin.
+	if c.autoKXPolicy().DisableGCRequests {
+		c.log.Debugf("Skipping autoKX with members of GC %s due to auto "+
+			"KX policy", gl.ID)
+		return nil
+	}
 	me := c.PublicID()
 	for _, v := range gl.Members {
 		v := v
@@ -995,20 +1072,55 @@ func (c *Client) handleDelayedGCMessages(msg clientintf.ReceivedGCMsg) {
 		}
 
 		gcAlias, _ := c.GetGCAlias(msg.GCM.ID)
-		err := c.db.LogGCMsg(tx, gcAlias, msg.GCM.ID, false, user.Nick(),
-			msg.GCM.Message, msg.TS)
+		var err error
+		if msg.GCM.Channel != nil {
+			err = c.db.LogGCChannelMsg(tx, gcAlias, msg.GCM.ID,
+				*msg.GCM.Channel, user.Nick(), msg.GCM.Message, msg.TS)
+		} else {
+			err = c.db.LogGCMsg(tx, gcAlias, msg.GCM.ID, false, user.Nick(),
+				msg.GCM.Message, msg.TS)
+		}
 		if err != nil {
 			c.log.Warnf("Unable to log RGCM: %v", err)
 		}
 
+		if msg.GCM.MsgID != nil && msg.GCM.ReplyTo != nil {
+			reply := clientdb.ThreadMessage{
+				MsgID:     *msg.GCM.MsgID,
+				ReplyTo:   *msg.GCM.ReplyTo,
+				From:      msg.UID,
+				Message:   msg.GCM.Message,
+				Timestamp: msg.TS,
+			}
+			_, err := c.db.StoreThreadReply(tx, &msg.GCM.ID, reply)
+			if err != nil {
+				c.log.Warnf("Unable to store GC reply: %v", err)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		// Not a fatal error, so just log a warning.
 		c.log.Warnf("Unable to handle cached RGCM: %v", err)
 	}
+	if msg.GCM.Channel == nil {
+		c.syncDevicesGCM(msg.GCM.ID, user.Nick(), msg.GCM.Message, msg.TS)
+	}
 
+	if c.isMsgMuted(msg.UID, &msg.GCM.ID, msg.GCM.Message) {
+		// Muted messages are logged but not notified.
+		c.sendMsgReceiveReceipt(user, msg.GCM.MsgID, &msg.GCM.ID)
+		return
+	}
+
+	c.unarchiveOnNewMsg(msg.GCM.ID)
 	c.ntfns.notifyOnGCM(user, msg.GCM, msg.TS)
+	c.notifyVoiceMsgs(user, &msg.GCM.ID, msg.GCM.MsgID, msg.GCM.Message, msg.TS)
+	if c.isMentioned(msg.GCM) {
+		c.ntfns.notifyGCMention(user, msg.GCM, msg.TS)
+	}
+	c.sendMsgReceiveReceipt(user, msg.GCM.MsgID, &msg.GCM.ID)
 }
 
 // SendProgress is sent to track progress of messages that are sent to multiple
@@ -1025,9 +1137,37 @@ type SendProgress struct {
 func (c *Client) GCMessage(gcID zkidentity.ShortID, msg string, mode rpc.MessageMode,
 	progressChan chan SendProgress) error {
 
+	_, err := c.GCMessageWithMsgID(gcID, msg, mode, progressChan)
+	return err
+}
+
+// GCMessageWithMsgID sends a message to the given GC and returns the ID chosen
+// for the message, which may be used to edit it later.
+func (c *Client) GCMessageWithMsgID(gcID zkidentity.ShortID, msg string, mode rpc.MessageMode,
+	progressChan chan SendProgress) (zkidentity.ShortID, error) {
+
+	return c.gcMessage(gcID, nil, msg, mode, nil, false, progressChan)
+}
+
+// gcMessage sends a message to the given GC, optionally to one of its channels
+// and as a reply to a previous message. bridged is set for messages relayed
+// from a bridged GC.
+func (c *Client) gcMessage(gcID zkidentity.ShortID, channel *zkidentity.ShortID,
+	msg string, mode rpc.MessageMode, replyTo *zkidentity.ShortID,
+	bridged bool, progressChan chan SendProgress) (zkidentity.ShortID, error) {
+
+	var msgID zkidentity.ShortID
+	if _, err := rand.Read(msgID[:]); err != nil {
+		return msgID, err
+	}
+	if !bridged {
+		msg = c.addLinkPreviews(msg)
+	}
+
 	var gc rpc.RMGroupList
 	var gcBlockList clientdb.GCBlockList
 	myNick := c.LocalNick()
+	now := time.Now()
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
 		if gc, err = c.db.GetGC(tx, gcID); err != nil {
@@ -1042,10 +1182,38 @@ func (c *Client) GCMessage(gcID zkidentity.ShortID, msg string, mode rpc.Message
 			gcAlias = gc.Name
 		}
 
-		return c.db.LogGCMsg(tx, gcAlias, gcID, false, myNick, msg, time.Now())
+		if channel != nil && gcChannelByID(&gc, *channel) == nil {
+			return fmt.Errorf("channel %s not found in GC %s",
+				channel, gcID)
+		}
+
+		if err := c.checkGCSlowMode(&gc, c.PublicID(), now, 0); err != nil {
+			return err
+		}
+		if replyTo != nil {
+			reply := clientdb.ThreadMessage{
+				MsgID:     msgID,
+				ReplyTo:   *replyTo,
+				From:      c.PublicID(),
+				Message:   msg,
+				Timestamp: now,
+			}
+			if _, err := c.db.StoreThreadReply(tx, &gcID, reply); err != nil {
+				return err
+			}
+		}
+
+		if channel != nil {
+			return c.db.LogGCChannelMsg(tx, gcAlias, gcID, *channel,
+				myNick, msg, now)
+		}
+		return c.db.LogGCMsg(tx, gcAlias, gcID, false, myNick, msg, now)
 	})
 	if err != nil {
-		return err
+		return msgID, err
+	}
+	if channel == nil {
+		c.syncDevicesGCM(gcID, myNick, msg, now)
 	}
 
 	p := rpc.RMGroupMessage{
@@ -1053,18 +1221,24 @@ func (c *Client) GCMessage(gcID zkidentity.ShortID, msg string, mode rpc.Message
 		Generation: gc.Generation,
 		Message:    msg,
 		Mode:       mode,
+		MsgID:      &msgID,
+		ReplyTo:    replyTo,
+		Mentions:   c.gcMentions(gc.Members, msg),
+		Channel:    channel,
+		Bridged:    bridged,
 	}
 	members := gcBlockList.FilterMembers(gc.Members)
 	if len(members) == 0 {
-		return nil
+		return msgID, nil
 	}
 
-	return c.sendToGCMembers(gcID, members, "msg", p, progressChan)
+	return msgID, c.sendToGCMembers(gcID, members, "msg", p, progressChan)
 }
 
 func (c *Client) handleGCMessage(ru *RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) error {
 	var gc rpc.RMGroupList
 	var found, isBlocked bool
+	var slowModeErr error
 	var gcAlias string
 
 	// Create the local cached structure for a received GCM. The MsgID is
@@ -1102,6 +1276,16 @@ func (c *Client) handleGCMessage(ru *RemoteUser, gcm rpc.RMGroupMessage, ts time
 			return nil
 		}
 
+		// Admins enforce slow mode by dropping messages from
+		// members that do not respect it.
+		if GCMemberRole(&gc, c.PublicID()) >= GCRoleAdmin {
+			err := c.checkGCSlowMode(&gc, ru.ID(), ts, gcSlowModeTolerance)
+			if errors.Is(err, GCSlowModeError{}) {
+				slowModeErr = err
+				return nil
+			}
+		}
+
 		return c.db.CacheReceivedGCM(tx, rgcm)
 	})
 	if errors.Is(err, clientdb.ErrNotFound) {
@@ -1133,6 +1317,12 @@ func (c *Client) handleGCMessage(ru *RemoteUser, gcm rpc.RMGroupMessage, ts time
 		return nil
 	}
 
+	if slowModeErr != nil {
+		c.log.Warnf("Dropping message in GC %q from %s: %v", gcAlias,
+			ru, slowModeErr)
+		return nil
+	}
+
 	if filter, _ := c.FilterGCM(ru.ID(), gc.ID, gcm.Message); filter {
 		return nil
 	}
@@ -1140,7 +1330,15 @@ func (c *Client) handleGCMessage(ru *RemoteUser, gcm rpc.RMGroupMessage, ts time
 	ru.log.Debugf("Received message of len %d in GC %q (%s)", len(gcm.Message),
 		gcAlias, gc.ID)
 
+	// Admins alert members that were mentioned by unkxd members.
+	if GCMemberRole(&gc, c.PublicID()) >= GCRoleAdmin {
+		c.notifyUnkxdMentions(ru, &gc, gcm)
+	}
+
 	c.gcmq.GCMessageReceived(rgcm)
+
+	// Relay the message to bridged GCs.
+	c.relayBridgedGCM(ru, &gc, gcm)
 	return nil
 }
 
@@ -1198,9 +1396,15 @@ func (c *Client) removeFromGC(gcID zkidentity.ShortID, uid UserID,
 		oldMembers = gc.Members
 
 		if localUserMustBeAdmin {
-			if err := c.uidHasGCPerm(gc, c.PublicID()); err != nil {
+			if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermKick); err != nil {
 				return fmt.Errorf("local user cannot remove from GC: %v", err)
 			}
+			localRole, uidRole := GCMemberRole(&gc, c.PublicID()), GCMemberRole(&gc, uid)
+			if gc.Version >= gcRolesVersion && uidRole >= localRole {
+				return fmt.Errorf("local user with role %s cannot "+
+					"remove user with role %s from GC", localRole,
+					uidRole)
+			}
 		}
 
 		// Ensure the user is in the GC.
@@ -1224,6 +1428,9 @@ func (c *Client) removeFromGC(gcID zkidentity.ShortID, uid UserID,
 		if idxAdmin := slices.Index(gc.ExtraAdmins, uid); idxAdmin > -1 {
 			gc.ExtraAdmins = slices.Delete(gc.ExtraAdmins, idxAdmin, idxAdmin+1)
 		}
+		if idxMod := slices.Index(gc.Moderators, uid); idxMod > -1 {
+			gc.Moderators = slices.Delete(gc.Moderators, idxMod, idxMod+1)
+		}
 
 		gc.Members = newMembers
 		gc.Timestamp = time.Now().Unix()
@@ -1258,6 +1465,10 @@ func (c *Client) GCKick(gcID zkidentity.ShortID, uid UserID, reason string) erro
 		NewGroupList: gc,
 	}
 
+	oldGC := gc
+	oldGC.Members = oldMembers
+	c.logGCModActions(c.PublicID(), &oldGC, &gc, reason)
+
 	us := uid.String()
 	if ru, err := c.rul.byID(uid); err == nil {
 		us = ru.String()
@@ -1293,7 +1504,11 @@ func (c *Client) handleGCKick(ru *RemoteUser, rmgk rpc.RMGroupKick) error {
 	// Notify specific part and any other updates.
 	c.ntfns.notifyGCUserParted(rmgk.NewGroupList.ID, rmgk.Member,
 		rmgk.Reason, !rmgk.Parted)
-	c.notifyUpdatedGC(ru, oldGC, rmgk.NewGroupList)
+	reason := rmgk.Reason
+	if rmgk.Parted {
+		reason = ""
+	}
+	c.notifyUpdatedGC(ru, oldGC, rmgk.NewGroupList, reason)
 
 	return nil
 }
@@ -1555,6 +1770,10 @@ func (c *Client) UpgradeGC(gcid zkidentity.ShortID, newVersion uint8) error {
 
 		}
 
+		if gc.Version < gcRolesVersion && newVersion >= gcRolesVersion {
+			gc.AdminPerms = rpc.GCPermAll
+			gc.ModeratorPerms = defaultGCModeratorPerms
+		}
 		gc.Version = newVersion
 		gc.Timestamp = time.Now().Unix()
 		gc.Generation += 1
@@ -1567,6 +1786,7 @@ func (c *Client) UpgradeGC(gcid zkidentity.ShortID, newVersion uint8) error {
 	}
 	c.log.Infof("Upgraded GC %s version from %d to %d",
 		gcid, oldGC.Version, newVersion)
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, "")
 
 	rm := rpc.RMGroupUpgradeVersion{
 		NewGroupList: newGC,
@@ -1581,7 +1801,7 @@ func (c *Client) handleGCUpgradeVersion(ru *RemoteUser, gcuv rpc.RMGroupUpgradeV
 	}
 	ru.log.Infof("Received GC %s Version Upgrade from %d to %d",
 		gcuv.NewGroupList.ID, oldGC.Version, gcuv.NewGroupList.Version)
-	c.notifyUpdatedGC(ru, oldGC, gcuv.NewGroupList)
+	c.notifyUpdatedGC(ru, oldGC, gcuv.NewGroupList, "")
 	return err
 }
 
@@ -1597,10 +1817,11 @@ func (c *Client) ModifyGCAdmins(gcid zkidentity.ShortID, extraAdmins []zkidentit
 		return nil
 	}
 
-	_, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
 	if err != nil {
 		return err
 	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, reason)
 
 	c.log.Infof("Changed list of GC admins for GC %s to %v",
 		gcid, extraAdmins)
@@ -1637,13 +1858,20 @@ func (c *Client) ModifyGCOwner(gcid zkidentity.ShortID, newOwner clientintf.User
 		if newOwnerAdminIdx > -1 {
 			gc.ExtraAdmins = slices.Delete(gc.ExtraAdmins, newOwnerAdminIdx, newOwnerAdminIdx+1)
 		}
+
+		// Same for moderators.
+		newOwnerModIdx := slices.Index(gc.Moderators, newOwner)
+		if newOwnerModIdx > -1 {
+			gc.Moderators = slices.Delete(gc.Moderators, newOwnerModIdx, newOwnerModIdx+1)
+		}
 		return nil
 	}
 
-	_, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
+	oldGC, newGC, err := c.maybeUpdateGCFunc(nil, gcid, cb)
 	if err != nil {
 		return err
 	}
+	c.logGCModActions(c.PublicID(), &oldGC, &newGC, reason)
 
 	gcAlias, _ := c.GetGCAlias(gcid)
 	newOwnerNick, _ := c.UserNick(newOwner)
@@ -1672,7 +1900,7 @@ func (c *Client) handleGCUpdateAdmins(ru *RemoteUser, gcup rpc.RMGroupUpdateAdmi
 		ru.log.Infof("Updated list of GC admins for GC %s to %v",
 			gcup.NewGroupList.ID, gcup.NewGroupList.ExtraAdmins)
 	}
-	c.notifyUpdatedGC(ru, oldGC, gcup.NewGroupList)
+	c.notifyUpdatedGC(ru, oldGC, gcup.NewGroupList, gcup.Reason)
 	return err
 }
 
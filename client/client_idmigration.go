@@ -0,0 +1,341 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// Identity migration flow (where Alice moves from identity A1 to A2):
+//
+// Alice KXs A2 with A1 (for example, through a regular invite), calls
+// AllowIdentityMigration() on A2's client and then MigrateIdentity() on A1's
+// client. A1 sends an RMIdentityMigration, signed with A1's signing key, to A2.
+// A2 verifies it was allowed, signs it with A2's signing key and sends it back
+// to A1, which sends it to all its contacts.
+//
+// Each contact verifies both signatures and asks A1 to mediate a KX with A2
+// (using the standard transitive KX flow). Once the KX with A2 completes, the
+// migration is applied: A2 takes over the nick of A1 and A1 is ignored. If A2
+// was already a contact, the migration is only applied after the local user
+// confirms it with ApplyIdentityMigration().
+//
+// RevokeIdentity() sends an RMIdentityMigration without a new identity, which
+// causes the contacts to ignore the revoked identity.
+
+// allowIDMigrationTimeout is how long an old identity is allowed to migrate to
+// the local identity after AllowIdentityMigration() is called.
+const allowIDMigrationTimeout = 24 * time.Hour
+
+// AllowIdentityMigration allows the user oldID to migrate to the local
+// identity. This must be called before MigrateIdentity() is called on the
+// client of the old identity, which requests the local client to sign the
+// migration. The permission expires after 24 hours.
+func (c *Client) AllowIdentityMigration(oldID UserID) error {
+	if _, err := c.rul.byID(oldID); err != nil {
+		return err
+	}
+	c.allowedIDMigrationsMtx.Lock()
+	c.allowedIDMigrations[oldID] = time.Now().Add(allowIDMigrationTimeout)
+	c.allowedIDMigrationsMtx.Unlock()
+	c.log.Infof("Allowed identity migration from %s", oldID)
+	return nil
+}
+
+// takeAllowedIDMigration returns true if the old identity is allowed to
+// migrate to the local identity. The permission is removed.
+func (c *Client) takeAllowedIDMigration(oldID UserID) bool {
+	c.allowedIDMigrationsMtx.Lock()
+	defer c.allowedIDMigrationsMtx.Unlock()
+	deadline, ok := c.allowedIDMigrations[oldID]
+	delete(c.allowedIDMigrations, oldID)
+	return ok && time.Now().Before(deadline)
+}
+
+// MigrateIdentity announces to all contacts that the local user moved to the
+// identity newID. newID must have already been KX'd with the local client, as
+// the local client is used to mediate the KX between the contacts and the new
+// identity.
+//
+// The migration is first sent to newID, which must have allowed it with
+// AllowIdentityMigration(). It is sent to all contacts after newID signs it.
+func (c *Client) MigrateIdentity(newID UserID, reason string) error {
+	ab, err := c.getAddressBookEntry(newID)
+	if err != nil {
+		return fmt.Errorf("new identity is not a contact: %w", err)
+	}
+	im := rpc.RMIdentityMigration{
+		NewIdentity: ab.ID,
+		Reason:      reason,
+		Timestamp:   time.Now().Unix(),
+	}
+	hash := im.MigrationHash(c.PublicID())
+	im.Signature = c.localID.signMessage(hash[:])
+
+	c.log.Infof("Requesting new identity %s to sign identity migration",
+		newID)
+	return c.sendWithSendQ("idmigration", im, newID)
+}
+
+// RevokeIdentity announces to all contacts that the local identity is revoked
+// and should no longer be trusted. This cannot be undone.
+func (c *Client) RevokeIdentity(reason string) error {
+	im := rpc.RMIdentityMigration{
+		Reason:    reason,
+		Timestamp: time.Now().Unix(),
+	}
+	hash := im.MigrationHash(c.PublicID())
+	im.Signature = c.localID.signMessage(hash[:])
+	return c.sendIdentityMigration(im)
+}
+
+// sendIdentityMigration sends the signed identity migration to all contacts
+// (except the new identity).
+func (c *Client) sendIdentityMigration(im rpc.RMIdentityMigration) error {
+	newID := im.NewIdentity
+	var uids []UserID
+	for _, uid := range c.rul.userList() {
+		if newID == nil || uid != newID.Identity {
+			uids = append(uids, uid)
+		}
+	}
+	if len(uids) == 0 {
+		return errors.New("no contacts to send identity migration to")
+	}
+
+	if newID != nil {
+		c.log.Infof("Announcing migration to identity %s to %d contacts",
+			newID.Identity, len(uids))
+	} else {
+		c.log.Infof("Announcing revocation of local identity to %d contacts",
+			len(uids))
+	}
+	return c.sendWithSendQ("idmigration", im, uids...)
+}
+
+// handleIdentityMigrationSignRequest handles a request from the old identity
+// ru to sign its migration to the local identity.
+func (c *Client) handleIdentityMigrationSignRequest(ru *RemoteUser, im rpc.RMIdentityMigration) error {
+	if !c.takeAllowedIDMigration(ru.ID()) {
+		ru.log.Warnf("Ignoring request to sign identity migration to " +
+			"the local identity that was not allowed")
+		return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			msg := "User requested to migrate to the local identity " +
+				"without being allowed to"
+			return c.db.LogPM(tx, ru.ID(), true, "", msg, time.Now())
+		})
+	}
+
+	// Sign using the current local identity, so that contacts may verify
+	// it matches the announced identity.
+	public := c.Public()
+	if public.Identity != im.NewIdentity.Identity ||
+		public.SigKey != im.NewIdentity.SigKey ||
+		public.Key != im.NewIdentity.Key {
+		return fmt.Errorf("identity migration announces different keys " +
+			"for the local identity")
+	}
+	hash := im.MigrationHash(ru.ID())
+	im.NewIdentitySignature = c.localID.signMessage(hash[:])
+	ru.log.Infof("Signed identity migration to the local identity")
+	return ru.sendRM(im, "idmigration")
+}
+
+// handleSignedIdentityMigration handles the identity migration of the local
+// identity, signed by the new identity ru.
+func (c *Client) handleSignedIdentityMigration(ru *RemoteUser, im rpc.RMIdentityMigration) error {
+	hash := im.MigrationHash(c.PublicID())
+	if !c.localID.verifyMessage(hash[:], &im.Signature) {
+		return fmt.Errorf("signed identity migration was not created " +
+			"by the local client")
+	}
+	if im.NewIdentity.SigKey != ru.sigKey ||
+		!zkidentity.VerifyMessage(hash[:], &im.NewIdentitySignature, &ru.sigKey) {
+		return fmt.Errorf("identity migration has invalid new identity " +
+			"signature")
+	}
+
+	ru.log.Infof("New identity signed the identity migration")
+	if err := c.sendIdentityMigration(im); err != nil {
+		return err
+	}
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		msg := "User signed the migration to their identity, which " +
+			"was announced to all contacts"
+		return c.db.LogPM(tx, ru.ID(), true, "", msg, time.Now())
+	})
+}
+
+// ListIdentityMigrations lists the identity migrations announced by remote
+// users.
+func (c *Client) ListIdentityMigrations() ([]clientdb.IdentityMigration, error) {
+	var res []clientdb.IdentityMigration
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListIdentityMigrations(tx)
+		return err
+	})
+	return res, err
+}
+
+func (c *Client) handleIdentityMigration(ru *RemoteUser, im rpc.RMIdentityMigration) error {
+	if im.NewIdentity != nil && im.NewIdentity.Identity == ru.ID() {
+		return c.handleSignedIdentityMigration(ru, im)
+	}
+
+	hash := im.MigrationHash(ru.ID())
+	if !zkidentity.VerifyMessage(hash[:], &im.Signature, &ru.sigKey) {
+		return fmt.Errorf("identity migration has invalid signature")
+	}
+	if im.NewIdentity != nil {
+		newID := im.NewIdentity
+		if !newID.VerifyIdentity() || !newID.Verify() {
+			return fmt.Errorf("identity migration has invalid new identity")
+		}
+		if newID.Identity == c.PublicID() {
+			return c.handleIdentityMigrationSignRequest(ru, im)
+		}
+		if !zkidentity.VerifyMessage(hash[:], &im.NewIdentitySignature, &newID.SigKey) {
+			return fmt.Errorf("identity migration has invalid new " +
+				"identity signature")
+		}
+	}
+
+	mig := clientdb.IdentityMigration{
+		OldID:     ru.ID(),
+		Reason:    im.Reason,
+		Timestamp: time.Unix(im.Timestamp, 0),
+		Received:  time.Now(),
+	}
+	if im.NewIdentity != nil {
+		mig.NewID = &im.NewIdentity.Identity
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if err := c.db.StoreIdentityMigration(tx, mig); err != nil {
+			return err
+		}
+		var msg string
+		if mig.NewID != nil {
+			msg = fmt.Sprintf("User announced they moved to identity "+
+				"%s (reason: %q)", mig.NewID, mig.Reason)
+		} else {
+			msg = fmt.Sprintf("User announced their identity is "+
+				"revoked (reason: %q)", mig.Reason)
+		}
+		return c.db.LogPM(tx, ru.ID(), true, "", msg, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	if mig.NewID == nil {
+		ru.log.Warnf("User revoked their identity (reason: %q)", im.Reason)
+		if !ru.IsIgnored() {
+			if err := c.Ignore(ru.ID(), true); err != nil {
+				return err
+			}
+		}
+		c.ntfns.notifyIdentityMigrationReceived(ru, mig)
+		return nil
+	}
+	c.ntfns.notifyIdentityMigrationReceived(ru, mig)
+
+	ru.log.Infof("User moved to identity %s (%q) (reason: %q)", mig.NewID,
+		im.NewIdentity.Nick, im.Reason)
+	if _, err := c.rul.byID(*mig.NewID); err == nil {
+		// Already KX'd with the new identity. Do not merge two existing
+		// contacts without confirmation from the local user.
+		ru.log.Infof("New identity %s is already a contact. Identity "+
+			"migration needs to be applied manually", mig.NewID)
+		return nil
+	}
+	return c.RequestMediateIdentity(ru.ID(), *mig.NewID)
+}
+
+// ApplyIdentityMigration applies the identity migration announced by oldID
+// to a new identity that was already a contact when the migration was
+// received. These migrations are not applied automatically.
+func (c *Client) ApplyIdentityMigration(oldID UserID) error {
+	var mig clientdb.IdentityMigration
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		mig, err = c.db.GetIdentityMigration(tx, oldID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if mig.NewID == nil {
+		return fmt.Errorf("identity %s was revoked without a new identity",
+			oldID)
+	}
+	if mig.Applied != nil {
+		return fmt.Errorf("identity migration already applied")
+	}
+	newRU, err := c.rul.byID(*mig.NewID)
+	if err != nil {
+		return fmt.Errorf("not KX'd with new identity: %w", err)
+	}
+	return c.applyIdentityMigration(newRU, mig)
+}
+
+// maybeApplyIdentityMigration applies a pending identity migration to the
+// given user, if one exists.
+func (c *Client) maybeApplyIdentityMigration(newRU *RemoteUser) {
+	migs, err := c.ListIdentityMigrations()
+	if err != nil {
+		newRU.log.Warnf("Unable to list identity migrations: %v", err)
+		return
+	}
+	for _, mig := range migs {
+		if mig.NewID == nil || *mig.NewID != newRU.ID() || mig.Applied != nil {
+			continue
+		}
+		if err := c.applyIdentityMigration(newRU, mig); err != nil {
+			newRU.log.Warnf("Unable to apply identity migration from "+
+				"%s: %v", mig.OldID, err)
+		}
+	}
+}
+
+// applyIdentityMigration updates the address book after the KX with the new
+// identity of a migration completed: the new identity takes over the nick of
+// the old one and the old identity is ignored.
+func (c *Client) applyIdentityMigration(newRU *RemoteUser, mig clientdb.IdentityMigration) error {
+	oldRU, err := c.rul.byID(mig.OldID)
+	if err == nil {
+		oldNick := oldRU.Nick()
+		if err := c.RenameUser(oldRU.ID(), oldNick+"-old"); err == nil {
+			if err := c.RenameUser(newRU.ID(), oldNick); err != nil {
+				newRU.log.Warnf("Unable to rename to old nick: %v", err)
+			}
+		}
+		if !oldRU.IsIgnored() {
+			if err := c.Ignore(oldRU.ID(), true); err != nil {
+				return err
+			}
+		}
+	}
+
+	now := time.Now()
+	mig.Applied = &now
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if err := c.db.StoreIdentityMigration(tx, mig); err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("User migrated from old identity %s", mig.OldID)
+		return c.db.LogPM(tx, newRU.ID(), true, "", msg, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	newRU.log.Infof("Applied identity migration from old identity %s",
+		mig.OldID)
+	c.ntfns.notifyIdentityMigrated(mig.OldID, newRU)
+	return nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// maxPrepaidInviteBatch is the max number of prepaid invites that may be
+// created in a single batch.
+const maxPrepaidInviteBatch = 100
+
+// InviteFundsCreator is called to create the funds included in an invite.
+type InviteFundsCreator func(amount dcrutil.Amount) (*rpc.InviteFunds, error)
+
+// PrepaidInviteBatchEntry is one of the invites created by
+// CreatePrepaidInviteBatch.
+type PrepaidInviteBatchEntry struct {
+	// Label is the label of the invite, as passed to
+	// CreatePrepaidInviteBatch.
+	Label string
+
+	// Invite is the created invite and InviteBytes its raw encoding.
+	Invite      rpc.OOBPublicIdentityInvite
+	InviteBytes []byte
+
+	// Key is the key to fetch the invite from the server. EncodedKey is
+	// its string encoding.
+	Key        clientintf.PaidInviteKey
+	EncodedKey string
+
+	// FundAmount is the amount of funds included in the invite.
+	FundAmount dcrutil.Amount
+}
+
+// CreatePrepaidInviteBatch creates one prepaid invite for each of the given
+// labels, pushing all of them to the server.
+//
+// If budget is non-zero, it is split equally among the invites and
+// createFunds is called to create the funds of each invite. If gcID is
+// specified, each invitee is invited to the GC after they complete the KX.
+//
+// If an error happens while creating one of the invites, the invites created
+// so far are returned along with the error.
+func (c *Client) CreatePrepaidInviteBatch(labels []string, budget dcrutil.Amount,
+	createFunds InviteFundsCreator, gcID *zkidentity.ShortID) ([]PrepaidInviteBatchEntry, error) {
+
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no invites to create")
+	}
+	if len(labels) > maxPrepaidInviteBatch {
+		return nil, fmt.Errorf("cannot create more than %d invites in a "+
+			"single batch", maxPrepaidInviteBatch)
+	}
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		if label == "" {
+			return nil, fmt.Errorf("invite label cannot be empty")
+		}
+		if seen[label] {
+			return nil, fmt.Errorf("duplicate invite label %q", label)
+		}
+		seen[label] = true
+	}
+
+	var fundAmount dcrutil.Amount
+	if budget > 0 {
+		if createFunds == nil {
+			return nil, fmt.Errorf("funds creator not specified")
+		}
+		fundAmount = budget / dcrutil.Amount(len(labels))
+		if fundAmount <= 0 {
+			return nil, fmt.Errorf("budget %s is too low to fund %d "+
+				"invites", budget, len(labels))
+		}
+	}
+	if gcID != nil {
+		if _, err := c.GetGC(*gcID); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]PrepaidInviteBatchEntry, 0, len(labels))
+	for _, label := range labels {
+		var funds *rpc.InviteFunds
+		if fundAmount > 0 {
+			var err error
+			funds, err = createFunds(fundAmount)
+			if err != nil {
+				return res, fmt.Errorf("unable to create funds for "+
+					"invite %q: %v", label, err)
+			}
+		}
+
+		var b bytes.Buffer
+		invite, key, err := c.kxl.createPrepaidInvite(&b, funds)
+		if err != nil {
+			return res, fmt.Errorf("unable to create invite %q: %v",
+				label, err)
+		}
+		encKey, err := key.Encode()
+		if err != nil {
+			return res, err
+		}
+		if gcID != nil {
+			err := c.AddInviteOnKX(invite.InitialRendezvous, *gcID)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		res = append(res, PrepaidInviteBatchEntry{
+			Label:       label,
+			Invite:      invite,
+			InviteBytes: b.Bytes(),
+			Key:         key,
+			EncodedKey:  encKey,
+			FundAmount:  fundAmount,
+		})
+	}
+
+	c.log.Infof("Created batch of %d prepaid invites with %s of funds each",
+		len(res), fundAmount)
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/companyzero/bisonrelay/internal/invitetransport"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// InviteTransport is an out of band method of delivering invites.
+type InviteTransport = invitetransport.Transport
+
+// PublishInvite creates a new invite, optionally including funds, and
+// publishes it through the given transport. The returned URL must be sent to
+// the invitee, who may use it to fetch and accept the invite with
+// FetchInviteURL.
+func (c *Client) PublishInvite(ctx context.Context, t InviteTransport,
+	funds *rpc.InviteFunds) (string, rpc.OOBPublicIdentityInvite, error) {
+
+	var b bytes.Buffer
+	invite, err := c.WriteNewInvite(&b, funds)
+	if err != nil {
+		return "", invite, err
+	}
+	u, err := invitetransport.Publish(ctx, t, b.Bytes())
+	if err != nil {
+		return "", invite, err
+	}
+	c.log.Infof("Published invite with initial RV %s using the %s transport",
+		invite.InitialRendezvous, t.Name())
+	return u, invite, nil
+}
+
+// FetchInviteURL fetches an invite published with PublishInvite, using the
+// first of the transports able to fetch the URL. The raw invite is written
+// to w (if not nil), so that it may be stored before being accepted with
+// AcceptInvite.
+func (c *Client) FetchInviteURL(ctx context.Context, transports []InviteTransport,
+	u string, w io.Writer) (rpc.OOBPublicIdentityInvite, error) {
+
+	var invite rpc.OOBPublicIdentityInvite
+	blob, err := invitetransport.Fetch(ctx, transports, u)
+	if err != nil {
+		return invite, err
+	}
+	invite, err = c.ReadInvite(bytes.NewReader(blob))
+	if err != nil {
+		return invite, err
+	}
+	if w != nil {
+		if _, err := w.Write(blob); err != nil {
+			return invite, err
+		}
+	}
+	return invite, nil
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// InvoiceStatus is the status of an invoice.
+type InvoiceStatus = clientdb.InvoiceStatus
+
+// InvoiceDirection is whether an invoice was generated by the local client
+// (to receive a payment) or received from a remote user (to send a payment).
+type InvoiceDirection string
+
+const (
+	InvoiceGenerated InvoiceDirection = "generated"
+	InvoiceReceived  InvoiceDirection = "received"
+)
+
+// Invoice is an invoice generated or received by the local client for a tip.
+type Invoice struct {
+	Invoice    string
+	UID        UserID
+	Direction  InvoiceDirection
+	MilliAtoms int64
+	Created    time.Time
+	Status     InvoiceStatus
+
+	// Label and Memo are the local annotations of the invoice.
+	Label string
+	Memo  string
+}
+
+// listInvoices lists the invoices generated and received for tips.
+func (c *Client) listInvoices(tx clientdb.ReadTx) ([]Invoice, error) {
+	var res []Invoice
+	for _, status := range []InvoiceStatus{clientdb.InvoiceStatusOpen,
+		clientdb.InvoiceStatusSettled, clientdb.InvoiceStatusExpired,
+		clientdb.InvoiceStatusCanceled} {
+
+		invoices, err := c.db.ListTipInvoicesByStatus(tx, status)
+		if err != nil {
+			return nil, err
+		}
+		for _, inv := range invoices {
+			res = append(res, Invoice{
+				Invoice:    inv.Invoice,
+				UID:        inv.UID,
+				Direction:  InvoiceGenerated,
+				MilliAtoms: int64(inv.MilliAtoms),
+				Created:    inv.Created,
+				Status:     status,
+			})
+		}
+	}
+
+	// Invoices received from remote users are the ones of the attempts to
+	// tip them. Only the last invoice of an attempt may still be paid.
+	for _, uid := range c.rul.userList() {
+		attempts, err := c.db.ListTipUserAttempts(tx, uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, ta := range attempts {
+			for _, inv := range ta.PrevInvoices {
+				res = append(res, Invoice{
+					Invoice:    inv,
+					UID:        uid,
+					Direction:  InvoiceReceived,
+					MilliAtoms: int64(ta.MilliAtoms),
+					Created:    ta.Created,
+					Status:     clientdb.InvoiceStatusExpired,
+				})
+			}
+			if ta.LastInvoice == "" {
+				continue
+			}
+			status := clientdb.InvoiceStatusOpen
+			if ta.Completed != nil {
+				status = clientdb.InvoiceStatusSettled
+			}
+			res = append(res, Invoice{
+				Invoice:    ta.LastInvoice,
+				UID:        uid,
+				Direction:  InvoiceReceived,
+				MilliAtoms: int64(ta.MilliAtoms),
+				Created:    ta.Created,
+				Status:     status,
+			})
+		}
+	}
+
+	labels, err := c.db.ListInvoiceLabels(tx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		if label, ok := labels[res[i].Invoice]; ok {
+			res[i].Label = label.Label
+			res[i].Memo = label.Memo
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
+
+// ListInvoices lists the invoices generated by the local client for
+// receiving tips and the invoices received from remote users for sending
+// tips, sorted by creation time. If onlyOpen is true, only the invoices that
+// may still be paid are returned.
+func (c *Client) ListInvoices(onlyOpen bool) ([]Invoice, error) {
+	var res []Invoice
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.listInvoices(tx)
+		return err
+	})
+	if err != nil || !onlyOpen {
+		return res, err
+	}
+
+	open := res[:0]
+	for _, inv := range res {
+		if inv.Status == clientdb.InvoiceStatusOpen {
+			open = append(open, inv)
+		}
+	}
+	return open, nil
+}
+
+// InvoiceStatus returns the invoice with its current settlement status.
+// Returns ErrNotFound if the invoice was not generated or received by the
+// local client.
+func (c *Client) InvoiceStatus(invoice string) (Invoice, error) {
+	invoices, err := c.ListInvoices(false)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	// The same invoice may have been recorded with multiple statuses
+	// (e.g. received invoices that were retried), so prefer the most
+	// recent one.
+	var res *Invoice
+	for i := range invoices {
+		if invoices[i].Invoice == invoice {
+			res = &invoices[i]
+		}
+	}
+	if res == nil {
+		return Invoice{}, fmt.Errorf("invoice: %w", clientdb.ErrNotFound)
+	}
+	return *res, nil
+}
+
+// CancelInvoice cancels an open invoice generated by the local client for
+// receiving a tip, such that it can no longer be paid.
+func (c *Client) CancelInvoice(invoice string) error {
+	inv, err := c.InvoiceStatus(invoice)
+	if err != nil {
+		return err
+	}
+	if inv.Direction != InvoiceGenerated {
+		return errors.New("only invoices generated by the local client " +
+			"can be canceled")
+	}
+	if inv.Status != clientdb.InvoiceStatusOpen {
+		return fmt.Errorf("cannot cancel %s invoice", inv.Status)
+	}
+
+	ipc, ok := c.pc.(clientintf.InvoiceCancelerPaymentClient)
+	if !ok {
+		return errors.New("payment client does not support canceling invoices")
+	}
+
+	// Stop tracking the invoice before canceling it, so that it is not
+	// concurrently marked with a different status. Tracking is restarted
+	// if the invoice cannot be canceled.
+	c.genTipInvoicesMtx.Lock()
+	if cancel := c.genTipInvoicesCancel[invoice]; cancel != nil {
+		cancel()
+	}
+	c.genTipInvoicesMtx.Unlock()
+	if err := ipc.CancelInvoice(c.ctx, invoice); err != nil {
+		go c.trackGeneratedTipInvoice(c.ctx, inv.UID, invoice, inv.MilliAtoms)
+		return err
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.MarkGeneratedTipInvoiceCanceled(tx, inv.UID, invoice)
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		// Settled, expired or canceled while canceling.
+		if inv, err = c.InvoiceStatus(invoice); err != nil {
+			return err
+		}
+		if inv.Status != clientdb.InvoiceStatusCanceled {
+			return fmt.Errorf("invoice was %s before being canceled",
+				inv.Status)
+		}
+	} else if err != nil {
+		return err
+	}
+	c.log.Infof("Canceled invoice for tip of %.8f DCR from %s",
+		float64(inv.MilliAtoms)/1e11, inv.UID)
+	return nil
+}
+
+// SetInvoiceLabel sets the local label and memo of an invoice. Setting both
+// to empty strings removes them.
+func (c *Client) SetInvoiceLabel(invoice, label, memo string) error {
+	if _, err := c.InvoiceStatus(invoice); err != nil {
+		return err
+	}
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetInvoiceLabel(tx, invoice, clientdb.InvoiceLabel{
+			Label:   label,
+			Memo:    memo,
+			Updated: time.Now(),
+		})
+	})
+}
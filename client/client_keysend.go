@@ -0,0 +1,150 @@
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// updateUserLNNode records the LN node of the remote user. The node is learned
+// from the invoices sent by the user.
+func (c *Client) updateUserLNNode(ru *RemoteUser, node string) {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		oldNode, err := c.db.LNNodeForUser(tx, ru.ID())
+		if err != nil || oldNode == node {
+			return err
+		}
+		return c.db.UpdateLNNodeForUser(tx, ru.ID(), node)
+	})
+	if err != nil {
+		ru.log.Warnf("Unable to update LN node of user: %v", err)
+	}
+}
+
+// UserLNNode returns the hex-encoded pubkey of the LN node of the user or an
+// empty string if the node is not known yet.
+func (c *Client) UserLNNode(uid UserID) (string, error) {
+	var node string
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		node, err = c.db.LNNodeForUser(tx, uid)
+		return err
+	})
+	return node, err
+}
+
+// tipUserKeysend attempts to tip the user through a keysend payment to their
+// LN node. This is only attempted for tips up to TipUserKeysendMaxMAtoms and
+// when the node of the user is known. It returns true if the tip was paid.
+func (c *Client) tipUserKeysend(ru *RemoteUser, milliAmt uint64, gc *zkidentity.ShortID,
+	fiat *rpc.RMFiatConversion, budgetOverride bool) bool {
+	maxMAtoms := c.cfg.TipUserKeysendMaxMAtoms
+	if maxMAtoms <= 0 || milliAmt > uint64(maxMAtoms) {
+		return false
+	}
+	kpc, ok := c.pc.(clientintf.KeysendPaymentClient)
+	if !ok {
+		return false
+	}
+	node, err := c.UserLNNode(ru.ID())
+	if err != nil {
+		ru.log.Warnf("Unable to load LN node of user: %v", err)
+		return false
+	}
+	if node == "" {
+		return false
+	}
+
+	spendID, err := c.reservePaymentSpend(ru.ID(), gc, int64(milliAmt),
+		budgetOverride)
+	if err != nil {
+		ru.log.Infof("Unable to tip through keysend payment: %v", err)
+		return false
+	}
+	hash, fees, err := kpc.PayKeysend(c.ctx, node, int64(milliAmt))
+	if err != nil {
+		c.releasePaymentSpend(spendID)
+		ru.log.Infof("Unable to tip through keysend payment: %v. "+
+			"Falling back to requesting an invoice", err)
+		return false
+	}
+
+	ru.log.Infof("Sent %.8f DCR as keysend tip", float64(milliAmt)/1e11)
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		// Amount is negative because we're making a payment.
+		return c.db.RecordUserPayEvent(tx, ru.ID(), "paytip",
+			-int64(milliAmt), -fees)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to record keysend tip payment: %v", err)
+	}
+
+	// Let the remote user know who sent the payment.
+	rm := rpc.RMKeysendTip{PaymentHash: hash, MilliAtoms: milliAmt}
+	if err := c.sendWithSendQ("keysendtip", rm, ru.ID()); err != nil {
+		ru.log.Errorf("Unable to send keysend tip notification: %v", err)
+	}
+	c.sendPaymentReceipt(ru, rpc.PaymentReceiptKindTip, int64(milliAmt), hash,
+		gc, fiat)
+
+	c.ntfns.notifyTipAttemptProgress(ru, TipAttemptProgress{
+		AmtMAtoms:   int64(milliAmt),
+		Completed:   true,
+		Attempt:     1,
+		MaxAttempts: 1,
+		Destination: node,
+		FeesMAtoms:  fees,
+		Keysend:     true,
+	})
+	return true
+}
+
+// handleKeysendTip handles a notification from a remote user that a tip was
+// sent as a keysend payment to the local node.
+func (c *Client) handleKeysendTip(ru *RemoteUser, tip rpc.RMKeysendTip) error {
+	kpc, ok := c.pc.(clientintf.KeysendPaymentClient)
+	if !ok {
+		return fmt.Errorf("payment client does not support keysend payments")
+	}
+
+	// Use the amount actually received instead of the one in the message.
+	receivedMAtoms, err := kpc.KeysendReceived(c.ctx, tip.PaymentHash)
+	if err != nil {
+		return err
+	}
+	if receivedMAtoms <= 0 {
+		return fmt.Errorf("keysend payment %x did not pay any amount",
+			tip.PaymentHash)
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		err := c.db.RecordReceivedKeysendTip(tx, ru.ID(), tip.PaymentHash,
+			receivedMAtoms)
+		if err != nil {
+			return err
+		}
+		return c.db.RecordUserPayEvent(tx, ru.ID(), "tip", receivedMAtoms, 0)
+	})
+	if errors.Is(err, clientdb.ErrAlreadyExists) {
+		ru.log.Warnf("Ignoring already received keysend tip %x",
+			tip.PaymentHash)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Received %f DCR as keysend tip", float64(receivedMAtoms)/1e11)
+	c.ntfns.notifyTipReceived(ru, receivedMAtoms)
+	c.firePaymentEvent(ru, PaymentEvent{
+		Type:        PaymentEventTipReceived,
+		MilliAtoms:  receivedMAtoms,
+		PaymentHash: hex.EncodeToString(tip.PaymentHash),
+	})
+	return nil
+}
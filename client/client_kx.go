@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client/clientdb"
@@ -90,6 +91,18 @@ func (c *Client) takePostKXAction(ru *RemoteUser, act clientdb.PostKXAction) err
 		}
 
 		return c.InviteToGroupChat(gcID, ru.ID())
+
+	case clientdb.PKXActionRedeemGCLink:
+		// Request to join the GC of the invite link.
+		link, err := ParseGCInviteLink(act.Data)
+		if err != nil {
+			return err
+		}
+		if link.Issuer != ru.ID() {
+			return fmt.Errorf("invite link not issued by %s", ru.ID())
+		}
+		return c.sendGCJoinLink(ru, link)
+
 	default:
 		return fmt.Errorf("unknown post-kx action type")
 	}
@@ -121,17 +134,20 @@ func (c *Client) takePostKXActions(ru *RemoteUser, actions []clientdb.PostKXActi
 // returns whether this is a new user.
 func (c *Client) initRemoteUser(id *zkidentity.PublicIdentity, r *ratchet.Ratchet,
 	updateAB bool, initialRV, myResetRV, theirResetRV clientdb.RawRVID,
-	ignored bool, nickAlias string) (*RemoteUser, bool, error) {
+	ignored bool, nickAlias string, rvsUnsubbed bool) (*RemoteUser, bool, error) {
 
 	var postKXActions []clientdb.PostKXAction
 
 	// Track the new user.
 	ru := newRemoteUser(c.q, c.rmgr, c.db, id, c.localID.signMessage, r)
 	ru.ignored = ignored
+	ru.rvsUnsubbed = rvsUnsubbed
 	ru.compressLevel = c.cfg.CompressLevel
 	ru.log = c.cfg.logger(fmt.Sprintf("RUSR %x", id.Identity[:8]))
 	ru.logPayloads = c.cfg.logger(fmt.Sprintf("RMPL %x", id.Identity[:8]))
 	ru.rmHandler = c.handleUserRM
+	ru.msgStatusHandler = c.handleMsgStatus
+	ru.decryptFailedHandler = c.handleDecryptFailed
 	ru.myResetRV = myResetRV
 	ru.theirResetRV = theirResetRV
 	if nickAlias != "" {
@@ -166,6 +182,7 @@ func (c *Client) initRemoteUser(id *zkidentity.PublicIdentity, r *ratchet.Ratche
 	// Save the newly formed address book entry to the DB.
 	var oldEntry *clientdb.AddressBookEntry
 	hadKXSearch := false
+	verifiedKeyChanged := false
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
 		oldEntry, err = c.db.GetAddressBookEntry(tx, id.Identity)
@@ -173,6 +190,14 @@ func (c *Client) initRemoteUser(id *zkidentity.PublicIdentity, r *ratchet.Ratche
 			return err
 		}
 
+		// Check if the keys of a verified user changed.
+		if updateAB && oldEntry != nil {
+			verifiedKeyChanged, err = c.checkVerifiedKeyChanged(tx, id)
+			if err != nil {
+				return err
+			}
+		}
+
 		if err := c.db.UpdateRatchet(tx, r, id.Identity); err != nil {
 			return err
 		}
@@ -292,6 +317,12 @@ func (c *Client) initRemoteUser(id *zkidentity.PublicIdentity, r *ratchet.Ratche
 		c.ntfns.notifyOnKXSearchCompleted(ru)
 	}
 
+	if verifiedKeyChanged {
+		ru.log.Warnf("Keys of verified user changed after KX. Safety " +
+			"number must be verified again")
+		c.ntfns.notifyVerifiedKeyChanged(ru)
+	}
+
 	return ru, !oldUser, nil
 }
 
@@ -299,13 +330,17 @@ func (c *Client) kxCompleted(public *zkidentity.PublicIdentity, r *ratchet.Ratch
 	initialRV, myResetRV, theirResetRV clientdb.RawRVID) {
 
 	ru, isNew, err := c.initRemoteUser(public, r, true, initialRV, myResetRV,
-		theirResetRV, false, "")
+		theirResetRV, false, "", false)
 	if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
 		c.log.Errorf("unable to init user for completed kx: %v", err)
 	}
 
 	if err == nil {
 		c.ntfns.notifyOnKXCompleted(&initialRV, ru, isNew)
+		if isNew {
+			c.maybeApplyIdentityMigration(ru)
+			c.syncDevicesContact(ru)
+		}
 	}
 }
 
@@ -378,7 +413,24 @@ func (c *Client) ResetRatchet(uid UserID) error {
 
 	ru.log.Infof("Initiating reset via RV %s", resetRV)
 
-	return c.kxl.requestReset(resetRV, userPub)
+	err = c.kxl.requestReset(resetRV, userPub)
+	if err != nil {
+		return err
+	}
+	c.ntfns.notifyRatchetResetRequested(ru, false)
+	return nil
+}
+
+// UserUnackedRMsCount returns the number of RMs encrypted for the given user
+// that have not yet been acked by the server.
+func (c *Client) UserUnackedRMsCount(uid UserID) (int, error) {
+	var count int
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		count, err = c.db.CountUserUnackedRMs(tx, uid)
+		return err
+	})
+	return count, err
 }
 
 // ResetAllOldRatchets starts the reset ratchet procedure with all users from
@@ -632,6 +684,29 @@ func (c *Client) handleKXSuggestion(ru *RemoteUser, kxsg rpc.RMKXSuggestion) err
 	ru.log.Infof("Received suggestion to KX with %s %s (%q)", known,
 		kxsg.Target.Identity, targetNick)
 
+	// Apply the auto KX policy to suggestions of unknown users.
+	if targetRu == nil {
+		policy := c.autoKXPolicy().ForContact(ru.ID())
+		switch policy.Suggestions {
+		case clientdb.AutoKXActionReject:
+			ru.log.Infof("Ignoring suggestion to KX with %s due to "+
+				"auto KX policy", kxsg.Target.Identity)
+			return nil
+
+		case clientdb.AutoKXActionAccept:
+			err := c.maybeRequestMediateID(ru.ID(), kxsg.Target.Identity)
+			if err == nil {
+				ru.log.Infof("Accepted suggestion to KX with %s "+
+					"due to auto KX policy", kxsg.Target.Identity)
+				return nil
+			}
+
+			// Fallback to prompting the user.
+			ru.log.Warnf("Unable to automatically accept suggestion "+
+				"to KX with %s: %v", kxsg.Target.Identity, err)
+		}
+	}
+
 	if c.cfg.KXSuggestion != nil {
 		c.cfg.KXSuggestion(ru, kxsg.Target)
 	}
@@ -722,6 +797,83 @@ func (c *Client) handshakeIdleUsers(limitInterval time.Duration) error {
 	return nil
 }
 
+// idleUsersIgnoreList returns the set of users that should not be acted upon
+// when they are idle.
+func (c *Client) idleUsersIgnoreList() map[clientintf.UserID]struct{} {
+	ignoreList := make(map[clientintf.UserID]struct{}, len(c.cfg.AutoRemoveIdleUsersIgnoreList))
+	for _, nick := range c.cfg.AutoRemoveIdleUsersIgnoreList {
+		uid, err := c.UIDByNick(nick)
+		if err == nil {
+			ignoreList[uid] = struct{}{}
+		} else {
+			c.log.Warnf("User %q in list to ignore from auto remove "+
+				"not found", nick)
+		}
+	}
+	return ignoreList
+}
+
+// inIdleUsersIgnoreList returns true if the user with the given id and nick
+// is in the list of users that should not be acted upon when idle. This
+// matches entries in the same way as UserByNick() and is safe to call before
+// the address book is loaded.
+func (c *Client) inIdleUsersIgnoreList(uid UserID, nick string) bool {
+	suid := uid.String()
+	for _, entry := range c.cfg.AutoRemoveIdleUsersIgnoreList {
+		if strings.EqualFold(nick, entry) || len(entry) > 4 && strings.HasPrefix(suid, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdleForRVs returns true if no message was exchanged with an user since the
+// limit date, such that the client may stop listening for their messages.
+func isIdleForRVs(lastEncTime, lastDecTime, firstCreated, limitDate time.Time) bool {
+	return lastEncTime.Before(limitDate) && lastDecTime.Before(limitDate) &&
+		!firstCreated.IsZero() && firstCreated.Before(limitDate)
+}
+
+// unsubIdleUsersRVs stops listening for messages from users with which no
+// message has been exchanged in the configured interval. Listening is resumed
+// once a message is sent to the user.
+func (c *Client) unsubIdleUsersRVs() {
+	<-c.abLoaded
+
+	if c.cfg.AutoUnsubIdleUsersRVsInterval == 0 {
+		return
+	}
+	limitDate := time.Now().Add(-c.cfg.AutoUnsubIdleUsersRVsInterval)
+	ignoreList := c.idleUsersIgnoreList()
+
+	var nb int
+	for _, uid := range c.rul.userList() {
+		if _, ok := ignoreList[uid]; ok {
+			continue
+		}
+		ru, err := c.rul.byID(uid)
+		if err != nil || ru.RVsUnsubscribed() {
+			continue
+		}
+		ab, err := c.getAddressBookEntry(uid)
+		if err != nil {
+			continue
+		}
+		lastEncTime, lastDecTime := ru.LastRatchetTimes()
+		if !isIdleForRVs(lastEncTime, lastDecTime, ab.FirstCreated, limitDate) {
+			continue
+		}
+		ru.log.Debugf("Unsubscribing from RVs of idle user (last "+
+			"encrypted msg %s, last decrypted msg %s)",
+			lastEncTime.Format(time.RFC3339), lastDecTime.Format(time.RFC3339))
+		ru.setRVsUnsubscribed(true)
+		nb += 1
+	}
+	if nb > 0 {
+		c.log.Infof("Stopped listening for messages from %d idle users", nb)
+	}
+}
+
 // unsubIdleUsers forcibly unsubscribes and removes from GCs the local client
 // admins any users from which no messages have been received since
 // limitInterval and from which the last handshake attempt was made at least
@@ -738,6 +890,9 @@ func (c *Client) unsubIdleUsers(limitInterval, lastHandshakeInterval time.Durati
 		}
 	}
 	limitDate := time.Now().Add(-limitInterval)
+	if lastHandshakeInterval == 0 {
+		lastHandshakeInterval = c.cfg.AutoHandshakeTimeout
+	}
 	if lastHandshakeInterval == 0 {
 		lastHandshakeInterval = c.cfg.AutoHandshakeInterval / 2
 	}
@@ -751,7 +906,7 @@ func (c *Client) unsubIdleUsers(limitInterval, lastHandshakeInterval time.Durati
 	adminGCs := make([]*rpc.RMGroupList, 0, len(gcs))
 	for i := range gcs {
 		gc := gcs[i]
-		if err := c.uidHasGCPerm(gc, c.PublicID()); err != nil {
+		if err := c.uidHasGCPermission(&gc, c.PublicID(), rpc.GCPermKick); err != nil {
 			// Cannot admin this GC.
 			continue
 		}
@@ -762,17 +917,7 @@ func (c *Client) unsubIdleUsers(limitInterval, lastHandshakeInterval time.Durati
 		"and limitHandshakeDate %s", limitDate.Format(time.RFC3339),
 		limitHandshakeDate.Format(time.RFC3339))
 
-	// Build ignore list.
-	ignoreList := make(map[clientintf.UserID]struct{}, len(c.cfg.AutoRemoveIdleUsersIgnoreList))
-	for _, nick := range c.cfg.AutoRemoveIdleUsersIgnoreList {
-		uid, err := c.UIDByNick(nick)
-		if err == nil {
-			ignoreList[uid] = struct{}{}
-		} else {
-			c.log.Warnf("User %q in list to ignore from auto remove "+
-				"not found", nick)
-		}
-	}
+	ignoreList := c.idleUsersIgnoreList()
 
 	users := c.rul.userList()
 	for _, uid := range users {
@@ -853,16 +998,62 @@ func (c *Client) unsubIdleUsers(limitInterval, lastHandshakeInterval time.Durati
 	return nil
 }
 
+// LocalProfileUpdate lists the local profile fields to update.
+type LocalProfileUpdate struct {
+	// Name is the new display name. If nil, the name is not updated.
+	Name *string
+
+	// Avatar is the new avatar. If nil, the avatar is not updated. If set
+	// to an empty slice, the avatar is cleared.
+	Avatar []byte
+
+	// Description is the new profile description. If nil, the description
+	// is not updated. If set to an empty string, the description is
+	// cleared.
+	Description *string
+
+	// Status is the new status line. If nil, the status is not updated. If
+	// set to an empty string, the status is cleared.
+	Status *string
+}
+
 // UpdateLocalAvatar changes the local avatar. If set to nil or an empty slice,
 // an update will be sent to remote clients to clear the avatar.
 func (c *Client) UpdateLocalAvatar(avatar []byte) error {
+	if avatar == nil {
+		avatar = []byte{}
+	}
+	return c.UpdateLocalProfile(LocalProfileUpdate{Avatar: avatar})
+}
+
+// UpdateLocalProfile changes the specified fields of the local profile and
+// sends an update to all remote users.
+func (c *Client) UpdateLocalProfile(update LocalProfileUpdate) error {
 	// Restrict max size of avatar stored by default to ensure
 	// OOBPublicIdentityInvite is less than the max msg size and can
 	// flow through a single server message.
-	maxAvatarSize := 200 * 1024 // 200KiB
-	if len(avatar) > maxAvatarSize {
+	if len(update.Avatar) > rpc.MaxAvatarSize {
 		return fmt.Errorf("avatar byte size %d > max avatar size %d",
-			len(avatar), maxAvatarSize)
+			len(update.Avatar), rpc.MaxAvatarSize)
+	}
+	if update.Name != nil && len(*update.Name) > rpc.MaxNameSize {
+		return fmt.Errorf("name size %d > max name size %d",
+			len(*update.Name), rpc.MaxNameSize)
+	}
+	if update.Description != nil && len(*update.Description) > rpc.MaxDescriptionSize {
+		return fmt.Errorf("description size %d > max description size %d",
+			len(*update.Description), rpc.MaxDescriptionSize)
+	}
+	if update.Status != nil && len(*update.Status) > rpc.MaxStatusSize {
+		return fmt.Errorf("status size %d > max status size %d",
+			len(*update.Status), rpc.MaxStatusSize)
+	}
+	if update.Name != nil && *update.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if update.Name == nil && update.Avatar == nil && update.Description == nil &&
+		update.Status == nil {
+		return fmt.Errorf("no profile fields to update")
 	}
 
 	// Update the DB.
@@ -871,10 +1062,19 @@ func (c *Client) UpdateLocalAvatar(avatar []byte) error {
 		if err != nil {
 			return err
 		}
-		if len(avatar) == 0 {
+		if update.Name != nil {
+			id.Public.Name = *update.Name
+		}
+		if len(update.Avatar) > 0 {
+			id.Public.Avatar = update.Avatar
+		} else if update.Avatar != nil {
 			id.Public.Avatar = nil
-		} else {
-			id.Public.Avatar = avatar
+		}
+		if update.Description != nil {
+			id.Public.Description = *update.Description
+		}
+		if update.Status != nil {
+			id.Public.Status = *update.Status
 		}
 		return c.db.UpdateLocalID(tx, id)
 	})
@@ -884,18 +1084,31 @@ func (c *Client) UpdateLocalAvatar(avatar []byte) error {
 
 	// Update runtime.
 	c.profileMtx.Lock()
-	c.profile.avatar = avatar
+	if update.Name != nil {
+		c.profile.name = *update.Name
+	}
+	if len(update.Avatar) > 0 {
+		c.profile.avatar = update.Avatar
+	} else if update.Avatar != nil {
+		c.profile.avatar = nil
+	}
+	if update.Description != nil {
+		c.profile.description = *update.Description
+	}
+	if update.Status != nil {
+		c.profile.status = *update.Status
+	}
 	c.profileMtx.Unlock()
 
-	// Let everyone know the avatar has been updated.
-	if avatar == nil {
-		avatar = []byte{}
-	}
+	// Let everyone know the profile has been updated.
 	rmpu := rpc.RMProfileUpdate{
-		Avatar: avatar,
+		Avatar:      update.Avatar,
+		Name:        update.Name,
+		Description: update.Description,
+		Status:      update.Status,
 	}
 	allUsers := c.rul.userList()
-	payType := "profile.avatar"
+	payType := "profile"
 	return c.sendWithSendQ(payType, rmpu, allUsers...)
 }
 
@@ -906,10 +1119,35 @@ func (c *Client) handleProfileUpdate(ru *RemoteUser, rmpu rpc.RMProfileUpdate) e
 	if rmpu.Avatar != nil {
 		fields = append(fields, ProfileUpdateAvatar)
 	}
+	if rmpu.Name != nil {
+		fields = append(fields, ProfileUpdateName)
+	}
+	if rmpu.Description != nil {
+		fields = append(fields, ProfileUpdateDescription)
+	}
+	if rmpu.Status != nil {
+		fields = append(fields, ProfileUpdateStatus)
+	}
 
 	if len(fields) == 0 {
 		return fmt.Errorf("profile update message without any updates")
 	}
+	if len(rmpu.Avatar) > rpc.MaxAvatarSize {
+		return fmt.Errorf("avatar byte size %d > max avatar size %d",
+			len(rmpu.Avatar), rpc.MaxAvatarSize)
+	}
+	if rmpu.Name != nil && len(*rmpu.Name) > rpc.MaxNameSize {
+		return fmt.Errorf("name size %d > max name size %d",
+			len(*rmpu.Name), rpc.MaxNameSize)
+	}
+	if rmpu.Description != nil && len(*rmpu.Description) > rpc.MaxDescriptionSize {
+		return fmt.Errorf("description size %d > max description size %d",
+			len(*rmpu.Description), rpc.MaxDescriptionSize)
+	}
+	if rmpu.Status != nil && len(*rmpu.Status) > rpc.MaxStatusSize {
+		return fmt.Errorf("status size %d > max status size %d",
+			len(*rmpu.Status), rpc.MaxStatusSize)
+	}
 
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
@@ -926,6 +1164,15 @@ func (c *Client) handleProfileUpdate(ru *RemoteUser, rmpu rpc.RMProfileUpdate) e
 				ab.ID.Avatar = rmpu.Avatar
 			}
 		}
+		if rmpu.Name != nil {
+			ab.ID.Name = *rmpu.Name
+		}
+		if rmpu.Description != nil {
+			ab.ID.Description = *rmpu.Description
+		}
+		if rmpu.Status != nil {
+			ab.ID.Status = *rmpu.Status
+		}
 
 		return c.db.UpdateAddressBookEntry(tx, ab)
 	})
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/internal/linkpreview"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+)
+
+// maxLinkPreviews is the max number of link previews generated for a single
+// message.
+const maxLinkPreviews = 3
+
+// LinkPreview is the preview of a link sent in a PM or GC message.
+type LinkPreview = linkpreview.Preview
+
+// linkPreviewHTTPClient returns the http client used to fetch link previews.
+func (c *Client) linkPreviewHTTPClient() *http.Client {
+	dialFunc := c.cfg.LinkPreviewDialFunc
+	if dialFunc == nil {
+		var d net.Dialer
+		dialFunc = d.DialContext
+	}
+	return &http.Client{
+		// Proxy settings from the environment are not used: all
+		// connections go through the configured dial function.
+		Transport: &http.Transport{DialContext: dialFunc},
+		Timeout:   c.cfg.LinkPreviewTimeout,
+	}
+}
+
+// addLinkPreviews returns the message with the previews of its links appended
+// as embeds. This is a no-op if link previews are disabled. Links for which a
+// preview cannot be fetched are skipped.
+func (c *Client) addLinkPreviews(msg string) string {
+	if !c.cfg.LinkPreviews {
+		return msg
+	}
+
+	// Ignore any text that is inside embeds.
+	var hasPreviews bool
+	text := mdembeds.ReplaceEmbeds(msg, func(args mdembeds.EmbeddedArgs) string {
+		hasPreviews = hasPreviews || args.Typ == linkpreview.MimeType
+		return " "
+	})
+	if hasPreviews {
+		return msg
+	}
+	urls := linkpreview.FindURLs(text)
+	if len(urls) == 0 {
+		return msg
+	}
+	if len(urls) > maxLinkPreviews {
+		urls = urls[:maxLinkPreviews]
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.LinkPreviewTimeout)
+	defer cancel()
+	httpClient := c.linkPreviewHTTPClient()
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, u := range urls {
+		p, err := linkpreview.Fetch(ctx, httpClient, u)
+		if err != nil {
+			c.log.Debugf("Unable to fetch preview of %s: %v", u, err)
+			continue
+		}
+		data, err := p.Encode()
+		if err != nil {
+			c.log.Warnf("Unable to encode preview of %s: %v", u, err)
+			continue
+		}
+		args := mdembeds.EmbeddedArgs{
+			Typ:  linkpreview.MimeType,
+			Data: data,
+		}
+		b.WriteString("\n")
+		b.WriteString(args.String())
+	}
+	return b.String()
+}
+
+// ParseLinkPreviews returns the link previews embedded in a PM or GC message.
+func ParseLinkPreviews(msg string) []*LinkPreview {
+	var res []*LinkPreview
+	for _, idx := range mdembeds.FindAllStringIndex(msg) {
+		args := mdembeds.ParseEmbedArgs(msg[idx[0]:idx[1]])
+		if args.Typ != linkpreview.MimeType {
+			continue
+		}
+		p, err := linkpreview.Decode(args.Data)
+		if err != nil {
+			continue
+		}
+		res = append(res, p)
+	}
+	return res
+}
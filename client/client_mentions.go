@@ -0,0 +1,68 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// mentionRegexp matches @nick mentions in messages.
+var mentionRegexp = regexp.MustCompile(`(?:^|\s)@([^\s@]+)`)
+
+// parseMentions returns the list of nicks mentioned (with @nick) in msg.
+func parseMentions(msg string) []string {
+	matches := mentionRegexp.FindAllStringSubmatch(msg, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	res := make([]string, 0, len(matches))
+	for _, m := range matches {
+		// Ignore trailing punctuation (e.g. "@alice, hi").
+		nick := strings.TrimRight(m[1], ".,:;!?")
+		if nick != "" && !slices.Contains(res, nick) {
+			res = append(res, nick)
+		}
+	}
+	return res
+}
+
+// gcMentions returns the list of GC members mentioned in msg. Members are
+// matched by their local nick or alias.
+func (c *Client) gcMentions(members []zkidentity.ShortID, msg string) []zkidentity.ShortID {
+	nicks := parseMentions(msg)
+	if len(nicks) == 0 {
+		return nil
+	}
+
+	me := c.PublicID()
+	var res []zkidentity.ShortID
+	for _, uid := range members {
+		if uid == me {
+			continue
+		}
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			continue
+		}
+		nick := ru.Nick()
+		if slices.IndexFunc(nicks, func(s string) bool { return strings.EqualFold(s, nick) }) > -1 {
+			res = append(res, uid)
+		}
+	}
+	return res
+}
+
+// isMentioned returns true if the local client is mentioned in the GC message.
+// Besides the list of mentions filled by the sender, the message is also
+// checked for the local nick, which handles messages sent by older clients.
+func (c *Client) isMentioned(gcm rpc.RMGroupMessage) bool {
+	if slices.Contains(gcm.Mentions, c.PublicID()) {
+		return true
+	}
+	myNick := c.LocalNick()
+	nicks := parseMentions(gcm.Message)
+	return slices.IndexFunc(nicks, func(s string) bool { return strings.EqualFold(s, myNick) }) > -1
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// chatTarget is the destination of a message that may be sent either to a
+// remote user or to a GC.
+type chatTarget struct {
+	ru      *RemoteUser
+	gcID    *zkidentity.ShortID
+	members []zkidentity.ShortID
+}
+
+// resolveChatTarget determines whether target is the ID of a GC or of a remote
+// user.
+func (c *Client) resolveChatTarget(target zkidentity.ShortID) (chatTarget, error) {
+	var res chatTarget
+	var gc rpc.RMGroupList
+	var gcBlockList clientdb.GCBlockList
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		gc, err = c.db.GetGC(tx, target)
+		if err != nil {
+			return err
+		}
+		gcBlockList, err = c.db.GetGCBlockList(tx, target)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		// Not a GC, so it must be a user.
+		res.ru, err = c.rul.byID(target)
+		return res, err
+	}
+	if err != nil {
+		return res, err
+	}
+
+	res.gcID = &target
+	res.members = gcBlockList.FilterMembers(gc.Members)
+	return res, nil
+}
+
+// sendToChatTarget sends the given msg to the chat target.
+func (c *Client) sendToChatTarget(ct chatTarget, payType string, msg interface{}) error {
+	if ct.ru != nil {
+		return ct.ru.sendRMPriority(msg, payType, priorityPM)
+	}
+	if len(ct.members) == 0 {
+		return nil
+	}
+	return c.sendToGCMembers(*ct.gcID, ct.members, payType, msg, nil)
+}
+
+// checkGCSender ensures the remote user is a member of the given GC and that
+// it is not blocked in it. It returns nil if gcID is nil.
+func (c *Client) checkGCSender(tx clientdb.ReadTx, gcID *zkidentity.ShortID, ru *RemoteUser) error {
+	if gcID == nil {
+		return nil
+	}
+	gc, err := c.db.GetGC(tx, *gcID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(gc.Members, ru.ID()) {
+		return fmt.Errorf("user is not a member of GC %s", gcID)
+	}
+	gcBlockList, err := c.db.GetGCBlockList(tx, *gcID)
+	if err != nil {
+		return err
+	}
+	if gcBlockList.IsBlocked(ru.ID()) {
+		return fmt.Errorf("user is blocked in GC %s", gcID)
+	}
+	return nil
+}
+
+// EditMessage replaces the contents of a PM or GC message previously sent by
+// the local client. target is either the ID of the user the PM was sent to or
+// the ID of the GC the message was sent to. msgID is the ID returned when the
+// message was sent.
+func (c *Client) EditMessage(target, msgID zkidentity.ShortID, newMsg string) error {
+	if newMsg == "" {
+		return fmt.Errorf("cannot edit message to an empty message")
+	}
+
+	ct, err := c.resolveChatTarget(target)
+	if err != nil {
+		return err
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, err := c.db.StoreMessageEdit(tx, c.PublicID(), ct.gcID,
+			msgID, newMsg, time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	rm := rpc.RMEditMessage{MsgID: msgID, GC: ct.gcID, Message: newMsg}
+	return c.sendToChatTarget(ct, "editmsg", rm)
+}
+
+// MessageEdits returns the edit history of the message with the given ID,
+// sent by the specified user (which may be the local client).
+func (c *Client) MessageEdits(from UserID, msgID zkidentity.ShortID) (*clientdb.MessageEdits, error) {
+	var edits *clientdb.MessageEdits
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		edits, err = c.db.ReadMessageEdits(tx, from, msgID)
+		return err
+	})
+	return edits, err
+}
+
+func (c *Client) handleEditMessage(ru *RemoteUser, rmem rpc.RMEditMessage, ts time.Time) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if err := c.checkGCSender(tx, rmem.GC, ru); err != nil {
+			return err
+		}
+
+		_, err := c.db.StoreMessageEdit(tx, ru.ID(), rmem.GC, rmem.MsgID,
+			rmem.Message, ts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if rmem.GC != nil {
+		if filter, _ := c.FilterGCM(ru.ID(), *rmem.GC, rmem.Message); filter {
+			return nil
+		}
+	} else if filter, _ := c.FilterPM(ru.ID(), rmem.Message); filter {
+		return nil
+	}
+
+	ru.log.Debugf("Received edit of message %s", rmem.MsgID)
+	c.ntfns.notifyMsgEdited(ru, rmem, ts)
+	return nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// ReactToMessage adds (or removes, if remove is true) a reaction to a PM or GC
+// message. target is either the ID of the user with which the PM was
+// exchanged or the ID of the GC where the message was sent.
+func (c *Client) ReactToMessage(target, msgID zkidentity.ShortID, reaction string, remove bool) error {
+	if reaction == "" {
+		return fmt.Errorf("reaction cannot be empty")
+	}
+	if len(reaction) > rpc.MaxReactionLen {
+		return fmt.Errorf("reaction is longer than the max %d bytes",
+			rpc.MaxReactionLen)
+	}
+
+	ct, err := c.resolveChatTarget(target)
+	if err != nil {
+		return err
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, err := c.db.StoreMessageReaction(tx, c.PublicID(), ct.gcID,
+			msgID, reaction, remove)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	rm := rpc.RMMessageReaction{
+		MsgID:    msgID,
+		GC:       ct.gcID,
+		Reaction: reaction,
+		Remove:   remove,
+	}
+	return c.sendToChatTarget(ct, "msgreaction", rm)
+}
+
+// MessageReactions returns the aggregated reactions to the message with the
+// given ID.
+func (c *Client) MessageReactions(msgID zkidentity.ShortID) (*clientdb.MessageReactions, error) {
+	var reactions *clientdb.MessageReactions
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		reactions, err = c.db.ReadMessageReactions(tx, msgID)
+		return err
+	})
+	return reactions, err
+}
+
+func (c *Client) handleMessageReaction(ru *RemoteUser, rmmr rpc.RMMessageReaction, ts time.Time) error {
+	if rmmr.Reaction == "" || len(rmmr.Reaction) > rpc.MaxReactionLen {
+		return fmt.Errorf("invalid reaction length %d", len(rmmr.Reaction))
+	}
+
+	var reactions *clientdb.MessageReactions
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if err := c.checkGCSender(tx, rmmr.GC, ru); err != nil {
+			return err
+		}
+
+		var err error
+		reactions, err = c.db.StoreMessageReaction(tx, ru.ID(), rmmr.GC,
+			rmmr.MsgID, rmmr.Reaction, rmmr.Remove)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	ru.log.Debugf("Received reaction %q to message %s (remove %v)",
+		rmmr.Reaction, rmmr.MsgID, rmmr.Remove)
+	c.ntfns.notifyMsgReaction(ru, rmmr, reactions, ts)
+	return nil
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// SearchMessages searches the logged PMs and GC messages for messages that
+// contain all the terms of the query (case insensitive). The text of embedded
+// content (file names, alt text and embedded text files) is also searched.
+//
+// Only messages logged while the message logs are enabled are searchable.
+func (c *Client) SearchMessages(query string, filter clientdb.MsgSearchFilter) ([]clientdb.MsgSearchResult, error) {
+	var res []clientdb.MsgSearchResult
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.SearchMessages(tx, query, filter)
+		return err
+	})
+	return res, err
+}
+
+// RebuildMsgSearchIndex rebuilds the message search index from the existing
+// message logs. This may be used to index messages logged before the search
+// index existed. Returns the number of indexed messages.
+func (c *Client) RebuildMsgSearchIndex() (int, error) {
+	var count int
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		count, err = c.db.RebuildMsgSearchIndex(tx)
+		return err
+	})
+	if err == nil {
+		c.log.Infof("Rebuilt message search index with %d messages", count)
+	}
+	return count, err
+}
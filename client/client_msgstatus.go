@@ -0,0 +1,102 @@
+package client
+
+import (
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// MsgDeliveryStatus is the delivery status of a chat message sent to a remote
+// user.
+type MsgDeliveryStatus int
+
+const (
+	// MsgStatusQueued is the status of a message that was queued to be
+	// sent to the server.
+	MsgStatusQueued MsgDeliveryStatus = iota
+
+	// MsgStatusPaid is the status of a message for which the push payment
+	// to the server was completed.
+	MsgStatusPaid
+
+	// MsgStatusSent is the status of a message that was written to the
+	// server.
+	MsgStatusSent
+
+	// MsgStatusAcked is the status of a message that was acked by the
+	// server.
+	MsgStatusAcked
+
+	// MsgStatusDelivered is the status of a message for which the remote
+	// user sent back a receive receipt. This is only sent by remote users
+	// that have receive receipts enabled.
+	MsgStatusDelivered
+
+	// MsgStatusFailed is the status of a message that failed to be sent
+	// (for example, because it was canceled).
+	MsgStatusFailed
+)
+
+func (s MsgDeliveryStatus) String() string {
+	switch s {
+	case MsgStatusQueued:
+		return "queued"
+	case MsgStatusPaid:
+		return "paid"
+	case MsgStatusSent:
+		return "sent"
+	case MsgStatusAcked:
+		return "acked"
+	case MsgStatusDelivered:
+		return "delivered"
+	case MsgStatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// payloadMsgID returns the ID of the chat message in the given RM payload, if
+// the payload is a chat message sent with an ID.
+func payloadMsgID(payload interface{}) *zkidentity.ShortID {
+	switch p := payload.(type) {
+	case rpc.RMPrivateMessage:
+		return p.MsgID
+	case rpc.RMGroupMessage:
+		return p.MsgID
+	default:
+		return nil
+	}
+}
+
+// handleMsgStatus is called by remote users when the delivery status of a chat
+// message changes.
+func (c *Client) handleMsgStatus(ru *RemoteUser, msgID zkidentity.ShortID,
+	status MsgDeliveryStatus, err error) {
+
+	ru.log.Tracef("Message %s delivery status: %s (err: %v)", msgID, status, err)
+	c.ntfns.notifyMsgDeliveryStatus(ru, msgID, status, err)
+	c.updateBroadcastStatus(ru.ID(), msgID, status)
+}
+
+// sendMsgReceiveReceipt sends a receive receipt for the given chat message
+// back to its sender, if receive receipts are enabled.
+func (c *Client) sendMsgReceiveReceipt(ru *RemoteUser, msgID, gcID *zkidentity.ShortID) {
+	if !c.cfg.SendReceiveReceipts || msgID == nil {
+		return
+	}
+
+	rr := rpc.RMReceiveReceipt{
+		Domain:     rpc.ReceiptDomainMessages,
+		ID:         msgID,
+		SubID:      gcID,
+		ClientTime: time.Now().UnixMilli(),
+	}
+	ru.log.Debugf("Sending receive receipt for message %s", msgID)
+	err := c.sendWithSendQ("msgs.recvreceipt", rr, ru.ID())
+	if err != nil {
+		ru.log.Warnf("Unable to send receive receipt for message %s: %v",
+			msgID, err)
+	}
+}
@@ -0,0 +1,483 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// OnchainTip is a tip sent to or received from a user as an on-chain
+// transaction.
+type OnchainTip = clientdb.OnchainTip
+
+const (
+	// onchainTipMinConfs is the number of confirmations after which the
+	// transaction of an on-chain tip is considered final.
+	onchainTipMinConfs = 1
+
+	// onchainTipMaxLifetime is how long to wait for the address of an
+	// outbound tip to be received and for the transaction of an inbound
+	// tip to be confirmed.
+	onchainTipMaxLifetime = 7 * 24 * time.Hour
+)
+
+// onchainPC returns the payment client as an on-chain payment client.
+func (c *Client) onchainPC() (clientintf.OnchainPaymentClient, error) {
+	opc, ok := c.pc.(clientintf.OnchainPaymentClient)
+	if !ok {
+		return nil, fmt.Errorf("payment client does not support on-chain payments")
+	}
+	return opc, nil
+}
+
+// SendOnchainTip starts sending a tip to the user as an on-chain transaction.
+// The address to pay is requested from the user, so the tip is only sent after
+// they reply. Progress is reported through OnOnchainTipUpdatedNtfn.
+//
+// Tips that exceed a payment budget fail, unless the override is confirmed
+// (see Config.PaymentBudgetConfirmer).
+func (c *Client) SendOnchainTip(uid UserID, dcrAmount float64) (OnchainTip, error) {
+	if err := c.checkPaymentsAllowed("on-chain tip"); err != nil {
+		return OnchainTip{}, err
+	}
+	amt, err := dcrutil.NewAmount(dcrAmount)
+	if err != nil {
+		return OnchainTip{}, err
+	}
+	if amt <= 0 {
+		return OnchainTip{}, fmt.Errorf("cannot pay user %s <= 0", amt)
+	}
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return OnchainTip{}, err
+	}
+	budgetOverride, err := c.checkPaymentBudget(uid, nil, int64(amt)*1000)
+	if err != nil {
+		return OnchainTip{}, err
+	}
+	return c.startOnchainTip(ru, int64(amt), nil, budgetOverride)
+}
+
+// startOnchainTip requests an address from the user to send an on-chain tip.
+func (c *Client) startOnchainTip(ru *RemoteUser, atoms int64, gc *zkidentity.ShortID,
+	budgetOverride bool) (OnchainTip, error) {
+
+	if _, err := c.onchainPC(); err != nil {
+		return OnchainTip{}, err
+	}
+
+	tip := OnchainTip{
+		ID:             clientintf.RandomID(),
+		UID:            ru.ID(),
+		Outbound:       true,
+		Atoms:          atoms,
+		Created:        time.Now(),
+		GC:             gc,
+		BudgetOverride: budgetOverride,
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		return OnchainTip{}, err
+	}
+
+	rm := rpc.RMGetOnchainAddress{ID: tip.ID, Atoms: atoms}
+	if err := c.sendWithSendQ("getonchainaddress", rm, ru.ID()); err != nil {
+		return OnchainTip{}, err
+	}
+	ru.log.Infof("Requested address to send on-chain tip of %s (id %s)",
+		dcrutil.Amount(atoms), tip.ID)
+	return tip, nil
+}
+
+// startOnchainTipFallback sends the tip attempt as an on-chain tip when it is
+// large enough.
+func (c *Client) startOnchainTipFallback(ru *RemoteUser, ta *clientdb.TipUserAttempt) {
+	minMAtoms := c.cfg.TipUserOnchainMinMAtoms
+	if minMAtoms <= 0 || int64(ta.MilliAtoms) < minMAtoms {
+		return
+	}
+	if _, err := c.onchainPC(); err != nil {
+		return
+	}
+
+	tip, err := c.startOnchainTip(ru, int64(ta.MilliAtoms/1000), ta.GC,
+		ta.BudgetOverride)
+	if err != nil {
+		ru.log.Errorf("Unable to fall back to on-chain tip: %v", err)
+		return
+	}
+	ru.log.Infof("Falling back to on-chain payment for tip attempt (tag %d) "+
+		"as on-chain tip %s", ta.Tag, tip.ID)
+}
+
+// ListOnchainTips lists the on-chain tips sent and received by the local
+// client.
+func (c *Client) ListOnchainTips() ([]OnchainTip, error) {
+	var res []OnchainTip
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListOnchainTips(tx)
+		return err
+	})
+	return res, err
+}
+
+// failOnchainTip records the failure of the on-chain tip.
+func (c *Client) failOnchainTip(ru *RemoteUser, tip OnchainTip, tipErr error) {
+	tip.Error = tipErr.Error()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to store on-chain tip failure: %v", err)
+	}
+	ru.log.Warnf("On-chain tip %s of %s failed: %v", tip.ID,
+		dcrutil.Amount(tip.Atoms), tipErr)
+	c.ntfns.notifyOnchainTipUpdated(ru, tip)
+}
+
+// handleGetOnchainAddress handles a request from a remote user for an address
+// to send an on-chain tip to.
+func (c *Client) handleGetOnchainAddress(ru *RemoteUser, getAddr rpc.RMGetOnchainAddress) error {
+	replyWithErr := func(err error) error {
+		errStr := err.Error()
+		reply := rpc.RMOnchainAddress{ID: getAddr.ID, Error: &errStr}
+		return c.sendWithSendQ("onchainaddress", reply, ru.ID())
+	}
+
+	opc, err := c.onchainPC()
+	if err != nil {
+		return replyWithErr(err)
+	}
+	if getAddr.Atoms <= 0 {
+		return replyWithErr(fmt.Errorf("invalid tip amount %d", getAddr.Atoms))
+	}
+
+	c.onchainTipsMtx.Lock()
+	defer c.onchainTipsMtx.Unlock()
+
+	// Reply with the same address when the request is repeated.
+	var tip OnchainTip
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		tip, err = c.db.GetOnchainTip(tx, ru.ID(), getAddr.ID)
+		return err
+	})
+	switch {
+	case err == nil && tip.Outbound:
+		return fmt.Errorf("on-chain tip %s is an outbound tip", getAddr.ID)
+	case err == nil && !tip.Pending():
+		return fmt.Errorf("on-chain tip %s was already completed", getAddr.ID)
+	case err == nil:
+	case errors.Is(err, clientdb.ErrNotFound):
+		addr, err := opc.NewOnchainAddress(c.ctx)
+		if err != nil {
+			ru.log.Warnf("Unable to generate address for on-chain "+
+				"tip: %v", err)
+			return replyWithErr(errors.New("unable to generate address"))
+		}
+		tip = OnchainTip{
+			ID:      getAddr.ID,
+			UID:     ru.ID(),
+			Atoms:   getAddr.Atoms,
+			Created: time.Now(),
+			Address: addr,
+		}
+		err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			return c.db.StoreOnchainTip(tx, tip)
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	ru.log.Infof("Generated address %s for on-chain tip of %s (id %s)",
+		tip.Address, dcrutil.Amount(tip.Atoms), tip.ID)
+	reply := rpc.RMOnchainAddress{ID: tip.ID, Address: tip.Address}
+	return c.sendWithSendQ("onchainaddress", reply, ru.ID())
+}
+
+// handleOnchainAddress handles the address sent by a remote user to pay an
+// on-chain tip.
+func (c *Client) handleOnchainAddress(ru *RemoteUser, addr rpc.RMOnchainAddress) error {
+	opc, err := c.onchainPC()
+	if err != nil {
+		return err
+	}
+
+	c.onchainTipsMtx.Lock()
+	defer c.onchainTipsMtx.Unlock()
+
+	var tip OnchainTip
+	err = c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		tip, err = c.db.GetOnchainTip(tx, ru.ID(), addr.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !tip.Outbound {
+		return fmt.Errorf("on-chain tip %s is not an outbound tip", tip.ID)
+	}
+	if !tip.Pending() || tip.Sent != nil {
+		ru.log.Warnf("Ignoring address for already sent on-chain tip %s",
+			tip.ID)
+		return nil
+	}
+
+	switch {
+	case addr.Error != nil:
+		c.failOnchainTip(ru, tip, fmt.Errorf("remote user replied with "+
+			"error: %s", *addr.Error))
+		return nil
+	case time.Since(tip.Created) > onchainTipMaxLifetime:
+		c.failOnchainTip(ru, tip, fmt.Errorf("address received %s after "+
+			"the tip was created", time.Since(tip.Created).Truncate(time.Second)))
+		return nil
+	}
+
+	mAtoms := tip.Atoms * 1000
+	spendID, err := c.reservePaymentSpend(ru.ID(), tip.GC, mAtoms, tip.BudgetOverride)
+	if err != nil {
+		c.failOnchainTip(ru, tip, err)
+		return nil
+	}
+	txh, err := opc.SendOnchain(c.ctx, addr.Address, tip.Atoms)
+	if err != nil {
+		c.releasePaymentSpend(spendID)
+		c.failOnchainTip(ru, tip, err)
+		return nil
+	}
+
+	now := time.Now()
+	tip.Address = addr.Address
+	tip.TxHash = txh.String()
+	tip.Sent = &now
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		// Amount is negative because we're making a payment.
+		err := c.db.RecordUserPayEvent(tx, ru.ID(), "paytiponchain", -mAtoms, 0)
+		if err != nil {
+			return err
+		}
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Sent on-chain tip of %s (id %s) in tx %s",
+		dcrutil.Amount(tip.Atoms), tip.ID, tip.TxHash)
+	c.ntfns.notifyOnchainTipUpdated(ru, tip)
+	go c.trackOnchainTip(c.ctx, ru, tip)
+	return nil
+}
+
+// waitOnchainTipConfirmed blocks until the transaction of the tip has the
+// required number of confirmations. It returns the amount the transaction
+// pays to the address of the tip.
+func (c *Client) waitOnchainTipConfirmed(ctx context.Context, ru *RemoteUser,
+	tip OnchainTip, deadline time.Time) (int64, error) {
+
+	opc, err := c.onchainPC()
+	if err != nil {
+		return 0, err
+	}
+	txh, err := chainhash.NewHashFromStr(tip.TxHash)
+	if err != nil {
+		return 0, err
+	}
+
+	ticker := time.NewTicker(c.cfg.OnchainTipCheckInterval)
+	defer ticker.Stop()
+	for {
+		atoms, confs, err := opc.OnchainTxOutput(ctx, *txh, tip.Address)
+		switch {
+		case err != nil:
+			ru.log.Debugf("Unable to check tx %s of on-chain tip %s: %v",
+				txh, tip.ID, err)
+		case confs >= onchainTipMinConfs:
+			return atoms, nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("tx %s has %d confirmations", txh, confs)
+			}
+			return 0, fmt.Errorf("tx not confirmed after %s: %w",
+				onchainTipMaxLifetime, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// trackOnchainTip waits until the transaction of the outbound tip is
+// confirmed, then sends the receipt to the remote user.
+func (c *Client) trackOnchainTip(ctx context.Context, ru *RemoteUser, tip OnchainTip) {
+	if _, err := c.waitOnchainTipConfirmed(ctx, ru, tip, time.Time{}); err != nil {
+		if !errors.Is(err, context.Canceled) {
+			ru.log.Errorf("Unable to track on-chain tip %s: %v", tip.ID, err)
+		}
+		return
+	}
+
+	// Send the receipt before recording the confirmation, so that it is
+	// resent if the client stops before recording it. Receivers ignore
+	// duplicated receipts.
+	receipt := rpc.RMOnchainTipReceipt{
+		ID:     tip.ID,
+		TxHash: tip.TxHash,
+		Atoms:  tip.Atoms,
+	}
+	if err := c.sendWithSendQ("onchaintipreceipt", receipt, ru.ID()); err != nil {
+		ru.log.Errorf("Unable to send on-chain tip receipt: %v", err)
+		return
+	}
+
+	now := time.Now()
+	tip.Confirmed = &now
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to store on-chain tip confirmation: %v", err)
+		return
+	}
+	ru.log.Infof("On-chain tip %s of %s confirmed in tx %s", tip.ID,
+		dcrutil.Amount(tip.Atoms), tip.TxHash)
+	c.ntfns.notifyOnchainTipUpdated(ru, tip)
+}
+
+// handleOnchainTipReceipt handles the receipt sent by a remote user after the
+// transaction of an on-chain tip is confirmed.
+func (c *Client) handleOnchainTipReceipt(ru *RemoteUser, receipt rpc.RMOnchainTipReceipt) error {
+	if _, err := chainhash.NewHashFromStr(receipt.TxHash); err != nil {
+		return fmt.Errorf("invalid tx hash in on-chain tip receipt: %v", err)
+	}
+
+	c.onchainTipsMtx.Lock()
+	defer c.onchainTipsMtx.Unlock()
+
+	var tip OnchainTip
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		tip, err = c.db.GetOnchainTip(tx, ru.ID(), receipt.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if tip.Outbound {
+		return fmt.Errorf("on-chain tip %s is not an inbound tip", tip.ID)
+	}
+	if !tip.Pending() || tip.TxHash != "" {
+		ru.log.Debugf("Ignoring duplicated receipt for on-chain tip %s",
+			tip.ID)
+		return nil
+	}
+
+	now := time.Now()
+	tip.TxHash = receipt.TxHash
+	tip.Sent = &now
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		return err
+	}
+
+	go c.verifyOnchainTip(c.ctx, ru, tip)
+	return nil
+}
+
+// verifyOnchainTip verifies the transaction of the inbound tip pays to the
+// address generated for it, then records the tip as received.
+func (c *Client) verifyOnchainTip(ctx context.Context, ru *RemoteUser, tip OnchainTip) {
+	deadline := tip.Sent.Add(onchainTipMaxLifetime)
+	atoms, err := c.waitOnchainTipConfirmed(ctx, ru, tip, deadline)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil && atoms <= 0 {
+		err = fmt.Errorf("tx %s does not pay to address %s", tip.TxHash,
+			tip.Address)
+	}
+	if err != nil {
+		c.failOnchainTip(ru, tip, err)
+		return
+	}
+
+	// Use the amount actually received instead of the requested one.
+	now := time.Now()
+	tip.Atoms = atoms
+	tip.Confirmed = &now
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		err := c.db.RecordUserPayEvent(tx, ru.ID(), "tiponchain", atoms*1000, 0)
+		if err != nil {
+			return err
+		}
+		return c.db.StoreOnchainTip(tx, tip)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to store received on-chain tip: %v", err)
+		return
+	}
+
+	ru.log.Infof("Received on-chain tip of %s in tx %s",
+		dcrutil.Amount(atoms), tip.TxHash)
+	c.ntfns.notifyOnchainTipUpdated(ru, tip)
+}
+
+// restartOnchainTips restarts tracking the transactions of on-chain tips that
+// were sent but not yet confirmed.
+func (c *Client) restartOnchainTips(ctx context.Context) error {
+	<-c.abLoaded
+
+	if _, err := c.onchainPC(); err != nil {
+		return nil
+	}
+
+	tips, err := c.ListOnchainTips()
+	if err != nil {
+		return err
+	}
+
+	var n int
+	for _, tip := range tips {
+		if !tip.Pending() || tip.TxHash == "" {
+			continue
+		}
+		ru, err := c.rul.byID(tip.UID)
+		if err != nil {
+			c.log.Warnf("Unable to restart on-chain tip %s: %v",
+				tip.ID, err)
+			continue
+		}
+		if tip.Outbound {
+			go c.trackOnchainTip(ctx, ru, tip)
+		} else {
+			go c.verifyOnchainTip(ctx, ru, tip)
+		}
+		n++
+	}
+	if n > 0 {
+		c.log.Infof("Tracking %d on-chain tips", n)
+	}
+	return nil
+}
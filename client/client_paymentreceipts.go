@@ -0,0 +1,147 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// PaymentReceipt is a signed receipt of a payment made to or received from a
+// user.
+type PaymentReceipt = clientdb.PaymentReceipt
+
+// sendPaymentReceipt signs and stores a receipt for a completed payment made
+// to the remote user and sends it to them. Tips made within the context of a
+// GC with the tipping leaderboard enabled are also announced to the GC. fiat
+// records the conversion of fiat-denominated payments.
+func (c *Client) sendPaymentReceipt(ru *RemoteUser, kind string, mAtoms int64,
+	paymentHash []byte, gc *zkidentity.ShortID, fiat *rpc.RMFiatConversion) {
+
+	rm := rpc.RMPaymentReceipt{
+		Kind:        kind,
+		MilliAtoms:  mAtoms,
+		PaymentHash: paymentHash,
+		Timestamp:   time.Now().Unix(),
+		Fiat:        fiat,
+	}
+	var announceTo []UserID
+	if gc != nil && kind == rpc.PaymentReceiptKindTip {
+		var announce bool
+		announceTo, announce = c.gcTipAnnounceTargets(*gc, ru.ID())
+		if announce {
+			gcID, payee := *gc, ru.ID()
+			rm.GC, rm.Payee = &gcID, &payee
+		}
+	}
+	hash := rm.ReceiptHash(c.PublicID(), ru.ID())
+	rm.Signature = c.localID.signMessage(hash[:])
+
+	pr := PaymentReceipt{
+		UID:      ru.ID(),
+		Outbound: true,
+		Receipt:  rm,
+		Stored:   time.Now(),
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePaymentReceipt(tx, pr)
+	})
+	if err != nil {
+		ru.log.Errorf("Unable to store payment receipt: %v", err)
+	}
+
+	if err := c.sendWithSendQ("paymentreceipt", rm, ru.ID()); err != nil {
+		ru.log.Errorf("Unable to send payment receipt: %v", err)
+	}
+
+	if rm.GC == nil {
+		return
+	}
+	if err := c.recordGCTip(c.PublicID(), ru.ID(), rm); err != nil {
+		ru.log.Errorf("Unable to record GC tip: %v", err)
+	}
+	if len(announceTo) > 0 {
+		if err := c.sendWithSendQ("paymentreceipt", rm, announceTo...); err != nil {
+			ru.log.Errorf("Unable to announce GC tip: %v", err)
+		}
+	}
+}
+
+// sendInvoicePaymentReceipt sends the receipt for a paid invoice.
+func (c *Client) sendInvoicePaymentReceipt(ru *RemoteUser, kind string,
+	mAtoms int64, invoice string, gc *zkidentity.ShortID, fiat *rpc.RMFiatConversion) {
+
+	decoded, err := c.pc.DecodeInvoice(c.ctx, invoice)
+	if err != nil {
+		ru.log.Warnf("Unable to decode paid invoice to send receipt: %v", err)
+		return
+	}
+	c.sendPaymentReceipt(ru, kind, mAtoms, decoded.ID, gc, fiat)
+}
+
+// handlePaymentReceipt handles a receipt sent by a remote user for a payment
+// they made to the local client, or the announcement of a tip they made to
+// another member of a GC.
+func (c *Client) handlePaymentReceipt(ru *RemoteUser, rm rpc.RMPaymentReceipt) error {
+	switch rm.Kind {
+	case rpc.PaymentReceiptKindTip, rpc.PaymentReceiptKindContent:
+	default:
+		return fmt.Errorf("unknown payment receipt kind %q", rm.Kind)
+	}
+	if rm.MilliAtoms <= 0 {
+		return fmt.Errorf("payment receipt has invalid amount %d", rm.MilliAtoms)
+	}
+	if len(rm.PaymentHash) != 32 {
+		return fmt.Errorf("payment receipt has invalid payment hash len %d",
+			len(rm.PaymentHash))
+	}
+	payee := c.PublicID()
+	if rm.Payee != nil {
+		payee = *rm.Payee
+	}
+	if payee != c.PublicID() && rm.GC == nil {
+		return fmt.Errorf("payment receipt for another user without a GC")
+	}
+	hash := rm.ReceiptHash(ru.ID(), payee)
+	if !zkidentity.VerifyMessage(hash[:], &rm.Signature, &ru.sigKey) {
+		return fmt.Errorf("payment receipt has invalid signature")
+	}
+
+	if err := c.recordGCTip(ru.ID(), payee, rm); err != nil {
+		ru.log.Errorf("Unable to record GC tip: %v", err)
+	}
+	if payee != c.PublicID() {
+		// Announcement of a tip to another GC member.
+		return nil
+	}
+
+	pr := PaymentReceipt{
+		UID:     ru.ID(),
+		Receipt: rm,
+		Stored:  time.Now(),
+	}
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePaymentReceipt(tx, pr)
+	})
+	if err != nil {
+		return err
+	}
+
+	ru.log.Debugf("Received %s payment receipt of %.8f DCR for payment %x",
+		rm.Kind, float64(rm.MilliAtoms)/1e11, rm.PaymentHash)
+	c.ntfns.notifyPaymentReceipt(ru, pr)
+	return nil
+}
+
+// ListPaymentReceipts lists the payment receipts exchanged with the user.
+func (c *Client) ListPaymentReceipts(uid UserID) ([]PaymentReceipt, error) {
+	var res []PaymentReceipt
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListPaymentReceipts(tx, uid)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/slog"
+)
+
+// TestPaymentReport tests aggregating payment events in a payment report.
+func TestPaymentReport(t *testing.T) {
+	db := testDB(t, nil, slog.Disabled)
+	runTestDB(t, db)
+
+	alice, bob := zkidentity.ShortID{0: 1}, zkidentity.ShortID{0: 2}
+	events := []struct {
+		uid    UserID
+		event  string
+		amount int64
+		fee    int64
+	}{
+		{alice, "tip", 5000, 0},
+		{alice, "paytip", -1000, -10},
+		{alice, "ftgetchunk.0011223344.0", 300, 0},
+		{bob, "tip", 7000, 0},
+		{bob, "ftpaychunk.0011223344.1", -200, -5},
+		{bob, "sub.0011223344", -50, 0},
+	}
+	err := db.Update(context.Background(), func(tx clientdb.ReadWriteTx) error {
+		for _, e := range events {
+			err := db.RecordUserPayEvent(tx, e.uid, e.event, e.amount, e.fee)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	start, end := now.Add(-90*time.Minute), now.Add(30*time.Minute)
+	var report *clientdb.PaymentReport
+	err = db.View(context.Background(), func(tx clientdb.ReadTx) error {
+		var err error
+		report, err = db.PaymentReport(tx, start, end, time.Hour)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTotals := clientdb.PaymentTotals{
+		Tips:    clientdb.PayCategoryTotals{Sent: 1000, Received: 12000},
+		Content: clientdb.PayCategoryTotals{Sent: 200, Received: 300},
+		Other:   clientdb.PayCategoryTotals{Sent: 50},
+		PayFees: 15,
+	}
+	if report.Totals != wantTotals {
+		t.Fatalf("unexpected totals: got %+v, want %+v", report.Totals, wantTotals)
+	}
+	if len(report.Users) != 2 || report.Users[0].UID != bob || report.Users[1].UID != alice {
+		t.Fatalf("unexpected users: %+v", report.Users)
+	}
+	if got := report.Users[1].TotalReceived(); got != 5300 {
+		t.Fatalf("unexpected received from alice: got %d, want 5300", got)
+	}
+	if got := report.Users[1].TotalSent(); got != 1010 {
+		t.Fatalf("unexpected sent to alice: got %d, want 1010", got)
+	}
+	if len(report.Windows) != 2 {
+		t.Fatalf("unexpected nb of windows: got %d, want 2", len(report.Windows))
+	}
+	if report.Windows[0].TotalReceived() != 0 || report.Windows[1].PaymentTotals != wantTotals {
+		t.Fatalf("unexpected windows: %+v", report.Windows)
+	}
+
+	// A report of a past period has no payments.
+	err = db.View(context.Background(), func(tx clientdb.ReadTx) error {
+		var err error
+		report, err = db.PaymentReport(tx, start.Add(-time.Hour), start, 0)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Users) != 0 || report.Totals != (clientdb.PaymentTotals{}) {
+		t.Fatalf("unexpected report of past period: %+v", report)
+	}
+}
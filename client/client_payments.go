@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/slog"
 )
@@ -26,6 +28,9 @@ import (
 //
 //   handleInvoice()
 //     (out-of-band payment)
+//
+// Small tips to users with a known LN node may instead be paid directly
+// through a keysend payment (see tipUserKeysend()).
 
 // TipUser starts an attempt to tip the user some amount of dcr. This dispatches
 // a request for an invoice to the remote user, which once received will be
@@ -33,14 +38,78 @@ import (
 //
 // By the time the invoice is received, the local client may or may not have
 // enough funds to pay for it, so multiple attempts will be made to fetch and
-// pay for an invoice.
+// pay for an invoice. If maxAttempts is zero, the max attempts defined in the
+// tip retry policy for the user is used (see SetTipRetryPolicy).
+//
+// Tips up to TipUserKeysendMaxMAtoms are first attempted as keysend payments,
+// in which case this blocks until that payment completes.
+//
+// Tips that exceed a payment budget fail, unless the override is confirmed
+// (see Config.PaymentBudgetConfirmer).
 func (c *Client) TipUser(uid UserID, dcrAmount float64, maxAttempts int32) error {
+	return c.tipUser(uid, dcrAmount, maxAttempts, nil, nil)
+}
+
+// EstimateTipFee estimates the routing fee to tip the user with the given
+// amount. The estimate is done against the LN node of the user, which is
+// learned from previous invoices sent by them. ErrUnknownUserLNNode is returned
+// if the node is not known yet.
+func (c *Client) EstimateTipFee(uid UserID, dcrAmount float64) (clientintf.PaymentFeeEstimate, error) {
+	var res clientintf.PaymentFeeEstimate
+	amt, err := dcrutil.NewAmount(dcrAmount)
+	if err != nil {
+		return res, err
+	}
+	if amt <= 0 {
+		return res, fmt.Errorf("cannot estimate fee of tip %s <= 0", amt)
+	}
+	fepc, ok := c.pc.(clientintf.FeeEstimatorPaymentClient)
+	if !ok {
+		return res, fmt.Errorf("payment client does not support fee estimation")
+	}
+	if _, err := c.rul.byID(uid); err != nil {
+		return res, err
+	}
+	node, err := c.UserLNNode(uid)
+	if err != nil {
+		return res, err
+	}
+	if node == "" {
+		return res, ErrUnknownUserLNNode
+	}
+	return fepc.EstimateNodeFee(c.ctx, node, int64(amt)*1000)
+}
+
+// RebalanceChannels moves funds between two channels of the local LN node by
+// making a circular payment, within the given fee limit.
+func (c *Client) RebalanceChannels(ctx context.Context, req clientintf.RebalanceRequest) (clientintf.RebalanceResult, error) {
+	if err := c.checkPaymentsAllowed("rebalance"); err != nil {
+		return clientintf.RebalanceResult{}, err
+	}
+	rbpc, ok := c.pc.(clientintf.RebalancerPaymentClient)
+	if !ok {
+		return clientintf.RebalanceResult{}, fmt.Errorf("payment client " +
+			"does not support rebalancing channels")
+	}
+	return rbpc.Rebalance(ctx, req)
+}
+
+// tipUser starts an attempt to tip the user. gc is set when tipping on behalf
+// of a GC and fiat is set for tips denominated in a fiat currency.
+func (c *Client) tipUser(uid UserID, dcrAmount float64, maxAttempts int32,
+	gc *zkidentity.ShortID, fiat *rpc.RMFiatConversion) error {
+	if err := c.checkPaymentsAllowed("tip"); err != nil {
+		return err
+	}
 	if dcrAmount <= 0 {
 		return fmt.Errorf("cannot pay user %f <= 0", dcrAmount)
 	}
 
-	if maxAttempts <= 0 {
-		return fmt.Errorf("maxAttempts %d <= 0", maxAttempts)
+	if maxAttempts < 0 {
+		return fmt.Errorf("maxAttempts %d < 0", maxAttempts)
+	}
+	if maxAttempts == 0 {
+		maxAttempts = c.tipRetrySettingsFor(uid).maxAttempts
 	}
 
 	// Wait until the main tip processing goroutine has performed its
@@ -62,17 +131,29 @@ func (c *Client) TipUser(uid UserID, dcrAmount float64, maxAttempts int32) error
 	}
 	milliAmt := uint64(amt) * 1e3
 
+	budgetOverride, err := c.checkPaymentBudget(uid, gc, int64(milliAmt))
+	if err != nil {
+		return err
+	}
+
+	if c.tipUserKeysend(ru, milliAmt, gc, fiat, budgetOverride) {
+		return nil
+	}
+
 	var tag int32
 	var ta clientdb.TipUserAttempt
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		tag = c.db.UnusedTipUserTag(tx, uid)
 		ta = clientdb.TipUserAttempt{
-			UID:         uid,
-			Tag:         tag,
-			MilliAtoms:  milliAmt,
-			Created:     time.Now(),
-			Attempts:    0,
-			MaxAttempts: maxAttempts,
+			UID:            uid,
+			Tag:            tag,
+			MilliAtoms:     milliAmt,
+			Created:        time.Now(),
+			Attempts:       0,
+			MaxAttempts:    maxAttempts,
+			GC:             gc,
+			BudgetOverride: budgetOverride,
+			Fiat:           fiat,
 		}
 		return c.db.StoreTipUserAttempt(tx, ta)
 	})
@@ -109,6 +190,18 @@ func (c *Client) trackGeneratedTipInvoice(ctx context.Context, uid clientintf.Us
 		}
 	}()
 
+	// Allow the tracking to be stopped when the invoice is canceled.
+	ctx, cancel := context.WithCancel(ctx)
+	c.genTipInvoicesMtx.Lock()
+	c.genTipInvoicesCancel[invoice] = cancel
+	c.genTipInvoicesMtx.Unlock()
+	defer func() {
+		c.genTipInvoicesMtx.Lock()
+		delete(c.genTipInvoicesCancel, invoice)
+		c.genTipInvoicesMtx.Unlock()
+		cancel()
+	}()
+
 	// Wait until invoice is settled or expires.
 	receivedMAtoms, err := c.pc.TrackInvoice(ctx, invoice, wantMAtoms)
 	if errors.Is(err, clientintf.ErrInvoiceExpired) {
@@ -117,6 +210,17 @@ func (c *Client) trackGeneratedTipInvoice(ctx context.Context, uid clientintf.Us
 		})
 		return
 	}
+	if errors.Is(err, clientintf.ErrInvoiceCanceled) {
+		// Canceled outside of CancelInvoice().
+		err = c.db.Update(ctx, func(tx clientdb.ReadWriteTx) error {
+			err := c.db.MarkGeneratedTipInvoiceCanceled(tx, uid, invoice)
+			if errors.Is(err, clientdb.ErrNotFound) {
+				err = nil
+			}
+			return err
+		})
+		return
+	}
 	if err != nil {
 		return
 	}
@@ -140,6 +244,21 @@ func (c *Client) trackGeneratedTipInvoice(ctx context.Context, uid clientintf.Us
 	}
 
 	c.ntfns.notifyTipReceived(ru, receivedMAtoms)
+
+	ev := PaymentEvent{
+		Type:       PaymentEventTipReceived,
+		MilliAtoms: receivedMAtoms,
+		Invoice:    invoice,
+	}
+	if decoded, err := c.pc.DecodeInvoice(c.ctx, invoice); err == nil {
+		ev.PaymentHash = hex.EncodeToString(decoded.ID)
+	}
+	c.firePaymentEvent(ru, ev)
+
+	// The settlement of the invoice is also reported on its own, as done
+	// for every invoice generated by the local client.
+	ev.Type = PaymentEventInvoiceSettled
+	c.firePaymentEvent(ru, ev)
 }
 
 func (c *Client) handleGetInvoice(ru *RemoteUser, getInvoice rpc.RMGetInvoice) error {
@@ -240,6 +359,7 @@ func (c *Client) handleTipUserPaymentResult(ru *RemoteUser, tag int32, payErr er
 			now := time.Now()
 			ta.Completed = &now
 			ta.LastInvoiceError = nil
+			ta.PaymentFees = -fees
 
 		case errors.Is(payErr, clientintf.ErrRetriablePayment):
 			// Will try the payment again after a delay.
@@ -263,6 +383,11 @@ func (c *Client) handleTipUserPaymentResult(ru *RemoteUser, tag int32, payErr er
 		return
 	}
 
+	if payErr == nil {
+		c.sendInvoicePaymentReceipt(ru, rpc.PaymentReceiptKindTip,
+			int64(ta.MilliAtoms), ta.LastInvoice, ta.GC, ta.Fiat)
+	}
+
 	// When there's an error and it's not yet the last attempt, notify
 	// the UI.
 	if ta.LastInvoiceError != nil && ta.Attempts < ta.MaxAttempts {
@@ -270,8 +395,8 @@ func (c *Client) handleTipUserPaymentResult(ru *RemoteUser, tag int32, payErr er
 		ru.log.Debugf("Attempt %d/%d (pay retry #%d) at tip tag %d failed payment "+
 			"due to %v", ta.Attempts, ta.MaxAttempts, ta.PaymentAttemptCount-1,
 			ta.Tag, invoiceErr)
-		c.ntfns.notifyTipAttemptProgress(ru, int64(ta.MilliAtoms), false,
-			int(ta.Attempts), invoiceErr, true)
+		c.ntfns.notifyTipAttemptProgress(ru,
+			c.tipAttemptProgress(ru, &ta, invoiceErr, true))
 	} else if errors.Is(payErr, clientintf.ErrRetriablePayment) {
 		ru.log.Debugf("Attempt %d/%d (pay retry #%d) at tip tag %d failed payment "+
 			"due to %v. Will retry payment of the same invoice.",
@@ -287,9 +412,35 @@ func (c *Client) handleTipUserPaymentResult(ru *RemoteUser, tag int32, payErr er
 }
 
 // payTipInvoice starts the payment process for a received invoice.
-func (c *Client) payTipInvoice(ru *RemoteUser, invoice string, amtMAtoms int64, tag int32) {
-	fees, payErr := c.pc.PayInvoice(c.ctx, invoice)
-	c.handleTipUserPaymentResult(ru, tag, payErr, fees)
+func (c *Client) payTipInvoice(ru *RemoteUser, ta clientdb.TipUserAttempt) {
+	if err := c.checkFiatSlippage(ta.Fiat); err != nil {
+		c.handleTipUserPaymentResult(ru, ta.Tag, err, 0)
+		return
+	}
+
+	spendID, err := c.reservePaymentSpend(ru.ID(), ta.GC, int64(ta.MilliAtoms),
+		ta.BudgetOverride)
+	if err != nil {
+		c.handleTipUserPaymentResult(ru, ta.Tag, err, 0)
+		return
+	}
+
+	// Large tips may be split into multiple parts when the payment client
+	// supports it.
+	var fees int64
+	var payErr error
+	if mpc, ok := c.pc.(clientintf.MultiPathPaymentClient); ok {
+		fees, payErr = mpc.PayInvoiceMultiPath(c.ctx, ta.LastInvoice,
+			func(p clientintf.MultiPathProgress) {
+				c.ntfns.notifyTipPaymentProgress(ru, ta.Tag, p)
+			})
+	} else {
+		fees, payErr = c.pc.PayInvoice(c.ctx, ta.LastInvoice)
+	}
+	if payErr != nil {
+		c.releasePaymentSpend(spendID)
+	}
+	c.handleTipUserPaymentResult(ru, ta.Tag, payErr, fees)
 }
 
 // handleInvoice handles received RMInvoice calls.
@@ -297,6 +448,9 @@ func (c *Client) handleInvoice(ru *RemoteUser, invoice rpc.RMInvoice) error {
 
 	// Decode invoice to determine if it's valid.
 	decoded, decodedErr := c.pc.DecodeInvoice(c.ctx, invoice.Invoice)
+	if decodedErr == nil && decoded.Destination != "" {
+		c.updateUserLNNode(ru, decoded.Destination)
+	}
 
 	var ta clientdb.TipUserAttempt
 	var invoiceErr error
@@ -344,11 +498,12 @@ func (c *Client) handleInvoice(ru *RemoteUser, invoice rpc.RMInvoice) error {
 			invoiceErr = fmt.Errorf("invoice received is already expired")
 		}
 		now := time.Now()
-		if invoiceErr == nil && ta.Created.Before(now.Add(-c.cfg.TipUserMaxLifetime)) {
+		maxLifetime := c.tipRetrySettingsFor(ru.ID()).maxLifetime
+		if invoiceErr == nil && ta.Created.Before(now.Add(-maxLifetime)) {
 			invoiceErr = fmt.Errorf("invoice created %s ago "+
 				"which is greater than max lifetime %s",
 				now.Sub(ta.Created).Truncate(time.Second),
-				c.cfg.TipUserMaxLifetime)
+				maxLifetime)
 		}
 
 		if invoiceErr == nil {
@@ -385,8 +540,8 @@ func (c *Client) handleInvoice(ru *RemoteUser, invoice rpc.RMInvoice) error {
 		invoiceErr := errors.New(*ta.LastInvoiceError)
 		ru.log.Debugf("Attempt %d/%d at tip tag %d failed to fetch invoice "+
 			"due to %v", ta.Attempts, ta.MaxAttempts, ta.Tag, invoiceErr)
-		c.ntfns.notifyTipAttemptProgress(ru, int64(ta.MilliAtoms), false,
-			int(ta.Attempts), invoiceErr, true)
+		c.ntfns.notifyTipAttemptProgress(ru,
+			c.tipAttemptProgress(ru, &ta, invoiceErr, true))
 	}
 
 	// Send to main tip payment run() goroutine.
@@ -471,7 +626,7 @@ func (c *Client) takeTipAttemptAction(ctx context.Context, attempts *tipAttempts
 			// The action to take is final (cancel, complete, expire). So
 			// look for the next tip attempt for this user.
 			nextTA, err := c.db.NextTipAttemptToRetryForUser(tx, rta.uid,
-				c.cfg.TipUserMaxLifetime)
+				c.tipRetrySettingsFor(rta.uid).maxLifetime)
 			if errors.Is(err, clientdb.ErrNotFound) {
 				// No more attempts to add for this user.
 				ru.log.Trace("No more tip attempts for user to progress")
@@ -582,8 +737,14 @@ func (c *Client) takeTipAttemptAction(ctx context.Context, attempts *tipAttempts
 		ru.log.Infof("Tip attempt (tag %d) failed after %d attempts "+
 			"to request invoice due to %v. Giving up.",
 			ta.Tag, ta.Attempts, err)
-		c.ntfns.notifyTipAttemptProgress(ru, int64(ta.MilliAtoms), false,
-			int(ta.Attempts), err, false)
+		c.ntfns.notifyTipAttemptProgress(ru,
+			c.tipAttemptProgress(ru, &ta, err, false))
+
+		// Large tips that failed to be paid through LN are sent
+		// on-chain instead.
+		if ta.LastInvoiceError != nil {
+			c.startOnchainTipFallback(ru, &ta)
+		}
 
 	case actionExpire:
 		// Notify tip attempt expired.
@@ -592,15 +753,16 @@ func (c *Client) takeTipAttemptAction(ctx context.Context, attempts *tipAttempts
 		ru.log.Infof("Tip attempt (tag %d) expired %s after creation "+
 			"with %d/%d attempts", ta.Tag, lifetime, ta.Attempts,
 			ta.MaxAttempts)
-		c.ntfns.notifyTipAttemptProgress(ru, int64(ta.MilliAtoms), false,
-			int(ta.Attempts), err, false)
+		progress := c.tipAttemptProgress(ru, &ta, err, false)
+		progress.FailureReason = TipFailureTipExpired
+		c.ntfns.notifyTipAttemptProgress(ru, progress)
 
 	case actionComplete:
 		// Notify tip completed successfully.
 		ru.log.Infof("Completed tip user attempt (tag %d) for %.8f DCR",
 			ta.Tag, float64(ta.MilliAtoms)/1e11)
-		c.ntfns.notifyTipAttemptProgress(ru, int64(ta.MilliAtoms), true,
-			int(ta.Attempts), nil, false)
+		c.ntfns.notifyTipAttemptProgress(ru,
+			c.tipAttemptProgress(ru, &ta, nil, false))
 
 	case actionRequestInvoice:
 		// Request a new invoice from remote user.
@@ -622,7 +784,7 @@ func (c *Client) takeTipAttemptAction(ctx context.Context, attempts *tipAttempts
 		ru.log.Debugf("Attempt %d/%d at paying tip user invoice of "+
 			"%.8f DCR (tag %d, pay retry #%d)", ta.Attempts, ta.MaxAttempts,
 			float64(ta.MilliAtoms)/1e11, ta.Tag, ta.PaymentAttemptCount)
-		go c.payTipInvoice(ru, ta.LastInvoice, int64(ta.MilliAtoms), ta.Tag)
+		go c.payTipInvoice(ru, ta)
 
 	case actionCheckPayment:
 		// Check if payment was completed.
@@ -642,8 +804,7 @@ func (c *Client) takeTipAttemptAction(ctx context.Context, attempts *tipAttempts
 func (c *Client) runTipAttempts(ctx context.Context) error {
 	<-c.abLoaded
 
-	attempts := newTipAttemptsList(c.cfg.TipUserReRequestInvoiceDelay,
-		c.cfg.TipUserMaxLifetime, c.cfg.TipUserPayRetryDelayFactor)
+	attempts := newTipAttemptsList(c.tipRetrySettingsFor)
 
 	// This is called on client start, so wait until the first set of RV
 	// subscriptions is done, then sleep for a bit to allow any invoices
@@ -671,7 +832,7 @@ func (c *Client) runTipAttempts(ctx context.Context) error {
 	var oldestTAs []clientdb.TipUserAttempt
 	err := c.dbView(func(tx clientdb.ReadTx) error {
 		var err error
-		oldestTAs, err = c.db.ListOldestValidTipUserAttempts(tx, c.cfg.TipUserMaxLifetime)
+		oldestTAs, err = c.db.ListOldestValidTipUserAttempts(tx, c.maxTipLifetime())
 		return err
 	})
 	if err != nil {
@@ -781,6 +942,23 @@ func (c *Client) SummarizeUserPayStats(uid UserID) ([]clientdb.PayStatsSummary,
 
 }
 
+// PaymentReport is a report of the payments made and received during a
+// period of time.
+type PaymentReport = clientdb.PaymentReport
+
+// PaymentReport aggregates the payments made and received by the local client
+// in [start, end), in total and per user. If window is not zero, the totals
+// are also aggregated in windows of that duration (for example, daily totals).
+func (c *Client) PaymentReport(start, end time.Time, window time.Duration) (*PaymentReport, error) {
+	var res *PaymentReport
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.PaymentReport(tx, start, end, window)
+		return err
+	})
+	return res, err
+}
+
 // ClearPayStats removes the payment stats associated with the given user. If
 // nil is passed, then the payment stats for all users are cleared.
 func (c *Client) ClearPayStats(uid *UserID) error {
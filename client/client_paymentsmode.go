@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/decred/slog"
+)
+
+// PaymentsMode restricts the payments made by the client.
+type PaymentsMode string
+
+const (
+	// PaymentsModeNormal does not restrict payments.
+	PaymentsModeNormal PaymentsMode = ""
+
+	// PaymentsModeReceiveOnly rejects payments to other users (tips, paid
+	// content, escrows, on-chain tips and channel rebalances). Payments
+	// may still be received and the fees of the server are still paid.
+	PaymentsModeReceiveOnly PaymentsMode = "receiveonly"
+
+	// PaymentsModeDisabled rejects every payment, including the fees of
+	// the server. Clients in this mode can only send messages through
+	// servers that do not charge for them.
+	PaymentsModeDisabled PaymentsMode = "disabled"
+)
+
+// ParsePaymentsMode parses a payments mode. "normal" and the empty string
+// both parse to PaymentsModeNormal.
+func ParsePaymentsMode(s string) (PaymentsMode, error) {
+	switch PaymentsMode(s) {
+	case PaymentsModeNormal, "normal":
+		return PaymentsModeNormal, nil
+	case PaymentsModeReceiveOnly, PaymentsModeDisabled:
+		return PaymentsMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown payments mode %q", s)
+	}
+}
+
+// String returns the name of the mode.
+func (m PaymentsMode) String() string {
+	if m == PaymentsModeNormal {
+		return "normal"
+	}
+	return string(m)
+}
+
+// PaymentsMode returns the mode that restricts the payments made by the
+// client.
+func (c *Client) PaymentsMode() PaymentsMode {
+	return c.cfg.PaymentsMode
+}
+
+// checkPaymentsAllowed returns an error if payments to other users are not
+// allowed. Rejected payments are logged, so that payments triggered by
+// automated actions are not silently ignored.
+func (c *Client) checkPaymentsAllowed(action string) error {
+	mode := c.cfg.PaymentsMode
+	if mode == PaymentsModeNormal {
+		return nil
+	}
+	c.log.Warnf("Rejected %s: payments are disabled (%s mode)", action, mode)
+	return fmt.Errorf("unable to make %s: %w (%s mode)", action,
+		clientintf.ErrPaymentsDisabled, mode)
+}
+
+// noSpendPaymentClient is a payment client that fails every payment. It is
+// used to pay for server operations when payments are disabled.
+type noSpendPaymentClient struct {
+	clientintf.PaymentClient
+	log slog.Logger
+}
+
+func (pc noSpendPaymentClient) PayInvoice(context.Context, string) (int64, error) {
+	pc.log.Warnf("Rejected server payment: payments are disabled")
+	return 0, fmt.Errorf("unable to pay server: %w", clientintf.ErrPaymentsDisabled)
+}
+
+func (pc noSpendPaymentClient) PayInvoiceAmount(context.Context, string, int64) (int64, error) {
+	pc.log.Warnf("Rejected server payment: payments are disabled")
+	return 0, fmt.Errorf("unable to pay server: %w", clientintf.ErrPaymentsDisabled)
+}
@@ -0,0 +1,28 @@
+package client
+
+import "testing"
+
+// TestParsePaymentsMode tests parsing the payments modes.
+func TestParsePaymentsMode(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    PaymentsMode
+		wantErr bool
+	}{
+		{s: "", want: PaymentsModeNormal},
+		{s: "normal", want: PaymentsModeNormal},
+		{s: "receiveonly", want: PaymentsModeReceiveOnly},
+		{s: "disabled", want: PaymentsModeDisabled},
+		{s: "watchonly", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParsePaymentsMode(tc.s)
+		if tc.wantErr != (err != nil) {
+			t.Fatalf("%q: unexpected error: %v", tc.s, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: unexpected mode: got %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"golang.org/x/exp/slices"
+)
+
+// MaxPinnedPosts is the max number of posts the local client may pin.
+const MaxPinnedPosts = 5
+
+// pinnedPost is a pinned post ready to be sent to a subscriber.
+type pinnedPost struct {
+	pid     clientintf.PostID
+	post    rpc.PostMetadata
+	updates []rpc.PostMetadataStatus
+}
+
+// PinPost pins (or unpins) a post created by the local client. New subscribers
+// receive the pinned posts before any other posts.
+func (c *Client) PinPost(pid clientintf.PostID, pin bool) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if pin {
+			if _, err := c.db.ReadPost(tx, c.PublicID(), pid); err != nil {
+				return err
+			}
+			pinned, err := c.db.ListPinnedPosts(tx)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(pinned, pid) && len(pinned) >= MaxPinnedPosts {
+				return fmt.Errorf("cannot pin more than %d posts",
+					MaxPinnedPosts)
+			}
+		}
+		return c.db.PinPost(tx, pid, pin)
+	})
+}
+
+// ListPinnedPosts returns the summaries of the posts pinned by the local
+// client, in the order they were pinned.
+func (c *Client) ListPinnedPosts() ([]clientdb.PostSummary, error) {
+	var pinned []clientdb.PostID
+	var posts []clientdb.PostSummary
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		if pinned, err = c.db.ListPinnedPosts(tx); err != nil {
+			return err
+		}
+		posts, err = c.db.ListPosts(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]clientdb.PostSummary, 0, len(pinned))
+	for _, pid := range pinned {
+		i := slices.IndexFunc(posts, func(summ clientdb.PostSummary) bool {
+			return summ.ID == pid && summ.From == c.PublicID()
+		})
+		if i < 0 {
+			continue
+		}
+		summ := posts[i]
+		summ.Pinned = true
+		res = append(res, summ)
+	}
+	return res, nil
+}
+
+// readPinnedPosts reads the pinned posts of the local client (along with their
+// status updates), marking them as pinned to be sent to a subscriber.
+func (c *Client) readPinnedPosts(tx clientdb.ReadTx) ([]pinnedPost, error) {
+	pids, err := c.db.ListPinnedPosts(tx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]pinnedPost, 0, len(pids))
+	for _, pid := range pids {
+		post, err := c.db.ReadPost(tx, c.PublicID(), pid)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			c.log.Warnf("Pinned post %s not found", pid)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		updates, err := c.db.ListPostStatusUpdates(tx, c.PublicID(), pid)
+		if err != nil {
+			return nil, err
+		}
+		post.Attributes[rpc.RMPPinned] = "1"
+		res = append(res, pinnedPost{pid: pid, post: post, updates: updates})
+	}
+	return res, nil
+}
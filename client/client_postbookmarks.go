@@ -0,0 +1,185 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// maxPostBookmarkExcerpt is the max length of the excerpt of a bookmarked
+// comment.
+const maxPostBookmarkExcerpt = 280
+
+// PostBookmark is a local bookmark of a received post or of one of its
+// comments.
+type PostBookmark = clientdb.PostBookmark
+
+// PostBookmarkQuery selects post bookmarks. Empty fields match all bookmarks.
+type PostBookmarkQuery struct {
+	// Tags are the tags a bookmark must have (all of them).
+	Tags []string
+
+	// Text is searched (case insensitively) in the title of the post, the
+	// excerpt of the comment, the note and the author nick.
+	Text string
+
+	// PostFrom and Post select the bookmarks of the posts received from
+	// a user and of a single post.
+	PostFrom *UserID
+	Post     *clientintf.PostID
+
+	// Since selects bookmarks created after this time.
+	Since time.Time
+
+	// Limit is the max number of returned bookmarks. Zero means no limit.
+	Limit int
+}
+
+// Matches returns true if the bookmark is selected by the query.
+func (q *PostBookmarkQuery) Matches(bm *PostBookmark) bool {
+	if q.PostFrom != nil && *q.PostFrom != bm.PostFrom {
+		return false
+	}
+	if q.Post != nil && *q.Post != bm.Post {
+		return false
+	}
+	if !q.Since.IsZero() && !bm.Created.After(q.Since) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		tag = rpc.NormalizePostTag(tag)
+		found := false
+		for _, bmTag := range bm.Tags {
+			if bmTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		fields := []string{bm.Title, bm.Excerpt, bm.Note, bm.AuthorNick}
+		found := false
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), text) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// truncatePostBookmarkExcerpt truncates the text of a comment to be used as
+// the excerpt of a bookmark.
+func truncatePostBookmarkExcerpt(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= maxPostBookmarkExcerpt {
+		return s
+	}
+	s = s[:maxPostBookmarkExcerpt]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "…"
+}
+
+// BookmarkPost bookmarks a received post or, if commentID is not nil, one of
+// its comments. Bookmarking an already bookmarked post or comment replaces its
+// tags and note.
+func (c *Client) BookmarkPost(postFrom UserID, pid clientintf.PostID,
+	commentID *clientintf.ID, tags []string, note string) (PostBookmark, error) {
+
+	bm := PostBookmark{
+		PostFrom: postFrom,
+		Post:     pid,
+		Comment:  commentID,
+		Note:     strings.TrimSpace(note),
+		Created:  time.Now(),
+	}
+	if len(tags) > 0 {
+		tagsAttr, err := rpc.FormatPostTags(tags)
+		if err != nil {
+			return bm, err
+		}
+		bm.Tags = rpc.ParsePostTags(tagsAttr)
+	}
+
+	pm, err := c.ReadPost(postFrom, pid)
+	if err != nil {
+		return bm, err
+	}
+	bm.Title = clientintf.PostTitle(&pm)
+	bm.AuthorNick = pm.Attributes[rpc.RMPFromNick]
+	if commentID != nil {
+		roots, err := c.PostCommentTree(postFrom, pid)
+		if err != nil {
+			return bm, err
+		}
+		cmt := findPostComment(roots, *commentID)
+		if cmt == nil {
+			return bm, fmt.Errorf("comment %s: %w", commentID, clientdb.ErrNotFound)
+		}
+		bm.Excerpt = truncatePostBookmarkExcerpt(cmt.Status.Attributes[rpc.RMPSComment])
+		bm.AuthorNick = cmt.Status.Attributes[rpc.RMPFromNick]
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		old, err := c.db.GetPostBookmark(tx, postFrom, pid, commentID)
+		if err == nil {
+			bm.Created = old.Created
+		} else if !errors.Is(err, clientdb.ErrNotFound) {
+			return err
+		}
+		return c.db.StorePostBookmark(tx, bm)
+	})
+	return bm, err
+}
+
+// RemovePostBookmark removes the bookmark of a post or, if commentID is not
+// nil, of one of its comments.
+func (c *Client) RemovePostBookmark(postFrom UserID, pid clientintf.PostID,
+	commentID *clientintf.ID) error {
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemovePostBookmark(tx, postFrom, pid, commentID)
+	})
+}
+
+// QueryPostBookmarks returns the post bookmarks selected by the query, from
+// newest to oldest.
+func (c *Client) QueryPostBookmarks(q PostBookmarkQuery) ([]PostBookmark, error) {
+	var bookmarks []PostBookmark
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		bookmarks, err = c.db.ListPostBookmarks(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]PostBookmark, 0, len(bookmarks))
+	for i := range bookmarks {
+		if !q.Matches(&bookmarks[i]) {
+			continue
+		}
+		res = append(res, bookmarks[i])
+		if q.Limit > 0 && len(res) >= q.Limit {
+			break
+		}
+	}
+	return res, nil
+}
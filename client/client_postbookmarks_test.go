@@ -0,0 +1,74 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// TestPostBookmarkQuery tests matching bookmarks against queries.
+func TestPostBookmarkQuery(t *testing.T) {
+	alice := clientintf.UserID{0: 0x01}
+	bob := clientintf.UserID{0: 0x02}
+	pid := clientintf.PostID{0: 0x03}
+	now := time.Now()
+
+	bm := PostBookmark{
+		PostFrom:   alice,
+		Post:       pid,
+		Title:      "Go Tips",
+		Excerpt:    "use contexts",
+		AuthorNick: "alice",
+		Tags:       []string{"go", "later"},
+		Note:       "Read this weekend",
+		Created:    now,
+	}
+
+	otherPid := clientintf.PostID{0: 0x04}
+	tests := []struct {
+		name  string
+		q     PostBookmarkQuery
+		match bool
+	}{
+		{"empty query", PostBookmarkQuery{}, true},
+		{"single tag", PostBookmarkQuery{Tags: []string{"go"}}, true},
+		{"normalized tag", PostBookmarkQuery{Tags: []string{"#Later"}}, true},
+		{"all tags", PostBookmarkQuery{Tags: []string{"go", "later"}}, true},
+		{"missing tag", PostBookmarkQuery{Tags: []string{"go", "rust"}}, false},
+		{"title text", PostBookmarkQuery{Text: "go tips"}, true},
+		{"excerpt text", PostBookmarkQuery{Text: "CONTEXTS"}, true},
+		{"note text", PostBookmarkQuery{Text: "weekend"}, true},
+		{"nick text", PostBookmarkQuery{Text: "ali"}, true},
+		{"missing text", PostBookmarkQuery{Text: "python"}, false},
+		{"post from", PostBookmarkQuery{PostFrom: &alice}, true},
+		{"other post from", PostBookmarkQuery{PostFrom: &bob}, false},
+		{"post", PostBookmarkQuery{Post: &pid}, true},
+		{"other post", PostBookmarkQuery{Post: &otherPid}, false},
+		{"since before", PostBookmarkQuery{Since: now.Add(-time.Hour)}, true},
+		{"since after", PostBookmarkQuery{Since: now}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.q.Matches(&bm); got != tc.match {
+				t.Fatalf("unexpected match: got %v, want %v", got, tc.match)
+			}
+		})
+	}
+}
+
+// TestTruncatePostBookmarkExcerpt tests truncating comment excerpts.
+func TestTruncatePostBookmarkExcerpt(t *testing.T) {
+	if got := truncatePostBookmarkExcerpt("  short  "); got != "short" {
+		t.Fatalf("unexpected excerpt: %q", got)
+	}
+	long := strings.Repeat("é", maxPostBookmarkExcerpt)
+	got := truncatePostBookmarkExcerpt(long)
+	if !strings.HasSuffix(got, "…") || len(got) > maxPostBookmarkExcerpt+len("…") {
+		t.Fatalf("unexpected excerpt len: %d", len(got))
+	}
+	if !strings.HasPrefix(long, strings.TrimSuffix(got, "…")) {
+		t.Fatalf("excerpt is not a prefix of the text")
+	}
+}
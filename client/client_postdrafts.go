@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// PostDraft is a post that has not been published yet.
+type PostDraft = clientdb.PostDraft
+
+// wakeScheduledPosts signals the scheduled posts publisher that the schedule
+// of post drafts changed.
+func (c *Client) wakeScheduledPosts() {
+	select {
+	case c.postDraftsChan <- struct{}{}:
+	default:
+	}
+}
+
+// SavePostDraft stores a post draft. If id is nil, a new draft is created,
+// otherwise the existing draft with the given id is replaced. If publishAt is
+// not nil, the draft is automatically published at that time (or as soon as
+// possible, if the time is in the past), including after the client restarts.
+func (c *Client) SavePostDraft(id *zkidentity.ShortID, post, descr string,
+	publishAt *time.Time) (PostDraft, error) {
+
+	draft := PostDraft{Post: post, Descr: descr, PublishAt: publishAt}
+	if post == "" {
+		return draft, errors.New("post cannot be empty")
+	}
+	if id == nil {
+		if _, err := rand.Read(draft.ID[:]); err != nil {
+			return draft, err
+		}
+	} else {
+		draft.ID = *id
+	}
+
+	c.postDraftsMtx.Lock()
+	defer c.postDraftsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if id != nil {
+			if _, err := c.db.GetPostDraft(tx, *id); err != nil {
+				return fmt.Errorf("post draft %s: %w", id, err)
+			}
+		}
+		var err error
+		draft, err = c.db.StorePostDraft(tx, draft)
+		return err
+	})
+	if err != nil {
+		return draft, err
+	}
+	if publishAt != nil {
+		c.log.Infof("Scheduled post draft %s to be published at %s",
+			draft.ID, publishAt.Format(time.RFC3339))
+	}
+	c.wakeScheduledPosts()
+	return draft, nil
+}
+
+// SchedulePostDraft sets the time the given post draft is automatically
+// published. If publishAt is nil, the draft is no longer scheduled to be
+// published.
+func (c *Client) SchedulePostDraft(id zkidentity.ShortID, publishAt *time.Time) error {
+	c.postDraftsMtx.Lock()
+	defer c.postDraftsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		draft, err := c.db.GetPostDraft(tx, id)
+		if err != nil {
+			return fmt.Errorf("post draft %s: %w", id, err)
+		}
+		draft.PublishAt = publishAt
+		_, err = c.db.StorePostDraft(tx, draft)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if publishAt != nil {
+		c.log.Infof("Scheduled post draft %s to be published at %s", id,
+			publishAt.Format(time.RFC3339))
+	} else {
+		c.log.Infof("Canceled schedule of post draft %s", id)
+	}
+	c.wakeScheduledPosts()
+	return nil
+}
+
+// GetPostDraft returns the post draft with the given id.
+func (c *Client) GetPostDraft(id zkidentity.ShortID) (PostDraft, error) {
+	var draft PostDraft
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		draft, err = c.db.GetPostDraft(tx, id)
+		return err
+	})
+	return draft, err
+}
+
+// ListPostDrafts lists the post drafts, including the ones scheduled to be
+// published.
+func (c *Client) ListPostDrafts() ([]PostDraft, error) {
+	var res []PostDraft
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListPostDrafts(tx)
+		return err
+	})
+	return res, err
+}
+
+// RemovePostDraft removes the given post draft, canceling its publication if
+// it was scheduled.
+func (c *Client) RemovePostDraft(id zkidentity.ShortID) error {
+	c.postDraftsMtx.Lock()
+	defer c.postDraftsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemovePostDraft(tx, id)
+	})
+	if err != nil {
+		return err
+	}
+	c.wakeScheduledPosts()
+	return nil
+}
+
+// PublishPostDraft publishes the given post draft, removing the draft.
+func (c *Client) PublishPostDraft(id zkidentity.ShortID) (clientdb.PostSummary, error) {
+	c.postDraftsMtx.Lock()
+	defer c.postDraftsMtx.Unlock()
+
+	var summ clientdb.PostSummary
+	draft, err := c.GetPostDraft(id)
+	if err != nil {
+		return summ, err
+	}
+	summ, err = c.CreatePost(draft.Post, draft.Descr)
+	if err != nil {
+		return summ, err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemovePostDraft(tx, id)
+	})
+	if err != nil {
+		return summ, err
+	}
+	c.log.Infof("Published post draft %s as post %s", id, summ.ID)
+	c.wakeScheduledPosts()
+	return summ, nil
+}
+
+// publishDuePostDrafts publishes the post drafts whose scheduled time has
+// passed. Returns the time of the next scheduled draft, if there is one.
+func (c *Client) publishDuePostDrafts() (*time.Time, error) {
+	drafts, err := c.ListPostDrafts()
+	if err != nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	now := time.Now()
+	for _, draft := range drafts {
+		if draft.PublishAt == nil {
+			continue
+		}
+		if draft.PublishAt.After(now) {
+			if next == nil || draft.PublishAt.Before(*next) {
+				next = draft.PublishAt
+			}
+			continue
+		}
+
+		summ, err := c.PublishPostDraft(draft.ID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			// Removed or published concurrently.
+			continue
+		}
+		if err != nil {
+			// Keep the draft, but do not attempt to publish it
+			// again.
+			c.log.Errorf("Unable to publish scheduled post draft %s: %v",
+				draft.ID, err)
+			if err := c.SchedulePostDraft(draft.ID, nil); err != nil {
+				c.log.Errorf("Unable to cancel schedule of post draft %s: %v",
+					draft.ID, err)
+			}
+		}
+		c.ntfns.notifyScheduledPostPublished(draft, summ, err)
+	}
+	return next, nil
+}
+
+// runScheduledPosts publishes the post drafts at their scheduled time.
+func (c *Client) runScheduledPosts(ctx context.Context) error {
+	for {
+		next, err := c.publishDuePostDrafts()
+		if err != nil {
+			c.log.Errorf("Unable to publish scheduled posts: %v", err)
+		}
+
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if next != nil {
+			timer = time.NewTimer(time.Until(*next))
+			timerChan = timer.C
+		}
+
+		select {
+		case <-timerChan:
+		case <-c.postDraftsChan:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
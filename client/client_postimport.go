@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// ImportPost creates a post from an item imported from an external source
+// (such as a blog feed) and shares it with all current subscribers. The import
+// ID uniquely identifies the item in its source: if an item with the same ID
+// was already imported, no post is created and the ID of the existing post is
+// returned along with false.
+func (c *Client) ImportPost(importID, title, post string, tags []string) (clientintf.PostID, bool, error) {
+	var existing clientdb.ImportedPost
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		existing, err = c.db.GetImportedPost(tx, importID)
+		return err
+	})
+	switch {
+	case err == nil:
+		return existing.Post, false, nil
+	case !errors.Is(err, clientdb.ErrNotFound):
+		return clientintf.PostID{}, false, err
+	}
+
+	extraAttrs := make(map[string]string)
+	if title != "" {
+		extraAttrs[rpc.RMPTitle] = title
+	}
+	if len(tags) > 0 {
+		tagsAttr, err := rpc.FormatPostTags(tags)
+		if err != nil {
+			return clientintf.PostID{}, false, err
+		}
+		extraAttrs[rpc.RMPTags] = tagsAttr
+	}
+
+	summ, createErr := c.createPost(post, "", extraAttrs)
+	if summ.ID.IsEmpty() {
+		return summ.ID, false, createErr
+	}
+
+	// Record the import even if sharing the post failed, so that it is
+	// not created again.
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RecordImportedPost(tx, importID, summ.ID)
+	})
+	if err != nil {
+		return summ.ID, true, err
+	}
+	c.log.Infof("Imported post %s from %q", summ.ID, importID)
+	return summ.ID, true, createErr
+}
+
+// GetImportedPost returns the record of the post imported with the given
+// import ID. Returns clientdb.ErrNotFound if no such post was imported.
+func (c *Client) GetImportedPost(importID string) (clientdb.ImportedPost, error) {
+	var res clientdb.ImportedPost
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.GetImportedPost(tx, importID)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/internal/imgproc"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+)
+
+// sharePostMedia shares the full version of an image embedded in a post as a
+// global file, so that it may be downloaded from the post.
+func (c *Client) sharePostMedia(data []byte, typ string) (mdembeds.EmbeddedArgs, error) {
+	var args mdembeds.EmbeddedArgs
+
+	// The file is named after its hash, so that sharing the same image
+	// again reuses the existing share.
+	ext := ".jpg"
+	if typ != imgproc.MimeType {
+		ext = "." + filepath.Base(typ)
+	}
+	hash := sha256.Sum256(data)
+	fname := "postmedia-" + hex.EncodeToString(hash[:8]) + ext
+
+	// The file is chunked into the db, so it is only needed in a temp dir
+	// while being shared.
+	dir, err := os.MkdirTemp("", "br-postmedia")
+	if err != nil {
+		return args, err
+	}
+	defer os.RemoveAll(dir)
+	fname = filepath.Join(dir, fname)
+	if err := os.WriteFile(fname, data, 0o600); err != nil {
+		return args, err
+	}
+
+	sf, md, err := c.ShareFile(fname, nil, c.cfg.PostMediaCost, "Post media")
+	if err != nil {
+		return args, err
+	}
+	args.Download = sf.FID
+	args.Filename = sf.Filename
+	args.Size = md.Size
+	args.Cost = md.Cost
+	return args, nil
+}
+
+// processPostMediaEmbed processes a single image embedded in a post, returning
+// the new embed string or an empty string if the embed should be left
+// unmodified.
+func (c *Client) processPostMediaEmbed(args mdembeds.EmbeddedArgs) (string, error) {
+	if !args.Download.IsEmpty() || !imgproc.IsSupportedType(args.Typ) ||
+		len(args.Data) <= c.cfg.PostMediaMaxInlineSize {
+		return "", nil
+	}
+
+	full, fullTyp, err := imgproc.Recompress(args.Data, args.Typ, 0,
+		imgproc.DefaultQuality)
+	if err != nil {
+		c.log.Warnf("Unable to recompress image embedded in post: %v", err)
+		return "", nil
+	}
+	alt := url.PathEscape(args.Alt)
+	if len(full) <= c.cfg.PostMediaMaxInlineSize {
+		c.log.Debugf("Recompressed image embedded in post from %d to %d "+
+			"bytes", len(args.Data), len(full))
+		res := mdembeds.EmbeddedArgs{Alt: alt, Typ: fullTyp, Data: full}
+		return res.String(), nil
+	}
+
+	thumb, err := imgproc.Thumbnail(full, c.cfg.PostMediaThumbnailDim,
+		imgproc.DefaultQuality)
+	if err != nil {
+		c.log.Warnf("Unable to generate thumbnail of image embedded in "+
+			"post: %v", err)
+		return "", nil
+	}
+	res, err := c.sharePostMedia(full, fullTyp)
+	if err != nil {
+		return "", err
+	}
+	c.log.Debugf("Replaced image embedded in post (%d bytes) with thumbnail "+
+		"(%d bytes) and shared file %s", len(args.Data), len(thumb),
+		res.Download)
+	res.Alt = alt
+	res.Typ = imgproc.MimeType
+	res.Data = thumb
+	return res.String(), nil
+}
+
+// processPostMedia processes the images embedded in a new post. Images that
+// are small enough are kept inline. Larger images are recompressed and, if
+// still too large, replaced by a thumbnail along with a link to download the
+// full (recompressed) image as a shared file.
+//
+// This is a no-op if post media processing is disabled. Embeds that cannot be
+// processed are left unmodified.
+func (c *Client) processPostMedia(post string) (string, error) {
+	if !c.cfg.PostMediaThumbnails {
+		return post, nil
+	}
+
+	var b strings.Builder
+	var last int
+	for _, idx := range mdembeds.FindAllStringIndex(post) {
+		args := mdembeds.ParseEmbedArgs(post[idx[0]:idx[1]])
+		embed, err := c.processPostMediaEmbed(args)
+		if err != nil {
+			return "", err
+		}
+		if embed == "" {
+			continue
+		}
+		b.WriteString(post[last:idx[0]])
+		b.WriteString(embed)
+		last = idx[1]
+	}
+	b.WriteString(post[last:])
+	return b.String(), nil
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// ModeratePostComment takes a moderation action (one of the
+// rpc.RMPSModeration* actions) on a comment of a post created by the local
+// client. The action is sent to the post's subscribers, who may choose to
+// honor it.
+func (c *Client) ModeratePostComment(pid clientintf.PostID, commentID clientintf.ID,
+	action string) error {
+
+	switch action {
+	case rpc.RMPSModerationHide, rpc.RMPSModerationUnhide:
+	case rpc.RMPSModerationFlag, rpc.RMPSModerationUnflag:
+	default:
+		return fmt.Errorf("unknown moderation action %q", action)
+	}
+
+	// Ensure the comment exists.
+	roots, err := c.PostCommentTree(c.PublicID(), pid)
+	if err != nil {
+		return err
+	}
+	if findPostComment(roots, commentID) == nil {
+		return fmt.Errorf("comment %s: %w", commentID, clientdb.ErrNotFound)
+	}
+
+	attr := map[string]string{
+		rpc.RMPSModeration:       action,
+		rpc.RMPSModerationTarget: commentID.String(),
+	}
+	_, err = c.sendPostStatus(c.PublicID(), pid, attr)
+	return err
+}
+
+// verifyPostModeration verifies that a moderation status update received from
+// a subscription was made by the author of the post.
+func (c *Client) verifyPostModeration(tx clientdb.ReadTx, postFrom, statusFrom UserID,
+	pid clientintf.PostID) error {
+
+	post, err := c.db.ReadPost(tx, postFrom, pid)
+	if err != nil {
+		return err
+	}
+	author := postFrom
+	if s := post.Attributes[rpc.RMPStatusFrom]; s != "" {
+		if err := author.FromString(s); err != nil {
+			return err
+		}
+	}
+	if statusFrom != author {
+		return fmt.Errorf("moderation of post %s not made by its author", pid)
+	}
+	return nil
+}
+
+// MutePostCommenter mutes (or unmutes) a user from commenting on the local
+// client's posts. Comments from muted users are neither stored nor sent to
+// subscribers.
+func (c *Client) MutePostCommenter(uid UserID, mute bool) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.MutePostCommenter(tx, uid, mute)
+	})
+}
+
+// ListMutedPostCommenters returns the users muted from commenting on the local
+// client's posts, along with the time they were muted.
+func (c *Client) ListMutedPostCommenters() (map[UserID]time.Time, error) {
+	var res map[UserID]time.Time
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListMutedPostCommenters(tx)
+		return err
+	})
+	return res, err
+}
+
+// isPostCommenterMuted returns true if the user is muted from commenting on
+// the local client's posts.
+func (c *Client) isPostCommenterMuted(uid UserID) bool {
+	var muted bool
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		muted, err = c.db.IsPostCommenterMuted(tx, uid)
+		return err
+	})
+	if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+		c.log.Warnf("Unable to check if %s is muted from commenting: %v",
+			uid, err)
+	}
+	return muted
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// PostUnlock is the record of unlocking the paywalled body of a post.
+type PostUnlock = clientdb.PostUnlock
+
+// PostPaywall returns the link to download the paywalled body of the post, or
+// nil if the post is not paywalled.
+//
+// The link is an embed in the main content of the post, which is signed by
+// its author, so that relayers cannot change the price of the post.
+func PostPaywall(pm *rpc.PostMetadata) *mdembeds.EmbeddedArgs {
+	var fid clientdb.FileID
+	if err := fid.FromString(pm.Attributes[rpc.RMPPaywall]); err != nil {
+		return nil
+	}
+	main := pm.Attributes[rpc.RMPMain]
+	for _, idx := range mdembeds.FindAllStringIndex(main) {
+		args := mdembeds.ParseEmbedArgs(main[idx[0]:idx[1]])
+		if args.Download == fid {
+			return &args
+		}
+	}
+	return nil
+}
+
+// CreatePaywalledPost creates a new post where only the summary is free. The
+// body is shared as a file that costs price atoms to download from the local
+// client, and is displayed as the content of the post once downloaded.
+func (c *Client) CreatePaywalledPost(summary, body, descr string, price uint64,
+	tags []string) (clientdb.PostSummary, error) {
+
+	var summ clientdb.PostSummary
+	if strings.TrimSpace(summary) == "" {
+		return summ, errors.New("post summary cannot be empty")
+	}
+	if strings.TrimSpace(body) == "" {
+		return summ, errors.New("post body cannot be empty")
+	}
+	if price == 0 {
+		return summ, errors.New("price of paywalled post cannot be zero")
+	}
+
+	// The body is chunked into the db, so it is only needed in a temp dir
+	// while being shared.
+	dir, err := os.MkdirTemp("", "br-postbody")
+	if err != nil {
+		return summ, err
+	}
+	defer os.RemoveAll(dir)
+	hash := sha256.Sum256([]byte(body))
+	fname := filepath.Join(dir, "post-"+hex.EncodeToString(hash[:8])+".md")
+	if err := os.WriteFile(fname, []byte(body), 0o600); err != nil {
+		return summ, err
+	}
+	sf, md, err := c.ShareFile(fname, nil, price, "Paywalled post body")
+	if err != nil {
+		return summ, err
+	}
+
+	link := mdembeds.EmbeddedArgs{
+		Alt:      url.PathEscape("Unlock the full post"),
+		Download: sf.FID,
+		Filename: sf.Filename,
+		Size:     md.Size,
+		Cost:     md.Cost,
+	}
+	post := strings.TrimSpace(summary) + "\n\n" + link.String() + "\n"
+
+	extraAttrs := map[string]string{rpc.RMPPaywall: sf.FID.String()}
+	if len(tags) > 0 {
+		tagsAttr, err := rpc.FormatPostTags(tags)
+		if err != nil {
+			return summ, err
+		}
+		extraAttrs[rpc.RMPTags] = tagsAttr
+	}
+	summ, err = c.createPost(post, descr, extraAttrs)
+	if err != nil {
+		return summ, err
+	}
+
+	// The local client does not need to pay to read its own posts.
+	now := time.Now()
+	unlock := PostUnlock{
+		Post:      summ.ID,
+		Author:    c.PublicID(),
+		FID:       sf.FID,
+		MaxCost:   md.Cost,
+		Requested: now,
+		Completed: &now,
+		Body:      body,
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePostUnlock(tx, unlock)
+	})
+	return summ, err
+}
+
+// UnlockPost starts downloading the paywalled body of the given post from its
+// author, paying the price advertised in the post. The author must be a known
+// user. The OnPostUnlocked notification is called once the body is received.
+func (c *Client) UnlockPost(postFrom UserID, pid clientintf.PostID) error {
+	pm, err := c.ReadPost(postFrom, pid)
+	if err != nil {
+		return err
+	}
+	link := PostPaywall(&pm)
+	if link == nil {
+		return fmt.Errorf("post %s is not paywalled", pid)
+	}
+	var author UserID
+	if err := author.FromString(pm.Attributes[rpc.RMPStatusFrom]); err != nil {
+		return fmt.Errorf("post %s does not have a valid author: %v", pid, err)
+	}
+	ru, err := c.rul.byID(author)
+	if err != nil {
+		return fmt.Errorf("unable to unlock post %s from unknown author: %w",
+			pid, err)
+	}
+
+	var alreadyUnlocked bool
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		old, err := c.db.GetPostUnlock(tx, pid)
+		if err == nil && old.Completed != nil {
+			alreadyUnlocked = true
+			return nil
+		}
+		unlock := PostUnlock{
+			Post:      pid,
+			Author:    author,
+			FID:       link.Download,
+			MaxCost:   link.Cost,
+			Requested: time.Now(),
+		}
+		return c.db.StorePostUnlock(tx, unlock)
+	})
+	if err != nil || alreadyUnlocked {
+		return err
+	}
+
+	ru.log.Infof("Unlocking post %s for %s", pid, dcrutil.Amount(link.Cost))
+	return c.GetUserContent(author, link.Download)
+}
+
+// GetPostUnlock returns the unlock record of the given post. Returns
+// ErrNotFound if unlocking the post was never requested.
+func (c *Client) GetPostUnlock(pid clientintf.PostID) (PostUnlock, error) {
+	var unlock PostUnlock
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		unlock, err = c.db.GetPostUnlock(tx, pid)
+		return err
+	})
+	return unlock, err
+}
+
+// findPostUnlock returns the pending unlock record of the post whose body is
+// the given file shared by the user, if there is one.
+func (c *Client) findPostUnlock(uid UserID, fid clientdb.FileID) (*PostUnlock, error) {
+	var unlock PostUnlock
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		unlock, err = c.db.FindPostUnlock(tx, uid, fid)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &unlock, nil
+}
+
+// maybeHandlePostUnlockDownload stores the body of a paywalled post, if the
+// completed download is the body of a post being unlocked.
+func (c *Client) maybeHandlePostUnlockDownload(ru *RemoteUser, fid clientdb.FileID,
+	diskPath string) error {
+
+	unlock, err := c.findPostUnlock(ru.ID(), fid)
+	if unlock == nil || err != nil {
+		return err
+	}
+
+	body, err := os.ReadFile(diskPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	unlock.Completed = &now
+	unlock.Body = string(body)
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StorePostUnlock(tx, *unlock)
+	})
+	if err != nil {
+		return err
+	}
+
+	ru.log.Infof("Unlocked post %s", unlock.Post)
+	c.ntfns.notifyPostUnlocked(ru, *unlock)
+	return nil
+}
+
+// maybeAutoUnlockPost starts unlocking a newly received paywalled post if its
+// price is within the configured limit for automatically unlocking posts.
+func (c *Client) maybeAutoUnlockPost(from UserID, pid clientintf.PostID, pm *rpc.PostMetadata) {
+	if c.cfg.PostAutoUnlockMaxCost == 0 {
+		return
+	}
+	link := PostPaywall(pm)
+	if link == nil || link.Cost > c.cfg.PostAutoUnlockMaxCost {
+		return
+	}
+	go func() {
+		if err := c.UnlockPost(from, pid); err != nil {
+			c.log.Warnf("Unable to automatically unlock post %s: %v",
+				pid, err)
+		}
+	}()
+}
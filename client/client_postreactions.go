@@ -0,0 +1,109 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// PostReaction is the aggregated count of a single reaction on a post.
+type PostReaction struct {
+	// Reaction is the reaction (usually an emoji).
+	Reaction string
+
+	// Users are the users that currently have this reaction on the post,
+	// in the order they first reacted.
+	Users []clientintf.UserID
+}
+
+// AggregatePostReactions aggregates the reactions in the given status updates
+// of a post. Reactions later removed by the same user are not counted. The
+// result is sorted by number of users (descending), then by reaction.
+func AggregatePostReactions(statuses []rpc.PostMetadataStatus) []PostReaction {
+	byReaction := make(map[string][]clientintf.UserID)
+	for _, status := range statuses {
+		add := status.Attributes[rpc.RMPSReaction]
+		remove := status.Attributes[rpc.RMPSReactionRemove]
+		if add == "" && remove == "" {
+			continue
+		}
+
+		var from clientintf.UserID
+		if err := from.FromString(status.From); err != nil {
+			continue
+		}
+
+		if remove != "" {
+			users := byReaction[remove]
+			for i := range users {
+				if users[i] == from {
+					byReaction[remove] = append(users[:i], users[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+
+		users := byReaction[add]
+		found := false
+		for i := range users {
+			found = found || users[i] == from
+		}
+		if !found {
+			byReaction[add] = append(users, from)
+		}
+	}
+
+	res := make([]PostReaction, 0, len(byReaction))
+	for reaction, users := range byReaction {
+		if len(users) == 0 {
+			continue
+		}
+		res = append(res, PostReaction{Reaction: reaction, Users: users})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i].Users) != len(res[j].Users) {
+			return len(res[i].Users) > len(res[j].Users)
+		}
+		return res[i].Reaction < res[j].Reaction
+	})
+	return res
+}
+
+// ReactToPost sends a status update adding (or removing, if remove is true)
+// the given reaction from the local client on the post. Reactions are
+// lightweight feedback that, unlike comments, are aggregated per post.
+func (c *Client) ReactToPost(postFrom clientintf.UserID, pid clientintf.PostID,
+	reaction string, remove bool) error {
+
+	reaction = strings.TrimSpace(reaction)
+	if reaction == "" {
+		return fmt.Errorf("reaction cannot be empty")
+	}
+	if len(reaction) > rpc.MaxReactionLen {
+		return fmt.Errorf("reaction is too long (%d > %d)", len(reaction),
+			rpc.MaxReactionLen)
+	}
+
+	key := rpc.RMPSReaction
+	if remove {
+		key = rpc.RMPSReactionRemove
+	}
+	attr := map[string]string{
+		key: reaction,
+	}
+	_, err := c.sendPostStatus(postFrom, pid, attr)
+	return err
+}
+
+// PostReactions returns the aggregated reactions on the given post.
+func (c *Client) PostReactions(postFrom clientintf.UserID, pid clientintf.PostID) ([]PostReaction, error) {
+	statuses, err := c.ListPostStatusUpdates(postFrom, pid)
+	if err != nil {
+		return nil, err
+	}
+	return AggregatePostReactions(statuses), nil
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestAggregatePostReactions tests aggregating the reactions of a post.
+func TestAggregatePostReactions(t *testing.T) {
+	alice := clientintf.UserID{0: 0x01}
+	bob := clientintf.UserID{0: 0x02}
+	charlie := clientintf.UserID{0: 0x03}
+
+	var statuses []rpc.PostMetadataStatus
+	add := func(from clientintf.UserID, key, reaction string) {
+		statuses = append(statuses, rpc.PostMetadataStatus{
+			Version:    rpc.PostMetadataStatusVersion,
+			From:       from.String(),
+			Attributes: map[string]string{key: reaction},
+		})
+	}
+
+	add(alice, rpc.RMPSReaction, "👍")
+	add(bob, rpc.RMPSReaction, "👍")
+	add(bob, rpc.RMPSReaction, "🎉")
+	add(charlie, rpc.RMPSReaction, "🎉")
+	add(charlie, rpc.RMPSReaction, "👀")
+	add(charlie, rpc.RMPSReactionRemove, "👀")
+	add(alice, rpc.RMPSReaction, "👍") // Duplicate is ignored.
+	add(charlie, rpc.RMPSComment, "not a reaction")
+	add(alice, rpc.RMPSReactionRemove, "🔥") // Unknown removal is ignored.
+
+	got := AggregatePostReactions(statuses)
+	if len(got) != 2 {
+		t.Fatalf("unexpected nb of reactions: got %d, want 2", len(got))
+	}
+
+	// Reactions with the same count are sorted by reaction.
+	first, second := "👍", "🎉"
+	if first > second {
+		first, second = second, first
+	}
+	if got[0].Reaction != first || got[1].Reaction != second {
+		t.Fatalf("unexpected order: %q %q", got[0].Reaction, got[1].Reaction)
+	}
+	for _, r := range got {
+		if len(r.Users) != 2 {
+			t.Fatalf("unexpected nb of users on %q: %d", r.Reaction, len(r.Users))
+		}
+	}
+
+	// Removing bob's thumbs up moves it after the party reaction.
+	add(bob, rpc.RMPSReactionRemove, "👍")
+	got = AggregatePostReactions(statuses)
+	if got[0].Reaction != "🎉" || len(got[1].Users) != 1 || got[1].Users[0] != alice {
+		t.Fatalf("unexpected reactions after removal: %v", got)
+	}
+}
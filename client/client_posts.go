@@ -12,6 +12,7 @@ import (
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/rpc"
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
 )
 
 // subscribeToPosts subscribes to the given user posts, optionally fetching
@@ -46,11 +47,18 @@ func (c *Client) SubscribeToPostsAndFetch(uid UserID, pid clientintf.PostID) err
 func (c *Client) handlePostsSubscribe(ru *RemoteUser, ps rpc.RMPostsSubscribe) error {
 	var post rpc.PostMetadata
 	var updates []rpc.PostMetadataStatus
+	var pinned []pinnedPost
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		err := c.db.SubscribeToPosts(tx, ru.ID())
 		if err != nil {
 			return err
 		}
+		if err := c.db.RecordPostSubscriberEvent(tx, ru.ID(), true); err != nil {
+			return err
+		}
+		if pinned, err = c.readPinnedPosts(tx); err != nil {
+			return err
+		}
 
 		if ps.GetPost == nil {
 			return nil
@@ -89,7 +97,21 @@ func (c *Client) handlePostsSubscribe(ru *RemoteUser, ps rpc.RMPostsSubscribe) e
 		return err
 	}
 
-	if errMsg != nil || ps.GetPost == nil {
+	if errMsg != nil {
+		return nil
+	}
+
+	// Send pinned posts first, so that they are not buried by newer
+	// posts.
+	sentGetPost := false
+	for _, pp := range pinned {
+		if err := c.sendPostToUser(ru, pp.pid, pp.post, pp.updates); err != nil {
+			return err
+		}
+		sentGetPost = sentGetPost || (ps.GetPost != nil && *ps.GetPost == pp.pid)
+	}
+
+	if ps.GetPost == nil || sentGetPost {
 		return nil
 	}
 
@@ -150,7 +172,10 @@ func (c *Client) UnsubscribeToPosts(uid UserID) error {
 // unsubRemoteFromLocalPosts unsubscribes a remote user from local posts.
 func (c *Client) unsubRemoteFromLocalPosts(ru *RemoteUser, replyEvenIfErr bool) error {
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
-		return c.db.UnsubscribeToPosts(tx, ru.ID())
+		if err := c.db.UnsubscribeToPosts(tx, ru.ID()); err != nil {
+			return err
+		}
+		return c.db.RecordPostSubscriberEvent(tx, ru.ID(), false)
 	})
 
 	if err != nil && !errors.Is(err, clientdb.ErrNotSubscribed) {
@@ -245,9 +270,14 @@ func (c *Client) ListUserPosts(uid UserID) error {
 
 func (c *Client) handleListPosts(ru *RemoteUser, lp rpc.RMListPosts) error {
 	var posts []rpc.PostMetadata
+	var pinned []clientintf.PostID
 	err := c.dbView(func(tx clientdb.ReadTx) error {
 		var err error
 		posts, err = c.db.ListUserPosts(tx, c.PublicID())
+		if err != nil {
+			return err
+		}
+		pinned, err = c.db.ListPinnedPosts(tx)
 		return err
 	})
 	if err != nil {
@@ -264,10 +294,14 @@ func (c *Client) handleListPosts(ru *RemoteUser, lp rpc.RMListPosts) error {
 		if err := id.FromString(p.Attributes[rpc.RMPIdentifier]); err != nil {
 			continue
 		}
-		rm.Posts = append(rm.Posts, rpc.PostListItem{
+		item := rpc.PostListItem{
 			ID:    id,
 			Title: clientintf.PostTitle(&p),
-		})
+		}
+		rm.Posts = append(rm.Posts, item)
+		if slices.Contains(pinned, id) {
+			rm.Pinned = append(rm.Pinned, item)
+		}
 	}
 
 	return ru.sendRM(rm, "posts.listreply")
@@ -324,19 +358,47 @@ func (c *Client) shareWithPostSubscribers(subs []clientintf.UserID,
 }
 
 // CreatePost creates a new post and shares it with all current subscribers.
+// If post media processing is enabled, large images embedded in the post are
+// replaced by thumbnails before the post is created.
 func (c *Client) CreatePost(post, descr string) (clientdb.PostSummary, error) {
+	return c.CreateTaggedPost(post, descr, nil)
+}
+
+// CreateTaggedPost creates a new post with the given tags and shares it with
+// all current subscribers. Subscribers may use the tags to filter which posts
+// they accept.
+func (c *Client) CreateTaggedPost(post, descr string, tags []string) (clientdb.PostSummary, error) {
+	extraAttrs := make(map[string]string)
+	if len(tags) > 0 {
+		tagsAttr, err := rpc.FormatPostTags(tags)
+		if err != nil {
+			return clientdb.PostSummary{}, err
+		}
+		extraAttrs[rpc.RMPTags] = tagsAttr
+	}
+	return c.createPost(post, descr, extraAttrs)
+}
+
+// createPost creates a new post with the given extra attributes and shares it
+// with all current subscribers.
+func (c *Client) createPost(post, descr string, extraAttrs map[string]string) (clientdb.PostSummary, error) {
 	// Filename for embedded data is not currently used, so it's disabled at
 	// the client API level.
 	const fname = ""
 
+	post, err := c.processPostMedia(post)
+	if err != nil {
+		return clientdb.PostSummary{}, fmt.Errorf("unable to process post media: %w", err)
+	}
+
 	me := c.Public()
 	var pm rpc.PostMetadata
 	var subs []clientdb.UserID
 	var summ clientdb.PostSummary
-	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
-		summ, pm, err = c.db.CreatePost(tx, post, descr, fname, nil,
-			&me, c.localID.signMessage)
+		summ, pm, err = c.db.CreatePost(tx, post, descr, fname,
+			extraAttrs, &me, c.localID.signMessage)
 		if err != nil {
 			return err
 		}
@@ -476,6 +538,13 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 	errStatusWithoutPost := errors.New("status without post")
 	errHaveCopyFromAuthor := errors.New("have post copy from author")
 	errFilter := errors.New("filtered post/comment")
+	errHaveRelayedCopy := errors.New("have relayed copy of post")
+	errHavePost := errors.New("have post")
+
+	// Posts relayed with commentary are stored even if the post was
+	// already received from its author, as the commentary only exists in
+	// the relayed copy.
+	isQuote := p.Attributes[rpc.RMPRelayComment] != "" && !rpc.IsPostStatus(p.Attributes)
 
 	var summ clientdb.PostSummary
 	var isUpdate bool
@@ -493,6 +562,10 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 			return err
 		} else if !exists && rpc.IsPostStatus(p.Attributes) {
 			return errStatusWithoutPost
+		} else if exists && isQuote {
+			return errHaveRelayedCopy
+		} else if exists && !rpc.IsPostStatus(p.Attributes) {
+			return errHavePost
 		} else if exists {
 			// Verify post status signature.
 			pms := rpc.PostMetadataStatus{
@@ -513,6 +586,17 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 				return fmt.Errorf("invalid statusfrom field: %v", err)
 			}
 
+			// Moderation actions are only accepted from the post
+			// author and only if not ignored.
+			if p.Attributes[rpc.RMPSModeration] != "" {
+				if c.cfg.IgnorePostModeration {
+					return errFilter
+				}
+				if err := c.verifyPostModeration(tx, from, statusFrom, pid); err != nil {
+					return err
+				}
+			}
+
 			// Check if content should be filtered (don't filter
 			// the client's own contents).
 			comment := p.Attributes[rpc.RMPSComment]
@@ -543,6 +627,16 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 			return err
 		}
 
+		// Verify the commentary attached by the relayer.
+		if isQuote {
+			if err := c.verifyPostRelayComment(ru, pid, &p); err != nil {
+				return err
+			}
+		} else {
+			delete(p.Attributes, rpc.RMPRelayComment)
+			delete(p.Attributes, rpc.RMPRelaySignature)
+		}
+
 		// If we received this post from its author, remove the relayed
 		// copies.
 		var postAuthor UserID
@@ -555,7 +649,18 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 				return err
 			}
 		}
+		if postAuthor == c.PublicID() && from != postAuthor {
+			// Post made by the local client relayed by one of its
+			// subscribers.
+			if err := c.recordPostRelay(tx, pid, from); err != nil {
+				return err
+			}
+		}
 		if !postAuthor.IsEmpty() && from != postAuthor {
+			// Only the author may mark a post as pinned.
+			delete(p.Attributes, rpc.RMPPinned)
+		}
+		if !postAuthor.IsEmpty() && from != postAuthor && !isQuote {
 			if exists, _ := c.db.PostExists(tx, postAuthor, pid); exists {
 				// Ignore relayed copy of the post in favor of
 				// the one from the author.
@@ -568,6 +673,18 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 			return errFilter
 		}
 
+		// Check if the post is filtered by its tags.
+		author := postAuthor
+		if author.IsEmpty() {
+			author = from
+		}
+		if filter, err := c.filterPostTags(tx, author, &p); err != nil {
+			return err
+		} else if filter {
+			ru.log.Debugf("Filtered post %s by its tags", pid)
+			return errFilter
+		}
+
 		// Post does not exist. Save it.
 		pid, summ, err = c.db.SaveReceivedPost(tx, from, p)
 		return err
@@ -600,12 +717,31 @@ func (c *Client) handlePostShare(ru *RemoteUser, ps rpc.RMPostShare) error {
 		if errors.Is(err, errFilter) {
 			return nil
 		}
+
+		// Relaying a post with commentary after relaying it without is
+		// not supported.
+		if errors.Is(err, errHaveRelayedCopy) {
+			ru.log.Debugf("Ignoring relayed copy with commentary of post "+
+				"%s already relayed by user", pid)
+			return nil
+		}
+
+		// Posts may be sent again (for example, pinned posts sent
+		// after subscribing again).
+		if errors.Is(err, errHavePost) {
+			ru.log.Debugf("Ignoring already received post %s", pid)
+			return nil
+		}
 		return err
 	}
 
-	if !isUpdate {
+	if !isUpdate && isQuote {
+		ru.log.Infof("Received post %s relayed with comment", pid)
+		c.ntfns.notifyOnPostRcvd(ru, summ, p)
+	} else if !isUpdate {
 		ru.log.Infof("Received post %s", pid)
 		c.ntfns.notifyOnPostRcvd(ru, summ, p)
+		c.maybeAutoUnlockPost(from, pid, &p)
 	} else {
 		ru.log.Infof("Received post update %s from %s", pid, statusFrom)
 		c.ntfns.notifyOnPostStatusRcvd(ru, pid, statusFrom, update)
@@ -669,6 +805,18 @@ func (c *Client) addStatusToPost(statusFrom clientintf.UserID, pms *rpc.PostMeta
 		return fmt.Errorf("specified link is not a post ID: %v", err)
 	}
 
+	// Only the post author may moderate comments.
+	if !statusFromMe && pms.Attributes[rpc.RMPSModeration] != "" {
+		return fmt.Errorf("moderation of post %s not made by its author", pid)
+	}
+
+	// Drop comments from muted commenters.
+	if !statusFromMe && pms.Attributes[rpc.RMPSComment] != "" && c.isPostCommenterMuted(statusFrom) {
+		c.log.Debugf("Ignoring comment on post %s from muted commenter %s",
+			pid, statusFrom)
+		return nil
+	}
+
 	// Verify if the status is filtered.
 	if !statusFromMe && pms.Attributes[rpc.RMPSComment] != "" {
 		comment := pms.Attributes[rpc.RMPSComment]
@@ -683,10 +831,24 @@ func (c *Client) addStatusToPost(statusFrom clientintf.UserID, pms *rpc.PostMeta
 	// Add status to DB (this validates the status udpate against
 	// the DB as well).
 	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		statuses, err := c.db.ListPostStatusUpdates(tx, postFrom, pid)
+		if err != nil {
+			return err
+		}
+		if err := verifyCommentParent(statuses, pms); err != nil {
+			return err
+		}
 		if err := c.db.AddPostStatus(tx, postFrom, statusFrom, pid, pms); err != nil {
 			return err
 		}
-		var err error
+		if !statusFromMe {
+			err := c.db.UpdatePostStats(tx, pid, func(stats *PostStats) {
+				applyPostStatusToStats(stats, pms)
+			})
+			if err != nil {
+				return err
+			}
+		}
 		subs, err = c.db.ListPostSubscribers(tx)
 		return err
 	})
@@ -717,6 +879,12 @@ func (c *Client) addStatusToPost(statusFrom clientintf.UserID, pms *rpc.PostMeta
 		statusType = "comment"
 	} else if _, ok := attr[rpc.RMPSHeart]; ok {
 		statusType = "heart"
+	} else if _, ok := attr[rpc.RMPSReaction]; ok {
+		statusType = "reaction"
+	} else if _, ok := attr[rpc.RMPSReactionRemove]; ok {
+		statusType = "reaction removal"
+	} else if _, ok := attr[rpc.RMPSModeration]; ok {
+		statusType = "moderation"
 	}
 	c.log.Infof("New %s %x from %s on post %s", statusType, pms.Hash(), fromStr, pid)
 
@@ -847,7 +1015,8 @@ func (c *Client) HeartPost(from clientintf.UserID, pid clientintf.PostID, heart
 	return err
 }
 
-// CommentPost sends a comment status update on the received post.
+// CommentPost sends a comment status update on the received post. If parent
+// is specified, the comment is a reply to that comment.
 func (c *Client) CommentPost(postFrom clientintf.UserID, pid clientintf.PostID,
 	comment string, parent *clientintf.ID) (clientintf.ID, error) {
 
@@ -856,6 +1025,18 @@ func (c *Client) CommentPost(postFrom clientintf.UserID, pid clientintf.PostID,
 	}
 	if parent != nil {
 		attr[rpc.RMPParent] = parent.String()
+
+		// Fail early when replying to comments that do not allow
+		// replies. Comments not known locally are verified by the
+		// post author.
+		statuses, err := c.ListPostStatusUpdates(postFrom, pid)
+		if err != nil {
+			return clientintf.ID{}, err
+		}
+		pms := rpc.PostMetadataStatus{Attributes: attr}
+		if err := verifyCommentParent(statuses, &pms); err != nil {
+			return clientintf.ID{}, err
+		}
 	}
 	return c.sendPostStatus(postFrom, pid, attr)
 }
@@ -1029,12 +1210,17 @@ func (c *Client) handleGetPost(ru *RemoteUser, gp rpc.RMGetPost) error {
 	return c.sendPostToUser(ru, gp.ID, post, updates)
 }
 
-// relayPost relays the post to the specified users.
+// relayPost relays the post to the specified users. If comment is not empty,
+// it is attached to the relayed post as the commentary of the local client.
 func (c *Client) relayPost(postFrom clientintf.UserID, pid clientintf.PostID,
-	users ...clientintf.UserID) error {
+	comment string, users ...clientintf.UserID) error {
+
+	if len(comment) > rpc.MaxPostRelayCommentLen {
+		return fmt.Errorf("relay comment is too long (%d > %d)",
+			len(comment), rpc.MaxPostRelayCommentLen)
+	}
 
 	var post rpc.PostMetadata
-	var updates []rpc.PostMetadataStatus
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		var err error
 		post, err = c.db.ReadPost(tx, postFrom, pid)
@@ -1044,13 +1230,29 @@ func (c *Client) relayPost(postFrom clientintf.UserID, pid clientintf.PostID,
 		return err
 	}
 
+	// The commentary of whoever relayed the post to the local client is
+	// not relayed further.
+	delete(post.Attributes, rpc.RMPRelayComment)
+	delete(post.Attributes, rpc.RMPRelaySignature)
+	if comment != "" {
+		hash := rpc.PostRelayCommentHash(pid, comment)
+		signature := c.localID.signMessage(hash[:])
+		post.Attributes[rpc.RMPRelayComment] = comment
+		post.Attributes[rpc.RMPRelaySignature] = hex.EncodeToString(signature[:])
+	}
+
 	// Log the event.
 	var from interface{}
 	if from, err = c.rul.byID(postFrom); err != nil {
 		from = postFrom
 	}
-	c.log.Infof("Relaying post %s from %s to %d subscribers",
-		pid, len(updates), from, len(users))
+	if comment != "" {
+		c.log.Infof("Relaying post %s from %s with comment to %d subscribers",
+			pid, from, len(users))
+	} else {
+		c.log.Infof("Relaying post %s from %s to %d subscribers",
+			pid, from, len(users))
+	}
 
 	// Relay post.
 	rm := rpc.RMPostShare(post)
@@ -1079,7 +1281,7 @@ func (c *Client) RelayPost(postFrom clientintf.UserID, pid clientintf.PostID,
 		return err
 	}
 
-	return c.relayPost(postFrom, pid, toUser)
+	return c.relayPost(postFrom, pid, "", toUser)
 }
 
 // RelayPostToSubscribers relays the specified post to all current post
@@ -1095,10 +1297,74 @@ func (c *Client) RelayPostToSubscribers(postFrom clientintf.UserID, pid clientin
 		return err
 	}
 
-	return c.relayPost(postFrom, pid, subs...)
+	return c.relayPost(postFrom, pid, "", subs...)
+}
+
+// QuoteRelayPost relays the specified post to all current post subscribers,
+// attaching the given comment to it. The comment is signed by the local client
+// and displayed by subscribers alongside the post, attributed to the local
+// client.
+func (c *Client) QuoteRelayPost(postFrom clientintf.UserID, pid clientintf.PostID,
+	comment string) error {
+
+	if strings.TrimSpace(comment) == "" {
+		return errors.New("relay comment cannot be empty")
+	}
+
+	var subs []clientintf.UserID
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		subs, err = c.db.ListPostSubscribers(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.relayPost(postFrom, pid, comment, subs...)
+}
+
+// verifyPostRelayComment returns an error if the commentary attached to a
+// post relayed by the given user is not signed by that user.
+func (c *Client) verifyPostRelayComment(ru *RemoteUser, pid clientintf.PostID,
+	p *rpc.PostMetadata) error {
+
+	comment := p.Attributes[rpc.RMPRelayComment]
+	if len(comment) > rpc.MaxPostRelayCommentLen {
+		return fmt.Errorf("relay comment is too long (%d > %d)",
+			len(comment), rpc.MaxPostRelayCommentLen)
+	}
+
+	var sig zkidentity.FixedSizeSignature
+	sigStr := p.Attributes[rpc.RMPRelaySignature]
+	if len(sigStr) != len(sig)*2 {
+		return fmt.Errorf("relay signature has wrong len (%d != %d)",
+			len(sigStr), len(sig)*2)
+	}
+	if _, err := hex.Decode(sig[:], []byte(sigStr)); err != nil {
+		return fmt.Errorf("unable to decode relay signature: %v", err)
+	}
+
+	hash := rpc.PostRelayCommentHash(pid, comment)
+	if !ru.verifyMessage(hash[:], &sig) {
+		return fmt.Errorf("relay signature verification failed")
+	}
+	return nil
 }
 
 func (c *Client) handleReceiveReceipt(ru *RemoteUser, rr rpc.RMReceiveReceipt, serverTime time.Time) error {
+	if rr.Domain == rpc.ReceiptDomainMessages {
+		// Receipts for chat messages are not stored, only used to
+		// alert about the delivery status of the message.
+		if rr.ID == nil {
+			return fmt.Errorf("message receive receipt with nil ID")
+		}
+		ru.log.Debugf("Received receive receipt for message %s", rr.ID)
+		c.handleMsgStatus(ru, *rr.ID, MsgStatusDelivered, nil)
+		c.ntfns.notifyReceiveReceipt(ru, rr, serverTime)
+		return nil
+	}
+
 	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
 		return c.db.StoreReceiveReceipt(tx, ru.ID(), c.PublicID(), &rr, serverTime)
 	})
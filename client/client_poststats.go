@@ -0,0 +1,165 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+	"golang.org/x/exp/slices"
+)
+
+// PostStats are the metrics tracked for a post created by the local client.
+type PostStats = clientdb.PostStats
+
+// PostSubscriberEvent is an event of a remote user subscribing or
+// unsubscribing to the posts of the local client.
+type PostSubscriberEvent = clientdb.PostSubscriberEvent
+
+// PostSubscriberChurn summarizes the changes to the list of subscribers to
+// the posts of the local client over a period of time.
+type PostSubscriberChurn struct {
+	// Since is the start of the period.
+	Since time.Time
+
+	// Subscribed and Unsubscribed are the number of times users
+	// subscribed and unsubscribed during the period.
+	Subscribed   int
+	Unsubscribed int
+
+	// Subscribers is the current number of subscribers.
+	Subscribers int
+
+	// Events are the individual events of the period.
+	Events []PostSubscriberEvent
+}
+
+// applyPostStatusToStats updates the stats of a post with a status update
+// received from a remote user.
+func applyPostStatusToStats(stats *PostStats, pms *rpc.PostMetadataStatus) {
+	attr := pms.Attributes
+	switch {
+	case attr[rpc.RMPSComment] != "":
+		stats.Comments += 1
+	case attr[rpc.RMPSHeart] == rpc.RMPSHeartYes:
+		stats.Hearts += 1
+	case attr[rpc.RMPSHeart] == rpc.RMPSHeartNo:
+		stats.Hearts -= 1
+	case attr[rpc.RMPSReaction] != "":
+		stats.Reactions += 1
+	case attr[rpc.RMPSReactionRemove] != "":
+		stats.Reactions -= 1
+	}
+}
+
+// recordPostRelay records that a post created by the local client was relayed
+// by the given user.
+func (c *Client) recordPostRelay(tx clientdb.ReadWriteTx, pid clientintf.PostID, relayer UserID) error {
+	return c.db.UpdatePostStats(tx, pid, func(stats *PostStats) {
+		if !slices.Contains(stats.Relayers, relayer) {
+			stats.Relayers = append(stats.Relayers, relayer)
+		}
+	})
+}
+
+// maybeRecordPostUnlockSale records the sale of the body of a paywalled post,
+// if the given file is the body of a post created by the local client and the
+// remote user completed its download.
+func (c *Client) maybeRecordPostUnlockSale(ru *RemoteUser, sf clientdb.SharedFile) error {
+	if sf.Swarm {
+		// Not shared by the local client.
+		return nil
+	}
+
+	uid := ru.ID()
+	var pid *clientintf.PostID
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, md, err := c.db.GetSharedFileForUpload(tx, uid, sf.FID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if md.Cost == 0 || !c.db.FileUploadCompleted(tx, uid, sf.FID, len(md.Manifest)) {
+			return nil
+		}
+
+		unlock, err := c.db.FindCompletedPostUnlock(tx, c.PublicID(), sf.FID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		stats, err := c.db.GetPostStats(tx, unlock.Post)
+		if err != nil && !errors.Is(err, clientdb.ErrNotFound) {
+			return err
+		}
+		if slices.Contains(stats.Unlockers, uid) {
+			// Already recorded (e.g. a chunk was sent again).
+			return nil
+		}
+		pid = &unlock.Post
+		return c.db.UpdatePostStats(tx, unlock.Post, func(stats *PostStats) {
+			stats.Unlockers = append(stats.Unlockers, uid)
+			stats.UnlockAtoms += md.Cost
+		})
+	})
+	if err == nil && pid != nil {
+		ru.log.Infof("Unlocked paywalled post %s", *pid)
+	}
+	return err
+}
+
+// GetPostStats returns the stats of a post created by the local client.
+func (c *Client) GetPostStats(pid clientintf.PostID) (PostStats, error) {
+	var stats PostStats
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		stats, err = c.db.GetPostStats(tx, pid)
+		return err
+	})
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return PostStats{Post: pid}, nil
+	}
+	return stats, err
+}
+
+// ListPostStats lists the stats of the posts created by the local client that
+// had any activity, ordered by most recent activity.
+func (c *Client) ListPostStats() ([]PostStats, error) {
+	var res []PostStats
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListPostStats(tx)
+		return err
+	})
+	return res, err
+}
+
+// PostSubscriberChurn returns the changes to the list of subscribers to the
+// posts of the local client since the given time.
+func (c *Client) PostSubscriberChurn(since time.Time) (PostSubscriberChurn, error) {
+	res := PostSubscriberChurn{Since: since}
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res.Events, err = c.db.ListPostSubscriberEvents(tx, since)
+		if err != nil {
+			return err
+		}
+		subs, err := c.db.ListPostSubscribers(tx)
+		res.Subscribers = len(subs)
+		return err
+	})
+	for _, e := range res.Events {
+		if e.Subscribed {
+			res.Subscribed += 1
+		} else {
+			res.Unsubscribed += 1
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestApplyPostStatusToStats tests updating the stats of a post with status
+// updates.
+func TestApplyPostStatusToStats(t *testing.T) {
+	var stats PostStats
+	apply := func(key, value string) {
+		pms := rpc.PostMetadataStatus{Attributes: map[string]string{key: value}}
+		applyPostStatusToStats(&stats, &pms)
+	}
+
+	apply(rpc.RMPSComment, "first")
+	apply(rpc.RMPSComment, "second")
+	apply(rpc.RMPSHeart, rpc.RMPSHeartYes)
+	apply(rpc.RMPSHeart, rpc.RMPSHeartYes)
+	apply(rpc.RMPSHeart, rpc.RMPSHeartNo)
+	apply(rpc.RMPSReaction, "👍")
+	apply(rpc.RMPSReaction, "🎉")
+	apply(rpc.RMPSReactionRemove, "👍")
+	apply(rpc.RMPNonce, "not a status")
+
+	if stats.Comments != 2 {
+		t.Fatalf("unexpected nb of comments: got %d, want 2", stats.Comments)
+	}
+	if stats.Hearts != 1 {
+		t.Fatalf("unexpected nb of hearts: got %d, want 1", stats.Hearts)
+	}
+	if stats.Reactions != 1 {
+		t.Fatalf("unexpected nb of reactions: got %d, want 1", stats.Reactions)
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// PostTagFilter is the filter applied to the tags of posts received from an
+// author.
+type PostTagFilter = clientdb.PostTagFilter
+
+// normalizePostTags returns the normalized list of tags, or an error if any
+// of the tags is invalid.
+func normalizePostTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	s, err := rpc.FormatPostTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.ParsePostTags(s), nil
+}
+
+// postTagFilterAccepts returns true if a post with the given tags passes the
+// filter: it has at least one of the included tags (when there are any) and
+// none of the excluded ones.
+func postTagFilterAccepts(filter PostTagFilter, tags []string) bool {
+	for _, tag := range tags {
+		for _, excl := range filter.Exclude {
+			if tag == excl {
+				return false
+			}
+		}
+	}
+	if len(filter.Include) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, incl := range filter.Include {
+			if tag == incl {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterPostTags returns true if the post from the given author should be
+// filtered based on its tags.
+func (c *Client) filterPostTags(tx clientdb.ReadTx, author UserID, p *rpc.PostMetadata) (bool, error) {
+	filter, err := c.db.GetPostTagFilter(tx, author)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	tags := rpc.ParsePostTags(p.Attributes[rpc.RMPTags])
+	return !postTagFilterAccepts(filter, tags), nil
+}
+
+// SetPostTagFilter sets the filter applied to the tags of new posts received
+// from the given author (either directly or relayed by other users). Posts
+// that do not pass the filter are not stored. Empty include and exclude lists
+// remove the filter.
+func (c *Client) SetPostTagFilter(author UserID, include, exclude []string) error {
+	var filter PostTagFilter
+	var err error
+	if filter.Include, err = normalizePostTags(include); err != nil {
+		return err
+	}
+	if filter.Exclude, err = normalizePostTags(exclude); err != nil {
+		return err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetPostTagFilter(tx, author, filter)
+	})
+	if err != nil {
+		return err
+	}
+	if filter.IsEmpty() {
+		c.log.Infof("Removed post tag filter for author %s", author)
+	} else {
+		c.log.Infof("Set post tag filter for author %s (include %q, "+
+			"exclude %q)", author, filter.Include, filter.Exclude)
+	}
+	return nil
+}
+
+// GetPostTagFilter returns the filter applied to the tags of posts from the
+// given author. Returns an empty filter if the author has no filter.
+func (c *Client) GetPostTagFilter(author UserID) (PostTagFilter, error) {
+	var filter PostTagFilter
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		filter, err = c.db.GetPostTagFilter(tx, author)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			err = nil
+		}
+		return err
+	})
+	return filter, err
+}
+
+// ListPostTagFilters returns the post tag filters of all authors.
+func (c *Client) ListPostTagFilters() (map[UserID]PostTagFilter, error) {
+	var res map[UserID]PostTagFilter
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListPostTagFilters(tx)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,59 @@
+package client
+
+import "testing"
+
+// TestPostTagFilterAccepts tests the rules for filtering posts by their tags.
+func TestPostTagFilterAccepts(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter PostTagFilter
+		tags   []string
+		want   bool
+	}{{
+		name: "empty filter",
+		tags: []string{"dev"},
+		want: true,
+	}, {
+		name:   "untagged post with include",
+		filter: PostTagFilter{Include: []string{"dev"}},
+		want:   false,
+	}, {
+		name:   "untagged post with exclude",
+		filter: PostTagFilter{Exclude: []string{"sports"}},
+		want:   true,
+	}, {
+		name:   "included tag",
+		filter: PostTagFilter{Include: []string{"dev", "go"}},
+		tags:   []string{"misc", "go"},
+		want:   true,
+	}, {
+		name:   "not included tag",
+		filter: PostTagFilter{Include: []string{"dev"}},
+		tags:   []string{"misc"},
+		want:   false,
+	}, {
+		name:   "excluded tag",
+		filter: PostTagFilter{Exclude: []string{"sports"}},
+		tags:   []string{"news", "sports"},
+		want:   false,
+	}, {
+		name: "exclude has priority",
+		filter: PostTagFilter{
+			Include: []string{"dev"},
+			Exclude: []string{"sports"},
+		},
+		tags: []string{"dev", "sports"},
+		want: false,
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := postTagFilterAccepts(tc.filter, tc.tags)
+			if got != tc.want {
+				t.Fatalf("unexpected result: got %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/ratchet"
+)
+
+const (
+	// ratchetHealthMaxSilence is the max interval between the last message
+	// sent to a user and the last message received from them after which
+	// the ratchet is considered probably broken.
+	ratchetHealthMaxSilence = 14 * 24 * time.Hour
+
+	// ratchetHealthHandshakeTimeout is the default max interval to wait
+	// for a reply to a handshake before the ratchet is considered probably
+	// broken.
+	ratchetHealthHandshakeTimeout = 3 * 24 * time.Hour
+
+	// ratchetHealthMinKeysRemaining is the min number of keys remaining
+	// for missing messages before the ratchet is considered probably
+	// broken.
+	ratchetHealthMinKeysRemaining = 10
+)
+
+// RatchetHealth is a diagnostic report of the state of the ratchet with a
+// remote user.
+type RatchetHealth struct {
+	UID  UserID `json:"uid"`
+	Nick string `json:"nick"`
+
+	// UnackedRMs is the number of RMs encrypted for the user that have
+	// not yet been acked by the server.
+	UnackedRMs int `json:"unacked_rms"`
+
+	LastEncTime          time.Time `json:"last_enc_time"`
+	LastDecTime          time.Time `json:"last_dec_time"`
+	LastHandshakeAttempt time.Time `json:"last_handshake_attempt"`
+
+	// Draining is true when the ratchet is still listening for messages
+	// sent on the previous receive chain.
+	Draining bool `json:"draining"`
+
+	// NbSavedKeys is the number of keys saved for missing messages and
+	// KeysRemaining is the approximate number of further messages that
+	// may be missed before messages from the user can't be decrypted.
+	NbSavedKeys   int `json:"nb_saved_keys"`
+	KeysRemaining int `json:"keys_remaining"`
+
+	// FailedDecrypts is the number of consecutive messages received from
+	// the user that failed to be decrypted.
+	FailedDecrypts int `json:"failed_decrypts"`
+
+	// RVsUnsubscribed is true when the client is not listening for
+	// messages from the user due to the user being idle.
+	RVsUnsubscribed bool `json:"rvs_unsubscribed"`
+
+	// ResetInProgress is true when a ratchet reset with the user was
+	// requested and has not yet completed.
+	ResetInProgress bool `json:"reset_in_progress"`
+
+	// ProbablyBroken is set when the heuristics indicate the ratchet is
+	// probably broken and should be reset. BrokenReason describes why.
+	ProbablyBroken bool   `json:"probably_broken"`
+	BrokenReason   string `json:"broken_reason"`
+}
+
+// checkRatchetBroken applies heuristics to the ratchet health report to
+// determine whether the ratchet is probably broken. It returns a non-empty
+// reason if it is.
+func checkRatchetBroken(h *RatchetHealth, firstCreated, now time.Time,
+	handshakeTimeout time.Duration) string {
+	if h.ResetInProgress {
+		// Reset has already been requested.
+		return ""
+	}
+
+	switch {
+	case h.KeysRemaining < ratchetHealthMinKeysRemaining:
+		return fmt.Sprintf("too many missing messages (%d)", h.NbSavedKeys)
+
+	case h.LastDecTime.IsZero() && !firstCreated.IsZero() &&
+		now.Sub(firstCreated) > ratchetHealthMaxSilence:
+		return fmt.Sprintf("no messages received since KX on %s",
+			firstCreated.Format(time.RFC3339))
+
+	case !h.LastDecTime.IsZero() &&
+		h.LastEncTime.Sub(h.LastDecTime) > ratchetHealthMaxSilence:
+		return fmt.Sprintf("no messages received since %s while messages "+
+			"were sent until %s", h.LastDecTime.Format(time.RFC3339),
+			h.LastEncTime.Format(time.RFC3339))
+
+	case h.LastHandshakeAttempt.After(h.LastDecTime) &&
+		now.Sub(h.LastHandshakeAttempt) > handshakeTimeout:
+		return fmt.Sprintf("handshake attempted on %s was not replied",
+			h.LastHandshakeAttempt.Format(time.RFC3339))
+	}
+	return ""
+}
+
+// RatchetHealth returns a diagnostic report of the state of the ratchet with
+// the given user.
+func (c *Client) RatchetHealth(uid UserID) (RatchetHealth, error) {
+	reports, err := c.ratchetHealth([]UserID{uid})
+	if err != nil {
+		return RatchetHealth{}, err
+	}
+	return reports[0], nil
+}
+
+// ListRatchetHealth returns a diagnostic report of the state of the ratchets
+// with all remote users.
+func (c *Client) ListRatchetHealth() ([]RatchetHealth, error) {
+	<-c.abLoaded
+	return c.ratchetHealth(c.rul.userList())
+}
+
+// ratchetHealth returns the ratchet health reports of the given users.
+func (c *Client) ratchetHealth(uids []UserID) ([]RatchetHealth, error) {
+	now := time.Now()
+	handshakeTimeout := c.cfg.AutoHandshakeTimeout
+	if handshakeTimeout == 0 {
+		handshakeTimeout = ratchetHealthHandshakeTimeout
+	}
+	res := make([]RatchetHealth, 0, len(uids))
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		kxs, err := c.db.ListKXs(tx)
+		if err != nil {
+			return err
+		}
+		resetting := make(map[UserID]bool)
+		for _, kx := range kxs {
+			if kx.IsForReset && kx.MediatorID != nil {
+				resetting[*kx.MediatorID] = true
+			}
+		}
+
+		for _, uid := range uids {
+			ru, err := c.rul.byID(uid)
+			if err != nil {
+				return err
+			}
+			ab, err := c.db.GetAddressBookEntry(tx, uid)
+			if err != nil {
+				return err
+			}
+			unacked, err := c.db.CountUserUnackedRMs(tx, uid)
+			if err != nil {
+				return err
+			}
+
+			r := ru.RatchetDebugInfo()
+			h := RatchetHealth{
+				UID:                  uid,
+				Nick:                 ru.Nick(),
+				UnackedRMs:           unacked,
+				LastEncTime:          r.LastEncTime,
+				LastDecTime:          r.LastDecTime,
+				LastHandshakeAttempt: ab.LastHandshakeAttempt,
+				Draining:             !r.DrainRV.IsEmpty(),
+				NbSavedKeys:          r.NbSavedKeys,
+				KeysRemaining:        ratchet.MaxMissingMessages - r.NbSavedKeys,
+				FailedDecrypts:       ru.FailedDecrypts(),
+				RVsUnsubscribed:      ru.RVsUnsubscribed(),
+				ResetInProgress:      resetting[uid],
+			}
+			if h.KeysRemaining < 0 {
+				h.KeysRemaining = 0
+			}
+			h.BrokenReason = checkRatchetBroken(&h, ab.FirstCreated, now,
+				handshakeTimeout)
+			h.ProbablyBroken = h.BrokenReason != ""
+			res = append(res, h)
+		}
+		return nil
+	})
+	return res, err
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCheckRatchetBroken tests the heuristics used to determine whether a
+// ratchet is probably broken.
+func TestCheckRatchetBroken(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name         string
+		h            RatchetHealth
+		firstCreated time.Time
+		broken       bool
+	}{{
+		name: "healthy",
+		h: RatchetHealth{
+			LastEncTime:   ago(day),
+			LastDecTime:   ago(2 * day),
+			KeysRemaining: 80,
+		},
+		firstCreated: ago(30 * day),
+	}, {
+		name: "new user",
+		h: RatchetHealth{
+			LastEncTime:   ago(day),
+			KeysRemaining: 80,
+		},
+		firstCreated: ago(day),
+	}, {
+		name: "never received",
+		h: RatchetHealth{
+			LastEncTime:   ago(day),
+			KeysRemaining: 80,
+		},
+		firstCreated: ago(30 * day),
+		broken:       true,
+	}, {
+		name: "sent without replies",
+		h: RatchetHealth{
+			LastEncTime:   ago(day),
+			LastDecTime:   ago(20 * day),
+			KeysRemaining: 80,
+		},
+		firstCreated: ago(30 * day),
+		broken:       true,
+	}, {
+		name: "unreplied handshake",
+		h: RatchetHealth{
+			LastDecTime:          ago(10 * day),
+			LastHandshakeAttempt: ago(5 * day),
+			KeysRemaining:        80,
+		},
+		firstCreated: ago(30 * day),
+		broken:       true,
+	}, {
+		name: "recent handshake",
+		h: RatchetHealth{
+			LastDecTime:          ago(10 * day),
+			LastHandshakeAttempt: ago(day),
+			KeysRemaining:        80,
+		},
+		firstCreated: ago(30 * day),
+	}, {
+		name: "few keys remaining",
+		h: RatchetHealth{
+			LastDecTime:   ago(day),
+			NbSavedKeys:   75,
+			KeysRemaining: 5,
+		},
+		firstCreated: ago(30 * day),
+		broken:       true,
+	}, {
+		name: "reset in progress",
+		h: RatchetHealth{
+			LastEncTime:     ago(day),
+			LastDecTime:     ago(20 * day),
+			KeysRemaining:   80,
+			ResetInProgress: true,
+		},
+		firstCreated: ago(30 * day),
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			reason := checkRatchetBroken(&tc.h, tc.firstCreated, now,
+				ratchetHealthHandshakeTimeout)
+			if broken := reason != ""; broken != tc.broken {
+				t.Fatalf("unexpected broken: got %v (%q), want %v",
+					broken, reason, tc.broken)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/strescape"
+)
+
+// RatchetResetPolicy returns the policy used to automatically request ratchet
+// resets with contacts.
+func (c *Client) RatchetResetPolicy() (*clientdb.RatchetResetPolicy, error) {
+	var policy *clientdb.RatchetResetPolicy
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		policy, err = c.db.GetRatchetResetPolicy(tx)
+		return err
+	})
+	return policy, err
+}
+
+// SetRatchetResetPolicy sets the policy used to automatically request ratchet
+// resets with contacts.
+func (c *Client) SetRatchetResetPolicy(policy *clientdb.RatchetResetPolicy) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.UpdateRatchetResetPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Updated ratchet reset policy (%d contact overrides)",
+		len(policy.Contacts))
+	return nil
+}
+
+// SetContactRatchetResetPolicy overrides the default ratchet reset policy for
+// the given contact. Settings that are not specified in the override use the
+// setting of the default policy. Setting an empty override removes it.
+func (c *Client) SetContactRatchetResetPolicy(uid UserID, cp clientdb.RatchetResetContactPolicy) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		policy, err := c.db.GetRatchetResetPolicy(tx)
+		if err != nil {
+			return err
+		}
+		if cp.IsEmpty() {
+			delete(policy.Contacts, uid.String())
+		} else {
+			if policy.Contacts == nil {
+				policy.Contacts = make(map[string]clientdb.RatchetResetContactPolicy)
+			}
+			policy.Contacts[uid.String()] = cp
+		}
+		return c.db.UpdateRatchetResetPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	ru.log.Infof("Updated ratchet reset policy override")
+	return nil
+}
+
+// ratchetResetPolicy returns the current ratchet reset policy. Errors loading
+// the policy are logged and an empty (disabled) policy is returned.
+func (c *Client) ratchetResetPolicy() *clientdb.RatchetResetPolicy {
+	policy, err := c.RatchetResetPolicy()
+	if err != nil {
+		c.log.Warnf("Unable to load ratchet reset policy: %v", err)
+		return new(clientdb.RatchetResetPolicy)
+	}
+	return policy
+}
+
+// resetInProgress returns true if a ratchet reset with the given user was
+// requested and has not yet completed.
+func (c *Client) resetInProgress(uid UserID) (bool, error) {
+	var res bool
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		kxs, err := c.db.ListKXs(tx)
+		if err != nil {
+			return err
+		}
+		for _, kx := range kxs {
+			if kx.IsForReset && kx.MediatorID != nil && *kx.MediatorID == uid {
+				res = true
+				break
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// autoResetRatchet requests a ratchet reset with the user, unless a reset is
+// already in progress.
+func (c *Client) autoResetRatchet(ru *RemoteUser, reason string) error {
+	resetting, err := c.resetInProgress(ru.ID())
+	if err != nil {
+		return err
+	}
+	if resetting {
+		ru.log.Debugf("Skipping automatic ratchet reset (%s) due to "+
+			"reset already in progress", reason)
+		return nil
+	}
+
+	ru.log.Infof("Automatic ratchet reset with user %s due to %s",
+		strescape.Nick(ru.Nick()), reason)
+	return c.ResetRatchet(ru.ID())
+}
+
+// handleDecryptFailed is called when a message received from the user fails
+// to be decrypted.
+func (c *Client) handleDecryptFailed(ru *RemoteUser, failed int) {
+	limit := c.ratchetResetPolicy().ForContact(ru.ID()).FailedDecryptsLimit()
+	if limit == 0 || failed < int(limit) {
+		return
+	}
+	reason := fmt.Sprintf("%d failed decrypts", failed)
+	if err := c.autoResetRatchet(ru, reason); err != nil {
+		ru.log.Errorf("Unable to automatically reset ratchet: %v", err)
+	}
+}
+
+// checkUnrepliedReset returns true if a ratchet reset should be requested
+// because messages were sent to a user but no message was received from them
+// in the given number of days.
+func checkUnrepliedReset(days uint32, lastEncTime, lastDecTime, firstCreated, now time.Time) bool {
+	if days == 0 {
+		return false
+	}
+	limitDate := now.Add(-time.Duration(days) * 24 * time.Hour)
+	switch {
+	case !lastEncTime.After(lastDecTime):
+		// No message was sent since the last received one.
+		return false
+	case lastDecTime.After(limitDate):
+		// A message was received more recently than the limit date.
+		return false
+	case !firstCreated.IsZero() && firstCreated.After(limitDate):
+		// The user was created more recently than the limit date.
+		return false
+	}
+	return true
+}
+
+// autoResetUnrepliedRatchets requests a ratchet reset with every user to
+// which messages were sent but from which no message has been received for
+// the number of days specified in the ratchet reset policy.
+func (c *Client) autoResetUnrepliedRatchets() error {
+	<-c.abLoaded
+
+	policy := c.ratchetResetPolicy()
+	now := time.Now()
+	for _, uid := range c.rul.userList() {
+		ru, err := c.rul.byID(uid)
+		if err != nil {
+			continue
+		}
+
+		days := policy.ForContact(uid).UnrepliedDaysLimit()
+		if days == 0 {
+			continue
+		}
+		ab, err := c.getAddressBookEntry(uid)
+		if err != nil {
+			continue
+		}
+		lastEncTime, lastDecTime := ru.LastRatchetTimes()
+		if !checkUnrepliedReset(days, lastEncTime, lastDecTime, ab.FirstCreated, now) {
+			continue
+		}
+
+		reason := fmt.Sprintf("no reply in %d days", days)
+		if err := c.autoResetRatchet(ru, reason); err != nil {
+			return fmt.Errorf("unable to reset ratchet with %s: %v", uid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// TestCheckUnrepliedReset tests the checks used to determine whether a
+// ratchet reset should be automatically requested with a user that has not
+// replied to sent messages.
+func TestCheckUnrepliedReset(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name         string
+		days         uint32
+		lastEnc      time.Time
+		lastDec      time.Time
+		firstCreated time.Time
+		reset        bool
+	}{{
+		name:         "disabled",
+		lastEnc:      ago(day),
+		lastDec:      ago(20 * day),
+		firstCreated: ago(30 * day),
+	}, {
+		name:         "unreplied",
+		days:         7,
+		lastEnc:      ago(day),
+		lastDec:      ago(20 * day),
+		firstCreated: ago(30 * day),
+		reset:        true,
+	}, {
+		name:         "never replied",
+		days:         7,
+		lastEnc:      ago(day),
+		firstCreated: ago(30 * day),
+		reset:        true,
+	}, {
+		name:         "recent reply",
+		days:         7,
+		lastEnc:      ago(day),
+		lastDec:      ago(2 * day),
+		firstCreated: ago(30 * day),
+	}, {
+		name:         "nothing sent",
+		days:         7,
+		lastEnc:      ago(25 * day),
+		lastDec:      ago(20 * day),
+		firstCreated: ago(30 * day),
+	}, {
+		name:         "new user",
+		days:         7,
+		lastEnc:      ago(day),
+		firstCreated: ago(2 * day),
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			reset := checkUnrepliedReset(tc.days, tc.lastEnc, tc.lastDec,
+				tc.firstCreated, now)
+			if reset != tc.reset {
+				t.Fatalf("unexpected reset: got %v, want %v", reset,
+					tc.reset)
+			}
+		})
+	}
+}
+
+// TestRatchetResetPolicyForContact tests that contact overrides of the ratchet
+// reset policy are merged with the default policy.
+func TestRatchetResetPolicyForContact(t *testing.T) {
+	u32 := func(v uint32) *uint32 { return &v }
+	var alice, bob, charlie UserID
+	alice[0], bob[0], charlie[0] = 1, 2, 3
+
+	policy := &clientdb.RatchetResetPolicy{
+		Default: clientdb.RatchetResetContactPolicy{
+			UnrepliedDays:  u32(14),
+			FailedDecrypts: u32(10),
+		},
+		Contacts: map[string]clientdb.RatchetResetContactPolicy{
+			alice.String(): {UnrepliedDays: u32(3)},
+			bob.String():   {UnrepliedDays: u32(0), FailedDecrypts: u32(0)},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		uid            UserID
+		unrepliedDays  uint32
+		failedDecrypts uint32
+	}{
+		{name: "partial override", uid: alice, unrepliedDays: 3, failedDecrypts: 10},
+		{name: "disabled", uid: bob},
+		{name: "no override", uid: charlie, unrepliedDays: 14, failedDecrypts: 10},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cp := policy.ForContact(tc.uid)
+			if got := cp.UnrepliedDaysLimit(); got != tc.unrepliedDays {
+				t.Fatalf("unexpected unreplied days: got %d, want %d",
+					got, tc.unrepliedDays)
+			}
+			if got := cp.FailedDecryptsLimit(); got != tc.failedDecrypts {
+				t.Fatalf("unexpected failed decrypts: got %d, want %d",
+					got, tc.failedDecrypts)
+			}
+		})
+	}
+}
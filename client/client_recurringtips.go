@@ -0,0 +1,319 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+const (
+	// recurringTipRetryDelay is how long to wait before retrying a payment
+	// of a recurring tip that could not be started.
+	recurringTipRetryDelay = time.Hour
+
+	// recurringTipMaxFailures is the number of consecutive failures after
+	// which a recurring tip is paused.
+	recurringTipMaxFailures = 24
+
+	// recurringTipMaxRateAge is the max age of the exchange rate used to
+	// convert USD-denominated recurring tips.
+	recurringTipMaxRateAge = 6 * time.Hour
+
+	// recurringTipMaxAttempts is the max number of attempts of each
+	// payment of a recurring tip.
+	recurringTipMaxAttempts = 3
+)
+
+// RecurringTip is a tip periodically sent to a user.
+type RecurringTip = clientdb.RecurringTip
+
+// wakeRecurringTips signals the recurring tips scheduler that the recurring
+// tips changed.
+func (c *Client) wakeRecurringTips() {
+	select {
+	case c.recurringTipsChan <- struct{}{}:
+	default:
+	}
+}
+
+// AddRecurringTip adds a tip that is periodically paid to the given user. The
+// amount is either denominated in DCR (dcrAmount) or in USD (usdAmount), in
+// which case it is converted to DCR at the exchange rate of the time of each
+// payment. The first payment is made immediately.
+func (c *Client) AddRecurringTip(uid UserID, dcrAmount, usdAmount float64,
+	period clientdb.RecurringTipPeriod) (RecurringTip, error) {
+
+	var rt RecurringTip
+	if err := c.checkPaymentsAllowed("recurring tip"); err != nil {
+		return rt, err
+	}
+	if !period.Valid() {
+		return rt, fmt.Errorf("invalid recurring tip period %q", period)
+	}
+	if (dcrAmount > 0) == (usdAmount > 0) {
+		return rt, errors.New("exactly one of the DCR or USD amounts must " +
+			"be positive")
+	}
+	if usdAmount > 0 && c.cfg.DCRUSDRate == nil {
+		return rt, errors.New("exchange rate is not available to " +
+			"convert USD amounts")
+	}
+	if dcrAmount > 0 {
+		if _, err := dcrutil.NewAmount(dcrAmount); err != nil {
+			return rt, err
+		}
+	}
+	if _, err := c.rul.byID(uid); err != nil {
+		return rt, err
+	}
+
+	now := time.Now()
+	rt = RecurringTip{
+		UID:         uid,
+		DCRAmount:   dcrAmount,
+		USDAmount:   usdAmount,
+		Period:      period,
+		Created:     now,
+		NextPayment: now,
+	}
+	if _, err := rand.Read(rt.ID[:]); err != nil {
+		return rt, err
+	}
+
+	c.recurringTipsMtx.Lock()
+	defer c.recurringTipsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreRecurringTip(tx, rt)
+	})
+	if err != nil {
+		return rt, err
+	}
+	c.log.Infof("Added %s recurring tip %s to %s", period, rt.ID, uid)
+	c.wakeRecurringTips()
+	return rt, nil
+}
+
+// GetRecurringTip returns the recurring tip with the given id.
+func (c *Client) GetRecurringTip(id zkidentity.ShortID) (RecurringTip, error) {
+	var rt RecurringTip
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		rt, err = c.db.GetRecurringTip(tx, id)
+		return err
+	})
+	return rt, err
+}
+
+// ListRecurringTips lists the recurring tips, including the paused ones.
+func (c *Client) ListRecurringTips() ([]RecurringTip, error) {
+	var res []RecurringTip
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		res, err = c.db.ListRecurringTips(tx)
+		return err
+	})
+	return res, err
+}
+
+// PauseRecurringTip pauses (if pause is true) or resumes the given recurring
+// tip. Payments missed while paused are not made once the tip is resumed.
+func (c *Client) PauseRecurringTip(id zkidentity.ShortID, pause bool) error {
+	c.recurringTipsMtx.Lock()
+	defer c.recurringTipsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		rt, err := c.db.GetRecurringTip(tx, id)
+		if err != nil {
+			return fmt.Errorf("recurring tip %s: %w", id, err)
+		}
+		rt.Paused = pause
+		if !pause {
+			rt.Failures = 0
+			now := time.Now()
+			for rt.NextPayment.Before(now) && !rt.LastPayment.IsZero() {
+				rt.NextPayment = rt.Period.Next(rt.NextPayment)
+			}
+		}
+		return c.db.StoreRecurringTip(tx, rt)
+	})
+	if err != nil {
+		return err
+	}
+	if pause {
+		c.log.Infof("Paused recurring tip %s", id)
+	} else {
+		c.log.Infof("Resumed recurring tip %s", id)
+	}
+	c.wakeRecurringTips()
+	return nil
+}
+
+// CancelRecurringTip removes the given recurring tip. Payments already
+// started are not canceled.
+func (c *Client) CancelRecurringTip(id zkidentity.ShortID) error {
+	c.recurringTipsMtx.Lock()
+	defer c.recurringTipsMtx.Unlock()
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.RemoveRecurringTip(tx, id)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Canceled recurring tip %s", id)
+	c.wakeRecurringTips()
+	return nil
+}
+
+// recurringTipAmount returns the DCR amount of the next payment of the
+// recurring tip and, for USD-denominated tips, the conversion of the amount.
+func (c *Client) recurringTipAmount(rt *RecurringTip) (dcrutil.Amount, *rpc.RMFiatConversion, error) {
+	if rt.DCRAmount > 0 {
+		amt, err := dcrutil.NewAmount(rt.DCRAmount)
+		return amt, nil, err
+	}
+	rate, updated, err := c.dcrUSDRate(recurringTipMaxRateAge)
+	if err != nil {
+		return 0, nil, err
+	}
+	return convertUSD(rt.USDAmount, rate, updated)
+}
+
+// payRecurringTip starts the payment of a due recurring tip and schedules its
+// next payment.
+func (c *Client) payRecurringTip(id zkidentity.ShortID) {
+	c.recurringTipsMtx.Lock()
+	defer c.recurringTipsMtx.Unlock()
+
+	rt, err := c.GetRecurringTip(id)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		// Canceled concurrently.
+		return
+	}
+	if err != nil {
+		c.log.Errorf("Unable to load recurring tip %s: %v", id, err)
+		return
+	}
+	if rt.Paused || rt.NextPayment.After(time.Now()) {
+		return
+	}
+
+	amt, fiat, err := c.recurringTipAmount(&rt)
+	if err == nil {
+		err = c.tipUser(rt.UID, amt.ToCoin(), recurringTipMaxAttempts,
+			nil, fiat)
+	}
+
+	now := time.Now()
+	if err != nil {
+		c.log.Warnf("Unable to pay recurring tip %s to %s: %v", rt.ID,
+			rt.UID, err)
+		rt.LastError = err.Error()
+		rt.Failures += 1
+		rt.NextPayment = now.Add(recurringTipRetryDelay)
+		if rt.Failures >= recurringTipMaxFailures {
+			c.log.Warnf("Pausing recurring tip %s after %d failures",
+				rt.ID, rt.Failures)
+			rt.Paused = true
+		}
+	} else {
+		c.log.Infof("Started payment of recurring tip %s of %s to %s",
+			rt.ID, amt, rt.UID)
+		rt.LastError = ""
+		rt.Failures = 0
+		rt.LastPayment = now
+		rt.Payments += 1
+		rt.PaidAtoms += int64(amt)
+
+		// Skip periods missed while the client was offline.
+		for !rt.NextPayment.After(now) {
+			rt.NextPayment = rt.Period.Next(rt.NextPayment)
+		}
+	}
+
+	dbErr := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.StoreRecurringTip(tx, rt)
+	})
+	if dbErr != nil {
+		c.log.Errorf("Unable to store recurring tip %s: %v", rt.ID, dbErr)
+	}
+	if err != nil {
+		c.ntfns.notifyRecurringTipFailed(rt, err)
+	}
+}
+
+// payDueRecurringTips pays the recurring tips that are due. Returns the time
+// of the next payment, if there is one.
+func (c *Client) payDueRecurringTips() (*time.Time, error) {
+	tips, err := c.ListRecurringTips()
+	if err != nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	now := time.Now()
+	for _, rt := range tips {
+		if rt.Paused {
+			continue
+		}
+		if rt.NextPayment.After(now) {
+			if next == nil || rt.NextPayment.Before(*next) {
+				nextPayment := rt.NextPayment
+				next = &nextPayment
+			}
+			continue
+		}
+		c.payRecurringTip(rt.ID)
+
+		// Reload to account for the rescheduled payment.
+		rt, err := c.GetRecurringTip(rt.ID)
+		if err != nil || rt.Paused {
+			continue
+		}
+		if next == nil || rt.NextPayment.Before(*next) {
+			next = &rt.NextPayment
+		}
+	}
+	return next, nil
+}
+
+// runRecurringTips pays the recurring tips when they are due.
+func (c *Client) runRecurringTips(ctx context.Context) error {
+	// Wait until the tip attempts are running.
+	select {
+	case <-c.tipAttemptsRunning:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for {
+		next, err := c.payDueRecurringTips()
+		if err != nil {
+			c.log.Errorf("Unable to pay recurring tips: %v", err)
+		}
+
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if next != nil {
+			timer = time.NewTimer(time.Until(*next))
+			timerChan = timer.C
+		}
+
+		select {
+		case <-timerChan:
+		case <-c.recurringTipsChan:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
@@ -131,19 +131,44 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 			return nil
 		}
 
-		if filter, _ := c.FilterPM(ru.ID(), p.Message); filter {
+		filter, ruleID := c.FilterPM(ru.ID(), p.Message)
+		if filter {
 			return nil
 		}
+		muted := ruleID != 0 && c.isMsgMuted(ru.ID(), nil, p.Message)
 
 		err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			if p.MsgID != nil && p.ReplyTo != nil {
+				reply := clientdb.ThreadMessage{
+					MsgID:     *p.MsgID,
+					ReplyTo:   *p.ReplyTo,
+					From:      ru.ID(),
+					Message:   p.Message,
+					Timestamp: ts,
+				}
+				_, err := c.db.StoreThreadReply(tx, nil, reply)
+				if err != nil {
+					ru.log.Warnf("Unable to store PM reply: %v", err)
+				}
+			}
 			return c.db.LogPM(tx, ru.ID(), false, ru.Nick(), p.Message, ts)
 		})
 		if err != nil {
 			return err
 		}
 		ru.log.Debugf("Received private message of length %d", len(p.Message))
+		c.syncDevicesPM(ru.ID(), ru.Nick(), p.Message, ts)
 
+		if muted {
+			// Muted messages are logged but not notified.
+			c.sendMsgReceiveReceipt(ru, p.MsgID, nil)
+			return nil
+		}
+		c.unarchiveOnNewMsg(ru.ID())
 		c.ntfns.notifyOnPM(ru, p, ts)
+		c.notifyVoiceMsgs(ru, nil, p.MsgID, p.Message, ts)
+		c.sendMsgReceiveReceipt(ru, p.MsgID, nil)
+		c.maybeAutoReply(ru)
 
 	case rpc.RMHandshakeSYN, rpc.RMHandshakeACK, rpc.RMHandshakeSYNACK:
 		return c.handleRMHandshake(ru, p)
@@ -151,6 +176,20 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 	case rpc.RMProfileUpdate:
 		return c.handleProfileUpdate(ru, p)
 
+	case rpc.RMEditMessage:
+		if ru.IsIgnored() {
+			ru.log.Tracef("Ignoring received message edit")
+			return nil
+		}
+		return c.handleEditMessage(ru, p, ts)
+
+	case rpc.RMMessageReaction:
+		if ru.IsIgnored() {
+			ru.log.Tracef("Ignoring received message reaction")
+			return nil
+		}
+		return c.handleMessageReaction(ru, p, ts)
+
 	case rpc.RMGroupInvite:
 		return c.handleGCInvite(ru, p)
 
@@ -173,6 +212,12 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 		}
 		return c.handleGCMessage(ru, p, ts)
 
+	case rpc.RMGroupUnkxdMention:
+		return c.handleGCUnkxdMention(ru, p)
+
+	case rpc.RMGroupHistory:
+		return c.handleGCHistory(ru, p)
+
 	case rpc.RMMediateIdentity:
 		return c.handleMediateID(ru, p)
 
@@ -206,6 +251,9 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 	case rpc.RMFTSendFile:
 		return c.handleFTSendFile(ru, p)
 
+	case rpc.RMFTSwarmHave:
+		return c.handleFTSwarmHave(ru, p)
+
 	case rpc.RMTransitiveMessage:
 		return c.handleTransitiveMsg(ru, p)
 
@@ -218,6 +266,36 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 	case rpc.RMInvoice:
 		return c.handleInvoice(ru, p)
 
+	case rpc.RMKeysendTip:
+		return c.handleKeysendTip(ru, p)
+
+	case rpc.RMGetOnchainAddress:
+		return c.handleGetOnchainAddress(ru, p)
+
+	case rpc.RMOnchainAddress:
+		return c.handleOnchainAddress(ru, p)
+
+	case rpc.RMOnchainTipReceipt:
+		return c.handleOnchainTipReceipt(ru, p)
+
+	case rpc.RMPaymentReceipt:
+		return c.handlePaymentReceipt(ru, p)
+
+	case rpc.RMEscrowRequest:
+		return c.handleEscrowRequest(ru, p)
+
+	case rpc.RMEscrowInvoice:
+		return c.handleEscrowInvoice(ru, p)
+
+	case rpc.RMEscrowFulfilled:
+		return c.handleEscrowFulfilled(ru, p)
+
+	case rpc.RMEscrowRelease:
+		return c.handleEscrowRelease(ru, p)
+
+	case rpc.RMEscrowCancel:
+		return c.handleEscrowCancel(ru, p)
+
 	case rpc.RMListPosts:
 		return c.handleListPosts(ru, p)
 
@@ -269,6 +347,9 @@ func (c *Client) innerHandleUserRM(ru *RemoteUser, h *rpc.RMHeader,
 	case rpc.RMKXSuggestion:
 		return c.handleKXSuggestion(ru, p)
 
+	case rpc.RMIdentityMigration:
+		return c.handleIdentityMigration(ru, p)
+
 	case rpc.RMFetchResource:
 		return c.handleFetchResource(ru, p)
 
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+const (
+	// safetyNumberVersion is the version of the safety number derivation.
+	safetyNumberVersion = 0
+
+	// safetyNumberIterations is the number of hash iterations used when
+	// deriving the fingerprint of an identity.
+	safetyNumberIterations = 1024
+
+	// safetyNumberGroups is the number of 5 digit groups generated for
+	// each identity.
+	safetyNumberGroups = 6
+
+	// safetyEmojiCount is the number of emojis in the emoji safety code.
+	safetyEmojiCount = 8
+)
+
+// safetyEmojis is the list of emojis used in emoji safety codes. The list
+// MUST have 64 entries and MUST NOT be modified, otherwise safety codes will
+// not match across clients.
+var safetyEmojis = [64]string{
+	"🐶", "🐱", "🐭", "🐹", "🐰", "🦊", "🐻", "🐼",
+	"🐨", "🐯", "🦁", "🐮", "🐷", "🐸", "🐵", "🐔",
+	"🐧", "🐦", "🦆", "🦉", "🐴", "🦄", "🐝", "🐛",
+	"🦋", "🐌", "🐞", "🐢", "🐍", "🐙", "🦀", "🐬",
+	"🐳", "🦈", "🐘", "🦒", "🌵", "🌲", "🌻", "🍄",
+	"🌙", "⭐", "🔥", "🌈", "⛄", "🌊", "🍎", "🍌",
+	"🍇", "🍓", "🍒", "🍍", "🥕", "🌽", "🍕", "🎂",
+	"⚽", "🎸", "🚗", "🚀", "⏰", "🔑", "🔔", "⚓",
+}
+
+// SafetyNumber is a short code derived from the identity keys of two users.
+// Both users compute the same code, and comparing it out of band (in person
+// or through another trusted channel) verifies that no MITM attack happened
+// during their KX.
+type SafetyNumber struct {
+	// Digits is the safety number as a sequence of 5 digit groups,
+	// separated by spaces.
+	Digits string
+
+	// Emojis is an alternative representation of the safety number,
+	// easier to compare visually.
+	Emojis []string
+}
+
+// identityFingerprint returns the fingerprint used to derive safety numbers
+// for the given identity.
+func identityFingerprint(id *zkidentity.PublicIdentity) []byte {
+	h := sha512.New()
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], safetyNumberVersion)
+	h.Write(b[:])
+	h.Write(id.Key[:])
+	h.Write(id.SigKey[:])
+	h.Write(id.Identity[:])
+	fp := h.Sum(nil)
+	for i := 1; i < safetyNumberIterations; i++ {
+		h.Reset()
+		h.Write(fp)
+		h.Write(id.Key[:])
+		h.Write(id.SigKey[:])
+		fp = h.Sum(fp[:0])
+	}
+	return fp
+}
+
+// fingerprintDigits encodes the fingerprint as groups of 5 digits.
+func fingerprintDigits(fp []byte) []string {
+	res := make([]string, safetyNumberGroups)
+	for i := range res {
+		var b [8]byte
+		copy(b[3:], fp[i*5:i*5+5])
+		res[i] = fmt.Sprintf("%05d", binary.BigEndian.Uint64(b[:])%100000)
+	}
+	return res
+}
+
+// ComputeSafetyNumber computes the safety number of the pair of identities.
+// The result is the same regardless of the order of the arguments.
+func ComputeSafetyNumber(a, b *zkidentity.PublicIdentity) SafetyNumber {
+	fpa, fpb := identityFingerprint(a), identityFingerprint(b)
+	if bytes.Compare(a.Identity[:], b.Identity[:]) > 0 {
+		fpa, fpb = fpb, fpa
+	}
+
+	groups := append(fingerprintDigits(fpa), fingerprintDigits(fpb)...)
+
+	h := sha256.New()
+	h.Write(fpa)
+	h.Write(fpb)
+	sum := h.Sum(nil)
+	emojis := make([]string, safetyEmojiCount)
+	for i := range emojis {
+		emojis[i] = safetyEmojis[sum[i]%64]
+	}
+
+	return SafetyNumber{
+		Digits: strings.Join(groups, " "),
+		Emojis: emojis,
+	}
+}
+
+// contactKeysHash returns the hash of the keys of the given identity, used to
+// detect changes after a contact was verified.
+func contactKeysHash(id *zkidentity.PublicIdentity) string {
+	h := sha256.New()
+	h.Write(id.Key[:])
+	h.Write(id.SigKey[:])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SafetyNumber returns the safety number between the local client and the
+// given user.
+func (c *Client) SafetyNumber(uid UserID) (SafetyNumber, error) {
+	ab, err := c.getAddressBookEntry(uid)
+	if err != nil {
+		return SafetyNumber{}, err
+	}
+	localID := c.Public()
+	return ComputeSafetyNumber(&localID, ab.ID), nil
+}
+
+// VerifyContact compares the given safety number with the one computed for
+// the given user. If they match, the user is marked as verified. Spaces in
+// the safety number are ignored.
+func (c *Client) VerifyContact(uid UserID, safetyNumber string) (bool, error) {
+	sn, err := c.SafetyNumber(uid)
+	if err != nil {
+		return false, err
+	}
+	clean := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	if clean(safetyNumber) != clean(sn.Digits) {
+		return false, nil
+	}
+	return true, c.MarkContactVerified(uid, true)
+}
+
+// MarkContactVerified marks the given user as verified (or not verified). This
+// should only be done after the safety number has been compared with the
+// user through a trusted channel.
+func (c *Client) MarkContactVerified(uid UserID, verified bool) error {
+	ab, err := c.getAddressBookEntry(uid)
+	if err != nil {
+		return err
+	}
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		if !verified {
+			return c.db.RemoveContactVerification(tx, uid)
+		}
+		cv := &clientdb.ContactVerification{
+			VerifiedAt: time.Now(),
+			KeysHash:   contactKeysHash(ab.ID),
+		}
+		return c.db.StoreContactVerification(tx, uid, cv)
+	})
+	if err != nil {
+		return err
+	}
+	if verified {
+		c.log.Infof("Marked user %s as verified", uid)
+	} else {
+		c.log.Infof("Marked user %s as not verified", uid)
+	}
+	return nil
+}
+
+// ContactVerification returns the verification record of the given user.
+// Returns nil if the user was never verified.
+func (c *Client) ContactVerification(uid UserID) (*clientdb.ContactVerification, error) {
+	var cv *clientdb.ContactVerification
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		cv, err = c.db.GetContactVerification(tx, uid)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	return cv, err
+}
+
+// checkVerifiedKeyChanged checks whether the keys of a verified user changed.
+// If they did, the verification is flagged as invalid and this returns true.
+func (c *Client) checkVerifiedKeyChanged(tx clientdb.ReadWriteTx, id *zkidentity.PublicIdentity) (bool, error) {
+	cv, err := c.db.GetContactVerification(tx, id.Identity)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !cv.IsVerified() || cv.KeysHash == contactKeysHash(id) {
+		return false, nil
+	}
+	now := time.Now()
+	cv.KeyChangedAt = &now
+	return true, c.db.StoreContactVerification(tx, id.Identity, cv)
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"decred.org/dcrwallet/v3/walletseed"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/sw"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// Seed backup flow:
+//
+// CreateSeedBackup generates a random seed, encodes it as a list of words to
+// be written down by the user and returns a bundle with the local identity and
+// the critical client state (contacts, GCs and GC aliases), encrypted with a
+// key derived from the seed. The bundle may be stored anywhere (including
+// untrusted locations), as it is useless without the seed words.
+//
+// To restore, the bundle is decoded with DecodeSeedBackup and a new client is
+// started with the restored identity (through Config.LocalIDIniter). The
+// server session is re-established by the client on connection. Afterwards,
+// RestoreSeedBackup restores the GCs and requests a ratchet reset with every
+// contact, which notifies them and re-establishes the ratchets.
+
+const (
+	// seedBackupVersion is the current version of the seed backup bundle.
+	seedBackupVersion = 1
+
+	// seedBackupSeedSize is the size of the random seed used to encrypt
+	// seed backups.
+	seedBackupSeedSize = 32
+
+	// seedBackupKeyDomain is the domain separation string used when
+	// deriving the encryption key from the seed.
+	seedBackupKeyDomain = "bisonrelay-seed-backup-v1"
+)
+
+// IdentityBackupContact is a contact stored in a seed backup.
+type IdentityBackupContact struct {
+	ID           zkidentity.PublicIdentity `json:"id"`
+	NickAlias    string                    `json:"nick_alias"`
+	MyResetRV    clientdb.RawRVID          `json:"my_reset_rv"`
+	TheirResetRV clientdb.RawRVID          `json:"their_reset_rv"`
+}
+
+// IdentityBackup is the decrypted contents of a seed backup bundle.
+type IdentityBackup struct {
+	Version   int                           `json:"version"`
+	Created   time.Time                     `json:"created"`
+	Identity  zkidentity.FullIdentity       `json:"identity"`
+	Contacts  []IdentityBackupContact       `json:"contacts"`
+	GCs       []rpc.RMGroupList             `json:"gcs"`
+	GCAliases map[string]zkidentity.ShortID `json:"gc_aliases"`
+}
+
+// LocalIDIniter returns a function that may be used as the LocalIDIniter of
+// the config of the client being restored.
+func (b *IdentityBackup) LocalIDIniter() func(context.Context) (*zkidentity.FullIdentity, error) {
+	return func(context.Context) (*zkidentity.FullIdentity, error) {
+		id := new(zkidentity.FullIdentity)
+		*id = b.Identity
+		return id, nil
+	}
+}
+
+// seedBackupKey derives the encryption key of a seed backup from its seed.
+func seedBackupKey(seed []byte) *[32]byte {
+	return domainHash(seedBackupKeyDomain, seed)
+}
+
+// domainHash returns the sha256 hash of the domain separation string followed
+// by the data.
+func domainHash(domain string, data []byte) *[32]byte {
+	h := sha256.New()
+	h.Write([]byte(domain))
+	h.Write(data)
+	var res [32]byte
+	copy(res[:], h.Sum(nil))
+	return &res
+}
+
+// CreateSeedBackup creates a backup of the local identity and critical client
+// state, encrypted with a new random seed. It returns the seed encoded as a
+// list of words and the encrypted backup bundle.
+//
+// The returned words MUST be stored by the user, as they are required to
+// decrypt the bundle.
+func (c *Client) CreateSeedBackup() (string, []byte, error) {
+	backup := IdentityBackup{
+		Version: seedBackupVersion,
+		Created: time.Now(),
+	}
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		id, err := c.db.LocalID(tx)
+		if err != nil {
+			return err
+		}
+		backup.Identity = *id
+
+		for _, uid := range c.rul.userList() {
+			ab, err := c.db.GetAddressBookEntry(tx, uid)
+			if err != nil {
+				return err
+			}
+			backup.Contacts = append(backup.Contacts, IdentityBackupContact{
+				ID:           *ab.ID,
+				NickAlias:    ab.NickAlias,
+				MyResetRV:    ab.MyResetRV,
+				TheirResetRV: ab.TheirResetRV,
+			})
+		}
+
+		if backup.GCs, err = c.db.ListGCs(tx); err != nil {
+			return err
+		}
+		backup.GCAliases, err = c.db.GetGCAliases(tx)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := json.Marshal(backup)
+	zeroSlice(backup.Identity.PrivateKey[:])
+	zeroSlice(backup.Identity.PrivateSigKey[:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	seed, err := walletseed.GenerateRandomSeed(seedBackupSeedSize)
+	if err != nil {
+		return "", nil, err
+	}
+	bundle, err := sw.Seal(data, seedBackupKey(seed))
+	zeroSlice(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.log.Infof("Created seed backup with %d contacts and %d GCs",
+		len(backup.Contacts), len(backup.GCs))
+	return walletseed.EncodeMnemonic(seed), bundle, nil
+}
+
+// DecodeSeedBackup decrypts the seed backup bundle using the seed words
+// returned by CreateSeedBackup.
+func DecodeSeedBackup(words string, bundle []byte) (*IdentityBackup, error) {
+	seed, err := walletseed.DecodeUserInput(words)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed: %v", err)
+	}
+	if len(bundle) < sw.MinPackedEncryptedSize {
+		return nil, errors.New("seed backup bundle is too short")
+	}
+	data, ok := sw.Open(bundle, seedBackupKey(seed))
+	if !ok {
+		return nil, errors.New("unable to decrypt seed backup bundle " +
+			"(wrong seed?)")
+	}
+
+	backup := new(IdentityBackup)
+	err = json.Unmarshal(data, backup)
+	zeroSlice(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode seed backup: %v", err)
+	}
+	if backup.Version != seedBackupVersion {
+		return nil, fmt.Errorf("unsupported seed backup version %d",
+			backup.Version)
+	}
+	return backup, nil
+}
+
+// RestoreSeedBackup restores the client state from a decoded seed backup. The
+// client must be running with the identity stored in the backup.
+//
+// GCs and GC aliases not known by the local client are restored and a ratchet
+// reset is requested with every contact not known by the local client. Each
+// contact is notified of the reset and the ratchet is established once they
+// are online and reply to it.
+func (c *Client) RestoreSeedBackup(backup *IdentityBackup) error {
+	if backup.Identity.Public.Identity != c.PublicID() {
+		return fmt.Errorf("seed backup is for identity %s, not the "+
+			"local identity", backup.Identity.Public.Identity)
+	}
+
+	var restoredGCs int
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		for _, gc := range backup.GCs {
+			_, err := c.db.GetGC(tx, gc.ID)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, clientdb.ErrNotFound) {
+				return err
+			}
+			if err := c.db.SaveGC(tx, gc); err != nil {
+				return err
+			}
+			restoredGCs += 1
+		}
+
+		aliases, err := c.db.GetGCAliases(tx)
+		if err != nil {
+			return err
+		}
+		hasAlias := make(map[zkidentity.ShortID]bool, len(aliases))
+		for _, gcID := range aliases {
+			hasAlias[gcID] = true
+		}
+		for name, gcID := range backup.GCAliases {
+			if _, ok := aliases[name]; ok || hasAlias[gcID] {
+				continue
+			}
+			hasAlias[gcID] = true
+			aliases, err = c.db.SetGCAlias(tx, gcID, name)
+			if err != nil {
+				return err
+			}
+		}
+		c.setGCAlias(aliases)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var resets int
+	for _, contact := range backup.Contacts {
+		if _, err := c.rul.byID(contact.ID.Identity); err == nil {
+			continue
+		}
+		contact := contact
+		c.log.Infof("Requesting ratchet reset with restored contact %s "+
+			"(%q)", contact.ID.Identity, contact.ID.Nick)
+		err := c.kxl.requestReset(contact.TheirResetRV, &contact.ID)
+		if err != nil {
+			c.log.Warnf("Unable to request reset with restored "+
+				"contact %s: %v", contact.ID.Identity, err)
+			continue
+		}
+		resets += 1
+	}
+
+	c.log.Infof("Restored seed backup: %d GCs restored, %d ratchet resets "+
+		"requested", restoredGCs, resets)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client/clientdb"
@@ -87,7 +88,10 @@ func (c *Client) sendWithSendQPriority(typ string, msg interface{}, priority uin
 
 		// Helper called when we get an error.
 		failed := func(err error) {
-			if !errors.Is(err, clientintf.ErrSubsysExiting) {
+			if errors.Is(err, ErrRMCanceled) {
+				ru.log.Infof("Queued %T was canceled", msg)
+				c.removeFromSendQ(sqid, uid)
+			} else if !errors.Is(err, clientintf.ErrSubsysExiting) {
 				ru.log.Errorf("unable to queue  %T: %v",
 					msg, err)
 				c.removeFromSendQ(sqid, uid)
@@ -190,7 +194,10 @@ func (c *Client) runSendQ(ctx context.Context) error {
 			continue
 		}
 		err = ru.sendRMPriority(rm, el.msg.Type, el.msg.Priority)
-		if err != nil {
+		if errors.Is(err, ErrRMCanceled) {
+			ru.log.Infof("Queued msg %s was canceled", el.msg.Type)
+			removeCurrent()
+		} else if err != nil {
 			// Failed to send this. Try next one so we're not stuck.
 			ru.log.Errorf("Unable to send RM from sendq: %v", err)
 			sendlist[i].tries += 1
@@ -216,3 +223,58 @@ func (c *Client) runSendQ(ctx context.Context) error {
 	c.log.Infof("Finished sending queued messages")
 	return nil
 }
+
+// ListPendingRMs lists the RMs that were queued to be sent to remote users but
+// have not yet been acked by the server. If uid is specified, only RMs queued
+// to be sent to that user are returned. The list is sorted by the time the RMs
+// were queued.
+func (c *Client) ListPendingRMs(uid *UserID) ([]PendingRM, error) {
+	var users []*RemoteUser
+	if uid != nil {
+		ru, err := c.rul.byID(*uid)
+		if err != nil {
+			return nil, err
+		}
+		users = []*RemoteUser{ru}
+	} else {
+		for _, id := range c.rul.userList() {
+			if ru, err := c.rul.byID(id); err == nil {
+				users = append(users, ru)
+			}
+		}
+	}
+
+	var res []PendingRM
+	for _, ru := range users {
+		res = append(res, ru.listPendingRMs()...)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].QueuedAt.Before(res[j].QueuedAt)
+	})
+	return res, nil
+}
+
+// CancelPendingRM cancels sending the pending RM with the given ID to the
+// specified user. Only RMs that have not yet been encrypted may be canceled.
+// The original sender of the RM receives an ErrRMCanceled error.
+func (c *Client) CancelPendingRM(uid UserID, id uint64) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+	return ru.cancelPendingRM(id, nil)
+}
+
+// ReprioritizePendingRM changes the priority of the pending RM with the given
+// ID queued to be sent to the specified user. Lower priority numbers are sent
+// first. Only RMs that have not yet been encrypted may be reprioritized.
+func (c *Client) ReprioritizePendingRM(uid UserID, id uint64, priority uint) error {
+	if priority > 4 {
+		return fmt.Errorf("priority must be max 4")
+	}
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+	return ru.cancelPendingRM(id, &priority)
+}
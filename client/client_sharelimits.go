@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// shareExpiryInterval is the interval between checks for expired shares of
+// files.
+const shareExpiryInterval = time.Minute
+
+// Reasons for automatically unsharing files.
+const (
+	AutoUnshareExpired       = "expired"
+	AutoUnshareDownloadLimit = "download limit reached"
+)
+
+// ShareLimits are the limits of a share of a file, after which the file is
+// automatically unshared.
+type ShareLimits = clientdb.ShareLimits
+
+// SetShareLimits sets the expiration date and download limit of the share of
+// the given file with the given user (or the global share if uid is nil).
+// After the share expires or the number of users that completed a download of
+// the file through it reaches the limit, the file is automatically unshared.
+// If limits is nil, the limits of the share are removed.
+func (c *Client) SetShareLimits(fid clientdb.FileID, uid *UserID, limits *ShareLimits) error {
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.SetShareLimits(tx, fid, uid, limits)
+	})
+}
+
+// autoUnshareFile removes the share of the file with the given user (or the
+// global share if uid is nil) after it reached its limits.
+func (c *Client) autoUnshareFile(sf clientdb.SharedFile, uid *UserID, reason string) error {
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.db.UnshareFile(tx, sf.FID, uid)
+	})
+	if err != nil {
+		return err
+	}
+
+	if uid == nil {
+		c.log.Infof("Unshared file %q (%s) globally: %s", sf.Filename,
+			sf.FID, reason)
+	} else {
+		c.log.Infof("Unshared file %q (%s) with user %s: %s", sf.Filename,
+			sf.FID, uid, reason)
+	}
+	c.ntfns.notifySharedFileAutoUnshared(sf, uid, reason)
+	return nil
+}
+
+// maybeRecordShareDownload records the download of the shared file by the
+// remote user if all of its chunks have been uploaded, unsharing the file if
+// the share reached its download limit.
+func (c *Client) maybeRecordShareDownload(ru *RemoteUser, sf clientdb.SharedFile) error {
+	if sf.Swarm {
+		// Not shared by the local client.
+		return nil
+	}
+
+	var shareUID *UserID
+	var limitReached bool
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		_, md, err := c.db.GetSharedFileForUpload(tx, ru.ID(), sf.FID)
+		if errors.Is(err, clientdb.ErrNotFound) {
+			// Already unshared.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !c.db.FileUploadCompleted(tx, ru.ID(), sf.FID, len(md.Manifest)) {
+			return nil
+		}
+		shareUID, limitReached, err = c.db.RecordSharedFileDownload(tx,
+			sf.FID, ru.ID())
+		return err
+	})
+	if err != nil || !limitReached {
+		return err
+	}
+
+	return c.autoUnshareFile(sf, shareUID, AutoUnshareDownloadLimit)
+}
+
+// unshareExpiredFiles unshares all files whose shares expired.
+func (c *Client) unshareExpiredFiles() error {
+	var shares []clientdb.FileShare
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		shares, err = c.db.ListExpiredShares(tx, time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		sf := clientdb.SharedFile{FID: share.FID, Filename: share.Filename}
+		err := c.autoUnshareFile(sf, share.UID, AutoUnshareExpired)
+		if err != nil {
+			c.log.Errorf("Unable to unshare expired file %s: %v",
+				share.FID, err)
+		}
+	}
+	return nil
+}
+
+// runShareExpiry periodically unshares files whose shares expired.
+func (c *Client) runShareExpiry(ctx context.Context) error {
+	ticker := time.NewTicker(shareExpiryInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.unshareExpiredFiles(); err != nil {
+			c.log.Errorf("Unable to unshare expired files: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// swarmHaveBatchChunks is the number of chunks received between announcements
+// of the chunks available in a re-shared download.
+const swarmHaveBatchChunks = 8
+
+// SetFileDownloadSwarm sets whether the chunks of the given download (started
+// from the given user) are re-shared with the other downloaders of the file
+// that also opted into re-sharing it. The chunks are re-shared for the same
+// cost as the one set by the publisher of the file.
+//
+// The publisher of the file relays the announcements of available chunks among
+// the downloaders, so that they may fetch chunks from one another instead of
+// only from the publisher.
+func (c *Client) SetFileDownloadSwarm(uid UserID, fid clientdb.FileID, swarm bool) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+
+	var chunks []int
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		fd, err := c.db.ReadFileDownload(tx, uid, fid)
+		if err != nil {
+			return err
+		}
+		if fd.UID != uid {
+			return fmt.Errorf("download %s was not started from user %s",
+				fid, uid)
+		}
+		if err := c.db.SetFileDownloadSwarm(tx, &fd, swarm); err != nil {
+			return err
+		}
+		chunks = c.db.AvailableFileDownloadChunks(tx, &fd)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if swarm {
+		ru.log.Infof("Re-sharing %d chunks of download %s", len(chunks), fid)
+	} else {
+		ru.log.Infof("Stopped re-sharing download %s", fid)
+	}
+	rm := rpc.RMFTSwarmHave{
+		FileID: fid.String(),
+		Chunks: chunks,
+		Leave:  !swarm,
+	}
+	payEvent := fmt.Sprintf("ftswarmhave.%s", fid.ShortLogID())
+	return ru.sendRM(rm, payEvent)
+}
+
+// maybeAnnounceSwarmChunks announces the chunks available in the given
+// re-shared download to its publisher, after every batch of received chunks
+// and when the download completes.
+func (c *Client) maybeAnnounceSwarmChunks(ru *RemoteUser, fd *clientdb.FileDownload,
+	nbMissingChunks int) {
+
+	if !fd.Swarm || fd.Metadata == nil {
+		return
+	}
+	nbChunks := len(fd.Metadata.Manifest)
+	nbReceived := nbChunks - nbMissingChunks
+	if nbMissingChunks > 0 && nbReceived%swarmHaveBatchChunks != 0 {
+		return
+	}
+
+	var chunks []int
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		chunks = c.db.AvailableFileDownloadChunks(tx, fd)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	rm := rpc.RMFTSwarmHave{
+		FileID: fd.FID.String(),
+		Chunks: chunks,
+	}
+	payEvent := fmt.Sprintf("ftswarmhave.%s", fd.FID.ShortLogID())
+	go func() {
+		err := ru.sendRM(rm, payEvent)
+		if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+			ru.log.Errorf("Unable to announce swarm chunks: %v", err)
+		}
+	}()
+}
+
+// sendSwarmHaves sends the given swarm announcements to the remote user.
+// Should be called as a goroutine.
+func (c *Client) sendSwarmHaves(ru *RemoteUser, fid clientdb.FileID, rms []rpc.RMFTSwarmHave) {
+	payEvent := fmt.Sprintf("ftswarmhave.%s", fid.ShortLogID())
+	for _, rm := range rms {
+		err := ru.sendRM(rm, payEvent)
+		if errors.Is(err, clientintf.ErrSubsysExiting) {
+			return
+		}
+		if err != nil {
+			ru.log.Errorf("Unable to send swarm announcement: %v", err)
+		}
+	}
+}
+
+// handleFTSwarmHave handles announcements of chunks available in downloaders
+// that re-share a file.
+func (c *Client) handleFTSwarmHave(ru *RemoteUser, sh rpc.RMFTSwarmHave) error {
+	var fid clientdb.FileID
+	if err := fid.FromString(sh.FileID); err != nil {
+		return err
+	}
+
+	if sh.Member != nil {
+		// Announcement relayed by the publisher of a file being
+		// downloaded.
+		return c.handleFTSwarmMemberHave(ru, fid, sh)
+	}
+
+	// Announcement from a downloader of a locally shared file.
+	var isNew bool
+	var members []clientdb.FileSwarmMember
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		sf, md, err := c.db.GetSharedFileForUpload(tx, ru.ID(), fid)
+		if err != nil {
+			return err
+		}
+		if sf.Swarm {
+			return fmt.Errorf("file %s is not published by the local "+
+				"client", fid)
+		}
+
+		if sh.Leave {
+			err := c.db.RemoveFileSwarmMember(tx, fid, ru.ID())
+			if errors.Is(err, clientdb.ErrNotFound) {
+				// Not a member. Nothing to relay.
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		} else {
+			isNew, err = c.db.UpdateFileSwarmMember(tx, fid,
+				len(md.Manifest), ru.ID(), sh.Chunks)
+			if err != nil {
+				return err
+			}
+		}
+
+		members, err = c.db.GetFileSwarm(tx, fid)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if isNew {
+		ru.log.Infof("User joined the swarm of file %s", fid)
+	} else if sh.Leave {
+		ru.log.Infof("User left the swarm of file %s", fid)
+	}
+
+	// Relay the announcement to the other members and, if the user just
+	// joined the swarm, send the chunks available in them to the user.
+	memberID := ru.ID()
+	relay := rpc.RMFTSwarmHave{
+		FileID: sh.FileID,
+		Member: &memberID,
+		Leave:  sh.Leave,
+	}
+	var toNewMember []rpc.RMFTSwarmHave
+	for i := range members {
+		m := &members[i]
+		if m.UID == memberID {
+			relay.Chunks = m.Chunks
+			continue
+		}
+		if isNew {
+			toNewMember = append(toNewMember, rpc.RMFTSwarmHave{
+				FileID: sh.FileID,
+				Member: &m.UID,
+				Chunks: m.Chunks,
+			})
+		}
+	}
+	for i := range members {
+		if members[i].UID == memberID {
+			continue
+		}
+		mru, err := c.rul.byID(members[i].UID)
+		if err != nil {
+			// Removed user.
+			continue
+		}
+		go c.sendSwarmHaves(mru, fid, []rpc.RMFTSwarmHave{relay})
+	}
+	if len(toNewMember) > 0 {
+		go c.sendSwarmHaves(ru, fid, toNewMember)
+	}
+	return nil
+}
+
+// handleFTSwarmMemberHave handles an announcement of chunks available in a
+// downloader of a file, relayed by the publisher of the file.
+func (c *Client) handleFTSwarmMemberHave(ru *RemoteUser, fid clientdb.FileID,
+	sh rpc.RMFTSwarmHave) error {
+
+	member := *sh.Member
+	if member == c.PublicID() {
+		return nil
+	}
+	mru, err := c.rul.byID(member)
+	if err != nil {
+		// Chunks can only be fetched from KX'd users.
+		ru.log.Debugf("Ignoring announcement of swarm member %s of "+
+			"file %s: %v", member, fid, err)
+		return nil
+	}
+
+	var fd clientdb.FileDownload
+	var restart bool
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		var err error
+		fd, err = c.db.ReadFileDownload(tx, ru.ID(), fid)
+		if err != nil {
+			return err
+		}
+		if fd.UID != ru.ID() {
+			return fmt.Errorf("user is not the publisher of download %s",
+				fid)
+		}
+
+		if sh.Leave {
+			err := c.db.RemoveFileDownloadSwarmSource(tx, &fd, member)
+			if errors.Is(err, clientdb.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		isNew, err := c.db.UpdateFileDownloadSwarmSource(tx, &fd, member, sh.Chunks)
+		if err != nil {
+			return err
+		}
+
+		// Restart the download when a new source is added, so that
+		// chunks are fetched from it as well. Chunks announced later
+		// by the source are used when chunks are requested again.
+		// Downloads that did not start requesting chunks yet (e.g.
+		// waiting for confirmation) use the source once they do.
+		restart = isNew && len(fd.ChunkStates) > 0 &&
+			fd.CompletedName == "" && !fd.PreviewOnly && !fd.IsSentFile
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !restart {
+		return nil
+	}
+
+	mru.log.Debugf("User has %d chunks of download %s available",
+		len(sh.Chunks), fid)
+	go func() {
+		err := c.downloadChunks(ru, fd)
+		if err != nil && !errors.Is(err, clientintf.ErrSubsysExiting) {
+			ru.log.Errorf("Unable to download file chunk: %v", err)
+		}
+	}()
+	return nil
+}
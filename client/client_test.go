@@ -3,8 +3,11 @@ package client
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
 )
 
 // TestCanceledRunTerminates ensures running with a canceled context correctly
@@ -39,3 +42,38 @@ func TestCanceledRunTerminates(t *testing.T) {
 		t.Fatal("timeout waiting for Run() to complete")
 	}
 }
+
+// TestHandleProfileUpdateLimits ensures profile updates with oversized fields
+// are rejected on receipt.
+func TestHandleProfileUpdateLimits(t *testing.T) {
+	oversized := func(size int) *string {
+		s := strings.Repeat("a", size+1)
+		return &s
+	}
+	tests := []struct {
+		name string
+		rmpu rpc.RMProfileUpdate
+	}{{
+		name: "avatar",
+		rmpu: rpc.RMProfileUpdate{Avatar: make([]byte, rpc.MaxAvatarSize+1)},
+	}, {
+		name: "name",
+		rmpu: rpc.RMProfileUpdate{Name: oversized(rpc.MaxNameSize)},
+	}, {
+		name: "description",
+		rmpu: rpc.RMProfileUpdate{Description: oversized(rpc.MaxDescriptionSize)},
+	}, {
+		name: "status",
+		rmpu: rpc.RMProfileUpdate{Status: oversized(rpc.MaxStatusSize)},
+	}}
+
+	c := &Client{}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if err := c.handleProfileUpdate(nil, tc.rmpu); err == nil {
+				t.Fatalf("oversized %s was not rejected", tc.name)
+			}
+		})
+	}
+}
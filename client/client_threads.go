@@ -0,0 +1,39 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// ReplyToMessage sends msg as a reply to a previous PM or GC message. target
+// is either the ID of the user with which the PM was exchanged or the ID of
+// the GC where the message was sent. It returns the ID of the new message.
+func (c *Client) ReplyToMessage(target, replyTo zkidentity.ShortID, msg string) (zkidentity.ShortID, error) {
+	if msg == "" {
+		return zkidentity.ShortID{}, fmt.Errorf("cannot send empty reply")
+	}
+
+	ct, err := c.resolveChatTarget(target)
+	if err != nil {
+		return zkidentity.ShortID{}, err
+	}
+	if ct.ru != nil {
+		return c.pm(ct.ru.ID(), msg, &replyTo)
+	}
+	return c.gcMessage(*ct.gcID, nil, msg, rpc.MessageModeNormal, &replyTo, false, nil)
+}
+
+// Thread returns the thread that the message with the given ID belongs to. The
+// message may be either the root of the thread or one of its replies.
+func (c *Client) Thread(msgID zkidentity.ShortID) (*clientdb.Thread, error) {
+	var thread *clientdb.Thread
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		thread, err = c.db.ReadThread(tx, msgID)
+		return err
+	})
+	return thread, err
+}
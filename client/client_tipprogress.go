@@ -0,0 +1,149 @@
+package client
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// TipFailureReason classifies the reason why an attempt at tipping a user
+// failed.
+type TipFailureReason string
+
+const (
+	// TipFailureNone is used when the attempt did not fail.
+	TipFailureNone TipFailureReason = ""
+
+	// TipFailureNoRoute is used when no route to the remote user's node
+	// could be found.
+	TipFailureNoRoute TipFailureReason = "no_route"
+
+	// TipFailureInsufficientBalance is used when the local node does not
+	// have enough outbound capacity to pay the tip.
+	TipFailureInsufficientBalance TipFailureReason = "insufficient_balance"
+
+	// TipFailureInvoiceExpired is used when the invoice sent by the remote
+	// user expired before it could be paid.
+	TipFailureInvoiceExpired TipFailureReason = "invoice_expired"
+
+	// TipFailureInvoiceError is used when the remote user failed to
+	// generate an invoice or sent an invalid one.
+	TipFailureInvoiceError TipFailureReason = "invoice_error"
+
+	// TipFailureBudgetExceeded is used when the tip would exceed a payment
+	// budget.
+	TipFailureBudgetExceeded TipFailureReason = "budget_exceeded"
+
+	// TipFailureTipExpired is used when the tip was not completed within
+	// its max lifetime.
+	TipFailureTipExpired TipFailureReason = "tip_expired"
+
+	// TipFailureOther is used for errors that do not fit any other reason.
+	TipFailureOther TipFailureReason = "other"
+)
+
+// classifyTipFailure returns the failure reason for an error of an attempt at
+// tipping a user. Errors of tip attempts are persisted as strings, so this
+// also matches on the text of known payment errors.
+func classifyTipFailure(err error) TipFailureReason {
+	if err == nil {
+		return TipFailureNone
+	}
+	switch {
+	case errors.Is(err, clientintf.ErrNoRouteWithinFeeLimit):
+		return TipFailureNoRoute
+	case errors.Is(err, clientintf.ErrInvoiceExpired):
+		return TipFailureInvoiceExpired
+	case errors.Is(err, PaymentBudgetExceededError{}):
+		return TipFailureBudgetExceeded
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "no_route"), strings.Contains(msg, "no route"):
+		return TipFailureNoRoute
+	case strings.Contains(msg, "insufficient_balance"),
+		strings.Contains(msg, "insufficient local balance"),
+		strings.Contains(msg, "insufficient balance"):
+		return TipFailureInsufficientBalance
+	case strings.Contains(msg, "invoice expired"),
+		strings.Contains(msg, "invoice is expired"),
+		strings.Contains(msg, "already expired"):
+		return TipFailureInvoiceExpired
+	case strings.Contains(msg, "budget of"):
+		return TipFailureBudgetExceeded
+	case strings.Contains(msg, "invoice"):
+		return TipFailureInvoiceError
+	}
+	return TipFailureOther
+}
+
+// TipAttemptProgress details the progress of an attempt at tipping a user.
+type TipAttemptProgress struct {
+	// Tag is the tag of the tip attempt. It is zero for tips paid through
+	// keysend payments.
+	Tag int32
+
+	// AmtMAtoms is the amount being tipped.
+	AmtMAtoms int64
+
+	// Completed is true when the tip was paid.
+	Completed bool
+
+	// Attempt is the number of invoice requests made so far and
+	// MaxAttempts is the max number of invoice requests that will be made.
+	Attempt     int
+	MaxAttempts int
+
+	// RemainingRetries is the number of invoice requests that may still
+	// be made after this one.
+	RemainingRetries int
+
+	// PaymentRetries is the number of times the payment of the current
+	// invoice was retried.
+	PaymentRetries int
+
+	// Err is the error of the attempt and FailureReason its
+	// classification.
+	Err           error
+	FailureReason TipFailureReason
+
+	// WillRetry is true when a new attempt will be made.
+	WillRetry bool
+
+	// Destination is the LN node of the remote user, if known. FeesMAtoms
+	// is the amount of routing fees paid for a completed tip. Keysend is
+	// true when the tip was paid through a keysend payment.
+	Destination string
+	FeesMAtoms  int64
+	Keysend     bool
+}
+
+// tipAttemptProgress returns the progress of the tip attempt ta.
+func (c *Client) tipAttemptProgress(ru *RemoteUser, ta *clientdb.TipUserAttempt,
+	attemptErr error, willRetry bool) TipAttemptProgress {
+
+	p := TipAttemptProgress{
+		Tag:            ta.Tag,
+		AmtMAtoms:      int64(ta.MilliAtoms),
+		Completed:      ta.Completed != nil,
+		Attempt:        int(ta.Attempts),
+		MaxAttempts:    int(ta.MaxAttempts),
+		PaymentRetries: int(ta.PaymentAttemptCount),
+		FeesMAtoms:     ta.PaymentFees,
+		Err:            attemptErr,
+		FailureReason:  classifyTipFailure(attemptErr),
+		WillRetry:      willRetry,
+	}
+	if willRetry && ta.MaxAttempts > ta.Attempts {
+		p.RemainingRetries = int(ta.MaxAttempts - ta.Attempts)
+	}
+	node, err := c.UserLNNode(ru.ID())
+	if err != nil {
+		ru.log.Warnf("Unable to load LN node of user: %v", err)
+	}
+	p.Destination = node
+	return p
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// TestClassifyTipFailure tests the classification of errors of tip attempts.
+func TestClassifyTipFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want TipFailureReason
+	}{{
+		name: "nil error",
+		err:  nil,
+		want: TipFailureNone,
+	}, {
+		name: "no route within fee limit",
+		err:  fmt.Errorf("estimate: %w", clientintf.ErrNoRouteWithinFeeLimit),
+		want: TipFailureNoRoute,
+	}, {
+		name: "LN no_route",
+		err:  errors.New("LN retriable payment error: unable to find a path: no_route"),
+		want: TipFailureNoRoute,
+	}, {
+		name: "insufficient balance",
+		err:  errors.New("payment failed due to FAILURE_REASON_INSUFFICIENT_BALANCE"),
+		want: TipFailureInsufficientBalance,
+	}, {
+		name: "invoice expired",
+		err:  errors.New("invoice received is already expired"),
+		want: TipFailureInvoiceExpired,
+	}, {
+		name: "budget exceeded",
+		err:  PaymentBudgetExceededError{Period: "daily"},
+		want: TipFailureBudgetExceeded,
+	}, {
+		name: "invalid invoice",
+		err:  errors.New("milliatoms requested in invoice (1) different than milliatoms originally requested (2)"),
+		want: TipFailureInvoiceError,
+	}, {
+		name: "other",
+		err:  errors.New("unable to complete LN payment: connection refused"),
+		want: TipFailureOther,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyTipFailure(tc.err)
+			if got != tc.want {
+				t.Fatalf("unexpected reason: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
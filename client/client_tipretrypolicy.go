@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// tipRetrySettings are the effective settings of the tip retry policy for a
+// user.
+type tipRetrySettings struct {
+	maxAttempts           int32
+	reRequestInvoiceDelay time.Duration
+	payRetryDelayFactor   time.Duration
+	maxPayRetryDelay      time.Duration
+	maxLifetime           time.Duration
+}
+
+// payRetryDelay returns the delay before retrying a payment that failed with
+// a retriable error for the given number of times.
+//
+//nolint:durationcheck
+func (s tipRetrySettings) payRetryDelay(failures uint32) time.Duration {
+	// Cap the exponent to avoid overflowing the delay.
+	if failures > 20 {
+		failures = 20
+	}
+	delay := time.Duration(1<<failures) * s.payRetryDelayFactor
+	if s.maxPayRetryDelay > 0 && delay > s.maxPayRetryDelay {
+		delay = s.maxPayRetryDelay
+	}
+	return delay
+}
+
+// loadTipRetryPolicy loads the tip retry policy from the DB.
+func (c *Client) loadTipRetryPolicy(ctx context.Context) error {
+	var policy *clientdb.TipRetryPolicy
+	err := c.db.View(ctx, func(tx clientdb.ReadTx) error {
+		var err error
+		policy, err = c.db.GetTipRetryPolicy(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c.tipRetryPolicyMtx.Lock()
+	c.tipRetryPolicy = policy
+	c.tipRetryPolicyMtx.Unlock()
+	return nil
+}
+
+// TipRetryPolicy returns the policy used when tipping users. Settings that are
+// not specified in the default policy use the values from the client config.
+func (c *Client) TipRetryPolicy() *clientdb.TipRetryPolicy {
+	c.tipRetryPolicyMtx.Lock()
+	defer c.tipRetryPolicyMtx.Unlock()
+	return copyTipRetryPolicy(c.tipRetryPolicy)
+}
+
+// copyTipRetryPolicy returns a copy of the policy that may be modified
+// without changing the original one.
+func copyTipRetryPolicy(policy *clientdb.TipRetryPolicy) *clientdb.TipRetryPolicy {
+	res := &clientdb.TipRetryPolicy{Default: policy.Default}
+	if len(policy.Contacts) > 0 {
+		res.Contacts = make(map[string]clientdb.TipRetryContactPolicy,
+			len(policy.Contacts))
+		for uid, cp := range policy.Contacts {
+			res.Contacts[uid] = cp
+		}
+	}
+	return res
+}
+
+// storeTipRetryPolicy stores the policy in the DB and sets it as the current
+// one.
+func (c *Client) storeTipRetryPolicy(tx clientdb.ReadWriteTx, policy *clientdb.TipRetryPolicy) error {
+	if err := c.db.UpdateTipRetryPolicy(tx, policy); err != nil {
+		return err
+	}
+	c.tipRetryPolicyMtx.Lock()
+	c.tipRetryPolicy = copyTipRetryPolicy(policy)
+	c.tipRetryPolicyMtx.Unlock()
+	return nil
+}
+
+// SetTipRetryPolicy sets the policy used when tipping users. The policy
+// applies to running tip attempts on their next action.
+func (c *Client) SetTipRetryPolicy(policy *clientdb.TipRetryPolicy) error {
+	if err := policy.Default.Validate(); err != nil {
+		return err
+	}
+	for _, cp := range policy.Contacts {
+		if err := cp.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		return c.storeTipRetryPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	c.log.Infof("Updated tip retry policy (%d contact overrides)",
+		len(policy.Contacts))
+	return nil
+}
+
+// SetContactTipRetryPolicy overrides the default tip retry policy for the
+// given user. Settings that are not specified in the override use the setting
+// of the default policy. Setting an empty override removes it.
+func (c *Client) SetContactTipRetryPolicy(uid UserID, cp clientdb.TipRetryContactPolicy) error {
+	ru, err := c.rul.byID(uid)
+	if err != nil {
+		return err
+	}
+	if err := cp.Validate(); err != nil {
+		return err
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		policy, err := c.db.GetTipRetryPolicy(tx)
+		if err != nil {
+			return err
+		}
+		if cp.IsEmpty() {
+			delete(policy.Contacts, uid.String())
+		} else {
+			if policy.Contacts == nil {
+				policy.Contacts = make(map[string]clientdb.TipRetryContactPolicy)
+			}
+			policy.Contacts[uid.String()] = cp
+		}
+		return c.storeTipRetryPolicy(tx, policy)
+	})
+	if err != nil {
+		return err
+	}
+	ru.log.Infof("Updated tip retry policy override")
+	return nil
+}
+
+// tipRetrySettingsFor returns the effective tip retry settings for the given
+// user. This does not access the DB, so it may be called from within a DB
+// transaction.
+func (c *Client) tipRetrySettingsFor(uid UserID) tipRetrySettings {
+	c.tipRetryPolicyMtx.Lock()
+	cp := c.tipRetryPolicy.ForContact(uid)
+	c.tipRetryPolicyMtx.Unlock()
+
+	s := tipRetrySettings{
+		maxAttempts:           c.cfg.TipUserMaxAttempts,
+		reRequestInvoiceDelay: c.cfg.TipUserReRequestInvoiceDelay,
+		payRetryDelayFactor:   c.cfg.TipUserPayRetryDelayFactor,
+		maxPayRetryDelay:      c.cfg.TipUserMaxPayRetryDelay,
+		maxLifetime:           c.cfg.TipUserMaxLifetime,
+	}
+	if cp.MaxAttempts != nil {
+		s.maxAttempts = *cp.MaxAttempts
+	}
+	if cp.ReRequestInvoiceDelay != nil {
+		s.reRequestInvoiceDelay = *cp.ReRequestInvoiceDelay
+	}
+	if cp.PayRetryDelayFactor != nil {
+		s.payRetryDelayFactor = *cp.PayRetryDelayFactor
+	}
+	if cp.MaxPayRetryDelay != nil {
+		s.maxPayRetryDelay = *cp.MaxPayRetryDelay
+	}
+	if cp.MaxLifetime != nil {
+		s.maxLifetime = *cp.MaxLifetime
+	}
+	return s
+}
+
+// maxTipLifetime returns the largest max lifetime of tip attempts across the
+// default tip retry policy and every user override.
+func (c *Client) maxTipLifetime() time.Duration {
+	c.tipRetryPolicyMtx.Lock()
+	defer c.tipRetryPolicyMtx.Unlock()
+	res := c.cfg.TipUserMaxLifetime
+	if l := c.tipRetryPolicy.Default.MaxLifetime; l != nil {
+		res = *l
+	}
+	for _, cp := range c.tipRetryPolicy.Contacts {
+		if cp.MaxLifetime != nil && *cp.MaxLifetime > res {
+			res = *cp.MaxLifetime
+		}
+	}
+	return res
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTipRetryPayRetryDelay tests the delays between retries of tip payments.
+func TestTipRetryPayRetryDelay(t *testing.T) {
+	s := tipRetrySettings{
+		payRetryDelayFactor: time.Second,
+		maxPayRetryDelay:    10 * time.Second,
+	}
+	tests := []struct {
+		failures uint32
+		want     time.Duration
+	}{
+		{failures: 0, want: time.Second},
+		{failures: 1, want: 2 * time.Second},
+		{failures: 3, want: 8 * time.Second},
+		{failures: 4, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+	for _, tc := range tests {
+		got := s.payRetryDelay(tc.failures)
+		if got != tc.want {
+			t.Fatalf("unexpected delay for %d failures: got %s, want %s",
+				tc.failures, got, tc.want)
+		}
+	}
+
+	// Without a max delay, the delay is not limited.
+	s.maxPayRetryDelay = 0
+	if got, want := s.payRetryDelay(10), 1024*time.Second; got != want {
+		t.Fatalf("unexpected delay: got %s, want %s", got, want)
+	}
+}
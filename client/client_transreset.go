@@ -66,7 +66,12 @@ func (c *Client) RequestTransitiveReset(mediator, target UserID) error {
 
 	// Ask mediator to forward msg to target.
 	tr := rpc.RMTransitiveReset{HalfKX: *kxA}
-	return mediatorRU.sendTransitive(tr, "transreset", targetAB.ID, priorityDefault)
+	err = mediatorRU.sendTransitive(tr, "transreset", targetAB.ID, priorityDefault)
+	if err != nil {
+		return err
+	}
+	c.ntfns.notifyRatchetResetRequested(targetRU, false)
+	return nil
 }
 
 func (c *Client) handleRMTransitiveReset(mediator *RemoteUser, target UserID, tr rpc.RMTransitiveReset) error {
@@ -85,6 +90,7 @@ func (c *Client) handleRMTransitiveReset(mediator *RemoteUser, target UserID, tr
 	}
 
 	mediator.log.Infof("Received trans reset request from %s", ru)
+	c.ntfns.notifyRatchetResetRequested(ru, true)
 
 	// Fill out missing bits
 	r := ratchet.New(rand.Reader) // full
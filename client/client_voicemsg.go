@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+const (
+	// VoiceMsgMimeType is the mime type of voice message embeds. Voice
+	// messages are Opus-encoded audio in an Ogg container.
+	VoiceMsgMimeType = "audio/ogg"
+
+	// MaxVoiceMsgSize is the max size of the encoded audio of a voice
+	// message.
+	MaxVoiceMsgSize = 512 * 1024
+
+	// MaxVoiceMsgDuration is the max duration of a voice message.
+	MaxVoiceMsgDuration = 5 * time.Minute
+)
+
+// VoiceMsg is a voice message embedded in a PM or GC message.
+type VoiceMsg struct {
+	// Audio is the Opus-encoded audio (in an Ogg container).
+	Audio []byte
+
+	// Duration is the duration of the audio, as reported by the sender.
+	Duration time.Duration
+}
+
+// validateVoiceMsgAudio ensures the audio of a voice message is Opus-encoded
+// in an Ogg container and is within the size limit.
+func validateVoiceMsgAudio(audio []byte) error {
+	if len(audio) > MaxVoiceMsgSize {
+		return fmt.Errorf("voice message size %d > max size %d",
+			len(audio), MaxVoiceMsgSize)
+	}
+
+	// The first Ogg page of an Opus stream contains the Opus
+	// identification header, which starts after the 27 byte page header
+	// and the 1 byte segment table.
+	if len(audio) < 36 || !bytes.HasPrefix(audio, []byte("OggS")) {
+		return fmt.Errorf("voice message audio is not in an Ogg container")
+	}
+	if !bytes.Equal(audio[28:36], []byte("OpusHead")) {
+		return fmt.Errorf("voice message audio is not Opus-encoded")
+	}
+	return nil
+}
+
+// OggOpusDuration returns the duration of Opus-encoded audio in an Ogg
+// container. This is determined by the granule position of the last Ogg page,
+// which for Opus streams is always in units of 48kHz samples.
+func OggOpusDuration(audio []byte) (time.Duration, error) {
+	if err := validateVoiceMsgAudio(audio); err != nil {
+		return 0, err
+	}
+	lastPage := bytes.LastIndex(audio, []byte("OggS"))
+	if lastPage < 0 || len(audio) < lastPage+14 {
+		return 0, fmt.Errorf("truncated Ogg page")
+	}
+	granule := binary.LittleEndian.Uint64(audio[lastPage+6:])
+	preSkip := uint64(binary.LittleEndian.Uint16(audio[28+10:]))
+	if granule <= preSkip {
+		return 0, fmt.Errorf("invalid Ogg granule position")
+	}
+	samples := granule - preSkip
+	return time.Duration(samples) * time.Second / 48000, nil
+}
+
+// VoiceMsgEmbed returns the embed string for a voice message with the given
+// audio and duration. If duration is zero, it is determined from the audio.
+func VoiceMsgEmbed(audio []byte, duration time.Duration) (string, error) {
+	if err := validateVoiceMsgAudio(audio); err != nil {
+		return "", err
+	}
+	if duration == 0 {
+		var err error
+		if duration, err = OggOpusDuration(audio); err != nil {
+			return "", err
+		}
+	}
+	if duration <= 0 || duration > MaxVoiceMsgDuration {
+		return "", fmt.Errorf("invalid voice message duration %s", duration)
+	}
+	args := mdembeds.EmbeddedArgs{
+		Typ:        VoiceMsgMimeType,
+		Data:       audio,
+		DurationMs: uint64(duration.Milliseconds()),
+	}
+	return args.String(), nil
+}
+
+// ParseVoiceMsgs returns the valid voice messages embedded in a PM or GC
+// message.
+func ParseVoiceMsgs(msg string) []VoiceMsg {
+	var res []VoiceMsg
+	for _, idx := range mdembeds.FindAllStringIndex(msg) {
+		args := mdembeds.ParseEmbedArgs(msg[idx[0]:idx[1]])
+		if args.Typ != VoiceMsgMimeType || validateVoiceMsgAudio(args.Data) != nil {
+			continue
+		}
+		res = append(res, VoiceMsg{
+			Audio:    args.Data,
+			Duration: time.Duration(args.DurationMs) * time.Millisecond,
+		})
+	}
+	return res
+}
+
+// SendVoiceMsg sends a voice message to the target, which is either the ID of
+// a user or of a GC. The audio must be Opus-encoded in an Ogg container and is
+// limited to MaxVoiceMsgSize bytes. If duration is zero, it is determined from
+// the audio. Returns the ID of the sent message.
+func (c *Client) SendVoiceMsg(target zkidentity.ShortID, audio []byte,
+	duration time.Duration) (zkidentity.ShortID, error) {
+
+	embed, err := VoiceMsgEmbed(audio, duration)
+	if err != nil {
+		return zkidentity.ShortID{}, err
+	}
+
+	ct, err := c.resolveChatTarget(target)
+	if err != nil {
+		return zkidentity.ShortID{}, err
+	}
+	if ct.gcID != nil {
+		return c.GCMessageWithMsgID(*ct.gcID, embed, rpc.MessageModeNormal, nil)
+	}
+	return c.PMWithMsgID(target, embed)
+}
+
+// notifyVoiceMsgs sends the voice message notifications for the voice
+// messages embedded in a received PM or GC message.
+func (c *Client) notifyVoiceMsgs(ru *RemoteUser, gcID *zkidentity.ShortID,
+	msgID *zkidentity.ShortID, msg string, ts time.Time) {
+
+	if !c.ntfns.AnyRegistered(OnVoiceMsgRcvdNtfn(nil)) {
+		return
+	}
+	for _, vm := range ParseVoiceMsgs(msg) {
+		c.ntfns.notifyVoiceMsgRcvd(ru, gcID, msgID, vm, ts)
+	}
+}
@@ -0,0 +1,403 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/sync/errgroup"
+)
+
+// Payment webhooks:
+//
+// Payment events (received tips, settled invoices and content purchases) are
+// delivered to the configured webhooks as an HTTP POST request with a
+// JSON-encoded PaymentEvent as the body. Deliveries are stored in the DB before
+// being attempted, so that events are not lost when a webhook is unreachable
+// or the client is restarted, and failed deliveries are retried with an
+// exponential backoff up to webhookMaxAttempts times.
+//
+// Every request carries the Unix time of the attempt in the header
+// X-BR-Timestamp. When a webhook has a secret, the string
+// "<timestamp>.<body>" is signed with HMAC-SHA256 using the secret as key,
+// with the hex-encoded signature sent in the header X-BR-Signature as
+// "sha256=<signature>". Receivers should reject requests with stale
+// timestamps and deduplicate deliveries by the X-BR-Delivery header, so that
+// captured requests cannot be replayed.
+//
+// Each webhook is served independently, so an unreachable webhook only delays
+// its own deliveries.
+
+const (
+	// PaymentEventTipReceived is the event of a tip received from a user,
+	// either by paying an invoice or through a keysend payment.
+	PaymentEventTipReceived = "tip_received"
+
+	// PaymentEventInvoiceSettled is the event of the settlement of an
+	// invoice generated by the local client, either to receive a tip or
+	// as the hold invoice of an escrowed payment.
+	PaymentEventInvoiceSettled = "invoice_settled"
+
+	// PaymentEventContentPurchased is the event of a payment for a chunk
+	// of a file shared by the local client.
+	PaymentEventContentPurchased = "content_purchased"
+)
+
+const (
+	// webhookTimeout is the max duration of a request to a webhook.
+	webhookTimeout = 30 * time.Second
+
+	// webhookRetryDelay is the delay before the first retry of a failed
+	// delivery. The delay doubles after each failed attempt, up to
+	// webhookMaxRetryDelay.
+	webhookRetryDelay    = 30 * time.Second
+	webhookMaxRetryDelay = 6 * time.Hour
+
+	// webhookMaxAttempts is the number of failed attempts after which a
+	// delivery is discarded.
+	webhookMaxAttempts = 12
+)
+
+// PaymentWebhook is a URL notified of payment events. If Secret is not empty,
+// requests are signed with it.
+type PaymentWebhook struct {
+	URL    string
+	Secret string
+}
+
+// PaymentEventEscrow is the escrow of an invoice_settled event.
+type PaymentEventEscrow struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// PaymentEventContent is the purchased content of a content_purchased event.
+type PaymentEventContent struct {
+	FileID     string `json:"file_id"`
+	Filename   string `json:"filename"`
+	ChunkIndex int    `json:"chunk_index"`
+}
+
+// PaymentEvent is the body of the requests sent to payment webhooks. The ID
+// of the event is the same in the requests to every webhook, and in retries
+// of the delivery to a webhook.
+type PaymentEvent struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	UID         string    `json:"uid"`
+	Nick        string    `json:"nick"`
+	MilliAtoms  int64     `json:"milli_atoms"`
+	PaymentHash string    `json:"payment_hash,omitempty"`
+	Invoice     string    `json:"invoice,omitempty"`
+
+	Escrow  *PaymentEventEscrow  `json:"escrow,omitempty"`
+	Content *PaymentEventContent `json:"content,omitempty"`
+}
+
+// wakeWebhooks signals the webhooks runner that new deliveries are pending.
+func (c *Client) wakeWebhooks() {
+	select {
+	case c.webhooksChan <- struct{}{}:
+	default:
+	}
+}
+
+// firePaymentEvent stores the deliveries of the event to every configured
+// webhook.
+func (c *Client) firePaymentEvent(ru *RemoteUser, ev PaymentEvent) {
+	if len(c.cfg.PaymentWebhooks) == 0 {
+		return
+	}
+
+	var id zkidentity.ShortID
+	if _, err := rand.Read(id[:]); err != nil {
+		c.log.Errorf("Unable to generate ID of payment event: %v", err)
+		return
+	}
+	ev.ID = id.String()
+	ev.Timestamp = time.Now()
+	ev.UID = ru.ID().String()
+	ev.Nick = ru.Nick()
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		c.log.Errorf("Unable to encode payment event: %v", err)
+		return
+	}
+
+	err = c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		for _, wh := range c.cfg.PaymentWebhooks {
+			d := clientdb.WebhookDelivery{
+				URL:         wh.URL,
+				Event:       ev.Type,
+				Payload:     payload,
+				Created:     ev.Timestamp,
+				NextAttempt: ev.Timestamp,
+			}
+			if _, err := rand.Read(d.ID[:]); err != nil {
+				return err
+			}
+			if err := c.db.StoreWebhookDelivery(tx, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.log.Errorf("Unable to store %s event deliveries: %v", ev.Type, err)
+		return
+	}
+	c.log.Debugf("Queued %s event %s to %d webhooks", ev.Type, ev.ID,
+		len(c.cfg.PaymentWebhooks))
+	c.wakeWebhooks()
+}
+
+// webhookHTTPClient returns the http client used to deliver events to
+// webhooks.
+func (c *Client) webhookHTTPClient() *http.Client {
+	dialFunc := c.cfg.WebhookDialFunc
+	if dialFunc == nil {
+		var d net.Dialer
+		dialFunc = d.DialContext
+	}
+	return &http.Client{
+		Transport: &http.Transport{DialContext: dialFunc},
+		Timeout:   webhookTimeout,
+	}
+}
+
+// signWebhookPayload returns the hex-encoded HMAC-SHA256 signature of the
+// timestamp (formatted as in the X-BR-Timestamp header) and payload of a
+// request.
+func signWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelayFor returns the delay before retrying a delivery that
+// failed the given number of attempts.
+func webhookRetryDelayFor(attempts int) time.Duration {
+	delay := webhookRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+// deliverWebhook sends the delivery to its webhook.
+func (c *Client) deliverWebhook(ctx context.Context, hc *http.Client,
+	wh PaymentWebhook, d *clientdb.WebhookDelivery) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL,
+		bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BR-Event", d.Event)
+	req.Header.Set("X-BR-Delivery", d.ID.String())
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-BR-Timestamp", timestamp)
+	if wh.Secret != "" {
+		req.Header.Set("X-BR-Signature", "sha256="+
+			signWebhookPayload(wh.Secret, timestamp, d.Payload))
+	}
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook replied with status %s", res.Status)
+	}
+	return nil
+}
+
+// discardUnconfiguredWebhookDeliveries removes the pending deliveries to
+// webhooks that were removed from the config.
+func (c *Client) discardUnconfiguredWebhookDeliveries() error {
+	webhooks := make(map[string]struct{}, len(c.cfg.PaymentWebhooks))
+	for _, wh := range c.cfg.PaymentWebhooks {
+		webhooks[wh.URL] = struct{}{}
+	}
+
+	return c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+		deliveries, err := c.db.ListWebhookDeliveries(tx)
+		if err != nil {
+			return err
+		}
+		for _, d := range deliveries {
+			if _, ok := webhooks[d.URL]; ok {
+				continue
+			}
+			c.log.Infof("Discarding %s event delivery %s to "+
+				"unconfigured webhook %s", d.Event, d.ID, d.URL)
+			if err := c.db.RemoveWebhookDelivery(tx, d.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deliverDueWebhooks attempts the due deliveries to the webhook. It returns the
+// time of the next pending delivery to the webhook, if there is one.
+func (c *Client) deliverDueWebhooks(ctx context.Context, hc *http.Client,
+	wh PaymentWebhook) (*time.Time, error) {
+
+	var deliveries []clientdb.WebhookDelivery
+	err := c.dbView(func(tx clientdb.ReadTx) error {
+		var err error
+		deliveries, err = c.db.ListWebhookDeliveries(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	for i := range deliveries {
+		d := &deliveries[i]
+		if d.URL != wh.URL {
+			continue
+		}
+
+		if d.NextAttempt.After(time.Now()) {
+			if next == nil || d.NextAttempt.Before(*next) {
+				next = &d.NextAttempt
+			}
+			continue
+		}
+
+		deliverErr := c.deliverWebhook(ctx, hc, wh, d)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		remove := deliverErr == nil
+		if deliverErr != nil {
+			d.Attempts += 1
+			d.LastError = deliverErr.Error()
+			d.NextAttempt = time.Now().Add(webhookRetryDelayFor(d.Attempts))
+			if d.Attempts >= webhookMaxAttempts {
+				c.log.Errorf("Discarding %s event delivery %s to %s "+
+					"after %d failed attempts: %v", d.Event, d.ID,
+					d.URL, d.Attempts, deliverErr)
+				remove = true
+			} else {
+				c.log.Warnf("Unable to deliver %s event %s to %s "+
+					"(attempt %d): %v", d.Event, d.ID, d.URL,
+					d.Attempts, deliverErr)
+				if next == nil || d.NextAttempt.Before(*next) {
+					next = &d.NextAttempt
+				}
+			}
+		} else {
+			c.log.Debugf("Delivered %s event %s to %s", d.Event, d.ID,
+				d.URL)
+		}
+
+		err := c.dbUpdate(func(tx clientdb.ReadWriteTx) error {
+			if remove {
+				return c.db.RemoveWebhookDelivery(tx, d.ID)
+			}
+			return c.db.StoreWebhookDelivery(tx, *d)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// runWebhook delivers the payment events to a single webhook. It is woken up
+// through wakeChan when new deliveries are pending.
+func (c *Client) runWebhook(ctx context.Context, hc *http.Client,
+	wh PaymentWebhook, wakeChan <-chan struct{}) error {
+
+	for {
+		next, err := c.deliverDueWebhooks(ctx, hc, wh)
+		if err != nil && ctx.Err() == nil {
+			c.log.Errorf("Unable to deliver events to webhook %s: %v",
+				wh.URL, err)
+		}
+
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if next != nil {
+			timer = time.NewTimer(time.Until(*next))
+			timerChan = timer.C
+		}
+
+		select {
+		case <-timerChan:
+		case <-wakeChan:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// runWebhooks delivers the payment events to the configured webhooks. Each
+// webhook is served by its own goroutine, so that an unreachable or slow
+// webhook does not delay the deliveries to the others.
+func (c *Client) runWebhooks(ctx context.Context) error {
+	if err := c.discardUnconfiguredWebhookDeliveries(); err != nil {
+		c.log.Errorf("Unable to discard deliveries to unconfigured "+
+			"webhooks: %v", err)
+	}
+	if len(c.cfg.PaymentWebhooks) == 0 {
+		return nil
+	}
+
+	hc := c.webhookHTTPClient()
+	g, gctx := errgroup.WithContext(ctx)
+	wakeChans := make([]chan struct{}, len(c.cfg.PaymentWebhooks))
+	for i, wh := range c.cfg.PaymentWebhooks {
+		wh, wakeChan := wh, make(chan struct{}, 1)
+		wakeChans[i] = wakeChan
+		g.Go(func() error { return c.runWebhook(gctx, hc, wh, wakeChan) })
+	}
+
+	g.Go(func() error {
+		for {
+			select {
+			case <-c.webhooksChan:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+			for _, wakeChan := range wakeChans {
+				select {
+				case wakeChan <- struct{}{}:
+				default:
+				}
+			}
+		}
+	})
+	return g.Wait()
+}
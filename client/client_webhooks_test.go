@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+)
+
+// TestWebhookRetryDelay tests the backoff of failed webhook deliveries.
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: webhookRetryDelay},
+		{attempts: 2, want: 2 * webhookRetryDelay},
+		{attempts: 3, want: 4 * webhookRetryDelay},
+		{attempts: 100, want: webhookMaxRetryDelay},
+	}
+	for _, tc := range tests {
+		got := webhookRetryDelayFor(tc.attempts)
+		if got != tc.want {
+			t.Fatalf("%d attempts: unexpected delay: got %s, want %s",
+				tc.attempts, got, tc.want)
+		}
+	}
+}
+
+// TestDeliverWebhook tests that deliveries to webhooks are signed with the
+// secret of the webhook, covering both the timestamp and the body.
+func TestDeliverWebhook(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{"type":"tip_received"}`)
+	status := http.StatusOK
+	var gotSig, gotEvent, gotTimestamp string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-BR-Signature")
+		gotEvent = r.Header.Get("X-BR-Event")
+		gotTimestamp = r.Header.Get("X-BR-Timestamp")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	c := &Client{}
+	wh := PaymentWebhook{URL: srv.URL, Secret: secret}
+	d := &clientdb.WebhookDelivery{
+		URL:     srv.URL,
+		Event:   PaymentEventTipReceived,
+		Payload: payload,
+	}
+	err := c.deliverWebhook(context.Background(), srv.Client(), wh, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotBody) != string(payload) {
+		t.Fatalf("unexpected body: got %s, want %s", gotBody, payload)
+	}
+	if gotEvent != PaymentEventTipReceived {
+		t.Fatalf("unexpected event: got %q", gotEvent)
+	}
+	ts, err := strconv.ParseInt(gotTimestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp %q: %v", gotTimestamp, err)
+	}
+	if d := time.Since(time.Unix(ts, 0)); d < -time.Minute || d > time.Minute {
+		t.Fatalf("unexpected timestamp %d", ts)
+	}
+	wantSig := "sha256=" + signWebhookPayload(secret, gotTimestamp, payload)
+	if gotSig != wantSig {
+		t.Fatalf("unexpected signature: got %q, want %q", gotSig, wantSig)
+	}
+
+	// The signature does not verify with a different timestamp.
+	replaySig := "sha256=" + signWebhookPayload(secret,
+		strconv.FormatInt(ts+3600, 10), payload)
+	if gotSig == replaySig {
+		t.Fatal("signature does not cover the timestamp")
+	}
+
+	// Non-2xx replies are failed deliveries.
+	status = http.StatusInternalServerError
+	err = c.deliverWebhook(context.Background(), srv.Client(), wh, d)
+	if err == nil {
+		t.Fatal("expected error on failed delivery")
+	}
+}
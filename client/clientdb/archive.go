@@ -0,0 +1,79 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// readArchivedChats reads the set of archived chats. The map is keyed by the
+// string ID of the chat.
+func (db *DB) readArchivedChats() (map[string]time.Time, error) {
+	fname := filepath.Join(db.root, archivedChatsFile)
+	archived := make(map[string]time.Time)
+	err := db.readJsonFile(fname, &archived)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// ArchiveChat marks the chat (PM or GC) with the given ID as archived.
+func (db *DB) ArchiveChat(tx ReadWriteTx, id zkidentity.ShortID) error {
+	archived, err := db.readArchivedChats()
+	if err != nil {
+		return err
+	}
+	if _, ok := archived[id.String()]; ok {
+		return nil
+	}
+	archived[id.String()] = time.Now()
+	fname := filepath.Join(db.root, archivedChatsFile)
+	return db.saveJsonFile(fname, archived)
+}
+
+// UnarchiveChat removes the archived mark of the chat with the given ID.
+// Returns true if the chat was archived.
+func (db *DB) UnarchiveChat(tx ReadWriteTx, id zkidentity.ShortID) (bool, error) {
+	archived, err := db.readArchivedChats()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := archived[id.String()]; !ok {
+		return false, nil
+	}
+	delete(archived, id.String())
+	fname := filepath.Join(db.root, archivedChatsFile)
+	return true, db.saveJsonFile(fname, archived)
+}
+
+// IsChatArchived returns true if the chat with the given ID is archived.
+func (db *DB) IsChatArchived(tx ReadTx, id zkidentity.ShortID) (bool, error) {
+	archived, err := db.readArchivedChats()
+	if err != nil {
+		return false, err
+	}
+	_, ok := archived[id.String()]
+	return ok, nil
+}
+
+// ListArchivedChats returns the IDs of archived chats, along with the time
+// they were archived.
+func (db *DB) ListArchivedChats(tx ReadTx) (map[zkidentity.ShortID]time.Time, error) {
+	archived, err := db.readArchivedChats()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[zkidentity.ShortID]time.Time, len(archived))
+	for sid, t := range archived {
+		var id zkidentity.ShortID
+		if err := id.FromString(sid); err != nil {
+			db.log.Warnf("Not a valid chat ID in archived chats: %s", sid)
+			continue
+		}
+		res[id] = t
+	}
+	return res, nil
+}
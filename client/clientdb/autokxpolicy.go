@@ -0,0 +1,131 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// AutoKXAction is the action automatically taken by the client on a
+// transitive KX event.
+type AutoKXAction string
+
+const (
+	// AutoKXActionDefault means the action of the default policy is used.
+	AutoKXActionDefault AutoKXAction = ""
+
+	// AutoKXActionPrompt means the user is prompted for the action.
+	AutoKXActionPrompt AutoKXAction = "prompt"
+
+	// AutoKXActionAccept means the event is automatically accepted.
+	AutoKXActionAccept AutoKXAction = "accept"
+
+	// AutoKXActionReject means the event is automatically rejected.
+	AutoKXActionReject AutoKXAction = "reject"
+)
+
+// IsValid returns true if this is a known action.
+func (a AutoKXAction) IsValid() bool {
+	switch a {
+	case AutoKXActionDefault, AutoKXActionPrompt, AutoKXActionAccept,
+		AutoKXActionReject:
+		return true
+	default:
+		return false
+	}
+}
+
+// AutoKXContactPolicy is the policy applied to transitive KX events that
+// originate from a contact.
+type AutoKXContactPolicy struct {
+	// Suggestions is the action taken when the contact suggests KXing
+	// with another user. Accepting a suggestion requests the contact to
+	// mediate the KX.
+	Suggestions AutoKXAction `json:"suggestions,omitempty"`
+
+	// MediateID is the action taken when the contact asks the local client
+	// to mediate a KX with another user. Prompting is not supported for
+	// this action.
+	MediateID AutoKXAction `json:"mediate_id,omitempty"`
+
+	// MediatedKX is the action taken when the contact, acting as a
+	// mediator, asks the local client to KX with another user. Prompting
+	// is not supported for this action.
+	MediatedKX AutoKXAction `json:"mediated_kx,omitempty"`
+}
+
+// Merge returns the policy where every action not specified in p is replaced
+// by the corresponding action from def.
+func (p AutoKXContactPolicy) Merge(def AutoKXContactPolicy) AutoKXContactPolicy {
+	if p.Suggestions == AutoKXActionDefault {
+		p.Suggestions = def.Suggestions
+	}
+	if p.MediateID == AutoKXActionDefault {
+		p.MediateID = def.MediateID
+	}
+	if p.MediatedKX == AutoKXActionDefault {
+		p.MediatedKX = def.MediatedKX
+	}
+	return p
+}
+
+// Validate returns an error if any of the actions is not valid.
+func (p AutoKXContactPolicy) Validate() error {
+	if !p.Suggestions.IsValid() {
+		return fmt.Errorf("invalid suggestions action %q", p.Suggestions)
+	}
+	if !p.MediateID.IsValid() || p.MediateID == AutoKXActionPrompt {
+		return fmt.Errorf("invalid mediate id action %q", p.MediateID)
+	}
+	if !p.MediatedKX.IsValid() || p.MediatedKX == AutoKXActionPrompt {
+		return fmt.Errorf("invalid mediated kx action %q", p.MediatedKX)
+	}
+	return nil
+}
+
+// AutoKXPolicy is the policy used to automatically handle transitive KX
+// events.
+type AutoKXPolicy struct {
+	// Default is the policy for contacts without an override.
+	Default AutoKXContactPolicy `json:"default"`
+
+	// Contacts are the per-contact overrides of the default policy. Keys
+	// are user IDs.
+	Contacts map[string]AutoKXContactPolicy `json:"contacts,omitempty"`
+
+	// DisableGCRequests disables automatically requesting KX with unknown
+	// GC members.
+	DisableGCRequests bool `json:"disable_gc_requests"`
+
+	// RequestViaGCAdmins allows automatically requesting KX with unknown
+	// GC members through any of the GC admins, instead of only through
+	// the GC owner.
+	RequestViaGCAdmins bool `json:"request_via_gc_admins"`
+
+	// MaxRequestsPerHour is the max number of automatic KX requests
+	// (mediate ID requests) made per hour. Zero means unlimited.
+	MaxRequestsPerHour uint32 `json:"max_requests_per_hour"`
+}
+
+// ForContact returns the effective policy for the given contact.
+func (p *AutoKXPolicy) ForContact(uid UserID) AutoKXContactPolicy {
+	return p.Contacts[uid.String()].Merge(p.Default)
+}
+
+// GetAutoKXPolicy returns the stored auto KX policy. If no policy has been
+// stored yet, an empty policy is returned.
+func (db *DB) GetAutoKXPolicy(tx ReadTx) (*AutoKXPolicy, error) {
+	filename := filepath.Join(db.root, autoKXPolicyFile)
+	policy := new(AutoKXPolicy)
+	err := db.readJsonFile(filename, policy)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdateAutoKXPolicy stores the auto KX policy.
+func (db *DB) UpdateAutoKXPolicy(tx ReadWriteTx, policy *AutoKXPolicy) error {
+	filename := filepath.Join(db.root, autoKXPolicyFile)
+	return db.saveJsonFile(filename, policy)
+}
@@ -0,0 +1,90 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// AutoReplyConfig is the config of the automatic replies sent to received
+// PMs.
+type AutoReplyConfig struct {
+	// Enabled is true when automatic replies are sent.
+	Enabled bool `json:"enabled"`
+
+	// Message is the automatic reply.
+	Message string `json:"message"`
+
+	// Interval is the minimum interval between automatic replies sent to
+	// the same user.
+	Interval time.Duration `json:"interval"`
+}
+
+// autoReplyState is the persisted state of automatic replies.
+type autoReplyState struct {
+	Config AutoReplyConfig `json:"config"`
+
+	// LastReplies tracks the last time an automatic reply was sent to
+	// each user. Keys are user IDs.
+	LastReplies map[string]time.Time `json:"last_replies"`
+}
+
+func (db *DB) readAutoReplyState() (*autoReplyState, error) {
+	filename := filepath.Join(db.root, autoReplyFile)
+	state := new(autoReplyState)
+	err := db.readJsonFile(filename, state)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if state.LastReplies == nil {
+		state.LastReplies = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// GetAutoReplyConfig returns the current automatic reply config.
+func (db *DB) GetAutoReplyConfig(tx ReadTx) (AutoReplyConfig, error) {
+	state, err := db.readAutoReplyState()
+	if err != nil {
+		return AutoReplyConfig{}, err
+	}
+	return state.Config, nil
+}
+
+// UpdateAutoReplyConfig updates the automatic reply config. Changing the
+// config resets the tracking of users that were already sent automatic
+// replies.
+func (db *DB) UpdateAutoReplyConfig(tx ReadWriteTx, cfg AutoReplyConfig) error {
+	state := autoReplyState{
+		Config:      cfg,
+		LastReplies: make(map[string]time.Time),
+	}
+	filename := filepath.Join(db.root, autoReplyFile)
+	return db.saveJsonFile(filename, state)
+}
+
+// ShouldAutoReply returns the automatic reply that should be sent to the given
+// user. If automatic replies are disabled or a reply was sent to the user
+// within the configured interval, this returns an empty string. Otherwise,
+// the user is marked as being sent an automatic reply at the given time.
+func (db *DB) ShouldAutoReply(tx ReadWriteTx, uid UserID, now time.Time) (string, error) {
+	state, err := db.readAutoReplyState()
+	if err != nil {
+		return "", err
+	}
+	if !state.Config.Enabled || state.Config.Message == "" {
+		return "", nil
+	}
+
+	key := uid.String()
+	if last, ok := state.LastReplies[key]; ok && now.Sub(last) < state.Config.Interval {
+		return "", nil
+	}
+
+	state.LastReplies[key] = now
+	filename := filepath.Join(db.root, autoReplyFile)
+	if err := db.saveJsonFile(filename, state); err != nil {
+		return "", err
+	}
+	return state.Config.Message, nil
+}
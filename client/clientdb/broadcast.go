@@ -0,0 +1,90 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BroadcastList is a locally defined list of users to which messages may be
+// broadcast as individual PMs.
+type BroadcastList struct {
+	Name        string    `json:"name"`
+	Members     []UserID  `json:"members"`
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// readBroadcastLists reads the broadcast lists. The map is keyed by the name
+// of the list.
+func (db *DB) readBroadcastLists() (map[string]BroadcastList, error) {
+	fname := filepath.Join(db.root, broadcastListsFile)
+	lists := make(map[string]BroadcastList)
+	err := db.readJsonFile(fname, &lists)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetBroadcastList returns the broadcast list with the given name. Returns
+// ErrNotFound if the list does not exist.
+func (db *DB) GetBroadcastList(tx ReadTx, name string) (BroadcastList, error) {
+	lists, err := db.readBroadcastLists()
+	if err != nil {
+		return BroadcastList{}, err
+	}
+	bl, ok := lists[name]
+	if !ok {
+		return BroadcastList{}, ErrNotFound
+	}
+	return bl, nil
+}
+
+// StoreBroadcastList creates or replaces the broadcast list with the name of
+// the passed list.
+func (db *DB) StoreBroadcastList(tx ReadWriteTx, bl BroadcastList) error {
+	lists, err := db.readBroadcastLists()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if old, ok := lists[bl.Name]; ok {
+		bl.Created = old.Created
+	} else {
+		bl.Created = now
+	}
+	bl.LastUpdated = now
+	lists[bl.Name] = bl
+	fname := filepath.Join(db.root, broadcastListsFile)
+	return db.saveJsonFile(fname, lists)
+}
+
+// RemoveBroadcastList removes the broadcast list with the given name.
+func (db *DB) RemoveBroadcastList(tx ReadWriteTx, name string) error {
+	lists, err := db.readBroadcastLists()
+	if err != nil {
+		return err
+	}
+	if _, ok := lists[name]; !ok {
+		return ErrNotFound
+	}
+	delete(lists, name)
+	fname := filepath.Join(db.root, broadcastListsFile)
+	return db.saveJsonFile(fname, lists)
+}
+
+// ListBroadcastLists returns all broadcast lists, sorted by name.
+func (db *DB) ListBroadcastLists(tx ReadTx) ([]BroadcastList, error) {
+	lists, err := db.readBroadcastLists()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]BroadcastList, 0, len(lists))
+	for _, bl := range lists {
+		res = append(res, bl)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// maxPaymentSpendAge is the max age of payment spends kept in the db. This
+// must be greater than the longest budget period.
+const maxPaymentSpendAge = 31 * 24 * time.Hour
+
+// PaymentBudgetScope is the scope of the payments limited by a budget.
+type PaymentBudgetScope string
+
+const (
+	PaymentBudgetGlobal PaymentBudgetScope = "global"
+	PaymentBudgetUser   PaymentBudgetScope = "user"
+	PaymentBudgetGC     PaymentBudgetScope = "gc"
+)
+
+// PaymentBudget limits the amount spent in payments. ID is the user or GC
+// ID (for the user and GC scopes). A zero limit means no limit for the
+// respective period.
+type PaymentBudget struct {
+	Scope        PaymentBudgetScope `json:"scope"`
+	ID           zkidentity.ShortID `json:"id"`
+	DailyAtoms   int64              `json:"daily_atoms"`
+	MonthlyAtoms int64              `json:"monthly_atoms"`
+}
+
+// PaymentSpend is an amount spent (or reserved to be spent) in an outbound
+// payment. GC is set for payments made on behalf of a GC (e.g. GC tip
+// splits).
+type PaymentSpend struct {
+	ID         zkidentity.ShortID  `json:"id"`
+	Time       time.Time           `json:"time"`
+	UID        UserID              `json:"uid"`
+	GC         *zkidentity.ShortID `json:"gc,omitempty"`
+	MilliAtoms int64               `json:"milli_atoms"`
+}
+
+// ListPaymentBudgets lists the configured payment budgets.
+func (db *DB) ListPaymentBudgets(tx ReadTx) ([]PaymentBudget, error) {
+	fname := filepath.Join(db.root, paymentBudgetsFile)
+	var res []PaymentBudget
+	err := db.readJsonFile(fname, &res)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// StorePaymentBudget creates or replaces the budget for the scope and ID of
+// the passed budget.
+func (db *DB) StorePaymentBudget(tx ReadWriteTx, budget PaymentBudget) error {
+	budgets, err := db.ListPaymentBudgets(tx)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range budgets {
+		if budgets[i].Scope == budget.Scope && budgets[i].ID == budget.ID {
+			budgets[i] = budget
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		budgets = append(budgets, budget)
+	}
+	fname := filepath.Join(db.root, paymentBudgetsFile)
+	return db.saveJsonFile(fname, budgets)
+}
+
+// RemovePaymentBudget removes the budget with the given scope and ID.
+func (db *DB) RemovePaymentBudget(tx ReadWriteTx, scope PaymentBudgetScope, id zkidentity.ShortID) error {
+	budgets, err := db.ListPaymentBudgets(tx)
+	if err != nil {
+		return err
+	}
+	for i := range budgets {
+		if budgets[i].Scope == scope && budgets[i].ID == id {
+			budgets = append(budgets[:i], budgets[i+1:]...)
+			fname := filepath.Join(db.root, paymentBudgetsFile)
+			return db.saveJsonFile(fname, budgets)
+		}
+	}
+	return ErrNotFound
+}
+
+// ListPaymentSpends lists the payment spends made after the given time.
+func (db *DB) ListPaymentSpends(tx ReadTx, since time.Time) ([]PaymentSpend, error) {
+	fname := filepath.Join(db.root, paymentSpendsFile)
+	var spends []PaymentSpend
+	err := db.readJsonFile(fname, &spends)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	res := spends[:0]
+	for _, s := range spends {
+		if s.Time.After(since) {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+// RecordPaymentSpend records a payment spend. Spends older than the longest
+// budget period are removed.
+func (db *DB) RecordPaymentSpend(tx ReadWriteTx, spend PaymentSpend) error {
+	spends, err := db.ListPaymentSpends(tx, time.Now().Add(-maxPaymentSpendAge))
+	if err != nil {
+		return err
+	}
+	spends = append(spends, spend)
+	fname := filepath.Join(db.root, paymentSpendsFile)
+	return db.saveJsonFile(fname, spends)
+}
+
+// RemovePaymentSpend removes a payment spend (for example, because the
+// payment failed).
+func (db *DB) RemovePaymentSpend(tx ReadWriteTx, id zkidentity.ShortID) error {
+	spends, err := db.ListPaymentSpends(tx, time.Now().Add(-maxPaymentSpendAge))
+	if err != nil {
+		return err
+	}
+	for i := range spends {
+		if spends[i].ID == id {
+			spends = append(spends[:i], spends[i+1:]...)
+			fname := filepath.Join(db.root, paymentSpendsFile)
+			return db.saveJsonFile(fname, spends)
+		}
+	}
+	return ErrNotFound
+}
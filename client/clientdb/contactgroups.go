@@ -0,0 +1,90 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ContactGroup is a locally defined, named group of contacts on which batch
+// operations may be performed.
+type ContactGroup struct {
+	Name        string    `json:"name"`
+	Members     []UserID  `json:"members"`
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// readContactGroups reads the contact groups. The map is keyed by the name of
+// the group.
+func (db *DB) readContactGroups() (map[string]ContactGroup, error) {
+	fname := filepath.Join(db.root, contactGroupsFile)
+	groups := make(map[string]ContactGroup)
+	err := db.readJsonFile(fname, &groups)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetContactGroup returns the contact group with the given name. Returns
+// ErrNotFound if the group does not exist.
+func (db *DB) GetContactGroup(tx ReadTx, name string) (ContactGroup, error) {
+	groups, err := db.readContactGroups()
+	if err != nil {
+		return ContactGroup{}, err
+	}
+	cg, ok := groups[name]
+	if !ok {
+		return ContactGroup{}, ErrNotFound
+	}
+	return cg, nil
+}
+
+// StoreContactGroup creates or replaces the contact group with the name of the
+// passed group.
+func (db *DB) StoreContactGroup(tx ReadWriteTx, cg ContactGroup) error {
+	groups, err := db.readContactGroups()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if old, ok := groups[cg.Name]; ok {
+		cg.Created = old.Created
+	} else {
+		cg.Created = now
+	}
+	cg.LastUpdated = now
+	groups[cg.Name] = cg
+	fname := filepath.Join(db.root, contactGroupsFile)
+	return db.saveJsonFile(fname, groups)
+}
+
+// RemoveContactGroup removes the contact group with the given name.
+func (db *DB) RemoveContactGroup(tx ReadWriteTx, name string) error {
+	groups, err := db.readContactGroups()
+	if err != nil {
+		return err
+	}
+	if _, ok := groups[name]; !ok {
+		return ErrNotFound
+	}
+	delete(groups, name)
+	fname := filepath.Join(db.root, contactGroupsFile)
+	return db.saveJsonFile(fname, groups)
+}
+
+// ListContactGroups returns all contact groups, sorted by name.
+func (db *DB) ListContactGroups(tx ReadTx) ([]ContactGroup, error) {
+	groups, err := db.readContactGroups()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ContactGroup, 0, len(groups))
+	for _, cg := range groups {
+		res = append(res, cg)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ContactMetadata is private metadata the local client keeps about a remote
+// user. This is never sent to remote clients.
+type ContactMetadata struct {
+	// Notes are free-form notes about the user.
+	Notes string `json:"notes,omitempty"`
+
+	// Tags are labels used to group users. Tags are stored normalized
+	// (lower case, sorted and without duplicates).
+	Tags []string `json:"tags,omitempty"`
+
+	// Fields are custom key/value fields about the user.
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// LastUpdated is the last time the metadata was modified.
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// HasTag returns true if the metadata has the given (normalized) tag.
+func (cm *ContactMetadata) HasTag(tag string) bool {
+	i := sort.SearchStrings(cm.Tags, tag)
+	return i < len(cm.Tags) && cm.Tags[i] == tag
+}
+
+// GetContactMetadata returns the private metadata of the given user. If no
+// metadata has been stored yet, an empty value is returned.
+func (db *DB) GetContactMetadata(tx ReadTx, uid UserID) (*ContactMetadata, error) {
+	if !db.AddressBookEntryExists(tx, uid) {
+		return nil, ErrNotFound
+	}
+	filename := filepath.Join(db.root, inboundDir, uid.String(), contactMetaFile)
+	meta := new(ContactMetadata)
+	err := db.readJsonFile(filename, meta)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// UpdateContactMetadata stores the private metadata of the given user.
+func (db *DB) UpdateContactMetadata(tx ReadWriteTx, uid UserID, meta *ContactMetadata) error {
+	if !db.AddressBookEntryExists(tx, uid) {
+		return ErrNotFound
+	}
+	filename := filepath.Join(db.root, inboundDir, uid.String(), contactMetaFile)
+	return db.saveJsonFile(filename, meta)
+}
+
+// ListContactsMetadata returns the private metadata of all users that have
+// any metadata stored.
+func (db *DB) ListContactsMetadata(tx ReadTx) (map[UserID]*ContactMetadata, error) {
+	fi, err := os.ReadDir(filepath.Join(db.root, inboundDir))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[UserID]*ContactMetadata)
+	for _, v := range fi {
+		var uid UserID
+		if err := uid.FromString(v.Name()); err != nil {
+			continue
+		}
+
+		filename := filepath.Join(db.root, inboundDir, v.Name(), contactMetaFile)
+		meta := new(ContactMetadata)
+		err := db.readJsonFile(filename, meta)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			db.log.Warnf("Unable to load contact metadata file %s: %v",
+				filename, err)
+			continue
+		}
+		res[uid] = meta
+	}
+	return res, nil
+}
@@ -1,6 +1,7 @@
 package clientdb
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -157,6 +158,8 @@ func (db *DB) ShareFile(tx ReadWriteTx, fname string, uid *UserID,
 		}
 		copy(f.FileHash[:], fhash)
 		md.Hash = f.FileHash.String()
+		root := rpc.FileManifestMerkleRoot(md.Manifest)
+		md.MerkleRoot = hex.EncodeToString(root[:])
 
 		// Sign the hash.
 		sig, err := sign(fhash)
@@ -201,6 +204,16 @@ func (db *DB) ShareFile(tx ReadWriteTx, fname string, uid *UserID,
 		return f, md, err
 	}
 
+	// Clients that only support version 1 metadata calculate a different
+	// id for the file. Keep an alias for their id, so that they may still
+	// download it.
+	if legacyFID := FileID(md.LegacyMetadataHash()); legacyFID != f.FID {
+		aliasFname := filepath.Join(db.root, legacyFileIDsDir, legacyFID.String())
+		if err := db.saveJsonFile(aliasFname, f.FID); err != nil {
+			return f, md, err
+		}
+	}
+
 	// Now deal with the actual sharing of the file. If it's a global share,
 	// put in the global share dir, otherwise put in the user's share dir.
 	shareDir := sharedContentDir
@@ -349,7 +362,11 @@ func (db *DB) ListSharedFiles(tx ReadTx, uid *UserID) ([]rpc.FileMetadata, error
 
 	// Convert to metadata.
 	res := make([]rpc.FileMetadata, 0, len(shares))
+	now := time.Now()
 	for _, sf := range shares {
+		if sf.Limits != nil && sf.Limits.Expired(now) {
+			continue
+		}
 		var md rpc.FileMetadata
 		chunksDir := filepath.Join(db.root, contentDir, sf.Filename)
 		metaFname := filepath.Join(chunksDir, sf.FileHash.String()+contentHashSuffix)
@@ -406,21 +423,34 @@ func (db *DB) ListAllSharedFiles(tx ReadTx) ([]SharedFileAndShares, error) {
 		// Check if it was globally shared.
 		global := false
 		uids := make([]clientintf.ID, 0, len(shares))
+		var limits []SharedFileLimits
 		for i := range shares {
+			var shareUID *UserID
 			if shares[i] == sharedEveryone {
 				// Globally shared! Remove from list.
 				global = true
-				continue
+			} else {
+				// Shared to a user.
+				var uid clientintf.ID
+				if err := uid.FromString(shares[i]); err != nil {
+					db.log.Warnf("Not a UID (%q) in shares file %s: %v",
+						shares[i], sharesFname, err)
+					continue
+				}
+				uids = append(uids, uid)
+				shareUID = &uid
 			}
 
-			// Shared to a user.
-			var uid clientintf.ID
-			if err := uid.FromString(shares[i]); err != nil {
-				db.log.Warnf("Not a UID (%q) in shares file %s: %v",
-					shares[i], sharesFname, err)
-				continue
+			// Track the limits of the share.
+			var sf SharedFile
+			err := db.readJsonFile(db.shareFilename(fid, shareUID), &sf)
+			if err == nil && sf.Limits != nil {
+				limits = append(limits, SharedFileLimits{
+					UID:       shareUID,
+					Limits:    *sf.Limits,
+					Downloads: len(sf.Downloads),
+				})
 			}
-			uids = append(uids, uid)
 		}
 
 		res = append(res, SharedFileAndShares{
@@ -433,12 +463,68 @@ func (db *DB) ListAllSharedFiles(tx ReadTx) ([]SharedFileAndShares, error) {
 			Size:   fm.Size,
 			Global: global,
 			Shares: uids,
+			Limits: limits,
 		})
 	}
 
 	return res, nil
 }
 
+// UpdateSharedFilePricing updates the cost, preview and price tiers of the
+// given shared file. These are not part of the metadata hash, so the id of the
+// file does not change.
+func (db *DB) UpdateSharedFilePricing(tx ReadWriteTx, fid FileID, cost uint64,
+	preview *rpc.FilePreview, tiers []rpc.FilePriceTier) error {
+
+	files, err := db.ListAllSharedFiles(tx)
+	if err != nil {
+		return err
+	}
+	var sf *SharedFile
+	for i := range files {
+		if files[i].SF.FID == fid {
+			sf = &files[i].SF
+			break
+		}
+	}
+	if sf == nil {
+		return fmt.Errorf("shared file %s: %w", fid, ErrNotFound)
+	}
+
+	md, err := db.fileMetadataForSharedFile(sf)
+	if err != nil {
+		return err
+	}
+
+	if preview != nil && preview.Size > md.Size {
+		return fmt.Errorf("preview size %d is larger than file size %d",
+			preview.Size, md.Size)
+	}
+	if len(tiers) > 0 {
+		cost = 0
+		var lastEnd uint64
+		for i, tier := range tiers {
+			if tier.End <= lastEnd {
+				return fmt.Errorf("end of tier %d is not after "+
+					"the end of the previous tier", i)
+			}
+			lastEnd = tier.End
+			cost += tier.Cost
+		}
+		if lastEnd != md.Size {
+			return fmt.Errorf("last tier ends at %d instead of the "+
+				"file size %d", lastEnd, md.Size)
+		}
+	}
+
+	md.Cost = cost
+	md.Preview = preview
+	md.Tiers = tiers
+	chunksPath := filepath.Join(db.root, contentDir, sf.Filename)
+	metaFname := filepath.Join(chunksPath, sf.FileHash.String()+contentHashSuffix)
+	return db.saveJsonFile(metaFname, md)
+}
+
 // GetSharedFile returns information about the given shared file. If uid is
 // nil, then it's assumed the shared file is on the global dir.
 func (db *DB) GetSharedFile(tx ReadTx, uid *UserID, fid FileID) (SharedFile, rpc.FileMetadata, error) {
@@ -454,6 +540,10 @@ func (db *DB) GetSharedFile(tx ReadTx, uid *UserID, fid FileID) (SharedFile, rpc
 	} else if err != nil {
 		return res, md, fmt.Errorf("unable to read file metadata: %w", err)
 	}
+	if res.Limits != nil && res.Limits.Expired(time.Now()) {
+		return res, md, fmt.Errorf("share of file %s expired: %w",
+			fid.String(), ErrNotFound)
+	}
 
 	md, err := db.fileMetadataForSharedFile(&res)
 	if err != nil {
@@ -474,7 +564,59 @@ func (db *DB) GetSharedFileForUpload(tx ReadTx, uid UserID, fid FileID) (SharedF
 	}
 
 	// Not globally shared. See if shared with user.
-	return db.GetSharedFile(tx, &uid, fid)
+	f, md, err = db.GetSharedFile(tx, &uid, fid)
+	if err == nil || !errors.Is(err, ErrNotFound) {
+		return f, md, err
+	}
+
+	// Not shared by the local client. See if it is a download re-shared
+	// with the user as a member of its swarm.
+	fd, swarmErr := db.readSwarmFileDownload(uid, fid)
+	if swarmErr != nil {
+		// See if fid is the legacy id of a shared file, as calculated
+		// by clients that only support version 1 metadata. The file is
+		// returned with the legacy id, so that replies use the id known
+		// by the remote user.
+		var realFID FileID
+		aliasFname := filepath.Join(db.root, legacyFileIDsDir, fid.String())
+		if db.readJsonFile(aliasFname, &realFID) == nil && realFID != fid {
+			f, md, aliasErr := db.GetSharedFileForUpload(tx, uid, realFID)
+			if aliasErr == nil {
+				f.FID = fid
+			}
+			return f, md, aliasErr
+		}
+		return f, md, err
+	}
+	f = SharedFile{
+		FID:      fd.FID,
+		Filename: fd.Metadata.Filename,
+		Swarm:    true,
+	}
+	if err := f.FileHash.FromString(fd.Metadata.Hash); err != nil {
+		return f, md, fmt.Errorf("invalid hash of download %s: %v", fid, err)
+	}
+	return f, *fd.Metadata, nil
+}
+
+// readSwarmFileDownload returns the download of the given file if it is
+// re-shared by the local client and the given user is a member of its swarm.
+func (db *DB) readSwarmFileDownload(uid UserID, fid FileID) (FileDownload, error) {
+	var fd FileDownload
+	metaPath := filepath.Join(db.root, downloadingDir, fid.String()+contentMetaExt)
+	if err := db.readJsonFile(metaPath, &fd); err != nil {
+		return fd, err
+	}
+	if !fd.Swarm || fd.Metadata == nil || fd.PreviewOnly {
+		return fd, fmt.Errorf("download %s is not re-shared: %w", fid, ErrNotFound)
+	}
+	for _, src := range fd.Sources {
+		if src.UID == uid && src.Swarm {
+			return fd, nil
+		}
+	}
+	return fd, fmt.Errorf("user is not a swarm member of download %s: %w",
+		fid, ErrNotFound)
 }
 
 // readOrNewChunkUpload reads an existing or creates a new chunk upload
@@ -565,8 +707,9 @@ func (db *DB) MarkChunkUploadInvoiceExpired(tx ReadWriteTx, uid UserID, fid File
 		cup.Invoices = cup.Invoices[:l-1]
 	}
 
-	// Remove chunk upload if no more uploads exist for it.
-	if cup.Paid <= 0 && len(cup.Invoices) == 0 {
+	// Remove chunk upload if no more uploads exist for it. Chunks that
+	// were already uploaded are kept to allow resending them.
+	if cup.Paid <= 0 && len(cup.Invoices) == 0 && cup.State != ChunkStateUploaded {
 		return db.removeChunkUpload(&cup)
 	}
 
@@ -583,6 +726,12 @@ func (db *DB) MarkChunkUploadInvoiceSent(tx ReadWriteTx, uid UserID, fid FileID,
 		return err
 	}
 
+	// The invoice may have been paid (and the chunk uploaded) before the
+	// remote user acknowledged receiving it.
+	if cup.State != ChunkStateHasInvoice {
+		return nil
+	}
+
 	cup.State = ChunkStateSentInvoice
 	return db.saveChunkUpload(&cup)
 }
@@ -624,9 +773,12 @@ func (db *DB) MarkChunkUploadSent(tx ReadWriteTx, uid UserID, fid FileID,
 	// Dec count of paid invoices for this chunk.
 	cup.Paid -= 1
 
-	// Remove chunk upload if no more uploads exist for it.
+	// Once all paid copies of the chunk have been sent, keep the upload
+	// marked as completed, so that the chunk may be sent again without a
+	// new payment if the remote user needs to resume the download.
 	if cup.Paid <= 0 && len(cup.Invoices) == 0 {
-		return db.removeChunkUpload(&cup)
+		cup.Paid = 0
+		cup.State = ChunkStateUploaded
 	}
 	return db.saveChunkUpload(&cup)
 }
@@ -643,6 +795,15 @@ func (db *DB) fileMetadataForSharedFile(sf *SharedFile) (rpc.FileMetadata, error
 
 // GetSharedFileChunkData returns the actual chunk data for a given shared file.
 func (db *DB) GetSharedFileChunkData(tx ReadTx, sf *SharedFile, chunkIdx int) ([]byte, error) {
+	if sf.Swarm {
+		var fd FileDownload
+		metaPath := filepath.Join(db.root, downloadingDir, sf.FID.String()+contentMetaExt)
+		if err := db.readJsonFile(metaPath, &fd); err != nil {
+			return nil, err
+		}
+		return db.ReadFileDownloadChunk(tx, &fd, chunkIdx)
+	}
+
 	md, err := db.fileMetadataForSharedFile(sf)
 	if err != nil {
 		return nil, err
@@ -675,6 +836,11 @@ func (db *DB) ListOutstandingUploads(tx ReadTx) ([]ChunkUpload, error) {
 			continue
 		}
 
+		if cup.State == ChunkStateUploaded && cup.Paid <= 0 && len(cup.Invoices) == 0 {
+			// Completed upload.
+			continue
+		}
+
 		res = append(res, cup)
 	}
 
@@ -710,22 +876,131 @@ func (db *DB) StartFileDownload(tx ReadWriteTx, uid UserID, fid FileID, isSentFi
 	return fd, nil
 }
 
+// StartFilePreviewDownload starts a download of only the free preview of the
+// given file.
+func (db *DB) StartFilePreviewDownload(tx ReadWriteTx, uid UserID, fid FileID) (FileDownload, error) {
+	diskDir := filepath.Join(db.root, downloadingDir)
+	metaPath := filepath.Join(diskDir, fid.String()+contentMetaExt)
+	fd := FileDownload{
+		UID:         uid,
+		FID:         fid,
+		PreviewOnly: true,
+	}
+	if err := db.saveJsonFile(metaPath, fd); err != nil {
+		return fd, err
+	}
+
+	return fd, nil
+}
+
 func (db *DB) ReadFileDownload(tx ReadTx, uid UserID, fid FileID) (FileDownload, error) {
 	var fd FileDownload
 
 	diskDir := filepath.Join(db.root, downloadingDir)
 	metaPath := filepath.Join(diskDir, fid.String()+contentMetaExt)
-	if err := db.readJsonFile(metaPath, &fd); err != nil {
+	err := db.readJsonFile(metaPath, &fd)
+	if errors.Is(err, ErrNotFound) {
+		// The file may be downloaded from the user as an additional
+		// source of a download of the same file from another user.
+		return db.readFileDownloadBySource(uid, fid)
+	}
+	if err != nil {
 		return fd, err
 	}
 
-	// TODO: support downloading from multiple users at the same time?
 	if fd.UID != uid {
+		// Swarm members share the file under the same id.
+		for _, src := range fd.Sources {
+			if src.UID == uid && src.Swarm {
+				return fd, nil
+			}
+		}
 		return fd, fmt.Errorf("specified user not the download user")
 	}
 	return fd, nil
 }
 
+// readFileDownloadBySource returns the download that has the given user as an
+// additional source of the given file.
+func (db *DB) readFileDownloadBySource(uid UserID, fid FileID) (FileDownload, error) {
+	diskDir := filepath.Join(db.root, downloadingDir)
+	files, err := filepath.Glob(diskDir + "/*" + contentMetaExt)
+	if err != nil {
+		return FileDownload{}, err
+	}
+
+	for _, fname := range files {
+		var fd FileDownload
+		if err := db.readJsonFile(fname, &fd); err != nil {
+			continue
+		}
+		for _, src := range fd.Sources {
+			if src.UID == uid && src.FID == fid {
+				return fd, nil
+			}
+		}
+	}
+	return FileDownload{}, fmt.Errorf("download of file %s: %w", fid, ErrNotFound)
+}
+
+// FindFileDownloadByHash finds an in-progress download (other than the one
+// with the specified FID) of a file with the given content hash.
+func (db *DB) FindFileDownloadByHash(tx ReadTx, hash string, exceptFID FileID) (FileDownload, error) {
+	fds, err := db.ListOutstandingDownloads(tx)
+	if err != nil {
+		return FileDownload{}, err
+	}
+	for _, fd := range fds {
+		if fd.FID == exceptFID || fd.IsSentFile || fd.PreviewOnly || fd.Metadata == nil {
+			continue
+		}
+		if fd.Metadata.Hash == hash {
+			return fd, nil
+		}
+	}
+	return FileDownload{}, fmt.Errorf("download of file with hash %s: %w",
+		hash, ErrNotFound)
+}
+
+// AddFileDownloadSource adds the given user as an additional source of the
+// download. The metadata of the file as shared by the user must match the
+// content of the file being downloaded.
+func (db *DB) AddFileDownloadSource(tx ReadWriteTx, fd *FileDownload, uid UserID,
+	md rpc.FileMetadata) error {
+
+	if fd.Metadata == nil {
+		return fmt.Errorf("cannot add source to download without metadata")
+	}
+	if _, ok := fd.SourceFID(uid); ok {
+		return fmt.Errorf("user is already a source of download %s", fd.FID)
+	}
+	if md.Hash != fd.Metadata.Hash || md.Size != fd.Metadata.Size {
+		return fmt.Errorf("file of source does not match download %s", fd.FID)
+	}
+	if len(md.Manifest) != len(fd.Metadata.Manifest) {
+		return fmt.Errorf("source uses a different number of chunks "+
+			"(%d) than download %s (%d)", len(md.Manifest), fd.FID,
+			len(fd.Metadata.Manifest))
+	}
+	for i, ch := range md.Manifest {
+		want := fd.Metadata.Manifest[i]
+		if ch.Size != want.Size || !bytes.Equal(ch.Hash, want.Hash) {
+			return fmt.Errorf("chunk %d of source does not match "+
+				"download %s", i, fd.FID)
+		}
+	}
+
+	fd.Sources = append(fd.Sources, FileDownloadSource{
+		UID:  uid,
+		FID:  md.MetadataHash(),
+		Cost: md.Cost,
+	})
+
+	diskDir := filepath.Join(db.root, downloadingDir)
+	metaPath := filepath.Join(diskDir, fd.FID.String()+contentMetaExt)
+	return db.saveJsonFile(metaPath, fd)
+}
+
 // CancelFileDownload removes the in-progress download from the DB.
 func (db *DB) CancelFileDownload(tx ReadWriteTx, fid FileID) error {
 	diskDir := filepath.Join(db.root, downloadingDir)
@@ -752,6 +1027,9 @@ func (db *DB) UpdateFileDownloadMetadata(tx ReadWriteTx, fd *FileDownload,
 	if fd.Metadata != nil {
 		return fmt.Errorf("cannot update file metadata: metadata already filled")
 	}
+	if err := md.VerifyManifest(); err != nil {
+		return fmt.Errorf("invalid metadata of file %s: %w", fd.FID, err)
+	}
 	fd.Metadata = &md
 
 	diskDir := filepath.Join(db.root, downloadingDir)
@@ -811,6 +1089,27 @@ func (db *DB) ReplaceFileDownloadChunkState(tx ReadWriteTx, fd *FileDownload,
 	return db.saveJsonFile(metaPath, fd)
 }
 
+// MarkFileDownloadChunkRequested marks the given chunk as requested from the
+// given user.
+func (db *DB) MarkFileDownloadChunkRequested(tx ReadWriteTx, fd *FileDownload,
+	chunkIdx int, uid UserID) error {
+
+	if fd.ChunkSource(chunkIdx) != uid {
+		// Any invoice for the chunk was sent by the previous source.
+		delete(fd.Invoices, chunkIdx)
+	}
+	if uid == fd.UID {
+		delete(fd.ChunkSources, chunkIdx)
+	} else {
+		if fd.ChunkSources == nil {
+			fd.ChunkSources = make(map[int]UserID)
+		}
+		fd.ChunkSources[chunkIdx] = uid
+	}
+	return db.ReplaceFileDownloadChunkState(tx, fd, chunkIdx,
+		ChunkStateRequestedChunk)
+}
+
 func (db *DB) SaveFileDownloadChunk(tx ReadWriteTx, user string, fd *FileDownload,
 	chunkIdx int, data []byte) (string, error) {
 
@@ -823,6 +1122,9 @@ func (db *DB) SaveFileDownloadChunk(tx ReadWriteTx, user string, fd *FileDownloa
 	if fd.Metadata == nil {
 		return "", fmt.Errorf("file metadata is nil")
 	}
+	if fd.CompletedName != "" {
+		return "", fmt.Errorf("download of file %s already completed", fd.FID)
+	}
 
 	// Verify chunk index is correct.
 	if !clientintf.ChunkIndexMatches(fd.Metadata, chunkIdx, hash[:]) {
@@ -853,11 +1155,17 @@ func (db *DB) SaveFileDownloadChunk(tx ReadWriteTx, user string, fd *FileDownloa
 	// Assemble final file. First: figure out final name.
 	baseDestFileName := filepath.Join(db.downloadsDir, escapeNickForFname(user),
 		strescape.PathElement(fd.Metadata.Filename))
-	destFileName := baseDestFileName
 	ext := filepath.Ext(baseDestFileName)
 	if len(ext) > 0 {
 		baseDestFileName = baseDestFileName[:len(baseDestFileName)-len(ext)]
 	}
+	manifest := fd.Metadata.Manifest
+	if fd.PreviewOnly {
+		// Only the preview chunks were downloaded.
+		baseDestFileName += ".preview"
+		manifest = manifest[:len(clientintf.FilePreviewChunks(fd.Metadata))]
+	}
+	destFileName := baseDestFileName + ext
 	for i := 1; fileExists(destFileName); i++ {
 		destFileName = fmt.Sprintf("%s_%.2d%s", baseDestFileName, i, ext)
 	}
@@ -873,7 +1181,7 @@ func (db *DB) SaveFileDownloadChunk(tx ReadWriteTx, user string, fd *FileDownloa
 
 	// Next: Copy over chunks, while accumulating final hash.
 	hasher = sha256.New()
-	for _, ch := range fd.Metadata.Manifest {
+	for _, ch := range manifest {
 		chunkFname := filepath.Join(chunkDir, hex.EncodeToString(ch.Hash))
 		data, err := os.ReadFile(chunkFname)
 		if err != nil {
@@ -885,10 +1193,11 @@ func (db *DB) SaveFileDownloadChunk(tx ReadWriteTx, user string, fd *FileDownloa
 		}
 	}
 
-	// Ensure final file hash is correct.
+	// Ensure final file hash is correct. Previews are not the full file, so
+	// only the hashes of their chunks are verified.
 	hash = hasher.Sum(nil)
 	hashStr = hex.EncodeToString(hash)
-	if hashStr != fd.Metadata.Hash {
+	if !fd.PreviewOnly && hashStr != fd.Metadata.Hash {
 		return "", fmt.Errorf("unexpected final file hash (got %s, want %s)",
 			hashStr, fd.Metadata.Hash)
 	}
@@ -922,8 +1231,12 @@ func (db *DB) MissingFileDownloadChunks(tx ReadTx, fd *FileDownload) []int {
 	}
 
 	// Verify which chunk files already exist.
-	res := make([]int, 0, len(fd.Metadata.Manifest)-len(filesMap))
-	for i, ch := range fd.Metadata.Manifest {
+	manifest := fd.Metadata.Manifest
+	if fd.PreviewOnly {
+		manifest = manifest[:len(clientintf.FilePreviewChunks(fd.Metadata))]
+	}
+	res := make([]int, 0, len(manifest))
+	for i, ch := range manifest {
 		if _, ok := filesMap[hex.EncodeToString(ch.Hash)]; !ok {
 			res = append(res, i)
 		}
@@ -931,6 +1244,52 @@ func (db *DB) MissingFileDownloadChunks(tx ReadTx, fd *FileDownload) []int {
 	return res
 }
 
+// ReadFileDownloadChunk returns the data of the given chunk of a download. The
+// data is read from the downloaded chunk or, if the download is completed, from
+// the final file. Returns ErrNotFound if the chunk has not been downloaded yet.
+func (db *DB) ReadFileDownloadChunk(tx ReadTx, fd *FileDownload, chunkIdx int) ([]byte, error) {
+	if fd.Metadata == nil {
+		return nil, fmt.Errorf("file metadata is nil")
+	}
+	if chunkIdx < 0 || chunkIdx >= len(fd.Metadata.Manifest) {
+		return nil, fmt.Errorf("chunk index %d out of bounds", chunkIdx)
+	}
+	ch := fd.Metadata.Manifest[chunkIdx]
+
+	if fd.CompletedName == "" {
+		chunkFname := filepath.Join(db.root, downloadingDir,
+			fd.FID.String()+chunkDirSuffix, hex.EncodeToString(ch.Hash))
+		data, err := os.ReadFile(chunkFname)
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return data, err
+	}
+
+	fname, err := db.HasDownloadedFile(tx, fd.FID)
+	if err != nil {
+		return nil, err
+	}
+	if fname == "" {
+		return nil, fmt.Errorf("downloaded file %s: %w", fd.FID, ErrNotFound)
+	}
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var offset int64
+	for i := 0; i < chunkIdx; i++ {
+		offset += int64(fd.Metadata.Manifest[i].Size)
+	}
+	data := make([]byte, ch.Size)
+	if _, err := f.ReadAt(data, offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // HasDownloadedFile returns the path to the completed downloaded file (if it
 // exists).
 func (db *DB) HasDownloadedFile(tx ReadTx, fid zkidentity.ShortID) (string, error) {
@@ -0,0 +1,228 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// UserContentIndex is the index of the content shared by a remote user, built
+// from the file listings fetched from the user.
+type UserContentIndex struct {
+	// Global are the files the user shares with everyone.
+	Global []rpc.FileMetadata `json:"global,omitempty"`
+
+	// Shared are the files the user shares only with the local client.
+	Shared []rpc.FileMetadata `json:"shared,omitempty"`
+
+	// GlobalUpdated and SharedUpdated are the last time the listing of
+	// the respective dir was fetched.
+	GlobalUpdated time.Time `json:"global_updated"`
+	SharedUpdated time.Time `json:"shared_updated"`
+}
+
+// LastUpdated returns the oldest update time of the dirs of the index.
+func (idx *UserContentIndex) LastUpdated() time.Time {
+	if idx.SharedUpdated.Before(idx.GlobalUpdated) {
+		return idx.SharedUpdated
+	}
+	return idx.GlobalUpdated
+}
+
+// ContentQuery is a query for files in the content index. Empty fields do not
+// restrict the results.
+type ContentQuery struct {
+	// Name is matched (case insensitively) against the filename and
+	// description of files.
+	Name string
+
+	// Types is a list of file extensions (with or without the leading
+	// dot). Files must have one of the extensions.
+	Types []string
+
+	// MinSize and MaxSize are the limits for the size (in bytes) of files.
+	MinSize uint64
+	MaxSize uint64
+
+	// MaxCost is the max cost (in atoms) of files.
+	MaxCost *uint64
+
+	// Sharers are the users that share the files.
+	Sharers []UserID
+}
+
+// Matches returns true if the file shared by the given user matches the
+// query.
+func (q *ContentQuery) Matches(uid UserID, fm *rpc.FileMetadata) bool {
+	if len(q.Sharers) > 0 {
+		found := false
+		for i := range q.Sharers {
+			found = found || q.Sharers[i] == uid
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Name != "" {
+		name := strings.ToLower(q.Name)
+		if !strings.Contains(strings.ToLower(fm.Filename), name) &&
+			!strings.Contains(strings.ToLower(fm.Description), name) {
+			return false
+		}
+	}
+	if len(q.Types) > 0 {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(fm.Filename)), ".")
+		found := false
+		for _, typ := range q.Types {
+			found = found || strings.TrimPrefix(strings.ToLower(typ), ".") == ext
+		}
+		if !found {
+			return false
+		}
+	}
+	if fm.Size < q.MinSize || (q.MaxSize > 0 && fm.Size > q.MaxSize) {
+		return false
+	}
+	if q.MaxCost != nil && fm.Cost > *q.MaxCost {
+		return false
+	}
+	return true
+}
+
+// GetUserContentIndex returns the content index of the given user. If no
+// listing has been fetched from the user yet, an empty index is returned.
+func (db *DB) GetUserContentIndex(tx ReadTx, uid UserID) (*UserContentIndex, error) {
+	if !db.AddressBookEntryExists(tx, uid) {
+		return nil, ErrNotFound
+	}
+	filename := filepath.Join(db.root, inboundDir, uid.String(), contentIndexFile)
+	idx := new(UserContentIndex)
+	err := db.readJsonFile(filename, idx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// UpdateUserContentIndex updates the content index of the given user with a
+// listing fetched from the user. The files of the listed dirs replace the
+// ones previously in the index. When dirs is empty (i.e. the listed dirs are
+// not known), the files are merged into the index instead.
+func (db *DB) UpdateUserContentIndex(tx ReadWriteTx, uid UserID, dirs []string,
+	global, shared []rpc.FileMetadata) error {
+
+	idx, err := db.GetUserContentIndex(tx, uid)
+	if err != nil {
+		return err
+	}
+
+	// Helper to merge files into the list, replacing files with the same
+	// id.
+	merge := func(old, files []rpc.FileMetadata) []rpc.FileMetadata {
+		res := append([]rpc.FileMetadata(nil), files...)
+		seen := make(map[FileID]struct{}, len(files))
+		for i := range files {
+			seen[files[i].MetadataHash()] = struct{}{}
+		}
+		for i := range old {
+			if _, ok := seen[old[i].MetadataHash()]; !ok {
+				res = append(res, old[i])
+			}
+		}
+		return res
+	}
+
+	now := time.Now()
+	if len(dirs) == 0 {
+		idx.Global = merge(idx.Global, global)
+		idx.Shared = merge(idx.Shared, shared)
+	}
+	for _, dir := range dirs {
+		switch dir {
+		case rpc.RMFTDGlobal:
+			idx.Global = global
+			idx.GlobalUpdated = now
+		case rpc.RMFTDShared:
+			idx.Shared = shared
+			idx.SharedUpdated = now
+		}
+	}
+
+	filename := filepath.Join(db.root, inboundDir, uid.String(), contentIndexFile)
+	return db.saveJsonFile(filename, idx)
+}
+
+// ListContentIndexes returns the content indexes of all users.
+func (db *DB) ListContentIndexes(tx ReadTx) (map[UserID]*UserContentIndex, error) {
+	fi, err := os.ReadDir(filepath.Join(db.root, inboundDir))
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[UserID]*UserContentIndex)
+	for _, v := range fi {
+		var uid UserID
+		if err := uid.FromString(v.Name()); err != nil {
+			continue
+		}
+
+		filename := filepath.Join(db.root, inboundDir, v.Name(), contentIndexFile)
+		idx := new(UserContentIndex)
+		err := db.readJsonFile(filename, idx)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			db.log.Warnf("Unable to load content index file %s: %v",
+				filename, err)
+			continue
+		}
+		res[uid] = idx
+	}
+	return res, nil
+}
+
+// SearchContentIndex returns the files of the content index that match the
+// query, sorted by filename.
+func (db *DB) SearchContentIndex(tx ReadTx, q *ContentQuery) ([]RemoteFile, error) {
+	indexes, err := db.ListContentIndexes(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []RemoteFile
+	for uid, idx := range indexes {
+		seen := make(map[FileID]struct{})
+		for _, files := range [][]rpc.FileMetadata{idx.Global, idx.Shared} {
+			for i := range files {
+				fid := files[i].MetadataHash()
+				if _, ok := seen[fid]; ok {
+					continue
+				}
+				seen[fid] = struct{}{}
+				if !q.Matches(uid, &files[i]) {
+					continue
+				}
+				res = append(res, RemoteFile{
+					FID:      fid,
+					UID:      uid,
+					Metadata: files[i],
+				})
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		fi, fj := res[i].Metadata.Filename, res[j].Metadata.Filename
+		if fi != fj {
+			return fi < fj
+		}
+		return res[i].UID.String() < res[j].UID.String()
+	})
+	return res, nil
+}
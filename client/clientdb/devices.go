@@ -0,0 +1,134 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// DeviceRole is the role of a paired device.
+type DeviceRole string
+
+const (
+	// DeviceRolePrimary is the role of the device that sends its state to
+	// the paired secondary devices.
+	DeviceRolePrimary DeviceRole = "primary"
+
+	// DeviceRoleSecondary is the role of a device that receives the state
+	// of its paired primary device.
+	DeviceRoleSecondary DeviceRole = "secondary"
+)
+
+// PairedDevice is another client of the local user (running with the same
+// identity) that was paired with the local client.
+type PairedDevice struct {
+	// ID is the random ID assigned to the device during pairing.
+	ID       zkidentity.ShortID `json:"id"`
+	Role     DeviceRole         `json:"role"`
+	Paired   time.Time          `json:"paired"`
+	LastSync time.Time          `json:"last_sync"`
+
+	// SendChain and RecvChain are the current keys of the hash chains
+	// used to derive the RVs and encryption keys of the next messages
+	// sent to and received from the device. SendCount and RecvCount are
+	// the number of messages sent and received through the chains.
+	SendChain zkidentity.FixedSizeDigest `json:"send_chain"`
+	RecvChain zkidentity.FixedSizeDigest `json:"recv_chain"`
+	SendCount uint64                     `json:"send_count"`
+	RecvCount uint64                     `json:"recv_count"`
+}
+
+// DeviceSync is the last state received from the primary device.
+type DeviceSync struct {
+	From     zkidentity.ShortID `json:"from"`
+	Received time.Time          `json:"received"`
+	Sync     rpc.RMDeviceSync   `json:"sync"`
+}
+
+// readPairedDevices reads the paired devices. The map is keyed by the string
+// ID of the device.
+func (db *DB) readPairedDevices() (map[string]PairedDevice, error) {
+	fname := filepath.Join(db.root, pairedDevicesFile)
+	devices := make(map[string]PairedDevice)
+	err := db.readJsonFile(fname, &devices)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// GetPairedDevice returns the paired device with the given ID. Returns
+// ErrNotFound if the device is not paired.
+func (db *DB) GetPairedDevice(tx ReadTx, id zkidentity.ShortID) (PairedDevice, error) {
+	devices, err := db.readPairedDevices()
+	if err != nil {
+		return PairedDevice{}, err
+	}
+	dev, ok := devices[id.String()]
+	if !ok {
+		return PairedDevice{}, ErrNotFound
+	}
+	return dev, nil
+}
+
+// StorePairedDevice creates or replaces a paired device.
+func (db *DB) StorePairedDevice(tx ReadWriteTx, dev PairedDevice) error {
+	devices, err := db.readPairedDevices()
+	if err != nil {
+		return err
+	}
+	devices[dev.ID.String()] = dev
+	fname := filepath.Join(db.root, pairedDevicesFile)
+	return db.saveJsonFile(fname, devices)
+}
+
+// DelPairedDevice removes a paired device. Returns ErrNotFound if the device
+// is not paired.
+func (db *DB) DelPairedDevice(tx ReadWriteTx, id zkidentity.ShortID) error {
+	devices, err := db.readPairedDevices()
+	if err != nil {
+		return err
+	}
+	if _, ok := devices[id.String()]; !ok {
+		return ErrNotFound
+	}
+	delete(devices, id.String())
+	fname := filepath.Join(db.root, pairedDevicesFile)
+	return db.saveJsonFile(fname, devices)
+}
+
+// ListPairedDevices lists the paired devices, sorted by pairing time.
+func (db *DB) ListPairedDevices(tx ReadTx) ([]PairedDevice, error) {
+	devices, err := db.readPairedDevices()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PairedDevice, 0, len(devices))
+	for _, dev := range devices {
+		res = append(res, dev)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Paired.Before(res[j].Paired)
+	})
+	return res, nil
+}
+
+// StoreDeviceSync stores the last state received from the primary device,
+// replacing any previously stored state.
+func (db *DB) StoreDeviceSync(tx ReadWriteTx, ds DeviceSync) error {
+	fname := filepath.Join(db.root, deviceSyncFile)
+	return db.saveJsonFile(fname, ds)
+}
+
+// ReadDeviceSync returns the last state received from the primary device.
+// Returns ErrNotFound if no state was received.
+func (db *DB) ReadDeviceSync(tx ReadTx) (DeviceSync, error) {
+	fname := filepath.Join(db.root, deviceSyncFile)
+	var ds DeviceSync
+	err := db.readJsonFile(fname, &ds)
+	return ds, err
+}
@@ -0,0 +1,78 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// Draft is an unsent message of a PM conversation or GC.
+type Draft struct {
+	Text        string    `json:"text"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// readDrafts reads the drafts of all chats. The map is keyed by the string ID
+// of the chat.
+func (db *DB) readDrafts() (map[string]Draft, error) {
+	fname := filepath.Join(db.root, draftsFile)
+	drafts := make(map[string]Draft)
+	err := db.readJsonFile(fname, &drafts)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// GetDraft returns the draft of the chat with the given ID. Returns
+// ErrNotFound if the chat has no draft.
+func (db *DB) GetDraft(tx ReadTx, id zkidentity.ShortID) (Draft, error) {
+	drafts, err := db.readDrafts()
+	if err != nil {
+		return Draft{}, err
+	}
+	draft, ok := drafts[id.String()]
+	if !ok {
+		return Draft{}, ErrNotFound
+	}
+	return draft, nil
+}
+
+// StoreDraft stores the draft of the chat with the given ID. An empty text
+// removes the draft.
+func (db *DB) StoreDraft(tx ReadWriteTx, id zkidentity.ShortID, text string) error {
+	drafts, err := db.readDrafts()
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		if _, ok := drafts[id.String()]; !ok {
+			return nil
+		}
+		delete(drafts, id.String())
+	} else {
+		drafts[id.String()] = Draft{Text: text, LastUpdated: time.Now()}
+	}
+	fname := filepath.Join(db.root, draftsFile)
+	return db.saveJsonFile(fname, drafts)
+}
+
+// ListDrafts returns the drafts of all chats.
+func (db *DB) ListDrafts(tx ReadTx) (map[zkidentity.ShortID]Draft, error) {
+	drafts, err := db.readDrafts()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[zkidentity.ShortID]Draft, len(drafts))
+	for sid, draft := range drafts {
+		var id zkidentity.ShortID
+		if err := id.FromString(sid); err != nil {
+			db.log.Warnf("Not a valid chat ID in drafts: %s", sid)
+			continue
+		}
+		res[id] = draft
+	}
+	return res, nil
+}
@@ -0,0 +1,144 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// EscrowStatus is the status of an escrowed payment.
+type EscrowStatus string
+
+const (
+	// EscrowStatusRequested is the status of escrows requested by the
+	// buyer, for which the seller has not yet sent an invoice.
+	EscrowStatusRequested EscrowStatus = "requested"
+
+	// EscrowStatusInvoiced is the status of escrows whose hold invoice was
+	// generated by the seller but not yet paid.
+	EscrowStatusInvoiced EscrowStatus = "invoiced"
+
+	// EscrowStatusHeld is the status of escrows whose payment is held by
+	// the node of the seller.
+	EscrowStatusHeld EscrowStatus = "held"
+
+	// EscrowStatusFulfilled is the status of held escrows after the seller
+	// fulfilled the order paid by the escrow.
+	EscrowStatusFulfilled EscrowStatus = "fulfilled"
+
+	// EscrowStatusSettled is the status of escrows whose payment was
+	// released by the buyer and settled by the seller.
+	EscrowStatusSettled EscrowStatus = "settled"
+
+	// EscrowStatusCanceled is the status of escrows canceled before their
+	// payment was settled.
+	EscrowStatusCanceled EscrowStatus = "canceled"
+)
+
+// Escrow is a payment to or from a user that is held by the node of the
+// seller until the buyer releases it or the seller cancels it.
+type Escrow struct {
+	ID  zkidentity.ShortID `json:"id"`
+	UID UserID             `json:"uid"`
+
+	// Buyer is true for escrows where the local client is the buyer
+	// (payer).
+	Buyer       bool   `json:"buyer"`
+	MilliAtoms  int64  `json:"milli_atoms"`
+	Description string `json:"description"`
+
+	// PaymentHash is the hash of Preimage. The preimage is only known by
+	// the seller after the buyer releases the payment.
+	PaymentHash []byte `json:"payment_hash"`
+	Preimage    []byte `json:"preimage,omitempty"`
+	Invoice     string `json:"invoice,omitempty"`
+
+	Status  EscrowStatus `json:"status"`
+	Created time.Time    `json:"created"`
+	Updated time.Time    `json:"updated"`
+
+	// Expires is the time after which a payment that was not settled is
+	// canceled.
+	Expires time.Time `json:"expires"`
+
+	// Note is the note sent by the seller when fulfilling the escrow.
+	Note string `json:"note,omitempty"`
+
+	// Released is the time the buyer released the payment.
+	Released *time.Time `json:"released,omitempty"`
+
+	// Error is the reason for canceling the escrow.
+	Error string `json:"error,omitempty"`
+
+	// BudgetOverride is set when the payment was allowed to exceed a
+	// payment budget.
+	BudgetOverride bool `json:"budget_override,omitempty"`
+
+	// SpendID is the ID of the spend reserved in the payment budgets for
+	// the payment of the buyer.
+	SpendID *zkidentity.ShortID `json:"spend_id,omitempty"`
+}
+
+// Final returns true if the escrow was settled or canceled.
+func (e *Escrow) Final() bool {
+	return e.Status == EscrowStatusSettled || e.Status == EscrowStatusCanceled
+}
+
+// StoreEscrow creates or replaces the escrow with the user and ID of the
+// passed escrow.
+func (db *DB) StoreEscrow(tx ReadWriteTx, e Escrow) error {
+	fname := filepath.Join(db.root, escrowsDir, e.UID.String(), e.ID.String())
+	return db.saveJsonFile(fname, &e)
+}
+
+// GetEscrow returns the escrow with the given ID, exchanged with the given
+// user. Returns ErrNotFound if the escrow does not exist.
+func (db *DB) GetEscrow(tx ReadTx, uid UserID, id zkidentity.ShortID) (Escrow, error) {
+	fname := filepath.Join(db.root, escrowsDir, uid.String(), id.String())
+	var e Escrow
+	err := db.readJsonFile(fname, &e)
+	return e, err
+}
+
+// ListEscrows returns all escrows, sorted by creation time.
+func (db *DB) ListEscrows(tx ReadTx) ([]Escrow, error) {
+	dir := filepath.Join(db.root, escrowsDir)
+	userDirs, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Escrow
+	for _, userDir := range userDirs {
+		if !userDir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, userDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			var e Escrow
+			fname := filepath.Join(dir, userDir.Name(), entry.Name())
+			if err := db.readJsonFile(fname, &e); err != nil {
+				if !errors.Is(err, ErrNotFound) {
+					db.log.Warnf("Unable to read escrow %s: %v",
+						entry.Name(), err)
+				}
+				continue
+			}
+			res = append(res, e)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// ExternalTipReceipt is the receipt of a tip paid to a recipient outside of
+// the contact graph (for example, to a lightning address).
+type ExternalTipReceipt struct {
+	Target         string    `json:"target"`
+	Description    string    `json:"description"`
+	MAtoms         int64     `json:"matoms"`
+	Fees           int64     `json:"fees"`
+	Invoice        string    `json:"invoice"`
+	PaymentHash    []byte    `json:"payment_hash"`
+	Comment        string    `json:"comment,omitempty"`
+	SuccessMessage string    `json:"success_message,omitempty"`
+	SuccessURL     string    `json:"success_url,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// ListExternalTipReceipts lists the receipts of external tips, in the order
+// they were stored.
+func (db *DB) ListExternalTipReceipts(tx ReadTx) ([]ExternalTipReceipt, error) {
+	fname := filepath.Join(db.root, externalTipsFile)
+	var receipts []ExternalTipReceipt
+	err := db.readJsonFile(fname, &receipts)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// StoreExternalTipReceipt stores the receipt of an external tip.
+func (db *DB) StoreExternalTipReceipt(tx ReadWriteTx, r ExternalTipReceipt) error {
+	receipts, err := db.ListExternalTipReceipts(tx)
+	if err != nil {
+		return err
+	}
+	receipts = append(receipts, r)
+	fname := filepath.Join(db.root, externalTipsFile)
+	return db.saveJsonFile(fname, receipts)
+}
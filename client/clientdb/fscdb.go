@@ -27,45 +27,97 @@ import (
 )
 
 const (
-	lockFileName        = "db.lock"
-	zkcServerDir        = "myserver"
-	zkcServerFile       = "myserver.ini"
-	ratchetFilename     = "ratchet.json"
-	inboundDir          = "inbound"
-	identityFilename    = "publicidentity.json"
-	groupchatDir        = "groupchat"
-	invitesDir          = "invites"
-	contentDir          = "content"
-	postsDir            = "posts"
-	postsSubscribers    = "subscribers"
-	postsSubscriptions  = "subscriptns"
-	postsStatusExt      = ".status"
-	kxDir               = "kx"
-	transResetFile      = "transreset.json"
-	sendqDir            = "sendqueue"
-	blockedUsersFile    = "blockedusers.json"
-	paidRVsDir          = "paidrvs"
-	paidPushesDir       = "paidpushes"
-	kxSearches          = "kxsearches"
-	miRequestsDir       = "mirequests"
-	postKXActionsDir    = "postkxactions"
-	initKXActionsDir    = "initkxactions"
-	payStatsFile        = "paystats.json"
-	unackedRMsDir       = "unackedrms"
-	lastConnDateFile    = "lastconndate.json"
-	tipsDir             = "tips"
-	onboardStateFile    = "onboard.json"
-	reqResourcesDir     = "reqresources"
-	recvAddrForUserFile = "onchainrecvaddr.json"
-	cachedGCMsDir       = "cachedgcms"
-	unkxdUsersDir       = "unkxd"
-	filtersDir          = "contentfilters"
+	lockFileName           = "db.lock"
+	zkcServerDir           = "myserver"
+	zkcServerFile          = "myserver.ini"
+	ratchetFilename        = "ratchet.json"
+	inboundDir             = "inbound"
+	identityFilename       = "publicidentity.json"
+	groupchatDir           = "groupchat"
+	invitesDir             = "invites"
+	contentDir             = "content"
+	postsDir               = "posts"
+	postsSubscribers       = "subscribers"
+	postsSubscriptions     = "subscriptns"
+	postsStatusExt         = ".status"
+	kxDir                  = "kx"
+	transResetFile         = "transreset.json"
+	sendqDir               = "sendqueue"
+	blockedUsersFile       = "blockedusers.json"
+	paidRVsDir             = "paidrvs"
+	paidPushesDir          = "paidpushes"
+	kxSearches             = "kxsearches"
+	miRequestsDir          = "mirequests"
+	postKXActionsDir       = "postkxactions"
+	initKXActionsDir       = "initkxactions"
+	payStatsFile           = "paystats.json"
+	unackedRMsDir          = "unackedrms"
+	lastConnDateFile       = "lastconndate.json"
+	tipsDir                = "tips"
+	onboardStateFile       = "onboard.json"
+	reqResourcesDir        = "reqresources"
+	recvAddrForUserFile    = "onchainrecvaddr.json"
+	lnNodeForUserFile      = "lnnode.json"
+	cachedGCMsDir          = "cachedgcms"
+	unkxdUsersDir          = "unkxd"
+	filtersDir             = "contentfilters"
+	msgEditsDir            = "msgedits"
+	msgReactionsDir        = "msgreactions"
+	threadsDir             = "threads"
+	threadsIndexDir        = "threadsidx"
+	msgSearchDir           = "msgsearch"
+	archivedChatsFile      = "archivedchats.json"
+	contactMetaFile        = "contactmeta.json"
+	autoReplyFile          = "autoreply.json"
+	draftsFile             = "drafts.json"
+	broadcastListsFile     = "broadcastlists.json"
+	gcModLogDir            = "gcmodlog"
+	gcInviteLinksFile      = "gcinvitelinks.json"
+	gcJoinRequestsFile     = "gcjoinrequests.json"
+	gcRetentionFile        = "gcretention.json"
+	pairedDevicesFile      = "paireddevices.json"
+	deviceSyncFile         = "devicesync.json"
+	autoKXPolicyFile       = "autokxpolicy.json"
+	verificationFile       = "verification.json"
+	gcMentionAutoKXFile    = "gcmentionautokx.json"
+	idMigrationsFile       = "idmigrations.json"
+	gcHistoryShareFile     = "gchistoryshare.json"
+	gcHistoryDLsFile       = "gchistorydownloads.json"
+	ratchetResetPolicyFile = "ratchetresetpolicy.json"
+	gcBridgesFile          = "gcbridges.json"
+	contactGroupsFile      = "contactgroups.json"
+	contentIndexFile       = "contentindex.json"
+	legacyFileIDsDir       = "legacyfileids"
+	fileSwarmsDir          = "fileswarms"
+	postDraftsDir          = "postdrafts"
+	postTagFiltersFile     = "posttagfilters.json"
+	postUnlocksDir         = "postunlocks"
+	postStatsFile          = "poststats.json"
+	postSubEventsFile      = "postsubevents.json"
+	postCommentMutesFile   = "postcommentmutes.json"
+	pinnedPostsFile        = "pinnedposts.json"
+	importedPostsFile      = "importedposts.json"
+	postBookmarksFile      = "postbookmarks.json"
+	recvKeysendTipsFile    = "recvkeysendtips.json"
+	externalTipsFile       = "externaltips.json"
+	recurringTipsDir       = "recurringtips"
+	onchainTipsDir         = "onchaintips"
+	paymentReceiptsDir     = "paymentreceipts"
+	escrowsDir             = "escrows"
+	paymentBudgetsFile     = "paymentbudgets.json"
+	paymentSpendsFile      = "paymentspends.json"
+	tipRetryPolicyFile     = "tipretrypolicy.json"
+	gcTipLeaderboardFile   = "gctipleaderboard.json"
+	gcTipsDir              = "gctips"
+	webhookDeliveriesDir   = "webhookdeliveries"
 
 	pageSessionsDir         = "pagesessions"
 	pageSessionOverviewFile = "overview.json"
 	genTipInvoicesFile      = "generated-tip-invoices.json"
 	recvTipInvoicesFile     = "received-tip-invoices.json"
 	expiredTipInvoicesFile  = "expired-tip-invoices.json"
+	canceledTipInvoicesFile = "canceled-tip-invoices.json"
+	invoiceLabelsFile       = "invoicelabels.json"
 
 	postRecvReceiptSuff       = "-recvreceipts.json"
 	postCommentRecvReceiptDir = "-comments-recvreceipts"
@@ -617,15 +669,37 @@ func (db *DB) LogPM(tx ReadWriteTx, uid UserID, internal bool, from, msg string,
 
 	nick := entry.ID.Nick
 	logFname := fmt.Sprintf("%s.%s.log", escapeNickForFname(nick), uid)
-	return db.logMsg(logFname, internal, from, msg, ts)
+	if err := db.logMsg(logFname, internal, from, msg, ts); err != nil {
+		return err
+	}
+	if internal || db.cfg.MsgsRoot == "" {
+		return nil
+	}
+	return db.indexMsg(&uid, nil, from, msg, ts)
+}
+
+// gcLogFname returns the name of the log file of messages of the GC. If
+// channel is specified, this is the log of messages of that GC channel.
+func gcLogFname(gcName string, gcID zkidentity.ShortID, channel *zkidentity.ShortID) string {
+	if channel != nil {
+		return fmt.Sprintf("groupchat.%s.%s.%s.log", escapeNickForFname(gcName),
+			gcID, channel)
+	}
+	return fmt.Sprintf("groupchat.%s.%s.log", escapeNickForFname(gcName), gcID)
 }
 
 // LogGCMsg logs a GC message sent in the given GC.
 func (db *DB) LogGCMsg(tx ReadWriteTx, gcName string, gcID zkidentity.ShortID,
 	internal bool, from, msg string, ts time.Time) error {
 
-	logFname := fmt.Sprintf("groupchat.%s.%s.log", escapeNickForFname(gcName), gcID)
-	return db.logMsg(logFname, internal, from, msg, ts)
+	logFname := gcLogFname(gcName, gcID, nil)
+	if err := db.logMsg(logFname, internal, from, msg, ts); err != nil {
+		return err
+	}
+	if internal || db.cfg.MsgsRoot == "" {
+		return nil
+	}
+	return db.indexMsg(nil, &gcID, from, msg, ts)
 }
 
 // ReadLogPM reads the log of PM messages from the given user.
@@ -640,10 +714,33 @@ func (db *DB) ReadLogPM(tx ReadTx, uid UserID, page, pageNum int) ([]PMLogEntry,
 	return db.readLogMsg(logFname, page, pageNum)
 }
 
+// LogGCChannelMsg logs a GC message sent in the given GC channel.
+func (db *DB) LogGCChannelMsg(tx ReadWriteTx, gcName string, gcID, channel zkidentity.ShortID,
+	from, msg string, ts time.Time) error {
+
+	logFname := gcLogFname(gcName, gcID, &channel)
+	if err := db.logMsg(logFname, false, from, msg, ts); err != nil {
+		return err
+	}
+	if db.cfg.MsgsRoot == "" {
+		return nil
+	}
+	return db.indexMsg(nil, &gcID, from, msg, ts)
+}
+
 // ReadLogGCMsg reads the log a GC messages sent in the given GC.
 func (db *DB) ReadLogGCMsg(tx ReadTx, gcName string, gcID zkidentity.ShortID, page, pageNum int) ([]PMLogEntry, error) {
 
-	logFname := fmt.Sprintf("groupchat.%s.%s.log", escapeNickForFname(gcName), gcID)
+	logFname := gcLogFname(gcName, gcID, nil)
+	return db.readLogMsg(logFname, page, pageNum)
+}
+
+// ReadLogGCChannelMsg reads the log of GC messages sent in the given GC
+// channel.
+func (db *DB) ReadLogGCChannelMsg(tx ReadTx, gcName string, gcID, channel zkidentity.ShortID,
+	page, pageNum int) ([]PMLogEntry, error) {
+
+	logFname := gcLogFname(gcName, gcID, &channel)
 	return db.readLogMsg(logFname, page, pageNum)
 }
 
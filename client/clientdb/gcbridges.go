@@ -0,0 +1,84 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCBridge is a bridge between two GCs. Messages received in either GC are
+// relayed to the other one.
+type GCBridge struct {
+	GCs     [2]zkidentity.ShortID `json:"gcs"`
+	Created time.Time             `json:"created"`
+}
+
+// Bridges returns true if the bridge is between the two given GCs.
+func (b *GCBridge) Bridges(gcA, gcB zkidentity.ShortID) bool {
+	return (b.GCs[0] == gcA && b.GCs[1] == gcB) ||
+		(b.GCs[0] == gcB && b.GCs[1] == gcA)
+}
+
+// Other returns the GC bridged to the given one. Returns false if the bridge
+// does not include the given GC.
+func (b *GCBridge) Other(gcID zkidentity.ShortID) (zkidentity.ShortID, bool) {
+	switch gcID {
+	case b.GCs[0]:
+		return b.GCs[1], true
+	case b.GCs[1]:
+		return b.GCs[0], true
+	default:
+		return zkidentity.ShortID{}, false
+	}
+}
+
+// ListGCBridges lists the existing GC bridges.
+func (db *DB) ListGCBridges(tx ReadTx) ([]GCBridge, error) {
+	fname := filepath.Join(db.root, gcBridgesFile)
+	var bridges []GCBridge
+	err := db.readJsonFile(fname, &bridges)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return bridges, nil
+}
+
+// AddGCBridge adds a bridge between the two GCs. Adding an existing bridge is
+// a no-op.
+func (db *DB) AddGCBridge(tx ReadWriteTx, gcA, gcB zkidentity.ShortID) error {
+	bridges, err := db.ListGCBridges(tx)
+	if err != nil {
+		return err
+	}
+	for i := range bridges {
+		if bridges[i].Bridges(gcA, gcB) {
+			return nil
+		}
+	}
+	bridges = append(bridges, GCBridge{
+		GCs:     [2]zkidentity.ShortID{gcA, gcB},
+		Created: time.Now(),
+	})
+	fname := filepath.Join(db.root, gcBridgesFile)
+	return db.saveJsonFile(fname, bridges)
+}
+
+// RemoveGCBridge removes the bridge between the two GCs. Returns ErrNotFound
+// if there is no such bridge.
+func (db *DB) RemoveGCBridge(tx ReadWriteTx, gcA, gcB zkidentity.ShortID) error {
+	bridges, err := db.ListGCBridges(tx)
+	if err != nil {
+		return err
+	}
+	for i := range bridges {
+		if !bridges[i].Bridges(gcA, gcB) {
+			continue
+		}
+		bridges = append(bridges[:i], bridges[i+1:]...)
+		fname := filepath.Join(db.root, gcBridgesFile)
+		return db.saveJsonFile(fname, bridges)
+	}
+	return ErrNotFound
+}
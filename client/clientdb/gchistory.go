@@ -0,0 +1,125 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCHistoryDownload is the record of a GC history backlog shared with the
+// local client by a GC member.
+type GCHistoryDownload struct {
+	// GC is the ID of the GC the history refers to.
+	GC zkidentity.ShortID `json:"gc"`
+
+	// From is the member that shared the history.
+	From UserID `json:"from"`
+
+	// FID is the ID of the shared file with the history.
+	FID FileID `json:"fid"`
+
+	// Count is the number of messages announced by the member.
+	Count int `json:"count"`
+
+	// Received is the time when the history was announced.
+	Received time.Time `json:"received"`
+
+	// Completed is the time when the download of the history completed.
+	Completed *time.Time `json:"completed,omitempty"`
+}
+
+// readGCHistoryShare reads the max number of messages shared with new
+// members of each GC. The map is keyed by the string ID of the GC.
+func (db *DB) readGCHistoryShare() (map[string]int, error) {
+	fname := filepath.Join(db.root, gcHistoryShareFile)
+	limits := make(map[string]int)
+	err := db.readJsonFile(fname, &limits)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SetGCHistoryShare sets the max number of recent messages of the GC shared
+// with new members. A zero maxMsgs disables sharing the GC history.
+func (db *DB) SetGCHistoryShare(tx ReadWriteTx, gcID zkidentity.ShortID, maxMsgs int) error {
+	limits, err := db.readGCHistoryShare()
+	if err != nil {
+		return err
+	}
+	if maxMsgs > 0 {
+		limits[gcID.String()] = maxMsgs
+	} else {
+		delete(limits, gcID.String())
+	}
+	fname := filepath.Join(db.root, gcHistoryShareFile)
+	return db.saveJsonFile(fname, limits)
+}
+
+// GetGCHistoryShare returns the max number of recent messages of the GC
+// shared with new members. Returns zero if sharing is disabled.
+func (db *DB) GetGCHistoryShare(tx ReadTx, gcID zkidentity.ShortID) (int, error) {
+	limits, err := db.readGCHistoryShare()
+	if err != nil {
+		return 0, err
+	}
+	return limits[gcID.String()], nil
+}
+
+// readGCHistoryDownloads reads the GC history downloads. The map is keyed by
+// the string ID of the GC.
+func (db *DB) readGCHistoryDownloads() (map[string]GCHistoryDownload, error) {
+	fname := filepath.Join(db.root, gcHistoryDLsFile)
+	dls := make(map[string]GCHistoryDownload)
+	err := db.readJsonFile(fname, &dls)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return dls, nil
+}
+
+// StoreGCHistoryDownload stores the record of a GC history download,
+// replacing an existing record for the same GC.
+func (db *DB) StoreGCHistoryDownload(tx ReadWriteTx, dl GCHistoryDownload) error {
+	dls, err := db.readGCHistoryDownloads()
+	if err != nil {
+		return err
+	}
+	dls[dl.GC.String()] = dl
+	fname := filepath.Join(db.root, gcHistoryDLsFile)
+	return db.saveJsonFile(fname, dls)
+}
+
+// GetGCHistoryDownload returns the history download record of the given GC.
+// Returns ErrNotFound if no history was received for the GC.
+func (db *DB) GetGCHistoryDownload(tx ReadTx, gcID zkidentity.ShortID) (GCHistoryDownload, error) {
+	dls, err := db.readGCHistoryDownloads()
+	if err != nil {
+		return GCHistoryDownload{}, err
+	}
+	dl, ok := dls[gcID.String()]
+	if !ok {
+		return dl, fmt.Errorf("GC history download for %s: %w", gcID, ErrNotFound)
+	}
+	return dl, nil
+}
+
+// FindGCHistoryDownload returns the history download record of the given
+// file, shared by the given user. Returns ErrNotFound if the file is not a
+// GC history.
+func (db *DB) FindGCHistoryDownload(tx ReadTx, from UserID, fid FileID) (GCHistoryDownload, error) {
+	dls, err := db.readGCHistoryDownloads()
+	if err != nil {
+		return GCHistoryDownload{}, err
+	}
+	for _, dl := range dls {
+		if dl.From == from && dl.FID == fid {
+			return dl, nil
+		}
+	}
+	return GCHistoryDownload{}, fmt.Errorf("GC history download of file %s: %w",
+		fid, ErrNotFound)
+}
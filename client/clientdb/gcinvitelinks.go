@@ -0,0 +1,99 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCInviteLink is a shareable invite to a GC, created by the local client,
+// that may be redeemed by multiple users.
+type GCInviteLink struct {
+	ID      zkidentity.ShortID `json:"id"`
+	GC      zkidentity.ShortID `json:"gc"`
+	Created time.Time          `json:"created"`
+	Expires time.Time          `json:"expires"`
+
+	// MaxUses is the max number of users that may join the GC through
+	// this link. Zero means unlimited uses.
+	MaxUses uint32 `json:"max_uses"`
+
+	// UsedBy is the list of users that joined the GC through this link.
+	UsedBy []UserID `json:"used_by,omitempty"`
+
+	Revoked bool `json:"revoked"`
+}
+
+// Usable returns nil if the link can still be used to join the GC at the
+// given time.
+func (l *GCInviteLink) Usable(now time.Time) error {
+	switch {
+	case l.Revoked:
+		return errors.New("invite link was revoked")
+	case !l.Expires.IsZero() && now.After(l.Expires):
+		return errors.New("invite link expired")
+	case l.MaxUses > 0 && uint32(len(l.UsedBy)) >= l.MaxUses:
+		return errors.New("invite link reached its max number of uses")
+	}
+	return nil
+}
+
+// readGCInviteLinks reads the GC invite links. The map is keyed by the string
+// ID of the link.
+func (db *DB) readGCInviteLinks() (map[string]GCInviteLink, error) {
+	fname := filepath.Join(db.root, gcInviteLinksFile)
+	links := make(map[string]GCInviteLink)
+	err := db.readJsonFile(fname, &links)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetGCInviteLink returns the GC invite link with the given ID. Returns
+// ErrNotFound if the link does not exist.
+func (db *DB) GetGCInviteLink(tx ReadTx, id zkidentity.ShortID) (GCInviteLink, error) {
+	links, err := db.readGCInviteLinks()
+	if err != nil {
+		return GCInviteLink{}, err
+	}
+	link, ok := links[id.String()]
+	if !ok {
+		return GCInviteLink{}, ErrNotFound
+	}
+	return link, nil
+}
+
+// StoreGCInviteLink creates or replaces a GC invite link.
+func (db *DB) StoreGCInviteLink(tx ReadWriteTx, link GCInviteLink) error {
+	links, err := db.readGCInviteLinks()
+	if err != nil {
+		return err
+	}
+	links[link.ID.String()] = link
+	fname := filepath.Join(db.root, gcInviteLinksFile)
+	return db.saveJsonFile(fname, links)
+}
+
+// ListGCInviteLinks lists the GC invite links, sorted by creation time. If gc
+// is specified, only links to that GC are returned.
+func (db *DB) ListGCInviteLinks(tx ReadTx, gc *zkidentity.ShortID) ([]GCInviteLink, error) {
+	links, err := db.readGCInviteLinks()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]GCInviteLink, 0, len(links))
+	for _, link := range links {
+		if gc != nil && link.GC != *gc {
+			continue
+		}
+		res = append(res, link)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCJoinRequest is a pending request from a remote user to join a GC through
+// an invite link created by the local client, on a GC that requires join
+// requests to be approved.
+type GCJoinRequest struct {
+	GC       zkidentity.ShortID `json:"gc"`
+	UID      UserID             `json:"uid"`
+	LinkID   zkidentity.ShortID `json:"link_id"`
+	Received time.Time          `json:"received"`
+}
+
+// readGCJoinRequests reads the pending GC join requests.
+func (db *DB) readGCJoinRequests() ([]GCJoinRequest, error) {
+	fname := filepath.Join(db.root, gcJoinRequestsFile)
+	var reqs []GCJoinRequest
+	err := db.readJsonFile(fname, &reqs)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// saveGCJoinRequests saves the pending GC join requests.
+func (db *DB) saveGCJoinRequests(reqs []GCJoinRequest) error {
+	fname := filepath.Join(db.root, gcJoinRequestsFile)
+	return db.saveJsonFile(fname, reqs)
+}
+
+// StoreGCJoinRequest stores a pending GC join request. An existing request
+// from the same user to the same GC is replaced.
+func (db *DB) StoreGCJoinRequest(tx ReadWriteTx, req GCJoinRequest) error {
+	reqs, err := db.readGCJoinRequests()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range reqs {
+		if reqs[i].GC == req.GC && reqs[i].UID == req.UID {
+			reqs[i] = req
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		reqs = append(reqs, req)
+	}
+	return db.saveGCJoinRequests(reqs)
+}
+
+// GetGCJoinRequest returns the pending request from the user to join the GC.
+// Returns ErrNotFound if there is no such request.
+func (db *DB) GetGCJoinRequest(tx ReadTx, gc zkidentity.ShortID, uid UserID) (GCJoinRequest, error) {
+	reqs, err := db.readGCJoinRequests()
+	if err != nil {
+		return GCJoinRequest{}, err
+	}
+	for _, req := range reqs {
+		if req.GC == gc && req.UID == uid {
+			return req, nil
+		}
+	}
+	return GCJoinRequest{}, ErrNotFound
+}
+
+// DelGCJoinRequest removes the pending request from the user to join the GC.
+// Returns ErrNotFound if there is no such request.
+func (db *DB) DelGCJoinRequest(tx ReadWriteTx, gc zkidentity.ShortID, uid UserID) error {
+	reqs, err := db.readGCJoinRequests()
+	if err != nil {
+		return err
+	}
+	for i := range reqs {
+		if reqs[i].GC == gc && reqs[i].UID == uid {
+			reqs = append(reqs[:i], reqs[i+1:]...)
+			return db.saveGCJoinRequests(reqs)
+		}
+	}
+	return ErrNotFound
+}
+
+// ListGCJoinRequests lists the pending GC join requests, sorted by the time
+// they were received. If gc is specified, only requests to join that GC are
+// returned.
+func (db *DB) ListGCJoinRequests(tx ReadTx, gc *zkidentity.ShortID) ([]GCJoinRequest, error) {
+	reqs, err := db.readGCJoinRequests()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]GCJoinRequest, 0, len(reqs))
+	for _, req := range reqs {
+		if gc != nil && req.GC != *gc {
+			continue
+		}
+		res = append(res, req)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Received.Before(res[j].Received)
+	})
+	return res, nil
+}
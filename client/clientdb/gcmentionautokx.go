@@ -0,0 +1,46 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// readGCMentionAutoKX reads the set of GCs where mentions by unknown members
+// trigger an automatic KX. The map is keyed by the string ID of the GC.
+func (db *DB) readGCMentionAutoKX() (map[string]bool, error) {
+	fname := filepath.Join(db.root, gcMentionAutoKXFile)
+	enabled := make(map[string]bool)
+	err := db.readJsonFile(fname, &enabled)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return enabled, nil
+}
+
+// SetGCMentionAutoKX sets whether mentions by unknown members of the GC
+// trigger an automatic KX with them.
+func (db *DB) SetGCMentionAutoKX(tx ReadWriteTx, gcID zkidentity.ShortID, enabled bool) error {
+	gcs, err := db.readGCMentionAutoKX()
+	if err != nil {
+		return err
+	}
+	if enabled {
+		gcs[gcID.String()] = true
+	} else {
+		delete(gcs, gcID.String())
+	}
+	fname := filepath.Join(db.root, gcMentionAutoKXFile)
+	return db.saveJsonFile(fname, gcs)
+}
+
+// GetGCMentionAutoKX returns whether mentions by unknown members of the GC
+// trigger an automatic KX with them.
+func (db *DB) GetGCMentionAutoKX(tx ReadTx, gcID zkidentity.ShortID) (bool, error) {
+	gcs, err := db.readGCMentionAutoKX()
+	if err != nil {
+		return false, err
+	}
+	return gcs[gcID.String()], nil
+}
@@ -0,0 +1,53 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// maxGCModLogEntries is the max number of entries kept in the moderation log
+// of a GC. Older entries are dropped.
+const maxGCModLogEntries = 1000
+
+// GCModLogEntry is an entry in the local audit log of moderation actions
+// taken in a GC.
+type GCModLogEntry struct {
+	Timestamp time.Time           `json:"timestamp"`
+	Actor     UserID              `json:"actor"`
+	Action    string              `json:"action"`
+	Target    *zkidentity.ShortID `json:"target,omitempty"`
+	Details   string              `json:"details,omitempty"`
+	Reason    string              `json:"reason,omitempty"`
+}
+
+// AppendGCModLog appends the entries to the moderation log of the GC.
+func (db *DB) AppendGCModLog(tx ReadWriteTx, gcID zkidentity.ShortID, entries ...GCModLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	log, err := db.ListGCModLog(tx, gcID)
+	if err != nil {
+		return err
+	}
+	log = append(log, entries...)
+	if len(log) > maxGCModLogEntries {
+		log = log[len(log)-maxGCModLogEntries:]
+	}
+	fname := filepath.Join(db.root, gcModLogDir, gcID.String())
+	return db.saveJsonFile(fname, log)
+}
+
+// ListGCModLog returns the moderation log of the GC, sorted from oldest to
+// newest entry.
+func (db *DB) ListGCModLog(tx ReadTx, gcID zkidentity.ShortID) ([]GCModLogEntry, error) {
+	var log []GCModLogEntry
+	fname := filepath.Join(db.root, gcModLogDir, gcID.String())
+	err := db.readJsonFile(fname, &log)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return log, nil
+}
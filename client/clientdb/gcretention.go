@@ -0,0 +1,330 @@
+package clientdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// GCRetentionPolicy is the local policy for how many messages of a GC are kept
+// in the client db.
+type GCRetentionPolicy struct {
+	// MaxAgeDays is the max age (in days) of messages kept in the log of
+	// the GC. Zero means messages are not pruned based on their age.
+	MaxAgeDays uint32 `json:"max_age_days"`
+
+	// MaxMessages is the max number of messages kept in the log of the
+	// GC. Zero means messages are not pruned based on their number.
+	MaxMessages uint32 `json:"max_messages"`
+}
+
+// IsEmpty returns true if the policy does not prune any messages.
+func (p GCRetentionPolicy) IsEmpty() bool {
+	return p.MaxAgeDays == 0 && p.MaxMessages == 0
+}
+
+// readGCRetentionPolicies reads the GC retention policies. The map is keyed by
+// the string ID of the GC.
+func (db *DB) readGCRetentionPolicies() (map[string]GCRetentionPolicy, error) {
+	fname := filepath.Join(db.root, gcRetentionFile)
+	policies := make(map[string]GCRetentionPolicy)
+	err := db.readJsonFile(fname, &policies)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SetGCRetentionPolicy sets the retention policy of the GC. An empty policy
+// removes the existing policy.
+func (db *DB) SetGCRetentionPolicy(tx ReadWriteTx, gcID zkidentity.ShortID, policy GCRetentionPolicy) error {
+	policies, err := db.readGCRetentionPolicies()
+	if err != nil {
+		return err
+	}
+	if policy.IsEmpty() {
+		delete(policies, gcID.String())
+	} else {
+		policies[gcID.String()] = policy
+	}
+	fname := filepath.Join(db.root, gcRetentionFile)
+	return db.saveJsonFile(fname, policies)
+}
+
+// GetGCRetentionPolicy returns the retention policy of the GC. Returns
+// ErrNotFound if the GC does not have a retention policy.
+func (db *DB) GetGCRetentionPolicy(tx ReadTx, gcID zkidentity.ShortID) (GCRetentionPolicy, error) {
+	policies, err := db.readGCRetentionPolicies()
+	if err != nil {
+		return GCRetentionPolicy{}, err
+	}
+	policy, ok := policies[gcID.String()]
+	if !ok {
+		return policy, ErrNotFound
+	}
+	return policy, nil
+}
+
+// ListGCRetentionPolicies returns the retention policies of all GCs.
+func (db *DB) ListGCRetentionPolicies(tx ReadTx) (map[zkidentity.ShortID]GCRetentionPolicy, error) {
+	policies, err := db.readGCRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[zkidentity.ShortID]GCRetentionPolicy, len(policies))
+	for k, v := range policies {
+		var id zkidentity.ShortID
+		if err := id.FromString(k); err != nil {
+			db.log.Warnf("Invalid GC ID %q in retention policies", k)
+			continue
+		}
+		res[id] = v
+	}
+	return res, nil
+}
+
+// retentionEntry is an entry of a log file that may be pruned.
+type retentionEntry struct {
+	ts    time.Time
+	isMsg bool
+	data  []byte
+}
+
+// retentionCut returns the index of the first entry that must be kept, given
+// the policy. Entries are assumed to be sorted from oldest to newest.
+func retentionCut(entries []retentionEntry, policy GCRetentionPolicy, now time.Time) int {
+	var cut int
+	if policy.MaxMessages > 0 {
+		var msgs uint32
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !entries[i].isMsg {
+				continue
+			}
+			msgs += 1
+			if msgs == policy.MaxMessages {
+				cut = i
+				break
+			}
+		}
+	}
+	if policy.MaxAgeDays > 0 {
+		minTs := now.Add(-time.Duration(policy.MaxAgeDays) * 24 * time.Hour)
+		ageCut := len(entries)
+		for i := range entries {
+			if !entries[i].ts.Before(minTs) {
+				ageCut = i
+				break
+			}
+		}
+		if ageCut > cut {
+			cut = ageCut
+		}
+	}
+	return cut
+}
+
+// rewritePrunedFile replaces the contents of the file with the given entries.
+// If there are no entries left, the file is removed.
+func rewritePrunedFile(fname string, entries []retentionEntry) error {
+	if len(entries) == 0 {
+		return os.Remove(fname)
+	}
+
+	tmpFname := fname + ".tmp"
+	f, err := os.OpenFile(tmpFname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, err := f.Write(e.data); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFname, fname)
+}
+
+// readLogEntries reads the entries of a message log file. Each entry includes
+// all lines of a (possibly multi-line) message.
+func readLogEntries(fname string) ([]retentionEntry, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []retentionEntry
+	for len(data) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(data, '\n'); i > -1 {
+			line, data = data[:i+1], data[i+1:]
+		} else {
+			line, data = data, nil
+		}
+
+		matches := logLineRegexp.FindSubmatchIndex(line)
+		var ts time.Time
+		if len(matches) == 6 {
+			ts, err = time.ParseInLocation("2006-01-02T15:04:05",
+				string(line[matches[2]:matches[3]]), time.Local)
+		}
+		if len(matches) != 6 || err != nil {
+			// Continuation of the previous message.
+			if len(entries) == 0 {
+				entries = append(entries, retentionEntry{})
+			}
+			last := &entries[len(entries)-1]
+			last.data = append(last.data, line...)
+			continue
+		}
+
+		name := line[matches[4]:matches[5]]
+		entries = append(entries, retentionEntry{
+			ts:    ts,
+			isMsg: len(name) > 0 && name[0] == '<',
+			data:  append([]byte(nil), line...),
+		})
+	}
+	return entries, nil
+}
+
+// readMsgSearchEntries reads the entries of a message search index file.
+func readMsgSearchEntries(fname string) ([]retentionEntry, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []retentionEntry
+	for _, line := range bytes.SplitAfter(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry msgSearchEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("unable to decode search index "+
+				"entry: %v", err)
+		}
+		entries = append(entries, retentionEntry{
+			ts:    entry.Timestamp,
+			isMsg: true,
+			data:  line,
+		})
+	}
+	return entries, nil
+}
+
+// pruneGCLogFile prunes the messages of the log file that are not retained
+// according to the policy. Returns the number of pruned messages.
+func (db *DB) pruneGCLogFile(logFname string, policy GCRetentionPolicy, now time.Time) (int, error) {
+	filename := filepath.Join(db.cfg.MsgsRoot, logFname)
+	entries, err := readLogEntries(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cut := retentionCut(entries, policy, now)
+	if cut == 0 {
+		return 0, nil
+	}
+
+	var pruned int
+	for _, e := range entries[:cut] {
+		if e.isMsg {
+			pruned += 1
+		}
+	}
+	if err := rewritePrunedFile(filename, entries[cut:]); err != nil {
+		return 0, err
+	}
+	if cut == len(entries) {
+		// Ensure the next logged message starts a new conversation in
+		// the log.
+		delete(db.lastMsgTS, logFname)
+	}
+	return pruned, nil
+}
+
+// gcLogFnames returns the names of the log files of the GC, including the logs
+// of its channels.
+func (db *DB) gcLogFnames(gcName string, gcID zkidentity.ShortID) ([]string, error) {
+	mainFname := gcLogFname(gcName, gcID, nil)
+	fnames := []string{mainFname}
+	prefix := strings.TrimSuffix(mainFname, ".log") + "."
+	dirEntries, err := os.ReadDir(db.cfg.MsgsRoot)
+	if errors.Is(err, os.ErrNotExist) {
+		return fnames, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, de := range dirEntries {
+		name := de.Name()
+		if !de.IsDir() && strings.HasPrefix(name, prefix) &&
+			strings.HasSuffix(name, ".log") {
+			fnames = append(fnames, name)
+		}
+	}
+	return fnames, nil
+}
+
+// PruneGCMsgs removes the messages of the GC that are not retained according
+// to the policy from the GC message logs (including the logs of its channels)
+// and search index. Embedded content is stored inline in the messages, so it
+// is removed along with them. Returns the number of messages removed from the
+// logs.
+func (db *DB) PruneGCMsgs(tx ReadWriteTx, gcName string, gcID zkidentity.ShortID,
+	policy GCRetentionPolicy, now time.Time) (int, error) {
+
+	if policy.IsEmpty() || db.cfg.MsgsRoot == "" {
+		return 0, nil
+	}
+
+	logFnames, err := db.gcLogFnames(gcName, gcID)
+	if err != nil {
+		return 0, err
+	}
+	var pruned int
+	for _, logFname := range logFnames {
+		n, err := db.pruneGCLogFile(logFname, policy, now)
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+
+	// The search index includes messages of all channels, so the max
+	// number of messages applies to the total of indexed messages.
+	fname := db.msgSearchFname(nil, &gcID)
+	entries, err := readMsgSearchEntries(fname)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return pruned, err
+	default:
+		cut := retentionCut(entries, policy, now)
+		if cut == 0 {
+			break
+		}
+		if err := rewritePrunedFile(fname, entries[cut:]); err != nil {
+			return pruned, err
+		}
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,150 @@
+package clientdb
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// maxGCTips is the max number of tips kept for the leaderboard of a GC. Older
+// tips are dropped.
+const maxGCTips = 10000
+
+// GCTip is a tip made between members of a GC, within the context of the GC.
+type GCTip struct {
+	Payer       UserID    `json:"payer"`
+	Payee       UserID    `json:"payee"`
+	MilliAtoms  int64     `json:"milli_atoms"`
+	PaymentHash []byte    `json:"payment_hash"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// GCTipLeaderboardEntry is the total of the tips sent and received by a GC
+// member.
+type GCTipLeaderboardEntry struct {
+	UID            UserID `json:"uid"`
+	SentMAtoms     int64  `json:"sent_matoms"`
+	SentCount      int    `json:"sent_count"`
+	ReceivedMAtoms int64  `json:"received_matoms"`
+	ReceivedCount  int    `json:"received_count"`
+}
+
+// readGCTipLeaderboards reads the set of GCs for which the tipping
+// leaderboard is enabled. The map is keyed by the string ID of the GC.
+func (db *DB) readGCTipLeaderboards() (map[string]bool, error) {
+	fname := filepath.Join(db.root, gcTipLeaderboardFile)
+	enabled := make(map[string]bool)
+	err := db.readJsonFile(fname, &enabled)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return enabled, nil
+}
+
+// SetGCTipLeaderboard sets whether the tipping leaderboard of the GC is
+// enabled.
+func (db *DB) SetGCTipLeaderboard(tx ReadWriteTx, gcID zkidentity.ShortID, enabled bool) error {
+	gcs, err := db.readGCTipLeaderboards()
+	if err != nil {
+		return err
+	}
+	if enabled {
+		gcs[gcID.String()] = true
+	} else {
+		delete(gcs, gcID.String())
+	}
+	fname := filepath.Join(db.root, gcTipLeaderboardFile)
+	return db.saveJsonFile(fname, gcs)
+}
+
+// GetGCTipLeaderboard returns whether the tipping leaderboard of the GC is
+// enabled.
+func (db *DB) GetGCTipLeaderboard(tx ReadTx, gcID zkidentity.ShortID) (bool, error) {
+	gcs, err := db.readGCTipLeaderboards()
+	if err != nil {
+		return false, err
+	}
+	return gcs[gcID.String()], nil
+}
+
+// StoreGCTip stores a tip made within the GC. Tips with a payment hash that
+// was already stored are ignored. Returns true if the tip was stored.
+func (db *DB) StoreGCTip(tx ReadWriteTx, gcID zkidentity.ShortID, tip GCTip) (bool, error) {
+	tips, err := db.ListGCTips(tx, gcID)
+	if err != nil {
+		return false, err
+	}
+	for i := range tips {
+		if bytes.Equal(tips[i].PaymentHash, tip.PaymentHash) {
+			return false, nil
+		}
+	}
+	tips = append(tips, tip)
+	if len(tips) > maxGCTips {
+		tips = tips[len(tips)-maxGCTips:]
+	}
+	fname := filepath.Join(db.root, gcTipsDir, gcID.String())
+	return true, db.saveJsonFile(fname, tips)
+}
+
+// ListGCTips returns the tips made within the GC, in the order they were
+// stored.
+func (db *DB) ListGCTips(tx ReadTx, gcID zkidentity.ShortID) ([]GCTip, error) {
+	var tips []GCTip
+	fname := filepath.Join(db.root, gcTipsDir, gcID.String())
+	err := db.readJsonFile(fname, &tips)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return tips, nil
+}
+
+// GCTipLeaderboard returns the totals of the tips sent and received by the
+// members of the GC since the specified time. Entries are sorted by amount
+// sent, then by amount received.
+func (db *DB) GCTipLeaderboard(tx ReadTx, gcID zkidentity.ShortID, since time.Time) ([]GCTipLeaderboardEntry, error) {
+	tips, err := db.ListGCTips(tx, gcID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[UserID]*GCTipLeaderboardEntry)
+	entry := func(uid UserID) *GCTipLeaderboardEntry {
+		e, ok := entries[uid]
+		if !ok {
+			e = &GCTipLeaderboardEntry{UID: uid}
+			entries[uid] = e
+		}
+		return e
+	}
+	for _, tip := range tips {
+		if tip.Timestamp.Before(since) {
+			continue
+		}
+		payer := entry(tip.Payer)
+		payer.SentMAtoms += tip.MilliAtoms
+		payer.SentCount += 1
+		payee := entry(tip.Payee)
+		payee.ReceivedMAtoms += tip.MilliAtoms
+		payee.ReceivedCount += 1
+	}
+
+	res := make([]GCTipLeaderboardEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].SentMAtoms != res[j].SentMAtoms {
+			return res[i].SentMAtoms > res[j].SentMAtoms
+		}
+		if res[i].ReceivedMAtoms != res[j].ReceivedMAtoms {
+			return res[i].ReceivedMAtoms > res[j].ReceivedMAtoms
+		}
+		return bytes.Compare(res[i].UID[:], res[j].UID[:]) < 0
+	})
+	return res, nil
+}
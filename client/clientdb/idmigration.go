@@ -0,0 +1,82 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// IdentityMigration is the record of a remote user that announced they moved
+// to a new identity or that their identity was revoked.
+type IdentityMigration struct {
+	// OldID is the identity of the user that sent the announcement.
+	OldID UserID `json:"old_id"`
+
+	// NewID is the new identity of the user. If nil, the old identity was
+	// revoked without a replacement.
+	NewID *UserID `json:"new_id,omitempty"`
+
+	// Reason is the reason for the migration, as specified by the user.
+	Reason string `json:"reason"`
+
+	// Timestamp is the (signed) time when the announcement was created.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Received is the time when the announcement was received.
+	Received time.Time `json:"received"`
+
+	// Applied is the time when the migration was applied to the address
+	// book (after completing the KX with the new identity).
+	Applied *time.Time `json:"applied,omitempty"`
+}
+
+// readIdentityMigrations reads the identity migrations. The map is keyed by
+// the string old ID of the migration.
+func (db *DB) readIdentityMigrations() (map[string]IdentityMigration, error) {
+	fname := filepath.Join(db.root, idMigrationsFile)
+	migs := make(map[string]IdentityMigration)
+	err := db.readJsonFile(fname, &migs)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return migs, nil
+}
+
+// StoreIdentityMigration stores the identity migration, replacing an existing
+// migration of the same old identity.
+func (db *DB) StoreIdentityMigration(tx ReadWriteTx, mig IdentityMigration) error {
+	migs, err := db.readIdentityMigrations()
+	if err != nil {
+		return err
+	}
+	migs[mig.OldID.String()] = mig
+	fname := filepath.Join(db.root, idMigrationsFile)
+	return db.saveJsonFile(fname, migs)
+}
+
+// GetIdentityMigration returns the migration of the given old identity.
+// Returns ErrNotFound if there is no such migration.
+func (db *DB) GetIdentityMigration(tx ReadTx, oldID UserID) (IdentityMigration, error) {
+	migs, err := db.readIdentityMigrations()
+	if err != nil {
+		return IdentityMigration{}, err
+	}
+	mig, ok := migs[oldID.String()]
+	if !ok {
+		return mig, ErrNotFound
+	}
+	return mig, nil
+}
+
+// ListIdentityMigrations returns all stored identity migrations.
+func (db *DB) ListIdentityMigrations(tx ReadTx) ([]IdentityMigration, error) {
+	migs, err := db.readIdentityMigrations()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]IdentityMigration, 0, len(migs))
+	for _, mig := range migs {
+		res = append(res, mig)
+	}
+	return res, nil
+}
@@ -155,15 +155,54 @@ type SharedFile struct {
 
 	// Filename is the base filename of the file.
 	Filename string `json:"filename"`
+
+	// Swarm is set when the file is not shared by the local client but is
+	// a download re-shared with other downloaders of the file.
+	Swarm bool `json:"swarm,omitempty"`
+
+	// Limits are the limits of the share, after which the file is
+	// automatically unshared.
+	Limits *ShareLimits `json:"limits,omitempty"`
+
+	// Downloads are the users that completed a download of the file
+	// through this share.
+	Downloads []UserID `json:"downloads,omitempty"`
+}
+
+// ShareLimits are the limits of a share of a file, after which the file is
+// automatically unshared.
+type ShareLimits struct {
+	// Expires is the time after which the file is unshared. If zero, the
+	// share does not expire.
+	Expires time.Time `json:"expires"`
+
+	// MaxDownloads is the number of users that may complete a download of
+	// the file. If zero, the number of downloads is not limited.
+	MaxDownloads int `json:"max_downloads"`
+}
+
+// Expired returns true if the share expired by the given time.
+func (l *ShareLimits) Expired(now time.Time) bool {
+	return !l.Expires.IsZero() && !now.Before(l.Expires)
+}
+
+// SharedFileLimits are the limits of one of the shares of a file.
+type SharedFileLimits struct {
+	// UID is the user the file is shared with, or nil for the global
+	// share.
+	UID       *UserID     `json:"uid,omitempty"`
+	Limits    ShareLimits `json:"limits"`
+	Downloads int         `json:"downloads"`
 }
 
 // SharedFileAndShares tracks all the shares made for the given shared file.
 type SharedFileAndShares struct {
-	SF     SharedFile      `json:"shared_file"`
-	Cost   uint64          `json:"cost"`
-	Size   uint64          `json:"size"`
-	Global bool            `json:"global"`
-	Shares []clientintf.ID `json:"shares"`
+	SF     SharedFile         `json:"shared_file"`
+	Cost   uint64             `json:"cost"`
+	Size   uint64             `json:"size"`
+	Global bool               `json:"global"`
+	Shares []clientintf.ID    `json:"shares"`
+	Limits []SharedFileLimits `json:"limits,omitempty"`
 }
 
 type ChunkState string
@@ -178,6 +217,32 @@ const (
 	ChunkStateDownloaded     ChunkState = "downloaded"
 )
 
+// FileDownloadSource is an additional remote user from which chunks of a file
+// download may be fetched, because they share the same file.
+type FileDownloadSource struct {
+	UID  UserID `json:"uid"`
+	FID  FileID `json:"fid"` // FID of the file in the source's share
+	Cost uint64 `json:"cost"`
+
+	// Swarm is set when the source is another downloader of the file that
+	// re-shares it. In that case, only Chunks are available from it.
+	Swarm  bool  `json:"swarm,omitempty"`
+	Chunks []int `json:"chunks,omitempty"`
+}
+
+// HasChunk returns true if the given chunk is available from the source.
+func (src *FileDownloadSource) HasChunk(chunkIdx int) bool {
+	if !src.Swarm {
+		return true
+	}
+	for _, idx := range src.Chunks {
+		if idx == chunkIdx {
+			return true
+		}
+	}
+	return false
+}
+
 type FileDownload struct {
 	UID              UserID             `json:"uid"`
 	FID              FileID             `json:"fid"`
@@ -187,6 +252,83 @@ type FileDownload struct {
 	ChunkStates      map[int]ChunkState `json:"chunkstates"`
 	ChunkUpdatedTime map[int]time.Time  `json:"chunkupdttimes"`
 	IsSentFile       bool               `json:"is_sent_file"`
+
+	// PreviewOnly is set when only the free preview of the file is being
+	// downloaded.
+	PreviewOnly bool `json:"preview_only,omitempty"`
+
+	// Sources are additional users that share the same file, from which
+	// chunks are downloaded in parallel to the ones fetched from UID.
+	Sources []FileDownloadSource `json:"sources,omitempty"`
+
+	// ChunkSources tracks the user from which each chunk was requested,
+	// when that is not UID.
+	ChunkSources map[int]UserID `json:"chunk_sources,omitempty"`
+
+	// Swarm is set when the local client re-shares the chunks of the file
+	// with other downloaders of the file.
+	Swarm bool `json:"swarm,omitempty"`
+}
+
+// ChunkSource returns the id of the user from which the given chunk was
+// requested.
+func (fd *FileDownload) ChunkSource(chunkIdx int) UserID {
+	if uid, ok := fd.ChunkSources[chunkIdx]; ok {
+		return uid
+	}
+	return fd.UID
+}
+
+// SourceFID returns the id of the file in the share of the given user. It
+// returns false if the user is not a source for this download.
+func (fd *FileDownload) SourceFID(uid UserID) (FileID, bool) {
+	if uid == fd.UID {
+		return fd.FID, true
+	}
+	for _, src := range fd.Sources {
+		if src.UID == uid {
+			return src.FID, true
+		}
+	}
+	return FileID{}, false
+}
+
+// SourceHasChunk returns true if the given chunk is available from the
+// given user.
+func (fd *FileDownload) SourceHasChunk(uid UserID, chunkIdx int) bool {
+	if uid == fd.UID {
+		return true
+	}
+	for i := range fd.Sources {
+		if fd.Sources[i].UID == uid {
+			return fd.Sources[i].HasChunk(chunkIdx)
+		}
+	}
+	return false
+}
+
+// SourceCost returns the cost of the file when downloaded from the given user.
+func (fd *FileDownload) SourceCost(uid UserID) uint64 {
+	for _, src := range fd.Sources {
+		if src.UID == uid {
+			return src.Cost
+		}
+	}
+	if fd.Metadata == nil {
+		return 0
+	}
+	return fd.Metadata.Cost
+}
+
+// ChunkMAtoms returns the amount (in milli-atoms) to pay for the given chunk
+// when downloaded from the given user.
+func (fd *FileDownload) ChunkMAtoms(chunkIdx int, uid UserID) uint64 {
+	if fd.Metadata == nil {
+		return 0
+	}
+	fm := *fd.Metadata
+	fm.Cost = fd.SourceCost(uid)
+	return clientintf.FileChunkMAtoms(chunkIdx, &fm)
 }
 
 func (fd *FileDownload) GetChunkState(chunkIdx int) ChunkState {
@@ -247,6 +389,9 @@ type PostSummary struct {
 	Date         time.Time `json:"date"`
 	LastStatusTS time.Time `json:"last_status_ts"`
 	Title        string    `json:"title"`
+	Tags         []string  `json:"tags,omitempty"`
+	RelayComment string    `json:"relay_comment,omitempty"`
+	Pinned       bool      `json:"pinned,omitempty"`
 }
 
 type PostSubscription struct {
@@ -289,6 +434,10 @@ const (
 	PKXActionKXSearch  PostKXActionType = "kx_search"
 	PKXActionFetchPost PostKXActionType = "fetch_post"
 	PKXActionInviteGC  PostKXActionType = "invite_gc"
+
+	// PKXActionRedeemGCLink redeems a GC invite link (Data is the encoded
+	// link).
+	PKXActionRedeemGCLink PostKXActionType = "redeem_gc_link"
 )
 
 type PostKXAction struct {
@@ -338,6 +487,15 @@ type TipUserAttempt struct {
 	PrevInvoices         []string   `json:"prev_invoices"`
 	LastInvoiceError     *string    `json:"last_invoice_error,omitempty"`
 	Completed            *time.Time `json:"completed,omitempty"`
+	PaymentFees          int64      `json:"payment_fees,omitempty"`
+
+	// GC is set for tips made on behalf of a GC and BudgetOverride is
+	// set when the tip was confirmed to exceed a payment budget.
+	GC             *zkidentity.ShortID `json:"gc,omitempty"`
+	BudgetOverride bool                `json:"budget_override,omitempty"`
+
+	// Fiat is set for tips denominated in a fiat currency.
+	Fiat *rpc.RMFiatConversion `json:"fiat,omitempty"`
 }
 
 // ResourceRequest is a serialized request for a resource.
@@ -408,6 +566,52 @@ type PMLogEntry struct {
 	Internal  bool   `json:"internal"`
 }
 
+// MessageEdit is a single edit done to a PM or GC message.
+type MessageEdit struct {
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MessageEdits is the edit history of a PM or GC message.
+type MessageEdits struct {
+	MsgID zkidentity.ShortID  `json:"msgid"`
+	From  UserID              `json:"from"`
+	GC    *zkidentity.ShortID `json:"gc,omitempty"`
+
+	// Edits is the list of edits done to the message, in the order they
+	// were received. The last one is the current contents of the message.
+	Edits []MessageEdit `json:"edits"`
+}
+
+// ThreadMessage is a reply to a PM or GC message.
+type ThreadMessage struct {
+	MsgID     zkidentity.ShortID `json:"msgid"`
+	ReplyTo   zkidentity.ShortID `json:"replyto"`
+	From      UserID             `json:"from"`
+	Message   string             `json:"message"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// Thread is the list of replies to a PM or GC message. Replies to replies are
+// tracked in the thread of the original (root) message.
+type Thread struct {
+	RootMsgID zkidentity.ShortID  `json:"root_msgid"`
+	GC        *zkidentity.ShortID `json:"gc,omitempty"`
+
+	// Replies is the list of replies in the thread, in the order they were
+	// stored.
+	Replies []ThreadMessage `json:"replies"`
+}
+
+// MessageReactions is the aggregated list of reactions to a PM or GC message.
+type MessageReactions struct {
+	MsgID zkidentity.ShortID  `json:"msgid"`
+	GC    *zkidentity.ShortID `json:"gc,omitempty"`
+
+	// Reactions maps each reaction to the list of users that sent it.
+	Reactions map[string][]UserID `json:"reactions"`
+}
+
 // UnkxdUserInfo tracks information about unxked users.
 type UnkxdUserInfo struct {
 	UID           UserID     `json:"uid"`
@@ -422,6 +626,23 @@ type GeneratedInvoiceForTip struct {
 	MilliAtoms uint64    `json:"milli_atoms"`
 }
 
+// InvoiceStatus is the status of an invoice.
+type InvoiceStatus string
+
+const (
+	InvoiceStatusOpen     InvoiceStatus = "open"
+	InvoiceStatusSettled  InvoiceStatus = "settled"
+	InvoiceStatusExpired  InvoiceStatus = "expired"
+	InvoiceStatusCanceled InvoiceStatus = "canceled"
+)
+
+// InvoiceLabel is a local label and memo attached to an invoice.
+type InvoiceLabel struct {
+	Label   string    `json:"label"`
+	Memo    string    `json:"memo"`
+	Updated time.Time `json:"updated"`
+}
+
 // ContentFilter stores filtering rules for content.
 type ContentFilter struct {
 	// ID is the local ID of the filter.
@@ -448,6 +669,50 @@ type ContentFilter struct {
 
 	// Regexp is the raw filter to apply.
 	Regexp string
+
+	// EmbedType, if set, restricts the filter to messages that have an
+	// embed whose mime type starts with this value (for example, "image/"
+	// or "video/mp4").
+	EmbedType string
+
+	// Action is the action taken when a message matches this filter.
+	Action ContentFilterAction
+}
+
+// ContentFilterAction is the action taken when a message matches a content
+// filter.
+type ContentFilterAction string
+
+const (
+	// ContentFilterActionHide drops the message before it is presented to
+	// the user. This is the default action.
+	ContentFilterActionHide ContentFilterAction = ""
+
+	// ContentFilterActionMute logs the message, but does not notify the
+	// user about it. This only applies to PMs and GC messages. Muted
+	// posts and post comments are treated as flagged.
+	ContentFilterActionMute ContentFilterAction = "mute"
+
+	// ContentFilterActionFlag presents the message to the user as usual,
+	// but sends a content filtered notification so that it may be marked.
+	ContentFilterActionFlag ContentFilterAction = "flag"
+)
+
+func (a ContentFilterAction) String() string {
+	if a == ContentFilterActionHide {
+		return "hide"
+	}
+	return string(a)
+}
+
+// IsValid returns true if this is a known action.
+func (a ContentFilterAction) IsValid() bool {
+	switch a {
+	case ContentFilterActionHide, ContentFilterActionMute, ContentFilterActionFlag:
+		return true
+	default:
+		return false
+	}
 }
 
 // ReceiveReceipt stores receive receipt times.
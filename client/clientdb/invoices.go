@@ -0,0 +1,50 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// ListTipInvoicesByStatus lists the invoices generated for tipping from all
+// users that have the given status.
+func (db *DB) ListTipInvoicesByStatus(tx ReadTx, status InvoiceStatus) ([]GeneratedInvoiceForTip, error) {
+	switch status {
+	case InvoiceStatusOpen:
+		return db.listTipInvoicesFiles(genTipInvoicesFile)
+	case InvoiceStatusSettled:
+		return db.listTipInvoicesFiles(recvTipInvoicesFile)
+	case InvoiceStatusExpired:
+		return db.listTipInvoicesFiles(expiredTipInvoicesFile)
+	case InvoiceStatusCanceled:
+		return db.listTipInvoicesFiles(canceledTipInvoicesFile)
+	default:
+		return nil, fmt.Errorf("unknown invoice status %q", status)
+	}
+}
+
+// SetInvoiceLabel sets the label and memo of an invoice. If both are empty,
+// the label is removed.
+func (db *DB) SetInvoiceLabel(tx ReadWriteTx, invoice string, label InvoiceLabel) error {
+	fname := filepath.Join(db.root, invoiceLabelsFile)
+	labels := make(map[string]InvoiceLabel)
+	if err := db.readJsonFile(fname, &labels); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if label.Label == "" && label.Memo == "" {
+		delete(labels, invoice)
+	} else {
+		labels[invoice] = label
+	}
+	return db.saveJsonFile(fname, labels)
+}
+
+// ListInvoiceLabels returns the labels of invoices, keyed by invoice.
+func (db *DB) ListInvoiceLabels(tx ReadTx) (map[string]InvoiceLabel, error) {
+	fname := filepath.Join(db.root, invoiceLabelsFile)
+	labels := make(map[string]InvoiceLabel)
+	if err := db.readJsonFile(fname, &labels); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return labels, nil
+}
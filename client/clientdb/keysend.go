@@ -0,0 +1,72 @@
+package clientdb
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+type lnNodeForUser struct {
+	Node    string    `json:"node"`
+	Updated time.Time `json:"updated"`
+}
+
+// LNNodeForUser returns the hex-encoded pubkey of the LN node of the user or
+// an empty string if the node is not known.
+func (db *DB) LNNodeForUser(tx ReadTx, uid UserID) (string, error) {
+	filename := filepath.Join(db.root, inboundDir, uid.String(), lnNodeForUserFile)
+	var node lnNodeForUser
+	err := db.readJsonFile(filename, &node)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return node.Node, nil
+}
+
+// UpdateLNNodeForUser stores the hex-encoded pubkey of the LN node of the user.
+func (db *DB) UpdateLNNodeForUser(tx ReadWriteTx, uid UserID, node string) error {
+	if !db.AddressBookEntryExists(tx, uid) {
+		return ErrNotFound
+	}
+	filename := filepath.Join(db.root, inboundDir, uid.String(), lnNodeForUserFile)
+	return db.saveJsonFile(filename, lnNodeForUser{Node: node, Updated: time.Now()})
+}
+
+// ReceivedKeysendTip is a keysend tip received from a remote user.
+type ReceivedKeysendTip struct {
+	UID         UserID    `json:"uid"`
+	PaymentHash string    `json:"payment_hash"`
+	MilliAtoms  int64     `json:"milli_atoms"`
+	Received    time.Time `json:"received"`
+}
+
+// RecordReceivedKeysendTip records that a keysend payment with the given hash
+// was received as a tip from the user. It returns ErrAlreadyExists if the
+// payment was already recorded.
+func (db *DB) RecordReceivedKeysendTip(tx ReadWriteTx, uid UserID, hash []byte, mat int64) error {
+	hashStr := hex.EncodeToString(hash)
+	filename := filepath.Join(db.root, recvKeysendTipsFile)
+	var tips []ReceivedKeysendTip
+	err := db.readJsonFile(filename, &tips)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	for _, tip := range tips {
+		if tip.PaymentHash == hashStr {
+			return fmt.Errorf("keysend payment %s: %w", hashStr,
+				ErrAlreadyExists)
+		}
+	}
+
+	tips = append(tips, ReceivedKeysendTip{
+		UID:         uid,
+		PaymentHash: hashStr,
+		MilliAtoms:  mat,
+		Received:    time.Now(),
+	})
+	return db.saveJsonFile(filename, tips)
+}
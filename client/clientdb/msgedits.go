@@ -0,0 +1,45 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// StoreMessageEdit adds an edit to the edit history of the message with the
+// given ID, sent by the specified user. It returns the updated history.
+func (db *DB) StoreMessageEdit(tx ReadWriteTx, from UserID, gc *zkidentity.ShortID,
+	msgID zkidentity.ShortID, msg string, ts time.Time) (*MessageEdits, error) {
+
+	fname := filepath.Join(db.root, msgEditsDir, from.String(), msgID.String())
+	var edits MessageEdits
+	err := db.readJsonFile(fname, &edits)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		edits = MessageEdits{MsgID: msgID, From: from, GC: gc}
+	case err != nil:
+		return nil, err
+	case (edits.GC == nil) != (gc == nil) || (gc != nil && *edits.GC != *gc):
+		return nil, fmt.Errorf("message %s was edited in a different chat", msgID)
+	}
+
+	edits.Edits = append(edits.Edits, MessageEdit{Message: msg, Timestamp: ts})
+	if err := db.saveJsonFile(fname, &edits); err != nil {
+		return nil, err
+	}
+	return &edits, nil
+}
+
+// ReadMessageEdits returns the edit history of the message with the given ID,
+// sent by the specified user.
+func (db *DB) ReadMessageEdits(tx ReadTx, from UserID, msgID zkidentity.ShortID) (*MessageEdits, error) {
+	fname := filepath.Join(db.root, msgEditsDir, from.String(), msgID.String())
+	var edits MessageEdits
+	if err := db.readJsonFile(fname, &edits); err != nil {
+		return nil, err
+	}
+	return &edits, nil
+}
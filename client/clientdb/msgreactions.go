@@ -0,0 +1,65 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"golang.org/x/exp/slices"
+)
+
+// StoreMessageReaction adds (or removes, if remove is true) the reaction of
+// the given user to the message with the specified ID. It returns the updated
+// list of reactions.
+func (db *DB) StoreMessageReaction(tx ReadWriteTx, from UserID, gc *zkidentity.ShortID,
+	msgID zkidentity.ShortID, reaction string, remove bool) (*MessageReactions, error) {
+
+	fname := filepath.Join(db.root, msgReactionsDir, msgID.String())
+	var mr MessageReactions
+	err := db.readJsonFile(fname, &mr)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		mr = MessageReactions{MsgID: msgID, GC: gc}
+	case err != nil:
+		return nil, err
+	case (mr.GC == nil) != (gc == nil) || (gc != nil && *mr.GC != *gc):
+		return nil, fmt.Errorf("message %s was reacted to in a different chat", msgID)
+	}
+	if mr.Reactions == nil {
+		mr.Reactions = make(map[string][]UserID)
+	}
+
+	users := mr.Reactions[reaction]
+	i := slices.Index(users, from)
+	switch {
+	case remove && i > -1:
+		users = slices.Delete(users, i, i+1)
+	case !remove && i == -1:
+		users = append(users, from)
+	default:
+		// Nothing to change.
+		return &mr, nil
+	}
+	if len(users) == 0 {
+		delete(mr.Reactions, reaction)
+	} else {
+		mr.Reactions[reaction] = users
+	}
+
+	if err := db.saveJsonFile(fname, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// ReadMessageReactions returns the list of reactions to the message with the
+// given ID.
+func (db *DB) ReadMessageReactions(tx ReadTx, msgID zkidentity.ShortID) (*MessageReactions, error) {
+	fname := filepath.Join(db.root, msgReactionsDir, msgID.String())
+	var mr MessageReactions
+	if err := db.readJsonFile(fname, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
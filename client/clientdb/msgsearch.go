@@ -0,0 +1,286 @@
+package clientdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// msgSearchEntry is an entry in the message search index.
+type msgSearchEntry struct {
+	From      string    `json:"from"`
+	Message   string    `json:"message"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MsgSearchFilter are the filters applied when searching for messages.
+type MsgSearchFilter struct {
+	// UID restricts the search to PMs exchanged with this user.
+	UID *UserID
+
+	// GC restricts the search to messages of this GC.
+	GC *zkidentity.ShortID
+
+	// Since and Until restrict the search to messages in this time
+	// range. Zero values mean no restriction.
+	Since time.Time
+	Until time.Time
+
+	// Limit is the max number of results returned. Zero means no limit.
+	Limit int
+}
+
+// MsgSearchResult is a message found when searching the message index.
+type MsgSearchResult struct {
+	// UID is set for PMs and is the user the message was exchanged with.
+	UID *UserID `json:"uid,omitempty"`
+
+	// GC is set for messages sent in GCs.
+	GC *zkidentity.ShortID `json:"gc,omitempty"`
+
+	From      string    `json:"from"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// msgSearchText returns the normalized text of a message used for searching.
+// The text of embeds (file names, alt text and contents of embedded text
+// files) is included, so that it may be found as well.
+func msgSearchText(msg string) string {
+	var extra []string
+	text := mdembeds.ReplaceEmbeds(msg, func(args mdembeds.EmbeddedArgs) string {
+		for _, s := range []string{args.Name, args.Alt, args.Filename} {
+			if s != "" {
+				extra = append(extra, s)
+			}
+		}
+		if strings.HasPrefix(args.Typ, "text/") && len(args.Data) > 0 {
+			extra = append(extra, string(args.Data))
+		}
+		return " "
+	})
+	if len(extra) > 0 {
+		text += " " + strings.Join(extra, " ")
+	}
+	return strings.ToLower(text)
+}
+
+// msgSearchFname returns the name of the search index file for PMs with the
+// given user or for the given GC.
+func (db *DB) msgSearchFname(uid *UserID, gc *zkidentity.ShortID) string {
+	if gc != nil {
+		return filepath.Join(db.root, msgSearchDir, "gc."+gc.String())
+	}
+	return filepath.Join(db.root, msgSearchDir, "pm."+uid.String())
+}
+
+// indexMsg adds the given message to the search index of the PM or GC.
+func (db *DB) indexMsg(uid *UserID, gc *zkidentity.ShortID, from, msg string, ts time.Time) error {
+	entry := msgSearchEntry{
+		From:      from,
+		Message:   msg,
+		Text:      msgSearchText(msg),
+		Timestamp: ts,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	fname := db.msgSearchFname(uid, gc)
+	if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// searchIndexFile searches the given index file for messages that contain all
+// terms.
+func searchIndexFile(fname string, terms []string, filter *MsgSearchFilter,
+	uid *UserID, gc *zkidentity.ShortID) ([]MsgSearchResult, error) {
+
+	f, err := os.Open(fname)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []MsgSearchResult
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var entry msgSearchEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip corrupted entries.
+			continue
+		}
+		if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && entry.Timestamp.After(filter.Until) {
+			continue
+		}
+		match := true
+		for _, term := range terms {
+			if !strings.Contains(entry.Text, term) {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		res = append(res, MsgSearchResult{
+			UID:       uid,
+			GC:        gc,
+			From:      entry.From,
+			Message:   entry.Message,
+			Timestamp: entry.Timestamp,
+		})
+	}
+	return res, scanner.Err()
+}
+
+// SearchMessages searches the message index for PMs and GC messages that
+// contain all the terms of the query. Results are returned from newest to
+// oldest.
+func (db *DB) SearchMessages(tx ReadTx, query string, filter MsgSearchFilter) ([]MsgSearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+
+	// Determine which index files to search.
+	type indexFile struct {
+		fname string
+		uid   *UserID
+		gc    *zkidentity.ShortID
+	}
+	var files []indexFile
+	switch {
+	case filter.UID != nil && filter.GC != nil:
+		return nil, fmt.Errorf("cannot filter by both user and GC")
+	case filter.UID != nil:
+		files = append(files, indexFile{db.msgSearchFname(filter.UID, nil), filter.UID, nil})
+	case filter.GC != nil:
+		files = append(files, indexFile{db.msgSearchFname(nil, filter.GC), nil, filter.GC})
+	default:
+		dir := filepath.Join(db.root, msgSearchDir)
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			typ, rawID, ok := strings.Cut(e.Name(), ".")
+			if !ok {
+				continue
+			}
+			var id zkidentity.ShortID
+			if err := id.FromString(rawID); err != nil {
+				continue
+			}
+			fname := filepath.Join(dir, e.Name())
+			switch typ {
+			case "pm":
+				files = append(files, indexFile{fname, &id, nil})
+			case "gc":
+				files = append(files, indexFile{fname, nil, &id})
+			}
+		}
+	}
+
+	var res []MsgSearchResult
+	for _, f := range files {
+		fileRes, err := searchIndexFile(f.fname, terms, &filter, f.uid, f.gc)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fileRes...)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Timestamp.After(res[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+	return res, nil
+}
+
+// RebuildMsgSearchIndex rebuilds the message search index from the existing
+// PM and GC message logs. Returns the number of indexed messages.
+func (db *DB) RebuildMsgSearchIndex(tx ReadWriteTx) (int, error) {
+	if db.cfg.MsgsRoot == "" {
+		return 0, fmt.Errorf("message logs are disabled")
+	}
+
+	logs, err := os.ReadDir(db.cfg.MsgsRoot)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	dir := filepath.Join(db.root, msgSearchDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, log := range logs {
+		// Log files are named [groupchat.]<name>.<id>.log.
+		fname := log.Name()
+		if log.IsDir() || !strings.HasSuffix(fname, ".log") {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(fname, ".log"), ".")
+		var id zkidentity.ShortID
+		if len(parts) < 2 || id.FromString(parts[len(parts)-1]) != nil {
+			continue
+		}
+		var uid *UserID
+		var gc *zkidentity.ShortID
+		if parts[0] == "groupchat" && len(parts) > 2 {
+			gc = &id
+		} else {
+			uid = &id
+		}
+
+		entries, err := db.readLogMsg(fname, math.MaxInt32, 0)
+		if err != nil {
+			return count, err
+		}
+		for _, e := range entries {
+			if e.Internal || e.From == "" {
+				continue
+			}
+			ts := time.Unix(e.Timestamp, 0)
+			if err := db.indexMsg(uid, gc, e.From, e.Message, ts); err != nil {
+				return count, err
+			}
+			count += 1
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,106 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// OnchainTip is a tip sent to or received from a user as an on-chain DCR
+// transaction.
+type OnchainTip struct {
+	ID  zkidentity.ShortID `json:"id"`
+	UID UserID             `json:"uid"`
+
+	// Outbound is true for tips sent by the local client.
+	Outbound bool      `json:"outbound"`
+	Atoms    int64     `json:"atoms"`
+	Created  time.Time `json:"created"`
+
+	// Address is the address generated by the receiver of the tip.
+	Address string `json:"address,omitempty"`
+
+	// TxHash is the hash of the transaction that pays the tip, once it
+	// is sent.
+	TxHash string     `json:"tx_hash,omitempty"`
+	Sent   *time.Time `json:"sent,omitempty"`
+
+	// Confirmed is set once the transaction is confirmed (for outbound
+	// tips) or once the receipt sent by the remote user is verified (for
+	// inbound tips).
+	Confirmed *time.Time `json:"confirmed,omitempty"`
+
+	// Error is set when the tip fails.
+	Error string `json:"error,omitempty"`
+
+	// GC is set for outbound tips sent on behalf of a GC and
+	// BudgetOverride is set when the tip was allowed to exceed a payment
+	// budget.
+	GC             *zkidentity.ShortID `json:"gc,omitempty"`
+	BudgetOverride bool                `json:"budget_override,omitempty"`
+}
+
+// Pending returns true if the tip has not been confirmed and has not failed.
+func (tip *OnchainTip) Pending() bool {
+	return tip.Confirmed == nil && tip.Error == ""
+}
+
+// StoreOnchainTip creates or replaces the on-chain tip with the user and ID of
+// the passed tip.
+func (db *DB) StoreOnchainTip(tx ReadWriteTx, tip OnchainTip) error {
+	fname := filepath.Join(db.root, onchainTipsDir, tip.UID.String(),
+		tip.ID.String())
+	return db.saveJsonFile(fname, &tip)
+}
+
+// GetOnchainTip returns the on-chain tip with the given ID, sent to or received
+// from the given user. Returns ErrNotFound if the tip does not exist.
+func (db *DB) GetOnchainTip(tx ReadTx, uid UserID, id zkidentity.ShortID) (OnchainTip, error) {
+	fname := filepath.Join(db.root, onchainTipsDir, uid.String(), id.String())
+	var tip OnchainTip
+	err := db.readJsonFile(fname, &tip)
+	return tip, err
+}
+
+// ListOnchainTips returns all on-chain tips, sorted by creation time.
+func (db *DB) ListOnchainTips(tx ReadTx) ([]OnchainTip, error) {
+	dir := filepath.Join(db.root, onchainTipsDir)
+	userDirs, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []OnchainTip
+	for _, userDir := range userDirs {
+		if !userDir.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, userDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			var tip OnchainTip
+			fname := filepath.Join(dir, userDir.Name(), entry.Name())
+			if err := db.readJsonFile(fname, &tip); err != nil {
+				if !errors.Is(err, ErrNotFound) {
+					db.log.Warnf("Unable to read on-chain tip %s: %v",
+						entry.Name(), err)
+				}
+				continue
+			}
+			res = append(res, tip)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
@@ -0,0 +1,54 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// PaymentReceipt is a signed receipt of a payment made to or received from a
+// user.
+type PaymentReceipt struct {
+	UID UserID `json:"uid"`
+
+	// Outbound is true for receipts of payments made by the local client.
+	Outbound bool                 `json:"outbound"`
+	Receipt  rpc.RMPaymentReceipt `json:"receipt"`
+	Stored   time.Time            `json:"stored"`
+}
+
+// StorePaymentReceipt stores the receipt with the other receipts exchanged
+// with the user. A receipt with the same payment hash and direction replaces
+// the existing one.
+func (db *DB) StorePaymentReceipt(tx ReadWriteTx, pr PaymentReceipt) error {
+	fname := filepath.Join(db.root, paymentReceiptsDir, pr.UID.String()+".json")
+	var receipts []PaymentReceipt
+	err := db.readJsonFile(fname, &receipts)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	for i := range receipts {
+		if receipts[i].Outbound == pr.Outbound &&
+			string(receipts[i].Receipt.PaymentHash) == string(pr.Receipt.PaymentHash) {
+			receipts[i] = pr
+			return db.saveJsonFile(fname, receipts)
+		}
+	}
+	receipts = append(receipts, pr)
+	return db.saveJsonFile(fname, receipts)
+}
+
+// ListPaymentReceipts lists the receipts exchanged with the user, in the order
+// they were stored.
+func (db *DB) ListPaymentReceipts(tx ReadTx, uid UserID) ([]PaymentReceipt, error) {
+	fname := filepath.Join(db.root, paymentReceiptsDir, uid.String()+".json")
+	var receipts []PaymentReceipt
+	err := db.readJsonFile(fname, &receipts)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return receipts, nil
+}
@@ -202,7 +202,13 @@ func (db *DB) StoreGeneratedTipInvoice(tx ReadWriteTx, uid UserID, invoice strin
 // ListGeneratedTipInvoices lists all invoices generated for tipping from all
 // users.
 func (db *DB) ListGeneratedTipInvoices(tx ReadTx) ([]GeneratedInvoiceForTip, error) {
-	pattern := filepath.Join(db.root, inboundDir, "*", genTipInvoicesFile)
+	return db.listTipInvoicesFiles(genTipInvoicesFile)
+}
+
+// listTipInvoicesFiles lists the invoices generated for tipping stored in the
+// given file of all users.
+func (db *DB) listTipInvoicesFiles(fileName string) ([]GeneratedInvoiceForTip, error) {
+	pattern := filepath.Join(db.root, inboundDir, "*", fileName)
 	files, err := filepath.Glob(pattern)
 	if err != nil {
 		return nil, err
@@ -322,3 +328,14 @@ func (db *DB) MarkGeneratedTipInvoiceReceived(tx ReadWriteTx, uid UserID, invoic
 	recvFname := filepath.Join(db.root, inboundDir, uid.String(), recvTipInvoicesFile)
 	return db.appendToJsonFile(recvFname, data)
 }
+
+// MarkGeneratedTipInvoiceCanceled marks an invoice generated for tipping as
+// having been canceled by the local client.
+func (db *DB) MarkGeneratedTipInvoiceCanceled(tx ReadWriteTx, uid UserID, invoice string) error {
+	data, err := db.removeFromGeneratedTipInvoice(uid, invoice)
+	if err != nil {
+		return err
+	}
+	canceledFname := filepath.Join(db.root, inboundDir, uid.String(), canceledTipInvoicesFile)
+	return db.appendToJsonFile(canceledFname, data)
+}
@@ -2,6 +2,9 @@ package clientdb
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -116,6 +119,33 @@ func (db *DB) SummarizeUserPayStats(tx ReadTx, uid UserID) ([]PayStatsSummary, e
 	return res, nil
 }
 
+// ListUserPayEvents lists all the payment events recorded for the given user.
+func (db *DB) ListUserPayEvents(tx ReadTx, uid UserID) ([]PayStatEvent, error) {
+	fname := filepath.Join(db.root, inboundDir, uid.String(), payStatsFile)
+	f, err := os.Open(fname)
+	if os.IsNotExist(err) {
+		// No stats.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []PayStatEvent
+	dec := json.NewDecoder(f)
+	for {
+		var evnt PayStatEvent
+		err := dec.Decode(&evnt)
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, evnt)
+	}
+}
+
 // ClearPayStats removes pay stats for the given user or for all users if user
 // equals nil.
 func (db *DB) ClearPayStats(tx ReadWriteTx, user *UserID) error {
@@ -157,3 +187,172 @@ func (db *DB) ClearPayStats(tx ReadWriteTx, user *UserID) error {
 
 	return nil
 }
+
+// maxPaymentReportWindows is the max number of time windows in a payment
+// report.
+const maxPaymentReportWindows = 1000
+
+// PayEventCategory returns the category of a payment event recorded with
+// RecordUserPayEvent. It returns one of "tips", "content" or "other".
+func PayEventCategory(event string) string {
+	prefix := event
+	if p := strings.Index(prefix, "."); p > -1 {
+		prefix = prefix[:p]
+	}
+	switch {
+	case prefix == "tip", prefix == "paytip", prefix == "tiponchain",
+		prefix == "paytiponchain":
+		return "tips"
+	case strings.HasPrefix(prefix, "ft"), prefix == "sendfile":
+		return "content"
+	default:
+		return "other"
+	}
+}
+
+// PayCategoryTotals are the totals sent and received in a category of
+// payments. Amounts are in milli-atoms.
+type PayCategoryTotals struct {
+	Sent     int64 `json:"sent"`
+	Received int64 `json:"received"`
+}
+
+func (ct *PayCategoryTotals) add(amount int64) {
+	if amount < 0 {
+		ct.Sent += -amount
+	} else {
+		ct.Received += amount
+	}
+}
+
+// PaymentTotals are the totals of payments, grouped by category. Amounts are
+// in milli-atoms.
+type PaymentTotals struct {
+	Tips    PayCategoryTotals `json:"tips"`
+	Content PayCategoryTotals `json:"content"`
+	Other   PayCategoryTotals `json:"other"`
+
+	// PayFees is the total amount of routing fees paid.
+	PayFees int64 `json:"pay_fees"`
+}
+
+// add adds the amounts of the event to the totals.
+func (pt *PaymentTotals) add(evnt *PayStatEvent) {
+	switch PayEventCategory(evnt.Event) {
+	case "tips":
+		pt.Tips.add(evnt.Amount)
+	case "content":
+		pt.Content.add(evnt.Amount)
+	default:
+		pt.Other.add(evnt.Amount)
+	}
+	pt.PayFees += -evnt.PayFee
+}
+
+// TotalSent returns the total amount sent, including fees.
+func (pt *PaymentTotals) TotalSent() int64 {
+	return pt.Tips.Sent + pt.Content.Sent + pt.Other.Sent + pt.PayFees
+}
+
+// TotalReceived returns the total amount received.
+func (pt *PaymentTotals) TotalReceived() int64 {
+	return pt.Tips.Received + pt.Content.Received + pt.Other.Received
+}
+
+// UserPaymentTotals are the payment totals related to a user.
+type UserPaymentTotals struct {
+	UID UserID `json:"uid"`
+	PaymentTotals
+}
+
+// PaymentReportWindow are the payment totals of a time window of a report.
+type PaymentReportWindow struct {
+	Start time.Time `json:"start"`
+	PaymentTotals
+}
+
+// PaymentReport is a report of the payments made and received during a
+// period of time.
+type PaymentReport struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// Totals are the totals of the period.
+	Totals PaymentTotals `json:"totals"`
+
+	// Users are the totals per user, ordered by most received.
+	Users []UserPaymentTotals `json:"users"`
+
+	// Windows are the totals for each window of the period, if a window
+	// duration was requested.
+	Windows []PaymentReportWindow `json:"windows"`
+}
+
+// PaymentReport aggregates the payment events recorded for all users that
+// happened in [start, end). If window is not zero, the totals are also
+// aggregated in windows of that duration starting at start.
+func (db *DB) PaymentReport(tx ReadTx, start, end time.Time, window time.Duration) (*PaymentReport, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("end of report must be after its start")
+	}
+	report := &PaymentReport{Start: start, End: end}
+	if window > 0 {
+		nb := (end.Sub(start) + window - 1) / window
+		if nb > maxPaymentReportWindows {
+			return nil, fmt.Errorf("report would have too many windows "+
+				"(%d > %d)", nb, maxPaymentReportWindows)
+		}
+		report.Windows = make([]PaymentReportWindow, nb)
+		for i := range report.Windows {
+			report.Windows[i].Start = start.Add(time.Duration(i) * window)
+		}
+	}
+
+	startTs, endTs := start.Unix(), end.Unix()
+	pattern := filepath.Join(db.root, inboundDir, "*", payStatsFile)
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, fname := range files {
+		var uid UserID
+		sid := filepath.Base(filepath.Dir(fname))
+		if err := uid.FromString(sid); err != nil {
+			db.log.Warnf("Not a valid user ID in pay stats dir: %s", sid)
+			continue
+		}
+
+		events, err := db.ListUserPayEvents(tx, uid)
+		if err != nil {
+			return nil, err
+		}
+		user := UserPaymentTotals{UID: uid}
+		var found bool
+		for i := range events {
+			evnt := &events[i]
+			if evnt.Timestamp < startTs || evnt.Timestamp >= endTs {
+				continue
+			}
+			found = true
+			user.add(evnt)
+			report.Totals.add(evnt)
+			if window > 0 {
+				ts := time.Unix(evnt.Timestamp, 0)
+				report.Windows[ts.Sub(start)/window].add(evnt)
+			}
+		}
+		if found {
+			report.Users = append(report.Users, user)
+		}
+	}
+
+	sort.Slice(report.Users, func(i, j int) bool {
+		ri := report.Users[i].TotalReceived()
+		rj := report.Users[j].TotalReceived()
+		if ri != rj {
+			return ri > rj
+		}
+		return report.Users[i].TotalSent() > report.Users[j].TotalSent()
+	})
+	return report, nil
+}
@@ -0,0 +1,40 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+
+	"golang.org/x/exp/slices"
+)
+
+// ListPinnedPosts returns the IDs of the posts pinned by the local client, in
+// the order they were pinned.
+func (db *DB) ListPinnedPosts(tx ReadTx) ([]PostID, error) {
+	fname := filepath.Join(db.root, pinnedPostsFile)
+	var pinned []PostID
+	err := db.readJsonFile(fname, &pinned)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return pinned, nil
+}
+
+// PinPost pins (or unpins) the given post created by the local client. Pinning
+// an already pinned post is a noop.
+func (db *DB) PinPost(tx ReadWriteTx, pid PostID, pin bool) error {
+	pinned, err := db.ListPinnedPosts(tx)
+	if err != nil {
+		return err
+	}
+	i := slices.Index(pinned, pid)
+	switch {
+	case pin && i > -1, !pin && i < 0:
+		return nil
+	case pin:
+		pinned = append(pinned, pid)
+	default:
+		pinned = slices.Delete(pinned, i, i+1)
+	}
+	fname := filepath.Join(db.root, pinnedPostsFile)
+	return db.saveJsonFile(fname, pinned)
+}
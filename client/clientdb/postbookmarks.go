@@ -0,0 +1,122 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// PostBookmark is a local bookmark of a received post or of one of its
+// comments.
+type PostBookmark struct {
+	// PostFrom is the user from which the post was received.
+	PostFrom UserID `json:"post_from"`
+
+	// Post is the ID of the post.
+	Post PostID `json:"post"`
+
+	// Comment is the ID of the status update of the bookmarked comment. It
+	// is nil when the post itself is bookmarked.
+	Comment *clientintf.ID `json:"comment,omitempty"`
+
+	// Title is the title of the post and Excerpt is the text of the
+	// bookmarked comment, stored so that bookmarks may be searched
+	// without reading the posts.
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt,omitempty"`
+
+	// AuthorNick is the nick of the author of the post or comment.
+	AuthorNick string `json:"author_nick,omitempty"`
+
+	Tags    []string  `json:"tags,omitempty"`
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// key returns the key of the bookmark in the db.
+func (bm *PostBookmark) key() string {
+	return postBookmarkKey(bm.PostFrom, bm.Post, bm.Comment)
+}
+
+// postBookmarkKey returns the key of the bookmark of a post or comment.
+func postBookmarkKey(postFrom UserID, pid PostID, comment *clientintf.ID) string {
+	key := postFrom.String() + "/" + pid.String()
+	if comment != nil {
+		key += "/" + comment.String()
+	}
+	return key
+}
+
+// readPostBookmarks reads the post bookmarks. The map is keyed by the
+// bookmark key.
+func (db *DB) readPostBookmarks() (map[string]PostBookmark, error) {
+	fname := filepath.Join(db.root, postBookmarksFile)
+	bookmarks := make(map[string]PostBookmark)
+	err := db.readJsonFile(fname, &bookmarks)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// StorePostBookmark stores the bookmark, replacing an existing bookmark of the
+// same post or comment.
+func (db *DB) StorePostBookmark(tx ReadWriteTx, bm PostBookmark) error {
+	bookmarks, err := db.readPostBookmarks()
+	if err != nil {
+		return err
+	}
+	bookmarks[bm.key()] = bm
+	fname := filepath.Join(db.root, postBookmarksFile)
+	return db.saveJsonFile(fname, bookmarks)
+}
+
+// GetPostBookmark returns the bookmark of the post or comment. Returns
+// ErrNotFound if there is no such bookmark.
+func (db *DB) GetPostBookmark(tx ReadTx, postFrom UserID, pid PostID, comment *clientintf.ID) (PostBookmark, error) {
+	bookmarks, err := db.readPostBookmarks()
+	if err != nil {
+		return PostBookmark{}, err
+	}
+	bm, ok := bookmarks[postBookmarkKey(postFrom, pid, comment)]
+	if !ok {
+		return bm, fmt.Errorf("bookmark of post %s: %w", pid, ErrNotFound)
+	}
+	return bm, nil
+}
+
+// RemovePostBookmark removes the bookmark of the post or comment. Returns
+// ErrNotFound if there is no such bookmark.
+func (db *DB) RemovePostBookmark(tx ReadWriteTx, postFrom UserID, pid PostID, comment *clientintf.ID) error {
+	bookmarks, err := db.readPostBookmarks()
+	if err != nil {
+		return err
+	}
+	key := postBookmarkKey(postFrom, pid, comment)
+	if _, ok := bookmarks[key]; !ok {
+		return fmt.Errorf("bookmark of post %s: %w", pid, ErrNotFound)
+	}
+	delete(bookmarks, key)
+	fname := filepath.Join(db.root, postBookmarksFile)
+	return db.saveJsonFile(fname, bookmarks)
+}
+
+// ListPostBookmarks lists all post bookmarks, from newest to oldest.
+func (db *DB) ListPostBookmarks(tx ReadTx) ([]PostBookmark, error) {
+	bookmarks, err := db.readPostBookmarks()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PostBookmark, 0, len(bookmarks))
+	for _, bm := range bookmarks {
+		res = append(res, bm)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.After(res[j].Created)
+	})
+	return res, nil
+}
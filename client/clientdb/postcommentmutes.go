@@ -0,0 +1,66 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// readPostCommentMutes reads the list of users muted from commenting on the
+// local client's posts. The map is keyed by the string ID of the user and
+// the value is the time the user was muted.
+func (db *DB) readPostCommentMutes() (map[string]time.Time, error) {
+	fname := filepath.Join(db.root, postCommentMutesFile)
+	mutes := make(map[string]time.Time)
+	err := db.readJsonFile(fname, &mutes)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return mutes, nil
+}
+
+// MutePostCommenter mutes (or unmutes) the user from commenting on the local
+// client's posts.
+func (db *DB) MutePostCommenter(tx ReadWriteTx, uid UserID, mute bool) error {
+	mutes, err := db.readPostCommentMutes()
+	if err != nil {
+		return err
+	}
+	if !mute {
+		delete(mutes, uid.String())
+	} else if _, ok := mutes[uid.String()]; !ok {
+		mutes[uid.String()] = time.Now()
+	}
+	fname := filepath.Join(db.root, postCommentMutesFile)
+	return db.saveJsonFile(fname, mutes)
+}
+
+// IsPostCommenterMuted returns true if the user is muted from commenting on
+// the local client's posts.
+func (db *DB) IsPostCommenterMuted(tx ReadTx, uid UserID) (bool, error) {
+	mutes, err := db.readPostCommentMutes()
+	if err != nil {
+		return false, err
+	}
+	_, ok := mutes[uid.String()]
+	return ok, nil
+}
+
+// ListMutedPostCommenters returns the users muted from commenting on the
+// local client's posts, along with the time they were muted.
+func (db *DB) ListMutedPostCommenters(tx ReadTx) (map[UserID]time.Time, error) {
+	mutes, err := db.readPostCommentMutes()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[UserID]time.Time, len(mutes))
+	for k, v := range mutes {
+		var id UserID
+		if err := id.FromString(k); err != nil {
+			db.log.Warnf("Invalid user ID %q in post comment mutes", k)
+			continue
+		}
+		res[id] = v
+	}
+	return res, nil
+}
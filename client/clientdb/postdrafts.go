@@ -0,0 +1,87 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// PostDraft is a post that has not been published yet. If PublishAt is set,
+// the draft is published automatically at that time.
+type PostDraft struct {
+	ID          zkidentity.ShortID `json:"id"`
+	Post        string             `json:"post"`
+	Descr       string             `json:"descr"`
+	Created     time.Time          `json:"created"`
+	LastUpdated time.Time          `json:"last_updated"`
+	PublishAt   *time.Time         `json:"publish_at,omitempty"`
+}
+
+// StorePostDraft creates or replaces the post draft with the ID of the passed
+// draft.
+func (db *DB) StorePostDraft(tx ReadWriteTx, draft PostDraft) (PostDraft, error) {
+	fname := filepath.Join(db.root, postDraftsDir, draft.ID.String())
+	var old PostDraft
+	err := db.readJsonFile(fname, &old)
+	now := time.Now()
+	switch {
+	case errors.Is(err, ErrNotFound):
+		draft.Created = now
+	case err != nil:
+		return draft, err
+	default:
+		draft.Created = old.Created
+	}
+	draft.LastUpdated = now
+	return draft, db.saveJsonFile(fname, &draft)
+}
+
+// GetPostDraft returns the post draft with the given ID. Returns ErrNotFound
+// if the draft does not exist.
+func (db *DB) GetPostDraft(tx ReadTx, id zkidentity.ShortID) (PostDraft, error) {
+	fname := filepath.Join(db.root, postDraftsDir, id.String())
+	var draft PostDraft
+	err := db.readJsonFile(fname, &draft)
+	return draft, err
+}
+
+// RemovePostDraft removes the post draft with the given ID.
+func (db *DB) RemovePostDraft(tx ReadWriteTx, id zkidentity.ShortID) error {
+	fname := filepath.Join(db.root, postDraftsDir, id.String())
+	if !fileExists(fname) {
+		return ErrNotFound
+	}
+	return os.Remove(fname)
+}
+
+// ListPostDrafts returns all post drafts, sorted by creation time.
+func (db *DB) ListPostDrafts(tx ReadTx) ([]PostDraft, error) {
+	dir := filepath.Join(db.root, postDraftsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]PostDraft, 0, len(entries))
+	for _, entry := range entries {
+		var draft PostDraft
+		fname := filepath.Join(dir, entry.Name())
+		if err := db.readJsonFile(fname, &draft); err != nil {
+			db.log.Warnf("Unable to read post draft %s: %v",
+				entry.Name(), err)
+			continue
+		}
+		res = append(res, draft)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
@@ -0,0 +1,64 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// ImportedPost records a local post created by importing an item from an
+// external source (such as a blog feed).
+type ImportedPost struct {
+	// ImportID is the unique identifier of the item in the external
+	// source.
+	ImportID string `json:"import_id"`
+
+	// Post is the ID of the local post created from the item.
+	Post PostID `json:"post"`
+
+	// Timestamp is the time the item was imported.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// readImportedPosts reads the list of imported posts. The map is keyed by the
+// import ID.
+func (db *DB) readImportedPosts() (map[string]ImportedPost, error) {
+	fname := filepath.Join(db.root, importedPostsFile)
+	imported := make(map[string]ImportedPost)
+	err := db.readJsonFile(fname, &imported)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// GetImportedPost returns the record of the post imported with the given
+// import ID. Returns ErrNotFound if no such post was imported.
+func (db *DB) GetImportedPost(tx ReadTx, importID string) (ImportedPost, error) {
+	imported, err := db.readImportedPosts()
+	if err != nil {
+		return ImportedPost{}, err
+	}
+	ip, ok := imported[importID]
+	if !ok {
+		return ip, fmt.Errorf("imported post %q: %w", importID, ErrNotFound)
+	}
+	return ip, nil
+}
+
+// RecordImportedPost records that the given post was created by importing
+// the item with the given import ID.
+func (db *DB) RecordImportedPost(tx ReadWriteTx, importID string, pid PostID) error {
+	imported, err := db.readImportedPosts()
+	if err != nil {
+		return err
+	}
+	imported[importID] = ImportedPost{
+		ImportID:  importID,
+		Post:      pid,
+		Timestamp: time.Now(),
+	}
+	fname := filepath.Join(db.root, importedPostsFile)
+	return db.saveJsonFile(fname, imported)
+}
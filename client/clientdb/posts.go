@@ -48,11 +48,14 @@ func PostSummFromMetadata(post *rpc.PostMetadata, from UserID) PostSummary {
 		title = title[:maxTitleLen]
 	}
 	return PostSummary{
-		ID:         pid,
-		From:       from,
-		AuthorID:   authorID,
-		AuthorNick: authorNick,
-		Title:      title,
+		ID:           pid,
+		From:         from,
+		AuthorID:     authorID,
+		AuthorNick:   authorNick,
+		Title:        title,
+		Tags:         rpc.ParsePostTags(post.Attributes[rpc.RMPTags]),
+		RelayComment: post.Attributes[rpc.RMPRelayComment],
+		Pinned:       post.Attributes[rpc.RMPPinned] != "",
 	}
 }
 
@@ -330,6 +333,28 @@ func (db *DB) verifyPostStatusUpdate(statusFname string, from UserID, pid PostID
 				return fmt.Errorf("%w: empty comment", ErrPostStatusValidation)
 			}
 
+		case rpc.RMPSReaction, rpc.RMPSReactionRemove:
+			if strings.TrimSpace(v) == "" {
+				return fmt.Errorf("%w: empty reaction", ErrPostStatusValidation)
+			}
+			if len(v) > rpc.MaxReactionLen {
+				return fmt.Errorf("%w: reaction is too long (%d > %d)",
+					ErrPostStatusValidation, len(v), rpc.MaxReactionLen)
+			}
+
+		case rpc.RMPSModeration:
+			switch v {
+			case rpc.RMPSModerationHide, rpc.RMPSModerationUnhide:
+			case rpc.RMPSModerationFlag, rpc.RMPSModerationUnflag:
+			default:
+				return fmt.Errorf("%w: unknown moderation action %q",
+					ErrPostStatusValidation, v)
+			}
+			if _, ok := attr[rpc.RMPSModerationTarget]; !ok {
+				return fmt.Errorf("%w: moderation without target",
+					ErrPostStatusValidation)
+			}
+
 		case rpc.RMPSignature, rpc.RMPNonce, rpc.RMPFromNick, rpc.RMPTimestamp:
 			// Ignore.
 
@@ -339,7 +364,8 @@ func (db *DB) verifyPostStatusUpdate(statusFname string, from UserID, pid PostID
 					ErrPostStatusValidation, rpc.RMPVersion, err)
 			}
 
-		case rpc.RMPIdentifier, rpc.RMPStatusFrom, rpc.RMPParent:
+		case rpc.RMPIdentifier, rpc.RMPStatusFrom, rpc.RMPParent,
+			rpc.RMPSModerationTarget:
 			// Ensure a valid ID.
 			var id clientintf.ID
 			if err := id.FromString(v); err != nil {
@@ -370,9 +396,16 @@ func (db *DB) verifyPostStatusUpdate(statusFname string, from UserID, pid PostID
 	fromStr := from.String()
 	_, hearting := attr[rpc.RMPSHeart]
 	_, commenting := attr[rpc.RMPSComment]
+	addReaction, removeReaction := attr[rpc.RMPSReaction], attr[rpc.RMPSReactionRemove]
+	reacting := addReaction != "" || removeReaction != ""
+	if addReaction != "" && removeReaction != "" {
+		return fmt.Errorf("%w: cannot add and remove a reaction at the same time",
+			ErrPostStatusValidation)
+	}
 
 	var lastHeart string
 	var lastComment string
+	reactions := make(map[string]struct{})
 	hash := pms.Hash()
 
 	d := json.NewDecoder(f)
@@ -401,6 +434,14 @@ func (db *DB) verifyPostStatusUpdate(statusFname string, from UserID, pid PostID
 		if oldc, ok := old.Attributes[rpc.RMPSComment]; ok && commenting {
 			lastComment = oldc
 		}
+		if reacting {
+			if r := old.Attributes[rpc.RMPSReaction]; r != "" {
+				reactions[r] = struct{}{}
+			}
+			if r := old.Attributes[rpc.RMPSReactionRemove]; r != "" {
+				delete(reactions, r)
+			}
+		}
 	}
 
 	if hearting && lastHeart == attr[rpc.RMPSHeart] {
@@ -409,6 +450,19 @@ func (db *DB) verifyPostStatusUpdate(statusFname string, from UserID, pid PostID
 	if commenting && lastComment == attr[rpc.RMPSComment] {
 		return fmt.Errorf("%w: cannot send the exact same comment twice", ErrPostStatusValidation)
 	}
+	if addReaction != "" {
+		if _, ok := reactions[addReaction]; ok {
+			return fmt.Errorf("%w: cannot send the same reaction twice", ErrPostStatusValidation)
+		}
+		if len(reactions) >= rpc.MaxPostReactionsPerUser {
+			return fmt.Errorf("%w: too many reactions on post", ErrPostStatusValidation)
+		}
+	}
+	if removeReaction != "" {
+		if _, ok := reactions[removeReaction]; !ok {
+			return fmt.Errorf("%w: cannot remove reaction that was not sent", ErrPostStatusValidation)
+		}
+	}
 
 	return nil
 }
@@ -964,6 +1018,12 @@ func (db *DB) RemoveRelayedPostCopies(author UserID, pid PostID) error {
 			continue
 		}
 
+		// Keep copies relayed with commentary, as the commentary is
+		// only available in the relayed copy.
+		if p, err := db.readPost(f); err == nil && p.Attributes[rpc.RMPRelayComment] != "" {
+			continue
+		}
+
 		if err := os.Remove(f); err != nil {
 			db.log.Debugf("Unable to remove relayed post %s: %v",
 				f, err)
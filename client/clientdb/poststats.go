@@ -0,0 +1,150 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxPostSubscriberEvents is the max number of subscriber events kept in the
+// db. Older events are pruned.
+const maxPostSubscriberEvents = 10000
+
+// PostStats are the metrics tracked for a post created by the local client.
+type PostStats struct {
+	// Post is the ID of the post.
+	Post PostID `json:"post"`
+
+	// Relayers are the users observed relaying the post.
+	Relayers []UserID `json:"relayers,omitempty"`
+
+	// Comments is the number of comments made on the post by other users.
+	Comments uint64 `json:"comments"`
+
+	// Hearts is the current number of hearts on the post.
+	Hearts int64 `json:"hearts"`
+
+	// Reactions is the current number of reactions on the post.
+	Reactions int64 `json:"reactions"`
+
+	// Unlockers are the users that paid to unlock the body of the post.
+	Unlockers []UserID `json:"unlockers,omitempty"`
+
+	// UnlockAtoms is the total amount (in atoms) paid to unlock the body of
+	// the post.
+	UnlockAtoms uint64 `json:"unlock_atoms"`
+
+	// LastActivity is the time of the last update to the metrics.
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// PostSubscriberEvent is an event of a remote user subscribing or
+// unsubscribing to the posts of the local client.
+type PostSubscriberEvent struct {
+	UID        UserID    `json:"uid"`
+	Subscribed bool      `json:"subscribed"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// readPostStats reads the stats of all posts. The map is keyed by the string
+// ID of the post.
+func (db *DB) readPostStats() (map[string]PostStats, error) {
+	fname := filepath.Join(db.root, postStatsFile)
+	stats := make(map[string]PostStats)
+	err := db.readJsonFile(fname, &stats)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// UpdatePostStats calls f to modify the stats of the given post and stores the
+// result.
+func (db *DB) UpdatePostStats(tx ReadWriteTx, pid PostID, f func(stats *PostStats)) error {
+	allStats, err := db.readPostStats()
+	if err != nil {
+		return err
+	}
+	stats := allStats[pid.String()]
+	stats.Post = pid
+	f(&stats)
+	stats.LastActivity = time.Now()
+	allStats[pid.String()] = stats
+	fname := filepath.Join(db.root, postStatsFile)
+	return db.saveJsonFile(fname, allStats)
+}
+
+// GetPostStats returns the stats of the given post. Returns ErrNotFound if no
+// stats were recorded for the post.
+func (db *DB) GetPostStats(tx ReadTx, pid PostID) (PostStats, error) {
+	allStats, err := db.readPostStats()
+	if err != nil {
+		return PostStats{}, err
+	}
+	stats, ok := allStats[pid.String()]
+	if !ok {
+		return stats, fmt.Errorf("stats of post %s: %w", pid, ErrNotFound)
+	}
+	return stats, nil
+}
+
+// ListPostStats lists the stats of all posts, ordered by most recent activity.
+func (db *DB) ListPostStats(tx ReadTx) ([]PostStats, error) {
+	allStats, err := db.readPostStats()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]PostStats, 0, len(allStats))
+	for _, stats := range allStats {
+		res = append(res, stats)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastActivity.After(res[j].LastActivity)
+	})
+	return res, nil
+}
+
+// readPostSubscriberEvents reads the list of subscriber events.
+func (db *DB) readPostSubscriberEvents() ([]PostSubscriberEvent, error) {
+	fname := filepath.Join(db.root, postSubEventsFile)
+	var events []PostSubscriberEvent
+	err := db.readJsonFile(fname, &events)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RecordPostSubscriberEvent records that the given user subscribed or
+// unsubscribed to the posts of the local client.
+func (db *DB) RecordPostSubscriberEvent(tx ReadWriteTx, uid UserID, subscribed bool) error {
+	events, err := db.readPostSubscriberEvents()
+	if err != nil {
+		return err
+	}
+	events = append(events, PostSubscriberEvent{
+		UID:        uid,
+		Subscribed: subscribed,
+		Timestamp:  time.Now(),
+	})
+	if len(events) > maxPostSubscriberEvents {
+		events = events[len(events)-maxPostSubscriberEvents:]
+	}
+	fname := filepath.Join(db.root, postSubEventsFile)
+	return db.saveJsonFile(fname, events)
+}
+
+// ListPostSubscriberEvents lists the subscriber events that happened after
+// the given time, in the order they happened.
+func (db *DB) ListPostSubscriberEvents(tx ReadTx, since time.Time) ([]PostSubscriberEvent, error) {
+	events, err := db.readPostSubscriberEvents()
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(events), func(i int) bool {
+		return events[i].Timestamp.After(since)
+	})
+	return events[i:], nil
+}
@@ -0,0 +1,83 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// PostTagFilter is the local filter applied to the tags of posts received
+// from an author.
+type PostTagFilter struct {
+	// Include is the list of tags of which a post must have at least one
+	// to be accepted. If empty, posts are accepted regardless of their
+	// tags (unless excluded).
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is the list of tags that cause a post to be rejected.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsEmpty returns true if the filter does not reject any posts.
+func (f PostTagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// readPostTagFilters reads the post tag filters. The map is keyed by the
+// string ID of the author.
+func (db *DB) readPostTagFilters() (map[string]PostTagFilter, error) {
+	fname := filepath.Join(db.root, postTagFiltersFile)
+	filters := make(map[string]PostTagFilter)
+	err := db.readJsonFile(fname, &filters)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// SetPostTagFilter sets the tag filter for posts of the author. An empty filter
+// removes the existing filter.
+func (db *DB) SetPostTagFilter(tx ReadWriteTx, author UserID, filter PostTagFilter) error {
+	filters, err := db.readPostTagFilters()
+	if err != nil {
+		return err
+	}
+	if filter.IsEmpty() {
+		delete(filters, author.String())
+	} else {
+		filters[author.String()] = filter
+	}
+	fname := filepath.Join(db.root, postTagFiltersFile)
+	return db.saveJsonFile(fname, filters)
+}
+
+// GetPostTagFilter returns the tag filter for posts of the author. Returns
+// ErrNotFound if the author does not have a tag filter.
+func (db *DB) GetPostTagFilter(tx ReadTx, author UserID) (PostTagFilter, error) {
+	filters, err := db.readPostTagFilters()
+	if err != nil {
+		return PostTagFilter{}, err
+	}
+	filter, ok := filters[author.String()]
+	if !ok {
+		return filter, ErrNotFound
+	}
+	return filter, nil
+}
+
+// ListPostTagFilters returns the tag filters of all authors.
+func (db *DB) ListPostTagFilters(tx ReadTx) (map[UserID]PostTagFilter, error) {
+	filters, err := db.readPostTagFilters()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[UserID]PostTagFilter, len(filters))
+	for k, v := range filters {
+		var id UserID
+		if err := id.FromString(k); err != nil {
+			db.log.Warnf("Invalid author ID %q in post tag filters", k)
+			continue
+		}
+		res[id] = v
+	}
+	return res, nil
+}
@@ -0,0 +1,95 @@
+package clientdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PostUnlock is the record of unlocking the paywalled body of a post.
+type PostUnlock struct {
+	// Post is the ID of the post.
+	Post PostID `json:"post"`
+
+	// Author is the author of the post, who is paid to unlock its body.
+	Author UserID `json:"author"`
+
+	// FID is the ID of the file shared by the author with the body of the
+	// post.
+	FID FileID `json:"fid"`
+
+	// MaxCost is the price (in atoms) advertised in the post. Downloads of
+	// the body that cost more than this are not accepted.
+	MaxCost uint64 `json:"max_cost"`
+
+	// Requested is the time when unlocking the post was requested.
+	Requested time.Time `json:"requested"`
+
+	// Completed is the time when the body of the post was received.
+	Completed *time.Time `json:"completed,omitempty"`
+
+	// Body is the unlocked body of the post.
+	Body string `json:"body,omitempty"`
+}
+
+// StorePostUnlock stores the record of unlocking a post, replacing an
+// existing record for the same post.
+func (db *DB) StorePostUnlock(tx ReadWriteTx, unlock PostUnlock) error {
+	fname := filepath.Join(db.root, postUnlocksDir, unlock.Post.String())
+	return db.saveJsonFile(fname, &unlock)
+}
+
+// GetPostUnlock returns the unlock record of the given post. Returns
+// ErrNotFound if unlocking the post was never requested.
+func (db *DB) GetPostUnlock(tx ReadTx, pid PostID) (PostUnlock, error) {
+	fname := filepath.Join(db.root, postUnlocksDir, pid.String())
+	var unlock PostUnlock
+	if err := db.readJsonFile(fname, &unlock); err != nil {
+		return unlock, fmt.Errorf("unlock of post %s: %w", pid, err)
+	}
+	return unlock, nil
+}
+
+// findPostUnlock returns the first unlock record of a post whose body is the
+// given file shared by the given author and for which filter returns true.
+func (db *DB) findPostUnlock(author UserID, fid FileID, filter func(*PostUnlock) bool) (PostUnlock, error) {
+	dir := filepath.Join(db.root, postUnlocksDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return PostUnlock{}, err
+	}
+	for _, entry := range entries {
+		var unlock PostUnlock
+		fname := filepath.Join(dir, entry.Name())
+		if err := db.readJsonFile(fname, &unlock); err != nil {
+			db.log.Warnf("Unable to read post unlock %s: %v",
+				entry.Name(), err)
+			continue
+		}
+		if unlock.Author == author && unlock.FID == fid && filter(&unlock) {
+			return unlock, nil
+		}
+	}
+	return PostUnlock{}, fmt.Errorf("unlock of post with file %s: %w",
+		fid, ErrNotFound)
+}
+
+// FindPostUnlock returns the pending unlock record of the post whose body is
+// the given file shared by the given author. Returns ErrNotFound if the file
+// is not the body of a post being unlocked.
+func (db *DB) FindPostUnlock(tx ReadTx, author UserID, fid FileID) (PostUnlock, error) {
+	return db.findPostUnlock(author, fid, func(unlock *PostUnlock) bool {
+		return unlock.Completed == nil
+	})
+}
+
+// FindCompletedPostUnlock returns the completed unlock record of the post
+// whose body is the given file shared by the given author. For posts created
+// by the local client, this is the record stored when the post was created.
+// Returns ErrNotFound if the file is not the body of an unlocked post.
+func (db *DB) FindCompletedPostUnlock(tx ReadTx, author UserID, fid FileID) (PostUnlock, error) {
+	return db.findPostUnlock(author, fid, func(unlock *PostUnlock) bool {
+		return unlock.Completed != nil
+	})
+}
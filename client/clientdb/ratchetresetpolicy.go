@@ -0,0 +1,92 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// RatchetResetContactPolicy determines when a ratchet reset is automatically
+// requested with a contact.
+type RatchetResetContactPolicy struct {
+	// UnrepliedDays is the number of days after which a reset is requested
+	// when messages were sent to the contact but no message was received
+	// from them. Nil means the value of the default policy is used and
+	// zero disables the check.
+	UnrepliedDays *uint32 `json:"unreplied_days,omitempty"`
+
+	// FailedDecrypts is the number of consecutive messages from the
+	// contact that failed to be decrypted after which a reset is
+	// requested. Nil means the value of the default policy is used and
+	// zero disables the check.
+	FailedDecrypts *uint32 `json:"failed_decrypts,omitempty"`
+}
+
+// IsEmpty returns true if the policy does not override any setting.
+func (p RatchetResetContactPolicy) IsEmpty() bool {
+	return p.UnrepliedDays == nil && p.FailedDecrypts == nil
+}
+
+// Merge returns the policy where every setting not specified in p is replaced
+// by the corresponding setting from def.
+func (p RatchetResetContactPolicy) Merge(def RatchetResetContactPolicy) RatchetResetContactPolicy {
+	if p.UnrepliedDays == nil {
+		p.UnrepliedDays = def.UnrepliedDays
+	}
+	if p.FailedDecrypts == nil {
+		p.FailedDecrypts = def.FailedDecrypts
+	}
+	return p
+}
+
+// UnrepliedDaysLimit returns the number of unreplied days after which a
+// reset is requested or zero if this check is disabled.
+func (p RatchetResetContactPolicy) UnrepliedDaysLimit() uint32 {
+	if p.UnrepliedDays == nil {
+		return 0
+	}
+	return *p.UnrepliedDays
+}
+
+// FailedDecryptsLimit returns the number of failed decrypts after which a
+// reset is requested or zero if this check is disabled.
+func (p RatchetResetContactPolicy) FailedDecryptsLimit() uint32 {
+	if p.FailedDecrypts == nil {
+		return 0
+	}
+	return *p.FailedDecrypts
+}
+
+// RatchetResetPolicy is the policy used to automatically request ratchet
+// resets with contacts.
+type RatchetResetPolicy struct {
+	// Default is the policy for contacts without an override.
+	Default RatchetResetContactPolicy `json:"default"`
+
+	// Contacts are the per-contact overrides of the default policy. Keys
+	// are user IDs.
+	Contacts map[string]RatchetResetContactPolicy `json:"contacts,omitempty"`
+}
+
+// ForContact returns the effective policy for the given contact.
+func (p *RatchetResetPolicy) ForContact(uid UserID) RatchetResetContactPolicy {
+	return p.Contacts[uid.String()].Merge(p.Default)
+}
+
+// GetRatchetResetPolicy returns the stored ratchet reset policy. If no policy
+// has been stored yet, an empty policy (which never requests resets) is
+// returned.
+func (db *DB) GetRatchetResetPolicy(tx ReadTx) (*RatchetResetPolicy, error) {
+	filename := filepath.Join(db.root, ratchetResetPolicyFile)
+	policy := new(RatchetResetPolicy)
+	err := db.readJsonFile(filename, policy)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdateRatchetResetPolicy stores the ratchet reset policy.
+func (db *DB) UpdateRatchetResetPolicy(tx ReadWriteTx, policy *RatchetResetPolicy) error {
+	filename := filepath.Join(db.root, ratchetResetPolicyFile)
+	return db.saveJsonFile(filename, policy)
+}
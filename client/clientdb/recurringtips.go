@@ -0,0 +1,114 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// RecurringTipPeriod is the period between payments of a recurring tip.
+type RecurringTipPeriod string
+
+const (
+	RecurringTipWeekly  RecurringTipPeriod = "weekly"
+	RecurringTipMonthly RecurringTipPeriod = "monthly"
+)
+
+// Next returns the time of the payment after the one at t.
+func (p RecurringTipPeriod) Next(t time.Time) time.Time {
+	if p == RecurringTipMonthly {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 7)
+}
+
+// Valid returns true if the period is one of the supported periods.
+func (p RecurringTipPeriod) Valid() bool {
+	return p == RecurringTipWeekly || p == RecurringTipMonthly
+}
+
+// RecurringTip is a tip periodically sent to a user. The amount is either
+// denominated in DCR or in USD (converted at the exchange rate of the time of
+// each payment).
+type RecurringTip struct {
+	ID        zkidentity.ShortID `json:"id"`
+	UID       UserID             `json:"uid"`
+	DCRAmount float64            `json:"dcr_amount,omitempty"`
+	USDAmount float64            `json:"usd_amount,omitempty"`
+	Period    RecurringTipPeriod `json:"period"`
+	Created   time.Time          `json:"created"`
+	Paused    bool               `json:"paused"`
+
+	// NextPayment is when the next payment is due.
+	NextPayment time.Time `json:"next_payment"`
+
+	// LastPayment is when the last payment was started, Payments is the
+	// number of payments started and PaidAtoms is their total.
+	LastPayment time.Time `json:"last_payment"`
+	Payments    int       `json:"payments"`
+	PaidAtoms   int64     `json:"paid_atoms"`
+
+	// LastError is the error of the last attempt to start a payment and
+	// Failures is the number of consecutive failed attempts.
+	LastError string `json:"last_error,omitempty"`
+	Failures  int    `json:"failures"`
+}
+
+// StoreRecurringTip creates or replaces the recurring tip with the ID of the
+// passed tip.
+func (db *DB) StoreRecurringTip(tx ReadWriteTx, rt RecurringTip) error {
+	fname := filepath.Join(db.root, recurringTipsDir, rt.ID.String())
+	return db.saveJsonFile(fname, &rt)
+}
+
+// GetRecurringTip returns the recurring tip with the given ID. Returns
+// ErrNotFound if the recurring tip does not exist.
+func (db *DB) GetRecurringTip(tx ReadTx, id zkidentity.ShortID) (RecurringTip, error) {
+	fname := filepath.Join(db.root, recurringTipsDir, id.String())
+	var rt RecurringTip
+	err := db.readJsonFile(fname, &rt)
+	return rt, err
+}
+
+// RemoveRecurringTip removes the recurring tip with the given ID.
+func (db *DB) RemoveRecurringTip(tx ReadWriteTx, id zkidentity.ShortID) error {
+	fname := filepath.Join(db.root, recurringTipsDir, id.String())
+	if !fileExists(fname) {
+		return ErrNotFound
+	}
+	return os.Remove(fname)
+}
+
+// ListRecurringTips returns all recurring tips, sorted by creation time.
+func (db *DB) ListRecurringTips(tx ReadTx) ([]RecurringTip, error) {
+	dir := filepath.Join(db.root, recurringTipsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]RecurringTip, 0, len(entries))
+	for _, entry := range entries {
+		var rt RecurringTip
+		fname := filepath.Join(dir, entry.Name())
+		if err := db.readJsonFile(fname, &rt); err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				db.log.Warnf("Unable to read recurring tip %s: %v",
+					entry.Name(), err)
+			}
+			continue
+		}
+		res = append(res, rt)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
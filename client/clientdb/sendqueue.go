@@ -164,6 +164,16 @@ func (db *DB) RemoveUserUnackedRMWithRV(tx ReadWriteTx, uid UserID, rv RawRVID)
 	return existed, err
 }
 
+// CountUserUnackedRMs returns the number of unacked RMs of the given user.
+func (db *DB) CountUserUnackedRMs(tx ReadTx, uid UserID) (int, error) {
+	pattern := filepath.Join(db.root, inboundDir, uid.String(), unackedRMsDir, "*")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return 0, err
+	}
+	return len(matches), nil
+}
+
 // ListUnackedtUserRMs lists unacked RMs from all users.
 func (db *DB) ListUnackedUserRMs(tx ReadTx) ([]UnackedRM, error) {
 	// Find all unacked rms from each user's unackedRMs dir.
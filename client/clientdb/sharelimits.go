@@ -0,0 +1,128 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileShare identifies one of the shares of a file.
+type FileShare struct {
+	FID      FileID
+	Filename string
+
+	// UID is the user the file is shared with, or nil for the global
+	// share.
+	UID *UserID
+}
+
+// shareFilename returns the name of the file of the share of the given file
+// with the given user (or the global share if uid is nil).
+func (db *DB) shareFilename(fid FileID, uid *UserID) string {
+	shareDir := sharedContentDir
+	if uid != nil {
+		shareDir = filepath.Join(inboundDir, uid.String(), sharedContentDir)
+	}
+	return filepath.Join(db.root, shareDir, fid.String())
+}
+
+// SetShareLimits sets the limits of the share of the given file with the
+// given user (or the global share if uid is nil). If limits is nil, the limits
+// of the share are removed.
+func (db *DB) SetShareLimits(tx ReadWriteTx, fid FileID, uid *UserID, limits *ShareLimits) error {
+	if limits != nil && limits.MaxDownloads < 0 {
+		return fmt.Errorf("max downloads cannot be negative")
+	}
+
+	fname := db.shareFilename(fid, uid)
+	var sf SharedFile
+	if err := db.readJsonFile(fname, &sf); err != nil {
+		return fmt.Errorf("share of file %s: %w", fid, err)
+	}
+	sf.Limits = limits
+	return db.saveJsonFile(fname, sf)
+}
+
+// FileUploadCompleted returns true if all chunks of the given file (which has
+// nbChunks chunks) have been uploaded to the user.
+func (db *DB) FileUploadCompleted(tx ReadTx, uid UserID, fid FileID, nbChunks int) bool {
+	dir := filepath.Join(db.root, inboundDir, uid.String(), uploadsDir,
+		fid.String())
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) < nbChunks {
+		return false
+	}
+
+	uploaded := make(map[int]struct{}, nbChunks)
+	for _, entry := range entries {
+		var cup ChunkUpload
+		if err := db.readJsonFile(filepath.Join(dir, entry.Name()), &cup); err != nil {
+			continue
+		}
+		if cup.State == ChunkStateUploaded {
+			uploaded[cup.Index] = struct{}{}
+		}
+	}
+	return len(uploaded) >= nbChunks
+}
+
+// RecordSharedFileDownload records that the given user completed a download of
+// the shared file. The download is recorded in the share through which the
+// user is allowed to fetch the file. Returns the user of the share (nil for
+// the global share) and whether the share reached its download limit.
+func (db *DB) RecordSharedFileDownload(tx ReadWriteTx, fid FileID, downloader UserID) (*UserID, bool, error) {
+	// Global shares take precedence, as in GetSharedFileForUpload.
+	var shareUID *UserID
+	fname := db.shareFilename(fid, nil)
+	var sf SharedFile
+	err := db.readJsonFile(fname, &sf)
+	if errors.Is(err, ErrNotFound) {
+		shareUID = &downloader
+		fname = db.shareFilename(fid, shareUID)
+		err = db.readJsonFile(fname, &sf)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("share of file %s: %w", fid, err)
+	}
+
+	found := false
+	for _, uid := range sf.Downloads {
+		found = found || uid == downloader
+	}
+	if !found {
+		sf.Downloads = append(sf.Downloads, downloader)
+		if err := db.saveJsonFile(fname, sf); err != nil {
+			return nil, false, err
+		}
+	}
+
+	limitReached := sf.Limits != nil && sf.Limits.MaxDownloads > 0 &&
+		len(sf.Downloads) >= sf.Limits.MaxDownloads
+	return shareUID, limitReached, nil
+}
+
+// ListExpiredShares lists the shares of files that expired by the given
+// time.
+func (db *DB) ListExpiredShares(tx ReadTx, now time.Time) ([]FileShare, error) {
+	files, err := db.ListAllSharedFiles(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []FileShare
+	for _, f := range files {
+		for _, l := range f.Limits {
+			if !l.Limits.Expired(now) {
+				continue
+			}
+			res = append(res, FileShare{
+				FID:      f.SF.FID,
+				Filename: f.SF.Filename,
+				UID:      l.UID,
+			})
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,198 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileSwarmMember is a downloader of a locally shared file that opted into
+// re-sharing it with the other downloaders of the file.
+type FileSwarmMember struct {
+	UID UserID `json:"uid"`
+
+	// Chunks are the indexes of the chunks available in the member.
+	Chunks []int `json:"chunks"`
+
+	// Updated is the last time the member announced its chunks.
+	Updated time.Time `json:"updated"`
+}
+
+// swarmChunks returns the sorted, deduplicated list of the chunks. Returns an
+// error if any of the chunks is not a chunk of a file with nbChunks chunks.
+func swarmChunks(chunks []int, nbChunks int) ([]int, error) {
+	res := make([]int, 0, len(chunks))
+	seen := make(map[int]struct{}, len(chunks))
+	for _, idx := range chunks {
+		if idx < 0 || idx >= nbChunks {
+			return nil, fmt.Errorf("chunk index %d out of bounds", idx)
+		}
+		if _, ok := seen[idx]; ok {
+			continue
+		}
+		seen[idx] = struct{}{}
+		res = append(res, idx)
+	}
+	sort.Ints(res)
+	return res, nil
+}
+
+// SetFileDownloadSwarm sets whether the local client re-shares the chunks of
+// the given download with other downloaders of the file.
+func (db *DB) SetFileDownloadSwarm(tx ReadWriteTx, fd *FileDownload, swarm bool) error {
+	if swarm {
+		if fd.Metadata == nil {
+			return fmt.Errorf("metadata of download %s not received yet", fd.FID)
+		}
+		if fd.PreviewOnly || fd.IsSentFile {
+			return fmt.Errorf("download %s cannot be re-shared", fd.FID)
+		}
+	}
+
+	fd.Swarm = swarm
+	metaPath := filepath.Join(db.root, downloadingDir, fd.FID.String()+contentMetaExt)
+	return db.saveJsonFile(metaPath, fd)
+}
+
+// AvailableFileDownloadChunks returns the indexes of the chunks of the given
+// download that have already been downloaded.
+func (db *DB) AvailableFileDownloadChunks(tx ReadTx, fd *FileDownload) []int {
+	if fd.Metadata == nil {
+		return nil
+	}
+	nbChunks := len(fd.Metadata.Manifest)
+	missing := make(map[int]struct{})
+	if fd.CompletedName == "" {
+		for _, idx := range db.MissingFileDownloadChunks(tx, fd) {
+			missing[idx] = struct{}{}
+		}
+	}
+	res := make([]int, 0, nbChunks-len(missing))
+	for i := 0; i < nbChunks; i++ {
+		if _, ok := missing[i]; !ok {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// UpdateFileDownloadSwarmSource adds the given user (another downloader of the
+// file that re-shares it) as a source of the download or updates the chunks
+// available from it. Returns true if the user was not a source of the
+// download.
+func (db *DB) UpdateFileDownloadSwarmSource(tx ReadWriteTx, fd *FileDownload,
+	uid UserID, chunks []int) (bool, error) {
+
+	if fd.Metadata == nil {
+		return false, fmt.Errorf("cannot add source to download without metadata")
+	}
+	if uid == fd.UID {
+		return false, fmt.Errorf("user is the publisher of download %s", fd.FID)
+	}
+	chunks, err := swarmChunks(chunks, len(fd.Metadata.Manifest))
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for i := range fd.Sources {
+		src := &fd.Sources[i]
+		if src.UID != uid {
+			continue
+		}
+		if !src.Swarm {
+			return false, fmt.Errorf("user is already a source of "+
+				"download %s", fd.FID)
+		}
+		src.Chunks = chunks
+		found = true
+	}
+	if !found {
+		fd.Sources = append(fd.Sources, FileDownloadSource{
+			UID:    uid,
+			FID:    fd.FID,
+			Cost:   fd.Metadata.Cost,
+			Swarm:  true,
+			Chunks: chunks,
+		})
+	}
+
+	metaPath := filepath.Join(db.root, downloadingDir, fd.FID.String()+contentMetaExt)
+	return !found, db.saveJsonFile(metaPath, fd)
+}
+
+// RemoveFileDownloadSwarmSource removes the given user as a swarm source of
+// the download.
+func (db *DB) RemoveFileDownloadSwarmSource(tx ReadWriteTx, fd *FileDownload, uid UserID) error {
+	for i := range fd.Sources {
+		if fd.Sources[i].UID != uid || !fd.Sources[i].Swarm {
+			continue
+		}
+		fd.Sources = append(fd.Sources[:i], fd.Sources[i+1:]...)
+		metaPath := filepath.Join(db.root, downloadingDir, fd.FID.String()+contentMetaExt)
+		return db.saveJsonFile(metaPath, fd)
+	}
+	return fmt.Errorf("swarm source of download %s: %w", fd.FID, ErrNotFound)
+}
+
+// GetFileSwarm returns the members of the swarm of the given locally shared
+// file.
+func (db *DB) GetFileSwarm(tx ReadTx, fid FileID) ([]FileSwarmMember, error) {
+	fname := filepath.Join(db.root, fileSwarmsDir, fid.String())
+	var swarm []FileSwarmMember
+	err := db.readJsonFile(fname, &swarm)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return swarm, nil
+}
+
+// UpdateFileSwarmMember updates the chunks available in a member of the swarm
+// of a locally shared file (adding the member if needed). The file must have
+// nbChunks chunks. Returns true if the user was not a member of the swarm.
+func (db *DB) UpdateFileSwarmMember(tx ReadWriteTx, fid FileID, nbChunks int,
+	uid UserID, chunks []int) (bool, error) {
+
+	chunks, err := swarmChunks(chunks, nbChunks)
+	if err != nil {
+		return false, err
+	}
+	swarm, err := db.GetFileSwarm(tx, fid)
+	if err != nil {
+		return false, err
+	}
+
+	member := FileSwarmMember{UID: uid, Chunks: chunks, Updated: time.Now()}
+	isMember := false
+	for i := range swarm {
+		if swarm[i].UID == uid {
+			swarm[i] = member
+			isMember = true
+		}
+	}
+	if !isMember {
+		swarm = append(swarm, member)
+	}
+	fname := filepath.Join(db.root, fileSwarmsDir, fid.String())
+	return !isMember, db.saveJsonFile(fname, swarm)
+}
+
+// RemoveFileSwarmMember removes the user from the swarm of a locally shared
+// file.
+func (db *DB) RemoveFileSwarmMember(tx ReadWriteTx, fid FileID, uid UserID) error {
+	swarm, err := db.GetFileSwarm(tx, fid)
+	if err != nil {
+		return err
+	}
+	for i := range swarm {
+		if swarm[i].UID != uid {
+			continue
+		}
+		swarm = append(swarm[:i], swarm[i+1:]...)
+		fname := filepath.Join(db.root, fileSwarmsDir, fid.String())
+		return db.saveJsonFile(fname, swarm)
+	}
+	return fmt.Errorf("member of swarm of file %s: %w", fid, ErrNotFound)
+}
@@ -0,0 +1,80 @@
+package clientdb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// threadRoot returns the ID of the root message of the thread the message with
+// the given ID belongs to.
+func (db *DB) threadRoot(msgID zkidentity.ShortID) (zkidentity.ShortID, error) {
+	fname := filepath.Join(db.root, threadsIndexDir, msgID.String())
+	var root zkidentity.ShortID
+	err := db.readJsonFile(fname, &root)
+	if errors.Is(err, ErrNotFound) {
+		// Not a reply, so it is the root of its own thread.
+		return msgID, nil
+	}
+	return root, err
+}
+
+// StoreThreadReply stores a reply in the thread of the message it replies to.
+// It returns the updated thread.
+func (db *DB) StoreThreadReply(tx ReadWriteTx, gc *zkidentity.ShortID, reply ThreadMessage) (*Thread, error) {
+	if reply.MsgID == reply.ReplyTo {
+		return nil, fmt.Errorf("message %s cannot reply to itself", reply.MsgID)
+	}
+
+	root, err := db.threadRoot(reply.ReplyTo)
+	if err != nil {
+		return nil, err
+	}
+
+	fname := filepath.Join(db.root, threadsDir, root.String())
+	var thread Thread
+	err = db.readJsonFile(fname, &thread)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		thread = Thread{RootMsgID: root, GC: gc}
+	case err != nil:
+		return nil, err
+	case (thread.GC == nil) != (gc == nil) || (gc != nil && *thread.GC != *gc):
+		return nil, fmt.Errorf("message %s was replied to in a different chat", reply.ReplyTo)
+	}
+
+	for i := range thread.Replies {
+		if thread.Replies[i].MsgID == reply.MsgID {
+			// Already stored.
+			return &thread, nil
+		}
+	}
+
+	thread.Replies = append(thread.Replies, reply)
+	if err := db.saveJsonFile(fname, &thread); err != nil {
+		return nil, err
+	}
+	idxFname := filepath.Join(db.root, threadsIndexDir, reply.MsgID.String())
+	if err := db.saveJsonFile(idxFname, &root); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// ReadThread returns the thread that the message with the given ID belongs to.
+// The message may be either the root of the thread or one of its replies.
+func (db *DB) ReadThread(tx ReadTx, msgID zkidentity.ShortID) (*Thread, error) {
+	root, err := db.threadRoot(msgID)
+	if err != nil {
+		return nil, err
+	}
+
+	fname := filepath.Join(db.root, threadsDir, root.String())
+	var thread Thread
+	if err := db.readJsonFile(fname, &thread); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
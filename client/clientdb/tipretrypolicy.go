@@ -0,0 +1,116 @@
+package clientdb
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// TipRetryContactPolicy determines how attempts at tipping a user are made
+// and retried.
+type TipRetryContactPolicy struct {
+	// MaxAttempts is the max number of invoice requests made for tips
+	// that do not specify one. Nil means the value of the default policy
+	// is used.
+	MaxAttempts *int32 `json:"max_attempts,omitempty"`
+
+	// ReRequestInvoiceDelay is how long to wait before requesting a new
+	// invoice after an invoice was not received or after an attempt
+	// failed. Nil means the value of the default policy is used.
+	ReRequestInvoiceDelay *time.Duration `json:"rerequest_invoice_delay,omitempty"`
+
+	// PayRetryDelayFactor is the factor of the exponential delay for
+	// retrying a payment that failed with a retriable error. Nil means the
+	// value of the default policy is used.
+	PayRetryDelayFactor *time.Duration `json:"pay_retry_delay_factor,omitempty"`
+
+	// MaxPayRetryDelay is the max delay between retries of a payment that
+	// failed with a retriable error. Nil means the value of the default
+	// policy is used and zero means the delay is not limited.
+	MaxPayRetryDelay *time.Duration `json:"max_pay_retry_delay,omitempty"`
+
+	// MaxLifetime is the max amount of time after which a tip attempt
+	// expires. Nil means the value of the default policy is used.
+	MaxLifetime *time.Duration `json:"max_lifetime,omitempty"`
+}
+
+// IsEmpty returns true if the policy does not override any setting.
+func (p TipRetryContactPolicy) IsEmpty() bool {
+	return p.MaxAttempts == nil && p.ReRequestInvoiceDelay == nil &&
+		p.PayRetryDelayFactor == nil && p.MaxPayRetryDelay == nil &&
+		p.MaxLifetime == nil
+}
+
+// Merge returns the policy where every setting not specified in p is replaced
+// by the corresponding setting from def.
+func (p TipRetryContactPolicy) Merge(def TipRetryContactPolicy) TipRetryContactPolicy {
+	if p.MaxAttempts == nil {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.ReRequestInvoiceDelay == nil {
+		p.ReRequestInvoiceDelay = def.ReRequestInvoiceDelay
+	}
+	if p.PayRetryDelayFactor == nil {
+		p.PayRetryDelayFactor = def.PayRetryDelayFactor
+	}
+	if p.MaxPayRetryDelay == nil {
+		p.MaxPayRetryDelay = def.MaxPayRetryDelay
+	}
+	if p.MaxLifetime == nil {
+		p.MaxLifetime = def.MaxLifetime
+	}
+	return p
+}
+
+// Validate returns an error if the policy has invalid settings.
+func (p TipRetryContactPolicy) Validate() error {
+	if p.MaxAttempts != nil && *p.MaxAttempts <= 0 {
+		return errors.New("max attempts must be positive")
+	}
+	if p.ReRequestInvoiceDelay != nil && *p.ReRequestInvoiceDelay <= 0 {
+		return errors.New("invoice re-request delay must be positive")
+	}
+	if p.PayRetryDelayFactor != nil && *p.PayRetryDelayFactor <= 0 {
+		return errors.New("pay retry delay factor must be positive")
+	}
+	if p.MaxPayRetryDelay != nil && *p.MaxPayRetryDelay < 0 {
+		return errors.New("max pay retry delay cannot be negative")
+	}
+	if p.MaxLifetime != nil && *p.MaxLifetime <= 0 {
+		return errors.New("max lifetime must be positive")
+	}
+	return nil
+}
+
+// TipRetryPolicy is the policy used when tipping users.
+type TipRetryPolicy struct {
+	// Default is the policy for users without an override.
+	Default TipRetryContactPolicy `json:"default"`
+
+	// Contacts are the per-user overrides of the default policy. Keys are
+	// user IDs.
+	Contacts map[string]TipRetryContactPolicy `json:"contacts,omitempty"`
+}
+
+// ForContact returns the effective policy for the given user.
+func (p *TipRetryPolicy) ForContact(uid UserID) TipRetryContactPolicy {
+	return p.Contacts[uid.String()].Merge(p.Default)
+}
+
+// GetTipRetryPolicy returns the stored tip retry policy. If no policy has been
+// stored yet, an empty policy is returned.
+func (db *DB) GetTipRetryPolicy(tx ReadTx) (*TipRetryPolicy, error) {
+	filename := filepath.Join(db.root, tipRetryPolicyFile)
+	policy := new(TipRetryPolicy)
+	err := db.readJsonFile(filename, policy)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpdateTipRetryPolicy stores the tip retry policy.
+func (db *DB) UpdateTipRetryPolicy(tx ReadWriteTx, policy *TipRetryPolicy) error {
+	filename := filepath.Join(db.root, tipRetryPolicyFile)
+	return db.saveJsonFile(filename, policy)
+}
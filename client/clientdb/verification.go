@@ -0,0 +1,60 @@
+package clientdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ContactVerification is the record of the verification of the keys of a
+// remote user (by comparing safety numbers out of band).
+type ContactVerification struct {
+	// VerifiedAt is the time the user was marked as verified.
+	VerifiedAt time.Time `json:"verified_at"`
+
+	// KeysHash is the hash of the keys of the user at the time they were
+	// verified.
+	KeysHash string `json:"keys_hash"`
+
+	// KeyChangedAt is set when the keys of the user changed after they
+	// were verified. In that case, the verification is no longer valid.
+	KeyChangedAt *time.Time `json:"key_changed_at,omitempty"`
+}
+
+// IsVerified returns true if the user is verified and their keys did not
+// change after verification.
+func (cv *ContactVerification) IsVerified() bool {
+	return cv != nil && !cv.VerifiedAt.IsZero() && cv.KeyChangedAt == nil
+}
+
+// GetContactVerification returns the verification record of the given user.
+// Returns ErrNotFound if the user was never verified.
+func (db *DB) GetContactVerification(tx ReadTx, uid UserID) (*ContactVerification, error) {
+	filename := filepath.Join(db.root, inboundDir, uid.String(), verificationFile)
+	cv := new(ContactVerification)
+	if err := db.readJsonFile(filename, cv); err != nil {
+		return nil, err
+	}
+	return cv, nil
+}
+
+// StoreContactVerification stores the verification record of the given user.
+func (db *DB) StoreContactVerification(tx ReadWriteTx, uid UserID, cv *ContactVerification) error {
+	if !db.AddressBookEntryExists(tx, uid) {
+		return ErrNotFound
+	}
+	filename := filepath.Join(db.root, inboundDir, uid.String(), verificationFile)
+	return db.saveJsonFile(filename, cv)
+}
+
+// RemoveContactVerification removes the verification record of the given
+// user.
+func (db *DB) RemoveContactVerification(tx ReadWriteTx, uid UserID) error {
+	filename := filepath.Join(db.root, inboundDir, uid.String(), verificationFile)
+	err := os.Remove(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
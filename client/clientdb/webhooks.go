@@ -0,0 +1,74 @@
+package clientdb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// WebhookDelivery is a pending delivery of a payment event to a webhook.
+type WebhookDelivery struct {
+	ID      zkidentity.ShortID `json:"id"`
+	URL     string             `json:"url"`
+	Event   string             `json:"event"`
+	Payload json.RawMessage    `json:"payload"`
+	Created time.Time          `json:"created"`
+
+	// Attempts is the number of failed attempts to deliver the event and
+	// NextAttempt is when the next attempt is due.
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// StoreWebhookDelivery creates or replaces the webhook delivery with the ID of
+// the passed delivery.
+func (db *DB) StoreWebhookDelivery(tx ReadWriteTx, d WebhookDelivery) error {
+	fname := filepath.Join(db.root, webhookDeliveriesDir, d.ID.String())
+	return db.saveJsonFile(fname, &d)
+}
+
+// RemoveWebhookDelivery removes the webhook delivery with the given ID.
+func (db *DB) RemoveWebhookDelivery(tx ReadWriteTx, id zkidentity.ShortID) error {
+	fname := filepath.Join(db.root, webhookDeliveriesDir, id.String())
+	if !fileExists(fname) {
+		return ErrNotFound
+	}
+	return os.Remove(fname)
+}
+
+// ListWebhookDeliveries returns all pending webhook deliveries, sorted by
+// creation time.
+func (db *DB) ListWebhookDeliveries(tx ReadTx) ([]WebhookDelivery, error) {
+	dir := filepath.Join(db.root, webhookDeliveriesDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]WebhookDelivery, 0, len(entries))
+	for _, entry := range entries {
+		var d WebhookDelivery
+		fname := filepath.Join(dir, entry.Name())
+		if err := db.readJsonFile(fname, &d); err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				db.log.Warnf("Unable to read webhook delivery %s: %v",
+					entry.Name(), err)
+			}
+			continue
+		}
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res, nil
+}
@@ -95,6 +95,13 @@ type DecodedInvoice struct {
 	ID         []byte
 	MAtoms     int64
 	ExpiryTime time.Time
+
+	// Destination is the hex-encoded pubkey of the node that generated
+	// the invoice, if known.
+	Destination string
+
+	// DescriptionHash is the description hash of the invoice, if set.
+	DescriptionHash []byte
 }
 
 // isExpired is similar to IsExpired, but with a parametrized nowFunc to allow
@@ -123,6 +130,185 @@ type PaymentClient interface {
 	IsPaymentCompleted(context.Context, string) (int64, error)
 }
 
+// KeysendPaymentClient is implemented by payment clients that can send and
+// verify spontaneous (keysend) payments, which do not need an invoice.
+type KeysendPaymentClient interface {
+	// PayKeysend pays the amount to the node with the given hex-encoded
+	// pubkey. It returns the hash of the payment and the fees paid.
+	PayKeysend(ctx context.Context, dest string, amtMAtoms int64) ([]byte, int64, error)
+
+	// KeysendReceived returns the amount received by the local node in a
+	// settled keysend payment with the given hash.
+	KeysendReceived(ctx context.Context, hash []byte) (int64, error)
+}
+
+// InvoiceCancelerPaymentClient is implemented by payment clients that can
+// cancel open invoices generated by the local client.
+type InvoiceCancelerPaymentClient interface {
+	// CancelInvoice cancels the given open invoice, such that it can no
+	// longer be paid.
+	CancelInvoice(ctx context.Context, invoice string) error
+}
+
+// OnchainPaymentClient is implemented by payment clients that can send and
+// receive on-chain payments.
+type OnchainPaymentClient interface {
+	// NewOnchainAddress returns a new address of the wallet to receive
+	// on-chain payments.
+	NewOnchainAddress(ctx context.Context) (string, error)
+
+	// SendOnchain sends the amount to the address and returns the hash of
+	// the transaction.
+	SendOnchain(ctx context.Context, addr string, atoms int64) (chainhash.Hash, error)
+
+	// OnchainTxOutput returns the amount the wallet transaction pays to
+	// the address and the number of confirmations of the transaction.
+	OnchainTxOutput(ctx context.Context, tx chainhash.Hash, addr string) (int64, int32, error)
+}
+
+// HoldInvoiceState is the state of a hold invoice.
+type HoldInvoiceState string
+
+const (
+	// HoldInvoiceOpen is the state of hold invoices not yet paid.
+	HoldInvoiceOpen HoldInvoiceState = "open"
+
+	// HoldInvoiceAccepted is the state of hold invoices whose payment is
+	// held by the local node, waiting to be settled or canceled.
+	HoldInvoiceAccepted HoldInvoiceState = "accepted"
+
+	HoldInvoiceSettled  HoldInvoiceState = "settled"
+	HoldInvoiceCanceled HoldInvoiceState = "canceled"
+)
+
+// HoldInvoicePaymentClient is implemented by payment clients that can
+// generate hold invoices: invoices for a payment hash whose preimage is not
+// known by the local node, such that the payment is held until the invoice is
+// explicitly settled with the preimage or canceled.
+type HoldInvoicePaymentClient interface {
+	// AddHoldInvoice generates a hold invoice for the payment hash. The
+	// invoice may be paid during the lifetime and the payment may be held
+	// for (at least) the same time.
+	AddHoldInvoice(ctx context.Context, hash []byte, mAtoms int64,
+		memo string, lifetime time.Duration) (string, error)
+
+	// SettleHoldInvoice settles the held payment of the hold invoice for
+	// the hash of the preimage.
+	SettleHoldInvoice(ctx context.Context, preimage []byte) error
+
+	// CancelHoldInvoice cancels the hold invoice for the hash. A held
+	// payment is returned to the payer.
+	CancelHoldInvoice(ctx context.Context, hash []byte) error
+
+	// HoldInvoiceState returns the state of the hold invoice for the hash.
+	HoldInvoiceState(ctx context.Context, hash []byte) (HoldInvoiceState, error)
+}
+
+// PaymentFeeEstimate is an estimate of the routing fees of a payment.
+type PaymentFeeEstimate struct {
+	// AmountMAtoms is the amount of the payment.
+	AmountMAtoms int64
+
+	// FeeMAtoms is the routing fee of the best route currently known.
+	FeeMAtoms int64
+
+	// MaxFeeMAtoms is the max routing fee allowed for the payment. It is
+	// -1 if the fee is not limited.
+	MaxFeeMAtoms int64
+
+	// Hops is the number of hops in the route.
+	Hops int
+}
+
+// FeeEstimatorPaymentClient is implemented by payment clients that can
+// estimate routing fees before attempting a payment.
+type FeeEstimatorPaymentClient interface {
+	// EstimateInvoiceFee estimates the fee to pay the invoice. If
+	// amtMAtoms is zero, then the amount of the invoice is used.
+	EstimateInvoiceFee(ctx context.Context, invoice string, amtMAtoms int64) (PaymentFeeEstimate, error)
+
+	// EstimateNodeFee estimates the fee to send the amount to the node
+	// with the given hex-encoded pubkey.
+	EstimateNodeFee(ctx context.Context, dest string, amtMAtoms int64) (PaymentFeeEstimate, error)
+}
+
+// RebalanceRequest is a request to move funds between two channels of the
+// local LN node by making a circular payment to itself.
+type RebalanceRequest struct {
+	// OutChannel is the channel point (or a unique prefix of it) of the
+	// channel through which the payment is sent. Its local balance
+	// decreases.
+	OutChannel string
+
+	// InChannel is the channel point (or a unique prefix of it) of the
+	// channel through which the payment returns. Its local balance
+	// increases.
+	InChannel string
+
+	// AmountMAtoms is the amount to move between the channels.
+	AmountMAtoms int64
+
+	// MaxFeeMAtoms is the max routing fee to pay. If zero, the limit of
+	// the payment fee policy is used.
+	MaxFeeMAtoms int64
+}
+
+// RebalanceResult is the result of a completed rebalance.
+type RebalanceResult struct {
+	// OutChanPoint and InChanPoint are the full channel points of the
+	// channels.
+	OutChanPoint string
+	InChanPoint  string
+
+	// AmountMAtoms is the amount moved between the channels.
+	AmountMAtoms int64
+
+	// FeeMAtoms is the routing fee paid.
+	FeeMAtoms int64
+
+	// Hops is the number of hops in the route of the payment.
+	Hops int
+}
+
+// RebalancerPaymentClient is implemented by payment clients that can
+// rebalance the channels of the local node.
+type RebalancerPaymentClient interface {
+	// Rebalance moves funds between two channels of the local node.
+	Rebalance(ctx context.Context, req RebalanceRequest) (RebalanceResult, error)
+}
+
+// MultiPathProgress is the aggregate progress of a payment split into
+// multiple parts.
+type MultiPathProgress struct {
+	// AmountMAtoms is the total amount of the payment.
+	AmountMAtoms int64
+
+	// PaidMAtoms is the amount of the parts that already succeeded and
+	// InFlightMAtoms the amount of the parts still in flight.
+	PaidMAtoms     int64
+	InFlightMAtoms int64
+
+	// FeesMAtoms is the routing fees of the parts that succeeded.
+	FeesMAtoms int64
+
+	// Parts, InFlight and Failed are the number of parts (HTLCs) that
+	// succeeded, that are in flight and that failed.
+	Parts    int
+	InFlight int
+	Failed   int
+}
+
+// MultiPathPaymentClient is implemented by payment clients that can split
+// payments across multiple paths.
+type MultiPathPaymentClient interface {
+	// PayInvoiceMultiPath pays the invoice, splitting it into multiple
+	// parts when it does not fit the outbound capacity of a single
+	// channel. The progress func (if not nil) is called as the parts of a
+	// split payment are attempted. Returns the total routing fees paid.
+	PayInvoiceMultiPath(ctx context.Context, invoice string,
+		progress func(MultiPathProgress)) (int64, error)
+}
+
 // FreePaymentClient implements the PaymentClient interface for servers that
 // offer the "free" payment scheme: namely, invoices are requested but there is
 // nothing to pay for.
@@ -201,6 +387,9 @@ var (
 	ErrSubsysExiting             = errors.New("subsys exiting")
 	ErrInvoiceInsufficientlyPaid = errors.New("invoice insufficiently paid")
 	ErrInvoiceExpired            = errors.New("invoice expired")
+	ErrInvoiceCanceled           = errors.New("invoice canceled")
 	ErrOnboardNoFunds            = errors.New("onboarding invite does not have any funds")
 	ErrRetriablePayment          = errors.New("retriable payment error")
+	ErrNoRouteWithinFeeLimit     = errors.New("no route found within fee limit")
+	ErrPaymentsDisabled          = errors.New("payments are disabled")
 )
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/companyzero/bisonrelay/ratchet"
 	"github.com/companyzero/bisonrelay/sw"
@@ -40,6 +41,14 @@ func (pik PaidInviteKey) Encode() (string, error) {
 	return bech32.EncodeFromBase256("brpik", pik.key[:])
 }
 
+// EncodeQR encodes this key as an uppercase string. Uppercase strings are
+// encoded with the compact alphanumeric mode of QR codes, which results in
+// smaller codes. The string may be decoded with DecodePaidInviteKey.
+func (pik PaidInviteKey) EncodeQR() (string, error) {
+	s, err := pik.Encode()
+	return strings.ToUpper(s), err
+}
+
 // MarshalJSON marshals the id into a json string.
 func (pik PaidInviteKey) MarshalJSON() ([]byte, error) {
 	s, err := pik.Encode()
@@ -49,9 +58,11 @@ func (pik PaidInviteKey) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s)
 }
 
-// Decode the key from its string encoding.
+// Decode the key from its string encoding. Both the lowercase encoding
+// returned by Encode and the uppercase encoding returned by EncodeQR are
+// accepted.
 func (pik *PaidInviteKey) Decode(s string) error {
-	hrp, keyBytes, err := bech32.DecodeToBase256(s)
+	hrp, keyBytes, err := bech32.DecodeToBase256(strings.TrimSpace(s))
 	if err != nil {
 		return fmt.Errorf("unable to decode paid invite key: %v", err)
 	}
@@ -0,0 +1,35 @@
+package clientintf
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/internal/qrcode"
+)
+
+// TestPaidInviteKeyQR tests the QR encoding of paid invite keys.
+func TestPaidInviteKeyQR(t *testing.T) {
+	pik := GeneratePaidInviteKey()
+	s, err := pik.EncodeQR()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is decoded from the uppercase encoding, even with the
+	// surrounding whitespace commonly added by QR scanners.
+	decoded, err := DecodePaidInviteKey(" " + s + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded.key != *pik.key {
+		t.Fatalf("decoded key is not equal to original key")
+	}
+
+	// The uppercase encoding results in a small QR code.
+	code, err := qrcode.Encode(s, qrcode.LevelM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version > 4 {
+		t.Fatalf("QR code version %d is too large", code.Version)
+	}
+}
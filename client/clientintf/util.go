@@ -63,7 +63,7 @@ func ChunkIndexMatches(fm *rpc.FileMetadata, index int, hash []byte) bool {
 }
 
 // FileChunkMAtoms returns the cost to download the specified chunk from the
-// file.
+// file. Chunks entirely within the free preview of the file have zero cost.
 func FileChunkMAtoms(chunkIdx int, fm *rpc.FileMetadata) uint64 {
 	if chunkIdx >= len(fm.Manifest) {
 		return 0
@@ -76,13 +76,66 @@ func FileChunkMAtoms(chunkIdx int, fm *rpc.FileMetadata) uint64 {
 	if fileSize < 0 {
 		return 0
 	}
-	matoms := fm.Cost * chunkSize * 1000 / fileSize
+
+	var matoms uint64
+	if fm.Preview == nil && len(fm.Tiers) == 0 {
+		matoms = fm.Cost * chunkSize * 1000 / fileSize
+	} else {
+		var start uint64
+		for i := 0; i < chunkIdx; i++ {
+			start += fm.Manifest[i].Size
+		}
+		end := start + chunkSize
+		if fm.Preview != nil && end <= fm.Preview.Size {
+			// Free preview chunk.
+			return 0
+		}
+
+		if len(fm.Tiers) == 0 {
+			matoms = fm.Cost * chunkSize * 1000 / fileSize
+		}
+
+		// Add the cost of the part of the chunk in each tier.
+		var tierStart uint64
+		for _, tier := range fm.Tiers {
+			overlapStart, overlapEnd := start, end
+			if tierStart > overlapStart {
+				overlapStart = tierStart
+			}
+			if tier.End < overlapEnd {
+				overlapEnd = tier.End
+			}
+			if overlapEnd > overlapStart && tier.End > tierStart {
+				overlap := overlapEnd - overlapStart
+				matoms += tier.Cost * overlap * 1000 / (tier.End - tierStart)
+			}
+			tierStart = tier.End
+		}
+	}
 	if matoms < 1000 {
 		return 1000
 	}
 	return matoms
 }
 
+// FilePreviewChunks returns the indices of the chunks of the file that are
+// part of its free preview.
+func FilePreviewChunks(fm *rpc.FileMetadata) []int {
+	if fm.Preview == nil {
+		return nil
+	}
+	var res []int
+	var end uint64
+	for i, ch := range fm.Manifest {
+		end += ch.Size
+		if end > fm.Preview.Size {
+			break
+		}
+		res = append(res, i)
+	}
+	return res
+}
+
 // dummySigner dummy signer used for estimation.
 func dummySigner(message []byte) zkidentity.FixedSizeSignature {
 	return zkidentity.FixedSizeSignature{}
@@ -134,14 +187,15 @@ func EstimateUploadCost(size int64, feeRate uint64) (uint64, error) {
 	ftGetReply := rpc.RMFTGetReply{
 		Tag: math.MaxUint32,
 		Metadata: rpc.FileMetadata{
-			Version:   1000,
-			Cost:      10000000,
-			Size:      uint64(size),
-			Directory: randStr(10),
-			Filename:  randStr(20),
-			Hash:      randStr(64),
-			Signature: randStr(256),
-			Manifest:  make([]rpc.FileManifest, nbChunks),
+			Version:    1000,
+			Cost:       10000000,
+			Size:       uint64(size),
+			Directory:  randStr(10),
+			Filename:   randStr(20),
+			Hash:       randStr(64),
+			Signature:  randStr(256),
+			Manifest:   make([]rpc.FileManifest, nbChunks),
+			MerkleRoot: randStr(64),
 		},
 	}
 	for i := 0; i < nbChunks; i++ {
@@ -70,3 +70,74 @@ func TestPostTitle(t *testing.T) {
 		})
 	}
 }
+
+// TestFileChunkMAtoms tests the cost of file chunks with previews and tiers.
+func TestFileChunkMAtoms(t *testing.T) {
+	// File with 4 chunks of 100 bytes.
+	manifest := make([]rpc.FileManifest, 4)
+	for i := range manifest {
+		manifest[i] = rpc.FileManifest{Index: uint64(i), Size: 100}
+	}
+
+	tests := []struct {
+		name    string
+		cost    uint64
+		preview *rpc.FilePreview
+		tiers   []rpc.FilePriceTier
+		want    []uint64
+	}{{
+		name: "flat cost",
+		cost: 400,
+		want: []uint64{100000, 100000, 100000, 100000},
+	}, {
+		name: "free file",
+		want: []uint64{1000, 1000, 1000, 1000},
+	}, {
+		name:    "preview size",
+		cost:    400,
+		preview: &rpc.FilePreview{Size: 150},
+		want:    []uint64{0, 100000, 100000, 100000},
+	}, {
+		name:    "preview file",
+		cost:    400,
+		preview: &rpc.FilePreview{FID: "preview"},
+		want:    []uint64{100000, 100000, 100000, 100000},
+	}, {
+		name: "tiers",
+		cost: 1000,
+		tiers: []rpc.FilePriceTier{
+			{End: 200, Cost: 200},
+			{End: 250, Cost: 100},
+			{End: 400, Cost: 700},
+		},
+		want: []uint64{100000, 100000, 100000 + 233333, 466666},
+	}, {
+		name:    "tiers with preview",
+		cost:    800,
+		preview: &rpc.FilePreview{Size: 100},
+		tiers: []rpc.FilePriceTier{
+			{End: 100, Cost: 0},
+			{End: 400, Cost: 600},
+		},
+		want: []uint64{0, 200000, 200000, 200000},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fm := &rpc.FileMetadata{
+				Cost:     tc.cost,
+				Size:     400,
+				Manifest: manifest,
+				Preview:  tc.preview,
+				Tiers:    tc.tiers,
+			}
+			for i, want := range tc.want {
+				got := FileChunkMAtoms(i, fm)
+				if got != want {
+					t.Fatalf("chunk %d: unexpected cost: got %d, "+
+						"want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
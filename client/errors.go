@@ -3,9 +3,12 @@ package client
 import (
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/dcrutil/v4"
 )
 
 var (
@@ -14,6 +17,14 @@ var (
 	errAlreadyExists     = fmt.Errorf("already exists")
 	errUserBlocked       = fmt.Errorf("user is blocked")
 	errRMTooLarge        = errors.New("RM is too large")
+
+	// ErrRMCanceled is returned when sending an RM that was canceled
+	// through CancelPendingRM.
+	ErrRMCanceled = errors.New("RM was canceled")
+
+	// ErrUnknownUserLNNode is returned when estimating the fee of a tip to
+	// a user whose LN node is not known yet.
+	ErrUnknownUserLNNode = errors.New("LN node of user is not known")
 )
 
 type userNotFoundError struct {
@@ -29,6 +40,66 @@ func (err userNotFoundError) Is(target error) bool {
 	return ok
 }
 
+// GCSlowModeError is returned when attempting to send a message to a GC in slow
+// mode before the min interval between messages has elapsed.
+type GCSlowModeError struct {
+	// Wait is the remaining time until a new message may be sent.
+	Wait time.Duration
+}
+
+func (err GCSlowModeError) Error() string {
+	return fmt.Sprintf("GC is in slow mode (wait %s before sending a "+
+		"new message)", err.Wait.Round(time.Second))
+}
+
+func (err GCSlowModeError) Is(target error) bool {
+	_, ok := target.(GCSlowModeError)
+	return ok
+}
+
+// GCFullError is returned when attempting to add a member to a GC that has
+// reached its max number of members.
+type GCFullError struct {
+	MaxMembers uint32
+}
+
+func (err GCFullError) Error() string {
+	return fmt.Sprintf("GC is full (max %d members)", err.MaxMembers)
+}
+
+func (err GCFullError) Is(target error) bool {
+	_, ok := target.(GCFullError)
+	return ok
+}
+
+// PaymentBudgetExceededError is returned when an outbound payment would
+// exceed a configured payment budget.
+type PaymentBudgetExceededError struct {
+	Budget PaymentBudget
+
+	// Period is either "daily" or "monthly".
+	Period string
+
+	Limit  dcrutil.Amount
+	Spent  dcrutil.Amount
+	Amount dcrutil.Amount
+}
+
+func (err PaymentBudgetExceededError) Error() string {
+	scope := string(err.Budget.Scope)
+	if err.Budget.Scope != clientdb.PaymentBudgetGlobal {
+		scope += " " + err.Budget.ID.String()
+	}
+	return fmt.Sprintf("payment of %s exceeds the %s budget of %s for %s "+
+		"(%s already spent)", err.Amount, err.Period, err.Limit, scope,
+		err.Spent)
+}
+
+func (err PaymentBudgetExceededError) Is(target error) bool {
+	_, ok := target.(PaymentBudgetExceededError)
+	return ok
+}
+
 type alreadyHaveUserError struct {
 	id UserID
 }
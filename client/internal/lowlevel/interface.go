@@ -6,4 +6,9 @@ type OutboundRM interface {
 	EncryptedMsg() (RVID, []byte, error)
 	Priority() uint
 	PaidForRM(int64, int64)
+
+	// SentToServer is called when the RM has been written to the
+	// server. It may be called multiple times if the RM needs to be
+	// resent (for example, after a reconnection).
+	SentToServer()
 }
@@ -426,6 +426,7 @@ func (q *RMQ) sendToSession(ctx context.Context, rmm *rmmsg, sess clientintf.Ser
 	}
 
 	q.log.Debugf("Success sending rm %s at RV %s", rmm.orm, rmm.rv)
+	rmm.orm.SentToServer()
 
 	// Wait for server ack.
 	var ackReply interface{}
@@ -408,6 +408,8 @@ func (rm mockRM) EncryptedMsg() (RVID, []byte, error) {
 
 func (rm mockRM) PaidForRM(amount, fees int64) {}
 
+func (rm mockRM) SentToServer() {}
+
 var mockRMError = errors.New("mock RM error")
 
 type mockFailedRM struct{}
@@ -426,6 +428,8 @@ func (rm mockFailedRM) EncryptedMsg() (RVID, []byte, error) {
 
 func (rm mockFailedRM) PaidForRM(amount, fees int64) {}
 
+func (rm mockFailedRM) SentToServer() {}
+
 type mockRvMgrDB struct {
 	alwaysPaid bool
 	paid       map[RVID]struct{}
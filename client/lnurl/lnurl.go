@@ -0,0 +1,360 @@
+// Package lnurl implements paying external recipients through LNURL-pay
+// (LUD-06) and lightning addresses (LUD-16).
+//
+// Amounts in LNURL are specified in millisatoshis. On the Decred LN, they are
+// interpreted as milliatoms.
+package lnurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/bech32"
+)
+
+// maxResponseSize is the max size of responses from LNURL services.
+const maxResponseSize = 1 << 16
+
+// maxSuccessMessage is the max length of the message of a success action.
+const maxSuccessMessage = 144
+
+var addressUserRegexp = regexp.MustCompile(`^[a-z0-9\-_.+]+$`)
+
+// isOnion returns true if the host is a Tor onion service.
+func isOnion(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), ".onion")
+}
+
+// checkURL checks that the url may be used for LNURL requests. Clearnet
+// services must use https.
+func checkURL(u *url.URL) error {
+	if u.Host == "" {
+		return fmt.Errorf("url %q does not have a host", u)
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && isOnion(u.Hostname()):
+	default:
+		return fmt.Errorf("url %q must use https", u)
+	}
+	return nil
+}
+
+// ParseTarget returns the url of the LNURL-pay endpoint of the target. The
+// target may be a lightning address (user@domain), a bech32 encoded LNURL or
+// an lnurlp:// url, optionally prefixed with lightning:.
+func ParseTarget(target string) (*url.URL, error) {
+	s := strings.TrimSpace(target)
+	if len(s) > 10 && strings.EqualFold(s[:10], "lightning:") {
+		s = s[10:]
+	}
+	lower := strings.ToLower(s)
+
+	var rawURL string
+	switch {
+	case strings.HasPrefix(lower, "lnurl1"):
+		hrp, data, err := bech32.DecodeNoLimit(lower)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lnurl: %v", err)
+		}
+		if hrp != "lnurl" {
+			return nil, fmt.Errorf("invalid lnurl prefix %q", hrp)
+		}
+		b, err := bech32.ConvertBits(data, 5, 8, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lnurl data: %v", err)
+		}
+		rawURL = string(b)
+
+	case strings.HasPrefix(lower, "lnurlp://"):
+		rest := s[len("lnurlp://"):]
+		u, err := url.Parse("https://" + rest)
+		if err != nil {
+			return nil, err
+		}
+		if isOnion(u.Hostname()) {
+			u.Scheme = "http"
+		}
+		rawURL = u.String()
+
+	case strings.Contains(s, "@") && !strings.Contains(s, "/"):
+		user, domain, _ := strings.Cut(lower, "@")
+		if !addressUserRegexp.MatchString(user) {
+			return nil, fmt.Errorf("invalid user %q in lightning address", user)
+		}
+		if domain == "" || strings.ContainsAny(domain, "@?#") {
+			return nil, fmt.Errorf("invalid domain %q in lightning address", domain)
+		}
+		scheme := "https"
+		if isOnion(domain) {
+			scheme = "http"
+		}
+		rawURL = fmt.Sprintf("%s://%s/.well-known/lnurlp/%s", scheme, domain, user)
+
+	default:
+		return nil, fmt.Errorf("%q is not a lightning address or lnurl", target)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkURL(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// IsAddress returns true if the target is a lightning address.
+func IsAddress(target string) bool {
+	s := strings.TrimSpace(target)
+	if len(s) > 10 && strings.EqualFold(s[:10], "lightning:") {
+		s = s[10:]
+	}
+	lower := strings.ToLower(s)
+	return strings.Contains(s, "@") && !strings.HasPrefix(lower, "lnurl")
+}
+
+// serviceError is the response sent by LNURL services on errors.
+type serviceError struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// getJSON performs a GET request and decodes the JSON reply into v.
+func getJSON(ctx context.Context, hc *http.Client, u *url.URL, v interface{}) error {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxResponseSize {
+		return errors.New("response is too large")
+	}
+
+	var svcErr serviceError
+	if json.Unmarshal(data, &svcErr) == nil && strings.EqualFold(svcErr.Status, "ERROR") {
+		return fmt.Errorf("service error: %s", svcErr.Reason)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected http status %s", resp.Status)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// PayParams are the parameters of an LNURL-pay endpoint.
+type PayParams struct {
+	Tag            string `json:"tag"`
+	Callback       string `json:"callback"`
+	MinSendable    int64  `json:"minSendable"`
+	MaxSendable    int64  `json:"maxSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed int    `json:"commentAllowed"`
+
+	entries [][]string
+}
+
+// parseMetadata parses the metadata of the pay params. Entries that do not
+// have a string value (e.g. images) are kept with an empty value.
+func (p *PayParams) parseMetadata() error {
+	var raw [][]json.RawMessage
+	if err := json.Unmarshal([]byte(p.Metadata), &raw); err != nil {
+		return fmt.Errorf("invalid metadata: %v", err)
+	}
+	p.entries = make([][]string, 0, len(raw))
+	for _, e := range raw {
+		if len(e) < 2 {
+			return errors.New("invalid metadata entry")
+		}
+		var typ, value string
+		if err := json.Unmarshal(e[0], &typ); err != nil {
+			return fmt.Errorf("invalid metadata entry type: %v", err)
+		}
+		_ = json.Unmarshal(e[1], &value)
+		p.entries = append(p.entries, []string{typ, value})
+	}
+	return nil
+}
+
+// metadataValue returns the value of the first metadata entry of the given
+// type.
+func (p *PayParams) metadataValue(typ string) (string, bool) {
+	for _, e := range p.entries {
+		if e[0] == typ {
+			return e[1], true
+		}
+	}
+	return "", false
+}
+
+// Description returns the description of the payment, from the text/plain
+// metadata entry.
+func (p *PayParams) Description() string {
+	descr, _ := p.metadataValue("text/plain")
+	return descr
+}
+
+// MetadataHash returns the hash of the metadata, which must be the description
+// hash of the invoices generated for the pay request.
+func (p *PayParams) MetadataHash() [32]byte {
+	return sha256.Sum256([]byte(p.Metadata))
+}
+
+// validate validates the pay params returned for the target.
+func (p *PayParams) validate(target string) error {
+	if p.Tag != "payRequest" {
+		return fmt.Errorf("unsupported lnurl tag %q", p.Tag)
+	}
+	callback, err := url.Parse(p.Callback)
+	if err != nil {
+		return fmt.Errorf("invalid callback: %v", err)
+	}
+	if err := checkURL(callback); err != nil {
+		return err
+	}
+	if p.MinSendable <= 0 || p.MaxSendable < p.MinSendable {
+		return fmt.Errorf("invalid sendable range [%d, %d]",
+			p.MinSendable, p.MaxSendable)
+	}
+	if err := p.parseMetadata(); err != nil {
+		return err
+	}
+	if _, ok := p.metadataValue("text/plain"); !ok {
+		return errors.New("metadata does not have a text/plain entry")
+	}
+
+	// Lightning addresses, when present in the metadata, must be the one
+	// being paid.
+	if IsAddress(target) {
+		addr := strings.ToLower(strings.TrimSpace(target))
+		addr = strings.TrimPrefix(addr, "lightning:")
+		for _, typ := range []string{"text/identifier", "text/email"} {
+			v, ok := p.metadataValue(typ)
+			if ok && strings.ToLower(v) != addr {
+				return fmt.Errorf("metadata %s %q does not match "+
+					"address %q", typ, v, addr)
+			}
+		}
+	}
+	return nil
+}
+
+// FetchPayParams fetches and validates the pay params of the target.
+func FetchPayParams(ctx context.Context, hc *http.Client, target string) (*PayParams, error) {
+	u, err := ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	p := new(PayParams)
+	if err := getJSON(ctx, hc, u, p); err != nil {
+		return nil, err
+	}
+	if err := p.validate(target); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SuccessAction is the action to take after the payment completes.
+type SuccessAction struct {
+	Tag         string `json:"tag"`
+	Message     string `json:"message,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PayResponse is the response of the callback of an LNURL-pay endpoint.
+type PayResponse struct {
+	PR            string         `json:"pr"`
+	SuccessAction *SuccessAction `json:"successAction"`
+}
+
+// validateSuccessAction validates the success action against the callback
+// of the request.
+func validateSuccessAction(sa *SuccessAction, callback *url.URL) error {
+	switch sa.Tag {
+	case "message":
+		if len(sa.Message) > maxSuccessMessage {
+			return errors.New("success message is too long")
+		}
+	case "url":
+		u, err := url.Parse(sa.URL)
+		if err != nil {
+			return fmt.Errorf("invalid success url: %v", err)
+		}
+		if !strings.EqualFold(u.Hostname(), callback.Hostname()) {
+			return fmt.Errorf("success url host %q is not the "+
+				"callback host", u.Hostname())
+		}
+		if err := checkURL(u); err != nil {
+			return err
+		}
+	case "aes":
+		// The encrypted data needs the preimage of the payment,
+		// which is not available, so only the description is used.
+	default:
+		return fmt.Errorf("unsupported success action %q", sa.Tag)
+	}
+	return nil
+}
+
+// RequestInvoice requests an invoice for the given amount to the callback of
+// the pay params.
+func (p *PayParams) RequestInvoice(ctx context.Context, hc *http.Client,
+	amtMAtoms int64, comment string) (*PayResponse, error) {
+
+	if amtMAtoms < p.MinSendable || amtMAtoms > p.MaxSendable {
+		return nil, fmt.Errorf("amount %d out of the sendable range "+
+			"[%d, %d]", amtMAtoms, p.MinSendable, p.MaxSendable)
+	}
+	if len(comment) > p.CommentAllowed {
+		return nil, fmt.Errorf("comment length %d is greater than "+
+			"allowed length %d", len(comment), p.CommentAllowed)
+	}
+
+	u, err := url.Parse(p.Callback)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("amount", strconv.FormatInt(amtMAtoms, 10))
+	if comment != "" {
+		q.Set("comment", comment)
+	}
+	u.RawQuery = q.Encode()
+
+	res := new(PayResponse)
+	if err := getJSON(ctx, hc, u, res); err != nil {
+		return nil, err
+	}
+	if res.PR == "" {
+		return nil, errors.New("service did not return an invoice")
+	}
+	if res.SuccessAction != nil {
+		if err := validateSuccessAction(res.SuccessAction, u); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,221 @@
+package lnurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/testutils"
+	"github.com/decred/dcrd/bech32"
+)
+
+// TestParseTarget tests parsing the supported LNURL targets.
+func TestParseTarget(t *testing.T) {
+	const payURL = "https://example.com/lnurlp/bob?x=1"
+	conv, err := bech32.ConvertBits([]byte(payURL), 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := bech32.Encode("lnurl", conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"bob@example.com", "https://example.com/.well-known/lnurlp/bob"},
+		{"lightning:Bob@Example.com", "https://example.com/.well-known/lnurlp/bob"},
+		{"bob@abcdef.onion", "http://abcdef.onion/.well-known/lnurlp/bob"},
+		{encoded, payURL},
+		{strings.ToUpper(encoded), payURL},
+		{"lightning:" + encoded, payURL},
+		{"lnurlp://example.com/pay/bob", "https://example.com/pay/bob"},
+		{"lnurlp://abcdef.onion/pay", "http://abcdef.onion/pay"},
+		{"bob smith@example.com", ""},
+		{"bob@", ""},
+		{"http://example.com/pay", ""},
+		{"example.com", ""},
+		{"lnurl1invalid", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.target, func(t *testing.T) {
+			u, err := ParseTarget(tc.target)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %s", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.String() != tc.want {
+				t.Fatalf("unexpected url: got %s, want %s", u, tc.want)
+			}
+		})
+	}
+}
+
+// TestPay tests paying a lightning address.
+func TestPay(t *testing.T) {
+	var svr *httptest.Server
+	var metadata, identifier string
+	var successAction *SuccessAction
+	svr = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/lnurlp/alice":
+			json.NewEncoder(w).Encode(PayParams{
+				Tag:            "payRequest",
+				Callback:       svr.URL + "/cb",
+				MinSendable:    1000,
+				MaxSendable:    1e8,
+				Metadata:       metadata,
+				CommentAllowed: 10,
+			})
+		case "/cb":
+			amt, _ := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+			if amt == 66000 {
+				fmt.Fprintln(w, `{"status":"ERROR","reason":"no can do"}`)
+				return
+			}
+			json.NewEncoder(w).Encode(PayResponse{
+				PR:            fmt.Sprintf("invoice for %d", amt),
+				SuccessAction: successAction,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer svr.Close()
+
+	svrURL, _ := url.Parse(svr.URL)
+	addr := "alice@" + svrURL.Host
+	var descrHash []byte
+	var paid atomic.Int32
+	pc := &testutils.MockPayClient{}
+	pc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := pc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		inv.DescriptionHash = descrHash
+		return inv, nil
+	})
+	pc.HookPayInvoice(func(string) (int64, error) {
+		paid.Add(1)
+		return 10, nil
+	})
+
+	setMetadata := func(entries ...[]string) {
+		b, _ := json.Marshal(entries)
+		metadata = string(b)
+		h := sha256.Sum256(b)
+		descrHash = h[:]
+	}
+
+	tests := []struct {
+		name    string
+		setup   func()
+		amt     int64
+		comment string
+		wantErr string
+	}{{
+		name: "success",
+		setup: func() {
+			setMetadata([]string{"text/plain", "tip alice"},
+				[]string{"text/identifier", identifier})
+			successAction = &SuccessAction{Tag: "message", Message: "thanks"}
+		},
+		amt:     5000,
+		comment: "hi",
+	}, {
+		name: "wrong description hash",
+		setup: func() {
+			setMetadata([]string{"text/plain", "tip alice"})
+			descrHash = make([]byte, 32)
+		},
+		amt:     5000,
+		wantErr: "description hash",
+	}, {
+		name: "wrong identifier",
+		setup: func() {
+			setMetadata([]string{"text/plain", "tip alice"},
+				[]string{"text/identifier", "bob@example.com"})
+		},
+		amt:     5000,
+		wantErr: "does not match",
+	}, {
+		name: "missing text/plain",
+		setup: func() {
+			setMetadata([]string{"text/identifier", identifier})
+		},
+		amt:     5000,
+		wantErr: "text/plain",
+	}, {
+		name:    "amount too low",
+		setup:   func() { setMetadata([]string{"text/plain", "tip alice"}) },
+		amt:     999,
+		wantErr: "sendable range",
+	}, {
+		name:    "comment too long",
+		setup:   func() { setMetadata([]string{"text/plain", "tip alice"}) },
+		amt:     5000,
+		comment: "a long comment",
+		wantErr: "comment length",
+	}, {
+		name:    "service error",
+		setup:   func() { setMetadata([]string{"text/plain", "tip alice"}) },
+		amt:     66000,
+		wantErr: "no can do",
+	}, {
+		name: "success url of other host",
+		setup: func() {
+			setMetadata([]string{"text/plain", "tip alice"})
+			successAction = &SuccessAction{Tag: "url", URL: "https://example.com/x"}
+		},
+		amt:     5000,
+		wantErr: "success url",
+	}}
+
+	identifier = addr
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			successAction = nil
+			tc.setup()
+			paid.Store(0)
+			p, err := Pay(context.Background(), svr.Client(), pc, addr,
+				tc.amt, tc.comment)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("unexpected error: got %v, want %q",
+						err, tc.wantErr)
+				}
+				if paid.Load() != 0 {
+					t.Fatalf("invoice was paid")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if paid.Load() != 1 {
+				t.Fatalf("invoice was not paid")
+			}
+			if p.MAtoms != tc.amt || p.Fees != 10 || p.Description != "tip alice" {
+				t.Fatalf("unexpected payment: %+v", p)
+			}
+			if p.SuccessAction == nil || p.SuccessAction.Message != "thanks" {
+				t.Fatalf("unexpected success action: %+v", p.SuccessAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package lnurl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// Payment is the result of a completed LNURL payment.
+type Payment struct {
+	Target      string
+	Description string
+	MAtoms      int64
+	Fees        int64
+	Invoice     string
+	PaymentHash []byte
+	Comment     string
+
+	SuccessAction *SuccessAction
+}
+
+// Pay pays amtMAtoms to the target, which may be a lightning address or an
+// LNURL-pay link. The invoice returned by the service is validated against
+// the requested amount and the metadata of the pay request before being paid.
+func Pay(ctx context.Context, hc *http.Client, pc clientintf.PaymentClient,
+	target string, amtMAtoms int64, comment string) (*Payment, error) {
+
+	params, err := FetchPayParams(ctx, hc, target)
+	if err != nil {
+		return nil, err
+	}
+	res, err := params.RequestInvoice(ctx, hc, amtMAtoms, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := pc.DecodeInvoice(ctx, res.PR)
+	if err != nil {
+		return nil, err
+	}
+	if inv.MAtoms != amtMAtoms {
+		return nil, fmt.Errorf("invoice amount %d is not the requested "+
+			"amount %d", inv.MAtoms, amtMAtoms)
+	}
+	metaHash := params.MetadataHash()
+	if !bytes.Equal(inv.DescriptionHash, metaHash[:]) {
+		return nil, fmt.Errorf("invoice description hash does not match " +
+			"the hash of the metadata")
+	}
+	if inv.IsExpired(10 * time.Second) {
+		return nil, fmt.Errorf("invoice is expired")
+	}
+
+	fees, err := pc.PayInvoice(ctx, res.PR)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payment{
+		Target:        target,
+		Description:   params.Description(),
+		MAtoms:        amtMAtoms,
+		Fees:          fees,
+		Invoice:       res.PR,
+		PaymentHash:   inv.ID,
+		Comment:       comment,
+		SuccessAction: res.SuccessAction,
+	}, nil
+}
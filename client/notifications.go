@@ -176,6 +176,15 @@ type OnTipAttemptProgressNtfn func(ru *RemoteUser, amtMAtoms int64, completed bo
 
 func (_ OnTipAttemptProgressNtfn) typ() string { return onTipAttemptProgressNtfnType }
 
+const onTipAttemptDetailsNtfnType = "onTipAttemptDetails"
+
+// OnTipAttemptDetailsNtfn is called on the same events as
+// OnTipAttemptProgressNtfn, with the full details of the progress of the tip
+// attempt.
+type OnTipAttemptDetailsNtfn func(ru *RemoteUser, progress TipAttemptProgress)
+
+func (_ OnTipAttemptDetailsNtfn) typ() string { return onTipAttemptDetailsNtfnType }
+
 const onBlockNtfnType = "onBlock"
 
 // OnBlockNtfn is called when we blocked the specified user due to their
@@ -228,6 +237,252 @@ type OnHandshakeStageNtfn func(ru *RemoteUser, msgtype string)
 
 func (_ OnHandshakeStageNtfn) typ() string { return onHandshakeStageNtfnType }
 
+const onRatchetResetRequestedNtfnType = "onRatchetResetRequested"
+
+// OnRatchetResetRequestedNtfn is called when a ratchet reset is requested with
+// a remote user. byRemote is true if the reset was requested by the remote
+// user (through a transitive reset) instead of by the local client.
+type OnRatchetResetRequestedNtfn func(ru *RemoteUser, byRemote bool)
+
+func (_ OnRatchetResetRequestedNtfn) typ() string { return onRatchetResetRequestedNtfnType }
+
+const onMsgEditedNtfnType = "onMsgEdited"
+
+// OnMsgEditedNtfn is called when a remote user edits a PM or GC message it
+// previously sent. The GC field of the edit is filled when the edited message
+// was sent in a GC.
+type OnMsgEditedNtfn func(ru *RemoteUser, edit rpc.RMEditMessage, ts time.Time)
+
+func (_ OnMsgEditedNtfn) typ() string { return onMsgEditedNtfnType }
+
+const onGCMentionNtfnType = "onGCMention"
+
+// OnGCMentionNtfn is called when a GC message that mentions the local client
+// is received. It is called in addition to OnGCMNtfn.
+type OnGCMentionNtfn func(user *RemoteUser, gcm rpc.RMGroupMessage, ts time.Time)
+
+func (_ OnGCMentionNtfn) typ() string { return onGCMentionNtfnType }
+
+const onVoiceMsgRcvdNtfnType = "onVoiceMsgRcvd"
+
+// OnVoiceMsgRcvdNtfn is called when a voice message is received in a PM (in
+// which case gcID is nil) or GC message. This is called after the
+// corresponding PM or GC message notification and may be used by frontends
+// to play back the audio.
+type OnVoiceMsgRcvdNtfn func(ru *RemoteUser, gcID, msgID *zkidentity.ShortID,
+	vm VoiceMsg, ts time.Time)
+
+func (_ OnVoiceMsgRcvdNtfn) typ() string { return onVoiceMsgRcvdNtfnType }
+
+const onBroadcastStatusNtfnType = "onBroadcastStatus"
+
+// OnBroadcastStatusNtfn is called when the delivery status of any of the PMs
+// sent in a broadcast changes. The passed status is a copy of the aggregated
+// status of the broadcast.
+type OnBroadcastStatusNtfn func(bs BroadcastStatus)
+
+func (_ OnBroadcastStatusNtfn) typ() string { return onBroadcastStatusNtfnType }
+
+const onGCModeratorsChangedNtfnType = "onGCModeratorsChanged"
+
+// OnGCModeratorsChangedNtfn is called when the list of moderators of a GC is
+// changed.
+type OnGCModeratorsChangedNtfn func(ru *RemoteUser, gc rpc.RMGroupList, added, removed []zkidentity.ShortID)
+
+func (_ OnGCModeratorsChangedNtfn) typ() string { return onGCModeratorsChangedNtfnType }
+
+const onGCSlowModeChangedNtfnType = "onGCSlowModeChanged"
+
+// OnGCSlowModeChangedNtfn is called when the slow mode interval of a GC is
+// changed by a remote user. An interval of zero means slow mode was disabled.
+type OnGCSlowModeChangedNtfn func(ru *RemoteUser, gc rpc.RMGroupList, interval time.Duration)
+
+func (_ OnGCSlowModeChangedNtfn) typ() string { return onGCSlowModeChangedNtfnType }
+
+const onGCMetadataUpdatedNtfnType = "onGCMetadataUpdated"
+
+// OnGCMetadataUpdatedNtfn is called when a remote user updates the metadata
+// (topic, description or avatar) of a GC.
+type OnGCMetadataUpdatedNtfn func(ru *RemoteUser, gc rpc.RMGroupList, fields []GCMetadataField)
+
+func (_ OnGCMetadataUpdatedNtfn) typ() string { return onGCMetadataUpdatedNtfnType }
+
+const onGCChannelsChangedNtfnType = "onGCChannelsChanged"
+
+// OnGCChannelsChangedNtfn is called when a remote user adds or removes
+// channels of a GC.
+type OnGCChannelsChangedNtfn func(ru *RemoteUser, gc rpc.RMGroupList, added, removed []GCChannel)
+
+func (_ OnGCChannelsChangedNtfn) typ() string { return onGCChannelsChangedNtfnType }
+
+const onGCJoinRequestNtfnType = "onGCJoinRequest"
+
+// OnGCJoinRequestNtfn is called when a remote user requests to join a GC that
+// requires approval, through an invite link created by the local client. The
+// request is queued until it is approved or denied.
+type OnGCJoinRequestNtfn func(ru *RemoteUser, gc rpc.RMGroupList, req GCJoinRequest)
+
+func (_ OnGCJoinRequestNtfn) typ() string { return onGCJoinRequestNtfnType }
+
+const onDeviceSyncedNtfnType = "onDeviceSynced"
+
+// OnDeviceSyncedNtfn is called when the local client receives the state of its
+// paired primary device.
+type OnDeviceSyncedNtfn func(dev PairedDevice, sync rpc.RMDeviceSync)
+
+func (_ OnDeviceSyncedNtfn) typ() string { return onDeviceSyncedNtfnType }
+
+const onDeviceSyncUpdateNtfnType = "onDeviceSyncUpdate"
+
+// OnDeviceSyncUpdateNtfn is called when the local client receives an update of
+// the state of its paired primary device, after it has been applied.
+type OnDeviceSyncUpdateNtfn func(dev PairedDevice, update rpc.RMDeviceSyncUpdate)
+
+func (_ OnDeviceSyncUpdateNtfn) typ() string { return onDeviceSyncUpdateNtfnType }
+
+const onDeviceHandoffNtfnType = "onDeviceHandoff"
+
+// OnDeviceHandoffNtfn is called when the local client becomes the primary
+// device after receiving the handoff of the contacts and GCs of its paired
+// primary device.
+type OnDeviceHandoffNtfn func(dev PairedDevice, handoff DeviceHandoff)
+
+func (_ OnDeviceHandoffNtfn) typ() string { return onDeviceHandoffNtfnType }
+
+const onGCUnkxdMentionNtfnType = "onGCUnkxdMention"
+
+// OnGCUnkxdMentionNtfn is called when a GC admin alerts that the local client
+// was mentioned in a GC message by a member the local client has not KX'd
+// with. autoKX is true if a KX with the member is being requested.
+type OnGCUnkxdMentionNtfn func(admin *RemoteUser, mention rpc.RMGroupUnkxdMention, autoKX bool)
+
+func (_ OnGCUnkxdMentionNtfn) typ() string { return onGCUnkxdMentionNtfnType }
+
+const onIdentityMigrationReceivedNtfnType = "onIdentityMigrationReceived"
+
+// OnIdentityMigrationReceivedNtfn is called when a remote user announces they
+// moved to a new identity or that their identity is revoked (in which case,
+// mig.NewID is nil).
+type OnIdentityMigrationReceivedNtfn func(ru *RemoteUser, mig clientdb.IdentityMigration)
+
+func (_ OnIdentityMigrationReceivedNtfn) typ() string {
+	return onIdentityMigrationReceivedNtfnType
+}
+
+const onIdentityMigratedNtfnType = "onIdentityMigrated"
+
+// OnIdentityMigratedNtfn is called when the KX with the new identity of a
+// remote user that migrated identities completes and the address book is
+// updated.
+type OnIdentityMigratedNtfn func(oldID UserID, newRU *RemoteUser)
+
+func (_ OnIdentityMigratedNtfn) typ() string { return onIdentityMigratedNtfnType }
+
+const onGCHistoryReceivedNtfnType = "onGCHistoryReceived"
+
+// OnGCHistoryReceivedNtfn is called when the download of the recent history
+// of a GC, shared by a GC member after the local client joined the GC,
+// completes.
+type OnGCHistoryReceivedNtfn func(ru *RemoteUser, gcID zkidentity.ShortID, msgs []ChatExportMessage)
+
+func (_ OnGCHistoryReceivedNtfn) typ() string { return onGCHistoryReceivedNtfnType }
+
+const onGCTipSplitProgressNtfnType = "onGCTipSplitProgress"
+
+// OnGCTipSplitProgressNtfn is called when the tip to one of the members of a
+// GC tip split (see TipGCMembers) completes or fails. The split is completed
+// once split.Done() returns true.
+type OnGCTipSplitProgressNtfn func(split GCTipSplit)
+
+func (_ OnGCTipSplitProgressNtfn) typ() string { return onGCTipSplitProgressNtfnType }
+
+const onRecurringTipFailedNtfnType = "onRecurringTipFailed"
+
+// OnRecurringTipFailedNtfn is called when a due payment of a recurring tip
+// could not be started. The payment is retried later, unless the recurring tip
+// was paused after too many consecutive failures. Failures of started
+// payments are reported through OnTipAttemptProgressNtfn.
+type OnRecurringTipFailedNtfn func(rt RecurringTip, err error)
+
+func (_ OnRecurringTipFailedNtfn) typ() string { return onRecurringTipFailedNtfnType }
+
+const onOnchainTipUpdatedNtfnType = "onOnchainTipUpdated"
+
+// OnOnchainTipUpdatedNtfn is called when an on-chain tip sent to or received
+// from a user changes state: when its transaction is sent, when it is
+// confirmed and when it fails.
+type OnOnchainTipUpdatedNtfn func(ru *RemoteUser, tip OnchainTip)
+
+func (_ OnOnchainTipUpdatedNtfn) typ() string { return onOnchainTipUpdatedNtfnType }
+
+const onPaymentReceiptNtfnType = "onPaymentReceipt"
+
+// OnPaymentReceiptNtfn is called when a remote user sends a valid signed
+// receipt of a payment made to the local client.
+type OnPaymentReceiptNtfn func(ru *RemoteUser, pr PaymentReceipt)
+
+func (_ OnPaymentReceiptNtfn) typ() string { return onPaymentReceiptNtfnType }
+
+const onEscrowUpdatedNtfnType = "onEscrowUpdated"
+
+// OnEscrowUpdatedNtfn is called when an escrow where the local client is the
+// buyer or the seller changes status.
+type OnEscrowUpdatedNtfn func(ru *RemoteUser, e Escrow)
+
+func (_ OnEscrowUpdatedNtfn) typ() string { return onEscrowUpdatedNtfnType }
+
+const onTipPaymentProgressNtfnType = "onTipPaymentProgress"
+
+// OnTipPaymentProgressNtfn is called with the aggregate progress of the
+// payment of a tip that was split into multiple parts.
+type OnTipPaymentProgressNtfn func(ru *RemoteUser, tag int32, progress clientintf.MultiPathProgress)
+
+func (_ OnTipPaymentProgressNtfn) typ() string { return onTipPaymentProgressNtfnType }
+
+const onVerifiedKeyChangedNtfnType = "onVerifiedKeyChanged"
+
+// OnVerifiedKeyChangedNtfn is called when the keys of a verified contact
+// change after a KX. The contact is no longer considered verified and its
+// safety number should be compared again.
+type OnVerifiedKeyChangedNtfn func(ru *RemoteUser)
+
+func (_ OnVerifiedKeyChangedNtfn) typ() string { return onVerifiedKeyChangedNtfnType }
+
+const onAutoReplySentNtfnType = "onAutoReplySent"
+
+// OnAutoReplySentNtfn is called when an automatic reply is sent to a user.
+type OnAutoReplySentNtfn func(ru *RemoteUser, msg string)
+
+func (_ OnAutoReplySentNtfn) typ() string { return onAutoReplySentNtfnType }
+
+const onChatUnarchivedNtfnType = "onChatUnarchived"
+
+// OnChatUnarchivedNtfn is called when an archived chat (identified by the ID
+// of the user or GC) is automatically unarchived due to a new message.
+type OnChatUnarchivedNtfn func(id zkidentity.ShortID)
+
+func (_ OnChatUnarchivedNtfn) typ() string { return onChatUnarchivedNtfnType }
+
+const onMsgDeliveryStatusNtfnType = "onMsgDeliveryStatus"
+
+// OnMsgDeliveryStatusNtfn is called when the delivery status of a PM or GC
+// message sent by the local client to a remote user changes. For GC messages,
+// this is called once for every member. err is only set when the status is
+// MsgStatusFailed.
+type OnMsgDeliveryStatusNtfn func(ru *RemoteUser, msgID zkidentity.ShortID, status MsgDeliveryStatus, err error)
+
+func (_ OnMsgDeliveryStatusNtfn) typ() string { return onMsgDeliveryStatusNtfnType }
+
+const onMsgReactionNtfnType = "onMsgReaction"
+
+// OnMsgReactionNtfn is called when a remote user adds or removes a reaction to
+// a PM or GC message. reactions is the updated list of reactions to the
+// message.
+type OnMsgReactionNtfn func(ru *RemoteUser, reaction rpc.RMMessageReaction,
+	reactions *clientdb.MessageReactions, ts time.Time)
+
+func (_ OnMsgReactionNtfn) typ() string { return onMsgReactionNtfnType }
+
 const onGCWithUnkxdMemberNtfnType = "onGCWithUnkxdMember"
 
 // OnGCWithUnkxdMemberNtfn is called when attempting to send a message to a
@@ -320,6 +575,31 @@ type OnFileDownloadProgress func(user *RemoteUser, fm rpc.FileMetadata, nbMissin
 
 func (_ OnFileDownloadProgress) typ() string { return onFileDownloadProgress }
 
+const onSharedFileAutoUnshared = "onSharedFileAutoUnshared"
+
+// OnSharedFileAutoUnshared is called when a share of a file is automatically
+// removed after reaching its limits. The uid is nil for the global share.
+type OnSharedFileAutoUnshared func(sf clientdb.SharedFile, uid *UserID, reason string)
+
+func (_ OnSharedFileAutoUnshared) typ() string { return onSharedFileAutoUnshared }
+
+const onScheduledPostPublished = "onScheduledPostPublished"
+
+// OnScheduledPostPublished is called after attempting to publish a scheduled
+// post draft. If err is not nil, the draft was not published and is kept
+// without a schedule.
+type OnScheduledPostPublished func(draft clientdb.PostDraft, summ clientdb.PostSummary, err error)
+
+func (_ OnScheduledPostPublished) typ() string { return onScheduledPostPublished }
+
+const onPostUnlocked = "onPostUnlocked"
+
+// OnPostUnlocked is called when the paywalled body of a post is received from
+// its author.
+type OnPostUnlocked func(author *RemoteUser, unlock clientdb.PostUnlock)
+
+func (_ OnPostUnlocked) typ() string { return onPostUnlocked }
+
 const onRMReceived = "onRMReceived"
 
 // OnRMReceived is a notification sent whenever a remote user receives an RM.
@@ -345,6 +625,18 @@ const (
 	// ProfileUpdateAvatar is the profile field that corresponds to the
 	// user's avatar.
 	ProfileUpdateAvatar ProfileUpdateField = "avatar"
+
+	// ProfileUpdateName is the profile field that corresponds to the
+	// user's display name.
+	ProfileUpdateName ProfileUpdateField = "name"
+
+	// ProfileUpdateDescription is the profile field that corresponds to
+	// the user's profile description.
+	ProfileUpdateDescription ProfileUpdateField = "description"
+
+	// ProfileUpdateStatus is the profile field that corresponds to the
+	// user's status line.
+	ProfileUpdateStatus ProfileUpdateField = "status"
 )
 
 const onProfileUpdatedType = "onProfileChanged"
@@ -501,6 +793,38 @@ func (nmgr *NotificationManager) notifyOnGCM(user *RemoteUser, gcm rpc.RMGroupMe
 		visit(func(h OnGCMNtfn) { h(user, gcm, ts) })
 }
 
+func (nmgr *NotificationManager) notifyGCMention(user *RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) {
+	nmgr.handlers[onGCMentionNtfnType].(*handlersFor[OnGCMentionNtfn]).
+		visit(func(h OnGCMentionNtfn) { h(user, gcm, ts) })
+}
+
+func (nmgr *NotificationManager) notifyMsgDeliveryStatus(ru *RemoteUser, msgID zkidentity.ShortID, status MsgDeliveryStatus, err error) {
+	nmgr.handlers[onMsgDeliveryStatusNtfnType].(*handlersFor[OnMsgDeliveryStatusNtfn]).
+		visit(func(h OnMsgDeliveryStatusNtfn) { h(ru, msgID, status, err) })
+}
+
+func (nmgr *NotificationManager) notifyVoiceMsgRcvd(ru *RemoteUser, gcID,
+	msgID *zkidentity.ShortID, vm VoiceMsg, ts time.Time) {
+
+	nmgr.handlers[onVoiceMsgRcvdNtfnType].(*handlersFor[OnVoiceMsgRcvdNtfn]).
+		visit(func(h OnVoiceMsgRcvdNtfn) { h(ru, gcID, msgID, vm, ts) })
+}
+
+func (nmgr *NotificationManager) notifyBroadcastStatus(bs BroadcastStatus) {
+	nmgr.handlers[onBroadcastStatusNtfnType].(*handlersFor[OnBroadcastStatusNtfn]).
+		visit(func(h OnBroadcastStatusNtfn) { h(bs) })
+}
+
+func (nmgr *NotificationManager) notifyAutoReplySent(ru *RemoteUser, msg string) {
+	nmgr.handlers[onAutoReplySentNtfnType].(*handlersFor[OnAutoReplySentNtfn]).
+		visit(func(h OnAutoReplySentNtfn) { h(ru, msg) })
+}
+
+func (nmgr *NotificationManager) notifyChatUnarchived(id zkidentity.ShortID) {
+	nmgr.handlers[onChatUnarchivedNtfnType].(*handlersFor[OnChatUnarchivedNtfn]).
+		visit(func(h OnChatUnarchivedNtfn) { h(id) })
+}
+
 func (nmgr *NotificationManager) notifyOnPostRcvd(user *RemoteUser, summary clientdb.PostSummary, post rpc.PostMetadata) {
 	nmgr.handlers[onPostRcvdNtfnType].(*handlersFor[OnPostRcvdNtfn]).
 		visit(func(h OnPostRcvdNtfn) { h(user, summary, post) })
@@ -598,9 +922,107 @@ func (nmgr *NotificationManager) notifyGCAdminsChanged(ru *RemoteUser, gc rpc.RM
 		visit(func(h OnGCAdminsChangedNtfn) { h(ru, gc, added, removed) })
 }
 
-func (nmgr *NotificationManager) notifyTipAttemptProgress(ru *RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+func (nmgr *NotificationManager) notifyGCModeratorsChanged(ru *RemoteUser, gc rpc.RMGroupList,
+	added, removed []zkidentity.ShortID) {
+	nmgr.handlers[onGCModeratorsChangedNtfnType].(*handlersFor[OnGCModeratorsChangedNtfn]).
+		visit(func(h OnGCModeratorsChangedNtfn) { h(ru, gc, added, removed) })
+}
+
+func (nmgr *NotificationManager) notifyGCSlowModeChanged(ru *RemoteUser, gc rpc.RMGroupList, interval time.Duration) {
+	nmgr.handlers[onGCSlowModeChangedNtfnType].(*handlersFor[OnGCSlowModeChangedNtfn]).
+		visit(func(h OnGCSlowModeChangedNtfn) { h(ru, gc, interval) })
+}
+
+func (nmgr *NotificationManager) notifyGCMetadataUpdated(ru *RemoteUser, gc rpc.RMGroupList, fields []GCMetadataField) {
+	nmgr.handlers[onGCMetadataUpdatedNtfnType].(*handlersFor[OnGCMetadataUpdatedNtfn]).
+		visit(func(h OnGCMetadataUpdatedNtfn) { h(ru, gc, fields) })
+}
+
+func (nmgr *NotificationManager) notifyGCChannelsChanged(ru *RemoteUser, gc rpc.RMGroupList, added, removed []GCChannel) {
+	nmgr.handlers[onGCChannelsChangedNtfnType].(*handlersFor[OnGCChannelsChangedNtfn]).
+		visit(func(h OnGCChannelsChangedNtfn) { h(ru, gc, added, removed) })
+}
+
+func (nmgr *NotificationManager) notifyGCJoinRequest(ru *RemoteUser, gc rpc.RMGroupList, req GCJoinRequest) {
+	nmgr.handlers[onGCJoinRequestNtfnType].(*handlersFor[OnGCJoinRequestNtfn]).
+		visit(func(h OnGCJoinRequestNtfn) { h(ru, gc, req) })
+}
+
+func (nmgr *NotificationManager) notifyDeviceSynced(dev PairedDevice, sync rpc.RMDeviceSync) {
+	nmgr.handlers[onDeviceSyncedNtfnType].(*handlersFor[OnDeviceSyncedNtfn]).
+		visit(func(h OnDeviceSyncedNtfn) { h(dev, sync) })
+}
+
+func (nmgr *NotificationManager) notifyDeviceSyncUpdate(dev PairedDevice, update rpc.RMDeviceSyncUpdate) {
+	nmgr.handlers[onDeviceSyncUpdateNtfnType].(*handlersFor[OnDeviceSyncUpdateNtfn]).
+		visit(func(h OnDeviceSyncUpdateNtfn) { h(dev, update) })
+}
+
+func (nmgr *NotificationManager) notifyDeviceHandoff(dev PairedDevice, handoff DeviceHandoff) {
+	nmgr.handlers[onDeviceHandoffNtfnType].(*handlersFor[OnDeviceHandoffNtfn]).
+		visit(func(h OnDeviceHandoffNtfn) { h(dev, handoff) })
+}
+
+func (nmgr *NotificationManager) notifyGCUnkxdMention(admin *RemoteUser, mention rpc.RMGroupUnkxdMention, autoKX bool) {
+	nmgr.handlers[onGCUnkxdMentionNtfnType].(*handlersFor[OnGCUnkxdMentionNtfn]).
+		visit(func(h OnGCUnkxdMentionNtfn) { h(admin, mention, autoKX) })
+}
+
+func (nmgr *NotificationManager) notifyIdentityMigrationReceived(ru *RemoteUser, mig clientdb.IdentityMigration) {
+	nmgr.handlers[onIdentityMigrationReceivedNtfnType].(*handlersFor[OnIdentityMigrationReceivedNtfn]).
+		visit(func(h OnIdentityMigrationReceivedNtfn) { h(ru, mig) })
+}
+
+func (nmgr *NotificationManager) notifyIdentityMigrated(oldID UserID, newRU *RemoteUser) {
+	nmgr.handlers[onIdentityMigratedNtfnType].(*handlersFor[OnIdentityMigratedNtfn]).
+		visit(func(h OnIdentityMigratedNtfn) { h(oldID, newRU) })
+}
+
+func (nmgr *NotificationManager) notifyGCHistoryReceived(ru *RemoteUser, gcID zkidentity.ShortID, msgs []ChatExportMessage) {
+	nmgr.handlers[onGCHistoryReceivedNtfnType].(*handlersFor[OnGCHistoryReceivedNtfn]).
+		visit(func(h OnGCHistoryReceivedNtfn) { h(ru, gcID, msgs) })
+}
+
+func (nmgr *NotificationManager) notifyGCTipSplitProgress(split GCTipSplit) {
+	nmgr.handlers[onGCTipSplitProgressNtfnType].(*handlersFor[OnGCTipSplitProgressNtfn]).
+		visit(func(h OnGCTipSplitProgressNtfn) { h(split) })
+}
+
+func (nmgr *NotificationManager) notifyRecurringTipFailed(rt RecurringTip, err error) {
+	nmgr.handlers[onRecurringTipFailedNtfnType].(*handlersFor[OnRecurringTipFailedNtfn]).
+		visit(func(h OnRecurringTipFailedNtfn) { h(rt, err) })
+}
+
+func (nmgr *NotificationManager) notifyOnchainTipUpdated(ru *RemoteUser, tip OnchainTip) {
+	nmgr.handlers[onOnchainTipUpdatedNtfnType].(*handlersFor[OnOnchainTipUpdatedNtfn]).
+		visit(func(h OnOnchainTipUpdatedNtfn) { h(ru, tip) })
+}
+
+func (nmgr *NotificationManager) notifyPaymentReceipt(ru *RemoteUser, pr PaymentReceipt) {
+	nmgr.handlers[onPaymentReceiptNtfnType].(*handlersFor[OnPaymentReceiptNtfn]).
+		visit(func(h OnPaymentReceiptNtfn) { h(ru, pr) })
+}
+
+func (nmgr *NotificationManager) notifyEscrowUpdated(ru *RemoteUser, e Escrow) {
+	nmgr.handlers[onEscrowUpdatedNtfnType].(*handlersFor[OnEscrowUpdatedNtfn]).
+		visit(func(h OnEscrowUpdatedNtfn) { h(ru, e) })
+}
+
+func (nmgr *NotificationManager) notifyTipPaymentProgress(ru *RemoteUser, tag int32, p clientintf.MultiPathProgress) {
+	nmgr.handlers[onTipPaymentProgressNtfnType].(*handlersFor[OnTipPaymentProgressNtfn]).
+		visit(func(h OnTipPaymentProgressNtfn) { h(ru, tag, p) })
+}
+
+func (nmgr *NotificationManager) notifyVerifiedKeyChanged(ru *RemoteUser) {
+	nmgr.handlers[onVerifiedKeyChangedNtfnType].(*handlersFor[OnVerifiedKeyChangedNtfn]).
+		visit(func(h OnVerifiedKeyChangedNtfn) { h(ru) })
+}
+
+func (nmgr *NotificationManager) notifyTipAttemptProgress(ru *RemoteUser, p TipAttemptProgress) {
 	nmgr.handlers[onTipAttemptProgressNtfnType].(*handlersFor[OnTipAttemptProgressNtfn]).
-		visit(func(h OnTipAttemptProgressNtfn) { h(ru, amtMAtoms, completed, attempt, attemptErr, willRetry) })
+		visit(func(h OnTipAttemptProgressNtfn) { h(ru, p.AmtMAtoms, p.Completed, p.Attempt, p.Err, p.WillRetry) })
+	nmgr.handlers[onTipAttemptDetailsNtfnType].(*handlersFor[OnTipAttemptDetailsNtfn]).
+		visit(func(h OnTipAttemptDetailsNtfn) { h(ru, p) })
 }
 
 func (nmgr *NotificationManager) notifyTipUserInvoiceGenerated(ru *RemoteUser, tag uint32, invoice string) {
@@ -634,6 +1056,22 @@ func (nmgr *NotificationManager) notifyHandshakeStage(ru *RemoteUser, msgtype st
 		visit(func(h OnHandshakeStageNtfn) { h(ru, msgtype) })
 }
 
+func (nmgr *NotificationManager) notifyRatchetResetRequested(ru *RemoteUser, byRemote bool) {
+	nmgr.handlers[onRatchetResetRequestedNtfnType].(*handlersFor[OnRatchetResetRequestedNtfn]).
+		visit(func(h OnRatchetResetRequestedNtfn) { h(ru, byRemote) })
+}
+
+func (nmgr *NotificationManager) notifyMsgEdited(ru *RemoteUser, edit rpc.RMEditMessage, ts time.Time) {
+	nmgr.handlers[onMsgEditedNtfnType].(*handlersFor[OnMsgEditedNtfn]).
+		visit(func(h OnMsgEditedNtfn) { h(ru, edit, ts) })
+}
+
+func (nmgr *NotificationManager) notifyMsgReaction(ru *RemoteUser, reaction rpc.RMMessageReaction,
+	reactions *clientdb.MessageReactions, ts time.Time) {
+	nmgr.handlers[onMsgReactionNtfnType].(*handlersFor[OnMsgReactionNtfn]).
+		visit(func(h OnMsgReactionNtfn) { h(ru, reaction, reactions, ts) })
+}
+
 func (nmgr *NotificationManager) notifyGCWithUnxkdMember(gc zkidentity.ShortID, uid clientintf.UserID,
 	hasKX, hasMI bool, miCount uint32, startedMIMediator *clientintf.UserID) {
 	nmgr.handlers[onGCWithUnkxdMemberNtfnType].(*handlersFor[OnGCWithUnkxdMemberNtfn]).
@@ -690,6 +1128,21 @@ func (nmgr *NotificationManager) notifyFileDownloadProgress(user *RemoteUser, fm
 		visit(func(h OnFileDownloadProgress) { h(user, fm, nbMissingChunks) })
 }
 
+func (nmgr *NotificationManager) notifySharedFileAutoUnshared(sf clientdb.SharedFile, uid *UserID, reason string) {
+	nmgr.handlers[onSharedFileAutoUnshared].(*handlersFor[OnSharedFileAutoUnshared]).
+		visit(func(h OnSharedFileAutoUnshared) { h(sf, uid, reason) })
+}
+
+func (nmgr *NotificationManager) notifyScheduledPostPublished(draft clientdb.PostDraft, summ clientdb.PostSummary, err error) {
+	nmgr.handlers[onScheduledPostPublished].(*handlersFor[OnScheduledPostPublished]).
+		visit(func(h OnScheduledPostPublished) { h(draft, summ, err) })
+}
+
+func (nmgr *NotificationManager) notifyPostUnlocked(author *RemoteUser, unlock clientdb.PostUnlock) {
+	nmgr.handlers[onPostUnlocked].(*handlersFor[OnPostUnlocked]).
+		visit(func(h OnPostUnlocked) { h(author, unlock) })
+}
+
 func (nmgr *NotificationManager) notifyRMReceived(ru *RemoteUser, rmh *rpc.RMHeader, p interface{}, ts time.Time) {
 	nmgr.handlers[onRMReceived].(*handlersFor[OnRMReceived]).
 		visit(func(h OnRMReceived) { h(ru, rmh, p, ts) })
@@ -738,6 +1191,9 @@ func NewNotificationManager() *NotificationManager {
 			onContentListReceived:      &handlersFor[OnContentListReceived]{},
 			onFileDownloadCompleted:    &handlersFor[OnFileDownloadCompleted]{},
 			onFileDownloadProgress:     &handlersFor[OnFileDownloadProgress]{},
+			onSharedFileAutoUnshared:   &handlersFor[OnSharedFileAutoUnshared]{},
+			onScheduledPostPublished:   &handlersFor[OnScheduledPostPublished]{},
+			onPostUnlocked:             &handlersFor[OnPostUnlocked]{},
 			onServerUnwelcomeError:     &handlersFor[OnServerUnwelcomeError]{},
 
 			onKXSearchCompletedNtfnType:       &handlersFor[OnKXSearchCompleted]{},
@@ -746,13 +1202,43 @@ func NewNotificationManager() *NotificationManager {
 			onRemoteSubscriptionErrorNtfnType: &handlersFor[OnRemoteSubscriptionErrorNtfn]{},
 			onLocalClientOfflineTooLong:       &handlersFor[OnLocalClientOfflineTooLong]{},
 			onTipAttemptProgressNtfnType:      &handlersFor[OnTipAttemptProgressNtfn]{},
+			onTipAttemptDetailsNtfnType:       &handlersFor[OnTipAttemptDetailsNtfn]{},
 			onTipUserInvoiceGeneratedNtfnType: &handlersFor[OnTipUserInvoiceGeneratedNtfn]{},
 			onServerSessionChangedNtfnType:    &handlersFor[OnServerSessionChangedNtfn]{},
 			onOnboardStateChangedNtfnType:     &handlersFor[OnOnboardStateChangedNtfn]{},
 			onResourceFetchedNtfnType:         &handlersFor[OnResourceFetchedNtfn]{},
+			onRatchetResetRequestedNtfnType:   &handlersFor[OnRatchetResetRequestedNtfn]{},
+			onMsgEditedNtfnType:               &handlersFor[OnMsgEditedNtfn]{},
+			onMsgReactionNtfnType:             &handlersFor[OnMsgReactionNtfn]{},
+			onGCMentionNtfnType:               &handlersFor[OnGCMentionNtfn]{},
+			onMsgDeliveryStatusNtfnType:       &handlersFor[OnMsgDeliveryStatusNtfn]{},
+			onChatUnarchivedNtfnType:          &handlersFor[OnChatUnarchivedNtfn]{},
+			onAutoReplySentNtfnType:           &handlersFor[OnAutoReplySentNtfn]{},
+			onVoiceMsgRcvdNtfnType:            &handlersFor[OnVoiceMsgRcvdNtfn]{},
+			onBroadcastStatusNtfnType:         &handlersFor[OnBroadcastStatusNtfn]{},
+			onGCModeratorsChangedNtfnType:     &handlersFor[OnGCModeratorsChangedNtfn]{},
+			onGCSlowModeChangedNtfnType:       &handlersFor[OnGCSlowModeChangedNtfn]{},
+			onGCMetadataUpdatedNtfnType:       &handlersFor[OnGCMetadataUpdatedNtfn]{},
+			onGCChannelsChangedNtfnType:       &handlersFor[OnGCChannelsChangedNtfn]{},
+			onGCJoinRequestNtfnType:           &handlersFor[OnGCJoinRequestNtfn]{},
+			onDeviceSyncedNtfnType:            &handlersFor[OnDeviceSyncedNtfn]{},
+			onDeviceSyncUpdateNtfnType:        &handlersFor[OnDeviceSyncUpdateNtfn]{},
+			onDeviceHandoffNtfnType:           &handlersFor[OnDeviceHandoffNtfn]{},
+			onVerifiedKeyChangedNtfnType:      &handlersFor[OnVerifiedKeyChangedNtfn]{},
+			onGCUnkxdMentionNtfnType:          &handlersFor[OnGCUnkxdMentionNtfn]{},
 			onGCWithUnkxdMemberNtfnType:       &handlersFor[OnGCWithUnkxdMemberNtfn]{},
 			onMessageContentFilteredNtfType:   &handlersFor[OnMsgContentFilteredNtfn]{},
 			onUnsubscribingIdleRemoteClient:   &handlersFor[OnUnsubscribingIdleRemoteClient]{},
+
+			onIdentityMigrationReceivedNtfnType: &handlersFor[OnIdentityMigrationReceivedNtfn]{},
+			onIdentityMigratedNtfnType:          &handlersFor[OnIdentityMigratedNtfn]{},
+			onGCHistoryReceivedNtfnType:         &handlersFor[OnGCHistoryReceivedNtfn]{},
+			onGCTipSplitProgressNtfnType:        &handlersFor[OnGCTipSplitProgressNtfn]{},
+			onRecurringTipFailedNtfnType:        &handlersFor[OnRecurringTipFailedNtfn]{},
+			onOnchainTipUpdatedNtfnType:         &handlersFor[OnOnchainTipUpdatedNtfn]{},
+			onPaymentReceiptNtfnType:            &handlersFor[OnPaymentReceiptNtfn]{},
+			onEscrowUpdatedNtfnType:             &handlersFor[OnEscrowUpdatedNtfn]{},
+			onTipPaymentProgressNtfnType:        &handlersFor[OnTipPaymentProgressNtfn]{},
 		},
 	}
 }
@@ -1,12 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client/clientintf"
@@ -22,12 +25,12 @@ import (
 	"github.com/decred/dcrlnd/lnrpc/invoicesrpc"
 	"github.com/decred/dcrlnd/lnrpc/routerrpc"
 	"github.com/decred/dcrlnd/lnrpc/walletrpc"
-	"github.com/decred/dcrlnd/macaroons"
+	"github.com/decred/dcrlnd/lnrpc/wtclientrpc"
+	"github.com/decred/dcrlnd/record"
 	"github.com/decred/slog"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"gopkg.in/macaroon.v2"
 )
 
 type DcrlnPaymentClientCfg struct {
@@ -35,52 +38,80 @@ type DcrlnPaymentClientCfg struct {
 	MacaroonPath string
 	Address      string
 	Log          slog.Logger
+
+	// FeePolicy is the initial routing fee policy for payments. If empty,
+	// the default limits returned by PaymentFeeLimit are used.
+	FeePolicy PaymentFeePolicy
+
+	// MaxPaymentParts is the max number of parts into which payments of
+	// invoices that do not fit the outbound capacity of any single channel
+	// are split. Values <= 1 disable multi-path payments.
+	MaxPaymentParts uint32
+
+	// OnConnStateChange is called when the connection to dcrlnd is lost
+	// or reestablished. The connection is watched until the context passed
+	// to NewDcrlndPaymentClient is done.
+	OnConnStateChange func(connected bool)
 }
 
 // DcrlnPaymentClient implements the PaymentClient interface for servers that
 // offer the "dcrln" payment scheme.
 type DcrlnPaymentClient struct {
+	conn        *grpc.ClientConn
 	lnRpc       lnrpc.LightningClient
 	lnInvoices  invoicesrpc.InvoicesClient
 	lnUnlocker  lnrpc.WalletUnlockerClient
 	lnRouter    routerrpc.RouterClient
 	lnWallet    walletrpc.WalletKitClient
 	lnChain     chainrpc.ChainNotifierClient
+	lnWtClient  wtclientrpc.WatchtowerClientClient
 	log         slog.Logger
 	payTiming   *timestats.Tracker
 	chainParams *chaincfg.Params
+
+	maxPaymentParts uint32
+
+	feeMtx    sync.Mutex
+	feePolicy PaymentFeePolicy
 }
 
 // NewDcrlndPaymentClient creates a new payment client that can send payments
 // through dcrlnd.
 func NewDcrlndPaymentClient(ctx context.Context, cfg DcrlnPaymentClientCfg) (*DcrlnPaymentClient, error) {
+	if err := cfg.FeePolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fee policy: %v", err)
+	}
+
 	// First attempt to establish a connection to lnd's RPC sever.
 	creds, err := credentials.NewClientTLSFromFile(cfg.TLSCertPath, "")
 	if err != nil {
 		return nil, fmt.Errorf("unable to read cert file: %v", err)
 	}
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(lnKeepaliveParams),
+	}
 
-	// Load the specified macaroon file.
-	macBytes, err := os.ReadFile(cfg.MacaroonPath)
-	if err != nil {
-		return nil, err
+	log := slog.Disabled
+	if cfg.Log != nil {
+		log = cfg.Log
 	}
-	mac := &macaroon.Macaroon{}
-	if err = mac.UnmarshalBinary(macBytes); err != nil {
+
+	// Load the specified macaroon file. The macaroon is reloaded if the
+	// file is modified.
+	macCred, err := newFileMacaroonCredential(cfg.MacaroonPath, log)
+	if err != nil {
 		return nil, err
 	}
 
 	// Now we append the macaroon credentials to the dial options.
-	opts = append(
-		opts,
-		grpc.WithPerRPCCredentials(macaroons.NewMacaroonCredential(mac)),
-	)
+	opts = append(opts, grpc.WithPerRPCCredentials(macCred))
 
 	conn, err := grpc.Dial(cfg.Address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to dial to dcrlnd's gRPC server: %v", err)
 	}
+	go watchConnState(ctx, conn, log, cfg.OnConnStateChange)
 
 	// Start RPCs.
 	lnRpc := lnrpc.NewLightningClient(conn)
@@ -89,24 +120,50 @@ func NewDcrlndPaymentClient(ctx context.Context, cfg DcrlnPaymentClientCfg) (*Dc
 	lnRouter := routerrpc.NewRouterClient(conn)
 	lnWallet := walletrpc.NewWalletKitClient(conn)
 	lnChain := chainrpc.NewChainNotifierClient(conn)
-
-	log := slog.Disabled
-	if cfg.Log != nil {
-		log = cfg.Log
-	}
+	lnWtClient := wtclientrpc.NewWatchtowerClientClient(conn)
 
 	return &DcrlnPaymentClient{
+		conn:       conn,
 		lnRpc:      lnRpc,
 		lnInvoices: lnInvoices,
 		lnUnlocker: lnUnlocker,
 		lnRouter:   lnRouter,
 		lnWallet:   lnWallet,
 		lnChain:    lnChain,
+		lnWtClient: lnWtClient,
 		log:        log,
 		payTiming:  timestats.NewTracker(250),
+		feePolicy:  cfg.FeePolicy.Sorted(),
+
+		maxPaymentParts: cfg.MaxPaymentParts,
 	}, nil
 }
 
+// FeePolicy returns the current routing fee policy of the client.
+func (pc *DcrlnPaymentClient) FeePolicy() PaymentFeePolicy {
+	pc.feeMtx.Lock()
+	res := pc.feePolicy.Sorted()
+	pc.feeMtx.Unlock()
+	return res
+}
+
+// SetFeePolicy replaces the routing fee policy used for payments.
+func (pc *DcrlnPaymentClient) SetFeePolicy(policy PaymentFeePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	pc.feeMtx.Lock()
+	pc.feePolicy = policy.Sorted()
+	pc.feeMtx.Unlock()
+	return nil
+}
+
+// feeLimit returns the fee limit for a payment of the given amount, according
+// to the current fee policy.
+func (pc *DcrlnPaymentClient) feeLimit(amountMAtoms int64) *lnrpc.FeeLimit {
+	return pc.FeePolicy().FeeLimit(amountMAtoms)
+}
+
 func (pc *DcrlnPaymentClient) LNRPC() lnrpc.LightningClient {
 	return pc.lnRpc
 }
@@ -115,6 +172,10 @@ func (pc *DcrlnPaymentClient) LNWallet() walletrpc.WalletKitClient {
 	return pc.lnWallet
 }
 
+func (pc *DcrlnPaymentClient) LNWtClient() wtclientrpc.WatchtowerClientClient {
+	return pc.lnWtClient
+}
+
 func (pc *DcrlnPaymentClient) PayScheme() string {
 	return rpc.PaySchemeDCRLN
 }
@@ -148,7 +209,7 @@ func (pc *DcrlnPaymentClient) PayInvoice(ctx context.Context, invoice string) (i
 
 	sendPayReq := &lnrpc.SendRequest{
 		PaymentRequest:       invoice,
-		FeeLimit:             PaymentFeeLimit(uint64(payReq.NumMAtoms)),
+		FeeLimit:             pc.feeLimit(payReq.NumMAtoms),
 		IgnoreMaxOutboundAmt: true,
 	}
 
@@ -199,7 +260,7 @@ func (pc *DcrlnPaymentClient) PayInvoiceAmount(ctx context.Context, invoice stri
 	sendPayReq := &lnrpc.SendRequest{
 		PaymentRequest:       invoice,
 		AmtMAtoms:            amount,
-		FeeLimit:             PaymentFeeLimit(uint64(amount)),
+		FeeLimit:             pc.feeLimit(amount),
 		IgnoreMaxOutboundAmt: true,
 	}
 
@@ -320,13 +381,157 @@ func (pc *DcrlnPaymentClient) DecodeInvoice(ctx context.Context, invoice string)
 		return clientintf.DecodedInvoice{}, fmt.Errorf("unable to decode payment hash: %v", err)
 	}
 
+	var descrHash []byte
+	if payReq.DescriptionHash != "" {
+		descrHash, err = hex.DecodeString(payReq.DescriptionHash)
+		if err != nil {
+			return clientintf.DecodedInvoice{}, fmt.Errorf("unable to decode description hash: %v", err)
+		}
+	}
+
 	return clientintf.DecodedInvoice{
-		ID:         id,
-		MAtoms:     payReq.NumMAtoms,
-		ExpiryTime: time.Unix(expiryTS, 0),
+		ID:              id,
+		MAtoms:          payReq.NumMAtoms,
+		ExpiryTime:      time.Unix(expiryTS, 0),
+		Destination:     payReq.Destination,
+		DescriptionHash: descrHash,
 	}, nil
 }
 
+// PayKeysend sends a spontaneous (keysend) payment to the given node. The
+// remote node must be configured to accept keysend payments.
+func (pc *DcrlnPaymentClient) PayKeysend(ctx context.Context, dest string, amtMAtoms int64) ([]byte, int64, error) {
+	destBytes, err := hex.DecodeString(dest)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid destination node: %v", err)
+	}
+
+	var preimage [32]byte
+	if _, err := rand.Read(preimage[:]); err != nil {
+		return nil, 0, err
+	}
+	hash := sha256.Sum256(preimage[:])
+
+	pc.log.Debugf("Attempting to keysend %d MAtoms to %s, hash %x",
+		amtMAtoms, dest, hash)
+
+	sendPayReq := &lnrpc.SendRequest{
+		Dest:        destBytes,
+		AmtMAtoms:   amtMAtoms,
+		PaymentHash: hash[:],
+		FeeLimit:    pc.feeLimit(amtMAtoms),
+		DestCustomRecords: map[uint64][]byte{
+			record.KeySendType: preimage[:],
+		},
+		DestFeatures:         []lnrpc.FeatureBit{lnrpc.FeatureBit_TLV_ONION_OPT},
+		IgnoreMaxOutboundAmt: true,
+	}
+
+	start := time.Now()
+	sendPayRes, err := pc.lnRpc.SendPaymentSync(ctx, sendPayReq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to complete keysend payment: %v", err)
+	}
+	if sendPayRes.PaymentError != "" {
+		return nil, 0, fmt.Errorf("keysend payment error: %s", sendPayRes.PaymentError)
+	}
+	pc.payTiming.Add(time.Since(start))
+
+	fees := sendPayRes.PaymentRoute.TotalFeesMAtoms
+	pc.log.Debugf("completed keysend payment of hash %x fees %d hops %d",
+		hash, fees, len(sendPayRes.PaymentRoute.Hops))
+
+	return hash[:], fees, nil
+}
+
+// estimateRouteFee queries the best route for the payment and returns its
+// fee.
+func (pc *DcrlnPaymentClient) estimateRouteFee(ctx context.Context,
+	req *lnrpc.QueryRoutesRequest) (clientintf.PaymentFeeEstimate, error) {
+
+	policy := pc.FeePolicy()
+	req.FeeLimit = policy.FeeLimit(req.AmtMAtoms)
+	req.UseMissionControl = true
+	res := clientintf.PaymentFeeEstimate{
+		AmountMAtoms: req.AmtMAtoms,
+		MaxFeeMAtoms: policy.MaxFee(req.AmtMAtoms),
+	}
+
+	routes, err := pc.lnRpc.QueryRoutes(ctx, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "unable to find a path") {
+			return res, fmt.Errorf("%w: %v",
+				clientintf.ErrNoRouteWithinFeeLimit, err)
+		}
+		return res, fmt.Errorf("unable to query routes: %v", err)
+	}
+	if len(routes.Routes) == 0 {
+		return res, clientintf.ErrNoRouteWithinFeeLimit
+	}
+	route := routes.Routes[0]
+	res.FeeMAtoms = route.TotalFeesMAtoms
+	res.Hops = len(route.Hops)
+	return res, nil
+}
+
+// EstimateInvoiceFee estimates the routing fee to pay the given invoice. If
+// amtMAtoms is zero, the amount of the invoice is used.
+func (pc *DcrlnPaymentClient) EstimateInvoiceFee(ctx context.Context, invoice string,
+	amtMAtoms int64) (clientintf.PaymentFeeEstimate, error) {
+
+	payReq, err := pc.lnRpc.DecodePayReq(ctx, &lnrpc.PayReqString{PayReq: invoice})
+	if err != nil {
+		return clientintf.PaymentFeeEstimate{}, fmt.Errorf("unable to decode pay req")
+	}
+	if amtMAtoms == 0 {
+		amtMAtoms = payReq.NumMAtoms
+	}
+	if amtMAtoms <= 0 {
+		return clientintf.PaymentFeeEstimate{}, fmt.Errorf("invoice " +
+			"does not specify an amount")
+	}
+
+	req := &lnrpc.QueryRoutesRequest{
+		PubKey:         payReq.Destination,
+		AmtMAtoms:      amtMAtoms,
+		FinalCltvDelta: int32(payReq.CltvExpiry),
+		RouteHints:     payReq.RouteHints,
+	}
+	return pc.estimateRouteFee(ctx, req)
+}
+
+// EstimateNodeFee estimates the routing fee to send the amount to the node
+// with the given hex-encoded pubkey.
+func (pc *DcrlnPaymentClient) EstimateNodeFee(ctx context.Context, dest string,
+	amtMAtoms int64) (clientintf.PaymentFeeEstimate, error) {
+
+	if _, err := hex.DecodeString(dest); err != nil {
+		return clientintf.PaymentFeeEstimate{}, fmt.Errorf("invalid destination node: %v", err)
+	}
+	req := &lnrpc.QueryRoutesRequest{
+		PubKey:       dest,
+		AmtMAtoms:    amtMAtoms,
+		DestFeatures: []lnrpc.FeatureBit{lnrpc.FeatureBit_TLV_ONION_OPT},
+	}
+	return pc.estimateRouteFee(ctx, req)
+}
+
+// KeysendReceived returns the amount received in the settled keysend payment
+// with the given hash.
+func (pc *DcrlnPaymentClient) KeysendReceived(ctx context.Context, hash []byte) (int64, error) {
+	inv, err := pc.lnRpc.LookupInvoice(ctx, &lnrpc.PaymentHash{RHash: hash})
+	if err != nil {
+		return 0, fmt.Errorf("unable to lookup keysend payment: %v", err)
+	}
+	if !inv.IsKeysend {
+		return 0, fmt.Errorf("payment %x is not a keysend payment", hash)
+	}
+	if inv.State != lnrpc.Invoice_SETTLED {
+		return 0, fmt.Errorf("keysend payment %x is not settled", hash)
+	}
+	return inv.AmtPaidMAtoms, nil
+}
+
 func (pc *DcrlnPaymentClient) IsPaymentCompleted(ctx context.Context, invoice string) (int64, error) {
 	payReq, err := pc.lnRpc.DecodePayReq(ctx, &lnrpc.PayReqString{PayReq: invoice})
 	if err != nil {
@@ -434,7 +639,7 @@ func (pc *DcrlnPaymentClient) TrackInvoice(ctx context.Context, invoice string,
 
 			case res.State == lnrpc.Invoice_CANCELED:
 				cancelTimer()
-				return 0, fmt.Errorf("LN invoice canceled")
+				return 0, clientintf.ErrInvoiceCanceled
 
 			case res.State == lnrpc.Invoice_OPEN:
 				// Continue waiting for payment or expiration.
@@ -448,6 +653,86 @@ func (pc *DcrlnPaymentClient) TrackInvoice(ctx context.Context, invoice string,
 	}
 }
 
+// CancelInvoice cancels an open invoice generated by the local node.
+func (pc *DcrlnPaymentClient) CancelInvoice(ctx context.Context, invoice string) error {
+	payReq, err := pc.lnRpc.DecodePayReq(ctx, &lnrpc.PayReqString{PayReq: invoice})
+	if err != nil {
+		return fmt.Errorf("unable to decode pay req")
+	}
+
+	payHash, err := hex.DecodeString(payReq.PaymentHash)
+	if err != nil {
+		return fmt.Errorf("unable to decode payment hash: %v", err)
+	}
+
+	req := &invoicesrpc.CancelInvoiceMsg{PaymentHash: payHash}
+	_, err = pc.lnInvoices.CancelInvoice(ctx, req)
+	return err
+}
+
+// holdInvoiceCltvMargin is the number of blocks added to the CLTV expiry of
+// hold invoices, after the number of blocks expected during their lifetime.
+const holdInvoiceCltvMargin = 40
+
+// AddHoldInvoice generates a hold invoice for the payment hash.
+func (pc *DcrlnPaymentClient) AddHoldInvoice(ctx context.Context, hash []byte,
+	mAtoms int64, memo string, lifetime time.Duration) (string, error) {
+
+	params, err := pc.ChainParams(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// The HTLCs of the payment must not expire while it is held.
+	blocks := uint64(lifetime / params.TargetTimePerBlock)
+	req := &invoicesrpc.AddHoldInvoiceRequest{
+		Memo:        memo,
+		Hash:        hash,
+		ValueMAtoms: mAtoms,
+		Expiry:      int64(lifetime.Seconds()),
+		CltvExpiry:  blocks + holdInvoiceCltvMargin,
+	}
+	res, err := pc.lnInvoices.AddHoldInvoice(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return res.PaymentRequest, nil
+}
+
+// SettleHoldInvoice settles the hold invoice for the hash of the preimage.
+func (pc *DcrlnPaymentClient) SettleHoldInvoice(ctx context.Context, preimage []byte) error {
+	req := &invoicesrpc.SettleInvoiceMsg{Preimage: preimage}
+	_, err := pc.lnInvoices.SettleInvoice(ctx, req)
+	return err
+}
+
+// CancelHoldInvoice cancels the hold invoice for the hash.
+func (pc *DcrlnPaymentClient) CancelHoldInvoice(ctx context.Context, hash []byte) error {
+	req := &invoicesrpc.CancelInvoiceMsg{PaymentHash: hash}
+	_, err := pc.lnInvoices.CancelInvoice(ctx, req)
+	return err
+}
+
+// HoldInvoiceState returns the state of the hold invoice for the hash.
+func (pc *DcrlnPaymentClient) HoldInvoiceState(ctx context.Context, hash []byte) (clientintf.HoldInvoiceState, error) {
+	inv, err := pc.lnRpc.LookupInvoice(ctx, &lnrpc.PaymentHash{RHash: hash})
+	if err != nil {
+		return "", err
+	}
+	switch inv.State {
+	case lnrpc.Invoice_OPEN:
+		return clientintf.HoldInvoiceOpen, nil
+	case lnrpc.Invoice_ACCEPTED:
+		return clientintf.HoldInvoiceAccepted, nil
+	case lnrpc.Invoice_SETTLED:
+		return clientintf.HoldInvoiceSettled, nil
+	case lnrpc.Invoice_CANCELED:
+		return clientintf.HoldInvoiceCanceled, nil
+	default:
+		return "", fmt.Errorf("unknown invoice state %s", inv.State)
+	}
+}
+
 // PaymentTimingStats returns timing information for payment stats.
 func (pc *DcrlnPaymentClient) PaymentTimingStats() []timestats.Quantile {
 	return pc.payTiming.Quantiles()
@@ -669,6 +954,75 @@ func (pc *DcrlnPaymentClient) NewReceiveAddress(ctx context.Context, acct string
 	return stdaddr.DecodeAddress(addrRes.Address, pc.chainParams)
 }
 
+// NewOnchainAddress returns a new address of the default account of the
+// wallet.
+func (pc *DcrlnPaymentClient) NewOnchainAddress(ctx context.Context) (string, error) {
+	addr, err := pc.NewReceiveAddress(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// SendOnchain sends the amount to the address from the default account of the
+// wallet.
+func (pc *DcrlnPaymentClient) SendOnchain(ctx context.Context, addr string, atoms int64) (chainhash.Hash, error) {
+	chainParams, err := pc.ChainParams(ctx)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	if _, err := stdaddr.DecodeAddress(addr, chainParams); err != nil {
+		return chainhash.Hash{}, fmt.Errorf("invalid address: %v", err)
+	}
+
+	req := &lnrpc.SendCoinsRequest{
+		Addr:   addr,
+		Amount: atoms,
+	}
+	res, err := pc.lnRpc.SendCoins(ctx, req)
+	if err != nil {
+		return chainhash.Hash{}, fmt.Errorf("unable to send on-chain "+
+			"payment: %v", err)
+	}
+	txh, err := chainhash.NewHashFromStr(res.Txid)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	pc.log.Infof("Sent %s on-chain to %s in tx %s", dcrutil.Amount(atoms),
+		addr, txh)
+	return *txh, nil
+}
+
+// OnchainTxOutput returns the amount the wallet transaction pays to the
+// address and the number of confirmations of the transaction.
+func (pc *DcrlnPaymentClient) OnchainTxOutput(ctx context.Context, tx chainhash.Hash, addr string) (int64, int32, error) {
+	chainParams, err := pc.ChainParams(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	decodedAddr, err := stdaddr.DecodeAddress(addr, chainParams)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid address: %v", err)
+	}
+	scriptVersion, script := decodedAddr.PaymentScript()
+
+	res, err := pc.lnWallet.GetWalletTx(ctx, &walletrpc.GetWalletTxRequest{Txid: tx[:]})
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to fetch wallet tx %s: %v", tx, err)
+	}
+	var msgTx wire.MsgTx
+	if err := msgTx.FromBytes(res.RawTx); err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, out := range msgTx.TxOut {
+		if out.Version == scriptVersion && bytes.Equal(out.PkScript, script) {
+			total += out.Value
+		}
+	}
+	return total, res.Confirmations, nil
+}
+
 // WatchTransactions watches transactions until the given context is closed.
 func (pc *DcrlnPaymentClient) WatchTransactions(ctx context.Context, handler func(tx *lnrpc.Transaction)) {
 	ctxCanceled := func() bool {
@@ -840,7 +1194,9 @@ func PaymentFeeLimit(amountMAtoms uint64) *lnrpc.FeeLimit {
 
 // TrackWalletCheckEvents tracks events of the lightnint wallet that are
 // relevant for wallet checks. Once an event is detected, the chan is written
-// to. The checks are tracked until the context is done.
+// to. The checks are tracked until the context is done. Subscriptions that
+// fail (for example, due to a lost connection to a remote dcrlnd node) are
+// reestablished, and reestablishing them also counts as an event.
 func TrackWalletCheckEvents(ctx context.Context, lnRPC lnrpc.LightningClient) (chan struct{}, error) {
 	chanEvents, err := lnRPC.SubscribeChannelEvents(ctx, &lnrpc.ChannelEventSubscription{})
 	if err != nil {
@@ -853,22 +1209,51 @@ func TrackWalletCheckEvents(ctx context.Context, lnRPC lnrpc.LightningClient) (c
 	}
 
 	innerChan := make(chan struct{}, 10)
+	signal := func() bool {
+		select {
+		case innerChan <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// resubscribe calls subscribe until it succeeds or the context is
+	// done.
+	resubscribe := func(subscribe func() error) bool {
+		for {
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return false
+			}
+			if err := subscribe(); err == nil {
+				return signal()
+			}
+		}
+	}
 
 	// Trach chan events.
 	go func() {
 		for {
 			event, err := chanEvents.Recv()
 			if err != nil {
-				return
+				ok := resubscribe(func() error {
+					chanEvents, err = lnRPC.SubscribeChannelEvents(ctx,
+						&lnrpc.ChannelEventSubscription{})
+					return err
+				})
+				if !ok {
+					return
+				}
+				continue
 			}
 
 			switch event.Channel.(type) {
 			case *lnrpc.ChannelEventUpdate_OpenChannel,
 				*lnrpc.ChannelEventUpdate_ActiveChannel:
 
-				select {
-				case innerChan <- struct{}{}:
-				case <-ctx.Done():
+				if !signal() {
 					return
 				}
 			}
@@ -880,13 +1265,19 @@ func TrackWalletCheckEvents(ctx context.Context, lnRPC lnrpc.LightningClient) (c
 		for {
 			event, err := peerEvents.Recv()
 			if err != nil {
-				return
+				ok := resubscribe(func() error {
+					peerEvents, err = lnRPC.SubscribePeerEvents(ctx,
+						&lnrpc.PeerEventSubscription{})
+					return err
+				})
+				if !ok {
+					return
+				}
+				continue
 			}
 
 			if event.Type == lnrpc.PeerEvent_PEER_ONLINE {
-				select {
-				case innerChan <- struct{}{}:
-				case <-ctx.Done():
+				if !signal() {
 					return
 				}
 			}
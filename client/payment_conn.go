@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrlnd/macaroons"
+	"github.com/decred/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"gopkg.in/macaroon.v2"
+)
+
+// lnKeepaliveParams are the keepalive params used in the connection to
+// dcrlnd, so that broken connections to remote nodes are detected and
+// reestablished.
+var lnKeepaliveParams = keepalive.ClientParameters{
+	Time:                30 * time.Second,
+	Timeout:             20 * time.Second,
+	PermitWithoutStream: true,
+}
+
+// fileMacaroonCredential is a per-RPC credential that reloads the macaroon
+// file when it is modified. This allows macaroons of remote dcrlnd nodes to
+// be rotated without restarting the client.
+type fileMacaroonCredential struct {
+	path string
+	log  slog.Logger
+
+	mtx     sync.Mutex
+	modTime time.Time
+	cred    macaroons.MacaroonCredential
+}
+
+// newFileMacaroonCredential loads the macaroon file in path.
+func newFileMacaroonCredential(path string, log slog.Logger) (*fileMacaroonCredential, error) {
+	fmc := &fileMacaroonCredential{path: path, log: log}
+	if _, err := fmc.credential(); err != nil {
+		return nil, err
+	}
+	return fmc, nil
+}
+
+// credential returns the current macaroon credential, reloading it from the
+// file if the file was modified since it was last loaded.
+func (fmc *fileMacaroonCredential) credential() (macaroons.MacaroonCredential, error) {
+	fmc.mtx.Lock()
+	defer fmc.mtx.Unlock()
+
+	fi, err := os.Stat(fmc.path)
+	if err != nil {
+		if fmc.cred.Macaroon != nil {
+			// Keep using the last loaded macaroon while the file
+			// is being replaced.
+			return fmc.cred, nil
+		}
+		return fmc.cred, err
+	}
+	if fmc.cred.Macaroon != nil && fi.ModTime().Equal(fmc.modTime) {
+		return fmc.cred, nil
+	}
+
+	macBytes, err := os.ReadFile(fmc.path)
+	if err != nil {
+		if fmc.cred.Macaroon != nil {
+			return fmc.cred, nil
+		}
+		return fmc.cred, err
+	}
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(macBytes); err != nil {
+		if fmc.cred.Macaroon != nil {
+			fmc.log.Warnf("Unable to decode modified macaroon %s: %v",
+				fmc.path, err)
+			fmc.modTime = fi.ModTime()
+			return fmc.cred, nil
+		}
+		return fmc.cred, fmt.Errorf("unable to decode macaroon: %v", err)
+	}
+	if fmc.cred.Macaroon != nil {
+		fmc.log.Infof("Reloaded modified macaroon %s", fmc.path)
+	}
+	fmc.cred = macaroons.NewMacaroonCredential(mac)
+	fmc.modTime = fi.ModTime()
+	return fmc.cred, nil
+}
+
+// GetRequestMetadata is part of the credentials.PerRPCCredentials interface.
+func (fmc *fileMacaroonCredential) GetRequestMetadata(ctx context.Context,
+	uri ...string) (map[string]string, error) {
+
+	cred, err := fmc.credential()
+	if err != nil {
+		return nil, err
+	}
+	return cred.GetRequestMetadata(ctx, uri...)
+}
+
+// RequireTransportSecurity is part of the credentials.PerRPCCredentials
+// interface.
+func (fmc *fileMacaroonCredential) RequireTransportSecurity() bool {
+	return true
+}
+
+// watchConnState watches the state of the connection to dcrlnd until the
+// context is done, calling onChange when the connection is lost or
+// reestablished.
+func watchConnState(ctx context.Context, conn *grpc.ClientConn, log slog.Logger,
+	onChange func(connected bool)) {
+
+	connected := true
+	for {
+		state := conn.GetState()
+		switch {
+		case state == connectivity.Ready && !connected:
+			connected = true
+			log.Infof("Reconnected to dcrlnd")
+			if onChange != nil {
+				onChange(true)
+			}
+
+		case (state == connectivity.TransientFailure ||
+			state == connectivity.Shutdown) && connected:
+			connected = false
+			log.Warnf("Connection to dcrlnd lost")
+			if onChange != nil {
+				onChange(false)
+			}
+		}
+
+		if state == connectivity.Idle {
+			// Trigger a reconnection of idle connections, so that
+			// reconnections are noticed without waiting for a call.
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
+}
+
+// Connected returns true if the connection to dcrlnd is currently
+// established.
+func (pc *DcrlnPaymentClient) Connected() bool {
+	return pc.conn.GetState() == connectivity.Ready
+}
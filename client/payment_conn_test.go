@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/slog"
+	"gopkg.in/macaroon.v2"
+)
+
+// TestFileMacaroonCredentialReload tests that the macaroon credential is
+// reloaded when the macaroon file is modified.
+func TestFileMacaroonCredentialReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "admin.macaroon")
+	modTime := time.Now()
+	writeMacaroon := func(id string) {
+		t.Helper()
+		mac, err := macaroon.New([]byte("root key"), []byte(id), "dcrlnd",
+			macaroon.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := mac.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		modTime = modTime.Add(time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadata := func(fmc *fileMacaroonCredential) string {
+		t.Helper()
+		md, err := fmc.GetRequestMetadata(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return md["macaroon"]
+	}
+
+	writeMacaroon("first")
+	fmc, err := newFileMacaroonCredential(path, slog.Disabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := metadata(fmc)
+	if first == "" {
+		t.Fatal("empty macaroon metadata")
+	}
+	if got := metadata(fmc); got != first {
+		t.Fatal("macaroon changed without modifying the file")
+	}
+
+	// Rotating the macaroon file reloads it.
+	writeMacaroon("second")
+	second := metadata(fmc)
+	if second == first {
+		t.Fatal("macaroon not reloaded after modifying the file")
+	}
+
+	// An invalid or missing file keeps the last loaded macaroon.
+	if err := os.WriteFile(path, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime = modTime.Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata(fmc); got != second {
+		t.Fatal("invalid macaroon file replaced the loaded macaroon")
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata(fmc); got != second {
+		t.Fatal("missing macaroon file replaced the loaded macaroon")
+	}
+
+	// A missing file fails when no macaroon was loaded.
+	if _, err := newFileMacaroonCredential(path, slog.Disabled); err == nil {
+		t.Fatal("expected error loading missing macaroon file")
+	}
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+)
+
+// PaymentFeeTier is a limit on the routing fees of payments that are at least
+// MinMAtoms in size.
+type PaymentFeeTier struct {
+	// MinMAtoms is the minimum payment amount to which the tier applies.
+	MinMAtoms int64
+
+	// MaxFeeMAtoms is the absolute max routing fee. Zero means the fee is
+	// limited only by MaxFeePercent.
+	MaxFeeMAtoms int64
+
+	// MaxFeePercent is the max routing fee as a percentage of the payment
+	// amount. Zero means the fee is limited only by MaxFeeMAtoms.
+	MaxFeePercent float64
+}
+
+// maxFee returns the max fee (in milliatoms) allowed by the tier for a
+// payment of the given amount. When both limits are set, the lowest one
+// applies.
+func (t PaymentFeeTier) maxFee(amountMAtoms int64) int64 {
+	maxFee := int64(-1)
+	if t.MaxFeePercent > 0 {
+		maxFee = int64(math.Floor(float64(amountMAtoms) * t.MaxFeePercent / 100))
+	}
+	if t.MaxFeeMAtoms > 0 && (maxFee < 0 || t.MaxFeeMAtoms < maxFee) {
+		maxFee = t.MaxFeeMAtoms
+	}
+	return maxFee
+}
+
+// PaymentFeePolicy is a list of routing fee limits based on the payment size.
+// The tier with the largest MinMAtoms that is not greater than the payment
+// amount applies to the payment. Payments smaller than every tier use the
+// default limit returned by PaymentFeeLimit.
+type PaymentFeePolicy []PaymentFeeTier
+
+// Validate returns an error if the policy has invalid tiers.
+func (p PaymentFeePolicy) Validate() error {
+	seen := make(map[int64]bool, len(p))
+	for _, t := range p {
+		if t.MinMAtoms < 0 {
+			return errors.New("tier min amount cannot be negative")
+		}
+		if t.MaxFeeMAtoms < 0 || t.MaxFeePercent < 0 {
+			return errors.New("tier max fee cannot be negative")
+		}
+		if t.MaxFeeMAtoms == 0 && t.MaxFeePercent == 0 {
+			return fmt.Errorf("tier for payments of at least %s does "+
+				"not specify a max fee", dcrutil.Amount(t.MinMAtoms/1000))
+		}
+		if t.MaxFeePercent > 100 {
+			return errors.New("tier max fee percent cannot be " +
+				"greater than 100")
+		}
+		if seen[t.MinMAtoms] {
+			return fmt.Errorf("duplicated tier for payments of at "+
+				"least %s", dcrutil.Amount(t.MinMAtoms/1000))
+		}
+		seen[t.MinMAtoms] = true
+	}
+	return nil
+}
+
+// tierFor returns the tier that applies to a payment of the given amount.
+func (p PaymentFeePolicy) tierFor(amountMAtoms int64) (PaymentFeeTier, bool) {
+	var res PaymentFeeTier
+	var found bool
+	for _, t := range p {
+		if t.MinMAtoms > amountMAtoms {
+			continue
+		}
+		if !found || t.MinMAtoms > res.MinMAtoms {
+			res, found = t, true
+		}
+	}
+	return res, found
+}
+
+// FeeLimit returns the fee limit to use when making a payment of the given
+// amount.
+func (p PaymentFeePolicy) FeeLimit(amountMAtoms int64) *lnrpc.FeeLimit {
+	t, ok := p.tierFor(amountMAtoms)
+	if !ok {
+		return PaymentFeeLimit(uint64(amountMAtoms))
+	}
+	return &lnrpc.FeeLimit{
+		Limit: &lnrpc.FeeLimit_FixedMAtoms{
+			FixedMAtoms: t.maxFee(amountMAtoms),
+		},
+	}
+}
+
+// MaxFee returns the max routing fee (in milliatoms) of a payment of the given
+// amount. It returns -1 if the fee is not limited.
+func (p PaymentFeePolicy) MaxFee(amountMAtoms int64) int64 {
+	feeLimit := p.FeeLimit(amountMAtoms)
+	if feeLimit == nil {
+		return -1
+	}
+	switch l := feeLimit.Limit.(type) {
+	case *lnrpc.FeeLimit_FixedMAtoms:
+		return l.FixedMAtoms
+	case *lnrpc.FeeLimit_Fixed:
+		return l.Fixed * 1000
+	case *lnrpc.FeeLimit_Percent:
+		return amountMAtoms * l.Percent / 100
+	default:
+		return -1
+	}
+}
+
+// Sorted returns a copy of the policy with the tiers sorted by min amount.
+func (p PaymentFeePolicy) Sorted() PaymentFeePolicy {
+	res := make(PaymentFeePolicy, len(p))
+	copy(res, p)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].MinMAtoms < res[j].MinMAtoms
+	})
+	return res
+}
+
+// String returns the policy in the format accepted by ParsePaymentFeePolicy.
+func (p PaymentFeePolicy) String() string {
+	fmtDCR := func(mat int64) string {
+		return strconv.FormatFloat(dcrutil.Amount(mat/1000).ToCoin(), 'f', -1, 64)
+	}
+	tiers := make([]string, 0, len(p))
+	for _, t := range p.Sorted() {
+		tiers = append(tiers, fmt.Sprintf("%s:%s:%s", fmtDCR(t.MinMAtoms),
+			fmtDCR(t.MaxFeeMAtoms),
+			strconv.FormatFloat(t.MaxFeePercent, 'f', -1, 64)))
+	}
+	return strings.Join(tiers, ",")
+}
+
+// ParsePaymentFeePolicy parses a fee policy from a comma separated list of
+// tiers. Each tier is specified as "<min amount>:<max fee>:<max fee percent>",
+// where amounts are in DCR. Empty limits are interpreted as zero (no limit).
+func ParsePaymentFeePolicy(s string) (PaymentFeePolicy, error) {
+	var res PaymentFeePolicy
+	for _, tier := range strings.Split(s, ",") {
+		tier = strings.TrimSpace(tier)
+		if tier == "" {
+			continue
+		}
+		fields := strings.Split(tier, ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("tier %q is not in the format "+
+				"<min amount>:<max fee>:<max fee percent>", tier)
+		}
+
+		parseAmount := func(s string) (int64, error) {
+			if s = strings.TrimSpace(s); s == "" {
+				return 0, nil
+			}
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return 0, err
+			}
+			amt, err := dcrutil.NewAmount(f)
+			if err != nil {
+				return 0, err
+			}
+			return int64(amt) * 1000, nil
+		}
+
+		var t PaymentFeeTier
+		var err error
+		if t.MinMAtoms, err = parseAmount(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid min amount in tier %q: %v", tier, err)
+		}
+		if t.MaxFeeMAtoms, err = parseAmount(fields[1]); err != nil {
+			return nil, fmt.Errorf("invalid max fee in tier %q: %v", tier, err)
+		}
+		if pct := strings.TrimSpace(fields[2]); pct != "" {
+			t.MaxFeePercent, err = strconv.ParseFloat(pct, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max fee percent in "+
+					"tier %q: %v", tier, err)
+			}
+		}
+		res = append(res, t)
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res.Sorted(), nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"testing"
+)
+
+// TestPaymentFeePolicy tests the fee limits returned by fee policies.
+func TestPaymentFeePolicy(t *testing.T) {
+	const dcr = int64(1e11)
+
+	policy, err := ParsePaymentFeePolicy("0.01:0.001:1, 0:0.0002:, 1::0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := policy.String(), "0:0.0002:0,0.01:0.001:1,1:0:0.5"; got != want {
+		t.Fatalf("unexpected policy string: got %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		amount int64
+		want   int64
+	}{{
+		name:   "first tier",
+		amount: dcr / 1000,
+		want:   dcr / 5000,
+	}, {
+		name:   "second tier percent",
+		amount: dcr / 100,
+		want:   dcr / 10000,
+	}, {
+		name:   "second tier absolute",
+		amount: dcr / 2,
+		want:   dcr / 1000,
+	}, {
+		name:   "third tier",
+		amount: 10 * dcr,
+		want:   dcr / 20,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policy.MaxFee(tc.amount)
+			if got != tc.want {
+				t.Fatalf("unexpected max fee: got %d, want %d",
+					got, tc.want)
+			}
+		})
+	}
+
+	// An empty policy uses the default limits, which only limit small
+	// payments.
+	var empty PaymentFeePolicy
+	if got, want := empty.MaxFee(1000), int64(21000); got != want {
+		t.Fatalf("unexpected default max fee: got %d, want %d", got, want)
+	}
+	if got := empty.MaxFee(dcr); got != -1 {
+		t.Fatalf("unexpected default max fee: got %d, want -1", got)
+	}
+
+	// Invalid policies.
+	invalid := []string{
+		"0:0.1",
+		"0::",
+		"0:-1:",
+		"0::101",
+		"0:0.1:,0::1",
+		"x:0.1:",
+	}
+	for _, s := range invalid {
+		if _, err := ParsePaymentFeePolicy(s); err == nil {
+			t.Fatalf("expected error parsing policy %q", s)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/decred/dcrlnd/lnrpc"
+	"github.com/decred/dcrlnd/lnrpc/routerrpc"
+	"github.com/decred/dcrlnd/lnwire"
+)
+
+// DefaultMaxPaymentParts is the default max number of parts into which
+// multi-path payments are split.
+const DefaultMaxPaymentParts = 16
+
+// multiPathPaymentTimeout is the max time (in seconds) that dcrlnd spends
+// attempting the parts of a multi-path payment.
+const multiPathPaymentTimeout = 120
+
+// maxChannelOutbound returns the max amount (in milli-atoms) that can be sent
+// through a single one of the channels.
+func maxChannelOutbound(chans []*lnrpc.Channel) int64 {
+	var res int64
+	for _, c := range chans {
+		avail := (c.LocalBalance - c.LocalChanReserveAtoms) * 1000
+		if avail > res {
+			res = avail
+		}
+	}
+	return res
+}
+
+// multiPathProgress returns the aggregate progress of the HTLCs of a payment.
+func multiPathProgress(amtMAtoms int64, htlcs []*lnrpc.HTLCAttempt) clientintf.MultiPathProgress {
+	p := clientintf.MultiPathProgress{AmountMAtoms: amtMAtoms}
+	for _, htlc := range htlcs {
+		var amt, fees int64
+		if htlc.Route != nil {
+			fees = htlc.Route.TotalFeesMAtoms
+			amt = htlc.Route.TotalAmtMAtoms - fees
+		}
+		switch htlc.Status {
+		case lnrpc.HTLCAttempt_SUCCEEDED:
+			p.Parts += 1
+			p.PaidMAtoms += amt
+			p.FeesMAtoms += fees
+		case lnrpc.HTLCAttempt_IN_FLIGHT:
+			p.InFlight += 1
+			p.InFlightMAtoms += amt
+		case lnrpc.HTLCAttempt_FAILED:
+			p.Failed += 1
+		}
+	}
+	return p
+}
+
+// PayInvoiceMultiPath is part of the clientintf.MultiPathPaymentClient
+// interface.
+//
+// Invoices that fit the outbound capacity of a single channel (or when
+// multi-path payments are disabled) are paid as regular payments.
+func (pc *DcrlnPaymentClient) PayInvoiceMultiPath(ctx context.Context,
+	invoice string, progress func(clientintf.MultiPathProgress)) (int64, error) {
+
+	if pc.maxPaymentParts <= 1 {
+		return pc.PayInvoice(ctx, invoice)
+	}
+
+	payReq, err := pc.lnRpc.DecodePayReq(ctx, &lnrpc.PayReqString{PayReq: invoice})
+	if err != nil {
+		return 0, fmt.Errorf("unable to decode pay req")
+	}
+	chans, err := pc.lnRpc.ListChannels(ctx, &lnrpc.ListChannelsRequest{ActiveOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	if maxOut := maxChannelOutbound(chans.Channels); payReq.NumMAtoms <= maxOut {
+		return pc.PayInvoice(ctx, invoice)
+	}
+
+	feeLimit := lnrpc.CalculateFeeLimit(pc.feeLimit(payReq.NumMAtoms),
+		lnwire.MilliAtom(payReq.NumMAtoms))
+
+	pc.log.Debugf("Attempting to pay %d MAtoms in up to %d parts, hash %s "+
+		"req %s", payReq.NumMAtoms, pc.maxPaymentParts,
+		payReq.PaymentHash, invoice)
+
+	start := time.Now()
+	stream, err := pc.lnRouter.SendPaymentV2(ctx, &routerrpc.SendPaymentRequest{
+		PaymentRequest: invoice,
+		FeeLimitMAtoms: int64(feeLimit),
+		TimeoutSeconds: multiPathPaymentTimeout,
+		MaxParts:       pc.maxPaymentParts,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to complete LN payment: %v", err)
+	}
+	for {
+		payment, err := stream.Recv()
+		if err != nil {
+			return 0, fmt.Errorf("error reading multi-path payment "+
+				"updates: %v", err)
+		}
+
+		if progress != nil {
+			progress(multiPathProgress(payReq.NumMAtoms, payment.Htlcs))
+		}
+
+		switch payment.Status {
+		case lnrpc.Payment_SUCCEEDED:
+			pc.payTiming.Add(time.Since(start))
+			pc.log.Debugf("Completed multi-path LN payment of hash %s "+
+				"fees %d parts %d", payReq.PaymentHash,
+				payment.FeeMAtoms, len(payment.Htlcs))
+			return payment.FeeMAtoms, nil
+
+		case lnrpc.Payment_FAILED:
+			reason := payment.FailureReason.String()
+			pc.log.Warnf("Multi-path payment error (%s) when attempting "+
+				"to pay invoice. hash=%s, target=%s numMAtoms=%d",
+				reason, payReq.PaymentHash, payReq.Destination,
+				payReq.NumMAtoms)
+			if payment.FailureReason == lnrpc.PaymentFailureReason_FAILURE_REASON_NO_ROUTE {
+				return 0, fmt.Errorf("LN %w: %s",
+					clientintf.ErrRetriablePayment, reason)
+			}
+			return 0, fmt.Errorf("LN payment error: %s", reason)
+
+		case lnrpc.Payment_IN_FLIGHT:
+			pc.log.Tracef("Multi-path payment %s is inflight",
+				payReq.PaymentHash)
+
+		default:
+			return 0, fmt.Errorf("unknown payment status %s", payment.Status)
+		}
+	}
+}
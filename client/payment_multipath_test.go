@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/decred/dcrlnd/lnrpc"
+)
+
+// TestMultiPathProgress tests the aggregate progress of the parts of a
+// multi-path payment.
+func TestMultiPathProgress(t *testing.T) {
+	chans := []*lnrpc.Channel{
+		{LocalBalance: 1000, LocalChanReserveAtoms: 100},
+		{LocalBalance: 2000, LocalChanReserveAtoms: 200},
+		{LocalBalance: 50, LocalChanReserveAtoms: 100},
+	}
+	if got := maxChannelOutbound(chans); got != 1800000 {
+		t.Fatalf("unexpected max channel outbound: got %d, want %d",
+			got, 1800000)
+	}
+
+	route := func(amt, fees int64) *lnrpc.Route {
+		return &lnrpc.Route{TotalAmtMAtoms: amt + fees, TotalFeesMAtoms: fees}
+	}
+	htlcs := []*lnrpc.HTLCAttempt{
+		{Status: lnrpc.HTLCAttempt_SUCCEEDED, Route: route(1000, 10)},
+		{Status: lnrpc.HTLCAttempt_FAILED, Route: route(3000, 10)},
+		{Status: lnrpc.HTLCAttempt_IN_FLIGHT, Route: route(1500, 5)},
+		{Status: lnrpc.HTLCAttempt_SUCCEEDED, Route: route(500, 2)},
+	}
+	want := clientintf.MultiPathProgress{
+		AmountMAtoms:   3000,
+		PaidMAtoms:     1500,
+		InFlightMAtoms: 1500,
+		FeesMAtoms:     12,
+		Parts:          2,
+		InFlight:       1,
+		Failed:         1,
+	}
+	if got := multiPathProgress(3000, htlcs); got != want {
+		t.Fatalf("unexpected progress: got %+v, want %+v", got, want)
+	}
+}
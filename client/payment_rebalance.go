@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/decred/dcrlnd/lnrpc"
+)
+
+// rebalanceInvoiceExpiry is the expiry (in seconds) of the invoices generated
+// to rebalance channels.
+const rebalanceInvoiceExpiry = 600
+
+// findChannel finds the channel with the given channel point or unique
+// channel point prefix.
+func findChannel(chans []*lnrpc.Channel, cp string) (*lnrpc.Channel, error) {
+	if cp == "" {
+		return nil, fmt.Errorf("channel point cannot be empty")
+	}
+	var res *lnrpc.Channel
+	for _, c := range chans {
+		if !strings.HasPrefix(c.ChannelPoint, cp) {
+			continue
+		}
+		if res != nil {
+			return nil, fmt.Errorf("channel prefix %q matches multiple "+
+				"channels", cp)
+		}
+		res = c
+	}
+	if res == nil {
+		return nil, fmt.Errorf("channel with ChannelPoint prefix %s not "+
+			"found", cp)
+	}
+	return res, nil
+}
+
+// Rebalance moves funds between two channels of the local node by paying an
+// invoice generated by the local node itself, using the outbound channel as
+// first hop and the peer of the inbound channel as the last hop.
+func (pc *DcrlnPaymentClient) Rebalance(ctx context.Context,
+	req clientintf.RebalanceRequest) (clientintf.RebalanceResult, error) {
+
+	var res clientintf.RebalanceResult
+	if req.AmountMAtoms <= 0 {
+		return res, fmt.Errorf("rebalance amount must be positive")
+	}
+	if req.MaxFeeMAtoms < 0 {
+		return res, fmt.Errorf("max fee cannot be negative")
+	}
+
+	chans, err := pc.lnRpc.ListChannels(ctx, &lnrpc.ListChannelsRequest{ActiveOnly: true})
+	if err != nil {
+		return res, err
+	}
+	outChan, err := findChannel(chans.Channels, req.OutChannel)
+	if err != nil {
+		return res, fmt.Errorf("outbound channel: %v", err)
+	}
+	inChan, err := findChannel(chans.Channels, req.InChannel)
+	if err != nil {
+		return res, fmt.Errorf("inbound channel: %v", err)
+	}
+	if outChan.ChanId == inChan.ChanId {
+		return res, fmt.Errorf("outbound and inbound channels must be different")
+	}
+	if outChan.RemotePubkey == inChan.RemotePubkey {
+		return res, fmt.Errorf("outbound and inbound channels must be " +
+			"with different peers")
+	}
+
+	amountAtoms := req.AmountMAtoms / 1000
+	if avail := outChan.LocalBalance - outChan.LocalChanReserveAtoms; amountAtoms > avail {
+		return res, fmt.Errorf("outbound channel only has %d atoms "+
+			"available to send", avail)
+	}
+	if avail := inChan.RemoteBalance - inChan.RemoteChanReserveAtoms; amountAtoms > avail {
+		return res, fmt.Errorf("inbound channel only has %d atoms "+
+			"available to receive", avail)
+	}
+
+	lastHop, err := hex.DecodeString(inChan.RemotePubkey)
+	if err != nil {
+		return res, fmt.Errorf("invalid pubkey of inbound channel peer: %v", err)
+	}
+	feeLimit := pc.feeLimit(req.AmountMAtoms)
+	if req.MaxFeeMAtoms > 0 {
+		feeLimit = &lnrpc.FeeLimit{
+			Limit: &lnrpc.FeeLimit_FixedMAtoms{FixedMAtoms: req.MaxFeeMAtoms},
+		}
+	}
+
+	inv, err := pc.lnRpc.AddInvoice(ctx, &lnrpc.Invoice{
+		Memo:        "rebalance",
+		ValueMAtoms: req.AmountMAtoms,
+		Expiry:      rebalanceInvoiceExpiry,
+	})
+	if err != nil {
+		return res, fmt.Errorf("unable to generate rebalance invoice: %v", err)
+	}
+
+	pc.log.Infof("Attempting to rebalance %d MAtoms from channel %s to %s",
+		req.AmountMAtoms, outChan.ChannelPoint, inChan.ChannelPoint)
+	sendRes, err := pc.lnRpc.SendPaymentSync(ctx, &lnrpc.SendRequest{
+		PaymentRequest:   inv.PaymentRequest,
+		OutgoingChanId:   outChan.ChanId,
+		LastHopPubkey:    lastHop,
+		AllowSelfPayment: true,
+		FeeLimit:         feeLimit,
+	})
+	if err == nil && sendRes.PaymentError != "" {
+		err = fmt.Errorf("LN payment error: %s", sendRes.PaymentError)
+		if strings.Contains(sendRes.PaymentError, "no_route") {
+			err = fmt.Errorf("%w: %s", clientintf.ErrNoRouteWithinFeeLimit,
+				sendRes.PaymentError)
+		}
+	}
+	if err != nil {
+		if cancelErr := pc.CancelInvoice(ctx, inv.PaymentRequest); cancelErr != nil {
+			pc.log.Warnf("Unable to cancel rebalance invoice: %v", cancelErr)
+		}
+		return res, fmt.Errorf("unable to complete rebalance: %w", err)
+	}
+
+	res = clientintf.RebalanceResult{
+		OutChanPoint: outChan.ChannelPoint,
+		InChanPoint:  inChan.ChannelPoint,
+		AmountMAtoms: req.AmountMAtoms,
+		FeeMAtoms:    sendRes.PaymentRoute.TotalFeesMAtoms,
+		Hops:         len(sendRes.PaymentRoute.Hops),
+	}
+	pc.log.Infof("Rebalanced %d MAtoms from channel %s to %s (fees %d "+
+		"MAtoms, %d hops)", res.AmountMAtoms, res.OutChanPoint,
+		res.InChanPoint, res.FeeMAtoms, res.Hops)
+	return res, nil
+}
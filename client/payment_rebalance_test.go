@@ -0,0 +1,44 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/decred/dcrlnd/lnrpc"
+)
+
+// TestFindChannel tests finding channels by their channel point prefix.
+func TestFindChannel(t *testing.T) {
+	chans := []*lnrpc.Channel{
+		{ChannelPoint: "aabbcc:0", ChanId: 1},
+		{ChannelPoint: "aabbdd:1", ChanId: 2},
+		{ChannelPoint: "112233:0", ChanId: 3},
+	}
+	tests := []struct {
+		cp      string
+		wantID  uint64
+		wantErr bool
+	}{
+		{cp: "aabbcc:0", wantID: 1},
+		{cp: "aabbd", wantID: 2},
+		{cp: "11", wantID: 3},
+		{cp: "aabb", wantErr: true},
+		{cp: "ffff", wantErr: true},
+		{cp: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		c, err := findChannel(chans, tc.cp)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("%q: expected error, got channel %d", tc.cp, c.ChanId)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tc.cp, err)
+		}
+		if c.ChanId != tc.wantID {
+			t.Fatalf("%q: unexpected channel: got %d, want %d", tc.cp,
+				c.ChanId, tc.wantID)
+		}
+	}
+}
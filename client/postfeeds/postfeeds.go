@@ -0,0 +1,273 @@
+// Package postfeeds renders the posts of the local client as RSS and Atom
+// feeds, so that they may be followed with standard feed readers.
+//
+// Two feeds are provided: the "own" feed, with the posts created by the local
+// client, and the "all" feed, which also includes the posts received from
+// subscribed users. The feeds may be served over HTTP and/or written as files
+// to a directory.
+package postfeeds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/slog"
+)
+
+const (
+	// defaultMaxEntries is the default max number of posts in a feed.
+	defaultMaxEntries = 50
+
+	// writeInterval is the interval between writes of the feed files.
+	writeInterval = time.Minute
+)
+
+// feedFiles are the names of the feeds, as served over HTTP and written to
+// files.
+var feedFiles = []string{"own.atom", "own.rss", "all.atom", "all.rss"}
+
+// Config holds the configuration for the post feeds.
+type Config struct {
+	// Client is the client whose posts are rendered.
+	Client *client.Client
+
+	// Listen is the address of the HTTP server that serves the feeds. The
+	// feeds are served without authentication, so this should be a
+	// loopback address. If empty, the feeds are not served over HTTP.
+	Listen string
+
+	// OutDir is the directory where the feeds are written as files. If
+	// empty, the feeds are not written to files.
+	OutDir string
+
+	// MaxEntries is the max number of posts in each feed. If unspecified,
+	// a default of 50 is used.
+	MaxEntries int
+
+	Log slog.Logger
+}
+
+// Feeds renders the posts of the client as feeds.
+type Feeds struct {
+	cfg Config
+	log slog.Logger
+}
+
+// New creates a new post feeds renderer.
+func New(cfg Config) *Feeds {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultMaxEntries
+	}
+	log := slog.Disabled
+	if cfg.Log != nil {
+		log = cfg.Log
+	}
+	return &Feeds{cfg: cfg, log: log}
+}
+
+// postContent returns the text content of a post, with its embeds replaced by
+// a short description of the embedded content.
+func postContent(pm *rpc.PostMetadata) string {
+	content := mdembeds.ReplaceEmbeds(pm.Attributes[rpc.RMPMain], func(args mdembeds.EmbeddedArgs) string {
+		var b strings.Builder
+		switch {
+		case !args.Download.IsEmpty() && args.Filename != "":
+			fmt.Fprintf(&b, "[file %s]", args.Filename)
+		case !args.Download.IsEmpty():
+			fmt.Fprintf(&b, "[file %s]", args.Download)
+		case args.Typ != "":
+			fmt.Fprintf(&b, "[embedded %s]", args.Typ)
+		default:
+			b.WriteString("[embedded content]")
+		}
+		if args.Alt != "" {
+			fmt.Fprintf(&b, " %s", args.Alt)
+		}
+		return b.String()
+	})
+	return strings.TrimSpace(content)
+}
+
+// Feed builds the feed of the posts of the local client. If all is true, the
+// posts received from subscribed users are also included.
+func (f *Feeds) Feed(all bool) (*Feed, error) {
+	c := f.cfg.Client
+	posts, err := c.ListPosts()
+	if err != nil {
+		return nil, err
+	}
+
+	me := c.PublicID()
+	feed := &Feed{
+		ID:    "urn:bisonrelay:feed:own:" + me.String(),
+		Title: fmt.Sprintf("Posts by %s", c.LocalNick()),
+	}
+	if all {
+		feed.ID = "urn:bisonrelay:feed:all:" + me.String()
+		feed.Title = fmt.Sprintf("Posts received by %s", c.LocalNick())
+	} else {
+		n := 0
+		for _, post := range posts {
+			if post.AuthorID == me {
+				posts[n] = post
+				n++
+			}
+		}
+		posts = posts[:n]
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Date.After(posts[j].Date)
+	})
+	if len(posts) > f.cfg.MaxEntries {
+		posts = posts[:f.cfg.MaxEntries]
+	}
+
+	feed.Entries = make([]Entry, 0, len(posts))
+	for _, post := range posts {
+		pm, err := c.ReadPost(post.From, post.ID)
+		if err != nil {
+			f.log.Warnf("Unable to read post %s from %s: %v", post.ID,
+				post.From, err)
+			continue
+		}
+		feed.Entries = append(feed.Entries, Entry{
+			ID:         post.ID,
+			AuthorID:   post.AuthorID,
+			AuthorNick: post.AuthorNick,
+			Title:      post.Title,
+			Content:    postContent(&pm),
+			Date:       post.Date,
+		})
+	}
+	return feed, nil
+}
+
+// Render writes the feed with the given name (one of "own.atom", "own.rss",
+// "all.atom" or "all.rss").
+func (f *Feeds) Render(w io.Writer, name string) error {
+	kind, format, ok := strings.Cut(name, ".")
+	if !ok || (kind != "own" && kind != "all") || (format != "atom" && format != "rss") {
+		return fmt.Errorf("unknown feed %q: %w", name, clientdb.ErrNotFound)
+	}
+	feed, err := f.Feed(kind == "all")
+	if err != nil {
+		return err
+	}
+	if format == "atom" {
+		return WriteAtom(w, feed)
+	}
+	return WriteRSS(w, feed)
+}
+
+// ServeHTTP serves the feeds at paths named after them (e.g. "/own.atom").
+func (f *Feeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	var b bytes.Buffer
+	err := f.Render(&b, name)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		f.log.Errorf("Unable to render feed %s: %v", name, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/atom+xml; charset=utf-8"
+	if strings.HasSuffix(name, ".rss") {
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(b.Bytes())
+}
+
+// WriteFiles writes all feeds to files in the output dir.
+func (f *Feeds) WriteFiles() error {
+	if err := os.MkdirAll(f.cfg.OutDir, 0o700); err != nil {
+		return err
+	}
+	for _, name := range feedFiles {
+		var b bytes.Buffer
+		if err := f.Render(&b, name); err != nil {
+			return err
+		}
+
+		// Write to a temp file first, so that readers never see a
+		// partially written feed.
+		fname := filepath.Join(f.cfg.OutDir, name)
+		tmpName := fname + ".tmp"
+		if err := os.WriteFile(tmpName, b.Bytes(), 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpName, fname); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run serves the feeds over HTTP and periodically writes them to files (as
+// configured) until the passed context is canceled.
+func (f *Feeds) Run(ctx context.Context) error {
+	errChan := make(chan error, 1)
+	if f.cfg.Listen != "" {
+		l, err := net.Listen("tcp", f.cfg.Listen)
+		if err != nil {
+			return fmt.Errorf("unable to listen for post feeds: %v", err)
+		}
+		svr := &http.Server{
+			Handler:           f,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() { errChan <- svr.Serve(l) }()
+		defer svr.Close()
+		f.log.Infof("Serving post feeds on http://%s/", l.Addr())
+	}
+
+	if f.cfg.OutDir == "" {
+		select {
+		case err := <-errChan:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	f.log.Infof("Writing post feeds to %s", f.cfg.OutDir)
+	ticker := time.NewTicker(writeInterval)
+	defer ticker.Stop()
+	for {
+		if err := f.WriteFiles(); err != nil {
+			f.log.Errorf("Unable to write post feeds: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case err := <-errChan:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
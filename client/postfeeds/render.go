@@ -0,0 +1,158 @@
+package postfeeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+)
+
+// Entry is a post in a feed.
+type Entry struct {
+	ID         clientintf.PostID
+	AuthorID   clientintf.UserID
+	AuthorNick string
+	Title      string
+	Content    string
+	Date       time.Time
+}
+
+// Feed is a list of posts, sorted from newest to oldest.
+type Feed struct {
+	// ID is a unique and stable identifier of the feed.
+	ID      string
+	Title   string
+	Entries []Entry
+}
+
+// updated returns the date of the newest entry of the feed.
+func (f *Feed) updated() time.Time {
+	var res time.Time
+	for i := range f.Entries {
+		if f.Entries[i].Date.After(res) {
+			res = f.Entries[i].Date
+		}
+	}
+	return res
+}
+
+// entryID returns the unique identifier of the entry.
+func entryID(e *Entry) string {
+	return fmt.Sprintf("urn:bisonrelay:post:%s:%s", e.AuthorID, e.ID)
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Content atomText   `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// WriteAtom writes the feed as an Atom (RFC 4287) document.
+func WriteAtom(w io.Writer, f *Feed) error {
+	af := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.updated().UTC().Format(time.RFC3339),
+		Entries: make([]atomEntry, len(f.Entries)),
+	}
+	for i := range f.Entries {
+		e := &f.Entries[i]
+		af.Entries[i] = atomEntry{
+			ID:      entryID(e),
+			Title:   e.Title,
+			Updated: e.Date.UTC().Format(time.RFC3339),
+			Author: atomAuthor{
+				Name: e.AuthorNick,
+				URI:  "urn:bisonrelay:user:" + e.AuthorID.String(),
+			},
+			Content: atomText{Type: "text", Body: e.Content},
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(&af)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	GUID        rssGUID `xml:"guid"`
+	Title       string  `xml:"title"`
+	Author      string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// WriteRSS writes the feed as an RSS 2.0 document.
+func WriteRSS(w io.Writer, f *Feed) error {
+	rf := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.ID,
+			Description: f.Title,
+			Items:       make([]rssItem, len(f.Entries)),
+		},
+	}
+	if updated := f.updated(); !updated.IsZero() {
+		rf.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+	for i := range f.Entries {
+		e := &f.Entries[i]
+		rf.Channel.Items[i] = rssItem{
+			GUID:        rssGUID{Value: entryID(e)},
+			Title:       e.Title,
+			Author:      e.AuthorNick,
+			PubDate:     e.Date.UTC().Format(time.RFC1123Z),
+			Description: e.Content,
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(&rf)
+}
@@ -0,0 +1,103 @@
+package postfeeds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+func testFeed() *Feed {
+	return &Feed{
+		ID:    "urn:bisonrelay:feed:own:test",
+		Title: "Posts by alice",
+		Entries: []Entry{{
+			ID:         clientintf.PostID{0: 1},
+			AuthorID:   clientintf.UserID{0: 2},
+			AuthorNick: "alice",
+			Title:      "Second <post>",
+			Content:    "second & last",
+			Date:       time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+		}, {
+			ID:         clientintf.PostID{0: 3},
+			AuthorID:   clientintf.UserID{0: 2},
+			AuthorNick: "alice",
+			Title:      "First",
+			Content:    "first",
+			Date:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		}},
+	}
+}
+
+// TestWriteAtom tests rendering feeds as Atom documents.
+func TestWriteAtom(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteAtom(&b, testFeed()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got atomFeed
+	if err := xml.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("unable to decode feed: %v", err)
+	}
+	if got.Updated != "2024-03-02T10:00:00Z" {
+		t.Fatalf("unexpected updated date %q", got.Updated)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("unexpected nb of entries: %d", len(got.Entries))
+	}
+	e := got.Entries[0]
+	if e.Title != "Second <post>" || e.Content.Body != "second & last" ||
+		e.Author.Name != "alice" {
+		t.Fatalf("unexpected entry %#v", e)
+	}
+	if !strings.HasPrefix(e.ID, "urn:bisonrelay:post:02") {
+		t.Fatalf("unexpected entry id %q", e.ID)
+	}
+}
+
+// TestWriteRSS tests rendering feeds as RSS documents.
+func TestWriteRSS(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteRSS(&b, testFeed()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got rssFeed
+	if err := xml.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("unable to decode feed: %v", err)
+	}
+	if got.Version != "2.0" || got.Channel.Title != "Posts by alice" {
+		t.Fatalf("unexpected channel %#v", got.Channel)
+	}
+	if len(got.Channel.Items) != 2 {
+		t.Fatalf("unexpected nb of items: %d", len(got.Channel.Items))
+	}
+	item := got.Channel.Items[1]
+	if item.Title != "First" || item.Author != "alice" ||
+		item.PubDate != "Fri, 01 Mar 2024 10:00:00 +0000" {
+		t.Fatalf("unexpected item %#v", item)
+	}
+	if item.GUID.IsPermaLink {
+		t.Fatalf("unexpected permalink guid")
+	}
+}
+
+// TestPostContent tests that embeds are replaced in the content of posts.
+func TestPostContent(t *testing.T) {
+	img := mdembeds.EmbeddedArgs{Typ: "image/png", Alt: "cat", Data: []byte{1}}
+	file := mdembeds.EmbeddedArgs{Download: [32]byte{1}, Filename: "doc.pdf"}
+	pm := &rpc.PostMetadata{Attributes: map[string]string{
+		rpc.RMPMain: "look " + img.String() + "\nand " + file.String() + "\n",
+	}}
+	got := postContent(pm)
+	want := "look [embedded image/png] cat\nand [file doc.pdf]"
+	if got != want {
+		t.Fatalf("unexpected content: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,561 @@
+package postimport
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Item is a post to be imported.
+type Item struct {
+	// ID uniquely identifies the item in its source. It is used to avoid
+	// importing the same item multiple times.
+	ID string
+
+	Title string
+
+	// Body is the content of the post, in markdown.
+	Body string
+
+	// Link is the URL where the item was originally published (if any).
+	Link string
+
+	Date time.Time
+	Tags []string
+
+	// base is the URL or dir used to resolve relative references to media
+	// in the body.
+	base string
+}
+
+// dateLayouts are the layouts of dates accepted in feeds and front matter.
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseDate parses a date in one of the accepted layouts.
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", s)
+}
+
+type rssItem struct {
+	GUID        string   `xml:"guid"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Categories  []string `xml:"category"`
+}
+
+// atomText is the content of an Atom text construct, which may be plain
+// text, escaped html or inline xhtml.
+type atomText struct {
+	Type string
+	Body string
+}
+
+func (t *atomText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "type" {
+			t.Type = attr.Value
+		}
+	}
+	if t.Type == "xhtml" {
+		var inner struct {
+			XML string `xml:",innerxml"`
+		}
+		err := d.DecodeElement(&inner, &start)
+		t.Body = inner.XML
+		return err
+	}
+	var text struct {
+		Body string `xml:",chardata"`
+	}
+	err := d.DecodeElement(&text, &start)
+	t.Body = text.Body
+	return err
+}
+
+// markdown returns the text converted to markdown.
+func (t *atomText) markdown(base *url.URL) string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return htmlToMarkdown(t.Body, base)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// feedDoc is either an RSS or an Atom document.
+type feedDoc struct {
+	XMLName xml.Name
+	Channel struct {
+		Link  string    `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// ParseFeed parses an RSS 2.0 or Atom feed into a list of items, sorted from
+// oldest to newest. The html content of the items is converted to markdown,
+// with relative links resolved against the link of the item (or the passed
+// feed URL, if the item has no link).
+func ParseFeed(r io.Reader, feedURL string) ([]Item, error) {
+	var doc feedDoc
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unable to decode feed: %w", err)
+	}
+
+	var items []Item
+	switch doc.XMLName.Local {
+	case "rss":
+		for _, ri := range doc.Channel.Items {
+			item := Item{
+				ID:    ri.GUID,
+				Title: strings.TrimSpace(ri.Title),
+				Link:  strings.TrimSpace(ri.Link),
+				Tags:  ri.Categories,
+			}
+			if item.ID == "" {
+				item.ID = item.Link
+			}
+			if ri.PubDate != "" {
+				item.Date, _ = parseDate(ri.PubDate)
+			}
+			content := ri.Content
+			if content == "" {
+				content = ri.Description
+			}
+			item.base = resolveBase(feedURL, doc.Channel.Link, item.Link)
+			base, _ := url.Parse(item.base)
+			item.Body = htmlToMarkdown(content, base)
+			items = append(items, item)
+		}
+
+	case "feed":
+		for _, ae := range doc.Entries {
+			item := Item{
+				ID:    strings.TrimSpace(ae.ID),
+				Title: strings.TrimSpace(ae.Title),
+			}
+			for _, l := range ae.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					item.Link = l.Href
+					break
+				}
+			}
+			for _, c := range ae.Categories {
+				item.Tags = append(item.Tags, c.Term)
+			}
+			date := ae.Published
+			if date == "" {
+				date = ae.Updated
+			}
+			if date != "" {
+				item.Date, _ = parseDate(date)
+			}
+			item.base = resolveBase(feedURL, item.Link)
+			base, _ := url.Parse(item.base)
+			if ae.Content.Body != "" {
+				item.Body = ae.Content.markdown(base)
+			} else {
+				item.Body = ae.Summary.markdown(base)
+			}
+			items = append(items, item)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", doc.XMLName.Local)
+	}
+
+	// Items without an ID or content cannot be imported.
+	n := 0
+	for _, item := range items {
+		if item.ID == "" || item.Body == "" {
+			continue
+		}
+		item.ID = "feed:" + item.ID
+		items[n] = item
+		n++
+	}
+	items = items[:n]
+	sortItems(items)
+	return items, nil
+}
+
+// resolveBase returns the result of resolving each of the URLs against the
+// previous ones.
+func resolveBase(urls ...string) string {
+	var base *url.URL
+	for _, s := range urls {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil || s == "" {
+			continue
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		base = u
+	}
+	if base == nil {
+		return ""
+	}
+	return base.String()
+}
+
+// sortItems sorts the items from oldest to newest.
+func sortItems(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Date.Before(items[j].Date)
+	})
+}
+
+// mdExtensions are the extensions of the files read as markdown posts.
+var mdExtensions = map[string]bool{".md": true, ".markdown": true}
+
+// ReadMarkdownDir reads the markdown files in the dir (and its subdirs) as a
+// list of items, sorted from oldest to newest.
+//
+// Files may start with a front matter block (delimited by "---" lines) with
+// the title, date and tags of the post. Files with "draft: true" in their front
+// matter are skipped. Files without a title use their first heading or their
+// file name, and files without a date use their modification time.
+func ReadMarkdownDir(dir string) ([]Item, error) {
+	var items []Item
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !mdExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		item, skip, err := readMarkdownFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		if skip {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		item.ID = "file:" + filepath.ToSlash(rel)
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortItems(items)
+	return items, nil
+}
+
+var mdHeadingRegexp = regexp.MustCompile(`(?m)^#{1,6}\s+(.+?)\s*#*\s*$`)
+
+// readMarkdownFile reads a markdown file as an item. Returns true if the file
+// is a draft that should be skipped.
+func readMarkdownFile(path string) (Item, bool, error) {
+	var item Item
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return item, false, err
+	}
+	body := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	fm, body, err := splitFrontMatter(body)
+	if err != nil {
+		return item, false, err
+	}
+	if strings.EqualFold(fm["draft"], "true") {
+		return item, true, nil
+	}
+
+	item.Title = fm["title"]
+	item.Link = fm["link"]
+	item.Tags = splitList(fm["tags"])
+	item.Tags = append(item.Tags, splitList(fm["categories"])...)
+	item.Body = strings.TrimSpace(body)
+	item.base = filepath.Dir(path)
+	if fm["date"] != "" {
+		if item.Date, err = parseDate(fm["date"]); err != nil {
+			return item, false, err
+		}
+	} else {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return item, false, err
+		}
+		item.Date = fi.ModTime()
+	}
+	if item.Title == "" {
+		if m := mdHeadingRegexp.FindStringSubmatch(item.Body); m != nil {
+			item.Title = m[1]
+		} else {
+			item.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+	return item, false, nil
+}
+
+// splitFrontMatter splits the front matter block from the body of a markdown
+// file. The front matter is parsed as a list of "key: value" lines, where
+// values may also be given as a list of "- value" lines.
+func splitFrontMatter(s string) (map[string]string, string, error) {
+	fm := make(map[string]string)
+	if !strings.HasPrefix(s, "---\n") {
+		return fm, s, nil
+	}
+	end := strings.Index(s[4:], "\n---")
+	if end < 0 {
+		return nil, "", errors.New("unterminated front matter")
+	}
+	block, body := s[4:4+end], s[4+end+4:]
+	if i := strings.IndexByte(body, '\n'); i > -1 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+
+	var lastKey string
+	scanner := bufio.NewScanner(strings.NewReader(block))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "- "); ok && lastKey != "" {
+			if fm[lastKey] != "" {
+				fm[lastKey] += ","
+			}
+			fm[lastKey] += unquote(v)
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		lastKey = strings.ToLower(strings.TrimSpace(k))
+		fm[lastKey] = unquote(v)
+	}
+	return fm, body, scanner.Err()
+}
+
+// unquote removes surrounding whitespace and quotes from a value.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// splitList splits a list given as "a, b" or "[a, b]".
+func splitList(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = unquote(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+var blankLinesRegexp = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+\n`)
+
+// htmlToMarkdown converts the html content of a feed item to markdown. Only
+// the common text formatting, links and images are converted; other elements
+// are reduced to their text. Relative links are resolved against base (if not
+// nil).
+func htmlToMarkdown(s string, base *url.URL) string {
+	resolve := func(ref string) string {
+		u, err := url.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return ""
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		return u.String()
+	}
+
+	var b strings.Builder
+	var links []string
+	var listDepth, preDepth, skipDepth int
+	block := func(prefix string) {
+		b.WriteString("\n\n")
+		b.WriteString(prefix)
+	}
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		attr := func(key string) string {
+			for _, a := range tok.Attr {
+				if a.Key == key {
+					return a.Val
+				}
+			}
+			return ""
+		}
+
+		switch tt {
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := tok.Data
+			if preDepth == 0 {
+				text = strings.Join(strings.Fields(text), " ")
+				if text == "" {
+					continue
+				}
+				// Keep the spacing between inline elements.
+				if strings.TrimLeft(tok.Data, " \t\n\r") != tok.Data {
+					text = " " + text
+				}
+				if strings.TrimRight(tok.Data, " \t\n\r") != tok.Data {
+					text += " "
+				}
+			}
+			b.WriteString(text)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.Data {
+			case "script", "style":
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+			case "p", "div", "section", "article", "table", "tr":
+				block("")
+			case "br":
+				b.WriteString("  \n")
+			case "hr":
+				block("---\n\n")
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				block(strings.Repeat("#", int(tok.Data[1]-'0')) + " ")
+			case "blockquote":
+				block("> ")
+			case "ul", "ol":
+				listDepth++
+			case "li":
+				b.WriteString("\n")
+				if listDepth > 1 {
+					b.WriteString(strings.Repeat("  ", listDepth-1))
+				}
+				b.WriteString("- ")
+			case "pre":
+				preDepth++
+				block("```\n")
+			case "code":
+				if preDepth == 0 {
+					b.WriteString("`")
+				}
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("_")
+			case "a":
+				links = append(links, resolve(attr("href")))
+				b.WriteString("[")
+			case "img":
+				src := resolve(attr("src"))
+				if src != "" {
+					fmt.Fprintf(&b, "![%s](%s)", attr("alt"), src)
+				}
+			}
+
+		case html.EndTagToken:
+			switch tok.Data {
+			case "script", "style":
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case "p", "div", "section", "article", "table", "tr",
+				"h1", "h2", "h3", "h4", "h5", "h6", "blockquote":
+				b.WriteString("\n\n")
+			case "ul", "ol":
+				if listDepth > 0 {
+					listDepth--
+				}
+				b.WriteString("\n\n")
+			case "pre":
+				if preDepth > 0 {
+					preDepth--
+				}
+				b.WriteString("\n```\n\n")
+			case "code":
+				if preDepth == 0 {
+					b.WriteString("`")
+				}
+			case "strong", "b":
+				b.WriteString("**")
+			case "em", "i":
+				b.WriteString("_")
+			case "a":
+				var href string
+				if len(links) > 0 {
+					href, links = links[len(links)-1], links[:len(links)-1]
+				}
+				fmt.Fprintf(&b, "](%s)", href)
+			}
+		}
+	}
+
+	res := blankLinesRegexp.ReplaceAllString(b.String(), "\n\n")
+	return strings.TrimSpace(res)
+}
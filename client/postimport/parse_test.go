@@ -0,0 +1,232 @@
+package postimport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+)
+
+// pngData is a minimal 1x1 png image.
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01" +
+	"\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89\x00\x00\x00\rIDATx" +
+	"\x9cc\xf8\x0f\x00\x00\x01\x01\x00\x05\x18\xd8N\x00\x00\x00\x00IEND\xaeB`\x82")
+
+// TestParseRSS tests parsing RSS feeds.
+func TestParseRSS(t *testing.T) {
+	const feed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Blog</title>
+  <link>https://example.com/blog/</link>
+  <item>
+    <guid>second</guid>
+    <title>Second &amp; last</title>
+    <link>https://example.com/blog/second</link>
+    <pubDate>Sat, 02 Mar 2024 10:00:00 +0000</pubDate>
+    <description>summary</description>
+    <content:encoded><![CDATA[<p>Hello <b>world</b>, see <a href="/about">this</a>.</p><img src="img.png" alt="pic">]]></content:encoded>
+    <category>Go Lang</category>
+  </item>
+  <item>
+    <title>First</title>
+    <link>https://example.com/blog/first</link>
+    <pubDate>Fri, 01 Mar 2024 10:00:00 +0000</pubDate>
+    <description>&lt;p&gt;first&lt;/p&gt;</description>
+  </item>
+  <item>
+    <title>No content</title>
+    <guid>empty</guid>
+  </item>
+</channel>
+</rss>`
+
+	items, err := ParseFeed(strings.NewReader(feed), "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("unexpected nb of items: %d", len(items))
+	}
+
+	// Items are sorted from oldest to newest.
+	first, second := items[0], items[1]
+	if first.ID != "feed:https://example.com/blog/first" || first.Body != "first" {
+		t.Fatalf("unexpected first item: %+v", first)
+	}
+	if second.ID != "feed:second" || second.Title != "Second & last" {
+		t.Fatalf("unexpected second item: %+v", second)
+	}
+	wantBody := "Hello **world**, see [this](https://example.com/about).\n\n" +
+		"![pic](https://example.com/blog/img.png)"
+	if second.Body != wantBody {
+		t.Fatalf("unexpected body: got %q, want %q", second.Body, wantBody)
+	}
+	wantDate := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	if !second.Date.Equal(wantDate) {
+		t.Fatalf("unexpected date: %v", second.Date)
+	}
+	if len(second.Tags) != 1 || second.Tags[0] != "Go Lang" {
+		t.Fatalf("unexpected tags: %v", second.Tags)
+	}
+}
+
+// TestParseAtom tests parsing Atom feeds.
+func TestParseAtom(t *testing.T) {
+	const feed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <entry>
+    <id>urn:post:1</id>
+    <title>Html post</title>
+    <link rel="alternate" href="https://example.com/1"/>
+    <published>2024-03-01T10:00:00Z</published>
+    <content type="html">&lt;h2&gt;Title&lt;/h2&gt;&lt;ul&gt;&lt;li&gt;one&lt;/li&gt;&lt;li&gt;two&lt;/li&gt;&lt;/ul&gt;</content>
+    <category term="news"/>
+  </entry>
+  <entry>
+    <id>urn:post:2</id>
+    <title>Xhtml post</title>
+    <updated>2024-03-02T10:00:00Z</updated>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>x<i>html</i></p></div></content>
+  </entry>
+  <entry>
+    <id>urn:post:3</id>
+    <title>Text post</title>
+    <updated>2024-03-03T10:00:00Z</updated>
+    <summary>plain &lt;text&gt;</summary>
+  </entry>
+</feed>`
+
+	items, err := ParseFeed(strings.NewReader(feed), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("unexpected nb of items: %d", len(items))
+	}
+	tests := []struct {
+		id   string
+		body string
+	}{
+		{"feed:urn:post:1", "## Title\n\n- one\n- two"},
+		{"feed:urn:post:2", "x_html_"},
+		{"feed:urn:post:3", "plain <text>"},
+	}
+	for i, tc := range tests {
+		if items[i].ID != tc.id || items[i].Body != tc.body {
+			t.Fatalf("unexpected item %d: got %q %q, want %q %q", i,
+				items[i].ID, items[i].Body, tc.id, tc.body)
+		}
+	}
+	if items[0].Link != "https://example.com/1" || len(items[0].Tags) != 1 {
+		t.Fatalf("unexpected first item: %+v", items[0])
+	}
+}
+
+// TestReadMarkdownDir tests reading a dir of markdown files.
+func TestReadMarkdownDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fname, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.md", "---\ntitle: \"Front matter\"\ndate: 2024-03-02\n"+
+		"tags: [one, two]\n---\nbody b\n")
+	write("posts/a.markdown", "---\ndate: 2024-03-01 10:00\ntags:\n"+
+		"  - three\n---\n# Heading title\n\nbody a\n")
+	write("draft.md", "---\ndraft: true\n---\ndraft\n")
+	write("notes.txt", "not markdown")
+
+	items, err := ReadMarkdownDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("unexpected nb of items: %d", len(items))
+	}
+	a, b := items[0], items[1]
+	if a.ID != "file:posts/a.markdown" || a.Title != "Heading title" ||
+		a.Body != "# Heading title\n\nbody a" || len(a.Tags) != 1 || a.Tags[0] != "three" {
+		t.Fatalf("unexpected first item: %+v", a)
+	}
+	if b.ID != "file:b.md" || b.Title != "Front matter" || b.Body != "body b" ||
+		len(b.Tags) != 2 || b.Tags[1] != "two" {
+		t.Fatalf("unexpected second item: %+v", b)
+	}
+	if a.base != filepath.Join(dir, "posts") {
+		t.Fatalf("unexpected base: %s", a.base)
+	}
+}
+
+// TestEmbedMedia tests embedding the media referenced in items.
+func TestEmbedMedia(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "img.png"), pngData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "big.png"), make([]byte, 1024), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/remote.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	imp := New(Config{MaxMediaSize: 512})
+	item := &Item{
+		ID:   "file:test.md",
+		Body: "![local](img.png) ![big](big.png) ![](missing.png) ![remote](" + svr.URL + "/remote.png)",
+		base: dir,
+	}
+
+	// Remote media is not fetched unless enabled.
+	wantEmbed := mdembeds.EmbeddedArgs{Alt: "local", Typ: "image/png", Data: pngData}.String()
+	want := wantEmbed + " big  [remote](" + svr.URL + "/remote.png)"
+	if got := imp.embedMedia(ctx, item); got != want {
+		t.Fatalf("unexpected body: got %q, want %q", got, want)
+	}
+
+	imp = New(Config{MaxMediaSize: 512, FetchMedia: true})
+	wantRemote := mdembeds.EmbeddedArgs{Alt: "remote", Typ: "image/png", Data: pngData}.String()
+	want = wantEmbed + " big  " + wantRemote
+	if got := imp.embedMedia(ctx, item); got != want {
+		t.Fatalf("unexpected body: got %q, want %q", got, want)
+	}
+
+	// Relative media of feed items is resolved against their URL.
+	item = &Item{ID: "feed:1", Body: "![x](remote.png) ![y](/nothere.png)", base: svr.URL + "/post"}
+	want = mdembeds.EmbeddedArgs{Alt: "x", Typ: "image/png", Data: pngData}.String() +
+		" [y](" + svr.URL + "/nothere.png)"
+	if got := imp.embedMedia(ctx, item); got != want {
+		t.Fatalf("unexpected body: got %q, want %q", got, want)
+	}
+}
+
+// TestPostTags tests converting item tags to post tags.
+func TestPostTags(t *testing.T) {
+	item := &Item{Tags: []string{"Go Lang", "#news", "bad!tag", ""}}
+	got := postTags(item)
+	if len(got) != 2 || got[0] != "go-lang" || got[1] != "news" {
+		t.Fatalf("unexpected tags: %v", got)
+	}
+}
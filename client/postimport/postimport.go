@@ -0,0 +1,353 @@
+// Package postimport imports posts from external blogs, so that writers may
+// mirror their existing content as Bison Relay posts.
+//
+// Posts may be imported from an RSS or Atom feed (fetched from a URL or read
+// from a file) or from a dir of markdown files. Images referenced by the posts
+// are embedded in them, and posts are created at a limited rate, to avoid
+// flooding subscribers with a burst of posts. Items that were already imported
+// are skipped, so importing from the same source again only creates the posts
+// added since the last import.
+package postimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/slog"
+)
+
+const (
+	// defaultInterval is the default interval between created posts.
+	defaultInterval = 10 * time.Second
+
+	// defaultMaxMediaSize is the default max size of embedded images.
+	defaultMaxMediaSize = 512 * 1024
+
+	// maxFeedSize is the max size of a feed.
+	maxFeedSize = 16 * 1024 * 1024
+
+	// fetchTimeout is the timeout for fetching feeds and media.
+	fetchTimeout = 30 * time.Second
+)
+
+// Config holds the configuration for the post importer.
+type Config struct {
+	// Client is the client where posts are created.
+	Client *client.Client
+
+	// Interval is the interval between created posts. If unspecified, a
+	// default of 10 seconds is used.
+	Interval time.Duration
+
+	// MaxItems is the max number of posts created in each import. Zero
+	// means no limit.
+	MaxItems int
+
+	// MaxMediaSize is the max size of images embedded in posts. Larger
+	// images are kept as links. If unspecified, a default of 512KiB is
+	// used.
+	MaxMediaSize int
+
+	// FetchMedia is whether to fetch and embed remote images. If false,
+	// only images read from the local filesystem are embedded, while
+	// remote images are kept as links.
+	FetchMedia bool
+
+	// HTTPClient is the client used to fetch feeds and media. If nil, the
+	// default http client is used.
+	HTTPClient *http.Client
+
+	// OnImported is called after each imported post is created.
+	OnImported func(item *Item, pid clientintf.PostID)
+
+	Log slog.Logger
+}
+
+// Result is the result of an import.
+type Result struct {
+	// Imported are the IDs of the created posts.
+	Imported []clientintf.PostID
+
+	// Skipped is the number of items skipped because they were already
+	// imported.
+	Skipped int
+
+	// Remaining is the number of items not imported due to the max number
+	// of items per import.
+	Remaining int
+}
+
+// Importer imports posts from external sources.
+type Importer struct {
+	cfg Config
+	log slog.Logger
+
+	// mtx is held while an import is running, so that imports do not
+	// run concurrently.
+	mtx sync.Mutex
+}
+
+// New creates a new post importer.
+func New(cfg Config) *Importer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.MaxMediaSize <= 0 {
+		cfg.MaxMediaSize = defaultMaxMediaSize
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	log := slog.Disabled
+	if cfg.Log != nil {
+		log = cfg.Log
+	}
+	return &Importer{cfg: cfg, log: log}
+}
+
+// isURL returns true if the source is an http or https URL.
+func isURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
+
+// fetch fetches the contents of the URL, up to maxSize bytes. Returns the
+// contents and their mime type.
+func (imp *Importer) fetch(ctx context.Context, u string, maxSize int) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := imp.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status fetching %s: %s", u,
+			res.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxSize {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", u, maxSize)
+	}
+	return data, res.Header.Get("Content-Type"), nil
+}
+
+// Items reads the items of the source, which may be the URL of a feed, the
+// path to a feed file or the path to a dir of markdown files.
+func (imp *Importer) Items(ctx context.Context, src string) ([]Item, error) {
+	if isURL(src) {
+		data, _, err := imp.fetch(ctx, src, maxFeedSize)
+		if err != nil {
+			return nil, err
+		}
+		return ParseFeed(strings.NewReader(string(data)), src)
+	}
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return ReadMarkdownDir(src)
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseFeed(io.LimitReader(f, maxFeedSize), "")
+}
+
+var mdImageRegexp = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+// readMedia reads the image at the given (possibly relative) reference of an
+// item. Returns the data and the mime type of the image.
+func (imp *Importer) readMedia(ctx context.Context, item *Item, ref string) ([]byte, string, error) {
+	if isURL(item.base) || isURL(ref) {
+		u, err := url.Parse(resolveBase(item.base, ref))
+		if err != nil {
+			return nil, "", err
+		}
+		if !isURL(u.String()) {
+			return nil, "", fmt.Errorf("unsupported media URL %q", u)
+		}
+		if !imp.cfg.FetchMedia {
+			return nil, "", errors.New("fetching remote media is disabled")
+		}
+		data, typ, err := imp.fetch(ctx, u.String(), imp.cfg.MaxMediaSize)
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(typ, "image/") {
+			typ = http.DetectContentType(data)
+		}
+		return data, typ, nil
+	}
+
+	if item.base == "" || filepath.IsAbs(ref) || strings.Contains(ref, ":") {
+		return nil, "", fmt.Errorf("unsupported media reference %q", ref)
+	}
+	ref, err := url.PathUnescape(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	fname := filepath.Join(item.base, filepath.FromSlash(ref))
+	fi, err := os.Stat(fname)
+	if err != nil {
+		return nil, "", err
+	}
+	if fi.Size() > int64(imp.cfg.MaxMediaSize) {
+		return nil, "", fmt.Errorf("%s is larger than %d bytes", fname,
+			imp.cfg.MaxMediaSize)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, http.DetectContentType(data), nil
+}
+
+// embedMedia replaces the images referenced in the body of the item with
+// embeds of the images. Images that cannot be read are replaced by links to
+// them (if remote) or by their alt text.
+func (imp *Importer) embedMedia(ctx context.Context, item *Item) string {
+	return mdImageRegexp.ReplaceAllStringFunc(item.Body, func(s string) string {
+		m := mdImageRegexp.FindStringSubmatch(s)
+		alt, ref := m[1], m[2]
+		data, typ, err := imp.readMedia(ctx, item, ref)
+		if err == nil && !strings.HasPrefix(typ, "image/") {
+			err = fmt.Errorf("unsupported media type %q", typ)
+		}
+		if err != nil {
+			imp.log.Debugf("Not embedding media %q of %s: %v", ref,
+				item.ID, err)
+			if u := resolveBase(item.base, ref); isURL(u) {
+				if alt == "" {
+					alt = "image"
+				}
+				return fmt.Sprintf("[%s](%s)", alt, u)
+			}
+			return alt
+		}
+
+		typ, _, _ = strings.Cut(typ, ";")
+		args := mdembeds.EmbeddedArgs{
+			Alt:  url.PathEscape(alt),
+			Typ:  strings.TrimSpace(typ),
+			Data: data,
+		}
+		return args.String()
+	})
+}
+
+// postTags returns the tags of the item that are valid post tags.
+func postTags(item *Item) []string {
+	var res []string
+	for _, tag := range item.Tags {
+		tag = strings.Join(strings.Fields(rpc.NormalizePostTag(tag)), "-")
+		if _, err := rpc.FormatPostTags([]string{tag}); err != nil {
+			continue
+		}
+		res = append(res, tag)
+		if len(res) >= rpc.MaxPostTags {
+			break
+		}
+	}
+	return res
+}
+
+// postBody returns the body of the post created for the item.
+func (imp *Importer) postBody(ctx context.Context, item *Item) string {
+	body := imp.embedMedia(ctx, item)
+	if item.Link == "" {
+		return body
+	}
+	body += fmt.Sprintf("\n\n[Originally published](%s)", item.Link)
+	if !item.Date.IsZero() {
+		body += fmt.Sprintf(" on %s", item.Date.Format("2006-01-02"))
+	}
+	return body
+}
+
+// Import imports the items of the source (see Items) as posts, from oldest to
+// newest. Posts are created at most once per configured interval, until all
+// items have been imported, the max number of items is reached or the passed
+// context is canceled. Only one import runs at a time.
+func (imp *Importer) Import(ctx context.Context, src string) (Result, error) {
+	imp.mtx.Lock()
+	defer imp.mtx.Unlock()
+
+	var res Result
+	items, err := imp.Items(ctx, src)
+	if err != nil {
+		return res, err
+	}
+
+	c := imp.cfg.Client
+	var lastPost time.Time
+	for i := range items {
+		item := &items[i]
+		if imp.cfg.MaxItems > 0 && len(res.Imported) >= imp.cfg.MaxItems {
+			res.Remaining = len(items) - i
+			break
+		}
+
+		// Skip items already imported before fetching their media.
+		_, err := c.GetImportedPost(item.ID)
+		if err == nil {
+			res.Skipped += 1
+			continue
+		}
+		if !errors.Is(err, clientdb.ErrNotFound) {
+			return res, err
+		}
+
+		// Wait for the interval since the last created post.
+		if !lastPost.IsZero() {
+			select {
+			case <-time.After(time.Until(lastPost.Add(imp.cfg.Interval))):
+			case <-ctx.Done():
+				return res, ctx.Err()
+			}
+		}
+
+		body := imp.postBody(ctx, item)
+		pid, created, err := c.ImportPost(item.ID, item.Title, body, postTags(item))
+		if created {
+			lastPost = time.Now()
+			res.Imported = append(res.Imported, pid)
+			if imp.cfg.OnImported != nil {
+				imp.cfg.OnImported(item, pid)
+			}
+		} else if err == nil {
+			res.Skipped += 1
+		}
+		if err != nil {
+			return res, fmt.Errorf("unable to import %s: %w", item.ID, err)
+		}
+	}
+	imp.log.Infof("Imported %d posts from %s (%d already imported)",
+		len(res.Imported), src, res.Skipped)
+	return res, nil
+}
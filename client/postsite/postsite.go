@@ -0,0 +1,375 @@
+// Package postsite exports posts as a static HTML site, so that they may be
+// archived and hosted outside of Bison Relay.
+//
+// The site has an index page with the list of posts and one page per post
+// (optionally with its comments). Images embedded in the posts are written as
+// separate files, so that the site can be served by any static web server.
+package postsite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/slog"
+)
+
+const (
+	postsDir = "posts"
+	mediaDir = "media"
+)
+
+// Config holds the configuration for exporting a site.
+type Config struct {
+	// Client is the client whose posts are exported.
+	Client *client.Client
+
+	// Author is the author of the exported posts. If nil, the posts
+	// created by the local client are exported.
+	Author *clientintf.UserID
+
+	// Comments is whether to include the comments of the posts. Comments
+	// hidden by the author of the post are not included.
+	Comments bool
+
+	// Paywalled is whether to include the body of paywalled posts that
+	// were unlocked by the local client. If false, only the free summary
+	// of paywalled posts is included.
+	Paywalled bool
+
+	Log slog.Logger
+}
+
+// Result is the result of exporting a site.
+type Result struct {
+	// Posts is the number of exported posts.
+	Posts int
+
+	// Media is the number of exported media files.
+	Media int
+}
+
+// segment is a part of the content of a post or comment: either text, an
+// image, a file or a reference to content not included in the site.
+type segment struct {
+	Text     string
+	Image    string
+	Alt      string
+	File     string
+	Filename string
+	Missing  string
+}
+
+// comment is a comment in a post page.
+type comment struct {
+	From    string
+	Date    time.Time
+	Depth   int
+	Hidden  bool
+	Flagged bool
+	Content []segment
+}
+
+// post is a post in the site.
+type post struct {
+	ID           clientintf.PostID
+	Title        string
+	AuthorNick   string
+	AuthorID     clientintf.UserID
+	Date         time.Time
+	Tags         []string
+	RelayComment string
+	Content      []segment
+	Comments     []comment
+}
+
+// Href returns the link to the page of the post, relative to the index.
+func (p *post) Href() string {
+	return postsDir + "/" + p.ID.String() + ".html"
+}
+
+// site is the data used to render the index page.
+type site struct {
+	Title      string
+	AuthorID   clientintf.UserID
+	ExportedAt time.Time
+	Posts      []*post
+}
+
+// exporter holds the state of an export.
+type exporter struct {
+	cfg    Config
+	log    slog.Logger
+	outDir string
+	media  map[string]string
+}
+
+// writeFile writes the file atomically, so that a site being served is never
+// seen partially written.
+func writeFile(fname string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+		return err
+	}
+	tmpName := fname + ".tmp"
+	if err := os.WriteFile(tmpName, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, fname)
+}
+
+// writeMedia writes the data of an embed as a media file. Returns the link to
+// the file, relative to the post pages. Equal data is written only once.
+func (e *exporter) writeMedia(typ string, data []byte) (string, error) {
+	hash := sha256.Sum256(data)
+	name := hex.EncodeToString(hash[:16])
+	if link, ok := e.media[name]; ok {
+		return link, nil
+	}
+
+	ext := ".bin"
+	if exts, _ := mime.ExtensionsByType(typ); len(exts) > 0 {
+		ext = exts[0]
+	}
+	name += ext
+	if err := writeFile(filepath.Join(e.outDir, mediaDir, name), data); err != nil {
+		return "", err
+	}
+	link := "../" + mediaDir + "/" + name
+	e.media[name] = link
+	return link, nil
+}
+
+// segments splits the content of a post or comment into segments, writing
+// the data of embeds as media files.
+func (e *exporter) segments(content string, replace map[clientdb.FileID][]segment) ([]segment, error) {
+	var res []segment
+	var last int
+	for _, idx := range mdembeds.FindAllStringIndex(content) {
+		if text := content[last:idx[0]]; strings.TrimSpace(text) != "" {
+			res = append(res, segment{Text: strings.Trim(text, "\n")})
+		}
+		last = idx[1]
+
+		args := mdembeds.ParseEmbedArgs(content[idx[0]:idx[1]])
+		if r, ok := replace[args.Download]; ok && !args.Download.IsEmpty() {
+			res = append(res, r...)
+			continue
+		}
+
+		seg := segment{Alt: args.Alt, Filename: args.Filename}
+		switch {
+		case len(args.Data) > 0 && strings.HasPrefix(args.Typ, "text/"):
+			seg.Text = string(args.Data)
+		case len(args.Data) > 0:
+			link, err := e.writeMedia(args.Typ, args.Data)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(args.Typ, "image/") {
+				seg.Image = link
+			} else {
+				seg.File = link
+				if seg.Filename == "" {
+					seg.Filename = args.Typ + " file"
+				}
+			}
+		case !args.Download.IsEmpty():
+			name := args.Filename
+			if name == "" {
+				name = args.Download.String()
+			}
+			seg.Missing = fmt.Sprintf("File %s, available on Bison Relay", name)
+		default:
+			seg.Missing = "Embedded content"
+		}
+		res = append(res, seg)
+	}
+	if text := content[last:]; strings.TrimSpace(text) != "" {
+		res = append(res, segment{Text: strings.Trim(text, "\n")})
+	}
+	return res, nil
+}
+
+// flattenComments converts the tree of comments into a list, in the order
+// they are displayed.
+func (e *exporter) flattenComments(roots []*client.PostComment, res []comment) ([]comment, error) {
+	for _, pc := range roots {
+		attr := pc.Status.Attributes
+		cmt := comment{
+			From:    attr[rpc.RMPFromNick],
+			Depth:   pc.Depth,
+			Hidden:  pc.Hidden,
+			Flagged: pc.Flagged,
+		}
+		if cmt.From == "" {
+			cmt.From = pc.Status.From
+		}
+		if ts, err := strconv.ParseInt(attr[rpc.RMPTimestamp], 16, 64); err == nil {
+			cmt.Date = time.Unix(ts, 0)
+		}
+		if !pc.Hidden {
+			var err error
+			cmt.Content, err = e.segments(attr[rpc.RMPSComment], nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, cmt)
+
+		var err error
+		res, err = e.flattenComments(pc.Children, res)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// post reads the post to be exported.
+func (e *exporter) post(summ *clientdb.PostSummary) (*post, error) {
+	c := e.cfg.Client
+	pm, err := c.ReadPost(summ.From, summ.ID)
+	if err != nil {
+		return nil, err
+	}
+	p := &post{
+		ID:           summ.ID,
+		Title:        summ.Title,
+		AuthorNick:   summ.AuthorNick,
+		AuthorID:     summ.AuthorID,
+		Date:         summ.Date,
+		Tags:         summ.Tags,
+		RelayComment: summ.RelayComment,
+	}
+
+	// Replace the link to the paywalled body of the post with the body,
+	// if it was unlocked and should be included.
+	replace := make(map[clientdb.FileID][]segment)
+	if link := client.PostPaywall(&pm); link != nil {
+		missing := []segment{{Missing: "The full post is available on Bison Relay"}}
+		replace[link.Download] = missing
+		unlock, err := c.GetPostUnlock(summ.ID)
+		switch {
+		case errors.Is(err, clientdb.ErrNotFound):
+		case err != nil:
+			return nil, err
+		case e.cfg.Paywalled && unlock.Completed != nil:
+			body, err := e.segments(unlock.Body, nil)
+			if err != nil {
+				return nil, err
+			}
+			replace[link.Download] = body
+		}
+	}
+	if p.Content, err = e.segments(pm.Attributes[rpc.RMPMain], replace); err != nil {
+		return nil, err
+	}
+
+	if e.cfg.Comments {
+		roots, err := c.PostCommentTree(summ.From, summ.ID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Comments, err = e.flattenComments(roots, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Export renders the posts as a static site in the output dir. Existing files
+// of a previous export to the same dir are overwritten.
+func Export(cfg Config, outDir string) (Result, error) {
+	var res Result
+	log := slog.Disabled
+	if cfg.Log != nil {
+		log = cfg.Log
+	}
+	e := &exporter{
+		cfg:    cfg,
+		log:    log,
+		outDir: outDir,
+		media:  make(map[string]string),
+	}
+
+	c := cfg.Client
+	author := c.PublicID()
+	if cfg.Author != nil {
+		author = *cfg.Author
+	}
+	summaries, err := c.ListPosts()
+	if err != nil {
+		return res, err
+	}
+
+	// The same post may have been received from multiple relayers. Prefer
+	// the copy received directly from the author.
+	byID := make(map[clientintf.PostID]clientdb.PostSummary)
+	for _, summ := range summaries {
+		if summ.AuthorID != author {
+			continue
+		}
+		if _, ok := byID[summ.ID]; ok && summ.From != author {
+			continue
+		}
+		byID[summ.ID] = summ
+	}
+
+	s := &site{AuthorID: author, ExportedAt: time.Now()}
+	for _, summ := range byID {
+		summ := summ
+		p, err := e.post(&summ)
+		if err != nil {
+			return res, fmt.Errorf("unable to export post %s: %w", summ.ID, err)
+		}
+		if s.Title == "" {
+			s.Title = fmt.Sprintf("Posts by %s", p.AuthorNick)
+		}
+
+		var b bytes.Buffer
+		if err := postTmpl.Execute(&b, p); err != nil {
+			return res, err
+		}
+		fname := filepath.Join(outDir, filepath.FromSlash(p.Href()))
+		if err := writeFile(fname, b.Bytes()); err != nil {
+			return res, err
+		}
+		s.Posts = append(s.Posts, p)
+	}
+	if s.Title == "" {
+		s.Title = "Posts"
+	}
+	sort.Slice(s.Posts, func(i, j int) bool {
+		return s.Posts[i].Date.After(s.Posts[j].Date)
+	})
+
+	var b bytes.Buffer
+	if err := indexTmpl.Execute(&b, s); err != nil {
+		return res, err
+	}
+	if err := writeFile(filepath.Join(outDir, "index.html"), b.Bytes()); err != nil {
+		return res, err
+	}
+	if err := writeFile(filepath.Join(outDir, "style.css"), []byte(styleCSS)); err != nil {
+		return res, err
+	}
+
+	res.Posts = len(s.Posts)
+	res.Media = len(e.media)
+	log.Infof("Exported %d posts by %s to %s", res.Posts, author, outDir)
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package postsite
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+)
+
+// TestSegments tests splitting content into segments.
+func TestSegments(t *testing.T) {
+	e := &exporter{outDir: t.TempDir(), media: make(map[string]string)}
+
+	img := mdembeds.EmbeddedArgs{Alt: "pic", Typ: "image/png", Data: []byte("png data")}
+	txt := mdembeds.EmbeddedArgs{Typ: "text/plain", Data: []byte("some text")}
+	file := mdembeds.EmbeddedArgs{Download: clientdb.FileID{0: 1}, Filename: "song.mp3"}
+	paywall := mdembeds.EmbeddedArgs{Download: clientdb.FileID{0: 2}, Cost: 1000}
+	content := "intro\n" + img.String() + "\n" + txt.String() + file.String() +
+		img.String() + paywall.String() + "\noutro"
+
+	replace := map[clientdb.FileID][]segment{
+		paywall.Download: {{Text: "full body"}},
+	}
+	got, err := e.segments(content, replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []segment{
+		{Text: "intro"},
+		{Image: got[1].Image, Alt: "pic"},
+		{Text: "some text"},
+		{Filename: "song.mp3", Missing: "File song.mp3, available on Bison Relay"},
+		{Image: got[1].Image, Alt: "pic"},
+		{Text: "full body"},
+		{Text: "outro"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected nb of segments: got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected segment %d: got %+v, want %+v", i,
+				got[i], want[i])
+		}
+	}
+
+	// The image is written only once.
+	if len(e.media) != 1 || !strings.HasPrefix(got[1].Image, "../media/") {
+		t.Fatalf("unexpected media: %v", e.media)
+	}
+	fname := filepath.Join(e.outDir, filepath.FromSlash(strings.TrimPrefix(got[1].Image, "../")))
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "png data" {
+		t.Fatalf("unexpected media data: %q", data)
+	}
+}
+
+// TestTemplates tests rendering the site pages.
+func TestTemplates(t *testing.T) {
+	p := &post{
+		ID:         clientintf.PostID{0: 1},
+		Title:      "A <post>",
+		AuthorNick: "alice",
+		Date:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Tags:       []string{"news"},
+		Content:    []segment{{Text: "hello <b>"}, {Image: "../media/x.png", Alt: "pic"}},
+		Comments: []comment{
+			{From: "bob", Content: []segment{{Text: "first"}}},
+			{From: "charlie", Depth: 1, Hidden: true},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := postTmpl.Execute(&b, p); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, s := range []string{
+		"<title>A &lt;post&gt;</title>",
+		"hello &lt;b&gt;",
+		`<img src="../media/x.png" alt="pic">`,
+		"#news",
+		`style="margin-left: 2em"`,
+		"[Comment hidden by the author]",
+	} {
+		if !strings.Contains(page, s) {
+			t.Fatalf("post page does not contain %q: %s", s, page)
+		}
+	}
+
+	b.Reset()
+	s := &site{Title: "Posts by alice", Posts: []*post{p}}
+	if err := indexTmpl.Execute(&b, s); err != nil {
+		t.Fatal(err)
+	}
+	index := b.String()
+	wantLink := `<a href="posts/` + p.ID.String() + `.html">A &lt;post&gt;</a>`
+	if !strings.Contains(index, wantLink) {
+		t.Fatalf("index does not contain link to post: %s", index)
+	}
+}
@@ -0,0 +1,106 @@
+package postsite
+
+import (
+	"html/template"
+	"time"
+)
+
+const styleCSS = `body { font-family: sans-serif; max-width: 48em; margin: 2em auto; padding: 0 1em; }
+a { color: #2970ff; }
+.meta { color: #888; font-size: 0.9em; }
+.tag { margin-right: 0.5em; }
+.relay { border-left: 3px solid #ccc; padding-left: 1em; }
+.text { white-space: pre-wrap; }
+.content img { max-width: 100%; }
+.missing { color: #888; font-style: italic; }
+.comment { margin: 1em 0; }
+.comment .text { margin: 0.3em 0; }
+ul.posts { list-style: none; padding: 0; }
+ul.posts li { margin: 0.5em 0; }
+`
+
+var tmplFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"ts": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+	"indent": func(depth int) int {
+		return depth * 2
+	},
+}
+
+// contentTmpl renders the segments of a post or comment.
+const contentTmpl = `{{ define "content" }}
+{{- range . }}
+{{- if .Image }}
+<p><img src="{{ .Image }}" alt="{{ .Alt }}"></p>
+{{- else if .File }}
+<p><a download="{{ .Filename }}" href="{{ .File }}">{{ .Filename }}</a></p>
+{{- else if .Missing }}
+<p class="missing">[{{ .Missing }}]</p>
+{{- else }}
+<div class="text">{{ .Text }}</div>
+{{- end }}
+{{- end }}
+{{- end }}`
+
+var postTmpl = template.Must(template.New("post").Funcs(tmplFuncs).Parse(contentTmpl + `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<link rel="stylesheet" href="../style.css">
+</head>
+<body>
+<p><a href="../index.html">&larr; All posts</a></p>
+<h1>{{ .Title }}</h1>
+<p class="meta">By {{ .AuthorNick }} on {{ ts .Date }}
+{{- range .Tags }} <span class="tag">#{{ . }}</span>{{ end }}</p>
+{{- if .RelayComment }}
+<div class="relay text">{{ .RelayComment }}</div>
+{{- end }}
+<div class="content">
+{{- template "content" .Content }}
+</div>
+{{- if .Comments }}
+<h2>Comments</h2>
+{{- range .Comments }}
+<div class="comment" style="margin-left: {{ indent .Depth }}em">
+<div class="meta">{{ .From }}{{ if not .Date.IsZero }} on {{ ts .Date }}{{ end }}{{ if .Flagged }} (flagged by the author){{ end }}</div>
+{{- if .Hidden }}
+<p class="missing">[Comment hidden by the author]</p>
+{{- else }}
+{{- template "content" .Content }}
+{{- end }}
+</div>
+{{- end }}
+{{- end }}
+<p class="meta">Post {{ .ID }} by {{ .AuthorID }}</p>
+</body>
+</html>
+`))
+
+var indexTmpl = template.Must(template.New("index").Funcs(tmplFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<ul class="posts">
+{{- range .Posts }}
+<li><span class="meta">{{ date .Date }}</span> <a href="{{ .Href }}">{{ .Title }}</a></li>
+{{- else }}
+<li>No posts</li>
+{{- end }}
+</ul>
+<p class="meta">Archive of posts by {{ .AuthorID }}, exported on {{ ts .ExportedAt }}.</p>
+</body>
+</html>
+`))
@@ -74,6 +74,7 @@ type RemoteUser struct {
 	ratchetChan     chan *ratchet.Ratchet
 	decryptedRMChan chan error
 	sentRMChan      chan error
+	rvsSubChanged   chan struct{}
 	compressLevel   int
 	myResetRV       clientdb.RawRVID
 	theirResetRV    clientdb.RawRVID
@@ -81,13 +82,28 @@ type RemoteUser struct {
 	nick atomic.Pointer[string]
 
 	// mtx protects the following fields.
-	mtx     sync.Mutex
-	ignored bool
+	mtx           sync.Mutex
+	ignored       bool
+	pendingRMs    map[uint64]*remoteUserRM
+	nextPendingID uint64
+
+	// rvsUnsubbed is true when the client should not be subscribed to
+	// the receive RVs of this user.
+	rvsUnsubbed bool
 
 	// rmHandler is called whenever we receive a RM from this user. This is
 	// called as a goroutine.
 	rmHandler func(ru *RemoteUser, h *rpc.RMHeader, c interface{}, ts time.Time)
 
+	// msgStatusHandler is called whenever the delivery status of a chat
+	// message sent to this user changes.
+	msgStatusHandler func(ru *RemoteUser, msgID zkidentity.ShortID, status MsgDeliveryStatus, err error)
+
+	// decryptFailedHandler is called whenever a message received from
+	// this user fails to be decrypted, with the number of consecutive
+	// failures. This is called as a goroutine.
+	decryptFailedHandler func(ru *RemoteUser, failed int)
+
 	// rmHandlerWG tracks calls to the rmHandler that need to complete
 	// before run() returns.
 	rmHandlerWG sync.WaitGroup
@@ -99,6 +115,10 @@ type RemoteUser struct {
 	rLock  sync.Mutex
 	r      *ratchet.Ratchet
 	rError error
+
+	// failedDecrypts is the number of consecutive received messages that
+	// failed to be decrypted. Protected by rLock.
+	failedDecrypts int
 }
 
 func newRemoteUser(q rmqIntf, rmgr rdzvManagerIntf, db *clientdb.DB,
@@ -122,6 +142,8 @@ func newRemoteUser(q rmqIntf, rmgr rdzvManagerIntf, db *clientdb.DB,
 		ratchetChan:     make(chan *ratchet.Ratchet),
 		decryptedRMChan: make(chan error),
 		sentRMChan:      make(chan error),
+		rvsSubChanged:   make(chan struct{}, 1),
+		pendingRMs:      make(map[uint64]*remoteUserRM),
 	}
 	ru.setNick(remoteID.Nick)
 	return ru
@@ -203,6 +225,41 @@ func (ru *RemoteUser) LastRatchetTimes() (time.Time, time.Time) {
 	return enc, dec
 }
 
+// RVsUnsubscribed returns true if the client is not listening for messages
+// from this user due to the user being idle.
+func (ru *RemoteUser) RVsUnsubscribed() bool {
+	ru.mtx.Lock()
+	res := ru.rvsUnsubbed
+	ru.mtx.Unlock()
+	return res
+}
+
+// setRVsUnsubscribed sets whether the client should be subscribed to the
+// receive RVs of this user. Returns true if this changed the subscription
+// state.
+func (ru *RemoteUser) setRVsUnsubscribed(unsub bool) bool {
+	ru.mtx.Lock()
+	changed := ru.rvsUnsubbed != unsub
+	ru.rvsUnsubbed = unsub
+	ru.mtx.Unlock()
+	if changed {
+		select {
+		case ru.rvsSubChanged <- struct{}{}:
+		default:
+		}
+	}
+	return changed
+}
+
+// FailedDecrypts returns the number of consecutive messages received from
+// this user that failed to be decrypted.
+func (ru *RemoteUser) FailedDecrypts() int {
+	ru.rLock.Lock()
+	res := ru.failedDecrypts
+	ru.rLock.Unlock()
+	return res
+}
+
 func (ru *RemoteUser) String() string {
 	return fmt.Sprintf("%s (%q)", ru.ID(), ru.Nick())
 }
@@ -264,6 +321,12 @@ func (ru *RemoteUser) queueRMPriority(payload interface{}, priority uint,
 		return err
 	}
 
+	// Sending a message to an idle user is likely to generate a reply, so
+	// listen for messages from them again.
+	if ru.setRVsUnsubscribed(false) {
+		ru.log.Infof("Resubscribing to RVs of idle user")
+	}
+
 	estSize := rpc.EstimateRoutedRMWireSize(len(me))
 	maxMsgSize := int(ru.q.MaxMsgSize())
 	if estSize > maxMsgSize {
@@ -279,42 +342,91 @@ func (ru *RemoteUser) queueRMPriority(payload interface{}, priority uint,
 		ru.logPayloads.Debugf("Queueing RM %T", payload)
 	}
 
+	// The inner reply channel is needed in order to alert run() of
+	// completed sends.
 	orm := &remoteUserRM{
-		pri:      priority,
-		msg:      me,
-		ru:       ru,
-		payloadT: fmt.Sprintf("%T", payload),
-		payEvent: payEvent,
+		pri:        priority,
+		msg:        me,
+		ru:         ru,
+		payloadT:   fmt.Sprintf("%T", payload),
+		payEvent:   payEvent,
+		msgID:      payloadMsgID(payload),
+		queuedAt:   time.Now(),
+		replyChan:  make(chan error),
+		cancelChan: make(chan struct{}),
 	}
 
-	// This inner channel is needed in order to alert run() of completed
-	// sends.
-	innerReplyChan := make(chan error)
+	ru.mtx.Lock()
+	ru.nextPendingID += 1
+	orm.pendingID = ru.nextPendingID
+	ru.pendingRMs[orm.pendingID] = orm
+	ru.mtx.Unlock()
 
 	ru.log.Tracef("Queuing to RMQ %T", payload)
-	if err := ru.q.QueueRM(orm, innerReplyChan); err != nil {
+	ru.notifyMsgStatus(orm, MsgStatusQueued, nil)
+	if err := ru.q.QueueRM(orm, orm.replyChan); err != nil {
+		ru.mtx.Lock()
+		delete(ru.pendingRMs, orm.pendingID)
+		ru.mtx.Unlock()
+		ru.notifyMsgStatus(orm, MsgStatusFailed, err)
 		return err
 	}
 
 	// Handle sending reply.
 	go func() {
 		var err error
-		select {
-		case err = <-innerReplyChan:
-			ru.log.Debugf("Sent RM %T via RV %s (err: %v)", payload,
-				orm.sendRV, err)
-
-			if removeUnackedRMDueToErr(err) {
-				ru.removeUnacked(orm.sendRV)
-			}
-
-			// Alert run() of result of send.
+	wait:
+		for {
 			select {
-			case ru.sentRMChan <- err:
+			case err = <-orm.replyChan:
+				ru.log.Debugf("Sent RM %T via RV %s (err: %v)", payload,
+					orm.sendRV, err)
+
+				if removeUnackedRMDueToErr(err) {
+					ru.removeUnacked(orm.sendRV)
+				}
+
+				// Alert run() of result of send.
+				select {
+				case ru.sentRMChan <- err:
+				case <-ru.runDone:
+				}
+
+			case <-orm.cancelChan:
+				// The RMQ still replies to the canceled RM
+				// once it attempts to send it, so drain its
+				// reply. The RM was never encrypted, so run()
+				// does not need to be alerted.
+				go func(c chan error) { <-c }(orm.replyChan)
+
+				ru.rLock.Lock()
+				next := orm.replacedBy
+				ru.rLock.Unlock()
+				if next != nil {
+					// Requeued with a different priority.
+					orm = next
+					continue wait
+				}
+				err = ErrRMCanceled
+
 			case <-ru.runDone:
+				err = errRemoteUserExiting
 			}
-		case <-ru.runDone:
-			err = errRemoteUserExiting
+			break wait
+		}
+
+		ru.mtx.Lock()
+		delete(ru.pendingRMs, orm.pendingID)
+		ru.mtx.Unlock()
+
+		switch {
+		case err == nil:
+			ru.notifyMsgStatus(orm, MsgStatusAcked, nil)
+		case errors.Is(err, errRemoteUserExiting),
+			errors.Is(err, clientintf.ErrSubsysExiting):
+			// The RM will be sent again after the client restarts.
+		default:
+			ru.notifyMsgStatus(orm, MsgStatusFailed, err)
 		}
 
 		if replyChan != nil {
@@ -325,6 +437,122 @@ func (ru *RemoteUser) queueRMPriority(payload interface{}, priority uint,
 	return nil
 }
 
+// notifyMsgStatus alerts about a change in the delivery status of the chat
+// message sent in the given RM.
+func (ru *RemoteUser) notifyMsgStatus(rm *remoteUserRM, status MsgDeliveryStatus, err error) {
+	if rm.msgID == nil || ru.msgStatusHandler == nil {
+		return
+	}
+	ru.msgStatusHandler(ru, *rm.msgID, status, err)
+}
+
+// PendingRM is information about an outbound RM that was queued to be sent
+// to a remote user but was not yet acked by the server.
+type PendingRM struct {
+	ID       uint64
+	UID      UserID
+	Type     string
+	PayEvent string
+	Priority uint
+	Size     int
+	QueuedAt time.Time
+
+	// Encrypted is true if the RM was already encrypted and is being
+	// sent to the server. Encrypted RMs can no longer be canceled or
+	// reprioritized.
+	Encrypted bool
+}
+
+// listPendingRMs returns the list of RMs queued to be sent to this user.
+func (ru *RemoteUser) listPendingRMs() []PendingRM {
+	ru.mtx.Lock()
+	orms := make([]*remoteUserRM, 0, len(ru.pendingRMs))
+	for _, orm := range ru.pendingRMs {
+		orms = append(orms, orm)
+	}
+	ru.mtx.Unlock()
+
+	res := make([]PendingRM, len(orms))
+	ru.rLock.Lock()
+	for i, orm := range orms {
+		res[i] = PendingRM{
+			ID:        orm.pendingID,
+			UID:       ru.ID(),
+			Type:      orm.payloadT,
+			PayEvent:  orm.payEvent,
+			Priority:  orm.pri,
+			Size:      len(orm.msg),
+			QueuedAt:  orm.queuedAt,
+			Encrypted: orm.encrypted != nil,
+		}
+	}
+	ru.rLock.Unlock()
+	return res
+}
+
+// cancelPendingRM cancels the pending RM with the given id. If newPriority is
+// not nil, the RM is requeued with the new priority instead.
+func (ru *RemoteUser) cancelPendingRM(id uint64, newPriority *uint) error {
+	ru.mtx.Lock()
+	orm := ru.pendingRMs[id]
+	ru.mtx.Unlock()
+	if orm == nil {
+		return fmt.Errorf("pending RM %d not found", id)
+	}
+
+	ru.rLock.Lock()
+	if orm.encrypted != nil {
+		ru.rLock.Unlock()
+		return fmt.Errorf("pending RM %d is already being sent", id)
+	}
+	if orm.canceled {
+		ru.rLock.Unlock()
+		return fmt.Errorf("pending RM %d was already canceled", id)
+	}
+	orm.canceled = true
+
+	// When reprioritizing, a copy of the RM is queued with the new
+	// priority. The original RM will be dropped by the RMQ when it
+	// attempts to encrypt it.
+	var next *remoteUserRM
+	if newPriority != nil {
+		next = &remoteUserRM{
+			pri:        *newPriority,
+			msg:        orm.msg,
+			ru:         ru,
+			payloadT:   orm.payloadT,
+			payEvent:   orm.payEvent,
+			msgID:      orm.msgID,
+			pendingID:  orm.pendingID,
+			queuedAt:   orm.queuedAt,
+			replyChan:  make(chan error),
+			cancelChan: make(chan struct{}),
+		}
+		orm.replacedBy = next
+	}
+	ru.rLock.Unlock()
+
+	if next == nil {
+		ru.log.Infof("Canceled pending RM %s queued at %s", orm,
+			orm.queuedAt.Format(time.RFC3339))
+		close(orm.cancelChan)
+		return nil
+	}
+
+	ru.mtx.Lock()
+	ru.pendingRMs[id] = next
+	ru.mtx.Unlock()
+	err := ru.q.QueueRM(next, next.replyChan)
+	close(orm.cancelChan)
+	if err != nil {
+		// Reply with the error to the original caller.
+		go func() { next.replyChan <- err }()
+		return err
+	}
+	ru.log.Infof("Requeued pending RM %s with priority %d", orm, *newPriority)
+	return nil
+}
+
 // sendRMPriority schedules the given RM in the user's rmq with the given
 // priority number. It returns when the RM has been ack'd by the server .
 func (ru *RemoteUser) sendRMPriority(payload interface{}, payEvent string, priority uint) error {
@@ -374,10 +602,12 @@ func (ru *RemoteUser) sendTransitive(payload interface{}, payType string,
 }
 
 // sendPM sends a private message to this remote user.
-func (ru *RemoteUser) sendPM(msg string) error {
+func (ru *RemoteUser) sendPM(msg string, msgID, replyTo *zkidentity.ShortID) error {
 	return ru.sendRMPriority(rpc.RMPrivateMessage{
 		Mode:    rpc.RMPrivateMessageModeNormal,
 		Message: msg,
+		MsgID:   msgID,
+		ReplyTo: replyTo,
 	}, "pm", priorityPM)
 }
 
@@ -481,6 +711,10 @@ func (ru *RemoteUser) encryptRM(rm *remoteUserRM) (lowlevel.RVID, []byte, error)
 	rm.ru.rLock.Lock()
 	defer rm.ru.rLock.Unlock()
 
+	if rm.encrypted == nil && rm.canceled {
+		return rm.sendRV, nil, ErrRMCanceled
+	}
+
 	if rm.encrypted != nil || ru.rError != nil {
 		// If already encrypted, then re-send (this was likely a server
 		// write error).
@@ -522,6 +756,7 @@ func (ru *RemoteUser) handleReceivedEncrypted(recvBlob lowlevel.RVBlob) error {
 	cleartext, decodeErr := ru.r.Decrypt(recvBlob.Decoded)
 
 	if decodeErr == nil {
+		ru.failedDecrypts = 0
 		err := ru.saveRatchet(nil, nil, "")
 		if err != nil {
 			ru.rLock.Unlock()
@@ -529,7 +764,10 @@ func (ru *RemoteUser) handleReceivedEncrypted(recvBlob lowlevel.RVBlob) error {
 				"This might cause a busted ratchet")
 			return err
 		}
+	} else {
+		ru.failedDecrypts += 1
 	}
+	failedDecrypts := ru.failedDecrypts
 	ru.rLock.Unlock()
 
 	if decodeErr != nil {
@@ -544,6 +782,9 @@ func (ru *RemoteUser) handleReceivedEncrypted(recvBlob lowlevel.RVBlob) error {
 		// ratchet state was _not_ updated and _not_ saved do the DB.
 		ru.log.Warnf("Error decrypting ratchet msg at RV %s: %v",
 			recvBlob.ID, decodeErr)
+		if ru.decryptFailedHandler != nil {
+			go ru.decryptFailedHandler(ru, failedDecrypts)
+		}
 		return nil
 	}
 
@@ -655,6 +896,21 @@ func (ru *RemoteUser) maybeUpdateRVs(lastRecvRV, lastDrainRV ratchet.RVPoint, ha
 	return rv, drainRV, nil
 }
 
+// unsubRVs unsubscribes from the given receive RVs.
+func (ru *RemoteUser) unsubRVs(lastRecvRV, lastDrainRV ratchet.RVPoint) error {
+	var g errgroup.Group
+	var emptyRV ratchet.RVPoint
+	if lastDrainRV != emptyRV {
+		ru.log.Tracef("Unsubscribing to lastDrainRV %s", lastDrainRV)
+		g.Go(func() error { return ru.rmgr.Unsub(lastDrainRV) })
+	}
+	if lastRecvRV != emptyRV && lastRecvRV != lastDrainRV {
+		ru.log.Tracef("Unsubscribing to lastRecvRV %s", lastRecvRV)
+		g.Go(func() error { return ru.rmgr.Unsub(lastRecvRV) })
+	}
+	return g.Wait()
+}
+
 // stop requests this user to be stopped unilaterally.
 func (ru *RemoteUser) stop() {
 	select {
@@ -664,12 +920,17 @@ func (ru *RemoteUser) stop() {
 }
 
 func (ru *RemoteUser) run(ctx context.Context) error {
-	// Perform initial subscription to the ratchet receive RVs.
+	// Perform initial subscription to the ratchet receive RVs (unless the
+	// user is idle).
 	var emptyRV ratchet.RVPoint
+	var lastRecvRV, lastDrainRV ratchet.RVPoint
+	var err error
 	handler := ru.handleReceivedEncrypted
-	lastRecvRV, lastDrainRV, err := ru.maybeUpdateRVs(emptyRV, emptyRV, handler)
-	if err != nil {
-		return err
+	if !ru.RVsUnsubscribed() {
+		lastRecvRV, lastDrainRV, err = ru.maybeUpdateRVs(emptyRV, emptyRV, handler)
+		if err != nil {
+			return err
+		}
 	}
 
 nextAction:
@@ -699,9 +960,16 @@ nextAction:
 			ru.rLock.Lock()
 			ru.r = newR
 			ru.rError = nil
+			ru.failedDecrypts = 0
 			err = ru.saveRatchet(nil, nil, "")
 			ru.rLock.Unlock()
 
+			// Listen for messages on the new ratchet.
+			ru.setRVsUnsubscribed(false)
+
+		case <-ru.rvsSubChanged:
+			// Handled below.
+
 		case <-ctx.Done():
 			err = ctx.Err()
 		}
@@ -710,6 +978,16 @@ nextAction:
 			break nextAction
 		}
 
+		// Unsubscribe from the receive RVs of idle users.
+		if ru.RVsUnsubscribed() {
+			if lastRecvRV != emptyRV || lastDrainRV != emptyRV {
+				ru.log.Debugf("Unsubscribing from RVs of idle user")
+				err = ru.unsubRVs(lastRecvRV, lastDrainRV)
+				lastRecvRV, lastDrainRV = emptyRV, emptyRV
+			}
+			continue
+		}
+
 		// Rotate receive RVs in the rv manager if needed.
 		lastRecvRV, lastDrainRV, err = ru.maybeUpdateRVs(lastRecvRV,
 			lastDrainRV, handler)
@@ -71,7 +71,7 @@ func TestRemoteUserPMs(t *testing.T) {
 	go func() {
 		for i := 0; i < nbMsgs; i++ {
 			wantAliceMsgs[i] = randomHex(arnd, 1+arnd.Intn(maxMsgSize))
-			err := aliceRemote.sendPM(wantAliceMsgs[i])
+			err := aliceRemote.sendPM(wantAliceMsgs[i], nil, nil)
 			if err != nil {
 				doneAliceMsgs <- err
 				return
@@ -83,7 +83,7 @@ func TestRemoteUserPMs(t *testing.T) {
 	go func() {
 		for i := 0; i < nbMsgs; i++ {
 			wantBobMsgs[i] = randomHex(brnd, 1+brnd.Intn(maxMsgSize))
-			err := bobRemote.sendPM(wantBobMsgs[i])
+			err := bobRemote.sendPM(wantBobMsgs[i], nil, nil)
 			if err != nil {
 				doneBobMsgs <- err
 				return
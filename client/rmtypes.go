@@ -1,8 +1,11 @@
 package client
 
 import (
+	"time"
+
 	"github.com/companyzero/bisonrelay/client/internal/lowlevel"
 	"github.com/companyzero/bisonrelay/ratchet"
+	"github.com/companyzero/bisonrelay/zkidentity"
 )
 
 // rawRM is a routed message that is considered already encrypted and is sent
@@ -36,6 +39,8 @@ func (rm rawRM) PaidForRM(amount, fees int64) {
 	}
 }
 
+func (rm rawRM) SentToServer() {}
+
 // remoteUserRM is an outbound RM type that encrypts messages via a ratchet
 // associated with a remote user. This encrypts the msg just before being sent.
 type remoteUserRM struct {
@@ -47,6 +52,22 @@ type remoteUserRM struct {
 	sendRV    lowlevel.RVID
 	ru        *RemoteUser
 	payEvent  string
+
+	// msgID is the ID of the chat message sent in this RM (if any). It
+	// is used to alert about the delivery status of the message.
+	msgID *zkidentity.ShortID
+
+	// The following fields are used to track pending RMs.
+	pendingID  uint64
+	queuedAt   time.Time
+	replyChan  chan error
+	cancelChan chan struct{}
+
+	// canceled and replacedBy are protected by ru.rLock. They are set when
+	// the RM is canceled (and optionally replaced by a new RM) before
+	// being encrypted. cancelChan is closed after they are set.
+	canceled   bool
+	replacedBy *remoteUserRM
 }
 
 // Assert remoteUserRM fulfills the outboundRM interface.
@@ -70,4 +91,9 @@ func (rm *remoteUserRM) String() string {
 
 func (rm *remoteUserRM) PaidForRM(amount, fees int64) {
 	go rm.ru.paidForRM(rm.payEvent, amount, fees)
+	rm.ru.notifyMsgStatus(rm, MsgStatusPaid, nil)
+}
+
+func (rm *remoteUserRM) SentToServer() {
+	rm.ru.notifyMsgStatus(rm, MsgStatusSent, nil)
 }
@@ -0,0 +1,174 @@
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/rates"
+	"github.com/decred/dcrlnd/lnrpc"
+	"github.com/decred/slog"
+)
+
+type AdminServerCfg struct {
+	// Client should be set to the [client.Client] instance.
+	Client *client.Client
+
+	// Log should be set to the app's logger.
+	Log slog.Logger
+
+	// PayClient is the payment client used to fetch the LN wallet status.
+	// May be nil if the client does not use an LN wallet.
+	PayClient *client.DcrlnPaymentClient
+
+	// Rates is the exchange rate provider. May be nil.
+	Rates *rates.Rates
+
+	// ExecCommand is called to execute a command in the client and return
+	// its textual output. If nil, ExecCommand calls fail.
+	ExecCommand func(ctx context.Context, cmd string) (string, error)
+}
+
+type adminServer struct {
+	cfg AdminServerCfg
+	log slog.Logger
+	c   *client.Client
+}
+
+func (a *adminServer) ExecCommand(ctx context.Context, req *types.ExecCommandRequest, res *types.ExecCommandResponse) error {
+	if a.cfg.ExecCommand == nil {
+		return fmt.Errorf("executing commands is not supported")
+	}
+	if req.Command == "" {
+		return fmt.Errorf("command is empty")
+	}
+
+	a.log.Debugf("Executing command %q via clientrpc", req.Command)
+	output, err := a.cfg.ExecCommand(ctx, req.Command)
+	if err != nil {
+		return err
+	}
+	res.Output = output
+	return nil
+}
+
+// lnStatus fills the LN-related fields of the status response.
+func (a *adminServer) lnStatus(ctx context.Context, res *types.StatusResponse) error {
+	lnRPC := a.cfg.PayClient.LNRPC()
+	info, err := lnRPC.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to get LN info: %v", err)
+	}
+	res.LnSyncedToChain = info.SyncedToChain
+	res.LnSyncedToGraph = info.SyncedToGraph
+	res.LnBlockHeight = info.BlockHeight
+	res.LnNumActiveChannels = info.NumActiveChannels
+	res.LnNumPendingChannels = info.NumPendingChannels
+
+	wallBalance, err := lnRPC.WalletBalance(ctx, &lnrpc.WalletBalanceRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to get wallet balance: %v", err)
+	}
+	res.WalletConfirmedBalance = wallBalance.ConfirmedBalance
+	res.WalletUnconfirmedBalance = wallBalance.UnconfirmedBalance
+
+	chanBalance, err := lnRPC.ChannelBalance(ctx, &lnrpc.ChannelBalanceRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to get channel balance: %v", err)
+	}
+	res.MaxOutboundAmount = chanBalance.MaxOutboundAmount
+	res.MaxInboundAmount = chanBalance.MaxInboundAmount
+	return nil
+}
+
+func (a *adminServer) Status(ctx context.Context, _ *types.StatusRequest, res *types.StatusResponse) error {
+	queued, sending := a.c.RMQLen()
+	*res = types.StatusResponse{
+		ServerConnected:   a.c.ServerSession() != nil,
+		ServerLnNode:      a.c.ServerLNNode(),
+		RvsUpToDate:       a.c.RVsUpToDate(),
+		PendingRmsQueued:  uint32(queued),
+		PendingRmsSending: uint32(sending),
+	}
+
+	if a.cfg.PayClient != nil {
+		res.LnEnabled = true
+		if err := a.lnStatus(ctx, res); err != nil {
+			res.LnError = err.Error()
+		}
+	}
+
+	if a.cfg.Rates != nil {
+		res.DcrUsdRate, res.BtcUsdRate = a.cfg.Rates.Get()
+		if lastUpdated := a.cfg.Rates.LastUpdated(); !lastUpdated.IsZero() {
+			res.RatesLastUpdated = lastUpdated.Unix()
+		}
+	}
+
+	return nil
+}
+
+func (a *adminServer) ListPendingRMs(_ context.Context, req *types.ListPendingRMsRequest, res *types.ListPendingRMsResponse) error {
+	var uid *client.UserID
+	if req.User != "" {
+		ru, err := a.c.UserByNick(req.User)
+		if err != nil {
+			return err
+		}
+		id := ru.ID()
+		uid = &id
+	}
+
+	rms, err := a.c.ListPendingRMs(uid)
+	if err != nil {
+		return err
+	}
+
+	res.Rms = make([]*types.PendingRM, len(rms))
+	for i, rm := range rms {
+		nick, _ := a.c.UserNick(rm.UID)
+		res.Rms[i] = &types.PendingRM{
+			Id:                rm.ID,
+			Uid:               rm.UID.Bytes(),
+			Nick:              nick,
+			Type:              rm.Type,
+			PayEvent:          rm.PayEvent,
+			Priority:          uint32(rm.Priority),
+			Size:              uint64(rm.Size),
+			QueuedTimestampMs: rm.QueuedAt.UnixMilli(),
+			Sending:           rm.Encrypted,
+		}
+	}
+	return nil
+}
+
+func (a *adminServer) CancelPendingRM(_ context.Context, req *types.CancelPendingRMRequest, _ *types.CancelPendingRMResponse) error {
+	var uid client.UserID
+	if err := uid.FromBytes(req.Uid); err != nil {
+		return fmt.Errorf("invalid uid: %v", err)
+	}
+	return a.c.CancelPendingRM(uid, req.Id)
+}
+
+func (a *adminServer) ReprioritizePendingRM(_ context.Context, req *types.ReprioritizePendingRMRequest, _ *types.ReprioritizePendingRMResponse) error {
+	var uid client.UserID
+	if err := uid.FromBytes(req.Uid); err != nil {
+		return fmt.Errorf("invalid uid: %v", err)
+	}
+	return a.c.ReprioritizePendingRM(uid, req.Id, uint(req.Priority))
+}
+
+var _ types.AdminServiceServer = (*adminServer)(nil)
+
+// InitAdminService initializes and binds an AdminService server to the RPC
+// server.
+func (s *Server) InitAdminService(cfg AdminServerCfg) error {
+	as := &adminServer{
+		cfg: cfg,
+		log: cfg.Log,
+		c:   cfg.Client,
+	}
+	s.services.Bind("AdminService", types.AdminServiceDefn(), as)
+	return nil
+}
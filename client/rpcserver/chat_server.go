@@ -3,13 +3,17 @@ package rpcserver
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/clientrpc/types"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/dcrutil/v4"
 	"github.com/decred/slog"
 )
@@ -40,8 +44,17 @@ type ChatServerCfg struct {
 	// the request in a user-friendly way in the client UI or to block the
 	// request from propagating (by returning a non-nil error).
 
-	OnPM  func(ctx context.Context, uid client.UserID, req *types.PMRequest) error
-	OnGCM func(ctx context.Context, gcid client.GCID, req *types.GCMRequest) error
+	OnPM       func(ctx context.Context, uid client.UserID, req *types.PMRequest) error
+	OnGCM      func(ctx context.Context, gcid client.GCID, req *types.GCMRequest) error
+	OnMarkRead func(ctx context.Context, ev *types.ReceivedReadReceipt) error
+
+	// OnEditMessage is called with the ID of the target user or GC of the
+	// edit.
+	OnEditMessage func(ctx context.Context, target zkidentity.ShortID, req *types.EditMessageRequest) error
+
+	// OnReactToMessage is called with the ID of the target user or GC of
+	// the reaction.
+	OnReactToMessage func(ctx context.Context, target zkidentity.ShortID, req *types.ReactToMessageRequest) error
 }
 
 type chatServer struct {
@@ -52,6 +65,13 @@ type chatServer struct {
 	pmStreams  *serverStreams[*types.ReceivedPM]
 	gcmStreams *serverStreams[*types.GCReceivedMsg]
 	kxStreams  *serverStreams[*types.KXCompleted]
+	rrStreams  *serverStreams[*types.ReceivedReadReceipt]
+	rhStreams  *serverStreams[*types.RatchetHealthEvent]
+	meStreams  *serverStreams[*types.ReceivedMessageEdit]
+	mrStreams  *serverStreams[*types.ReceivedMessageReaction]
+
+	mentionStreams *serverStreams[*types.GCReceivedMsg]
+	mdsStreams     *serverStreams[*types.MsgDeliveryStatusEvent]
 }
 
 func (c *chatServer) SendFile(_ context.Context, req *types.SendFileRequest, _ *types.SendFileResponse) error {
@@ -80,7 +100,21 @@ func (c *chatServer) PM(ctx context.Context, req *types.PMRequest, res *types.PM
 			return err
 		}
 	}
-	return c.c.PM(user.ID(), req.Msg.Message)
+	var msgID zkidentity.ShortID
+	if len(req.Msg.ReplyTo) > 0 {
+		var replyTo zkidentity.ShortID
+		if err := replyTo.FromBytes(req.Msg.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply_to: %v", err)
+		}
+		msgID, err = c.c.ReplyToMessage(user.ID(), replyTo, req.Msg.Message)
+	} else {
+		msgID, err = c.c.PMWithMsgID(user.ID(), req.Msg.Message)
+	}
+	if err != nil {
+		return err
+	}
+	res.MsgId = msgID[:]
+	return nil
 }
 
 func (c *chatServer) PMStream(ctx context.Context, req *types.PMStreamRequest, stream types.ChatService_PMStreamServer) error {
@@ -98,6 +132,12 @@ func (c *chatServer) pmNtfnHandler(ru *client.RemoteUser, p rpc.RMPrivateMessage
 			Mode:    types.MessageMode(p.Mode),
 		},
 	}
+	if p.MsgID != nil {
+		ntfn.Msg.MsgId = p.MsgID[:]
+	}
+	if p.ReplyTo != nil {
+		ntfn.Msg.ReplyTo = p.ReplyTo[:]
+	}
 
 	c.pmStreams.send(ntfn)
 }
@@ -121,7 +161,31 @@ func (c *chatServer) GCM(ctx context.Context, req *types.GCMRequest, res *types.
 			return err
 		}
 	}
-	return c.c.GCMessage(gcid, req.Msg, rpc.MessageModeNormal, nil)
+	var msgID zkidentity.ShortID
+	if len(req.Channel) > 0 {
+		if len(req.ReplyTo) > 0 {
+			return fmt.Errorf("cannot specify both channel and reply_to")
+		}
+		var channel zkidentity.ShortID
+		if err := channel.FromBytes(req.Channel); err != nil {
+			return fmt.Errorf("invalid channel: %v", err)
+		}
+		msgID, err = c.c.GCChannelMessage(gcid, channel, req.Msg,
+			rpc.MessageModeNormal, nil)
+	} else if len(req.ReplyTo) > 0 {
+		var replyTo zkidentity.ShortID
+		if err := replyTo.FromBytes(req.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply_to: %v", err)
+		}
+		msgID, err = c.c.ReplyToMessage(gcid, replyTo, req.Msg)
+	} else {
+		msgID, err = c.c.GCMessageWithMsgID(gcid, req.Msg, rpc.MessageModeNormal, nil)
+	}
+	if err != nil {
+		return err
+	}
+	res.MsgId = msgID[:]
+	return nil
 }
 
 // GCMStream returns a stream that gets GC messages received by the client.
@@ -129,9 +193,8 @@ func (c *chatServer) GCMStream(ctx context.Context, req *types.GCMStreamRequest,
 	return c.gcmStreams.runStream(ctx, req.UnackedFrom, stream)
 }
 
-// gcmNtfnHandler is called by the client when a GC message arrives from a
-// remote user.
-func (c *chatServer) gcmNtfnHandler(ru *client.RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) {
+// marshalGCM converts a received GC message into its rpc representation.
+func (c *chatServer) marshalGCM(ru *client.RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) *types.GCReceivedMsg {
 	gcalias, err := c.c.GetGCAlias(gcm.ID)
 	if err != nil {
 		c.log.Debugf("Skipping received GCM without group %s", gcm.ID)
@@ -147,8 +210,74 @@ func (c *chatServer) gcmNtfnHandler(ru *client.RemoteUser, gcm rpc.RMGroupMessag
 			Mode:    types.MessageMode(gcm.Mode),
 		},
 	}
+	if gcm.MsgID != nil {
+		ntfn.Msg.MsgId = gcm.MsgID[:]
+	}
+	if gcm.ReplyTo != nil {
+		ntfn.Msg.ReplyTo = gcm.ReplyTo[:]
+	}
+	if gcm.Channel != nil {
+		ntfn.Msg.Channel = gcm.Channel[:]
+	}
+	if len(gcm.Mentions) > 0 {
+		ntfn.Msg.Mentions = make([][]byte, len(gcm.Mentions))
+		for i := range gcm.Mentions {
+			ntfn.Msg.Mentions[i] = gcm.Mentions[i].Bytes()
+		}
+	}
+	return ntfn
+}
+
+// gcmNtfnHandler is called by the client when a GC message arrives from a
+// remote user.
+func (c *chatServer) gcmNtfnHandler(ru *client.RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) {
+	c.gcmStreams.send(c.marshalGCM(ru, gcm, ts))
+}
+
+// GCMentionsStream returns a stream that gets GC messages that mention the
+// local client.
+func (c *chatServer) GCMentionsStream(ctx context.Context, req *types.GCMentionsStreamRequest, stream types.ChatService_GCMentionsStreamServer) error {
+	return c.mentionStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+// AckGCMentions acks received GC mention events.
+func (c *chatServer) AckGCMentions(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.mentionStreams.ack(req.SequenceId)
+}
+
+// gcMentionNtfnHandler is called by the client when a GC message that
+// mentions the local client arrives.
+func (c *chatServer) gcMentionNtfnHandler(ru *client.RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) {
+	c.mentionStreams.send(c.marshalGCM(ru, gcm, ts))
+}
+
+// MsgDeliveryStatusStream returns a stream that gets sent events about the
+// delivery status of sent messages.
+func (c *chatServer) MsgDeliveryStatusStream(ctx context.Context, req *types.MsgDeliveryStatusStreamRequest, stream types.ChatService_MsgDeliveryStatusStreamServer) error {
+	return c.mdsStreams.runStream(ctx, req.UnackedFrom, stream)
+}
 
-	c.gcmStreams.send(ntfn)
+// AckMsgDeliveryStatus acks received message delivery status events.
+func (c *chatServer) AckMsgDeliveryStatus(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.mdsStreams.ack(req.SequenceId)
+}
+
+// msgDeliveryStatusNtfnHandler is called by the client when the delivery
+// status of a sent message changes.
+func (c *chatServer) msgDeliveryStatusNtfnHandler(ru *client.RemoteUser,
+	msgID zkidentity.ShortID, status client.MsgDeliveryStatus, err error) {
+
+	ntfn := &types.MsgDeliveryStatusEvent{
+		Uid:         ru.ID().Bytes(),
+		Nick:        ru.Nick(),
+		MsgId:       msgID[:],
+		Status:      types.MsgDeliveryStatus(status), // Values match.
+		TimestampMs: time.Now().UnixMilli(),
+	}
+	if err != nil {
+		ntfn.Error = err.Error()
+	}
+	c.mdsStreams.send(ntfn)
 }
 
 // AckReceivedGCM acks to the server that GCMs up to a sequence ID have been
@@ -275,6 +404,48 @@ func (c *chatServer) WriteNewInvite(ctx context.Context, req *types.WriteNewInvi
 	return nil
 }
 
+func (c *chatServer) WriteNewInviteBatch(ctx context.Context, req *types.WriteNewInviteBatchRequest, res *types.WriteNewInviteBatchResponse) error {
+	var createFunds client.InviteFundsCreator
+	if req.FundBudget > 0 {
+		if c.cfg.PayClient == nil {
+			return fmt.Errorf("PayClient is nil in chatServer")
+		}
+		if c.cfg.InviteFundsAccount == "" || c.cfg.InviteFundsAccount == "default" {
+			return fmt.Errorf("cannot generate invite funds in default account")
+		}
+		createFunds = func(amount dcrutil.Amount) (*rpc.InviteFunds, error) {
+			return c.cfg.PayClient.CreateInviteFunds(ctx, amount,
+				c.cfg.InviteFundsAccount)
+		}
+	}
+
+	var gcid *zkidentity.ShortID
+	if req.Gc != "" {
+		id, err := c.c.GCIDByName(req.Gc)
+		if err != nil {
+			return err
+		}
+		gcid = &id
+	}
+
+	invites, err := c.c.CreatePrepaidInviteBatch(req.Labels,
+		dcrutil.Amount(req.FundBudget), createFunds, gcid)
+	if err != nil {
+		return err
+	}
+	res.Invites = make([]*types.WriteNewInviteBatchEntry, len(invites))
+	for i := range invites {
+		res.Invites[i] = &types.WriteNewInviteBatchEntry{
+			Label:       invites[i].Label,
+			InviteBytes: invites[i].InviteBytes,
+			Invite:      marshalOOBPublicIDInvite(&invites[i].Invite, nil),
+			InviteKey:   invites[i].EncodedKey,
+			FundAmount:  uint64(invites[i].FundAmount),
+		}
+	}
+	return nil
+}
+
 func (c *chatServer) AcceptInvite(_ context.Context, req *types.AcceptInviteRequest, res *types.AcceptInviteResponse) error {
 	b := bytes.NewBuffer(req.InviteBytes)
 	invite, err := c.c.ReadInvite(b)
@@ -314,6 +485,838 @@ func (c *chatServer) UserNick(ctx context.Context, req *types.UserNickRequest, r
 	return nil
 }
 
+// MarkRead marks the messages of a PM or GC chat as read up to a given
+// message, or the entire chat if no message is specified.
+func (c *chatServer) MarkRead(ctx context.Context, req *types.MarkReadRequest, _ *types.MarkReadResponse) error {
+	if len(req.MsgId) != 0 {
+		var msgID zkidentity.ShortID
+		if err := msgID.FromBytes(req.MsgId); err != nil {
+			return fmt.Errorf("invalid msg_id: %v", err)
+		}
+	}
+	ntfn := &types.ReceivedReadReceipt{
+		MsgId:       req.MsgId,
+		TimestampMs: time.Now().UnixMilli(),
+	}
+	switch {
+	case req.User != "" && req.Gc != "":
+		return fmt.Errorf("only one of user or gc may be specified")
+	case req.User != "":
+		user, err := c.c.UserByNick(req.User)
+		if err != nil {
+			return err
+		}
+		ntfn.Uid = user.ID().Bytes()
+		ntfn.Nick = user.Nick()
+	case req.Gc != "":
+		gcid, err := c.c.GCIDByName(req.Gc)
+		if err != nil {
+			return err
+		}
+		gcalias, err := c.c.GetGCAlias(gcid)
+		if err != nil {
+			return err
+		}
+		ntfn.Gc = gcid[:]
+		ntfn.GcAlias = gcalias
+	default:
+		return fmt.Errorf("either user or gc must be specified")
+	}
+
+	if c.cfg.OnMarkRead != nil {
+		if err := c.cfg.OnMarkRead(ctx, ntfn); err != nil {
+			return err
+		}
+	}
+
+	c.rrStreams.send(ntfn)
+	return nil
+}
+
+// chatTarget returns the ID of the user or GC specified in a request. Exactly
+// one of user or gc must be specified.
+func (c *chatServer) chatTarget(user, gc string) (zkidentity.ShortID, error) {
+	switch {
+	case user != "" && gc != "":
+		return zkidentity.ShortID{}, fmt.Errorf("only one of user or gc may be specified")
+	case user != "":
+		ru, err := c.c.UserByNick(user)
+		if err != nil {
+			return zkidentity.ShortID{}, err
+		}
+		return ru.ID(), nil
+	case gc != "":
+		return c.c.GCIDByName(gc)
+	default:
+		return zkidentity.ShortID{}, fmt.Errorf("either user or gc must be specified")
+	}
+}
+
+// EditMessage edits a PM or GC message previously sent by the local client.
+func (c *chatServer) EditMessage(ctx context.Context, req *types.EditMessageRequest, _ *types.EditMessageResponse) error {
+	var msgID zkidentity.ShortID
+	if err := msgID.FromBytes(req.MsgId); err != nil {
+		return fmt.Errorf("invalid msg_id: %v", err)
+	}
+	if req.Msg == "" {
+		return fmt.Errorf("msg is empty")
+	}
+
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+
+	if c.cfg.OnEditMessage != nil {
+		if err := c.cfg.OnEditMessage(ctx, target, req); err != nil {
+			return err
+		}
+	}
+
+	return c.c.EditMessage(target, msgID, req.Msg)
+}
+
+// MessageEditsStream returns a stream that gets sent edits of messages done
+// by remote users.
+func (c *chatServer) MessageEditsStream(ctx context.Context, req *types.MessageEditsStreamRequest, stream types.ChatService_MessageEditsStreamServer) error {
+	return c.meStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+// AckMessageEdits acks received message edit events.
+func (c *chatServer) AckMessageEdits(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.meStreams.ack(req.SequenceId)
+}
+
+// msgEditedNtfnHandler is called by the client when a remote user edits a
+// message.
+func (c *chatServer) msgEditedNtfnHandler(ru *client.RemoteUser, edit rpc.RMEditMessage, ts time.Time) {
+	ntfn := &types.ReceivedMessageEdit{
+		Uid:         ru.ID().Bytes(),
+		Nick:        ru.Nick(),
+		MsgId:       edit.MsgID[:],
+		Msg:         edit.Message,
+		TimestampMs: ts.UnixMilli(),
+	}
+	if edit.GC != nil {
+		ntfn.Gc = edit.GC[:]
+		ntfn.GcAlias, _ = c.c.GetGCAlias(*edit.GC)
+	}
+	c.meStreams.send(ntfn)
+}
+
+// ReactToMessage adds or removes a reaction to a PM or GC message.
+func (c *chatServer) ReactToMessage(ctx context.Context, req *types.ReactToMessageRequest, _ *types.ReactToMessageResponse) error {
+	var msgID zkidentity.ShortID
+	if err := msgID.FromBytes(req.MsgId); err != nil {
+		return fmt.Errorf("invalid msg_id: %v", err)
+	}
+	if req.Reaction == "" {
+		return fmt.Errorf("reaction is empty")
+	}
+
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+
+	if c.cfg.OnReactToMessage != nil {
+		if err := c.cfg.OnReactToMessage(ctx, target, req); err != nil {
+			return err
+		}
+	}
+
+	return c.c.ReactToMessage(target, msgID, req.Reaction, req.Remove)
+}
+
+// marshalMessageReactions converts the db reactions into their rpc
+// representation.
+func marshalMessageReactions(mr *clientdb.MessageReactions) *types.MessageReactions {
+	res := &types.MessageReactions{
+		MsgId:     mr.MsgID[:],
+		Reactions: make([]*types.MessageReaction, 0, len(mr.Reactions)),
+	}
+	if mr.GC != nil {
+		res.Gc = mr.GC[:]
+	}
+	for reaction, uids := range mr.Reactions {
+		r := &types.MessageReaction{
+			Reaction: reaction,
+			Uids:     make([][]byte, len(uids)),
+		}
+		for i := range uids {
+			r.Uids[i] = uids[i].Bytes()
+		}
+		res.Reactions = append(res.Reactions, r)
+	}
+	sort.Slice(res.Reactions, func(i, j int) bool {
+		return res.Reactions[i].Reaction < res.Reactions[j].Reaction
+	})
+	return res
+}
+
+// GetMessageReactions returns the aggregated reactions to a message.
+func (c *chatServer) GetMessageReactions(_ context.Context, req *types.GetMessageReactionsRequest, res *types.MessageReactions) error {
+	var msgID zkidentity.ShortID
+	if err := msgID.FromBytes(req.MsgId); err != nil {
+		return fmt.Errorf("invalid msg_id: %v", err)
+	}
+
+	mr, err := c.c.MessageReactions(msgID)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		*res = types.MessageReactions{MsgId: msgID[:]}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*res = *marshalMessageReactions(mr)
+	return nil
+}
+
+// MessageReactionsStream returns a stream that gets sent reactions of remote
+// users to messages.
+func (c *chatServer) MessageReactionsStream(ctx context.Context, req *types.MessageReactionsStreamRequest, stream types.ChatService_MessageReactionsStreamServer) error {
+	return c.mrStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+// AckMessageReactions acks received message reaction events.
+func (c *chatServer) AckMessageReactions(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.mrStreams.ack(req.SequenceId)
+}
+
+// msgReactionNtfnHandler is called by the client when a remote user reacts to
+// a message.
+func (c *chatServer) msgReactionNtfnHandler(ru *client.RemoteUser, reaction rpc.RMMessageReaction,
+	reactions *clientdb.MessageReactions, ts time.Time) {
+
+	ntfn := &types.ReceivedMessageReaction{
+		Uid:         ru.ID().Bytes(),
+		Nick:        ru.Nick(),
+		MsgId:       reaction.MsgID[:],
+		Reaction:    reaction.Reaction,
+		Removed:     reaction.Remove,
+		Reactions:   marshalMessageReactions(reactions),
+		TimestampMs: ts.UnixMilli(),
+	}
+	if reaction.GC != nil {
+		ntfn.Gc = reaction.GC[:]
+		ntfn.GcAlias, _ = c.c.GetGCAlias(*reaction.GC)
+	}
+	c.mrStreams.send(ntfn)
+}
+
+// GetThread returns the thread of replies that a message belongs to.
+func (c *chatServer) GetThread(_ context.Context, req *types.GetThreadRequest, res *types.MessageThread) error {
+	var msgID zkidentity.ShortID
+	if err := msgID.FromBytes(req.MsgId); err != nil {
+		return fmt.Errorf("invalid msg_id: %v", err)
+	}
+
+	thread, err := c.c.Thread(msgID)
+	if errors.Is(err, clientdb.ErrNotFound) {
+		// Message without replies.
+		*res = types.MessageThread{RootMsgId: msgID[:]}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	*res = types.MessageThread{
+		RootMsgId: thread.RootMsgID[:],
+		Replies:   make([]*types.ThreadReply, len(thread.Replies)),
+	}
+	if thread.GC != nil {
+		res.Gc = thread.GC[:]
+	}
+	for i, r := range thread.Replies {
+		res.Replies[i] = &types.ThreadReply{
+			MsgId:       r.MsgID[:],
+			ReplyTo:     r.ReplyTo[:],
+			Uid:         r.From.Bytes(),
+			Msg:         r.Message,
+			TimestampMs: r.Timestamp.UnixMilli(),
+		}
+	}
+	return nil
+}
+
+// SearchMessages searches the logged messages.
+func (c *chatServer) SearchMessages(_ context.Context, req *types.SearchMessagesRequest, res *types.SearchMessagesResponse) error {
+	filter := clientdb.MsgSearchFilter{Limit: int(req.Limit)}
+	if req.User != "" {
+		ru, err := c.c.UserByNick(req.User)
+		if err != nil {
+			return err
+		}
+		uid := ru.ID()
+		filter.UID = &uid
+	}
+	if req.Gc != "" {
+		gcID, err := c.c.GCIDByName(req.Gc)
+		if err != nil {
+			return err
+		}
+		filter.GC = &gcID
+	}
+	if req.SinceTimestamp > 0 {
+		filter.Since = time.Unix(req.SinceTimestamp, 0)
+	}
+	if req.UntilTimestamp > 0 {
+		filter.Until = time.Unix(req.UntilTimestamp, 0)
+	}
+
+	results, err := c.c.SearchMessages(req.Query, filter)
+	if err != nil {
+		return err
+	}
+
+	res.Results = make([]*types.MessageSearchResult, len(results))
+	for i, r := range results {
+		res.Results[i] = &types.MessageSearchResult{
+			From:      r.From,
+			Msg:       r.Message,
+			Timestamp: r.Timestamp.Unix(),
+		}
+		if r.UID != nil {
+			res.Results[i].Uid = r.UID.Bytes()
+		}
+		if r.GC != nil {
+			res.Results[i].Gc = r.GC[:]
+		}
+	}
+	return nil
+}
+
+// ExportChat exports a PM conversation or GC.
+func (c *chatServer) ExportChat(_ context.Context, req *types.ExportChatRequest, res *types.ExportChatResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+
+	export, err := c.c.ExportChat(target)
+	if err != nil {
+		return err
+	}
+
+	b := new(bytes.Buffer)
+	if err := export.Write(b, client.ChatExportFormat(req.Format)); err != nil {
+		return err
+	}
+	res.Data = b.Bytes()
+	return nil
+}
+
+// ArchiveChat archives a PM conversation or GC.
+func (c *chatServer) ArchiveChat(_ context.Context, req *types.ArchiveChatRequest, _ *types.ArchiveChatResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+	return c.c.ArchiveChat(target)
+}
+
+// UnarchiveChat removes the archived status of a PM conversation or GC.
+func (c *chatServer) UnarchiveChat(_ context.Context, req *types.ArchiveChatRequest, _ *types.ArchiveChatResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+	return c.c.UnarchiveChat(target)
+}
+
+// ListArchivedChats lists the archived PM conversations and GCs.
+func (c *chatServer) ListArchivedChats(_ context.Context, _ *types.ListArchivedChatsRequest, res *types.ListArchivedChatsResponse) error {
+	archived, err := c.c.ListArchivedChats()
+	if err != nil {
+		return err
+	}
+	res.Chats = make([]*types.ArchivedChat, 0, len(archived))
+	for id, ts := range archived {
+		chat := &types.ArchivedChat{
+			Id:         id.Bytes(),
+			ArchivedAt: ts.Unix(),
+		}
+		if ru, err := c.c.UserByID(id); err == nil {
+			chat.Name = ru.Nick()
+		} else if alias, err := c.c.GetGCAlias(id); err == nil {
+			chat.IsGc = true
+			chat.Name = alias
+		}
+		res.Chats = append(res.Chats, chat)
+	}
+	return nil
+}
+
+// GetDraft returns the unsent draft of a PM conversation or GC.
+func (c *chatServer) GetDraft(_ context.Context, req *types.GetDraftRequest, res *types.GetDraftResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+	res.Text, err = c.c.Draft(target)
+	return err
+}
+
+// SetDraft stores the unsent draft of a PM conversation or GC.
+func (c *chatServer) SetDraft(_ context.Context, req *types.SetDraftRequest, _ *types.SetDraftResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+	return c.c.SetDraft(target, req.Text)
+}
+
+// ListDrafts lists the unsent drafts of all chats.
+func (c *chatServer) ListDrafts(_ context.Context, _ *types.ListDraftsRequest, res *types.ListDraftsResponse) error {
+	drafts, err := c.c.ListDrafts()
+	if err != nil {
+		return err
+	}
+	res.Drafts = make([]*types.Draft, 0, len(drafts))
+	for id, draft := range drafts {
+		d := &types.Draft{
+			Id:          id.Bytes(),
+			Text:        draft.Text,
+			LastUpdated: draft.LastUpdated.Unix(),
+		}
+		if ru, err := c.c.UserByID(id); err == nil {
+			d.Name = ru.Nick()
+		} else if alias, err := c.c.GetGCAlias(id); err == nil {
+			d.IsGc = true
+			d.Name = alias
+		}
+		res.Drafts = append(res.Drafts, d)
+	}
+	return nil
+}
+
+// StoreBroadcastList creates or replaces a broadcast list.
+func (c *chatServer) StoreBroadcastList(_ context.Context, req *types.BroadcastList, _ *types.StoreBroadcastListResponse) error {
+	members := make([]clientintf.UserID, 0, len(req.Members))
+	for _, m := range req.Members {
+		ru, err := c.c.UserByNick(m)
+		if err != nil {
+			return err
+		}
+		members = append(members, ru.ID())
+	}
+	return c.c.StoreBroadcastList(req.Name, members)
+}
+
+// RemoveBroadcastList removes a broadcast list.
+func (c *chatServer) RemoveBroadcastList(_ context.Context, req *types.RemoveBroadcastListRequest, _ *types.RemoveBroadcastListResponse) error {
+	return c.c.RemoveBroadcastList(req.Name)
+}
+
+// ListBroadcastLists lists the existing broadcast lists.
+func (c *chatServer) ListBroadcastLists(_ context.Context, _ *types.ListBroadcastListsRequest, res *types.ListBroadcastListsResponse) error {
+	lists, err := c.c.ListBroadcastLists()
+	if err != nil {
+		return err
+	}
+	res.Lists = make([]*types.BroadcastList, len(lists))
+	for i, bl := range lists {
+		members := make([]string, len(bl.Members))
+		for j, uid := range bl.Members {
+			members[j] = uid.String()
+		}
+		res.Lists[i] = &types.BroadcastList{Name: bl.Name, Members: members}
+	}
+	return nil
+}
+
+// marshalBroadcastStatus converts a broadcast status to its rpc type.
+func marshalBroadcastStatus(bs *client.BroadcastStatus) *types.BroadcastStatus {
+	res := &types.BroadcastStatus{
+		Id:        bs.ID.Bytes(),
+		List:      bs.List,
+		Timestamp: bs.Timestamp.Unix(),
+		Members:   make([]*types.BroadcastMemberStatus, 0, len(bs.MsgIDs)),
+	}
+	for uid, msgID := range bs.MsgIDs {
+		ms := &types.BroadcastMemberStatus{
+			Uid:    uid.Bytes(),
+			MsgId:  msgID.Bytes(),
+			Status: bs.Statuses[uid].String(),
+		}
+		if err := bs.Errors[uid]; err != nil {
+			ms.Error = err.Error()
+		}
+		res.Members = append(res.Members, ms)
+	}
+	return res
+}
+
+// SendBroadcast sends a message to each member of a broadcast list.
+func (c *chatServer) SendBroadcast(_ context.Context, req *types.SendBroadcastRequest, res *types.BroadcastStatus) error {
+	if req.Msg == "" {
+		return fmt.Errorf("msg is empty")
+	}
+	bs, err := c.c.SendBroadcast(req.List, req.Msg)
+	if err != nil {
+		return err
+	}
+	*res = *marshalBroadcastStatus(&bs)
+	return nil
+}
+
+// GetBroadcastStatus returns the delivery status of a broadcast.
+func (c *chatServer) GetBroadcastStatus(_ context.Context, req *types.GetBroadcastStatusRequest, res *types.BroadcastStatus) error {
+	var id zkidentity.ShortID
+	if err := id.FromBytes(req.Id); err != nil {
+		return fmt.Errorf("invalid id: %v", err)
+	}
+	bs, err := c.c.BroadcastStatus(id)
+	if err != nil {
+		return err
+	}
+	*res = *marshalBroadcastStatus(&bs)
+	return nil
+}
+
+// StoreContactGroup creates or replaces a contact group.
+func (c *chatServer) StoreContactGroup(_ context.Context, req *types.ContactGroup, _ *types.StoreContactGroupResponse) error {
+	members := make([]clientintf.UserID, 0, len(req.Members))
+	for _, m := range req.Members {
+		ru, err := c.c.UserByNick(m)
+		if err != nil {
+			return err
+		}
+		members = append(members, ru.ID())
+	}
+	return c.c.StoreContactGroup(req.Name, members)
+}
+
+// RemoveContactGroup removes a contact group.
+func (c *chatServer) RemoveContactGroup(_ context.Context, req *types.RemoveContactGroupRequest, _ *types.RemoveContactGroupResponse) error {
+	return c.c.RemoveContactGroup(req.Name)
+}
+
+// ListContactGroups lists the existing contact groups.
+func (c *chatServer) ListContactGroups(_ context.Context, _ *types.ListContactGroupsRequest, res *types.ListContactGroupsResponse) error {
+	groups, err := c.c.ListContactGroups()
+	if err != nil {
+		return err
+	}
+	res.Groups = make([]*types.ContactGroup, len(groups))
+	for i, cg := range groups {
+		members := make([]string, len(cg.Members))
+		for j, uid := range cg.Members {
+			members[j] = uid.String()
+		}
+		res.Groups[i] = &types.ContactGroup{Name: cg.Name, Members: members}
+	}
+	return nil
+}
+
+// marshalContactGroupResult converts the result of a contact group batch
+// operation to its rpc type.
+func marshalContactGroupResult(cgr *client.ContactGroupResult) *types.ContactGroupResult {
+	res := &types.ContactGroupResult{
+		Group: cgr.Group,
+		Members: make([]*types.ContactGroupMemberResult, 0,
+			len(cgr.Succeeded)+len(cgr.Skipped)+len(cgr.Errors)),
+	}
+	for _, uid := range cgr.Succeeded {
+		res.Members = append(res.Members, &types.ContactGroupMemberResult{
+			Uid:    uid.Bytes(),
+			Status: "succeeded",
+		})
+	}
+	for _, uid := range cgr.Skipped {
+		res.Members = append(res.Members, &types.ContactGroupMemberResult{
+			Uid:    uid.Bytes(),
+			Status: "skipped",
+		})
+	}
+	for uid, err := range cgr.Errors {
+		res.Members = append(res.Members, &types.ContactGroupMemberResult{
+			Uid:    uid.Bytes(),
+			Status: "failed",
+			Error:  err.Error(),
+		})
+	}
+	return res
+}
+
+// PMContactGroup sends a message to each member of a contact group.
+func (c *chatServer) PMContactGroup(_ context.Context, req *types.PMContactGroupRequest, res *types.ContactGroupResult) error {
+	cgr, err := c.c.PMContactGroup(req.Group, req.Msg)
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactGroupResult(&cgr)
+	return nil
+}
+
+// ApplyContactGroupFilter applies a content filter rule to each member of a
+// contact group.
+func (c *chatServer) ApplyContactGroupFilter(_ context.Context, req *types.ApplyContactGroupFilterRequest, res *types.ContactGroupResult) error {
+	if req.Filter == nil {
+		return fmt.Errorf("filter is empty")
+	}
+	cf, err := unmarshalContentFilter(req.Filter)
+	if err != nil {
+		return err
+	}
+	cgr, err := c.c.ApplyContactGroupFilter(req.Group, cf)
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactGroupResult(&cgr)
+	return nil
+}
+
+// SendVoiceMessage sends a voice message to a user or GC.
+func (c *chatServer) SendVoiceMessage(_ context.Context, req *types.SendVoiceMessageRequest, res *types.SendVoiceMessageResponse) error {
+	target, err := c.chatTarget(req.User, req.Gc)
+	if err != nil {
+		return err
+	}
+
+	duration := time.Duration(req.DurationMs) * time.Millisecond
+	msgID, err := c.c.SendVoiceMsg(target, req.Audio, duration)
+	if err != nil {
+		return err
+	}
+	res.MsgId = msgID[:]
+	return nil
+}
+
+// GetAutoReply returns the current config for automatic replies.
+func (c *chatServer) GetAutoReply(_ context.Context, _ *types.GetAutoReplyRequest, res *types.AutoReplyConfig) error {
+	cfg, err := c.c.AutoReplyConfig()
+	if err != nil {
+		return err
+	}
+	*res = types.AutoReplyConfig{
+		Enabled:         cfg.Enabled,
+		Message:         cfg.Message,
+		IntervalSeconds: int64(cfg.Interval / time.Second),
+	}
+	return nil
+}
+
+// SetAutoReply sets the config for automatic replies.
+func (c *chatServer) SetAutoReply(_ context.Context, req *types.AutoReplyConfig, _ *types.SetAutoReplyResponse) error {
+	return c.c.SetAutoReply(clientdb.AutoReplyConfig{
+		Enabled:  req.Enabled,
+		Message:  req.Message,
+		Interval: time.Duration(req.IntervalSeconds) * time.Second,
+	})
+}
+
+// marshalContentFilter converts a content filter to its rpc representation.
+func marshalContentFilter(cf *clientdb.ContentFilter) *types.ContentFilter {
+	res := &types.ContentFilter{
+		Id:               cf.ID,
+		SkipPms:          cf.SkipPMs,
+		SkipGcms:         cf.SkipGCMs,
+		SkipPosts:        cf.SkipPosts,
+		SkipPostComments: cf.SkipPostComments,
+		Regexp:           cf.Regexp,
+		EmbedType:        cf.EmbedType,
+		Action:           cf.Action.String(),
+	}
+	if cf.UID != nil {
+		res.Uid = cf.UID.Bytes()
+	}
+	if cf.GC != nil {
+		res.Gc = cf.GC.Bytes()
+	}
+	return res
+}
+
+// ListContentFilters lists the content filter rules.
+func (c *chatServer) ListContentFilters(_ context.Context, _ *types.ListContentFiltersRequest, res *types.ListContentFiltersResponse) error {
+	filters := c.c.ListContentFilters()
+	res.Filters = make([]*types.ContentFilter, len(filters))
+	for i := range filters {
+		res.Filters[i] = marshalContentFilter(&filters[i])
+	}
+	return nil
+}
+
+// unmarshalContentFilter converts a content filter from its rpc
+// representation.
+func unmarshalContentFilter(req *types.ContentFilter) (clientdb.ContentFilter, error) {
+	cf := clientdb.ContentFilter{
+		ID:               req.Id,
+		SkipPMs:          req.SkipPms,
+		SkipGCMs:         req.SkipGcms,
+		SkipPosts:        req.SkipPosts,
+		SkipPostComments: req.SkipPostComments,
+		Regexp:           req.Regexp,
+		EmbedType:        req.EmbedType,
+		Action:           clientdb.ContentFilterAction(req.Action),
+	}
+	if cf.Action == "hide" {
+		cf.Action = clientdb.ContentFilterActionHide
+	}
+	if len(req.Uid) > 0 {
+		var uid client.UserID
+		if err := uid.FromBytes(req.Uid); err != nil {
+			return cf, fmt.Errorf("invalid uid: %v", err)
+		}
+		cf.UID = &uid
+	}
+	if len(req.Gc) > 0 {
+		var gc zkidentity.ShortID
+		if err := gc.FromBytes(req.Gc); err != nil {
+			return cf, fmt.Errorf("invalid gc: %v", err)
+		}
+		cf.GC = &gc
+	}
+	return cf, nil
+}
+
+// StoreContentFilter adds or updates a content filter rule.
+func (c *chatServer) StoreContentFilter(_ context.Context, req *types.ContentFilter, res *types.ContentFilter) error {
+	cf, err := unmarshalContentFilter(req)
+	if err != nil {
+		return err
+	}
+	if err := c.c.StoreContentFilter(&cf); err != nil {
+		return err
+	}
+	*res = *marshalContentFilter(&cf)
+	return nil
+}
+
+// RemoveContentFilter removes a content filter rule.
+func (c *chatServer) RemoveContentFilter(_ context.Context, req *types.RemoveContentFilterRequest, _ *types.RemoveContentFilterResponse) error {
+	return c.c.RemoveContentFilter(req.Id)
+}
+
+// ReadReceiptsStream returns a stream that gets sent events about chats
+// marked as read.
+func (c *chatServer) ReadReceiptsStream(ctx context.Context, req *types.ReadReceiptsStreamRequest, stream types.ChatService_ReadReceiptsStreamServer) error {
+	return c.rrStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+// AckReadReceipts acks received read receipt events.
+func (c *chatServer) AckReadReceipts(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.rrStreams.ack(req.SequenceId)
+}
+
+// sendRatchetHealthEvent fills the ratchet state of the given user in the
+// event and sends it to the ratchet health streams.
+func (c *chatServer) sendRatchetHealthEvent(ru *client.RemoteUser, ntfn *types.RatchetHealthEvent) {
+	unacked, err := c.c.UserUnackedRMsCount(ru.ID())
+	if err != nil {
+		c.log.Warnf("Unable to count unacked RMs of user %s: %v", ru, err)
+	}
+	encTime, decTime := ru.LastRatchetTimes()
+
+	ntfn.Uid = ru.ID().Bytes()
+	ntfn.Nick = ru.Nick()
+	ntfn.UnackedRms = uint32(unacked)
+	if !encTime.IsZero() {
+		ntfn.LastEncryptTime = encTime.Unix()
+	}
+	if !decTime.IsZero() {
+		ntfn.LastDecryptTime = decTime.Unix()
+	}
+	ntfn.TimestampMs = time.Now().UnixMilli()
+	c.rhStreams.send(ntfn)
+}
+
+// resetRequestedNtfnHandler is called by the client when a ratchet reset is
+// requested.
+func (c *chatServer) resetRequestedNtfnHandler(ru *client.RemoteUser, byRemote bool) {
+	ntfn := &types.RatchetHealthEvent{
+		Type: types.RatchetHealthEventType_RATCHET_HEALTH_RESET_REQUESTED,
+	}
+	if byRemote {
+		ntfn.Type = types.RatchetHealthEventType_RATCHET_HEALTH_RESET_REQUEST_RECEIVED
+	}
+	c.sendRatchetHealthEvent(ru, ntfn)
+}
+
+// resetCompletedNtfnHandler is called by the client when a KX completes, to
+// track completed ratchet resets.
+func (c *chatServer) resetCompletedNtfnHandler(_ *clientintf.RawRVID, ru *client.RemoteUser, isNew bool) {
+	if isNew {
+		return
+	}
+	ntfn := &types.RatchetHealthEvent{
+		Type: types.RatchetHealthEventType_RATCHET_HEALTH_RESET_COMPLETED,
+	}
+	c.sendRatchetHealthEvent(ru, ntfn)
+}
+
+// handshakeStageNtfnHandler is called by the client when a handshake message
+// is received.
+func (c *chatServer) handshakeStageNtfnHandler(ru *client.RemoteUser, msgtype string) {
+	ntfn := &types.RatchetHealthEvent{
+		Type:           types.RatchetHealthEventType_RATCHET_HEALTH_HANDSHAKE_STAGE,
+		HandshakeStage: msgtype,
+	}
+	c.sendRatchetHealthEvent(ru, ntfn)
+}
+
+// RatchetHealthStream returns a stream that gets sent events about the state
+// of ratchets with remote users.
+func (c *chatServer) RatchetHealthStream(ctx context.Context, req *types.RatchetHealthStreamRequest, stream types.ChatService_RatchetHealthStreamServer) error {
+	return c.rhStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+// AckRatchetHealth acks received ratchet health events.
+func (c *chatServer) AckRatchetHealth(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return c.rhStreams.ack(req.SequenceId)
+}
+
+// RatchetHealth returns diagnostics about the state of the ratchets with
+// remote users.
+func (c *chatServer) RatchetHealth(_ context.Context, req *types.RatchetHealthRequest, res *types.RatchetHealthResponse) error {
+	var reports []client.RatchetHealth
+	if req.User != "" {
+		ru, err := c.c.UserByNick(req.User)
+		if err != nil {
+			return err
+		}
+		report, err := c.c.RatchetHealth(ru.ID())
+		if err != nil {
+			return err
+		}
+		reports = []client.RatchetHealth{report}
+	} else {
+		var err error
+		reports, err = c.c.ListRatchetHealth()
+		if err != nil {
+			return err
+		}
+	}
+
+	res.Reports = make([]*types.RatchetHealthReport, len(reports))
+	for i, h := range reports {
+		r := &types.RatchetHealthReport{
+			Uid:             h.UID.Bytes(),
+			Nick:            h.Nick,
+			UnackedRms:      uint32(h.UnackedRMs),
+			Draining:        h.Draining,
+			SavedKeys:       uint32(h.NbSavedKeys),
+			KeysRemaining:   uint32(h.KeysRemaining),
+			ResetInProgress: h.ResetInProgress,
+			ProbablyBroken:  h.ProbablyBroken,
+			BrokenReason:    h.BrokenReason,
+		}
+		if !h.LastEncTime.IsZero() {
+			r.LastEncryptTime = h.LastEncTime.Unix()
+		}
+		if !h.LastDecTime.IsZero() {
+			r.LastDecryptTime = h.LastDecTime.Unix()
+		}
+		if !h.LastHandshakeAttempt.IsZero() {
+			r.LastHandshakeAttempt = h.LastHandshakeAttempt.Unix()
+		}
+		res.Reports[i] = r
+	}
+	return nil
+}
+
 // registerOfflineMessageStorageHandlers registers the handlers for streams on
 // the client's notification manager.
 func (c *chatServer) registerOfflineMessageStorageHandlers() {
@@ -321,6 +1324,13 @@ func (c *chatServer) registerOfflineMessageStorageHandlers() {
 	nmgr.RegisterSync(client.OnPMNtfn(c.pmNtfnHandler))
 	nmgr.RegisterSync(client.OnGCMNtfn(c.gcmNtfnHandler))
 	nmgr.RegisterSync(client.OnKXCompleted(c.kxNtfnHandler))
+	nmgr.RegisterSync(client.OnKXCompleted(c.resetCompletedNtfnHandler))
+	nmgr.RegisterSync(client.OnRatchetResetRequestedNtfn(c.resetRequestedNtfnHandler))
+	nmgr.RegisterSync(client.OnHandshakeStageNtfn(c.handshakeStageNtfnHandler))
+	nmgr.RegisterSync(client.OnMsgEditedNtfn(c.msgEditedNtfnHandler))
+	nmgr.RegisterSync(client.OnMsgReactionNtfn(c.msgReactionNtfnHandler))
+	nmgr.RegisterSync(client.OnGCMentionNtfn(c.gcMentionNtfnHandler))
+	nmgr.RegisterSync(client.OnMsgDeliveryStatusNtfn(c.msgDeliveryStatusNtfnHandler))
 }
 
 var _ types.ChatServiceServer = (*chatServer)(nil)
@@ -342,6 +1352,36 @@ func (s *Server) InitChatService(cfg ChatServerCfg) error {
 		return err
 	}
 
+	rrStreams, err := newServerStreams[*types.ReceivedReadReceipt](cfg.RootReplayMsgLogs, "readreceipts", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	rhStreams, err := newServerStreams[*types.RatchetHealthEvent](cfg.RootReplayMsgLogs, "ratchethealth", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	meStreams, err := newServerStreams[*types.ReceivedMessageEdit](cfg.RootReplayMsgLogs, "msgedits", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	mrStreams, err := newServerStreams[*types.ReceivedMessageReaction](cfg.RootReplayMsgLogs, "msgreactions", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	mentionStreams, err := newServerStreams[*types.GCReceivedMsg](cfg.RootReplayMsgLogs, "gcmentions", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	mdsStreams, err := newServerStreams[*types.MsgDeliveryStatusEvent](cfg.RootReplayMsgLogs, "msgdeliverystatus", cfg.Log)
+	if err != nil {
+		return err
+	}
+
 	cs := &chatServer{
 		cfg: cfg,
 		log: cfg.Log,
@@ -350,6 +1390,13 @@ func (s *Server) InitChatService(cfg ChatServerCfg) error {
 		pmStreams:  pmStreams,
 		gcmStreams: gcmStreams,
 		kxStreams:  kxStreams,
+		rrStreams:  rrStreams,
+		rhStreams:  rhStreams,
+		meStreams:  meStreams,
+		mrStreams:  mrStreams,
+
+		mentionStreams: mentionStreams,
+		mdsStreams:     mdsStreams,
 	}
 	cs.registerOfflineMessageStorageHandlers()
 	s.services.Bind("ChatService", types.ChatServiceDefn(), cs)
@@ -62,6 +62,21 @@ func (cs *contentServer) fileDownloadCompletedHandler(ru *client.RemoteUser, fm
 		}
 	}
 
+	if fm.Preview != nil {
+		ntfn.FileMetadata.Preview = &types.FilePreview{
+			Size: fm.Preview.Size,
+			Fid:  fm.Preview.FID,
+		}
+	}
+	ntfn.FileMetadata.Tiers = make([]*types.FilePriceTier, len(fm.Tiers))
+	for i, t := range fm.Tiers {
+		ntfn.FileMetadata.Tiers[i] = &types.FilePriceTier{
+			Name: t.Name,
+			End:  t.End,
+			Cost: t.Cost,
+		}
+	}
+
 	cs.completedStreams.send(ntfn)
 }
 
@@ -69,6 +84,25 @@ func (cs *contentServer) AckDownloadCompleted(ctx context.Context, req *types.Ac
 	return cs.completedStreams.ack(req.SequenceId)
 }
 
+func (cs *contentServer) GetBandwidthLimits(ctx context.Context, req *types.GetBandwidthLimitsRequest, res *types.BandwidthLimits) error {
+	limits := cs.c.BandwidthLimits()
+	res.Upload = limits.Upload
+	res.Download = limits.Download
+	res.PeerUpload = limits.PeerUpload
+	res.PeerDownload = limits.PeerDownload
+	return nil
+}
+
+func (cs *contentServer) SetBandwidthLimits(ctx context.Context, req *types.BandwidthLimits, res *types.SetBandwidthLimitsResponse) error {
+	cs.c.SetBandwidthLimits(client.BandwidthLimits{
+		Upload:       req.Upload,
+		Download:     req.Download,
+		PeerUpload:   req.PeerUpload,
+		PeerDownload: req.PeerDownload,
+	})
+	return nil
+}
+
 // registerOfflineMessageStorageHandlers registers the handlers for streams on
 // the client's notification manager.
 func (cs *contentServer) registerOfflineMessageStorageHandlers() {
@@ -2,6 +2,8 @@ package rpcserver
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/companyzero/bisonrelay/client"
 	"github.com/companyzero/bisonrelay/client/clientintf"
@@ -53,6 +55,19 @@ func (g *gcServer) InviteToGC(_ context.Context, req *types.InviteToGCRequest, _
 	return g.c.InviteToGroupChat(gcid, uid)
 }
 
+func (g *gcServer) InviteContactGroupToGC(_ context.Context, req *types.InviteContactGroupToGCRequest, res *types.ContactGroupResult) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	cgr, err := g.c.InviteContactGroupToGC(req.Group, gcid)
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactGroupResult(&cgr)
+	return nil
+}
+
 func (g *gcServer) AcceptGCInvite(_ context.Context, req *types.AcceptGCInviteRequest, _ *types.AcceptGCInviteResponse) error {
 	return g.c.AcceptGroupChatInvite(req.InviteId)
 }
@@ -93,10 +108,463 @@ func marshalRMGroupList(gl *rpc.RMGroupList, res *types.RMGroupList) *types.RMGr
 		Version:     uint32(gl.Version),
 		Members:     marshalRepeatedIDs(gl.Members, res.Members),
 		ExtraAdmins: marshalRepeatedIDs(gl.ExtraAdmins, res.ExtraAdmins),
+
+		Moderators:     marshalRepeatedIDs(gl.Moderators, res.Moderators),
+		AdminPerms:     uint32(gl.AdminPerms),
+		ModeratorPerms: uint32(gl.ModeratorPerms),
+		Pinned:         marshalRepeatedIDs(gl.Pinned, res.Pinned),
+		Banned:         marshalRepeatedIDs(gl.Banned, res.Banned),
+
+		SlowModeInterval: gl.SlowModeInterval,
+		Topic:            gl.Topic,
+		Description:      gl.Description,
+		Avatar:           gl.Avatar,
+		Channels:         marshalGCChannels(gl.Channels),
+		MaxMembers:       gl.MaxMembers,
+		JoinApproval:     gl.JoinApproval,
+	}
+	return res
+}
+
+func marshalGCChannels(channels []rpc.GCChannel) []*types.GCChannel {
+	if len(channels) == 0 {
+		return nil
+	}
+	res := make([]*types.GCChannel, len(channels))
+	for i := range channels {
+		res[i] = &types.GCChannel{
+			Id:   channels[i].ID[:],
+			Name: channels[i].Name,
+		}
 	}
 	return res
 }
 
+func (g *gcServer) ModifyGCModerators(_ context.Context, req *types.ModifyGCModeratorsRequest, _ *types.ModifyGCModeratorsResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	mods := make([]zkidentity.ShortID, 0, len(req.Moderators))
+	for _, nick := range req.Moderators {
+		uid, err := g.c.UIDByNick(nick)
+		if err != nil {
+			return err
+		}
+		mods = append(mods, uid)
+	}
+	return g.c.ModifyGCModerators(gcid, mods, req.Reason)
+}
+
+func (g *gcServer) SetGCRolePerms(_ context.Context, req *types.SetGCRolePermsRequest, _ *types.SetGCRolePermsResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var role client.GCRole
+	switch req.Role {
+	case types.GCRole_GC_ROLE_ADMIN:
+		role = client.GCRoleAdmin
+	case types.GCRole_GC_ROLE_MODERATOR:
+		role = client.GCRoleModerator
+	default:
+		return fmt.Errorf("cannot set permissions of role %s", req.Role)
+	}
+	return g.c.SetGCRolePerms(gcid, role, rpc.GCPermission(req.Perms))
+}
+
+func (g *gcServer) PinGCMessage(_ context.Context, req *types.PinGCMessageRequest, _ *types.PinGCMessageResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var msgID zkidentity.ShortID
+	if err := msgID.FromBytes(req.MsgId); err != nil {
+		return err
+	}
+	if req.Unpin {
+		return g.c.UnpinGCMessage(gcid, msgID)
+	}
+	return g.c.PinGCMessage(gcid, msgID)
+}
+
+// gcUserID returns the ID of the user identified by the given nick or full
+// hex-encoded ID. Full IDs are not required to be of known users, to allow
+// banning users the local client is not KX'd with.
+func (g *gcServer) gcUserID(s string) (clientintf.UserID, error) {
+	var uid clientintf.UserID
+	if len(s) == 64 {
+		err := uid.FromString(s)
+		return uid, err
+	}
+	return g.c.UIDByNick(s)
+}
+
+func (g *gcServer) BanFromGC(_ context.Context, req *types.BanFromGCRequest, _ *types.BanFromGCResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	uid, err := g.gcUserID(req.User)
+	if err != nil {
+		return err
+	}
+
+	return g.c.BanFromGC(gcid, uid, req.Reason)
+}
+
+func (g *gcServer) UnbanFromGC(_ context.Context, req *types.UnbanFromGCRequest, _ *types.UnbanFromGCResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	uid, err := g.gcUserID(req.User)
+	if err != nil {
+		return err
+	}
+
+	return g.c.UnbanFromGC(gcid, uid)
+}
+
+func (g *gcServer) ListGCBans(_ context.Context, req *types.ListGCBansRequest, res *types.ListGCBansResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	banned, err := g.c.ListGCBans(gcid)
+	if err != nil {
+		return err
+	}
+
+	res.Banned = g.marshalUserAndNick(banned, res.Banned)
+	return nil
+}
+
+func (g *gcServer) GCModLog(_ context.Context, req *types.GCModLogRequest, res *types.GCModLogResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	entries, err := g.c.GCModLog(gcid)
+	if err != nil {
+		return err
+	}
+
+	res.Entries = make([]*types.GCModLogEntry, len(entries))
+	for i, e := range entries {
+		res.Entries[i] = &types.GCModLogEntry{
+			Timestamp: e.Timestamp.Unix(),
+			Actor:     e.Actor[:],
+			Action:    e.Action,
+			Details:   e.Details,
+			Reason:    e.Reason,
+		}
+		if e.Target != nil {
+			res.Entries[i].Target = e.Target[:]
+		}
+	}
+	return nil
+}
+
+func (g *gcServer) CreateGCInviteLink(_ context.Context, req *types.CreateGCInviteLinkRequest, res *types.CreateGCInviteLinkResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	expiry := time.Duration(req.ExpirySeconds) * time.Second
+	link, err := g.c.CreateGCInviteLink(gcid, expiry, req.MaxUses)
+	if err != nil {
+		return err
+	}
+	res.Id = link.ID[:]
+	res.Link = link.String()
+	return nil
+}
+
+func (g *gcServer) RevokeGCInviteLink(_ context.Context, req *types.RevokeGCInviteLinkRequest, _ *types.RevokeGCInviteLinkResponse) error {
+	var id zkidentity.ShortID
+	if err := id.FromBytes(req.Id); err != nil {
+		return err
+	}
+	return g.c.RevokeGCInviteLink(id)
+}
+
+func (g *gcServer) ListGCInviteLinks(_ context.Context, req *types.ListGCInviteLinksRequest, res *types.ListGCInviteLinksResponse) error {
+	var gcid *zkidentity.ShortID
+	if req.Gc != "" {
+		id, err := g.c.GCIDByName(req.Gc)
+		if err != nil {
+			return err
+		}
+		gcid = &id
+	}
+	links, err := g.c.ListGCInviteLinks(gcid)
+	if err != nil {
+		return err
+	}
+
+	res.Links = make([]*types.GCInviteLinkInfo, len(links))
+	for i, l := range links {
+		res.Links[i] = &types.GCInviteLinkInfo{
+			Id:      l.ID[:],
+			Gc:      l.GC[:],
+			Created: l.Created.Unix(),
+			MaxUses: l.MaxUses,
+			UsedBy:  marshalRepeatedIDs(l.UsedBy, nil),
+			Revoked: l.Revoked,
+		}
+		if !l.Expires.IsZero() {
+			res.Links[i].Expires = l.Expires.Unix()
+		}
+	}
+	return nil
+}
+
+func (g *gcServer) RedeemGCInviteLink(_ context.Context, req *types.RedeemGCInviteLinkRequest, _ *types.RedeemGCInviteLinkResponse) error {
+	link, err := client.ParseGCInviteLink(req.Link)
+	if err != nil {
+		return err
+	}
+	return g.c.RedeemGCInviteLink(link)
+}
+
+func (g *gcServer) SetGCRetention(_ context.Context, req *types.SetGCRetentionRequest, res *types.SetGCRetentionResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var policy client.GCRetentionPolicy
+	if req.Policy != nil {
+		policy.MaxAgeDays = req.Policy.MaxAgeDays
+		policy.MaxMessages = req.Policy.MaxMessages
+	}
+	pruned, err := g.c.SetGCRetentionPolicy(gcid, policy)
+	if err != nil {
+		return err
+	}
+	res.Pruned = uint32(pruned)
+	return nil
+}
+
+func (g *gcServer) GetGCRetention(_ context.Context, req *types.GetGCRetentionRequest, res *types.GetGCRetentionResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	policy, err := g.c.GetGCRetentionPolicy(gcid)
+	if err != nil {
+		return err
+	}
+	res.Policy = &types.GCRetentionPolicy{
+		MaxAgeDays:  policy.MaxAgeDays,
+		MaxMessages: policy.MaxMessages,
+	}
+	return nil
+}
+
+func (g *gcServer) SetGCSlowMode(_ context.Context, req *types.SetGCSlowModeRequest, _ *types.SetGCSlowModeResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	return g.c.SetGCSlowMode(gcid, interval)
+}
+
+func (g *gcServer) UpdateGCMetadata(_ context.Context, req *types.UpdateGCMetadataRequest, _ *types.UpdateGCMetadataResponse) error {
+	if req.ClearTopic && req.Topic != "" {
+		return fmt.Errorf("cannot both set and clear the topic")
+	}
+	if req.ClearDescription && req.Description != "" {
+		return fmt.Errorf("cannot both set and clear the description")
+	}
+	if req.ClearAvatar && len(req.Avatar) > 0 {
+		return fmt.Errorf("cannot both set and clear the avatar")
+	}
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+
+	var update client.GCMetadataUpdate
+	if req.Topic != "" || req.ClearTopic {
+		update.Topic = &req.Topic
+	}
+	if req.Description != "" || req.ClearDescription {
+		update.Description = &req.Description
+	}
+	if len(req.Avatar) > 0 {
+		update.Avatar = req.Avatar
+	} else if req.ClearAvatar {
+		update.Avatar = []byte{}
+	}
+	return g.c.UpdateGCMetadata(gcid, update)
+}
+
+func (g *gcServer) CreateGCChannel(_ context.Context, req *types.CreateGCChannelRequest, res *types.CreateGCChannelResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	ch, err := g.c.CreateGCChannel(gcid, req.Name)
+	if err != nil {
+		return err
+	}
+	res.Channel = &types.GCChannel{Id: ch.ID[:], Name: ch.Name}
+	return nil
+}
+
+func (g *gcServer) RemoveGCChannel(_ context.Context, req *types.RemoveGCChannelRequest, _ *types.RemoveGCChannelResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var channel zkidentity.ShortID
+	if err := channel.FromBytes(req.Channel); err != nil {
+		return err
+	}
+	return g.c.RemoveGCChannel(gcid, channel)
+}
+
+func (g *gcServer) ListGCChannels(_ context.Context, req *types.ListGCChannelsRequest, res *types.ListGCChannelsResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	channels, err := g.c.ListGCChannels(gcid)
+	if err != nil {
+		return err
+	}
+	res.Channels = marshalGCChannels(channels)
+	return nil
+}
+
+func (g *gcServer) GCChannelHistory(_ context.Context, req *types.GCChannelHistoryRequest, res *types.GCChannelHistoryResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var channel zkidentity.ShortID
+	if err := channel.FromBytes(req.Channel); err != nil {
+		return err
+	}
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = 50
+	}
+	entries, err := g.c.ReadGCChannelHistory(gcid, channel, pageSize, int(req.PageNum))
+	if err != nil {
+		return err
+	}
+	res.Messages = make([]*types.GCChannelHistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Internal {
+			continue
+		}
+		res.Messages = append(res.Messages, &types.GCChannelHistoryEntry{
+			From:      e.From,
+			Message:   e.Message,
+			Timestamp: e.Timestamp,
+		})
+	}
+	return nil
+}
+
+func (g *gcServer) SetGCJoinPolicy(_ context.Context, req *types.SetGCJoinPolicyRequest, _ *types.SetGCJoinPolicyResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	return g.c.SetGCJoinPolicy(gcid, req.MaxMembers, req.RequireApproval)
+}
+
+func (g *gcServer) ListGCJoinRequests(_ context.Context, req *types.ListGCJoinRequestsRequest, res *types.ListGCJoinRequestsResponse) error {
+	var gcid *zkidentity.ShortID
+	if req.Gc != "" {
+		id, err := g.c.GCIDByName(req.Gc)
+		if err != nil {
+			return err
+		}
+		gcid = &id
+	}
+	reqs, err := g.c.ListGCJoinRequests(gcid)
+	if err != nil {
+		return err
+	}
+
+	res.Requests = make([]*types.GCJoinRequest, len(reqs))
+	for i, r := range reqs {
+		res.Requests[i] = &types.GCJoinRequest{
+			Gc:       r.GC[:],
+			Uid:      r.UID[:],
+			LinkId:   r.LinkID[:],
+			Received: r.Received.Unix(),
+		}
+	}
+	return nil
+}
+
+func (g *gcServer) ApproveGCJoinRequest(_ context.Context, req *types.ApproveGCJoinRequestRequest, _ *types.ApproveGCJoinRequestResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var uid zkidentity.ShortID
+	if err := uid.FromBytes(req.Uid); err != nil {
+		return err
+	}
+	return g.c.ApproveGCJoinRequest(gcid, uid)
+}
+
+func (g *gcServer) DenyGCJoinRequest(_ context.Context, req *types.DenyGCJoinRequestRequest, _ *types.DenyGCJoinRequestResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var uid zkidentity.ShortID
+	if err := uid.FromBytes(req.Uid); err != nil {
+		return err
+	}
+	return g.c.DenyGCJoinRequest(gcid, uid)
+}
+
+func (g *gcServer) SetGCTipLeaderboard(_ context.Context, req *types.SetGCTipLeaderboardRequest, _ *types.SetGCTipLeaderboardResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	return g.c.SetGCTipLeaderboard(gcid, req.Enabled)
+}
+
+func (g *gcServer) GCTipLeaderboard(_ context.Context, req *types.GCTipLeaderboardRequest, res *types.GCTipLeaderboardResponse) error {
+	gcid, err := g.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	res.Enabled, err = g.c.GCTipLeaderboardEnabled(gcid)
+	if err != nil {
+		return err
+	}
+	entries, err := g.c.GCTipLeaderboard(gcid, time.Unix(req.Since, 0))
+	if err != nil {
+		return err
+	}
+
+	res.Entries = make([]*types.GCTipLeaderboardEntry, len(entries))
+	for i, e := range entries {
+		nick, _ := g.c.UserNick(e.UID)
+		res.Entries[i] = &types.GCTipLeaderboardEntry{
+			Uid:            e.UID[:],
+			Nick:           nick,
+			SentMatoms:     e.SentMAtoms,
+			SentCount:      int32(e.SentCount),
+			ReceivedMatoms: e.ReceivedMAtoms,
+			ReceivedCount:  int32(e.ReceivedCount),
+		}
+	}
+	return nil
+}
+
 func (g *gcServer) GetGC(_ context.Context, req *types.GetGCRequest, res *types.GetGCResponse) error {
 	gcid, err := g.c.GCIDByName(req.Gc)
 	if err != nil {
@@ -129,6 +597,8 @@ func (g *gcServer) List(_ context.Context, _ *types.ListGCsRequest, res *types.L
 			Version:   uint32(gc.Version),
 			Timestamp: gc.Timestamp,
 			NbMembers: uint32(len(gc.Members)),
+			Topic:     gc.Topic,
+			HasAvatar: len(gc.Avatar) > 0,
 		}
 		res.Gcs = append(res.Gcs, gci)
 	}
@@ -2,8 +2,10 @@ package rpcserver
 
 import (
 	"context"
+	"time"
 
 	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/clientrpc/types"
 	"github.com/decred/slog"
@@ -50,23 +52,212 @@ func (p *paymentsServer) TipUser(ctx context.Context, req *types.TipUserRequest,
 	return p.c.TipUser(user.ID(), req.DcrAmount, req.MaxAttempts)
 }
 
+func (p *paymentsServer) EstimateTipFee(ctx context.Context, req *types.EstimateTipFeeRequest, res *types.EstimateTipFeeResponse) error {
+	user, err := p.c.UserByNick(req.User)
+	if err != nil {
+		return err
+	}
+	est, err := p.c.EstimateTipFee(user.ID(), req.DcrAmount)
+	if err != nil {
+		return err
+	}
+	*res = types.EstimateTipFeeResponse{
+		FeeMatoms:    est.FeeMAtoms,
+		MaxFeeMatoms: est.MaxFeeMAtoms,
+		Hops:         int32(est.Hops),
+	}
+	return nil
+}
+
+func (p *paymentsServer) TipContactGroup(ctx context.Context, req *types.TipContactGroupRequest, res *types.ContactGroupResult) error {
+	if p.cfg.OnTipUser != nil {
+		cg, err := p.c.GetContactGroup(req.Group)
+		if err != nil {
+			return err
+		}
+		for _, uid := range cg.Members {
+			err := p.cfg.OnTipUser(uid, req.DcrAmount)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cgr, err := p.c.TipContactGroup(req.Group, req.DcrAmount, req.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactGroupResult(&cgr)
+	return nil
+}
+
+func (p *paymentsServer) TipGCMembers(ctx context.Context, req *types.TipGCMembersRequest, res *types.TipGCMembersResponse) error {
+	gcID, err := p.c.GCIDByName(req.Gc)
+	if err != nil {
+		return err
+	}
+	var members []clientintf.UserID
+	for _, nick := range req.Members {
+		ru, err := p.c.UserByNick(nick)
+		if err != nil {
+			return err
+		}
+		members = append(members, ru.ID())
+	}
+	if p.cfg.OnTipUser != nil {
+		members, err = p.c.GCTipSplitMembers(gcID, members)
+		if err != nil {
+			return err
+		}
+		share := req.DcrAmount / float64(len(members))
+		for _, uid := range members {
+			err := p.cfg.OnTipUser(uid, share)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	split, err := p.c.TipGCMembers(gcID, req.DcrAmount, members,
+		req.MaxAttempts, req.Announce)
+	if err != nil {
+		return err
+	}
+	res.ShareAtoms = int64(split.Share)
+	res.Members = make([][]byte, len(split.Members))
+	for i := range split.Members {
+		res.Members[i] = split.Members[i].UID.Bytes()
+	}
+	return nil
+}
+
+func marshalInvoice(inv *client.Invoice) *types.Invoice {
+	return &types.Invoice{
+		Invoice:    inv.Invoice,
+		User:       inv.UID.Bytes(),
+		Generated:  inv.Direction == client.InvoiceGenerated,
+		MilliAtoms: inv.MilliAtoms,
+		Created:    inv.Created.Unix(),
+		Status:     string(inv.Status),
+		Label:      inv.Label,
+		Memo:       inv.Memo,
+	}
+}
+
+func (p *paymentsServer) ListInvoices(ctx context.Context, req *types.ListInvoicesRequest, res *types.ListInvoicesResponse) error {
+	invoices, err := p.c.ListInvoices(req.OnlyOpen)
+	if err != nil {
+		return err
+	}
+	res.Invoices = make([]*types.Invoice, len(invoices))
+	for i := range invoices {
+		res.Invoices[i] = marshalInvoice(&invoices[i])
+	}
+	return nil
+}
+
+func (p *paymentsServer) InvoiceStatus(ctx context.Context, req *types.InvoiceStatusRequest, res *types.Invoice) error {
+	inv, err := p.c.InvoiceStatus(req.Invoice)
+	if err != nil {
+		return err
+	}
+	*res = *marshalInvoice(&inv)
+	return nil
+}
+
+func (p *paymentsServer) CancelInvoice(ctx context.Context, req *types.CancelInvoiceRequest, _ *types.CancelInvoiceResponse) error {
+	return p.c.CancelInvoice(req.Invoice)
+}
+
+func (p *paymentsServer) SetInvoiceLabel(ctx context.Context, req *types.SetInvoiceLabelRequest, _ *types.SetInvoiceLabelResponse) error {
+	return p.c.SetInvoiceLabel(req.Invoice, req.Label, req.Memo)
+}
+
+func marshalPaymentTotals(t *clientdb.PaymentTotals) *types.PaymentTotals {
+	return &types.PaymentTotals{
+		TipsSent:        t.Tips.Sent,
+		TipsReceived:    t.Tips.Received,
+		ContentSent:     t.Content.Sent,
+		ContentReceived: t.Content.Received,
+		OtherSent:       t.Other.Sent,
+		OtherReceived:   t.Other.Received,
+		PayFees:         t.PayFees,
+	}
+}
+
+func (p *paymentsServer) PaymentReport(_ context.Context, req *types.PaymentReportRequest, res *types.PaymentReportResponse) error {
+	end := time.Now()
+	if req.End != 0 {
+		end = time.Unix(req.End, 0)
+	}
+	window := time.Duration(req.WindowSeconds) * time.Second
+	report, err := p.c.PaymentReport(time.Unix(req.Start, 0), end, window)
+	if err != nil {
+		return err
+	}
+
+	res.Totals = marshalPaymentTotals(&report.Totals)
+	res.Users = make([]*types.UserPaymentTotals, len(report.Users))
+	for i := range report.Users {
+		u := &report.Users[i]
+		nick, _ := p.c.UserNick(u.UID)
+		res.Users[i] = &types.UserPaymentTotals{
+			Uid:    u.UID.Bytes(),
+			Nick:   nick,
+			Totals: marshalPaymentTotals(&u.PaymentTotals),
+		}
+	}
+	res.Windows = make([]*types.PaymentReportWindow, len(report.Windows))
+	for i := range report.Windows {
+		w := &report.Windows[i]
+		res.Windows[i] = &types.PaymentReportWindow{
+			Start:  w.Start.Unix(),
+			Totals: marshalPaymentTotals(&w.PaymentTotals),
+		}
+	}
+	return nil
+}
+
+func (p *paymentsServer) RebalanceChannels(ctx context.Context, req *types.RebalanceChannelsRequest, res *types.RebalanceChannelsResponse) error {
+	rebalance, err := p.c.RebalanceChannels(ctx, clientintf.RebalanceRequest{
+		OutChannel:   req.OutChannel,
+		InChannel:    req.InChannel,
+		AmountMAtoms: req.AmountMatoms,
+		MaxFeeMAtoms: req.MaxFeeMatoms,
+	})
+	if err != nil {
+		return err
+	}
+	res.OutChanPoint = rebalance.OutChanPoint
+	res.InChanPoint = rebalance.InChanPoint
+	res.AmountMatoms = rebalance.AmountMAtoms
+	res.FeeMatoms = rebalance.FeeMAtoms
+	res.Hops = uint32(rebalance.Hops)
+	return nil
+}
+
 func (p *paymentsServer) TipProgress(ctx context.Context, req *types.TipProgressRequest, stream types.PaymentsService_TipProgressServer) error {
 	return p.tipProgressStreams.runStream(ctx, req.UnackedFrom, stream)
 }
 
-func (p *paymentsServer) tipProgressNtfnHandler(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+func (p *paymentsServer) tipProgressNtfnHandler(ru *client.RemoteUser, progress client.TipAttemptProgress) {
 	var attemptErrMsg string
-	if attemptErr != nil {
-		attemptErrMsg = attemptErr.Error()
+	if progress.Err != nil {
+		attemptErrMsg = progress.Err.Error()
 	}
 	ntfn := &types.TipProgressEvent{
-		Uid:          ru.ID().Bytes(),
-		Nick:         ru.Nick(),
-		AmountMatoms: amtMAtoms,
-		Completed:    completed,
-		Attempt:      int32(attempt),
-		AttemptErr:   attemptErrMsg,
-		WillRetry:    willRetry,
+		Uid:              ru.ID().Bytes(),
+		Nick:             ru.Nick(),
+		AmountMatoms:     progress.AmtMAtoms,
+		Completed:        progress.Completed,
+		Attempt:          int32(progress.Attempt),
+		AttemptErr:       attemptErrMsg,
+		WillRetry:        progress.WillRetry,
+		MaxAttempts:      int32(progress.MaxAttempts),
+		RemainingRetries: int32(progress.RemainingRetries),
+		PaymentRetries:   int32(progress.PaymentRetries),
+		FailureReason:    string(progress.FailureReason),
+		Destination:      progress.Destination,
+		FeesMatoms:       progress.FeesMAtoms,
+		Keysend:          progress.Keysend,
 	}
 	p.tipProgressStreams.send(ntfn)
 }
@@ -77,7 +268,7 @@ func (p *paymentsServer) AckTipProgress(_ context.Context, req *types.AckRequest
 
 func (p *paymentsServer) registerOfflineMessageStorageHandlers() {
 	nmgr := p.c.NotificationManager()
-	nmgr.RegisterSync(client.OnTipAttemptProgressNtfn(p.tipProgressNtfnHandler))
+	nmgr.RegisterSync(client.OnTipAttemptDetailsNtfn(p.tipProgressNtfnHandler))
 }
 
 var _ types.PaymentsServiceServer = (*paymentsServer)(nil)
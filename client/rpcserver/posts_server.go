@@ -1,13 +1,18 @@
 package rpcserver
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"sort"
+	"time"
 
 	"github.com/companyzero/bisonrelay/client"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/clientrpc/types"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/slog"
 )
 
@@ -52,6 +57,22 @@ func (p *postsServer) PostsStream(ctx context.Context, req *types.PostsStreamReq
 	return p.postStreams.runStream(ctx, req.UnackedFrom, stream)
 }
 
+// marshalPostSummary converts the post summary to its rpc representation.
+func marshalPostSummary(summ *clientdb.PostSummary) *types.PostSummary {
+	return &types.PostSummary{
+		Id:           summ.ID[:],
+		From:         summ.From[:],
+		AuthorId:     summ.AuthorID[:],
+		AuthorNick:   summ.AuthorNick,
+		Date:         summ.Date.Unix(),
+		LastStatusTs: summ.LastStatusTS.Unix(),
+		Title:        summ.Title,
+		Tags:         summ.Tags,
+		RelayComment: summ.RelayComment,
+		Pinned:       summ.Pinned,
+	}
+}
+
 func (p *postsServer) postsNtfnHandler(ru *client.RemoteUser, summ clientdb.PostSummary, pm rpc.PostMetadata) {
 	var relayerID []byte
 	if ru != nil {
@@ -59,15 +80,7 @@ func (p *postsServer) postsNtfnHandler(ru *client.RemoteUser, summ clientdb.Post
 	}
 	ntfn := &types.ReceivedPost{
 		RelayerId: relayerID,
-		Summary: &types.PostSummary{
-			Id:           summ.ID[:],
-			From:         summ.From[:],
-			AuthorId:     summ.AuthorID[:],
-			AuthorNick:   summ.AuthorNick,
-			Date:         summ.Date.Unix(),
-			LastStatusTs: summ.LastStatusTS.Unix(),
-			Title:        summ.Title,
-		},
+		Summary:   marshalPostSummary(&summ),
 		Post: &types.PostMetadata{
 			Version:    pm.Version,
 			Attributes: pm.Attributes,
@@ -122,6 +135,421 @@ func (p *postsServer) postStatusNtfnHandler(user *client.RemoteUser, pid clienti
 	p.statusStreams.send(ntfn)
 }
 
+// marshalPostComment converts the comment (and its replies) to its rpc
+// representation.
+func marshalPostComment(cmt *client.PostComment) *types.PostComment {
+	res := &types.PostComment{
+		Id: cmt.ID[:],
+		Status: &types.PostMetadataStatus{
+			Version:    cmt.Status.Version,
+			From:       cmt.Status.From,
+			Link:       cmt.Status.Link,
+			Attributes: cmt.Status.Attributes,
+		},
+		Depth:    int32(cmt.Depth),
+		Children: make([]*types.PostComment, len(cmt.Children)),
+		Hidden:   cmt.Hidden,
+		Flagged:  cmt.Flagged,
+	}
+	for i, child := range cmt.Children {
+		res.Children[i] = marshalPostComment(child)
+	}
+	return res
+}
+
+func (p *postsServer) CommentTree(_ context.Context, req *types.CommentTreeRequest, res *types.CommentTreeResponse) error {
+	var from clientintf.UserID
+	if err := from.FromBytes(req.PostFrom); err != nil {
+		return err
+	}
+	var pid clientintf.PostID
+	if err := pid.FromBytes(req.PostId); err != nil {
+		return err
+	}
+	maxDepth := int(req.MaxDepth)
+	if maxDepth == 0 {
+		maxDepth = -1
+	}
+
+	var comments []*client.PostComment
+	if len(req.CommentId) > 0 {
+		var cid clientintf.ID
+		if err := cid.FromBytes(req.CommentId); err != nil {
+			return err
+		}
+		cmt, err := p.c.PostCommentSubtree(from, pid, cid, maxDepth)
+		if err != nil {
+			return err
+		}
+		comments = []*client.PostComment{cmt}
+	} else {
+		var err error
+		comments, err = p.c.PostCommentTree(from, pid)
+		if err != nil {
+			return err
+		}
+		if maxDepth >= 0 {
+			for _, cmt := range comments {
+				client.PrunePostComment(cmt, maxDepth)
+			}
+		}
+	}
+
+	res.Comments = make([]*types.PostComment, len(comments))
+	for i, cmt := range comments {
+		res.Comments[i] = marshalPostComment(cmt)
+	}
+	return nil
+}
+
+// marshalPostDraft converts the post draft to its rpc representation.
+func marshalPostDraft(draft *client.PostDraft, res *types.PostDraft) {
+	res.Id = draft.ID[:]
+	res.Post = draft.Post
+	res.Descr = draft.Descr
+	res.CreatedMs = draft.Created.UnixMilli()
+	res.LastUpdatedMs = draft.LastUpdated.UnixMilli()
+	if draft.PublishAt != nil {
+		res.PublishAtMs = draft.PublishAt.UnixMilli()
+	}
+}
+
+func (p *postsServer) SavePostDraft(_ context.Context, req *types.SavePostDraftRequest, res *types.PostDraft) error {
+	var id *zkidentity.ShortID
+	if len(req.Id) > 0 {
+		id = new(zkidentity.ShortID)
+		if err := id.FromBytes(req.Id); err != nil {
+			return err
+		}
+	}
+	var publishAt *time.Time
+	if req.PublishAtMs > 0 {
+		t := time.UnixMilli(req.PublishAtMs)
+		publishAt = &t
+	}
+	draft, err := p.c.SavePostDraft(id, req.Post, req.Descr, publishAt)
+	if err != nil {
+		return err
+	}
+	marshalPostDraft(&draft, res)
+	return nil
+}
+
+func (p *postsServer) ListPostDrafts(_ context.Context, _ *types.ListPostDraftsRequest, res *types.ListPostDraftsResponse) error {
+	drafts, err := p.c.ListPostDrafts()
+	if err != nil {
+		return err
+	}
+	res.Drafts = make([]*types.PostDraft, len(drafts))
+	for i := range drafts {
+		res.Drafts[i] = &types.PostDraft{}
+		marshalPostDraft(&drafts[i], res.Drafts[i])
+	}
+	return nil
+}
+
+func (p *postsServer) RemovePostDraft(_ context.Context, req *types.RemovePostDraftRequest, _ *types.RemovePostDraftResponse) error {
+	var id zkidentity.ShortID
+	if err := id.FromBytes(req.Id); err != nil {
+		return err
+	}
+	return p.c.RemovePostDraft(id)
+}
+
+func (p *postsServer) PublishPostDraft(_ context.Context, req *types.PublishPostDraftRequest, res *types.PublishPostDraftResponse) error {
+	var id zkidentity.ShortID
+	if err := id.FromBytes(req.Id); err != nil {
+		return err
+	}
+	summ, err := p.c.PublishPostDraft(id)
+	if err != nil {
+		return err
+	}
+	res.PostId = summ.ID[:]
+	return nil
+}
+
+func (p *postsServer) SetPostTagFilter(_ context.Context, req *types.SetPostTagFilterRequest, _ *types.SetPostTagFilterResponse) error {
+	if req.Filter == nil {
+		return errors.New("filter not specified")
+	}
+	var author clientintf.UserID
+	if err := author.FromBytes(req.Filter.AuthorId); err != nil {
+		return err
+	}
+	return p.c.SetPostTagFilter(author, req.Filter.Include, req.Filter.Exclude)
+}
+
+func (p *postsServer) ListPostTagFilters(_ context.Context, _ *types.ListPostTagFiltersRequest, res *types.ListPostTagFiltersResponse) error {
+	filters, err := p.c.ListPostTagFilters()
+	if err != nil {
+		return err
+	}
+	res.Filters = make([]*types.PostTagFilter, 0, len(filters))
+	for author, filter := range filters {
+		author := author
+		res.Filters = append(res.Filters, &types.PostTagFilter{
+			AuthorId: author[:],
+			Include:  filter.Include,
+			Exclude:  filter.Exclude,
+		})
+	}
+	sort.Slice(res.Filters, func(i, j int) bool {
+		return bytes.Compare(res.Filters[i].AuthorId, res.Filters[j].AuthorId) < 0
+	})
+	return nil
+}
+
+func (p *postsServer) ReactToPost(_ context.Context, req *types.ReactToPostRequest, _ *types.ReactToPostResponse) error {
+	var from clientintf.UserID
+	if err := from.FromBytes(req.PostFrom); err != nil {
+		return err
+	}
+	var pid clientintf.PostID
+	if err := pid.FromBytes(req.PostId); err != nil {
+		return err
+	}
+	return p.c.ReactToPost(from, pid, req.Reaction, req.Remove)
+}
+
+func (p *postsServer) PostReactions(_ context.Context, req *types.PostReactionsRequest, res *types.PostReactionsResponse) error {
+	var from clientintf.UserID
+	if err := from.FromBytes(req.PostFrom); err != nil {
+		return err
+	}
+	var pid clientintf.PostID
+	if err := pid.FromBytes(req.PostId); err != nil {
+		return err
+	}
+	reactions, err := p.c.PostReactions(from, pid)
+	if err != nil {
+		return err
+	}
+	res.Reactions = make([]*types.PostReaction, len(reactions))
+	for i, r := range reactions {
+		users := make([][]byte, len(r.Users))
+		for j := range r.Users {
+			users[j] = r.Users[j].Bytes()
+		}
+		res.Reactions[i] = &types.PostReaction{
+			Reaction: r.Reaction,
+			Users:    users,
+		}
+	}
+	return nil
+}
+
+func marshalPostStats(stats *client.PostStats) *types.PostStats {
+	return &types.PostStats{
+		PostId:       stats.Post[:],
+		Relays:       uint32(len(stats.Relayers)),
+		Comments:     stats.Comments,
+		Hearts:       stats.Hearts,
+		Reactions:    stats.Reactions,
+		Unlocks:      uint32(len(stats.Unlockers)),
+		UnlockAtoms:  stats.UnlockAtoms,
+		LastActivity: stats.LastActivity.Unix(),
+	}
+}
+
+func (p *postsServer) PostStats(_ context.Context, req *types.PostStatsRequest, res *types.PostStatsResponse) error {
+	if len(req.PostId) > 0 {
+		var pid clientintf.PostID
+		if err := pid.FromBytes(req.PostId); err != nil {
+			return err
+		}
+		stats, err := p.c.GetPostStats(pid)
+		if err != nil {
+			return err
+		}
+		res.Stats = []*types.PostStats{marshalPostStats(&stats)}
+		return nil
+	}
+
+	stats, err := p.c.ListPostStats()
+	if err != nil {
+		return err
+	}
+	res.Stats = make([]*types.PostStats, len(stats))
+	for i := range stats {
+		res.Stats[i] = marshalPostStats(&stats[i])
+	}
+	return nil
+}
+
+func (p *postsServer) PostSubscriberChurn(_ context.Context, req *types.PostSubscriberChurnRequest, res *types.PostSubscriberChurnResponse) error {
+	churn, err := p.c.PostSubscriberChurn(time.Unix(req.Since, 0))
+	if err != nil {
+		return err
+	}
+	res.Subscribed = uint32(churn.Subscribed)
+	res.Unsubscribed = uint32(churn.Unsubscribed)
+	res.Subscribers = uint32(churn.Subscribers)
+	res.Events = make([]*types.PostSubscriberEvent, len(churn.Events))
+	for i, e := range churn.Events {
+		res.Events[i] = &types.PostSubscriberEvent{
+			Uid:        e.UID.Bytes(),
+			Subscribed: e.Subscribed,
+			Timestamp:  e.Timestamp.Unix(),
+		}
+	}
+	return nil
+}
+
+func (p *postsServer) ModeratePostComment(_ context.Context, req *types.ModeratePostCommentRequest, _ *types.ModeratePostCommentResponse) error {
+	var pid clientintf.PostID
+	if err := pid.FromBytes(req.PostId); err != nil {
+		return err
+	}
+	var cid clientintf.ID
+	if err := cid.FromBytes(req.CommentId); err != nil {
+		return err
+	}
+	return p.c.ModeratePostComment(pid, cid, req.Action)
+}
+
+func (p *postsServer) MutePostCommenter(_ context.Context, req *types.MutePostCommenterRequest, _ *types.MutePostCommenterResponse) error {
+	var uid clientintf.UserID
+	if err := uid.FromBytes(req.Uid); err != nil {
+		return err
+	}
+	return p.c.MutePostCommenter(uid, req.Mute)
+}
+
+func (p *postsServer) ListMutedPostCommenters(_ context.Context, _ *types.ListMutedPostCommentersRequest, res *types.ListMutedPostCommentersResponse) error {
+	muted, err := p.c.ListMutedPostCommenters()
+	if err != nil {
+		return err
+	}
+	res.Commenters = make([]*types.MutedPostCommenter, 0, len(muted))
+	for uid, ts := range muted {
+		uid := uid
+		res.Commenters = append(res.Commenters, &types.MutedPostCommenter{
+			Uid:   uid[:],
+			Muted: ts.Unix(),
+		})
+	}
+	sort.Slice(res.Commenters, func(i, j int) bool {
+		return bytes.Compare(res.Commenters[i].Uid, res.Commenters[j].Uid) < 0
+	})
+	return nil
+}
+
+func (p *postsServer) PinPost(_ context.Context, req *types.PinPostRequest, _ *types.PinPostResponse) error {
+	var pid clientintf.PostID
+	if err := pid.FromBytes(req.PostId); err != nil {
+		return err
+	}
+	return p.c.PinPost(pid, req.Pin)
+}
+
+func (p *postsServer) ListPinnedPosts(_ context.Context, _ *types.ListPinnedPostsRequest, res *types.ListPinnedPostsResponse) error {
+	posts, err := p.c.ListPinnedPosts()
+	if err != nil {
+		return err
+	}
+	res.Posts = make([]*types.PostSummary, len(posts))
+	for i := range posts {
+		res.Posts[i] = marshalPostSummary(&posts[i])
+	}
+	return nil
+}
+
+// marshalPostBookmark converts the post bookmark to its rpc representation.
+func marshalPostBookmark(bm *client.PostBookmark) *types.PostBookmark {
+	res := &types.PostBookmark{
+		PostFrom:   bm.PostFrom[:],
+		PostId:     bm.Post[:],
+		Title:      bm.Title,
+		Excerpt:    bm.Excerpt,
+		AuthorNick: bm.AuthorNick,
+		Tags:       bm.Tags,
+		Note:       bm.Note,
+		Created:    bm.Created.Unix(),
+	}
+	if bm.Comment != nil {
+		res.CommentId = bm.Comment[:]
+	}
+	return res
+}
+
+// parsePostBookmarkTarget parses the post and optional comment of a bookmark.
+func parsePostBookmarkTarget(postFrom, postID, commentID []byte) (client.UserID,
+	clientintf.PostID, *clientintf.ID, error) {
+
+	var from client.UserID
+	var pid clientintf.PostID
+	if err := from.FromBytes(postFrom); err != nil {
+		return from, pid, nil, err
+	}
+	if err := pid.FromBytes(postID); err != nil {
+		return from, pid, nil, err
+	}
+	if len(commentID) == 0 {
+		return from, pid, nil, nil
+	}
+	var cid clientintf.ID
+	if err := cid.FromBytes(commentID); err != nil {
+		return from, pid, nil, err
+	}
+	return from, pid, &cid, nil
+}
+
+func (p *postsServer) BookmarkPost(_ context.Context, req *types.BookmarkPostRequest, res *types.BookmarkPostResponse) error {
+	from, pid, cid, err := parsePostBookmarkTarget(req.PostFrom, req.PostId, req.CommentId)
+	if err != nil {
+		return err
+	}
+	bm, err := p.c.BookmarkPost(from, pid, cid, req.Tags, req.Note)
+	if err != nil {
+		return err
+	}
+	res.Bookmark = marshalPostBookmark(&bm)
+	return nil
+}
+
+func (p *postsServer) RemovePostBookmark(_ context.Context, req *types.RemovePostBookmarkRequest, _ *types.RemovePostBookmarkResponse) error {
+	from, pid, cid, err := parsePostBookmarkTarget(req.PostFrom, req.PostId, req.CommentId)
+	if err != nil {
+		return err
+	}
+	return p.c.RemovePostBookmark(from, pid, cid)
+}
+
+func (p *postsServer) ListPostBookmarks(_ context.Context, req *types.ListPostBookmarksRequest, res *types.ListPostBookmarksResponse) error {
+	q := client.PostBookmarkQuery{
+		Tags:  req.Tags,
+		Text:  req.Text,
+		Limit: int(req.Limit),
+	}
+	if len(req.PostFrom) > 0 {
+		var from client.UserID
+		if err := from.FromBytes(req.PostFrom); err != nil {
+			return err
+		}
+		q.PostFrom = &from
+	}
+	if len(req.PostId) > 0 {
+		var pid clientintf.PostID
+		if err := pid.FromBytes(req.PostId); err != nil {
+			return err
+		}
+		q.Post = &pid
+	}
+	if req.Since > 0 {
+		q.Since = time.Unix(req.Since, 0)
+	}
+	bookmarks, err := p.c.QueryPostBookmarks(q)
+	if err != nil {
+		return err
+	}
+	res.Bookmarks = make([]*types.PostBookmark, len(bookmarks))
+	for i := range bookmarks {
+		res.Bookmarks[i] = marshalPostBookmark(&bookmarks[i])
+	}
+	return nil
+}
+
 func (p *postsServer) AckReceivedPostStatus(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
 	return p.statusStreams.ack(req.SequenceId)
 }
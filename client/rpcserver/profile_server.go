@@ -0,0 +1,236 @@
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/decred/slog"
+)
+
+type ProfileServerCfg struct {
+	// Client should be set to the [client.Client] instance.
+	Client *client.Client
+
+	// Log should be set to the app's logger.
+	Log slog.Logger
+
+	// RootReplayMsgLogs is the root dir where replaymsglogs are stored for
+	// supported message types.
+	RootReplayMsgLogs string
+}
+
+type profileServer struct {
+	cfg ProfileServerCfg
+	log slog.Logger
+	c   *client.Client
+
+	updatesStreams *serverStreams[*types.ProfileUpdatedEvent]
+}
+
+// marshalABProfile converts an address book entry into a user profile.
+func marshalABProfile(ab *clientdb.AddressBookEntry) *types.UserProfile {
+	return &types.UserProfile{
+		Uid:         ab.ID.Identity[:],
+		Nick:        ab.ID.Nick,
+		NickAlias:   ab.NickAlias,
+		Name:        ab.ID.Name,
+		Avatar:      ab.ID.Avatar,
+		Description: ab.ID.Description,
+		Status:      ab.ID.Status,
+	}
+}
+
+func (p *profileServer) GetLocalProfile(_ context.Context, _ *types.GetLocalProfileRequest, res *types.UserProfile) error {
+	public := p.c.Public()
+	*res = types.UserProfile{
+		Uid:         public.Identity[:],
+		Nick:        public.Nick,
+		Name:        public.Name,
+		Avatar:      public.Avatar,
+		Description: public.Description,
+		Status:      public.Status,
+	}
+	return nil
+}
+
+func (p *profileServer) UpdateLocalProfile(_ context.Context, req *types.UpdateLocalProfileRequest, _ *types.UpdateLocalProfileResponse) error {
+	if req.ClearAvatar && len(req.Avatar) > 0 {
+		return fmt.Errorf("cannot both set and clear the avatar")
+	}
+	if req.ClearDescription && req.Description != "" {
+		return fmt.Errorf("cannot both set and clear the description")
+	}
+	if req.ClearStatus && req.Status != "" {
+		return fmt.Errorf("cannot both set and clear the status")
+	}
+
+	var update client.LocalProfileUpdate
+	if req.Name != "" {
+		update.Name = &req.Name
+	}
+	if len(req.Avatar) > 0 {
+		update.Avatar = req.Avatar
+	} else if req.ClearAvatar {
+		update.Avatar = []byte{}
+	}
+	if req.Description != "" || req.ClearDescription {
+		update.Description = &req.Description
+	}
+	if req.Status != "" || req.ClearStatus {
+		update.Status = &req.Status
+	}
+
+	return p.c.UpdateLocalProfile(update)
+}
+
+func (p *profileServer) GetUserProfile(_ context.Context, req *types.GetUserProfileRequest, res *types.UserProfile) error {
+	ru, err := p.c.UserByNick(req.User)
+	if err != nil {
+		return err
+	}
+
+	ab, err := p.c.AddressBookEntry(ru.ID())
+	if err != nil {
+		return err
+	}
+
+	*res = *marshalABProfile(ab)
+	return nil
+}
+
+func (p *profileServer) ProfileUpdatesStream(ctx context.Context, req *types.ProfileUpdatesStreamRequest, stream types.ProfileService_ProfileUpdatesStreamServer) error {
+	return p.updatesStreams.runStream(ctx, req.UnackedFrom, stream)
+}
+
+func (p *profileServer) AckProfileUpdates(_ context.Context, req *types.AckRequest, _ *types.AckResponse) error {
+	return p.updatesStreams.ack(req.SequenceId)
+}
+
+// marshalContactMetadata converts contact metadata to its rpc representation.
+func marshalContactMetadata(uid client.UserID, meta *clientdb.ContactMetadata) *types.ContactMetadata {
+	return &types.ContactMetadata{
+		Uid:         uid[:],
+		Notes:       meta.Notes,
+		Tags:        meta.Tags,
+		Fields:      meta.Fields,
+		LastUpdated: meta.LastUpdated.Unix(),
+	}
+}
+
+func (p *profileServer) GetContactMetadata(_ context.Context, req *types.GetContactMetadataRequest, res *types.ContactMetadata) error {
+	ru, err := p.c.UserByNick(req.User)
+	if err != nil {
+		return err
+	}
+
+	meta, err := p.c.ContactMetadata(ru.ID())
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactMetadata(ru.ID(), meta)
+	return nil
+}
+
+func (p *profileServer) UpdateContactMetadata(_ context.Context, req *types.UpdateContactMetadataRequest, res *types.ContactMetadata) error {
+	if req.ClearNotes && req.Notes != "" {
+		return fmt.Errorf("cannot both set and clear the notes")
+	}
+
+	ru, err := p.c.UserByNick(req.User)
+	if err != nil {
+		return err
+	}
+	uid := ru.ID()
+
+	if req.Notes != "" || req.ClearNotes {
+		if err := p.c.SetContactNotes(uid, req.Notes); err != nil {
+			return err
+		}
+	}
+	if len(req.AddTags) > 0 {
+		if err := p.c.AddContactTags(uid, req.AddTags...); err != nil {
+			return err
+		}
+	}
+	if len(req.RemoveTags) > 0 {
+		if err := p.c.RemoveContactTags(uid, req.RemoveTags...); err != nil {
+			return err
+		}
+	}
+	for k, v := range req.SetFields {
+		if err := p.c.SetContactField(uid, k, v); err != nil {
+			return err
+		}
+	}
+	for _, k := range req.RemoveFields {
+		if err := p.c.SetContactField(uid, k, ""); err != nil {
+			return err
+		}
+	}
+
+	meta, err := p.c.ContactMetadata(uid)
+	if err != nil {
+		return err
+	}
+	*res = *marshalContactMetadata(uid, meta)
+	return nil
+}
+
+func (p *profileServer) ListContactsByTag(_ context.Context, req *types.ListContactsByTagRequest, res *types.ListContactsByTagResponse) error {
+	uids, err := p.c.ContactsByTag(req.Tag)
+	if err != nil {
+		return err
+	}
+	res.Uids = make([][]byte, len(uids))
+	for i := range uids {
+		res.Uids[i] = uids[i].Bytes()
+	}
+	return nil
+}
+
+// profileUpdatedHandler is called by the client when a remote user updates
+// its profile.
+func (p *profileServer) profileUpdatedHandler(_ *client.RemoteUser,
+	ab *clientdb.AddressBookEntry, fields []client.ProfileUpdateField) {
+
+	ntfn := &types.ProfileUpdatedEvent{
+		Profile:       marshalABProfile(ab),
+		UpdatedFields: make([]string, len(fields)),
+	}
+	for i := range fields {
+		ntfn.UpdatedFields[i] = string(fields[i])
+	}
+	p.updatesStreams.send(ntfn)
+}
+
+// registerOfflineMessageStorageHandlers registers the handlers for streams on
+// the client's notification manager.
+func (p *profileServer) registerOfflineMessageStorageHandlers() {
+	nmgr := p.c.NotificationManager()
+	nmgr.RegisterSync(client.OnProfileUpdated(p.profileUpdatedHandler))
+}
+
+var _ types.ProfileServiceServer = (*profileServer)(nil)
+
+// InitProfileService initializes and binds a ProfileService server to the RPC
+// server.
+func (s *Server) InitProfileService(cfg ProfileServerCfg) error {
+	updatesStreams, err := newServerStreams[*types.ProfileUpdatedEvent](cfg.RootReplayMsgLogs, "profileupdates", cfg.Log)
+	if err != nil {
+		return err
+	}
+
+	ps := &profileServer{
+		cfg: cfg,
+		log: cfg.Log,
+		c:   cfg.Client,
+
+		updatesStreams: updatesStreams,
+	}
+	ps.registerOfflineMessageStorageHandlers()
+	s.services.Bind("ProfileService", types.ProfileServiceDefn(), ps)
+	return nil
+}
@@ -29,19 +29,16 @@ type runningTipAttempt struct {
 
 // tipAttemptsList maintains a list of per-user running attempts at tipping.
 type tipAttemptsList struct {
-	requestInvoiceDeadline time.Duration
-	maxLifetimeDuration    time.Duration
-	payRetryDelayFactor    time.Duration
+	// settingsFor returns the tip retry settings for a user.
+	settingsFor func(uid clientintf.UserID) tipRetrySettings
 
 	m map[clientintf.UserID]runningTipAttempt
 }
 
-func newTipAttemptsList(requestInvoiceDeadline, maxLifetimeDuration, payRetryDelayFactor time.Duration) *tipAttemptsList {
+func newTipAttemptsList(settingsFor func(uid clientintf.UserID) tipRetrySettings) *tipAttemptsList {
 	return &tipAttemptsList{
-		requestInvoiceDeadline: requestInvoiceDeadline,
-		maxLifetimeDuration:    maxLifetimeDuration,
-		payRetryDelayFactor:    payRetryDelayFactor,
-		m:                      map[clientintf.UserID]runningTipAttempt{},
+		settingsFor: settingsFor,
+		m:           map[clientintf.UserID]runningTipAttempt{},
 	}
 }
 
@@ -133,8 +130,6 @@ func (tal *tipAttemptsList) timeToNextAction() (time.Duration, bool) {
 
 // determineTipAttemptAction determines what is the next action and the time
 // to take it for a given TipUserAttempt.
-//
-//nolint:durationcheck
 func (tal *tipAttemptsList) determineTipAttemptAction(ta *clientdb.TipUserAttempt,
 	paying bool) (tipUserAttemptAction, time.Time) {
 
@@ -156,8 +151,10 @@ func (tal *tipAttemptsList) determineTipAttemptAction(ta *clientdb.TipUserAttemp
 		return actionComplete, actNow()
 	}
 
+	settings := tal.settingsFor(ta.UID)
+
 	// expireDeadline is when the entire tip attempt expires.
-	expireDeadline := ta.Created.Add(tal.maxLifetimeDuration)
+	expireDeadline := ta.Created.Add(settings.maxLifetime)
 	if expireDeadline.Before(time.Now()) {
 		// Expired.
 		return actionExpire, expireDeadline
@@ -177,8 +174,7 @@ func (tal *tipAttemptsList) determineTipAttemptAction(ta *clientdb.TipUserAttemp
 			}
 
 			// Exponential delay for repeated retriable payment attempts.
-			expDelay := time.Duration(1 << ta.PaymentAttemptCount)
-			delay := expDelay * tal.payRetryDelayFactor
+			delay := settings.payRetryDelay(ta.PaymentAttemptCount)
 			return actionAttemptPayment, (*ta.PaymentAttemptFailed).Add(delay)
 		}
 
@@ -195,7 +191,7 @@ func (tal *tipAttemptsList) determineTipAttemptAction(ta *clientdb.TipUserAttemp
 	if ta.LastInvoiceError != nil {
 		// Had an error paying or requesting an invoice. Wait until
 		// it's time to try and request a new invoice.
-		return actionRequestInvoice, ta.InvoiceRequested.Add(tal.requestInvoiceDeadline)
+		return actionRequestInvoice, ta.InvoiceRequested.Add(settings.reRequestInvoiceDelay)
 	}
 
 	if ta.InvoiceRequested == nil {
@@ -132,6 +132,9 @@ type ChatServiceClient interface {
 	// WriteNewInvite writes an invite to be sent (out-of-band) to an user to
 	// perform KX with.
 	WriteNewInvite(ctx context.Context, in *WriteNewInviteRequest, out *WriteNewInviteResponse) error
+	// WriteNewInviteBatch writes a batch of prepaid invites, optionally
+	// splitting a funding budget among them.
+	WriteNewInviteBatch(ctx context.Context, in *WriteNewInviteBatchRequest, out *WriteNewInviteBatchResponse) error
 	// AcceptInvite accepts an invite to kx with the user-provided invite.
 	AcceptInvite(ctx context.Context, in *AcceptInviteRequest, out *AcceptInviteResponse) error
 	// SendFile sends a file to a user.
@@ -139,6 +142,138 @@ type ChatServiceClient interface {
 	SendFile(ctx context.Context, in *SendFileRequest, out *SendFileResponse) error
 	// UserNick returns the nick of an user.
 	UserNick(ctx context.Context, in *UserNickRequest, out *UserNickResponse) error
+	// MarkRead marks the messages of a PM or GC chat as read, up to and
+	// including a given message, or the entire chat when no message is
+	// specified. Every clientrpc client attached to the local client receives a
+	// corresponding event in the ReadReceiptsStream, so that unread counts may
+	// be shared across multiple frontends.
+	MarkRead(ctx context.Context, in *MarkReadRequest, out *MarkReadResponse) error
+	// ReadReceiptsStream returns a stream that gets sent events about chats
+	// marked as read through MarkRead calls.
+	ReadReceiptsStream(ctx context.Context, in *ReadReceiptsStreamRequest) (ChatService_ReadReceiptsStreamClient, error)
+	// AckReadReceipts acks received read receipt events.
+	AckReadReceipts(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// EditMessage replaces the contents of a PM or GC message previously sent
+	// by the local client. The edit is sent to the remote user or to the GC
+	// members.
+	EditMessage(ctx context.Context, in *EditMessageRequest, out *EditMessageResponse) error
+	// MessageEditsStream returns a stream that gets sent edits of PM and GC
+	// messages done by remote users.
+	MessageEditsStream(ctx context.Context, in *MessageEditsStreamRequest) (ChatService_MessageEditsStreamClient, error)
+	// AckMessageEdits acks received message edit events.
+	AckMessageEdits(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// RatchetHealthStream returns a stream that gets sent events about the
+	// state of the ratchets with remote users, such as when ratchet resets are
+	// requested and completed, and when handshakes progress. This may be used
+	// to detect and repair stuck conversations.
+	RatchetHealthStream(ctx context.Context, in *RatchetHealthStreamRequest) (ChatService_RatchetHealthStreamClient, error)
+	// AckRatchetHealth acks received ratchet health events.
+	AckRatchetHealth(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// RatchetHealth returns diagnostics about the state of the ratchets with
+	// remote users, including whether the ratchet is probably broken and
+	// should be reset.
+	RatchetHealth(ctx context.Context, in *RatchetHealthRequest, out *RatchetHealthResponse) error
+	// ReactToMessage adds or removes a reaction (usually an emoji) to a PM or
+	// GC message. The reaction is sent to the remote user or to the GC
+	// members.
+	ReactToMessage(ctx context.Context, in *ReactToMessageRequest, out *ReactToMessageResponse) error
+	// GetMessageReactions returns the aggregated reactions to a message.
+	GetMessageReactions(ctx context.Context, in *GetMessageReactionsRequest, out *MessageReactions) error
+	// MessageReactionsStream returns a stream that gets sent reactions of
+	// remote users to PM and GC messages.
+	MessageReactionsStream(ctx context.Context, in *MessageReactionsStreamRequest) (ChatService_MessageReactionsStreamClient, error)
+	// AckMessageReactions acks received message reaction events.
+	AckMessageReactions(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// GetThread returns the thread of replies that a PM or GC message belongs
+	// to. The message may be either the root of the thread or one of its
+	// replies. Replies are sent by setting the reply_to field of PM and GCM
+	// requests.
+	GetThread(ctx context.Context, in *GetThreadRequest, out *MessageThread) error
+	// SearchMessages searches the logged PMs and GC messages for messages that
+	// contain all the terms of the query. The search is case insensitive and
+	// includes the text of embedded content. Results are returned from newest
+	// to oldest.
+	SearchMessages(ctx context.Context, in *SearchMessagesRequest, out *SearchMessagesResponse) error
+	// ExportChat exports the logged history of a PM conversation or GC as JSON
+	// or as a self-contained HTML page. PM exports also include the tips
+	// exchanged with the user.
+	ExportChat(ctx context.Context, in *ExportChatRequest, out *ExportChatResponse) error
+	// ArchiveChat archives a PM conversation or GC. Archived chats are hidden
+	// from the list of active chats and are automatically unarchived when a
+	// new message is received on them.
+	ArchiveChat(ctx context.Context, in *ArchiveChatRequest, out *ArchiveChatResponse) error
+	// UnarchiveChat removes the archived status of a PM conversation or GC.
+	UnarchiveChat(ctx context.Context, in *ArchiveChatRequest, out *ArchiveChatResponse) error
+	// ListArchivedChats lists the archived PM conversations and GCs.
+	ListArchivedChats(ctx context.Context, in *ListArchivedChatsRequest, out *ListArchivedChatsResponse) error
+	// GetAutoReply returns the current config for automatic replies to PMs.
+	GetAutoReply(ctx context.Context, in *GetAutoReplyRequest, out *AutoReplyConfig) error
+	// SetAutoReply sets the config for automatic replies to PMs. When enabled,
+	// the first PM received from each user within the configured interval is
+	// automatically replied to.
+	SetAutoReply(ctx context.Context, in *AutoReplyConfig, out *SetAutoReplyResponse) error
+	// SendVoiceMessage sends a voice message to a user or GC. The audio must be
+	// Opus-encoded in an Ogg container. Voice messages are embedded in PMs and
+	// GC messages with the audio/ogg mime type.
+	SendVoiceMessage(ctx context.Context, in *SendVoiceMessageRequest, out *SendVoiceMessageResponse) error
+	// GetDraft returns the unsent draft of a PM conversation or GC.
+	GetDraft(ctx context.Context, in *GetDraftRequest, out *GetDraftResponse) error
+	// SetDraft stores the unsent draft of a PM conversation or GC, so that it
+	// may be restored by any frontend after a restart. An empty text removes
+	// the draft.
+	SetDraft(ctx context.Context, in *SetDraftRequest, out *SetDraftResponse) error
+	// ListDrafts lists the unsent drafts of all chats.
+	ListDrafts(ctx context.Context, in *ListDraftsRequest, out *ListDraftsResponse) error
+	// StoreBroadcastList creates or replaces a broadcast list. Broadcast lists
+	// are locally defined sets of users to which messages are sent as
+	// individual PMs, without creating a GC.
+	StoreBroadcastList(ctx context.Context, in *BroadcastList, out *StoreBroadcastListResponse) error
+	// RemoveBroadcastList removes a broadcast list.
+	RemoveBroadcastList(ctx context.Context, in *RemoveBroadcastListRequest, out *RemoveBroadcastListResponse) error
+	// ListBroadcastLists lists the existing broadcast lists.
+	ListBroadcastLists(ctx context.Context, in *ListBroadcastListsRequest, out *ListBroadcastListsResponse) error
+	// SendBroadcast sends a message as an individual PM to each member of a
+	// broadcast list.
+	SendBroadcast(ctx context.Context, in *SendBroadcastRequest, out *BroadcastStatus) error
+	// GetBroadcastStatus returns the aggregated delivery status of a broadcast
+	// sent since the client started.
+	GetBroadcastStatus(ctx context.Context, in *GetBroadcastStatusRequest, out *BroadcastStatus) error
+	// StoreContactGroup creates or replaces a contact group. Contact groups are
+	// locally defined, named sets of users on which batch operations may be
+	// performed.
+	StoreContactGroup(ctx context.Context, in *ContactGroup, out *StoreContactGroupResponse) error
+	// RemoveContactGroup removes a contact group.
+	RemoveContactGroup(ctx context.Context, in *RemoveContactGroupRequest, out *RemoveContactGroupResponse) error
+	// ListContactGroups lists the existing contact groups.
+	ListContactGroups(ctx context.Context, in *ListContactGroupsRequest, out *ListContactGroupsResponse) error
+	// PMContactGroup sends a message as an individual PM to each member of a
+	// contact group.
+	PMContactGroup(ctx context.Context, in *PMContactGroupRequest, out *ContactGroupResult) error
+	// ApplyContactGroupFilter stores a copy of a content filter rule for each
+	// member of a contact group.
+	ApplyContactGroupFilter(ctx context.Context, in *ApplyContactGroupFilterRequest, out *ContactGroupResult) error
+	// ListContentFilters lists the content filter rules applied to received
+	// messages.
+	ListContentFilters(ctx context.Context, in *ListContentFiltersRequest, out *ListContentFiltersResponse) error
+	// StoreContentFilter adds a new content filter rule (when id is zero) or
+	// updates an existing one. The rule starts applying immediately.
+	StoreContentFilter(ctx context.Context, in *ContentFilter, out *ContentFilter) error
+	// RemoveContentFilter removes a content filter rule.
+	RemoveContentFilter(ctx context.Context, in *RemoveContentFilterRequest, out *RemoveContentFilterResponse) error
+	// GCMentionsStream returns a stream that gets sent GC messages that mention
+	// the local client. Messages sent to this stream are also sent to the
+	// GCMStream, so this may be used to be notified of mentions in GCs that are
+	// otherwise ignored.
+	GCMentionsStream(ctx context.Context, in *GCMentionsStreamRequest) (ChatService_GCMentionsStreamClient, error)
+	// AckGCMentions acks received GC mention events.
+	AckGCMentions(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// MsgDeliveryStatusStream returns a stream that gets sent events about the
+	// delivery status of PMs and GC messages sent by the local client. Events
+	// refer to the msg_id returned by the PM and GCM calls. For GC messages,
+	// one event is sent per GC member.
+	MsgDeliveryStatusStream(ctx context.Context, in *MsgDeliveryStatusStreamRequest) (ChatService_MsgDeliveryStatusStreamClient, error)
+	// AckMsgDeliveryStatus acks received message delivery status events.
+	AckMsgDeliveryStatus(ctx context.Context, in *AckRequest, out *AckResponse) error
 }
 
 type client_ChatService struct {
@@ -220,6 +355,11 @@ func (c *client_ChatService) WriteNewInvite(ctx context.Context, in *WriteNewInv
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_ChatService) WriteNewInviteBatch(ctx context.Context, in *WriteNewInviteBatchRequest, out *WriteNewInviteBatchResponse) error {
+	const method = "WriteNewInviteBatch"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 func (c *client_ChatService) AcceptInvite(ctx context.Context, in *AcceptInviteRequest, out *AcceptInviteResponse) error {
 	const method = "AcceptInvite"
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
@@ -235,6 +375,264 @@ func (c *client_ChatService) UserNick(ctx context.Context, in *UserNickRequest,
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_ChatService) MarkRead(ctx context.Context, in *MarkReadRequest, out *MarkReadResponse) error {
+	const method = "MarkRead"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_ReadReceiptsStreamClient interface {
+	Recv(*ReceivedReadReceipt) error
+}
+
+func (c *client_ChatService) ReadReceiptsStream(ctx context.Context, in *ReadReceiptsStreamRequest) (ChatService_ReadReceiptsStreamClient, error) {
+	const method = "ReadReceiptsStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*ReceivedReadReceipt]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckReadReceipts(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckReadReceipts"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) EditMessage(ctx context.Context, in *EditMessageRequest, out *EditMessageResponse) error {
+	const method = "EditMessage"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_MessageEditsStreamClient interface {
+	Recv(*ReceivedMessageEdit) error
+}
+
+func (c *client_ChatService) MessageEditsStream(ctx context.Context, in *MessageEditsStreamRequest) (ChatService_MessageEditsStreamClient, error) {
+	const method = "MessageEditsStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*ReceivedMessageEdit]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckMessageEdits(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckMessageEdits"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_RatchetHealthStreamClient interface {
+	Recv(*RatchetHealthEvent) error
+}
+
+func (c *client_ChatService) RatchetHealthStream(ctx context.Context, in *RatchetHealthStreamRequest) (ChatService_RatchetHealthStreamClient, error) {
+	const method = "RatchetHealthStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*RatchetHealthEvent]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckRatchetHealth(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckRatchetHealth"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) RatchetHealth(ctx context.Context, in *RatchetHealthRequest, out *RatchetHealthResponse) error {
+	const method = "RatchetHealth"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ReactToMessage(ctx context.Context, in *ReactToMessageRequest, out *ReactToMessageResponse) error {
+	const method = "ReactToMessage"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) GetMessageReactions(ctx context.Context, in *GetMessageReactionsRequest, out *MessageReactions) error {
+	const method = "GetMessageReactions"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_MessageReactionsStreamClient interface {
+	Recv(*ReceivedMessageReaction) error
+}
+
+func (c *client_ChatService) MessageReactionsStream(ctx context.Context, in *MessageReactionsStreamRequest) (ChatService_MessageReactionsStreamClient, error) {
+	const method = "MessageReactionsStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*ReceivedMessageReaction]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckMessageReactions(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckMessageReactions"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) GetThread(ctx context.Context, in *GetThreadRequest, out *MessageThread) error {
+	const method = "GetThread"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) SearchMessages(ctx context.Context, in *SearchMessagesRequest, out *SearchMessagesResponse) error {
+	const method = "SearchMessages"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ExportChat(ctx context.Context, in *ExportChatRequest, out *ExportChatResponse) error {
+	const method = "ExportChat"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ArchiveChat(ctx context.Context, in *ArchiveChatRequest, out *ArchiveChatResponse) error {
+	const method = "ArchiveChat"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) UnarchiveChat(ctx context.Context, in *ArchiveChatRequest, out *ArchiveChatResponse) error {
+	const method = "UnarchiveChat"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ListArchivedChats(ctx context.Context, in *ListArchivedChatsRequest, out *ListArchivedChatsResponse) error {
+	const method = "ListArchivedChats"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) GetAutoReply(ctx context.Context, in *GetAutoReplyRequest, out *AutoReplyConfig) error {
+	const method = "GetAutoReply"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) SetAutoReply(ctx context.Context, in *AutoReplyConfig, out *SetAutoReplyResponse) error {
+	const method = "SetAutoReply"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) SendVoiceMessage(ctx context.Context, in *SendVoiceMessageRequest, out *SendVoiceMessageResponse) error {
+	const method = "SendVoiceMessage"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) GetDraft(ctx context.Context, in *GetDraftRequest, out *GetDraftResponse) error {
+	const method = "GetDraft"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) SetDraft(ctx context.Context, in *SetDraftRequest, out *SetDraftResponse) error {
+	const method = "SetDraft"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ListDrafts(ctx context.Context, in *ListDraftsRequest, out *ListDraftsResponse) error {
+	const method = "ListDrafts"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) StoreBroadcastList(ctx context.Context, in *BroadcastList, out *StoreBroadcastListResponse) error {
+	const method = "StoreBroadcastList"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) RemoveBroadcastList(ctx context.Context, in *RemoveBroadcastListRequest, out *RemoveBroadcastListResponse) error {
+	const method = "RemoveBroadcastList"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ListBroadcastLists(ctx context.Context, in *ListBroadcastListsRequest, out *ListBroadcastListsResponse) error {
+	const method = "ListBroadcastLists"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) SendBroadcast(ctx context.Context, in *SendBroadcastRequest, out *BroadcastStatus) error {
+	const method = "SendBroadcast"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) GetBroadcastStatus(ctx context.Context, in *GetBroadcastStatusRequest, out *BroadcastStatus) error {
+	const method = "GetBroadcastStatus"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) StoreContactGroup(ctx context.Context, in *ContactGroup, out *StoreContactGroupResponse) error {
+	const method = "StoreContactGroup"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) RemoveContactGroup(ctx context.Context, in *RemoveContactGroupRequest, out *RemoveContactGroupResponse) error {
+	const method = "RemoveContactGroup"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ListContactGroups(ctx context.Context, in *ListContactGroupsRequest, out *ListContactGroupsResponse) error {
+	const method = "ListContactGroups"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) PMContactGroup(ctx context.Context, in *PMContactGroupRequest, out *ContactGroupResult) error {
+	const method = "PMContactGroup"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ApplyContactGroupFilter(ctx context.Context, in *ApplyContactGroupFilterRequest, out *ContactGroupResult) error {
+	const method = "ApplyContactGroupFilter"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) ListContentFilters(ctx context.Context, in *ListContentFiltersRequest, out *ListContentFiltersResponse) error {
+	const method = "ListContentFilters"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) StoreContentFilter(ctx context.Context, in *ContentFilter, out *ContentFilter) error {
+	const method = "StoreContentFilter"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ChatService) RemoveContentFilter(ctx context.Context, in *RemoveContentFilterRequest, out *RemoveContentFilterResponse) error {
+	const method = "RemoveContentFilter"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_GCMentionsStreamClient interface {
+	Recv(*GCReceivedMsg) error
+}
+
+func (c *client_ChatService) GCMentionsStream(ctx context.Context, in *GCMentionsStreamRequest) (ChatService_GCMentionsStreamClient, error) {
+	const method = "GCMentionsStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*GCReceivedMsg]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckGCMentions(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckGCMentions"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ChatService_MsgDeliveryStatusStreamClient interface {
+	Recv(*MsgDeliveryStatusEvent) error
+}
+
+func (c *client_ChatService) MsgDeliveryStatusStream(ctx context.Context, in *MsgDeliveryStatusStreamRequest) (ChatService_MsgDeliveryStatusStreamClient, error) {
+	const method = "MsgDeliveryStatusStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*MsgDeliveryStatusEvent]{c: inner}, nil
+}
+
+func (c *client_ChatService) AckMsgDeliveryStatus(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckMsgDeliveryStatus"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 func NewChatServiceClient(c ClientConn) ChatServiceClient {
 	return &client_ChatService{c: c, defn: ChatServiceDefn()}
 }
@@ -268,6 +666,9 @@ type ChatServiceServer interface {
 	// WriteNewInvite writes an invite to be sent (out-of-band) to an user to
 	// perform KX with.
 	WriteNewInvite(context.Context, *WriteNewInviteRequest, *WriteNewInviteResponse) error
+	// WriteNewInviteBatch writes a batch of prepaid invites, optionally
+	// splitting a funding budget among them.
+	WriteNewInviteBatch(context.Context, *WriteNewInviteBatchRequest, *WriteNewInviteBatchResponse) error
 	// AcceptInvite accepts an invite to kx with the user-provided invite.
 	AcceptInvite(context.Context, *AcceptInviteRequest, *AcceptInviteResponse) error
 	// SendFile sends a file to a user.
@@ -275,6 +676,138 @@ type ChatServiceServer interface {
 	SendFile(context.Context, *SendFileRequest, *SendFileResponse) error
 	// UserNick returns the nick of an user.
 	UserNick(context.Context, *UserNickRequest, *UserNickResponse) error
+	// MarkRead marks the messages of a PM or GC chat as read, up to and
+	// including a given message, or the entire chat when no message is
+	// specified. Every clientrpc client attached to the local client receives a
+	// corresponding event in the ReadReceiptsStream, so that unread counts may
+	// be shared across multiple frontends.
+	MarkRead(context.Context, *MarkReadRequest, *MarkReadResponse) error
+	// ReadReceiptsStream returns a stream that gets sent events about chats
+	// marked as read through MarkRead calls.
+	ReadReceiptsStream(context.Context, *ReadReceiptsStreamRequest, ChatService_ReadReceiptsStreamServer) error
+	// AckReadReceipts acks received read receipt events.
+	AckReadReceipts(context.Context, *AckRequest, *AckResponse) error
+	// EditMessage replaces the contents of a PM or GC message previously sent
+	// by the local client. The edit is sent to the remote user or to the GC
+	// members.
+	EditMessage(context.Context, *EditMessageRequest, *EditMessageResponse) error
+	// MessageEditsStream returns a stream that gets sent edits of PM and GC
+	// messages done by remote users.
+	MessageEditsStream(context.Context, *MessageEditsStreamRequest, ChatService_MessageEditsStreamServer) error
+	// AckMessageEdits acks received message edit events.
+	AckMessageEdits(context.Context, *AckRequest, *AckResponse) error
+	// RatchetHealthStream returns a stream that gets sent events about the
+	// state of the ratchets with remote users, such as when ratchet resets are
+	// requested and completed, and when handshakes progress. This may be used
+	// to detect and repair stuck conversations.
+	RatchetHealthStream(context.Context, *RatchetHealthStreamRequest, ChatService_RatchetHealthStreamServer) error
+	// AckRatchetHealth acks received ratchet health events.
+	AckRatchetHealth(context.Context, *AckRequest, *AckResponse) error
+	// RatchetHealth returns diagnostics about the state of the ratchets with
+	// remote users, including whether the ratchet is probably broken and
+	// should be reset.
+	RatchetHealth(context.Context, *RatchetHealthRequest, *RatchetHealthResponse) error
+	// ReactToMessage adds or removes a reaction (usually an emoji) to a PM or
+	// GC message. The reaction is sent to the remote user or to the GC
+	// members.
+	ReactToMessage(context.Context, *ReactToMessageRequest, *ReactToMessageResponse) error
+	// GetMessageReactions returns the aggregated reactions to a message.
+	GetMessageReactions(context.Context, *GetMessageReactionsRequest, *MessageReactions) error
+	// MessageReactionsStream returns a stream that gets sent reactions of
+	// remote users to PM and GC messages.
+	MessageReactionsStream(context.Context, *MessageReactionsStreamRequest, ChatService_MessageReactionsStreamServer) error
+	// AckMessageReactions acks received message reaction events.
+	AckMessageReactions(context.Context, *AckRequest, *AckResponse) error
+	// GetThread returns the thread of replies that a PM or GC message belongs
+	// to. The message may be either the root of the thread or one of its
+	// replies. Replies are sent by setting the reply_to field of PM and GCM
+	// requests.
+	GetThread(context.Context, *GetThreadRequest, *MessageThread) error
+	// SearchMessages searches the logged PMs and GC messages for messages that
+	// contain all the terms of the query. The search is case insensitive and
+	// includes the text of embedded content. Results are returned from newest
+	// to oldest.
+	SearchMessages(context.Context, *SearchMessagesRequest, *SearchMessagesResponse) error
+	// ExportChat exports the logged history of a PM conversation or GC as JSON
+	// or as a self-contained HTML page. PM exports also include the tips
+	// exchanged with the user.
+	ExportChat(context.Context, *ExportChatRequest, *ExportChatResponse) error
+	// ArchiveChat archives a PM conversation or GC. Archived chats are hidden
+	// from the list of active chats and are automatically unarchived when a
+	// new message is received on them.
+	ArchiveChat(context.Context, *ArchiveChatRequest, *ArchiveChatResponse) error
+	// UnarchiveChat removes the archived status of a PM conversation or GC.
+	UnarchiveChat(context.Context, *ArchiveChatRequest, *ArchiveChatResponse) error
+	// ListArchivedChats lists the archived PM conversations and GCs.
+	ListArchivedChats(context.Context, *ListArchivedChatsRequest, *ListArchivedChatsResponse) error
+	// GetAutoReply returns the current config for automatic replies to PMs.
+	GetAutoReply(context.Context, *GetAutoReplyRequest, *AutoReplyConfig) error
+	// SetAutoReply sets the config for automatic replies to PMs. When enabled,
+	// the first PM received from each user within the configured interval is
+	// automatically replied to.
+	SetAutoReply(context.Context, *AutoReplyConfig, *SetAutoReplyResponse) error
+	// SendVoiceMessage sends a voice message to a user or GC. The audio must be
+	// Opus-encoded in an Ogg container. Voice messages are embedded in PMs and
+	// GC messages with the audio/ogg mime type.
+	SendVoiceMessage(context.Context, *SendVoiceMessageRequest, *SendVoiceMessageResponse) error
+	// GetDraft returns the unsent draft of a PM conversation or GC.
+	GetDraft(context.Context, *GetDraftRequest, *GetDraftResponse) error
+	// SetDraft stores the unsent draft of a PM conversation or GC, so that it
+	// may be restored by any frontend after a restart. An empty text removes
+	// the draft.
+	SetDraft(context.Context, *SetDraftRequest, *SetDraftResponse) error
+	// ListDrafts lists the unsent drafts of all chats.
+	ListDrafts(context.Context, *ListDraftsRequest, *ListDraftsResponse) error
+	// StoreBroadcastList creates or replaces a broadcast list. Broadcast lists
+	// are locally defined sets of users to which messages are sent as
+	// individual PMs, without creating a GC.
+	StoreBroadcastList(context.Context, *BroadcastList, *StoreBroadcastListResponse) error
+	// RemoveBroadcastList removes a broadcast list.
+	RemoveBroadcastList(context.Context, *RemoveBroadcastListRequest, *RemoveBroadcastListResponse) error
+	// ListBroadcastLists lists the existing broadcast lists.
+	ListBroadcastLists(context.Context, *ListBroadcastListsRequest, *ListBroadcastListsResponse) error
+	// SendBroadcast sends a message as an individual PM to each member of a
+	// broadcast list.
+	SendBroadcast(context.Context, *SendBroadcastRequest, *BroadcastStatus) error
+	// GetBroadcastStatus returns the aggregated delivery status of a broadcast
+	// sent since the client started.
+	GetBroadcastStatus(context.Context, *GetBroadcastStatusRequest, *BroadcastStatus) error
+	// StoreContactGroup creates or replaces a contact group. Contact groups are
+	// locally defined, named sets of users on which batch operations may be
+	// performed.
+	StoreContactGroup(context.Context, *ContactGroup, *StoreContactGroupResponse) error
+	// RemoveContactGroup removes a contact group.
+	RemoveContactGroup(context.Context, *RemoveContactGroupRequest, *RemoveContactGroupResponse) error
+	// ListContactGroups lists the existing contact groups.
+	ListContactGroups(context.Context, *ListContactGroupsRequest, *ListContactGroupsResponse) error
+	// PMContactGroup sends a message as an individual PM to each member of a
+	// contact group.
+	PMContactGroup(context.Context, *PMContactGroupRequest, *ContactGroupResult) error
+	// ApplyContactGroupFilter stores a copy of a content filter rule for each
+	// member of a contact group.
+	ApplyContactGroupFilter(context.Context, *ApplyContactGroupFilterRequest, *ContactGroupResult) error
+	// ListContentFilters lists the content filter rules applied to received
+	// messages.
+	ListContentFilters(context.Context, *ListContentFiltersRequest, *ListContentFiltersResponse) error
+	// StoreContentFilter adds a new content filter rule (when id is zero) or
+	// updates an existing one. The rule starts applying immediately.
+	StoreContentFilter(context.Context, *ContentFilter, *ContentFilter) error
+	// RemoveContentFilter removes a content filter rule.
+	RemoveContentFilter(context.Context, *RemoveContentFilterRequest, *RemoveContentFilterResponse) error
+	// GCMentionsStream returns a stream that gets sent GC messages that mention
+	// the local client. Messages sent to this stream are also sent to the
+	// GCMStream, so this may be used to be notified of mentions in GCs that are
+	// otherwise ignored.
+	GCMentionsStream(context.Context, *GCMentionsStreamRequest, ChatService_GCMentionsStreamServer) error
+	// AckGCMentions acks received GC mention events.
+	AckGCMentions(context.Context, *AckRequest, *AckResponse) error
+	// MsgDeliveryStatusStream returns a stream that gets sent events about the
+	// delivery status of PMs and GC messages sent by the local client. Events
+	// refer to the msg_id returned by the PM and GCM calls. For GC messages,
+	// one event is sent per GC member.
+	MsgDeliveryStatusStream(context.Context, *MsgDeliveryStatusStreamRequest, ChatService_MsgDeliveryStatusStreamServer) error
+	// AckMsgDeliveryStatus acks received message delivery status events.
+	AckMsgDeliveryStatus(context.Context, *AckRequest, *AckResponse) error
 }
 
 type ChatService_PMStreamServer interface {
@@ -289,6 +822,30 @@ type ChatService_KXStreamServer interface {
 	Send(m *KXCompleted) error
 }
 
+type ChatService_ReadReceiptsStreamServer interface {
+	Send(m *ReceivedReadReceipt) error
+}
+
+type ChatService_MessageEditsStreamServer interface {
+	Send(m *ReceivedMessageEdit) error
+}
+
+type ChatService_RatchetHealthStreamServer interface {
+	Send(m *RatchetHealthEvent) error
+}
+
+type ChatService_MessageReactionsStreamServer interface {
+	Send(m *ReceivedMessageReaction) error
+}
+
+type ChatService_GCMentionsStreamServer interface {
+	Send(m *GCReceivedMsg) error
+}
+
+type ChatService_MsgDeliveryStatusStreamServer interface {
+	Send(m *MsgDeliveryStatusEvent) error
+}
+
 func ChatServiceDefn() ServiceDefn {
 	return ServiceDefn{
 		Name: "ChatService",
@@ -443,6 +1000,25 @@ func ChatServiceDefn() ServiceDefn {
 					return conn.Request(ctx, method, request, response)
 				},
 			},
+			"WriteNewInviteBatch": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(WriteNewInviteBatchRequest) },
+				NewResponse: func() proto.Message { return new(WriteNewInviteBatchResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(WriteNewInviteBatchRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(WriteNewInviteBatchResponse).ProtoReflect().Descriptor()
+				},
+				Help: "WriteNewInviteBatch writes a batch of prepaid invites, optionally splitting a funding budget among them.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).WriteNewInviteBatch(ctx, request.(*WriteNewInviteBatchRequest), response.(*WriteNewInviteBatchResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.WriteNewInviteBatch"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
 			"AcceptInvite": {
 				IsStreaming:  false,
 				NewRequest:   func() proto.Message { return new(AcceptInviteRequest) },
@@ -488,77 +1064,832 @@ func ChatServiceDefn() ServiceDefn {
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-		},
-	}
-}
-
-// GCServiceClient is the client API for GCService service.
-type GCServiceClient interface {
-	// InviteToGC invites an user to join a GC. The local user must have admin
-	// privileges in the gc.
-	InviteToGC(ctx context.Context, in *InviteToGCRequest, out *InviteToGCResponse) error
-	// AcceptGCInvite accepts an invitation to join a GC.
-	AcceptGCInvite(ctx context.Context, in *AcceptGCInviteRequest, out *AcceptGCInviteResponse) error
-	// KickFromGC kicks an user from a GC. The local user must have admin
-	// privileges in the GC.
-	KickFromGC(ctx context.Context, in *KickFromGCRequest, out *KickFromGCResponse) error
-	// GetGC returns the full definition for a GC.
-	GetGC(ctx context.Context, in *GetGCRequest, out *GetGCResponse) error
-	// List returns a list with all GCs.
-	List(ctx context.Context, in *ListGCsRequest, out *ListGCsResponse) error
-	// ReceivedGCInvites returns a stream that gets sent invitations that remote
-	// users make for the local client to join GCs.
-	ReceivedGCInvites(ctx context.Context, in *ReceivedGCInvitesRequest) (GCService_ReceivedGCInvitesClient, error)
-	// AckReceivedGCInvites acks received gc invite events.
-	AckReceivedGCInvites(ctx context.Context, in *AckRequest, out *AckResponse) error
-	// MembersAdded returns a stream that gets sent events about members added
-	// to GCs the local client participates in.
-	MembersAdded(ctx context.Context, in *GCMembersAddedRequest) (GCService_MembersAddedClient, error)
-	// AckMembersAdded acks received members added events.
-	AckMembersAdded(ctx context.Context, in *AckRequest, out *AckResponse) error
-	// MembersRemoved returns a stream that gets sent events about members removed
-	// from GCs the local client participates in.
-	MembersRemoved(ctx context.Context, in *GCMembersRemovedRequest) (GCService_MembersRemovedClient, error)
-	// AckMembersRemoved acks received members removed events.
-	AckMembersRemoved(ctx context.Context, in *AckRequest, out *AckResponse) error
-	// JoinedGCs returns a stream that gets sent events about GCs the local client
-	// has joined.
-	JoinedGCs(ctx context.Context, in *JoinedGCsRequest) (GCService_JoinedGCsClient, error)
-	// AckJoinedGCs acks received joined gc events.
-	AckJoinedGCs(ctx context.Context, in *AckRequest, out *AckResponse) error
-}
-
-type client_GCService struct {
-	c    ClientConn
-	defn ServiceDefn
-}
-
-func (c *client_GCService) InviteToGC(ctx context.Context, in *InviteToGCRequest, out *InviteToGCResponse) error {
-	const method = "InviteToGC"
-	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
-}
-
-func (c *client_GCService) AcceptGCInvite(ctx context.Context, in *AcceptGCInviteRequest, out *AcceptGCInviteResponse) error {
-	const method = "AcceptGCInvite"
-	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
-}
-
-func (c *client_GCService) KickFromGC(ctx context.Context, in *KickFromGCRequest, out *KickFromGCResponse) error {
-	const method = "KickFromGC"
-	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
-}
-
-func (c *client_GCService) GetGC(ctx context.Context, in *GetGCRequest, out *GetGCResponse) error {
-	const method = "GetGC"
-	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
-}
-
-func (c *client_GCService) List(ctx context.Context, in *ListGCsRequest, out *ListGCsResponse) error {
-	const method = "List"
-	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
-}
-
-type GCService_ReceivedGCInvitesClient interface {
+			"MarkRead": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(MarkReadRequest) },
+				NewResponse:  func() proto.Message { return new(MarkReadResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(MarkReadRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(MarkReadResponse).ProtoReflect().Descriptor() },
+				Help:         "MarkRead marks the messages of a PM or GC chat as read, up to and including a given message, or the entire chat when no message is specified. Every clientrpc client attached to the local client receives a corresponding event in the ReadReceiptsStream, so that unread counts may be shared across multiple frontends.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).MarkRead(ctx, request.(*MarkReadRequest), response.(*MarkReadResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.MarkRead"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ReadReceiptsStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(ReadReceiptsStreamRequest) },
+				NewResponse: func() proto.Message { return new(ReceivedReadReceipt) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ReadReceiptsStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReceivedReadReceipt).ProtoReflect().Descriptor() },
+				Help:         "ReadReceiptsStream returns a stream that gets sent events about chats marked as read through MarkRead calls.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).ReadReceiptsStream(ctx, request.(*ReadReceiptsStreamRequest), streamerImpl[*ReceivedReadReceipt]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.ReadReceiptsStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckReadReceipts": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckReadReceipts acks received read receipt events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckReadReceipts(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckReadReceipts"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"EditMessage": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(EditMessageRequest) },
+				NewResponse:  func() proto.Message { return new(EditMessageResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(EditMessageRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(EditMessageResponse).ProtoReflect().Descriptor() },
+				Help:         "EditMessage replaces the contents of a PM or GC message previously sent by the local client. The edit is sent to the remote user or to the GC members.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).EditMessage(ctx, request.(*EditMessageRequest), response.(*EditMessageResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.EditMessage"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MessageEditsStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(MessageEditsStreamRequest) },
+				NewResponse: func() proto.Message { return new(ReceivedMessageEdit) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(MessageEditsStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReceivedMessageEdit).ProtoReflect().Descriptor() },
+				Help:         "MessageEditsStream returns a stream that gets sent edits of PM and GC messages done by remote users.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).MessageEditsStream(ctx, request.(*MessageEditsStreamRequest), streamerImpl[*ReceivedMessageEdit]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.MessageEditsStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckMessageEdits": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckMessageEdits acks received message edit events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckMessageEdits(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckMessageEdits"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RatchetHealthStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(RatchetHealthStreamRequest) },
+				NewResponse: func() proto.Message { return new(RatchetHealthEvent) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RatchetHealthStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(RatchetHealthEvent).ProtoReflect().Descriptor() },
+				Help:         "RatchetHealthStream returns a stream that gets sent events about the state of the ratchets with remote users, such as when ratchet resets are requested and completed, and when handshakes progress. This may be used to detect and repair stuck conversations.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).RatchetHealthStream(ctx, request.(*RatchetHealthStreamRequest), streamerImpl[*RatchetHealthEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.RatchetHealthStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckRatchetHealth": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckRatchetHealth acks received ratchet health events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckRatchetHealth(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckRatchetHealth"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RatchetHealth": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(RatchetHealthRequest) },
+				NewResponse:  func() proto.Message { return new(RatchetHealthResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(RatchetHealthRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(RatchetHealthResponse).ProtoReflect().Descriptor() },
+				Help:         "RatchetHealth returns diagnostics about the state of the ratchets with remote users, including whether the ratchet is probably broken and should be reset.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).RatchetHealth(ctx, request.(*RatchetHealthRequest), response.(*RatchetHealthResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.RatchetHealth"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ReactToMessage": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ReactToMessageRequest) },
+				NewResponse:  func() proto.Message { return new(ReactToMessageResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ReactToMessageRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReactToMessageResponse).ProtoReflect().Descriptor() },
+				Help:         "ReactToMessage adds or removes a reaction (usually an emoji) to a PM or GC message. The reaction is sent to the remote user or to the GC members.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ReactToMessage(ctx, request.(*ReactToMessageRequest), response.(*ReactToMessageResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ReactToMessage"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetMessageReactions": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GetMessageReactionsRequest) },
+				NewResponse: func() proto.Message { return new(MessageReactions) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(GetMessageReactionsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(MessageReactions).ProtoReflect().Descriptor() },
+				Help:         "GetMessageReactions returns the aggregated reactions to a message.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).GetMessageReactions(ctx, request.(*GetMessageReactionsRequest), response.(*MessageReactions))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.GetMessageReactions"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MessageReactionsStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(MessageReactionsStreamRequest) },
+				NewResponse: func() proto.Message { return new(ReceivedMessageReaction) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(MessageReactionsStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReceivedMessageReaction).ProtoReflect().Descriptor() },
+				Help:         "MessageReactionsStream returns a stream that gets sent reactions of remote users to PM and GC messages.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).MessageReactionsStream(ctx, request.(*MessageReactionsStreamRequest), streamerImpl[*ReceivedMessageReaction]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.MessageReactionsStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckMessageReactions": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckMessageReactions acks received message reaction events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckMessageReactions(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckMessageReactions"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetThread": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetThreadRequest) },
+				NewResponse:  func() proto.Message { return new(MessageThread) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetThreadRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(MessageThread).ProtoReflect().Descriptor() },
+				Help:         "GetThread returns the thread of replies that a PM or GC message belongs to. The message may be either the root of the thread or one of its replies. Replies are sent by setting the reply_to field of PM and GCM requests.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).GetThread(ctx, request.(*GetThreadRequest), response.(*MessageThread))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.GetThread"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SearchMessages": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SearchMessagesRequest) },
+				NewResponse:  func() proto.Message { return new(SearchMessagesResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SearchMessagesRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SearchMessagesResponse).ProtoReflect().Descriptor() },
+				Help:         "SearchMessages searches the logged PMs and GC messages for messages that contain all the terms of the query. The search is case insensitive and includes the text of embedded content. Results are returned from newest to oldest.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).SearchMessages(ctx, request.(*SearchMessagesRequest), response.(*SearchMessagesResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.SearchMessages"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ExportChat": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ExportChatRequest) },
+				NewResponse:  func() proto.Message { return new(ExportChatResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ExportChatRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ExportChatResponse).ProtoReflect().Descriptor() },
+				Help:         "ExportChat exports the logged history of a PM conversation or GC as JSON or as a self-contained HTML page. PM exports also include the tips exchanged with the user.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ExportChat(ctx, request.(*ExportChatRequest), response.(*ExportChatResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ExportChat"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ArchiveChat": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ArchiveChatRequest) },
+				NewResponse:  func() proto.Message { return new(ArchiveChatResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ArchiveChatRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ArchiveChatResponse).ProtoReflect().Descriptor() },
+				Help:         "ArchiveChat archives a PM conversation or GC. Archived chats are hidden from the list of active chats and are automatically unarchived when a new message is received on them.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ArchiveChat(ctx, request.(*ArchiveChatRequest), response.(*ArchiveChatResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ArchiveChat"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"UnarchiveChat": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ArchiveChatRequest) },
+				NewResponse:  func() proto.Message { return new(ArchiveChatResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ArchiveChatRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ArchiveChatResponse).ProtoReflect().Descriptor() },
+				Help:         "UnarchiveChat removes the archived status of a PM conversation or GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).UnarchiveChat(ctx, request.(*ArchiveChatRequest), response.(*ArchiveChatResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.UnarchiveChat"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListArchivedChats": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListArchivedChatsRequest) },
+				NewResponse: func() proto.Message { return new(ListArchivedChatsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListArchivedChatsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListArchivedChatsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListArchivedChats lists the archived PM conversations and GCs.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ListArchivedChats(ctx, request.(*ListArchivedChatsRequest), response.(*ListArchivedChatsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ListArchivedChats"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetAutoReply": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetAutoReplyRequest) },
+				NewResponse:  func() proto.Message { return new(AutoReplyConfig) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetAutoReplyRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AutoReplyConfig).ProtoReflect().Descriptor() },
+				Help:         "GetAutoReply returns the current config for automatic replies to PMs.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).GetAutoReply(ctx, request.(*GetAutoReplyRequest), response.(*AutoReplyConfig))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.GetAutoReply"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetAutoReply": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AutoReplyConfig) },
+				NewResponse:  func() proto.Message { return new(SetAutoReplyResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AutoReplyConfig).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetAutoReplyResponse).ProtoReflect().Descriptor() },
+				Help:         "SetAutoReply sets the config for automatic replies to PMs. When enabled, the first PM received from each user within the configured interval is automatically replied to.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).SetAutoReply(ctx, request.(*AutoReplyConfig), response.(*SetAutoReplyResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.SetAutoReply"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SendVoiceMessage": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(SendVoiceMessageRequest) },
+				NewResponse: func() proto.Message { return new(SendVoiceMessageResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(SendVoiceMessageRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(SendVoiceMessageResponse).ProtoReflect().Descriptor()
+				},
+				Help: "SendVoiceMessage sends a voice message to a user or GC. The audio must be Opus-encoded in an Ogg container. Voice messages are embedded in PMs and GC messages with the audio/ogg mime type.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).SendVoiceMessage(ctx, request.(*SendVoiceMessageRequest), response.(*SendVoiceMessageResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.SendVoiceMessage"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetDraft": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetDraftRequest) },
+				NewResponse:  func() proto.Message { return new(GetDraftResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetDraftRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GetDraftResponse).ProtoReflect().Descriptor() },
+				Help:         "GetDraft returns the unsent draft of a PM conversation or GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).GetDraft(ctx, request.(*GetDraftRequest), response.(*GetDraftResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.GetDraft"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetDraft": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SetDraftRequest) },
+				NewResponse:  func() proto.Message { return new(SetDraftResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetDraftRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetDraftResponse).ProtoReflect().Descriptor() },
+				Help:         "SetDraft stores the unsent draft of a PM conversation or GC, so that it may be restored by any frontend after a restart. An empty text removes the draft.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).SetDraft(ctx, request.(*SetDraftRequest), response.(*SetDraftResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.SetDraft"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListDrafts": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListDraftsRequest) },
+				NewResponse:  func() proto.Message { return new(ListDraftsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListDraftsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListDraftsResponse).ProtoReflect().Descriptor() },
+				Help:         "ListDrafts lists the unsent drafts of all chats.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ListDrafts(ctx, request.(*ListDraftsRequest), response.(*ListDraftsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ListDrafts"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"StoreBroadcastList": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(BroadcastList) },
+				NewResponse: func() proto.Message { return new(StoreBroadcastListResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(BroadcastList).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(StoreBroadcastListResponse).ProtoReflect().Descriptor()
+				},
+				Help: "StoreBroadcastList creates or replaces a broadcast list. Broadcast lists are locally defined sets of users to which messages are sent as individual PMs, without creating a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).StoreBroadcastList(ctx, request.(*BroadcastList), response.(*StoreBroadcastListResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.StoreBroadcastList"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RemoveBroadcastList": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RemoveBroadcastListRequest) },
+				NewResponse: func() proto.Message { return new(RemoveBroadcastListResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveBroadcastListRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveBroadcastListResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RemoveBroadcastList removes a broadcast list.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).RemoveBroadcastList(ctx, request.(*RemoveBroadcastListRequest), response.(*RemoveBroadcastListResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.RemoveBroadcastList"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListBroadcastLists": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListBroadcastListsRequest) },
+				NewResponse: func() proto.Message { return new(ListBroadcastListsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListBroadcastListsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListBroadcastListsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListBroadcastLists lists the existing broadcast lists.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ListBroadcastLists(ctx, request.(*ListBroadcastListsRequest), response.(*ListBroadcastListsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ListBroadcastLists"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SendBroadcast": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SendBroadcastRequest) },
+				NewResponse:  func() proto.Message { return new(BroadcastStatus) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SendBroadcastRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(BroadcastStatus).ProtoReflect().Descriptor() },
+				Help:         "SendBroadcast sends a message as an individual PM to each member of a broadcast list.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).SendBroadcast(ctx, request.(*SendBroadcastRequest), response.(*BroadcastStatus))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.SendBroadcast"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetBroadcastStatus": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GetBroadcastStatusRequest) },
+				NewResponse: func() proto.Message { return new(BroadcastStatus) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(GetBroadcastStatusRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(BroadcastStatus).ProtoReflect().Descriptor() },
+				Help:         "GetBroadcastStatus returns the aggregated delivery status of a broadcast sent since the client started.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).GetBroadcastStatus(ctx, request.(*GetBroadcastStatusRequest), response.(*BroadcastStatus))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.GetBroadcastStatus"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"StoreContactGroup": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ContactGroup) },
+				NewResponse: func() proto.Message { return new(StoreContactGroupResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(ContactGroup).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(StoreContactGroupResponse).ProtoReflect().Descriptor()
+				},
+				Help: "StoreContactGroup creates or replaces a contact group. Contact groups are locally defined, named sets of users on which batch operations may be performed.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).StoreContactGroup(ctx, request.(*ContactGroup), response.(*StoreContactGroupResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.StoreContactGroup"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RemoveContactGroup": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RemoveContactGroupRequest) },
+				NewResponse: func() proto.Message { return new(RemoveContactGroupResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveContactGroupRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveContactGroupResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RemoveContactGroup removes a contact group.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).RemoveContactGroup(ctx, request.(*RemoveContactGroupRequest), response.(*RemoveContactGroupResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.RemoveContactGroup"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListContactGroups": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListContactGroupsRequest) },
+				NewResponse: func() proto.Message { return new(ListContactGroupsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContactGroupsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContactGroupsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListContactGroups lists the existing contact groups.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ListContactGroups(ctx, request.(*ListContactGroupsRequest), response.(*ListContactGroupsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ListContactGroups"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PMContactGroup": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PMContactGroupRequest) },
+				NewResponse:  func() proto.Message { return new(ContactGroupResult) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PMContactGroupRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactGroupResult).ProtoReflect().Descriptor() },
+				Help:         "PMContactGroup sends a message as an individual PM to each member of a contact group.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).PMContactGroup(ctx, request.(*PMContactGroupRequest), response.(*ContactGroupResult))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.PMContactGroup"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ApplyContactGroupFilter": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ApplyContactGroupFilterRequest) },
+				NewResponse: func() proto.Message { return new(ContactGroupResult) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ApplyContactGroupFilterRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactGroupResult).ProtoReflect().Descriptor() },
+				Help:         "ApplyContactGroupFilter stores a copy of a content filter rule for each member of a contact group.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ApplyContactGroupFilter(ctx, request.(*ApplyContactGroupFilterRequest), response.(*ContactGroupResult))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ApplyContactGroupFilter"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListContentFilters": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListContentFiltersRequest) },
+				NewResponse: func() proto.Message { return new(ListContentFiltersResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContentFiltersRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContentFiltersResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListContentFilters lists the content filter rules applied to received messages.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).ListContentFilters(ctx, request.(*ListContentFiltersRequest), response.(*ListContentFiltersResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.ListContentFilters"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"StoreContentFilter": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ContentFilter) },
+				NewResponse:  func() proto.Message { return new(ContentFilter) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ContentFilter).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContentFilter).ProtoReflect().Descriptor() },
+				Help:         "StoreContentFilter adds a new content filter rule (when id is zero) or updates an existing one. The rule starts applying immediately.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).StoreContentFilter(ctx, request.(*ContentFilter), response.(*ContentFilter))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.StoreContentFilter"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RemoveContentFilter": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RemoveContentFilterRequest) },
+				NewResponse: func() proto.Message { return new(RemoveContentFilterResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveContentFilterRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RemoveContentFilterResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RemoveContentFilter removes a content filter rule.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).RemoveContentFilter(ctx, request.(*RemoveContentFilterRequest), response.(*RemoveContentFilterResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.RemoveContentFilter"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GCMentionsStream": {
+				IsStreaming:  true,
+				NewRequest:   func() proto.Message { return new(GCMentionsStreamRequest) },
+				NewResponse:  func() proto.Message { return new(GCReceivedMsg) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCMentionsStreamRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCReceivedMsg).ProtoReflect().Descriptor() },
+				Help:         "GCMentionsStream returns a stream that gets sent GC messages that mention the local client. Messages sent to this stream are also sent to the GCMStream, so this may be used to be notified of mentions in GCs that are otherwise ignored.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).GCMentionsStream(ctx, request.(*GCMentionsStreamRequest), streamerImpl[*GCReceivedMsg]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.GCMentionsStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckGCMentions": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckGCMentions acks received GC mention events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckGCMentions(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckGCMentions"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MsgDeliveryStatusStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(MsgDeliveryStatusStreamRequest) },
+				NewResponse: func() proto.Message { return new(MsgDeliveryStatusEvent) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(MsgDeliveryStatusStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(MsgDeliveryStatusEvent).ProtoReflect().Descriptor() },
+				Help:         "MsgDeliveryStatusStream returns a stream that gets sent events about the delivery status of PMs and GC messages sent by the local client. Events refer to the msg_id returned by the PM and GCM calls. For GC messages, one event is sent per GC member.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ChatServiceServer).MsgDeliveryStatusStream(ctx, request.(*MsgDeliveryStatusStreamRequest), streamerImpl[*MsgDeliveryStatusEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ChatService.MsgDeliveryStatusStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckMsgDeliveryStatus": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckMsgDeliveryStatus acks received message delivery status events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ChatServiceServer).AckMsgDeliveryStatus(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ChatService.AckMsgDeliveryStatus"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+		},
+	}
+}
+
+// GCServiceClient is the client API for GCService service.
+type GCServiceClient interface {
+	// InviteToGC invites an user to join a GC. The local user must have admin
+	// privileges in the gc.
+	InviteToGC(ctx context.Context, in *InviteToGCRequest, out *InviteToGCResponse) error
+	// InviteContactGroupToGC invites each member of a contact group to join a
+	// GC. Members already in the GC are skipped.
+	InviteContactGroupToGC(ctx context.Context, in *InviteContactGroupToGCRequest, out *ContactGroupResult) error
+	// AcceptGCInvite accepts an invitation to join a GC.
+	AcceptGCInvite(ctx context.Context, in *AcceptGCInviteRequest, out *AcceptGCInviteResponse) error
+	// KickFromGC kicks an user from a GC. The local user must have admin
+	// privileges in the GC.
+	KickFromGC(ctx context.Context, in *KickFromGCRequest, out *KickFromGCResponse) error
+	// GetGC returns the full definition for a GC.
+	GetGC(ctx context.Context, in *GetGCRequest, out *GetGCResponse) error
+	// List returns a list with all GCs.
+	List(ctx context.Context, in *ListGCsRequest, out *ListGCsResponse) error
+	// ReceivedGCInvites returns a stream that gets sent invitations that remote
+	// users make for the local client to join GCs.
+	ReceivedGCInvites(ctx context.Context, in *ReceivedGCInvitesRequest) (GCService_ReceivedGCInvitesClient, error)
+	// AckReceivedGCInvites acks received gc invite events.
+	AckReceivedGCInvites(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// MembersAdded returns a stream that gets sent events about members added
+	// to GCs the local client participates in.
+	MembersAdded(ctx context.Context, in *GCMembersAddedRequest) (GCService_MembersAddedClient, error)
+	// AckMembersAdded acks received members added events.
+	AckMembersAdded(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// MembersRemoved returns a stream that gets sent events about members removed
+	// from GCs the local client participates in.
+	MembersRemoved(ctx context.Context, in *GCMembersRemovedRequest) (GCService_MembersRemovedClient, error)
+	// AckMembersRemoved acks received members removed events.
+	AckMembersRemoved(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// JoinedGCs returns a stream that gets sent events about GCs the local client
+	// has joined.
+	JoinedGCs(ctx context.Context, in *JoinedGCsRequest) (GCService_JoinedGCsClient, error)
+	// AckJoinedGCs acks received joined gc events.
+	AckJoinedGCs(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// ModifyGCModerators replaces the list of moderators of a GC. The local
+	// user must be the owner or an admin of the GC.
+	ModifyGCModerators(ctx context.Context, in *ModifyGCModeratorsRequest, out *ModifyGCModeratorsResponse) error
+	// SetGCRolePerms sets the permissions of the admins or moderators of a GC.
+	SetGCRolePerms(ctx context.Context, in *SetGCRolePermsRequest, out *SetGCRolePermsResponse) error
+	// PinGCMessage pins or unpins a message in a GC. The local user must have
+	// the pin permission in the GC.
+	PinGCMessage(ctx context.Context, in *PinGCMessageRequest, out *PinGCMessageResponse) error
+	// BanFromGC bans an user from a GC, kicking them if they are a member. The
+	// local user must have the kick permission in the GC.
+	BanFromGC(ctx context.Context, in *BanFromGCRequest, out *BanFromGCResponse) error
+	// UnbanFromGC revokes the ban of an user from a GC.
+	UnbanFromGC(ctx context.Context, in *UnbanFromGCRequest, out *UnbanFromGCResponse) error
+	// ListGCBans lists the users banned from a GC.
+	ListGCBans(ctx context.Context, in *ListGCBansRequest, out *ListGCBansResponse) error
+	// GCModLog returns the local audit log of moderation actions taken in a
+	// GC.
+	GCModLog(ctx context.Context, in *GCModLogRequest, out *GCModLogResponse) error
+	// CreateGCInviteLink creates a shareable invite link to a GC. The local
+	// user must have the invite permission in the GC.
+	CreateGCInviteLink(ctx context.Context, in *CreateGCInviteLinkRequest, out *CreateGCInviteLinkResponse) error
+	// RevokeGCInviteLink revokes an invite link created by the local client.
+	RevokeGCInviteLink(ctx context.Context, in *RevokeGCInviteLinkRequest, out *RevokeGCInviteLinkResponse) error
+	// ListGCInviteLinks lists the invite links created by the local client.
+	ListGCInviteLinks(ctx context.Context, in *ListGCInviteLinksRequest, out *ListGCInviteLinksResponse) error
+	// RedeemGCInviteLink requests to join a GC using an invite link. If the local
+	// client is not KX'd with the issuer of the link, it first KXs with them.
+	RedeemGCInviteLink(ctx context.Context, in *RedeemGCInviteLinkRequest, out *RedeemGCInviteLinkResponse) error
+	// SetGCRetention sets the local message retention policy of a GC. Messages
+	// that are not retained by the policy are pruned from the client db.
+	SetGCRetention(ctx context.Context, in *SetGCRetentionRequest, out *SetGCRetentionResponse) error
+	// GetGCRetention returns the local message retention policy of a GC.
+	GetGCRetention(ctx context.Context, in *GetGCRetentionRequest, out *GetGCRetentionResponse) error
+	// SetGCSlowMode sets the min interval between messages sent by each member
+	// of a GC without a moderation role. The local user must have the metadata
+	// permission in the GC.
+	SetGCSlowMode(ctx context.Context, in *SetGCSlowModeRequest, out *SetGCSlowModeResponse) error
+	// UpdateGCMetadata updates the topic, description or avatar of a GC. The
+	// local user must have the metadata permission in the GC.
+	UpdateGCMetadata(ctx context.Context, in *UpdateGCMetadataRequest, out *UpdateGCMetadataResponse) error
+	// CreateGCChannel creates a named channel in a GC. The local user must have
+	// the metadata permission in the GC.
+	CreateGCChannel(ctx context.Context, in *CreateGCChannelRequest, out *CreateGCChannelResponse) error
+	// RemoveGCChannel removes a channel from a GC. The local user must have the
+	// metadata permission in the GC.
+	RemoveGCChannel(ctx context.Context, in *RemoveGCChannelRequest, out *RemoveGCChannelResponse) error
+	// ListGCChannels lists the channels of a GC.
+	ListGCChannels(ctx context.Context, in *ListGCChannelsRequest, out *ListGCChannelsResponse) error
+	// GCChannelHistory returns the logged messages of a GC channel.
+	GCChannelHistory(ctx context.Context, in *GCChannelHistoryRequest, out *GCChannelHistoryResponse) error
+	// SetGCJoinPolicy sets the max number of members of a GC and whether
+	// requests to join it through invite links must be approved. The local
+	// user must have the invite permission in the GC.
+	SetGCJoinPolicy(ctx context.Context, in *SetGCJoinPolicyRequest, out *SetGCJoinPolicyResponse) error
+	// ListGCJoinRequests lists the pending requests to join GCs through invite
+	// links created by the local client.
+	ListGCJoinRequests(ctx context.Context, in *ListGCJoinRequestsRequest, out *ListGCJoinRequestsResponse) error
+	// ApproveGCJoinRequest approves a pending request to join a GC.
+	ApproveGCJoinRequest(ctx context.Context, in *ApproveGCJoinRequestRequest, out *ApproveGCJoinRequestResponse) error
+	// DenyGCJoinRequest denies a pending request to join a GC.
+	DenyGCJoinRequest(ctx context.Context, in *DenyGCJoinRequestRequest, out *DenyGCJoinRequestResponse) error
+	// SetGCTipLeaderboard enables or disables the tipping leaderboard of a GC.
+	// When enabled, tips made to members from within the context of the GC are
+	// announced to the other members and tips announced by them are recorded.
+	SetGCTipLeaderboard(ctx context.Context, in *SetGCTipLeaderboardRequest, out *SetGCTipLeaderboardResponse) error
+	// GCTipLeaderboard returns the tipping leaderboard of a GC.
+	GCTipLeaderboard(ctx context.Context, in *GCTipLeaderboardRequest, out *GCTipLeaderboardResponse) error
+}
+
+type client_GCService struct {
+	c    ClientConn
+	defn ServiceDefn
+}
+
+func (c *client_GCService) InviteToGC(ctx context.Context, in *InviteToGCRequest, out *InviteToGCResponse) error {
+	const method = "InviteToGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) InviteContactGroupToGC(ctx context.Context, in *InviteContactGroupToGCRequest, out *ContactGroupResult) error {
+	const method = "InviteContactGroupToGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) AcceptGCInvite(ctx context.Context, in *AcceptGCInviteRequest, out *AcceptGCInviteResponse) error {
+	const method = "AcceptGCInvite"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) KickFromGC(ctx context.Context, in *KickFromGCRequest, out *KickFromGCResponse) error {
+	const method = "KickFromGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) GetGC(ctx context.Context, in *GetGCRequest, out *GetGCResponse) error {
+	const method = "GetGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) List(ctx context.Context, in *ListGCsRequest, out *ListGCsResponse) error {
+	const method = "List"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type GCService_ReceivedGCInvitesClient interface {
 	Recv(*ReceivedGCInvite) error
 }
 
@@ -630,6 +1961,131 @@ func (c *client_GCService) AckJoinedGCs(ctx context.Context, in *AckRequest, out
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_GCService) ModifyGCModerators(ctx context.Context, in *ModifyGCModeratorsRequest, out *ModifyGCModeratorsResponse) error {
+	const method = "ModifyGCModerators"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) SetGCRolePerms(ctx context.Context, in *SetGCRolePermsRequest, out *SetGCRolePermsResponse) error {
+	const method = "SetGCRolePerms"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) PinGCMessage(ctx context.Context, in *PinGCMessageRequest, out *PinGCMessageResponse) error {
+	const method = "PinGCMessage"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) BanFromGC(ctx context.Context, in *BanFromGCRequest, out *BanFromGCResponse) error {
+	const method = "BanFromGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) UnbanFromGC(ctx context.Context, in *UnbanFromGCRequest, out *UnbanFromGCResponse) error {
+	const method = "UnbanFromGC"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) ListGCBans(ctx context.Context, in *ListGCBansRequest, out *ListGCBansResponse) error {
+	const method = "ListGCBans"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) GCModLog(ctx context.Context, in *GCModLogRequest, out *GCModLogResponse) error {
+	const method = "GCModLog"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) CreateGCInviteLink(ctx context.Context, in *CreateGCInviteLinkRequest, out *CreateGCInviteLinkResponse) error {
+	const method = "CreateGCInviteLink"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) RevokeGCInviteLink(ctx context.Context, in *RevokeGCInviteLinkRequest, out *RevokeGCInviteLinkResponse) error {
+	const method = "RevokeGCInviteLink"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) ListGCInviteLinks(ctx context.Context, in *ListGCInviteLinksRequest, out *ListGCInviteLinksResponse) error {
+	const method = "ListGCInviteLinks"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) RedeemGCInviteLink(ctx context.Context, in *RedeemGCInviteLinkRequest, out *RedeemGCInviteLinkResponse) error {
+	const method = "RedeemGCInviteLink"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) SetGCRetention(ctx context.Context, in *SetGCRetentionRequest, out *SetGCRetentionResponse) error {
+	const method = "SetGCRetention"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) GetGCRetention(ctx context.Context, in *GetGCRetentionRequest, out *GetGCRetentionResponse) error {
+	const method = "GetGCRetention"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) SetGCSlowMode(ctx context.Context, in *SetGCSlowModeRequest, out *SetGCSlowModeResponse) error {
+	const method = "SetGCSlowMode"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) UpdateGCMetadata(ctx context.Context, in *UpdateGCMetadataRequest, out *UpdateGCMetadataResponse) error {
+	const method = "UpdateGCMetadata"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) CreateGCChannel(ctx context.Context, in *CreateGCChannelRequest, out *CreateGCChannelResponse) error {
+	const method = "CreateGCChannel"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) RemoveGCChannel(ctx context.Context, in *RemoveGCChannelRequest, out *RemoveGCChannelResponse) error {
+	const method = "RemoveGCChannel"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) ListGCChannels(ctx context.Context, in *ListGCChannelsRequest, out *ListGCChannelsResponse) error {
+	const method = "ListGCChannels"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) GCChannelHistory(ctx context.Context, in *GCChannelHistoryRequest, out *GCChannelHistoryResponse) error {
+	const method = "GCChannelHistory"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) SetGCJoinPolicy(ctx context.Context, in *SetGCJoinPolicyRequest, out *SetGCJoinPolicyResponse) error {
+	const method = "SetGCJoinPolicy"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) ListGCJoinRequests(ctx context.Context, in *ListGCJoinRequestsRequest, out *ListGCJoinRequestsResponse) error {
+	const method = "ListGCJoinRequests"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) ApproveGCJoinRequest(ctx context.Context, in *ApproveGCJoinRequestRequest, out *ApproveGCJoinRequestResponse) error {
+	const method = "ApproveGCJoinRequest"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) DenyGCJoinRequest(ctx context.Context, in *DenyGCJoinRequestRequest, out *DenyGCJoinRequestResponse) error {
+	const method = "DenyGCJoinRequest"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) SetGCTipLeaderboard(ctx context.Context, in *SetGCTipLeaderboardRequest, out *SetGCTipLeaderboardResponse) error {
+	const method = "SetGCTipLeaderboard"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_GCService) GCTipLeaderboard(ctx context.Context, in *GCTipLeaderboardRequest, out *GCTipLeaderboardResponse) error {
+	const method = "GCTipLeaderboard"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 func NewGCServiceClient(c ClientConn) GCServiceClient {
 	return &client_GCService{c: c, defn: GCServiceDefn()}
 }
@@ -639,6 +2095,9 @@ type GCServiceServer interface {
 	// InviteToGC invites an user to join a GC. The local user must have admin
 	// privileges in the gc.
 	InviteToGC(context.Context, *InviteToGCRequest, *InviteToGCResponse) error
+	// InviteContactGroupToGC invites each member of a contact group to join a
+	// GC. Members already in the GC are skipped.
+	InviteContactGroupToGC(context.Context, *InviteContactGroupToGCRequest, *ContactGroupResult) error
 	// AcceptGCInvite accepts an invitation to join a GC.
 	AcceptGCInvite(context.Context, *AcceptGCInviteRequest, *AcceptGCInviteResponse) error
 	// KickFromGC kicks an user from a GC. The local user must have admin
@@ -668,6 +2127,73 @@ type GCServiceServer interface {
 	JoinedGCs(context.Context, *JoinedGCsRequest, GCService_JoinedGCsServer) error
 	// AckJoinedGCs acks received joined gc events.
 	AckJoinedGCs(context.Context, *AckRequest, *AckResponse) error
+	// ModifyGCModerators replaces the list of moderators of a GC. The local
+	// user must be the owner or an admin of the GC.
+	ModifyGCModerators(context.Context, *ModifyGCModeratorsRequest, *ModifyGCModeratorsResponse) error
+	// SetGCRolePerms sets the permissions of the admins or moderators of a GC.
+	SetGCRolePerms(context.Context, *SetGCRolePermsRequest, *SetGCRolePermsResponse) error
+	// PinGCMessage pins or unpins a message in a GC. The local user must have
+	// the pin permission in the GC.
+	PinGCMessage(context.Context, *PinGCMessageRequest, *PinGCMessageResponse) error
+	// BanFromGC bans an user from a GC, kicking them if they are a member. The
+	// local user must have the kick permission in the GC.
+	BanFromGC(context.Context, *BanFromGCRequest, *BanFromGCResponse) error
+	// UnbanFromGC revokes the ban of an user from a GC.
+	UnbanFromGC(context.Context, *UnbanFromGCRequest, *UnbanFromGCResponse) error
+	// ListGCBans lists the users banned from a GC.
+	ListGCBans(context.Context, *ListGCBansRequest, *ListGCBansResponse) error
+	// GCModLog returns the local audit log of moderation actions taken in a
+	// GC.
+	GCModLog(context.Context, *GCModLogRequest, *GCModLogResponse) error
+	// CreateGCInviteLink creates a shareable invite link to a GC. The local
+	// user must have the invite permission in the GC.
+	CreateGCInviteLink(context.Context, *CreateGCInviteLinkRequest, *CreateGCInviteLinkResponse) error
+	// RevokeGCInviteLink revokes an invite link created by the local client.
+	RevokeGCInviteLink(context.Context, *RevokeGCInviteLinkRequest, *RevokeGCInviteLinkResponse) error
+	// ListGCInviteLinks lists the invite links created by the local client.
+	ListGCInviteLinks(context.Context, *ListGCInviteLinksRequest, *ListGCInviteLinksResponse) error
+	// RedeemGCInviteLink requests to join a GC using an invite link. If the local
+	// client is not KX'd with the issuer of the link, it first KXs with them.
+	RedeemGCInviteLink(context.Context, *RedeemGCInviteLinkRequest, *RedeemGCInviteLinkResponse) error
+	// SetGCRetention sets the local message retention policy of a GC. Messages
+	// that are not retained by the policy are pruned from the client db.
+	SetGCRetention(context.Context, *SetGCRetentionRequest, *SetGCRetentionResponse) error
+	// GetGCRetention returns the local message retention policy of a GC.
+	GetGCRetention(context.Context, *GetGCRetentionRequest, *GetGCRetentionResponse) error
+	// SetGCSlowMode sets the min interval between messages sent by each member
+	// of a GC without a moderation role. The local user must have the metadata
+	// permission in the GC.
+	SetGCSlowMode(context.Context, *SetGCSlowModeRequest, *SetGCSlowModeResponse) error
+	// UpdateGCMetadata updates the topic, description or avatar of a GC. The
+	// local user must have the metadata permission in the GC.
+	UpdateGCMetadata(context.Context, *UpdateGCMetadataRequest, *UpdateGCMetadataResponse) error
+	// CreateGCChannel creates a named channel in a GC. The local user must have
+	// the metadata permission in the GC.
+	CreateGCChannel(context.Context, *CreateGCChannelRequest, *CreateGCChannelResponse) error
+	// RemoveGCChannel removes a channel from a GC. The local user must have the
+	// metadata permission in the GC.
+	RemoveGCChannel(context.Context, *RemoveGCChannelRequest, *RemoveGCChannelResponse) error
+	// ListGCChannels lists the channels of a GC.
+	ListGCChannels(context.Context, *ListGCChannelsRequest, *ListGCChannelsResponse) error
+	// GCChannelHistory returns the logged messages of a GC channel.
+	GCChannelHistory(context.Context, *GCChannelHistoryRequest, *GCChannelHistoryResponse) error
+	// SetGCJoinPolicy sets the max number of members of a GC and whether
+	// requests to join it through invite links must be approved. The local
+	// user must have the invite permission in the GC.
+	SetGCJoinPolicy(context.Context, *SetGCJoinPolicyRequest, *SetGCJoinPolicyResponse) error
+	// ListGCJoinRequests lists the pending requests to join GCs through invite
+	// links created by the local client.
+	ListGCJoinRequests(context.Context, *ListGCJoinRequestsRequest, *ListGCJoinRequestsResponse) error
+	// ApproveGCJoinRequest approves a pending request to join a GC.
+	ApproveGCJoinRequest(context.Context, *ApproveGCJoinRequestRequest, *ApproveGCJoinRequestResponse) error
+	// DenyGCJoinRequest denies a pending request to join a GC.
+	DenyGCJoinRequest(context.Context, *DenyGCJoinRequestRequest, *DenyGCJoinRequestResponse) error
+	// SetGCTipLeaderboard enables or disables the tipping leaderboard of a GC.
+	// When enabled, tips made to members from within the context of the GC are
+	// announced to the other members and tips announced by them are recorded.
+	SetGCTipLeaderboard(context.Context, *SetGCTipLeaderboardRequest, *SetGCTipLeaderboardResponse) error
+	// GCTipLeaderboard returns the tipping leaderboard of a GC.
+	GCTipLeaderboard(context.Context, *GCTipLeaderboardRequest, *GCTipLeaderboardResponse) error
 }
 
 type GCService_ReceivedGCInvitesServer interface {
@@ -692,198 +2218,632 @@ func GCServiceDefn() ServiceDefn {
 		Methods: map[string]MethodDefn{
 			"InviteToGC": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(InviteToGCRequest) },
-				NewResponse:  func() proto.Message { return new(InviteToGCResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(InviteToGCRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(InviteToGCResponse).ProtoReflect().Descriptor() },
-				Help:         "InviteToGC invites an user to join a GC. The local user must have admin privileges in the gc.",
+				NewRequest:   func() proto.Message { return new(InviteToGCRequest) },
+				NewResponse:  func() proto.Message { return new(InviteToGCResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(InviteToGCRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(InviteToGCResponse).ProtoReflect().Descriptor() },
+				Help:         "InviteToGC invites an user to join a GC. The local user must have admin privileges in the gc.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).InviteToGC(ctx, request.(*InviteToGCRequest), response.(*InviteToGCResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.InviteToGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"InviteContactGroupToGC": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(InviteContactGroupToGCRequest) },
+				NewResponse: func() proto.Message { return new(ContactGroupResult) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(InviteContactGroupToGCRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactGroupResult).ProtoReflect().Descriptor() },
+				Help:         "InviteContactGroupToGC invites each member of a contact group to join a GC. Members already in the GC are skipped.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).InviteContactGroupToGC(ctx, request.(*InviteContactGroupToGCRequest), response.(*ContactGroupResult))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.InviteContactGroupToGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"AcceptGCInvite": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AcceptGCInviteRequest) },
+				NewResponse:  func() proto.Message { return new(AcceptGCInviteResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AcceptGCInviteRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AcceptGCInviteResponse).ProtoReflect().Descriptor() },
+				Help:         "AcceptGCInvite accepts an invitation to join a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).AcceptGCInvite(ctx, request.(*AcceptGCInviteRequest), response.(*AcceptGCInviteResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.AcceptGCInvite"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"KickFromGC": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(KickFromGCRequest) },
+				NewResponse:  func() proto.Message { return new(KickFromGCResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(KickFromGCRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(KickFromGCResponse).ProtoReflect().Descriptor() },
+				Help:         "KickFromGC kicks an user from a GC. The local user must have admin privileges in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).KickFromGC(ctx, request.(*KickFromGCRequest), response.(*KickFromGCResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.KickFromGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetGC": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetGCRequest) },
+				NewResponse:  func() proto.Message { return new(GetGCResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetGCRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GetGCResponse).ProtoReflect().Descriptor() },
+				Help:         "GetGC returns the full definition for a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).GetGC(ctx, request.(*GetGCRequest), response.(*GetGCResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.GetGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"List": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListGCsRequest) },
+				NewResponse:  func() proto.Message { return new(ListGCsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListGCsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListGCsResponse).ProtoReflect().Descriptor() },
+				Help:         "List returns a list with all GCs.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).List(ctx, request.(*ListGCsRequest), response.(*ListGCsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.List"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ReceivedGCInvites": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(ReceivedGCInvitesRequest) },
+				NewResponse: func() proto.Message { return new(ReceivedGCInvite) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ReceivedGCInvitesRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReceivedGCInvite).ProtoReflect().Descriptor() },
+				Help:         "ReceivedGCInvites returns a stream that gets sent invitations that remote users make for the local client to join GCs.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(GCServiceServer).ReceivedGCInvites(ctx, request.(*ReceivedGCInvitesRequest), streamerImpl[*ReceivedGCInvite]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "GCService.ReceivedGCInvites"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckReceivedGCInvites": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckReceivedGCInvites acks received gc invite events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).AckReceivedGCInvites(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.AckReceivedGCInvites"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MembersAdded": {
+				IsStreaming:  true,
+				NewRequest:   func() proto.Message { return new(GCMembersAddedRequest) },
+				NewResponse:  func() proto.Message { return new(GCMembersAddedEvent) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCMembersAddedRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCMembersAddedEvent).ProtoReflect().Descriptor() },
+				Help:         "MembersAdded returns a stream that gets sent events about members added to GCs the local client participates in.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(GCServiceServer).MembersAdded(ctx, request.(*GCMembersAddedRequest), streamerImpl[*GCMembersAddedEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "GCService.MembersAdded"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckMembersAdded": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckMembersAdded acks received members added events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).AckMembersAdded(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.AckMembersAdded"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MembersRemoved": {
+				IsStreaming:  true,
+				NewRequest:   func() proto.Message { return new(GCMembersRemovedRequest) },
+				NewResponse:  func() proto.Message { return new(GCMembersRemovedEvent) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCMembersRemovedRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCMembersRemovedEvent).ProtoReflect().Descriptor() },
+				Help:         "MembersRemoved returns a stream that gets sent events about members removed from GCs the local client participates in.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(GCServiceServer).MembersRemoved(ctx, request.(*GCMembersRemovedRequest), streamerImpl[*GCMembersRemovedEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "GCService.MembersRemoved"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckMembersRemoved": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckMembersRemoved acks received members removed events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).AckMembersRemoved(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.AckMembersRemoved"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"JoinedGCs": {
+				IsStreaming:  true,
+				NewRequest:   func() proto.Message { return new(JoinedGCsRequest) },
+				NewResponse:  func() proto.Message { return new(JoinedGCEvent) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(JoinedGCsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(JoinedGCEvent).ProtoReflect().Descriptor() },
+				Help:         "JoinedGCs returns a stream that gets sent events about GCs the local client has joined.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(GCServiceServer).JoinedGCs(ctx, request.(*JoinedGCsRequest), streamerImpl[*JoinedGCEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "GCService.JoinedGCs"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckJoinedGCs": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckJoinedGCs acks received joined gc events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).AckJoinedGCs(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.AckJoinedGCs"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ModifyGCModerators": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ModifyGCModeratorsRequest) },
+				NewResponse: func() proto.Message { return new(ModifyGCModeratorsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ModifyGCModeratorsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ModifyGCModeratorsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ModifyGCModerators replaces the list of moderators of a GC. The local user must be the owner or an admin of the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).ModifyGCModerators(ctx, request.(*ModifyGCModeratorsRequest), response.(*ModifyGCModeratorsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.ModifyGCModerators"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetGCRolePerms": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SetGCRolePermsRequest) },
+				NewResponse:  func() proto.Message { return new(SetGCRolePermsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetGCRolePermsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetGCRolePermsResponse).ProtoReflect().Descriptor() },
+				Help:         "SetGCRolePerms sets the permissions of the admins or moderators of a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).SetGCRolePerms(ctx, request.(*SetGCRolePermsRequest), response.(*SetGCRolePermsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.SetGCRolePerms"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PinGCMessage": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PinGCMessageRequest) },
+				NewResponse:  func() proto.Message { return new(PinGCMessageResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PinGCMessageRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PinGCMessageResponse).ProtoReflect().Descriptor() },
+				Help:         "PinGCMessage pins or unpins a message in a GC. The local user must have the pin permission in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).PinGCMessage(ctx, request.(*PinGCMessageRequest), response.(*PinGCMessageResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.PinGCMessage"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"BanFromGC": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(BanFromGCRequest) },
+				NewResponse:  func() proto.Message { return new(BanFromGCResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(BanFromGCRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(BanFromGCResponse).ProtoReflect().Descriptor() },
+				Help:         "BanFromGC bans an user from a GC, kicking them if they are a member. The local user must have the kick permission in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).BanFromGC(ctx, request.(*BanFromGCRequest), response.(*BanFromGCResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.BanFromGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"UnbanFromGC": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(UnbanFromGCRequest) },
+				NewResponse:  func() proto.Message { return new(UnbanFromGCResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(UnbanFromGCRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(UnbanFromGCResponse).ProtoReflect().Descriptor() },
+				Help:         "UnbanFromGC revokes the ban of an user from a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).UnbanFromGC(ctx, request.(*UnbanFromGCRequest), response.(*UnbanFromGCResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.UnbanFromGC"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListGCBans": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListGCBansRequest) },
+				NewResponse:  func() proto.Message { return new(ListGCBansResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListGCBansRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListGCBansResponse).ProtoReflect().Descriptor() },
+				Help:         "ListGCBans lists the users banned from a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).ListGCBans(ctx, request.(*ListGCBansRequest), response.(*ListGCBansResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.ListGCBans"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GCModLog": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GCModLogRequest) },
+				NewResponse:  func() proto.Message { return new(GCModLogResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCModLogRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCModLogResponse).ProtoReflect().Descriptor() },
+				Help:         "GCModLog returns the local audit log of moderation actions taken in a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).GCModLog(ctx, request.(*GCModLogRequest), response.(*GCModLogResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.GCModLog"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"CreateGCInviteLink": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(CreateGCInviteLinkRequest) },
+				NewResponse: func() proto.Message { return new(CreateGCInviteLinkResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(CreateGCInviteLinkRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(CreateGCInviteLinkResponse).ProtoReflect().Descriptor()
+				},
+				Help: "CreateGCInviteLink creates a shareable invite link to a GC. The local user must have the invite permission in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).CreateGCInviteLink(ctx, request.(*CreateGCInviteLinkRequest), response.(*CreateGCInviteLinkResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.CreateGCInviteLink"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RevokeGCInviteLink": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RevokeGCInviteLinkRequest) },
+				NewResponse: func() proto.Message { return new(RevokeGCInviteLinkResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RevokeGCInviteLinkRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RevokeGCInviteLinkResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RevokeGCInviteLink revokes an invite link created by the local client.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).InviteToGC(ctx, request.(*InviteToGCRequest), response.(*InviteToGCResponse))
+					return x.(GCServiceServer).RevokeGCInviteLink(ctx, request.(*RevokeGCInviteLinkRequest), response.(*RevokeGCInviteLinkResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.InviteToGC"
+					method := "GCService.RevokeGCInviteLink"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"AcceptGCInvite": {
-				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(AcceptGCInviteRequest) },
-				NewResponse:  func() proto.Message { return new(AcceptGCInviteResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AcceptGCInviteRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AcceptGCInviteResponse).ProtoReflect().Descriptor() },
-				Help:         "AcceptGCInvite accepts an invitation to join a GC.",
+			"ListGCInviteLinks": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListGCInviteLinksRequest) },
+				NewResponse: func() proto.Message { return new(ListGCInviteLinksResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListGCInviteLinksRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListGCInviteLinksResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListGCInviteLinks lists the invite links created by the local client.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).AcceptGCInvite(ctx, request.(*AcceptGCInviteRequest), response.(*AcceptGCInviteResponse))
+					return x.(GCServiceServer).ListGCInviteLinks(ctx, request.(*ListGCInviteLinksRequest), response.(*ListGCInviteLinksResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.AcceptGCInvite"
+					method := "GCService.ListGCInviteLinks"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"KickFromGC": {
+			"RedeemGCInviteLink": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RedeemGCInviteLinkRequest) },
+				NewResponse: func() proto.Message { return new(RedeemGCInviteLinkResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RedeemGCInviteLinkRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RedeemGCInviteLinkResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RedeemGCInviteLink requests to join a GC using an invite link. If the local client is not KX'd with the issuer of the link, it first KXs with them.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).RedeemGCInviteLink(ctx, request.(*RedeemGCInviteLinkRequest), response.(*RedeemGCInviteLinkResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.RedeemGCInviteLink"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetGCRetention": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(KickFromGCRequest) },
-				NewResponse:  func() proto.Message { return new(KickFromGCResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(KickFromGCRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(KickFromGCResponse).ProtoReflect().Descriptor() },
-				Help:         "KickFromGC kicks an user from a GC. The local user must have admin privileges in the GC.",
+				NewRequest:   func() proto.Message { return new(SetGCRetentionRequest) },
+				NewResponse:  func() proto.Message { return new(SetGCRetentionResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetGCRetentionRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetGCRetentionResponse).ProtoReflect().Descriptor() },
+				Help:         "SetGCRetention sets the local message retention policy of a GC. Messages that are not retained by the policy are pruned from the client db.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).KickFromGC(ctx, request.(*KickFromGCRequest), response.(*KickFromGCResponse))
+					return x.(GCServiceServer).SetGCRetention(ctx, request.(*SetGCRetentionRequest), response.(*SetGCRetentionResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.KickFromGC"
+					method := "GCService.SetGCRetention"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"GetGC": {
+			"GetGCRetention": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(GetGCRequest) },
-				NewResponse:  func() proto.Message { return new(GetGCResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetGCRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GetGCResponse).ProtoReflect().Descriptor() },
-				Help:         "GetGC returns the full definition for a GC.",
+				NewRequest:   func() proto.Message { return new(GetGCRetentionRequest) },
+				NewResponse:  func() proto.Message { return new(GetGCRetentionResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetGCRetentionRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GetGCRetentionResponse).ProtoReflect().Descriptor() },
+				Help:         "GetGCRetention returns the local message retention policy of a GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).GetGC(ctx, request.(*GetGCRequest), response.(*GetGCResponse))
+					return x.(GCServiceServer).GetGCRetention(ctx, request.(*GetGCRetentionRequest), response.(*GetGCRetentionResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.GetGC"
+					method := "GCService.GetGCRetention"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"List": {
+			"SetGCSlowMode": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(ListGCsRequest) },
-				NewResponse:  func() proto.Message { return new(ListGCsResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListGCsRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListGCsResponse).ProtoReflect().Descriptor() },
-				Help:         "List returns a list with all GCs.",
+				NewRequest:   func() proto.Message { return new(SetGCSlowModeRequest) },
+				NewResponse:  func() proto.Message { return new(SetGCSlowModeResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetGCSlowModeRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetGCSlowModeResponse).ProtoReflect().Descriptor() },
+				Help:         "SetGCSlowMode sets the min interval between messages sent by each member of a GC without a moderation role. The local user must have the metadata permission in the GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).List(ctx, request.(*ListGCsRequest), response.(*ListGCsResponse))
+					return x.(GCServiceServer).SetGCSlowMode(ctx, request.(*SetGCSlowModeRequest), response.(*SetGCSlowModeResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.List"
+					method := "GCService.SetGCSlowMode"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"ReceivedGCInvites": {
-				IsStreaming: true,
-				NewRequest:  func() proto.Message { return new(ReceivedGCInvitesRequest) },
-				NewResponse: func() proto.Message { return new(ReceivedGCInvite) },
-				RequestDefn: func() protoreflect.MessageDescriptor {
-					return new(ReceivedGCInvitesRequest).ProtoReflect().Descriptor()
+			"UpdateGCMetadata": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(UpdateGCMetadataRequest) },
+				NewResponse: func() proto.Message { return new(UpdateGCMetadataResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(UpdateGCMetadataRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(UpdateGCMetadataResponse).ProtoReflect().Descriptor()
 				},
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReceivedGCInvite).ProtoReflect().Descriptor() },
-				Help:         "ReceivedGCInvites returns a stream that gets sent invitations that remote users make for the local client to join GCs.",
-				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
-					return x.(GCServiceServer).ReceivedGCInvites(ctx, request.(*ReceivedGCInvitesRequest), streamerImpl[*ReceivedGCInvite]{s: stream})
+				Help: "UpdateGCMetadata updates the topic, description or avatar of a GC. The local user must have the metadata permission in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).UpdateGCMetadata(ctx, request.(*UpdateGCMetadataRequest), response.(*UpdateGCMetadataResponse))
 				},
-				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
-					method := "GCService.ReceivedGCInvites"
-					return conn.Stream(ctx, method, request)
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.UpdateGCMetadata"
+					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"AckReceivedGCInvites": {
+			"CreateGCChannel": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(AckRequest) },
-				NewResponse:  func() proto.Message { return new(AckResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
-				Help:         "AckReceivedGCInvites acks received gc invite events.",
+				NewRequest:   func() proto.Message { return new(CreateGCChannelRequest) },
+				NewResponse:  func() proto.Message { return new(CreateGCChannelResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(CreateGCChannelRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(CreateGCChannelResponse).ProtoReflect().Descriptor() },
+				Help:         "CreateGCChannel creates a named channel in a GC. The local user must have the metadata permission in the GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).AckReceivedGCInvites(ctx, request.(*AckRequest), response.(*AckResponse))
+					return x.(GCServiceServer).CreateGCChannel(ctx, request.(*CreateGCChannelRequest), response.(*CreateGCChannelResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.AckReceivedGCInvites"
+					method := "GCService.CreateGCChannel"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"MembersAdded": {
-				IsStreaming:  true,
-				NewRequest:   func() proto.Message { return new(GCMembersAddedRequest) },
-				NewResponse:  func() proto.Message { return new(GCMembersAddedEvent) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCMembersAddedRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCMembersAddedEvent).ProtoReflect().Descriptor() },
-				Help:         "MembersAdded returns a stream that gets sent events about members added to GCs the local client participates in.",
-				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
-					return x.(GCServiceServer).MembersAdded(ctx, request.(*GCMembersAddedRequest), streamerImpl[*GCMembersAddedEvent]{s: stream})
+			"RemoveGCChannel": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(RemoveGCChannelRequest) },
+				NewResponse:  func() proto.Message { return new(RemoveGCChannelResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(RemoveGCChannelRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(RemoveGCChannelResponse).ProtoReflect().Descriptor() },
+				Help:         "RemoveGCChannel removes a channel from a GC. The local user must have the metadata permission in the GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).RemoveGCChannel(ctx, request.(*RemoveGCChannelRequest), response.(*RemoveGCChannelResponse))
 				},
-				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
-					method := "GCService.MembersAdded"
-					return conn.Stream(ctx, method, request)
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.RemoveGCChannel"
+					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"AckMembersAdded": {
+			"ListGCChannels": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(AckRequest) },
-				NewResponse:  func() proto.Message { return new(AckResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
-				Help:         "AckMembersAdded acks received members added events.",
+				NewRequest:   func() proto.Message { return new(ListGCChannelsRequest) },
+				NewResponse:  func() proto.Message { return new(ListGCChannelsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListGCChannelsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListGCChannelsResponse).ProtoReflect().Descriptor() },
+				Help:         "ListGCChannels lists the channels of a GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).AckMembersAdded(ctx, request.(*AckRequest), response.(*AckResponse))
+					return x.(GCServiceServer).ListGCChannels(ctx, request.(*ListGCChannelsRequest), response.(*ListGCChannelsResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.AckMembersAdded"
+					method := "GCService.ListGCChannels"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"MembersRemoved": {
-				IsStreaming:  true,
-				NewRequest:   func() proto.Message { return new(GCMembersRemovedRequest) },
-				NewResponse:  func() proto.Message { return new(GCMembersRemovedEvent) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GCMembersRemovedRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(GCMembersRemovedEvent).ProtoReflect().Descriptor() },
-				Help:         "MembersRemoved returns a stream that gets sent events about members removed from GCs the local client participates in.",
-				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
-					return x.(GCServiceServer).MembersRemoved(ctx, request.(*GCMembersRemovedRequest), streamerImpl[*GCMembersRemovedEvent]{s: stream})
+			"GCChannelHistory": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GCChannelHistoryRequest) },
+				NewResponse: func() proto.Message { return new(GCChannelHistoryResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(GCChannelHistoryRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(GCChannelHistoryResponse).ProtoReflect().Descriptor()
 				},
-				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
-					method := "GCService.MembersRemoved"
-					return conn.Stream(ctx, method, request)
+				Help: "GCChannelHistory returns the logged messages of a GC channel.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).GCChannelHistory(ctx, request.(*GCChannelHistoryRequest), response.(*GCChannelHistoryResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.GCChannelHistory"
+					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"AckMembersRemoved": {
+			"SetGCJoinPolicy": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(AckRequest) },
-				NewResponse:  func() proto.Message { return new(AckResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
-				Help:         "AckMembersRemoved acks received members removed events.",
+				NewRequest:   func() proto.Message { return new(SetGCJoinPolicyRequest) },
+				NewResponse:  func() proto.Message { return new(SetGCJoinPolicyResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetGCJoinPolicyRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetGCJoinPolicyResponse).ProtoReflect().Descriptor() },
+				Help:         "SetGCJoinPolicy sets the max number of members of a GC and whether requests to join it through invite links must be approved. The local user must have the invite permission in the GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).AckMembersRemoved(ctx, request.(*AckRequest), response.(*AckResponse))
+					return x.(GCServiceServer).SetGCJoinPolicy(ctx, request.(*SetGCJoinPolicyRequest), response.(*SetGCJoinPolicyResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.AckMembersRemoved"
+					method := "GCService.SetGCJoinPolicy"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"JoinedGCs": {
-				IsStreaming:  true,
-				NewRequest:   func() proto.Message { return new(JoinedGCsRequest) },
-				NewResponse:  func() proto.Message { return new(JoinedGCEvent) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(JoinedGCsRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(JoinedGCEvent).ProtoReflect().Descriptor() },
-				Help:         "JoinedGCs returns a stream that gets sent events about GCs the local client has joined.",
-				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
-					return x.(GCServiceServer).JoinedGCs(ctx, request.(*JoinedGCsRequest), streamerImpl[*JoinedGCEvent]{s: stream})
+			"ListGCJoinRequests": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListGCJoinRequestsRequest) },
+				NewResponse: func() proto.Message { return new(ListGCJoinRequestsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListGCJoinRequestsRequest).ProtoReflect().Descriptor()
 				},
-				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
-					method := "GCService.JoinedGCs"
-					return conn.Stream(ctx, method, request)
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListGCJoinRequestsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListGCJoinRequests lists the pending requests to join GCs through invite links created by the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).ListGCJoinRequests(ctx, request.(*ListGCJoinRequestsRequest), response.(*ListGCJoinRequestsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.ListGCJoinRequests"
+					return conn.Request(ctx, method, request, response)
 				},
 			},
-			"AckJoinedGCs": {
-				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(AckRequest) },
-				NewResponse:  func() proto.Message { return new(AckResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
-				Help:         "AckJoinedGCs acks received joined gc events.",
+			"ApproveGCJoinRequest": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ApproveGCJoinRequestRequest) },
+				NewResponse: func() proto.Message { return new(ApproveGCJoinRequestResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ApproveGCJoinRequestRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ApproveGCJoinRequestResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ApproveGCJoinRequest approves a pending request to join a GC.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(GCServiceServer).AckJoinedGCs(ctx, request.(*AckRequest), response.(*AckResponse))
+					return x.(GCServiceServer).ApproveGCJoinRequest(ctx, request.(*ApproveGCJoinRequestRequest), response.(*ApproveGCJoinRequestResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "GCService.AckJoinedGCs"
+					method := "GCService.ApproveGCJoinRequest"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"DenyGCJoinRequest": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(DenyGCJoinRequestRequest) },
+				NewResponse: func() proto.Message { return new(DenyGCJoinRequestResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(DenyGCJoinRequestRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(DenyGCJoinRequestResponse).ProtoReflect().Descriptor()
+				},
+				Help: "DenyGCJoinRequest denies a pending request to join a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).DenyGCJoinRequest(ctx, request.(*DenyGCJoinRequestRequest), response.(*DenyGCJoinRequestResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.DenyGCJoinRequest"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetGCTipLeaderboard": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(SetGCTipLeaderboardRequest) },
+				NewResponse: func() proto.Message { return new(SetGCTipLeaderboardResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(SetGCTipLeaderboardRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(SetGCTipLeaderboardResponse).ProtoReflect().Descriptor()
+				},
+				Help: "SetGCTipLeaderboard enables or disables the tipping leaderboard of a GC. When enabled, tips made to members from within the context of the GC are announced to the other members and tips announced by them are recorded.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).SetGCTipLeaderboard(ctx, request.(*SetGCTipLeaderboardRequest), response.(*SetGCTipLeaderboardResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.SetGCTipLeaderboard"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GCTipLeaderboard": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GCTipLeaderboardRequest) },
+				NewResponse: func() proto.Message { return new(GCTipLeaderboardResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(GCTipLeaderboardRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(GCTipLeaderboardResponse).ProtoReflect().Descriptor()
+				},
+				Help: "GCTipLeaderboard returns the tipping leaderboard of a GC.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(GCServiceServer).GCTipLeaderboard(ctx, request.(*GCTipLeaderboardRequest), response.(*GCTipLeaderboardResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "GCService.GCTipLeaderboard"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
@@ -909,6 +2869,61 @@ type PostsServiceClient interface {
 	// AckReceivedPostStatus acknowledges post status received up to a given
 	// sequence_id have been processed.
 	AckReceivedPostStatus(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// CommentTree returns the tree of comments of a post, or the subtree of
+	// replies to one of its comments.
+	CommentTree(ctx context.Context, in *CommentTreeRequest, out *CommentTreeResponse) error
+	// SavePostDraft creates or updates a post draft, optionally scheduling it
+	// to be published at a future time. Scheduled drafts are published even
+	// after the client restarts.
+	SavePostDraft(ctx context.Context, in *SavePostDraftRequest, out *PostDraft) error
+	// ListPostDrafts lists the post drafts, including scheduled ones.
+	ListPostDrafts(ctx context.Context, in *ListPostDraftsRequest, out *ListPostDraftsResponse) error
+	// RemovePostDraft removes a post draft, canceling its publication if it
+	// was scheduled.
+	RemovePostDraft(ctx context.Context, in *RemovePostDraftRequest, out *RemovePostDraftResponse) error
+	// PublishPostDraft publishes a post draft immediately, removing the
+	// draft.
+	PublishPostDraft(ctx context.Context, in *PublishPostDraftRequest, out *PublishPostDraftResponse) error
+	// SetPostTagFilter sets the filter applied to the tags of new posts from an
+	// author. Posts that do not pass the filter are neither stored nor
+	// relayed.
+	SetPostTagFilter(ctx context.Context, in *SetPostTagFilterRequest, out *SetPostTagFilterResponse) error
+	// ListPostTagFilters lists the post tag filters of all authors.
+	ListPostTagFilters(ctx context.Context, in *ListPostTagFiltersRequest, out *ListPostTagFiltersResponse) error
+	// ReactToPost adds or removes a reaction of the local client on a post.
+	// Reactions are sent to the post author, who relays them to subscribers.
+	ReactToPost(ctx context.Context, in *ReactToPostRequest, out *ReactToPostResponse) error
+	// PostReactions returns the aggregated reactions on a post.
+	PostReactions(ctx context.Context, in *PostReactionsRequest, out *PostReactionsResponse) error
+	// PostStats returns the metrics tracked for posts created by the local
+	// client.
+	PostStats(ctx context.Context, in *PostStatsRequest, out *PostStatsResponse) error
+	// PostSubscriberChurn returns the changes to the list of subscribers to
+	// the local client's posts over a period of time.
+	PostSubscriberChurn(ctx context.Context, in *PostSubscriberChurnRequest, out *PostSubscriberChurnResponse) error
+	// ModeratePostComment hides, unhides, flags or unflags a comment on a post
+	// created by the local client. Subscribers may choose to honor the
+	// moderation action.
+	ModeratePostComment(ctx context.Context, in *ModeratePostCommentRequest, out *ModeratePostCommentResponse) error
+	// MutePostCommenter mutes or unmutes a user from commenting on the local
+	// client's posts.
+	MutePostCommenter(ctx context.Context, in *MutePostCommenterRequest, out *MutePostCommenterResponse) error
+	// ListMutedPostCommenters lists the users muted from commenting on the
+	// local client's posts.
+	ListMutedPostCommenters(ctx context.Context, in *ListMutedPostCommentersRequest, out *ListMutedPostCommentersResponse) error
+	// PinPost pins or unpins a post created by the local client. New
+	// subscribers receive the pinned posts before any other posts.
+	PinPost(ctx context.Context, in *PinPostRequest, out *PinPostResponse) error
+	// ListPinnedPosts lists the posts pinned by the local client.
+	ListPinnedPosts(ctx context.Context, in *ListPinnedPostsRequest, out *ListPinnedPostsResponse) error
+	// BookmarkPost bookmarks a received post or one of its comments.
+	// Bookmarking an already bookmarked post or comment replaces its tags and
+	// note.
+	BookmarkPost(ctx context.Context, in *BookmarkPostRequest, out *BookmarkPostResponse) error
+	// RemovePostBookmark removes the bookmark of a post or comment.
+	RemovePostBookmark(ctx context.Context, in *RemovePostBookmarkRequest, out *RemovePostBookmarkResponse) error
+	// ListPostBookmarks lists the post bookmarks that match a query.
+	ListPostBookmarks(ctx context.Context, in *ListPostBookmarksRequest, out *ListPostBookmarksResponse) error
 }
 
 type client_PostsService struct {
@@ -939,26 +2954,121 @@ func (c *client_PostsService) PostsStream(ctx context.Context, in *PostsStreamRe
 	return streamerImpl[*ReceivedPost]{c: inner}, nil
 }
 
-func (c *client_PostsService) AckReceivedPost(ctx context.Context, in *AckRequest, out *AckResponse) error {
-	const method = "AckReceivedPost"
+func (c *client_PostsService) AckReceivedPost(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckReceivedPost"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type PostsService_PostsStatusStreamClient interface {
+	Recv(*ReceivedPostStatus) error
+}
+
+func (c *client_PostsService) PostsStatusStream(ctx context.Context, in *PostsStatusStreamRequest) (PostsService_PostsStatusStreamClient, error) {
+	const method = "PostsStatusStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*ReceivedPostStatus]{c: inner}, nil
+}
+
+func (c *client_PostsService) AckReceivedPostStatus(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckReceivedPostStatus"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) CommentTree(ctx context.Context, in *CommentTreeRequest, out *CommentTreeResponse) error {
+	const method = "CommentTree"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) SavePostDraft(ctx context.Context, in *SavePostDraftRequest, out *PostDraft) error {
+	const method = "SavePostDraft"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ListPostDrafts(ctx context.Context, in *ListPostDraftsRequest, out *ListPostDraftsResponse) error {
+	const method = "ListPostDrafts"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) RemovePostDraft(ctx context.Context, in *RemovePostDraftRequest, out *RemovePostDraftResponse) error {
+	const method = "RemovePostDraft"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) PublishPostDraft(ctx context.Context, in *PublishPostDraftRequest, out *PublishPostDraftResponse) error {
+	const method = "PublishPostDraft"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) SetPostTagFilter(ctx context.Context, in *SetPostTagFilterRequest, out *SetPostTagFilterResponse) error {
+	const method = "SetPostTagFilter"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ListPostTagFilters(ctx context.Context, in *ListPostTagFiltersRequest, out *ListPostTagFiltersResponse) error {
+	const method = "ListPostTagFilters"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ReactToPost(ctx context.Context, in *ReactToPostRequest, out *ReactToPostResponse) error {
+	const method = "ReactToPost"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) PostReactions(ctx context.Context, in *PostReactionsRequest, out *PostReactionsResponse) error {
+	const method = "PostReactions"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) PostStats(ctx context.Context, in *PostStatsRequest, out *PostStatsResponse) error {
+	const method = "PostStats"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) PostSubscriberChurn(ctx context.Context, in *PostSubscriberChurnRequest, out *PostSubscriberChurnResponse) error {
+	const method = "PostSubscriberChurn"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ModeratePostComment(ctx context.Context, in *ModeratePostCommentRequest, out *ModeratePostCommentResponse) error {
+	const method = "ModeratePostComment"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) MutePostCommenter(ctx context.Context, in *MutePostCommenterRequest, out *MutePostCommenterResponse) error {
+	const method = "MutePostCommenter"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ListMutedPostCommenters(ctx context.Context, in *ListMutedPostCommentersRequest, out *ListMutedPostCommentersResponse) error {
+	const method = "ListMutedPostCommenters"
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
-type PostsService_PostsStatusStreamClient interface {
-	Recv(*ReceivedPostStatus) error
+func (c *client_PostsService) PinPost(ctx context.Context, in *PinPostRequest, out *PinPostResponse) error {
+	const method = "PinPost"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
-func (c *client_PostsService) PostsStatusStream(ctx context.Context, in *PostsStatusStreamRequest) (PostsService_PostsStatusStreamClient, error) {
-	const method = "PostsStatusStream"
-	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
-	if err != nil {
-		return nil, err
-	}
-	return streamerImpl[*ReceivedPostStatus]{c: inner}, nil
+func (c *client_PostsService) ListPinnedPosts(ctx context.Context, in *ListPinnedPostsRequest, out *ListPinnedPostsResponse) error {
+	const method = "ListPinnedPosts"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
-func (c *client_PostsService) AckReceivedPostStatus(ctx context.Context, in *AckRequest, out *AckResponse) error {
-	const method = "AckReceivedPostStatus"
+func (c *client_PostsService) BookmarkPost(ctx context.Context, in *BookmarkPostRequest, out *BookmarkPostResponse) error {
+	const method = "BookmarkPost"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) RemovePostBookmark(ctx context.Context, in *RemovePostBookmarkRequest, out *RemovePostBookmarkResponse) error {
+	const method = "RemovePostBookmark"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PostsService) ListPostBookmarks(ctx context.Context, in *ListPostBookmarksRequest, out *ListPostBookmarksResponse) error {
+	const method = "ListPostBookmarks"
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
@@ -984,6 +3094,61 @@ type PostsServiceServer interface {
 	// AckReceivedPostStatus acknowledges post status received up to a given
 	// sequence_id have been processed.
 	AckReceivedPostStatus(context.Context, *AckRequest, *AckResponse) error
+	// CommentTree returns the tree of comments of a post, or the subtree of
+	// replies to one of its comments.
+	CommentTree(context.Context, *CommentTreeRequest, *CommentTreeResponse) error
+	// SavePostDraft creates or updates a post draft, optionally scheduling it
+	// to be published at a future time. Scheduled drafts are published even
+	// after the client restarts.
+	SavePostDraft(context.Context, *SavePostDraftRequest, *PostDraft) error
+	// ListPostDrafts lists the post drafts, including scheduled ones.
+	ListPostDrafts(context.Context, *ListPostDraftsRequest, *ListPostDraftsResponse) error
+	// RemovePostDraft removes a post draft, canceling its publication if it
+	// was scheduled.
+	RemovePostDraft(context.Context, *RemovePostDraftRequest, *RemovePostDraftResponse) error
+	// PublishPostDraft publishes a post draft immediately, removing the
+	// draft.
+	PublishPostDraft(context.Context, *PublishPostDraftRequest, *PublishPostDraftResponse) error
+	// SetPostTagFilter sets the filter applied to the tags of new posts from an
+	// author. Posts that do not pass the filter are neither stored nor
+	// relayed.
+	SetPostTagFilter(context.Context, *SetPostTagFilterRequest, *SetPostTagFilterResponse) error
+	// ListPostTagFilters lists the post tag filters of all authors.
+	ListPostTagFilters(context.Context, *ListPostTagFiltersRequest, *ListPostTagFiltersResponse) error
+	// ReactToPost adds or removes a reaction of the local client on a post.
+	// Reactions are sent to the post author, who relays them to subscribers.
+	ReactToPost(context.Context, *ReactToPostRequest, *ReactToPostResponse) error
+	// PostReactions returns the aggregated reactions on a post.
+	PostReactions(context.Context, *PostReactionsRequest, *PostReactionsResponse) error
+	// PostStats returns the metrics tracked for posts created by the local
+	// client.
+	PostStats(context.Context, *PostStatsRequest, *PostStatsResponse) error
+	// PostSubscriberChurn returns the changes to the list of subscribers to
+	// the local client's posts over a period of time.
+	PostSubscriberChurn(context.Context, *PostSubscriberChurnRequest, *PostSubscriberChurnResponse) error
+	// ModeratePostComment hides, unhides, flags or unflags a comment on a post
+	// created by the local client. Subscribers may choose to honor the
+	// moderation action.
+	ModeratePostComment(context.Context, *ModeratePostCommentRequest, *ModeratePostCommentResponse) error
+	// MutePostCommenter mutes or unmutes a user from commenting on the local
+	// client's posts.
+	MutePostCommenter(context.Context, *MutePostCommenterRequest, *MutePostCommenterResponse) error
+	// ListMutedPostCommenters lists the users muted from commenting on the
+	// local client's posts.
+	ListMutedPostCommenters(context.Context, *ListMutedPostCommentersRequest, *ListMutedPostCommentersResponse) error
+	// PinPost pins or unpins a post created by the local client. New
+	// subscribers receive the pinned posts before any other posts.
+	PinPost(context.Context, *PinPostRequest, *PinPostResponse) error
+	// ListPinnedPosts lists the posts pinned by the local client.
+	ListPinnedPosts(context.Context, *ListPinnedPostsRequest, *ListPinnedPostsResponse) error
+	// BookmarkPost bookmarks a received post or one of its comments.
+	// Bookmarking an already bookmarked post or comment replaces its tags and
+	// note.
+	BookmarkPost(context.Context, *BookmarkPostRequest, *BookmarkPostResponse) error
+	// RemovePostBookmark removes the bookmark of a post or comment.
+	RemovePostBookmark(context.Context, *RemovePostBookmarkRequest, *RemovePostBookmarkResponse) error
+	// ListPostBookmarks lists the post bookmarks that match a query.
+	ListPostBookmarks(context.Context, *ListPostBookmarksRequest, *ListPostBookmarksResponse) error
 }
 
 type PostsService_PostsStreamServer interface {
@@ -1096,6 +3261,323 @@ func PostsServiceDefn() ServiceDefn {
 					return conn.Request(ctx, method, request, response)
 				},
 			},
+			"CommentTree": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(CommentTreeRequest) },
+				NewResponse:  func() proto.Message { return new(CommentTreeResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(CommentTreeRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(CommentTreeResponse).ProtoReflect().Descriptor() },
+				Help:         "CommentTree returns the tree of comments of a post, or the subtree of replies to one of its comments.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).CommentTree(ctx, request.(*CommentTreeRequest), response.(*CommentTreeResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.CommentTree"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SavePostDraft": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SavePostDraftRequest) },
+				NewResponse:  func() proto.Message { return new(PostDraft) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SavePostDraftRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PostDraft).ProtoReflect().Descriptor() },
+				Help:         "SavePostDraft creates or updates a post draft, optionally scheduling it to be published at a future time. Scheduled drafts are published even after the client restarts.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).SavePostDraft(ctx, request.(*SavePostDraftRequest), response.(*PostDraft))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.SavePostDraft"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListPostDrafts": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListPostDraftsRequest) },
+				NewResponse:  func() proto.Message { return new(ListPostDraftsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListPostDraftsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListPostDraftsResponse).ProtoReflect().Descriptor() },
+				Help:         "ListPostDrafts lists the post drafts, including scheduled ones.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ListPostDrafts(ctx, request.(*ListPostDraftsRequest), response.(*ListPostDraftsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ListPostDrafts"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RemovePostDraft": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(RemovePostDraftRequest) },
+				NewResponse:  func() proto.Message { return new(RemovePostDraftResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(RemovePostDraftRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(RemovePostDraftResponse).ProtoReflect().Descriptor() },
+				Help:         "RemovePostDraft removes a post draft, canceling its publication if it was scheduled.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).RemovePostDraft(ctx, request.(*RemovePostDraftRequest), response.(*RemovePostDraftResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.RemovePostDraft"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PublishPostDraft": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(PublishPostDraftRequest) },
+				NewResponse: func() proto.Message { return new(PublishPostDraftResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(PublishPostDraftRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(PublishPostDraftResponse).ProtoReflect().Descriptor()
+				},
+				Help: "PublishPostDraft publishes a post draft immediately, removing the draft.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).PublishPostDraft(ctx, request.(*PublishPostDraftRequest), response.(*PublishPostDraftResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.PublishPostDraft"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetPostTagFilter": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(SetPostTagFilterRequest) },
+				NewResponse: func() proto.Message { return new(SetPostTagFilterResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(SetPostTagFilterRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(SetPostTagFilterResponse).ProtoReflect().Descriptor()
+				},
+				Help: "SetPostTagFilter sets the filter applied to the tags of new posts from an author. Posts that do not pass the filter are neither stored nor relayed.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).SetPostTagFilter(ctx, request.(*SetPostTagFilterRequest), response.(*SetPostTagFilterResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.SetPostTagFilter"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListPostTagFilters": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListPostTagFiltersRequest) },
+				NewResponse: func() proto.Message { return new(ListPostTagFiltersResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListPostTagFiltersRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListPostTagFiltersResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListPostTagFilters lists the post tag filters of all authors.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ListPostTagFilters(ctx, request.(*ListPostTagFiltersRequest), response.(*ListPostTagFiltersResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ListPostTagFilters"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ReactToPost": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ReactToPostRequest) },
+				NewResponse:  func() proto.Message { return new(ReactToPostResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ReactToPostRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ReactToPostResponse).ProtoReflect().Descriptor() },
+				Help:         "ReactToPost adds or removes a reaction of the local client on a post. Reactions are sent to the post author, who relays them to subscribers.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ReactToPost(ctx, request.(*ReactToPostRequest), response.(*ReactToPostResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ReactToPost"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PostReactions": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PostReactionsRequest) },
+				NewResponse:  func() proto.Message { return new(PostReactionsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PostReactionsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PostReactionsResponse).ProtoReflect().Descriptor() },
+				Help:         "PostReactions returns the aggregated reactions on a post.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).PostReactions(ctx, request.(*PostReactionsRequest), response.(*PostReactionsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.PostReactions"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PostStats": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PostStatsRequest) },
+				NewResponse:  func() proto.Message { return new(PostStatsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PostStatsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PostStatsResponse).ProtoReflect().Descriptor() },
+				Help:         "PostStats returns the metrics tracked for posts created by the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).PostStats(ctx, request.(*PostStatsRequest), response.(*PostStatsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.PostStats"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PostSubscriberChurn": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(PostSubscriberChurnRequest) },
+				NewResponse: func() proto.Message { return new(PostSubscriberChurnResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(PostSubscriberChurnRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(PostSubscriberChurnResponse).ProtoReflect().Descriptor()
+				},
+				Help: "PostSubscriberChurn returns the changes to the list of subscribers to the local client's posts over a period of time.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).PostSubscriberChurn(ctx, request.(*PostSubscriberChurnRequest), response.(*PostSubscriberChurnResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.PostSubscriberChurn"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ModeratePostComment": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ModeratePostCommentRequest) },
+				NewResponse: func() proto.Message { return new(ModeratePostCommentResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ModeratePostCommentRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ModeratePostCommentResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ModeratePostComment hides, unhides, flags or unflags a comment on a post created by the local client. Subscribers may choose to honor the moderation action.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ModeratePostComment(ctx, request.(*ModeratePostCommentRequest), response.(*ModeratePostCommentResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ModeratePostComment"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"MutePostCommenter": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(MutePostCommenterRequest) },
+				NewResponse: func() proto.Message { return new(MutePostCommenterResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(MutePostCommenterRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(MutePostCommenterResponse).ProtoReflect().Descriptor()
+				},
+				Help: "MutePostCommenter mutes or unmutes a user from commenting on the local client's posts.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).MutePostCommenter(ctx, request.(*MutePostCommenterRequest), response.(*MutePostCommenterResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.MutePostCommenter"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListMutedPostCommenters": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListMutedPostCommentersRequest) },
+				NewResponse: func() proto.Message { return new(ListMutedPostCommentersResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListMutedPostCommentersRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListMutedPostCommentersResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListMutedPostCommenters lists the users muted from commenting on the local client's posts.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ListMutedPostCommenters(ctx, request.(*ListMutedPostCommentersRequest), response.(*ListMutedPostCommentersResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ListMutedPostCommenters"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"PinPost": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PinPostRequest) },
+				NewResponse:  func() proto.Message { return new(PinPostResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PinPostRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PinPostResponse).ProtoReflect().Descriptor() },
+				Help:         "PinPost pins or unpins a post created by the local client. New subscribers receive the pinned posts before any other posts.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).PinPost(ctx, request.(*PinPostRequest), response.(*PinPostResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.PinPost"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListPinnedPosts": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListPinnedPostsRequest) },
+				NewResponse:  func() proto.Message { return new(ListPinnedPostsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListPinnedPostsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListPinnedPostsResponse).ProtoReflect().Descriptor() },
+				Help:         "ListPinnedPosts lists the posts pinned by the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ListPinnedPosts(ctx, request.(*ListPinnedPostsRequest), response.(*ListPinnedPostsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ListPinnedPosts"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"BookmarkPost": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(BookmarkPostRequest) },
+				NewResponse:  func() proto.Message { return new(BookmarkPostResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(BookmarkPostRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(BookmarkPostResponse).ProtoReflect().Descriptor() },
+				Help:         "BookmarkPost bookmarks a received post or one of its comments. Bookmarking an already bookmarked post or comment replaces its tags and note.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).BookmarkPost(ctx, request.(*BookmarkPostRequest), response.(*BookmarkPostResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.BookmarkPost"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RemovePostBookmark": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RemovePostBookmarkRequest) },
+				NewResponse: func() proto.Message { return new(RemovePostBookmarkResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RemovePostBookmarkRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RemovePostBookmarkResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RemovePostBookmark removes the bookmark of a post or comment.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).RemovePostBookmark(ctx, request.(*RemovePostBookmarkRequest), response.(*RemovePostBookmarkResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.RemovePostBookmark"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListPostBookmarks": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListPostBookmarksRequest) },
+				NewResponse: func() proto.Message { return new(ListPostBookmarksResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListPostBookmarksRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListPostBookmarksResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListPostBookmarks lists the post bookmarks that match a query.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PostsServiceServer).ListPostBookmarks(ctx, request.(*ListPostBookmarksRequest), response.(*ListPostBookmarksResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PostsService.ListPostBookmarks"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
 		},
 	}
 }
@@ -1106,12 +3588,43 @@ type PaymentsServiceClient interface {
 	// for this to complete. The request to tip is persisted and may continue
 	// after a client restart.
 	TipUser(ctx context.Context, in *TipUserRequest, out *TipUserResponse) error
+	// EstimateTipFee estimates the LN routing fee to tip a user, along with the
+	// max fee allowed by the local fee policy. The LN node of the user must be
+	// known (it is learned from previous invoices sent by the user).
+	EstimateTipFee(ctx context.Context, in *EstimateTipFeeRequest, out *EstimateTipFeeResponse) error
+	// TipContactGroup attempts to send a tip to each member of a contact
+	// group. See TipUser for details on each tip.
+	TipContactGroup(ctx context.Context, in *TipContactGroupRequest, out *ContactGroupResult) error
+	// TipGCMembers splits an amount across members of a GC, sending an
+	// individual tip to each member. See TipUser for details on each tip.
+	TipGCMembers(ctx context.Context, in *TipGCMembersRequest, out *TipGCMembersResponse) error
+	// ListInvoices lists the invoices generated by the local client for
+	// receiving tips and the ones received from remote users for sending
+	// tips.
+	ListInvoices(ctx context.Context, in *ListInvoicesRequest, out *ListInvoicesResponse) error
+	// InvoiceStatus returns the settlement status of an invoice generated or
+	// received by the local client.
+	InvoiceStatus(ctx context.Context, in *InvoiceStatusRequest, out *Invoice) error
+	// CancelInvoice cancels an open invoice generated by the local client, such
+	// that it can no longer be paid.
+	CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, out *CancelInvoiceResponse) error
+	// SetInvoiceLabel sets the local label and memo of an invoice.
+	SetInvoiceLabel(ctx context.Context, in *SetInvoiceLabelRequest, out *SetInvoiceLabelResponse) error
 	// TipProgress starts a stream that receives events about the progress of
 	// TipUser requests. Multiple events for the same request may be received.
 	TipProgress(ctx context.Context, in *TipProgressRequest) (PaymentsService_TipProgressClient, error)
 	// AckTipProgress acknowledges events received up to a given
 	// sequence_id have been processed.
 	AckTipProgress(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// PaymentReport aggregates the payments made and received by the client
+	// during a period of time, in total, per user and optionally per time
+	// window.
+	PaymentReport(ctx context.Context, in *PaymentReportRequest, out *PaymentReportResponse) error
+	// RebalanceChannels moves funds between two channels of the client's LN
+	// node by making a circular payment to itself. This allows increasing the
+	// outbound capacity of a channel by using the outbound capacity of another
+	// one.
+	RebalanceChannels(ctx context.Context, in *RebalanceChannelsRequest, out *RebalanceChannelsResponse) error
 }
 
 type client_PaymentsService struct {
@@ -1124,6 +3637,41 @@ func (c *client_PaymentsService) TipUser(ctx context.Context, in *TipUserRequest
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_PaymentsService) EstimateTipFee(ctx context.Context, in *EstimateTipFeeRequest, out *EstimateTipFeeResponse) error {
+	const method = "EstimateTipFee"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) TipContactGroup(ctx context.Context, in *TipContactGroupRequest, out *ContactGroupResult) error {
+	const method = "TipContactGroup"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) TipGCMembers(ctx context.Context, in *TipGCMembersRequest, out *TipGCMembersResponse) error {
+	const method = "TipGCMembers"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) ListInvoices(ctx context.Context, in *ListInvoicesRequest, out *ListInvoicesResponse) error {
+	const method = "ListInvoices"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) InvoiceStatus(ctx context.Context, in *InvoiceStatusRequest, out *Invoice) error {
+	const method = "InvoiceStatus"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) CancelInvoice(ctx context.Context, in *CancelInvoiceRequest, out *CancelInvoiceResponse) error {
+	const method = "CancelInvoice"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) SetInvoiceLabel(ctx context.Context, in *SetInvoiceLabelRequest, out *SetInvoiceLabelResponse) error {
+	const method = "SetInvoiceLabel"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 type PaymentsService_TipProgressClient interface {
 	Recv(*TipProgressEvent) error
 }
@@ -1142,6 +3690,16 @@ func (c *client_PaymentsService) AckTipProgress(ctx context.Context, in *AckRequ
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_PaymentsService) PaymentReport(ctx context.Context, in *PaymentReportRequest, out *PaymentReportResponse) error {
+	const method = "PaymentReport"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_PaymentsService) RebalanceChannels(ctx context.Context, in *RebalanceChannelsRequest, out *RebalanceChannelsResponse) error {
+	const method = "RebalanceChannels"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 func NewPaymentsServiceClient(c ClientConn) PaymentsServiceClient {
 	return &client_PaymentsService{c: c, defn: PaymentsServiceDefn()}
 }
@@ -1152,12 +3710,43 @@ type PaymentsServiceServer interface {
 	// for this to complete. The request to tip is persisted and may continue
 	// after a client restart.
 	TipUser(context.Context, *TipUserRequest, *TipUserResponse) error
+	// EstimateTipFee estimates the LN routing fee to tip a user, along with the
+	// max fee allowed by the local fee policy. The LN node of the user must be
+	// known (it is learned from previous invoices sent by the user).
+	EstimateTipFee(context.Context, *EstimateTipFeeRequest, *EstimateTipFeeResponse) error
+	// TipContactGroup attempts to send a tip to each member of a contact
+	// group. See TipUser for details on each tip.
+	TipContactGroup(context.Context, *TipContactGroupRequest, *ContactGroupResult) error
+	// TipGCMembers splits an amount across members of a GC, sending an
+	// individual tip to each member. See TipUser for details on each tip.
+	TipGCMembers(context.Context, *TipGCMembersRequest, *TipGCMembersResponse) error
+	// ListInvoices lists the invoices generated by the local client for
+	// receiving tips and the ones received from remote users for sending
+	// tips.
+	ListInvoices(context.Context, *ListInvoicesRequest, *ListInvoicesResponse) error
+	// InvoiceStatus returns the settlement status of an invoice generated or
+	// received by the local client.
+	InvoiceStatus(context.Context, *InvoiceStatusRequest, *Invoice) error
+	// CancelInvoice cancels an open invoice generated by the local client, such
+	// that it can no longer be paid.
+	CancelInvoice(context.Context, *CancelInvoiceRequest, *CancelInvoiceResponse) error
+	// SetInvoiceLabel sets the local label and memo of an invoice.
+	SetInvoiceLabel(context.Context, *SetInvoiceLabelRequest, *SetInvoiceLabelResponse) error
 	// TipProgress starts a stream that receives events about the progress of
 	// TipUser requests. Multiple events for the same request may be received.
 	TipProgress(context.Context, *TipProgressRequest, PaymentsService_TipProgressServer) error
 	// AckTipProgress acknowledges events received up to a given
 	// sequence_id have been processed.
 	AckTipProgress(context.Context, *AckRequest, *AckResponse) error
+	// PaymentReport aggregates the payments made and received by the client
+	// during a period of time, in total, per user and optionally per time
+	// window.
+	PaymentReport(context.Context, *PaymentReportRequest, *PaymentReportResponse) error
+	// RebalanceChannels moves funds between two channels of the client's LN
+	// node by making a circular payment to itself. This allows increasing the
+	// outbound capacity of a channel by using the outbound capacity of another
+	// one.
+	RebalanceChannels(context.Context, *RebalanceChannelsRequest, *RebalanceChannelsResponse) error
 }
 
 type PaymentsService_TipProgressServer interface {
@@ -1170,16 +3759,121 @@ func PaymentsServiceDefn() ServiceDefn {
 		Methods: map[string]MethodDefn{
 			"TipUser": {
 				IsStreaming:  false,
-				NewRequest:   func() proto.Message { return new(TipUserRequest) },
-				NewResponse:  func() proto.Message { return new(TipUserResponse) },
-				RequestDefn:  func() protoreflect.MessageDescriptor { return new(TipUserRequest).ProtoReflect().Descriptor() },
-				ResponseDefn: func() protoreflect.MessageDescriptor { return new(TipUserResponse).ProtoReflect().Descriptor() },
-				Help:         "TipUser attempts to send a tip to a user. The user must be or come online for this to complete. The request to tip is persisted and may continue after a client restart.",
+				NewRequest:   func() proto.Message { return new(TipUserRequest) },
+				NewResponse:  func() proto.Message { return new(TipUserResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(TipUserRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(TipUserResponse).ProtoReflect().Descriptor() },
+				Help:         "TipUser attempts to send a tip to a user. The user must be or come online for this to complete. The request to tip is persisted and may continue after a client restart.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).TipUser(ctx, request.(*TipUserRequest), response.(*TipUserResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.TipUser"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"EstimateTipFee": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(EstimateTipFeeRequest) },
+				NewResponse:  func() proto.Message { return new(EstimateTipFeeResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(EstimateTipFeeRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(EstimateTipFeeResponse).ProtoReflect().Descriptor() },
+				Help:         "EstimateTipFee estimates the LN routing fee to tip a user, along with the max fee allowed by the local fee policy. The LN node of the user must be known (it is learned from previous invoices sent by the user).",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).EstimateTipFee(ctx, request.(*EstimateTipFeeRequest), response.(*EstimateTipFeeResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.EstimateTipFee"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"TipContactGroup": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(TipContactGroupRequest) },
+				NewResponse:  func() proto.Message { return new(ContactGroupResult) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(TipContactGroupRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactGroupResult).ProtoReflect().Descriptor() },
+				Help:         "TipContactGroup attempts to send a tip to each member of a contact group. See TipUser for details on each tip.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).TipContactGroup(ctx, request.(*TipContactGroupRequest), response.(*ContactGroupResult))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.TipContactGroup"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"TipGCMembers": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(TipGCMembersRequest) },
+				NewResponse:  func() proto.Message { return new(TipGCMembersResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(TipGCMembersRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(TipGCMembersResponse).ProtoReflect().Descriptor() },
+				Help:         "TipGCMembers splits an amount across members of a GC, sending an individual tip to each member. See TipUser for details on each tip.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).TipGCMembers(ctx, request.(*TipGCMembersRequest), response.(*TipGCMembersResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.TipGCMembers"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListInvoices": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListInvoicesRequest) },
+				NewResponse:  func() proto.Message { return new(ListInvoicesResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListInvoicesRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListInvoicesResponse).ProtoReflect().Descriptor() },
+				Help:         "ListInvoices lists the invoices generated by the local client for receiving tips and the ones received from remote users for sending tips.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).ListInvoices(ctx, request.(*ListInvoicesRequest), response.(*ListInvoicesResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.ListInvoices"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"InvoiceStatus": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(InvoiceStatusRequest) },
+				NewResponse:  func() proto.Message { return new(Invoice) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(InvoiceStatusRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(Invoice).ProtoReflect().Descriptor() },
+				Help:         "InvoiceStatus returns the settlement status of an invoice generated or received by the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).InvoiceStatus(ctx, request.(*InvoiceStatusRequest), response.(*Invoice))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.InvoiceStatus"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"CancelInvoice": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(CancelInvoiceRequest) },
+				NewResponse:  func() proto.Message { return new(CancelInvoiceResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(CancelInvoiceRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(CancelInvoiceResponse).ProtoReflect().Descriptor() },
+				Help:         "CancelInvoice cancels an open invoice generated by the local client, such that it can no longer be paid.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(PaymentsServiceServer).TipUser(ctx, request.(*TipUserRequest), response.(*TipUserResponse))
+					return x.(PaymentsServiceServer).CancelInvoice(ctx, request.(*CancelInvoiceRequest), response.(*CancelInvoiceResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "PaymentsService.TipUser"
+					method := "PaymentsService.CancelInvoice"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetInvoiceLabel": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(SetInvoiceLabelRequest) },
+				NewResponse:  func() proto.Message { return new(SetInvoiceLabelResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(SetInvoiceLabelRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(SetInvoiceLabelResponse).ProtoReflect().Descriptor() },
+				Help:         "SetInvoiceLabel sets the local label and memo of an invoice.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).SetInvoiceLabel(ctx, request.(*SetInvoiceLabelRequest), response.(*SetInvoiceLabelResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.SetInvoiceLabel"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
@@ -1213,6 +3907,40 @@ func PaymentsServiceDefn() ServiceDefn {
 					return conn.Request(ctx, method, request, response)
 				},
 			},
+			"PaymentReport": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(PaymentReportRequest) },
+				NewResponse:  func() proto.Message { return new(PaymentReportResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(PaymentReportRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(PaymentReportResponse).ProtoReflect().Descriptor() },
+				Help:         "PaymentReport aggregates the payments made and received by the client during a period of time, in total, per user and optionally per time window.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).PaymentReport(ctx, request.(*PaymentReportRequest), response.(*PaymentReportResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.PaymentReport"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"RebalanceChannels": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(RebalanceChannelsRequest) },
+				NewResponse: func() proto.Message { return new(RebalanceChannelsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(RebalanceChannelsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(RebalanceChannelsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "RebalanceChannels moves funds between two channels of the client's LN node by making a circular payment to itself. This allows increasing the outbound capacity of a channel by using the outbound capacity of another one.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(PaymentsServiceServer).RebalanceChannels(ctx, request.(*RebalanceChannelsRequest), response.(*RebalanceChannelsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "PaymentsService.RebalanceChannels"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
 		},
 	}
 }
@@ -1321,6 +4049,12 @@ type ContentServiceClient interface {
 	DownloadsCompletedStream(ctx context.Context, in *DownloadsCompletedStreamRequest) (ContentService_DownloadsCompletedStreamClient, error)
 	// AckDownloadCompleted acks download completed events.
 	AckDownloadCompleted(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// GetBandwidthLimits returns the current limits of the bandwidth used for
+	// content traffic.
+	GetBandwidthLimits(ctx context.Context, in *GetBandwidthLimitsRequest, out *BandwidthLimits) error
+	// SetBandwidthLimits sets the limits of the bandwidth used for content
+	// traffic. The limits are applied to the next transferred chunks of files.
+	SetBandwidthLimits(ctx context.Context, in *BandwidthLimits, out *SetBandwidthLimitsResponse) error
 }
 
 type client_ContentService struct {
@@ -1345,6 +4079,16 @@ func (c *client_ContentService) AckDownloadCompleted(ctx context.Context, in *Ac
 	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
 }
 
+func (c *client_ContentService) GetBandwidthLimits(ctx context.Context, in *GetBandwidthLimitsRequest, out *BandwidthLimits) error {
+	const method = "GetBandwidthLimits"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ContentService) SetBandwidthLimits(ctx context.Context, in *BandwidthLimits, out *SetBandwidthLimitsResponse) error {
+	const method = "SetBandwidthLimits"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
 func NewContentServiceClient(c ClientConn) ContentServiceClient {
 	return &client_ContentService{c: c, defn: ContentServiceDefn()}
 }
@@ -1357,6 +4101,12 @@ type ContentServiceServer interface {
 	DownloadsCompletedStream(context.Context, *DownloadsCompletedStreamRequest, ContentService_DownloadsCompletedStreamServer) error
 	// AckDownloadCompleted acks download completed events.
 	AckDownloadCompleted(context.Context, *AckRequest, *AckResponse) error
+	// GetBandwidthLimits returns the current limits of the bandwidth used for
+	// content traffic.
+	GetBandwidthLimits(context.Context, *GetBandwidthLimitsRequest, *BandwidthLimits) error
+	// SetBandwidthLimits sets the limits of the bandwidth used for content
+	// traffic. The limits are applied to the next transferred chunks of files.
+	SetBandwidthLimits(context.Context, *BandwidthLimits, *SetBandwidthLimitsResponse) error
 }
 
 type ContentService_DownloadsCompletedStreamServer interface {
@@ -1394,10 +4144,469 @@ func ContentServiceDefn() ServiceDefn {
 				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
 				Help:         "AckDownloadCompleted acks download completed events.",
 				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
-					return x.(ContentServiceServer).AckDownloadCompleted(ctx, request.(*AckRequest), response.(*AckResponse))
+					return x.(ContentServiceServer).AckDownloadCompleted(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ContentService.AckDownloadCompleted"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetBandwidthLimits": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GetBandwidthLimitsRequest) },
+				NewResponse: func() proto.Message { return new(BandwidthLimits) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(GetBandwidthLimitsRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(BandwidthLimits).ProtoReflect().Descriptor() },
+				Help:         "GetBandwidthLimits returns the current limits of the bandwidth used for content traffic.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ContentServiceServer).GetBandwidthLimits(ctx, request.(*GetBandwidthLimitsRequest), response.(*BandwidthLimits))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ContentService.GetBandwidthLimits"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"SetBandwidthLimits": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(BandwidthLimits) },
+				NewResponse: func() proto.Message { return new(SetBandwidthLimitsResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor { return new(BandwidthLimits).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(SetBandwidthLimitsResponse).ProtoReflect().Descriptor()
+				},
+				Help: "SetBandwidthLimits sets the limits of the bandwidth used for content traffic. The limits are applied to the next transferred chunks of files.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ContentServiceServer).SetBandwidthLimits(ctx, request.(*BandwidthLimits), response.(*SetBandwidthLimitsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ContentService.SetBandwidthLimits"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+		},
+	}
+}
+
+// AdminServiceClient is the client API for AdminService service.
+type AdminServiceClient interface {
+	// ExecCommand executes a command on the client, using the same syntax as the
+	// commands available in the client's UI (e.g. /gc kick <gc> <user>) and
+	// returns its textual output.
+	//
+	// Depending on the client, executing commands through this call may need to
+	// be explicitly enabled.
+	ExecCommand(ctx context.Context, in *ExecCommandRequest, out *ExecCommandResponse) error
+	// Status returns health and status information about the client, such as
+	// its connection to the server, the state of its LN wallet and outbound
+	// message queues.
+	Status(ctx context.Context, in *StatusRequest, out *StatusResponse) error
+	// ListPendingRMs lists the outbound RMs queued to be sent to remote users
+	// that have not yet been acked by the server.
+	ListPendingRMs(ctx context.Context, in *ListPendingRMsRequest, out *ListPendingRMsResponse) error
+	// CancelPendingRM cancels sending a pending outbound RM. Only RMs that have
+	// not yet started being sent may be canceled.
+	CancelPendingRM(ctx context.Context, in *CancelPendingRMRequest, out *CancelPendingRMResponse) error
+	// ReprioritizePendingRM changes the priority of a pending outbound RM. Only
+	// RMs that have not yet started being sent may be reprioritized.
+	ReprioritizePendingRM(ctx context.Context, in *ReprioritizePendingRMRequest, out *ReprioritizePendingRMResponse) error
+}
+
+type client_AdminService struct {
+	c    ClientConn
+	defn ServiceDefn
+}
+
+func (c *client_AdminService) ExecCommand(ctx context.Context, in *ExecCommandRequest, out *ExecCommandResponse) error {
+	const method = "ExecCommand"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_AdminService) Status(ctx context.Context, in *StatusRequest, out *StatusResponse) error {
+	const method = "Status"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_AdminService) ListPendingRMs(ctx context.Context, in *ListPendingRMsRequest, out *ListPendingRMsResponse) error {
+	const method = "ListPendingRMs"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_AdminService) CancelPendingRM(ctx context.Context, in *CancelPendingRMRequest, out *CancelPendingRMResponse) error {
+	const method = "CancelPendingRM"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_AdminService) ReprioritizePendingRM(ctx context.Context, in *ReprioritizePendingRMRequest, out *ReprioritizePendingRMResponse) error {
+	const method = "ReprioritizePendingRM"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func NewAdminServiceClient(c ClientConn) AdminServiceClient {
+	return &client_AdminService{c: c, defn: AdminServiceDefn()}
+}
+
+// AdminServiceServer is the server API for AdminService service.
+type AdminServiceServer interface {
+	// ExecCommand executes a command on the client, using the same syntax as the
+	// commands available in the client's UI (e.g. /gc kick <gc> <user>) and
+	// returns its textual output.
+	//
+	// Depending on the client, executing commands through this call may need to
+	// be explicitly enabled.
+	ExecCommand(context.Context, *ExecCommandRequest, *ExecCommandResponse) error
+	// Status returns health and status information about the client, such as
+	// its connection to the server, the state of its LN wallet and outbound
+	// message queues.
+	Status(context.Context, *StatusRequest, *StatusResponse) error
+	// ListPendingRMs lists the outbound RMs queued to be sent to remote users
+	// that have not yet been acked by the server.
+	ListPendingRMs(context.Context, *ListPendingRMsRequest, *ListPendingRMsResponse) error
+	// CancelPendingRM cancels sending a pending outbound RM. Only RMs that have
+	// not yet started being sent may be canceled.
+	CancelPendingRM(context.Context, *CancelPendingRMRequest, *CancelPendingRMResponse) error
+	// ReprioritizePendingRM changes the priority of a pending outbound RM. Only
+	// RMs that have not yet started being sent may be reprioritized.
+	ReprioritizePendingRM(context.Context, *ReprioritizePendingRMRequest, *ReprioritizePendingRMResponse) error
+}
+
+func AdminServiceDefn() ServiceDefn {
+	return ServiceDefn{
+		Name: "AdminService",
+		Methods: map[string]MethodDefn{
+			"ExecCommand": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ExecCommandRequest) },
+				NewResponse:  func() proto.Message { return new(ExecCommandResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ExecCommandRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ExecCommandResponse).ProtoReflect().Descriptor() },
+				Help: "ExecCommand executes a command on the client, using the same syntax as the commands available in the client's UI (e.g. /gc kick <gc> <user>) and returns its textual output.\n" +
+					"Depending on the client, executing commands through this call may need to be explicitly enabled.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(AdminServiceServer).ExecCommand(ctx, request.(*ExecCommandRequest), response.(*ExecCommandResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "AdminService.ExecCommand"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"Status": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(StatusRequest) },
+				NewResponse:  func() proto.Message { return new(StatusResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(StatusRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(StatusResponse).ProtoReflect().Descriptor() },
+				Help:         "Status returns health and status information about the client, such as its connection to the server, the state of its LN wallet and outbound message queues.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(AdminServiceServer).Status(ctx, request.(*StatusRequest), response.(*StatusResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "AdminService.Status"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListPendingRMs": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(ListPendingRMsRequest) },
+				NewResponse:  func() proto.Message { return new(ListPendingRMsResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(ListPendingRMsRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ListPendingRMsResponse).ProtoReflect().Descriptor() },
+				Help:         "ListPendingRMs lists the outbound RMs queued to be sent to remote users that have not yet been acked by the server.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(AdminServiceServer).ListPendingRMs(ctx, request.(*ListPendingRMsRequest), response.(*ListPendingRMsResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "AdminService.ListPendingRMs"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"CancelPendingRM": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(CancelPendingRMRequest) },
+				NewResponse:  func() proto.Message { return new(CancelPendingRMResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(CancelPendingRMRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(CancelPendingRMResponse).ProtoReflect().Descriptor() },
+				Help:         "CancelPendingRM cancels sending a pending outbound RM. Only RMs that have not yet started being sent may be canceled.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(AdminServiceServer).CancelPendingRM(ctx, request.(*CancelPendingRMRequest), response.(*CancelPendingRMResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "AdminService.CancelPendingRM"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ReprioritizePendingRM": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ReprioritizePendingRMRequest) },
+				NewResponse: func() proto.Message { return new(ReprioritizePendingRMResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ReprioritizePendingRMRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ReprioritizePendingRMResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ReprioritizePendingRM changes the priority of a pending outbound RM. Only RMs that have not yet started being sent may be reprioritized.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(AdminServiceServer).ReprioritizePendingRM(ctx, request.(*ReprioritizePendingRMRequest), response.(*ReprioritizePendingRMResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "AdminService.ReprioritizePendingRM"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+		},
+	}
+}
+
+// ProfileServiceClient is the client API for ProfileService service.
+type ProfileServiceClient interface {
+	// GetLocalProfile returns the profile of the local client.
+	GetLocalProfile(ctx context.Context, in *GetLocalProfileRequest, out *UserProfile) error
+	// UpdateLocalProfile updates fields of the local client profile. The update
+	// is sent to all remote users the local client has KX'd with.
+	UpdateLocalProfile(ctx context.Context, in *UpdateLocalProfileRequest, out *UpdateLocalProfileResponse) error
+	// GetUserProfile returns the profile of a remote user, as last received by
+	// the local client.
+	GetUserProfile(ctx context.Context, in *GetUserProfileRequest, out *UserProfile) error
+	// ProfileUpdatesStream returns a stream that gets sent events when remote
+	// users update their profiles.
+	ProfileUpdatesStream(ctx context.Context, in *ProfileUpdatesStreamRequest) (ProfileService_ProfileUpdatesStreamClient, error)
+	// AckProfileUpdates acks received profile update events.
+	AckProfileUpdates(ctx context.Context, in *AckRequest, out *AckResponse) error
+	// GetContactMetadata returns the private notes, tags and custom fields the
+	// local client keeps about a remote user. This metadata is never sent to
+	// remote users.
+	GetContactMetadata(ctx context.Context, in *GetContactMetadataRequest, out *ContactMetadata) error
+	// UpdateContactMetadata modifies the private metadata about a remote
+	// user.
+	UpdateContactMetadata(ctx context.Context, in *UpdateContactMetadataRequest, out *ContactMetadata) error
+	// ListContactsByTag lists the remote users that have a given tag.
+	ListContactsByTag(ctx context.Context, in *ListContactsByTagRequest, out *ListContactsByTagResponse) error
+}
+
+type client_ProfileService struct {
+	c    ClientConn
+	defn ServiceDefn
+}
+
+func (c *client_ProfileService) GetLocalProfile(ctx context.Context, in *GetLocalProfileRequest, out *UserProfile) error {
+	const method = "GetLocalProfile"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ProfileService) UpdateLocalProfile(ctx context.Context, in *UpdateLocalProfileRequest, out *UpdateLocalProfileResponse) error {
+	const method = "UpdateLocalProfile"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ProfileService) GetUserProfile(ctx context.Context, in *GetUserProfileRequest, out *UserProfile) error {
+	const method = "GetUserProfile"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+type ProfileService_ProfileUpdatesStreamClient interface {
+	Recv(*ProfileUpdatedEvent) error
+}
+
+func (c *client_ProfileService) ProfileUpdatesStream(ctx context.Context, in *ProfileUpdatesStreamRequest) (ProfileService_ProfileUpdatesStreamClient, error) {
+	const method = "ProfileUpdatesStream"
+	inner, err := c.defn.Methods[method].ClientStreamHandler(c.c, ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return streamerImpl[*ProfileUpdatedEvent]{c: inner}, nil
+}
+
+func (c *client_ProfileService) AckProfileUpdates(ctx context.Context, in *AckRequest, out *AckResponse) error {
+	const method = "AckProfileUpdates"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ProfileService) GetContactMetadata(ctx context.Context, in *GetContactMetadataRequest, out *ContactMetadata) error {
+	const method = "GetContactMetadata"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ProfileService) UpdateContactMetadata(ctx context.Context, in *UpdateContactMetadataRequest, out *ContactMetadata) error {
+	const method = "UpdateContactMetadata"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func (c *client_ProfileService) ListContactsByTag(ctx context.Context, in *ListContactsByTagRequest, out *ListContactsByTagResponse) error {
+	const method = "ListContactsByTag"
+	return c.defn.Methods[method].ClientHandler(c.c, ctx, in, out)
+}
+
+func NewProfileServiceClient(c ClientConn) ProfileServiceClient {
+	return &client_ProfileService{c: c, defn: ProfileServiceDefn()}
+}
+
+// ProfileServiceServer is the server API for ProfileService service.
+type ProfileServiceServer interface {
+	// GetLocalProfile returns the profile of the local client.
+	GetLocalProfile(context.Context, *GetLocalProfileRequest, *UserProfile) error
+	// UpdateLocalProfile updates fields of the local client profile. The update
+	// is sent to all remote users the local client has KX'd with.
+	UpdateLocalProfile(context.Context, *UpdateLocalProfileRequest, *UpdateLocalProfileResponse) error
+	// GetUserProfile returns the profile of a remote user, as last received by
+	// the local client.
+	GetUserProfile(context.Context, *GetUserProfileRequest, *UserProfile) error
+	// ProfileUpdatesStream returns a stream that gets sent events when remote
+	// users update their profiles.
+	ProfileUpdatesStream(context.Context, *ProfileUpdatesStreamRequest, ProfileService_ProfileUpdatesStreamServer) error
+	// AckProfileUpdates acks received profile update events.
+	AckProfileUpdates(context.Context, *AckRequest, *AckResponse) error
+	// GetContactMetadata returns the private notes, tags and custom fields the
+	// local client keeps about a remote user. This metadata is never sent to
+	// remote users.
+	GetContactMetadata(context.Context, *GetContactMetadataRequest, *ContactMetadata) error
+	// UpdateContactMetadata modifies the private metadata about a remote
+	// user.
+	UpdateContactMetadata(context.Context, *UpdateContactMetadataRequest, *ContactMetadata) error
+	// ListContactsByTag lists the remote users that have a given tag.
+	ListContactsByTag(context.Context, *ListContactsByTagRequest, *ListContactsByTagResponse) error
+}
+
+type ProfileService_ProfileUpdatesStreamServer interface {
+	Send(m *ProfileUpdatedEvent) error
+}
+
+func ProfileServiceDefn() ServiceDefn {
+	return ServiceDefn{
+		Name: "ProfileService",
+		Methods: map[string]MethodDefn{
+			"GetLocalProfile": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetLocalProfileRequest) },
+				NewResponse:  func() proto.Message { return new(UserProfile) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetLocalProfileRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(UserProfile).ProtoReflect().Descriptor() },
+				Help:         "GetLocalProfile returns the profile of the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).GetLocalProfile(ctx, request.(*GetLocalProfileRequest), response.(*UserProfile))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.GetLocalProfile"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"UpdateLocalProfile": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(UpdateLocalProfileRequest) },
+				NewResponse: func() proto.Message { return new(UpdateLocalProfileResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(UpdateLocalProfileRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(UpdateLocalProfileResponse).ProtoReflect().Descriptor()
+				},
+				Help: "UpdateLocalProfile updates fields of the local client profile. The update is sent to all remote users the local client has KX'd with.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).UpdateLocalProfile(ctx, request.(*UpdateLocalProfileRequest), response.(*UpdateLocalProfileResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.UpdateLocalProfile"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetUserProfile": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(GetUserProfileRequest) },
+				NewResponse:  func() proto.Message { return new(UserProfile) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(GetUserProfileRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(UserProfile).ProtoReflect().Descriptor() },
+				Help:         "GetUserProfile returns the profile of a remote user, as last received by the local client.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).GetUserProfile(ctx, request.(*GetUserProfileRequest), response.(*UserProfile))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.GetUserProfile"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ProfileUpdatesStream": {
+				IsStreaming: true,
+				NewRequest:  func() proto.Message { return new(ProfileUpdatesStreamRequest) },
+				NewResponse: func() proto.Message { return new(ProfileUpdatedEvent) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ProfileUpdatesStreamRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ProfileUpdatedEvent).ProtoReflect().Descriptor() },
+				Help:         "ProfileUpdatesStream returns a stream that gets sent events when remote users update their profiles.",
+				ServerStreamHandler: func(x interface{}, ctx context.Context, request proto.Message, stream ServerStream) error {
+					return x.(ProfileServiceServer).ProfileUpdatesStream(ctx, request.(*ProfileUpdatesStreamRequest), streamerImpl[*ProfileUpdatedEvent]{s: stream})
+				},
+				ClientStreamHandler: func(conn ClientConn, ctx context.Context, request proto.Message) (ClientStream, error) {
+					method := "ProfileService.ProfileUpdatesStream"
+					return conn.Stream(ctx, method, request)
+				},
+			},
+			"AckProfileUpdates": {
+				IsStreaming:  false,
+				NewRequest:   func() proto.Message { return new(AckRequest) },
+				NewResponse:  func() proto.Message { return new(AckResponse) },
+				RequestDefn:  func() protoreflect.MessageDescriptor { return new(AckRequest).ProtoReflect().Descriptor() },
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(AckResponse).ProtoReflect().Descriptor() },
+				Help:         "AckProfileUpdates acks received profile update events.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).AckProfileUpdates(ctx, request.(*AckRequest), response.(*AckResponse))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.AckProfileUpdates"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"GetContactMetadata": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(GetContactMetadataRequest) },
+				NewResponse: func() proto.Message { return new(ContactMetadata) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(GetContactMetadataRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactMetadata).ProtoReflect().Descriptor() },
+				Help:         "GetContactMetadata returns the private notes, tags and custom fields the local client keeps about a remote user. This metadata is never sent to remote users.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).GetContactMetadata(ctx, request.(*GetContactMetadataRequest), response.(*ContactMetadata))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.GetContactMetadata"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"UpdateContactMetadata": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(UpdateContactMetadataRequest) },
+				NewResponse: func() proto.Message { return new(ContactMetadata) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(UpdateContactMetadataRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor { return new(ContactMetadata).ProtoReflect().Descriptor() },
+				Help:         "UpdateContactMetadata modifies the private metadata about a remote user.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).UpdateContactMetadata(ctx, request.(*UpdateContactMetadataRequest), response.(*ContactMetadata))
+				},
+				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
+					method := "ProfileService.UpdateContactMetadata"
+					return conn.Request(ctx, method, request, response)
+				},
+			},
+			"ListContactsByTag": {
+				IsStreaming: false,
+				NewRequest:  func() proto.Message { return new(ListContactsByTagRequest) },
+				NewResponse: func() proto.Message { return new(ListContactsByTagResponse) },
+				RequestDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContactsByTagRequest).ProtoReflect().Descriptor()
+				},
+				ResponseDefn: func() protoreflect.MessageDescriptor {
+					return new(ListContactsByTagResponse).ProtoReflect().Descriptor()
+				},
+				Help: "ListContactsByTag lists the remote users that have a given tag.",
+				ServerHandler: func(x interface{}, ctx context.Context, request, response proto.Message) error {
+					return x.(ProfileServiceServer).ListContactsByTag(ctx, request.(*ListContactsByTagRequest), response.(*ListContactsByTagResponse))
 				},
 				ClientHandler: func(conn ClientConn, ctx context.Context, request, response proto.Message) error {
-					method := "ContentService.AckDownloadCompleted"
+					method := "ProfileService.ListContactsByTag"
 					return conn.Request(ctx, method, request, response)
 				},
 			},
@@ -1437,7 +4646,8 @@ var help_messages = map[string]map[string]string{
 		"msg":  "msg is the message to be sent.",
 	},
 	"PMResponse": {
-		"@": "PMResponse is the response of the client for a new message.",
+		"@":      "PMResponse is the response of the client for a new message.",
+		"msg_id": "msg_id is the ID of the sent message, which may be used to edit it.",
 	},
 	"PMStreamRequest": {
 		"@":            "PMStreamRequest is the request for a new private message reception stream.",
@@ -1452,12 +4662,15 @@ var help_messages = map[string]map[string]string{
 		"sequence_id":  "sequence_id is an opaque sequential ID.",
 	},
 	"GCMRequest": {
-		"@":   "GCMRequest is a request to send a GC message.",
-		"gc":  "gc is either an hex-encoded GCID or a GC alias.",
-		"msg": "msg is the text payload of the message.",
+		"@":        "GCMRequest is a request to send a GC message.",
+		"gc":       "gc is either an hex-encoded GCID or a GC alias.",
+		"msg":      "msg is the text payload of the message.",
+		"reply_to": "reply_to is the optional ID of a previous message in the GC that this message replies to.",
+		"channel":  "channel is the optional ID of the GC channel to send the message to. It cannot be specified along with reply_to.",
 	},
 	"GCMResponse": {
-		"@": "GCMResponse is the response to sending a GC message.",
+		"@":      "GCMResponse is the response to sending a GC message.",
+		"msg_id": "msg_id is the ID of the sent message, which may be used to edit it.",
 	},
 	"GCMStreamRequest": {
 		"@":            "GCMStreamRequest is a request to a stream of received GC messages.",
@@ -1472,6 +4685,24 @@ var help_messages = map[string]map[string]string{
 		"timestamp_ms": "timestamp_ms is the server timestamp of the message with millisecond precision.",
 		"sequence_id":  "sequence_id is an opaque sequential ID.",
 	},
+	"GCMentionsStreamRequest": {
+		"@":            "GCMentionsStreamRequest is a request to a stream of received GC messages that mention the local client.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last processed GC mention. Mentions received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"MsgDeliveryStatusStreamRequest": {
+		"@":            "MsgDeliveryStatusStreamRequest is a request to a stream of delivery status events of sent messages.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last processed delivery status event. Events received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"MsgDeliveryStatusEvent": {
+		"@":            "MsgDeliveryStatusEvent is sent when the delivery status of a message sent to a remote user changes.",
+		"sequence_id":  "sequence_id is an opaque sequential ID.",
+		"uid":          "uid is the ID of the user the message was sent to.",
+		"nick":         "nick is the nick or alias of the user.",
+		"msg_id":       "msg_id is the ID of the message.",
+		"status":       "status is the new delivery status of the message.",
+		"error":        "error is the reason the message failed to be sent. Only set when status is MSG_STATUS_FAILED.",
+		"timestamp_ms": "timestamp_ms is the local timestamp of the status change with millisecond precision.",
+	},
 	"SubscribeToPostsRequest": {
 		"@":    "SubscribeToPostsRequest is a request to subscribe to a remote user's posts.",
 		"user": "user is the nick or hex-encoded ID of the user to subscribe to.",
@@ -1495,6 +4726,9 @@ var help_messages = map[string]map[string]string{
 		"date":           "date is the unix timestamp of the post.",
 		"last_status_ts": "last_status_ts is the timestamp of the last recorded status update of the post.",
 		"title":          "title is either the included or suggested title of the post.",
+		"tags":           "tags is the list of tags defined by the author of the post.",
+		"relay_comment":  "relay_comment is the commentary attached to the post by its relayer.",
+		"pinned":         "pinned is true if the post was pinned by its author.",
 	},
 	"PostsStreamRequest": {
 		"@":            "PostsStreamRequest is the request to establish a stream of received post events.",
@@ -1520,6 +4754,234 @@ var help_messages = map[string]map[string]string{
 		"status":           "status is the full status data.",
 		"status_from_nick": "status_from_nick is the nick of the original author of the status.",
 	},
+	"CommentTreeRequest": {
+		"@":          "CommentTreeRequest is a request for the tree of comments of a post.",
+		"post_from":  "post_from is the id of the user the post was received from.",
+		"post_id":    "post_id is the id of the post.",
+		"comment_id": "comment_id is the optional id of a comment. If specified, only the comment and its replies are returned.",
+		"max_depth":  "max_depth is the max number of levels of replies to return below the top-level comments (or below comment_id). Zero means no limit.",
+	},
+	"PostComment": {
+		"@":        "PostComment is a comment on a post, along with its replies.",
+		"id":       "id is the id of the status update of the comment.",
+		"status":   "status is the full status data of the comment.",
+		"depth":    "depth is the depth of the comment in the tree of comments of the post.",
+		"children": "children are the replies to the comment.",
+		"hidden":   "hidden is true if the post author hid the comment.",
+		"flagged":  "flagged is true if the post author flagged the comment.",
+	},
+	"CommentTreeResponse": {
+		"@":        "CommentTreeResponse is the response to a request for a tree of comments.",
+		"comments": "comments are the top-level comments of the tree.",
+	},
+	"SavePostDraftRequest": {
+		"@":             "SavePostDraftRequest is a request to create or update a post draft.",
+		"id":            "id is the id of the draft to update. If empty, a new draft is created.",
+		"post":          "post is the contents of the post.",
+		"descr":         "descr is the optional description of the post.",
+		"publish_at_ms": "publish_at_ms is the unix timestamp (with millisecond precision) at which to publish the draft. Zero means the draft is not scheduled.",
+	},
+	"PostDraft": {
+		"@":               "PostDraft is a post that has not been published yet.",
+		"id":              "id is the id of the draft.",
+		"post":            "post is the contents of the post.",
+		"descr":           "descr is the description of the post.",
+		"created_ms":      "created_ms is the unix timestamp (with millisecond precision) of when the draft was created.",
+		"last_updated_ms": "last_updated_ms is the unix timestamp (with millisecond precision) of the last update of the draft.",
+		"publish_at_ms":   "publish_at_ms is the unix timestamp (with millisecond precision) at which the draft is scheduled to be published, or zero if it is not scheduled.",
+	},
+	"ListPostDraftsRequest": {
+		"@": "ListPostDraftsRequest is a request to list the post drafts.",
+	},
+	"ListPostDraftsResponse": {
+		"@":      "ListPostDraftsResponse is the list of post drafts.",
+		"drafts": "drafts are the post drafts, sorted by creation time.",
+	},
+	"RemovePostDraftRequest": {
+		"@":  "RemovePostDraftRequest is a request to remove a post draft.",
+		"id": "id is the id of the draft.",
+	},
+	"RemovePostDraftResponse": {
+		"@": "RemovePostDraftResponse is the response to RemovePostDraft.",
+	},
+	"PublishPostDraftRequest": {
+		"@":  "PublishPostDraftRequest is a request to publish a post draft.",
+		"id": "id is the id of the draft.",
+	},
+	"PublishPostDraftResponse": {
+		"@":       "PublishPostDraftResponse is the response to PublishPostDraft.",
+		"post_id": "post_id is the id of the published post.",
+	},
+	"PostTagFilter": {
+		"@":         "PostTagFilter is the filter applied to the tags of posts from an author.",
+		"author_id": "author_id is the id of the author of the posts.",
+		"include":   "include is the list of tags of which a post must have at least one to be accepted. If empty, posts are accepted unless excluded.",
+		"exclude":   "exclude is the list of tags that cause a post to be rejected.",
+	},
+	"SetPostTagFilterRequest": {
+		"@":      "SetPostTagFilterRequest is a request to set the tag filter of an author. Empty include and exclude lists remove the filter.",
+		"filter": "filter is the new filter.",
+	},
+	"SetPostTagFilterResponse": {
+		"@": "SetPostTagFilterResponse is the response to SetPostTagFilter.",
+	},
+	"ListPostTagFiltersRequest": {
+		"@": "ListPostTagFiltersRequest is a request to list the post tag filters.",
+	},
+	"ListPostTagFiltersResponse": {
+		"@":       "ListPostTagFiltersResponse is the response to ListPostTagFilters.",
+		"filters": "filters is the list of post tag filters.",
+	},
+	"ReactToPostRequest": {
+		"@":         "ReactToPostRequest is a request to react to a post.",
+		"post_from": "post_from is the id of the user the post was received from.",
+		"post_id":   "post_id is the id of the post.",
+		"reaction":  "reaction is the reaction (usually an emoji) to add or remove.",
+		"remove":    "remove is true if the reaction should be removed instead of added.",
+	},
+	"ReactToPostResponse": {
+		"@": "ReactToPostResponse is the response to ReactToPost.",
+	},
+	"PostReactionsRequest": {
+		"@":         "PostReactionsRequest is a request for the reactions on a post.",
+		"post_from": "post_from is the id of the user the post was received from.",
+		"post_id":   "post_id is the id of the post.",
+	},
+	"PostReaction": {
+		"@":        "PostReaction is the aggregated count of a reaction on a post.",
+		"reaction": "reaction is the reaction (usually an emoji).",
+		"users":    "users are the ids of the users that currently have this reaction on the post.",
+	},
+	"PostReactionsResponse": {
+		"@":         "PostReactionsResponse is the response to PostReactions.",
+		"reactions": "reactions are the reactions on the post, sorted by number of users.",
+	},
+	"PostStatsRequest": {
+		"@":       "PostStatsRequest is a request for the metrics of local posts.",
+		"post_id": "post_id is the optional id of a post. If unspecified, the metrics of all posts with any activity are returned.",
+	},
+	"PostStats": {
+		"@":             "PostStats are the metrics tracked for a post created by the local client.",
+		"post_id":       "post_id is the id of the post.",
+		"relays":        "relays is the number of users observed relaying the post.",
+		"comments":      "comments is the number of comments made by other users.",
+		"hearts":        "hearts is the current number of hearts.",
+		"reactions":     "reactions is the current number of reactions.",
+		"unlocks":       "unlocks is the number of users that paid to unlock the body of the post.",
+		"unlock_atoms":  "unlock_atoms is the total amount (in atoms) paid to unlock the body of the post.",
+		"last_activity": "last_activity is the unix timestamp of the last update to the metrics.",
+	},
+	"PostStatsResponse": {
+		"@":     "PostStatsResponse is the response to PostStats.",
+		"stats": "stats are the metrics of the posts, ordered by most recent activity.",
+	},
+	"PostSubscriberChurnRequest": {
+		"@":     "PostSubscriberChurnRequest is a request for the subscriber churn.",
+		"since": "since is the unix timestamp of the start of the period.",
+	},
+	"PostSubscriberEvent": {
+		"@":          "PostSubscriberEvent is an event of a user subscribing or unsubscribing to the local client's posts.",
+		"uid":        "uid is the id of the user.",
+		"subscribed": "subscribed is true if the user subscribed and false if it unsubscribed.",
+		"timestamp":  "timestamp is the unix timestamp of the event.",
+	},
+	"PostSubscriberChurnResponse": {
+		"@":            "PostSubscriberChurnResponse is the response to PostSubscriberChurn.",
+		"subscribed":   "subscribed is the number of times users subscribed during the period.",
+		"unsubscribed": "unsubscribed is the number of times users unsubscribed during the period.",
+		"subscribers":  "subscribers is the current number of subscribers.",
+		"events":       "events are the individual events of the period.",
+	},
+	"ModeratePostCommentRequest": {
+		"@":          "ModeratePostCommentRequest is a request to moderate a comment.",
+		"post_id":    "post_id is the id of the post created by the local client.",
+		"comment_id": "comment_id is the id of the comment.",
+		"action":     "action is one of hide, unhide, flag or unflag.",
+	},
+	"ModeratePostCommentResponse": {
+		"@": "ModeratePostCommentResponse is the response to ModeratePostComment.",
+	},
+	"MutePostCommenterRequest": {
+		"@":    "MutePostCommenterRequest is a request to mute or unmute a commenter.",
+		"uid":  "uid is the id of the commenter.",
+		"mute": "mute is true to mute the commenter and false to unmute it.",
+	},
+	"MutePostCommenterResponse": {
+		"@": "MutePostCommenterResponse is the response to MutePostCommenter.",
+	},
+	"ListMutedPostCommentersRequest": {
+		"@": "ListMutedPostCommentersRequest is a request to list muted commenters.",
+	},
+	"MutedPostCommenter": {
+		"@":     "MutedPostCommenter is a user muted from commenting on local posts.",
+		"uid":   "uid is the id of the user.",
+		"muted": "muted is the unix timestamp of when the user was muted.",
+	},
+	"ListMutedPostCommentersResponse": {
+		"@":          "ListMutedPostCommentersResponse is the response to ListMutedPostCommenters.",
+		"commenters": "commenters are the muted commenters.",
+	},
+	"PinPostRequest": {
+		"@":       "PinPostRequest is a request to pin or unpin a local post.",
+		"post_id": "post_id is the id of the post created by the local client.",
+		"pin":     "pin is true to pin the post and false to unpin it.",
+	},
+	"PinPostResponse": {
+		"@": "PinPostResponse is the response to PinPost.",
+	},
+	"ListPinnedPostsRequest": {
+		"@": "ListPinnedPostsRequest is a request to list the pinned local posts.",
+	},
+	"ListPinnedPostsResponse": {
+		"@":     "ListPinnedPostsResponse is the response to ListPinnedPosts.",
+		"posts": "posts are the pinned posts, in the order they were pinned.",
+	},
+	"PostBookmark": {
+		"@":           "PostBookmark is a local bookmark of a received post or comment.",
+		"post_from":   "post_from is the id of the user from which the post was received.",
+		"post_id":     "post_id is the id of the post.",
+		"comment_id":  "comment_id is the id of the bookmarked comment. It is empty when the post itself is bookmarked.",
+		"title":       "title is the title of the post.",
+		"excerpt":     "excerpt is the text of the bookmarked comment.",
+		"author_nick": "author_nick is the nick of the author of the post or comment.",
+		"tags":        "tags are the tags of the bookmark.",
+		"note":        "note is the note of the bookmark.",
+		"created":     "created is the unix timestamp of when the bookmark was created.",
+	},
+	"BookmarkPostRequest": {
+		"@":          "BookmarkPostRequest is a request to bookmark a post or comment.",
+		"post_from":  "post_from is the id of the user from which the post was received.",
+		"post_id":    "post_id is the id of the post.",
+		"comment_id": "comment_id is the id of the comment to bookmark. If empty, the post itself is bookmarked.",
+		"tags":       "tags are the tags of the bookmark.",
+		"note":       "note is an optional note of the bookmark.",
+	},
+	"BookmarkPostResponse": {
+		"@":        "BookmarkPostResponse is the response to BookmarkPost.",
+		"bookmark": "bookmark is the stored bookmark.",
+	},
+	"RemovePostBookmarkRequest": {
+		"@":          "RemovePostBookmarkRequest is a request to remove a post bookmark.",
+		"post_from":  "post_from is the id of the user from which the post was received.",
+		"post_id":    "post_id is the id of the post.",
+		"comment_id": "comment_id is the id of the bookmarked comment. If empty, the bookmark of the post itself is removed.",
+	},
+	"RemovePostBookmarkResponse": {
+		"@": "RemovePostBookmarkResponse is the response to RemovePostBookmark.",
+	},
+	"ListPostBookmarksRequest": {
+		"@":         "ListPostBookmarksRequest is a query of post bookmarks. Empty fields match all bookmarks.",
+		"tags":      "tags are the tags a bookmark must have (all of them).",
+		"text":      "text is searched in the title, excerpt, note and author nick of the bookmarks.",
+		"post_from": "post_from selects the bookmarks of posts received from a user.",
+		"post_id":   "post_id selects the bookmarks of a single post.",
+		"since":     "since selects bookmarks created after this unix timestamp.",
+		"limit":     "limit is the max number of returned bookmarks.",
+	},
+	"ListPostBookmarksResponse": {
+		"@":         "ListPostBookmarksResponse is the response to ListPostBookmarks.",
+		"bookmarks": "bookmarks are the matching bookmarks, from newest to oldest.",
+	},
 	"TipUserRequest": {
 		"@":            "TipUserRequest is a request to tip a remote user.",
 		"user":         "user is the remote user nick or hex-encoded ID.",
@@ -1529,6 +4991,17 @@ var help_messages = map[string]map[string]string{
 	"TipUserResponse": {
 		"@": "TipUserResponse is the response to a tip user request.",
 	},
+	"EstimateTipFeeRequest": {
+		"@":          "EstimateTipFeeRequest is the request to estimate the fee of a tip.",
+		"user":       "user is the remote user nick or hex-encoded ID.",
+		"dcr_amount": "dcr_amount is the DCR amount of the tip.",
+	},
+	"EstimateTipFeeResponse": {
+		"@":              "EstimateTipFeeResponse is the response to an EstimateTipFee request.",
+		"fee_matoms":     "fee_matoms is the estimated routing fee, in milliatoms.",
+		"max_fee_matoms": "max_fee_matoms is the max routing fee allowed for the tip, in milliatoms. It is -1 if the fee is not limited.",
+		"hops":           "hops is the number of hops in the estimated route.",
+	},
 	"MediateKXRequest": {
 		"@":        "MediateKXRequest is the request to perform a transitive KX with a given user.",
 		"mediator": "mediator is the nick or hex ID of the mediator user (which must already be KX'd with).",
@@ -1559,6 +5032,24 @@ var help_messages = map[string]map[string]string{
 		"invite":       "invite is the data contained in the raw invite.",
 		"invite_key":   "invite_key is the pre-paid invite key string that can be used to fetch the invite from the server.",
 	},
+	"WriteNewInviteBatchRequest": {
+		"@":           "WriteNewInviteBatchRequest is the request to create a batch of prepaid invites.",
+		"labels":      "labels are the labels of the invites to create. One invite is created for each label.",
+		"gc":          "gc is the hex-encoded ID or the alias of the GC in the local client to invite each invitee to after KX.",
+		"fund_budget": "fund_budget is the total amount of funds split equally among the invites. If zero, no funds are included. Amount is in atoms.",
+	},
+	"WriteNewInviteBatchEntry": {
+		"@":            "WriteNewInviteBatchEntry is one of the invites created in a batch.",
+		"label":        "label is the label of the invite.",
+		"invite_bytes": "invite_bytes is the raw invite.",
+		"invite":       "invite is the data contained in the raw invite.",
+		"invite_key":   "invite_key is the pre-paid invite key string that can be used to fetch the invite from the server.",
+		"fund_amount":  "fund_amount is the amount of funds included in the invite, in atoms.",
+	},
+	"WriteNewInviteBatchResponse": {
+		"@":       "WriteNewInviteBatchResponse is the response to a WriteNewInviteBatch request.",
+		"invites": "invites are the created invites.",
+	},
 	"AcceptInviteRequest": {
 		"@":            "AcceptInviteRequest is the request to accept an invite to KX with an user.",
 		"invite_bytes": "invite_bytes is the raw invite.",
@@ -1599,6 +5090,489 @@ var help_messages = map[string]map[string]string{
 		"@":    "UserNickResponse is the response to fetch a user's nick.",
 		"nick": "nick is the nick of the user.",
 	},
+	"MarkReadRequest": {
+		"@":      "MarkReadRequest is the request to mark the messages of a chat as read.",
+		"user":   "user is the nick or hex-encoded ID of the user of a PM chat. Either user or gc must be specified, but not both.",
+		"gc":     "gc is the hex-encoded ID or alias of a GC.",
+		"msg_id": "msg_id is the ID of the last read message of the chat, as received in the msg_id field of RMPrivateMessage (for PMs) or RMGroupMessage (for GCs). If empty, every message of the chat is marked as read.",
+	},
+	"MarkReadResponse": {
+		"@": "MarkReadResponse is the response to a MarkRead request.",
+	},
+	"ReadReceiptsStreamRequest": {
+		"@":            "ReadReceiptsStreamRequest is the request to create a stream that receives events about chats being marked as read.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last received read receipt event. Events received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"ReceivedReadReceipt": {
+		"@":            "ReceivedReadReceipt is the event sent when a chat is marked as read.",
+		"sequence_id":  "sequence_id is an opaque sequential ID.",
+		"uid":          "uid is the ID of the user of a PM chat. Empty for GC chats.",
+		"nick":         "nick is the nick of the user of a PM chat.",
+		"gc":           "gc is the ID of the GC for GC chats. Empty for PM chats.",
+		"gc_alias":     "gc_alias is the local alias of the GC.",
+		"msg_id":       "msg_id is the ID of the last read message of the chat. Empty when the entire chat was marked as read.",
+		"timestamp_ms": "timestamp_ms is the timestamp of when the chat was marked as read, with millisecond precision.",
+	},
+	"EditMessageRequest": {
+		"@":      "EditMessageRequest is the request to edit a sent message.",
+		"user":   "user is the nick or hex-encoded ID of the user the PM was sent to. Must be empty if gc is specified.",
+		"gc":     "gc is the hex-encoded ID or alias of the GC the message was sent to. Must be empty if user is specified.",
+		"msg_id": "msg_id is the ID of the message to edit.",
+		"msg":    "msg is the new content of the message.",
+	},
+	"EditMessageResponse": {
+		"@": "",
+	},
+	"MessageEditsStreamRequest": {
+		"@":            "MessageEditsStreamRequest is the request for a new message edits stream.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last received message edit. Edits received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"ReceivedMessageEdit": {
+		"@":            "ReceivedMessageEdit is the event sent when a remote user edits a message.",
+		"sequence_id":  "sequence_id is an opaque sequential ID.",
+		"uid":          "uid is the ID of the user that sent and edited the message.",
+		"nick":         "nick is the nick or alias of the user.",
+		"gc":           "gc is the ID of the GC for edits of GC messages. Empty for PMs.",
+		"gc_alias":     "gc_alias is the local alias of the GC.",
+		"msg_id":       "msg_id is the ID of the edited message.",
+		"msg":          "msg is the new content of the message.",
+		"timestamp_ms": "timestamp_ms is the server timestamp of the edit with millisecond precision.",
+	},
+	"ReactToMessageRequest": {
+		"@":        "ReactToMessageRequest is the request to react to a message.",
+		"user":     "user is the nick or hex-encoded ID of the user with which the PM was exchanged. Must be empty if gc is specified.",
+		"gc":       "gc is the hex-encoded ID or alias of the GC the message was sent to. Must be empty if user is specified.",
+		"msg_id":   "msg_id is the ID of the message to react to.",
+		"reaction": "reaction is the reaction to the message (usually an emoji).",
+		"remove":   "remove is set to remove a previously sent reaction.",
+	},
+	"ReactToMessageResponse": {
+		"@": "",
+	},
+	"GetMessageReactionsRequest": {
+		"@":      "GetMessageReactionsRequest is the request to fetch the reactions to a message.",
+		"msg_id": "msg_id is the ID of the message.",
+	},
+	"MessageReaction": {
+		"@":        "MessageReaction is a reaction to a message and the list of users that sent it.",
+		"reaction": "reaction is the reaction (usually an emoji).",
+		"uids":     "uids is the list of users (including the local client) that sent this reaction.",
+	},
+	"MessageReactions": {
+		"@":         "MessageReactions is the aggregated list of reactions to a message.",
+		"msg_id":    "msg_id is the ID of the message.",
+		"gc":        "gc is the ID of the GC where the message was sent. Empty for PMs.",
+		"reactions": "reactions is the list of reactions to the message.",
+	},
+	"MessageReactionsStreamRequest": {
+		"@":            "MessageReactionsStreamRequest is the request for a new message reactions stream.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last received message reaction. Reactions received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"ReceivedMessageReaction": {
+		"@":            "ReceivedMessageReaction is the event sent when a remote user reacts to a message.",
+		"sequence_id":  "sequence_id is an opaque sequential ID.",
+		"uid":          "uid is the ID of the user that sent the reaction.",
+		"nick":         "nick is the nick or alias of the user.",
+		"gc":           "gc is the ID of the GC for reactions to GC messages. Empty for PMs.",
+		"gc_alias":     "gc_alias is the local alias of the GC.",
+		"msg_id":       "msg_id is the ID of the message reacted to.",
+		"reaction":     "reaction is the reaction sent by the user.",
+		"removed":      "removed is true if the user removed the reaction.",
+		"reactions":    "reactions is the updated aggregated list of reactions to the message.",
+		"timestamp_ms": "timestamp_ms is the server timestamp of the reaction with millisecond precision.",
+	},
+	"GetThreadRequest": {
+		"@":      "GetThreadRequest is the request to fetch a thread of replies.",
+		"msg_id": "msg_id is the ID of a message in the thread.",
+	},
+	"ThreadReply": {
+		"@":            "ThreadReply is a reply to a message in a thread.",
+		"msg_id":       "msg_id is the ID of the reply.",
+		"reply_to":     "reply_to is the ID of the message being replied to.",
+		"uid":          "uid is the ID of the user that sent the reply.",
+		"msg":          "msg is the content of the reply.",
+		"timestamp_ms": "timestamp_ms is the timestamp of the reply with millisecond precision.",
+	},
+	"MessageThread": {
+		"@":           "MessageThread is a thread of replies to a message.",
+		"root_msg_id": "root_msg_id is the ID of the message that started the thread.",
+		"gc":          "gc is the ID of the GC where the thread happened. Empty for PMs.",
+		"replies":     "replies is the list of replies in the thread.",
+	},
+	"SearchMessagesRequest": {
+		"@":               "SearchMessagesRequest is the request to search the message logs.",
+		"query":           "query is the list of space separated terms to search for.",
+		"user":            "user restricts the search to PMs exchanged with this user (nick, alias or hex-encoded ID).",
+		"gc":              "gc restricts the search to messages of this GC (hex-encoded ID or alias).",
+		"since_timestamp": "since_timestamp restricts the search to messages sent at or after this unix timestamp (in seconds).",
+		"until_timestamp": "until_timestamp restricts the search to messages sent at or before this unix timestamp (in seconds).",
+		"limit":           "limit is the max number of results returned. Zero means no limit.",
+	},
+	"MessageSearchResult": {
+		"@":         "MessageSearchResult is a message found by a search.",
+		"uid":       "uid is the ID of the user the PM was exchanged with. Empty for GC messages.",
+		"gc":        "gc is the ID of the GC where the message was sent. Empty for PMs.",
+		"from":      "from is the nick of the sender of the message.",
+		"msg":       "msg is the message.",
+		"timestamp": "timestamp is the unix timestamp (in seconds) of the message.",
+	},
+	"SearchMessagesResponse": {
+		"@":       "SearchMessagesResponse is the response to a message search.",
+		"results": "results is the list of found messages.",
+	},
+	"ExportChatRequest": {
+		"@":      "ExportChatRequest is the request to export a conversation.",
+		"user":   "user is the nick, alias or hex-encoded ID of the user to export the PMs of.",
+		"gc":     "gc is the hex-encoded ID or alias of the GC to export.",
+		"format": "format is the export format. Either json or html.",
+	},
+	"ExportChatResponse": {
+		"@":    "ExportChatResponse is the response to a conversation export.",
+		"data": "data is the exported conversation in the requested format.",
+	},
+	"ArchiveChatRequest": {
+		"@":    "ArchiveChatRequest is the request to archive or unarchive a chat.",
+		"user": "user is the nick, alias or hex-encoded ID of the user.",
+		"gc":   "gc is the hex-encoded ID or alias of the GC.",
+	},
+	"ArchiveChatResponse": {
+		"@": "ArchiveChatResponse is the response to an archive or unarchive request.",
+	},
+	"ListArchivedChatsRequest": {
+		"@": "ListArchivedChatsRequest is the request to list the archived chats.",
+	},
+	"ArchivedChat": {
+		"@":           "ArchivedChat is an archived PM conversation or GC.",
+		"id":          "id is the ID of the user or GC.",
+		"is_gc":       "is_gc is true if the chat is a GC.",
+		"name":        "name is the nick of the user or the alias of the GC.",
+		"archived_at": "archived_at is the unix timestamp of when the chat was archived.",
+	},
+	"ListArchivedChatsResponse": {
+		"@":     "ListArchivedChatsResponse is the list of archived chats.",
+		"chats": "",
+	},
+	"SendVoiceMessageRequest": {
+		"@":           "SendVoiceMessageRequest is the request to send a voice message.",
+		"user":        "user is the nick, alias or hex-encoded ID of the user.",
+		"gc":          "gc is the hex-encoded ID or alias of the GC.",
+		"audio":       "audio is the Opus-encoded audio, in an Ogg container.",
+		"duration_ms": "duration_ms is the duration of the audio in milliseconds. If zero, it is determined from the audio.",
+	},
+	"SendVoiceMessageResponse": {
+		"@":      "SendVoiceMessageResponse is the response to sending a voice message.",
+		"msg_id": "msg_id is the ID of the sent message.",
+	},
+	"GetDraftRequest": {
+		"@":    "GetDraftRequest is the request to fetch the draft of a chat.",
+		"user": "user is the nick, alias or hex-encoded ID of the user.",
+		"gc":   "gc is the hex-encoded ID or alias of the GC.",
+	},
+	"GetDraftResponse": {
+		"@":    "GetDraftResponse is the draft of a chat.",
+		"text": "text is the text of the draft. Empty if the chat has no draft.",
+	},
+	"SetDraftRequest": {
+		"@":    "SetDraftRequest is the request to store the draft of a chat.",
+		"user": "user is the nick, alias or hex-encoded ID of the user.",
+		"gc":   "gc is the hex-encoded ID or alias of the GC.",
+		"text": "text is the text of the draft.",
+	},
+	"SetDraftResponse": {
+		"@": "SetDraftResponse is the response to storing a draft.",
+	},
+	"ListDraftsRequest": {
+		"@": "ListDraftsRequest is the request to list the drafts of all chats.",
+	},
+	"Draft": {
+		"@":            "Draft is the unsent draft of a PM conversation or GC.",
+		"id":           "id is the ID of the user or GC.",
+		"is_gc":        "is_gc is true if the chat is a GC.",
+		"name":         "name is the nick of the user or the alias of the GC.",
+		"text":         "text is the text of the draft.",
+		"last_updated": "last_updated is the unix timestamp of when the draft was last updated.",
+	},
+	"ListDraftsResponse": {
+		"@":      "ListDraftsResponse is the list of drafts.",
+		"drafts": "",
+	},
+	"BroadcastList": {
+		"@":       "BroadcastList is a locally defined list of users to broadcast messages to.",
+		"name":    "name is the name of the list.",
+		"members": "members are the nicks, aliases or hex-encoded IDs of the members of the list. When listing, these are the hex-encoded IDs.",
+	},
+	"StoreBroadcastListResponse": {
+		"@": "StoreBroadcastListResponse is the response to storing a broadcast list.",
+	},
+	"RemoveBroadcastListRequest": {
+		"@":    "RemoveBroadcastListRequest is the request to remove a broadcast list.",
+		"name": "name is the name of the list.",
+	},
+	"RemoveBroadcastListResponse": {
+		"@": "RemoveBroadcastListResponse is the response to removing a broadcast list.",
+	},
+	"ListBroadcastListsRequest": {
+		"@": "ListBroadcastListsRequest is the request to list the broadcast lists.",
+	},
+	"ListBroadcastListsResponse": {
+		"@":     "ListBroadcastListsResponse is the list of broadcast lists.",
+		"lists": "",
+	},
+	"SendBroadcastRequest": {
+		"@":    "SendBroadcastRequest is the request to send a message to a broadcast list.",
+		"list": "list is the name of the broadcast list.",
+		"msg":  "msg is the message to send.",
+	},
+	"GetBroadcastStatusRequest": {
+		"@":  "GetBroadcastStatusRequest is the request for the status of a broadcast.",
+		"id": "id is the ID of the broadcast.",
+	},
+	"BroadcastMemberStatus": {
+		"@":      "BroadcastMemberStatus is the delivery status of the PM sent to a member of a broadcast list.",
+		"uid":    "uid is the ID of the member.",
+		"msg_id": "msg_id is the ID of the PM sent to the member.",
+		"status": "status is the delivery status of the PM (queued, paid, sent, acked, delivered or failed).",
+		"error":  "error is the error that prevented the PM from being sent, if any.",
+	},
+	"BroadcastStatus": {
+		"@":         "BroadcastStatus is the aggregated delivery status of a broadcast.",
+		"id":        "id is the ID of the broadcast.",
+		"list":      "list is the name of the broadcast list.",
+		"timestamp": "timestamp is the unix timestamp of when the broadcast was sent.",
+		"members":   "members is the delivery status for each member of the list.",
+	},
+	"ContactGroup": {
+		"@":       "ContactGroup is a locally defined, named group of users on which batch operations may be performed.",
+		"name":    "name is the name of the group.",
+		"members": "members are the nicks, aliases or hex-encoded IDs of the members of the group. When listing, these are the hex-encoded IDs.",
+	},
+	"StoreContactGroupResponse": {
+		"@": "StoreContactGroupResponse is the response to storing a contact group.",
+	},
+	"RemoveContactGroupRequest": {
+		"@":    "RemoveContactGroupRequest is the request to remove a contact group.",
+		"name": "name is the name of the group.",
+	},
+	"RemoveContactGroupResponse": {
+		"@": "RemoveContactGroupResponse is the response to removing a contact group.",
+	},
+	"ListContactGroupsRequest": {
+		"@": "ListContactGroupsRequest is the request to list the contact groups.",
+	},
+	"ListContactGroupsResponse": {
+		"@":      "ListContactGroupsResponse is the list of contact groups.",
+		"groups": "",
+	},
+	"PMContactGroupRequest": {
+		"@":     "PMContactGroupRequest is the request to send a message to the members of a contact group.",
+		"group": "group is the name of the contact group.",
+		"msg":   "msg is the message to send.",
+	},
+	"TipContactGroupRequest": {
+		"@":            "TipContactGroupRequest is the request to tip the members of a contact group.",
+		"group":        "group is the name of the contact group.",
+		"dcr_amount":   "dcr_amount is the DCR amount to send as tip to each member.",
+		"max_attempts": "max_attempts is the number of max attempts to try to send each tip.",
+	},
+	"TipGCMembersRequest": {
+		"@":            "TipGCMembersRequest is the request to split a tip across members of a GC.",
+		"gc":           "gc is the name or ID of the GC.",
+		"dcr_amount":   "dcr_amount is the total DCR amount split across the members.",
+		"members":      "members are the nicks or IDs of the members to tip. If empty, the amount is split across all members of the GC with which the local client has KX'd.",
+		"max_attempts": "max_attempts is the number of max attempts to try to send each tip.",
+		"announce":     "announce is whether to send a summary message to the GC once the tips to all members complete or fail.",
+	},
+	"TipGCMembersResponse": {
+		"@":           "TipGCMembersResponse is the response to a TipGCMembers request.",
+		"share_atoms": "share_atoms is the amount (in atoms) tipped to each member.",
+		"members":     "members are the IDs of the members being tipped.",
+	},
+	"ListInvoicesRequest": {
+		"@":         "ListInvoicesRequest is the request to list invoices.",
+		"only_open": "only_open is whether to only list the invoices that may still be paid.",
+	},
+	"Invoice": {
+		"@":           "Invoice is an invoice generated or received by the local client for a tip.",
+		"invoice":     "invoice is the encoded invoice.",
+		"user":        "user is the ID of the remote user that pays or generated the invoice.",
+		"generated":   "generated is true for invoices generated by the local client (to receive a tip) and false for invoices received from the remote user (to send a tip).",
+		"milli_atoms": "milli_atoms is the amount of the invoice.",
+		"created":     "created is the unix timestamp of when the invoice was created.",
+		"status":      "status is one of open, settled, expired or canceled.",
+		"label":       "label is the local label of the invoice.",
+		"memo":        "memo is the local memo of the invoice.",
+	},
+	"ListInvoicesResponse": {
+		"@":        "ListInvoicesResponse is the response to a ListInvoices request.",
+		"invoices": "",
+	},
+	"InvoiceStatusRequest": {
+		"@":       "InvoiceStatusRequest is the request to check the status of an invoice.",
+		"invoice": "",
+	},
+	"CancelInvoiceRequest": {
+		"@":       "CancelInvoiceRequest is the request to cancel an invoice.",
+		"invoice": "",
+	},
+	"CancelInvoiceResponse": {
+		"@": "CancelInvoiceResponse is the response to a CancelInvoice request.",
+	},
+	"SetInvoiceLabelRequest": {
+		"@":       "SetInvoiceLabelRequest is the request to set the label and memo of an invoice. Setting both to empty strings removes them.",
+		"invoice": "",
+		"label":   "",
+		"memo":    "",
+	},
+	"SetInvoiceLabelResponse": {
+		"@": "SetInvoiceLabelResponse is the response to a SetInvoiceLabel request.",
+	},
+	"PaymentReportRequest": {
+		"@":              "PaymentReportRequest is the request for a payment report.",
+		"start":          "start is the unix timestamp of the start of the report period.",
+		"end":            "end is the unix timestamp of the end of the report period. If zero, the current time is used.",
+		"window_seconds": "window_seconds is the duration of the windows in which the payments are also aggregated. If zero, no windows are returned.",
+	},
+	"PaymentTotals": {
+		"@":                "PaymentTotals are the totals of payments, grouped by category. Amounts are in milli-atoms.",
+		"tips_sent":        "tips_sent and tips_received are the totals of tips.",
+		"tips_received":    "",
+		"content_sent":     "content_sent and content_received are the totals of payments for content (file downloads).",
+		"content_received": "",
+		"other_sent":       "other_sent and other_received are the totals of other payments, such as the fees for sending messages.",
+		"other_received":   "",
+		"pay_fees":         "pay_fees is the total amount of routing fees paid.",
+	},
+	"UserPaymentTotals": {
+		"@":      "UserPaymentTotals are the payment totals related to a user.",
+		"uid":    "uid is the id of the user.",
+		"nick":   "nick is the nick of the user, if known.",
+		"totals": "",
+	},
+	"PaymentReportWindow": {
+		"@":      "PaymentReportWindow are the payment totals of a time window.",
+		"start":  "start is the unix timestamp of the start of the window.",
+		"totals": "",
+	},
+	"RebalanceChannelsRequest": {
+		"@":              "RebalanceChannelsRequest is the request to rebalance two channels.",
+		"out_channel":    "out_channel is the channel point (or a unique prefix of it) of the channel through which funds are sent. Its outbound capacity decreases.",
+		"in_channel":     "in_channel is the channel point (or a unique prefix of it) of the channel through which funds return. Its outbound capacity increases.",
+		"amount_matoms":  "amount_matoms is the amount to move between the channels.",
+		"max_fee_matoms": "max_fee_matoms is the max routing fee to pay. If zero, the limit of the payment fee policy of the client is used.",
+	},
+	"RebalanceChannelsResponse": {
+		"@":              "RebalanceChannelsResponse is the response to a RebalanceChannels request.",
+		"out_chan_point": "out_chan_point and in_chan_point are the full channel points of the channels.",
+		"in_chan_point":  "",
+		"amount_matoms":  "amount_matoms is the amount moved between the channels.",
+		"fee_matoms":     "fee_matoms is the routing fee paid.",
+		"hops":           "hops is the number of hops in the route of the payment.",
+	},
+	"PaymentReportResponse": {
+		"@":       "PaymentReportResponse is the response to a PaymentReport request.",
+		"totals":  "totals are the totals of the report period.",
+		"users":   "users are the totals per user, ordered by most received.",
+		"windows": "windows are the totals of each window of the period.",
+	},
+	"InviteContactGroupToGCRequest": {
+		"@":     "InviteContactGroupToGCRequest is the request to invite the members of a contact group to a GC.",
+		"group": "group is the name of the contact group.",
+		"gc":    "gc is the name or hex-encoded ID of the GC.",
+	},
+	"ApplyContactGroupFilterRequest": {
+		"@":      "ApplyContactGroupFilterRequest is the request to apply a content filter rule to the members of a contact group.",
+		"group":  "group is the name of the contact group.",
+		"filter": "filter is the rule to apply. Its id and uid are ignored.",
+	},
+	"ContactGroupMemberResult": {
+		"@":      "ContactGroupMemberResult is the result of a batch operation on a member of a contact group.",
+		"uid":    "uid is the ID of the member.",
+		"status": "status is the result of the operation (succeeded, skipped or failed).",
+		"error":  "error is the error that prevented the operation, if any.",
+	},
+	"ContactGroupResult": {
+		"@":       "ContactGroupResult is the result of a batch operation on a contact group.",
+		"group":   "group is the name of the contact group.",
+		"members": "members is the result for each member of the group.",
+	},
+	"GetAutoReplyRequest": {
+		"@": "",
+	},
+	"AutoReplyConfig": {
+		"@":                "AutoReplyConfig is the config for automatic replies to PMs.",
+		"enabled":          "enabled is true when automatic replies are sent.",
+		"message":          "message is the automatic reply.",
+		"interval_seconds": "interval_seconds is the minimum interval between automatic replies sent to the same user. If zero, a default interval of 24 hours is used.",
+	},
+	"SetAutoReplyResponse": {
+		"@": "",
+	},
+	"ContentFilter": {
+		"@":                  "ContentFilter is a rule used to filter received messages.",
+		"id":                 "id is the ID of the rule.",
+		"uid":                "uid restricts the rule to messages from this user.",
+		"gc":                 "gc restricts the rule to messages in this GC.",
+		"skip_pms":           "skip_pms is true if the rule does not apply to PMs.",
+		"skip_gcms":          "skip_gcms is true if the rule does not apply to GC messages.",
+		"skip_posts":         "skip_posts is true if the rule does not apply to posts.",
+		"skip_post_comments": "skip_post_comments is true if the rule does not apply to post comments.",
+		"regexp":             "regexp is the regular expression matched against the message.",
+		"embed_type":         "embed_type restricts the rule to messages with an embed whose mime type starts with this value.",
+		"action":             "action is the action taken on matching messages: hide (the default), mute or flag.",
+	},
+	"ListContentFiltersRequest": {
+		"@": "",
+	},
+	"ListContentFiltersResponse": {
+		"@":       "ListContentFiltersResponse is the list of content filter rules.",
+		"filters": "",
+	},
+	"RemoveContentFilterRequest": {
+		"@":  "RemoveContentFilterRequest is the request to remove a content filter.",
+		"id": "id is the ID of the rule to remove.",
+	},
+	"RemoveContentFilterResponse": {
+		"@": "",
+	},
+	"RatchetHealthStreamRequest": {
+		"@":            "RatchetHealthStreamRequest is the request for a new ratchet health event stream.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last received ratchet health event. Events received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"RatchetHealthEvent": {
+		"@":                 "RatchetHealthEvent is an event about the state of the ratchet with a remote user.",
+		"sequence_id":       "sequence_id is an opaque sequential ID.",
+		"uid":               "uid is the ID of the remote user.",
+		"nick":              "nick is the nick of the remote user.",
+		"type":              "type is the type of event.",
+		"handshake_stage":   "handshake_stage is the stage of the handshake (SYN, SYNACK or ACK) for RATCHET_HEALTH_HANDSHAKE_STAGE events.",
+		"unacked_rms":       "unacked_rms is the number of RMs encrypted for the user that have not yet been acked by the server at the time of the event.",
+		"last_encrypt_time": "last_encrypt_time is the unix timestamp of the last time a message was encrypted with the ratchet.",
+		"last_decrypt_time": "last_decrypt_time is the unix timestamp of the last time a message was decrypted with the ratchet.",
+		"timestamp_ms":      "timestamp_ms is the timestamp of the event, with millisecond precision.",
+	},
+	"RatchetHealthRequest": {
+		"@":    "RatchetHealthRequest is the request for ratchet diagnostics.",
+		"user": "user is the hex-encoded ID or nick of the target user. If empty, the diagnostics of all users are returned.",
+	},
+	"RatchetHealthReport": {
+		"@":                      "RatchetHealthReport is the diagnostic report of the ratchet with a remote user.",
+		"uid":                    "uid is the ID of the remote user.",
+		"nick":                   "nick is the nick of the remote user.",
+		"unacked_rms":            "unacked_rms is the number of RMs encrypted for the user that have not yet been acked by the server.",
+		"last_encrypt_time":      "last_encrypt_time is the unix timestamp of the last time a message was encrypted with the ratchet.",
+		"last_decrypt_time":      "last_decrypt_time is the unix timestamp of the last time a message was decrypted with the ratchet.",
+		"last_handshake_attempt": "last_handshake_attempt is the unix timestamp of the last handshake attempted with the user.",
+		"draining":               "draining is true when the ratchet is still listening for messages sent on the previous receive chain.",
+		"saved_keys":             "saved_keys is the number of keys saved for missing messages.",
+		"keys_remaining":         "keys_remaining is the approximate number of further messages that may be missed before messages from the user can not be decrypted.",
+		"reset_in_progress":      "reset_in_progress is true when a ratchet reset with the user was requested and has not yet completed.",
+		"probably_broken":        "probably_broken is true when the ratchet is probably broken and a reset is suggested.",
+		"broken_reason":          "broken_reason describes why the ratchet is probably broken.",
+	},
+	"RatchetHealthResponse": {
+		"@":       "RatchetHealthResponse is the response to a RatchetHealth request.",
+		"reports": "reports are the diagnostic reports of the ratchets.",
+	},
 	"KickFromGCRequest": {
 		"@":      "KickFromGCRequest is the request to kick an user from a GC.",
 		"gc":     "gc is the hex-encoded ID or alias of the target GC.",
@@ -1616,6 +5590,275 @@ var help_messages = map[string]map[string]string{
 		"@":  "GetGCResponse is the response to a request to get GC details.",
 		"gc": "gc is the gc definition.",
 	},
+	"ModifyGCModeratorsRequest": {
+		"@":          "ModifyGCModeratorsRequest is the request to modify the moderators of a GC.",
+		"gc":         "gc is the hex-encoded ID or alias of the target GC.",
+		"moderators": "moderators is the full new list of hex-encoded IDs or nicks of the moderators of the GC.",
+		"reason":     "reason is an optional reason for the change.",
+	},
+	"ModifyGCModeratorsResponse": {
+		"@": "ModifyGCModeratorsResponse is the response to a ModifyGCModerators request.",
+	},
+	"SetGCRolePermsRequest": {
+		"@":     "SetGCRolePermsRequest is the request to set the permissions of a GC role.",
+		"gc":    "gc is the hex-encoded ID or alias of the target GC.",
+		"role":  "role is either GC_ROLE_ADMIN or GC_ROLE_MODERATOR.",
+		"perms": "perms is the bitmask of GCPermission values granted to the role.",
+	},
+	"SetGCRolePermsResponse": {
+		"@": "SetGCRolePermsResponse is the response to a SetGCRolePerms request.",
+	},
+	"PinGCMessageRequest": {
+		"@":      "PinGCMessageRequest is the request to pin or unpin a GC message.",
+		"gc":     "gc is the hex-encoded ID or alias of the target GC.",
+		"msg_id": "msg_id is the ID of the message to pin.",
+		"unpin":  "unpin is true if the message should be unpinned instead.",
+	},
+	"PinGCMessageResponse": {
+		"@": "PinGCMessageResponse is the response to a PinGCMessage request.",
+	},
+	"BanFromGCRequest": {
+		"@":      "BanFromGCRequest is the request to ban an user from a GC.",
+		"gc":     "gc is the hex-encoded ID or alias of the target GC.",
+		"user":   "user is the hex-encoded ID or nick of the target user.",
+		"reason": "reason is an optional reason for the ban.",
+	},
+	"BanFromGCResponse": {
+		"@": "BanFromGCResponse is the response to a BanFromGC request.",
+	},
+	"UnbanFromGCRequest": {
+		"@":    "UnbanFromGCRequest is the request to revoke the ban of an user from a GC.",
+		"gc":   "gc is the hex-encoded ID or alias of the target GC.",
+		"user": "user is the hex-encoded ID or nick of the target user.",
+	},
+	"UnbanFromGCResponse": {
+		"@": "UnbanFromGCResponse is the response to an UnbanFromGC request.",
+	},
+	"ListGCBansRequest": {
+		"@":  "ListGCBansRequest is the request to list the users banned from a GC.",
+		"gc": "gc is the hex-encoded ID or alias of the target GC.",
+	},
+	"ListGCBansResponse": {
+		"@":      "ListGCBansResponse is the response to a ListGCBans request.",
+		"banned": "banned is the list of banned users.",
+	},
+	"GCModLogRequest": {
+		"@":  "GCModLogRequest is the request to fetch the moderation log of a GC.",
+		"gc": "gc is the hex-encoded ID or alias of the target GC.",
+	},
+	"GCModLogEntry": {
+		"@":         "GCModLogEntry is an entry of the moderation log of a GC.",
+		"timestamp": "timestamp is the unix timestamp of when the action was recorded.",
+		"actor":     "actor is the ID of the user that took the action.",
+		"action":    "action is the type of action (kick, ban, unban, addadmin, etc).",
+		"target":    "target is the ID of the user targeted by the action, if any.",
+		"details":   "details are additional details about the action.",
+		"reason":    "reason is the reason given for the action.",
+	},
+	"GCModLogResponse": {
+		"@":       "GCModLogResponse is the response to a GCModLog request.",
+		"entries": "entries is the list of log entries, from oldest to newest.",
+	},
+	"CreateGCInviteLinkRequest": {
+		"@":              "CreateGCInviteLinkRequest is the request to create a GC invite link.",
+		"gc":             "gc is the hex-encoded ID or alias of the target GC.",
+		"expiry_seconds": "expiry_seconds is the number of seconds after which the link expires. Zero means the link does not expire.",
+		"max_uses":       "max_uses is the max number of users that may join with the link. Zero means unlimited uses.",
+	},
+	"CreateGCInviteLinkResponse": {
+		"@":    "CreateGCInviteLinkResponse is the response to a CreateGCInviteLink request.",
+		"id":   "id is the ID of the new link.",
+		"link": "link is the encoded link, which may be shared with other users.",
+	},
+	"RevokeGCInviteLinkRequest": {
+		"@":  "RevokeGCInviteLinkRequest is the request to revoke a GC invite link.",
+		"id": "id is the ID of the link to revoke.",
+	},
+	"RevokeGCInviteLinkResponse": {
+		"@": "RevokeGCInviteLinkResponse is the response to a RevokeGCInviteLink request.",
+	},
+	"ListGCInviteLinksRequest": {
+		"@":  "ListGCInviteLinksRequest is the request to list GC invite links.",
+		"gc": "gc is the optional hex-encoded ID or alias of a GC to filter the links.",
+	},
+	"GCInviteLinkInfo": {
+		"@":        "GCInviteLinkInfo is the information about a GC invite link created by the local client.",
+		"id":       "id is the ID of the link.",
+		"gc":       "gc is the ID of the GC.",
+		"created":  "created is the unix timestamp of when the link was created.",
+		"expires":  "expires is the unix timestamp of when the link expires or zero.",
+		"max_uses": "max_uses is the max number of uses of the link or zero.",
+		"used_by":  "used_by is the list of users that joined the GC with the link.",
+		"revoked":  "revoked is true if the link was revoked.",
+	},
+	"ListGCInviteLinksResponse": {
+		"@":     "ListGCInviteLinksResponse is the response to a ListGCInviteLinks request.",
+		"links": "links is the list of invite links.",
+	},
+	"RedeemGCInviteLinkRequest": {
+		"@":    "RedeemGCInviteLinkRequest is the request to join a GC using an invite link.",
+		"link": "link is the encoded invite link.",
+	},
+	"RedeemGCInviteLinkResponse": {
+		"@": "RedeemGCInviteLinkResponse is the response to a RedeemGCInviteLink request.",
+	},
+	"GCRetentionPolicy": {
+		"@":            "GCRetentionPolicy is the local message retention policy of a GC.",
+		"max_age_days": "max_age_days is the max age (in days) of retained messages. Zero means messages are not pruned based on their age.",
+		"max_messages": "max_messages is the max number of retained messages. Zero means messages are not pruned based on their number.",
+	},
+	"SetGCRetentionRequest": {
+		"@":      "SetGCRetentionRequest is the request to set the retention policy of a GC.",
+		"gc":     "gc is the hex-encoded ID or alias of the target GC.",
+		"policy": "policy is the new retention policy. An empty policy disables pruning.",
+	},
+	"SetGCRetentionResponse": {
+		"@":      "SetGCRetentionResponse is the response to a SetGCRetention request.",
+		"pruned": "pruned is the number of messages pruned after setting the policy.",
+	},
+	"GetGCRetentionRequest": {
+		"@":  "GetGCRetentionRequest is the request to get the retention policy of a GC.",
+		"gc": "gc is the hex-encoded ID or alias of the target GC.",
+	},
+	"GetGCRetentionResponse": {
+		"@":      "GetGCRetentionResponse is the response to a GetGCRetention request.",
+		"policy": "policy is the retention policy of the GC.",
+	},
+	"SetGCSlowModeRequest": {
+		"@":                "SetGCSlowModeRequest is the request to set the slow mode interval of a GC.",
+		"gc":               "gc is the hex-encoded ID or alias of the target GC.",
+		"interval_seconds": "interval_seconds is the min interval between messages of each member. Zero disables slow mode.",
+	},
+	"SetGCSlowModeResponse": {
+		"@": "SetGCSlowModeResponse is the response to a SetGCSlowMode request.",
+	},
+	"UpdateGCMetadataRequest": {
+		"@":                 "UpdateGCMetadataRequest is the request to update the metadata of a GC. Empty fields are not modified.",
+		"gc":                "gc is the hex-encoded ID or alias of the target GC.",
+		"topic":             "topic is the new topic line.",
+		"clear_topic":       "clear_topic removes the current topic. Cannot be set if topic is also specified.",
+		"description":       "description is the new markdown description.",
+		"clear_description": "clear_description removes the current description. Cannot be set if description is also specified.",
+		"avatar":            "avatar is the new avatar image.",
+		"clear_avatar":      "clear_avatar removes the current avatar. Cannot be set if avatar is also specified.",
+	},
+	"UpdateGCMetadataResponse": {
+		"@": "UpdateGCMetadataResponse is the response to an UpdateGCMetadata request.",
+	},
+	"GCChannel": {
+		"@":    "GCChannel is a named channel inside a GC.",
+		"id":   "id is the ID of the channel.",
+		"name": "name is the name of the channel.",
+	},
+	"CreateGCChannelRequest": {
+		"@":    "CreateGCChannelRequest is the request to create a GC channel.",
+		"gc":   "gc is the hex-encoded ID or alias of the target GC.",
+		"name": "name is the name of the new channel.",
+	},
+	"CreateGCChannelResponse": {
+		"@":       "CreateGCChannelResponse is the response to a CreateGCChannel request.",
+		"channel": "channel is the new channel.",
+	},
+	"RemoveGCChannelRequest": {
+		"@":       "RemoveGCChannelRequest is the request to remove a GC channel.",
+		"gc":      "gc is the hex-encoded ID or alias of the target GC.",
+		"channel": "channel is the ID of the channel to remove.",
+	},
+	"RemoveGCChannelResponse": {
+		"@": "RemoveGCChannelResponse is the response to a RemoveGCChannel request.",
+	},
+	"ListGCChannelsRequest": {
+		"@":  "ListGCChannelsRequest is the request to list the channels of a GC.",
+		"gc": "gc is the hex-encoded ID or alias of the target GC.",
+	},
+	"ListGCChannelsResponse": {
+		"@":        "ListGCChannelsResponse is the response to a ListGCChannels request.",
+		"channels": "channels is the list of channels of the GC.",
+	},
+	"GCChannelHistoryRequest": {
+		"@":         "GCChannelHistoryRequest is the request to fetch the logged messages of a GC channel.",
+		"gc":        "gc is the hex-encoded ID or alias of the target GC.",
+		"channel":   "channel is the ID of the channel.",
+		"page_size": "page_size is the max number of messages to return.",
+		"page_num":  "page_num is the page to return, counting from the most recent messages.",
+	},
+	"GCChannelHistoryEntry": {
+		"@":         "GCChannelHistoryEntry is a logged message of a GC channel.",
+		"from":      "from is the nick of the sender.",
+		"message":   "message is the content of the message.",
+		"timestamp": "timestamp is the unix timestamp of the message.",
+	},
+	"GCChannelHistoryResponse": {
+		"@":        "GCChannelHistoryResponse is the response to a GCChannelHistory request.",
+		"messages": "messages are the logged messages, from oldest to newest.",
+	},
+	"SetGCJoinPolicyRequest": {
+		"@":                "SetGCJoinPolicyRequest is the request to set the join policy of a GC.",
+		"gc":               "gc is the hex-encoded ID or alias of the target GC.",
+		"max_members":      "max_members is the max number of members of the GC. Zero removes the limit.",
+		"require_approval": "require_approval is true if requests to join the GC through invite links must be approved.",
+	},
+	"SetGCJoinPolicyResponse": {
+		"@": "SetGCJoinPolicyResponse is the response to a SetGCJoinPolicy request.",
+	},
+	"ListGCJoinRequestsRequest": {
+		"@":  "ListGCJoinRequestsRequest is the request to list pending GC join requests.",
+		"gc": "gc is the optional hex-encoded ID or alias of a GC to filter the requests.",
+	},
+	"GCJoinRequest": {
+		"@":        "GCJoinRequest is a pending request to join a GC.",
+		"gc":       "gc is the ID of the GC.",
+		"uid":      "uid is the ID of the user requesting to join the GC.",
+		"link_id":  "link_id is the ID of the invite link used in the request.",
+		"received": "received is the unix timestamp of when the request was received.",
+	},
+	"ListGCJoinRequestsResponse": {
+		"@":        "ListGCJoinRequestsResponse is the response to a ListGCJoinRequests request.",
+		"requests": "requests is the list of pending join requests.",
+	},
+	"ApproveGCJoinRequestRequest": {
+		"@":   "ApproveGCJoinRequestRequest is the request to approve a GC join request.",
+		"gc":  "gc is the hex-encoded ID or alias of the target GC.",
+		"uid": "uid is the ID of the user that requested to join the GC.",
+	},
+	"ApproveGCJoinRequestResponse": {
+		"@": "ApproveGCJoinRequestResponse is the response to an ApproveGCJoinRequest request.",
+	},
+	"DenyGCJoinRequestRequest": {
+		"@":   "DenyGCJoinRequestRequest is the request to deny a GC join request.",
+		"gc":  "gc is the hex-encoded ID or alias of the target GC.",
+		"uid": "uid is the ID of the user that requested to join the GC.",
+	},
+	"DenyGCJoinRequestResponse": {
+		"@": "DenyGCJoinRequestResponse is the response to a DenyGCJoinRequest request.",
+	},
+	"SetGCTipLeaderboardRequest": {
+		"@":       "SetGCTipLeaderboardRequest is the request to enable or disable the tipping leaderboard of a GC.",
+		"gc":      "gc is the hex-encoded ID or alias of the target GC.",
+		"enabled": "enabled is whether the leaderboard is enabled.",
+	},
+	"SetGCTipLeaderboardResponse": {
+		"@": "SetGCTipLeaderboardResponse is the response to a SetGCTipLeaderboard request.",
+	},
+	"GCTipLeaderboardRequest": {
+		"@":     "GCTipLeaderboardRequest is the request for the tipping leaderboard of a GC.",
+		"gc":    "gc is the hex-encoded ID or alias of the target GC.",
+		"since": "since is the unix timestamp of the oldest tip included in the leaderboard. Zero includes every recorded tip.",
+	},
+	"GCTipLeaderboardEntry": {
+		"@":               "GCTipLeaderboardEntry is the total of the tips sent and received by a GC member.",
+		"uid":             "uid is the ID of the member.",
+		"nick":            "nick is the nick of the member, if known.",
+		"sent_matoms":     "sent_matoms is the total amount tipped by the member in milli-atoms.",
+		"sent_count":      "sent_count is the number of tips made by the member.",
+		"received_matoms": "received_matoms is the total amount received by the member in milli-atoms.",
+		"received_count":  "received_count is the number of tips received by the member.",
+	},
+	"GCTipLeaderboardResponse": {
+		"@":       "GCTipLeaderboardResponse is the response to a GCTipLeaderboard request.",
+		"enabled": "enabled is whether the leaderboard of the GC is enabled.",
+		"entries": "entries are the members that sent or received tips, sorted by amount sent.",
+	},
 	"ListGCsRequest": {
 		"@": "ListGCsRequest is the request to list GC data.",
 	},
@@ -1677,15 +5920,22 @@ var help_messages = map[string]map[string]string{
 		"unacked_from": "unacked_from specifies to the server the sequence_id of the last received tip progress event. Events received by the server that have a higher sequence_id will be streamed back to the client.",
 	},
 	"TipProgressEvent": {
-		"@":             "TipProgressEvent details the progress of a TipUser request.",
-		"sequence_id":   "sequence_id is an opaque sequential ID.",
-		"uid":           "uid is the User ID of the TipUser target.",
-		"nick":          "nick is the nick of the TipUser target.",
-		"amount_matoms": "amount_matoms is the amount being tipped in milli-atoms.",
-		"completed":     "completed flags whether the TipUser request was successfully completed (i.e. if the tip was paid).",
-		"attempt":       "attempt is the attempt number.",
-		"attempt_err":   "attempt_err is filled when the attempt to fetch an invoice or perform the payment for a received invoice failed.",
-		"will_retry":    "will_retry flags whether a new attempt to request an invoice and perform a payment will be done or if no more attempts will happen.",
+		"@":                 "TipProgressEvent details the progress of a TipUser request.",
+		"sequence_id":       "sequence_id is an opaque sequential ID.",
+		"uid":               "uid is the User ID of the TipUser target.",
+		"nick":              "nick is the nick of the TipUser target.",
+		"amount_matoms":     "amount_matoms is the amount being tipped in milli-atoms.",
+		"completed":         "completed flags whether the TipUser request was successfully completed (i.e. if the tip was paid).",
+		"attempt":           "attempt is the attempt number.",
+		"attempt_err":       "attempt_err is filled when the attempt to fetch an invoice or perform the payment for a received invoice failed.",
+		"will_retry":        "will_retry flags whether a new attempt to request an invoice and perform a payment will be done or if no more attempts will happen.",
+		"max_attempts":      "max_attempts is the max number of attempts that will be made.",
+		"remaining_retries": "remaining_retries is the number of attempts that may still be made after this one.",
+		"payment_retries":   "payment_retries is the number of times the payment of the current invoice was retried.",
+		"failure_reason":    "failure_reason classifies attempt_err. One of 'no_route', 'insufficient_balance', 'invoice_expired', 'invoice_error', 'budget_exceeded', 'tip_expired' or 'other'. Empty if the attempt did not fail.",
+		"destination":       "destination is the hex-encoded pubkey of the LN node of the user, if known.",
+		"fees_matoms":       "fees_matoms is the amount of routing fees paid for a completed tip.",
+		"keysend":           "keysend flags whether the tip was paid through a keysend payment.",
 	},
 	"ResourceRequestsStreamRequest": {
 		"@": "ResourceRequestsStreamRequest is the request for a stream to receive resource requests.",
@@ -1718,10 +5968,135 @@ var help_messages = map[string]map[string]string{
 		"disk_path":     "disk_path is the path of the file in the local client's disk.",
 		"file_metadata": "file_metadata is the metadata about the file.",
 	},
+	"GetBandwidthLimitsRequest": {
+		"@": "GetBandwidthLimitsRequest is the request for the bandwidth limits.",
+	},
+	"BandwidthLimits": {
+		"@":             "BandwidthLimits are the limits (in bytes per second) of the bandwidth used for content (file transfer) traffic. Chat traffic is not limited. A zero limit means no limit.",
+		"upload":        "upload is the limit of uploads to all users.",
+		"download":      "download is the limit of downloads from all users.",
+		"peer_upload":   "peer_upload is the limit of uploads to each user.",
+		"peer_download": "peer_download is the limit of downloads from each user.",
+	},
+	"SetBandwidthLimitsResponse": {
+		"@": "SetBandwidthLimitsResponse is the response to setting the bandwidth limits.",
+	},
+	"ExecCommandRequest": {
+		"@":       "ExecCommandRequest is the request to execute a client command.",
+		"command": "command is the full command line to execute. The leading slash is optional.",
+	},
+	"ExecCommandResponse": {
+		"@":      "ExecCommandResponse is the response to executing a client command.",
+		"output": "output is the textual output generated while executing the command.",
+	},
+	"StatusRequest": {
+		"@": "StatusRequest is the request for the client status.",
+	},
+	"StatusResponse": {
+		"@":                          "StatusResponse is the health and status information about the client.",
+		"server_connected":           "server_connected flags whether the client is connected to the server.",
+		"server_ln_node":             "server_ln_node is the LN node ID of the server the client is connected to.",
+		"rvs_up_to_date":             "rvs_up_to_date flags whether the subscriptions to remote rendezvous points are up to date in the server.",
+		"pending_rms_queued":         "pending_rms_queued is the number of outbound RMs waiting to be sent.",
+		"pending_rms_sending":        "pending_rms_sending is the number of outbound RMs in the process of being paid, sent and acked by the server.",
+		"ln_enabled":                 "ln_enabled flags whether the client has an LN wallet configured. The remaining ln_ and balance fields are only filled when this is true.",
+		"ln_error":                   "ln_error is filled when an error happened while fetching the LN wallet status.",
+		"ln_synced_to_chain":         "ln_synced_to_chain flags whether the LN wallet is synced to the chain.",
+		"ln_synced_to_graph":         "ln_synced_to_graph flags whether the LN wallet is synced to the network graph.",
+		"ln_block_height":            "ln_block_height is the current block height of the LN wallet.",
+		"ln_num_active_channels":     "ln_num_active_channels is the number of active LN channels.",
+		"ln_num_pending_channels":    "ln_num_pending_channels is the number of pending LN channels.",
+		"wallet_confirmed_balance":   "wallet_confirmed_balance is the confirmed on-chain balance (in atoms).",
+		"wallet_unconfirmed_balance": "wallet_unconfirmed_balance is the unconfirmed on-chain balance (in atoms).",
+		"max_outbound_amount":        "max_outbound_amount is the maximum amount that may be sent through the LN channels (in atoms).",
+		"max_inbound_amount":         "max_inbound_amount is the maximum amount that may be received through the LN channels (in atoms).",
+		"dcr_usd_rate":               "dcr_usd_rate is the last fetched USD/DCR exchange rate.",
+		"btc_usd_rate":               "btc_usd_rate is the last fetched USD/BTC exchange rate.",
+		"rates_last_updated":         "rates_last_updated is the unix timestamp of when the exchange rates were last updated. Zero if they were never fetched.",
+	},
+	"ListPendingRMsRequest": {
+		"@":    "ListPendingRMsRequest is the request to list pending outbound RMs.",
+		"user": "user is the optional nick or hex-encoded ID of the user to list RMs for. If empty, RMs queued for all users are listed.",
+	},
+	"PendingRM": {
+		"@":                   "PendingRM is an outbound RM that has not yet been acked by the server.",
+		"id":                  "id is the ID of the pending RM, unique among the RMs of the user.",
+		"uid":                 "uid is the ID of the destination user.",
+		"nick":                "nick is the nick or alias of the destination user.",
+		"type":                "type is the type of the RM payload.",
+		"pay_event":           "pay_event is the payment event associated with the RM.",
+		"priority":            "priority is the priority of the RM. Lower values are sent first.",
+		"size":                "size is the size of the RM payload in bytes.",
+		"queued_timestamp_ms": "queued_timestamp_ms is the timestamp of when the RM was queued with millisecond precision.",
+		"sending":             "sending flags whether the RM was already encrypted and is in the process of being sent to the server.",
+	},
+	"ListPendingRMsResponse": {
+		"@":   "ListPendingRMsResponse is the list of pending outbound RMs.",
+		"rms": "rms is the list of pending RMs, sorted by the time they were queued.",
+	},
+	"CancelPendingRMRequest": {
+		"@":   "CancelPendingRMRequest is the request to cancel a pending outbound RM.",
+		"uid": "uid is the ID of the destination user of the RM.",
+		"id":  "id is the ID of the pending RM.",
+	},
+	"CancelPendingRMResponse": {
+		"@": "",
+	},
+	"ReprioritizePendingRMRequest": {
+		"@":        "ReprioritizePendingRMRequest is the request to change the priority of a pending outbound RM.",
+		"uid":      "uid is the ID of the destination user of the RM.",
+		"id":       "id is the ID of the pending RM.",
+		"priority": "priority is the new priority (0-4) of the RM.",
+	},
+	"ReprioritizePendingRMResponse": {
+		"@": "",
+	},
+	"UserProfile": {
+		"@":           "UserProfile is the profile of a local or remote user.",
+		"uid":         "uid is the ID of the user.",
+		"nick":        "nick is the nick chosen by the user.",
+		"nick_alias":  "nick_alias is the local alias for a remote user, if one is set. Empty for the local profile.",
+		"name":        "name is the display name of the user.",
+		"avatar":      "avatar is the avatar image of the user.",
+		"description": "description is the free-form profile description of the user.",
+		"status":      "status is the short status line set by the user.",
+	},
+	"GetLocalProfileRequest": {
+		"@": "",
+	},
+	"UpdateLocalProfileRequest": {
+		"@":                 "UpdateLocalProfileRequest is the request to update the local profile. Empty fields are not modified.",
+		"name":              "name is the new display name.",
+		"avatar":            "avatar is the new avatar image.",
+		"clear_avatar":      "clear_avatar removes the current avatar. Cannot be set if avatar is also specified.",
+		"description":       "description is the new profile description.",
+		"clear_description": "clear_description removes the current description. Cannot be set if description is also specified.",
+		"status":            "status is the new status line.",
+		"clear_status":      "clear_status removes the current status line. Cannot be set if status is also specified.",
+	},
+	"UpdateLocalProfileResponse": {
+		"@": "",
+	},
+	"GetUserProfileRequest": {
+		"@":    "GetUserProfileRequest is the request to fetch the profile of a remote user.",
+		"user": "user is either the nick, alias or hex-encoded user ID of the user.",
+	},
+	"ProfileUpdatesStreamRequest": {
+		"@":            "ProfileUpdatesStreamRequest is the request for a new profile updates stream.",
+		"unacked_from": "unacked_from specifies to the server the sequence_id of the last processed profile update. Updates received by the server that have a higher sequence_id will be streamed back to the client.",
+	},
+	"ProfileUpdatedEvent": {
+		"@":              "ProfileUpdatedEvent is sent when a remote user updates its profile.",
+		"sequence_id":    "sequence_id is an opaque sequential ID.",
+		"profile":        "profile is the updated profile of the user.",
+		"updated_fields": "updated_fields are the names of the profile fields that were updated.",
+	},
 	"RMPrivateMessage": {
-		"@":       "RMPrivateMessage is the network-level routed private message.",
-		"message": "message is the private message payload.",
-		"mode":    "mode is the message mode.",
+		"@":        "RMPrivateMessage is the network-level routed private message.",
+		"message":  "message is the private message payload.",
+		"mode":     "mode is the message mode.",
+		"msg_id":   "msg_id is the sender-chosen ID of the message, used to reference it in edits. May be empty for messages sent by older clients.",
+		"reply_to": "reply_to is the ID of the message this message is a reply to.",
 	},
 	"RMGroupMessage": {
 		"@":          "RMGroupMessage is the network-level routed group message.",
@@ -1729,6 +6104,10 @@ var help_messages = map[string]map[string]string{
 		"generation": "generation is the internal generation of the group chat metadata when the sender sent this message.",
 		"message":    "message is the textual content.",
 		"mode":       "mode is the mode of the message.",
+		"msg_id":     "msg_id is the sender-chosen ID of the message, used to reference it in edits. May be empty for messages sent by older clients.",
+		"reply_to":   "reply_to is the ID of the message this message is a reply to.",
+		"mentions":   "mentions is the list of IDs of the members mentioned (with @nick) in the message.",
+		"channel":    "channel is the ID of the GC channel the message was sent to. Empty for messages sent to the main channel of the GC.",
 	},
 	"PostMetadata": {
 		"@":          "PostMetadata is the network-level post data.",
@@ -1778,14 +6157,26 @@ var help_messages = map[string]map[string]string{
 		"version":     "version is the version of the current definition of the GC.",
 	},
 	"RMGroupList": {
-		"@":            "RMGroupList is the full definition of a GC.",
-		"id":           "id is the ID of the GC.",
-		"name":         "name is the name of the GC.",
-		"generation":   "generation is a monotonically increasing count of GC changes.",
-		"timestamp":    "timestamp is the timestamp for the last modification of the GC defintions.",
-		"version":      "version is the GC rules version.",
-		"members":      "members is the list of user IDs that are in the GC.",
-		"extra_admins": "extra_admins is the list of user IDs that are additional admins of the GC.",
+		"@":                  "RMGroupList is the full definition of a GC.",
+		"id":                 "id is the ID of the GC.",
+		"name":               "name is the name of the GC.",
+		"generation":         "generation is a monotonically increasing count of GC changes.",
+		"timestamp":          "timestamp is the timestamp for the last modification of the GC defintions.",
+		"version":            "version is the GC rules version.",
+		"members":            "members is the list of user IDs that are in the GC.",
+		"extra_admins":       "extra_admins is the list of user IDs that are additional admins of the GC.",
+		"moderators":         "moderators is the list of user IDs that are moderators of the GC.",
+		"admin_perms":        "admin_perms is the bitmask of permissions of the admins of the GC.",
+		"moderator_perms":    "moderator_perms is the bitmask of permissions of the moderators of the GC.",
+		"pinned":             "pinned is the list of IDs of pinned messages in the GC.",
+		"banned":             "banned is the list of user IDs that are banned from the GC.",
+		"slow_mode_interval": "slow_mode_interval is the min interval (in seconds) between messages sent by each member without a moderation role. Zero means slow mode is disabled.",
+		"topic":              "topic is a short line describing the current topic of the GC.",
+		"description":        "description is a markdown description of the GC.",
+		"avatar":             "avatar is the avatar image of the GC.",
+		"channels":           "channels is the list of named channels of the GC.",
+		"max_members":        "max_members is the max number of members of the GC. Zero means the number of members is not limited.",
+		"join_approval":      "join_approval is true if requests to join the GC through invite links must be approved.",
 	},
 	"RMFetchResource": {
 		"@":     "RMFetchResource is the lowlevel request to fetch a resource.",
@@ -1811,6 +6202,17 @@ var help_messages = map[string]map[string]string{
 		"size":  "size is the size of this chunk.",
 		"hash":  "hash is the hash of the chunk data.",
 	},
+	"FilePreview": {
+		"@":    "FilePreview is the free preview of a file.",
+		"size": "size is the number of initial bytes of the file that are free.",
+		"fid":  "fid is the id of a separate file that serves as preview.",
+	},
+	"FilePriceTier": {
+		"@":    "FilePriceTier is the price of a range of bytes of a file.",
+		"name": "name of the tier.",
+		"end":  "end is the offset (exclusive) where the tier ends.",
+		"cost": "cost (in atoms) of the bytes of the tier.",
+	},
 	"FileMetadata": {
 		"@":           "FileMetadata is metadata about a file in the file transfer subsystem.",
 		"version":     "version of the metadata.",
@@ -1823,5 +6225,37 @@ var help_messages = map[string]map[string]string{
 		"manifest":    "manifest of the chunks that compose the file.",
 		"signature":   "signature of the file by the host.",
 		"attributes":  "attributes of the file.",
+		"preview":     "preview is the free preview of the file.",
+		"tiers":       "tiers are the price tiers of the file.",
+	},
+	"ContactMetadata": {
+		"@":            "ContactMetadata is the private metadata the local client keeps about a remote user.",
+		"uid":          "uid is the ID of the user.",
+		"notes":        "notes are free-form notes about the user.",
+		"tags":         "tags are the (lower case) tags of the user.",
+		"fields":       "fields are custom key/value fields about the user.",
+		"last_updated": "last_updated is the unix timestamp of the last modification.",
+	},
+	"GetContactMetadataRequest": {
+		"@":    "GetContactMetadataRequest is the request for the metadata of a user.",
+		"user": "user is the nick, alias or hex-encoded ID of the user.",
+	},
+	"UpdateContactMetadataRequest": {
+		"@":             "UpdateContactMetadataRequest is the request to modify the metadata of a user. Empty fields are not modified.",
+		"user":          "user is the nick, alias or hex-encoded ID of the user.",
+		"notes":         "notes are the new notes about the user.",
+		"clear_notes":   "clear_notes removes the existing notes. Cannot be set if notes is also specified.",
+		"add_tags":      "add_tags are tags to add to the user.",
+		"remove_tags":   "remove_tags are tags to remove from the user.",
+		"set_fields":    "set_fields are custom fields to set.",
+		"remove_fields": "remove_fields are the keys of custom fields to remove.",
+	},
+	"ListContactsByTagRequest": {
+		"@":   "ListContactsByTagRequest is the request to list users with a tag.",
+		"tag": "tag is the tag to search for.",
+	},
+	"ListContactsByTagResponse": {
+		"@":    "ListContactsByTagResponse is the list of users with a tag.",
+		"uids": "uids are the IDs of the users with the tag.",
 	},
 }
@@ -0,0 +1,505 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+	"github.com/decred/slog"
+)
+
+// maxAutopilotActions is the max number of actions kept in the autopilot
+// history.
+const maxAutopilotActions = 100
+
+// AutopilotConfig is the configuration of the channel autopilot.
+type AutopilotConfig struct {
+	// LN is the client used to manage the channels of the wallet.
+	LN lnrpc.LightningClient
+
+	// Candidates are the nodes ("pubkey@host:port") to which channels may
+	// be opened.
+	Candidates []string
+
+	// MinOutbound is the outbound capacity below which new channels are
+	// opened.
+	MinOutbound dcrutil.Amount
+
+	// ChannelSize is the amount committed to new channels.
+	ChannelSize dcrutil.Amount
+
+	// MaxChannels is the max number of channels (including pending ones)
+	// the wallet may have for the autopilot to open new channels. If
+	// zero, a default of 5 is used.
+	MaxChannels int
+
+	// WalletReserve is the on-chain balance that is never committed to
+	// new channels.
+	WalletReserve dcrutil.Amount
+
+	// MinUptime is the min ratio of uptime to lifetime of channels older
+	// than MinChannelAge. Channels below this ratio are closed. If zero,
+	// channels are never closed.
+	MinUptime     float64
+	MinChannelAge time.Duration
+
+	// FeeBudget is the max amount of on-chain fees spent by the autopilot
+	// to open and close channels over BudgetPeriod. If zero, a default of
+	// 0.001 DCR per day is used.
+	FeeBudget    dcrutil.Amount
+	BudgetPeriod time.Duration
+
+	// Interval is the interval between evaluations of the channels. If
+	// zero, a default of 10 minutes is used.
+	Interval time.Duration
+
+	// StateFile is where the history of actions is stored, so that the
+	// fee budget is respected across restarts.
+	StateFile string
+
+	// OnAction is called after the autopilot opens or closes a channel.
+	OnAction func(AutopilotAction)
+
+	Log slog.Logger
+}
+
+// AutopilotAction is a channel opened or closed by the autopilot.
+type AutopilotAction struct {
+	Time         time.Time      `json:"time"`
+	Open         bool           `json:"open"`
+	Peer         string         `json:"peer"`
+	ChannelPoint string         `json:"channel_point"`
+	Amount       dcrutil.Amount `json:"amount"`
+	Fee          dcrutil.Amount `json:"fee"`
+	Reason       string         `json:"reason"`
+}
+
+// AutopilotStatus is the status of the autopilot.
+type AutopilotStatus struct {
+	LastRun   time.Time
+	LastErr   error
+	FeesSpent dcrutil.Amount
+	FeeBudget dcrutil.Amount
+	Actions   []AutopilotAction
+}
+
+// Autopilot opens and closes channels of the wallet, to keep the outbound
+// capacity above a target while respecting a budget for on-chain fees.
+type Autopilot struct {
+	cfg AutopilotConfig
+	log slog.Logger
+
+	mtx     sync.Mutex
+	actions []AutopilotAction
+	lastRun time.Time
+	lastErr error
+}
+
+// NewAutopilot creates a new channel autopilot.
+func NewAutopilot(cfg AutopilotConfig) (*Autopilot, error) {
+	if cfg.LN == nil {
+		return nil, errors.New("LN client is not specified")
+	}
+	if cfg.ChannelSize <= 0 {
+		return nil, errors.New("channel size must be positive")
+	}
+	if cfg.MaxChannels <= 0 {
+		cfg.MaxChannels = 5
+	}
+	if cfg.FeeBudget <= 0 {
+		cfg.FeeBudget = 1e5
+	}
+	if cfg.BudgetPeriod <= 0 {
+		cfg.BudgetPeriod = 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Minute
+	}
+	log := cfg.Log
+	if log == nil {
+		log = slog.Disabled
+	}
+
+	ap := &Autopilot{cfg: cfg, log: log}
+	if cfg.StateFile != "" {
+		data, err := os.ReadFile(cfg.StateFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &ap.actions); err != nil {
+				return nil, fmt.Errorf("unable to decode autopilot "+
+					"state: %v", err)
+			}
+		}
+	}
+	return ap, nil
+}
+
+// saveState saves the history of actions. Must be called with the mutex held.
+func (ap *Autopilot) saveState() error {
+	if ap.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(ap.actions)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ap.cfg.StateFile), 0o700); err != nil {
+		return err
+	}
+	tmp := ap.cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ap.cfg.StateFile)
+}
+
+// feesSpent returns the fees spent within the budget period. Must be called
+// with the mutex held.
+func (ap *Autopilot) feesSpent(now time.Time) dcrutil.Amount {
+	var spent dcrutil.Amount
+	start := now.Add(-ap.cfg.BudgetPeriod)
+	for _, a := range ap.actions {
+		if a.Time.After(start) {
+			spent += a.Fee
+		}
+	}
+	return spent
+}
+
+// fitsBudget returns true if the fee fits in the remaining fee budget.
+func (ap *Autopilot) fitsBudget(fee dcrutil.Amount) bool {
+	ap.mtx.Lock()
+	defer ap.mtx.Unlock()
+	return ap.feesSpent(time.Now())+fee <= ap.cfg.FeeBudget
+}
+
+// recordAction records an action taken by the autopilot.
+func (ap *Autopilot) recordAction(a AutopilotAction) {
+	ap.mtx.Lock()
+	ap.actions = append(ap.actions, a)
+	if len(ap.actions) > maxAutopilotActions {
+		ap.actions = ap.actions[len(ap.actions)-maxAutopilotActions:]
+	}
+	err := ap.saveState()
+	ap.mtx.Unlock()
+	if err != nil {
+		ap.log.Errorf("Unable to save autopilot state: %v", err)
+	}
+
+	if ap.cfg.OnAction != nil {
+		ap.cfg.OnAction(a)
+	}
+}
+
+// Status returns the current status of the autopilot.
+func (ap *Autopilot) Status() AutopilotStatus {
+	ap.mtx.Lock()
+	defer ap.mtx.Unlock()
+	return AutopilotStatus{
+		LastRun:   ap.lastRun,
+		LastErr:   ap.lastErr,
+		FeesSpent: ap.feesSpent(time.Now()),
+		FeeBudget: ap.cfg.FeeBudget,
+		Actions:   append([]AutopilotAction(nil), ap.actions...),
+	}
+}
+
+// uptimeRatio returns the ratio of uptime to lifetime of the channel.
+func uptimeRatio(c *lnrpc.Channel) float64 {
+	if c.Lifetime <= 0 {
+		return 1
+	}
+	return float64(c.Uptime) / float64(c.Lifetime)
+}
+
+// scorePeer scores a candidate peer between 0 and 1. Nodes with more channels
+// and capacity score higher, and the score is scaled by the uptime of the
+// existing channels with the node.
+func scorePeer(info *lnrpc.NodeInfo, chans []*lnrpc.Channel) float64 {
+	score := 0.5
+	if info != nil {
+		connectivity := float64(info.NumChannels) / 50
+		if connectivity > 1 {
+			connectivity = 1
+		}
+		capacity := float64(info.TotalCapacity) / 100e8
+		if capacity > 1 {
+			capacity = 1
+		}
+		score = 0.5*connectivity + 0.5*capacity
+	}
+	for _, c := range chans {
+		score *= uptimeRatio(c)
+
+		// Prefer new peers to spread the channels.
+		score *= 0.5
+	}
+	return score
+}
+
+// closeChannel closes the channel, cooperatively if its peer is online.
+func (ap *Autopilot) closeChannel(ctx context.Context, c *lnrpc.Channel, reason string) error {
+	txid, idxStr, ok := strings.Cut(c.ChannelPoint, ":")
+	if !ok {
+		return fmt.Errorf("invalid channel point %q", c.ChannelPoint)
+	}
+	var idx uint32
+	if _, err := fmt.Sscanf(idxStr, "%d", &idx); err != nil {
+		return fmt.Errorf("invalid channel point %q", c.ChannelPoint)
+	}
+	req := &lnrpc.CloseChannelRequest{
+		ChannelPoint: &lnrpc.ChannelPoint{
+			FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{
+				FundingTxidStr: txid,
+			},
+			OutputIndex: idx,
+		},
+		Force: !c.Active,
+	}
+	stream, err := ap.cfg.LN.CloseChannel(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// Wait until the close is pending.
+	for {
+		updt, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if pending, ok := updt.Update.(*lnrpc.CloseStatusUpdate_ClosePending); ok {
+			tx, _ := chainhash.NewHash(pending.ClosePending.Txid)
+			ap.log.Infof("Closing channel %s with %s on tx %s (%s)",
+				c.ChannelPoint, c.RemotePubkey, tx, reason)
+			break
+		}
+	}
+
+	ap.recordAction(AutopilotAction{
+		Time:         time.Now(),
+		Peer:         c.RemotePubkey,
+		ChannelPoint: c.ChannelPoint,
+		Amount:       dcrutil.Amount(c.LocalBalance),
+		Fee:          dcrutil.Amount(c.CommitFee),
+		Reason:       reason,
+	})
+	return nil
+}
+
+// closeUnreliable closes channels with a low uptime. It returns the channels
+// that were not closed.
+func (ap *Autopilot) closeUnreliable(ctx context.Context, chans []*lnrpc.Channel) []*lnrpc.Channel {
+	if ap.cfg.MinUptime <= 0 {
+		return chans
+	}
+	minAge := int64(ap.cfg.MinChannelAge / time.Second)
+	res := make([]*lnrpc.Channel, 0, len(chans))
+	for _, c := range chans {
+		ratio := uptimeRatio(c)
+		if c.Lifetime < minAge || ratio >= ap.cfg.MinUptime {
+			res = append(res, c)
+			continue
+		}
+		if !ap.fitsBudget(dcrutil.Amount(c.CommitFee)) {
+			ap.log.Infof("Not closing channel %s with uptime %.2f "+
+				"due to fee budget", c.ChannelPoint, ratio)
+			res = append(res, c)
+			continue
+		}
+		reason := fmt.Sprintf("uptime %.2f below %.2f", ratio, ap.cfg.MinUptime)
+		if err := ap.closeChannel(ctx, c, reason); err != nil {
+			ap.log.Warnf("Unable to close channel %s: %v", c.ChannelPoint, err)
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// bestCandidate returns the candidate with the highest score.
+func (ap *Autopilot) bestCandidate(ctx context.Context, chans []*lnrpc.Channel) (pubkey, host string, err error) {
+	bestScore := 0.0
+	for _, cand := range ap.cfg.Candidates {
+		pk, h, ok := strings.Cut(cand, "@")
+		if !ok || pk == "" || h == "" {
+			ap.log.Warnf("Invalid autopilot candidate %q", cand)
+			continue
+		}
+
+		info, err := ap.cfg.LN.GetNodeInfo(ctx, &lnrpc.NodeInfoRequest{PubKey: pk})
+		if err != nil {
+			ap.log.Debugf("Unable to get node info of %s: %v", pk, err)
+			info = nil
+		}
+		var peerChans []*lnrpc.Channel
+		for _, c := range chans {
+			if c.RemotePubkey == pk {
+				peerChans = append(peerChans, c)
+			}
+		}
+		score := scorePeer(info, peerChans)
+		ap.log.Debugf("Autopilot candidate %s score %.3f", pk, score)
+		if score > bestScore {
+			bestScore, pubkey, host = score, pk, h
+		}
+	}
+	if pubkey == "" {
+		return "", "", errors.New("no suitable candidate peers")
+	}
+	return pubkey, host, nil
+}
+
+// openChannel opens a new channel if the outbound capacity is below the
+// target.
+func (ap *Autopilot) openChannel(ctx context.Context, chans []*lnrpc.Channel,
+	pending *lnrpc.PendingChannelsResponse) error {
+
+	var outbound dcrutil.Amount
+	for _, c := range chans {
+		if c.Active {
+			outbound += dcrutil.Amount(c.LocalBalance)
+		}
+	}
+	if outbound >= ap.cfg.MinOutbound {
+		return nil
+	}
+	if len(pending.PendingOpenChannels) > 0 {
+		ap.log.Debugf("Waiting for %d pending channels to open",
+			len(pending.PendingOpenChannels))
+		return nil
+	}
+	if len(chans) >= ap.cfg.MaxChannels {
+		ap.log.Debugf("Outbound capacity %s below target %s, but already "+
+			"at max channels", outbound, ap.cfg.MinOutbound)
+		return nil
+	}
+
+	bal, err := ap.cfg.LN.WalletBalance(ctx, &lnrpc.WalletBalanceRequest{})
+	if err != nil {
+		return err
+	}
+	available := dcrutil.Amount(bal.ConfirmedBalance) - ap.cfg.WalletReserve
+	if available < ap.cfg.ChannelSize {
+		return fmt.Errorf("outbound capacity %s below target %s, but "+
+			"available on-chain balance %s is not enough to open "+
+			"channel of %s", outbound, ap.cfg.MinOutbound,
+			available, ap.cfg.ChannelSize)
+	}
+
+	// Estimate the fee to fund the channel.
+	addr, err := ap.cfg.LN.NewAddress(ctx, &lnrpc.NewAddressRequest{
+		Type: lnrpc.AddressType_PUBKEY_HASH,
+	})
+	if err != nil {
+		return err
+	}
+	est, err := ap.cfg.LN.EstimateFee(ctx, &lnrpc.EstimateFeeRequest{
+		AddrToAmount: map[string]int64{addr.Address: int64(ap.cfg.ChannelSize)},
+		TargetConf:   6,
+	})
+	if err != nil {
+		return err
+	}
+	fee := dcrutil.Amount(est.FeeAtoms)
+	if !ap.fitsBudget(fee) {
+		return fmt.Errorf("fee %s to open channel exceeds the remaining "+
+			"fee budget", fee)
+	}
+
+	pubkey, host, err := ap.bestCandidate(ctx, chans)
+	if err != nil {
+		return err
+	}
+	_, err = ap.cfg.LN.ConnectPeer(ctx, &lnrpc.ConnectPeerRequest{
+		Addr: &lnrpc.LightningAddress{Pubkey: pubkey, Host: host},
+	})
+	if err != nil && !strings.Contains(err.Error(), "already connected") {
+		return fmt.Errorf("unable to connect to %s: %v", pubkey, err)
+	}
+
+	cp, err := ap.cfg.LN.OpenChannelSync(ctx, &lnrpc.OpenChannelRequest{
+		NodePubkeyString:   pubkey,
+		LocalFundingAmount: int64(ap.cfg.ChannelSize),
+		TargetConf:         6,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to open channel to %s: %v", pubkey, err)
+	}
+	txid, err := lnrpc.GetChanPointFundingTxid(cp)
+	if err != nil {
+		return err
+	}
+	chanPoint := fmt.Sprintf("%s:%d", txid, cp.OutputIndex)
+	ap.log.Infof("Opened channel %s of %s with %s", chanPoint,
+		ap.cfg.ChannelSize, pubkey)
+
+	ap.recordAction(AutopilotAction{
+		Time:         time.Now(),
+		Open:         true,
+		Peer:         pubkey,
+		ChannelPoint: chanPoint,
+		Amount:       ap.cfg.ChannelSize,
+		Fee:          fee,
+		Reason: fmt.Sprintf("outbound capacity %s below %s", outbound,
+			ap.cfg.MinOutbound),
+	})
+	return nil
+}
+
+// RunOnce evaluates the channels of the wallet, closing unreliable channels
+// and opening a new channel if the outbound capacity is below the target.
+func (ap *Autopilot) RunOnce(ctx context.Context) error {
+	err := ap.runOnce(ctx)
+	ap.mtx.Lock()
+	ap.lastRun = time.Now()
+	ap.lastErr = err
+	ap.mtx.Unlock()
+	return err
+}
+
+func (ap *Autopilot) runOnce(ctx context.Context) error {
+	res, err := ap.cfg.LN.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return err
+	}
+	pending, err := ap.cfg.LN.PendingChannels(ctx, &lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return err
+	}
+
+	// Evaluate channels in a stable order.
+	chans := res.Channels
+	sort.Slice(chans, func(i, j int) bool {
+		return chans[i].ChannelPoint < chans[j].ChannelPoint
+	})
+	chans = ap.closeUnreliable(ctx, chans)
+	return ap.openChannel(ctx, chans, pending)
+}
+
+// Run runs the autopilot until the context is canceled.
+func (ap *Autopilot) Run(ctx context.Context) error {
+	ticker := time.NewTicker(ap.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := ap.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			ap.log.Warnf("Autopilot run failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+	"google.golang.org/grpc"
+)
+
+type fakeCloseStream struct {
+	grpc.ClientStream
+}
+
+func (s *fakeCloseStream) Recv() (*lnrpc.CloseStatusUpdate, error) {
+	return &lnrpc.CloseStatusUpdate{
+		Update: &lnrpc.CloseStatusUpdate_ClosePending{
+			ClosePending: &lnrpc.PendingUpdate{Txid: make([]byte, 32)},
+		},
+	}, nil
+}
+
+// fakeLN is a fake LN client that implements the calls used by the autopilot.
+type fakeLN struct {
+	lnrpc.LightningClient
+
+	channels  []*lnrpc.Channel
+	pending   []*lnrpc.PendingChannelsResponse_PendingOpenChannel
+	balance   int64
+	fee       int64
+	nodes     map[string]*lnrpc.NodeInfo
+	opened    []*lnrpc.OpenChannelRequest
+	closed    []*lnrpc.CloseChannelRequest
+	connected []string
+}
+
+func (ln *fakeLN) ListChannels(context.Context, *lnrpc.ListChannelsRequest, ...grpc.CallOption) (*lnrpc.ListChannelsResponse, error) {
+	return &lnrpc.ListChannelsResponse{Channels: ln.channels}, nil
+}
+
+func (ln *fakeLN) PendingChannels(context.Context, *lnrpc.PendingChannelsRequest, ...grpc.CallOption) (*lnrpc.PendingChannelsResponse, error) {
+	return &lnrpc.PendingChannelsResponse{PendingOpenChannels: ln.pending}, nil
+}
+
+func (ln *fakeLN) WalletBalance(context.Context, *lnrpc.WalletBalanceRequest, ...grpc.CallOption) (*lnrpc.WalletBalanceResponse, error) {
+	return &lnrpc.WalletBalanceResponse{ConfirmedBalance: ln.balance}, nil
+}
+
+func (ln *fakeLN) NewAddress(context.Context, *lnrpc.NewAddressRequest, ...grpc.CallOption) (*lnrpc.NewAddressResponse, error) {
+	return &lnrpc.NewAddressResponse{Address: "addr"}, nil
+}
+
+func (ln *fakeLN) EstimateFee(context.Context, *lnrpc.EstimateFeeRequest, ...grpc.CallOption) (*lnrpc.EstimateFeeResponse, error) {
+	return &lnrpc.EstimateFeeResponse{FeeAtoms: ln.fee}, nil
+}
+
+func (ln *fakeLN) GetNodeInfo(_ context.Context, req *lnrpc.NodeInfoRequest, _ ...grpc.CallOption) (*lnrpc.NodeInfo, error) {
+	if info, ok := ln.nodes[req.PubKey]; ok {
+		return info, nil
+	}
+	return nil, errors.New("unknown node")
+}
+
+func (ln *fakeLN) ConnectPeer(_ context.Context, req *lnrpc.ConnectPeerRequest, _ ...grpc.CallOption) (*lnrpc.ConnectPeerResponse, error) {
+	ln.connected = append(ln.connected, req.Addr.Pubkey)
+	return &lnrpc.ConnectPeerResponse{}, nil
+}
+
+func (ln *fakeLN) OpenChannelSync(_ context.Context, req *lnrpc.OpenChannelRequest, _ ...grpc.CallOption) (*lnrpc.ChannelPoint, error) {
+	ln.opened = append(ln.opened, req)
+	return &lnrpc.ChannelPoint{
+		FundingTxid: &lnrpc.ChannelPoint_FundingTxidBytes{
+			FundingTxidBytes: make([]byte, 32),
+		},
+	}, nil
+}
+
+func (ln *fakeLN) CloseChannel(_ context.Context, req *lnrpc.CloseChannelRequest, _ ...grpc.CallOption) (lnrpc.Lightning_CloseChannelClient, error) {
+	ln.closed = append(ln.closed, req)
+	return &fakeCloseStream{}, nil
+}
+
+// TestAutopilotOpensChannel tests that the autopilot opens a channel to the
+// best scored candidate when the outbound capacity is low.
+func TestAutopilotOpensChannel(t *testing.T) {
+	ln := &fakeLN{
+		channels: []*lnrpc.Channel{{
+			Active:       true,
+			RemotePubkey: "hub",
+			ChannelPoint: chainhash.Hash{}.String() + ":0",
+			LocalBalance: 1e7,
+			Lifetime:     100,
+			Uptime:       100,
+		}},
+		balance: 3e8,
+		fee:     5000,
+		nodes: map[string]*lnrpc.NodeInfo{
+			"hub":   {NumChannels: 100, TotalCapacity: 1000e8},
+			"small": {NumChannels: 5, TotalCapacity: 10e8},
+		},
+	}
+	stateFile := filepath.Join(t.TempDir(), "autopilot.json")
+	var actions []AutopilotAction
+	cfg := AutopilotConfig{
+		LN:            ln,
+		Candidates:    []string{"hub@hub.example.com:9735", "small@small.example.com:9735"},
+		MinOutbound:   1e8,
+		ChannelSize:   2e8,
+		WalletReserve: 5e7,
+		FeeBudget:     8000,
+		StateFile:     stateFile,
+		OnAction:      func(a AutopilotAction) { actions = append(actions, a) },
+	}
+	ap, err := NewAutopilot(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hub already has a channel, so its score is halved but it is
+	// still better connected than the small node.
+	if err := ap.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ln.opened) != 1 || ln.opened[0].NodePubkeyString != "hub" ||
+		ln.opened[0].LocalFundingAmount != 2e8 {
+		t.Fatalf("unexpected opened channels: %v", ln.opened)
+	}
+	if len(actions) != 1 || !actions[0].Open || actions[0].Fee != 5000 {
+		t.Fatalf("unexpected actions: %v", actions)
+	}
+
+	// Do not open another channel while one is pending.
+	ln.pending = []*lnrpc.PendingChannelsResponse_PendingOpenChannel{{}}
+	if err := ap.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ln.opened) != 1 {
+		t.Fatalf("unexpected nb of opened channels: %d", len(ln.opened))
+	}
+
+	// The fee budget is enforced across restarts.
+	ln.pending = nil
+	ap, err = NewAutopilot(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ap.RunOnce(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "fee budget") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st := ap.Status(); st.FeesSpent != 5000 || st.LastErr != err {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+// TestAutopilotInsufficientFunds tests that the autopilot does not open
+// channels that would spend the wallet reserve.
+func TestAutopilotInsufficientFunds(t *testing.T) {
+	ln := &fakeLN{balance: 2e8}
+	ap, err := NewAutopilot(AutopilotConfig{
+		LN:            ln,
+		Candidates:    []string{"hub@hub.example.com:9735"},
+		MinOutbound:   1e8,
+		ChannelSize:   2e8,
+		WalletReserve: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ap.RunOnce(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "not enough") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ln.opened) != 0 {
+		t.Fatalf("unexpected opened channels")
+	}
+}
+
+// TestAutopilotClosesUnreliable tests that channels with low uptime are
+// closed.
+func TestAutopilotClosesUnreliable(t *testing.T) {
+	day := int64(24 * time.Hour / time.Second)
+	cp := chainhash.Hash{0: 1}.String()
+	ln := &fakeLN{
+		channels: []*lnrpc.Channel{{
+			// Reliable.
+			Active:       true,
+			RemotePubkey: "a",
+			ChannelPoint: cp + ":0",
+			LocalBalance: 2e8,
+			Lifetime:     10 * day,
+			Uptime:       9 * day,
+		}, {
+			// Unreliable, but too new.
+			RemotePubkey: "b",
+			ChannelPoint: cp + ":1",
+			Lifetime:     day,
+			Uptime:       0,
+		}, {
+			// Unreliable.
+			RemotePubkey: "c",
+			ChannelPoint: cp + ":2",
+			CommitFee:    3000,
+			Lifetime:     10 * day,
+			Uptime:       day,
+		}},
+	}
+	ap, err := NewAutopilot(AutopilotConfig{
+		LN:            ln,
+		MinOutbound:   1e8,
+		ChannelSize:   2e8,
+		MinUptime:     0.5,
+		MinChannelAge: 7 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ap.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ln.closed) != 1 {
+		t.Fatalf("unexpected nb of closed channels: %d", len(ln.closed))
+	}
+	req := ln.closed[0]
+	if req.ChannelPoint.OutputIndex != 2 || !req.Force {
+		t.Fatalf("unexpected close request: %v", req)
+	}
+	st := ap.Status()
+	if len(st.Actions) != 1 || st.Actions[0].Open || st.FeesSpent != dcrutil.Amount(3000) {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+// TestScorePeer tests scoring candidate peers.
+func TestScorePeer(t *testing.T) {
+	big := &lnrpc.NodeInfo{NumChannels: 100, TotalCapacity: 1000e8}
+	small := &lnrpc.NodeInfo{NumChannels: 5, TotalCapacity: 10e8}
+	if s := scorePeer(big, nil); s != 1 {
+		t.Fatalf("unexpected score of big node: %f", s)
+	}
+	if scorePeer(small, nil) >= scorePeer(big, nil) {
+		t.Fatalf("small node scored higher than big node")
+	}
+	if s := scorePeer(nil, nil); s != 0.5 {
+		t.Fatalf("unexpected score of unknown node: %f", s)
+	}
+	flaky := []*lnrpc.Channel{{Lifetime: 100, Uptime: 20}}
+	if s := scorePeer(big, flaky); s != 0.1 {
+		t.Fatalf("unexpected score of flaky node: %f", s)
+	}
+}
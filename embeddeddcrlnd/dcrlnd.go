@@ -62,6 +62,14 @@ type Config struct {
 
 	// SyncFreeList sets the SyncFreeList flag in the DB.
 	SyncFreeList bool
+
+	// AcceptKeysend enables receiving spontaneous (keysend) payments.
+	AcceptKeysend bool
+
+	// WatchtowerClient enables the watchtower client, which backs up
+	// channel states to the towers added through Watchtowers(), so that
+	// channels are protected while the wallet is offline.
+	WatchtowerClient bool
 }
 
 // Dcrlnd is a running instance of an embedded dcrlnd instance.
@@ -380,9 +388,10 @@ func RunDcrlnd(ctx context.Context, cfg Config) (*Dcrlnd, error) {
 	conf.BackupFilePath = filepath.Join(rootDir, "channels.backup")
 	conf.Decred.Node = "dcrw"
 	conf.DB.Bolt.SyncFreelist = cfg.SyncFreeList
+	conf.AcceptKeySend = cfg.AcceptKeysend
 	conf.DebugLevel = cfg.DebugLevel
 	conf.ProtocolOptions = &lncfg.ProtocolOptions{}
-	conf.WtClient = &lncfg.WtClient{}
+	conf.WtClient = &lncfg.WtClient{Active: cfg.WatchtowerClient}
 	conf.SubRPCServers.WalletKitRPC = &walletrpc.Config{}
 	conf.SubRPCServers.AutopilotRPC = &autopilotrpc.Config{}
 	conf.SubRPCServers.ChainRPC = &chainrpc.Config{}
@@ -0,0 +1,377 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+	lpclient "github.com/decred/dcrlnlpd/client"
+	"github.com/decred/slog"
+)
+
+// maxInboundActions is the max number of acquisitions kept in the inbound
+// liquidity manager history.
+const maxInboundActions = 100
+
+// errInboundBudget is returned when acquiring inbound liquidity would exceed
+// the fee budget.
+var errInboundBudget = errors.New("fee budget exceeded")
+
+// InboundConfig is the configuration of the inbound liquidity manager.
+type InboundConfig struct {
+	// LN is the client used to query the channels of the wallet and to pay
+	// the liquidity provider.
+	LN lnrpc.LightningClient
+
+	// Server, Key and Certificates identify the liquidity provider
+	// (dcrlnlpd) from which inbound channels are requested.
+	Server       string
+	Key          string
+	Certificates []byte
+
+	// MinInbound is the inbound (receive) capacity below which a new
+	// inbound channel is requested.
+	MinInbound dcrutil.Amount
+
+	// ChannelSize is the size of the requested inbound channels.
+	ChannelSize dcrutil.Amount
+
+	// FeeBudget is the max amount paid to the liquidity provider over
+	// BudgetPeriod. If zero, a default of 0.01 DCR per week is used.
+	FeeBudget    dcrutil.Amount
+	BudgetPeriod time.Duration
+
+	// Interval is the interval between checks of the inbound capacity. If
+	// zero, a default of 10 minutes is used.
+	Interval time.Duration
+
+	// StateFile is where the history of acquisitions is stored, so that
+	// the fee budget is respected across restarts.
+	StateFile string
+
+	// OnAction is called after a new inbound channel is pending.
+	OnAction func(InboundAction)
+
+	// OnError is called when the inbound capacity is below the target but
+	// a new channel could not be acquired. It is only called when the
+	// error differs from the one of the previous check.
+	OnError func(error)
+
+	Log slog.Logger
+
+	// requestChannel overrides the request to the liquidity provider in
+	// tests.
+	requestChannel func(ctx context.Context, size dcrutil.Amount,
+		checkFee func(fee dcrutil.Amount) error) (string, error)
+}
+
+// InboundAction is an inbound channel acquired from the liquidity provider.
+type InboundAction struct {
+	Time         time.Time      `json:"time"`
+	ChannelPoint string         `json:"channel_point"`
+	Capacity     dcrutil.Amount `json:"capacity"`
+	Fee          dcrutil.Amount `json:"fee"`
+	Reason       string         `json:"reason"`
+}
+
+// InboundStatus is the status of the inbound liquidity manager.
+type InboundStatus struct {
+	LastRun      time.Time
+	LastErr      error
+	Inbound      dcrutil.Amount
+	MinInbound   dcrutil.Amount
+	FeesSpent    dcrutil.Amount
+	FeeBudget    dcrutil.Amount
+	Acquisitions []InboundAction
+}
+
+// InboundManager requests inbound channels from a liquidity provider when the
+// receive capacity of the wallet falls below a target, while respecting a
+// budget for the fees paid to the provider.
+type InboundManager struct {
+	cfg InboundConfig
+	log slog.Logger
+
+	mtx     sync.Mutex
+	actions []InboundAction
+	lastRun time.Time
+	lastErr error
+	inbound dcrutil.Amount
+}
+
+// NewInboundManager creates a new inbound liquidity manager.
+func NewInboundManager(cfg InboundConfig) (*InboundManager, error) {
+	if cfg.LN == nil {
+		return nil, errors.New("LN client is not specified")
+	}
+	if cfg.ChannelSize <= 0 {
+		return nil, errors.New("channel size must be positive")
+	}
+	if cfg.Server == "" && cfg.requestChannel == nil {
+		return nil, errors.New("liquidity provider server is not specified")
+	}
+	if cfg.FeeBudget <= 0 {
+		cfg.FeeBudget = 1e6
+	}
+	if cfg.BudgetPeriod <= 0 {
+		cfg.BudgetPeriod = 7 * 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Minute
+	}
+	log := cfg.Log
+	if log == nil {
+		log = slog.Disabled
+	}
+
+	im := &InboundManager{cfg: cfg, log: log}
+	if im.cfg.requestChannel == nil {
+		im.cfg.requestChannel = im.requestLPChannel
+	}
+	if cfg.StateFile != "" {
+		data, err := os.ReadFile(cfg.StateFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &im.actions); err != nil {
+				return nil, fmt.Errorf("unable to decode inbound "+
+					"liquidity state: %v", err)
+			}
+		}
+	}
+	return im, nil
+}
+
+// saveState saves the history of acquisitions. Must be called with the mutex
+// held.
+func (im *InboundManager) saveState() error {
+	if im.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(im.actions)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(im.cfg.StateFile), 0o700); err != nil {
+		return err
+	}
+	tmp := im.cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, im.cfg.StateFile)
+}
+
+// feesSpent returns the fees spent within the budget period. Must be called
+// with the mutex held.
+func (im *InboundManager) feesSpent(now time.Time) dcrutil.Amount {
+	var spent dcrutil.Amount
+	start := now.Add(-im.cfg.BudgetPeriod)
+	for _, a := range im.actions {
+		if a.Time.After(start) {
+			spent += a.Fee
+		}
+	}
+	return spent
+}
+
+// checkBudget returns an error if the fee does not fit in the remaining fee
+// budget.
+func (im *InboundManager) checkBudget(fee dcrutil.Amount) error {
+	im.mtx.Lock()
+	spent := im.feesSpent(time.Now())
+	im.mtx.Unlock()
+	if spent+fee > im.cfg.FeeBudget {
+		return fmt.Errorf("%w: fee %s to acquire inbound channel, %s of "+
+			"%s already spent", errInboundBudget, fee, spent,
+			im.cfg.FeeBudget)
+	}
+	return nil
+}
+
+// recordAction records an inbound channel acquisition.
+func (im *InboundManager) recordAction(a InboundAction) {
+	im.mtx.Lock()
+	im.actions = append(im.actions, a)
+	if len(im.actions) > maxInboundActions {
+		im.actions = im.actions[len(im.actions)-maxInboundActions:]
+	}
+	err := im.saveState()
+	im.mtx.Unlock()
+	if err != nil {
+		im.log.Errorf("Unable to save inbound liquidity state: %v", err)
+	}
+
+	if im.cfg.OnAction != nil {
+		im.cfg.OnAction(a)
+	}
+}
+
+// Status returns the current status of the inbound liquidity manager.
+func (im *InboundManager) Status() InboundStatus {
+	im.mtx.Lock()
+	defer im.mtx.Unlock()
+	return InboundStatus{
+		LastRun:      im.lastRun,
+		LastErr:      im.lastErr,
+		Inbound:      im.inbound,
+		MinInbound:   im.cfg.MinInbound,
+		FeesSpent:    im.feesSpent(time.Now()),
+		FeeBudget:    im.cfg.FeeBudget,
+		Acquisitions: append([]InboundAction(nil), im.actions...),
+	}
+}
+
+// requestLPChannel requests a channel from the liquidity provider. It returns
+// once the channel is pending.
+func (im *InboundManager) requestLPChannel(ctx context.Context, size dcrutil.Amount,
+	checkFee func(fee dcrutil.Amount) error) (string, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type pendingChan struct {
+		chanPoint string
+		capacity  uint64
+	}
+	pendingC := make(chan pendingChan, 1)
+	c, err := lpclient.New(lpclient.Config{
+		LC:           im.cfg.LN,
+		Address:      im.cfg.Server,
+		Key:          im.cfg.Key,
+		Certificates: im.cfg.Certificates,
+
+		PolicyFetched: func(policy lpclient.ServerPolicy) error {
+			fee := lpclient.EstimatedInvoiceAmount(uint64(size),
+				policy.ChanInvoiceFeeRate)
+			return checkFee(dcrutil.Amount(fee))
+		},
+
+		PayingInvoice: func(payHash string) {
+			im.log.Infof("Paying liquidity provider invoice %s", payHash)
+		},
+
+		PendingChannel: func(chanPoint string, capacity uint64) {
+			select {
+			case pendingC <- pendingChan{chanPoint, capacity}:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	errC := make(chan error, 1)
+	go func() { errC <- c.RequestChannel(ctx, uint64(size)) }()
+	select {
+	case err := <-errC:
+		if err == nil {
+			err = errors.New("liquidity provider did not open channel")
+		}
+		return "", err
+	case pc := <-pendingC:
+		im.log.Debugf("Liquidity provider opened channel %s with "+
+			"capacity %s", pc.chanPoint, dcrutil.Amount(pc.capacity))
+		return pc.chanPoint, nil
+	}
+}
+
+// RunOnce checks the inbound capacity of the wallet and requests a new inbound
+// channel if it is below the target.
+func (im *InboundManager) RunOnce(ctx context.Context) error {
+	err := im.runOnce(ctx)
+	im.mtx.Lock()
+	notify := err != nil && (im.lastErr == nil || im.lastErr.Error() != err.Error())
+	im.lastRun = time.Now()
+	im.lastErr = err
+	im.mtx.Unlock()
+	if notify && im.cfg.OnError != nil {
+		im.cfg.OnError(err)
+	}
+	return err
+}
+
+func (im *InboundManager) runOnce(ctx context.Context) error {
+	res, err := im.cfg.LN.ListChannels(ctx, &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return err
+	}
+	var inbound dcrutil.Amount
+	for _, c := range res.Channels {
+		if c.Active {
+			inbound += dcrutil.Amount(c.RemoteBalance)
+		}
+	}
+	im.mtx.Lock()
+	im.inbound = inbound
+	im.mtx.Unlock()
+	if inbound >= im.cfg.MinInbound {
+		return nil
+	}
+
+	pending, err := im.cfg.LN.PendingChannels(ctx, &lnrpc.PendingChannelsRequest{})
+	if err != nil {
+		return err
+	}
+	if len(pending.PendingOpenChannels) > 0 {
+		im.log.Debugf("Waiting for %d pending channels to open",
+			len(pending.PendingOpenChannels))
+		return nil
+	}
+
+	// Fail early when not even a free channel fits in the budget.
+	if err := im.checkBudget(0); err != nil {
+		return err
+	}
+
+	var fee dcrutil.Amount
+	checkFee := func(f dcrutil.Amount) error {
+		fee = f
+		return im.checkBudget(f)
+	}
+	reason := fmt.Sprintf("inbound capacity %s below %s", inbound,
+		im.cfg.MinInbound)
+	im.log.Infof("Requesting inbound channel of %s (%s)", im.cfg.ChannelSize,
+		reason)
+	chanPoint, err := im.cfg.requestChannel(ctx, im.cfg.ChannelSize, checkFee)
+	if err != nil {
+		return fmt.Errorf("unable to acquire inbound channel: %w", err)
+	}
+	im.log.Infof("Acquired inbound channel %s of %s for %s", chanPoint,
+		im.cfg.ChannelSize, fee)
+
+	im.recordAction(InboundAction{
+		Time:         time.Now(),
+		ChannelPoint: chanPoint,
+		Capacity:     im.cfg.ChannelSize,
+		Fee:          fee,
+		Reason:       reason,
+	})
+	return nil
+}
+
+// Run runs the inbound liquidity manager until the context is canceled.
+func (im *InboundManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(im.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := im.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			im.log.Warnf("Inbound liquidity check failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+)
+
+// TestInboundManagerAcquiresChannel tests that the inbound liquidity manager
+// requests channels when the inbound capacity is low, while respecting the
+// fee budget.
+func TestInboundManagerAcquiresChannel(t *testing.T) {
+	ln := &fakeLN{
+		channels: []*lnrpc.Channel{{
+			Active:        true,
+			RemoteBalance: 1e7,
+		}, {
+			// Inactive channels do not count towards inbound.
+			RemoteBalance: 1e9,
+		}},
+	}
+	var requested []dcrutil.Amount
+	lpFee := dcrutil.Amount(6000)
+	var actions []InboundAction
+	var errs []error
+	cfg := InboundConfig{
+		LN:          ln,
+		MinInbound:  1e8,
+		ChannelSize: 5e8,
+		FeeBudget:   10000,
+		StateFile:   filepath.Join(t.TempDir(), "inbound.json"),
+		OnAction:    func(a InboundAction) { actions = append(actions, a) },
+		OnError:     func(err error) { errs = append(errs, err) },
+		requestChannel: func(_ context.Context, size dcrutil.Amount,
+			checkFee func(dcrutil.Amount) error) (string, error) {
+			if err := checkFee(lpFee); err != nil {
+				return "", err
+			}
+			requested = append(requested, size)
+			return "chanpoint:0", nil
+		},
+	}
+	im, err := NewInboundManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := im.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 || requested[0] != 5e8 {
+		t.Fatalf("unexpected requested channels: %v", requested)
+	}
+	if len(actions) != 1 || actions[0].Fee != lpFee ||
+		actions[0].ChannelPoint != "chanpoint:0" {
+		t.Fatalf("unexpected actions: %v", actions)
+	}
+
+	// Do not request another channel while one is pending.
+	ln.pending = []*lnrpc.PendingChannelsResponse_PendingOpenChannel{{}}
+	if err := im.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 {
+		t.Fatalf("unexpected nb of requested channels: %d", len(requested))
+	}
+
+	// The fee budget is enforced across restarts and the error is only
+	// notified once.
+	ln.pending = nil
+	im, err = NewInboundManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err = im.RunOnce(context.Background())
+		if !errors.Is(err, errInboundBudget) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(requested) != 1 {
+		t.Fatalf("unexpected nb of requested channels: %d", len(requested))
+	}
+	if len(errs) != 1 {
+		t.Fatalf("unexpected nb of notified errors: %d", len(errs))
+	}
+	st := im.Status()
+	if st.FeesSpent != lpFee || st.Inbound != 1e7 || len(st.Acquisitions) != 1 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	// Nothing is requested when the inbound capacity is above the target.
+	ln.channels[0].RemoteBalance = 2e8
+	if err := im.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := im.Status(); st.LastErr != nil || st.Inbound != 2e8 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
@@ -0,0 +1,555 @@
+package embeddeddcrlnd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrlnd/lnrpc"
+	"github.com/decred/slog"
+)
+
+// scbFilePrefix and scbFileSuffix are used to name the files of exported
+// static channel backups.
+const (
+	scbFilePrefix  = "channels-"
+	scbFileSuffix  = ".backup"
+	scbFileTimeFmt = "20060102-150405"
+)
+
+// SCBFileName returns the name of the file of a static channel backup exported
+// at the given time.
+func SCBFileName(t time.Time) string {
+	return scbFilePrefix + t.UTC().Format(scbFileTimeFmt) + scbFileSuffix
+}
+
+// SCBBackup is an exported static channel backup (SCB) of all channels of the
+// wallet.
+type SCBBackup struct {
+	Time time.Time
+
+	// Data is the packed (encrypted) multi channel backup.
+	Data []byte
+
+	// ChanPoints are the channels included in the backup.
+	ChanPoints []string
+}
+
+// FileName returns the name of the file used to store the backup.
+func (b *SCBBackup) FileName() string {
+	return SCBFileName(b.Time)
+}
+
+// chanPointsHash returns a hash of the channels included in the backup. The
+// packed backup is encrypted with a new nonce on every export, so this is
+// used to detect whether the set of channels changed.
+func (b *SCBBackup) chanPointsHash() string {
+	cps := append([]string(nil), b.ChanPoints...)
+	sort.Strings(cps)
+	h := sha256.Sum256([]byte(strings.Join(cps, ",")))
+	return hex.EncodeToString(h[:])
+}
+
+// SCBDestination is a destination to which static channel backups are
+// uploaded.
+type SCBDestination interface {
+	// Name identifies the destination.
+	Name() string
+
+	// StoreSCB stores the backup in the destination.
+	StoreSCB(ctx context.Context, b *SCBBackup) error
+}
+
+// LocalSCBDestination stores backups as files in a local dir.
+type LocalSCBDestination struct {
+	Dir string
+
+	// Keep is the number of backup files kept in the dir. Older backups
+	// are removed. If zero, all backups are kept.
+	Keep int
+}
+
+// Name is part of the SCBDestination interface.
+func (d *LocalSCBDestination) Name() string {
+	return "local:" + d.Dir
+}
+
+// StoreSCB is part of the SCBDestination interface.
+func (d *LocalSCBDestination) StoreSCB(ctx context.Context, b *SCBBackup) error {
+	if err := os.MkdirAll(d.Dir, 0o700); err != nil {
+		return err
+	}
+	fname := filepath.Join(d.Dir, b.FileName())
+	tmp := fname + ".tmp"
+	if err := os.WriteFile(tmp, b.Data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		return err
+	}
+	if d.Keep <= 0 {
+		return nil
+	}
+
+	files, err := ListSCBFiles(d.Dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > d.Keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// SFTPSCBDestination uploads backups to a remote host through the sftp
+// command. Authentication must be configured such that sftp does not prompt
+// for a password (for example, by using an ssh key).
+type SFTPSCBDestination struct {
+	// Target is the remote dir, in the form [user@]host:[dir].
+	Target string
+
+	// Command is the sftp command. If empty, "sftp" is used.
+	Command string
+
+	// Args are additional arguments passed to the sftp command (for
+	// example, "-i" and the path to an ssh key).
+	Args []string
+
+	// TmpDir is the local dir where backups are written before being
+	// uploaded. If empty, the default dir for temporary files is used.
+	TmpDir string
+}
+
+// Name is part of the SCBDestination interface.
+func (d *SFTPSCBDestination) Name() string {
+	return "sftp:" + d.Target
+}
+
+// StoreSCB is part of the SCBDestination interface.
+func (d *SFTPSCBDestination) StoreSCB(ctx context.Context, b *SCBBackup) error {
+	host, dir, _ := strings.Cut(d.Target, ":")
+	if host == "" {
+		return fmt.Errorf("invalid sftp target %q", d.Target)
+	}
+
+	f, err := os.CreateTemp(d.TmpDir, "scb-*"+scbFileSuffix)
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	_, err = f.Write(b.Data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	var batch bytes.Buffer
+	if dir != "" {
+		fmt.Fprintf(&batch, "cd %q\n", dir)
+	}
+	fmt.Fprintf(&batch, "put %q %q\n", tmp, b.FileName())
+
+	command := d.Command
+	if command == "" {
+		command = "sftp"
+	}
+	args := append([]string{"-b", "-"}, d.Args...)
+	args = append(args, host)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdin = &batch
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("sftp upload failed: %v: %s", err,
+			strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// FuncSCBDestination is a destination that stores backups by calling a
+// function. It may be used to implement destinations outside this package.
+type FuncSCBDestination struct {
+	DestName string
+	Store    func(ctx context.Context, b *SCBBackup) error
+}
+
+// Name is part of the SCBDestination interface.
+func (d *FuncSCBDestination) Name() string {
+	return d.DestName
+}
+
+// StoreSCB is part of the SCBDestination interface.
+func (d *FuncSCBDestination) StoreSCB(ctx context.Context, b *SCBBackup) error {
+	return d.Store(ctx, b)
+}
+
+// SCBBackupsConfig is the configuration of the scheduled channel backups.
+type SCBBackupsConfig struct {
+	// LN is the client used to export the channel backups.
+	LN lnrpc.LightningClient
+
+	// Destinations are where the backups are stored.
+	Destinations []SCBDestination
+
+	// Interval is the interval between backups. If zero, a default of
+	// 24 hours is used. Backups are also made as soon as the set of
+	// channels of the wallet changes.
+	Interval time.Duration
+
+	// SkipUnchanged skips storing a backup in a destination when the set
+	// of channels did not change since the last backup stored in it.
+	SkipUnchanged bool
+
+	// StateFile is where the status of the destinations is stored.
+	StateFile string
+
+	// OnBackup is called after a backup is stored in a destination or
+	// fails to be stored in it.
+	OnBackup func(dest string, b *SCBBackup, err error)
+
+	Log slog.Logger
+}
+
+// SCBDestinationStatus is the status of the backups stored in a destination.
+type SCBDestinationStatus struct {
+	Name        string    `json:"name"`
+	LastBackup  time.Time `json:"last_backup"`
+	NumChannels int       `json:"num_channels"`
+	ChansHash   string    `json:"chans_hash"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastErr     string    `json:"last_err,omitempty"`
+}
+
+// SCBBackupsStatus is the status of the scheduled channel backups.
+type SCBBackupsStatus struct {
+	LastRun      time.Time
+	LastErr      error
+	Interval     time.Duration
+	Destinations []SCBDestinationStatus
+}
+
+// SCBBackups periodically exports the static channel backups of the wallet
+// and stores them in a set of destinations.
+type SCBBackups struct {
+	cfg SCBBackupsConfig
+	log slog.Logger
+
+	runMtx sync.Mutex
+
+	mtx     sync.Mutex
+	dests   map[string]SCBDestinationStatus
+	lastRun time.Time
+	lastErr error
+}
+
+// NewSCBBackups creates a new scheduler of channel backups.
+func NewSCBBackups(cfg SCBBackupsConfig) (*SCBBackups, error) {
+	if cfg.LN == nil {
+		return nil, errors.New("LN client is not specified")
+	}
+	if len(cfg.Destinations) == 0 {
+		return nil, errors.New("no backup destinations specified")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	log := cfg.Log
+	if log == nil {
+		log = slog.Disabled
+	}
+
+	s := &SCBBackups{
+		cfg:   cfg,
+		log:   log,
+		dests: make(map[string]SCBDestinationStatus),
+	}
+	if cfg.StateFile != "" {
+		data, err := os.ReadFile(cfg.StateFile)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &s.dests); err != nil {
+				return nil, fmt.Errorf("unable to decode SCB "+
+					"backups state: %v", err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// saveState saves the status of the destinations. Must be called with the
+// mutex held.
+func (s *SCBBackups) saveState() error {
+	if s.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.dests)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.StateFile), 0o700); err != nil {
+		return err
+	}
+	tmp := s.cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.cfg.StateFile)
+}
+
+// Status returns the current status of the channel backups.
+func (s *SCBBackups) Status() SCBBackupsStatus {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	st := SCBBackupsStatus{
+		LastRun:  s.lastRun,
+		LastErr:  s.lastErr,
+		Interval: s.cfg.Interval,
+	}
+	for _, d := range s.cfg.Destinations {
+		ds, ok := s.dests[d.Name()]
+		if !ok {
+			ds.Name = d.Name()
+		}
+		st.Destinations = append(st.Destinations, ds)
+	}
+	return st
+}
+
+// ExportSCB exports the static channel backup of all channels of the wallet.
+func ExportSCB(ctx context.Context, ln lnrpc.LightningClient) (*SCBBackup, error) {
+	res, err := ln.ExportAllChannelBackups(ctx, &lnrpc.ChanBackupExportRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.MultiChanBackup == nil {
+		return nil, errors.New("wallet did not return a multi channel backup")
+	}
+	b := &SCBBackup{
+		Time: time.Now(),
+		Data: res.MultiChanBackup.MultiChanBackup,
+	}
+	for _, cp := range res.MultiChanBackup.ChanPoints {
+		txid, err := lnrpc.GetChanPointFundingTxid(cp)
+		if err != nil {
+			return nil, err
+		}
+		b.ChanPoints = append(b.ChanPoints, fmt.Sprintf("%s:%d", txid,
+			cp.OutputIndex))
+	}
+	return b, nil
+}
+
+// RunOnce exports the channel backups and stores them in every destination.
+// If force is false and SkipUnchanged is set, destinations that already
+// store a backup of the current set of channels are skipped.
+func (s *SCBBackups) RunOnce(ctx context.Context, force bool) error {
+	s.runMtx.Lock()
+	defer s.runMtx.Unlock()
+
+	err := s.runOnce(ctx, force)
+	s.mtx.Lock()
+	s.lastRun = time.Now()
+	s.lastErr = err
+	s.mtx.Unlock()
+	return err
+}
+
+func (s *SCBBackups) runOnce(ctx context.Context, force bool) error {
+	b, err := ExportSCB(ctx, s.cfg.LN)
+	if err != nil {
+		return fmt.Errorf("unable to export channel backups: %v", err)
+	}
+	hash := b.chanPointsHash()
+
+	var errs []string
+	for _, d := range s.cfg.Destinations {
+		name := d.Name()
+		s.mtx.Lock()
+		ds := s.dests[name]
+		s.mtx.Unlock()
+		if !force && s.cfg.SkipUnchanged && ds.LastErr == "" && ds.ChansHash == hash {
+			s.log.Debugf("Skipping unchanged SCB backup to %s", name)
+			continue
+		}
+
+		err := d.StoreSCB(ctx, b)
+		ds.Name = name
+		ds.LastAttempt = time.Now()
+		if err != nil {
+			s.log.Warnf("Unable to store SCB backup to %s: %v", name, err)
+			ds.LastErr = err.Error()
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		} else {
+			s.log.Infof("Stored SCB backup of %d channels to %s",
+				len(b.ChanPoints), name)
+			ds.LastErr = ""
+			ds.LastBackup = b.Time
+			ds.NumChannels = len(b.ChanPoints)
+			ds.ChansHash = hash
+		}
+
+		s.mtx.Lock()
+		s.dests[name] = ds
+		saveErr := s.saveState()
+		s.mtx.Unlock()
+		if saveErr != nil {
+			s.log.Errorf("Unable to save SCB backups state: %v", saveErr)
+		}
+
+		if s.cfg.OnBackup != nil {
+			s.cfg.OnBackup(name, b, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to store SCB backups: %s",
+			strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// watchChanges sends to changed whenever the wallet reports a change in its
+// channel backups. It returns when the context is canceled or the
+// subscription fails.
+func (s *SCBBackups) watchChanges(ctx context.Context, changed chan struct{}) {
+	stream, err := s.cfg.LN.SubscribeChannelBackups(ctx,
+		&lnrpc.ChannelBackupSubscription{})
+	if err != nil {
+		s.log.Warnf("Unable to subscribe to channel backups: %v", err)
+		return
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if ctx.Err() == nil {
+				s.log.Warnf("Channel backups subscription failed: %v", err)
+			}
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run stores a backup on start, then periodically and whenever the channels
+// of the wallet change, until the context is canceled.
+func (s *SCBBackups) Run(ctx context.Context) error {
+	changed := make(chan struct{}, 1)
+	go s.watchChanges(ctx, changed)
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		err := s.RunOnce(ctx, false)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.log.Warnf("SCB backup failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ListSCBFiles lists the backup files in the dir, ordered from oldest to
+// newest.
+func ListSCBFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, scbFilePrefix) ||
+			!strings.HasSuffix(name, scbFileSuffix) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	// The timestamp in the file names sorts lexicographically.
+	sort.Strings(files)
+	return files, nil
+}
+
+// LatestSCBFile returns the most recent backup file in the dir.
+func LatestSCBFile(dir string) (string, error) {
+	files, err := ListSCBFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no channel backup files in %s", dir)
+	}
+	return files[len(files)-1], nil
+}
+
+// VerifySCB verifies that the packed multi channel backup can be decrypted by
+// the wallet.
+func VerifySCB(ctx context.Context, ln lnrpc.LightningClient, packedMulti []byte) error {
+	_, err := ln.VerifyChanBackup(ctx, &lnrpc.ChanBackupSnapshot{
+		MultiChanBackup: &lnrpc.MultiChanBackup{
+			MultiChanBackup: packedMulti,
+		},
+	})
+	return err
+}
+
+// RestoreSCB restores the channels of the packed multi channel backup. The
+// remote peers of the restored channels are asked to force-close them, so
+// that the funds of the wallet are recovered on-chain.
+func RestoreSCB(ctx context.Context, ln lnrpc.LightningClient, packedMulti []byte) error {
+	if err := VerifySCB(ctx, ln, packedMulti); err != nil {
+		return fmt.Errorf("invalid channel backup: %v", err)
+	}
+	_, err := ln.RestoreChannelBackups(ctx, &lnrpc.RestoreChanBackupRequest{
+		Backup: &lnrpc.RestoreChanBackupRequest_MultiChanBackup{
+			MultiChanBackup: packedMulti,
+		},
+	})
+	return err
+}
+
+// RestoreSCBFile restores the channels of a backup file. If path is a dir,
+// the most recent backup file in it is restored. It returns the restored
+// file.
+func RestoreSCBFile(ctx context.Context, ln lnrpc.LightningClient, path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		path, err = LatestSCBFile(path)
+		if err != nil {
+			return "", err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read channel backup: %v", err)
+	}
+	return path, RestoreSCB(ctx, ln, data)
+}
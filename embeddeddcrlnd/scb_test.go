@@ -0,0 +1,183 @@
+package embeddeddcrlnd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrlnd/lnrpc"
+	"google.golang.org/grpc"
+)
+
+// fakeSCBLN is a fake LN client that implements the calls used by the channel
+// backups.
+type fakeSCBLN struct {
+	lnrpc.LightningClient
+
+	chanPoints []*lnrpc.ChannelPoint
+	exports    int
+	restored   [][]byte
+}
+
+func (ln *fakeSCBLN) ExportAllChannelBackups(context.Context, *lnrpc.ChanBackupExportRequest, ...grpc.CallOption) (*lnrpc.ChanBackupSnapshot, error) {
+	ln.exports++
+	return &lnrpc.ChanBackupSnapshot{
+		MultiChanBackup: &lnrpc.MultiChanBackup{
+			ChanPoints:      ln.chanPoints,
+			MultiChanBackup: []byte{byte(ln.exports)},
+		},
+	}, nil
+}
+
+func (ln *fakeSCBLN) VerifyChanBackup(_ context.Context, req *lnrpc.ChanBackupSnapshot, _ ...grpc.CallOption) (*lnrpc.VerifyChanBackupResponse, error) {
+	if len(req.MultiChanBackup.MultiChanBackup) == 0 {
+		return nil, errors.New("empty backup")
+	}
+	return &lnrpc.VerifyChanBackupResponse{}, nil
+}
+
+func (ln *fakeSCBLN) RestoreChannelBackups(_ context.Context, req *lnrpc.RestoreChanBackupRequest, _ ...grpc.CallOption) (*lnrpc.RestoreBackupResponse, error) {
+	ln.restored = append(ln.restored, req.GetMultiChanBackup())
+	return &lnrpc.RestoreBackupResponse{}, nil
+}
+
+func fakeChanPoint(idx uint32) *lnrpc.ChannelPoint {
+	return &lnrpc.ChannelPoint{
+		FundingTxid: &lnrpc.ChannelPoint_FundingTxidBytes{
+			FundingTxidBytes: make([]byte, 32),
+		},
+		OutputIndex: idx,
+	}
+}
+
+// TestSCBBackups tests storing channel backups in multiple destinations.
+func TestSCBBackups(t *testing.T) {
+	ln := &fakeSCBLN{chanPoints: []*lnrpc.ChannelPoint{fakeChanPoint(0)}}
+	rootDir := t.TempDir()
+	localDir := filepath.Join(rootDir, "scb")
+	stateFile := filepath.Join(rootDir, "scbbackups.json")
+
+	failDest := true
+	var remote [][]byte
+	var failures int
+	cfg := SCBBackupsConfig{
+		LN: ln,
+		Destinations: []SCBDestination{
+			&LocalSCBDestination{Dir: localDir, Keep: 2},
+			&FuncSCBDestination{
+				DestName: "remote",
+				Store: func(_ context.Context, b *SCBBackup) error {
+					if failDest {
+						return errors.New("remote offline")
+					}
+					remote = append(remote, b.Data)
+					return nil
+				},
+			},
+		},
+		SkipUnchanged: true,
+		StateFile:     stateFile,
+		OnBackup: func(dest string, b *SCBBackup, err error) {
+			if err != nil {
+				failures++
+			}
+		},
+	}
+	s, err := NewSCBBackups(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := s.RunOnce(ctx, false); err == nil {
+		t.Fatal("expected error from failing destination")
+	}
+	if failures != 1 {
+		t.Fatalf("unexpected nb of failures: got %d, want 1", failures)
+	}
+	st := s.Status()
+	if len(st.Destinations) != 2 {
+		t.Fatalf("unexpected nb of destinations: %d", len(st.Destinations))
+	}
+	if st.Destinations[0].LastErr != "" || st.Destinations[0].NumChannels != 1 {
+		t.Fatalf("unexpected local status: %+v", st.Destinations[0])
+	}
+	if st.Destinations[1].LastErr == "" {
+		t.Fatalf("expected error in remote status")
+	}
+
+	// The unchanged local backup is skipped, but the failed remote is
+	// retried.
+	failDest = false
+	if err := s.RunOnce(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(remote) != 1 {
+		t.Fatalf("unexpected nb of remote backups: %d", len(remote))
+	}
+	files, err := ListSCBFiles(localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("unexpected nb of local backups: %d", len(files))
+	}
+
+	// A new channel triggers new backups in every destination. Only the
+	// last 2 local backups are kept.
+	ln.chanPoints = append(ln.chanPoints, fakeChanPoint(1))
+	time.Sleep(time.Second)
+	if err := s.RunOnce(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if err := s.RunOnce(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(remote) != 3 {
+		t.Fatalf("unexpected nb of remote backups: %d", len(remote))
+	}
+	files, err = ListSCBFiles(localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("unexpected nb of local backups: %d", len(files))
+	}
+
+	// The state is reloaded on restart.
+	s, err = NewSCBBackups(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st = s.Status()
+	for _, ds := range st.Destinations {
+		if ds.NumChannels != 2 || ds.LastErr != "" {
+			t.Fatalf("unexpected reloaded status: %+v", ds)
+		}
+	}
+
+	// Restoring the dir restores the latest backup.
+	latest, err := LatestSCBFile(localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreSCBFile(ctx, ln, localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != latest {
+		t.Fatalf("unexpected restored file: got %s, want %s", restored, latest)
+	}
+	want, err := os.ReadFile(latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ln.restored) != 1 || !bytes.Equal(ln.restored[0], want) {
+		t.Fatalf("unexpected restored backup")
+	}
+}
@@ -0,0 +1,153 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrlnd/lnrpc/wtclientrpc"
+)
+
+// ErrWatchtowerClientInactive is returned when the watchtower client of the
+// wallet is not active.
+var ErrWatchtowerClientInactive = errors.New("watchtower client is not active")
+
+// Watchtower is a tower that the wallet backs up its channel states to.
+type Watchtower struct {
+	PubKey    string
+	Addresses []string
+
+	// Active is true if the tower is a candidate for new backup sessions.
+	Active      bool
+	NumSessions uint32
+}
+
+// WatchtowerStatus is the breach-watch status of the wallet.
+type WatchtowerStatus struct {
+	Towers []Watchtower
+
+	NumBackups           uint32
+	NumPendingBackups    uint32
+	NumFailedBackups     uint32
+	NumSessionsAcquired  uint32
+	NumSessionsExhausted uint32
+}
+
+// Protected returns true if there is an active tower and all channel states
+// have been backed up to it, such that the channels are watched while the
+// wallet is offline.
+func (st *WatchtowerStatus) Protected() bool {
+	for _, t := range st.Towers {
+		if t.Active {
+			return st.NumPendingBackups == 0
+		}
+	}
+	return false
+}
+
+// Watchtowers manages the towers used by the watchtower client of a dcrlnd
+// wallet.
+type Watchtowers struct {
+	wtc wtclientrpc.WatchtowerClientClient
+}
+
+// NewWatchtowers returns a manager for the towers of the watchtower client.
+func NewWatchtowers(wtc wtclientrpc.WatchtowerClientClient) *Watchtowers {
+	return &Watchtowers{wtc: wtc}
+}
+
+// Watchtowers returns the manager for the towers of the running dcrlnd
+// instance. The instance must have been started with the watchtower client
+// enabled.
+func (lndc *Dcrlnd) Watchtowers() *Watchtowers {
+	return NewWatchtowers(wtclientrpc.NewWatchtowerClientClient(lndc.conn))
+}
+
+// wtError converts errors returned when the watchtower client is not active.
+func wtError(err error) error {
+	if err != nil && strings.Contains(err.Error(), wtclientrpc.ErrWtclientNotActive.Error()) {
+		return ErrWatchtowerClientInactive
+	}
+	return err
+}
+
+// parseTowerPubKey decodes the hex encoded pubkey of a tower.
+func parseTowerPubKey(s string) ([]byte, error) {
+	pubkey, err := hex.DecodeString(s)
+	if err != nil || len(pubkey) != 33 {
+		return nil, fmt.Errorf("invalid tower pubkey %q", s)
+	}
+	return pubkey, nil
+}
+
+// AddTower adds a tower to back up channel states to. The uri must be in the
+// form pubkey@host:port. Adding an existing tower adds the address to it.
+func (w *Watchtowers) AddTower(ctx context.Context, uri string) error {
+	pk, addr, ok := strings.Cut(strings.TrimSpace(uri), "@")
+	if !ok || addr == "" {
+		return fmt.Errorf("tower uri %q is not in the form pubkey@host:port", uri)
+	}
+	pubkey, err := parseTowerPubKey(pk)
+	if err != nil {
+		return err
+	}
+	_, err = w.wtc.AddTower(ctx, &wtclientrpc.AddTowerRequest{
+		Pubkey:  pubkey,
+		Address: addr,
+	})
+	return wtError(err)
+}
+
+// RemoveTower removes the address from a tower. If addr is empty, the tower
+// is removed and no new backup sessions are negotiated with it.
+func (w *Watchtowers) RemoveTower(ctx context.Context, pubkey, addr string) error {
+	pk, err := parseTowerPubKey(pubkey)
+	if err != nil {
+		return err
+	}
+	_, err = w.wtc.RemoveTower(ctx, &wtclientrpc.RemoveTowerRequest{
+		Pubkey:  pk,
+		Address: addr,
+	})
+	return wtError(err)
+}
+
+// ListTowers lists the towers of the watchtower client.
+func (w *Watchtowers) ListTowers(ctx context.Context) ([]Watchtower, error) {
+	res, err := w.wtc.ListTowers(ctx, &wtclientrpc.ListTowersRequest{})
+	if err != nil {
+		return nil, wtError(err)
+	}
+	towers := make([]Watchtower, len(res.Towers))
+	for i, t := range res.Towers {
+		towers[i] = Watchtower{
+			PubKey:      hex.EncodeToString(t.Pubkey),
+			Addresses:   t.Addresses,
+			Active:      t.ActiveSessionCandidate,
+			NumSessions: t.NumSessions,
+		}
+	}
+	return towers, nil
+}
+
+// Status returns the breach-watch status of the watchtower client.
+func (w *Watchtowers) Status(ctx context.Context) (*WatchtowerStatus, error) {
+	towers, err := w.ListTowers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := w.wtc.Stats(ctx, &wtclientrpc.StatsRequest{})
+	if err != nil {
+		return nil, wtError(err)
+	}
+	return &WatchtowerStatus{
+		Towers:               towers,
+		NumBackups:           stats.NumBackups,
+		NumPendingBackups:    stats.NumPendingBackups,
+		NumFailedBackups:     stats.NumFailedBackups,
+		NumSessionsAcquired:  stats.NumSessionsAcquired,
+		NumSessionsExhausted: stats.NumSessionsExhausted,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package embeddeddcrlnd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrlnd/lnrpc/wtclientrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeWtClient is a fake watchtower client that keeps the towers in memory.
+type fakeWtClient struct {
+	wtclientrpc.WatchtowerClientClient
+
+	inactive bool
+	towers   map[string][]string
+	stats    wtclientrpc.StatsResponse
+}
+
+func (c *fakeWtClient) err() error {
+	if c.inactive {
+		return status.Error(codes.Unknown, wtclientrpc.ErrWtclientNotActive.Error())
+	}
+	return nil
+}
+
+func (c *fakeWtClient) AddTower(_ context.Context, req *wtclientrpc.AddTowerRequest, _ ...grpc.CallOption) (*wtclientrpc.AddTowerResponse, error) {
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	pk := hex.EncodeToString(req.Pubkey)
+	c.towers[pk] = append(c.towers[pk], req.Address)
+	return &wtclientrpc.AddTowerResponse{}, nil
+}
+
+func (c *fakeWtClient) RemoveTower(_ context.Context, req *wtclientrpc.RemoveTowerRequest, _ ...grpc.CallOption) (*wtclientrpc.RemoveTowerResponse, error) {
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	delete(c.towers, hex.EncodeToString(req.Pubkey))
+	return &wtclientrpc.RemoveTowerResponse{}, nil
+}
+
+func (c *fakeWtClient) ListTowers(context.Context, *wtclientrpc.ListTowersRequest, ...grpc.CallOption) (*wtclientrpc.ListTowersResponse, error) {
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	res := &wtclientrpc.ListTowersResponse{}
+	for pk, addrs := range c.towers {
+		pubkey, _ := hex.DecodeString(pk)
+		res.Towers = append(res.Towers, &wtclientrpc.Tower{
+			Pubkey:                 pubkey,
+			Addresses:              addrs,
+			ActiveSessionCandidate: true,
+		})
+	}
+	return res, nil
+}
+
+func (c *fakeWtClient) Stats(context.Context, *wtclientrpc.StatsRequest, ...grpc.CallOption) (*wtclientrpc.StatsResponse, error) {
+	if err := c.err(); err != nil {
+		return nil, err
+	}
+	return &c.stats, nil
+}
+
+// TestWatchtowers tests managing the towers of the watchtower client.
+func TestWatchtowers(t *testing.T) {
+	ctx := context.Background()
+	wtc := &fakeWtClient{towers: make(map[string][]string)}
+	w := NewWatchtowers(wtc)
+	pubkey := "02" + strings.Repeat("ab", 32)
+
+	for _, uri := range []string{"", pubkey, "abcd@127.0.0.1:9911", "@127.0.0.1:9911"} {
+		if err := w.AddTower(ctx, uri); err == nil {
+			t.Fatalf("expected error adding tower %q", uri)
+		}
+	}
+
+	// Not protected without towers.
+	st, err := w.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Protected() {
+		t.Fatalf("unexpected protected status without towers")
+	}
+
+	if err := w.AddTower(ctx, pubkey+"@127.0.0.1:9911"); err != nil {
+		t.Fatal(err)
+	}
+	st, err = w.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Protected() || len(st.Towers) != 1 || st.Towers[0].PubKey != pubkey ||
+		st.Towers[0].Addresses[0] != "127.0.0.1:9911" {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+
+	// Pending backups are not protected yet.
+	wtc.stats.NumPendingBackups = 1
+	if st, _ := w.Status(ctx); st.Protected() {
+		t.Fatalf("unexpected protected status with pending backups")
+	}
+
+	if err := w.RemoveTower(ctx, pubkey, ""); err != nil {
+		t.Fatal(err)
+	}
+	if towers, _ := w.ListTowers(ctx); len(towers) != 0 {
+		t.Fatalf("unexpected towers after remove: %v", towers)
+	}
+
+	// Errors of inactive clients are converted.
+	wtc.inactive = true
+	if _, err := w.Status(ctx); !errors.Is(err, ErrWatchtowerClientInactive) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package e2etests
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestAddressBookExportImport tests exporting the address book of a client and
+// merging it into the address book of a different client.
+func TestAddressBookExportImport(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(dave, bob)
+
+	// Alice renames, tags and verifies Bob.
+	assert.NilErr(t, alice.RenameUser(bob.PublicID(), "bobby"))
+	assert.NilErr(t, alice.AddContactTags(bob.PublicID(), "friend", "work"))
+	assert.NilErr(t, alice.MarkContactVerified(bob.PublicID(), true))
+	assert.NilErr(t, dave.AddContactTags(bob.PublicID(), "gamer"))
+
+	// Short passphrases are rejected.
+	_, err := alice.ExportAddressBook("short")
+	assert.NonNilErr(t, err)
+
+	passphrase := "correct horse battery"
+	bundle, err := alice.ExportAddressBook(passphrase)
+	assert.NilErr(t, err)
+
+	// The export cannot be decoded with the wrong passphrase.
+	_, err = client.DecodeAddressBookExport("wrong passphrase", bundle)
+	assert.NonNilErr(t, err)
+
+	export, err := client.DecodeAddressBookExport(passphrase, bundle)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(export.Contacts), 2)
+
+	// Dave imports keeping the local data. Bob is merged and Charlie is
+	// reported as unknown.
+	res, err := dave.ImportAddressBook(export, client.ABMergeKeepLocal)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res.Merged, []client.UserID{bob.PublicID()})
+	assert.DeepEqual(t, len(res.Conflicts), 0)
+	assert.DeepEqual(t, len(res.Unknown), 1)
+	assert.DeepEqual(t, res.Unknown[0].ID.Identity, charlie.PublicID())
+
+	nick, err := dave.UserNick(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, nick, "bob")
+	meta, err := dave.ContactMetadata(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, meta.Tags, []string{"friend", "gamer", "work"})
+	cv, err := dave.ContactVerification(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cv.IsVerified(), true)
+
+	// Importing again does not change anything.
+	res, err = dave.ImportAddressBook(export, client.ABMergeKeepLocal)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res.Unchanged, []client.UserID{bob.PublicID()})
+
+	// Preferring the imported data renames Bob.
+	res, err = dave.ImportAddressBook(export, client.ABMergePreferImported)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res.Merged, []client.UserID{bob.PublicID()})
+	nick, err = dave.UserNick(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, nick, "bobby")
+}
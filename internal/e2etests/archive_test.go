@@ -0,0 +1,67 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestArchiveChats tests archiving and unarchiving chats, including the
+// automatic unarchiving of chats when new messages are received.
+func TestArchiveChats(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	unarchivedChan := make(chan zkidentity.ShortID, 5)
+	alice.handle(client.OnChatUnarchivedNtfn(func(id zkidentity.ShortID) {
+		unarchivedChan <- id
+	}))
+	pmChan := make(chan struct{}, 5)
+	alice.handle(client.OnPMNtfn(func(*client.RemoteUser, rpc.RMPrivateMessage, time.Time) {
+		pmChan <- struct{}{}
+	}))
+
+	// Archive and unarchive the chat with bob.
+	assert.NilErr(t, alice.ArchiveChat(bob.PublicID()))
+	assert.DeepEqual(t, alice.IsChatArchived(bob.PublicID()), true)
+	archived, err := alice.ListArchivedChats()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(archived), 1)
+	assert.NilErr(t, alice.UnarchiveChat(bob.PublicID()))
+	assert.DeepEqual(t, alice.IsChatArchived(bob.PublicID()), false)
+	assert.ChanNotWritten(t, unarchivedChan, 100*time.Millisecond)
+
+	// Archiving an unknown chat fails.
+	if err := alice.ArchiveChat(zkidentity.ShortID{31: 1}); err == nil {
+		t.Fatalf("expected error archiving unknown chat")
+	}
+
+	// A new PM unarchives the chat.
+	assert.NilErr(t, alice.ArchiveChat(bob.PublicID()))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "hello"))
+	assert.ChanWritten(t, pmChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, unarchivedChan), bob.PublicID())
+	assert.DeepEqual(t, alice.IsChatArchived(bob.PublicID()), false)
+
+	// A new GC message unarchives the GC.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	assert.NilErr(t, alice.ArchiveChat(gcID))
+	assert.NilErr(t, bob.GCMessage(gcID, "gc hello", rpc.MessageModeNormal, nil))
+	assert.DeepEqual(t, assert.ChanWritten(t, unarchivedChan), gcID)
+	assert.DeepEqual(t, alice.IsChatArchived(gcID), false)
+	archived, err = alice.ListArchivedChats()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(archived), 0)
+}
@@ -76,6 +76,20 @@ func assertClientSeesInGC(t testing.TB, c *testClient, gcID, target zkidentity.S
 	t.Fatalf("Client does not see target %s as part of GC %s", target, gcID)
 }
 
+// assertGCEventually asserts that the client's definition of the GC
+// eventually satisfies the check function.
+func assertGCEventually(t testing.TB, c *testClient, gcID zkidentity.ShortID, check func(gc *rpc.RMGroupList) bool) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		gc, err := c.GetGC(gcID)
+		if err == nil && check(&gc) {
+			return
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	t.Fatalf("Client %s GC %s did not reach the expected state", c.name, gcID)
+}
+
 // assertClientUpToDate verifies the client has no pending updates to send
 // to the server.
 func assertClientUpToDate(t testing.TB, c *testClient) {
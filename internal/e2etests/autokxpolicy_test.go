@@ -0,0 +1,74 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestAutoKXPolicy asserts that the auto KX policy is applied to transitive
+// KX events.
+func TestAutoKXPolicy(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	bobSuggestKxChan := make(chan clientintf.UserID, 5)
+	bob.handle(client.OnKXSuggested(func(ru *client.RemoteUser, target zkidentity.PublicIdentity) {
+		bobSuggestKxChan <- target.Identity
+	}))
+	daveKXChan := make(chan clientintf.UserID, 5)
+	dave.handle(client.OnKXCompleted(func(_ *clientintf.RawRVID, ru *client.RemoteUser, _ bool) {
+		daveKXChan <- ru.ID()
+	}))
+
+	// Bob rejects suggestions from Alice. He is not prompted about it.
+	err := bob.SetContactAutoKXPolicy(alice.PublicID(), clientdb.AutoKXContactPolicy{
+		Suggestions: clientdb.AutoKXActionReject,
+	})
+	assert.NilErr(t, err)
+	assert.NilErr(t, alice.SuggestKX(bob.PublicID(), charlie.PublicID()))
+	assert.ChanNotWritten(t, bobSuggestKxChan, time.Second)
+	assert.DeepEqual(t, bob.UserExists(charlie.PublicID()), false)
+
+	// Bob accepts suggestions from Alice. He is not prompted about it and
+	// Alice mediates the KX with Charlie.
+	err = bob.SetContactAutoKXPolicy(alice.PublicID(), clientdb.AutoKXContactPolicy{
+		Suggestions: clientdb.AutoKXActionAccept,
+	})
+	assert.NilErr(t, err)
+	assert.NilErr(t, alice.SuggestKX(bob.PublicID(), charlie.PublicID()))
+	assertClientsKXd(t, bob, charlie)
+	assert.ChanNotWritten(t, bobSuggestKxChan, time.Second)
+
+	// Dave rejects mediated KX requests by default. Bob's request for
+	// Alice to mediate a KX with Dave does not complete.
+	err = dave.SetAutoKXPolicy(&clientdb.AutoKXPolicy{
+		Default: clientdb.AutoKXContactPolicy{
+			MediatedKX: clientdb.AutoKXActionReject,
+		},
+	})
+	assert.NilErr(t, err)
+	assert.NilErr(t, bob.RequestMediateIdentity(alice.PublicID(), dave.PublicID()))
+	assert.ChanNotWritten(t, daveKXChan, time.Second)
+
+	// The prompt action is not valid for mediated KX requests.
+	err = dave.SetContactAutoKXPolicy(alice.PublicID(), clientdb.AutoKXContactPolicy{
+		MediatedKX: clientdb.AutoKXActionPrompt,
+	})
+	assert.NonNilErr(t, err)
+}
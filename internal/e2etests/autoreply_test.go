@@ -0,0 +1,74 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestAutoReply tests that automatic replies are sent only to the first PM
+// received from each user within the configured interval.
+func TestAutoReply(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	bobPMs := make(chan string, 5)
+	bob.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		bobPMs <- pm.Message
+	}))
+	charliePMs := make(chan string, 5)
+	charlie.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		charliePMs <- pm.Message
+	}))
+
+	// No auto reply while disabled.
+	assert.NilErr(t, bob.PM(alice.PublicID(), "hello"))
+	assert.ChanNotWritten(t, bobPMs, 500*time.Millisecond)
+
+	// Enabling auto reply without a message fails.
+	err := alice.SetAutoReply(clientdb.AutoReplyConfig{Enabled: true})
+	if err == nil {
+		t.Fatalf("expected error enabling auto reply without a message")
+	}
+
+	// Enable auto reply. Only the first PM from each user is replied to.
+	msg := "on vacation"
+	err = alice.SetAutoReply(clientdb.AutoReplyConfig{Enabled: true, Message: msg})
+	assert.NilErr(t, err)
+	cfg, err := alice.AutoReplyConfig()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cfg.Interval, client.DefaultAutoReplyInterval)
+
+	assert.NilErr(t, bob.PM(alice.PublicID(), "hello again"))
+	assert.ChanWrittenWithVal(t, bobPMs, msg)
+	assert.NilErr(t, bob.PM(alice.PublicID(), "are you there?"))
+	assert.ChanNotWritten(t, bobPMs, 500*time.Millisecond)
+	assert.NilErr(t, charlie.PM(alice.PublicID(), "hello"))
+	assert.ChanWrittenWithVal(t, charliePMs, msg)
+
+	// Replies are sent again after the interval elapses.
+	err = alice.SetAutoReply(clientdb.AutoReplyConfig{Enabled: true,
+		Message: msg, Interval: time.Millisecond})
+	assert.NilErr(t, err)
+	assert.NilErr(t, bob.PM(alice.PublicID(), "one"))
+	assert.ChanWrittenWithVal(t, bobPMs, msg)
+	time.Sleep(10 * time.Millisecond)
+	assert.NilErr(t, bob.PM(alice.PublicID(), "two"))
+	assert.ChanWrittenWithVal(t, bobPMs, msg)
+
+	// Disable auto replies.
+	assert.NilErr(t, alice.SetAutoReply(clientdb.AutoReplyConfig{}))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "three"))
+	assert.ChanNotWritten(t, bobPMs, 500*time.Millisecond)
+}
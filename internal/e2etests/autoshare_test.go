@@ -0,0 +1,91 @@
+package e2etests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/autoshare"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestAutoShare tests that files in watched dirs are automatically shared,
+// updated and unshared.
+func TestAutoShare(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+
+	freeDir := t.TempDir()
+	paidDir := t.TempDir()
+	var freeCost uint64
+	stateFile := filepath.Join(t.TempDir(), "autoshare.json")
+	cfg := autoshare.Config{
+		Client: alice.Client,
+		Dirs: []autoshare.Dir{
+			{Path: freeDir, Cost: &freeCost},
+			{Path: paidDir},
+		},
+		DefaultCost: 1000,
+		CostPerMB:   1e6,
+		Exclude:     []string{"*.tmp"},
+		StateFile:   stateFile,
+		Log:         alice.log,
+	}
+	sharer, err := autoshare.New(cfg)
+	assert.NilErr(t, err)
+
+	// Helper to list the shared files by name.
+	sharedFiles := func() map[string]clientdb.SharedFileAndShares {
+		t.Helper()
+		files, err := alice.ListLocalSharedFiles()
+		assert.NilErr(t, err)
+		res := make(map[string]clientdb.SharedFileAndShares, len(files))
+		for _, f := range files {
+			res[f.SF.Filename] = f
+		}
+		return res
+	}
+
+	// Files are shared with the cost of their dir. Excluded files are not
+	// shared.
+	freeFname := filepath.Join(freeDir, "free.txt")
+	paidFname := filepath.Join(paidDir, "paid.txt")
+	assert.NilErr(t, os.WriteFile(freeFname, []byte("free content"), 0o600))
+	assert.NilErr(t, os.WriteFile(paidFname, make([]byte, 2e6), 0o600))
+	assert.NilErr(t, os.WriteFile(filepath.Join(paidDir, "partial.tmp"), []byte("xx"), 0o600))
+	assert.NilErr(t, sharer.Scan())
+	files := sharedFiles()
+	assert.DeepEqual(t, len(files), 2)
+	assert.DeepEqual(t, files["free.txt"].Cost, uint64(0))
+	assert.DeepEqual(t, files["paid.txt"].Cost, uint64(1000+2e6))
+	oldFID := files["free.txt"].SF.FID
+
+	// Changing a file shares the new version instead of the old one.
+	modTime := time.Now().Add(time.Minute)
+	assert.NilErr(t, os.WriteFile(freeFname, []byte("new free content"), 0o600))
+	assert.NilErr(t, os.Chtimes(freeFname, modTime, modTime))
+	assert.NilErr(t, sharer.Scan())
+	files = sharedFiles()
+	assert.DeepEqual(t, len(files), 2)
+	if files["free.txt"].SF.FID == oldFID {
+		t.Fatalf("changed file was not shared again")
+	}
+
+	// The list of auto shared files is kept after a restart, so removed
+	// files are unshared.
+	sharer, err = autoshare.New(cfg)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(sharer.Shared()), 2)
+	assert.NilErr(t, os.Remove(paidFname))
+	assert.NilErr(t, sharer.Scan())
+	files = sharedFiles()
+	assert.DeepEqual(t, len(files), 1)
+	if _, ok := files["paid.txt"]; ok {
+		t.Fatalf("removed file is still shared")
+	}
+}
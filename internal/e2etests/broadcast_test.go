@@ -0,0 +1,83 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestBroadcastLists tests sending messages to broadcast lists and tracking
+// their aggregated delivery status.
+func TestBroadcastLists(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob", withSendRecvReceipts())
+	charlie := ts.newClient("charlie", withSendRecvReceipts())
+	dave := ts.newClient("dave")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	statusChan := make(chan client.BroadcastStatus, 20)
+	alice.handle(client.OnBroadcastStatusNtfn(func(bs client.BroadcastStatus) {
+		statusChan <- bs
+	}))
+	pmChans := make(map[string]chan string)
+	for _, c := range []*testClient{bob, charlie, dave} {
+		c := c
+		pmChan := make(chan string, 5)
+		pmChans[c.name] = pmChan
+		c.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+			pmChan <- pm.Message
+		}))
+	}
+
+	// Create the list and manage its members.
+	assert.NilErr(t, alice.StoreBroadcastList("news", []client.UserID{bob.PublicID()}))
+	assert.NilErr(t, alice.AddToBroadcastList("news", charlie.PublicID(), dave.PublicID(), bob.PublicID()))
+	bl, err := alice.GetBroadcastList("news")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(bl.Members), 3)
+	assert.NilErr(t, alice.RemoveFromBroadcastList("news", dave.PublicID()))
+	lists, err := alice.ListBroadcastLists()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(lists), 1)
+	assert.DeepEqual(t, lists[0].Members, []client.UserID{bob.PublicID(), charlie.PublicID()})
+
+	// Send a broadcast. Only the current members receive it.
+	bs, err := alice.SendBroadcast("news", "newsletter")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(bs.MsgIDs), 2)
+	assert.DeepEqual(t, len(bs.Errors), 0)
+	assert.DeepEqual(t, assert.ChanWritten(t, pmChans[bob.name]), "newsletter")
+	assert.DeepEqual(t, assert.ChanWritten(t, pmChans[charlie.name]), "newsletter")
+	assert.ChanNotWritten(t, pmChans[dave.name], 250*time.Millisecond)
+
+	// Wait until all members delivered the message.
+	for {
+		got := assert.ChanWritten(t, statusChan)
+		if got.ID != bs.ID {
+			t.Fatalf("unexpected broadcast ID %s", got.ID)
+		}
+		if got.Counts()[client.MsgStatusDelivered] == 2 {
+			break
+		}
+	}
+	bs, err = alice.BroadcastStatus(bs.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, bs.Statuses[bob.PublicID()], client.MsgStatusDelivered)
+	assert.DeepEqual(t, bs.Statuses[charlie.PublicID()], client.MsgStatusDelivered)
+	assert.DeepEqual(t, len(alice.ListBroadcasts()), 1)
+
+	// Removing the list prevents further broadcasts.
+	assert.NilErr(t, alice.RemoveBroadcastList("news"))
+	if _, err := alice.SendBroadcast("news", "again"); err == nil {
+		t.Fatalf("expected error sending to removed list")
+	}
+}
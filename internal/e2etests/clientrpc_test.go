@@ -0,0 +1,92 @@
+package e2etests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/rpcserver"
+	"github.com/companyzero/bisonrelay/clientrpc/jsonrpc"
+	"github.com/companyzero/bisonrelay/clientrpc/types"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rates"
+	"github.com/decred/slog"
+)
+
+// TestClientRPCAdminStatus tests the fields returned by the Status call of the
+// AdminService through a JSON-RPC connection.
+func TestClientRPCAdminStatus(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	r := rates.New(rates.Config{Log: slog.Disabled})
+	r.Set(20, 40000)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilErr(t, err)
+	srv := rpcserver.New(rpcserver.Config{
+		JSONRPCListeners: []net.Listener{l},
+		Log:              slog.Disabled,
+	})
+	assert.NilErr(t, srv.InitAdminService(rpcserver.AdminServerCfg{
+		Client: alice.Client,
+		Log:    slog.Disabled,
+		Rates:  r,
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Run(ctx)
+
+	c, err := jsonrpc.NewWSClient(jsonrpc.WithWebsocketURL("ws://" +
+		l.Addr().String() + "/ws"))
+	assert.NilErr(t, err)
+	go c.Run(ctx)
+	adminClient := types.NewAdminServiceClient(c)
+
+	status := func() *types.StatusResponse {
+		t.Helper()
+		var res types.StatusResponse
+		assert.NilErr(t, adminClient.Status(ctx, &types.StatusRequest{}, &res))
+		return &res
+	}
+
+	// Online status.
+	res := status()
+	assert.DeepEqual(t, res.ServerConnected, true)
+	assert.DeepEqual(t, res.ServerLnNode, alice.ServerLNNode())
+	assert.DeepEqual(t, res.PendingRmsQueued, uint32(0))
+	assert.DeepEqual(t, res.PendingRmsSending, uint32(0))
+	assert.DeepEqual(t, res.LnEnabled, false)
+	assert.DeepEqual(t, res.LnError, "")
+	assert.DeepEqual(t, res.DcrUsdRate, 20.0)
+	assert.DeepEqual(t, res.BtcUsdRate, 40000.0)
+	assert.DeepEqual(t, res.RatesLastUpdated, r.LastUpdated().Unix())
+
+	// Alice goes offline and queues a PM, which is reported as pending.
+	assertGoesOffline(t, alice)
+	errChan := make(chan error, 1)
+	go func() { errChan <- alice.PM(bob.PublicID(), "hello") }()
+	for i := 0; i < 100; i++ {
+		res = status()
+		if res.PendingRmsQueued > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.DeepEqual(t, res.ServerConnected, false)
+	assert.DeepEqual(t, res.PendingRmsQueued, uint32(1))
+
+	// Alice goes online and sends the PM.
+	assertGoesOnline(t, alice)
+	assert.NilErrFromChan(t, errChan)
+	res = status()
+	assert.DeepEqual(t, res.ServerConnected, true)
+	assert.DeepEqual(t, res.PendingRmsQueued, uint32(0))
+	assert.DeepEqual(t, res.PendingRmsSending, uint32(0))
+}
@@ -0,0 +1,96 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestContactGroups tests managing contact groups and performing batch
+// operations on them.
+func TestContactGroups(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	pmChans := make(map[string]chan string)
+	for _, c := range []*testClient{bob, charlie, dave} {
+		c := c
+		pmChan := make(chan string, 5)
+		pmChans[c.name] = pmChan
+		c.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+			pmChan <- pm.Message
+		}))
+	}
+	alicePMChan := make(chan string, 5)
+	alice.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+		alicePMChan <- pm.Message
+	}))
+
+	// Create the group and manage its members. Adding to a group that does
+	// not exist creates it.
+	assert.NilErr(t, alice.AddToContactGroup("friends", bob.PublicID()))
+	assert.NilErr(t, alice.AddToContactGroup("friends", charlie.PublicID(), dave.PublicID(), bob.PublicID()))
+	cg, err := alice.GetContactGroup("friends")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(cg.Members), 3)
+	assert.NilErr(t, alice.RemoveFromContactGroup("friends", dave.PublicID()))
+	groups, err := alice.ListContactGroups()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(groups), 1)
+	assert.DeepEqual(t, groups[0].Members, []client.UserID{bob.PublicID(), charlie.PublicID()})
+
+	// Message the group. Only the current members receive it.
+	res, err := alice.PMContactGroup("friends", "hello friends")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Succeeded), 2)
+	assert.DeepEqual(t, len(res.Errors), 0)
+	assert.DeepEqual(t, assert.ChanWritten(t, pmChans[bob.name]), "hello friends")
+	assert.DeepEqual(t, assert.ChanWritten(t, pmChans[charlie.name]), "hello friends")
+	assert.ChanNotWritten(t, pmChans[dave.name], 250*time.Millisecond)
+
+	// Invite the group to a GC. Bob is already a member, so only Charlie
+	// is invited.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	charlieAccepted := charlie.acceptNextGCInvite(gcID)
+	res, err = alice.InviteContactGroupToGC("friends", gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res.Succeeded, []client.UserID{charlie.PublicID()})
+	assert.DeepEqual(t, res.Skipped, []client.UserID{bob.PublicID()})
+	assert.NilErr(t, assert.ChanWritten(t, charlieAccepted))
+	assertClientInGC(t, charlie, gcID)
+
+	// Apply a filter rule to the group. Messages from members that match
+	// the rule are dropped, while messages from non-members are received.
+	res, err = alice.ApplyContactGroupFilter("friends", clientdb.ContentFilter{
+		Regexp: "spam",
+	})
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Succeeded), 2)
+	assert.DeepEqual(t, len(alice.ListContentFilters()), 2)
+	assert.NilErr(t, bob.PM(alice.PublicID(), "spam from bob"))
+	assert.NilErr(t, charlie.PM(alice.PublicID(), "spam from charlie"))
+	assert.ChanNotWritten(t, alicePMChan, 500*time.Millisecond)
+	assert.NilErr(t, dave.PM(alice.PublicID(), "spam from dave"))
+	assert.DeepEqual(t, assert.ChanWritten(t, alicePMChan), "spam from dave")
+
+	// Removing the group prevents further batch operations.
+	assert.NilErr(t, alice.RemoveContactGroup("friends"))
+	if _, err := alice.PMContactGroup("friends", "again"); err == nil {
+		t.Fatalf("expected error sending to removed group")
+	}
+}
@@ -0,0 +1,67 @@
+package e2etests
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestContactMetadata tests storing private notes, tags and fields about
+// remote users and querying users by tag.
+func TestContactMetadata(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	bobID, charlieID := bob.PublicID(), charlie.PublicID()
+
+	// No metadata initially.
+	meta, err := alice.ContactMetadata(bobID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, meta.Notes, "")
+	assert.DeepEqual(t, len(meta.Tags), 0)
+
+	// Set metadata.
+	assert.NilErr(t, alice.SetContactNotes(bobID, "met at conference"))
+	assert.NilErr(t, alice.AddContactTags(bobID, "Work", "friends", "work"))
+	assert.NilErr(t, alice.AddContactTags(charlieID, "friends"))
+	assert.NilErr(t, alice.SetContactField(bobID, "city", "Lisbon"))
+	meta, err = alice.ContactMetadata(bobID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, meta.Notes, "met at conference")
+	assert.DeepEqual(t, meta.Tags, []string{"friends", "work"})
+	assert.DeepEqual(t, meta.Fields, map[string]string{"city": "Lisbon"})
+
+	// Query by tag.
+	uids, err := alice.ContactsByTag("WORK")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, uids, []client.UserID{bobID})
+	uids, err = alice.ContactsByTag("friends")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(uids), 2)
+	tags, err := alice.ListContactTags()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, tags, map[string]int{"friends": 2, "work": 1})
+
+	// Remove tags and fields.
+	assert.NilErr(t, alice.RemoveContactTags(bobID, "work"))
+	assert.NilErr(t, alice.SetContactField(bobID, "city", ""))
+	uids, err = alice.ContactsByTag("work")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(uids), 0)
+	meta, err = alice.ContactMetadata(bobID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(meta.Fields), 0)
+
+	// Metadata is not available for unknown users.
+	if _, err := alice.ContactMetadata(client.UserID{31: 1}); err == nil {
+		t.Fatalf("expected error fetching metadata of unknown user")
+	}
+}
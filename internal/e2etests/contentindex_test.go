@@ -0,0 +1,87 @@
+package e2etests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestContentIndexSearch tests searching the content shared by remote users.
+func TestContentIndexSearch(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	carol := ts.newClient("carol")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(carol, bob)
+
+	// Helper to share a file.
+	share := func(tc *testClient, name string, size int, cost uint64, uid *clientdb.UserID) clientdb.FileID {
+		t.Helper()
+		fname := filepath.Join(t.TempDir(), name)
+		assert.NilErr(t, os.WriteFile(fname, make([]byte, size), 0o600))
+		sf, _, err := tc.ShareFile(fname, uid, cost, "")
+		assert.NilErr(t, err)
+		return sf.FID
+	}
+	bobID := bob.PublicID()
+	aliceSong := share(alice, "song.mp3", 100, 1000, nil)
+	share(alice, "video.mkv", 1000, 5000, nil)
+	carolSong := share(carol, "other song.MP3", 200, 0, &bobID)
+
+	// Helper to list the contents of a user.
+	listChan := make(chan struct{}, 5)
+	bob.handle(client.OnContentListReceived(func(user *client.RemoteUser, files []clientdb.RemoteFile, listErr error) {
+		listChan <- struct{}{}
+	}))
+	dirs := []string{rpc.RMFTDGlobal, rpc.RMFTDShared}
+	assert.NilErr(t, bob.ListUserContent(alice.PublicID(), dirs, ""))
+	assert.ChanWritten(t, listChan)
+	assert.NilErr(t, bob.ListUserContent(carol.PublicID(), dirs, ""))
+	assert.ChanWritten(t, listChan)
+
+	// Helper to search and return the file ids found.
+	search := func(q client.ContentQuery) []clientdb.FileID {
+		t.Helper()
+		files, err := bob.SearchContent(&q)
+		assert.NilErr(t, err)
+		res := make([]clientdb.FileID, len(files))
+		for i := range files {
+			res[i] = files[i].FID
+		}
+		return res
+	}
+	maxCost := uint64(1000)
+	assert.DeepEqual(t, len(search(client.ContentQuery{})), 3)
+	assert.DeepEqual(t, search(client.ContentQuery{Name: "SONG", Types: []string{".mp3"}}),
+		[]clientdb.FileID{carolSong, aliceSong})
+	assert.DeepEqual(t, search(client.ContentQuery{MaxCost: &maxCost, MinSize: 150}),
+		[]clientdb.FileID{carolSong})
+	assert.DeepEqual(t, search(client.ContentQuery{Types: []string{"mp3"},
+		Sharers: []clientdb.UserID{alice.PublicID()}}),
+		[]clientdb.FileID{aliceSong})
+
+	// Refreshing only requests the listing of users with outdated indexes.
+	n, err := bob.RefreshContentIndex(time.Now().Add(-time.Hour))
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, n, 0)
+
+	// Files unshared are removed from the index after it is refreshed.
+	assert.NilErr(t, alice.UnshareFile(aliceSong, nil))
+	n, err = bob.RefreshContentIndex(time.Now())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, n, 2)
+	assert.ChanWritten(t, listChan)
+	assert.ChanWritten(t, listChan)
+	assert.DeepEqual(t, search(client.ContentQuery{Name: "song"}),
+		[]clientdb.FileID{carolSong})
+}
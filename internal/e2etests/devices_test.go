@@ -0,0 +1,188 @@
+package e2etests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestPairedDevices tests pairing a secondary device (running the same
+// identity) with a primary device, keeping it in sync, relaying messages
+// through the primary and handing off the ratchets to the secondary.
+func TestPairedDevices(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob")
+	carol := ts.newClient("carol")
+
+	ts.kxUsers(alice, bob)
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientsCanPM(t, alice, bob)
+
+	// Alice creates the pairing for a new device. The bundle cannot be
+	// decoded if tampered with.
+	words, bundle, err := alice.CreateDevicePairing()
+	assert.NilErr(t, err)
+	badBundle := append([]byte(nil), bundle...)
+	badBundle[len(badBundle)-1] ^= 0xff
+	_, err = client.DecodeDevicePairing(words, badBundle)
+	assert.NonNilErr(t, err)
+	pairing, err := client.DecodeDevicePairing(words, bundle)
+	assert.NilErr(t, err)
+
+	// Alice2 is started with the same identity and completes the pairing.
+	newCfg := ts.defaultNewClientCfg("alice2")
+	newCfg.id = &pairing.Identity
+	newCfg.idIniter = pairing.LocalIDIniter()
+	alice2 := ts.newClientWithCfg(newCfg, withMsgLogs())
+	assert.DeepEqual(t, alice2.PublicID(), alice.PublicID())
+
+	alice2SyncChan := make(chan rpc.RMDeviceSync, 2)
+	alice2UpdateChan := make(chan rpc.RMDeviceSyncUpdate, 20)
+	registerAlice2 := func() {
+		alice2.handle(client.OnDeviceSyncedNtfn(func(_ client.PairedDevice, sync rpc.RMDeviceSync) {
+			alice2SyncChan <- sync
+		}))
+		alice2.handle(client.OnDeviceSyncUpdateNtfn(func(_ client.PairedDevice, update rpc.RMDeviceSyncUpdate) {
+			alice2UpdateChan <- update
+		}))
+	}
+	registerAlice2()
+	assert.NilErr(t, alice2.CompleteDevicePairing(pairing))
+	sync := assert.ChanWritten(t, alice2SyncChan)
+	assert.DeepEqual(t, len(sync.Contacts), 1)
+	assert.DeepEqual(t, sync.Contacts[0].ID, bob.PublicID())
+	assert.DeepEqual(t, len(sync.GCs), 1)
+	assert.DeepEqual(t, sync.GCs[0].ID, gcID)
+	if len(sync.History) == 0 {
+		t.Fatalf("sync did not include any history")
+	}
+
+	// The pairing cannot be completed twice and a secondary device cannot
+	// pair new devices.
+	assert.NonNilErr(t, alice2.CompleteDevicePairing(pairing))
+	_, _, err = alice2.CreateDevicePairing()
+	assert.NonNilErr(t, err)
+
+	// waitConvoUpdate waits for the update with the message, skipping
+	// other updates.
+	waitConvoUpdate := func(c chan rpc.RMDeviceSyncUpdate, msg string) rpc.RMDeviceSyncUpdate {
+		t.Helper()
+		for {
+			update := assert.ChanWritten(t, c)
+			if update.Convo != nil && update.Convo.Messages[0].Message == msg {
+				return update
+			}
+		}
+	}
+
+	bobPMChan := make(chan string, 5)
+	bob.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		if ru.ID() == alice.PublicID() {
+			bobPMChan <- pm.Message
+		}
+	}))
+	bobGCMChan := make(chan string, 5)
+	bob.handle(client.OnGCMNtfn(func(ru *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		if ru.ID() == alice.PublicID() {
+			bobGCMChan <- gcm.Message
+		}
+	}))
+
+	// PMs received by Alice are synced to Alice2.
+	assert.NilErr(t, bob.PM(alice.PublicID(), "msg to alice"))
+	update := waitConvoUpdate(alice2UpdateChan, "msg to alice")
+	assert.DeepEqual(t, *update.Convo.UID, bob.PublicID())
+
+	// PMs sent by Alice2 are relayed through Alice and synced back.
+	assert.NilErr(t, alice2.DevicePM(bob.PublicID(), "msg from alice2"))
+	assert.ChanWrittenWithVal(t, bobPMChan, "msg from alice2")
+	waitConvoUpdate(alice2UpdateChan, "msg from alice2")
+
+	// GC messages are synced and relayed.
+	assert.NilErr(t, bob.GCMessage(gcID, "gc msg to alice", rpc.MessageModeNormal, nil))
+	update = waitConvoUpdate(alice2UpdateChan, "gc msg to alice")
+	assert.DeepEqual(t, *update.Convo.GC, gcID)
+	assert.NilErr(t, alice2.DeviceGCMessage(gcID, "gc msg from alice2"))
+	assert.ChanWrittenWithVal(t, bobGCMChan, "gc msg from alice2")
+
+	// New contacts and GCs of Alice are synced.
+	ts.kxUsers(alice, carol)
+	gcID2, err := alice.NewGroupChatVersion("gc02", 2)
+	assert.NilErr(t, err)
+	var gotCarol, gotGC2 bool
+	for !gotCarol || !gotGC2 {
+		update := assert.ChanWritten(t, alice2UpdateChan)
+		gotCarol = gotCarol || (update.Contact != nil && update.Contact.ID == carol.PublicID())
+		gotGC2 = gotGC2 || (update.GC != nil && update.GC.ID == gcID2)
+	}
+	ds, err := alice2.LastDeviceSync()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(ds.Sync.Contacts), 2)
+	assert.DeepEqual(t, len(ds.Sync.GCs), 2)
+
+	// Alice2 requests a full sync.
+	assert.NilErr(t, alice2.RequestDeviceSync())
+	sync = assert.ChanWritten(t, alice2SyncChan)
+	assert.DeepEqual(t, len(sync.Contacts), 2)
+
+	// The link is kept after Alice2 restarts.
+	alice2 = ts.recreateClient(alice2)
+	registerAlice2()
+	assert.NilErr(t, bob.PM(alice.PublicID(), "after restart"))
+	waitConvoUpdate(alice2UpdateChan, "after restart")
+
+	// Alice hands off the ratchets to Alice2, which becomes the primary
+	// device.
+	handoffChan := make(chan client.DeviceHandoff, 1)
+	alice2.handle(client.OnDeviceHandoffNtfn(func(_ client.PairedDevice, handoff client.DeviceHandoff) {
+		handoffChan <- handoff
+	}))
+	aliceUpdateChan := make(chan rpc.RMDeviceSyncUpdate, 20)
+	alice.handle(client.OnDeviceSyncUpdateNtfn(func(_ client.PairedDevice, update rpc.RMDeviceSyncUpdate) {
+		aliceUpdateChan <- update
+	}))
+	assert.NilErr(t, alice.HandoffDevice(context.Background()))
+	handoff := assert.ChanWritten(t, handoffChan)
+	assert.DeepEqual(t, len(handoff.Contacts), 2)
+	assert.DeepEqual(t, len(handoff.GCs), 2)
+	assert.DeepEqual(t, len(alice.AddressBook()), 0)
+
+	aliceDevices, err := alice.ListPairedDevices()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(aliceDevices), 1)
+	assert.DeepEqual(t, aliceDevices[0].Role, client.DeviceRolePrimary)
+	alice2Devices, err := alice2.ListPairedDevices()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(alice2Devices), 1)
+	assert.DeepEqual(t, alice2Devices[0].Role, client.DeviceRoleSecondary)
+
+	// Alice2 now exchanges messages with the contacts and syncs them to
+	// Alice, which relays through Alice2.
+	assertClientsCanPM(t, alice2, bob)
+	assertClientsCanPM(t, alice2, carol)
+	assert.NilErr(t, bob.PM(alice2.PublicID(), "msg to alice2"))
+	waitConvoUpdate(aliceUpdateChan, "msg to alice2")
+	for len(bobPMChan) > 0 {
+		<-bobPMChan
+	}
+	assert.NilErr(t, alice.DevicePM(bob.PublicID(), "msg from alice"))
+	assert.ChanWrittenWithVal(t, bobPMChan, "msg from alice")
+
+	// After unpairing, updates are no longer received.
+	assert.NilErr(t, alice.UnpairDevice(aliceDevices[0].ID))
+	for len(aliceUpdateChan) > 0 {
+		<-aliceUpdateChan
+	}
+	assert.NilErr(t, bob.PM(alice2.PublicID(), "after unpair"))
+	assert.ChanNotWritten(t, aliceUpdateChan, 500*time.Millisecond)
+}
@@ -0,0 +1,154 @@
+package e2etests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestDrafts tests storing drafts of chats and that they are persisted across
+// client restarts.
+func TestDrafts(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+
+	// No drafts initially.
+	draft, err := alice.Draft(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, draft, "")
+
+	// Store drafts for the PM and GC.
+	assert.NilErr(t, alice.SetDraft(bob.PublicID(), "long reply to bob"))
+	assert.NilErr(t, alice.SetDraft(gcID, "gc draft"))
+
+	// Drafts for unknown chats or that are too large are rejected.
+	if err := alice.SetDraft(zkidentity.ShortID{31: 1}, "draft"); err == nil {
+		t.Fatalf("expected error storing draft of unknown chat")
+	}
+	tooLarge := strings.Repeat("x", client.MaxDraftSize+1)
+	if err := alice.SetDraft(bob.PublicID(), tooLarge); err == nil {
+		t.Fatalf("expected error storing too large draft")
+	}
+
+	// Drafts are restored after restarting.
+	alice = ts.recreateClient(alice)
+	draft, err = alice.Draft(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, draft, "long reply to bob")
+	drafts, err := alice.ListDrafts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(drafts), 2)
+	assert.DeepEqual(t, drafts[gcID].Text, "gc draft")
+
+	// An empty draft removes it.
+	assert.NilErr(t, alice.SetDraft(bob.PublicID(), ""))
+	draft, err = alice.Draft(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, draft, "")
+	drafts, err = alice.ListDrafts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(drafts), 1)
+}
+
+// TestPostDrafts tests storing post drafts and publishing them at their
+// scheduled time, including after the client restarts.
+func TestPostDrafts(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan string, 3)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm.Attributes[rpc.RMPMain]
+	}))
+	bobSubChanged := make(chan bool, 1)
+	bob.handle(client.OnRemoteSubscriptionChangedNtfn(func(user *client.RemoteUser, subscribed bool) {
+		bobSubChanged <- subscribed
+	}))
+	publishedChan := make(chan client.PostDraft, 3)
+	ntfns := client.NewNotificationManager()
+	ntfns.Register(client.OnScheduledPostPublished(func(draft clientdb.PostDraft, summ clientdb.PostSummary, err error) {
+		assert.NilErr(t, err)
+		publishedChan <- draft
+	}))
+	alice = ts.recreateClient(alice, withNtfns(ntfns))
+
+	ts.kxUsers(alice, bob)
+	assert.NilErr(t, bob.SubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, bobSubChanged, true)
+
+	// Empty drafts and updates of unknown drafts are rejected.
+	_, err := alice.SavePostDraft(nil, "", "", nil)
+	assert.NonNilErr(t, err)
+	_, err = alice.SavePostDraft(&zkidentity.ShortID{31: 1}, "post", "", nil)
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+
+	// Create and edit a draft. It is not published.
+	draft, err := alice.SavePostDraft(nil, "first version", "", nil)
+	assert.NilErr(t, err)
+	edited, err := alice.SavePostDraft(&draft.ID, "second version", "", nil)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, edited.ID, draft.ID)
+	assert.DeepEqual(t, edited.Created.Equal(draft.Created), true)
+	assert.ChanNotWritten(t, bobRecvPosts, 250*time.Millisecond)
+
+	// Schedule the draft. It is published at the scheduled time.
+	publishAt := time.Now().Add(time.Second)
+	assert.NilErr(t, alice.SchedulePostDraft(draft.ID, &publishAt))
+	assert.ChanNotWritten(t, bobRecvPosts, 500*time.Millisecond)
+	assert.ChanWrittenWithVal(t, bobRecvPosts, "second version")
+	published := assert.ChanWritten(t, publishedChan)
+	assert.DeepEqual(t, published.ID, draft.ID)
+	drafts, err := alice.ListPostDrafts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(drafts), 0)
+
+	// Removing a scheduled draft cancels its publication.
+	publishAt = time.Now().Add(500 * time.Millisecond)
+	draft, err = alice.SavePostDraft(nil, "removed", "", &publishAt)
+	assert.NilErr(t, err)
+	assert.NilErr(t, alice.RemovePostDraft(draft.ID))
+	assert.ChanNotWritten(t, bobRecvPosts, time.Second)
+
+	// Scheduled drafts are published after the client restarts.
+	publishAt = time.Now().Add(2 * time.Second)
+	draft, err = alice.SavePostDraft(nil, "after restart", "", &publishAt)
+	assert.NilErr(t, err)
+	alice = ts.recreateClient(alice, withNtfns(ntfns))
+	assert.ChanWrittenWithVal(t, bobRecvPosts, "after restart")
+	published = assert.ChanWritten(t, publishedChan)
+	assert.DeepEqual(t, published.ID, draft.ID)
+
+	// Drafts may be published manually.
+	draft, err = alice.SavePostDraft(nil, "manual", "", nil)
+	assert.NilErr(t, err)
+	summ, err := alice.PublishPostDraft(draft.ID)
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, bobRecvPosts, "manual")
+	_, err = alice.GetPostDraft(draft.ID)
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+	posts, err := alice.ListPosts()
+	assert.NilErr(t, err)
+	var found bool
+	for _, post := range posts {
+		found = found || post.ID == summ.ID
+	}
+	assert.DeepEqual(t, found, true)
+}
@@ -94,6 +94,27 @@ type clientCfg struct {
 
 	sendRecvReceipts bool
 	autoSubToPosts   bool
+	msgLogs          bool
+	linkPreviews     bool
+
+	postMediaThumbnails bool
+	postMediaCost       uint64
+
+	postAutoUnlockMaxCost uint64
+	ignorePostModeration  bool
+
+	autoUnsubIdleUsersRVs time.Duration
+
+	tipUserKeysendMaxMAtoms int64
+	tipUserOnchainMinMAtoms int64
+	escrowMaxLifetime       time.Duration
+
+	dcrUSDRate func() (float64, time.Time)
+
+	paymentBudgetConfirmer func(client.PaymentBudgetExceededError) bool
+	paymentWebhooks        []client.PaymentWebhook
+
+	fileDownloadConfirmer func(*client.RemoteUser, rpc.FileMetadata) bool
 }
 
 type newClientOpt func(*clientCfg)
@@ -110,6 +131,37 @@ func withSendRecvReceipts() newClientOpt {
 	}
 }
 
+func withLinkPreviews() newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.linkPreviews = true
+	}
+}
+
+func withPostMediaThumbnails(cost uint64) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.postMediaThumbnails = true
+		cfg.postMediaCost = cost
+	}
+}
+
+func withPostAutoUnlock(maxCost uint64) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.postAutoUnlockMaxCost = maxCost
+	}
+}
+
+func withIgnorePostModeration() newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.ignorePostModeration = true
+	}
+}
+
+func withMsgLogs() newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.msgLogs = true
+	}
+}
+
 func withSimnetEnvDcrlndPayClient(t testing.TB, alt bool) newClientOpt {
 	pcIniter := func(logBknd loggerSubsysIniter) clientintf.PaymentClient {
 		t.Helper()
@@ -148,6 +200,54 @@ func withAutoSubToPosts() newClientOpt {
 	}
 }
 
+func withFileDownloadConfirmer(confirmer func(*client.RemoteUser, rpc.FileMetadata) bool) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.fileDownloadConfirmer = confirmer
+	}
+}
+
+func withAutoUnsubIdleUsersRVs(interval time.Duration) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.autoUnsubIdleUsersRVs = interval
+	}
+}
+
+func withTipUserKeysend(maxMAtoms int64) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.tipUserKeysendMaxMAtoms = maxMAtoms
+	}
+}
+
+func withTipUserOnchain(minMAtoms int64) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.tipUserOnchainMinMAtoms = minMAtoms
+	}
+}
+
+func withEscrowMaxLifetime(lifetime time.Duration) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.escrowMaxLifetime = lifetime
+	}
+}
+
+func withDCRUSDRate(rate func() (float64, time.Time)) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.dcrUSDRate = rate
+	}
+}
+
+func withPaymentBudgetConfirmer(confirmer func(client.PaymentBudgetExceededError) bool) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.paymentBudgetConfirmer = confirmer
+	}
+}
+
+func withPaymentWebhooks(webhooks ...client.PaymentWebhook) newClientOpt {
+	return func(cfg *clientCfg) {
+		cfg.paymentWebhooks = webhooks
+	}
+}
+
 type testClient struct {
 	*client.Client
 	db      *clientdb.DB
@@ -373,6 +473,9 @@ func (ts *testScaffold) newClientWithCfg(nccfg *clientCfg, opts ...newClientOpt)
 		Logger:        dbLog,
 		ChunkSize:     8,
 	}
+	if nccfg.msgLogs {
+		dbCfg.MsgsRoot = filepath.Join(rootDir, "msglogs")
+	}
 	db, err := clientdb.New(dbCfg)
 	assert.NilErr(ts.t, err)
 
@@ -397,6 +500,14 @@ func (ts *testScaffold) newClientWithCfg(nccfg *clientCfg, opts ...newClientOpt)
 		TipUserReRequestInvoiceDelay: time.Second,
 		TipUserMaxLifetime:           20 * time.Second,
 		TipUserPayRetryDelayFactor:   100 * time.Millisecond,
+		TipUserKeysendMaxMAtoms:      nccfg.tipUserKeysendMaxMAtoms,
+		TipUserOnchainMinMAtoms:      nccfg.tipUserOnchainMinMAtoms,
+		OnchainTipCheckInterval:      100 * time.Millisecond,
+		EscrowMaxLifetime:            nccfg.escrowMaxLifetime,
+		EscrowCheckInterval:          100 * time.Millisecond,
+		DCRUSDRate:                   nccfg.dcrUSDRate,
+		PaymentBudgetConfirmer:       nccfg.paymentBudgetConfirmer,
+		PaymentWebhooks:              nccfg.paymentWebhooks,
 
 		GCMQUpdtDelay:    100 * time.Millisecond,
 		GCMQMaxLifetime:  time.Second,
@@ -410,6 +521,14 @@ func (ts *testScaffold) newClientWithCfg(nccfg *clientCfg, opts ...newClientOpt)
 		AutoRemoveIdleUsersInterval: time.Second * 14,
 		SendReceiveReceipts:         nccfg.sendRecvReceipts,
 		AutoSubscribeToPosts:        nccfg.autoSubToPosts,
+		LinkPreviews:                nccfg.linkPreviews,
+		PostMediaThumbnails:         nccfg.postMediaThumbnails,
+		PostMediaCost:               nccfg.postMediaCost,
+		PostAutoUnlockMaxCost:       nccfg.postAutoUnlockMaxCost,
+		IgnorePostModeration:        nccfg.ignorePostModeration,
+
+		AutoUnsubIdleUsersRVsInterval: nccfg.autoUnsubIdleUsersRVs,
+		FileDownloadConfirmer:         nccfg.fileDownloadConfirmer,
 
 		ResourcesProvider: resources.ProviderFunc(func(ctx context.Context,
 			uid clientintf.UserID,
@@ -0,0 +1,86 @@
+package e2etests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestExportChat tests exporting PM and GC conversations.
+func TestExportChat(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	pmChan := make(chan struct{}, 5)
+	alice.handle(client.OnPMNtfn(func(*client.RemoteUser, rpc.RMPrivateMessage, time.Time) {
+		pmChan <- struct{}{}
+	}))
+
+	embed := mdembeds.EmbeddedArgs{
+		Typ:  "image/png",
+		Alt:  "a picture",
+		Data: []byte("not really a png"),
+	}
+	assert.NilErr(t, alice.PM(bob.PublicID(), "hello bob"))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "look at this "+embed.String()))
+	assert.ChanWritten(t, pmChan)
+
+	export, err := alice.ExportChat(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, *export.UID, bob.PublicID())
+	assert.DeepEqual(t, len(export.Messages), 2)
+	assert.DeepEqual(t, export.Messages[0].From, "alice")
+	assert.DeepEqual(t, export.Messages[0].Message, "hello bob")
+	assert.DeepEqual(t, export.Messages[1].Message, "look at this [embed 0]")
+	assert.DeepEqual(t, len(export.Messages[1].Embeds), 1)
+	assert.DeepEqual(t, export.Messages[1].Embeds[0].Data, embed.Data)
+
+	// The JSON export can be decoded back.
+	b := new(bytes.Buffer)
+	assert.NilErr(t, export.Write(b, client.ChatExportJSON))
+	var decoded client.ChatExport
+	assert.NilErr(t, json.Unmarshal(b.Bytes(), &decoded))
+	assert.DeepEqual(t, decoded.Messages[1].Embeds[0].Alt, "a picture")
+
+	// The HTML export includes the image inline.
+	b.Reset()
+	assert.NilErr(t, export.Write(b, client.ChatExportHTML))
+	html := b.String()
+	if !strings.Contains(html, "hello bob") {
+		t.Fatalf("html export does not contain message")
+	}
+	if !strings.Contains(html, `src="data:image/png;base64,`) {
+		t.Fatalf("html export does not contain embedded image")
+	}
+
+	// Export a GC.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	assert.NilErr(t, alice.GCMessage(gcID, "gc <b>message</b>", rpc.MessageModeNormal, nil))
+	export, err = alice.ExportChat(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, *export.GC, gcID)
+	assert.DeepEqual(t, export.GCName, "testGC")
+	assert.DeepEqual(t, len(export.Messages), 1)
+
+	// Message content is escaped in the HTML export.
+	b.Reset()
+	assert.NilErr(t, export.Write(b, client.ChatExportHTML))
+	if strings.Contains(b.String(), "<b>message</b>") {
+		t.Fatalf("html export did not escape message")
+	}
+}
@@ -0,0 +1,663 @@
+package e2etests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/internal/testutils"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestResumeDownloadInterruptedPayment tests that a file download resumes
+// after the client is stopped while paying for one of its chunks.
+func TestResumeDownloadInterruptedPayment(t *testing.T) {
+	t.Parallel()
+
+	// Bob's first payment only returns after the test ends, simulating a
+	// client that was interrupted while paying for a chunk.
+	releasePayment := make(chan struct{})
+	t.Cleanup(func() { close(releasePayment) })
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	// Alice shares a file with a single chunk.
+	data := []byte("a chunk!")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, _, err := alice.ShareFile(fname, nil, 1000, "test file")
+	assert.NilErr(t, err)
+
+	// Alice's invoices are unique and their callbacks are called when Bob
+	// pays for them.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	var nbInvoices int                    // Protected by mtx
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		nbInvoices += 1
+		inv := fmt.Sprintf("invoice %d for %d", nbInvoices, amt)
+		invoiceCbs[inv] = func() { go cb(amt) }
+		return inv, nil
+	})
+	payInvoice := func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	}
+
+	var blockedInvoice string // Protected by mtx
+	paymentBlocked := make(chan struct{})
+	bob.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		if blockedInvoice != "" {
+			mtx.Unlock()
+			return payInvoice(invoice)
+		}
+		blockedInvoice = invoice
+		mtx.Unlock()
+		close(paymentBlocked)
+		<-releasePayment
+		return 0, errors.New("payment interrupted")
+	})
+
+	// Wait until Alice restarted uploads after connecting (which assumes
+	// invoices are paid when using the mock payment client).
+	time.Sleep(1500 * time.Millisecond)
+
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), sf.FID))
+	assert.ChanWritten(t, paymentBlocked)
+
+	ts.stopClient(bob)
+
+	// Restart Bob. The interrupted payment is checked, found to have
+	// failed, and the chunk is requested again.
+	isPayCompletedChan := make(chan string, 5)
+	bobPC := &testutils.MockPayClient{}
+	bobPC.HookIsPayCompleted(func(invoice string) (int64, error) {
+		isPayCompletedChan <- invoice
+		return 0, errors.New("payment failed")
+	})
+	bobPC.HookPayInvoice(payInvoice)
+	bob = ts.recreateStoppedClient(bob, withPCIniter(func(loggerSubsysIniter) clientintf.PaymentClient {
+		return bobPC
+	}))
+	completedChan := make(chan string, 1)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		completedChan <- diskPath
+	}))
+
+	mtx.Lock()
+	wantInvoice := blockedInvoice
+	mtx.Unlock()
+	assert.DeepEqual(t, assert.ChanWritten(t, isPayCompletedChan), wantInvoice)
+
+	// The download completes.
+	diskPath := assert.ChanWritten(t, completedChan)
+	got, err := os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data)
+	fds, err := bob.ListDownloads()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(fds), 0)
+}
+
+// TestMultiSourceDownload tests that chunks of a file shared by multiple users
+// are downloaded from all of them.
+func TestMultiSourceDownload(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	carol := ts.newClient("carol")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(carol, bob)
+
+	// Alice and Carol share the same file.
+	data := []byte("first chunk, second chunk, third chunk and the rest")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	aliceSF, _, err := alice.ShareFile(fname, nil, 1000, "test file")
+	assert.NilErr(t, err)
+	carolSF, _, err := carol.ShareFile(fname, nil, 1000, "test file")
+	assert.NilErr(t, err)
+
+	// Invoices are unique and their callbacks are called when Bob pays for
+	// them. Alice only generates invoices after Carol is added as a
+	// source of the download.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	var nbInvoices int                    // Protected by mtx
+	carolPaid := make(chan struct{}, 10)
+	releaseAlice := make(chan struct{})
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { close(releaseAlice) }) }
+	t.Cleanup(release)
+	hookGetInvoice := func(name string, tc *testClient, block bool) {
+		tc.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+			if block {
+				<-releaseAlice
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			nbInvoices += 1
+			inv := fmt.Sprintf("%s invoice %d for %d", name,
+				nbInvoices, amt)
+			invoiceCbs[inv] = func() {
+				if name == "carol" {
+					carolPaid <- struct{}{}
+				}
+				go cb(amt)
+			}
+			return inv, nil
+		})
+	}
+	hookGetInvoice("alice", alice, true)
+	hookGetInvoice("carol", carol, false)
+	bob.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	})
+	completedChan := make(chan string, 1)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		if user.ID() == alice.PublicID() {
+			completedChan <- diskPath
+		}
+	}))
+
+	// Wait until Alice and Carol restarted uploads after connecting (which
+	// assumes invoices are paid when using the mock payment client).
+	time.Sleep(1500 * time.Millisecond)
+
+	// Bob starts downloading the file from Alice, then from Carol. The
+	// second download is merged into the first one.
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), aliceSF.FID))
+	time.Sleep(time.Second)
+	assert.NilErr(t, bob.GetUserContent(carol.PublicID(), carolSF.FID))
+	assert.ChanWritten(t, carolPaid)
+	release()
+
+	// The download completes.
+	diskPath := assert.ChanWritten(t, completedChan)
+	got, err := os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data)
+	fds, err := bob.ListDownloads()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(fds), 0)
+}
+
+// TestFilePreviewDownload tests downloading the free preview of shared files
+// with price tiers.
+func TestFilePreviewDownload(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	// Alice shares a paid file with a free preview of its first two
+	// chunks and a separate free preview file.
+	data := []byte("free part 1 free part 2 paid part ...... paid part 2 ..!")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, _, err := alice.ShareFile(fname, nil, 1000, "")
+	assert.NilErr(t, err)
+	previewData := []byte("separate preview")
+	previewFname := filepath.Join(t.TempDir(), "preview.txt")
+	assert.NilErr(t, os.WriteFile(previewFname, previewData, 0o600))
+	previewSF, _, err := alice.ShareFile(previewFname, nil, 0, "")
+	assert.NilErr(t, err)
+
+	// Invalid tiers are rejected.
+	preview := &rpc.FilePreview{Size: 17}
+	badTiers := []rpc.FilePriceTier{{End: 32, Cost: 100}, {End: 20, Cost: 200}}
+	err = alice.SetSharedFilePricing(sf.FID, 0, preview, badTiers)
+	assert.NonNilErr(t, err)
+	tiers := []rpc.FilePriceTier{{End: 32, Cost: 100, Name: "first"},
+		{End: uint64(len(data)), Cost: 200, Name: "second"}}
+	assert.NilErr(t, alice.SetSharedFilePricing(sf.FID, 0, preview, tiers))
+
+	// The preview and tiers are advertised in the file listing.
+	listChan := make(chan rpc.FileMetadata, 1)
+	bob.handle(client.OnContentListReceived(func(user *client.RemoteUser, files []clientdb.RemoteFile, listErr error) {
+		for _, f := range files {
+			if f.FID == sf.FID {
+				listChan <- f.Metadata
+			}
+		}
+	}))
+	assert.NilErr(t, bob.ListUserContent(alice.PublicID(), []string{rpc.RMFTDGlobal}, ""))
+	md := assert.ChanWritten(t, listChan)
+	assert.DeepEqual(t, md.Preview, preview)
+	assert.DeepEqual(t, md.Tiers, tiers)
+	assert.DeepEqual(t, md.Cost, uint64(300))
+
+	// Previews are free, so Bob should not need to pay for any chunks.
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		return "", errors.New("previews should not require invoices")
+	})
+	completedChan := make(chan string, 1)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		completedChan <- diskPath
+	}))
+
+	// Wait until Alice restarted uploads after connecting.
+	time.Sleep(1500 * time.Millisecond)
+
+	// Downloading the preview only fetches the chunks entirely within the
+	// preview size.
+	assert.NilErr(t, bob.GetUserContentPreview(alice.PublicID(), sf.FID))
+	diskPath := assert.ChanWritten(t, completedChan)
+	assert.DeepEqual(t, filepath.Base(diskPath), "file.preview.txt")
+	got, err := os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data[:16])
+
+	// When the preview is a separate file, that file is downloaded. Its
+	// chunks are paid as usual.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		inv := fmt.Sprintf("invoice %d for %d", len(invoiceCbs), amt)
+		invoiceCbs[inv] = func() { go cb(amt) }
+		return inv, nil
+	})
+	bob.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	})
+	preview = &rpc.FilePreview{FID: previewSF.FID.String()}
+	assert.NilErr(t, alice.SetSharedFilePricing(sf.FID, 1000, preview, nil))
+	assert.NilErr(t, bob.GetUserContentPreview(alice.PublicID(), sf.FID))
+	diskPath = assert.ChanWritten(t, completedChan)
+	got, err = os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, previewData)
+}
+
+// TestStreamFileDownload tests reading the data of a file download while its
+// chunks are received.
+func TestStreamFileDownload(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	data := []byte("first chunk, second chunk, third chunk and the rest")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, _, err := alice.ShareFile(fname, nil, 1000, "")
+	assert.NilErr(t, err)
+
+	// Alice only generates invoices for chunks after being released.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	releaseAlice := make(chan struct{})
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { close(releaseAlice) }) }
+	t.Cleanup(release)
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		<-releaseAlice
+		mtx.Lock()
+		defer mtx.Unlock()
+		inv := fmt.Sprintf("invoice %d for %d", len(invoiceCbs), amt)
+		invoiceCbs[inv] = func() { go cb(amt) }
+		return inv, nil
+	})
+	bob.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	})
+
+	// Wait until Alice restarted uploads after connecting.
+	time.Sleep(1500 * time.Millisecond)
+
+	// The stream is stalled while no chunks are received.
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), sf.FID))
+	cfg := client.DownloadStreamConfig{StallTimeout: 500 * time.Millisecond}
+	stream, err := bob.StreamFileDownload(alice.PublicID(), sf.FID, cfg)
+	assert.NilErr(t, err)
+	_, err = stream.Read(make([]byte, 10))
+	assert.ErrorIs(t, err, client.ErrDownloadStalled)
+
+	// The data is read in order as the chunks are received.
+	completedChan := make(chan string, 1)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		completedChan <- diskPath
+	}))
+	assert.NilErr(t, stream.Close())
+	release()
+	cfg.StallTimeout = 30 * time.Second
+	stream, err = bob.StreamFileDownload(alice.PublicID(), sf.FID, cfg)
+	assert.NilErr(t, err)
+	got, err := io.ReadAll(stream)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data)
+	assert.NilErr(t, stream.Close())
+
+	// After the download completes, the data is read from the final file.
+	assert.ChanWritten(t, completedChan)
+	stream, err = bob.StreamFileDownload(alice.PublicID(), sf.FID, cfg)
+	assert.NilErr(t, err)
+	defer stream.Close()
+	pos, err := stream.Seek(10, io.SeekStart)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, pos, int64(10))
+	got, err = io.ReadAll(stream)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data[10:])
+}
+
+// TestSwarmFileDownload tests that downloaders of a file that opted into
+// re-sharing it download its chunks from one another.
+func TestSwarmFileDownload(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	carolMetadata := make(chan struct{}, 1)
+	carolConfirm := make(chan struct{})
+	var confirmOnce sync.Once
+	confirm := func() { confirmOnce.Do(func() { close(carolConfirm) }) }
+	t.Cleanup(confirm)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	carol := ts.newClient("carol", withFileDownloadConfirmer(func(*client.RemoteUser, rpc.FileMetadata) bool {
+		carolMetadata <- struct{}{}
+		<-carolConfirm
+		return true
+	}))
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, carol)
+	ts.kxUsers(bob, carol)
+
+	data := []byte("first chunk, second chunk, third chunk and the rest")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, _, err := alice.ShareFile(fname, nil, 1000, "")
+	assert.NilErr(t, err)
+
+	// Invoices are unique and their callbacks are called when they are
+	// paid.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	bobPaid := make(chan struct{}, 10)
+	hookGetInvoice := func(name string, tc *testClient) {
+		tc.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			inv := fmt.Sprintf("%s invoice %d for %d", name,
+				len(invoiceCbs), amt)
+			invoiceCbs[inv] = func() {
+				if name == "bob" {
+					bobPaid <- struct{}{}
+				}
+				go cb(amt)
+			}
+			return inv, nil
+		})
+	}
+	hookGetInvoice("alice", alice)
+	hookGetInvoice("bob", bob)
+	payInvoice := func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	}
+	bob.mpc.HookPayInvoice(payInvoice)
+	carol.mpc.HookPayInvoice(payInvoice)
+	completedChan := func(tc *testClient) chan []byte {
+		c := make(chan []byte, 1)
+		tc.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+			got, err := os.ReadFile(diskPath)
+			if err != nil {
+				t.Error(err)
+			}
+			c <- got
+		}))
+		return c
+	}
+	bobCompleted, carolCompleted := completedChan(bob), completedChan(carol)
+
+	// Wait until the clients restarted uploads after connecting.
+	time.Sleep(1500 * time.Millisecond)
+
+	// Re-sharing requires the file metadata.
+	err = carol.SetFileDownloadSwarm(alice.PublicID(), sf.FID, true)
+	assert.NonNilErr(t, err)
+
+	// Bob downloads the file and re-shares it.
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), sf.FID))
+	assert.DeepEqual(t, assert.ChanWritten(t, bobCompleted), data)
+	assert.NilErr(t, bob.SetFileDownloadSwarm(alice.PublicID(), sf.FID, true))
+
+	// Carol fetches the metadata and re-shares the file before confirming
+	// the download. Alice relays the chunks available in Bob to Carol.
+	assert.NilErr(t, carol.GetUserContent(alice.PublicID(), sf.FID))
+	assert.ChanWritten(t, carolMetadata)
+	assert.NilErr(t, carol.SetFileDownloadSwarm(alice.PublicID(), sf.FID, true))
+	hasBobSource := false
+	for i := 0; i < 100 && !hasBobSource; i++ {
+		time.Sleep(100 * time.Millisecond)
+		fds, err := carol.ListDownloads()
+		assert.NilErr(t, err)
+		for _, fd := range fds {
+			for _, src := range fd.Sources {
+				hasBobSource = hasBobSource || (src.UID == bob.PublicID() &&
+					src.Swarm && len(src.Chunks) == len(fd.Metadata.Manifest))
+			}
+		}
+	}
+	if !hasBobSource {
+		t.Fatalf("Bob was not added as a swarm source of Carol's download")
+	}
+
+	// Carol downloads chunks from both Alice and Bob.
+	confirm()
+	assert.ChanWritten(t, bobPaid)
+	assert.DeepEqual(t, assert.ChanWritten(t, carolCompleted), data)
+}
+
+// TestShareLimits tests that shares are automatically unshared after they
+// expire or reach their download limit.
+func TestShareLimits(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	data := []byte("first chunk, second chunk, third chunk and the rest")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, _, err := alice.ShareFile(fname, nil, 1000, "")
+	assert.NilErr(t, err)
+	bobID := bob.PublicID()
+	fname2 := filepath.Join(t.TempDir(), "file2.txt")
+	assert.NilErr(t, os.WriteFile(fname2, data, 0o600))
+	sf2, _, err := alice.ShareFile(fname2, &bobID, 1000, "")
+	assert.NilErr(t, err)
+
+	// Invalid limits are rejected.
+	limits := &client.ShareLimits{MaxDownloads: -1}
+	assert.NonNilErr(t, alice.SetShareLimits(sf.FID, nil, limits))
+
+	// The global share of the first file may be downloaded once and the
+	// share of the second file with Bob expires shortly.
+	limits = &client.ShareLimits{MaxDownloads: 1}
+	assert.NilErr(t, alice.SetShareLimits(sf.FID, nil, limits))
+	limits = &client.ShareLimits{Expires: time.Now().Add(2 * time.Second)}
+	assert.NilErr(t, alice.SetShareLimits(sf2.FID, &bobID, limits))
+	files, err := alice.ListLocalSharedFiles()
+	assert.NilErr(t, err)
+	for _, f := range files {
+		assert.DeepEqual(t, len(f.Limits), 1)
+	}
+
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		inv := fmt.Sprintf("alice invoice %d for %d", len(invoiceCbs), amt)
+		invoiceCbs[inv] = func() { go cb(amt) }
+		return inv, nil
+	})
+	bob.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	})
+	type unshared struct {
+		fid    clientdb.FileID
+		uid    *clientintf.UserID
+		reason string
+	}
+	unsharedChan := make(chan unshared, 2)
+	handleUnshared := client.OnSharedFileAutoUnshared(func(sf clientdb.SharedFile, uid *clientintf.UserID, reason string) {
+		unsharedChan <- unshared{fid: sf.FID, uid: uid, reason: reason}
+	})
+	alice.handle(handleUnshared)
+	completedChan := make(chan string, 1)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		completedChan <- diskPath
+	}))
+
+	// Wait until Alice restarted uploads after connecting.
+	time.Sleep(1500 * time.Millisecond)
+
+	// Bob downloads the first file. This reaches the download limit of its
+	// share.
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), sf.FID))
+	assert.ChanWritten(t, completedChan)
+	got := assert.ChanWritten(t, unsharedChan)
+	assert.DeepEqual(t, got.fid, sf.FID)
+	assert.DeepEqual(t, got.uid, (*clientintf.UserID)(nil))
+	assert.DeepEqual(t, got.reason, client.AutoUnshareDownloadLimit)
+
+	// After the second share expires, it is no longer listed.
+	time.Sleep(time.Until(limits.Expires))
+	listChan := make(chan []clientdb.RemoteFile, 1)
+	bob.handle(client.OnContentListReceived(func(user *client.RemoteUser, files []clientdb.RemoteFile, listErr error) {
+		listChan <- files
+	}))
+	assert.NilErr(t, bob.ListUserContent(alice.PublicID(), []string{rpc.RMFTDShared}, ""))
+	assert.DeepEqual(t, len(assert.ChanWritten(t, listChan)), 0)
+
+	// Alice unshares the expired file when restarted.
+	ntfns := client.NewNotificationManager()
+	ntfns.Register(handleUnshared)
+	alice = ts.recreateClient(alice, withNtfns(ntfns))
+	got = assert.ChanWritten(t, unsharedChan)
+	assert.DeepEqual(t, got.fid, sf2.FID)
+	assert.DeepEqual(t, *got.uid, bobID)
+	assert.DeepEqual(t, got.reason, client.AutoUnshareExpired)
+	files, err = alice.ListLocalSharedFiles()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(files), 0)
+}
+
+// TestDownloadLegacyFileID tests that files may be downloaded with the id
+// calculated by clients that only support version 1 metadata and that files
+// sent by a remote user are received.
+func TestDownloadLegacyFileID(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	completedChan := make(chan string, 2)
+	bob.handle(client.OnFileDownloadCompleted(func(user *client.RemoteUser, fm rpc.FileMetadata, diskPath string) {
+		completedChan <- diskPath
+	}))
+
+	// Alice shares a file. Its legacy id differs from its id.
+	data := []byte("legacy downloads")
+	fname := filepath.Join(t.TempDir(), "file.txt")
+	assert.NilErr(t, os.WriteFile(fname, data, 0o600))
+	sf, md, err := alice.ShareFile(fname, nil, 1000, "test file")
+	assert.NilErr(t, err)
+	legacyFID := clientdb.FileID(md.LegacyMetadataHash())
+	if legacyFID == sf.FID {
+		t.Fatalf("legacy id should differ from file id")
+	}
+
+	// Bob downloads the file using the legacy id.
+	assert.NilErr(t, bob.GetUserContent(alice.PublicID(), legacyFID))
+	diskPath := assert.ChanWritten(t, completedChan)
+	got, err := os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, data)
+
+	// Alice sends a file to Bob.
+	sentData := []byte("sent file")
+	sentFname := filepath.Join(t.TempDir(), "sent.txt")
+	assert.NilErr(t, os.WriteFile(sentFname, sentData, 0o600))
+	assert.NilErr(t, alice.SendFile(bob.PublicID(), sentFname))
+	diskPath = assert.ChanWritten(t, completedChan)
+	got, err = os.ReadFile(diskPath)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, got, sentData)
+}
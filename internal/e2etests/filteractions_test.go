@@ -0,0 +1,73 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestContentFilterActions tests the mute and flag content filter actions
+// and filtering by embed type.
+func TestContentFilterActions(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	pmChan := make(chan string, 5)
+	alice.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		pmChan <- pm.Message
+	}))
+	filteredChan := make(chan client.MsgContentFilteredEvent, 5)
+	alice.handle(client.OnMsgContentFilteredNtfn(func(e client.MsgContentFilteredEvent) {
+		filteredChan <- e
+	}))
+
+	// Invalid actions are rejected.
+	err := alice.StoreContentFilter(&clientdb.ContentFilter{Regexp: "x", Action: "boom"})
+	if err == nil {
+		t.Fatalf("expected error storing filter with invalid action")
+	}
+
+	// Hide PMs with image embeds.
+	imgEmbed := mdembeds.EmbeddedArgs{Typ: "image/png", Data: []byte{0x01}}.String()
+	cfImg := &clientdb.ContentFilter{EmbedType: "image/"}
+	assert.NilErr(t, alice.StoreContentFilter(cfImg))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "look "+imgEmbed))
+	e := assert.ChanWritten(t, filteredChan)
+	assert.DeepEqual(t, e.Rule.ID, cfImg.ID)
+	assert.ChanNotWritten(t, pmChan, 250*time.Millisecond)
+	assert.NilErr(t, bob.PM(alice.PublicID(), "no image"))
+	assert.ChanWrittenWithVal(t, pmChan, "no image")
+	assert.NilErr(t, alice.RemoveContentFilter(cfImg.ID))
+
+	// Muted PMs are logged but not notified.
+	cfMute := &clientdb.ContentFilter{Regexp: "spam",
+		Action: clientdb.ContentFilterActionMute}
+	assert.NilErr(t, alice.StoreContentFilter(cfMute))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "buy spam"))
+	e = assert.ChanWritten(t, filteredChan)
+	assert.DeepEqual(t, e.Rule.Action, clientdb.ContentFilterActionMute)
+	assert.ChanNotWritten(t, pmChan, 250*time.Millisecond)
+	res, err := alice.SearchMessages("spam", clientdb.MsgSearchFilter{})
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res), 1)
+	assert.NilErr(t, alice.RemoveContentFilter(cfMute.ID))
+
+	// Flagged PMs are notified as usual.
+	cfFlag := &clientdb.ContentFilter{Regexp: "urgent",
+		Action: clientdb.ContentFilterActionFlag}
+	assert.NilErr(t, alice.StoreContentFilter(cfFlag))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "urgent stuff"))
+	e = assert.ChanWritten(t, filteredChan)
+	assert.DeepEqual(t, e.Rule.Action, clientdb.ContentFilterActionFlag)
+	assert.ChanWrittenWithVal(t, pmChan, "urgent stuff")
+}
@@ -0,0 +1,88 @@
+package e2etests
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCBans tests banning users from a GC and the GC moderation log.
+func TestGCBans(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(bob, charlie)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+
+	charlieGCDeletedChan := make(chan struct{}, 1)
+	charlie.handle(client.OnGCUserPartedNtfn(func(gcid client.GCID, uid client.UserID, reason string, kicked bool) {
+		if gcid == gcID && uid == charlie.PublicID() && kicked {
+			charlieGCDeletedChan <- struct{}{}
+		}
+	}))
+	bobRemovedChan := make(chan []clientintf.UserID, 3)
+	bob.handle(client.OnRemovedGCMembersNtfn(func(gc rpc.RMGroupList, uids []clientintf.UserID) {
+		if gc.ID == gcID {
+			bobRemovedChan <- uids
+		}
+	}))
+
+	// Bob (a regular member) cannot ban Charlie.
+	assert.NonNilErr(t, bob.BanFromGC(gcID, charlie.PublicID(), ""))
+
+	// Alice bans Charlie. Charlie is kicked and Bob sees the update.
+	reason := "spamming"
+	assert.NilErr(t, alice.BanFromGC(gcID, charlie.PublicID(), reason))
+	assert.ChanWritten(t, charlieGCDeletedChan)
+	removed := assert.ChanWritten(t, bobRemovedChan)
+	assert.DeepEqual(t, removed, []clientintf.UserID{charlie.PublicID()})
+	for _, c := range []*testClient{alice, bob} {
+		bans, err := c.ListGCBans(gcID)
+		assert.NilErr(t, err)
+		assert.DeepEqual(t, bans, []clientintf.UserID{charlie.PublicID()})
+	}
+	assertGCDoesNotExist(t, gcID, charlie)
+
+	// Charlie cannot be invited back while banned.
+	assert.NonNilErr(t, alice.InviteToGroupChat(gcID, charlie.PublicID()))
+
+	// The ban was recorded in the moderation logs of Alice and Bob.
+	for _, c := range []*testClient{alice, bob} {
+		log, err := c.GCModLog(gcID)
+		assert.NilErr(t, err)
+		if len(log) != 1 {
+			t.Fatalf("unexpected nb of log entries: got %d, want 1", len(log))
+		}
+		assert.DeepEqual(t, log[0].Action, client.GCModActionBan)
+		assert.DeepEqual(t, log[0].Actor, alice.PublicID())
+		assert.DeepEqual(t, *log[0].Target, zkidentity.ShortID(charlie.PublicID()))
+		assert.DeepEqual(t, log[0].Reason, reason)
+	}
+
+	// Alice revokes the ban and Charlie may rejoin.
+	assert.NilErr(t, alice.UnbanFromGC(gcID, charlie.PublicID()))
+	assertGCEventually(t, bob, gcID, func(gc *rpc.RMGroupList) bool {
+		return len(gc.Banned) == 0
+	})
+	assertClientJoinsGC(t, gcID, alice, charlie)
+	assertClientsCanGCM(t, gcID, alice, bob, charlie)
+
+	log, err := bob.GCModLog(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, log[len(log)-1].Action, client.GCModActionUnban)
+}
@@ -0,0 +1,88 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCBridges tests that messages are relayed between bridged GCs and that
+// bridged messages are not relayed again.
+func TestGCBridges(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+	ts.kxUsers(dave, bob)
+	ts.kxUsers(dave, charlie)
+
+	// Alice creates two GCs. Bob joins the first one, Charlie joins the
+	// second one and Dave joins both.
+	gc1, err := alice.NewGroupChat("gc01")
+	assert.NilErr(t, err)
+	gc2, err := alice.NewGroupChat("gc02")
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gc1, alice, bob)
+	assertClientJoinsGC(t, gc2, alice, charlie)
+	assertClientJoinsGC(t, gc1, alice, dave)
+	assertClientJoinsGC(t, gc2, alice, dave)
+	assertClientSeesInGC(t, bob, gc1, dave.PublicID())
+	assertClientSeesInGC(t, charlie, gc2, dave.PublicID())
+
+	type gcm struct {
+		gcID zkidentity.ShortID
+		msg  string
+	}
+	handleGCMs := func(c *testClient) chan gcm {
+		ch := make(chan gcm, 10)
+		c.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+			ch <- gcm{gcID: msg.ID, msg: msg.Message}
+		}))
+		return ch
+	}
+	bobGCMs := handleGCMs(bob)
+	charlieGCMs := handleGCMs(charlie)
+
+	// Alice bridges the GCs.
+	assert.NilErr(t, alice.BridgeGCs(gc1, gc2))
+	bridges, err := alice.ListGCBridges()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(bridges), 1)
+
+	// Bob's message is relayed to the second GC and Charlie's message is
+	// relayed to the first GC.
+	assert.NilErr(t, bob.GCMessage(gc1, "hello from bob", rpc.MessageModeNormal, nil))
+	assert.DeepEqual(t, assert.ChanWritten(t, charlieGCMs),
+		gcm{gcID: gc2, msg: "[bob@gc01] hello from bob"})
+	assert.NilErr(t, charlie.GCMessage(gc2, "hello from charlie", rpc.MessageModeNormal, nil))
+	assert.DeepEqual(t, assert.ChanWritten(t, bobGCMs),
+		gcm{gcID: gc1, msg: "[charlie@gc02] hello from charlie"})
+
+	// Dave also bridges the GCs. Bob's message is relayed by both Alice
+	// and Dave, but the relayed messages are not relayed again.
+	assert.NilErr(t, dave.BridgeGCs(gc2, gc1))
+	assert.NilErr(t, bob.GCMessage(gc1, "two bridges", rpc.MessageModeNormal, nil))
+	wantMsg := gcm{gcID: gc2, msg: "[bob@gc01] two bridges"}
+	assert.DeepEqual(t, assert.ChanWritten(t, charlieGCMs), wantMsg)
+	assert.DeepEqual(t, assert.ChanWritten(t, charlieGCMs), wantMsg)
+	assert.ChanNotWritten(t, charlieGCMs, time.Second)
+	assert.ChanNotWritten(t, bobGCMs, time.Second)
+
+	// After unbridging, messages are no longer relayed.
+	assert.NilErr(t, alice.UnbridgeGCs(gc2, gc1))
+	assert.NilErr(t, dave.UnbridgeGCs(gc1, gc2))
+	assert.NilErr(t, bob.GCMessage(gc1, "no bridges", rpc.MessageModeNormal, nil))
+	assert.ChanNotWritten(t, charlieGCMs, time.Second)
+}
@@ -0,0 +1,108 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCChannels tests creating, messaging and removing channels in GCs.
+func TestGCChannels(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob", withMsgLogs())
+
+	ts.kxUsers(alice, bob)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+
+	type chanChange struct {
+		added, removed []client.GCChannel
+	}
+	bobChangedChan := make(chan chanChange, 3)
+	bob.handle(client.OnGCChannelsChangedNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, added, removed []client.GCChannel) {
+		if gc.ID == gcID {
+			bobChangedChan <- chanChange{added: added, removed: removed}
+		}
+	}))
+
+	// Bob (a regular member) cannot create channels.
+	_, err = bob.CreateGCChannel(gcID, "offtopic")
+	assert.NonNilErr(t, err)
+
+	// Alice creates a channel and Bob is notified.
+	ch, err := alice.CreateGCChannel(gcID, "#offtopic")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ch.Name, "offtopic")
+	change := assert.ChanWritten(t, bobChangedChan)
+	assert.DeepEqual(t, change.added, []client.GCChannel{ch})
+	assert.DeepEqual(t, len(change.removed), 0)
+	bobCh, err := bob.GCChannelByName(gcID, "OffTopic")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, bobCh, ch)
+
+	// Channels with duplicate names are rejected.
+	_, err = alice.CreateGCChannel(gcID, "OFFTOPIC")
+	assert.NonNilErr(t, err)
+
+	// Bob sends a message to the channel.
+	aliceMsgChan := make(chan rpc.RMGroupMessage, 5)
+	alice.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+		aliceMsgChan <- msg
+	}))
+	chanMsg := "channel msg"
+	_, err = bob.GCChannelMessage(gcID, ch.ID, chanMsg, 0, nil)
+	assert.NilErr(t, err)
+	gcm := assert.ChanWritten(t, aliceMsgChan)
+	assert.DeepEqual(t, gcm.Message, chanMsg)
+	assert.DeepEqual(t, gcm.Channel, &ch.ID)
+
+	// Messages to unknown channels are rejected.
+	_, err = bob.GCChannelMessage(gcID, zkidentity.ShortID{0x01}, chanMsg, 0, nil)
+	assert.NonNilErr(t, err)
+
+	// The message is in the channel history, but not in the main GC
+	// history.
+	assertChannelHistory := func(c *testClient) {
+		t.Helper()
+		history, err := c.ReadGCChannelHistory(gcID, ch.ID, 100, 0)
+		assert.NilErr(t, err)
+		var found bool
+		for _, e := range history {
+			found = found || e.Message == chanMsg
+		}
+		if !found {
+			t.Fatalf("channel message not found in history of %s", c.name)
+		}
+
+		history, _, err = c.ReadHistoryMessages(gcID, "gc01", 100, 0)
+		assert.NilErr(t, err)
+		for _, e := range history {
+			if e.Message == chanMsg {
+				t.Fatalf("channel message found in main history of %s", c.name)
+			}
+		}
+	}
+	assertChannelHistory(alice)
+	assertChannelHistory(bob)
+
+	// Alice removes the channel.
+	assert.NilErr(t, alice.RemoveGCChannel(gcID, ch.ID))
+	change = assert.ChanWritten(t, bobChangedChan)
+	assert.DeepEqual(t, len(change.added), 0)
+	assert.DeepEqual(t, change.removed, []client.GCChannel{ch})
+	channels, err := bob.ListGCChannels(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(channels), 0)
+
+	assertClientsCanGCM(t, gcID, alice, bob)
+}
@@ -0,0 +1,92 @@
+package e2etests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCHistorySharing tests that a GC admin that opted-in to share the GC
+// history sends the recent GC messages to new members.
+func TestGCHistorySharing(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	// Settle the invoices of the chunks of shared files when they are paid.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func())
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		inv := fmt.Sprintf("invoice %d for %d", len(invoiceCbs), amt)
+		invoiceCbs[inv] = func() { cb(amt) }
+		return inv, nil
+	})
+	dave.mpc.HookPayInvoice(func(inv string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[inv]
+		mtx.Unlock()
+		if cb != nil {
+			go cb()
+		}
+		return 0, nil
+	})
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+
+	gcmChan := make(chan string, 5)
+	alice.handle(client.OnGCMNtfn(func(ru *client.RemoteUser, msg rpc.RMGroupMessage, ts time.Time) {
+		gcmChan <- msg.Message
+	}))
+
+	type history struct {
+		from string
+		msgs []string
+	}
+	historyChan := make(chan history, 5)
+	handleHistory := func(ru *client.RemoteUser, _ zkidentity.ShortID, msgs []client.ChatExportMessage) {
+		h := history{from: ru.Nick()}
+		for _, m := range msgs {
+			h.msgs = append(h.msgs, m.From+": "+m.Message)
+		}
+		historyChan <- h
+	}
+	charlie.handle(client.OnGCHistoryReceivedNtfn(handleHistory))
+	dave.handle(client.OnGCHistoryReceivedNtfn(handleHistory))
+
+	assert.NilErr(t, alice.GCMessage(gcID, "msg 1", rpc.MessageModeNormal, nil))
+	assert.NilErr(t, bob.GCMessage(gcID, "msg 2", rpc.MessageModeNormal, nil))
+	assert.ChanWrittenWithVal(t, gcmChan, "msg 2")
+	assert.NilErr(t, alice.GCMessage(gcID, "msg 3", rpc.MessageModeNormal, nil))
+
+	// History sharing is disabled by default, so Charlie does not receive
+	// the history.
+	assertClientJoinsGC(t, gcID, alice, charlie)
+	assert.ChanNotWritten(t, historyChan, time.Second)
+
+	// Alice enables sharing the last 2 messages. Dave receives them after
+	// joining.
+	assert.NilErr(t, alice.SetGCHistorySharing(gcID, 2))
+	assertClientJoinsGC(t, gcID, alice, dave)
+	h := assert.ChanWritten(t, historyChan)
+	assert.DeepEqual(t, h.from, "alice")
+	assert.DeepEqual(t, h.msgs, []string{"bob: msg 2", "alice: msg 3"})
+}
@@ -0,0 +1,124 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCInviteLinks tests joining GCs through invite links.
+func TestGCInviteLinks(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+
+	// Alice creates a link with a single use. The link round trips through
+	// its encoding.
+	link, err := alice.CreateGCInviteLink(gcID, time.Hour, 1)
+	assert.NilErr(t, err)
+	decoded, err := client.ParseGCInviteLink(link.String())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, decoded, link)
+
+	// Bob joins using the link. As a regular member, he cannot create
+	// links.
+	bobJoinedChan := make(chan struct{}, 1)
+	bob.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			bobJoinedChan <- struct{}{}
+		}
+	}))
+	assert.NilErr(t, bob.RedeemGCInviteLink(decoded))
+	assert.ChanWritten(t, bobJoinedChan)
+	assertClientInGC(t, bob, gcID)
+	_, err = bob.CreateGCInviteLink(gcID, 0, 0)
+	assert.NonNilErr(t, err)
+
+	// The link was used once, so Charlie cannot use it.
+	charlieJoinedChan := make(chan struct{}, 1)
+	charlie.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			charlieJoinedChan <- struct{}{}
+		}
+	}))
+	assert.NilErr(t, charlie.RedeemGCInviteLink(decoded))
+	assert.ChanNotWritten(t, charlieJoinedChan, 500*time.Millisecond)
+	links, err := alice.ListGCInviteLinks(&gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(links), 1)
+	assert.DeepEqual(t, links[0].UsedBy, []client.UserID{bob.PublicID()})
+
+	// Alice creates a new unlimited link, then revokes it after Charlie
+	// uses it. Dave cannot use it after that.
+	link, err = alice.CreateGCInviteLink(gcID, 0, 0)
+	assert.NilErr(t, err)
+	assert.NilErr(t, charlie.RedeemGCInviteLink(link))
+	assert.ChanWritten(t, charlieJoinedChan)
+	assert.NilErr(t, alice.RevokeGCInviteLink(link.ID))
+
+	daveJoinedChan := make(chan struct{}, 1)
+	dave.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			daveJoinedChan <- struct{}{}
+		}
+	}))
+	assert.NilErr(t, dave.RedeemGCInviteLink(link))
+	assert.ChanNotWritten(t, daveJoinedChan, 500*time.Millisecond)
+
+	// Expired links are rejected.
+	link, err = alice.CreateGCInviteLink(gcID, time.Millisecond, 0)
+	assert.NilErr(t, err)
+	time.Sleep(time.Second)
+	assert.NonNilErr(t, dave.RedeemGCInviteLink(link))
+
+	assertClientsCanGCM(t, gcID, alice, bob, charlie)
+}
+
+// TestGCInviteLinkWithoutKX tests that users not KX'd with the issuer of an
+// invite link can use it to join the GC.
+func TestGCInviteLinkWithoutKX(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	link, err := alice.CreateGCInviteLink(gcID, time.Hour, 1)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(link.KXInvites), 1)
+
+	// Bob is not KX'd with Alice, so he KXs with her before joining.
+	bobJoinedChan := make(chan struct{}, 1)
+	bob.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			bobJoinedChan <- struct{}{}
+		}
+	}))
+	assert.NilErr(t, bob.RedeemGCInviteLink(link))
+	assert.ChanWritten(t, bobJoinedChan)
+	assertClientInGC(t, bob, gcID)
+	assertClientsCanGCM(t, gcID, alice, bob)
+
+	// Links with a public identity other than the issuer's are rejected.
+	charlie := ts.newClient("charlie")
+	link.Public.Identity = charlie.PublicID()
+	assert.NonNilErr(t, charlie.RedeemGCInviteLink(link))
+}
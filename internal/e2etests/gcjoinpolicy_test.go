@@ -0,0 +1,109 @@
+package e2etests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCJoinPolicy tests the join approval queue and max number of members of
+// GCs.
+func TestGCJoinPolicy(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+
+	// Bob (a regular member) cannot change the join policy. Alice cannot
+	// set the max below the current number of members.
+	assert.NonNilErr(t, bob.SetGCJoinPolicy(gcID, 3, true))
+	assert.NonNilErr(t, alice.SetGCJoinPolicy(gcID, 1, true))
+
+	// Alice limits the GC to 3 members and requires approval.
+	assert.NilErr(t, alice.SetGCJoinPolicy(gcID, 3, true))
+	assertGCEventually(t, bob, gcID, func(gc *rpc.RMGroupList) bool {
+		return gc.MaxMembers == 3 && gc.JoinApproval
+	})
+
+	aliceReqChan := make(chan client.GCJoinRequest, 3)
+	alice.handle(client.OnGCJoinRequestNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, req client.GCJoinRequest) {
+		aliceReqChan <- req
+	}))
+	charlieJoinedChan := make(chan struct{}, 1)
+	charlie.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			charlieJoinedChan <- struct{}{}
+		}
+	}))
+	daveJoinedChan := make(chan struct{}, 1)
+	dave.handle(client.OnJoinedGCNtfn(func(gc rpc.RMGroupList) {
+		if gc.ID == gcID {
+			daveJoinedChan <- struct{}{}
+		}
+	}))
+
+	// Charlie's request to join is queued until Alice approves it.
+	link, err := alice.CreateGCInviteLink(gcID, 0, 0)
+	assert.NilErr(t, err)
+	assert.NilErr(t, charlie.RedeemGCInviteLink(link))
+	req := assert.ChanWritten(t, aliceReqChan)
+	assert.DeepEqual(t, req.UID, charlie.PublicID())
+	assert.DeepEqual(t, req.LinkID, link.ID)
+	assert.ChanNotWritten(t, charlieJoinedChan, 500*time.Millisecond)
+	reqs, err := alice.ListGCJoinRequests(&gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(reqs), 1)
+
+	assert.NilErr(t, alice.ApproveGCJoinRequest(gcID, charlie.PublicID()))
+	assert.ChanWritten(t, charlieJoinedChan)
+	reqs, err = alice.ListGCJoinRequests(&gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(reqs), 0)
+
+	// The GC is full, so Dave can neither request to join nor be invited.
+	assert.NilErr(t, dave.RedeemGCInviteLink(link))
+	assert.ChanNotWritten(t, aliceReqChan, 500*time.Millisecond)
+	err = alice.InviteToGroupChat(gcID, dave.PublicID())
+	if !errors.Is(err, client.GCFullError{}) {
+		t.Fatalf("unexpected error: got %v, want %v", err, client.GCFullError{})
+	}
+
+	// Alice removes the limit. Dave requests to join, but Alice denies
+	// the request.
+	assert.NilErr(t, alice.SetGCJoinPolicy(gcID, 0, true))
+	assert.NilErr(t, dave.RedeemGCInviteLink(link))
+	req = assert.ChanWritten(t, aliceReqChan)
+	assert.DeepEqual(t, req.UID, dave.PublicID())
+	assert.NilErr(t, alice.DenyGCJoinRequest(gcID, dave.PublicID()))
+	assert.NonNilErr(t, alice.ApproveGCJoinRequest(gcID, dave.PublicID()))
+	assert.ChanNotWritten(t, daveJoinedChan, 500*time.Millisecond)
+
+	// The changes were recorded in the moderation log.
+	log, err := alice.GCModLog(gcID)
+	assert.NilErr(t, err)
+	var policyChanges int
+	for _, e := range log {
+		if e.Action == client.GCModActionJoinPolicy {
+			policyChanges += 1
+		}
+	}
+	assert.DeepEqual(t, policyChanges, 2)
+
+	assertClientsCanGCM(t, gcID, alice, bob, charlie)
+}
@@ -0,0 +1,65 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCMentionAutoKX tests that a GC member mentioned by an unKXd member is
+// alerted by the GC admin and automatically KXs with the sender when enabled.
+func TestGCMentionAutoKX(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	// Bob and Charlie do not automatically KX with the other GC members.
+	err := bob.SetAutoKXPolicy(&clientdb.AutoKXPolicy{DisableGCRequests: true})
+	assert.NilErr(t, err)
+	err = charlie.SetAutoKXPolicy(&clientdb.AutoKXPolicy{DisableGCRequests: true})
+	assert.NilErr(t, err)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+
+	type mention struct {
+		sender client.UserID
+		autoKX bool
+	}
+	mentionChan := make(chan mention, 5)
+	charlie.handle(client.OnGCUnkxdMentionNtfn(func(_ *client.RemoteUser, m rpc.RMGroupUnkxdMention, autoKX bool) {
+		mentionChan <- mention{sender: m.Sender, autoKX: autoKX}
+	}))
+
+	// Bob mentions Charlie. Charlie is alerted by Alice, but does not KX
+	// with Bob.
+	assert.NilErr(t, bob.GCMessage(gcID, "@charlie hi", rpc.MessageModeNormal, nil))
+	assert.ChanWrittenWithVal(t, mentionChan, mention{sender: bob.PublicID()})
+	time.Sleep(time.Second)
+	assert.DeepEqual(t, charlie.UserExists(bob.PublicID()), false)
+
+	// A message without mentions does not trigger the alert.
+	assert.NilErr(t, bob.GCMessage(gcID, "hello charlie", rpc.MessageModeNormal, nil))
+	assert.ChanNotWritten(t, mentionChan, time.Second)
+
+	// Charlie enables auto KX on mentions in the GC. The next mention
+	// triggers the KX.
+	assert.NilErr(t, charlie.SetGCMentionAutoKX(gcID, true))
+	assert.NilErr(t, bob.GCMessage(gcID, "@charlie hi again", rpc.MessageModeNormal, nil))
+	assert.ChanWrittenWithVal(t, mentionChan, mention{sender: bob.PublicID(), autoKX: true})
+	assertClientsKXd(t, bob, charlie)
+	assertClientsCanPM(t, bob, charlie)
+}
@@ -0,0 +1,78 @@
+package e2etests
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCMetadata tests updating the topic, description and avatar of GCs.
+func TestGCMetadata(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+
+	bobUpdatedChan := make(chan []client.GCMetadataField, 3)
+	bob.handle(client.OnGCMetadataUpdatedNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, fields []client.GCMetadataField) {
+		if gc.ID == gcID {
+			bobUpdatedChan <- fields
+		}
+	}))
+
+	// Bob (a regular member) cannot change the metadata.
+	topic := "the topic"
+	assert.NonNilErr(t, bob.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Topic: &topic}))
+
+	// Alice updates all fields at once.
+	descr := "# GC01\n\nA **test** GC."
+	avatar := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+	err = alice.UpdateGCMetadata(gcID, client.GCMetadataUpdate{
+		Topic:       &topic,
+		Description: &descr,
+		Avatar:      avatar,
+	})
+	assert.NilErr(t, err)
+	fields := assert.ChanWritten(t, bobUpdatedChan)
+	assert.DeepEqual(t, fields, []client.GCMetadataField{client.GCMetadataTopic,
+		client.GCMetadataDescription, client.GCMetadataAvatar})
+	gc, err := bob.GetGC(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gc.Topic, topic)
+	assert.DeepEqual(t, gc.Description, descr)
+	assert.DeepEqual(t, gc.Avatar, avatar)
+
+	// Updates that do not change anything or that are too large are
+	// rejected.
+	assert.NonNilErr(t, alice.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Topic: &topic}))
+	largeAvatar := make([]byte, rpc.MaxGCAvatarSize+1)
+	assert.NonNilErr(t, alice.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Avatar: largeAvatar}))
+
+	// Alice clears the avatar.
+	assert.NilErr(t, alice.UpdateGCMetadata(gcID, client.GCMetadataUpdate{Avatar: []byte{}}))
+	fields = assert.ChanWritten(t, bobUpdatedChan)
+	assert.DeepEqual(t, fields, []client.GCMetadataField{client.GCMetadataAvatar})
+	assertGCEventually(t, bob, gcID, func(gc *rpc.RMGroupList) bool {
+		return len(gc.Avatar) == 0 && gc.Topic == topic
+	})
+
+	// The changes were recorded in the moderation log.
+	log, err := bob.GCModLog(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(log), 4)
+	for _, e := range log {
+		assert.DeepEqual(t, e.Action, client.GCModActionMetadata)
+	}
+
+	assertClientsCanGCM(t, gcID, alice, bob)
+}
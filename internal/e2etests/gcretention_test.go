@@ -0,0 +1,95 @@
+package e2etests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCRetentionPolicy tests pruning GC messages according to a local
+// retention policy.
+func TestGCRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob", withMsgLogs())
+
+	ts.kxUsers(alice, bob)
+
+	gcID, err := alice.NewGroupChat("gc01")
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+
+	bobMsgChan := make(chan string, 10)
+	bob.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+		bobMsgChan <- msg.Message
+	}))
+
+	// Alice sends some messages that Bob receives.
+	const nbMsgs = 5
+	for i := 0; i < nbMsgs; i++ {
+		msg := fmt.Sprintf("retained msg %d", i)
+		assert.NilErr(t, alice.GCMessage(gcID, msg, 0, nil))
+		assert.ChanWrittenWithVal(t, bobMsgChan, msg)
+	}
+
+	bobGCName, err := bob.GetGCAlias(gcID)
+	assert.NilErr(t, err)
+	assertNbHistory := func(c *testClient, gcName string, want int) {
+		t.Helper()
+		history, _, err := c.ReadHistoryMessages(gcID, gcName, 100, 0)
+		assert.NilErr(t, err)
+		if len(history) != want {
+			t.Fatalf("unexpected nb of history msgs: got %d, want %d",
+				len(history), want)
+		}
+	}
+	assertNbHistory(bob, bobGCName, nbMsgs)
+
+	// Bob sets a policy to keep only the last 2 messages.
+	policy := client.GCRetentionPolicy{MaxMessages: 2}
+	pruned, err := bob.SetGCRetentionPolicy(gcID, policy)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, pruned, nbMsgs-2)
+	gotPolicy, err := bob.GetGCRetentionPolicy(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gotPolicy, policy)
+
+	// Only the last messages are kept in the history and search index.
+	assertNbHistory(bob, bobGCName, 2)
+	history, _, err := bob.ReadHistoryMessages(gcID, bobGCName, 100, 0)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, history[0].Message, fmt.Sprintf("retained msg %d", nbMsgs-2))
+	res, err := bob.SearchMessages("retained", clientdb.MsgSearchFilter{GC: &gcID})
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res), 2)
+
+	// Alice's log is not affected.
+	aliceGCName, err := alice.GetGCAlias(gcID)
+	assert.NilErr(t, err)
+	assertNbHistory(alice, aliceGCName, nbMsgs)
+
+	// Messages beyond the limit continue to be logged and are pruned on
+	// the next run.
+	assert.NilErr(t, alice.GCMessage(gcID, "new msg", 0, nil))
+	assert.ChanWrittenWithVal(t, bobMsgChan, "new msg")
+	assertNbHistory(bob, bobGCName, 3)
+	pruned, err = bob.SetGCRetentionPolicy(gcID, policy)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, pruned, 1)
+	assertNbHistory(bob, bobGCName, 2)
+
+	// Removing the policy stops pruning.
+	_, err = bob.SetGCRetentionPolicy(gcID, client.GCRetentionPolicy{})
+	assert.NilErr(t, err)
+	gotPolicy, err = bob.GetGCRetentionPolicy(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gotPolicy.IsEmpty(), true)
+}
@@ -0,0 +1,134 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCModerationRoles tests the moderation roles and permissions of version
+// 2 GCs.
+func TestGCModerationRoles(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	// KX everyone.
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(alice, dave)
+	ts.kxUsers(bob, charlie)
+	ts.kxUsers(bob, dave)
+	ts.kxUsers(charlie, dave)
+
+	// Alice creates a version 2 GC and adds everyone.
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+	assertClientJoinsGC(t, gcID, alice, dave)
+	assertClientsCanGCM(t, gcID, alice, bob, charlie, dave)
+
+	// Default permissions.
+	gc, err := alice.GetGC(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gc.AdminPerms, rpc.GCPermAll)
+	assert.DeepEqual(t, gc.ModeratorPerms, rpc.GCPermKick|rpc.GCPermPin)
+
+	daveModsChan := make(chan []zkidentity.ShortID, 3)
+	dave.handle(client.OnGCModeratorsChangedNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, added, _ []zkidentity.ShortID) {
+		if gc.ID == gcID {
+			daveModsChan <- added
+		}
+	}))
+	bobModsChan := make(chan struct{}, 3)
+	bob.handle(client.OnGCModeratorsChangedNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, _, _ []zkidentity.ShortID) {
+		if gc.ID == gcID {
+			bobModsChan <- struct{}{}
+		}
+	}))
+	aliceRemovedChan := make(chan []clientintf.UserID, 3)
+	alice.handle(client.OnRemovedGCMembersNtfn(func(gc rpc.RMGroupList, uids []clientintf.UserID) {
+		if gc.ID == gcID {
+			aliceRemovedChan <- uids
+		}
+	}))
+
+	// Bob cannot make himself a moderator.
+	err = bob.ModifyGCModerators(gcID, []zkidentity.ShortID{bob.PublicID()}, "")
+	assert.NonNilErr(t, err)
+
+	// Alice makes Charlie an admin and Bob a moderator.
+	assert.NilErr(t, alice.ModifyGCAdmins(gcID, []zkidentity.ShortID{charlie.PublicID()}, ""))
+	err = alice.ModifyGCModerators(gcID, []zkidentity.ShortID{bob.PublicID()}, "")
+	assert.NilErr(t, err)
+	added := assert.ChanWritten(t, daveModsChan)
+	assert.DeepEqual(t, added, []zkidentity.ShortID{bob.PublicID()})
+	assert.ChanWritten(t, bobModsChan)
+	gc, err = bob.GetGC(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, client.GCMemberRole(&gc, bob.PublicID()), client.GCRoleModerator)
+	assert.DeepEqual(t, client.GCMemberRole(&gc, charlie.PublicID()), client.GCRoleAdmin)
+
+	// Bob cannot invite (moderators do not have the invite permission by
+	// default) and cannot kick an admin.
+	assert.NonNilErr(t, bob.InviteToGroupChat(gcID, dave.PublicID()))
+	assert.NonNilErr(t, bob.GCKick(gcID, charlie.PublicID(), ""))
+
+	// Bob pins a message. Everyone sees it.
+	var msgID zkidentity.ShortID
+	msgID[0] = 0x01
+	assert.NilErr(t, bob.PinGCMessage(gcID, msgID))
+	for _, c := range []*testClient{alice, charlie, dave} {
+		assertGCEventually(t, c, gcID, func(gc *rpc.RMGroupList) bool {
+			return len(gc.Pinned) == 1 && gc.Pinned[0] == msgID
+		})
+	}
+
+	// Dave (a regular member) spoofs a change to the GC name. This is not
+	// accepted by Charlie.
+	gc, err = charlie.GetGC(gcID)
+	assert.NilErr(t, err)
+	spoofedUpdate := gc
+	spoofedUpdate.Name = "spoofed"
+	spoofedUpdate.Generation += 1
+	spoofedUpdate.Timestamp = time.Now().Unix()
+	err = dave.testInterface().SendUserRM(charlie.PublicID(), spoofedUpdate)
+	assert.NilErr(t, err)
+	time.Sleep(200 * time.Millisecond)
+	gc, err = charlie.GetGC(gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gc.Name, "gc01")
+
+	// Bob kicks Dave. Alice sees it.
+	assert.NilErr(t, bob.GCKick(gcID, dave.PublicID(), "test"))
+	removed := assert.ChanWritten(t, aliceRemovedChan)
+	assert.DeepEqual(t, removed, []clientintf.UserID{dave.PublicID()})
+
+	// Charlie (an admin) removes the pin permission from moderators. Bob
+	// can no longer unpin.
+	assert.NilErr(t, charlie.SetGCRolePerms(gcID, client.GCRoleModerator, rpc.GCPermKick))
+	assertGCEventually(t, bob, gcID, func(gc *rpc.RMGroupList) bool {
+		return gc.ModeratorPerms == rpc.GCPermKick
+	})
+	assert.NonNilErr(t, bob.UnpinGCMessage(gcID, msgID))
+
+	// Charlie cannot change the admin permissions.
+	assert.NonNilErr(t, charlie.SetGCRolePerms(gcID, client.GCRoleAdmin, rpc.GCPermInvite))
+
+	// Charlie unpins the message.
+	assert.NilErr(t, charlie.UnpinGCMessage(gcID, msgID))
+	assertGCEventually(t, alice, gcID, func(gc *rpc.RMGroupList) bool {
+		return len(gc.Pinned) == 0
+	})
+}
@@ -0,0 +1,87 @@
+package e2etests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestGCSlowMode tests the slow mode of GCs.
+func TestGCSlowMode(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	ts.kxUsers(bob, charlie)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+
+	// Regular members cannot set slow mode.
+	assert.NonNilErr(t, bob.SetGCSlowMode(gcID, time.Minute))
+
+	// Alice enables slow mode and Bob is notified.
+	bobSlowModeChan := make(chan time.Duration, 1)
+	bob.handle(client.OnGCSlowModeChangedNtfn(func(_ *client.RemoteUser, gc rpc.RMGroupList, interval time.Duration) {
+		if gc.ID == gcID {
+			bobSlowModeChan <- interval
+		}
+	}))
+	assert.NilErr(t, alice.SetGCSlowMode(gcID, time.Hour))
+	assert.ChanWrittenWithVal(t, bobSlowModeChan, time.Hour)
+
+	aliceMsgChan := make(chan string, 5)
+	alice.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+		aliceMsgChan <- msg.Message
+	}))
+	charlieMsgChan := make(chan string, 5)
+	charlie.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+		charlieMsgChan <- msg.Message
+	}))
+
+	// Bob can send one message, but not a second one.
+	assert.NilErr(t, bob.GCMessage(gcID, "first", 0, nil))
+	assert.ChanWrittenWithVal(t, aliceMsgChan, "first")
+	assert.ChanWrittenWithVal(t, charlieMsgChan, "first")
+	err = bob.GCMessage(gcID, "second", 0, nil)
+	if !errors.Is(err, client.GCSlowModeError{}) {
+		t.Fatalf("unexpected error: got %v, want %v", err, client.GCSlowModeError{})
+	}
+
+	// Alice (the owner) is not subject to slow mode.
+	assert.NilErr(t, alice.GCMessage(gcID, "admin msg 1", 0, nil))
+	assert.ChanWrittenWithVal(t, charlieMsgChan, "admin msg 1")
+	assert.NilErr(t, alice.GCMessage(gcID, "admin msg 2", 0, nil))
+	assert.ChanWrittenWithVal(t, charlieMsgChan, "admin msg 2")
+
+	// Bob bypasses the check of his client. Alice drops the message,
+	// while Charlie (a regular member) receives it.
+	gc, err := bob.GetGC(gcID)
+	assert.NilErr(t, err)
+	rm := rpc.RMGroupMessage{
+		ID:         gcID,
+		Generation: gc.Generation,
+		Message:    "spam",
+	}
+	assert.NilErr(t, bob.testInterface().SendUserRM(alice.PublicID(), rm))
+	assert.NilErr(t, bob.testInterface().SendUserRM(charlie.PublicID(), rm))
+	assert.ChanWrittenWithVal(t, charlieMsgChan, "spam")
+	assert.ChanNotWritten(t, aliceMsgChan, 500*time.Millisecond)
+
+	// Disabling slow mode allows Bob to send again.
+	assert.NilErr(t, alice.SetGCSlowMode(gcID, 0))
+	assert.ChanWrittenWithVal(t, bobSlowModeChan, 0)
+	assertClientsCanGCM(t, gcID, alice, bob, charlie)
+}
@@ -0,0 +1,100 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestIdentityMigration tests migrating to a new identity and revoking an
+// identity.
+func TestIdentityMigration(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	alice2 := ts.newClient("alice2")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	dave := ts.newClient("dave")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, alice2)
+	ts.kxUsers(alice2, charlie)
+	ts.kxUsers(alice, dave)
+	ts.kxUsers(alice2, dave)
+
+	// The new identity must be a contact.
+	assert.NonNilErr(t, alice.MigrateIdentity(charlie.PublicID(), ""))
+
+	bobMigRecvChan := make(chan clientdb.IdentityMigration, 5)
+	bob.handle(client.OnIdentityMigrationReceivedNtfn(func(ru *client.RemoteUser, mig clientdb.IdentityMigration) {
+		bobMigRecvChan <- mig
+	}))
+	bobMigratedChan := make(chan client.UserID, 5)
+	bob.handle(client.OnIdentityMigratedNtfn(func(oldID client.UserID, newRU *client.RemoteUser) {
+		bobMigratedChan <- newRU.ID()
+	}))
+
+	daveMigRecvChan := make(chan clientdb.IdentityMigration, 5)
+	dave.handle(client.OnIdentityMigrationReceivedNtfn(func(ru *client.RemoteUser, mig clientdb.IdentityMigration) {
+		daveMigRecvChan <- mig
+	}))
+	daveMigratedChan := make(chan client.UserID, 5)
+	dave.handle(client.OnIdentityMigratedNtfn(func(oldID client.UserID, newRU *client.RemoteUser) {
+		daveMigratedChan <- newRU.ID()
+	}))
+
+	// alice2 did not allow the migration, so it is not signed and not
+	// announced.
+	assert.NilErr(t, alice.MigrateIdentity(alice2.PublicID(), "key rotation"))
+	assert.ChanNotWritten(t, bobMigRecvChan, 500*time.Millisecond)
+
+	// Alice moves to alice2. Bob verifies the migration and KXs with the
+	// new identity, which replaces the old one.
+	assert.NilErr(t, alice2.AllowIdentityMigration(alice.PublicID()))
+	assert.NilErr(t, alice.MigrateIdentity(alice2.PublicID(), "key rotation"))
+	mig := assert.ChanWritten(t, bobMigRecvChan)
+	assert.DeepEqual(t, mig.OldID, alice.PublicID())
+	assert.DeepEqual(t, *mig.NewID, alice2.PublicID())
+	assert.DeepEqual(t, mig.Reason, "key rotation")
+	assertClientsKXd(t, bob, alice2)
+	assert.ChanWrittenWithVal(t, bobMigratedChan, alice2.PublicID())
+	assertClientsCanPM(t, bob, alice2)
+
+	ru, err := bob.UserByNick("alice")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ru.ID(), alice2.PublicID())
+	oldRU, err := bob.UserByID(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, oldRU.IsIgnored(), true)
+
+	// Dave was already KX'd with alice2, so the migration is only applied
+	// after he confirms it.
+	mig = assert.ChanWritten(t, daveMigRecvChan)
+	assert.DeepEqual(t, *mig.NewID, alice2.PublicID())
+	assert.ChanNotWritten(t, daveMigratedChan, 500*time.Millisecond)
+	oldRU, err = dave.UserByID(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, oldRU.IsIgnored(), false)
+	assert.NilErr(t, dave.ApplyIdentityMigration(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, daveMigratedChan, alice2.PublicID())
+	assert.DeepEqual(t, oldRU.IsIgnored(), true)
+
+	// alice2 revokes her identity. Charlie ignores it.
+	charlieMigRecvChan := make(chan clientdb.IdentityMigration, 5)
+	charlie.handle(client.OnIdentityMigrationReceivedNtfn(func(ru *client.RemoteUser, mig clientdb.IdentityMigration) {
+		charlieMigRecvChan <- mig
+	}))
+	assert.NilErr(t, alice2.RevokeIdentity("compromised"))
+	mig = assert.ChanWritten(t, charlieMigRecvChan)
+	assert.DeepEqual(t, mig.OldID, alice2.PublicID())
+	assert.DeepEqual(t, mig.NewID, (*client.UserID)(nil))
+	ru, err = charlie.UserByID(alice2.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ru.IsIgnored(), true)
+}
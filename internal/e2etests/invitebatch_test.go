@@ -0,0 +1,78 @@
+package e2etests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/dcrd/dcrutil/v4"
+)
+
+// TestPrepaidInviteBatch tests creating a batch of prepaid invites with a
+// shared funding budget.
+func TestPrepaidInviteBatch(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+
+	// Duplicate labels are rejected.
+	_, err = alice.CreatePrepaidInviteBatch([]string{"a", "a"}, 0, nil, nil)
+	assert.NonNilErr(t, err)
+
+	// Create the batch. The budget is split among the invites.
+	var fundCalls []dcrutil.Amount
+	createFunds := func(amount dcrutil.Amount) (*rpc.InviteFunds, error) {
+		fundCalls = append(fundCalls, amount)
+		return &rpc.InviteFunds{Address: "addr"}, nil
+	}
+	labels := []string{"inv-01", "inv-02", "inv-03"}
+	budget := dcrutil.Amount(3e8)
+	invites, err := alice.CreatePrepaidInviteBatch(labels, budget, createFunds, &gcID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(invites), 3)
+	assert.DeepEqual(t, fundCalls, []dcrutil.Amount{1e8, 1e8, 1e8})
+	for i, inv := range invites {
+		assert.DeepEqual(t, inv.Label, labels[i])
+		assert.DeepEqual(t, inv.FundAmount, dcrutil.Amount(1e8))
+		if inv.Invite.Funds == nil {
+			t.Fatalf("invite %d does not have funds", i)
+		}
+	}
+
+	// Bob and Charlie fetch their invites using the encoded keys and
+	// accept them.
+	fetchAndAccept := func(c *testClient, encKey string) {
+		t.Helper()
+		key, err := clientintf.DecodePaidInviteKey(encKey)
+		assert.NilErr(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		b := bytes.NewBuffer(nil)
+		invite, err := c.FetchPrepaidInvite(ctx, key, b)
+		assert.NilErr(t, err)
+		assert.NilErr(t, c.AcceptInvite(invite))
+	}
+	bobAcceptedChan := bob.acceptNextGCInvite(gcID)
+	charlieAcceptedChan := charlie.acceptNextGCInvite(gcID)
+	fetchAndAccept(bob, invites[0].EncodedKey)
+	fetchAndAccept(charlie, invites[2].EncodedKey)
+	assertClientsKXd(t, alice, bob)
+	assertClientsKXd(t, alice, charlie)
+
+	// Both are invited to the GC after KX.
+	assert.NilErrFromChan(t, bobAcceptedChan)
+	assert.NilErrFromChan(t, charlieAcceptedChan)
+	assertClientInGC(t, bob, gcID)
+	assertClientInGC(t, charlie, gcID)
+}
@@ -0,0 +1,74 @@
+package e2etests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestLinkPreviews tests that previews of links are attached to outgoing
+// messages only when the sender opts in.
+func TestLinkPreviews(t *testing.T) {
+	t.Parallel()
+
+	var reqCount int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reqCount, 1)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Test page</title>
+<meta name="description" content="A test page"></head></html>`))
+	}))
+	defer svr.Close()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withLinkPreviews())
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	pmChan := make(chan string, 5)
+	bob.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+		pmChan <- pm.Message
+	}))
+	gcmChan := make(chan string, 5)
+	alice.handle(client.OnGCMNtfn(func(ru *client.RemoteUser, gcm rpc.RMGroupMessage, ts time.Time) {
+		gcmChan <- gcm.Message
+	}))
+
+	// Alice opted in, so her PM includes the preview.
+	link := svr.URL + "/page"
+	assert.NilErr(t, alice.PM(bob.PublicID(), "check "+link))
+	msg := assert.ChanWritten(t, pmChan)
+	assert.DeepEqual(t, strings.HasPrefix(msg, "check "+link), true)
+	previews := client.ParseLinkPreviews(msg)
+	assert.DeepEqual(t, len(previews), 1)
+	assert.DeepEqual(t, previews[0].URL, link)
+	assert.DeepEqual(t, previews[0].Title, "Test page")
+	assert.DeepEqual(t, previews[0].Description, "A test page")
+
+	// Bob did not opt in, so his GC message does not include previews and
+	// no requests are made.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	wantReqs := atomic.LoadInt32(&reqCount)
+	assert.NilErr(t, bob.GCMessage(gcID, "see "+link, rpc.MessageModeNormal, nil))
+	msg = assert.ChanWritten(t, gcmChan)
+	assert.DeepEqual(t, msg, "see "+link)
+	assert.DeepEqual(t, len(client.ParseLinkPreviews(msg)), 0)
+	assert.DeepEqual(t, atomic.LoadInt32(&reqCount), wantReqs)
+
+	// Links that fail to be fetched are sent without previews.
+	svr.Close()
+	assert.NilErr(t, alice.PM(bob.PublicID(), "gone "+link))
+	msg = assert.ChanWritten(t, pmChan)
+	assert.DeepEqual(t, msg, "gone "+link)
+}
@@ -0,0 +1,57 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestGCMentions tests that mentions in GC messages generate targeted
+// notifications.
+func TestGCMentions(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertJoinsGC(t, alice, charlie, gcID)
+	assertClientInGC(t, bob, gcID)
+	assertClientInGC(t, charlie, gcID)
+
+	bobMentionChan := make(chan rpc.RMGroupMessage, 1)
+	bob.handle(client.OnGCMentionNtfn(func(_ *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		bobMentionChan <- gcm
+	}))
+	charlieGCMChan := make(chan rpc.RMGroupMessage, 1)
+	charlie.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		charlieGCMChan <- gcm
+	}))
+	charlieMentionChan := make(chan rpc.RMGroupMessage, 1)
+	charlie.handle(client.OnGCMentionNtfn(func(_ *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		charlieMentionChan <- gcm
+	}))
+
+	// Alice mentions Bob. Only Bob gets the mention ntfn.
+	assert.NilErr(t, alice.GCMessage(gcID, "@bob, take a look", rpc.MessageModeNormal, nil))
+	gcm := assert.ChanWritten(t, bobMentionChan)
+	assert.DeepEqual(t, gcm.Mentions, []zkidentity.ShortID{bob.PublicID()})
+	assert.ChanWritten(t, charlieGCMChan)
+	assert.ChanNotWritten(t, charlieMentionChan, 500*time.Millisecond)
+
+	// A message without mentions does not generate a mention ntfn.
+	assert.NilErr(t, alice.GCMessage(gcID, "hello bob", rpc.MessageModeNormal, nil))
+	assert.ChanWritten(t, charlieGCMChan)
+	assert.ChanNotWritten(t, bobMentionChan, 500*time.Millisecond)
+}
@@ -0,0 +1,82 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestEditMessages tests that editing PMs and GC messages works.
+func TestEditMessages(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	bobPMChan := make(chan rpc.RMPrivateMessage, 1)
+	bob.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		bobPMChan <- pm
+	}))
+	bobGCMChan := make(chan rpc.RMGroupMessage, 1)
+	bob.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		bobGCMChan <- gcm
+	}))
+	bobEditChan := make(chan rpc.RMEditMessage, 1)
+	bob.handle(client.OnMsgEditedNtfn(func(ru *client.RemoteUser, edit rpc.RMEditMessage, _ time.Time) {
+		bobEditChan <- edit
+	}))
+
+	// Alice sends a PM and Bob receives it with the same ID.
+	msgID, err := alice.PMWithMsgID(bob.PublicID(), "helo")
+	assert.NilErr(t, err)
+	pm := assert.ChanWritten(t, bobPMChan)
+	assert.DeepEqual(t, pm.MsgID, &msgID)
+
+	// Alice fixes the typo.
+	assert.NilErr(t, alice.EditMessage(bob.PublicID(), msgID, "hello"))
+	edit := assert.ChanWritten(t, bobEditChan)
+	assert.DeepEqual(t, edit.MsgID, msgID)
+	assert.DeepEqual(t, edit.Message, "hello")
+	assert.DeepEqual(t, edit.GC, (*zkidentity.ShortID)(nil))
+
+	// Both sides store the edit history.
+	edits, err := bob.MessageEdits(alice.PublicID(), msgID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(edits.Edits), 1)
+	assert.DeepEqual(t, edits.Edits[0].Message, "hello")
+	edits, err = alice.MessageEdits(alice.PublicID(), msgID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(edits.Edits), 1)
+
+	// Alice creates a GC with Bob and sends a message.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	gcMsgID, err := alice.GCMessageWithMsgID(gcID, "gc helo", rpc.MessageModeNormal, nil)
+	assert.NilErr(t, err)
+	gcm := assert.ChanWritten(t, bobGCMChan)
+	assert.DeepEqual(t, gcm.MsgID, &gcMsgID)
+
+	// Alice edits the GC message twice.
+	assert.NilErr(t, alice.EditMessage(gcID, gcMsgID, "gc hello"))
+	edit = assert.ChanWritten(t, bobEditChan)
+	assert.DeepEqual(t, edit.GC, &gcID)
+	assert.DeepEqual(t, edit.Message, "gc hello")
+	assert.NilErr(t, alice.EditMessage(gcID, gcMsgID, "gc hello!"))
+	edit = assert.ChanWritten(t, bobEditChan)
+	assert.DeepEqual(t, edit.Message, "gc hello!")
+
+	edits, err = bob.MessageEdits(alice.PublicID(), gcMsgID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(edits.Edits), 2)
+	assert.DeepEqual(t, edits.GC, &gcID)
+	assert.DeepEqual(t, edits.Edits[1].Message, "gc hello!")
+}
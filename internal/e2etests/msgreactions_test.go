@@ -0,0 +1,80 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestMessageReactions tests that reacting to PMs and GC messages works.
+func TestMessageReactions(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	type reactionEvent struct {
+		reaction  rpc.RMMessageReaction
+		reactions *clientdb.MessageReactions
+	}
+	aliceReactionChan := make(chan reactionEvent, 3)
+	alice.handle(client.OnMsgReactionNtfn(func(_ *client.RemoteUser, reaction rpc.RMMessageReaction,
+		reactions *clientdb.MessageReactions, _ time.Time) {
+		aliceReactionChan <- reactionEvent{reaction, reactions}
+	}))
+	bobPMChan := make(chan rpc.RMPrivateMessage, 1)
+	bob.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		bobPMChan <- pm
+	}))
+
+	// Alice sends a PM and Bob reacts to it.
+	msgID, err := alice.PMWithMsgID(bob.PublicID(), "hello")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobPMChan)
+	assert.NilErr(t, bob.ReactToMessage(alice.PublicID(), msgID, "👍", false))
+	ev := assert.ChanWritten(t, aliceReactionChan)
+	assert.DeepEqual(t, ev.reaction.MsgID, msgID)
+	assert.DeepEqual(t, ev.reaction.Reaction, "👍")
+	assert.DeepEqual(t, ev.reactions.Reactions["👍"], []clientdb.UserID{bob.PublicID()})
+
+	// Reactions are validated.
+	assert.NonNilErr(t, bob.ReactToMessage(alice.PublicID(), msgID, "", false))
+
+	// Alice creates a GC with Bob and Charlie and sends a message.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertJoinsGC(t, alice, charlie, gcID)
+	assertClientInGC(t, bob, gcID)
+	assertClientInGC(t, charlie, gcID)
+	gcMsgID, err := alice.GCMessageWithMsgID(gcID, "gc hello", rpc.MessageModeNormal, nil)
+	assert.NilErr(t, err)
+
+	// Bob and Charlie react with the same emoji, which is aggregated.
+	assert.NilErr(t, bob.ReactToMessage(gcID, gcMsgID, "🎉", false))
+	ev = assert.ChanWritten(t, aliceReactionChan)
+	assert.DeepEqual(t, ev.reaction.GC, &gcID)
+	assert.NilErr(t, charlie.ReactToMessage(gcID, gcMsgID, "🎉", false))
+	ev = assert.ChanWritten(t, aliceReactionChan)
+	assert.DeepEqual(t, len(ev.reactions.Reactions["🎉"]), 2)
+
+	// Bob removes his reaction.
+	assert.NilErr(t, bob.ReactToMessage(gcID, gcMsgID, "🎉", true))
+	ev = assert.ChanWritten(t, aliceReactionChan)
+	assert.DeepEqual(t, ev.reaction.Remove, true)
+	assert.DeepEqual(t, ev.reactions.Reactions["🎉"], []clientdb.UserID{charlie.PublicID()})
+
+	mr, err := alice.MessageReactions(gcMsgID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, mr.GC, &gcID)
+	assert.DeepEqual(t, len(mr.Reactions), 1)
+}
@@ -0,0 +1,91 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestSearchMessages tests searching the message logs.
+func TestSearchMessages(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withMsgLogs())
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	pmChan := make(chan struct{}, 5)
+	alice.handle(client.OnPMNtfn(func(*client.RemoteUser, rpc.RMPrivateMessage, time.Time) {
+		pmChan <- struct{}{}
+	}))
+
+	// Exchange some PMs. One of them has an embedded text file.
+	embed := mdembeds.EmbeddedArgs{
+		Typ:  "text/plain",
+		Alt:  "meeting-notes",
+		Data: []byte("the secret word is Pineapple"),
+	}
+	assert.NilErr(t, alice.PM(bob.PublicID(), "Hello Bob, how are things?"))
+	assert.NilErr(t, bob.PM(alice.PublicID(), "Things are fine. "+embed.String()))
+	assert.ChanWritten(t, pmChan)
+	assert.NilErr(t, charlie.PM(alice.PublicID(), "hello alice, things are great"))
+	assert.ChanWritten(t, pmChan)
+
+	search := func(query string, filter clientdb.MsgSearchFilter) []clientdb.MsgSearchResult {
+		t.Helper()
+		res, err := alice.SearchMessages(query, filter)
+		assert.NilErr(t, err)
+		return res
+	}
+
+	// Searches are case insensitive and require all terms.
+	assert.DeepEqual(t, len(search("things", clientdb.MsgSearchFilter{})), 3)
+	assert.DeepEqual(t, len(search("HELLO things", clientdb.MsgSearchFilter{})), 2)
+	assert.DeepEqual(t, len(search("hello nothere", clientdb.MsgSearchFilter{})), 0)
+
+	// Embedded content is searchable.
+	res := search("pineapple", clientdb.MsgSearchFilter{})
+	assert.DeepEqual(t, len(res), 1)
+	assert.DeepEqual(t, *res[0].UID, bob.PublicID())
+	assert.DeepEqual(t, len(search("meeting-notes", clientdb.MsgSearchFilter{})), 1)
+
+	// Filter by conversation.
+	charlieID := charlie.PublicID()
+	res = search("things", clientdb.MsgSearchFilter{UID: &charlieID})
+	assert.DeepEqual(t, len(res), 1)
+	assert.DeepEqual(t, res[0].Message, "hello alice, things are great")
+
+	// Filter by date range.
+	res = search("things", clientdb.MsgSearchFilter{Until: time.Now().Add(-time.Hour)})
+	assert.DeepEqual(t, len(res), 0)
+	res = search("things", clientdb.MsgSearchFilter{Since: time.Now().Add(-time.Hour), Limit: 2})
+	assert.DeepEqual(t, len(res), 2)
+
+	// GC messages are searchable.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	assert.NilErr(t, alice.GCMessage(gcID, "gc things", rpc.MessageModeNormal, nil))
+	res = search("things", clientdb.MsgSearchFilter{GC: &gcID})
+	assert.DeepEqual(t, len(res), 1)
+	assert.DeepEqual(t, *res[0].GC, gcID)
+
+	// Rebuilding the index from the logs finds the same messages.
+	count, err := alice.RebuildMsgSearchIndex()
+	assert.NilErr(t, err)
+	if count < 4 {
+		t.Fatalf("unexpected nb of indexed messages: %d", count)
+	}
+	assert.DeepEqual(t, len(search("things", clientdb.MsgSearchFilter{})), 4)
+	assert.DeepEqual(t, len(search("pineapple", clientdb.MsgSearchFilter{})), 1)
+}
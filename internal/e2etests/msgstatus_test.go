@@ -0,0 +1,76 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestMsgDeliveryStatus tests that the delivery status of sent messages is
+// tracked until the remote user sends a receive receipt.
+func TestMsgDeliveryStatus(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob", withSendRecvReceipts())
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	type statusEvent struct {
+		uid    client.UserID
+		msgID  zkidentity.ShortID
+		status client.MsgDeliveryStatus
+	}
+	statusChan := make(chan statusEvent, 20)
+	alice.handle(client.OnMsgDeliveryStatusNtfn(func(ru *client.RemoteUser,
+		msgID zkidentity.ShortID, status client.MsgDeliveryStatus, err error) {
+		statusChan <- statusEvent{ru.ID(), msgID, status}
+	}))
+
+	// waitStatus waits until the given status is received for the msg.
+	// Returns the set of statuses received for the msg until then.
+	waitStatus := func(uid client.UserID, msgID zkidentity.ShortID,
+		want client.MsgDeliveryStatus) map[client.MsgDeliveryStatus]bool {
+		t.Helper()
+		seen := make(map[client.MsgDeliveryStatus]bool)
+		for !seen[want] {
+			ev := assert.ChanWritten(t, statusChan)
+			if ev.uid == uid && ev.msgID == msgID {
+				seen[ev.status] = true
+			}
+		}
+		return seen
+	}
+
+	// Bob sends receive receipts, so the msg is marked as delivered.
+	msgID, err := alice.PMWithMsgID(bob.PublicID(), "hello")
+	assert.NilErr(t, err)
+	seen := waitStatus(bob.PublicID(), msgID, client.MsgStatusDelivered)
+	assert.DeepEqual(t, seen[client.MsgStatusQueued], true)
+	assert.DeepEqual(t, seen[client.MsgStatusSent], true)
+	assert.DeepEqual(t, seen[client.MsgStatusFailed], false)
+
+	// Charlie does not send receipts, so the status stops at acked.
+	msgID, err = alice.PMWithMsgID(charlie.PublicID(), "hello")
+	assert.NilErr(t, err)
+	waitStatus(charlie.PublicID(), msgID, client.MsgStatusAcked)
+	assert.ChanNotWritten(t, statusChan, 500*time.Millisecond)
+
+	// GC messages are tracked per member.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertJoinsGC(t, alice, charlie, gcID)
+	assertClientInGC(t, bob, gcID)
+	assertClientInGC(t, charlie, gcID)
+	gcMsgID, err := alice.GCMessageWithMsgID(gcID, "gc hello", rpc.MessageModeNormal, nil)
+	assert.NilErr(t, err)
+	waitStatus(bob.PublicID(), gcMsgID, client.MsgStatusDelivered)
+}
@@ -1,18 +1,29 @@
 package e2etests
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/internal/assert"
 	"github.com/companyzero/bisonrelay/internal/testutils"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/companyzero/bisonrelay/zkidentity"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v4"
 )
 
 // TestTipUserExceedsLifetime asserts that if the max lifetime of the tip
@@ -129,6 +140,191 @@ func TestTipUser(t *testing.T) {
 	assert.NilErrFromChan(t, progressErrChan)
 }
 
+// TestTipUserWebhooks asserts that receiving a tip is reported to the payment
+// webhooks both as a received tip and as a settled invoice.
+func TestTipUserWebhooks(t *testing.T) {
+	t.Parallel()
+
+	eventsChan := make(chan client.PaymentEvent, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev client.PaymentEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		eventsChan <- ev
+	}))
+	t.Cleanup(srv.Close)
+
+	// An unresponsive webhook does not delay deliveries to the others.
+	stallChan := make(chan struct{})
+	stalledSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stallChan
+	}))
+	t.Cleanup(stalledSrv.Close)
+	t.Cleanup(func() { close(stallChan) })
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob", withPaymentWebhooks(
+		client.PaymentWebhook{URL: stalledSrv.URL},
+		client.PaymentWebhook{URL: srv.URL},
+	))
+	ts.kxUsers(alice, bob)
+
+	progressErrChan := make(chan error, 1)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		progressErrChan <- attemptErr
+	}))
+
+	payMAtoms := int64(4321000)
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, err := alice.mpc.DefaultDecodeInvoice(invoice)
+		inv.MAtoms = payMAtoms
+		return inv, err
+	})
+	bob.mpc.HookTrackInvoice(func(inv string, minMAtoms int64) (int64, error) {
+		return minMAtoms, nil
+	})
+	assert.NilErr(t, alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, 1))
+	assert.NilErrFromChan(t, progressErrChan)
+
+	// Both events are delivered, for the same invoice.
+	gotTypes := make(map[string]client.PaymentEvent)
+	for i := 0; i < 2; i++ {
+		ev := assert.ChanWritten(t, eventsChan)
+		assert.DeepEqual(t, ev.UID, alice.PublicID().String())
+		assert.DeepEqual(t, ev.MilliAtoms, payMAtoms)
+		gotTypes[ev.Type] = ev
+	}
+	tipEv, ok := gotTypes[client.PaymentEventTipReceived]
+	assert.DeepEqual(t, ok, true)
+	settledEv, ok := gotTypes[client.PaymentEventInvoiceSettled]
+	assert.DeepEqual(t, ok, true)
+	assert.DeepEqual(t, settledEv.Invoice, tipEv.Invoice)
+	assert.DeepEqual(t, settledEv.Escrow == nil, true)
+	assert.ChanNotWritten(t, eventsChan, 500*time.Millisecond)
+}
+
+// TestTipUserProgressDetails asserts that the detailed tip progress events
+// include the classified failure reason and the remaining retries.
+func TestTipUserProgressDetails(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	progressChan := make(chan client.TipAttemptProgress, 10)
+	alice.handle(client.OnTipAttemptDetailsNtfn(func(ru *client.RemoteUser, progress client.TipAttemptProgress) {
+		progressChan <- progress
+	}))
+
+	const bobNode = "0102030405"
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "free invoice for %d milliatoms", &inv.MAtoms)
+		inv.Destination = bobNode
+		return inv, nil
+	})
+
+	// The first payment fails due to lack of outbound capacity, the
+	// second one succeeds.
+	const fees = 1000
+	var mtx sync.Mutex
+	var payAttempts int
+	alice.mpc.HookPayInvoice(func(string) (int64, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		payAttempts++
+		if payAttempts == 1 {
+			return 0, errors.New("LN payment error: insufficient_balance")
+		}
+		return fees, nil
+	})
+
+	const maxAttempts = 2
+	payMAtoms := int64(4321000)
+	err := alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+
+	progress := assert.ChanWritten(t, progressChan)
+	assert.DeepEqual(t, progress.Completed, false)
+	assert.DeepEqual(t, progress.AmtMAtoms, payMAtoms)
+	assert.DeepEqual(t, progress.Attempt, 1)
+	assert.DeepEqual(t, progress.MaxAttempts, maxAttempts)
+	assert.DeepEqual(t, progress.RemainingRetries, 1)
+	assert.DeepEqual(t, progress.WillRetry, true)
+	assert.DeepEqual(t, progress.FailureReason, client.TipFailureInsufficientBalance)
+	assert.DeepEqual(t, progress.Destination, bobNode)
+
+	progress = assert.ChanWritten(t, progressChan)
+	assert.NilErr(t, progress.Err)
+	assert.DeepEqual(t, progress.Completed, true)
+	assert.DeepEqual(t, progress.Attempt, 2)
+	assert.DeepEqual(t, progress.RemainingRetries, 0)
+	assert.DeepEqual(t, progress.FailureReason, client.TipFailureNone)
+	assert.DeepEqual(t, progress.FeesMAtoms, int64(fees))
+}
+
+// TestPaymentReceipts asserts that a signed receipt is sent after a tip is
+// paid and that both parties store it.
+func TestPaymentReceipts(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	progressErrChan := make(chan error, 1)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		progressErrChan <- attemptErr
+	}))
+	receiptChan := make(chan client.PaymentReceipt, 1)
+	bob.handle(client.OnPaymentReceiptNtfn(func(ru *client.RemoteUser, pr client.PaymentReceipt) {
+		receiptChan <- pr
+	}))
+
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "free invoice for %d milliatoms", &inv.MAtoms)
+		return inv, nil
+	})
+
+	// Send a tip from Alice to Bob.
+	const maxAttempts = 1
+	payMAtoms := int64(4321000)
+	err := alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+
+	// Bob receives the receipt of the payment.
+	pr := assert.ChanWritten(t, receiptChan)
+	assert.DeepEqual(t, pr.UID, alice.PublicID())
+	assert.DeepEqual(t, pr.Outbound, false)
+	assert.DeepEqual(t, pr.Receipt.Kind, rpc.PaymentReceiptKindTip)
+	assert.DeepEqual(t, pr.Receipt.MilliAtoms, payMAtoms)
+	inv := fmt.Sprintf("free invoice for %d milliatoms", payMAtoms)
+	decoded, _ := alice.mpc.DefaultDecodeInvoice(inv)
+	assert.DeepEqual(t, pr.Receipt.PaymentHash, decoded.ID)
+
+	// Both parties stored the receipt.
+	bobReceipts, err := bob.ListPaymentReceipts(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(bobReceipts), 1)
+	assert.DeepEqual(t, bobReceipts[0].Receipt, pr.Receipt)
+	aliceReceipts, err := alice.ListPaymentReceipts(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(aliceReceipts), 1)
+	assert.DeepEqual(t, aliceReceipts[0].Outbound, true)
+	assert.DeepEqual(t, aliceReceipts[0].Receipt, pr.Receipt)
+}
+
 // TestTipUserRejectsWrongInvoiceAmount asserts that if the remote client sends
 // an invoice for an amount different than the expected one, payment is not
 // attempted.
@@ -313,6 +509,68 @@ func TestTipUserMultipleAttempts(t *testing.T) {
 	assert.NilErrFromChan(t, progressErrChan)
 }
 
+// TestTipUserRetryPolicy asserts that the tip retry policy overridden for a
+// user is used when tipping them.
+func TestTipUserRetryPolicy(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	// Bob always fails to generate invoices.
+	bob.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		return "", errors.New("failed to get invoice")
+	})
+
+	progressChan := make(chan client.TipAttemptProgress, 10)
+	alice.handle(client.OnTipAttemptDetailsNtfn(func(ru *client.RemoteUser, progress client.TipAttemptProgress) {
+		progressChan <- progress
+	}))
+
+	// Invalid policies are rejected.
+	invalidAttempts := int32(-1)
+	err := alice.SetContactTipRetryPolicy(bob.PublicID(),
+		clientdb.TipRetryContactPolicy{MaxAttempts: &invalidAttempts})
+	assert.NonNilErr(t, err)
+
+	// Override the policy for Bob.
+	maxAttempts := int32(3)
+	reRequestDelay := 250 * time.Millisecond
+	err = alice.SetContactTipRetryPolicy(bob.PublicID(), clientdb.TipRetryContactPolicy{
+		MaxAttempts:           &maxAttempts,
+		ReRequestInvoiceDelay: &reRequestDelay,
+	})
+	assert.NilErr(t, err)
+	policy := alice.TipRetryPolicy()
+	assert.DeepEqual(t, len(policy.Contacts), 1)
+
+	// Tip without specifying the max attempts. The attempts from the
+	// policy are used.
+	err = alice.TipUser(bob.PublicID(), 0.0001, 0)
+	assert.NilErr(t, err)
+	for i := 1; i <= int(maxAttempts); i++ {
+		progress := assert.ChanWritten(t, progressChan)
+		assert.DeepEqual(t, progress.Attempt, i)
+		assert.DeepEqual(t, progress.MaxAttempts, int(maxAttempts))
+		assert.DeepEqual(t, progress.WillRetry, i < int(maxAttempts))
+	}
+	assert.ChanNotWritten(t, progressChan, reRequestDelay*3)
+
+	// Removing the override reverts to the default policy.
+	err = alice.SetContactTipRetryPolicy(bob.PublicID(), clientdb.TipRetryContactPolicy{})
+	assert.NilErr(t, err)
+	policy = alice.TipRetryPolicy()
+	assert.DeepEqual(t, len(policy.Contacts), 0)
+	err = alice.TipUser(bob.PublicID(), 0.0001, 0)
+	assert.NilErr(t, err)
+	progress := assert.ChanWritten(t, progressChan)
+	assert.DeepEqual(t, progress.MaxAttempts, 1)
+	assert.DeepEqual(t, progress.WillRetry, false)
+}
+
 // TestTipUserWithRestarts asserts that tipping works even when multiple
 // attempts are needed and the clients are restarted.
 func TestTipUserWithRestarts(t *testing.T) {
@@ -1087,3 +1345,903 @@ func TestRecvTipExpired(t *testing.T) {
 	// Bob should not be attempting to track an expired invoice.
 	assert.ChanNotWritten(t, trackInvoiceChan, time.Second)
 }
+
+// TestTipUserKeysend asserts that small tips are sent as keysend payments once
+// the LN node of the remote user is known, falling back to requesting an
+// invoice otherwise.
+func TestTipUserKeysend(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	const maxKeysendMAtoms = 1e8
+	alice := ts.newClient("alice", withTipUserKeysend(maxKeysendMAtoms))
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	progressErrChan := make(chan error, 3)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		progressErrChan <- attemptErr
+	}))
+	tipRecvChan := make(chan int64, 3)
+	bob.handle(client.OnTipReceivedNtfn(func(ru *client.RemoteUser, amountMAtoms int64) {
+		tipRecvChan <- amountMAtoms
+	}))
+
+	// Invoices generated by Bob come from Bob's node.
+	const bobNode = "02bb"
+	bob.mpc.HookTrackInvoice(func(_ string, minMAtoms int64) (int64, error) {
+		return minMAtoms, nil
+	})
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "free invoice for %d milliatoms", &inv.MAtoms)
+		inv.Destination = bobNode
+		return inv, nil
+	})
+	payInvoiceChan := make(chan struct{}, 3)
+	alice.mpc.HookPayInvoice(func(string) (int64, error) {
+		payInvoiceChan <- struct{}{}
+		return 0, nil
+	})
+
+	// Keysend payments are tracked to be verified by Bob.
+	var mtx sync.Mutex
+	keysends := make(map[string]int64)
+	var keysendErr error
+	alice.mpc.HookPayKeysend(func(dest string, amt int64) ([]byte, int64, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if keysendErr != nil {
+			return nil, 0, keysendErr
+		}
+		if dest != bobNode {
+			return nil, 0, fmt.Errorf("unexpected dest %q", dest)
+		}
+		hash := []byte(fmt.Sprintf("keysend %d", len(keysends)))
+		keysends[string(hash)] = amt
+		return hash, 0, nil
+	})
+	bob.mpc.HookKeysendReceived(func(hash []byte) (int64, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if amt, ok := keysends[string(hash)]; ok {
+			return amt, nil
+		}
+		return 0, fmt.Errorf("unknown keysend payment")
+	})
+
+	// The first tip is paid through an invoice, because Bob's node is not
+	// known yet.
+	const maxAttempts = 1
+	payMAtoms := int64(1234000)
+	err := alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+	assert.ChanWritten(t, payInvoiceChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, tipRecvChan), payMAtoms)
+	node, err := alice.UserLNNode(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, node, bobNode)
+
+	// The second tip is sent through keysend.
+	payMAtoms = 2345000
+	err = alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, tipRecvChan), payMAtoms)
+	assert.ChanNotWritten(t, payInvoiceChan, 100*time.Millisecond)
+
+	// A tip larger than the max keysend amount is paid through an invoice.
+	payMAtoms = maxKeysendMAtoms + 1000
+	err = alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+	assert.ChanWritten(t, payInvoiceChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, tipRecvChan), payMAtoms)
+
+	// A failed keysend payment falls back to paying an invoice.
+	mtx.Lock()
+	keysendErr = errors.New("no route")
+	mtx.Unlock()
+	payMAtoms = 3456000
+	err = alice.TipUser(bob.PublicID(), float64(payMAtoms)/1e11, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+	assert.ChanWritten(t, payInvoiceChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, tipRecvChan), payMAtoms)
+	mtx.Lock()
+	assert.DeepEqual(t, len(keysends), 1)
+	mtx.Unlock()
+}
+
+// TestEstimateTipFee asserts that the fee of tips can be estimated once the
+// LN node of the remote user is known.
+func TestEstimateTipFee(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	progressErrChan := make(chan error, 3)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		progressErrChan <- attemptErr
+	}))
+	tipRecvChan := make(chan int64, 3)
+	bob.handle(client.OnTipReceivedNtfn(func(ru *client.RemoteUser, amountMAtoms int64) {
+		tipRecvChan <- amountMAtoms
+	}))
+
+	const bobNode = "02bb"
+	bob.mpc.HookTrackInvoice(func(_ string, minMAtoms int64) (int64, error) {
+		return minMAtoms, nil
+	})
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "free invoice for %d milliatoms", &inv.MAtoms)
+		inv.Destination = bobNode
+		return inv, nil
+	})
+	var routeErr error
+	var mtx sync.Mutex
+	alice.mpc.HookEstimateFee(func(dest string, amt int64) (clientintf.PaymentFeeEstimate, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if dest != bobNode {
+			return clientintf.PaymentFeeEstimate{}, fmt.Errorf("unexpected dest %q", dest)
+		}
+		est := clientintf.PaymentFeeEstimate{
+			AmountMAtoms: amt,
+			FeeMAtoms:    amt / 100,
+			MaxFeeMAtoms: amt / 50,
+			Hops:         2,
+		}
+		return est, routeErr
+	})
+
+	// Bob's node is not known before the first invoice.
+	_, err := alice.EstimateTipFee(bob.PublicID(), 0.001)
+	assert.ErrorIs(t, err, client.ErrUnknownUserLNNode)
+
+	const maxAttempts = 1
+	err = alice.TipUser(bob.PublicID(), 0.001, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NilErrFromChan(t, progressErrChan)
+	assert.DeepEqual(t, assert.ChanWritten(t, tipRecvChan), int64(1e8))
+
+	// Bob's node is now known and the fee can be estimated.
+	est, err := alice.EstimateTipFee(bob.PublicID(), 0.002)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, est, clientintf.PaymentFeeEstimate{
+		AmountMAtoms: 2e8,
+		FeeMAtoms:    2e6,
+		MaxFeeMAtoms: 4e6,
+		Hops:         2,
+	})
+
+	// Errors finding a route are returned.
+	mtx.Lock()
+	routeErr = clientintf.ErrNoRouteWithinFeeLimit
+	mtx.Unlock()
+	_, err = alice.EstimateTipFee(bob.PublicID(), 0.002)
+	assert.ErrorIs(t, err, clientintf.ErrNoRouteWithinFeeLimit)
+
+	// Invalid amounts are rejected.
+	_, err = alice.EstimateTipFee(bob.PublicID(), 0)
+	assert.NonNilErr(t, err)
+}
+
+// TestOnchainTips asserts that large tips that fail to be paid through LN fall
+// back to on-chain payments and that on-chain tips can be sent directly.
+func TestOnchainTips(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	const onchainMinMAtoms = 1e10
+	alice := ts.newClient("alice", withTipUserOnchain(onchainMinMAtoms))
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	// LN payments from Alice always fail.
+	alice.mpc.HookPayInvoice(func(string) (int64, error) {
+		return 0, errors.New("no route")
+	})
+	progressErrChan := make(chan error, 10)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser, amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		progressErrChan <- attemptErr
+	}))
+
+	// Simulate the chain shared by Alice and Bob. Charlie does not support
+	// on-chain payments.
+	type chainTx struct {
+		addr  string
+		atoms int64
+	}
+	var mtx sync.Mutex
+	txs := make(map[chainhash.Hash]chainTx)
+	var confs int32
+	var nbAddrs int
+	bob.mpc.HookNewOnchainAddress(func() (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		nbAddrs++
+		return fmt.Sprintf("bobaddr%d", nbAddrs), nil
+	})
+	alice.mpc.HookSendOnchain(func(addr string, atoms int64) (chainhash.Hash, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		var txh chainhash.Hash
+		txh[0] = byte(len(txs) + 1)
+		txs[txh] = chainTx{addr: addr, atoms: atoms}
+		return txh, nil
+	})
+	txOutput := func(txh chainhash.Hash, addr string) (int64, int32, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		tx, ok := txs[txh]
+		if !ok {
+			return 0, 0, fmt.Errorf("tx %s not found", txh)
+		}
+		if tx.addr != addr {
+			return 0, confs, nil
+		}
+		return tx.atoms, confs, nil
+	}
+	alice.mpc.HookOnchainTxOutput(txOutput)
+	bob.mpc.HookOnchainTxOutput(txOutput)
+
+	aliceTipsChan := make(chan client.OnchainTip, 10)
+	alice.handle(client.OnOnchainTipUpdatedNtfn(func(ru *client.RemoteUser, tip client.OnchainTip) {
+		aliceTipsChan <- tip
+	}))
+	bobTipsChan := make(chan client.OnchainTip, 10)
+	bob.handle(client.OnOnchainTipUpdatedNtfn(func(ru *client.RemoteUser, tip client.OnchainTip) {
+		bobTipsChan <- tip
+	}))
+
+	// A small tip that fails is not sent on-chain.
+	const maxAttempts = 1
+	err := alice.TipUser(bob.PublicID(), 0.001, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NonNilErr(t, assert.ChanWritten(t, progressErrChan))
+	assert.ChanNotWritten(t, aliceTipsChan, 500*time.Millisecond)
+
+	// A large tip that fails is sent on-chain.
+	err = alice.TipUser(bob.PublicID(), 0.2, maxAttempts)
+	assert.NilErr(t, err)
+	assert.NonNilErr(t, assert.ChanWritten(t, progressErrChan))
+	tip := assert.ChanWritten(t, aliceTipsChan)
+	assert.DeepEqual(t, tip.Outbound, true)
+	assert.DeepEqual(t, tip.Atoms, int64(2e7))
+	assert.DeepEqual(t, tip.Address, "bobaddr1")
+	assert.DeepEqual(t, tip.Sent != nil, true)
+	assert.DeepEqual(t, tip.Confirmed == nil, true)
+
+	// Once the tx is confirmed, Alice sends the receipt and Bob verifies
+	// it.
+	mtx.Lock()
+	confs = 1
+	mtx.Unlock()
+	tip = assert.ChanWritten(t, aliceTipsChan)
+	assert.DeepEqual(t, tip.Confirmed != nil, true)
+	bobTip := assert.ChanWritten(t, bobTipsChan)
+	assert.DeepEqual(t, bobTip.ID, tip.ID)
+	assert.DeepEqual(t, bobTip.Outbound, false)
+	assert.DeepEqual(t, bobTip.Atoms, int64(2e7))
+	assert.DeepEqual(t, bobTip.TxHash, tip.TxHash)
+	assert.DeepEqual(t, bobTip.Confirmed != nil, true)
+
+	// Tips can be sent on-chain directly. The tx is confirmed as soon as
+	// it is sent, so the updates may be received in any order.
+	tip, err = alice.SendOnchainTip(bob.PublicID(), 0.003)
+	assert.NilErr(t, err)
+	var confirmed bool
+	for i := 0; i < 2; i++ {
+		sentTip := assert.ChanWritten(t, aliceTipsChan)
+		assert.DeepEqual(t, sentTip.ID, tip.ID)
+		assert.DeepEqual(t, sentTip.Address, "bobaddr2")
+		confirmed = confirmed || sentTip.Confirmed != nil
+	}
+	assert.DeepEqual(t, confirmed, true)
+	bobTip = assert.ChanWritten(t, bobTipsChan)
+	assert.DeepEqual(t, bobTip.Atoms, int64(3e5))
+
+	// Tips to users that do not support on-chain payments fail.
+	tip, err = alice.SendOnchainTip(charlie.PublicID(), 0.003)
+	assert.NilErr(t, err)
+	failedTip := assert.ChanWritten(t, aliceTipsChan)
+	assert.DeepEqual(t, failedTip.ID, tip.ID)
+	assert.DeepEqual(t, failedTip.Error != "", true)
+	assert.DeepEqual(t, failedTip.Sent == nil, true)
+
+	tips, err := alice.ListOnchainTips()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(tips), 3)
+}
+
+// TestTipGCMembers asserts that a tip split across members of a GC sends
+// individual tips to each member and reports aggregated progress.
+func TestTipGCMembers(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+
+	// Invoices encode the amount and the member. Payments to Charlie fail.
+	for _, c := range []*testClient{bob, charlie} {
+		name := c.name
+		c.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+			return fmt.Sprintf("invoice for %d to %s", amt, name), nil
+		})
+	}
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		return inv, nil
+	})
+	alice.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		if strings.HasSuffix(invoice, "to charlie") {
+			return 0, fmt.Errorf("no route")
+		}
+		return 0, nil
+	})
+
+	splitChan := make(chan client.GCTipSplit, 5)
+	alice.handle(client.OnGCTipSplitProgressNtfn(func(split client.GCTipSplit) {
+		splitChan <- split
+	}))
+	bobGCMsgChan := make(chan string, 5)
+	bob.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, msg rpc.RMGroupMessage, _ time.Time) {
+		bobGCMsgChan <- msg.Message
+	}))
+
+	// Split the tip across all members. The odd atom is not tipped.
+	const maxAttempts = 1
+	split, err := alice.TipGCMembers(gcID, 0.00020001, nil, maxAttempts, true)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, split.Share, dcrutil.Amount(10000))
+	assert.DeepEqual(t, len(split.Members), 2)
+
+	// Two progress events are received and the last one is done.
+	assert.DeepEqual(t, assert.ChanWritten(t, splitChan).Done(), false)
+	split = assert.ChanWritten(t, splitChan)
+	assert.DeepEqual(t, split.Done(), true)
+	completed, failed, _ := split.Counts()
+	assert.DeepEqual(t, completed, 1)
+	assert.DeepEqual(t, failed, 1)
+	for _, m := range split.Members {
+		assert.DeepEqual(t, m.Completed, m.UID == bob.PublicID())
+	}
+
+	// The summary is sent to the GC.
+	gotMsg := assert.ChanWritten(t, bobGCMsgChan)
+	if !strings.Contains(gotMsg, "to each of 1 members") || !strings.Contains(gotMsg, "bob") {
+		t.Fatalf("unexpected summary message: %q", gotMsg)
+	}
+
+	// Selected members must be part of the GC.
+	dave := ts.newClient("dave")
+	ts.kxUsers(alice, dave)
+	_, err = alice.TipGCMembers(gcID, 0.0001, []client.UserID{dave.PublicID()},
+		maxAttempts, false)
+	if err == nil {
+		t.Fatalf("expected error tipping non-member")
+	}
+
+	// Tipping only selected members.
+	split, err = alice.TipGCMembers(gcID, 0.0001, []client.UserID{bob.PublicID()},
+		maxAttempts, false)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, split.Share, dcrutil.Amount(10000))
+	split = assert.ChanWritten(t, splitChan)
+	assert.DeepEqual(t, split.Done(), true)
+	assert.DeepEqual(t, split.Members[0].Completed, true)
+	assert.ChanNotWritten(t, bobGCMsgChan, 500*time.Millisecond)
+}
+
+// TestRecurringTips tests that recurring tips are paid when due and that
+// payments that cannot be started are reported.
+func TestRecurringTips(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+
+	var rateMtx sync.Mutex
+	rate, rateUpdated := 20.0, time.Now()
+	dcrUSDRate := func() (float64, time.Time) {
+		rateMtx.Lock()
+		defer rateMtx.Unlock()
+		return rate, rateUpdated
+	}
+	alice := ts.newClient("alice", withDCRUSDRate(dcrUSDRate))
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	invoiceAmtChan := make(chan int64, 5)
+	bob.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		invoiceAmtChan <- amt
+		return fmt.Sprintf("invoice for %d", amt), nil
+	})
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		return inv, nil
+	})
+	failedChan := make(chan error, 5)
+	alice.handle(client.OnRecurringTipFailedNtfn(func(rt client.RecurringTip, err error) {
+		failedChan <- err
+	}))
+
+	// Only one of the amounts may be specified.
+	_, err := alice.AddRecurringTip(bob.PublicID(), 1, 5, clientdb.RecurringTipWeekly)
+	assert.NonNilErr(t, err)
+	_, err = alice.AddRecurringTip(bob.PublicID(), 1, 0, "daily")
+	assert.NonNilErr(t, err)
+
+	// The first payment of a USD-denominated tip is made immediately, at
+	// the current exchange rate.
+	rt, err := alice.AddRecurringTip(bob.PublicID(), 0, 5, clientdb.RecurringTipWeekly)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, assert.ChanWritten(t, invoiceAmtChan), int64(25000000*1000))
+	assert.ChanNotWritten(t, failedChan, 500*time.Millisecond)
+	rt, err = alice.GetRecurringTip(rt.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, rt.Payments, 1)
+	assert.DeepEqual(t, rt.PaidAtoms, int64(25000000))
+	assert.DeepEqual(t, rt.NextPayment.Equal(rt.Created.AddDate(0, 0, 7)), true)
+
+	// A stale exchange rate fails the payment, which is retried later.
+	assert.NilErr(t, alice.PauseRecurringTip(rt.ID, true))
+	rateMtx.Lock()
+	rateUpdated = time.Now().Add(-24 * time.Hour)
+	rateMtx.Unlock()
+	rt2, err := alice.AddRecurringTip(bob.PublicID(), 0, 1, clientdb.RecurringTipMonthly)
+	assert.NilErr(t, err)
+	assert.NonNilErr(t, assert.ChanWritten(t, failedChan))
+	assert.ChanNotWritten(t, invoiceAmtChan, 500*time.Millisecond)
+	rt2, err = alice.GetRecurringTip(rt2.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, rt2.Failures, 1)
+	assert.DeepEqual(t, rt2.Payments, 0)
+	if !rt2.NextPayment.After(time.Now()) || rt2.LastError == "" {
+		t.Fatalf("unexpected recurring tip after failure: %+v", rt2)
+	}
+
+	// Resuming the paused tip does not make the missed payments.
+	assert.NilErr(t, alice.PauseRecurringTip(rt.ID, false))
+	assert.ChanNotWritten(t, invoiceAmtChan, 500*time.Millisecond)
+
+	// Canceled tips are removed.
+	assert.NilErr(t, alice.CancelRecurringTip(rt.ID))
+	assert.NilErr(t, alice.CancelRecurringTip(rt2.ID))
+	tips, err := alice.ListRecurringTips()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(tips), 0)
+}
+
+// TestInvoiceLifecycle tests listing, labeling and canceling the invoices
+// generated and received for tips.
+func TestInvoiceLifecycle(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	// Bob's invoices are only settled when released.
+	releaseChan := make(chan struct{})
+	bob.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		return fmt.Sprintf("invoice for %d", amt), nil
+	})
+	bob.mpc.HookTrackInvoice(func(string, int64) (int64, error) {
+		<-releaseChan
+		return 0, context.Canceled
+	})
+	defer close(releaseChan)
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		return inv, nil
+	})
+	tipDoneChan := make(chan bool, 5)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser,
+		amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		tipDoneChan <- completed
+	}))
+
+	const maxAttempts = 1
+	assert.NilErr(t, alice.TipUser(bob.PublicID(), 0.0001, maxAttempts))
+	assert.DeepEqual(t, assert.ChanWritten(t, tipDoneChan), true)
+	const invoice = "invoice for 10000000"
+
+	// Alice received the invoice and paid it.
+	inv, err := alice.InvoiceStatus(invoice)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, inv.Direction, client.InvoiceReceived)
+	assert.DeepEqual(t, inv.Status, clientdb.InvoiceStatusSettled)
+	assert.DeepEqual(t, inv.UID, bob.PublicID())
+
+	// Bob generated the invoice, which is still open.
+	invoices, err := bob.ListInvoices(true)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(invoices), 1)
+	assert.DeepEqual(t, invoices[0].Invoice, invoice)
+	assert.DeepEqual(t, invoices[0].Direction, client.InvoiceGenerated)
+	assert.DeepEqual(t, invoices[0].MilliAtoms, int64(10000000))
+	assert.DeepEqual(t, invoices[0].UID, alice.PublicID())
+
+	// Label the invoice.
+	assert.NilErr(t, bob.SetInvoiceLabel(invoice, "coffee", "tip from alice"))
+	inv, err = bob.InvoiceStatus(invoice)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, inv.Label, "coffee")
+	assert.DeepEqual(t, inv.Memo, "tip from alice")
+	assert.NonNilErr(t, bob.SetInvoiceLabel("unknown invoice", "x", ""))
+
+	// Received invoices cannot be canceled.
+	assert.NonNilErr(t, alice.CancelInvoice(invoice))
+
+	// Cancel the generated invoice. It is no longer open.
+	assert.NilErr(t, bob.CancelInvoice(invoice))
+	inv, err = bob.InvoiceStatus(invoice)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, inv.Status, clientdb.InvoiceStatusCanceled)
+	assert.DeepEqual(t, inv.Label, "coffee")
+	invoices, err = bob.ListInvoices(true)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(invoices), 0)
+	assert.NonNilErr(t, bob.CancelInvoice(invoice))
+}
+
+// TestPaymentBudgets tests that payment budgets are enforced before tips are
+// paid, unless the override is confirmed.
+func TestPaymentBudgets(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+
+	var confirmMtx sync.Mutex
+	confirm := false
+	confirmChan := make(chan client.PaymentBudgetExceededError, 5)
+	confirmer := func(err client.PaymentBudgetExceededError) bool {
+		confirmChan <- err
+		confirmMtx.Lock()
+		defer confirmMtx.Unlock()
+		return confirm
+	}
+	alice := ts.newClient("alice", withPaymentBudgetConfirmer(confirmer))
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	for _, c := range []*testClient{bob, charlie} {
+		c.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+			return fmt.Sprintf("invoice for %d", amt), nil
+		})
+	}
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		return inv, nil
+	})
+	tipDoneChan := make(chan error, 5)
+	alice.handle(client.OnTipAttemptProgressNtfn(func(ru *client.RemoteUser,
+		amtMAtoms int64, completed bool, attempt int, attemptErr error, willRetry bool) {
+		if completed || !willRetry {
+			tipDoneChan <- attemptErr
+		}
+	}))
+
+	// Global budget of 0.001 DCR per day and budget of 0.0004 DCR per
+	// month for Charlie.
+	assert.NilErr(t, alice.SetPaymentBudget(client.PaymentBudget{
+		Scope:      clientdb.PaymentBudgetGlobal,
+		DailyAtoms: 100000,
+	}))
+	assert.NilErr(t, alice.SetPaymentBudget(client.PaymentBudget{
+		Scope:        clientdb.PaymentBudgetUser,
+		ID:           charlie.PublicID(),
+		MonthlyAtoms: 40000,
+	}))
+	assert.NonNilErr(t, alice.SetPaymentBudget(client.PaymentBudget{
+		Scope: clientdb.PaymentBudgetGlobal,
+	}))
+
+	// Tips within the budgets are paid.
+	const maxAttempts = 1
+	assert.NilErr(t, alice.TipUser(bob.PublicID(), 0.0004, maxAttempts))
+	assert.NilErr(t, assert.ChanWritten(t, tipDoneChan))
+	assert.NilErr(t, alice.TipUser(charlie.PublicID(), 0.0003, maxAttempts))
+	assert.NilErr(t, assert.ChanWritten(t, tipDoneChan))
+
+	// Tips that exceed a budget fail when not confirmed.
+	err := alice.TipUser(charlie.PublicID(), 0.0002, maxAttempts)
+	assert.ErrorIs(t, err, client.PaymentBudgetExceededError{})
+	budgetErr := assert.ChanWritten(t, confirmChan)
+	assert.DeepEqual(t, budgetErr.Budget.Scope, clientdb.PaymentBudgetUser)
+	assert.DeepEqual(t, budgetErr.Period, "monthly")
+	err = alice.TipUser(bob.PublicID(), 0.0006, maxAttempts)
+	assert.ErrorIs(t, err, client.PaymentBudgetExceededError{})
+	budgetErr = assert.ChanWritten(t, confirmChan)
+	assert.DeepEqual(t, budgetErr.Budget.Scope, clientdb.PaymentBudgetGlobal)
+	assert.DeepEqual(t, budgetErr.Spent, dcrutil.Amount(70000))
+
+	// Failed payments do not count towards the budget.
+	alice.mpc.HookPayInvoice(func(string) (int64, error) {
+		return 0, fmt.Errorf("no route")
+	})
+	assert.NilErr(t, alice.TipUser(bob.PublicID(), 0.0001, maxAttempts))
+	assert.NonNilErr(t, assert.ChanWritten(t, tipDoneChan))
+	alice.mpc.HookPayInvoice(nil)
+	budgets, err := alice.PaymentBudgets()
+	assert.NilErr(t, err)
+	for _, st := range budgets {
+		if st.Budget.Scope == clientdb.PaymentBudgetGlobal {
+			assert.DeepEqual(t, st.DailySpent, dcrutil.Amount(70000))
+		}
+	}
+
+	// Confirmed tips are paid even when exceeding the budget.
+	confirmMtx.Lock()
+	confirm = true
+	confirmMtx.Unlock()
+	assert.NilErr(t, alice.TipUser(bob.PublicID(), 0.0006, maxAttempts))
+	assert.ChanWritten(t, confirmChan)
+	assert.NilErr(t, assert.ChanWritten(t, tipDoneChan))
+	assert.ChanNotWritten(t, confirmChan, 250*time.Millisecond)
+
+	// Removing the budgets allows the tips.
+	assert.NilErr(t, alice.RemovePaymentBudget(clientdb.PaymentBudgetGlobal, zkidentity.ShortID{}))
+	assert.NilErr(t, alice.RemovePaymentBudget(clientdb.PaymentBudgetUser, charlie.PublicID()))
+	assert.NilErr(t, alice.TipUser(charlie.PublicID(), 0.001, maxAttempts))
+	assert.NilErr(t, assert.ChanWritten(t, tipDoneChan))
+	assert.ChanNotWritten(t, confirmChan, 250*time.Millisecond)
+}
+
+// TestPayExternalInvoiceBudget tests that payments of invoices not associated
+// with any contact are reserved against the global payment budget.
+func TestPayExternalInvoiceBudget(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+
+	confirmChan := make(chan client.PaymentBudgetExceededError, 5)
+	confirmer := func(err client.PaymentBudgetExceededError) bool {
+		confirmChan <- err
+		return false
+	}
+	alice := ts.newClient("alice", withPaymentBudgetConfirmer(confirmer))
+
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		fmt.Sscanf(invoice, "invoice for %d", &inv.MAtoms)
+		return inv, nil
+	})
+	paidChan := make(chan string, 5)
+	alice.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		paidChan <- invoice
+		return 0, nil
+	})
+	globalSpent := func() dcrutil.Amount {
+		t.Helper()
+		budgets, err := alice.PaymentBudgets()
+		assert.NilErr(t, err)
+		for _, st := range budgets {
+			if st.Budget.Scope == clientdb.PaymentBudgetGlobal {
+				return st.DailySpent
+			}
+		}
+		t.Fatal("global budget not found")
+		return 0
+	}
+
+	// Global budget of 0.001 DCR per day.
+	assert.NilErr(t, alice.SetPaymentBudget(client.PaymentBudget{
+		Scope:      clientdb.PaymentBudgetGlobal,
+		DailyAtoms: 100000,
+	}))
+
+	// Invoices within the budget are paid and count towards it.
+	ctx := context.Background()
+	_, err := alice.PayExternalInvoice(ctx, "invoice for 70000000")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, assert.ChanWritten(t, paidChan), "invoice for 70000000")
+	assert.DeepEqual(t, globalSpent(), dcrutil.Amount(70000))
+
+	// Invoices that exceed the budget are not paid when not confirmed.
+	_, err = alice.PayExternalInvoice(ctx, "invoice for 40000000")
+	assert.ErrorIs(t, err, client.PaymentBudgetExceededError{})
+	budgetErr := assert.ChanWritten(t, confirmChan)
+	assert.DeepEqual(t, budgetErr.Budget.Scope, clientdb.PaymentBudgetGlobal)
+	assert.ChanNotWritten(t, paidChan, 250*time.Millisecond)
+
+	// Failed payments release their reservation.
+	alice.mpc.HookPayInvoice(func(string) (int64, error) {
+		return 0, fmt.Errorf("no route")
+	})
+	_, err = alice.PayExternalInvoice(ctx, "invoice for 10000000")
+	assert.NonNilErr(t, err)
+	assert.DeepEqual(t, globalSpent(), dcrutil.Amount(70000))
+}
+
+// TestEscrows asserts that escrowed payments are held until released by the
+// buyer, and that the seller may cancel them or let them expire.
+func TestEscrows(t *testing.T) {
+	t.Parallel()
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie", withEscrowMaxLifetime(time.Second))
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	// Simulate hold invoices generated by the sellers and paid by Alice.
+	// Payments of Alice only complete when the invoice is settled or
+	// canceled.
+	type holdInvoice struct {
+		state clientintf.HoldInvoiceState
+		done  chan error
+	}
+	var mtx sync.Mutex
+	holdInvoices := make(map[string]*holdInvoice)
+	invoiceHash := func(invoice string) string {
+		var hash string
+		fmt.Sscanf(invoice, "hold invoice %s", &hash)
+		return hash
+	}
+	for _, seller := range []*testClient{bob, charlie} {
+		seller.mpc.HookAddHoldInvoice(func(hash []byte, mAtoms int64, _ time.Duration) (string, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			h := hex.EncodeToString(hash)
+			holdInvoices[h] = &holdInvoice{
+				state: clientintf.HoldInvoiceOpen,
+				done:  make(chan error, 1),
+			}
+			return fmt.Sprintf("hold invoice %s %d", h, mAtoms), nil
+		})
+		seller.mpc.HookHoldInvoiceState(func(hash []byte) (clientintf.HoldInvoiceState, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			inv := holdInvoices[hex.EncodeToString(hash)]
+			if inv == nil {
+				return "", fmt.Errorf("unknown hold invoice")
+			}
+			return inv.state, nil
+		})
+		seller.mpc.HookSettleHoldInvoice(func(preimage []byte) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			hash := sha256.Sum256(preimage)
+			inv := holdInvoices[hex.EncodeToString(hash[:])]
+			if inv == nil || inv.state != clientintf.HoldInvoiceAccepted {
+				return fmt.Errorf("hold invoice not accepted")
+			}
+			inv.state = clientintf.HoldInvoiceSettled
+			inv.done <- nil
+			return nil
+		})
+		seller.mpc.HookCancelHoldInvoice(func(hash []byte) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			inv := holdInvoices[hex.EncodeToString(hash)]
+			if inv == nil || inv.state == clientintf.HoldInvoiceSettled {
+				return fmt.Errorf("hold invoice cannot be canceled")
+			}
+			if inv.state == clientintf.HoldInvoiceAccepted {
+				inv.done <- clientintf.ErrInvoiceCanceled
+			}
+			inv.state = clientintf.HoldInvoiceCanceled
+			return nil
+		})
+	}
+	alice.mpc.HookDecodeInvoice(func(invoice string) (clientintf.DecodedInvoice, error) {
+		inv, _ := alice.mpc.DefaultDecodeInvoice(invoice)
+		var hash string
+		fmt.Sscanf(invoice, "hold invoice %s %d", &hash, &inv.MAtoms)
+		inv.ID, _ = hex.DecodeString(hash)
+		return inv, nil
+	})
+	alice.mpc.HookPayInvoice(func(invoice string) (int64, error) {
+		mtx.Lock()
+		inv := holdInvoices[invoiceHash(invoice)]
+		if inv == nil || inv.state != clientintf.HoldInvoiceOpen {
+			mtx.Unlock()
+			return 0, fmt.Errorf("hold invoice not open")
+		}
+		inv.state = clientintf.HoldInvoiceAccepted
+		mtx.Unlock()
+		return 0, <-inv.done
+	})
+
+	aliceEscrows := make(chan client.Escrow, 100)
+	alice.handle(client.OnEscrowUpdatedNtfn(func(ru *client.RemoteUser, e client.Escrow) {
+		aliceEscrows <- e
+	}))
+	sellerEscrows := make(chan client.Escrow, 100)
+	for _, seller := range []*testClient{bob, charlie} {
+		seller.handle(client.OnEscrowUpdatedNtfn(func(ru *client.RemoteUser, e client.Escrow) {
+			sellerEscrows <- e
+		}))
+	}
+	waitStatus := func(c chan client.Escrow, id zkidentity.ShortID,
+		status clientdb.EscrowStatus) client.Escrow {
+		t.Helper()
+		for {
+			e := assert.ChanWritten(t, c)
+			if e.ID == id && e.Status == status {
+				return e
+			}
+		}
+	}
+
+	// Alice requests an escrow. The payment is held by Bob.
+	const dcrAmount = 0.001
+	e, err := alice.RequestEscrow(bob.PublicID(), dcrAmount, "order #1")
+	assert.NilErr(t, err)
+	waitStatus(aliceEscrows, e.ID, clientdb.EscrowStatusHeld)
+	se := waitStatus(sellerEscrows, e.ID, clientdb.EscrowStatusHeld)
+	assert.DeepEqual(t, se.Description, "order #1")
+	assert.DeepEqual(t, se.MilliAtoms, int64(dcrAmount*1e11))
+
+	// Bob cannot settle before Alice releases the payment and Alice cannot
+	// cancel the held payment.
+	assert.NonNilErr(t, alice.CancelEscrow(bob.PublicID(), e.ID, ""))
+
+	// Bob fulfills the order and Alice releases the payment.
+	err = bob.FulfillEscrow(alice.PublicID(), e.ID, "shipped")
+	assert.NilErr(t, err)
+	ae := waitStatus(aliceEscrows, e.ID, clientdb.EscrowStatusFulfilled)
+	assert.DeepEqual(t, ae.Note, "shipped")
+	assert.NilErr(t, alice.ReleaseEscrow(bob.PublicID(), e.ID))
+	waitStatus(sellerEscrows, e.ID, clientdb.EscrowStatusSettled)
+	waitStatus(aliceEscrows, e.ID, clientdb.EscrowStatusSettled)
+
+	// Bob cancels a second escrow, which returns the payment to Alice.
+	e, err = alice.RequestEscrow(bob.PublicID(), dcrAmount, "order #2")
+	assert.NilErr(t, err)
+	waitStatus(sellerEscrows, e.ID, clientdb.EscrowStatusHeld)
+	assert.NilErr(t, bob.CancelEscrow(alice.PublicID(), e.ID, "out of stock"))
+	ae = waitStatus(aliceEscrows, e.ID, clientdb.EscrowStatusCanceled)
+	assert.DeepEqual(t, strings.Contains(ae.Error, "out of stock") ||
+		strings.Contains(ae.Error, "payment failed"), true)
+
+	// An escrow held by Charlie is canceled when it expires.
+	e, err = alice.RequestEscrow(charlie.PublicID(), dcrAmount, "order #3")
+	assert.NilErr(t, err)
+	waitStatus(sellerEscrows, e.ID, clientdb.EscrowStatusHeld)
+	se = waitStatus(sellerEscrows, e.ID, clientdb.EscrowStatusCanceled)
+	assert.DeepEqual(t, se.Error, "escrow expired")
+	waitStatus(aliceEscrows, e.ID, clientdb.EscrowStatusCanceled)
+
+	// Only the first escrow was paid.
+	escrows, err := alice.ListEscrows()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(escrows), 3)
+	var settled int
+	for _, e := range escrows {
+		if e.Status == clientdb.EscrowStatusSettled {
+			settled++
+		}
+	}
+	assert.DeepEqual(t, settled, 1)
+}
@@ -0,0 +1,78 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestPendingRMsManagement tests listing, canceling and reprioritizing
+// outbound RMs that are pending to be sent to the server.
+func TestPendingRMsManagement(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	bobPMChan := make(chan string, 3)
+	bob.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		bobPMChan <- pm.Message
+	}))
+
+	// Alice goes offline and queues some PMs.
+	assertGoesOffline(t, alice)
+	msgs := []string{"first", "second", "third"}
+	errChans := make([]chan error, len(msgs))
+	for i := range msgs {
+		errChans[i] = make(chan error, 1)
+		go func(i int) { errChans[i] <- alice.PM(bob.PublicID(), msgs[i]) }(i)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// All PMs are listed as pending.
+	var rms []client.PendingRM
+	var err error
+	for i := 0; i < 100; i++ {
+		rms, err = alice.ListPendingRMs(nil)
+		assert.NilErr(t, err)
+		if len(rms) == len(msgs) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.DeepEqual(t, len(rms), len(msgs))
+	for i := range rms {
+		assert.DeepEqual(t, rms[i].UID, bob.PublicID())
+		assert.DeepEqual(t, rms[i].Type, "rpc.RMPrivateMessage")
+		assert.DeepEqual(t, rms[i].Encrypted, false)
+	}
+
+	// Cancel the second PM. Its sender receives an error.
+	assert.NilErr(t, alice.CancelPendingRM(bob.PublicID(), rms[1].ID))
+	assert.ErrorIs(t, assert.ChanWritten(t, errChans[1]), client.ErrRMCanceled)
+	assert.NonNilErr(t, alice.CancelPendingRM(bob.PublicID(), rms[1].ID))
+
+	// Send the first PM last.
+	assert.NilErr(t, alice.ReprioritizePendingRM(bob.PublicID(), rms[0].ID, 4))
+	rms, err = alice.ListPendingRMs(nil)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(rms), 2)
+
+	// Alice goes online and Bob receives the remaining PMs.
+	assertGoesOnline(t, alice)
+	assert.NilErrFromChan(t, errChans[0])
+	assert.NilErrFromChan(t, errChans[2])
+	assert.DeepEqual(t, assert.ChanWritten(t, bobPMChan), "third")
+	assert.DeepEqual(t, assert.ChanWritten(t, bobPMChan), "first")
+	assert.ChanNotWritten(t, bobPMChan, 500*time.Millisecond)
+
+	rms, err = alice.ListPendingRMs(nil)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(rms), 0)
+}
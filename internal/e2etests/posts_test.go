@@ -1,13 +1,31 @@
 package e2etests
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/companyzero/bisonrelay/client"
 	"github.com/companyzero/bisonrelay/client/clientdb"
 	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/client/postfeeds"
+	"github.com/companyzero/bisonrelay/client/postimport"
+	"github.com/companyzero/bisonrelay/client/postsite"
 	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/internal/imgproc"
+	"github.com/companyzero/bisonrelay/internal/mdembeds"
 	"github.com/companyzero/bisonrelay/rpc"
 	"github.com/companyzero/bisonrelay/zkidentity"
 )
@@ -310,3 +328,1033 @@ func TestAutoSubToPosts(t *testing.T) {
 	assert.ChanWrittenWithVal(t, charlieSubChan, bob.PublicID())
 	assert.ChanNotWritten(t, aliceSubChan, 150*time.Millisecond)
 }
+
+// TestPostCommentTree tests threaded replies to comments on posts.
+func TestPostCommentTree(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan rpc.PostMetadata, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+	bobRecvComments := make(chan string, 10)
+	bob.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+		statusFrom client.UserID, status rpc.PostMetadataStatus) {
+		bobRecvComments <- status.Attributes[rpc.RMPSComment]
+	}))
+	bobSubChanged := make(chan bool, 1)
+	bob.handle(client.OnRemoteSubscriptionChangedNtfn(func(user *client.RemoteUser, subscribed bool) {
+		bobSubChanged <- subscribed
+	}))
+
+	ts.kxUsers(alice, bob)
+	assert.NilErr(t, bob.SubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, bobSubChanged, true)
+	alicePost, err := alice.CreatePost("post", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+
+	// Alice comments, Bob replies and Alice replies to Bob.
+	c1, err := alice.CommentPost(alice.PublicID(), alicePost.ID, "c1", nil)
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, bobRecvComments, "c1")
+	c2, err := bob.CommentPost(alice.PublicID(), alicePost.ID, "c2", &c1)
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, bobRecvComments, "c2")
+	c3, err := alice.CommentPost(alice.PublicID(), alicePost.ID, "c3", &c2)
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, bobRecvComments, "c3")
+
+	// Both Alice and Bob see the same tree.
+	for _, c := range []*testClient{alice, bob} {
+		roots, err := c.PostCommentTree(alice.PublicID(), alicePost.ID)
+		assert.NilErr(t, err)
+		if len(roots) != 1 || roots[0].ID != c1 {
+			t.Fatalf("unexpected roots for %s", c.name)
+		}
+		cmt := roots[0]
+		for i, id := range []clientintf.ID{c2, c3} {
+			if len(cmt.Children) != 1 || cmt.Children[0].ID != id {
+				t.Fatalf("unexpected children of depth %d for %s", i, c.name)
+			}
+			cmt = cmt.Children[0]
+			assert.DeepEqual(t, cmt.Depth, i+1)
+		}
+	}
+
+	// Fetch a pruned subtree.
+	cmt, err := bob.PostCommentSubtree(alice.PublicID(), alicePost.ID, c2, 0)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cmt.ID, c2)
+	assert.DeepEqual(t, len(cmt.Children), 0)
+	_, err = bob.PostCommentSubtree(alice.PublicID(), alicePost.ID, alicePost.ID, -1)
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+
+	// A reply to an unknown comment (which may not have been received yet)
+	// is relayed by Alice and shown as a top-level comment.
+	unknown := clientintf.ID{0: 0xff}
+	orphan, err := bob.CommentPost(alice.PublicID(), alicePost.ID, "orphan", &unknown)
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, bobRecvComments, "orphan")
+	roots, err := alice.PostCommentTree(alice.PublicID(), alicePost.ID)
+	assert.NilErr(t, err)
+	if len(roots) != 2 || roots[1].ID != orphan {
+		t.Fatalf("unexpected roots after orphan comment")
+	}
+
+	// Alice cannot reply beyond the max depth.
+	parent := c3
+	for i := 3; i <= client.MaxPostCommentDepth; i++ {
+		comment := fmt.Sprintf("deep %d", i)
+		parent, err = alice.CommentPost(alice.PublicID(), alicePost.ID, comment, &parent)
+		assert.NilErr(t, err)
+		assert.ChanWrittenWithVal(t, bobRecvComments, comment)
+	}
+	_, err = alice.CommentPost(alice.PublicID(), alicePost.ID, "too deep", &parent)
+	assert.NonNilErr(t, err)
+	_, err = bob.CommentPost(alice.PublicID(), alicePost.ID, "too deep", &parent)
+	assert.NonNilErr(t, err)
+}
+
+// TestPostReactions tests reacting to posts and that reactions are aggregated
+// by the author and subscribers.
+func TestPostReactions(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	charlieRecvPosts := make(chan rpc.PostMetadata, 1)
+	charlie.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		charlieRecvPosts <- pm
+	}))
+	handleReactions := func(c *testClient) chan string {
+		ch := make(chan string, 10)
+		c.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+			statusFrom client.UserID, status rpc.PostMetadataStatus) {
+			if r := status.Attributes[rpc.RMPSReaction]; r != "" {
+				ch <- r
+			} else if r := status.Attributes[rpc.RMPSReactionRemove]; r != "" {
+				ch <- "-" + r
+			}
+		}))
+		return ch
+	}
+	bobRecvReactions := handleReactions(bob)
+	charlieRecvReactions := handleReactions(charlie)
+	assertRecvReaction := func(reaction string) {
+		t.Helper()
+		assert.ChanWrittenWithVal(t, bobRecvReactions, reaction)
+		assert.ChanWrittenWithVal(t, charlieRecvReactions, reaction)
+	}
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	assertSubscribeToPosts(t, alice, bob)
+	assertSubscribeToPosts(t, alice, charlie)
+	alicePost, err := alice.CreatePost("post", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, charlieRecvPosts)
+
+	// Bob and Alice react to the post.
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), alicePost.ID, "👍", false))
+	assertRecvReaction("👍")
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), alicePost.ID, "🎉", false))
+	assertRecvReaction("🎉")
+	assert.NilErr(t, alice.ReactToPost(alice.PublicID(), alicePost.ID, "👍", false))
+	assertRecvReaction("👍")
+
+	// Duplicate reactions and removal of unknown reactions are not relayed.
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), alicePost.ID, "👍", false))
+	assert.ChanNotWritten(t, charlieRecvReactions, 500*time.Millisecond)
+	assert.ChanNotWritten(t, bobRecvReactions, 100*time.Millisecond)
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), alicePost.ID, "👀", true))
+	assert.ChanNotWritten(t, charlieRecvReactions, 500*time.Millisecond)
+	assert.ChanNotWritten(t, bobRecvReactions, 100*time.Millisecond)
+
+	// Reactions are aggregated the same way everywhere.
+	wantReactions := func(want []client.PostReaction) {
+		t.Helper()
+		for _, c := range []*testClient{alice, bob, charlie} {
+			got, err := c.PostReactions(alice.PublicID(), alicePost.ID)
+			assert.NilErr(t, err)
+			assert.DeepEqual(t, got, want)
+		}
+	}
+	wantReactions([]client.PostReaction{
+		{Reaction: "👍", Users: []clientintf.UserID{bob.PublicID(), alice.PublicID()}},
+		{Reaction: "🎉", Users: []clientintf.UserID{bob.PublicID()}},
+	})
+
+	// Bob removes a reaction. Reactions with the same number of users are
+	// sorted by reaction.
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), alicePost.ID, "👍", true))
+	assertRecvReaction("-👍")
+	wantReactions([]client.PostReaction{
+		{Reaction: "🎉", Users: []clientintf.UserID{bob.PublicID()}},
+		{Reaction: "👍", Users: []clientintf.UserID{alice.PublicID()}},
+	})
+
+	// Reactions are not comments.
+	roots, err := charlie.PostCommentTree(alice.PublicID(), alicePost.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(roots), 0)
+}
+
+// TestPostCommentModeration tests that post authors can hide and flag comments
+// and mute commenters.
+func TestPostCommentModeration(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie", withIgnorePostModeration())
+
+	handleStatus := func(c *testClient) chan rpc.PostMetadataStatus {
+		ch := make(chan rpc.PostMetadataStatus, 10)
+		c.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+			statusFrom client.UserID, status rpc.PostMetadataStatus) {
+			ch <- status
+		}))
+		return ch
+	}
+	aliceRecvStatus := handleStatus(alice)
+	bobRecvStatus := handleStatus(bob)
+	charlieRecvStatus := handleStatus(charlie)
+	charlieRecvPosts := make(chan rpc.PostMetadata, 1)
+	charlie.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		charlieRecvPosts <- pm
+	}))
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	assertSubscribeToPosts(t, alice, bob)
+	assertSubscribeToPosts(t, alice, charlie)
+	post, err := alice.CreatePost("post", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, charlieRecvPosts)
+
+	// Bob comments and Alice hides and flags the comment.
+	cid, err := bob.CommentPost(alice.PublicID(), post.ID, "spam", nil)
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, aliceRecvStatus)
+	assert.ChanWritten(t, bobRecvStatus)
+	assert.ChanWritten(t, charlieRecvStatus)
+	assert.NilErr(t, alice.ModeratePostComment(post.ID, cid, rpc.RMPSModerationHide))
+	assert.NilErr(t, alice.ModeratePostComment(post.ID, cid, rpc.RMPSModerationFlag))
+	for i := 0; i < 2; i++ {
+		assert.ChanWritten(t, aliceRecvStatus)
+		assert.ChanWritten(t, bobRecvStatus)
+	}
+
+	// Moderation of unknown comments fails.
+	err = alice.ModeratePostComment(post.ID, clientintf.ID{0: 0xff}, rpc.RMPSModerationHide)
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+
+	// Only posts created by the local client may be moderated.
+	err = bob.ModeratePostComment(post.ID, cid, rpc.RMPSModerationUnhide)
+	assert.NonNilErr(t, err)
+
+	// Alice and Bob see the comment as hidden and flagged, while Charlie,
+	// who ignores moderation, does not.
+	for _, c := range []*testClient{alice, bob, charlie} {
+		roots, err := c.PostCommentTree(alice.PublicID(), post.ID)
+		assert.NilErr(t, err)
+		assert.DeepEqual(t, len(roots), 1)
+		wantModerated := c != charlie
+		assert.DeepEqual(t, roots[0].Hidden, wantModerated)
+		assert.DeepEqual(t, roots[0].Flagged, wantModerated)
+	}
+
+	// Alice mutes Bob, so his comments are not relayed.
+	assert.NilErr(t, alice.MutePostCommenter(bob.PublicID(), true))
+	muted, err := alice.ListMutedPostCommenters()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(muted), 1)
+	_, err = bob.CommentPost(alice.PublicID(), post.ID, "more spam", nil)
+	assert.NilErr(t, err)
+	assert.ChanNotWritten(t, charlieRecvStatus, 500*time.Millisecond)
+
+	// After unmuting, Bob's comments are relayed again.
+	assert.NilErr(t, alice.MutePostCommenter(bob.PublicID(), false))
+	_, err = bob.CommentPost(alice.PublicID(), post.ID, "not spam", nil)
+	assert.NilErr(t, err)
+	status := assert.ChanWritten(t, charlieRecvStatus)
+	assert.DeepEqual(t, status.Attributes[rpc.RMPSComment], "not spam")
+}
+
+// TestPinnedPosts tests that new subscribers receive the pinned posts of the
+// author first.
+func TestPinnedPosts(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan clientdb.PostSummary, 10)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- summary
+	}))
+	bobRecvList := make(chan rpc.RMListPostsReply, 1)
+	bob.handle(client.OnPostsListReceived(func(user *client.RemoteUser, postList rpc.RMListPostsReply) {
+		bobRecvList <- postList
+	}))
+
+	// Alice creates posts and pins some of them.
+	var pids []clientintf.PostID
+	for i := 0; i < client.MaxPinnedPosts+1; i++ {
+		post, err := alice.CreatePost(fmt.Sprintf("post %d", i), "")
+		assert.NilErr(t, err)
+		pids = append(pids, post.ID)
+	}
+	assert.NilErr(t, alice.PinPost(pids[2], true))
+	assert.NilErr(t, alice.PinPost(pids[0], true))
+	assert.NonNilErr(t, alice.PinPost(clientintf.PostID{0: 0xff}, true))
+	pinned, err := alice.ListPinnedPosts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(pinned), 2)
+	assert.DeepEqual(t, pinned[0].ID, pids[2])
+	assert.DeepEqual(t, pinned[1].ID, pids[0])
+
+	// Only a limited number of posts may be pinned.
+	for i := 1; i < client.MaxPinnedPosts; i++ {
+		assert.NilErr(t, alice.PinPost(pids[i], true))
+	}
+	assert.NonNilErr(t, alice.PinPost(pids[client.MaxPinnedPosts], true))
+	for i := 3; i < client.MaxPinnedPosts; i++ {
+		assert.NilErr(t, alice.PinPost(pids[i], false))
+	}
+	assert.NilErr(t, alice.PinPost(pids[1], false))
+
+	// Bob subscribes and receives the pinned posts.
+	ts.kxUsers(alice, bob)
+	assertSubscribeToPosts(t, alice, bob)
+	for _, pid := range []clientintf.PostID{pids[2], pids[0]} {
+		summ := assert.ChanWritten(t, bobRecvPosts)
+		assert.DeepEqual(t, summ.ID, pid)
+		assert.DeepEqual(t, summ.Pinned, true)
+	}
+	assert.ChanNotWritten(t, bobRecvPosts, 500*time.Millisecond)
+
+	// The list of posts surfaces pinned posts separately.
+	assert.NilErr(t, bob.ListUserPosts(alice.PublicID()))
+	list := assert.ChanWritten(t, bobRecvList)
+	assert.DeepEqual(t, len(list.Posts), len(pids))
+	assert.DeepEqual(t, len(list.Pinned), 2)
+
+	// Subscribing again does not duplicate the posts.
+	assert.NilErr(t, bob.UnsubscribeToPosts(alice.PublicID()))
+	time.Sleep(500 * time.Millisecond)
+	assertSubscribeToPosts(t, alice, bob)
+	assert.ChanNotWritten(t, bobRecvPosts, 500*time.Millisecond)
+}
+
+// TestPostMediaThumbnails tests that large images embedded in posts are
+// replaced with thumbnails, with the full image shared for download.
+func TestPostMediaThumbnails(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice", withPostMediaThumbnails(1000))
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan rpc.PostMetadata, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+	bobSubChanged := make(chan bool, 1)
+	bob.handle(client.OnRemoteSubscriptionChangedNtfn(func(user *client.RemoteUser, subscribed bool) {
+		bobSubChanged <- subscribed
+	}))
+
+	ts.kxUsers(alice, bob)
+	assert.NilErr(t, bob.SubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, bobSubChanged, true)
+
+	// Create an image that is too large to be sent inline, even after
+	// being recompressed.
+	rnd := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	rnd.Read(img.Pix)
+	var b bytes.Buffer
+	assert.NilErr(t, png.Encode(&b, img))
+	small := mdembeds.EmbeddedArgs{Typ: "text/plain", Data: []byte("small")}
+	large := mdembeds.EmbeddedArgs{Alt: "large%20image", Typ: "image/png",
+		Data: b.Bytes()}
+	post := "small " + small.String() + " and large " + large.String()
+
+	// Bob receives the post with the thumbnail of the image.
+	_, err := alice.CreatePost(post, "")
+	assert.NilErr(t, err)
+	pm := assert.ChanWritten(t, bobRecvPosts)
+	var embeds []mdembeds.EmbeddedArgs
+	mdembeds.ReplaceEmbeds(pm.Attributes[rpc.RMPMain], func(args mdembeds.EmbeddedArgs) string {
+		embeds = append(embeds, args)
+		return ""
+	})
+	assert.DeepEqual(t, len(embeds), 2)
+	assert.DeepEqual(t, embeds[0].Data, small.Data)
+	thumb := embeds[1]
+	assert.DeepEqual(t, thumb.Typ, imgproc.MimeType)
+	assert.DeepEqual(t, thumb.Alt, "large image")
+	if thumb.Download.IsEmpty() || len(thumb.Data) > 32*1024 {
+		t.Fatalf("unexpected thumbnail embed (%d bytes)", len(thumb.Data))
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb.Data))
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cfg.Width, 256)
+
+	// The full image is shared by Alice. It is not downloaded by Bob due
+	// to the small chunk size used in tests.
+	files, err := alice.ListLocalSharedFiles()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(files), 1)
+	assert.DeepEqual(t, files[0].SF.FID, thumb.Download)
+	assert.DeepEqual(t, files[0].Size, thumb.Size)
+	assert.DeepEqual(t, files[0].Cost, uint64(1000))
+	assert.DeepEqual(t, files[0].Global, true)
+}
+
+// TestPostFeeds tests rendering the posts of a client as feeds.
+func TestPostFeeds(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan rpc.PostMetadata, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+	bobSubChanged := make(chan bool, 1)
+	bob.handle(client.OnRemoteSubscriptionChangedNtfn(func(user *client.RemoteUser, subscribed bool) {
+		bobSubChanged <- subscribed
+	}))
+
+	ts.kxUsers(alice, bob)
+	assert.NilErr(t, bob.SubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, bobSubChanged, true)
+
+	// Alice and Bob create posts.
+	_, err := alice.CreatePost("alice post\nwith <markup>", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	_, err = bob.CreatePost("bob post", "")
+	assert.NilErr(t, err)
+
+	// Bob's own feed only has his post, while the feed of all posts also
+	// has Alice's post.
+	outDir := t.TempDir()
+	feeds := postfeeds.New(postfeeds.Config{Client: bob.Client, OutDir: outDir})
+	svr := httptest.NewServer(feeds)
+	defer svr.Close()
+	get := func(name string) string {
+		t.Helper()
+		res, err := http.Get(svr.URL + "/" + name)
+		assert.NilErr(t, err)
+		defer res.Body.Close()
+		assert.DeepEqual(t, res.StatusCode, http.StatusOK)
+		b, err := io.ReadAll(res.Body)
+		assert.NilErr(t, err)
+		return string(b)
+	}
+	own := get("own.atom")
+	if !strings.Contains(own, "bob post") || strings.Contains(own, "alice post") {
+		t.Fatalf("unexpected own feed: %s", own)
+	}
+	all := get("all.rss")
+	if !strings.Contains(all, "bob post") || !strings.Contains(all, "with &lt;markup&gt;") {
+		t.Fatalf("unexpected feed of all posts: %s", all)
+	}
+	res, err := http.Get(svr.URL + "/other.atom")
+	assert.NilErr(t, err)
+	res.Body.Close()
+	assert.DeepEqual(t, res.StatusCode, http.StatusNotFound)
+
+	// The feeds are written to files.
+	assert.NilErr(t, feeds.WriteFiles())
+	data, err := os.ReadFile(filepath.Join(outDir, "all.atom"))
+	assert.NilErr(t, err)
+	if !strings.Contains(string(data), "alice post") {
+		t.Fatalf("unexpected feed file: %s", data)
+	}
+}
+
+// TestPostTagFilters asserts that posts from an author are filtered according
+// to the tag filter set for the author.
+func TestPostTagFilters(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan clientdb.PostSummary, 5)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- summary
+	}))
+	bobSubChanged := make(chan bool, 1)
+	bob.handle(client.OnRemoteSubscriptionChangedNtfn(func(user *client.RemoteUser, subscribed bool) {
+		bobSubChanged <- subscribed
+	}))
+
+	ts.kxUsers(alice, bob)
+	assert.NilErr(t, bob.SubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, bobSubChanged, true)
+
+	// Invalid tags are rejected.
+	_, err := alice.CreateTaggedPost("bad tags", "", []string{"two words"})
+	assert.NonNilErr(t, err)
+
+	// Bob only wants Alice's dev posts, except the ones about sports.
+	assert.NilErr(t, bob.SetPostTagFilter(alice.PublicID(),
+		[]string{"#Dev"}, []string{"sports"}))
+	filter, err := bob.GetPostTagFilter(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, filter.Include, []string{"dev"})
+
+	// Only the dev post is received.
+	_, err = alice.CreatePost("untagged post", "")
+	assert.NilErr(t, err)
+	_, err = alice.CreateTaggedPost("sports post", "", []string{"dev", "sports"})
+	assert.NilErr(t, err)
+	_, err = alice.CreateTaggedPost("misc post", "", []string{"misc"})
+	assert.NilErr(t, err)
+	devPost, err := alice.CreateTaggedPost("dev post", "", []string{"dev", "go"})
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, devPost.Tags, []string{"dev", "go"})
+	gotPost := assert.ChanWritten(t, bobRecvPosts)
+	assert.DeepEqual(t, gotPost.ID, devPost.ID)
+	assert.DeepEqual(t, gotPost.Tags, devPost.Tags)
+	assert.ChanNotWritten(t, bobRecvPosts, 500*time.Millisecond)
+
+	bobPosts, err := bob.ListPosts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(bobPosts), 1)
+
+	// After removing the filter, untagged posts are received again.
+	assert.NilErr(t, bob.SetPostTagFilter(alice.PublicID(), nil, nil))
+	filters, err := bob.ListPostTagFilters()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(filters), 0)
+	untagged, err := alice.CreatePost("another untagged post", "")
+	assert.NilErr(t, err)
+	gotPost = assert.ChanWritten(t, bobRecvPosts)
+	assert.DeepEqual(t, gotPost.ID, untagged.ID)
+}
+
+// TestQuoteRelayPost tests relaying posts with commentary.
+func TestQuoteRelayPost(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	bobRecvPosts := make(chan clientdb.PostSummary, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- summary
+	}))
+	charlieRecvPosts := make(chan clientdb.PostSummary, 2)
+	charlie.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		charlieRecvPosts <- summary
+	}))
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(bob, charlie)
+	ts.kxUsers(alice, charlie)
+	assertSubscribeToPosts(t, alice, bob)
+	assertSubscribeToPosts(t, alice, charlie)
+	assertSubscribeToPosts(t, bob, charlie)
+
+	// Alice creates a post. Bob and Charlie receive it.
+	alicePost, err := alice.CreatePost("alice post", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	assert.ChanWritten(t, charlieRecvPosts)
+
+	// Relaying with an empty comment fails.
+	assert.NonNilErr(t, bob.QuoteRelayPost(alice.PublicID(), alicePost.ID, " "))
+
+	// Bob relays Alice's post with a comment. Charlie receives it, even
+	// though Charlie already has the post from Alice.
+	comment := "bob thinks this is great"
+	assert.NilErr(t, bob.QuoteRelayPost(alice.PublicID(), alicePost.ID, comment))
+	summ := assert.ChanWritten(t, charlieRecvPosts)
+	assert.DeepEqual(t, summ.ID, alicePost.ID)
+	assert.DeepEqual(t, summ.From, bob.PublicID())
+	assert.DeepEqual(t, summ.AuthorID, alice.PublicID())
+	assert.DeepEqual(t, summ.RelayComment, comment)
+
+	// Both copies of the post are stored.
+	posts, err := charlie.ListPosts()
+	assert.NilErr(t, err)
+	var copies int
+	for _, post := range posts {
+		if post.ID == alicePost.ID {
+			copies++
+		}
+	}
+	assert.DeepEqual(t, copies, 2)
+	pm, err := charlie.ReadPost(bob.PublicID(), alicePost.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, pm.Attributes[rpc.RMPRelayComment], comment)
+	assert.DeepEqual(t, pm.Hash(), alicePost.ID)
+}
+
+// TestPaywalledPost tests unlocking the paywalled body of posts, both manually
+// and automatically.
+func TestPaywalledPost(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie", withPostAutoUnlock(1000))
+
+	// Alice's invoices are paid when Bob or Charlie pay them.
+	var mtx sync.Mutex
+	invoiceCbs := make(map[string]func()) // Protected by mtx
+	var nbInvoices int                    // Protected by mtx
+	alice.mpc.HookGetInvoice(func(amt int64, cb func(int64)) (string, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		nbInvoices += 1
+		inv := fmt.Sprintf("invoice %d for %d", nbInvoices, amt)
+		invoiceCbs[inv] = func() { go cb(amt) }
+		return inv, nil
+	})
+	payInvoice := func(invoice string) (int64, error) {
+		mtx.Lock()
+		cb := invoiceCbs[invoice]
+		mtx.Unlock()
+		if cb == nil {
+			return 0, fmt.Errorf("unknown invoice %q", invoice)
+		}
+		cb()
+		return 0, nil
+	}
+	bob.mpc.HookPayInvoice(payInvoice)
+	charlie.mpc.HookPayInvoice(payInvoice)
+
+	bobRecvPosts := make(chan rpc.PostMetadata, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+	bobUnlocked := make(chan client.PostUnlock, 1)
+	bob.handle(client.OnPostUnlocked(func(author *client.RemoteUser, unlock clientdb.PostUnlock) {
+		bobUnlocked <- unlock
+	}))
+	charlieUnlocked := make(chan client.PostUnlock, 1)
+	charlie.handle(client.OnPostUnlocked(func(author *client.RemoteUser, unlock clientdb.PostUnlock) {
+		charlieUnlocked <- unlock
+	}))
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	assertSubscribeToPosts(t, alice, bob)
+	assertSubscribeToPosts(t, alice, charlie)
+
+	// Wait until Alice restarted uploads after connecting (which assumes
+	// invoices are paid when using the mock payment client).
+	time.Sleep(1500 * time.Millisecond)
+
+	// Alice creates a paywalled post.
+	_, err := alice.CreatePaywalledPost("summary", "body", "", 0, nil)
+	assert.NonNilErr(t, err)
+	summary, body := "free summary", "the paywalled body"
+	post, err := alice.CreatePaywalledPost(summary, body, "", 1000, nil)
+	assert.NilErr(t, err)
+	aliceUnlock, err := alice.GetPostUnlock(post.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, aliceUnlock.Body, body)
+
+	// Bob receives the post with the summary and the price of the body.
+	pm := assert.ChanWritten(t, bobRecvPosts)
+	if !strings.HasPrefix(pm.Attributes[rpc.RMPMain], summary) ||
+		strings.Contains(pm.Attributes[rpc.RMPMain], body) {
+		t.Fatalf("unexpected post content %q", pm.Attributes[rpc.RMPMain])
+	}
+	link := client.PostPaywall(&pm)
+	if link == nil {
+		t.Fatalf("post is not paywalled")
+	}
+	assert.DeepEqual(t, link.Cost, uint64(1000))
+
+	// Charlie automatically unlocks the post.
+	unlock := assert.ChanWritten(t, charlieUnlocked)
+	assert.DeepEqual(t, unlock.Post, post.ID)
+	assert.DeepEqual(t, unlock.Body, body)
+
+	// Bob unlocks the post.
+	_, err = bob.GetPostUnlock(post.ID)
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+	assert.NilErr(t, bob.UnlockPost(alice.PublicID(), post.ID))
+	unlock = assert.ChanWritten(t, bobUnlocked)
+	assert.DeepEqual(t, unlock.Body, body)
+	unlock, err = bob.GetPostUnlock(post.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, unlock.Body, body)
+	if unlock.Completed == nil {
+		t.Fatalf("unlock not completed")
+	}
+
+	// Alice tracked both sales.
+	stats, err := alice.GetPostStats(post.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(stats.Unlockers), 2)
+	assert.DeepEqual(t, stats.UnlockAtoms, uint64(2000))
+}
+
+// TestPostStats tests the metrics tracked by the author of posts.
+func TestPostStats(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	aliceRecvStatus := make(chan struct{}, 10)
+	alice.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+		statusFrom client.UserID, status rpc.PostMetadataStatus) {
+		aliceRecvStatus <- struct{}{}
+	}))
+	aliceRecvPosts := make(chan clientdb.PostSummary, 1)
+	alice.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		aliceRecvPosts <- summary
+	}))
+	bobRecvPosts := make(chan rpc.PostMetadata, 1)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+
+	start := time.Now()
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+	assertSubscribeToPosts(t, alice, bob)
+	assertSubscribeToPosts(t, alice, charlie)
+	assertSubscribeToPosts(t, bob, alice)
+
+	// Charlie unsubscribes.
+	aliceSubUpdated := make(chan bool, 1)
+	alice.handle(client.OnPostSubscriberUpdated(func(ru *client.RemoteUser, subscribed bool) {
+		aliceSubUpdated <- subscribed
+	}))
+	assert.NilErr(t, charlie.UnsubscribeToPosts(alice.PublicID()))
+	assert.ChanWrittenWithVal(t, aliceSubUpdated, false)
+
+	churn, err := alice.PostSubscriberChurn(start)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, churn.Subscribed, 2)
+	assert.DeepEqual(t, churn.Unsubscribed, 1)
+	assert.DeepEqual(t, churn.Subscribers, 1)
+	churn, err = alice.PostSubscriberChurn(time.Now())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(churn.Events), 0)
+
+	// Bob comments, hearts and reacts to Alice's post. Alice's own
+	// comments are not tracked.
+	post, err := alice.CreatePost("post", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	_, err = bob.CommentPost(alice.PublicID(), post.ID, "comment", nil)
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, aliceRecvStatus)
+	assert.NilErr(t, bob.HeartPost(alice.PublicID(), post.ID, true))
+	assert.ChanWritten(t, aliceRecvStatus)
+	assert.NilErr(t, bob.ReactToPost(alice.PublicID(), post.ID, "👍", false))
+	assert.ChanWritten(t, aliceRecvStatus)
+	_, err = alice.CommentPost(alice.PublicID(), post.ID, "reply", nil)
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, aliceRecvStatus)
+
+	// Bob relays Alice's post back to her.
+	assert.NilErr(t, bob.RelayPost(alice.PublicID(), post.ID, alice.PublicID()))
+	assert.ChanNotWritten(t, aliceRecvPosts, 500*time.Millisecond)
+
+	stats, err := alice.GetPostStats(post.ID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, stats.Comments, uint64(1))
+	assert.DeepEqual(t, stats.Hearts, int64(1))
+	assert.DeepEqual(t, stats.Reactions, int64(1))
+	assert.DeepEqual(t, stats.Relayers, []clientintf.UserID{bob.PublicID()})
+
+	allStats, err := alice.ListPostStats()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(allStats), 1)
+	assert.DeepEqual(t, allStats[0].Post, post.ID)
+}
+
+// TestPostImport tests importing posts from external blogs.
+func TestPostImport(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan rpc.PostMetadata, 10)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- pm
+	}))
+
+	ts.kxUsers(alice, bob)
+	assertSubscribeToPosts(t, alice, bob)
+
+	// Alice has a markdown dir with an image and a feed served over
+	// http.
+	var b bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	assert.NilErr(t, png.Encode(&b, img))
+	dir := t.TempDir()
+	assert.NilErr(t, os.WriteFile(filepath.Join(dir, "img.png"), b.Bytes(), 0o600))
+	assert.NilErr(t, os.WriteFile(filepath.Join(dir, "post.md"),
+		[]byte("---\ntitle: Markdown post\ndate: 2024-03-01\ntags: [Blog Post]\n---\n"+
+			"Some text\n\n![a pic](img.png)\n"), 0o600))
+
+	const feed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><link>https://example.com/</link>
+<item><guid>1</guid><title>Feed post</title><link>https://example.com/1</link>
+<pubDate>Fri, 01 Mar 2024 10:00:00 +0000</pubDate>
+<description>&lt;p&gt;Feed &lt;b&gt;content&lt;/b&gt;&lt;/p&gt;</description></item>
+</channel></rss>`
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, feed)
+	}))
+	defer svr.Close()
+
+	imp := postimport.New(postimport.Config{
+		Client:   alice.Client,
+		Interval: 10 * time.Millisecond,
+	})
+
+	// Import the markdown dir. Bob receives the post with the embedded
+	// image.
+	res, err := imp.Import(context.Background(), dir)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Imported), 1)
+	pm := assert.ChanWritten(t, bobRecvPosts)
+	assert.DeepEqual(t, pm.Attributes[rpc.RMPTitle], "Markdown post")
+	assert.DeepEqual(t, pm.Attributes[rpc.RMPTags], "blog-post")
+	wantEmbed := mdembeds.EmbeddedArgs{Alt: "a%20pic", Typ: "image/png", Data: b.Bytes()}
+	if !strings.Contains(pm.Attributes[rpc.RMPMain], wantEmbed.String()) {
+		t.Fatalf("post does not have embedded image: %q", pm.Attributes[rpc.RMPMain])
+	}
+
+	// Import the feed.
+	res, err = imp.Import(context.Background(), svr.URL)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Imported), 1)
+	pm = assert.ChanWritten(t, bobRecvPosts)
+	assert.DeepEqual(t, pm.Attributes[rpc.RMPTitle], "Feed post")
+	wantMain := "Feed **content**\n\n[Originally published](https://example.com/1) on 2024-03-01"
+	assert.DeepEqual(t, pm.Attributes[rpc.RMPMain], wantMain)
+
+	// Importing again does not create new posts.
+	res, err = imp.Import(context.Background(), dir)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Imported), 0)
+	assert.DeepEqual(t, res.Skipped, 1)
+	res, err = imp.Import(context.Background(), svr.URL)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(res.Imported), 0)
+	assert.ChanNotWritten(t, bobRecvPosts, 250*time.Millisecond)
+
+	posts, err := alice.ListPosts()
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, len(posts), 2)
+}
+
+// TestPostSiteExport tests exporting posts as a static site.
+func TestPostSiteExport(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan clientdb.PostSummary, 2)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- summary
+	}))
+	aliceRecvComments := make(chan string, 1)
+	alice.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+		statusFrom client.UserID, status rpc.PostMetadataStatus) {
+		aliceRecvComments <- status.Attributes[rpc.RMPSComment]
+	}))
+
+	ts.kxUsers(alice, bob)
+	assertSubscribeToPosts(t, alice, bob)
+
+	// Alice creates a post with an image and a paywalled post. Bob
+	// comments on the first post.
+	img := mdembeds.EmbeddedArgs{Alt: "pic", Typ: "image/png", Data: []byte("png data")}
+	post, err := alice.CreatePost("first <post>\n"+img.String(), "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	_, err = alice.CreatePaywalledPost("free summary", "the paywalled body", "", 1000, nil)
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	_, err = bob.CommentPost(alice.PublicID(), post.ID, "bob comment", nil)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, assert.ChanWritten(t, aliceRecvComments), "bob comment")
+
+	read := func(dir, name string) string {
+		t.Helper()
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		assert.NilErr(t, err)
+		return string(b)
+	}
+
+	// Export without comments or paywalled content.
+	dir := t.TempDir()
+	res, err := postsite.Export(postsite.Config{Client: alice.Client}, dir)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res, postsite.Result{Posts: 2, Media: 1})
+	index := read(dir, "index.html")
+	if !strings.Contains(index, "first &lt;post&gt;") || !strings.Contains(index, "free summary") {
+		t.Fatalf("unexpected index: %s", index)
+	}
+	page := read(dir, "posts/"+post.ID.String()+".html")
+	if !strings.Contains(page, `<img src="../media/`) || strings.Contains(page, "bob comment") {
+		t.Fatalf("unexpected post page: %s", page)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "posts"))
+	assert.NilErr(t, err)
+	var all string
+	for _, e := range entries {
+		all += read(dir, "posts/"+e.Name())
+	}
+	if strings.Contains(all, "the paywalled body") {
+		t.Fatalf("paywalled body was exported")
+	}
+
+	// Export with comments and paywalled content.
+	dir = t.TempDir()
+	cfg := postsite.Config{Client: alice.Client, Comments: true, Paywalled: true}
+	_, err = postsite.Export(cfg, dir)
+	assert.NilErr(t, err)
+	page = read(dir, "posts/"+post.ID.String()+".html")
+	if !strings.Contains(page, "bob comment") {
+		t.Fatalf("comment was not exported: %s", page)
+	}
+	all = ""
+	for _, e := range entries {
+		all += read(dir, "posts/"+e.Name())
+	}
+	if !strings.Contains(all, "the paywalled body") {
+		t.Fatalf("paywalled body was not exported")
+	}
+
+	// Bob exports the posts by Alice.
+	dir = t.TempDir()
+	aliceID := alice.PublicID()
+	res, err = postsite.Export(postsite.Config{Client: bob.Client, Author: &aliceID}, dir)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, res.Posts, 2)
+}
+
+// TestPostBookmarks tests bookmarking received posts and comments.
+func TestPostBookmarks(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+
+	bobRecvPosts := make(chan clientdb.PostSummary, 2)
+	bob.handle(client.OnPostRcvdNtfn(func(ru *client.RemoteUser, summary clientdb.PostSummary, pm rpc.PostMetadata) {
+		bobRecvPosts <- summary
+	}))
+	bobRecvComments := make(chan clientintf.ID, 1)
+	bob.handle(client.OnPostStatusRcvdNtfn(func(user *client.RemoteUser, pid clientintf.PostID,
+		statusFrom client.UserID, status rpc.PostMetadataStatus) {
+		bobRecvComments <- status.Hash()
+	}))
+
+	ts.kxUsers(alice, bob)
+	assertSubscribeToPosts(t, alice, bob)
+
+	// Alice creates two posts and comments on the first one.
+	post1, err := alice.CreatePost("Go tips\nuse contexts", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	post2, err := alice.CreatePost("Rust tips", "")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobRecvPosts)
+	_, err = alice.CommentPost(alice.PublicID(), post1.ID, "an important comment", nil)
+	assert.NilErr(t, err)
+	commentID := assert.ChanWritten(t, bobRecvComments)
+
+	// Bob bookmarks the posts and the comment.
+	aliceID := alice.PublicID()
+	bm, err := bob.BookmarkPost(aliceID, post1.ID, nil, []string{"#Go", "later"}, "for the weekend")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, bm.Title, "Go tips")
+	assert.DeepEqual(t, bm.Tags, []string{"go", "later"})
+	_, err = bob.BookmarkPost(aliceID, post2.ID, nil, []string{"later"}, "")
+	assert.NilErr(t, err)
+	bm, err = bob.BookmarkPost(aliceID, post1.ID, &commentID, []string{"go"}, "")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, bm.Excerpt, "an important comment")
+	assert.DeepEqual(t, bm.AuthorNick, "alice")
+
+	// Bookmarking an unknown comment or post fails.
+	_, err = bob.BookmarkPost(aliceID, post1.ID, &clientintf.ID{0: 1}, nil, "")
+	assert.ErrorIs(t, err, clientdb.ErrNotFound)
+	_, err = bob.BookmarkPost(aliceID, clientintf.PostID{0: 1}, nil, nil, "")
+	assert.NonNilErr(t, err)
+
+	query := func(q client.PostBookmarkQuery) []client.PostBookmark {
+		t.Helper()
+		res, err := bob.QueryPostBookmarks(q)
+		assert.NilErr(t, err)
+		return res
+	}
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{})), 3)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Tags: []string{"go"}})), 2)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Tags: []string{"go", "later"}})), 1)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Text: "rust"})), 1)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Text: "important"})), 1)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Post: &post1.ID})), 2)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Limit: 1})), 1)
+
+	// Bookmarking the post again replaces its tags and note, but keeps
+	// its creation time.
+	old := query(client.PostBookmarkQuery{Text: "weekend"})[0]
+	bm, err = bob.BookmarkPost(aliceID, post1.ID, nil, []string{"done"}, "")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, bm.Created.Equal(old.Created), true)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Text: "weekend"})), 0)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Tags: []string{"done"}})), 1)
+
+	// Remove the bookmark of the comment.
+	assert.NilErr(t, bob.RemovePostBookmark(aliceID, post1.ID, &commentID))
+	assert.ErrorIs(t, bob.RemovePostBookmark(aliceID, post1.ID, &commentID), clientdb.ErrNotFound)
+	assert.DeepEqual(t, len(query(client.PostBookmarkQuery{Post: &post1.ID})), 1)
+}
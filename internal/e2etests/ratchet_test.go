@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -529,6 +530,71 @@ func TestUnsubsIdleClients(t *testing.T) {
 	assert.ChanWritten(t, bobKicked)
 }
 
+// TestUnsubsIdleUsersRVs asserts that clients stop listening for messages from
+// idle users and listen again once a message is sent to them.
+func TestUnsubsIdleUsersRVs(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	idleInterval := 2 * time.Second
+	alice := ts.newClient("alice", withAutoUnsubIdleUsersRVs(idleInterval))
+	bob := ts.newClient("bob")
+
+	ts.kxUsers(alice, bob)
+
+	alicePMs := make(chan string, 3)
+	alice.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+		alicePMs <- pm.Message
+	}))
+
+	assertRVsUnsubscribed := func(c *testClient, want bool) {
+		t.Helper()
+		var got bool
+		for i := 0; i < 100; i++ {
+			ru, err := c.UserByID(bob.PublicID())
+			assert.NilErr(t, err)
+			got = ru.RVsUnsubscribed()
+			if got == want {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("unexpected RVs unsubscribed: got %v, want %v", got, want)
+	}
+
+	// Bob is not yet idle.
+	assertGoesOffline(t, alice)
+	assertGoesOnline(t, alice)
+	assertRVsUnsubscribed(alice, false)
+
+	// Wait until Bob becomes idle. Flicking Alice's connection makes her
+	// stop listening for Bob's messages.
+	time.Sleep(idleInterval)
+	assertGoesOffline(t, alice)
+	assertGoesOnline(t, alice)
+	assertRVsUnsubscribed(alice, true)
+
+	// Bob sends a message. Alice does not receive it.
+	assert.NilErr(t, bob.PM(alice.PublicID(), "msg while idle"))
+	assert.ChanNotWritten(t, alicePMs, time.Second)
+
+	// Restarting Alice keeps Bob as idle.
+	alice = ts.recreateClient(alice, withAutoUnsubIdleUsersRVs(idleInterval))
+	alice.handle(client.OnPMNtfn(func(ru *client.RemoteUser, pm rpc.RMPrivateMessage, ts time.Time) {
+		alicePMs <- pm.Message
+	}))
+	assertRVsUnsubscribed(alice, true)
+	assert.ChanNotWritten(t, alicePMs, time.Second)
+
+	// Alice sends a message to Bob. She listens for messages again and
+	// receives the message Bob sent while she was not listening.
+	assert.NilErr(t, alice.PM(bob.PublicID(), "hello"))
+	assertRVsUnsubscribed(alice, false)
+	assert.ChanWrittenWithVal(t, alicePMs, "msg while idle")
+	assertClientsCanPM(t, alice, bob)
+}
+
 // TestUserNickAlias performs tests around duplicate and aliased users.
 func TestUserNickAlias(t *testing.T) {
 	t.Parallel()
@@ -655,6 +721,15 @@ func TestUpdateProfileAvatar(t *testing.T) {
 	assert.NilErr(t, alice.UpdateLocalAvatar(avatar1))
 	assert.ChanWrittenWithVal(t, avatarUpdateChan, avatar1)
 	assertUserAvatar(t, bob, alice, avatar1)
+	gotAvatar, err := bob.UserAvatar(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gotAvatar, avatar1)
+
+	// Avatars larger than the max size are rejected.
+	bigAvatar := bytes.Repeat([]byte{0xa2}, rpc.MaxAvatarSize+1)
+	if err := alice.UpdateLocalAvatar(bigAvatar); err == nil {
+		t.Fatalf("expected error when setting oversized avatar")
+	}
 
 	// Ensure avatar was saved.
 	bob = ts.recreateClient(bob)
@@ -662,7 +737,7 @@ func TestUpdateProfileAvatar(t *testing.T) {
 
 	// Ensure Alice's avatar was saved.
 	alice = ts.recreateClient(alice)
-	gotAvatar := alice.Public().Avatar
+	gotAvatar = alice.Public().Avatar
 	assert.DeepEqual(t, gotAvatar, avatar1)
 
 	// Ensure avatar can be cleared.
@@ -675,3 +750,78 @@ func TestUpdateProfileAvatar(t *testing.T) {
 	bob = ts.recreateClient(bob)
 	assertUserAvatar(t, bob, alice, nil)
 }
+
+func TestUpdateProfileFields(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	fieldsChan := make(chan []client.ProfileUpdateField, 5)
+	bob.handle(client.OnProfileUpdated(func(ru *client.RemoteUser,
+		ab *clientdb.AddressBookEntry, fields []client.ProfileUpdateField) {
+		fieldsChan <- fields
+	}))
+
+	// Update only the name and description.
+	name, descr := "Alice Liddell", "Down the rabbit hole"
+	err := alice.UpdateLocalProfile(client.LocalProfileUpdate{
+		Name:        &name,
+		Description: &descr,
+	})
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, fieldsChan, []client.ProfileUpdateField{
+		client.ProfileUpdateName, client.ProfileUpdateDescription})
+
+	ab, err := bob.AddressBookEntry(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ab.ID.Name, name)
+	assert.DeepEqual(t, ab.ID.Description, descr)
+	assert.DeepEqual(t, ab.ID.Nick, "alice")
+
+	// Ensure the fields were saved on both clients.
+	alice = ts.recreateClient(alice)
+	assert.DeepEqual(t, alice.Public().Name, name)
+	assert.DeepEqual(t, alice.Public().Description, descr)
+	bob = ts.recreateClient(bob)
+	ab, err = bob.AddressBookEntry(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ab.ID.Description, descr)
+
+	// Clear the description.
+	bob.handle(client.OnProfileUpdated(func(ru *client.RemoteUser,
+		ab *clientdb.AddressBookEntry, fields []client.ProfileUpdateField) {
+		fieldsChan <- fields
+	}))
+	descr = ""
+	err = alice.UpdateLocalProfile(client.LocalProfileUpdate{Description: &descr})
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, fieldsChan, []client.ProfileUpdateField{
+		client.ProfileUpdateDescription})
+	ab, err = bob.AddressBookEntry(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ab.ID.Description, "")
+	assert.DeepEqual(t, ab.ID.Name, name)
+
+	// Set the status line.
+	status := "away until Monday"
+	err = alice.UpdateLocalProfile(client.LocalProfileUpdate{Status: &status})
+	assert.NilErr(t, err)
+	assert.ChanWrittenWithVal(t, fieldsChan, []client.ProfileUpdateField{
+		client.ProfileUpdateStatus})
+	ab, err = bob.AddressBookEntry(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ab.ID.Status, status)
+	alice = ts.recreateClient(alice)
+	assert.DeepEqual(t, alice.Public().Status, status)
+
+	// Status lines are limited in size.
+	status = strings.Repeat("a", rpc.MaxStatusSize+1)
+	err = alice.UpdateLocalProfile(client.LocalProfileUpdate{Status: &status})
+	if err == nil {
+		t.Fatalf("expected error when setting oversized status")
+	}
+}
@@ -119,10 +119,22 @@ func TestTransitiveReset(t *testing.T) {
 	// Verify the Alice-Bob ratchet works.
 	checkAliceBobRatchet()
 
+	aliceResetReqChan, bobResetReqChan := make(chan bool, 1), make(chan bool, 1)
+	alice.handle(client.OnRatchetResetRequestedNtfn(func(_ *client.RemoteUser, byRemote bool) {
+		aliceResetReqChan <- byRemote
+	}))
+	bob.handle(client.OnRatchetResetRequestedNtfn(func(_ *client.RemoteUser, byRemote bool) {
+		bobResetReqChan <- byRemote
+	}))
+
 	// Perform a transitive reset Alice -> Charlie -> Bob
 	err := alice.RequestTransitiveReset(charlie.PublicID(), bob.PublicID())
 	assert.NilErr(t, err)
 
+	// Alice and Bob should be notified of the reset request.
+	assert.ChanWrittenWithVal(t, aliceResetReqChan, false)
+	assert.ChanWrittenWithVal(t, bobResetReqChan, true)
+
 	// We should get new KX completed events.
 	assertKXCompleted(false)
 
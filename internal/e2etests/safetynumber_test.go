@@ -0,0 +1,86 @@
+package e2etests
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// TestSafetyNumberVerification tests verifying contacts through their safety
+// number and the warning issued when the keys of a verified contact change.
+func TestSafetyNumberVerification(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bobCfg := ts.defaultNewClientCfg("bob")
+	bob := ts.newClientWithCfg(bobCfg)
+	ts.kxUsers(alice, bob)
+
+	// Both users compute the same safety number.
+	aliceSN, err := alice.SafetyNumber(bob.PublicID())
+	assert.NilErr(t, err)
+	bobSN, err := bob.SafetyNumber(alice.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, aliceSN, bobSN)
+
+	// Verifying with the wrong safety number fails.
+	ok, err := alice.VerifyContact(bob.PublicID(), "00000 00000")
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ok, false)
+	cv, err := alice.ContactVerification(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cv.IsVerified(), false)
+
+	// Verifying with the correct safety number works.
+	ok, err = alice.VerifyContact(bob.PublicID(), bobSN.Digits)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, ok, true)
+	cv, err = alice.ContactVerification(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cv.IsVerified(), true)
+
+	keyChangedChan := make(chan struct{}, 5)
+	alice.handle(client.OnVerifiedKeyChangedNtfn(func(ru *client.RemoteUser) {
+		keyChangedChan <- struct{}{}
+	}))
+
+	// Re-doing the KX with the same keys does not trigger the warning.
+	ts.kxUsers(alice, bob)
+	assert.ChanNotWritten(t, keyChangedChan, time.Second)
+
+	// Bob's signature key changes (simulating a MITM) and the KX is
+	// re-done. Alice is warned and Bob is no longer verified.
+	ts.stopClient(bob)
+	newID := *bobCfg.id
+	sigPub, sigPriv, err := ed25519.GenerateKey(nil)
+	assert.NilErr(t, err)
+	copy(newID.Public.SigKey[:], sigPub)
+	copy(newID.PrivateSigKey[:], sigPriv)
+	assert.NilErr(t, newID.RecalculateDigest())
+	bobCfg = ts.defaultNewClientCfg("bob")
+	bobCfg.id = &newID
+	bobCfg.idIniter = func(context.Context) (*zkidentity.FullIdentity, error) {
+		id := newID
+		return &id, nil
+	}
+	bob = ts.newClientWithCfg(bobCfg)
+	ts.kxUsers(alice, bob)
+	assert.ChanWritten(t, keyChangedChan)
+	cv, err = alice.ContactVerification(bob.PublicID())
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, cv.IsVerified(), false)
+
+	// The safety number changed.
+	newSN, err := alice.SafetyNumber(bob.PublicID())
+	assert.NilErr(t, err)
+	if newSN.Digits == aliceSN.Digits {
+		t.Fatalf("safety number did not change after key change")
+	}
+}
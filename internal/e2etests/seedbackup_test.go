@@ -0,0 +1,75 @@
+package e2etests
+
+import (
+	"testing"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/client/clientintf"
+	"github.com/companyzero/bisonrelay/internal/assert"
+)
+
+// TestSeedBackupRestore tests restoring a client from a seed backup after its
+// database is lost.
+func TestSeedBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	charlie := ts.newClient("charlie")
+
+	ts.kxUsers(alice, bob)
+	ts.kxUsers(alice, charlie)
+
+	gcID, err := alice.NewGroupChatVersion("gc01", 2)
+	assert.NilErr(t, err)
+	assertClientJoinsGC(t, gcID, alice, bob)
+	assertClientJoinsGC(t, gcID, alice, charlie)
+
+	words, bundle, err := alice.CreateSeedBackup()
+	assert.NilErr(t, err)
+
+	// The bundle cannot be decoded without the correct seed.
+	otherWords, _, err := bob.CreateSeedBackup()
+	assert.NilErr(t, err)
+	_, err = client.DecodeSeedBackup(otherWords, bundle)
+	assert.NonNilErr(t, err)
+
+	backup, err := client.DecodeSeedBackup(words, bundle)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, backup.Identity.Public.Identity, alice.PublicID())
+	assert.DeepEqual(t, len(backup.Contacts), 2)
+	assert.DeepEqual(t, len(backup.GCs), 1)
+
+	// Bob cannot restore Alice's backup.
+	assert.NonNilErr(t, bob.RestoreSeedBackup(backup))
+
+	// Alice loses her DB and restores her identity on a new one.
+	ts.stopClient(alice)
+	newCfg := ts.defaultNewClientCfg("alice")
+	newCfg.id = &backup.Identity
+	newCfg.idIniter = backup.LocalIDIniter()
+	alice = ts.newClientWithCfg(newCfg)
+	assert.DeepEqual(t, alice.PublicID(), backup.Identity.Public.Identity)
+
+	kxdChan := make(chan clientintf.UserID, 2)
+	alice.handle(client.OnKXCompleted(func(_ *clientintf.RawRVID, ru *client.RemoteUser, _ bool) {
+		kxdChan <- ru.ID()
+	}))
+	assert.NilErr(t, alice.RestoreSeedBackup(backup))
+
+	// Both contacts reset the ratchets with Alice.
+	for i := 0; i < 2; i++ {
+		uid := assert.ChanWritten(t, kxdChan)
+		if uid != bob.PublicID() && uid != charlie.PublicID() {
+			t.Fatalf("unexpected kx with %s", uid)
+		}
+	}
+	assertClientsCanPM(t, alice, bob)
+	assertClientsCanPM(t, alice, charlie)
+
+	// The GC was restored.
+	assertClientInGC(t, alice, gcID)
+	assertClientsCanGCM(t, gcID, alice, bob)
+}
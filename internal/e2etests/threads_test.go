@@ -0,0 +1,78 @@
+package e2etests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/rpc"
+)
+
+// TestThreadedReplies tests that replies to PMs and GC messages are tracked
+// in threads.
+func TestThreadedReplies(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	alicePMChan := make(chan rpc.RMPrivateMessage, 1)
+	alice.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		alicePMChan <- pm
+	}))
+	bobPMChan := make(chan rpc.RMPrivateMessage, 1)
+	bob.handle(client.OnPMNtfn(func(_ *client.RemoteUser, pm rpc.RMPrivateMessage, _ time.Time) {
+		bobPMChan <- pm
+	}))
+	aliceGCMChan := make(chan rpc.RMGroupMessage, 1)
+	alice.handle(client.OnGCMNtfn(func(_ *client.RemoteUser, gcm rpc.RMGroupMessage, _ time.Time) {
+		aliceGCMChan <- gcm
+	}))
+
+	// Alice sends a PM, Bob replies to it and Alice replies to the reply.
+	rootID, err := alice.PMWithMsgID(bob.PublicID(), "question")
+	assert.NilErr(t, err)
+	assert.ChanWritten(t, bobPMChan)
+	replyID, err := bob.ReplyToMessage(alice.PublicID(), rootID, "answer")
+	assert.NilErr(t, err)
+	pm := assert.ChanWritten(t, alicePMChan)
+	assert.DeepEqual(t, pm.ReplyTo, &rootID)
+	reply2ID, err := alice.ReplyToMessage(bob.PublicID(), replyID, "thanks")
+	assert.NilErr(t, err)
+	pm = assert.ChanWritten(t, bobPMChan)
+	assert.DeepEqual(t, pm.ReplyTo, &replyID)
+
+	// Both sides track the replies in the thread of the root message.
+	for _, c := range []*testClient{alice, bob} {
+		thread, err := c.Thread(reply2ID)
+		assert.NilErr(t, err)
+		assert.DeepEqual(t, thread.RootMsgID, rootID)
+		assert.DeepEqual(t, len(thread.Replies), 2)
+		assert.DeepEqual(t, thread.Replies[0].MsgID, replyID)
+		assert.DeepEqual(t, thread.Replies[1].MsgID, reply2ID)
+		assert.DeepEqual(t, thread.Replies[1].ReplyTo, replyID)
+	}
+
+	// Bob replies to a GC message.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	gcRootID, err := alice.GCMessageWithMsgID(gcID, "gc question", rpc.MessageModeNormal, nil)
+	assert.NilErr(t, err)
+	gcReplyID, err := bob.ReplyToMessage(gcID, gcRootID, "gc answer")
+	assert.NilErr(t, err)
+	gcm := assert.ChanWritten(t, aliceGCMChan)
+	assert.DeepEqual(t, gcm.ReplyTo, &gcRootID)
+
+	thread, err := alice.Thread(gcRootID)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, thread.GC, &gcID)
+	assert.DeepEqual(t, len(thread.Replies), 1)
+	assert.DeepEqual(t, thread.Replies[0].MsgID, gcReplyID)
+	assert.DeepEqual(t, thread.Replies[0].From, bob.PublicID())
+}
@@ -0,0 +1,96 @@
+package e2etests
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/companyzero/bisonrelay/client"
+	"github.com/companyzero/bisonrelay/internal/assert"
+	"github.com/companyzero/bisonrelay/zkidentity"
+)
+
+// testOggOpusAudio returns a minimal Ogg Opus stream with the given duration.
+// The stream only has the pages needed to validate the audio and determine
+// its duration.
+func testOggOpusAudio(duration time.Duration) []byte {
+	const preSkip = 312
+
+	// First page: Opus identification header.
+	head := make([]byte, 28+19)
+	copy(head, "OggS")
+	head[26] = 1  // Number of segments.
+	head[27] = 19 // Segment size.
+	copy(head[28:], "OpusHead")
+	head[36] = 1 // Version.
+	head[37] = 1 // Channels.
+	binary.LittleEndian.PutUint16(head[38:], preSkip)
+	binary.LittleEndian.PutUint32(head[40:], 48000)
+
+	// Last page: granule position marks the end of the stream.
+	last := make([]byte, 28+4)
+	copy(last, "OggS")
+	last[5] = 0x04 // End of stream.
+	granule := uint64(duration/time.Millisecond)*48 + preSkip
+	binary.LittleEndian.PutUint64(last[6:], granule)
+	last[26] = 1
+	last[27] = 4
+
+	return append(head, last...)
+}
+
+// TestVoiceMsgs tests sending voice messages in PMs and GCs.
+func TestVoiceMsgs(t *testing.T) {
+	t.Parallel()
+
+	tcfg := testScaffoldCfg{}
+	ts := newTestScaffold(t, tcfg)
+	alice := ts.newClient("alice")
+	bob := ts.newClient("bob")
+	ts.kxUsers(alice, bob)
+
+	type voiceEvent struct {
+		uid  client.UserID
+		gcID *zkidentity.ShortID
+		vm   client.VoiceMsg
+	}
+	voiceChan := make(chan voiceEvent, 5)
+	alice.handle(client.OnVoiceMsgRcvdNtfn(func(ru *client.RemoteUser,
+		gcID, msgID *zkidentity.ShortID, vm client.VoiceMsg, ts time.Time) {
+		voiceChan <- voiceEvent{ru.ID(), gcID, vm}
+	}))
+
+	// The duration is determined from the audio.
+	audio := testOggOpusAudio(3 * time.Second)
+	gotDuration, err := client.OggOpusDuration(audio)
+	assert.NilErr(t, err)
+	assert.DeepEqual(t, gotDuration, 3*time.Second)
+
+	// Send a voice message in a PM.
+	_, err = bob.SendVoiceMsg(alice.PublicID(), audio, 0)
+	assert.NilErr(t, err)
+	ev := assert.ChanWritten(t, voiceChan)
+	assert.DeepEqual(t, ev.uid, bob.PublicID())
+	assert.DeepEqual(t, ev.gcID, (*zkidentity.ShortID)(nil))
+	assert.DeepEqual(t, ev.vm.Audio, audio)
+	assert.DeepEqual(t, ev.vm.Duration, 3*time.Second)
+
+	// Send a voice message in a GC.
+	gcID, err := alice.NewGroupChat("testGC")
+	assert.NilErr(t, err)
+	assertJoinsGC(t, alice, bob, gcID)
+	assertClientInGC(t, bob, gcID)
+	_, err = bob.SendVoiceMsg(gcID, audio, 2*time.Second)
+	assert.NilErr(t, err)
+	ev = assert.ChanWritten(t, voiceChan)
+	assert.DeepEqual(t, *ev.gcID, gcID)
+	assert.DeepEqual(t, ev.vm.Duration, 2*time.Second)
+
+	// Invalid audio and durations are rejected.
+	_, err = bob.SendVoiceMsg(alice.PublicID(), []byte("not audio"), time.Second)
+	assert.NonNilErr(t, err)
+	longAudio := testOggOpusAudio(client.MaxVoiceMsgDuration + time.Second)
+	_, err = bob.SendVoiceMsg(alice.PublicID(), longAudio, 0)
+	assert.NonNilErr(t, err)
+	assert.ChanNotWritten(t, voiceChan, 500*time.Millisecond)
+}
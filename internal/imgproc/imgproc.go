@@ -0,0 +1,149 @@
+// Package imgproc generates thumbnails and recompressed variants of images
+// embedded in posts and messages.
+package imgproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+
+	// Register the supported image formats.
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// MimeType is the mime type of images generated by this package.
+	MimeType = "image/jpeg"
+
+	// DefaultQuality is the default JPEG quality of generated images.
+	DefaultQuality = 75
+
+	// maxPixels is the max number of pixels of decoded images, to avoid
+	// excessive memory use when processing untrusted images.
+	maxPixels = 64 * 1024 * 1024
+)
+
+// IsSupportedType returns true if images of the given mime type can be
+// processed.
+func IsSupportedType(typ string) bool {
+	switch typ {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Decode decodes a JPEG, PNG or (the first frame of a) GIF image.
+func Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("invalid image dimensions %dx%d",
+			cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Resize scales the image down so that neither of its dimensions is larger
+// than maxDim, preserving its aspect ratio. Each pixel of the resized image is
+// the average of the pixels of the source image it covers. Images that already
+// fit are returned unmodified.
+func Resize(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxDim <= 0 || (w <= maxDim && h <= maxDim) {
+		return img
+	}
+
+	nw, nh := maxDim, maxDim
+	if w > h {
+		nh = h * maxDim / w
+	} else {
+		nw = w * maxDim / h
+	}
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		sy0, sy1 := b.Min.Y+y*h/nh, b.Min.Y+(y+1)*h/nh
+		for x := 0; x < nw; x++ {
+			sx0, sx1 := b.Min.X+x*w/nw, b.Min.X+(x+1)*w/nw
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// encodeJPEG encodes the image as a JPEG with the given quality. As JPEG does
+// not support transparency, transparent areas are drawn over a white
+// background.
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	if quality <= 0 || quality > 100 {
+		quality = DefaultQuality
+	}
+
+	opaque := image.NewRGBA(img.Bounds())
+	draw.Draw(opaque, opaque.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, opaque, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Thumbnail returns a JPEG thumbnail of the image, with dimensions no larger
+// than maxDim.
+func Thumbnail(data []byte, maxDim, quality int) ([]byte, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJPEG(Resize(img, maxDim), quality)
+}
+
+// Recompress returns a JPEG variant of the image, scaled down so that its
+// dimensions are no larger than maxDim (if maxDim is positive). If the
+// variant is not smaller than the original image, the original image is
+// returned along with its mime type.
+func Recompress(data []byte, typ string, maxDim, quality int) ([]byte, string, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := encodeJPEG(Resize(img, maxDim), quality)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(res) >= len(data) {
+		return data, typ, nil
+	}
+	return res, MimeType, nil
+}
@@ -0,0 +1,94 @@
+package imgproc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+// testPNG returns a PNG image of the given size with random pixels.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	rnd := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(rnd.Intn(256)),
+				G: uint8(x), B: uint8(y), A: 255})
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// TestResize tests that resizing preserves the aspect ratio of images.
+func TestResize(t *testing.T) {
+	tests := []struct {
+		w, h, maxDim int
+		wantW, wantH int
+	}{
+		{w: 100, h: 50, maxDim: 200, wantW: 100, wantH: 50},
+		{w: 400, h: 200, maxDim: 100, wantW: 100, wantH: 50},
+		{w: 200, h: 400, maxDim: 100, wantW: 50, wantH: 100},
+		{w: 1000, h: 1, maxDim: 10, wantW: 10, wantH: 1},
+		{w: 300, h: 300, maxDim: 0, wantW: 300, wantH: 300},
+	}
+	for _, tc := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tc.w, tc.h))
+		got := Resize(img, tc.maxDim).Bounds()
+		if got.Dx() != tc.wantW || got.Dy() != tc.wantH {
+			t.Fatalf("unexpected size for %dx%d (max %d): got %dx%d, "+
+				"want %dx%d", tc.w, tc.h, tc.maxDim, got.Dx(),
+				got.Dy(), tc.wantW, tc.wantH)
+		}
+	}
+}
+
+// TestThumbnailAndRecompress tests generating thumbnails and recompressed
+// variants of images.
+func TestThumbnailAndRecompress(t *testing.T) {
+	data := testPNG(t, 640, 480)
+
+	thumb, err := Thumbnail(data, 64, DefaultQuality)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || cfg.Width != 64 || cfg.Height != 48 {
+		t.Fatalf("unexpected thumbnail %s %dx%d", format, cfg.Width, cfg.Height)
+	}
+	if len(thumb) >= len(data) {
+		t.Fatalf("thumbnail not smaller than original")
+	}
+
+	full, typ, err := Recompress(data, "image/png", 0, DefaultQuality)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != MimeType || len(full) >= len(data) {
+		t.Fatalf("unexpected recompressed image %s (%d >= %d bytes)", typ,
+			len(full), len(data))
+	}
+
+	// Images that do not get smaller are returned unmodified.
+	got, typ, err := Recompress(thumb, MimeType, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != MimeType || !bytes.Equal(got, thumb) {
+		t.Fatalf("recompressed image unexpectedly modified")
+	}
+
+	if _, err := Thumbnail([]byte("not an image"), 64, 0); err == nil {
+		t.Fatalf("unexpected success decoding invalid image")
+	}
+}
@@ -0,0 +1,86 @@
+package invitetransport
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// taggedHash is the tagged hash function defined in BIP340.
+func taggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msgs {
+		h.Write(m)
+	}
+	var res [32]byte
+	copy(res[:], h.Sum(nil))
+	return res
+}
+
+// schnorrPubKey returns the x-only public key of the given private key, as
+// defined in BIP340.
+func schnorrPubKey(privKey *[32]byte) ([32]byte, error) {
+	var d secp256k1.ModNScalar
+	if overflow := d.SetByteSlice(privKey[:]); overflow || d.IsZero() {
+		return [32]byte{}, errors.New("invalid private key")
+	}
+	var p secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&d, &p)
+	p.ToAffine()
+	return *p.X.Bytes(), nil
+}
+
+// schnorrSign signs the 32 byte message with the private key, as defined in
+// BIP340. aux is the auxiliary random data.
+func schnorrSign(privKey *[32]byte, msg []byte, aux *[32]byte) ([64]byte, error) {
+	var sig [64]byte
+
+	var d secp256k1.ModNScalar
+	if overflow := d.SetByteSlice(privKey[:]); overflow || d.IsZero() {
+		return sig, errors.New("invalid private key")
+	}
+	var p secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&d, &p)
+	p.ToAffine()
+	if p.Y.IsOdd() {
+		d.Negate()
+	}
+	pkX := p.X.Bytes()
+
+	dBytes := d.Bytes()
+	auxHash := taggedHash("BIP0340/aux", aux[:])
+	var t [32]byte
+	for i := range t {
+		t[i] = dBytes[i] ^ auxHash[i]
+	}
+	nonce := taggedHash("BIP0340/nonce", t[:], pkX[:], msg)
+
+	var k secp256k1.ModNScalar
+	k.SetByteSlice(nonce[:])
+	if k.IsZero() {
+		return sig, errors.New("generated zero nonce")
+	}
+	var r secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&k, &r)
+	r.ToAffine()
+	if r.Y.IsOdd() {
+		k.Negate()
+	}
+	rX := r.X.Bytes()
+
+	challenge := taggedHash("BIP0340/challenge", rX[:], pkX[:], msg)
+	var e secp256k1.ModNScalar
+	e.SetByteSlice(challenge[:])
+
+	var s secp256k1.ModNScalar
+	s.Mul2(&e, &d).Add(&k)
+	sBytes := s.Bytes()
+
+	copy(sig[:32], rX[:])
+	copy(sig[32:], sBytes[:])
+	return sig, nil
+}
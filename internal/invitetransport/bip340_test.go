@@ -0,0 +1,61 @@
+package invitetransport
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestSchnorrSign tests signing against the test vectors from BIP340.
+func TestSchnorrSign(t *testing.T) {
+	tests := []struct {
+		privKey string
+		pubKey  string
+		aux     string
+		msg     string
+		sig     string
+	}{{
+		privKey: "0000000000000000000000000000000000000000000000000000000000000003",
+		pubKey:  "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		aux:     "0000000000000000000000000000000000000000000000000000000000000000",
+		msg:     "0000000000000000000000000000000000000000000000000000000000000000",
+		sig:     "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+	}, {
+		privKey: "B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+		pubKey:  "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		aux:     "0000000000000000000000000000000000000000000000000000000000000001",
+		msg:     "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		sig:     "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+	}}
+
+	decode32 := func(s string) *[32]byte {
+		var b [32]byte
+		if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		return &b
+	}
+
+	for i, tc := range tests {
+		privKey := decode32(tc.privKey)
+		pubKey, err := schnorrPubKey(privKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotPub := strings.ToUpper(hex.EncodeToString(pubKey[:]))
+		if gotPub != tc.pubKey {
+			t.Fatalf("%d: unexpected pubkey: got %s, want %s", i,
+				gotPub, tc.pubKey)
+		}
+
+		sig, err := schnorrSign(privKey, decode32(tc.msg)[:], decode32(tc.aux))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotSig := strings.ToUpper(hex.EncodeToString(sig[:]))
+		if gotSig != tc.sig {
+			t.Fatalf("%d: unexpected sig: got %s, want %s", i,
+				gotSig, tc.sig)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package invitetransport publishes invite blobs out of band (on HTTPS paste
+// endpoints or nostr relays) and fetches them back from the generated URLs.
+//
+// Blobs are encrypted with a random key before being published. The key is
+// appended to the URL returned by Publish as its fragment, so that the service
+// storing the blob cannot decrypt it (fragments are not sent to http servers).
+// Anyone with the full URL may fetch and decrypt the blob, therefore the URL
+// must be sent only to the intended recipient.
+package invitetransport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/companyzero/bisonrelay/sw"
+)
+
+// MaxBlobSize is the max size of a blob that may be published or fetched.
+const MaxBlobSize = 256 * 1024
+
+// ErrNoTransport is returned when no transport can fetch a given URL.
+var ErrNoTransport = errors.New("no transport can fetch the URL")
+
+// Transport is a method of storing blobs out of band.
+type Transport interface {
+	// Name is a short name of the transport.
+	Name() string
+
+	// Store stores the (already encrypted) data and returns a locator
+	// that may be used to fetch it back.
+	Store(ctx context.Context, data []byte) (string, error)
+
+	// Load loads data previously stored with the given locator.
+	Load(ctx context.Context, locator string) ([]byte, error)
+
+	// CanLoad returns true if the locator may be loaded by the transport.
+	CanLoad(locator string) bool
+}
+
+// Publish encrypts the blob and stores it using the given transport. The
+// returned URL includes the decryption key.
+func Publish(ctx context.Context, t Transport, blob []byte) (string, error) {
+	if len(blob) > MaxBlobSize {
+		return "", fmt.Errorf("blob size %d is larger than max %d",
+			len(blob), MaxBlobSize)
+	}
+
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", err
+	}
+	data, err := sw.Seal(blob, &key)
+	if err != nil {
+		return "", err
+	}
+	locator, err := t.Store(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(locator, "#") {
+		return "", fmt.Errorf("transport %s returned locator with "+
+			"fragment", t.Name())
+	}
+	return locator + "#" + base64.RawURLEncoding.EncodeToString(key[:]), nil
+}
+
+// Fetch fetches and decrypts a blob published with Publish, using the first
+// transport that can load the URL.
+func Fetch(ctx context.Context, transports []Transport, u string) ([]byte, error) {
+	u = strings.TrimSpace(u)
+	i := strings.LastIndex(u, "#")
+	if i < 0 {
+		return nil, errors.New("URL does not include the decryption key")
+	}
+	locator := u[:i]
+	keyBytes, err := base64.RawURLEncoding.DecodeString(u[i+1:])
+	if err != nil || len(keyBytes) != 32 {
+		return nil, errors.New("URL includes an invalid decryption key")
+	}
+	var key [32]byte
+	copy(key[:], keyBytes)
+
+	for _, t := range transports {
+		if !t.CanLoad(locator) {
+			continue
+		}
+		data, err := t.Load(ctx, locator)
+		if err != nil {
+			return nil, err
+		}
+		blob, ok := sw.Open(data, &key)
+		if !ok {
+			return nil, errors.New("unable to decrypt fetched blob")
+		}
+		return blob, nil
+	}
+	return nil, ErrNoTransport
+}
+
+// MailtoURL returns a mailto: URL to send the invite URL by email.
+func MailtoURL(to, inviteURL string) string {
+	body := "You have been invited to Bison Relay. Use the following " +
+		"link to accept the invite:\n\n" + inviteURL + "\n"
+	q := url.Values{}
+	q.Set("subject", "Bison Relay invite")
+	q.Set("body", body)
+	u := url.URL{
+		Scheme:   "mailto",
+		Opaque:   to,
+		RawQuery: strings.ReplaceAll(q.Encode(), "+", "%20"),
+	}
+	return u.String()
+}
@@ -0,0 +1,181 @@
+package invitetransport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newPasteServer returns a test paste endpoint.
+func newPasteServer(t *testing.T) *httptest.Server {
+	var mtx sync.Mutex
+	pastes := make(map[string][]byte)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch r.Method {
+		case http.MethodPost:
+			data, _ := io.ReadAll(r.Body)
+			path := fmt.Sprintf("/p/%d", len(pastes))
+			pastes[path] = data
+			fmt.Fprintln(w, srv.URL+path)
+		case http.MethodGet:
+			data, ok := pastes[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newNostrRelay returns a test nostr relay that stores events in memory.
+func newNostrRelay(t *testing.T) *httptest.Server {
+	var mtx sync.Mutex
+	events := make(map[string]json.RawMessage)
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg []json.RawMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			var typ string
+			json.Unmarshal(msg[0], &typ)
+			switch typ {
+			case "EVENT":
+				var ev nostrEvent
+				json.Unmarshal(msg[1], &ev)
+				hash, _ := ev.hash()
+				ok := fmt.Sprintf("%x", hash) == ev.ID
+				if ok {
+					mtx.Lock()
+					events[ev.ID] = msg[1]
+					mtx.Unlock()
+				}
+				conn.WriteJSON([]interface{}{"OK", ev.ID, ok, ""})
+			case "REQ":
+				var subID string
+				var filter struct {
+					IDs []string `json:"ids"`
+				}
+				json.Unmarshal(msg[1], &subID)
+				json.Unmarshal(msg[2], &filter)
+				mtx.Lock()
+				for _, id := range filter.IDs {
+					if ev, ok := events[id]; ok {
+						conn.WriteJSON([]interface{}{"EVENT", subID, ev})
+					}
+				}
+				mtx.Unlock()
+				conn.WriteJSON([]interface{}{"EOSE", subID})
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestPublishFetch tests publishing and fetching blobs with the transports.
+func TestPublishFetch(t *testing.T) {
+	pasteSrv := newPasteServer(t)
+	relay := newNostrRelay(t)
+	relayURL := "ws" + strings.TrimPrefix(relay.URL, "http")
+
+	paste := &PasteTransport{Endpoint: pasteSrv.URL}
+	nostr := &NostrTransport{Relays: []string{relayURL}}
+	transports := []Transport{paste, nostr}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	blob := []byte("invite blob <&> contents")
+	for _, tr := range transports {
+		u, err := Publish(ctx, tr, blob)
+		if err != nil {
+			t.Fatalf("%s: unable to publish: %v", tr.Name(), err)
+		}
+
+		got, err := Fetch(ctx, transports, u)
+		if err != nil {
+			t.Fatalf("%s: unable to fetch: %v", tr.Name(), err)
+		}
+		if !bytes.Equal(got, blob) {
+			t.Fatalf("%s: unexpected blob: got %q, want %q", tr.Name(),
+				got, blob)
+		}
+
+		// Fetching without the key or with the wrong key fails.
+		locator := u[:strings.LastIndex(u, "#")]
+		if _, err := Fetch(ctx, transports, locator); err == nil {
+			t.Fatalf("%s: fetched without key", tr.Name())
+		}
+		wrongKey := locator + "#" + strings.Repeat("A", 43)
+		if _, err := Fetch(ctx, transports, wrongKey); err == nil {
+			t.Fatalf("%s: fetched with wrong key", tr.Name())
+		}
+	}
+
+	// URLs not supported by any transport are rejected.
+	_, err := Fetch(ctx, transports, "ftp://example.com/x#"+strings.Repeat("A", 43))
+	if err != ErrNoTransport {
+		t.Fatalf("unexpected error: got %v, want %v", err, ErrNoTransport)
+	}
+}
+
+// TestNEventEncoding tests encoding and decoding nevent identifiers.
+func TestNEventEncoding(t *testing.T) {
+	var id [32]byte
+	for i := range id {
+		id[i] = byte(i)
+	}
+	relays := []string{"wss://relay.example.com", "wss://other.example.com"}
+	s, err := encodeNEvent(id, relays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "nevent1") {
+		t.Fatalf("unexpected nevent prefix: %s", s)
+	}
+	gotID, gotRelays, err := decodeNEvent(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotID != id {
+		t.Fatalf("unexpected id: got %x, want %x", gotID, id)
+	}
+	if strings.Join(gotRelays, " ") != strings.Join(relays, " ") {
+		t.Fatalf("unexpected relays: got %v, want %v", gotRelays, relays)
+	}
+}
+
+// TestMailtoURL tests generating mailto URLs.
+func TestMailtoURL(t *testing.T) {
+	got := MailtoURL("bob@example.com", "https://paste.example.com/p/1#key")
+	wantPrefix := "mailto:bob@example.com?body=You%20have%20been%20invited"
+	if !strings.HasPrefix(got, wantPrefix) {
+		t.Fatalf("unexpected mailto URL: %s", got)
+	}
+	if !strings.Contains(got, "https%3A%2F%2Fpaste.example.com%2Fp%2F1%23key") {
+		t.Fatalf("mailto URL does not contain invite URL: %s", got)
+	}
+}
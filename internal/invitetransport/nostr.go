@@ -0,0 +1,358 @@
+package invitetransport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/bech32"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// nostrEventKind is the kind of the events used to store blobs
+	// (NIP-78 application-specific data).
+	nostrEventKind = 30078
+
+	// nostrAppTag is the value of the "d" tag prefix of the events used
+	// to store blobs.
+	nostrAppTag = "bisonrelay-invite"
+
+	// nostrEventHRP is the bech32 prefix of nevent (NIP-19) identifiers.
+	nostrEventHRP = "nevent"
+
+	// nostrURIPrefix is the prefix of locators of the nostr transport
+	// (NIP-21).
+	nostrURIPrefix = "nostr:"
+)
+
+// nostrEvent is a nostr event, as defined in NIP-01.
+type nostrEvent struct {
+	ID        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// marshalNoEscape encodes v as JSON without escaping html characters, as
+// required by the nostr event serialization.
+func marshalNoEscape(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// hash returns the hash of the event, used as its ID.
+func (ev *nostrEvent) hash() ([32]byte, error) {
+	ser, err := marshalNoEscape([]interface{}{0, ev.PubKey, ev.CreatedAt,
+		ev.Kind, ev.Tags, ev.Content})
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(ser), nil
+}
+
+// sign fills the pubkey, ID and signature of the event.
+func (ev *nostrEvent) sign(privKey *[32]byte) error {
+	pubKey, err := schnorrPubKey(privKey)
+	if err != nil {
+		return err
+	}
+	ev.PubKey = hex.EncodeToString(pubKey[:])
+	id, err := ev.hash()
+	if err != nil {
+		return err
+	}
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return err
+	}
+	sig, err := schnorrSign(privKey, id[:], &aux)
+	if err != nil {
+		return err
+	}
+	ev.ID = hex.EncodeToString(id[:])
+	ev.Sig = hex.EncodeToString(sig[:])
+	return nil
+}
+
+// encodeNEvent encodes the event ID and relays as a NIP-19 nevent.
+func encodeNEvent(id [32]byte, relays []string) (string, error) {
+	tlv := make([]byte, 0, 34)
+	tlv = append(tlv, 0, 32)
+	tlv = append(tlv, id[:]...)
+	for _, relay := range relays {
+		if len(relay) > 255 {
+			return "", fmt.Errorf("relay URL %q is too long", relay)
+		}
+		tlv = append(tlv, 1, byte(len(relay)))
+		tlv = append(tlv, relay...)
+	}
+	conv, err := bech32.ConvertBits(tlv, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(nostrEventHRP, conv)
+}
+
+// decodeNEvent decodes a NIP-19 nevent into the event ID and relays.
+func decodeNEvent(s string) ([32]byte, []string, error) {
+	var id [32]byte
+	hrp, data, err := bech32.DecodeNoLimit(s)
+	if err != nil {
+		return id, nil, err
+	}
+	if hrp != nostrEventHRP {
+		return id, nil, fmt.Errorf("unexpected nostr entity %q", hrp)
+	}
+	tlv, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return id, nil, err
+	}
+
+	var relays []string
+	var hasID bool
+	for len(tlv) >= 2 {
+		typ, l := tlv[0], int(tlv[1])
+		if len(tlv) < 2+l {
+			return id, nil, errors.New("truncated nevent")
+		}
+		v := tlv[2 : 2+l]
+		switch {
+		case typ == 0 && l == 32:
+			copy(id[:], v)
+			hasID = true
+		case typ == 1:
+			relays = append(relays, string(v))
+		}
+		tlv = tlv[2+l:]
+	}
+	if !hasID {
+		return id, nil, errors.New("nevent without event id")
+	}
+	return id, relays, nil
+}
+
+// NostrTransport stores blobs as events in nostr relays. Each blob is stored
+// in an event signed by a new random key.
+type NostrTransport struct {
+	// Relays are the relays where blobs are stored.
+	Relays []string
+
+	// Dialer is used to connect to the relays. If nil,
+	// websocket.DefaultDialer is used.
+	Dialer *websocket.Dialer
+}
+
+// Name is part of the Transport interface.
+func (t *NostrTransport) Name() string { return "nostr" }
+
+func (t *NostrTransport) dial(ctx context.Context, relay string) (*websocket.Conn, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	conn, _, err := dialer.DialContext(ctx, relay, nil)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+		conn.SetWriteDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// publishEvent sends the event to the relay and waits until it is accepted.
+func (t *NostrTransport) publishEvent(ctx context.Context, relay string, ev *nostrEvent) error {
+	conn, err := t.dial(ctx, relay)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	msg, err := marshalNoEscape([]interface{}{"EVENT", ev})
+	if err != nil {
+		return err
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		return err
+	}
+	for {
+		var reply []json.RawMessage
+		if err := conn.ReadJSON(&reply); err != nil {
+			return err
+		}
+		var typ, id string
+		if len(reply) < 3 || json.Unmarshal(reply[0], &typ) != nil || typ != "OK" {
+			continue
+		}
+		if json.Unmarshal(reply[1], &id) != nil || id != ev.ID {
+			continue
+		}
+		var accepted bool
+		var reason string
+		json.Unmarshal(reply[2], &accepted)
+		if len(reply) > 3 {
+			json.Unmarshal(reply[3], &reason)
+		}
+		if !accepted {
+			return fmt.Errorf("relay rejected event: %s", reason)
+		}
+		return nil
+	}
+}
+
+// Store is part of the Transport interface.
+func (t *NostrTransport) Store(ctx context.Context, data []byte) (string, error) {
+	if len(t.Relays) == 0 {
+		return "", errors.New("no nostr relays configured")
+	}
+
+	var privKey, tag [32]byte
+	if _, err := rand.Read(privKey[:]); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(tag[:]); err != nil {
+		return "", err
+	}
+	ev := &nostrEvent{
+		CreatedAt: time.Now().Unix(),
+		Kind:      nostrEventKind,
+		Tags:      [][]string{{"d", nostrAppTag + "-" + hex.EncodeToString(tag[:8])}},
+		Content:   base64.StdEncoding.EncodeToString(data),
+	}
+	if err := ev.sign(&privKey); err != nil {
+		return "", err
+	}
+
+	// The event only needs to be stored in one relay, but storing in
+	// multiple relays increases the chances of it being found later.
+	var relays []string
+	var errs []string
+	for _, relay := range t.Relays {
+		err := t.publishEvent(ctx, relay, ev)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", relay, err))
+			continue
+		}
+		relays = append(relays, relay)
+	}
+	if len(relays) == 0 {
+		return "", fmt.Errorf("unable to publish event: %s",
+			strings.Join(errs, "; "))
+	}
+
+	var id [32]byte
+	hex.Decode(id[:], []byte(ev.ID))
+	nevent, err := encodeNEvent(id, relays)
+	if err != nil {
+		return "", err
+	}
+	return nostrURIPrefix + nevent, nil
+}
+
+// fetchEvent fetches the event with the given ID from the relay.
+func (t *NostrTransport) fetchEvent(ctx context.Context, relay string, id string) (*nostrEvent, error) {
+	conn, err := t.dial(ctx, relay)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	subID := id[:16]
+	req, err := marshalNoEscape([]interface{}{"REQ", subID,
+		map[string][]string{"ids": {id}}})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, req); err != nil {
+		return nil, err
+	}
+	for {
+		var reply []json.RawMessage
+		if err := conn.ReadJSON(&reply); err != nil {
+			return nil, err
+		}
+		var typ, gotSubID string
+		if len(reply) < 2 || json.Unmarshal(reply[0], &typ) != nil {
+			continue
+		}
+		if json.Unmarshal(reply[1], &gotSubID) != nil || gotSubID != subID {
+			continue
+		}
+		switch {
+		case typ == "EOSE":
+			return nil, errors.New("event not found")
+		case typ != "EVENT" || len(reply) < 3:
+			continue
+		}
+
+		ev := new(nostrEvent)
+		if err := json.Unmarshal(reply[2], ev); err != nil {
+			return nil, err
+		}
+		hash, err := ev.hash()
+		if err != nil {
+			return nil, err
+		}
+		if ev.ID != id || hex.EncodeToString(hash[:]) != id {
+			continue
+		}
+		return ev, nil
+	}
+}
+
+// Load is part of the Transport interface.
+func (t *NostrTransport) Load(ctx context.Context, locator string) ([]byte, error) {
+	idBytes, relays, err := decodeNEvent(strings.TrimPrefix(locator, nostrURIPrefix))
+	if err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(idBytes[:])
+
+	// Try the relays from the locator first, then the configured ones.
+	relays = append(relays, t.Relays...)
+	var errs []string
+	tried := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		if tried[relay] {
+			continue
+		}
+		tried[relay] = true
+		ev, err := t.fetchEvent(ctx, relay, id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", relay, err))
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(ev.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event content: %v", err)
+		}
+		return data, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no nostr relays to fetch event from")
+	}
+	return nil, fmt.Errorf("unable to fetch event: %s", strings.Join(errs, "; "))
+}
+
+// CanLoad is part of the Transport interface.
+func (t *NostrTransport) CanLoad(locator string) bool {
+	return strings.HasPrefix(locator, nostrURIPrefix+nostrEventHRP+"1")
+}
@@ -0,0 +1,97 @@
+package invitetransport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// PasteTransport stores blobs on an HTTP paste endpoint. Blobs are stored
+// with a POST request to the endpoint, which must reply with the URL of the
+// paste in the response body. Pastes are loaded with a GET request to that
+// URL, which must reply with the raw blob.
+type PasteTransport struct {
+	// Endpoint is the URL where blobs are POSTed.
+	Endpoint string
+
+	// Client is the http client used for requests. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+func (t *PasteTransport) client() *http.Client {
+	if t.Client != nil {
+		return t.Client
+	}
+	return http.DefaultClient
+}
+
+// Name is part of the Transport interface.
+func (t *PasteTransport) Name() string { return "paste" }
+
+// readBody reads the body of the response, up to the max blob size.
+func readBody(resp *http.Response) ([]byte, error) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected http status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxBlobSize*2+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBlobSize*2 {
+		return nil, fmt.Errorf("response is too large")
+	}
+	return data, nil
+}
+
+// Store is part of the Transport interface.
+func (t *PasteTransport) Store(ctx context.Context, data []byte) (string, error) {
+	if t.Endpoint == "" {
+		return "", errors.New("no paste endpoint configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Endpoint,
+		bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := t.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := readBody(resp)
+	if err != nil {
+		return "", err
+	}
+	locator := strings.TrimSpace(string(body))
+	if !t.CanLoad(locator) {
+		return "", fmt.Errorf("paste endpoint replied with invalid URL %q",
+			locator)
+	}
+	return locator, nil
+}
+
+// Load is part of the Transport interface.
+func (t *PasteTransport) Load(ctx context.Context, locator string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, locator, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return readBody(resp)
+}
+
+// CanLoad is part of the Transport interface.
+func (t *PasteTransport) CanLoad(locator string) bool {
+	return strings.HasPrefix(locator, "https://") ||
+		strings.HasPrefix(locator, "http://")
+}
@@ -0,0 +1,267 @@
+// Package linkpreview fetches metadata (title, description and image) of web
+// pages in order to generate previews of links sent in messages.
+package linkpreview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// MimeType is the mime type of link preview embeds.
+	MimeType = "application/x-br-linkpreview"
+
+	// MaxTitleLen is the max length of the title of a preview.
+	MaxTitleLen = 200
+
+	// MaxDescriptionLen is the max length of the description of a preview.
+	MaxDescriptionLen = 500
+
+	// MaxImageSize is the max size of the image included in a preview.
+	MaxImageSize = 128 * 1024
+
+	// maxPageSize is the max amount of data read from a page when looking
+	// for its metadata.
+	maxPageSize = 1024 * 1024
+)
+
+// Preview is the metadata of a linked web page.
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageType   string `json:"image_type,omitempty"`
+	Image       []byte `json:"image,omitempty"`
+}
+
+// Encode encodes the preview for embedding in a message.
+func (p *Preview) Encode() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// Decode decodes a preview embedded in a message.
+func Decode(data []byte) (*Preview, error) {
+	var p Preview
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if p.URL == "" {
+		return nil, errors.New("link preview without url")
+	}
+	p.Title = truncate(p.Title, MaxTitleLen)
+	p.Description = truncate(p.Description, MaxDescriptionLen)
+	if len(p.Image) > MaxImageSize || !strings.HasPrefix(p.ImageType, "image/") {
+		p.Image = nil
+		p.ImageType = ""
+	}
+	return &p, nil
+}
+
+var urlRegexp = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FindURLs returns the unique http and https URLs in the given text, in the
+// order they appear.
+func FindURLs(s string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, u := range urlRegexp.FindAllString(s, -1) {
+		// Drop trailing punctuation, which is usually not part of
+		// the link.
+		u = strings.TrimRight(u, ".,;:!?)]}'")
+		if seen[u] {
+			continue
+		}
+		if _, err := url.ParseRequestURI(u); err != nil {
+			continue
+		}
+		seen[u] = true
+		res = append(res, u)
+	}
+	return res
+}
+
+// truncate truncates s to at most max bytes, without breaking utf-8
+// sequences.
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// pageMeta is the metadata extracted from an html page.
+type pageMeta struct {
+	title       string
+	ogTitle     string
+	description string
+	ogDesc      string
+	image       string
+}
+
+// parseMeta extracts the metadata of the html page. OpenGraph metadata is
+// preferred over the standard html tags.
+func parseMeta(r io.Reader) pageMeta {
+	var meta pageMeta
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return meta
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "title":
+				inTitle = meta.title == ""
+			case "meta":
+				var key, content string
+				for _, attr := range tok.Attr {
+					switch attr.Key {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = attr.Val
+					}
+				}
+				switch key {
+				case "og:title":
+					meta.ogTitle = content
+				case "og:description":
+					meta.ogDesc = content
+				case "description":
+					meta.description = content
+				case "og:image":
+					meta.image = content
+				}
+			case "body":
+				// Metadata is only in the head.
+				return meta
+			}
+
+		case html.TextToken:
+			if inTitle {
+				meta.title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			if tok := z.Token(); tok.Data == "title" {
+				inTitle = false
+			} else if tok.Data == "head" {
+				return meta
+			}
+		}
+	}
+}
+
+// get fetches the given url, ensuring the response has one of the given
+// content type prefixes.
+func get(ctx context.Context, c *http.Client, u string, contentTypePrefix string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, contentTypePrefix) {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %q", mediaType)
+	}
+	return res, nil
+}
+
+// fetchImage fetches the preview image. Images larger than MaxImageSize are
+// ignored.
+func fetchImage(ctx context.Context, c *http.Client, u string) (string, []byte, error) {
+	res, err := get(ctx, c, u, "image/")
+	if err != nil {
+		return "", nil, err
+	}
+	defer res.Body.Close()
+	if res.ContentLength > MaxImageSize {
+		return "", nil, fmt.Errorf("image size %d > max %d",
+			res.ContentLength, MaxImageSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, MaxImageSize+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) > MaxImageSize {
+		return "", nil, fmt.Errorf("image larger than max %d", MaxImageSize)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return mediaType, data, nil
+}
+
+// Fetch fetches the page at the given url and returns its preview. All
+// requests are made with the passed http client, which should be configured
+// with the desired proxy settings. Failure to fetch the preview image does not
+// cause an error; the preview is returned without the image.
+func Fetch(ctx context.Context, c *http.Client, u string) (*Preview, error) {
+	pageURL, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if pageURL.Scheme != "http" && pageURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", pageURL.Scheme)
+	}
+
+	res, err := get(ctx, c, u, "text/html")
+	if err != nil {
+		return nil, err
+	}
+	meta := parseMeta(io.LimitReader(res.Body, maxPageSize))
+	res.Body.Close()
+
+	p := &Preview{
+		URL:         u,
+		Title:       meta.ogTitle,
+		Description: meta.ogDesc,
+	}
+	if p.Title == "" {
+		p.Title = meta.title
+	}
+	if p.Description == "" {
+		p.Description = meta.description
+	}
+	p.Title = truncate(p.Title, MaxTitleLen)
+	p.Description = truncate(p.Description, MaxDescriptionLen)
+	if p.Title == "" && p.Description == "" {
+		return nil, errors.New("page has no title or description")
+	}
+
+	if meta.image != "" {
+		imgURL, err := pageURL.Parse(meta.image)
+		if err == nil && (imgURL.Scheme == "http" || imgURL.Scheme == "https") {
+			p.ImageType, p.Image, err = fetchImage(ctx, c, imgURL.String())
+			if err != nil {
+				p.ImageType, p.Image = "", nil
+			}
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,124 @@
+package linkpreview
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFindURLs tests finding links in messages.
+func TestFindURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want []string
+	}{{
+		name: "no links",
+		msg:  "hello world",
+	}, {
+		name: "single link",
+		msg:  "see https://example.com/page?a=1.",
+		want: []string{"https://example.com/page?a=1"},
+	}, {
+		name: "repeated links",
+		msg:  "http://a.example, http://b.example and http://a.example",
+		want: []string{"http://a.example", "http://b.example"},
+	}, {
+		name: "non http links",
+		msg:  "ftp://example.com and mailto:foo@example.com",
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := FindURLs(tc.msg)
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Fatalf("unexpected urls: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestFetch tests fetching previews from a test server.
+func TestFetch(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nfake image")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Plain title</title>
+<meta property="og:title" content="OG title">
+<meta property="og:description" content="OG description">
+<meta property="og:image" content="/img.png">
+</head><body>body</body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title> Plain title </title>
+<meta name="description" content="Plain description">
+<meta property="og:image" content="/missing.png">
+</head></html>`))
+	})
+	mux.HandleFunc("/img.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	})
+	mux.HandleFunc("/file.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("binary"))
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	ctx := context.Background()
+	c := svr.Client()
+
+	// OpenGraph metadata is preferred.
+	p, err := Fetch(ctx, c, svr.URL+"/og")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "OG title" || p.Description != "OG description" {
+		t.Fatalf("unexpected preview: %+v", p)
+	}
+	if p.ImageType != "image/png" || !bytes.Equal(p.Image, png) {
+		t.Fatalf("unexpected image %q %x", p.ImageType, p.Image)
+	}
+
+	// Standard html tags are used when there's no OpenGraph metadata. A
+	// missing image does not fail the preview.
+	p, err = Fetch(ctx, c, svr.URL+"/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Plain title" || p.Description != "Plain description" {
+		t.Fatalf("unexpected preview: %+v", p)
+	}
+	if p.Image != nil {
+		t.Fatalf("unexpected image in preview")
+	}
+
+	// Non-html pages do not generate previews.
+	if _, err := Fetch(ctx, c, svr.URL+"/file.bin"); err == nil {
+		t.Fatal("expected error fetching non-html page")
+	}
+
+	// Round trip the encoding.
+	p, err = Fetch(ctx, c, svr.URL+"/og")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := p.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := Decode(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.URL != p.URL || dec.Title != p.Title || !bytes.Equal(dec.Image, p.Image) {
+		t.Fatalf("unexpected decoded preview: %+v", dec)
+	}
+}
@@ -19,6 +19,9 @@ type EmbeddedArgs struct {
 	Alt  string
 	Typ  string
 
+	// DurationMs is the duration of embedded audio (in milliseconds).
+	DurationMs uint64
+
 	// shared link
 	Download zkidentity.ShortID
 	Filename string
@@ -55,6 +58,9 @@ func (args EmbeddedArgs) String() string {
 	if args.Cost > 0 {
 		parts = append(parts, "cost="+strconv.FormatUint(args.Cost, 10))
 	}
+	if args.DurationMs > 0 {
+		parts = append(parts, "duration="+strconv.FormatUint(args.DurationMs, 10))
+	}
 	if args.Data != nil {
 		parts = append(parts, "data="+base64.StdEncoding.EncodeToString(args.Data))
 	}
@@ -115,6 +121,8 @@ func ParseEmbedArgs(rawEmbedStr string) EmbeddedArgs {
 			args.Size, _ = strconv.ParseUint(v, 10, 64)
 		case "cost":
 			args.Cost, _ = strconv.ParseUint(v, 10, 64)
+		case "duration":
+			args.DurationMs, _ = strconv.ParseUint(v, 10, 64)
 		case "localfilename":
 			args.LocalFilename = v
 		}
@@ -51,6 +51,11 @@ func TestReplaceEmbeds(t *testing.T) {
 		src:      "start --embed[alt=alt,download=broken]-- end",
 		wantArgs: []EmbeddedArgs{{Alt: "alt"}},
 		wantDst:  "start xxx end",
+	}, {
+		name:     "audio with duration",
+		src:      "start --embed[type=audio/ogg,duration=1500,data=dGVzdA==]-- end",
+		wantArgs: []EmbeddedArgs{{Typ: "audio/ogg", DurationMs: 1500, Data: []byte("test")}},
+		wantDst:  "start xxx end",
 	}}
 
 	for _, tc := range tests {
@@ -0,0 +1,608 @@
+// Package qrcode encodes data as QR codes (ISO/IEC 18004) and renders them as
+// PNG images or as text suitable for displaying in terminals.
+//
+// Only the alphanumeric and byte modes and versions 1 to 10 are supported,
+// which is enough to encode short strings such as invite keys.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is the error correction level of a QR code.
+type Level int
+
+const (
+	// LevelL recovers from about 7% of damaged codewords.
+	LevelL Level = iota
+
+	// LevelM recovers from about 15% of damaged codewords.
+	LevelM
+
+	// LevelQ recovers from about 25% of damaged codewords.
+	LevelQ
+
+	// LevelH recovers from about 30% of damaged codewords.
+	LevelH
+)
+
+// formatBits returns the bits used to encode the level in the format info.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// MaxVersion is the max QR code version supported by this package.
+const MaxVersion = 10
+
+// ErrDataTooLong is returned when the data does not fit in a QR code of the
+// max supported version.
+var ErrDataTooLong = errors.New("data too long to encode as QR code")
+
+// ecBlocks describes the error correction blocks of a version and level.
+// Blocks in the second group have one more data codeword than those in the
+// first group.
+type ecBlocks struct {
+	ecLen     int // EC codewords per block.
+	g1Blocks  int
+	g1DataLen int
+	g2Blocks  int
+}
+
+func (b ecBlocks) numBlocks() int {
+	return b.g1Blocks + b.g2Blocks
+}
+
+func (b ecBlocks) dataLen() int {
+	return b.g1Blocks*b.g1DataLen + b.g2Blocks*(b.g1DataLen+1)
+}
+
+// ecTable is indexed by [version-1][level].
+var ecTable = [MaxVersion][4]ecBlocks{
+	{{7, 1, 19, 0}, {10, 1, 16, 0}, {13, 1, 13, 0}, {17, 1, 9, 0}},
+	{{10, 1, 34, 0}, {16, 1, 28, 0}, {22, 1, 22, 0}, {28, 1, 16, 0}},
+	{{15, 1, 55, 0}, {26, 1, 44, 0}, {18, 2, 17, 0}, {22, 2, 13, 0}},
+	{{20, 1, 80, 0}, {18, 2, 32, 0}, {26, 2, 24, 0}, {16, 4, 9, 0}},
+	{{26, 1, 108, 0}, {24, 2, 43, 0}, {18, 2, 15, 2}, {22, 2, 11, 2}},
+	{{18, 2, 68, 0}, {16, 4, 27, 0}, {24, 4, 19, 0}, {28, 4, 15, 0}},
+	{{20, 2, 78, 0}, {18, 4, 31, 0}, {18, 2, 14, 4}, {26, 4, 13, 1}},
+	{{24, 2, 97, 0}, {22, 2, 38, 2}, {22, 4, 18, 2}, {26, 4, 14, 2}},
+	{{30, 2, 116, 0}, {22, 3, 36, 2}, {20, 4, 16, 4}, {24, 4, 12, 4}},
+	{{18, 2, 68, 2}, {26, 4, 43, 1}, {24, 6, 19, 2}, {28, 6, 15, 2}},
+}
+
+// alignmentPositions is indexed by version-1.
+var alignmentPositions = [MaxVersion][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// alphanumChars are the chars encodable in alphanumeric mode, in order of
+// their value.
+const alphanumChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// isAlphanum returns true if s can be encoded in alphanumeric mode.
+func isAlphanum(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune(alphanumChars, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	// Version is the version of the code.
+	Version int
+
+	// Level is the error correction level of the code.
+	Level Level
+
+	// Mask is the mask pattern applied to the code.
+	Mask int
+
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Size returns the number of modules in each side of the code, not including
+// the quiet zone.
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark returns true if the module at the given row and column is dark.
+// Modules outside the code (for example, in the quiet zone) are light.
+func (c *Code) Dark(row, col int) bool {
+	if row < 0 || col < 0 || row >= c.size || col >= c.size {
+		return false
+	}
+	return c.modules[row][col]
+}
+
+// bitBuffer accumulates the bits of the encoded data.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (v>>i)&1 == 1)
+	}
+}
+
+func (b *bitBuffer) bytes() []byte {
+	res := make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			res[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return res
+}
+
+// encodeData encodes the data segment for the given version. The mode is
+// alphanumeric if possible, otherwise byte mode is used.
+func encodeData(data string, version int) *bitBuffer {
+	b := new(bitBuffer)
+	if isAlphanum(data) {
+		countBits := 9
+		if version >= 10 {
+			countBits = 11
+		}
+		b.append(0x2, 4)
+		b.append(len(data), countBits)
+		for i := 0; i+1 < len(data); i += 2 {
+			v := strings.IndexByte(alphanumChars, data[i])*45 +
+				strings.IndexByte(alphanumChars, data[i+1])
+			b.append(v, 11)
+		}
+		if len(data)%2 == 1 {
+			b.append(strings.IndexByte(alphanumChars, data[len(data)-1]), 6)
+		}
+		return b
+	}
+
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	b.append(0x4, 4)
+	b.append(len(data), countBits)
+	for i := 0; i < len(data); i++ {
+		b.append(int(data[i]), 8)
+	}
+	return b
+}
+
+// dataCodewords returns the data codewords of the data encoded at the given
+// version and level, including the terminator and padding. Returns nil if the
+// data does not fit.
+func dataCodewords(data string, version int, level Level) []byte {
+	capacity := ecTable[version-1][level].dataLen() * 8
+	b := encodeData(data, version)
+	if len(b.bits) > capacity {
+		return nil
+	}
+
+	// Terminator, then pad to a byte boundary.
+	b.append(0, min(4, capacity-len(b.bits)))
+	b.append(0, (8-len(b.bits)%8)%8)
+
+	// Pad bytes.
+	for pad := 0xec; len(b.bits) < capacity; pad ^= 0xec ^ 0x11 {
+		b.append(pad, 8)
+	}
+	return b.bytes()
+}
+
+// Encode encodes the data as a QR code with the given error correction level.
+// The smallest version able to hold the data is used. Data with only chars in
+// the QR alphanumeric set (uppercase letters, digits and a few symbols) is
+// encoded more compactly.
+func Encode(data string, level Level) (*Code, error) {
+	if level < LevelL || level > LevelH {
+		return nil, fmt.Errorf("invalid error correction level %d", level)
+	}
+
+	for version := 1; version <= MaxVersion; version++ {
+		dataCW := dataCodewords(data, version, level)
+		if dataCW == nil {
+			continue
+		}
+		codewords := addECAndInterleave(dataCW, ecTable[version-1][level])
+		return newCode(version, level, codewords), nil
+	}
+	return nil, ErrDataTooLong
+}
+
+// addECAndInterleave splits the data codewords in blocks, computes the error
+// correction codewords of each block and interleaves them.
+func addECAndInterleave(data []byte, ecb ecBlocks) []byte {
+	type block struct {
+		data []byte
+		ec   []byte
+	}
+	divisor := rsDivisor(ecb.ecLen)
+	blocks := make([]block, 0, ecb.numBlocks())
+	for i := 0; i < ecb.numBlocks(); i++ {
+		n := ecb.g1DataLen
+		if i >= ecb.g1Blocks {
+			n += 1
+		}
+		blk := block{data: data[:n]}
+		blk.ec = rsRemainder(blk.data, divisor)
+		blocks = append(blocks, blk)
+		data = data[n:]
+	}
+
+	var res []byte
+	for i := 0; i <= ecb.g1DataLen; i++ {
+		for _, blk := range blocks {
+			if i < len(blk.data) {
+				res = append(res, blk.data[i])
+			}
+		}
+	}
+	for i := 0; i < ecb.ecLen; i++ {
+		for _, blk := range blocks {
+			res = append(res, blk.ec[i])
+		}
+	}
+	return res
+}
+
+// gfMul multiplies two elements of GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given degree,
+// without the leading term.
+func rsDivisor(degree int) []byte {
+	res := make([]byte, degree)
+	res[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range res {
+			res[j] = gfMul(res[j], root)
+			if j+1 < len(res) {
+				res[j] ^= res[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return res
+}
+
+// rsRemainder returns the Reed-Solomon error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	res := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ res[0]
+		copy(res, res[1:])
+		res[len(res)-1] = 0
+		for i := range res {
+			res[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return res
+}
+
+// newCode draws the QR code with the given codewords, choosing the mask with
+// the lowest penalty.
+func newCode(version int, level Level, codewords []byte) *Code {
+	c := &Code{
+		Version: version,
+		Level:   level,
+		size:    version*4 + 17,
+	}
+	c.modules = make([][]bool, c.size)
+	c.function = make([][]bool, c.size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, c.size)
+		c.function[i] = make([]bool, c.size)
+	}
+
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords)
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		penalty := c.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // Undo (XOR).
+	}
+
+	c.Mask = bestMask
+	c.applyMask(bestMask)
+	c.drawFormatBits(bestMask)
+	return c
+}
+
+// setFunction sets a function module.
+func (c *Code) setFunction(row, col int, dark bool) {
+	c.modules[row][col] = dark
+	c.function[row][col] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	// Timing patterns.
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns (with separators).
+	c.drawFinder(3, 3)
+	c.drawFinder(3, c.size-4)
+	c.drawFinder(c.size-4, 3)
+
+	// Alignment patterns, except those overlapping the finders.
+	pos := alignmentPositions[c.Version-1]
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					dist := max(abs(dr), abs(dc))
+					c.setFunction(pos[i]+dr, pos[j]+dc, dist != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas (drawn after masking) and the dark module.
+	c.drawFormatBits(0)
+
+	// Version info.
+	if c.Version >= 7 {
+		bits := versionBits(c.Version)
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := c.size-11+i%3, i/3
+			c.setFunction(b, a, dark)
+			c.setFunction(a, b, dark)
+		}
+	}
+}
+
+func (c *Code) drawFinder(row, col int) {
+	for dr := -4; dr <= 4; dr++ {
+		for dc := -4; dc <= 4; dc++ {
+			r, cc := row+dr, col+dc
+			if r < 0 || cc < 0 || r >= c.size || cc >= c.size {
+				continue
+			}
+			dist := max(abs(dr), abs(dc))
+			c.setFunction(r, cc, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// formatBits returns the 15 format info bits for the level and mask.
+func formatBits(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits returns the 18 version info bits for the version.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	return version<<12 | rem
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(c.Level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	// First copy, around the top left finder.
+	for i := 0; i <= 5; i++ {
+		c.setFunction(i, 8, bit(i))
+	}
+	c.setFunction(7, 8, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(8, 14-i, bit(i))
+	}
+
+	// Second copy, split between the other finders.
+	for i := 0; i < 8; i++ {
+		c.setFunction(8, c.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(c.size-15+i, 8, bit(i))
+	}
+
+	// Dark module.
+	c.setFunction(c.size-8, 8, true)
+}
+
+// drawCodewords places the codewords in the non-function modules, in the
+// zigzag order defined by the standard.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	nbBits := len(codewords) * 8
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			row := vert
+			if upward {
+				row = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if c.function[row][col] || i >= nbBits {
+					continue
+				}
+				c.modules[row][col] = (codewords[i/8]>>(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// maskBit returns whether the module at the given position is flipped by the
+// mask.
+func maskBit(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return row*col%2+row*col%3 == 0
+	case 6:
+		return (row*col%2+row*col%3)%2 == 0
+	default:
+		return ((row+col)%2+row*col%3)%2 == 0
+	}
+}
+
+// applyMask XORs the mask into the non-function modules.
+func (c *Code) applyMask(mask int) {
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if !c.function[row][col] && maskBit(mask, row, col) {
+				c.modules[row][col] = !c.modules[row][col]
+			}
+		}
+	}
+}
+
+// finderLike are module sequences that resemble finder patterns, penalized
+// when choosing the mask.
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty returns the penalty score of the code, as defined by the standard.
+func (c *Code) penalty() int {
+	var res int
+	line := make([]bool, c.size)
+	for _, vertical := range []bool{false, true} {
+		for i := 0; i < c.size; i++ {
+			for j := 0; j < c.size; j++ {
+				if vertical {
+					line[j] = c.modules[j][i]
+				} else {
+					line[j] = c.modules[i][j]
+				}
+			}
+
+			// Runs of 5 or more modules of the same color.
+			run := 1
+			for j := 1; j <= c.size; j++ {
+				if j < c.size && line[j] == line[j-1] {
+					run += 1
+					continue
+				}
+				if run >= 5 {
+					res += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Finder-like patterns.
+			for j := 0; j+11 <= c.size; j++ {
+				for _, p := range finderLike {
+					match := true
+					for k := range p {
+						if line[j+k] != p[k] {
+							match = false
+							break
+						}
+					}
+					if match {
+						res += 40
+					}
+				}
+			}
+		}
+	}
+
+	// 2x2 blocks of the same color.
+	var dark int
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if c.modules[row][col] {
+				dark += 1
+			}
+			if row == 0 || col == 0 {
+				continue
+			}
+			m := c.modules[row][col]
+			if m == c.modules[row-1][col] && m == c.modules[row][col-1] &&
+				m == c.modules[row-1][col-1] {
+				res += 3
+			}
+		}
+	}
+
+	// Balance of dark and light modules.
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return res + k*10
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,210 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// TestHelloWorldCodewords tests the data and error correction codewords of
+// the well known "HELLO WORLD" example.
+func TestHelloWorldCodewords(t *testing.T) {
+	tests := []struct {
+		level Level
+		data  []byte
+		ec    []byte
+	}{{
+		level: LevelM,
+		data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17,
+			236, 17, 236, 17},
+		ec: []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+	}, {
+		level: LevelQ,
+		data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17,
+			236},
+		ec: []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16},
+	}}
+
+	for _, tc := range tests {
+		data := dataCodewords("HELLO WORLD", 1, tc.level)
+		if !bytes.Equal(data, tc.data) {
+			t.Fatalf("unexpected data codewords: got %v, want %v",
+				data, tc.data)
+		}
+		ec := rsRemainder(data, rsDivisor(len(tc.ec)))
+		if !bytes.Equal(ec, tc.ec) {
+			t.Fatalf("unexpected ec codewords: got %v, want %v", ec,
+				tc.ec)
+		}
+	}
+}
+
+// TestFormatVersionBits tests the format and version info bits against values
+// from the standard tables.
+func TestFormatVersionBits(t *testing.T) {
+	formats := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{LevelL, 0, 0x77c4},
+		{LevelL, 7, 0x6976},
+		{LevelM, 0, 0x5412},
+		{LevelQ, 3, 0x3a06},
+		{LevelH, 5, 0x0255},
+	}
+	for _, tc := range formats {
+		if got := formatBits(tc.level, tc.mask); got != tc.want {
+			t.Fatalf("unexpected format bits for %d/%d: got %015b, "+
+				"want %015b", tc.level, tc.mask, got, tc.want)
+		}
+	}
+
+	versions := map[int]int{7: 0x07c94, 8: 0x085bc, 9: 0x09a99, 10: 0x0a4d3}
+	for v, want := range versions {
+		if got := versionBits(v); got != want {
+			t.Fatalf("unexpected version bits for %d: got %x, want %x",
+				v, got, want)
+		}
+	}
+}
+
+// readCodewords reads the codewords back from the code, by reversing the mask
+// and reading the modules in placement order.
+func readCodewords(c *Code) []byte {
+	var bits []bool
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			row := vert
+			if upward {
+				row = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if c.function[row][col] {
+					continue
+				}
+				bits = append(bits, c.modules[row][col] != maskBit(c.Mask, row, col))
+			}
+		}
+	}
+	res := make([]byte, len(bits)/8)
+	for i := range res {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				res[i] |= 1 << (7 - j)
+			}
+		}
+	}
+	return res
+}
+
+// TestEncodeRoundTrip tests that the codewords and format info drawn in codes
+// of every supported version can be read back.
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, level := range []Level{LevelL, LevelM, LevelQ, LevelH} {
+		for version := 1; version <= MaxVersion; version++ {
+			// Fill the version with byte mode data.
+			ecb := ecTable[version-1][level]
+			countBytes := 1
+			if version >= 10 {
+				countBytes = 2
+			}
+			data := strings.Repeat("b", ecb.dataLen()-countBytes-1)
+
+			c, err := Encode(data, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Version != version {
+				t.Fatalf("unexpected version: got %d, want %d",
+					c.Version, version)
+			}
+			if c.Size() != version*4+17 {
+				t.Fatalf("unexpected size %d", c.Size())
+			}
+
+			want := addECAndInterleave(dataCodewords(data, version, level), ecb)
+			got := readCodewords(c)
+			if !bytes.Equal(got[:len(want)], want) {
+				t.Fatalf("unexpected codewords for version %d level %d",
+					version, level)
+			}
+
+			// Read the first copy of the format info.
+			var format int
+			for i := 0; i <= 5; i++ {
+				if c.Dark(i, 8) {
+					format |= 1 << i
+				}
+			}
+			if c.Dark(7, 8) {
+				format |= 1 << 6
+			}
+			if c.Dark(8, 8) {
+				format |= 1 << 7
+			}
+			if c.Dark(8, 7) {
+				format |= 1 << 8
+			}
+			for i := 9; i < 15; i++ {
+				if c.Dark(8, 14-i) {
+					format |= 1 << i
+				}
+			}
+			if format != formatBits(level, c.Mask) {
+				t.Fatalf("unexpected format info")
+			}
+		}
+	}
+
+	// Data larger than the max version is rejected.
+	_, err := Encode(strings.Repeat("b", 300), LevelL)
+	if err != ErrDataTooLong {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestRender tests rendering codes.
+func TestRender(t *testing.T) {
+	c, err := Encode("BRPIK1TEST", LevelM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 1 {
+		t.Fatalf("unexpected version %d", c.Version)
+	}
+
+	var b bytes.Buffer
+	if err := c.WritePNG(&b, 4); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSide := (c.Size() + 2*QuietZone) * 4
+	if img.Bounds().Dx() != wantSide || img.Bounds().Dy() != wantSide {
+		t.Fatalf("unexpected image size %v", img.Bounds())
+	}
+
+	// The top left corner of the finder pattern is dark.
+	if r, _, _, _ := img.At(QuietZone*4, QuietZone*4).RGBA(); r != 0 {
+		t.Fatalf("finder pattern corner is not dark")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(2, false), "\n"), "\n")
+	if len(lines) != (c.Size()+4+1)/2 {
+		t.Fatalf("unexpected number of lines %d", len(lines))
+	}
+	for _, l := range lines {
+		if n := len([]rune(l)); n != c.Size()+4 {
+			t.Fatalf("unexpected line length %d", n)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// QuietZone is the number of light modules around the code required by the
+// standard.
+const QuietZone = 4
+
+// Image returns the code as an image, where each module is drawn as a square
+// of scale x scale pixels. The image includes the quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			row, col := y/scale-QuietZone, x/scale-QuietZone
+			v := color.Gray{Y: 0xff}
+			if c.Dark(row, col) {
+				v = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, v)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the code as a PNG image to w. See Image for the meaning of
+// scale.
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+// Terminal returns the code as text to be displayed in terminals, using
+// Unicode half block chars such that each line of text holds two rows of
+// modules.
+//
+// Light modules are drawn with blocks and dark modules with spaces, so the
+// code is correctly displayed in terminals with a dark background. If invert
+// is true, the opposite is done, for terminals with a light background.
+//
+// quietZone is the number of light modules drawn around the code. Scanners
+// usually work with less than the standard QuietZone.
+func (c *Code) Terminal(quietZone int, invert bool) string {
+	var b strings.Builder
+	first, last := -quietZone, c.size+quietZone
+	light := func(row, col int) bool {
+		return c.Dark(row, col) == invert
+	}
+	for row := first; row < last; row += 2 {
+		for col := first; col < last; col++ {
+			top := light(row, col)
+			bottom := row+1 < last && light(row+1, col)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/companyzero/bisonrelay/client/clientintf"
 	"github.com/companyzero/bisonrelay/rpc"
+	"github.com/decred/dcrd/chaincfg/chainhash"
 )
 
 // MockPayClient fulfills the [clientintf.PaymentClient] interface, while
@@ -21,6 +22,17 @@ type MockPayClient struct {
 	getInvoice     func(int64, func(int64)) (string, error)
 	decodeInvoice  func(string) (clientintf.DecodedInvoice, error)
 	trackInvoice   func(string, int64) (int64, error)
+	payKeysend     func(string, int64) ([]byte, int64, error)
+	keysendRecv    func([]byte) (int64, error)
+	cancelInvoice  func(string) error
+	estimateFee    func(string, int64) (clientintf.PaymentFeeEstimate, error)
+	newOnchainAddr func() (string, error)
+	sendOnchain    func(string, int64) (chainhash.Hash, error)
+	onchainTxOut   func(chainhash.Hash, string) (int64, int32, error)
+	addHoldInv     func([]byte, int64, time.Duration) (string, error)
+	settleHoldInv  func([]byte) error
+	cancelHoldInv  func([]byte) error
+	holdInvState   func([]byte) (clientintf.HoldInvoiceState, error)
 }
 
 func (pc *MockPayClient) PayScheme() string {
@@ -136,3 +148,190 @@ func (pc *MockPayClient) TrackInvoice(ctx context.Context, inv string, minMAtoms
 	}
 	return 0, nil
 }
+
+func (pc *MockPayClient) HookPayKeysend(hook func(string, int64) ([]byte, int64, error)) {
+	pc.mtx.Lock()
+	pc.payKeysend = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) PayKeysend(_ context.Context, dest string, amtMAtoms int64) ([]byte, int64, error) {
+	pc.mtx.Lock()
+	hook := pc.payKeysend
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(dest, amtMAtoms)
+	}
+	return nil, 0, fmt.Errorf("keysend payments are not supported")
+}
+
+func (pc *MockPayClient) HookKeysendReceived(hook func([]byte) (int64, error)) {
+	pc.mtx.Lock()
+	pc.keysendRecv = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) KeysendReceived(_ context.Context, hash []byte) (int64, error) {
+	pc.mtx.Lock()
+	hook := pc.keysendRecv
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(hash)
+	}
+	return 0, fmt.Errorf("keysend payment %x not found", hash)
+}
+
+func (pc *MockPayClient) HookCancelInvoice(hook func(string) error) {
+	pc.mtx.Lock()
+	pc.cancelInvoice = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) CancelInvoice(_ context.Context, invoice string) error {
+	pc.mtx.Lock()
+	hook := pc.cancelInvoice
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(invoice)
+	}
+	return nil
+}
+
+// HookEstimateFee hooks the fee estimation calls. The first argument to the
+// hook is either the invoice or the destination node being estimated.
+func (pc *MockPayClient) HookEstimateFee(hook func(string, int64) (clientintf.PaymentFeeEstimate, error)) {
+	pc.mtx.Lock()
+	pc.estimateFee = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) estimate(target string, amtMAtoms int64) (clientintf.PaymentFeeEstimate, error) {
+	pc.mtx.Lock()
+	hook := pc.estimateFee
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(target, amtMAtoms)
+	}
+	return clientintf.PaymentFeeEstimate{AmountMAtoms: amtMAtoms, MaxFeeMAtoms: -1}, nil
+}
+
+func (pc *MockPayClient) EstimateInvoiceFee(_ context.Context, invoice string, amtMAtoms int64) (clientintf.PaymentFeeEstimate, error) {
+	return pc.estimate(invoice, amtMAtoms)
+}
+
+func (pc *MockPayClient) EstimateNodeFee(_ context.Context, dest string, amtMAtoms int64) (clientintf.PaymentFeeEstimate, error) {
+	return pc.estimate(dest, amtMAtoms)
+}
+
+func (pc *MockPayClient) HookNewOnchainAddress(hook func() (string, error)) {
+	pc.mtx.Lock()
+	pc.newOnchainAddr = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) NewOnchainAddress(_ context.Context) (string, error) {
+	pc.mtx.Lock()
+	hook := pc.newOnchainAddr
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook()
+	}
+	return "", fmt.Errorf("on-chain payments are not supported")
+}
+
+func (pc *MockPayClient) HookSendOnchain(hook func(string, int64) (chainhash.Hash, error)) {
+	pc.mtx.Lock()
+	pc.sendOnchain = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) SendOnchain(_ context.Context, addr string, atoms int64) (chainhash.Hash, error) {
+	pc.mtx.Lock()
+	hook := pc.sendOnchain
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(addr, atoms)
+	}
+	return chainhash.Hash{}, fmt.Errorf("on-chain payments are not supported")
+}
+
+func (pc *MockPayClient) HookOnchainTxOutput(hook func(chainhash.Hash, string) (int64, int32, error)) {
+	pc.mtx.Lock()
+	pc.onchainTxOut = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) OnchainTxOutput(_ context.Context, tx chainhash.Hash, addr string) (int64, int32, error) {
+	pc.mtx.Lock()
+	hook := pc.onchainTxOut
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(tx, addr)
+	}
+	return 0, 0, fmt.Errorf("tx %s not found", tx)
+}
+
+func (pc *MockPayClient) HookAddHoldInvoice(hook func([]byte, int64, time.Duration) (string, error)) {
+	pc.mtx.Lock()
+	pc.addHoldInv = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) AddHoldInvoice(_ context.Context, hash []byte, mAtoms int64,
+	_ string, lifetime time.Duration) (string, error) {
+	pc.mtx.Lock()
+	hook := pc.addHoldInv
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(hash, mAtoms, lifetime)
+	}
+	return "", fmt.Errorf("hold invoices are not supported")
+}
+
+func (pc *MockPayClient) HookSettleHoldInvoice(hook func([]byte) error) {
+	pc.mtx.Lock()
+	pc.settleHoldInv = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) SettleHoldInvoice(_ context.Context, preimage []byte) error {
+	pc.mtx.Lock()
+	hook := pc.settleHoldInv
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(preimage)
+	}
+	return fmt.Errorf("hold invoices are not supported")
+}
+
+func (pc *MockPayClient) HookCancelHoldInvoice(hook func([]byte) error) {
+	pc.mtx.Lock()
+	pc.cancelHoldInv = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) CancelHoldInvoice(_ context.Context, hash []byte) error {
+	pc.mtx.Lock()
+	hook := pc.cancelHoldInv
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(hash)
+	}
+	return fmt.Errorf("hold invoices are not supported")
+}
+
+func (pc *MockPayClient) HookHoldInvoiceState(hook func([]byte) (clientintf.HoldInvoiceState, error)) {
+	pc.mtx.Lock()
+	pc.holdInvState = hook
+	pc.mtx.Unlock()
+}
+
+func (pc *MockPayClient) HoldInvoiceState(_ context.Context, hash []byte) (clientintf.HoldInvoiceState, error) {
+	pc.mtx.Lock()
+	hook := pc.holdInvState
+	pc.mtx.Unlock()
+	if hook != nil {
+		return hook(hash)
+	}
+	return "", fmt.Errorf("hold invoice %x not found", hash)
+}
@@ -48,6 +48,10 @@ const (
 	maxMissingMessages = 80
 )
 
+// MaxMissingMessages is the maximum number of missing messages that are
+// tracked by a ratchet.
+const MaxMissingMessages = maxMissingMessages
+
 type RVPoint = zkidentity.ShortID
 
 // savedKey contains a message key and timestamp for a message which has not
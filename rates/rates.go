@@ -95,6 +95,19 @@ func (r *Rates) Get() (float64, float64) {
 	return dcrPrice, btcPrice
 }
 
+// LastUpdated returns the time the prices were last updated. Returns the zero
+// time if the prices were never fetched.
+func (r *Rates) LastUpdated() time.Time {
+	r.mtx.Lock()
+	lastUpdated := r.lastUpdated
+	r.mtx.Unlock()
+
+	if lastUpdated == 0 {
+		return time.Time{}
+	}
+	return time.Unix(lastUpdated, 0)
+}
+
 // Set manually sets the USD/DCR and USD/BTC prices.
 func (r *Rates) Set(dcrPrice, btcPrice float64) {
 	r.cfg.Log.Infof("Setting manual exchange rate: DCR:%0.2f BTC:%0.2f",
@@ -5,15 +5,20 @@ import (
 	"compress/zlib"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/companyzero/bisonrelay/ratchet"
+	"github.com/companyzero/bisonrelay/ratchet/disk"
 	"github.com/companyzero/bisonrelay/zkidentity"
 	"github.com/decred/dcrd/crypto/blake256"
+	"golang.org/x/exp/slices"
 )
 
 // Header that describes the payload that follows.
@@ -44,6 +49,13 @@ const (
 type RMPrivateMessage struct {
 	Mode    uint32 `json:"mode"`
 	Message string `json:"message"`
+
+	// MsgID is an ID chosen by the sender to identify the message in
+	// later edits. May be nil for messages sent by older clients.
+	MsgID *zkidentity.ShortID `json:"msgid,omitempty"`
+
+	// ReplyTo is the ID of the message this message is a reply to.
+	ReplyTo *zkidentity.ShortID `json:"replyto,omitempty"`
 }
 
 type RMBlock struct {
@@ -131,12 +143,242 @@ type RMInvoice struct {
 	Error   *string `json:"error,omitempty"`
 }
 
+// RMCKeysendTip is the command to notify a remote user of a tip sent as a
+// spontaneous (keysend) LN payment.
+const RMCKeysendTip = "keysendtip"
+
+// RMKeysendTip is sent after a keysend payment to the node of the remote user
+// completes, so that it may attribute the payment to the sender.
+type RMKeysendTip struct {
+	PaymentHash []byte `json:"payment_hash"`
+	MilliAtoms  uint64 `json:"milli_atoms"`
+}
+
+// RMCGetOnchainAddress is the command to request an on-chain address from a
+// remote user, to send a tip as an on-chain transaction.
+const RMCGetOnchainAddress = "getonchainaddress"
+
+// RMGetOnchainAddress requests an address to send an on-chain tip of the given
+// amount to. The ID identifies the tip in the following messages.
+type RMGetOnchainAddress struct {
+	ID    zkidentity.ShortID `json:"id"`
+	Atoms int64              `json:"atoms"`
+}
+
+// RMCOnchainAddress is the reply to RMCGetOnchainAddress.
+const RMCOnchainAddress = "onchainaddress"
+
+// RMOnchainAddress is the address generated to receive an on-chain tip.
+type RMOnchainAddress struct {
+	ID      zkidentity.ShortID `json:"id"`
+	Address string             `json:"address"`
+	Error   *string            `json:"error,omitempty"`
+}
+
+// RMCOnchainTipReceipt is the command to notify a remote user of a confirmed
+// on-chain tip.
+const RMCOnchainTipReceipt = "onchaintipreceipt"
+
+// RMOnchainTipReceipt is sent once the transaction of an on-chain tip is
+// confirmed, so that the remote user may attribute the payment to the sender.
+type RMOnchainTipReceipt struct {
+	ID     zkidentity.ShortID `json:"id"`
+	TxHash string             `json:"tx_hash"`
+	Atoms  int64              `json:"atoms"`
+}
+
+// RMCPaymentReceipt is the command to send a signed receipt of a payment made
+// to a remote user.
+const RMCPaymentReceipt = "paymentreceipt"
+
+const (
+	// PaymentReceiptKindTip is the kind of receipts of tips.
+	PaymentReceiptKindTip = "tip"
+
+	// PaymentReceiptKindContent is the kind of receipts of payments for
+	// content (for example, file chunks).
+	PaymentReceiptKindContent = "content"
+)
+
+// RMPaymentReceipt is sent by the payer of a completed payment to the payee.
+// Signature is the signature of ReceiptHash made with the signing key of the
+// payer, so that both parties hold a verifiable record of the payment.
+//
+// GC and Payee are only set in receipts of tips made within the context of a
+// GC. Those receipts are also announced to other members of the GC, which use
+// them to keep a tipping leaderboard.
+//
+// Fiat is only set in receipts of fiat-denominated payments.
+type RMPaymentReceipt struct {
+	Kind        string                        `json:"kind"`
+	MilliAtoms  int64                         `json:"milli_atoms"`
+	PaymentHash []byte                        `json:"payment_hash"`
+	Timestamp   int64                         `json:"timestamp"`
+	Signature   zkidentity.FixedSizeSignature `json:"signature"`
+	GC          *zkidentity.ShortID           `json:"gc,omitempty"`
+	Payee       *zkidentity.ShortID           `json:"payee,omitempty"`
+	Fiat        *RMFiatConversion             `json:"fiat,omitempty"`
+}
+
+// RMFiatConversion records the conversion to DCR of a payment denominated in
+// a fiat currency. Rate is the price of one DCR in the currency at the time
+// (Timestamp) the payment was started.
+type RMFiatConversion struct {
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	Rate      float64 `json:"rate"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// ReceiptHash calculates the hash of the receipt info, to be signed by the
+// payer.
+func (pr *RMPaymentReceipt) ReceiptHash(payer, payee zkidentity.ShortID) [32]byte {
+	h := sha256.New()
+	var b [32]byte
+
+	writeUint64 := func(i uint64) {
+		binary.LittleEndian.PutUint64(b[:], i)
+		h.Write(b[:8])
+	}
+
+	h.Write([]byte("paymentreceipt"))
+	h.Write(payer[:])
+	h.Write(payee[:])
+	writeUint64(uint64(len(pr.Kind)))
+	h.Write([]byte(pr.Kind))
+	writeUint64(uint64(pr.MilliAtoms))
+	writeUint64(uint64(len(pr.PaymentHash)))
+	h.Write(pr.PaymentHash)
+	writeUint64(uint64(pr.Timestamp))
+
+	// The GC is only hashed when set, so that the hash of receipts without
+	// a GC is unchanged.
+	if pr.GC != nil {
+		h.Write(pr.GC[:])
+	}
+	if pr.Fiat != nil {
+		h.Write([]byte("fiat"))
+		writeUint64(uint64(len(pr.Fiat.Currency)))
+		h.Write([]byte(pr.Fiat.Currency))
+		writeUint64(math.Float64bits(pr.Fiat.Amount))
+		writeUint64(math.Float64bits(pr.Fiat.Rate))
+		writeUint64(uint64(pr.Fiat.Timestamp))
+	}
+
+	copy(b[:], h.Sum(nil))
+	return b
+}
+
+// RMCEscrowRequest is the command to request an escrowed payment to a remote
+// user.
+const RMCEscrowRequest = "escrowrequest"
+
+// RMEscrowRequest is sent by the buyer of an escrowed payment to the seller.
+// PaymentHash is the hash of a preimage only known by the buyer, such that the
+// seller may only settle the payment after the buyer releases the preimage.
+type RMEscrowRequest struct {
+	ID          zkidentity.ShortID `json:"id"`
+	PaymentHash []byte             `json:"payment_hash"`
+	MilliAtoms  int64              `json:"milli_atoms"`
+	Description string             `json:"description"`
+}
+
+// RMCEscrowInvoice is the reply to RMCEscrowRequest.
+const RMCEscrowInvoice = "escrowinvoice"
+
+// RMEscrowInvoice is the hold invoice generated by the seller for the payment
+// hash of an escrow.
+type RMEscrowInvoice struct {
+	ID      zkidentity.ShortID `json:"id"`
+	Invoice string             `json:"invoice"`
+	Error   *string            `json:"error,omitempty"`
+}
+
+// RMCEscrowFulfilled is the command sent by the seller of an escrow once the
+// order it pays for was fulfilled.
+const RMCEscrowFulfilled = "escrowfulfilled"
+
+// RMEscrowFulfilled notifies the buyer that the seller fulfilled the escrow.
+type RMEscrowFulfilled struct {
+	ID   zkidentity.ShortID `json:"id"`
+	Note string             `json:"note,omitempty"`
+}
+
+// RMCEscrowRelease is the command sent by the buyer of an escrow to release
+// the held payment to the seller.
+const RMCEscrowRelease = "escrowrelease"
+
+// RMEscrowRelease releases the preimage of the payment hash of the escrow, so
+// that the seller may settle the held payment.
+type RMEscrowRelease struct {
+	ID       zkidentity.ShortID `json:"id"`
+	Preimage []byte             `json:"preimage"`
+}
+
+// RMCEscrowCancel is the command to notify the remote user that an escrow was
+// canceled.
+const RMCEscrowCancel = "escrowcancel"
+
+// RMEscrowCancel notifies that an escrow was canceled.
+type RMEscrowCancel struct {
+	ID     zkidentity.ShortID `json:"id"`
+	Reason string             `json:"reason"`
+}
+
 const RMCKXSuggestion = "kxsuggestion"
 
 type RMKXSuggestion struct {
 	Target zkidentity.PublicIdentity
 }
 
+const RMCIdentityMigration = "identitymigration"
+
+// RMIdentityMigration is sent by a user to their contacts to announce they
+// moved to a new identity. If NewIdentity is nil, this announces that the
+// identity of the sender is revoked (for example, because it was compromised)
+// and should no longer be trusted.
+//
+// Before being announced, a migration is sent by the old identity to the new
+// identity, which sends it back with NewIdentitySignature filled.
+//
+// Signature is the signature of MigrationHash made with the signing key of
+// the old identity. NewIdentitySignature is the signature of MigrationHash
+// made with the signing key of NewIdentity, which proves the new identity
+// agreed to the migration.
+type RMIdentityMigration struct {
+	NewIdentity          *zkidentity.PublicIdentity    `json:"new_identity,omitempty"`
+	Reason               string                        `json:"reason"`
+	Timestamp            int64                         `json:"timestamp"`
+	Signature            zkidentity.FixedSizeSignature `json:"signature"`
+	NewIdentitySignature zkidentity.FixedSizeSignature `json:"new_identity_signature"`
+}
+
+// MigrationHash calculates the hash of the migration info, to be signed by the
+// old and new identities.
+func (im *RMIdentityMigration) MigrationHash(oldID zkidentity.ShortID) [32]byte {
+	h := sha256.New()
+	var b [32]byte
+
+	writeUint64 := func(i uint64) {
+		binary.LittleEndian.PutUint64(b[:], i)
+		h.Write(b[:8])
+	}
+
+	h.Write([]byte("identitymigration"))
+	h.Write(oldID[:])
+	if im.NewIdentity != nil {
+		h.Write(im.NewIdentity.Identity[:])
+		h.Write(im.NewIdentity.SigKey[:])
+		h.Write(im.NewIdentity.Key[:])
+	}
+	writeUint64(uint64(len(im.Reason)))
+	h.Write([]byte(im.Reason))
+	writeUint64(uint64(im.Timestamp))
+
+	copy(b[:], h.Sum(nil))
+	return b
+}
+
 type ResourceTag uint64
 
 func (tag ResourceTag) String() string {
@@ -248,6 +490,36 @@ func ComposeCompressedRM(fromSigner MessageSigner, rm interface{}, zlibLevel int
 	case RMInvoice:
 		h.Command = RMCInvoice
 
+	case RMKeysendTip:
+		h.Command = RMCKeysendTip
+
+	case RMGetOnchainAddress:
+		h.Command = RMCGetOnchainAddress
+
+	case RMOnchainAddress:
+		h.Command = RMCOnchainAddress
+
+	case RMOnchainTipReceipt:
+		h.Command = RMCOnchainTipReceipt
+
+	case RMPaymentReceipt:
+		h.Command = RMCPaymentReceipt
+
+	case RMEscrowRequest:
+		h.Command = RMCEscrowRequest
+
+	case RMEscrowInvoice:
+		h.Command = RMCEscrowInvoice
+
+	case RMEscrowFulfilled:
+		h.Command = RMCEscrowFulfilled
+
+	case RMEscrowRelease:
+		h.Command = RMCEscrowRelease
+
+	case RMEscrowCancel:
+		h.Command = RMCEscrowCancel
+
 	case RMTransitiveMessage:
 		h.Command = RMCTransitiveMessage
 
@@ -266,9 +538,33 @@ func ComposeCompressedRM(fromSigner MessageSigner, rm interface{}, zlibLevel int
 	case RMKXSuggestion:
 		h.Command = RMCKXSuggestion
 
+	case RMIdentityMigration:
+		h.Command = RMCIdentityMigration
+
 	case RMProfileUpdate:
 		h.Command = RMCProfileUpdate
 
+	case RMEditMessage:
+		h.Command = RMCEditMessage
+
+	case RMMessageReaction:
+		h.Command = RMCMessageReaction
+
+	case RMDeviceSyncRequest:
+		h.Command = RMCDeviceSyncRequest
+
+	case RMDeviceSync:
+		h.Command = RMCDeviceSync
+
+	case RMDeviceSyncUpdate:
+		h.Command = RMCDeviceSyncUpdate
+
+	case RMDeviceRelay:
+		h.Command = RMCDeviceRelay
+
+	case RMDeviceHandoff:
+		h.Command = RMCDeviceHandoff
+
 	// Handshake
 	case RMHandshakeSYN:
 		h.Command = RMCHandshakeSYN
@@ -305,6 +601,12 @@ func ComposeCompressedRM(fromSigner MessageSigner, rm interface{}, zlibLevel int
 	case RMGroupMessage:
 		h.Command = RMCGroupMessage
 
+	case RMGroupUnkxdMention:
+		h.Command = RMCGroupUnkxdMention
+
+	case RMGroupHistory:
+		h.Command = RMCGroupHistory
+
 	// File transfer
 	case RMFTList:
 		h.Command = RMCFTList
@@ -330,6 +632,9 @@ func ComposeCompressedRM(fromSigner MessageSigner, rm interface{}, zlibLevel int
 	case RMFTSendFile:
 		h.Command = RMCFTSendFile
 
+	case RMFTSwarmHave:
+		h.Command = RMCFTSwarmHave
+
 	// User
 	case RMUser:
 		h.Command = RMCUser
@@ -520,6 +825,56 @@ func DecomposeRM(msgVerifier MessageVerifier, mb []byte, maxDecompressSize uint)
 		err = pmd.Decode(&inv)
 		payload = inv
 
+	case RMCKeysendTip:
+		var tip RMKeysendTip
+		err = pmd.Decode(&tip)
+		payload = tip
+
+	case RMCGetOnchainAddress:
+		var getAddr RMGetOnchainAddress
+		err = pmd.Decode(&getAddr)
+		payload = getAddr
+
+	case RMCOnchainAddress:
+		var addr RMOnchainAddress
+		err = pmd.Decode(&addr)
+		payload = addr
+
+	case RMCOnchainTipReceipt:
+		var receipt RMOnchainTipReceipt
+		err = pmd.Decode(&receipt)
+		payload = receipt
+
+	case RMCPaymentReceipt:
+		var receipt RMPaymentReceipt
+		err = pmd.Decode(&receipt)
+		payload = receipt
+
+	case RMCEscrowRequest:
+		var req RMEscrowRequest
+		err = pmd.Decode(&req)
+		payload = req
+
+	case RMCEscrowInvoice:
+		var inv RMEscrowInvoice
+		err = pmd.Decode(&inv)
+		payload = inv
+
+	case RMCEscrowFulfilled:
+		var fulfilled RMEscrowFulfilled
+		err = pmd.Decode(&fulfilled)
+		payload = fulfilled
+
+	case RMCEscrowRelease:
+		var release RMEscrowRelease
+		err = pmd.Decode(&release)
+		payload = release
+
+	case RMCEscrowCancel:
+		var cancel RMEscrowCancel
+		err = pmd.Decode(&cancel)
+		payload = cancel
+
 	case RMCTransitiveMessage:
 		var transitiveMessage RMTransitiveMessage
 		err = pmd.Decode(&transitiveMessage)
@@ -550,11 +905,51 @@ func DecomposeRM(msgVerifier MessageVerifier, mb []byte, maxDecompressSize uint)
 		err = pmd.Decode(&kxsg)
 		payload = kxsg
 
+	case RMCIdentityMigration:
+		var im RMIdentityMigration
+		err = pmd.Decode(&im)
+		payload = im
+
 	case RMCProfileUpdate:
 		var rmpu RMProfileUpdate
 		err = pmd.Decode(&rmpu)
 		payload = rmpu
 
+	case RMCEditMessage:
+		var rmem RMEditMessage
+		err = pmd.Decode(&rmem)
+		payload = rmem
+
+	case RMCMessageReaction:
+		var rmmr RMMessageReaction
+		err = pmd.Decode(&rmmr)
+		payload = rmmr
+
+	case RMCDeviceSyncRequest:
+		var rmdsr RMDeviceSyncRequest
+		err = pmd.Decode(&rmdsr)
+		payload = rmdsr
+
+	case RMCDeviceSync:
+		var rmds RMDeviceSync
+		err = pmd.Decode(&rmds)
+		payload = rmds
+
+	case RMCDeviceSyncUpdate:
+		var rmdsu RMDeviceSyncUpdate
+		err = pmd.Decode(&rmdsu)
+		payload = rmdsu
+
+	case RMCDeviceRelay:
+		var rmdr RMDeviceRelay
+		err = pmd.Decode(&rmdr)
+		payload = rmdr
+
+	case RMCDeviceHandoff:
+		var rmdh RMDeviceHandoff
+		err = pmd.Decode(&rmdh)
+		payload = rmdh
+
 	// Handshake
 	case RMCHandshakeSYN:
 		var hshk RMHandshakeSYN
@@ -612,6 +1007,16 @@ func DecomposeRM(msgVerifier MessageVerifier, mb []byte, maxDecompressSize uint)
 		err = pmd.Decode(&groupList)
 		payload = groupList
 
+	case RMCGroupUnkxdMention:
+		var groupMention RMGroupUnkxdMention
+		err = pmd.Decode(&groupMention)
+		payload = groupMention
+
+	case RMCGroupHistory:
+		var groupHistory RMGroupHistory
+		err = pmd.Decode(&groupHistory)
+		payload = groupHistory
+
 	// File transfer
 	case RMCFTList:
 		var ftList RMFTList
@@ -653,6 +1058,11 @@ func DecomposeRM(msgVerifier MessageVerifier, mb []byte, maxDecompressSize uint)
 		err = pmd.Decode(&ftSendFile)
 		payload = ftSendFile
 
+	case RMCFTSwarmHave:
+		var ftSwarmHave RMFTSwarmHave
+		err = pmd.Decode(&ftSwarmHave)
+		payload = ftSwarmHave
+
 	case RMCGroupMessage:
 		var groupMessage RMGroupMessage
 		err = pmd.Decode(&groupMessage)
@@ -830,6 +1240,10 @@ type RMGroupJoin struct {
 	ID    zkidentity.ShortID `json:"id"`    // group id
 	Token uint64             `json:"token"` // invite token, implicitly identifies sender
 	Error string             `json:"error"` // accept or deny Invite
+
+	// LinkID is set when joining the group through an invite link
+	// instead of a direct invite. In that case, Token is ignored.
+	LinkID *zkidentity.ShortID `json:"link_id,omitempty"`
 }
 
 const RMCGroupJoin = "groupjoin"
@@ -893,6 +1307,148 @@ type RMGroupList struct {
 	// ExtraAdmins are additional admins. Members[0] is still considered
 	// an admin in version 1 GCs.
 	ExtraAdmins []zkidentity.ShortID `json:"extra_admins"`
+
+	// Version 2 fields.
+
+	// Moderators are members with a moderation role, which is below the
+	// admins.
+	Moderators []zkidentity.ShortID `json:"moderators,omitempty"`
+
+	// AdminPerms and ModeratorPerms are the permissions of the members
+	// in the ExtraAdmins and Moderators lists. Members[0] (the owner) has
+	// all permissions.
+	AdminPerms     GCPermission `json:"admin_perms,omitempty"`
+	ModeratorPerms GCPermission `json:"moderator_perms,omitempty"`
+
+	// Pinned is the list of IDs of pinned GC messages.
+	Pinned []zkidentity.ShortID `json:"pinned,omitempty"`
+
+	// Banned is the list of users that were banned from the GC. Banned
+	// users cannot be members of the GC.
+	Banned []zkidentity.ShortID `json:"banned,omitempty"`
+
+	// SlowModeInterval is the min interval (in seconds) between messages
+	// sent by each member without a moderation role. Zero disables slow
+	// mode.
+	SlowModeInterval uint32 `json:"slow_mode_interval,omitempty"`
+
+	// Topic is a short line describing the current topic of the GC.
+	Topic string `json:"topic,omitempty"`
+
+	// Description is a markdown description of the GC.
+	Description string `json:"description,omitempty"`
+
+	// Avatar is the avatar image of the GC.
+	Avatar []byte `json:"avatar,omitempty"`
+
+	// Channels are the named channels of the GC. Messages not sent to any
+	// channel belong to the main channel of the GC.
+	Channels []GCChannel `json:"channels,omitempty"`
+
+	// MaxMembers is the max number of members (including the owner) of
+	// the GC. Zero means the number of members is not limited.
+	MaxMembers uint32 `json:"max_members,omitempty"`
+
+	// JoinApproval is set when requests to join the GC through invite
+	// links must be approved by the issuer of the link before the user
+	// is added to the GC.
+	JoinApproval bool `json:"join_approval,omitempty"`
+}
+
+// GCChannel is a named channel inside a GC. Channels share the membership of
+// the GC.
+type GCChannel struct {
+	ID   zkidentity.ShortID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// MaxGCChannels is the max number of channels in a GC.
+const MaxGCChannels = 32
+
+// MaxGCChannelNameSize is the max size of the name of a GC channel.
+const MaxGCChannelNameSize = 32
+
+// MaxGCTopicSize is the max size of the topic of a GC.
+const MaxGCTopicSize = 140
+
+// MaxGCDescriptionSize is the max size of the description of a GC.
+const MaxGCDescriptionSize = 4096
+
+// MaxGCAvatarSize is the max size of the avatar of a GC. This is smaller than
+// the max size of user avatars because the avatar is sent along with every
+// update to the GC list.
+const MaxGCAvatarSize = 64 * 1024 // 64KiB
+
+// GCPermission is a set of permissions granted to a moderation role of a GC.
+type GCPermission uint32
+
+const (
+	// GCPermInvite is the permission to invite new members to the GC.
+	GCPermInvite GCPermission = 1 << iota
+
+	// GCPermKick is the permission to kick members (of a lower role)
+	// from the GC.
+	GCPermKick
+
+	// GCPermPin is the permission to pin and unpin messages.
+	GCPermPin
+
+	// GCPermMetadata is the permission to change the metadata (such as
+	// the name) of the GC.
+	GCPermMetadata
+
+	// GCPermAll are all permissions.
+	GCPermAll = GCPermInvite | GCPermKick | GCPermPin | GCPermMetadata
+)
+
+// Has returns true if all the permissions of perm are in p.
+func (p GCPermission) Has(perm GCPermission) bool {
+	return p&perm == perm
+}
+
+// String returns a comma-separated list of the permissions.
+func (p GCPermission) String() string {
+	var perms []string
+	if p.Has(GCPermInvite) {
+		perms = append(perms, "invite")
+	}
+	if p.Has(GCPermKick) {
+		perms = append(perms, "kick")
+	}
+	if p.Has(GCPermPin) {
+		perms = append(perms, "pin")
+	}
+	if p.Has(GCPermMetadata) {
+		perms = append(perms, "metadata")
+	}
+	if len(perms) == 0 {
+		return "none"
+	}
+	return strings.Join(perms, ",")
+}
+
+// ParseGCPermission parses a comma-separated list of permissions, as returned
+// by GCPermission.String().
+func ParseGCPermission(s string) (GCPermission, error) {
+	var p GCPermission
+	for _, perm := range strings.Split(s, ",") {
+		switch strings.TrimSpace(perm) {
+		case "invite":
+			p |= GCPermInvite
+		case "kick":
+			p |= GCPermKick
+		case "pin":
+			p |= GCPermPin
+		case "metadata":
+			p |= GCPermMetadata
+		case "all":
+			p |= GCPermAll
+		case "none", "":
+		default:
+			return 0, fmt.Errorf("unknown GC permission %q", perm)
+		}
+	}
+	return p, nil
 }
 
 const RMCGroupList = "grouplist"
@@ -903,10 +1459,52 @@ type RMGroupMessage struct {
 	Generation uint64             `json:"generation"` // Generation used
 	Message    string             `json:"message"`    // Actual message
 	Mode       MessageMode        `json:"mode"`       // 0 regular mode, 1 /me
+
+	// MsgID is an ID chosen by the sender to identify the message in
+	// later edits. May be nil for messages sent by older clients.
+	MsgID *zkidentity.ShortID `json:"msgid,omitempty"`
+
+	// ReplyTo is the ID of the message this message is a reply to.
+	ReplyTo *zkidentity.ShortID `json:"replyto,omitempty"`
+
+	// Mentions is the list of members mentioned (with @nick) in the
+	// message.
+	Mentions []zkidentity.ShortID `json:"mentions,omitempty"`
+
+	// Channel is the ID of the GC channel the message was sent to. If
+	// nil, the message was sent to the main channel of the GC.
+	Channel *zkidentity.ShortID `json:"channel,omitempty"`
+
+	// Bridged is set when the message was relayed from another GC by a
+	// member that bridges both GCs. Bridged messages are never relayed
+	// again, which prevents loops between bridges.
+	Bridged bool `json:"bridged,omitempty"`
 }
 
 const RMCGroupMessage = "groupmessage"
 
+// RMGroupUnkxdMention is sent by a GC admin to a GC member that was mentioned
+// in a message by another member that could not identify them (which means
+// the two members have likely not KX'd with each other).
+type RMGroupUnkxdMention struct {
+	ID      zkidentity.ShortID `json:"id"`      // group name
+	Sender  zkidentity.ShortID `json:"sender"`  // Member that sent the message
+	Message string             `json:"message"` // Message with the mention
+}
+
+const RMCGroupUnkxdMention = "groupunkxdmention"
+
+// RMGroupHistory is sent by a GC member to a newly joined member to announce
+// that a backlog of recent GC messages was shared with them. The backlog is
+// fetched as a regular shared file.
+type RMGroupHistory struct {
+	ID     zkidentity.ShortID `json:"id"`      // group name
+	FileID string             `json:"file_id"` // ID of the shared file
+	Count  int                `json:"count"`   // Number of messages
+}
+
+const RMCGroupHistory = "grouphistory"
+
 // RMFTList asks other side for a list of files. Directories are constants that
 // describe which directories it should access. Currently only "global" and
 // "shared" are allowed.
@@ -940,13 +1538,64 @@ type FileMetadata struct {
 	Manifest    []FileManifest    `json:"manifest"` // len == number of chunks
 	Signature   string            `json:"signature"`
 	Attributes  map[string]string `json:"attributes,omitempty"`
+
+	// MerkleRoot is the hex-encoded root of the merkle tree of the chunks
+	// in Manifest. It is included in the metadata hash starting with
+	// version 2, so that the manifest (and thus each chunk) of a file may
+	// be verified against its id.
+	//
+	// Clients that only support version 1 do not include it in the hash,
+	// so they calculate a different id (see LegacyMetadataHash) for
+	// version 2 files.
+	MerkleRoot string `json:"merkle_root,omitempty"`
+
+	// Preview and Tiers are not included in the metadata hash, so that the
+	// pricing of a shared file may be changed without changing its id.
+	Preview *FilePreview    `json:"preview,omitempty"`
+	Tiers   []FilePriceTier `json:"tiers,omitempty"`
+}
+
+// FilePreview describes a free preview of a shared file.
+type FilePreview struct {
+	// Size is the number of bytes at the start of the file that may be
+	// downloaded for free. Only chunks entirely within this range are
+	// free.
+	Size uint64 `json:"size,omitempty"`
+
+	// FID is the id of a separate, free file, shared with the same users,
+	// with a preview of the contents of the file.
+	FID string `json:"fid,omitempty"`
 }
 
-const FileMetadataVersion = 1
+// FilePriceTier is the price of a range of a shared file. Tiers are sorted by
+// End and each tier covers the range from the end of the previous tier (or the
+// start of the file) up to (but not including) End.
+type FilePriceTier struct {
+	Name string `json:"name,omitempty"`
+	End  uint64 `json:"end"`
+	Cost uint64 `json:"cost"` // Cost of the entire range, in atoms.
+}
+
+const FileMetadataVersion = 2
 
 // MetadataHash calculates the hash of the metadata info. Note that the specific
 // information that is hashed depends on the version of the metadata.
 func (fm *FileMetadata) MetadataHash() [32]byte {
+	return fm.metadataHash(fm.Version)
+}
+
+// LegacyMetadataHash calculates the hash of the metadata info as done by
+// clients that only support version 1 of the metadata. Sharers accept this
+// hash as an alias for the file id, so that these clients may still download
+// files with newer metadata versions. For version 1 metadata, this is the same
+// as MetadataHash.
+func (fm *FileMetadata) LegacyMetadataHash() [32]byte {
+	return fm.metadataHash(1)
+}
+
+// metadataHash calculates the hash of the metadata, including only the fields
+// defined up to hashVersion.
+func (fm *FileMetadata) metadataHash(hashVersion uint64) [32]byte {
 	h := sha256.New()
 	var b [32]byte
 
@@ -967,11 +1616,79 @@ func (fm *FileMetadata) MetadataHash() [32]byte {
 
 	// In the future, add new fields conditional on the metadata version so
 	// that old versions will still calculate the same hash.
+	if hashVersion >= 2 {
+		writeStr(fm.MerkleRoot)
+	}
 
 	copy(b[:], h.Sum(nil))
 	return b
 }
 
+// FileManifestMerkleRoot returns the root of the merkle tree of the chunks of
+// a file. The leaves of the tree commit to the index, size and hash of each
+// chunk. Nodes without a sibling are promoted to the next level of the tree.
+func FileManifestMerkleRoot(manifest []FileManifest) [32]byte {
+	if len(manifest) == 0 {
+		return sha256.Sum256(nil)
+	}
+
+	var b [8]byte
+	level := make([][32]byte, len(manifest))
+	for i, m := range manifest {
+		h := sha256.New()
+		h.Write([]byte{0x00})
+		binary.LittleEndian.PutUint64(b[:], m.Index)
+		h.Write(b[:])
+		binary.LittleEndian.PutUint64(b[:], m.Size)
+		h.Write(b[:])
+		h.Write(m.Hash)
+		copy(level[i][:], h.Sum(nil))
+	}
+
+	for len(level) > 1 {
+		next := level[:0]
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			h := sha256.New()
+			h.Write([]byte{0x01})
+			h.Write(level[i][:])
+			h.Write(level[i+1][:])
+			var node [32]byte
+			copy(node[:], h.Sum(nil))
+			next = append(next, node)
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// VerifyManifest verifies the manifest of the file against its merkle root.
+// Metadata of versions prior to 2 does not include a merkle root, thus its
+// manifest cannot be verified.
+func (fm *FileMetadata) VerifyManifest() error {
+	if fm.Version < 2 {
+		return nil
+	}
+	for i, m := range fm.Manifest {
+		if m.Index != uint64(i) {
+			return fmt.Errorf("manifest entry %d has index %d", i, m.Index)
+		}
+		if len(m.Hash) != sha256.Size {
+			return fmt.Errorf("manifest entry %d has invalid hash "+
+				"length %d", i, len(m.Hash))
+		}
+	}
+	root := FileManifestMerkleRoot(fm.Manifest)
+	if hex.EncodeToString(root[:]) != fm.MerkleRoot {
+		return fmt.Errorf("manifest does not match merkle root %q",
+			fm.MerkleRoot)
+	}
+	return nil
+}
+
 type RMFTListReply struct {
 	Global []FileMetadata `json:"global,omitempty"`
 	Shared []FileMetadata `json:"shared,omitempty"`
@@ -1038,6 +1755,28 @@ type RMFTSendFile struct {
 
 const RMCFTSendFile = "ftsendfile"
 
+// RMFTSwarmHave announces the chunks of a file that a downloader which opted
+// into re-sharing the file has available.
+//
+// Downloaders send it to the publisher of the file. The publisher relays it
+// (with Member filled) to the other downloaders of the file that opted into
+// re-sharing it, so that they may fetch chunks from one another.
+type RMFTSwarmHave struct {
+	FileID string `json:"file_id"`
+
+	// Member is the downloader that has the chunks. It is only filled in
+	// announcements relayed by the publisher.
+	Member *zkidentity.ShortID `json:"member,omitempty"`
+
+	// Chunks are the indexes of the chunks available in the member.
+	Chunks []int `json:"chunks,omitempty"`
+
+	// Leave is set when the member stopped re-sharing the file.
+	Leave bool `json:"leave,omitempty"`
+}
+
+const RMCFTSwarmHave = "ftswarmhave"
+
 // RMUser retrieves user attributes such as status, profile etc. Attributes is a
 // key value store that is used to describe the user attributes.
 type RMUser struct{}
@@ -1067,6 +1806,10 @@ type PostListItem struct {
 
 type RMListPostsReply struct {
 	Posts []PostListItem `json:"posts"`
+
+	// Pinned are the posts pinned by the author. These are also included
+	// in Posts.
+	Pinned []PostListItem `json:"pinned,omitempty"`
 }
 
 const RMCListPostsReply = "listpostsreply"
@@ -1097,8 +1840,28 @@ const (
 	RMPSComment  = "comment" // Comment on a post
 	RMPSHeartYes = "1"       // +1 heart
 	RMPSHeartNo  = "0"       // -1 heart
+
+	// RMPSReaction adds a reaction (such as an emoji) to a post, while
+	// RMPSReactionRemove removes a previously added one. The value of
+	// either attribute is the reaction itself.
+	RMPSReaction       = "reaction"
+	RMPSReactionRemove = "reactionremove"
+
+	// RMPSModeration is a moderation action taken by the post author on
+	// the comment identified by RMPSModerationTarget.
+	RMPSModeration       = "moderation"
+	RMPSModerationTarget = "moderationtarget"
+
+	RMPSModerationHide   = "hide"   // Hide a comment
+	RMPSModerationUnhide = "unhide" // Show a previously hidden comment
+	RMPSModerationFlag   = "flag"   // Flag a comment
+	RMPSModerationUnflag = "unflag" // Remove the flag from a comment
 )
 
+// MaxPostReactionsPerUser is the maximum number of distinct reactions a single
+// user may have on a post at any one time.
+const MaxPostReactionsPerUser = 8
+
 // RMPostSubscribe subscribes to new posts from a user.
 type RMPostsSubscribe struct {
 	// GetPost is an optional post to send to the client, assuming
@@ -1163,8 +1926,101 @@ const (
 	RMPNonce       = "nonce"       // Random nonce to avoid equal hashes
 	RMPFromNick    = "from_nick"   // Nick of origin for post/status
 	RMPTimestamp   = "timestamp"   // Timestamp of the status update
+	RMPTags        = "tags"        // Comma-separated list of post tags
+	RMPPaywall     = "paywall"     // File ID of the paywalled post body
+	RMPPinned      = "pinned"      // Set by the author on pinned posts
+
+	RMPRelayComment   = "relaycomment"   // Commentary of the relayer of a post
+	RMPRelaySignature = "relaysignature" // Relayer's signature of the commentary
 )
 
+// MaxPostRelayCommentLen is the max length of the commentary attached to a
+// relayed post.
+const MaxPostRelayCommentLen = 4096
+
+// PostRelayCommentHash returns the hash signed by the relayer of a post to
+// attest to the commentary attached to the relayed post.
+//
+// The commentary and its signature are not part of the post hash, so relaying
+// a post with commentary does not change its id.
+func PostRelayCommentHash(pid [32]byte, comment string) [32]byte {
+	h := blake256.New()
+	h.Write([]byte(RMPRelayComment))
+	h.Write(pid[:])
+	h.Write([]byte(comment))
+	var b [32]byte
+	copy(b[:], h.Sum(nil))
+	return b
+}
+
+const (
+	// MaxPostTags is the max number of tags in a post.
+	MaxPostTags = 16
+
+	// MaxPostTagLen is the max length of an individual post tag.
+	MaxPostTagLen = 32
+)
+
+// NormalizePostTag returns the canonical form of a post tag: lowercased and
+// without surrounding whitespace or a leading '#'.
+func NormalizePostTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// validPostTag returns true if the (normalized) tag is composed only of
+// letters, digits, '-' and '_'.
+func validPostTag(tag string) bool {
+	if tag == "" || len(tag) > MaxPostTagLen {
+		return false
+	}
+	for _, r := range tag {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// FormatPostTags normalizes the list of tags and returns it encoded as the
+// value of the RMPTags post attribute. Duplicated tags are removed.
+func FormatPostTags(tags []string) (string, error) {
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizePostTag(tag)
+		if !validPostTag(tag) {
+			return "", fmt.Errorf("invalid post tag %q", tag)
+		}
+		if slices.Contains(res, tag) {
+			continue
+		}
+		res = append(res, tag)
+	}
+	if len(res) > MaxPostTags {
+		return "", fmt.Errorf("too many post tags (%d > %d)", len(res),
+			MaxPostTags)
+	}
+	return strings.Join(res, ","), nil
+}
+
+// ParsePostTags parses the value of the RMPTags post attribute. Invalid and
+// duplicated tags are ignored, as well as tags beyond MaxPostTags.
+func ParsePostTags(s string) []string {
+	var res []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = NormalizePostTag(tag)
+		if !validPostTag(tag) || slices.Contains(res, tag) {
+			continue
+		}
+		res = append(res, tag)
+		if len(res) == MaxPostTags {
+			break
+		}
+	}
+	return res
+}
+
 type PostMetadata struct {
 	Version    uint64            `json:"version"`
 	Attributes map[string]string `json:"attributes,omitempty"`
@@ -1192,6 +2048,9 @@ func (pm *PostMetadata) Hash() [32]byte {
 
 	// Gate newer fields with a version check to ensure older copies of the
 	// metadata still hash to the same value.
+	//
+	// RMPTags is not part of the hash, so that tagged posts can still be
+	// verified by clients that do not know about tags.
 	copy(b[:], h.Sum(nil))
 	return b
 }
@@ -1228,6 +2087,17 @@ func (pm *PostMetadataStatus) Hash() [32]byte {
 	wattr(RMPSComment)
 	wattr(RMPNonce)
 
+	// Reactions and moderation actions are only written when present, so
+	// that older status updates still hash to the same value. The key is
+	// included to differentiate between the attributes.
+	for _, key := range []string{RMPSReaction, RMPSReactionRemove,
+		RMPSModeration, RMPSModerationTarget} {
+		if v := pm.Attributes[key]; v != "" {
+			h.Write([]byte(key))
+			h.Write([]byte(v))
+		}
+	}
+
 	// RMPFromNick is not added because it's filled by post sharer.
 
 	// RMPTimestamp is not added because it's undecided which timestamp
@@ -1235,6 +2105,9 @@ func (pm *PostMetadataStatus) Hash() [32]byte {
 
 	// Gate newer fields with a version check to ensure older copies of the
 	// metadata still hash to the same value.
+	//
+	// RMPTags is not part of the hash, so that tagged posts can still be
+	// verified by clients that do not know about tags.
 	copy(b[:], h.Sum(nil))
 	return b
 }
@@ -1246,9 +2119,11 @@ const PostMetadataStatusVersion = 1
 func IsPostStatus(attrs map[string]string) bool {
 	// The current version of post status does not have a differentiating
 	// entry between status and post, so we infer based on the presence of
-	// either a comment or heart entry, which are the currently supported
-	// status updates.
-	return attrs[RMPSComment] != "" || attrs[RMPSHeart] != ""
+	// either a comment, heart, reaction or moderation entry, which are the
+	// currently supported status updates.
+	return attrs[RMPSComment] != "" || attrs[RMPSHeart] != "" ||
+		attrs[RMPSReaction] != "" || attrs[RMPSReactionRemove] != "" ||
+		attrs[RMPSModeration] != ""
 }
 
 // RMReceiptDomain are the valid read receipt domains.
@@ -1257,6 +2132,7 @@ type RMReceiptDomain string
 const (
 	ReceiptDomainPosts        RMReceiptDomain = "posts"
 	ReceiptDomainPostComments RMReceiptDomain = "postcomments"
+	ReceiptDomainMessages     RMReceiptDomain = "msgs"
 )
 
 // RMReceiveReceipt is a receipt sent by a client when it has received an RM
@@ -1268,12 +2144,14 @@ type RMReceiveReceipt struct {
 	// ID is the main ID of the receipt. Depends on the domain.
 	//
 	// post, postcomments : ID of the post.
+	// msgs: ID of the PM or GC message.
 	ID *zkidentity.ShortID
 
 	// SubID is the secondary id of the receipt. Depends on the domain.
 	//
 	// posts: nil
 	// postcomments: ID of the comment.
+	// msgs: ID of the GC (for GC messages) or nil (for PMs).
 	SubID *zkidentity.ShortID
 
 	// ClientTime is the unix millisecond timestamp of when the client
@@ -1290,7 +2168,189 @@ type RMProfileUpdate struct {
 	// Avatar is the user's avatar. If set to nil, the avatar is not
 	// updated. If set to an empty slice, the avatar is cleared.
 	Avatar []byte `json:"avatar"`
+
+	// Name is the user's display name. If nil, the name is not updated.
+	Name *string `json:"name,omitempty"`
+
+	// Description is the user's profile description. If nil, the
+	// description is not updated. If set to an empty string, the
+	// description is cleared.
+	Description *string `json:"description,omitempty"`
+
+	// Status is the user's status line. If nil, the status is not
+	// updated. If set to an empty string, the status is cleared.
+	Status *string `json:"status,omitempty"`
 }
 
 // RMCProfileUpdate is the command for a RMProfileUpdate.
 const RMCProfileUpdate = "profileupdt"
+
+// MaxAvatarSize is the max size of an avatar sent in a profile update. This is
+// restricted to ensure invites that include the avatar fit in a single server
+// message.
+const MaxAvatarSize = 200 * 1024 // 200KiB
+
+// MaxNameSize is the max size of a name sent in a profile update.
+const MaxNameSize = 256
+
+// MaxDescriptionSize is the max size of a description sent in a profile
+// update.
+const MaxDescriptionSize = 1024
+
+// MaxStatusSize is the max size of a status line sent in a profile update.
+const MaxStatusSize = 140
+
+// RMEditMessage is sent by a client to replace the contents of a PM or GC
+// message it previously sent.
+type RMEditMessage struct {
+	// MsgID is the ID of the message being edited.
+	MsgID zkidentity.ShortID `json:"msgid"`
+
+	// GC is the ID of the GC where the message was sent. It is nil when
+	// editing a PM.
+	GC *zkidentity.ShortID `json:"gc,omitempty"`
+
+	// Message is the new contents of the message.
+	Message string `json:"message"`
+}
+
+// RMCEditMessage is the command for a RMEditMessage.
+const RMCEditMessage = "editmsg"
+
+// MaxReactionLen is the maximum length (in bytes) of a message reaction.
+const MaxReactionLen = 32
+
+// RMMessageReaction is sent by a client to react (usually with an emoji) to a
+// PM or GC message.
+type RMMessageReaction struct {
+	// MsgID is the ID of the message being reacted to.
+	MsgID zkidentity.ShortID `json:"msgid"`
+
+	// GC is the ID of the GC where the message was sent. It is nil when
+	// reacting to a PM.
+	GC *zkidentity.ShortID `json:"gc,omitempty"`
+
+	// Reaction is the reaction to the message.
+	Reaction string `json:"reaction"`
+
+	// Remove is set when the sender is removing a previous reaction.
+	Remove bool `json:"remove,omitempty"`
+}
+
+// RMCMessageReaction is the command for a RMMessageReaction.
+const RMCMessageReaction = "msgreaction"
+
+// RMDeviceSyncRequest is sent by a secondary device to its paired primary
+// device to request a new RMDeviceSync.
+//
+// The RMDevice* messages are only sent through the device link of paired
+// devices (which run with the same identity), never to remote users.
+type RMDeviceSyncRequest struct{}
+
+// RMCDeviceSyncRequest is the command for a RMDeviceSyncRequest.
+const RMCDeviceSyncRequest = "devicesyncrequest"
+
+// DeviceSyncContact is a contact of the primary device, sent during device
+// sync.
+type DeviceSyncContact struct {
+	ID   zkidentity.ShortID `json:"id"`
+	Nick string             `json:"nick"`
+}
+
+// DeviceSyncGC is a GC the primary device is a member of, sent during device
+// sync.
+type DeviceSyncGC struct {
+	ID   zkidentity.ShortID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// DeviceSyncMsg is a logged message of a conversation, sent during device
+// sync.
+type DeviceSyncMsg struct {
+	From      string `json:"from"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// DeviceSyncConvo is the recent history of a conversation (either with a user
+// or in a GC) of the primary device.
+type DeviceSyncConvo struct {
+	// UID is the ID of the remote user, for PM conversations.
+	UID *zkidentity.ShortID `json:"uid,omitempty"`
+
+	// GC is the ID of the GC, for GC conversations.
+	GC *zkidentity.ShortID `json:"gc,omitempty"`
+
+	Messages []DeviceSyncMsg `json:"messages"`
+}
+
+// RMDeviceSync is sent by a primary device to its paired secondary devices to
+// synchronize their state.
+type RMDeviceSync struct {
+	// Timestamp is the unix time when the sync was generated.
+	Timestamp int64 `json:"timestamp"`
+
+	Contacts []DeviceSyncContact `json:"contacts,omitempty"`
+	GCs      []DeviceSyncGC      `json:"gcs,omitempty"`
+	History  []DeviceSyncConvo   `json:"history,omitempty"`
+}
+
+// RMCDeviceSync is the command for a RMDeviceSync.
+const RMCDeviceSync = "devicesync"
+
+// RMDeviceSyncUpdate is sent by a device to its paired devices as its state
+// changes after the initial RMDeviceSync. Each update carries a single change.
+type RMDeviceSyncUpdate struct {
+	// Contact is a new contact of the device.
+	Contact *DeviceSyncContact `json:"contact,omitempty"`
+
+	// GC is a new GC the device is a member of.
+	GC *DeviceSyncGC `json:"gc,omitempty"`
+
+	// Convo has new messages sent or received in a PM or GC conversation
+	// of the device.
+	Convo *DeviceSyncConvo `json:"convo,omitempty"`
+}
+
+// RMCDeviceSyncUpdate is the command for a RMDeviceSyncUpdate.
+const RMCDeviceSyncUpdate = "devicesyncupdate"
+
+// RMDeviceRelay is sent by a secondary device to its paired primary device to
+// request that a message is sent on its behalf. Exactly one of UID or GC is
+// set.
+type RMDeviceRelay struct {
+	// UID is the ID of the remote user to send a PM to.
+	UID *zkidentity.ShortID `json:"uid,omitempty"`
+
+	// GC is the ID of the GC to send a message to.
+	GC *zkidentity.ShortID `json:"gc,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// RMCDeviceRelay is the command for a RMDeviceRelay.
+const RMCDeviceRelay = "devicerelay"
+
+// DeviceHandoffContact is a contact handed off from the primary device, along
+// with the state of its ratchet.
+type DeviceHandoffContact struct {
+	ID           zkidentity.PublicIdentity `json:"id"`
+	NickAlias    string                    `json:"nick_alias"`
+	MyResetRV    zkidentity.ShortID        `json:"my_reset_rv"`
+	TheirResetRV zkidentity.ShortID        `json:"their_reset_rv"`
+	Ignored      bool                      `json:"ignored"`
+	FirstCreated int64                     `json:"first_created"`
+	Ratchet      disk.RatchetState         `json:"ratchet"`
+}
+
+// RMDeviceHandoff is sent by a primary device to its paired secondary device
+// to hand off the ratchets with its contacts and its GCs. After the handoff,
+// the roles of the devices are swapped.
+type RMDeviceHandoff struct {
+	Contacts  []DeviceHandoffContact        `json:"contacts,omitempty"`
+	GCs       []RMGroupList                 `json:"gcs,omitempty"`
+	GCAliases map[string]zkidentity.ShortID `json:"gc_aliases,omitempty"`
+}
+
+// RMCDeviceHandoff is the command for a RMDeviceHandoff.
+const RMCDeviceHandoff = "devicehandoff"
@@ -3,9 +3,15 @@ package rpc
 import (
 	"bytes"
 	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/companyzero/bisonrelay/zkidentity"
 )
 
 //func TestComposeRM(t *testing.T) {
@@ -370,3 +376,292 @@ func TestMetadataStatusHash(t *testing.T) {
 		})
 	}
 }
+
+// TestMetadataStatusHashReactions asserts that adding and removing the same
+// reaction hash to different values.
+func TestMetadataStatusHashReactions(t *testing.T) {
+	add := PostMetadataStatus{
+		Version:    1,
+		Attributes: map[string]string{RMPSReaction: "👍"},
+	}
+	remove := PostMetadataStatus{
+		Version:    1,
+		Attributes: map[string]string{RMPSReactionRemove: "👍"},
+	}
+	empty := PostMetadataStatus{Version: 1}
+	if add.Hash() == remove.Hash() {
+		t.Fatalf("adding and removing a reaction have the same hash")
+	}
+	if add.Hash() == empty.Hash() || remove.Hash() == empty.Hash() {
+		t.Fatalf("reaction is not part of the hash")
+	}
+}
+
+// TestPostTags tests formatting and parsing post tags.
+func TestPostTags(t *testing.T) {
+	got, err := FormatPostTags([]string{"#Dev", " go ", "dev", "release_notes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "dev,go,release_notes"; got != want {
+		t.Fatalf("unexpected formatted tags: got %q, want %q", got, want)
+	}
+
+	invalid := [][]string{
+		{""},
+		{"#"},
+		{"two words"},
+		{"a,b"},
+		{strings.Repeat("x", MaxPostTagLen+1)},
+	}
+	for _, tags := range invalid {
+		if _, err := FormatPostTags(tags); err == nil {
+			t.Fatalf("expected error formatting tags %q", tags)
+		}
+	}
+
+	many := make([]string, MaxPostTags+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("t%d", i)
+	}
+	if _, err := FormatPostTags(many); err == nil {
+		t.Fatalf("expected error formatting too many tags")
+	}
+
+	gotTags := ParsePostTags("dev,#Go,,bad tag,dev," + strings.Join(many, ","))
+	if len(gotTags) != MaxPostTags {
+		t.Fatalf("unexpected nb of parsed tags: %d", len(gotTags))
+	}
+	if gotTags[0] != "dev" || gotTags[1] != "go" || gotTags[2] != "t0" {
+		t.Fatalf("unexpected parsed tags: %q", gotTags)
+	}
+	if tags := ParsePostTags(""); len(tags) != 0 {
+		t.Fatalf("unexpected tags parsed from empty string: %q", tags)
+	}
+}
+
+// TestPostMetadataHashIgnoresTags asserts that tags are not part of the post
+// hash.
+func TestPostMetadataHashIgnoresTags(t *testing.T) {
+	pm := PostMetadata{
+		Version:    1,
+		Attributes: map[string]string{RMPMain: "post"},
+	}
+	h1 := pm.Hash()
+	pm.Attributes[RMPTags] = "dev"
+	if h2 := pm.Hash(); h1 != h2 {
+		t.Fatalf("tags changed the post hash")
+	}
+}
+
+func TestFileMetadataVerifyManifest(t *testing.T) {
+	manifest := make([]FileManifest, 5)
+	for i := range manifest {
+		hash := sha256.Sum256([]byte{byte(i)})
+		manifest[i] = FileManifest{Index: uint64(i), Size: 8, Hash: hash[:]}
+	}
+	root := FileManifestMerkleRoot(manifest)
+	fm := FileMetadata{
+		Version:    FileMetadataVersion,
+		Manifest:   manifest,
+		MerkleRoot: hex.EncodeToString(root[:]),
+	}
+	if err := fm.VerifyManifest(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The merkle root is part of the metadata hash.
+	fid := fm.MetadataHash()
+	fm.MerkleRoot = hex.EncodeToString(make([]byte, 32))
+	if fm.MetadataHash() == fid {
+		t.Fatalf("merkle root not included in metadata hash")
+	}
+
+	// Any changes to the manifest are detected.
+	tests := []struct {
+		name   string
+		modify func(m []FileManifest) []FileManifest
+	}{{
+		name: "substituted chunk",
+		modify: func(m []FileManifest) []FileManifest {
+			hash := sha256.Sum256([]byte("evil"))
+			m[3].Hash = hash[:]
+			return m
+		},
+	}, {
+		name: "changed size",
+		modify: func(m []FileManifest) []FileManifest {
+			m[4].Size = 7
+			return m
+		},
+	}, {
+		name: "swapped chunks",
+		modify: func(m []FileManifest) []FileManifest {
+			m[0].Hash, m[1].Hash = m[1].Hash, m[0].Hash
+			return m
+		},
+	}, {
+		name: "dropped chunk",
+		modify: func(m []FileManifest) []FileManifest {
+			return m[:4]
+		},
+	}, {
+		name: "wrong index",
+		modify: func(m []FileManifest) []FileManifest {
+			m[2].Index = 3
+			return m
+		},
+	}}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := append([]FileManifest(nil), manifest...)
+			fm := FileMetadata{
+				Version:    FileMetadataVersion,
+				Manifest:   tc.modify(m),
+				MerkleRoot: hex.EncodeToString(root[:]),
+			}
+			if err := fm.VerifyManifest(); err == nil {
+				t.Fatalf("modified manifest was not detected")
+			}
+		})
+	}
+
+	// Version 1 metadata does not have a merkle root.
+	fm = FileMetadata{Version: 1, Manifest: manifest}
+	if err := fm.VerifyManifest(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestFileMetadataLegacyHash asserts that the legacy metadata hash matches the
+// file id calculated by clients that only support version 1 metadata.
+func TestFileMetadataLegacyHash(t *testing.T) {
+	// v1Hash is the hash as calculated by clients that only support
+	// version 1 metadata.
+	v1Hash := func(fm *FileMetadata) [32]byte {
+		h := sha256.New()
+		var b [32]byte
+		binary.LittleEndian.PutUint64(b[:], fm.Version)
+		h.Write(b[:])
+		binary.LittleEndian.PutUint64(b[:], fm.Size)
+		h.Write(b[:])
+		h.Write([]byte(fm.Filename))
+		h.Write([]byte(fm.Hash))
+		h.Write([]byte(fm.Signature))
+		var res [32]byte
+		copy(res[:], h.Sum(nil))
+		return res
+	}
+
+	manifest := []FileManifest{{Index: 0, Size: 8, Hash: make([]byte, 32)}}
+	root := FileManifestMerkleRoot(manifest)
+	fm := FileMetadata{
+		Version:    FileMetadataVersion,
+		Size:       8,
+		Filename:   "file.txt",
+		Hash:       "0011",
+		Signature:  "2233",
+		Manifest:   manifest,
+		MerkleRoot: hex.EncodeToString(root[:]),
+	}
+	if fm.LegacyMetadataHash() != v1Hash(&fm) {
+		t.Fatalf("legacy hash does not match v1 hash")
+	}
+	if fm.MetadataHash() == fm.LegacyMetadataHash() {
+		t.Fatalf("v2 hash should differ from legacy hash")
+	}
+
+	// For version 1 metadata, both hashes are the same.
+	fm.Version = 1
+	fm.MerkleRoot = ""
+	if fm.MetadataHash() != v1Hash(&fm) {
+		t.Fatalf("v1 hash does not match")
+	}
+	if fm.LegacyMetadataHash() != fm.MetadataHash() {
+		t.Fatalf("legacy hash differs from v1 hash")
+	}
+}
+
+// TestPaymentReceiptSignature asserts that payment receipts survive encoding
+// and that their signature is bound to the receipt fields and parties.
+func TestPaymentReceiptSignature(t *testing.T) {
+	payer, err := zkidentity.New("payer", "payer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee, err := zkidentity.New("payee", "payee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerID, payeeID := payer.Public.Identity, payee.Public.Identity
+
+	pr := RMPaymentReceipt{
+		Kind:        PaymentReceiptKindTip,
+		MilliAtoms:  1000,
+		PaymentHash: bytes.Repeat([]byte{0x01}, 32),
+		Timestamp:   1700000000,
+	}
+	hash := pr.ReceiptHash(payerID, payeeID)
+	pr.Signature = payer.SignMessage(hash[:])
+
+	signer := func(msg []byte) zkidentity.FixedSizeSignature { return payer.SignMessage(msg) }
+	verifier := func(msg []byte, sig *zkidentity.FixedSizeSignature) bool {
+		return payer.Public.VerifyMessage(msg, sig)
+	}
+	b, err := ComposeCompressedRM(signer, pr, zlib.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, payload, err := DecomposeRM(verifier, b, testMaxDecompressSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := payload.(RMPaymentReceipt)
+	if !ok {
+		t.Fatalf("unexpected payload type %T", payload)
+	}
+	hash = got.ReceiptHash(payerID, payeeID)
+	if !payer.Public.VerifyMessage(hash[:], &got.Signature) {
+		t.Fatalf("valid receipt failed verification")
+	}
+
+	// Changing any of the receipt fields or the parties invalidates the
+	// signature.
+	mutations := []func(pr *RMPaymentReceipt) [32]byte{
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.MilliAtoms += 1
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.Kind = PaymentReceiptKindContent
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.PaymentHash = bytes.Repeat([]byte{0x02}, 32)
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.Timestamp += 1
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			return pr.ReceiptHash(payeeID, payerID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.GC = &zkidentity.ShortID{0x01}
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+		func(pr *RMPaymentReceipt) [32]byte {
+			pr.Fiat = &RMFiatConversion{Currency: "USD", Amount: 5, Rate: 20}
+			return pr.ReceiptHash(payerID, payeeID)
+		},
+	}
+	for i, mutate := range mutations {
+		mutated := got
+		hash := mutate(&mutated)
+		if payer.Public.VerifyMessage(hash[:], &mutated.Signature) {
+			t.Fatalf("mutation %d did not invalidate signature", i)
+		}
+	}
+}
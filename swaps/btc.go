@@ -0,0 +1,192 @@
+package swaps
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/bech32"
+	"github.com/decred/dcrd/txscript/v4"
+)
+
+// BTC script opcodes used in the contracts of swaps in. These differ from the
+// DCR opcodes defined in txscript.
+const (
+	btcOpIf                  = 0x63
+	btcOpElse                = 0x67
+	btcOpEndIf               = 0x68
+	btcOpDrop                = 0x75
+	btcOpEqual               = 0x87
+	btcOpSHA256              = 0xa8
+	btcOpCheckSig            = 0xac
+	btcOpCheckLockTimeVerify = 0xb1
+)
+
+// btcLockTimeThreshold is the min lock time interpreted as a unix timestamp
+// instead of a block height.
+const btcLockTimeThreshold = 500000000
+
+// btcSwapContract returns the script of the BTC contract of a swap in:
+//
+//	OP_SHA256 <payment hash> OP_EQUAL
+//	OP_IF
+//	    <claim pubkey>
+//	OP_ELSE
+//	    <lock time> OP_CHECKLOCKTIMEVERIFY OP_DROP
+//	    <refund pubkey>
+//	OP_ENDIF
+//	OP_CHECKSIG
+func btcSwapContract(paymentHash, claimPubKey, refundPubKey []byte, lockTime int64) ([]byte, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(btcOpSHA256).AddData(paymentHash).AddOp(btcOpEqual).
+		AddOp(btcOpIf).
+		AddData(claimPubKey).
+		AddOp(btcOpElse).
+		AddInt64(lockTime).AddOp(btcOpCheckLockTimeVerify).AddOp(btcOpDrop).
+		AddData(refundPubKey).
+		AddOp(btcOpEndIf).
+		AddOp(btcOpCheckSig).
+		Script()
+}
+
+// btcP2WSHAddress returns the P2WSH address of the script in the network with
+// the given bech32 hrp.
+func btcP2WSHAddress(hrp string, script []byte) (string, error) {
+	h := sha256.Sum256(script)
+	prog, err := bech32.ConvertBits(h[:], 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, append([]byte{0}, prog...))
+}
+
+// btcNetHRP returns the bech32 hrp of the addresses of the given BTC network.
+func btcNetHRP(net string) (string, error) {
+	switch net {
+	case "", "mainnet":
+		return "bc", nil
+	case "testnet":
+		return "tb", nil
+	case "regtest", "simnet":
+		return "bcrt", nil
+	default:
+		return "", fmt.Errorf("unknown BTC network %q", net)
+	}
+}
+
+// checkBTCP2WSHAddress returns an error unless addr is the P2WSH address of
+// the script.
+func checkBTCP2WSHAddress(wantHRP, addr string, script []byte) error {
+	hrp, data, err := bech32.Decode(addr)
+	if err != nil {
+		return fmt.Errorf("not a P2WSH address: %v", err)
+	}
+	if hrp != wantHRP {
+		return fmt.Errorf("BTC address prefix %q is not the prefix %q of "+
+			"the configured network", hrp, wantHRP)
+	}
+	if len(data) == 0 || data[0] != 0 {
+		return errors.New("not a P2WSH address")
+	}
+	prog, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(script)
+	if !bytes.Equal(prog, h[:]) {
+		return errors.New("address does not match the contract script")
+	}
+	return nil
+}
+
+// BTCChecker checks transactions in the BTC chain.
+type BTCChecker interface {
+	// TxOutput returns the total amount (in satoshis) sent to the address
+	// by the tx, and the number of confirmations of the tx.
+	TxOutput(ctx context.Context, txid, addr string) (int64, int32, error)
+}
+
+// EsploraChecker checks BTC transactions through an Esplora API (such as the
+// one provided by blockstream.info or mempool.space).
+type EsploraChecker struct {
+	// URL is the base URL of the API (for example,
+	// "https://mempool.space/api").
+	URL string
+
+	HTTPClient *http.Client
+}
+
+// get performs a GET request to the API.
+func (ec *EsploraChecker) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(ec.URL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := ec.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("esplora API returned status %d: %s",
+			res.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// TxOutput is part of the BTCChecker interface.
+func (ec *EsploraChecker) TxOutput(ctx context.Context, txid, addr string) (int64, int32, error) {
+	b, err := ec.get(ctx, "/tx/"+txid)
+	if err != nil {
+		return 0, 0, err
+	}
+	var tx struct {
+		Vout []struct {
+			Address string `json:"scriptpubkey_address"`
+			Value   int64  `json:"value"`
+		} `json:"vout"`
+		Status struct {
+			Confirmed   bool  `json:"confirmed"`
+			BlockHeight int64 `json:"block_height"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(b, &tx); err != nil {
+		return 0, 0, fmt.Errorf("unable to decode tx: %v", err)
+	}
+
+	var total int64
+	for _, out := range tx.Vout {
+		if out.Address == addr {
+			total += out.Value
+		}
+	}
+	if !tx.Status.Confirmed {
+		return total, 0, nil
+	}
+
+	b, err = ec.get(ctx, "/blocks/tip/height")
+	if err != nil {
+		return 0, 0, err
+	}
+	tip, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to decode tip height: %v", err)
+	}
+	return total, int32(tip - tx.Status.BlockHeight + 1), nil
+}
@@ -0,0 +1,233 @@
+package swaps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Direction is the direction of a swap.
+type Direction string
+
+const (
+	// DirectionIn swaps BTC sent on-chain for DCR received in the LN
+	// wallet.
+	DirectionIn Direction = "in"
+
+	// DirectionOut swaps DCR paid from the LN wallet for BTC received
+	// on-chain.
+	DirectionOut Direction = "out"
+)
+
+// Quote is a quote of a swap offered by a swap provider.
+type Quote struct {
+	Direction Direction `json:"direction"`
+
+	// DCRAmount is the amount of DCR (in atoms) received (swap in) or
+	// paid (swap out) in the LN wallet.
+	DCRAmount int64 `json:"dcr_amount"`
+
+	// BTCAmount is the amount of BTC (in satoshis) sent (swap in) or
+	// received (swap out) on-chain.
+	BTCAmount int64 `json:"btc_amount"`
+
+	// Rate is the exchange rate (in BTC/DCR) used by the provider, before
+	// the fee.
+	Rate float64 `json:"rate"`
+
+	// Fee is the fee (in atoms) charged by the provider, already included
+	// in the amounts.
+	Fee int64 `json:"fee"`
+
+	// MinDCRAmount and MaxDCRAmount are the limits of the swaps accepted
+	// by the provider.
+	MinDCRAmount int64 `json:"min_dcr_amount"`
+	MaxDCRAmount int64 `json:"max_dcr_amount"`
+
+	// Expires is when the quote expires.
+	Expires time.Time `json:"expires"`
+}
+
+// CreateSwapRequest is a request to create a swap with a provider.
+type CreateSwapRequest struct {
+	Direction Direction `json:"direction"`
+	DCRAmount int64     `json:"dcr_amount"`
+
+	// PaymentHash is the hash of the preimage generated by the client. The
+	// swap only completes once the preimage is revealed.
+	PaymentHash string `json:"payment_hash"`
+
+	// Invoice is the LN invoice (with PaymentHash) paid by the provider
+	// in swaps in.
+	Invoice string `json:"invoice,omitempty"`
+
+	// RefundPubKey is the pubkey of the client used in the refund path of
+	// the BTC contract of swaps in.
+	RefundPubKey string `json:"refund_pubkey,omitempty"`
+
+	// LockTime is the lock time (a unix timestamp) of the refund path of
+	// the BTC contract of swaps in.
+	LockTime int64 `json:"lock_time,omitempty"`
+
+	// BTCAddress is where the BTC of swaps out is sent.
+	BTCAddress string `json:"btc_address,omitempty"`
+}
+
+// CreateSwapResponse is the response of a provider to a new swap.
+type CreateSwapResponse struct {
+	ID        string `json:"id"`
+	BTCAmount int64  `json:"btc_amount"`
+
+	// DepositAddress and ContractScript are the P2WSH address and witness
+	// script of the BTC contract to which the BTC of swaps in is sent. The
+	// provider claims it with the preimage and ClaimPubKey, and the client
+	// may refund it with its refund key after LockTime, which must be the
+	// lock time of the request.
+	DepositAddress string `json:"deposit_address,omitempty"`
+	ContractScript string `json:"contract_script,omitempty"`
+	ClaimPubKey    string `json:"claim_pubkey,omitempty"`
+	LockTime       int64  `json:"lock_time,omitempty"`
+
+	// Invoice is the LN hold invoice (with the payment hash of the
+	// request) paid by the client in swaps out.
+	Invoice string `json:"invoice,omitempty"`
+
+	Expires time.Time `json:"expires"`
+}
+
+// ProviderSwapStatus is the status of a swap reported by the provider.
+type ProviderSwapStatus struct {
+	ID string `json:"id"`
+
+	// Status is one of "created", "deposit_seen", "deposit_confirmed",
+	// "invoice_paid", "payment_accepted", "btc_sent", "completed",
+	// "expired" and "failed".
+	Status string `json:"status"`
+
+	// BTCTxID is the tx that sends the BTC, either the deposit of swaps in
+	// or the payment of swaps out.
+	BTCTxID string `json:"btc_txid,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Provider is a provider of atomic swaps between DCR and BTC.
+type Provider interface {
+	// Quote returns a quote for a swap.
+	Quote(ctx context.Context, dir Direction, dcrAmount int64) (*Quote, error)
+
+	// CreateSwap creates a new swap.
+	CreateSwap(ctx context.Context, req *CreateSwapRequest) (*CreateSwapResponse, error)
+
+	// SwapStatus returns the status of a swap.
+	SwapStatus(ctx context.Context, id string) (*ProviderSwapStatus, error)
+
+	// RevealPreimage reveals the preimage of a swap out, which allows the
+	// provider to settle the hold invoice paid by the client.
+	RevealPreimage(ctx context.Context, id string, preimage string) error
+}
+
+// HTTPProvider is a swap provider accessed through its HTTP API.
+type HTTPProvider struct {
+	// URL is the base URL of the provider API.
+	URL string
+
+	HTTPClient *http.Client
+}
+
+// do performs a request to the provider API and decodes the response into
+// res.
+func (p *HTTPProvider) do(ctx context.Context, method, path string, req, res interface{}) error {
+	var body io.Reader
+	if req != nil {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, method,
+		strings.TrimSuffix(p.URL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if req != nil {
+		hreq.Header.Set("Content-Type", "application/json")
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	hres, err := client.Do(hreq)
+	if err != nil {
+		return err
+	}
+	defer hres.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(hres.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if hres.StatusCode != http.StatusOK {
+		var perr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(b, &perr) == nil && perr.Error != "" {
+			return fmt.Errorf("swap provider error: %s", perr.Error)
+		}
+		return fmt.Errorf("swap provider returned status %d", hres.StatusCode)
+	}
+	if res == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, res); err != nil {
+		return fmt.Errorf("unable to decode swap provider response: %v", err)
+	}
+	return nil
+}
+
+// Quote is part of the Provider interface.
+func (p *HTTPProvider) Quote(ctx context.Context, dir Direction, dcrAmount int64) (*Quote, error) {
+	q := url.Values{}
+	q.Set("direction", string(dir))
+	q.Set("dcr_amount", fmt.Sprintf("%d", dcrAmount))
+	var res Quote
+	if err := p.do(ctx, http.MethodGet, "/v1/quote?"+q.Encode(), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CreateSwap is part of the Provider interface.
+func (p *HTTPProvider) CreateSwap(ctx context.Context, req *CreateSwapRequest) (*CreateSwapResponse, error) {
+	var res CreateSwapResponse
+	if err := p.do(ctx, http.MethodPost, "/v1/swap", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SwapStatus is part of the Provider interface.
+func (p *HTTPProvider) SwapStatus(ctx context.Context, id string) (*ProviderSwapStatus, error) {
+	var res ProviderSwapStatus
+	path := "/v1/swap/" + url.PathEscape(id)
+	if err := p.do(ctx, http.MethodGet, path, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RevealPreimage is part of the Provider interface.
+func (p *HTTPProvider) RevealPreimage(ctx context.Context, id string, preimage string) error {
+	req := struct {
+		Preimage string `json:"preimage"`
+	}{Preimage: preimage}
+	path := "/v1/swap/" + url.PathEscape(id) + "/preimage"
+	return p.do(ctx, http.MethodPost, path, &req, nil)
+}
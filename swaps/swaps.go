@@ -0,0 +1,757 @@
+// Package swaps implements atomic swaps between BTC and the DCR LN wallet
+// through a swap provider.
+//
+// In swaps in, the client generates a preimage and an LN invoice for it, and
+// the user sends BTC to a contract that the provider may only claim with the
+// preimage, which it learns by paying the invoice. The contract returned by the
+// provider is rebuilt locally before the swap is accepted, to ensure it commits
+// to the payment hash, the refund key of the swap and the requested lock time.
+// This package does not create refund transactions: after the lock time, the
+// deposit of a swap that did not complete may be refunded with external BTC
+// tools, using the refund key and contract script of the swap.
+//
+// In swaps out, the client pays an LN hold invoice of the provider for the
+// hash of a preimage generated by the client. The preimage is only revealed
+// to the provider (allowing it to settle the invoice) after the BTC sent by
+// the provider is confirmed on-chain. Otherwise, the invoice expires and the
+// payment is canceled.
+package swaps
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/companyzero/bisonrelay/internal/jsonfile"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+	"github.com/decred/slog"
+)
+
+// State is the state of a swap.
+type State string
+
+const (
+	// StateAwaitingDeposit is the state of swaps in waiting for the BTC
+	// to be sent to the deposit address.
+	StateAwaitingDeposit State = "awaiting_deposit"
+
+	// StateDepositSeen is the state of swaps in after the provider saw
+	// the BTC deposit.
+	StateDepositSeen State = "deposit_seen"
+
+	// StatePaymentSent is the state of swaps out after the hold invoice
+	// of the provider was paid.
+	StatePaymentSent State = "payment_sent"
+
+	// StateBTCSent is the state of swaps out after the provider sent the
+	// BTC, while waiting for it to be confirmed.
+	StateBTCSent State = "btc_sent"
+
+	// StateCompleted is the state of completed swaps.
+	StateCompleted State = "completed"
+
+	// StateExpired is the state of swaps that expired before completing.
+	// Swaps in that expire after the BTC was sent must be refunded
+	// manually.
+	StateExpired State = "expired"
+
+	// StateFailed is the state of failed swaps.
+	StateFailed State = "failed"
+)
+
+// Final returns true if the swap will not progress further.
+func (s State) Final() bool {
+	return s == StateCompleted || s == StateExpired || s == StateFailed
+}
+
+// Swap is a swap between BTC and the DCR LN wallet.
+type Swap struct {
+	ID        string         `json:"id"`
+	Direction Direction      `json:"direction"`
+	State     State          `json:"state"`
+	Created   time.Time      `json:"created"`
+	Updated   time.Time      `json:"updated"`
+	Expires   time.Time      `json:"expires"`
+	DCRAmount dcrutil.Amount `json:"dcr_amount"`
+	BTCAmount int64          `json:"btc_amount"`
+	Fee       dcrutil.Amount `json:"fee"`
+
+	// Preimage and PaymentHash are the preimage generated for the swap
+	// and its hash.
+	Preimage    string `json:"preimage"`
+	PaymentHash string `json:"payment_hash"`
+
+	// Invoice is the LN invoice paid by the provider (swaps in) or by the
+	// client (swaps out).
+	Invoice string `json:"invoice"`
+
+	// BTCAddress is the deposit address (swaps in) or the address where
+	// the BTC is received (swaps out).
+	BTCAddress string `json:"btc_address"`
+	BTCTxID    string `json:"btc_txid,omitempty"`
+
+	// RefundKey, ContractScript and LockTime allow refunding the BTC sent
+	// to the contract of swaps in.
+	RefundKey      string `json:"refund_key,omitempty"`
+	ContractScript string `json:"contract_script,omitempty"`
+	LockTime       int64  `json:"lock_time,omitempty"`
+
+	Err string `json:"err,omitempty"`
+}
+
+// Config is the configuration of the swap manager.
+type Config struct {
+	// Provider is the swap provider.
+	Provider Provider
+
+	// LN is the client of the LN wallet that receives or pays the DCR.
+	LN lnrpc.LightningClient
+
+	// BTC is used to verify the BTC sent in swaps out.
+	BTC BTCChecker
+
+	// BTCNet is the BTC network of the swaps ("mainnet", "testnet" or
+	// "regtest", with "simnet" as an alias of the latter). Deposit
+	// addresses of other networks are rejected. If empty, mainnet is
+	// used.
+	BTCNet string
+
+	// FeeLimit returns the routing fee limit of the payments of swaps out.
+	// If nil, the default limit of the LN wallet is used.
+	FeeLimit func(amountMAtoms int64) *lnrpc.FeeLimit
+
+	// Rates returns the market USD/DCR and USD/BTC prices, used to
+	// evaluate the quotes of the provider. It may be nil.
+	Rates func() (float64, float64)
+
+	// MaxPremium is the max ratio by which the rate of a quote may be
+	// worse than the market rate (including the fee of the provider). If
+	// zero, a default of 0.05 (5%) is used.
+	MaxPremium float64
+
+	// RefundDelay is the time after the expiration of a swap in until the
+	// deposit may be refunded. If zero, a default of 24 hours is used.
+	RefundDelay time.Duration
+
+	// MinConfs is the number of confirmations of the BTC sent in swaps out
+	// before the preimage is revealed. If zero, a default of 2 is used.
+	MinConfs int32
+
+	// Interval is the interval between checks of pending swaps. If zero,
+	// a default of 30 seconds is used.
+	Interval time.Duration
+
+	// StateFile is where the swaps are stored.
+	StateFile string
+
+	// OnProgress is called when a swap changes state.
+	OnProgress func(Swap)
+
+	Log slog.Logger
+}
+
+// QuoteEval is a quote of the provider evaluated against the market rate.
+type QuoteEval struct {
+	Quote
+
+	// MarketRate is the market rate (in BTC/DCR) and Premium the ratio by
+	// which the effective rate of the quote is worse than the market
+	// rate. Both are zero when the market rate is unknown.
+	MarketRate float64
+	Premium    float64
+}
+
+// Manager manages swaps with a swap provider.
+type Manager struct {
+	cfg    Config
+	log    slog.Logger
+	btcHRP string
+
+	runMtx sync.Mutex
+
+	mtx   sync.Mutex
+	swaps map[string]*Swap
+}
+
+// NewManager creates a new swap manager.
+func NewManager(cfg Config) (*Manager, error) {
+	if cfg.Provider == nil {
+		return nil, errors.New("swap provider is not specified")
+	}
+	if cfg.LN == nil {
+		return nil, errors.New("LN client is not specified")
+	}
+	if cfg.MaxPremium <= 0 {
+		cfg.MaxPremium = 0.05
+	}
+	if cfg.RefundDelay <= 0 {
+		cfg.RefundDelay = 24 * time.Hour
+	}
+	if cfg.MinConfs <= 0 {
+		cfg.MinConfs = 2
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	btcHRP, err := btcNetHRP(cfg.BTCNet)
+	if err != nil {
+		return nil, err
+	}
+	log := cfg.Log
+	if log == nil {
+		log = slog.Disabled
+	}
+
+	m := &Manager{cfg: cfg, log: log, btcHRP: btcHRP, swaps: make(map[string]*Swap)}
+	if cfg.StateFile != "" {
+		var swaps []*Swap
+		err := jsonfile.Read(cfg.StateFile, &swaps)
+		if err != nil && !errors.Is(err, jsonfile.ErrNotFound) {
+			return nil, fmt.Errorf("unable to read swaps: %v", err)
+		}
+		for _, s := range swaps {
+			m.swaps[s.ID] = s
+		}
+	}
+	return m, nil
+}
+
+// saveState saves the swaps. Must be called with the mutex held.
+func (m *Manager) saveState() error {
+	if m.cfg.StateFile == "" {
+		return nil
+	}
+	swaps := make([]*Swap, 0, len(m.swaps))
+	for _, s := range m.swaps {
+		swaps = append(swaps, s)
+	}
+	sort.Slice(swaps, func(i, j int) bool {
+		return swaps[i].Created.Before(swaps[j].Created)
+	})
+	return jsonfile.Write(m.cfg.StateFile, swaps, m.log)
+}
+
+// updateSwap applies f to the swap with the given id and stores it. The
+// OnProgress callback is called if the state of the swap changed.
+func (m *Manager) updateSwap(id string, f func(s *Swap)) {
+	m.mtx.Lock()
+	s, ok := m.swaps[id]
+	if !ok {
+		m.mtx.Unlock()
+		return
+	}
+	oldState := s.State
+	f(s)
+	s.Updated = time.Now()
+	res := *s
+	err := m.saveState()
+	m.mtx.Unlock()
+	if err != nil {
+		m.log.Errorf("Unable to save swaps: %v", err)
+	}
+
+	if res.State != oldState {
+		m.log.Infof("Swap %s %s: %s", res.ID, res.Direction, res.State)
+		if m.cfg.OnProgress != nil {
+			m.cfg.OnProgress(res)
+		}
+	}
+}
+
+// addSwap adds a new swap.
+func (m *Manager) addSwap(s *Swap) error {
+	m.mtx.Lock()
+	if _, ok := m.swaps[s.ID]; ok {
+		m.mtx.Unlock()
+		return fmt.Errorf("swap %s already exists", s.ID)
+	}
+	m.swaps[s.ID] = s
+	err := m.saveState()
+	res := *s
+	m.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	m.log.Infof("Created swap %s %s of %s for %d sats", res.ID,
+		res.Direction, res.DCRAmount, res.BTCAmount)
+	if m.cfg.OnProgress != nil {
+		m.cfg.OnProgress(res)
+	}
+	return nil
+}
+
+// Swaps returns the swaps, ordered by creation time.
+func (m *Manager) Swaps() []Swap {
+	m.mtx.Lock()
+	res := make([]Swap, 0, len(m.swaps))
+	for _, s := range m.swaps {
+		res = append(res, *s)
+	}
+	m.mtx.Unlock()
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Created.Before(res[j].Created)
+	})
+	return res
+}
+
+// Swap returns the swap with the given id.
+func (m *Manager) Swap(id string) (Swap, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	s, ok := m.swaps[id]
+	if !ok {
+		return Swap{}, fmt.Errorf("swap %s not found", id)
+	}
+	return *s, nil
+}
+
+// marketRate returns the market rate in BTC/DCR, or zero if unknown.
+func (m *Manager) marketRate() float64 {
+	if m.cfg.Rates == nil {
+		return 0
+	}
+	dcrPrice, btcPrice := m.cfg.Rates()
+	if dcrPrice <= 0 || btcPrice <= 0 {
+		return 0
+	}
+	return dcrPrice / btcPrice
+}
+
+// Quote fetches a quote for a swap of the given amount of DCR and evaluates
+// it against the market rate.
+func (m *Manager) Quote(ctx context.Context, dir Direction, amt dcrutil.Amount) (*QuoteEval, error) {
+	if amt <= 0 {
+		return nil, errors.New("swap amount must be positive")
+	}
+	q, err := m.cfg.Provider.Quote(ctx, dir, int64(amt))
+	if err != nil {
+		return nil, err
+	}
+	if q.Direction != dir || q.DCRAmount != int64(amt) || q.BTCAmount <= 0 {
+		return nil, errors.New("swap provider returned an inconsistent quote")
+	}
+	if q.MinDCRAmount > 0 && int64(amt) < q.MinDCRAmount {
+		return nil, fmt.Errorf("swap amount below provider min of %s",
+			dcrutil.Amount(q.MinDCRAmount))
+	}
+	if q.MaxDCRAmount > 0 && int64(amt) > q.MaxDCRAmount {
+		return nil, fmt.Errorf("swap amount above provider max of %s",
+			dcrutil.Amount(q.MaxDCRAmount))
+	}
+
+	res := &QuoteEval{Quote: *q, MarketRate: m.marketRate()}
+	if res.MarketRate > 0 {
+		// The effective rate is in BTC/DCR. A higher rate is worse
+		// when swapping in (more BTC per DCR) and a lower rate is
+		// worse when swapping out.
+		rate := float64(q.BTCAmount) / float64(q.DCRAmount)
+		if dir == DirectionIn {
+			res.Premium = rate/res.MarketRate - 1
+		} else {
+			res.Premium = 1 - rate/res.MarketRate
+		}
+	}
+	return res, nil
+}
+
+// checkQuote returns an error if the quote is not acceptable.
+func (m *Manager) checkQuote(q *QuoteEval) error {
+	if time.Now().After(q.Expires) {
+		return errors.New("swap quote expired")
+	}
+	if q.Premium > m.cfg.MaxPremium {
+		return fmt.Errorf("swap quote premium %.2f%% above max of %.2f%%",
+			q.Premium*100, m.cfg.MaxPremium*100)
+	}
+	return nil
+}
+
+// newPreimage returns a new random preimage and its hash.
+func newPreimage() ([]byte, []byte, error) {
+	preimage := make([]byte, 32)
+	if _, err := rand.Read(preimage); err != nil {
+		return nil, nil, err
+	}
+	hash := sha256.Sum256(preimage)
+	return preimage, hash[:], nil
+}
+
+// SwapIn creates a swap that funds the LN wallet with the given amount of DCR
+// in exchange for BTC sent to the returned deposit address.
+func (m *Manager) SwapIn(ctx context.Context, amt dcrutil.Amount) (*Swap, error) {
+	q, err := m.Quote(ctx, DirectionIn, amt)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkQuote(q); err != nil {
+		return nil, err
+	}
+
+	preimage, hash, err := newPreimage()
+	if err != nil {
+		return nil, err
+	}
+	refundKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := int64(time.Until(q.Expires).Seconds())
+	if expiry < 3600 {
+		expiry = 3600
+	}
+	inv, err := m.cfg.LN.AddInvoice(ctx, &lnrpc.Invoice{
+		Memo:        "BTC swap in",
+		RPreimage:   preimage,
+		ValueMAtoms: int64(amt) * 1000,
+		Expiry:      expiry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create invoice: %v", err)
+	}
+
+	now := time.Now()
+	expires := now.Add(time.Duration(expiry) * time.Second)
+	lockTime := expires.Add(m.cfg.RefundDelay).Unix()
+	refundPubKey := refundKey.PubKey().SerializeCompressed()
+	res, err := m.cfg.Provider.CreateSwap(ctx, &CreateSwapRequest{
+		Direction:    DirectionIn,
+		DCRAmount:    int64(amt),
+		PaymentHash:  hex.EncodeToString(hash),
+		Invoice:      inv.PaymentRequest,
+		RefundPubKey: hex.EncodeToString(refundPubKey),
+		LockTime:     lockTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.ID == "" || res.DepositAddress == "" {
+		return nil, errors.New("swap provider did not return a deposit address")
+	}
+	if err := checkSwapInContract(m.btcHRP, res, hash, refundPubKey, lockTime); err != nil {
+		return nil, fmt.Errorf("swap provider returned an invalid "+
+			"contract: %v", err)
+	}
+	if res.BTCAmount > q.BTCAmount {
+		return nil, fmt.Errorf("swap provider requested %d sats instead "+
+			"of the quoted %d sats", res.BTCAmount, q.BTCAmount)
+	}
+
+	s := &Swap{
+		ID:             res.ID,
+		Direction:      DirectionIn,
+		State:          StateAwaitingDeposit,
+		Created:        now,
+		Updated:        now,
+		Expires:        expires,
+		DCRAmount:      amt,
+		BTCAmount:      res.BTCAmount,
+		Fee:            dcrutil.Amount(q.Fee),
+		Preimage:       hex.EncodeToString(preimage),
+		PaymentHash:    hex.EncodeToString(hash),
+		Invoice:        inv.PaymentRequest,
+		BTCAddress:     res.DepositAddress,
+		RefundKey:      hex.EncodeToString(refundKey.Serialize()),
+		ContractScript: res.ContractScript,
+		LockTime:       res.LockTime,
+	}
+	if err := m.addSwap(s); err != nil {
+		return nil, err
+	}
+	res2 := *s
+	return &res2, nil
+}
+
+// checkSwapInContract returns an error unless the BTC contract of the swap in
+// created by the provider commits to the payment hash, the refund pubkey and
+// the lock time of the swap, and the deposit address is the P2WSH address of
+// the contract in the network with the given bech32 hrp.
+func checkSwapInContract(btcHRP string, res *CreateSwapResponse, hash, refundPubKey []byte, lockTime int64) error {
+	if res.LockTime != lockTime {
+		return fmt.Errorf("lock time %d is not the requested %d",
+			res.LockTime, lockTime)
+	}
+	if lockTime < btcLockTimeThreshold {
+		return fmt.Errorf("lock time %d is not a timestamp", lockTime)
+	}
+	claimPubKey, err := hex.DecodeString(res.ClaimPubKey)
+	if err != nil {
+		return fmt.Errorf("invalid claim pubkey: %v", err)
+	}
+	if _, err := secp256k1.ParsePubKey(claimPubKey); err != nil {
+		return fmt.Errorf("invalid claim pubkey: %v", err)
+	}
+	script, err := hex.DecodeString(res.ContractScript)
+	if err != nil {
+		return fmt.Errorf("invalid contract script: %v", err)
+	}
+	wantScript, err := btcSwapContract(hash, claimPubKey, refundPubKey, lockTime)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(script, wantScript) {
+		return errors.New("contract script does not match the swap")
+	}
+	return checkBTCP2WSHAddress(btcHRP, res.DepositAddress, script)
+}
+
+// SwapOut creates a swap that pays the given amount of DCR from the LN wallet
+// in exchange for BTC sent to btcAddr.
+func (m *Manager) SwapOut(ctx context.Context, amt dcrutil.Amount, btcAddr string) (*Swap, error) {
+	if btcAddr == "" {
+		return nil, errors.New("BTC address is not specified")
+	}
+	if m.cfg.BTC == nil {
+		return nil, errors.New("BTC checker is not configured")
+	}
+	q, err := m.Quote(ctx, DirectionOut, amt)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkQuote(q); err != nil {
+		return nil, err
+	}
+
+	preimage, hash, err := newPreimage()
+	if err != nil {
+		return nil, err
+	}
+	res, err := m.cfg.Provider.CreateSwap(ctx, &CreateSwapRequest{
+		Direction:   DirectionOut,
+		DCRAmount:   int64(amt),
+		PaymentHash: hex.EncodeToString(hash),
+		BTCAddress:  btcAddr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.ID == "" || res.Invoice == "" {
+		return nil, errors.New("swap provider did not return an invoice")
+	}
+	if res.BTCAmount < q.BTCAmount {
+		return nil, fmt.Errorf("swap provider offered %d sats instead "+
+			"of the quoted %d sats", res.BTCAmount, q.BTCAmount)
+	}
+
+	// Ensure the invoice can only be settled with the preimage generated
+	// by the client and does not request more than the swap amount.
+	payReq, err := m.cfg.LN.DecodePayReq(ctx, &lnrpc.PayReqString{PayReq: res.Invoice})
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode swap invoice: %v", err)
+	}
+	if payReq.PaymentHash != hex.EncodeToString(hash) {
+		return nil, errors.New("swap invoice has a different payment hash")
+	}
+	if payReq.NumMAtoms <= 0 || payReq.NumMAtoms > int64(amt)*1000 {
+		return nil, fmt.Errorf("swap invoice requests %s instead of %s",
+			dcrutil.Amount(payReq.NumMAtoms/1000), amt)
+	}
+
+	now := time.Now()
+	s := &Swap{
+		ID:          res.ID,
+		Direction:   DirectionOut,
+		State:       StatePaymentSent,
+		Created:     now,
+		Updated:     now,
+		Expires:     res.Expires,
+		DCRAmount:   amt,
+		BTCAmount:   res.BTCAmount,
+		Fee:         dcrutil.Amount(q.Fee),
+		Preimage:    hex.EncodeToString(preimage),
+		PaymentHash: hex.EncodeToString(hash),
+		Invoice:     res.Invoice,
+		BTCAddress:  btcAddr,
+	}
+	if err := m.addSwap(s); err != nil {
+		return nil, err
+	}
+
+	// The payment of the hold invoice only completes once the preimage is
+	// revealed, so it is made in the background.
+	go m.payInvoice(ctx, s.ID, s.Invoice, payReq.NumMAtoms)
+
+	res2 := *s
+	return &res2, nil
+}
+
+// payInvoice pays the hold invoice of a swap out.
+func (m *Manager) payInvoice(ctx context.Context, id, invoice string, amountMAtoms int64) {
+	req := &lnrpc.SendRequest{PaymentRequest: invoice}
+	if m.cfg.FeeLimit != nil {
+		req.FeeLimit = m.cfg.FeeLimit(amountMAtoms)
+	}
+	res, err := m.cfg.LN.SendPaymentSync(ctx, req)
+	if err == nil && res.PaymentError != "" {
+		err = errors.New(res.PaymentError)
+	}
+	if err != nil {
+		m.log.Warnf("Unable to pay invoice of swap %s: %v", id, err)
+		m.updateSwap(id, func(s *Swap) {
+			if !s.State.Final() {
+				s.State = StateFailed
+				s.Err = err.Error()
+			}
+		})
+		return
+	}
+	m.updateSwap(id, func(s *Swap) {
+		s.State = StateCompleted
+		s.Err = ""
+	})
+}
+
+// checkSwapIn checks the progress of a swap in.
+func (m *Manager) checkSwapIn(ctx context.Context, s Swap) error {
+	hash, err := hex.DecodeString(s.PaymentHash)
+	if err != nil {
+		return err
+	}
+	inv, err := m.cfg.LN.LookupInvoice(ctx, &lnrpc.PaymentHash{RHash: hash})
+	if err != nil {
+		return err
+	}
+	if inv.State == lnrpc.Invoice_SETTLED {
+		m.updateSwap(s.ID, func(s *Swap) {
+			s.State = StateCompleted
+			s.Err = ""
+		})
+		return nil
+	}
+
+	st, err := m.cfg.Provider.SwapStatus(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	m.updateSwap(s.ID, func(s *Swap) {
+		if st.BTCTxID != "" {
+			s.BTCTxID = st.BTCTxID
+		}
+		switch {
+		case inv.State == lnrpc.Invoice_CANCELED ||
+			time.Now().After(s.Expires) || st.Status == "expired":
+			s.State = StateExpired
+			if s.BTCTxID != "" {
+				s.Err = "swap expired after the deposit was sent"
+			}
+		case st.Status == "failed":
+			s.State = StateFailed
+			s.Err = st.Error
+		case st.Status == "deposit_seen", st.Status == "deposit_confirmed":
+			s.State = StateDepositSeen
+		}
+	})
+	return nil
+}
+
+// checkSwapOut checks the progress of a swap out, revealing the preimage once
+// the BTC sent by the provider is confirmed.
+func (m *Manager) checkSwapOut(ctx context.Context, s Swap) error {
+	st, err := m.cfg.Provider.SwapStatus(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	switch st.Status {
+	case "completed":
+		m.updateSwap(s.ID, func(s *Swap) {
+			s.State = StateCompleted
+			s.Err = ""
+		})
+		return nil
+	case "failed", "expired":
+		m.updateSwap(s.ID, func(s *Swap) {
+			s.State = State(st.Status)
+			s.Err = st.Error
+		})
+		return nil
+	}
+	if st.BTCTxID == "" {
+		if time.Now().After(s.Expires) {
+			m.updateSwap(s.ID, func(s *Swap) {
+				s.State = StateExpired
+			})
+		}
+		return nil
+	}
+
+	sats, confs, err := m.cfg.BTC.TxOutput(ctx, st.BTCTxID, s.BTCAddress)
+	if err != nil {
+		return fmt.Errorf("unable to check BTC tx %s: %v", st.BTCTxID, err)
+	}
+	if sats < s.BTCAmount {
+		return fmt.Errorf("BTC tx %s sends %d sats instead of %d sats",
+			st.BTCTxID, sats, s.BTCAmount)
+	}
+	m.updateSwap(s.ID, func(s *Swap) {
+		s.BTCTxID = st.BTCTxID
+		s.State = StateBTCSent
+	})
+	if confs < m.cfg.MinConfs {
+		return nil
+	}
+
+	if err := m.cfg.Provider.RevealPreimage(ctx, s.ID, s.Preimage); err != nil {
+		return fmt.Errorf("unable to reveal preimage: %v", err)
+	}
+	m.log.Infof("Revealed preimage of swap %s after BTC tx %s got %d "+
+		"confirmations", s.ID, st.BTCTxID, confs)
+	return nil
+}
+
+// RunOnce checks the progress of all pending swaps.
+func (m *Manager) RunOnce(ctx context.Context) error {
+	m.runMtx.Lock()
+	defer m.runMtx.Unlock()
+
+	var firstErr error
+	for _, s := range m.Swaps() {
+		if s.State.Final() {
+			continue
+		}
+		var err error
+		if s.Direction == DirectionIn {
+			err = m.checkSwapIn(ctx, s)
+		} else {
+			err = m.checkSwapOut(ctx, s)
+		}
+		if err != nil {
+			m.log.Warnf("Unable to check swap %s: %v", s.ID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Run checks the progress of pending swaps until the context is canceled.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := m.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			m.log.Debugf("Swap check failed: %v", err)
+		}
+	}
+}
+
+// FormatBTC formats an amount of satoshis as BTC.
+func FormatBTC(sats int64) string {
+	return fmt.Sprintf("%.8f BTC", float64(sats)/math.Pow10(8))
+}
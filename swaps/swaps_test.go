@@ -0,0 +1,384 @@
+package swaps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrutil/v4"
+	"github.com/decred/dcrlnd/lnrpc"
+	"google.golang.org/grpc"
+)
+
+// fakeProvider is a swap provider used in tests.
+type fakeProvider struct {
+	mtx      sync.Mutex
+	rate     float64
+	reqs     []*CreateSwapRequest
+	status   map[string]*ProviderSwapStatus
+	revealed map[string]string
+
+	// tamper modifies the responses to new swaps.
+	tamper func(*CreateSwapResponse)
+}
+
+func (p *fakeProvider) Quote(_ context.Context, dir Direction, dcrAmount int64) (*Quote, error) {
+	return &Quote{
+		Direction: dir,
+		DCRAmount: dcrAmount,
+		BTCAmount: int64(float64(dcrAmount) * p.rate),
+		Rate:      p.rate,
+		Expires:   time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (p *fakeProvider) CreateSwap(_ context.Context, req *CreateSwapRequest) (*CreateSwapResponse, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.reqs = append(p.reqs, req)
+	id := req.PaymentHash[:8]
+	p.status[id] = &ProviderSwapStatus{ID: id, Status: "created"}
+	res := &CreateSwapResponse{
+		ID:        id,
+		BTCAmount: int64(float64(req.DCRAmount) * p.rate),
+		Expires:   time.Now().Add(time.Hour),
+	}
+	if req.Direction == DirectionIn {
+		hash, _ := hex.DecodeString(req.PaymentHash)
+		refundPubKey, _ := hex.DecodeString(req.RefundPubKey)
+		claimKey, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		claimPubKey := claimKey.PubKey().SerializeCompressed()
+		script, err := btcSwapContract(hash, claimPubKey, refundPubKey, req.LockTime)
+		if err != nil {
+			return nil, err
+		}
+		res.DepositAddress, err = btcP2WSHAddress("bcrt", script)
+		if err != nil {
+			return nil, err
+		}
+		res.ContractScript = hex.EncodeToString(script)
+		res.ClaimPubKey = hex.EncodeToString(claimPubKey)
+		res.LockTime = req.LockTime
+	} else {
+		res.Invoice = "invoice:" + req.PaymentHash
+	}
+	if p.tamper != nil {
+		p.tamper(res)
+	}
+	return res, nil
+}
+
+func (p *fakeProvider) SwapStatus(_ context.Context, id string) (*ProviderSwapStatus, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	st, ok := p.status[id]
+	if !ok {
+		return nil, errors.New("unknown swap")
+	}
+	res := *st
+	return &res, nil
+}
+
+func (p *fakeProvider) RevealPreimage(_ context.Context, id string, preimage string) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.revealed[id] = preimage
+	p.status[id].Status = "completed"
+	return nil
+}
+
+func (p *fakeProvider) setStatus(id, status, txid string) {
+	p.mtx.Lock()
+	p.status[id].Status = status
+	p.status[id].BTCTxID = txid
+	p.mtx.Unlock()
+}
+
+// fakeLN is a fake LN client that implements the calls used by the swaps.
+type fakeLN struct {
+	lnrpc.LightningClient
+
+	mtx      sync.Mutex
+	invoices map[string]*lnrpc.Invoice
+	payments chan *lnrpc.SendRequest
+	settled  chan struct{}
+}
+
+func (ln *fakeLN) AddInvoice(_ context.Context, inv *lnrpc.Invoice, _ ...grpc.CallOption) (*lnrpc.AddInvoiceResponse, error) {
+	hash := sha256.Sum256(inv.RPreimage)
+	ln.mtx.Lock()
+	ln.invoices[hex.EncodeToString(hash[:])] = &lnrpc.Invoice{State: lnrpc.Invoice_OPEN}
+	ln.mtx.Unlock()
+	return &lnrpc.AddInvoiceResponse{PaymentRequest: "invoice:" + hex.EncodeToString(hash[:])}, nil
+}
+
+func (ln *fakeLN) LookupInvoice(_ context.Context, req *lnrpc.PaymentHash, _ ...grpc.CallOption) (*lnrpc.Invoice, error) {
+	ln.mtx.Lock()
+	defer ln.mtx.Unlock()
+	inv, ok := ln.invoices[hex.EncodeToString(req.RHash)]
+	if !ok {
+		return nil, errors.New("unknown invoice")
+	}
+	return &lnrpc.Invoice{State: inv.State}, nil
+}
+
+func (ln *fakeLN) settle(hash string) {
+	ln.mtx.Lock()
+	ln.invoices[hash].State = lnrpc.Invoice_SETTLED
+	ln.mtx.Unlock()
+}
+
+func (ln *fakeLN) DecodePayReq(_ context.Context, req *lnrpc.PayReqString, _ ...grpc.CallOption) (*lnrpc.PayReq, error) {
+	return &lnrpc.PayReq{
+		PaymentHash: req.PayReq[len("invoice:"):],
+		NumMAtoms:   1e11,
+	}, nil
+}
+
+func (ln *fakeLN) SendPaymentSync(_ context.Context, req *lnrpc.SendRequest, _ ...grpc.CallOption) (*lnrpc.SendResponse, error) {
+	ln.payments <- req
+	<-ln.settled
+	return &lnrpc.SendResponse{}, nil
+}
+
+// fakeBTC is a fake BTC checker.
+type fakeBTC struct {
+	mtx   sync.Mutex
+	sats  int64
+	confs int32
+}
+
+func (b *fakeBTC) TxOutput(context.Context, string, string) (int64, int32, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.sats, b.confs, nil
+}
+
+func newTestManager(t *testing.T, rate float64) (*Manager, *fakeProvider, *fakeLN, *fakeBTC) {
+	t.Helper()
+	p := &fakeProvider{
+		rate:     rate,
+		status:   make(map[string]*ProviderSwapStatus),
+		revealed: make(map[string]string),
+	}
+	ln := &fakeLN{
+		invoices: make(map[string]*lnrpc.Invoice),
+		payments: make(chan *lnrpc.SendRequest, 1),
+		settled:  make(chan struct{}),
+	}
+	btc := &fakeBTC{}
+	m, err := NewManager(Config{
+		Provider:  p,
+		LN:        ln,
+		BTC:       btc,
+		BTCNet:    "regtest",
+		Rates:     func() (float64, float64) { return 20, 40000 },
+		StateFile: filepath.Join(t.TempDir(), "swaps.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, p, ln, btc
+}
+
+// TestSwapQuotePremium tests that quotes with a rate too far from the market
+// rate are rejected.
+func TestSwapQuotePremium(t *testing.T) {
+	// The market rate is 20/40000 = 0.0005 BTC/DCR.
+	m, _, _, _ := newTestManager(t, 0.0006)
+	ctx := context.Background()
+	q, err := m.Quote(ctx, DirectionIn, 1e8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Premium < 0.19 || q.Premium > 0.21 {
+		t.Fatalf("unexpected premium %f", q.Premium)
+	}
+	if _, err := m.SwapIn(ctx, 1e8); err == nil {
+		t.Fatal("expected error creating swap with high premium")
+	}
+
+	// The same rate is favorable when swapping out.
+	q, err = m.Quote(ctx, DirectionOut, 1e8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Premium > 0 {
+		t.Fatalf("unexpected premium %f", q.Premium)
+	}
+}
+
+// TestSwapIn tests a swap in completes once the invoice is paid.
+func TestSwapIn(t *testing.T) {
+	m, p, ln, _ := newTestManager(t, 0.0005)
+	ctx := context.Background()
+	s, err := m.SwapIn(ctx, dcrutil.Amount(1e8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != StateAwaitingDeposit || s.BTCAddress == "" ||
+		s.BTCAmount != 50000 || s.RefundKey == "" {
+		t.Fatalf("unexpected swap %+v", s)
+	}
+	if p.reqs[0].Invoice != s.Invoice || p.reqs[0].RefundPubKey == "" {
+		t.Fatalf("unexpected request %+v", p.reqs[0])
+	}
+
+	p.setStatus(s.ID, "deposit_seen", "txid")
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Swap(s.ID); got.State != StateDepositSeen || got.BTCTxID != "txid" {
+		t.Fatalf("unexpected swap %+v", got)
+	}
+
+	ln.settle(s.PaymentHash)
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Swap(s.ID); got.State != StateCompleted {
+		t.Fatalf("unexpected state %s", got.State)
+	}
+}
+
+// TestSwapInInvalidContract tests that swaps in are rejected when the contract
+// returned by the provider does not match the swap.
+func TestSwapInInvalidContract(t *testing.T) {
+	otherKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPubKey := otherKey.PubKey().SerializeCompressed()
+
+	tests := []struct {
+		name   string
+		tamper func(res *CreateSwapResponse)
+	}{{
+		name: "lock time",
+		tamper: func(res *CreateSwapResponse) {
+			res.LockTime += 3600
+		},
+	}, {
+		name: "refund key",
+		tamper: func(res *CreateSwapResponse) {
+			script, _ := hex.DecodeString(res.ContractScript)
+			hash := script[2:34]
+			claimPubKey, _ := hex.DecodeString(res.ClaimPubKey)
+			script, _ = btcSwapContract(hash, claimPubKey, otherPubKey, res.LockTime)
+			res.ContractScript = hex.EncodeToString(script)
+			res.DepositAddress, _ = btcP2WSHAddress("bcrt", script)
+		},
+	}, {
+		name: "payment hash",
+		tamper: func(res *CreateSwapResponse) {
+			script, _ := hex.DecodeString(res.ContractScript)
+			refundPubKey := script[len(script)-35 : len(script)-2]
+			claimPubKey, _ := hex.DecodeString(res.ClaimPubKey)
+			hash := sha256.Sum256(nil)
+			script, _ = btcSwapContract(hash[:], claimPubKey, refundPubKey, res.LockTime)
+			res.ContractScript = hex.EncodeToString(script)
+			res.DepositAddress, _ = btcP2WSHAddress("bcrt", script)
+		},
+	}, {
+		name: "deposit address",
+		tamper: func(res *CreateSwapResponse) {
+			res.DepositAddress, _ = btcP2WSHAddress("bcrt", []byte{0x51})
+		},
+	}, {
+		name: "deposit address network",
+		tamper: func(res *CreateSwapResponse) {
+			script, _ := hex.DecodeString(res.ContractScript)
+			res.DepositAddress, _ = btcP2WSHAddress("bc", script)
+		},
+	}, {
+		name: "claim pubkey",
+		tamper: func(res *CreateSwapResponse) {
+			res.ClaimPubKey = hex.EncodeToString(otherPubKey)
+		},
+	}}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m, p, _, _ := newTestManager(t, 0.0005)
+			p.tamper = tc.tamper
+			if _, err := m.SwapIn(context.Background(), dcrutil.Amount(1e8)); err == nil {
+				t.Fatal("expected error creating swap with invalid contract")
+			}
+			if len(m.Swaps()) != 0 {
+				t.Fatal("swap with invalid contract was stored")
+			}
+		})
+	}
+}
+
+// TestSwapOut tests that the preimage of a swap out is only revealed after
+// the BTC sent by the provider is confirmed.
+func TestSwapOut(t *testing.T) {
+	m, p, ln, btc := newTestManager(t, 0.0005)
+	ctx := context.Background()
+	s, err := m.SwapOut(ctx, dcrutil.Amount(1e8), "bc1dest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case req := <-ln.payments:
+		if req.PaymentRequest != s.Invoice {
+			t.Fatalf("unexpected paid invoice %s", req.PaymentRequest)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("invoice was not paid")
+	}
+
+	// An unconfirmed BTC tx does not reveal the preimage.
+	p.setStatus(s.ID, "btc_sent", "txid")
+	btc.sats = s.BTCAmount
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.revealed[s.ID]; ok {
+		t.Fatal("preimage revealed before BTC was confirmed")
+	}
+
+	// A confirmed BTC tx that underpays does not reveal the preimage.
+	btc.confs = 6
+	btc.sats = s.BTCAmount - 1
+	if err := m.RunOnce(ctx); err == nil {
+		t.Fatal("expected error checking underpaying BTC tx")
+	}
+	if _, ok := p.revealed[s.ID]; ok {
+		t.Fatal("preimage revealed for underpaying BTC tx")
+	}
+
+	btc.sats = s.BTCAmount
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p.revealed[s.ID] != s.Preimage {
+		t.Fatal("preimage not revealed after BTC was confirmed")
+	}
+
+	// The swap completes once the payment of the invoice completes. The
+	// swap is stored before its state is visible, so the state file is
+	// not written after the test ends.
+	close(ln.settled)
+	for i := 0; ; i++ {
+		got, _ := m.Swap(s.ID)
+		if got.State == StateCompleted {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("unexpected state %s", got.State)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -43,6 +43,13 @@ type PublicIdentity struct {
 	Digest    FixedSizeDigest           `json:"digest"`    // digest of name, keys and identity
 	Signature FixedSizeSignature        `json:"signature"` // signature of Digest
 	Avatar    []byte                    `json:"avatar"`
+
+	// Description is a free-form text the user uses to describe itself.
+	Description string `json:"description,omitempty"`
+
+	// Status is a short status line set by the user (for example, "away
+	// until Monday"). It does not imply any real-time presence tracking.
+	Status string `json:"status,omitempty"`
 }
 
 type FullIdentity struct {